@@ -47,7 +47,9 @@ const (
 type IOCallback struct {
 	Data uint64
 	Key  uint32
-	_    uint32
+
+	// RWFlags contains RWF_* flags that apply to read and write requests.
+	RWFlags uint32
 
 	OpCode  uint16
 	ReqPrio int16
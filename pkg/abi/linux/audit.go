@@ -20,4 +20,6 @@ const (
 	AUDIT_ARCH_X86_64 = 0xc000003e
 	// AUDIT_ARCH_AARCH64 identifies ARM64.
 	AUDIT_ARCH_AARCH64 = 0xc00000b7
+	// AUDIT_ARCH_RISCV64 identifies RISCV64.
+	AUDIT_ARCH_RISCV64 = 0xc00000f3
 )
@@ -233,6 +233,9 @@ const (
 	// VFS_CAP_FLAGS_EFFECTIVE allows the effective capability set to be
 	// initialized with the permitted file capabilities.
 	VFS_CAP_FLAGS_EFFECTIVE = 0x000001
+	// VFS_CAP_REVISION_1 is the original file capability format, with
+	// capability masks that are 32 bits in size.
+	VFS_CAP_REVISION_1 = 0x01000000
 	// VFS_CAP_REVISION_2 allows for file capability masks that are 64
 	// bits in size, and was necessary as the number of supported
 	// capabilities grew beyond 32.
@@ -244,6 +247,8 @@ const (
 	// extended attribute.
 	VFS_CAP_REVISION_3    = 0x03000000
 	VFS_CAP_REVISION_MASK = 0xFF000000
+	// XATTR_CAPS_SZ_1 is sizeof(struct vfs_cap_data) for VFS_CAP_REVISION_1.
+	XATTR_CAPS_SZ_1 = 12
 	// XATTR_CAPS_SZ_2 is sizeof(struct vfs_cap_data).
 	XATTR_CAPS_SZ_2 = 20
 	// XATTR_CAPS_SZ_3 is sizeof(struct vfs_ns_cap_data).
@@ -271,6 +276,11 @@ func (c *VfsCapData) Inheritable() uint64 {
 	return uint64(c.InheritableHi)<<32 | uint64(c.InheritableLo)
 }
 
+// IsRevision1 returns true if c is v1.
+func (c *VfsCapData) IsRevision1() bool {
+	return (c.MagicEtc & VFS_CAP_REVISION_MASK) == VFS_CAP_REVISION_1
+}
+
 // IsRevision2 returns true if c is v2.
 func (c *VfsCapData) IsRevision2() bool {
 	return (c.MagicEtc & VFS_CAP_REVISION_MASK) == VFS_CAP_REVISION_2
@@ -83,11 +83,21 @@ const (
 	// AT_HWCAP2 is an extension of AT_HWCAP.
 	AT_HWCAP2 = 26
 
+	// AT_RSEQ_FEATURE_SIZE is the size of the rseq fields supported by the
+	// kernel.
+	AT_RSEQ_FEATURE_SIZE = 27
+
+	// AT_RSEQ_ALIGN is the required alignment of the rseq area.
+	AT_RSEQ_ALIGN = 28
+
 	// AT_EXECFN is the path used to execute the program.
 	AT_EXECFN = 31
 
 	// AT_SYSINFO_EHDR is the address of the VDSO.
 	AT_SYSINFO_EHDR = 33
+
+	// AT_MINSIGSTKSZ is the minimal stack size required to deliver a signal.
+	AT_MINSIGSTKSZ = 51
 )
 
 // ELF ET_CORE and ptrace GETREGSET/SETREGSET register set types.
@@ -107,6 +117,57 @@ const (
 	NT_ARM_TLS = 0x401
 )
 
+// GNU note types, found in notes named "GNU".
+//
+// See include/uapi/linux/elf.h.
+const (
+	// NT_GNU_BUILD_ID is the unique build ID of an object.
+	NT_GNU_BUILD_ID = 3
+
+	// NT_GNU_PROPERTY_TYPE_0 is the note containing program properties, found
+	// in the PT_GNU_PROPERTY segment.
+	NT_GNU_PROPERTY_TYPE_0 = 5
+)
+
+// GNU program property types and values, found in NT_GNU_PROPERTY_TYPE_0
+// notes.
+//
+// See include/uapi/linux/elf.h and the x86-64 and AArch64 psABIs.
+const (
+	// GNU_PROPERTY_AARCH64_FEATURE_1_AND is a bitmask of AArch64 features
+	// that all objects linked into the ELF support.
+	GNU_PROPERTY_AARCH64_FEATURE_1_AND = 0xc0000000
+
+	// GNU_PROPERTY_AARCH64_FEATURE_1_BTI indicates that the ELF is compatible
+	// with Branch Target Identification.
+	GNU_PROPERTY_AARCH64_FEATURE_1_BTI = 1 << 0
+
+	// GNU_PROPERTY_AARCH64_FEATURE_1_PAC indicates that the ELF uses Pointer
+	// Authentication to protect return addresses.
+	GNU_PROPERTY_AARCH64_FEATURE_1_PAC = 1 << 1
+
+	// GNU_PROPERTY_X86_FEATURE_1_AND is a bitmask of x86 features that all
+	// objects linked into the ELF support.
+	GNU_PROPERTY_X86_FEATURE_1_AND = 0xc0000002
+
+	// GNU_PROPERTY_X86_FEATURE_1_IBT indicates that the ELF is compatible
+	// with Indirect Branch Tracking.
+	GNU_PROPERTY_X86_FEATURE_1_IBT = 1 << 0
+
+	// GNU_PROPERTY_X86_FEATURE_1_SHSTK indicates that the ELF is compatible
+	// with Shadow Stack.
+	GNU_PROPERTY_X86_FEATURE_1_SHSTK = 1 << 1
+)
+
+// ElfGNUPropertyHeaderSize is the size of a GNU program property header
+// (struct gnu_property), which consists of the property type and the data
+// size.
+const ElfGNUPropertyHeaderSize = 8
+
+// ElfNoteHeaderSize is the size of an ELF note header (Elf64_Nhdr), which
+// consists of the name size, the descriptor size and the note type.
+const ElfNoteHeaderSize = 12
+
 // ElfHeader64 is the ELF64 file header.
 //
 // +marshal
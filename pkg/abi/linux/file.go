@@ -232,10 +232,11 @@ const (
 	// NOTE(b/120162627): gVisor does not implement the RWF_HIPRI feature, but
 	// the flag is accepted as a valid flag argument for preadv2/pwritev2 and
 	// silently ignored.
-	RWF_HIPRI = 0x00000001
-	RWF_DSYNC = 0x00000002
-	RWF_SYNC  = 0x00000004
-	RWF_VALID = RWF_HIPRI | RWF_DSYNC | RWF_SYNC
+	RWF_HIPRI  = 0x00000001
+	RWF_DSYNC  = 0x00000002
+	RWF_SYNC   = 0x00000004
+	RWF_NOWAIT = 0x00000008
+	RWF_VALID  = RWF_HIPRI | RWF_DSYNC | RWF_SYNC | RWF_NOWAIT
 )
 
 // SizeOfStat is the size of a Stat struct.
@@ -417,6 +418,8 @@ const (
 	F_SEAL_SHRINK = 0x0002 // Prevent file from shrinking.
 	F_SEAL_GROW   = 0x0004 // Prevent file from growing.
 	F_SEAL_WRITE  = 0x0008 // Prevent writes.
+
+	F_SEAL_FUTURE_WRITE = 0x0010 // Prevent future writes while mapped.
 )
 
 // Constants related to fallocate(2). Source: include/uapi/linux/falloc.h
@@ -31,6 +31,41 @@ const (
 	IORING_ENTER_GETEVENTS = (1 << 0)
 )
 
+// Constants for io_uring_register(2) opcodes. See include/uapi/linux/io_uring.h.
+const (
+	IORING_REGISTER_BUFFERS      = 0
+	IORING_UNREGISTER_BUFFERS    = 1
+	IORING_REGISTER_FILES        = 2
+	IORING_REGISTER_FILES_UPDATE = 6
+	IORING_REGISTER_PROBE        = 8
+	IORING_REGISTER_RESTRICTIONS = 11
+	IORING_REGISTER_ENABLE_RINGS = 12
+)
+
+// Constants for IOUringRestriction.Opcode. See include/uapi/linux/io_uring.h.
+const (
+	IORING_RESTRICTION_REGISTER_OP        = 0
+	IORING_RESTRICTION_SQE_OP             = 1
+	IORING_RESTRICTION_SQE_FLAGS_ALLOWED  = 2
+	IORING_RESTRICTION_SQE_FLAGS_REQUIRED = 3
+)
+
+// Constants for IOUringSqe.Flags. See include/uapi/linux/io_uring.h.
+const (
+	IOSQE_FIXED_FILE = (1 << 0)
+)
+
+// Constants for IOUringSqe.OpFlags. See include/uapi/linux/io_uring.h.
+const (
+	IORING_FSYNC_DATASYNC = (1 << 0)
+	IORING_TIMEOUT_ABS    = (1 << 0)
+)
+
+// Constants for IOUringProbeOp.Flags. See include/uapi/linux/io_uring.h.
+const (
+	IO_URING_OP_SUPPORTED = (1 << 0)
+)
+
 // Constants for IoUringParams.Features. See include/uapi/linux/io_uring.h.
 const (
 	IORING_FEAT_SINGLE_MMAP = (1 << 0)
@@ -50,6 +85,10 @@ const (
 	IORING_MAX_CQ_ENTRIES = (2 * IORING_MAX_ENTRIES)
 )
 
+// IORING_MAX_REG_BUFFERS is the maximum number of buffers that may be
+// registered with IORING_REGISTER_BUFFERS. See io_uring/rsrc.c.
+const IORING_MAX_REG_BUFFERS = (1 << 14)
+
 // Constants for the offsets for the application to mmap the data it needs.
 // See include/uapi/linux/io_uring.h.
 const (
@@ -60,8 +99,17 @@ const (
 
 // Constants for the IO_URING opcodes. See include/uapi/linux/io_uring.h.
 const (
-	IORING_OP_NOP   = 0
-	IORING_OP_READV = 1
+	IORING_OP_NOP         = 0
+	IORING_OP_READV       = 1
+	IORING_OP_WRITEV      = 2
+	IORING_OP_FSYNC       = 3
+	IORING_OP_READ_FIXED  = 4
+	IORING_OP_WRITE_FIXED = 5
+	IORING_OP_TIMEOUT     = 11
+	IORING_OP_ACCEPT      = 13
+	IORING_OP_CONNECT     = 16
+	IORING_OP_SEND        = 26
+	IORING_OP_RECV        = 27
 )
 
 // IORingIndex represents SQE array indexes.
@@ -184,7 +232,7 @@ type IOUringSqe struct {
 	OffOrAddrOrCmdOp    uint64
 	AddrOrSpliceOff     uint64
 	Len                 uint32
-	specialFlags        uint32
+	OpFlags             uint32 // rw_flags, fsync_flags, msg_flags, timeout_flags, etc.
 	UserData            uint64
 	BufIndexOrGroup     uint16
 	personality         uint16
@@ -193,6 +241,52 @@ type IOUringSqe struct {
 	_                   uint64
 }
 
+// IOUringRestriction implements io_uring_restriction struct.
+// See struct io_uring_restriction in include/uapi/linux/io_uring.h.
+//
+// +marshal
+type IOUringRestriction struct {
+	Opcode uint16
+	// Arg is the register_op, sqe_op or sqe_flags union member, depending on
+	// Opcode.
+	Arg uint8
+	_   uint8
+	_   [3]uint32
+}
+
+// IOUringFilesUpdate implements io_uring_files_update struct.
+// See struct io_uring_files_update in include/uapi/linux/io_uring.h.
+//
+// +marshal
+type IOUringFilesUpdate struct {
+	Offset uint32
+	_      uint32
+	Fds    uint64 // Pointer to an array of int32 FDs.
+}
+
+// IOUringProbe implements the fixed-size header of the io_uring_probe struct.
+// It is followed in memory by OpsLen IOUringProbeOps.
+// See struct io_uring_probe in include/uapi/linux/io_uring.h.
+//
+// +marshal
+type IOUringProbe struct {
+	LastOp uint8
+	OpsLen uint8
+	_      uint16
+	_      [3]uint32
+}
+
+// IOUringProbeOp implements io_uring_probe_op struct.
+// See struct io_uring_probe_op in include/uapi/linux/io_uring.h.
+//
+// +marshal
+type IOUringProbeOp struct {
+	Op    uint8
+	_     uint8
+	Flags uint16
+	_     uint32
+}
+
 const (
 	_IOSqRingOffset        = 0   // +checkoffset . IORings.Sq
 	_IOSqRingOffsetHead    = 0   // +checkoffset . IOUring.Head
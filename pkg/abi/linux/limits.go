@@ -53,6 +53,10 @@ const (
 	// called MAX_THREADS / 2 in Linux.
 	DefaultNprocLimit = FUTEX_TID_MASK / 2
 
+	// DefaultSigpendingLimit is set by kernel/fork.c:fork_init to the same
+	// value as DefaultNprocLimit.
+	DefaultSigpendingLimit = DefaultNprocLimit
+
 	// DefaultNofileSoftLimit is called INR_OPEN_CUR in Linux.
 	DefaultNofileSoftLimit = 1024
 
@@ -80,7 +84,7 @@ var InitRLimits = map[int]RLimit{
 	RLIMIT_MEMLOCK:    {DefaultMemlockLimit, DefaultMemlockLimit},
 	RLIMIT_AS:         {RLimInfinity, RLimInfinity},
 	RLIMIT_LOCKS:      {RLimInfinity, RLimInfinity},
-	RLIMIT_SIGPENDING: {0, 0},
+	RLIMIT_SIGPENDING: {DefaultSigpendingLimit, DefaultSigpendingLimit},
 	RLIMIT_MSGQUEUE:   {DefaultMsgqueueLimit, DefaultMsgqueueLimit},
 	RLIMIT_NICE:       {0, 0},
 	RLIMIT_RTPRIO:     {0, 0},
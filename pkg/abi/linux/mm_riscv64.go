@@ -0,0 +1,24 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build riscv64
+// +build riscv64
+
+package linux
+
+// TASK_SIZE on riscv64 is one of three values, corresponding to Sv57, Sv48
+// and Sv39 paging.
+//
+// The array has to be sorted in decreasing order.
+var feasibleTaskSizes = []uintptr{1 << 56, 1 << 47, 1 << 38}
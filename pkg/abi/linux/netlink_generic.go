@@ -0,0 +1,90 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+// GenlMsgHeader is the header that follows the netlink message header in
+// NETLINK_GENERIC messages, struct genlmsghdr from uapi/linux/genetlink.h.
+//
+// +marshal
+type GenlMsgHeader struct {
+	Cmd      uint8
+	Version  uint8
+	Reserved uint16
+}
+
+// GenlMsgHeaderSize is the size of GenlMsgHeader.
+const GenlMsgHeaderSize = 4
+
+// GENL_NAMSIZ is the maximum length of a generic netlink family name,
+// including the terminating NUL, from uapi/linux/genetlink.h.
+const GENL_NAMSIZ = 16
+
+// Generic netlink family IDs, from uapi/linux/genetlink.h.
+const (
+	GENL_ID_CTRL = NLMSG_MIN_TYPE
+
+	// GENL_START_ALLOC is the first dynamically allocated family ID, from
+	// net/netlink/genetlink.c.
+	GENL_START_ALLOC = NLMSG_MIN_TYPE + 3
+)
+
+// Generic netlink operation flags, from uapi/linux/genetlink.h.
+const (
+	GENL_ADMIN_PERM     = 0x01
+	GENL_CMD_CAP_DO     = 0x02
+	GENL_CMD_CAP_DUMP   = 0x04
+	GENL_CMD_CAP_HASPOL = 0x08
+)
+
+// Generic netlink controller commands, from uapi/linux/genetlink.h.
+const (
+	CTRL_CMD_UNSPEC       = 0
+	CTRL_CMD_NEWFAMILY    = 1
+	CTRL_CMD_DELFAMILY    = 2
+	CTRL_CMD_GETFAMILY    = 3
+	CTRL_CMD_NEWOPS       = 4
+	CTRL_CMD_DELOPS       = 5
+	CTRL_CMD_GETOPS       = 6
+	CTRL_CMD_NEWMCAST_GRP = 7
+	CTRL_CMD_DELMCAST_GRP = 8
+	CTRL_CMD_GETMCAST_GRP = 9
+	CTRL_CMD_GETPOLICY    = 10
+)
+
+// Generic netlink controller attributes, from uapi/linux/genetlink.h.
+const (
+	CTRL_ATTR_UNSPEC       = 0
+	CTRL_ATTR_FAMILY_ID    = 1
+	CTRL_ATTR_FAMILY_NAME  = 2
+	CTRL_ATTR_VERSION      = 3
+	CTRL_ATTR_HDRSIZE      = 4
+	CTRL_ATTR_MAXATTR      = 5
+	CTRL_ATTR_OPS          = 6
+	CTRL_ATTR_MCAST_GROUPS = 7
+)
+
+// Generic netlink controller operation attributes, from
+// uapi/linux/genetlink.h.
+const (
+	CTRL_ATTR_OP_UNSPEC = 0
+	CTRL_ATTR_OP_ID     = 1
+	CTRL_ATTR_OP_FLAGS  = 2
+)
+
+// GENL_CTRL_NAME is the name of the generic netlink controller family.
+const GENL_CTRL_NAME = "nlctrl"
+
+// GENL_CTRL_VERSION is the version of the generic netlink controller family.
+const GENL_CTRL_VERSION = 0x2
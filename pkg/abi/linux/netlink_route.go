@@ -183,6 +183,12 @@ const (
 	VETH_INFO_PEER = 1
 )
 
+// VRF attributes, from uapi/linux/if_link.h.
+const (
+	IFLA_VRF_UNSPEC = 0
+	IFLA_VRF_TABLE  = 1
+)
+
 // InterfaceAddrMessage is struct ifaddrmsg, from uapi/linux/if_addr.h.
 //
 // +marshal
@@ -145,9 +145,29 @@ const (
 	// Protection eXtensions (MPX) bounds tables.
 	PR_MPX_DISABLE_MANAGEMENT = 44
 
+	// PR_SET_IO_FLUSHER marks the calling thread as an IO flusher, which
+	// must not block on reclaim of memory that depends on its own I/O.
+	PR_SET_IO_FLUSHER = 57
+
+	// PR_GET_IO_FLUSHER gets the IO flusher state of the calling thread.
+	PR_GET_IO_FLUSHER = 58
+
+	// PR_SCHED_CORE manages the core scheduling cookies of tasks, which
+	// control the tasks that may run on SMT siblings of the same core at the
+	// same time.
+	PR_SCHED_CORE = 62
+
+	// The following constants are the PR_SCHED_CORE commands.
+	PR_SCHED_CORE_GET        = 0
+	PR_SCHED_CORE_CREATE     = 1
+	PR_SCHED_CORE_SHARE_TO   = 2
+	PR_SCHED_CORE_SHARE_FROM = 3
+	PR_SCHED_CORE_MAX        = 4
+
 	// The following constants are used to control thread scheduling on cores.
-	PR_SCHED_CORE_SCOPE_THREAD       = 0
-	PR_SCHED_CORE_SCOPE_THREAD_GROUP = 1
+	PR_SCHED_CORE_SCOPE_THREAD        = 0
+	PR_SCHED_CORE_SCOPE_THREAD_GROUP  = 1
+	PR_SCHED_CORE_SCOPE_PROCESS_GROUP = 2
 
 	// PR_SET_VMA sets VMA attributes.
 	PR_SET_VMA           = 0x53564d41
@@ -159,6 +179,23 @@ const (
 	// specified) to ptrace the current task.
 	PR_SET_PTRACER     = 0x59616d61
 	PR_SET_PTRACER_ANY = -1
+
+	// PR_SET_SYSCALL_USER_DISPATCH configures syscall user dispatch for the
+	// calling thread.
+	PR_SET_SYSCALL_USER_DISPATCH = 59
+)
+
+// Modes for prctl(PR_SET_SYSCALL_USER_DISPATCH), from include/uapi/linux/prctl.h.
+const (
+	PR_SYS_DISPATCH_OFF = 0
+	PR_SYS_DISPATCH_ON  = 1
+)
+
+// Values of the syscall user dispatch selector byte, from
+// include/uapi/linux/prctl.h.
+const (
+	SYSCALL_DISPATCH_FILTER_ALLOW = 0
+	SYSCALL_DISPATCH_FILTER_BLOCK = 1
 )
 
 // From <asm/prctl.h>
@@ -36,6 +36,10 @@ const (
 	// RSEQ_CS_FLAG_NO_RESTART_ON_MIGRATE inhibits restart on CPU
 	// migration.
 	RSEQ_CS_FLAG_NO_RESTART_ON_MIGRATE = 1 << 2
+
+	// RSEQ_CS_NO_RESTART_FLAGS is the set of deprecated no-restart flags.
+	// Linux 6.0+ rejects critical sections with any flag set.
+	RSEQ_CS_NO_RESTART_FLAGS = RSEQ_CS_FLAG_NO_RESTART_ON_PREEMPT | RSEQ_CS_FLAG_NO_RESTART_ON_SIGNAL | RSEQ_CS_FLAG_NO_RESTART_ON_MIGRATE
 )
 
 // RSeqCriticalSection describes a restartable sequences critical section. It
@@ -112,19 +116,38 @@ type RSeq struct {
 	// Flags are the critical section flags that apply to all critical
 	// sections on this thread, defined above.
 	Flags uint32
+
+	// NodeID contains the NUMA node ID of the current CPU if rseq is
+	// initialized.
+	NodeID uint32
+
+	// MMCID contains the concurrency ID of this thread within its address
+	// space if rseq is initialized.
+	MMCID uint32
 }
 
 const (
 	// SizeOfRSeq is the size of RSeq.
 	//
-	// Note that RSeq is naively 24 bytes. However, it has 32-byte
-	// alignment, which in C increases sizeof to 32. That is the size that
-	// the Linux kernel uses.
+	// Note that RSeq is naively 28 bytes. However, it has 32-byte
+	// alignment, which in C increases sizeof to 32. That is the original
+	// size of struct rseq, and the minimum size that the Linux kernel
+	// accepts.
 	SizeOfRSeq = 32
 
+	// RSeqFeatureSize is the size of the RSeq fields supported by the
+	// kernel, reported to applications through AT_RSEQ_FEATURE_SIZE.
+	RSeqFeatureSize = 28
+
 	// AlignOfRSeq is the standard alignment of RSeq.
 	AlignOfRSeq = 32
 
 	// OffsetOfRSeqCriticalSection is the offset of RSeqCriticalSection in RSeq.
 	OffsetOfRSeqCriticalSection = 8
+
+	// OffsetOfRSeqFlags is the offset of Flags in RSeq.
+	OffsetOfRSeqFlags = 16
+
+	// OffsetOfRSeqNodeID is the offset of NodeID in RSeq.
+	OffsetOfRSeqNodeID = 20
 )
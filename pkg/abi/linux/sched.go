@@ -35,3 +35,47 @@ const (
 	PRIO_PROCESS = 0x0
 	PRIO_USER    = 0x2
 )
+
+// Scheduling flags, passed to sched_setattr(2) in SchedAttr.Flags.
+const (
+	SCHED_FLAG_RESET_ON_FORK  = 0x01
+	SCHED_FLAG_RECLAIM        = 0x02
+	SCHED_FLAG_DL_OVERRUN     = 0x04
+	SCHED_FLAG_KEEP_POLICY    = 0x08
+	SCHED_FLAG_KEEP_PARAMS    = 0x10
+	SCHED_FLAG_UTIL_CLAMP_MIN = 0x20
+	SCHED_FLAG_UTIL_CLAMP_MAX = 0x40
+
+	SCHED_FLAG_KEEP_ALL   = SCHED_FLAG_KEEP_POLICY | SCHED_FLAG_KEEP_PARAMS
+	SCHED_FLAG_UTIL_CLAMP = SCHED_FLAG_UTIL_CLAMP_MIN | SCHED_FLAG_UTIL_CLAMP_MAX
+	SCHED_FLAG_ALL        = SCHED_FLAG_RESET_ON_FORK | SCHED_FLAG_RECLAIM | SCHED_FLAG_DL_OVERRUN | SCHED_FLAG_KEEP_ALL | SCHED_FLAG_UTIL_CLAMP
+)
+
+// Sizes of the versions of struct sched_attr.
+const (
+	SCHED_ATTR_SIZE_VER0 = 48
+	SCHED_ATTR_SIZE_VER1 = 56
+)
+
+// MAX_RT_PRIO is one more than the highest priority of SCHED_FIFO and
+// SCHED_RR tasks.
+const MAX_RT_PRIO = 100
+
+// SchedAttr is equivalent to struct sched_attr.
+//
+// +marshal
+type SchedAttr struct {
+	Size     uint32
+	Policy   uint32
+	Flags    uint64
+	Nice     int32
+	Priority uint32
+	Runtime  uint64
+	Deadline uint64
+	Period   uint64
+	UtilMin  uint32
+	UtilMax  uint32
+}
+
+// SizeOfSchedAttr is the size of a SchedAttr struct.
+var SizeOfSchedAttr = uint32((*SchedAttr)(nil).SizeBytes())
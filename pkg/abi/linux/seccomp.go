@@ -36,8 +36,13 @@ const (
 
 	SECCOMP_IOCTL_NOTIF_RECV      = 0xc0502100
 	SECCOMP_IOCTL_NOTIF_SEND      = 0xc0182101
+	SECCOMP_IOCTL_NOTIF_ID_VALID  = 0x40082102
+	SECCOMP_IOCTL_NOTIF_ADDFD     = 0x40182103
 	SECCOMP_IOCTL_NOTIF_SET_FLAGS = 0x40082104
 
+	SECCOMP_ADDFD_FLAG_SETFD = 1
+	SECCOMP_ADDFD_FLAG_SEND  = 2
+
 	SECCOMP_USER_NOTIF_FD_SYNC_WAKE_UP = 1
 )
 
@@ -52,6 +57,7 @@ const (
 	SECCOMP_RET_ERRNO        BPFAction = 0x00050000
 	SECCOMP_RET_TRACE        BPFAction = 0x7ff00000
 	SECCOMP_RET_USER_NOTIF   BPFAction = 0x7fc00000
+	SECCOMP_RET_LOG          BPFAction = 0x7ffc0000
 	SECCOMP_RET_ALLOW        BPFAction = 0x7fff0000
 )
 
@@ -79,6 +85,8 @@ func (a BPFAction) String() string {
 		return "allow"
 	case SECCOMP_RET_USER_NOTIF:
 		return "unotify"
+	case SECCOMP_RET_LOG:
+		return "log"
 	}
 	return fmt.Sprintf("invalid action: %#x", uint32(a))
 }
@@ -156,6 +164,17 @@ type SeccompNotif struct {
 	Data  SeccompData
 }
 
+// SeccompNotifAddFD is equivalent to struct seccomp_notif_addfd.
+//
+// +marshal
+type SeccompNotifAddFD struct {
+	ID         uint64
+	Flags      uint32
+	Srcfd      uint32
+	Newfd      uint32
+	NewfdFlags uint32
+}
+
 // String returns a human-friendly representation of this `SeccompData`.
 func (sd SeccompData) String() string {
 	return fmt.Sprintf(
@@ -712,6 +712,10 @@ const SizeOfControlMessageIPPacketInfo = 12
 // ControlMessageIPv6PacketInfo.
 const SizeOfControlMessageIPv6PacketInfo = 20
 
+// SizeOfControlMessageDropCount is the size of an SO_RXQ_OVFL control
+// message.
+const SizeOfControlMessageDropCount = 4
+
 // SCM_MAX_FD is the maximum number of FDs accepted in a single sendmsg call.
 // From net/scm.h.
 const SCM_MAX_FD = 253
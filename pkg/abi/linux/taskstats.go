@@ -0,0 +1,126 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+// TASKSTATS_VERSION is the version of the Taskstats struct below.
+const TASKSTATS_VERSION = 10
+
+// TS_COMM_LEN is the length of Taskstats.AcComm.
+const TS_COMM_LEN = 32
+
+// Taskstats is struct taskstats, from uapi/linux/taskstats.h.
+//
+// +marshal
+type Taskstats struct {
+	Version    uint16
+	_          uint16
+	AcExitcode uint32
+	AcFlag     uint8
+	AcNice     uint8
+	_          [6]uint8
+
+	// Delay accounting fields, in nanoseconds.
+	CPUCount           uint64
+	CPUDelayTotal      uint64
+	BlkioCount         uint64
+	BlkioDelayTotal    uint64
+	SwapinCount        uint64
+	SwapinDelayTotal   uint64
+	CPURunRealTotal    uint64
+	CPURunVirtualTotal uint64
+
+	// Basic accounting fields.
+	AcComm   [TS_COMM_LEN]byte
+	AcSched  uint8
+	_        [3]uint8
+	_        [4]uint8
+	AcUID    uint32
+	AcGID    uint32
+	AcPID    uint32
+	AcPPID   uint32
+	AcBtime  uint32
+	_        uint32
+	AcEtime  uint64
+	AcUtime  uint64
+	AcStime  uint64
+	AcMinflt uint64
+	AcMajflt uint64
+
+	// Extended accounting fields.
+	Coremem    uint64
+	Virtmem    uint64
+	HiwaterRSS uint64
+	HiwaterVM  uint64
+
+	// I/O accounting fields.
+	ReadChar            uint64
+	WriteChar           uint64
+	ReadSyscalls        uint64
+	WriteSyscalls       uint64
+	ReadBytes           uint64
+	WriteBytes          uint64
+	CancelledWriteBytes uint64
+
+	Nvcsw  uint64
+	Nivcsw uint64
+
+	AcUtimescaled         uint64
+	AcStimescaled         uint64
+	CPUScaledRunRealTotal uint64
+
+	FreepagesCount      uint64
+	FreepagesDelayTotal uint64
+	ThrashingCount      uint64
+	ThrashingDelayTotal uint64
+
+	AcBtime64 uint64
+}
+
+// TaskstatsSize is the size of Taskstats.
+const TaskstatsSize = 352
+
+// Taskstats generic netlink commands, from uapi/linux/taskstats.h.
+const (
+	TASKSTATS_CMD_UNSPEC = 0
+	TASKSTATS_CMD_GET    = 1
+	TASKSTATS_CMD_NEW    = 2
+)
+
+// Taskstats reply attributes, from uapi/linux/taskstats.h.
+const (
+	TASKSTATS_TYPE_UNSPEC    = 0
+	TASKSTATS_TYPE_PID       = 1
+	TASKSTATS_TYPE_TGID      = 2
+	TASKSTATS_TYPE_STATS     = 3
+	TASKSTATS_TYPE_AGGR_PID  = 4
+	TASKSTATS_TYPE_AGGR_TGID = 5
+	TASKSTATS_TYPE_NULL      = 6
+)
+
+// Taskstats command attributes, from uapi/linux/taskstats.h.
+const (
+	TASKSTATS_CMD_ATTR_UNSPEC             = 0
+	TASKSTATS_CMD_ATTR_PID                = 1
+	TASKSTATS_CMD_ATTR_TGID               = 2
+	TASKSTATS_CMD_ATTR_REGISTER_CPUMASK   = 3
+	TASKSTATS_CMD_ATTR_DEREGISTER_CPUMASK = 4
+)
+
+// TASKSTATS_GENL_NAME is the name of the taskstats generic netlink family.
+const TASKSTATS_GENL_NAME = "TASKSTATS"
+
+// TASKSTATS_GENL_VERSION is the version of the taskstats generic netlink
+// family.
+const TASKSTATS_GENL_VERSION = 0x1
@@ -69,3 +69,47 @@ const (
 	TCP_CA_Recovery = 3
 	TCP_CA_Loss     = 4
 )
+
+// Values for TCP_REPAIR, from uapi/linux/tcp.h.
+const (
+	TCP_REPAIR_ON        = 1
+	TCP_REPAIR_OFF       = 0
+	TCP_REPAIR_OFF_NO_WP = -1
+)
+
+// Queues selected by TCP_REPAIR_QUEUE, from uapi/linux/tcp.h.
+const (
+	TCP_NO_QUEUE   = 0
+	TCP_RECV_QUEUE = 1
+	TCP_SEND_QUEUE = 2
+)
+
+// TCP option kinds accepted by TCP_REPAIR_OPTIONS, from include/net/tcp.h.
+const (
+	TCPOPT_MSS       = 2
+	TCPOPT_WINDOW    = 3
+	TCPOPT_SACK_PERM = 4
+	TCPOPT_TIMESTAMP = 8
+)
+
+// TCPRepairOpt is struct tcp_repair_opt, from uapi/linux/tcp.h.
+//
+// +marshal
+type TCPRepairOpt struct {
+	OptCode uint32
+	OptVal  uint32
+}
+
+// TCPRepairWindow is struct tcp_repair_window, from uapi/linux/tcp.h.
+//
+// +marshal
+type TCPRepairWindow struct {
+	SndWl1    uint32
+	SndWnd    uint32
+	MaxWindow uint32
+	RcvWnd    uint32
+	RcvWup    uint32
+}
+
+// SizeOfTCPRepairWindow is the binary size of a TCPRepairWindow struct.
+const SizeOfTCPRepairWindow = 20
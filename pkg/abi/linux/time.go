@@ -158,6 +158,9 @@ func DurationToTimespec(dur time.Duration) Timespec {
 // SizeOfTimeval is the size of a Timeval struct in bytes.
 const SizeOfTimeval = 16
 
+// SizeOfTimespec is the size of a Timespec struct in bytes.
+const SizeOfTimespec = 16
+
 // Timeval represents struct timeval in <time.h>.
 //
 // +marshal slice:TimevalSlice
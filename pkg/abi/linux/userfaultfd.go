@@ -0,0 +1,183 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+// Flags for userfaultfd(2), from include/uapi/linux/userfaultfd.h.
+const (
+	UFFD_USER_MODE_ONLY = 1
+	UFFD_CLOEXEC        = O_CLOEXEC
+	UFFD_NONBLOCK       = O_NONBLOCK
+)
+
+// UFFD_API is the only userfaultfd API version.
+const UFFD_API = 0xAA
+
+// Userfaultfd features, as reported and requested by UFFDIO_API.
+const (
+	UFFD_FEATURE_PAGEFAULT_FLAG_WP  = 1 << 0
+	UFFD_FEATURE_EVENT_FORK         = 1 << 1
+	UFFD_FEATURE_EVENT_REMAP        = 1 << 2
+	UFFD_FEATURE_EVENT_REMOVE       = 1 << 3
+	UFFD_FEATURE_MISSING_HUGETLBFS  = 1 << 4
+	UFFD_FEATURE_MISSING_SHMEM      = 1 << 5
+	UFFD_FEATURE_EVENT_UNMAP        = 1 << 6
+	UFFD_FEATURE_SIGBUS             = 1 << 7
+	UFFD_FEATURE_THREAD_ID          = 1 << 8
+	UFFD_FEATURE_MINOR_HUGETLBFS    = 1 << 9
+	UFFD_FEATURE_MINOR_SHMEM        = 1 << 10
+	UFFD_FEATURE_EXACT_ADDRESS      = 1 << 11
+	UFFD_FEATURE_WP_HUGETLBFS_SHMEM = 1 << 12
+	UFFD_FEATURE_WP_UNPOPULATED     = 1 << 13
+	UFFD_FEATURE_POISON             = 1 << 14
+	UFFD_FEATURE_WP_ASYNC           = 1 << 15
+	UFFD_FEATURE_MOVE               = 1 << 16
+)
+
+// Userfaultfd ioctl numbers, as bit positions in the ioctls masks returned by
+// UFFDIO_API and UFFDIO_REGISTER.
+const (
+	UFFDIO_REGISTER_NR     = 0x00
+	UFFDIO_UNREGISTER_NR   = 0x01
+	UFFDIO_WAKE_NR         = 0x02
+	UFFDIO_COPY_NR         = 0x03
+	UFFDIO_ZEROPAGE_NR     = 0x04
+	UFFDIO_WRITEPROTECT_NR = 0x06
+	UFFDIO_CONTINUE_NR     = 0x07
+	UFFDIO_API_NR          = 0x3F
+)
+
+// Userfaultfd ioctls.
+const (
+	UFFDIO_API          = 0xc018aa3f // _IOWR(UFFDIO, _UFFDIO_API, struct uffdio_api)
+	UFFDIO_REGISTER     = 0xc020aa00 // _IOWR(UFFDIO, _UFFDIO_REGISTER, struct uffdio_register)
+	UFFDIO_UNREGISTER   = 0x8010aa01 // _IOR(UFFDIO, _UFFDIO_UNREGISTER, struct uffdio_range)
+	UFFDIO_WAKE         = 0x8010aa02 // _IOR(UFFDIO, _UFFDIO_WAKE, struct uffdio_range)
+	UFFDIO_COPY         = 0xc028aa03 // _IOWR(UFFDIO, _UFFDIO_COPY, struct uffdio_copy)
+	UFFDIO_ZEROPAGE     = 0xc020aa04 // _IOWR(UFFDIO, _UFFDIO_ZEROPAGE, struct uffdio_zeropage)
+	UFFDIO_WRITEPROTECT = 0xc018aa06 // _IOWR(UFFDIO, _UFFDIO_WRITEPROTECT, struct uffdio_writeprotect)
+	UFFDIO_CONTINUE     = 0xc020aa07 // _IOWR(UFFDIO, _UFFDIO_CONTINUE, struct uffdio_continue)
+)
+
+// Userfaultfd registration modes.
+const (
+	UFFDIO_REGISTER_MODE_MISSING = 1 << 0
+	UFFDIO_REGISTER_MODE_WP      = 1 << 1
+	UFFDIO_REGISTER_MODE_MINOR   = 1 << 2
+)
+
+// Modes for userfaultfd ioctls that resolve faults.
+const (
+	UFFDIO_COPY_MODE_DONTWAKE         = 1 << 0
+	UFFDIO_COPY_MODE_WP               = 1 << 1
+	UFFDIO_ZEROPAGE_MODE_DONTWAKE     = 1 << 0
+	UFFDIO_WRITEPROTECT_MODE_WP       = 1 << 0
+	UFFDIO_WRITEPROTECT_MODE_DONTWAKE = 1 << 1
+	UFFDIO_CONTINUE_MODE_DONTWAKE     = 1 << 0
+	UFFDIO_CONTINUE_MODE_WP           = 1 << 1
+)
+
+// Userfaultfd events.
+const (
+	UFFD_EVENT_PAGEFAULT = 0x12
+	UFFD_EVENT_FORK      = 0x13
+	UFFD_EVENT_REMAP     = 0x14
+	UFFD_EVENT_REMOVE    = 0x15
+	UFFD_EVENT_UNMAP     = 0x16
+)
+
+// Flags for UFFD_EVENT_PAGEFAULT.
+const (
+	UFFD_PAGEFAULT_FLAG_WRITE = 1 << 0
+	UFFD_PAGEFAULT_FLAG_WP    = 1 << 1
+	UFFD_PAGEFAULT_FLAG_MINOR = 1 << 2
+)
+
+// UffdMsg is equivalent to struct uffd_msg, specialized for
+// UFFD_EVENT_PAGEFAULT.
+//
+// +marshal
+type UffdMsg struct {
+	Event     uint8
+	Reserved1 uint8
+	Reserved2 uint16
+	Reserved3 uint32
+	Flags     uint64
+	Address   uint64
+	Ptid      uint32
+	_         uint32
+}
+
+// UffdioAPI is equivalent to struct uffdio_api.
+//
+// +marshal
+type UffdioAPI struct {
+	API      uint64
+	Features uint64
+	Ioctls   uint64
+}
+
+// UffdioRange is equivalent to struct uffdio_range.
+//
+// +marshal
+type UffdioRange struct {
+	Start uint64
+	Len   uint64
+}
+
+// UffdioRegister is equivalent to struct uffdio_register.
+//
+// +marshal
+type UffdioRegister struct {
+	Range  UffdioRange
+	Mode   uint64
+	Ioctls uint64
+}
+
+// UffdioCopy is equivalent to struct uffdio_copy.
+//
+// +marshal
+type UffdioCopy struct {
+	Dst  uint64
+	Src  uint64
+	Len  uint64
+	Mode uint64
+	Copy int64
+}
+
+// UffdioZeropage is equivalent to struct uffdio_zeropage.
+//
+// +marshal
+type UffdioZeropage struct {
+	Range    UffdioRange
+	Mode     uint64
+	Zeropage int64
+}
+
+// UffdioWriteprotect is equivalent to struct uffdio_writeprotect.
+//
+// +marshal
+type UffdioWriteprotect struct {
+	Range UffdioRange
+	Mode  uint64
+}
+
+// UffdioContinue is equivalent to struct uffdio_continue.
+//
+// +marshal
+type UffdioContinue struct {
+	Range  UffdioRange
+	Mode   uint64
+	Mapped int64
+}
@@ -605,6 +605,16 @@ var _ io.Writer = (*Writer)(nil)
 // buffered (in the form of read-ahead, or buffered writes), and is limited to
 // O(chunkSize * [1+GOMAXPROCS]).
 func NewWriter(out io.Writer, key []byte, chunkSize uint32, level int) (*Writer, error) {
+	return NewWriterWithWorkers(out, key, chunkSize, level, 1+runtime.GOMAXPROCS(0))
+}
+
+// NewWriterWithWorkers is like NewWriter, but compresses chunks using the
+// given number of concurrent workers instead of 1+GOMAXPROCS. Extra memory is
+// limited to O(chunkSize * workers).
+func NewWriterWithWorkers(out io.Writer, key []byte, chunkSize uint32, level, workers int) (*Writer, error) {
+	if workers <= 0 {
+		workers = 1 + runtime.GOMAXPROCS(0)
+	}
 	w := &Writer{
 		pool: pool{
 			chunkSize: chunkSize,
@@ -612,7 +622,7 @@ func NewWriter(out io.Writer, key []byte, chunkSize uint32, level int) (*Writer,
 		},
 		out: out,
 	}
-	w.init(key, 1+runtime.GOMAXPROCS(0), true, level)
+	w.init(key, workers, true, level)
 
 	binary.BigEndian.PutUint32(w.scratch[:], chunkSize)
 	if _, err := w.out.Write(w.scratch[:4]); err != nil {
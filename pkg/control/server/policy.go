@@ -0,0 +1,118 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Policy authorizes control RPCs based on a token that the peer presents with
+// each call (see urpc.Client.Token). Peer credentials reported by SO_PEERCRED
+// can't be used for this, because the sandbox runs in a user namespace that
+// maps only its own user, so every peer outside of the sandbox appears as the
+// overflow user, which is the sandbox's own user.
+//
+// Peers presenting the owner token, which runsc generates for each sandbox and
+// stores next to the control socket, may invoke any method. Other peers may
+// only invoke methods granted to the token they present. Tokens are identified
+// by their hex-encoded SHA-256 digest (see TokenDigest), so that the policy
+// itself needn't be kept secret. Method patterns are either exact method names
+// (e.g. "Usage.Collect"), an object wildcard (e.g. "Usage.*"), or "*" to allow
+// every method.
+//
+// An example policy that lets holders of a token collect usage statistics and
+// events, but nothing else:
+//
+//	{"tokens": {"<digest>": ["containerManager.APIVersion", "Usage.*", "containerManager.Event"]}}
+type Policy struct {
+	// Tokens maps token digests to the method patterns that peers presenting
+	// the token may invoke.
+	Tokens map[string][]string `json:"tokens,omitempty"`
+
+	// ownerToken is the token that allows peers to invoke any method.
+	ownerToken string
+}
+
+// LoadPolicy reads a JSON encoded Policy from r. Peers presenting ownerToken
+// may invoke any method.
+func LoadPolicy(r io.Reader, ownerToken string) (*Policy, error) {
+	var p Policy
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("decoding control policy: %w", err)
+	}
+	for digest := range p.Tokens {
+		if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("control policy token %q is not a hex-encoded SHA-256 digest", digest)
+		}
+	}
+	if ownerToken == "" {
+		return nil, fmt.Errorf("control policy requires an owner token")
+	}
+	p.ownerToken = ownerToken
+	return &p, nil
+}
+
+// TokenDigest returns the digest that identifies token in a Policy.
+func TokenDigest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Allowed returns true if a peer that presented token may invoke method. A nil
+// policy allows every method.
+func (p *Policy) Allowed(token, method string) bool {
+	if p == nil {
+		return true
+	}
+	if token == "" {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(p.ownerToken)) == 1 {
+		return true
+	}
+	return matchMethod(p.Tokens[TokenDigest(token)], method)
+}
+
+// authorize implements urpc.Authorizer.
+func (p *Policy) authorize(method, token string) error {
+	if !p.Allowed(token, method) {
+		if token == "" {
+			return fmt.Errorf("no token presented for %s", method)
+		}
+		return fmt.Errorf("token may not call %s", method)
+	}
+	return nil
+}
+
+// matchMethod returns true if method matches any of patterns.
+func matchMethod(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == method {
+			return true
+		}
+		if obj, ok := strings.CutSuffix(pattern, ".*"); ok && strings.HasPrefix(method, obj+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testOwnerToken = "owner"
+	testUsageToken = "usage"
+	testAllToken   = "all"
+)
+
+func TestPolicy(t *testing.T) {
+	p, err := LoadPolicy(strings.NewReader(`{"tokens": {
+		"`+TokenDigest(testUsageToken)+`": ["Usage.*", "containerManager.Event"],
+		"`+TokenDigest(testAllToken)+`": ["*"]
+	}}`), testOwnerToken)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	for _, tc := range []struct {
+		token  string
+		method string
+		want   bool
+	}{
+		{token: testOwnerToken, method: "containerManager.Checkpoint", want: true},
+		{token: testUsageToken, method: "containerManager.Event", want: true},
+		{token: testUsageToken, method: "containerManager.Checkpoint", want: false},
+		{token: testUsageToken, method: "Usage.Collect", want: true},
+		{token: testUsageToken, method: "UsageX.Collect", want: false},
+		{token: testAllToken, method: "Lifecycle.StartContainer", want: true},
+		{token: "other", method: "Usage.Collect", want: false},
+		{token: "", method: "Usage.Collect", want: false},
+		// Digests don't grant access themselves.
+		{token: TokenDigest(testAllToken), method: "Usage.Collect", want: false},
+	} {
+		if got := p.Allowed(tc.token, tc.method); got != tc.want {
+			t.Errorf("Allowed(%q, %q) = %t, want %t", tc.token, tc.method, got, tc.want)
+		}
+	}
+}
+
+func TestLoadPolicyErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		policy     string
+		ownerToken string
+	}{
+		{name: "unknown field", policy: `{"uids": {}}`, ownerToken: testOwnerToken},
+		{name: "token not a digest", policy: `{"tokens": {"usage": ["*"]}}`, ownerToken: testOwnerToken},
+		{name: "no owner token", policy: `{}`},
+	} {
+		if _, err := LoadPolicy(strings.NewReader(tc.policy), tc.ownerToken); err == nil {
+			t.Errorf("%s: LoadPolicy succeeded, want error", tc.name)
+		}
+	}
+}
+
+func TestNilPolicy(t *testing.T) {
+	var p *Policy
+	if !p.Allowed("", "containerManager.Checkpoint") {
+		t.Errorf("nil policy denied a call, want allowed")
+	}
+}
+
+func TestSetPolicyNil(t *testing.T) {
+	s := &Server{}
+	s.server.Store(s.newURPCServer())
+	s.SetPolicy(&Policy{ownerToken: testOwnerToken})
+	s.SetPolicy(nil)
+	if p := s.policy.Load(); p != nil {
+		t.Errorf("policy after SetPolicy(nil) = %+v, want nil", p)
+	}
+	// Servers created after the policy is cleared must not use it.
+	s.ResetServer()
+	defer s.server.Load().Stop(0)
+}
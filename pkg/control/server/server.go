@@ -45,6 +45,9 @@ type Server struct {
 	// server is our rpc server.
 	server atomic.Pointer[urpc.Server]
 
+	// policy, if set, authorizes calls made to server.
+	policy atomic.Pointer[Policy]
+
 	// wg waits for the accept loop to terminate.
 	wg sync.WaitGroup
 }
@@ -54,14 +57,35 @@ func New(socket *unet.ServerSocket) *Server {
 	s := &Server{
 		socket: socket,
 	}
-	s.server.Store(urpc.NewServer())
+	s.server.Store(s.newURPCServer())
 	return s
 }
 
+// newURPCServer returns a new urpc.Server subject to the current policy.
+func (s *Server) newURPCServer() *urpc.Server {
+	srv := urpc.NewServer()
+	if p := s.policy.Load(); p != nil {
+		srv.SetAuthorizer(p.authorize)
+	}
+	return srv
+}
+
+// SetPolicy restricts the methods that peers may invoke. It applies to calls
+// made after SetPolicy returns, including after ResetServer. A nil policy
+// clears the current policy, allowing any peer to invoke any method.
+func (s *Server) SetPolicy(p *Policy) {
+	s.policy.Store(p)
+	var a urpc.Authorizer
+	if p != nil {
+		a = p.authorize
+	}
+	s.server.Load().SetAuthorizer(a)
+}
+
 // ResetServer resets the server, clearing all registered objects. It stops the
 // old server asynchronously.
 func (s *Server) ResetServer() {
-	if old := s.server.Swap(urpc.NewServer()); old != nil {
+	if old := s.server.Swap(s.newURPCServer()); old != nil {
 		go old.Stop(0)
 	}
 }
@@ -88,6 +112,33 @@ func (s *Server) Stop(timeout time.Duration) {
 	s.server.Load().Stop(timeout)
 }
 
+// StopAccepting stops accepting new connections and closes the server's copy
+// of the listening socket. Unlike Stop, it does not shut the socket down, so
+// other processes holding a duplicate of the socket may continue to accept
+// connections on it. Connections that are already established continue to be
+// served until Stop is called.
+func (s *Server) StopAccepting() error {
+	fd, err := s.socket.Release()
+	if err != nil {
+		return err
+	}
+	s.Wait()
+	return unix.Close(fd)
+}
+
+// ResumeAccepting resumes accepting new connections after StopAccepting, on
+// fd, which must be a duplicate of the listening socket. The server takes
+// ownership of fd.
+func (s *Server) ResumeAccepting(fd int) error {
+	socket, err := unet.NewServerSocket(fd)
+	if err != nil {
+		_ = unix.Close(fd)
+		return err
+	}
+	s.socket = socket
+	return s.StartServing()
+}
+
 // StartServing starts listening for connect and spawns the main service
 // goroutine for handling incoming control requests. StartServing does not
 // block; to wait for the control server to exit, call Wait.
@@ -0,0 +1,173 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/unet"
+	"gvisor.dev/gvisor/pkg/urpc"
+)
+
+const (
+	// testServerSocketEnv, if set in the environment, makes the test binary
+	// run a control server on the socket path that it names instead of
+	// running tests. The owner token is read from stdin.
+	testServerSocketEnv = "CONTROL_SERVER_TEST_SOCKET"
+
+	// testServerPolicyEnv is the policy of the control server run for
+	// testServerSocketEnv.
+	testServerPolicyEnv = "CONTROL_SERVER_TEST_POLICY"
+
+	// nobody is the user that the sandbox runs as in its user namespace.
+	nobody = 65534
+)
+
+func TestMain(m *testing.M) {
+	if path := os.Getenv(testServerSocketEnv); path != "" {
+		if err := runTestServer(path); err != nil {
+			fmt.Fprintf(os.Stderr, "test control server: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// testObject is registered with the control server run by runTestServer.
+type testObject struct{}
+
+// UID returns the user ID that the control server runs as.
+func (testObject) UID(_ *struct{}, uid *int) error {
+	*uid = os.Getuid()
+	return nil
+}
+
+// Checkpoint stands in for a privileged control method.
+func (testObject) Checkpoint(_ *struct{}, _ *int) error {
+	return nil
+}
+
+// runTestServer runs a control server on path until it is killed.
+func runTestServer(path string) error {
+	ownerToken, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	policy, err := LoadPolicy(strings.NewReader(os.Getenv(testServerPolicyEnv)), string(ownerToken))
+	if err != nil {
+		return err
+	}
+	fd, err := CreateSocket(path)
+	if err != nil {
+		return err
+	}
+	s, err := CreateFromFD(fd)
+	if err != nil {
+		return err
+	}
+	s.SetPolicy(policy)
+	s.Register(testObject{})
+	if err := s.StartServing(); err != nil {
+		return err
+	}
+	fmt.Println("ready")
+	s.Wait()
+	return nil
+}
+
+// TestPolicyInSandboxUserNamespace runs a control server in a user namespace
+// that maps only nobody, as runsc does for the sandbox. Peers outside of the
+// namespace, including the test itself, appear as nobody to the control
+// server, so they must be authorized by their token.
+func TestPolicyInSandboxUserNamespace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	cmd := exec.Command("/proc/self/exe")
+	cmd.Env = append(os.Environ(),
+		testServerSocketEnv+"="+path,
+		testServerPolicyEnv+"="+`{"tokens": {"`+TokenDigest(testUsageToken)+`": ["testObject.UID"]}}`)
+	cmd.Stdin = strings.NewReader(testOwnerToken)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("StdoutPipe(): %v", err)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER,
+		Credential: &syscall.Credential{Uid: nobody, Gid: nobody, NoSetGroups: true},
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: nobody, HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: nobody, HostID: os.Getgid(), Size: 1},
+		},
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Unable to start a control server in a user namespace: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "ready\n" {
+		t.Fatalf("control server didn't start: %q, %v", line, err)
+	}
+
+	call := func(token, method string, result any) error {
+		conn, err := unet.Connect(path, false)
+		if err != nil {
+			t.Fatalf("unet.Connect(%q): %v", path, err)
+		}
+		c := urpc.NewClient(conn)
+		defer c.Close()
+		c.Token = token
+		return c.Call(method, &struct{}{}, result)
+	}
+
+	var uid int
+	if err := call(testOwnerToken, "testObject.UID", &uid); err != nil {
+		t.Fatalf("testObject.UID with the owner token: %v", err)
+	}
+	if uid != nobody {
+		t.Errorf("control server runs as uid %d, want %d", uid, nobody)
+	}
+	for _, tc := range []struct {
+		token  string
+		method string
+		want   bool
+	}{
+		{token: testOwnerToken, method: "testObject.Checkpoint", want: true},
+		{token: testUsageToken, method: "testObject.UID", want: true},
+		{token: testUsageToken, method: "testObject.Checkpoint", want: false},
+		{token: "", method: "testObject.UID", want: false},
+		{token: "", method: "testObject.Checkpoint", want: false},
+	} {
+		var result int
+		err := call(tc.token, tc.method, &result)
+		if got := err == nil; got != tc.want {
+			t.Errorf("calling %s with token %q: got err %v, want allowed = %t", tc.method, tc.token, err, tc.want)
+		} else if err != nil && !strings.Contains(err.Error(), urpc.ErrPermissionDenied.Error()) {
+			t.Errorf("calling %s with token %q: got err %v, want %v", tc.method, tc.token, err, urpc.ErrPermissionDenied)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coretag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/sync"
+)
+
+// cookieHolder is a host thread that was given the core tag corresponding to
+// a cookie passed to SetCookie.
+type cookieHolder struct {
+	tid int
+	tag uint64
+}
+
+var (
+	// cookieHoldersMu serializes SetCookie.
+	cookieHoldersMu sync.Mutex
+
+	// cookieHolders maps cookies passed to SetCookie to the host thread that
+	// was last given the corresponding core tag. The thread may have exited or
+	// been given another core tag since, which shareCoreTag detects.
+	//
+	// +checklocks:cookieHoldersMu
+	cookieHolders = make(map[uint64]cookieHolder)
+)
+
+// errStaleHolder is returned by shareCoreTag if the thread whose core tag
+// should be shared no longer has it.
+var errStaleHolder = errors.New("core tag holder is stale")
+
+// SetCookie gives the host threads tids the core tag corresponding to cookie,
+// a core scheduling cookie of the sandbox (see prctl(PR_SCHED_CORE)). Threads
+// given the same cookie share a core tag, and threads given different cookies
+// never do. Cookie 0 corresponds to the core tag of the current thread group
+// (see Enable).
+//
+// Threads created by tids later inherit their core tag.
+func SetCookie(cookie uint64, tids []int) error {
+	if len(tids) == 0 {
+		return nil
+	}
+	cookieHoldersMu.Lock()
+	defer cookieHoldersMu.Unlock()
+
+	if cookie == 0 {
+		return shareCoreTag(os.Getpid(), 0, tids)
+	}
+	if h, ok := cookieHolders[cookie]; ok {
+		if err := shareCoreTag(h.tid, h.tag, tids); err != errStaleHolder {
+			return err
+		}
+	}
+
+	// No thread has the core tag corresponding to cookie, so create one for
+	// the first thread and share it with the rest.
+	if err := schedCore(unix.PR_SCHED_CORE_CREATE, tids[0], linux.PR_SCHED_CORE_SCOPE_THREAD); err != nil {
+		return fmt.Errorf("failed to core tag thread %d: %w", tids[0], err)
+	}
+	tag, err := getCoreTag(tids[0])
+	if err != nil {
+		return err
+	}
+	cookieHolders[cookie] = cookieHolder{tid: tids[0], tag: tag}
+	return shareCoreTag(tids[0], tag, tids[1:])
+}
+
+// shareCoreTag gives the host threads tids the core tag of the host thread
+// from. If want is not 0, it is the core tag that from is expected to have,
+// and shareCoreTag returns errStaleHolder if from doesn't have it.
+func shareCoreTag(from int, want uint64, tids []int) error {
+	if len(tids) == 0 {
+		return nil
+	}
+	// Core tags can only be shared through the calling thread, which takes the
+	// core tag of from in the meantime. Do it on a dedicated thread, which is
+	// destroyed when the goroutine exits unless its own core tag is restored.
+	errC := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		errC <- shareCoreTagLocked(from, want, tids)
+		if err := schedCore(unix.PR_SCHED_CORE_SHARE_FROM, os.Getpid(), linux.PR_SCHED_CORE_SCOPE_THREAD); err == nil {
+			runtime.UnlockOSThread()
+		}
+	}()
+	return <-errC
+}
+
+// Preconditions: The caller must have locked the OS thread.
+func shareCoreTagLocked(from int, want uint64, tids []int) error {
+	if err := schedCore(unix.PR_SCHED_CORE_SHARE_FROM, from, linux.PR_SCHED_CORE_SCOPE_THREAD); err != nil {
+		if want != 0 {
+			// from has exited, and its thread ID may have been reused.
+			return errStaleHolder
+		}
+		return fmt.Errorf("failed to get core tag of thread %d: %w", from, err)
+	}
+	if want != 0 {
+		if tag, err := getCoreTag(0); err != nil || tag != want {
+			return errStaleHolder
+		}
+	}
+	for _, tid := range tids {
+		if err := schedCore(unix.PR_SCHED_CORE_SHARE_TO, tid, linux.PR_SCHED_CORE_SCOPE_THREAD); err != nil {
+			return fmt.Errorf("failed to core tag thread %d: %w", tid, err)
+		}
+	}
+	return nil
+}
+
+// schedCore executes prctl(PR_SCHED_CORE, cmd, tid, scope, 0).
+func schedCore(cmd uintptr, tid int, scope uintptr) error {
+	if _, _, errno := unix.Syscall6(unix.SYS_PRCTL, unix.PR_SCHED_CORE, cmd, uintptr(tid), scope, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
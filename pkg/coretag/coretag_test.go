@@ -17,12 +17,15 @@ package coretag
 import (
 	"os"
 	"reflect"
+	"runtime"
 	"testing"
 
+	"golang.org/x/sys/unix"
 	"gvisor.dev/gvisor/pkg/hostos"
 )
 
-func TestEnable(t *testing.T) {
+// skipIfUnsupported skips the test if core tagging is not available.
+func skipIfUnsupported(t *testing.T) {
 	version, err := hostos.KernelVersion()
 	if err != nil {
 		t.Fatalf("Unable to parse kernel version: %v", err)
@@ -31,8 +34,15 @@ func TestEnable(t *testing.T) {
 	// is not available.
 	if version.LessThan(5, 14) {
 		t.Skipf("Running on Linux kernel: %s < 5.14. Core tagging not available. Skipping test.", version)
-		return
 	}
+	// The kernel may also have been built without CONFIG_SCHED_CORE.
+	if _, err := getCoreTag(0); err != nil {
+		t.Skipf("Core tagging not available: %v. Skipping test.", err)
+	}
+}
+
+func TestEnable(t *testing.T) {
+	skipIfUnsupported(t)
 	if err := Enable(); err != nil {
 		t.Fatalf("Enable() got error %v, wanted nil", err)
 	}
@@ -53,3 +63,72 @@ func TestEnable(t *testing.T) {
 		t.Fatalf("Got different coreTags for PID %d vs self: %v vs %v", pid, coreTags, coreTagsSelf)
 	}
 }
+
+// newTestThreads returns the thread IDs of n host threads that exist until the
+// test ends. The threads are destroyed rather than reused, since their core
+// tags are changed.
+func newTestThreads(t *testing.T, n int) []int {
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	tids := make([]int, n)
+	for i := range tids {
+		tidC := make(chan int)
+		go func() {
+			runtime.LockOSThread()
+			tidC <- unix.Gettid()
+			<-done
+		}()
+		tids[i] = <-tidC
+	}
+	return tids
+}
+
+func TestSetCookie(t *testing.T) {
+	skipIfUnsupported(t)
+	tids := newTestThreads(t, 4)
+	if err := SetCookie(1, tids[:2]); err != nil {
+		t.Fatalf("SetCookie(1, %v) got error %v, wanted nil", tids[:2], err)
+	}
+	// Cookies that are shared later get the same core tag.
+	if err := SetCookie(1, tids[2:3]); err != nil {
+		t.Fatalf("SetCookie(1, %v) got error %v, wanted nil", tids[2:3], err)
+	}
+	if err := SetCookie(2, tids[3:]); err != nil {
+		t.Fatalf("SetCookie(2, %v) got error %v, wanted nil", tids[3:], err)
+	}
+	tags := make([]uint64, len(tids))
+	for i, tid := range tids {
+		tag, err := getCoreTag(tid)
+		if err != nil {
+			t.Fatalf("getCoreTag(%d) got error %v, wanted nil", tid, err)
+		}
+		tags[i] = tag
+	}
+	if tags[0] == 0 || tags[0] != tags[1] || tags[0] != tags[2] {
+		t.Errorf("Got core tags %v for threads given cookie 1, wanted the same non-zero core tag", tags[:3])
+	}
+	if tags[3] == 0 || tags[3] == tags[0] {
+		t.Errorf("Got core tag %d for thread given cookie 2, wanted a non-zero core tag different from %d", tags[3], tags[0])
+	}
+
+	// Cookie 0 restores the core tag of the thread group.
+	if err := SetCookie(0, tids[:1]); err != nil {
+		t.Fatalf("SetCookie(0, %v) got error %v, wanted nil", tids[:1], err)
+	}
+	want, err := getCoreTag(os.Getpid())
+	if err != nil {
+		t.Fatalf("getCoreTag(%d) got error %v, wanted nil", os.Getpid(), err)
+	}
+	if got, err := getCoreTag(tids[0]); err != nil || got != want {
+		t.Errorf("getCoreTag(%d) = %d, %v after SetCookie(0), wanted %d, nil", tids[0], got, err, want)
+	}
+
+	// If the thread that was given cookie 1 first no longer has its core
+	// tag, the core tag is still shared.
+	if err := SetCookie(1, tids[3:]); err != nil {
+		t.Fatalf("SetCookie(1, %v) got error %v, wanted nil", tids[3:], err)
+	}
+	if got, err := getCoreTag(tids[3]); err != nil || got == 0 || got == want {
+		t.Errorf("getCoreTag(%d) = %d, %v after SetCookie(1), wanted a non-zero core tag different from %d", tids[3], got, err, want)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package faultinject implements opt-in fault injection ("chaos mode"), which
+// delays or fails operations of the sandbox that match configured rules, so
+// that applications' resilience can be tested.
+//
+// Fault injection is disabled until rules are installed with SetRules.
+// Callers check Enabled before doing any other work, so that it costs a
+// single atomic load when disabled.
+package faultinject
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/syserr"
+)
+
+// Layer identifies the layer of the sandbox that faults are injected into.
+type Layer string
+
+const (
+	// LayerSyscall injects faults into application syscalls. Operations are
+	// named after syscalls, e.g. "openat".
+	LayerSyscall Layer = "syscall"
+
+	// LayerGofer injects faults into gofer RPCs. Operations are named after
+	// lisafs messages, e.g. "PRead".
+	LayerGofer Layer = "gofer"
+
+	// LayerNetstack injects faults into operations on netstack sockets.
+	// Operations are "accept", "connect", "recvmsg" and "sendmsg".
+	LayerNetstack Layer = "netstack"
+)
+
+// Duration is a time.Duration that is represented in JSON as a string
+// accepted by time.ParseDuration, e.g. "10ms".
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.MarshalJSON.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.UnmarshalJSON.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Rule describes the faults injected into matching operations.
+type Rule struct {
+	// Layer is the layer of the operations that the rule applies to.
+	Layer Layer `json:"layer"`
+
+	// Pattern is matched against operation names using path.Match syntax,
+	// e.g. "*" or "read*".
+	Pattern string `json:"pattern"`
+
+	// Probability is the probability, between 0 and 1, that a matching
+	// operation is affected.
+	Probability float64 `json:"probability"`
+
+	// Delay is how long affected operations are delayed.
+	Delay Duration `json:"delay,omitempty"`
+
+	// Errno, if not 0, makes affected operations fail with this error after
+	// Delay has elapsed.
+	Errno int `json:"errno,omitempty"`
+}
+
+func (r *Rule) validate() error {
+	switch r.Layer {
+	case LayerSyscall, LayerGofer, LayerNetstack:
+	default:
+		return fmt.Errorf("invalid layer %q", r.Layer)
+	}
+	if _, err := path.Match(r.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+	}
+	if r.Probability < 0 || r.Probability > 1 {
+		return fmt.Errorf("probability %v is not between 0 and 1", r.Probability)
+	}
+	if r.Delay < 0 {
+		return fmt.Errorf("negative delay %v", time.Duration(r.Delay))
+	}
+	if r.Errno != 0 && (r.Errno < 0 || !syserr.IsValid(unix.Errno(r.Errno))) {
+		return fmt.Errorf("invalid errno %d", r.Errno)
+	}
+	if r.Delay == 0 && r.Errno == 0 {
+		return fmt.Errorf("rule for %s %q injects no fault", r.Layer, r.Pattern)
+	}
+	return nil
+}
+
+// rules are the installed rules. It is nil if fault injection is disabled.
+// The slice is never mutated after being stored.
+var rules atomic.Pointer[[]Rule]
+
+// SetRules replaces the installed rules with rs. If rs is empty, fault
+// injection is disabled.
+func SetRules(rs []Rule) error {
+	for i := range rs {
+		if err := rs[i].validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	if len(rs) == 0 {
+		rules.Store(nil)
+		return nil
+	}
+	rs = append([]Rule(nil), rs...)
+	rules.Store(&rs)
+	return nil
+}
+
+// Rules returns a copy of the installed rules.
+func Rules() []Rule {
+	rs := rules.Load()
+	if rs == nil {
+		return nil
+	}
+	return append([]Rule(nil), (*rs)...)
+}
+
+// Enabled returns true if any rules are installed.
+func Enabled() bool {
+	return rules.Load() != nil
+}
+
+// Fault is a fault to inject into an operation.
+type Fault struct {
+	// Delay is how long the operation must be delayed.
+	Delay time.Duration
+
+	// Errno, if not 0, is the error that the operation must fail with.
+	Errno unix.Errno
+}
+
+// Check returns the fault to inject into the operation called name in layer,
+// if any. The first matching rule applies. If it doesn't fire, according to
+// its probability, the operation is not affected.
+func Check(layer Layer, name string) (Fault, bool) {
+	rs := rules.Load()
+	if rs == nil {
+		return Fault{}, false
+	}
+	for i := range *rs {
+		r := &(*rs)[i]
+		if r.Layer != layer {
+			continue
+		}
+		if ok, _ := path.Match(r.Pattern, name); !ok {
+			continue
+		}
+		if r.Probability < 1 && rand.Float64() >= r.Probability {
+			return Fault{}, false
+		}
+		return Fault{Delay: time.Duration(r.Delay), Errno: unix.Errno(r.Errno)}, true
+	}
+	return Fault{}, false
+}
+
+// Inject injects the fault, if any, into the operation called name in layer
+// by sleeping for its delay, and returns the error that the operation must
+// fail with, or nil. It must only be used by callers that may block the
+// calling goroutine; tasks use kernel.Task.InjectFault instead.
+func Inject(layer Layer, name string) error {
+	f, ok := Check(layer, name)
+	if !ok {
+		return nil
+	}
+	if f.Delay > 0 {
+		time.Sleep(f.Delay)
+	}
+	if f.Errno != 0 {
+		return f.Errno
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package faultinject
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCheck(t *testing.T) {
+	defer SetRules(nil)
+	if err := SetRules([]Rule{
+		{Layer: LayerSyscall, Pattern: "read*", Probability: 1, Errno: int(unix.EIO)},
+		{Layer: LayerSyscall, Pattern: "*", Probability: 0, Delay: Duration(time.Second)},
+		{Layer: LayerGofer, Pattern: "Walk", Probability: 1, Delay: Duration(time.Millisecond)},
+	}); err != nil {
+		t.Fatalf("SetRules failed: %v", err)
+	}
+	if !Enabled() {
+		t.Errorf("Enabled() = false after SetRules")
+	}
+	for _, tc := range []struct {
+		layer  Layer
+		name   string
+		want   Fault
+		wantOK bool
+	}{
+		{layer: LayerSyscall, name: "readv", want: Fault{Errno: unix.EIO}, wantOK: true},
+		// The second rule matches, but never fires.
+		{layer: LayerSyscall, name: "write"},
+		{layer: LayerGofer, name: "Walk", want: Fault{Delay: time.Millisecond}, wantOK: true},
+		{layer: LayerGofer, name: "PRead"},
+		{layer: LayerNetstack, name: "connect"},
+	} {
+		if got, ok := Check(tc.layer, tc.name); got != tc.want || ok != tc.wantOK {
+			t.Errorf("Check(%s, %q) = %+v, %t, want %+v, %t", tc.layer, tc.name, got, ok, tc.want, tc.wantOK)
+		}
+	}
+
+	if err := SetRules(nil); err != nil {
+		t.Fatalf("SetRules(nil) failed: %v", err)
+	}
+	if Enabled() {
+		t.Errorf("Enabled() = true after SetRules(nil)")
+	}
+	if _, ok := Check(LayerSyscall, "readv"); ok {
+		t.Errorf("Check returned a fault with no rules")
+	}
+}
+
+func TestSetRulesInvalid(t *testing.T) {
+	defer SetRules(nil)
+	for _, r := range []Rule{
+		{Layer: "disk", Pattern: "*", Probability: 1, Errno: 5},
+		{Layer: LayerSyscall, Pattern: "[", Probability: 1, Errno: 5},
+		{Layer: LayerSyscall, Pattern: "*", Probability: 2, Errno: 5},
+		{Layer: LayerSyscall, Pattern: "*", Probability: 1, Delay: -1},
+		{Layer: LayerSyscall, Pattern: "*", Probability: 1, Errno: -5},
+		{Layer: LayerSyscall, Pattern: "*", Probability: 1},
+	} {
+		if err := SetRules([]Rule{r}); err == nil {
+			t.Errorf("SetRules(%+v) succeeded, want error", r)
+		}
+	}
+	if Enabled() {
+		t.Errorf("Enabled() = true after invalid rules")
+	}
+}
+
+func TestRuleJSON(t *testing.T) {
+	var r Rule
+	if err := json.Unmarshal([]byte(`{"layer": "netstack", "pattern": "connect", "probability": 0.5, "delay": "10ms"}`), &r); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	want := Rule{Layer: LayerNetstack, Pattern: "connect", Probability: 0.5, Delay: Duration(10 * time.Millisecond)}
+	if r != want {
+		t.Errorf("Unmarshal = %+v, want %+v", r, want)
+	}
+	b, err := json.Marshal(&r)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if got, want := string(b), `{"layer":"netstack","pattern":"connect","probability":0.5,"delay":"10ms"}`; got != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+}
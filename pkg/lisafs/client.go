@@ -20,6 +20,7 @@ import (
 
 	"golang.org/x/sys/unix"
 	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/faultinject"
 	"gvisor.dev/gvisor/pkg/flipcall"
 	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/sync"
@@ -324,6 +325,11 @@ func (c *Client) SndRcvMessage(m MID, payloadLen uint32, reqMarshal marshalFunc,
 	if !c.IsSupported(m) {
 		return unix.EOPNOTSUPP
 	}
+	if faultinject.Enabled() {
+		if err := faultinject.Inject(faultinject.LayerGofer, m.String()); err != nil {
+			return err
+		}
+	}
 	if payloadLen > c.maxMessageSize {
 		log.Warningf("message %d has payload which is too large: %d bytes", m, payloadLen)
 		return unix.EIO
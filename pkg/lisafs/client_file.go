@@ -600,6 +600,38 @@ func (f *ClientFD) RemoveXattr(ctx context.Context, name string) error {
 	return err
 }
 
+// RangeLock makes the FRangeLock RPC. cmd must be F_OFD_SETLK or F_OFD_GETLK,
+// and lock must be relative to the start of the file. For F_OFD_GETLK, lock is
+// updated as by fcntl(2). If the server doesn't support FRangeLock, RangeLock
+// returns ENOLCK.
+func (f *ClientFD) RangeLock(ctx context.Context, cmd int, lock *unix.Flock_t) error {
+	if !f.client.IsSupported(FRangeLock) {
+		return unix.ENOLCK
+	}
+	if lock.Whence != unix.SEEK_SET || lock.Start < 0 || lock.Len < 0 {
+		return unix.EINVAL
+	}
+	req := FRangeLockReq{
+		FD:     f.fd,
+		Start:  uint64(lock.Start),
+		Length: uint64(lock.Len),
+		Cmd:    uint32(cmd),
+		Type:   uint32(lock.Type),
+	}
+	var resp FRangeLockResp
+	ctx.UninterruptibleSleepStart(false)
+	err := f.client.SndRcvMessage(FRangeLock, uint32(req.SizeBytes()), req.MarshalUnsafe, resp.CheckedUnmarshal, nil, req.String, resp.String)
+	ctx.UninterruptibleSleepFinish(false)
+	if err == nil && cmd == unix.F_OFD_GETLK {
+		lock.Type = int16(resp.Type)
+		lock.Start = int64(resp.Start)
+		lock.Len = int64(resp.Length)
+		// OFD locks have no owning process.
+		lock.Pid = -1
+	}
+	return err
+}
+
 // ClientBoundSocketFD corresponds to a bound socket on the server. It
 // implements transport.BoundSocketFD.
 //
@@ -115,6 +115,11 @@ func (c *Connection) ServerImpl() ServerImpl {
 	return c.server.impl
 }
 
+// ReadOnly returns true if this connection is read-only.
+func (c *Connection) ReadOnly() bool {
+	return c.readonly
+}
+
 // Run defines the lifecycle of a connection.
 func (c *Connection) Run() {
 	defer c.close()
@@ -612,6 +612,14 @@ type OpenFDImpl interface {
 	// On the server, Flush has a read concurrency guarantee.
 	Flush() error
 
+	// RangeLock sets (cmd = F_OFD_SETLK) or tests (cmd = F_OFD_GETLK) an open
+	// file description lock on this FD without blocking, as by fcntl(2).
+	// lock.Whence is always SEEK_SET. For F_OFD_GETLK, lock must be updated to
+	// describe a conflicting lock, or have type F_UNLCK if there is none.
+	//
+	// On the server, RangeLock has a read concurrency guarantee.
+	RangeLock(cmd int, lock *unix.Flock_t) error
+
 	// Getdent64 fetches directory entries for this directory and calls
 	// recordDirent for each dirent read. If seek0 is true, then the directory FD
 	// is seeked to 0 and iteration starts from the beginning.
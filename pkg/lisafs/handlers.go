@@ -81,6 +81,7 @@ var handlers = [...]RPCHandler{
 	Listen:           ListenHandler,
 	Accept:           AcceptHandler,
 	ConnectWithCreds: ConnectWithCredsHandler,
+	FRangeLock:       FRangeLockHandler,
 }
 
 // ErrorHandler handles Error message.
@@ -1524,6 +1525,64 @@ func FRemoveXattrHandler(c *Connection, comm Communicator, payloadLen uint32) (u
 	})
 }
 
+// FRangeLockHandler handles the FRangeLock RPC.
+func FRangeLockHandler(c *Connection, comm Communicator, payloadLen uint32) (uint32, error) {
+	var req FRangeLockReq
+	if _, ok := req.CheckedUnmarshal(comm.PayloadBuf(payloadLen)); !ok {
+		return 0, unix.EIO
+	}
+	if req.Cmd != unix.F_OFD_SETLK && req.Cmd != unix.F_OFD_GETLK {
+		return 0, unix.EINVAL
+	}
+	if req.Length > math.MaxInt64 || req.Start > math.MaxInt64-req.Length {
+		return 0, unix.EINVAL
+	}
+
+	fd, err := c.lookupOpenFD(req.FD)
+	if err != nil {
+		return 0, err
+	}
+	defer fd.DecRef(nil)
+	switch req.Type {
+	case unix.F_RDLCK:
+		// Compare Linux's fs/locks.c:check_fmode_for_setlk().
+		if req.Cmd == unix.F_OFD_SETLK && !fd.readable {
+			return 0, unix.EBADF
+		}
+	case unix.F_WRLCK:
+		if req.Cmd == unix.F_OFD_SETLK && !fd.writable {
+			return 0, unix.EBADF
+		}
+	case unix.F_UNLCK:
+		if req.Cmd == unix.F_OFD_GETLK {
+			return 0, unix.EINVAL
+		}
+	default:
+		return 0, unix.EINVAL
+	}
+
+	lock := unix.Flock_t{
+		Type:   int16(req.Type),
+		Whence: unix.SEEK_SET,
+		Start:  int64(req.Start),
+		Len:    int64(req.Length),
+	}
+	if err := fd.controlFD.safelyRead(func() error {
+		return fd.impl.RangeLock(int(req.Cmd), &lock)
+	}); err != nil {
+		return 0, err
+	}
+	var resp FRangeLockResp
+	if req.Cmd == unix.F_OFD_GETLK {
+		resp.Start = uint64(lock.Start)
+		resp.Length = uint64(lock.Len)
+		resp.Type = uint32(lock.Type)
+	}
+	respLen := uint32(resp.SizeBytes())
+	resp.MarshalUnsafe(comm.PayloadBuf(respLen))
+	return respLen, nil
+}
+
 // checkSafeName validates the name and returns nil or returns an error.
 func checkSafeName(name string) error {
 	if name != "" && !strings.Contains(name, "/") && name != "." && name != ".." {
@@ -176,8 +176,58 @@ const (
 	// ConnectWithCreds is analogous to connect(2) but it asks the server
 	// to connect with the provided effective uid/gid.
 	ConnectWithCreds MID = 32
+
+	// FRangeLock is analogous to fcntl(2) with F_OFD_SETLK or F_OFD_GETLK. It
+	// never blocks.
+	FRangeLock MID = 33
 )
 
+// midNames maps message IDs to their names.
+var midNames = map[MID]string{
+	Error:            "Error",
+	Mount:            "Mount",
+	Channel:          "Channel",
+	FStat:            "FStat",
+	SetStat:          "SetStat",
+	Walk:             "Walk",
+	WalkStat:         "WalkStat",
+	OpenAt:           "OpenAt",
+	OpenCreateAt:     "OpenCreateAt",
+	Close:            "Close",
+	FSync:            "FSync",
+	PWrite:           "PWrite",
+	PRead:            "PRead",
+	MkdirAt:          "MkdirAt",
+	MknodAt:          "MknodAt",
+	SymlinkAt:        "SymlinkAt",
+	LinkAt:           "LinkAt",
+	FStatFS:          "FStatFS",
+	FAllocate:        "FAllocate",
+	ReadLinkAt:       "ReadLinkAt",
+	Flush:            "Flush",
+	Connect:          "Connect",
+	UnlinkAt:         "UnlinkAt",
+	RenameAt:         "RenameAt",
+	Getdents64:       "Getdents64",
+	FGetXattr:        "FGetXattr",
+	FSetXattr:        "FSetXattr",
+	FListXattr:       "FListXattr",
+	FRemoveXattr:     "FRemoveXattr",
+	BindAt:           "BindAt",
+	Listen:           "Listen",
+	Accept:           "Accept",
+	ConnectWithCreds: "ConnectWithCreds",
+	FRangeLock:       "FRangeLock",
+}
+
+// String implements fmt.Stringer.String.
+func (m MID) String() string {
+	if name, ok := midNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("MID(%d)", uint16(m))
+}
+
 const (
 	// NoUID is a sentinel used to indicate no valid UID. See auth.NoID.
 	NoUID UID = math.MaxUint32
@@ -1337,6 +1387,44 @@ func (c *ConnectWithCredsReq) String() string {
 	return fmt.Sprintf("ConnectWithCredsReq{FD: %d, SockType: %d, UID: %d, GID: %d}", c.FD, c.SockType, c.UID, c.GID)
 }
 
+// FRangeLockReq is used to set or test an open file description lock on a
+// byte range of an FD.
+//
+// +marshal boundCheck
+type FRangeLockReq struct {
+	FD FDID
+	// Start and Length describe the locked byte range. Length = 0 means that
+	// the range extends to the end of the file.
+	Start  uint64
+	Length uint64
+	// Cmd is F_OFD_SETLK or F_OFD_GETLK.
+	Cmd uint32
+	// Type is F_RDLCK, F_WRLCK or F_UNLCK.
+	Type uint32
+}
+
+// String implements fmt.Stringer.String.
+func (r *FRangeLockReq) String() string {
+	return fmt.Sprintf("FRangeLockReq{FD: %d, Start: %d, Length: %d, Cmd: %d, Type: %d}", r.FD, r.Start, r.Length, r.Cmd, r.Type)
+}
+
+// FRangeLockResp is used to communicate FRangeLock results. For F_OFD_GETLK,
+// it describes a lock that conflicts with the requested one, or has Type
+// F_UNLCK if there is none. For F_OFD_SETLK, it is zeroed.
+//
+// +marshal boundCheck
+type FRangeLockResp struct {
+	Start  uint64
+	Length uint64
+	Type   uint32
+	_      uint32 // Need to make struct packed.
+}
+
+// String implements fmt.Stringer.String.
+func (r *FRangeLockResp) String() string {
+	return fmt.Sprintf("FRangeLockResp{Start: %d, Length: %d, Type: %d}", r.Start, r.Length, r.Type)
+}
+
 // BindAtReq is used to make BindAt requests.
 type BindAtReq struct {
 	createCommon
@@ -80,6 +80,7 @@ var localFSTests = map[string]TestFunc{
 	"Mknod":           testMknod,
 	"UDS":             testUDS,
 	"Getdents":        testGetdents,
+	"RangeLock":       testRangeLock,
 }
 
 // RunTest runs the passed test function as a subtest.
@@ -442,6 +443,53 @@ func testAllocate(ctx context.Context, t *testing.T, tester Tester, root lisafs.
 	allocateAndVerify(ctx, t, fd, 20, 100)
 }
 
+func testRangeLock(ctx context.Context, t *testing.T, tester Tester, root lisafs.ClientFD) {
+	if !root.Client().IsSupported(lisafs.FRangeLock) {
+		t.Skipf("FRangeLock is not supported")
+	}
+	name := "tempFile"
+	controlFile, _, fd, hostFD := openCreateFile(ctx, t, root, name)
+	defer closeFD(ctx, t, controlFile)
+	defer closeFD(ctx, t, fd)
+	defer unix.Close(hostFD)
+
+	roFile, roHostFD := openFile(ctx, t, controlFile, unix.O_RDONLY, true /* isReg */)
+	defer closeFD(ctx, t, roFile)
+	defer unix.Close(roHostFD)
+
+	lock := unix.Flock_t{Type: unix.F_WRLCK, Whence: unix.SEEK_SET, Start: 10, Len: 10}
+	if err := fd.RangeLock(ctx, unix.F_OFD_SETLK, &lock); err != nil {
+		t.Fatalf("write locking failed: %v", err)
+	}
+
+	// The write lock conflicts with locks on other FDs.
+	conflict := unix.Flock_t{Type: unix.F_RDLCK, Whence: unix.SEEK_SET}
+	if err := roFile.RangeLock(ctx, unix.F_OFD_GETLK, &conflict); err != nil {
+		t.Fatalf("testing lock failed: %v", err)
+	}
+	if conflict.Type != unix.F_WRLCK || conflict.Start != 10 || conflict.Len != 10 {
+		t.Errorf("got conflicting lock %+v, want write lock on [10, 20)", conflict)
+	}
+	readLock := unix.Flock_t{Type: unix.F_RDLCK, Whence: unix.SEEK_SET, Start: 15, Len: 1}
+	if err := roFile.RangeLock(ctx, unix.F_OFD_SETLK, &readLock); err != unix.EAGAIN {
+		t.Errorf("read locking a write locked range should generate EAGAIN, but got %v", err)
+	}
+
+	// Read only FDs can't hold write locks.
+	writeLock := unix.Flock_t{Type: unix.F_WRLCK, Whence: unix.SEEK_SET, Start: 0, Len: 1}
+	if err := roFile.RangeLock(ctx, unix.F_OFD_SETLK, &writeLock); err != unix.EBADF {
+		t.Errorf("write locking a read only FD should generate EBADF, but got %v", err)
+	}
+
+	unlock := unix.Flock_t{Type: unix.F_UNLCK, Whence: unix.SEEK_SET, Start: 10, Len: 10}
+	if err := fd.RangeLock(ctx, unix.F_OFD_SETLK, &unlock); err != nil {
+		t.Fatalf("unlocking failed: %v", err)
+	}
+	if err := roFile.RangeLock(ctx, unix.F_OFD_SETLK, &readLock); err != nil {
+		t.Errorf("read locking an unlocked range failed: %v", err)
+	}
+}
+
 func testStatFS(ctx context.Context, t *testing.T, tester Tester, root lisafs.ClientFD) {
 	var statFS lisafs.StatFS
 	if err := root.StatFSTo(ctx, &statFS); err != nil {
@@ -0,0 +1,203 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rand
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"io"
+	"runtime"
+
+	"gvisor.dev/gvisor/pkg/sync"
+)
+
+const (
+	// drbgKeyLen and drbgBlockLen are the key and block lengths of AES-256.
+	drbgKeyLen   = 32
+	drbgBlockLen = aes.BlockSize
+
+	// drbgSeedLen is the length of the seed material used to instantiate and
+	// reseed the DRBG.
+	drbgSeedLen = drbgKeyLen + drbgBlockLen
+
+	// drbgMaxRequest is the maximum number of bytes returned by a single
+	// generate request (2^19 bits, SP 800-90A Table 3).
+	drbgMaxRequest = 1 << 16
+
+	// drbgReseedInterval is the number of generate requests after which the
+	// DRBG is reseeded from its entropy source. SP 800-90A allows up to 2^48.
+	drbgReseedInterval = 1 << 16
+)
+
+// drbg is a CTR_DRBG using AES-256 without a derivation function, as specified
+// by NIST SP 800-90A Rev. 1 section 10.2.1. It is seeded, and periodically
+// reseeded, from an entropy source.
+type drbg struct {
+	// entropy is the entropy source. entropy is immutable.
+	entropy io.Reader
+
+	mu            sync.Mutex
+	block         cipher.Block
+	v             [drbgBlockLen]byte
+	reseedCounter uint64
+}
+
+// newDRBG returns a DRBG instantiated with seed material read from entropy.
+func newDRBG(entropy io.Reader) (*drbg, error) {
+	d := &drbg{entropy: entropy}
+	var key [drbgKeyLen]byte
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	d.block = block
+	if err := d.reseedLocked(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// incV increments d.v as a big-endian counter.
+func (d *drbg) incV() {
+	for i := len(d.v) - 1; i >= 0; i-- {
+		d.v[i]++
+		if d.v[i] != 0 {
+			return
+		}
+	}
+}
+
+// updateLocked implements CTR_DRBG_Update.
+//
+// Preconditions: d.mu must be locked, unless d is being instantiated.
+func (d *drbg) updateLocked(provided *[drbgSeedLen]byte) {
+	var temp [drbgSeedLen]byte
+	for i := 0; i < drbgSeedLen; i += drbgBlockLen {
+		d.incV()
+		d.block.Encrypt(temp[i:i+drbgBlockLen], d.v[:])
+	}
+	for i := range temp {
+		temp[i] ^= provided[i]
+	}
+	block, err := aes.NewCipher(temp[:drbgKeyLen])
+	if err != nil {
+		// Only possible for invalid key lengths.
+		panic(fmt.Sprintf("aes.NewCipher failed: %v", err))
+	}
+	d.block = block
+	copy(d.v[:], temp[drbgKeyLen:])
+}
+
+// reseedLocked implements CTR_DRBG_Reseed (and instantiation, starting from a
+// zero key and V) with seed material read from d.entropy.
+//
+// Preconditions: d.mu must be locked, unless d is being instantiated.
+func (d *drbg) reseedLocked() error {
+	var seed [drbgSeedLen]byte
+	if _, err := io.ReadFull(d.entropy, seed[:]); err != nil {
+		return fmt.Errorf("reading DRBG seed: %w", err)
+	}
+	d.updateLocked(&seed)
+	d.reseedCounter = 1
+	return nil
+}
+
+// Read implements io.Reader.Read.
+func (d *drbg) Read(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.readLocked(p)
+}
+
+// readLocked fills p, split into as many generate requests as necessary.
+//
+// Preconditions: d.mu must be locked.
+func (d *drbg) readLocked(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		end := min(len(p), n+drbgMaxRequest)
+		if err := d.generateLocked(p[n:end]); err != nil {
+			return n, err
+		}
+		n = end
+	}
+	return n, nil
+}
+
+// generateLocked implements CTR_DRBG_Generate without additional input.
+//
+// Preconditions:
+//   - d.mu must be locked.
+//   - len(p) <= drbgMaxRequest.
+func (d *drbg) generateLocked(p []byte) error {
+	if d.reseedCounter > drbgReseedInterval {
+		if err := d.reseedLocked(); err != nil {
+			return err
+		}
+	}
+	var out [drbgBlockLen]byte
+	for i := 0; i < len(p); i += drbgBlockLen {
+		d.incV()
+		d.block.Encrypt(out[:], d.v[:])
+		copy(p[i:], out[:])
+	}
+	var zero [drbgSeedLen]byte
+	d.updateLocked(&zero)
+	d.reseedCounter++
+	return nil
+}
+
+// perCPUDRBG is an io.Reader that spreads reads across independently seeded
+// DRBGs, one per CPU, so that concurrent readers rarely contend on the same
+// DRBG. This is analogous to Linux's per-CPU CRNGs (see
+// drivers/char/random.c:crng_make_state()), each of which is reseeded from the
+// shared input pool.
+//
+// Since goroutines can't determine which CPU they are running on, readers
+// start at a random DRBG and take the first one that isn't already in use.
+type perCPUDRBG struct {
+	// drbgs is immutable.
+	drbgs []*drbg
+}
+
+// newPerCPUDRBG returns a perCPUDRBG with one DRBG for each CPU, each
+// instantiated with seed material read from entropy.
+func newPerCPUDRBG(entropy io.Reader) (*perCPUDRBG, error) {
+	r := &perCPUDRBG{drbgs: make([]*drbg, runtime.NumCPU())}
+	for i := range r.drbgs {
+		d, err := newDRBG(entropy)
+		if err != nil {
+			return nil, err
+		}
+		r.drbgs[i] = d
+	}
+	return r, nil
+}
+
+// Read implements io.Reader.Read.
+func (r *perCPUDRBG) Read(p []byte) (int, error) {
+	start := int(sync.Rand32() % uint32(len(r.drbgs)))
+	for i := range r.drbgs {
+		d := r.drbgs[(start+i)%len(r.drbgs)]
+		if d.mu.TryLock() {
+			n, err := d.readLocked(p)
+			d.mu.Unlock()
+			return n, err
+		}
+	}
+	// All DRBGs are busy; wait for the one we started with.
+	return r.drbgs[start].Read(p)
+}
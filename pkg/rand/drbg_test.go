@@ -0,0 +1,161 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rand
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"sync"
+	"testing"
+)
+
+// countingReader returns zero bytes and counts reads.
+type countingReader struct {
+	reads int
+}
+
+// Read implements io.Reader.Read.
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	clear(p)
+	return len(p), nil
+}
+
+// aesCTR returns n bytes of the AES-CTR keystream for key and the counter
+// following iv.
+func aesCTR(t *testing.T, key, iv []byte, n int) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("aes.NewCipher failed: %v", err)
+	}
+	ctr := make([]byte, len(iv))
+	copy(ctr, iv)
+	for i := len(ctr) - 1; i >= 0; i-- {
+		ctr[i]++
+		if ctr[i] != 0 {
+			break
+		}
+	}
+	out := make([]byte, n)
+	cipher.NewCTR(block, ctr).XORKeyStream(out, out)
+	return out
+}
+
+func TestDRBGOutput(t *testing.T) {
+	d, err := newDRBG(&countingReader{})
+	if err != nil {
+		t.Fatalf("newDRBG failed: %v", err)
+	}
+
+	// With an all-zero seed, instantiation sets (Key, V) to the AES-CTR
+	// keystream of the zero key and V = 0, and the first generate request
+	// returns the keystream that follows for (Key, V).
+	state := aesCTR(t, make([]byte, drbgKeyLen), make([]byte, drbgBlockLen), drbgSeedLen)
+	want := aesCTR(t, state[:drbgKeyLen], state[drbgKeyLen:], 100)
+
+	got := make([]byte, 100)
+	if _, err := d.Read(got); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Read() = %x, want %x", got, want)
+	}
+
+	// The state is updated after each request.
+	again := make([]byte, 100)
+	if _, err := d.Read(again); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if bytes.Equal(again, got) {
+		t.Errorf("consecutive reads returned the same bytes %x", got)
+	}
+}
+
+func TestDRBGReseed(t *testing.T) {
+	entropy := &countingReader{}
+	d, err := newDRBG(entropy)
+	if err != nil {
+		t.Fatalf("newDRBG failed: %v", err)
+	}
+	if entropy.reads != 1 {
+		t.Fatalf("got %d entropy reads after instantiation, want 1", entropy.reads)
+	}
+
+	var buf [1]byte
+	for i := 0; i < drbgReseedInterval; i++ {
+		if _, err := d.Read(buf[:]); err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+	if entropy.reads != 1 {
+		t.Fatalf("got %d entropy reads after %d requests, want 1", entropy.reads, drbgReseedInterval)
+	}
+	if _, err := d.Read(buf[:]); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if entropy.reads != 2 {
+		t.Errorf("got %d entropy reads after %d requests, want 2", entropy.reads, drbgReseedInterval+1)
+	}
+}
+
+func TestDRBGLargeRead(t *testing.T) {
+	d, err := newDRBG(&countingReader{})
+	if err != nil {
+		t.Fatalf("newDRBG failed: %v", err)
+	}
+	buf := make([]byte, 3*drbgMaxRequest+5)
+	n, err := d.Read(buf)
+	if err != nil || n != len(buf) {
+		t.Fatalf("Read() = %d, %v, want %d, nil", n, err, len(buf))
+	}
+	// Each request is limited to drbgMaxRequest bytes and updates the state,
+	// so the keystream doesn't simply continue across requests.
+	if d.reseedCounter != 5 {
+		t.Errorf("got reseed counter %d after read, want 5", d.reseedCounter)
+	}
+}
+
+func TestPerCPUDRBGConcurrentReads(t *testing.T) {
+	r, err := newPerCPUDRBG(&reader{})
+	if err != nil {
+		t.Fatalf("newPerCPUDRBG failed: %v", err)
+	}
+
+	// Every read is satisfied in full, and no two reads return the same
+	// bytes, even when all DRBGs are in use.
+	const readers = 64
+	bufs := make([][]byte, readers)
+	var wg sync.WaitGroup
+	for i := range bufs {
+		bufs[i] = make([]byte, 4096)
+		wg.Add(1)
+		go func(buf []byte) {
+			defer wg.Done()
+			if n, err := r.Read(buf); err != nil || n != len(buf) {
+				t.Errorf("Read() = %d, %v, want %d, nil", n, err, len(buf))
+			}
+		}(bufs[i])
+	}
+	wg.Wait()
+	seen := make(map[string]struct{})
+	for _, buf := range bufs {
+		if _, ok := seen[string(buf)]; ok {
+			t.Fatalf("two reads returned the same bytes %x", buf[:16])
+		}
+		seen[string(buf)] = struct{}{}
+	}
+}
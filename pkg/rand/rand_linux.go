@@ -17,6 +17,7 @@ package rand
 import (
 	"bufio"
 	"crypto/rand"
+	"fmt"
 	"io"
 
 	"golang.org/x/sys/unix"
@@ -65,9 +66,75 @@ func (b *bufferedReader) Read(p []byte) (int, error) {
 	return io.ReadAtLeast(b.r, p, min)
 }
 
+// fdReader implements an io.Reader that reads from a host file descriptor,
+// e.g. a hardware RNG device.
+type fdReader struct {
+	fd int
+}
+
+// Read implements io.Reader.Read.
+func (r *fdReader) Read(p []byte) (int, error) {
+	for {
+		n, err := unix.Read(r.fd, p)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 && len(p) != 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return n, nil
+	}
+}
+
 // Reader is the default reader.
 var Reader io.Reader = &bufferedReader{r: bufio.NewReader(&reader{})}
 
+// source is the name of the source backing Reader.
+var source = SourceGetrandom
+
+// UseSource replaces the default reader with one backed by the source name, or
+// SourceGetrandom if name is empty. hwrngFD is the host file descriptor of the
+// hardware RNG for SourceHWRNG, and is ignored otherwise.
+//
+// UseSource must be called before the default reader is used concurrently.
+func UseSource(name string, hwrngFD int) error {
+	var r io.Reader
+	switch name {
+	case "", SourceGetrandom:
+		name = SourceGetrandom
+		r = &reader{}
+	case SourceDRBG:
+		d, err := newPerCPUDRBG(&reader{})
+		if err != nil {
+			return err
+		}
+		// The DRBGs always satisfy reads in full and don't make a syscall per
+		// read, so they don't need to be buffered; buffering would instead
+		// serialize all readers on bufferedReader.mu.
+		Reader = d
+		source = name
+		return nil
+	case SourceHWRNG:
+		if hwrngFD < 0 {
+			return fmt.Errorf("entropy source %q requires a hardware RNG file descriptor", name)
+		}
+		r = &fdReader{fd: hwrngFD}
+	default:
+		return fmt.Errorf("invalid entropy source %q", name)
+	}
+	Reader = &bufferedReader{r: bufio.NewReader(r)}
+	source = name
+	return nil
+}
+
+// Source returns the name of the source backing the default reader.
+func Source() string {
+	return source
+}
+
 // Read reads from the default reader.
 func Read(b []byte) (int, error) {
 	return io.ReadFull(Reader, b)
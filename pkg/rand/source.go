@@ -0,0 +1,30 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rand
+
+// Sources of randomness that can back the default reader.
+const (
+	// SourceGetrandom reads from the host's getrandom(2), falling back to
+	// crypto/rand on hosts that don't support it. This is the default.
+	SourceGetrandom = "getrandom"
+
+	// SourceDRBG uses per-CPU AES-256 CTR_DRBGs (NIST SP 800-90A) that are
+	// seeded and periodically reseeded from SourceGetrandom. Unlike the other
+	// sources, concurrent readers are not serialized on a single lock.
+	SourceDRBG = "drbg"
+
+	// SourceHWRNG reads from a host hardware RNG device, e.g. /dev/hwrng.
+	SourceHWRNG = "hwrng"
+)
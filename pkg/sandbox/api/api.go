@@ -0,0 +1,90 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package api defines the types and method names of the sandbox control API
+// that are shared between the sandbox and its clients.
+//
+// This package must not depend on the sentry or on runsc, so that clients can
+// use the control API without linking them in.
+package api
+
+import "fmt"
+
+// Control API version. The major version is bumped whenever an RPC is removed
+// or its arguments or results change incompatibly; the minor version is bumped
+// when RPCs or optional fields are added. Clients must refuse to talk to a
+// sandbox with a different major version.
+const (
+	ControlAPIMajor = 1
+	ControlAPIMinor = 9
+)
+
+// APIVersion is the result of the ContMgrAPIVersion RPC.
+type APIVersion struct {
+	Major uint32 `json:"major"`
+	Minor uint32 `json:"minor"`
+}
+
+// String implements fmt.Stringer.
+func (v APIVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Container manager methods that are part of the public control API.
+const (
+	// ContMgrAPIVersion returns the version of the control API implemented by
+	// the sandbox.
+	ContMgrAPIVersion = "containerManager.APIVersion"
+
+	// ContMgrCheckpoint checkpoints a container.
+	ContMgrCheckpoint = "containerManager.Checkpoint"
+
+	// ContMgrContainerIOStats returns the I/O statistics of a container, across
+	// all of its mounts.
+	ContMgrContainerIOStats = "containerManager.ContainerIOStats"
+
+	// ContMgrEvent gets stats about the container used by "runsc events".
+	ContMgrEvent = "containerManager.Event"
+
+	// ContMgrExecuteAsync executes a command in a container.
+	ContMgrExecuteAsync = "containerManager.ExecuteAsync"
+
+	// ContMgrMountIOStats returns per-mount I/O statistics of a container.
+	ContMgrMountIOStats = "containerManager.MountIOStats"
+
+	// ContMgrProcesses lists processes running in a container.
+	ContMgrProcesses = "containerManager.Processes"
+
+	// ContMgrWaitPID waits on a process with a certain PID in the sandbox and
+	// return its ExitStatus.
+	ContMgrWaitPID = "containerManager.WaitPID"
+)
+
+// Profiling related commands.
+const (
+	ProfileCPU   = "Profile.CPU"
+	ProfileHeap  = "Profile.Heap"
+	ProfileBlock = "Profile.Block"
+	ProfileMutex = "Profile.Mutex"
+	ProfileTrace = "Profile.Trace"
+)
+
+// WaitPIDArgs are arguments to the WaitPID method.
+type WaitPIDArgs struct {
+	// PID is the PID in the container's PID namespace.
+	PID int32
+
+	// CID is the container ID.
+	CID string
+}
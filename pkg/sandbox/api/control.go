@@ -0,0 +1,164 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"time"
+
+	"gvisor.dev/gvisor/pkg/urpc"
+)
+
+// ExecArgs are the arguments of the ContMgrExecuteAsync RPC. The sandbox
+// converts them to the sentry's own arguments, so their encoding must not
+// change incompatibly.
+type ExecArgs struct {
+	// Filename is the filename to load. If it is empty, it is guessed from
+	// Argv[0].
+	Filename string `json:"filename"`
+
+	// Argv is a list of arguments.
+	Argv []string `json:"argv"`
+
+	// Envv is a list of environment variables.
+	Envv []string `json:"envv"`
+
+	// WorkingDirectory defines the working directory for the new process.
+	WorkingDirectory string `json:"wd"`
+
+	// KUID is the UID to run with in the root user namespace.
+	KUID uint32
+
+	// KGID is the GID to run with in the root user namespace.
+	KGID uint32
+
+	// ExtraKGIDs is the list of additional groups to which the user belongs.
+	ExtraKGIDs []uint32
+
+	// Capabilities is the list of capabilities to give to the process. If it
+	// is nil, the process gets the capabilities of KUID.
+	Capabilities *TaskCapabilities
+
+	// NoNewPrivs sets the no_new_privs bit of the process.
+	NoNewPrivs bool
+
+	// StdioIsPty indicates that FDs 0, 1, and 2 are connected to a host pty FD.
+	StdioIsPty bool
+
+	// FilePayload holds the files to give to the new process, followed by the
+	// file to execute if the process is executed from a host file.
+	urpc.FilePayload
+
+	// GuestFDs are the file descriptors of the files in FilePayload in the
+	// new process.
+	GuestFDs []int
+
+	// ContainerID is the container for the process being executed.
+	ContainerID string
+}
+
+// TaskCapabilities are the capability sets of a process, as bitmasks of
+// capability numbers.
+type TaskCapabilities struct {
+	PermittedCaps   uint64
+	InheritableCaps uint64
+	EffectiveCaps   uint64
+	BoundingCaps    uint64
+	AmbientCaps     uint64
+}
+
+// SaveOpts are the arguments of the ContMgrCheckpoint RPC.
+type SaveOpts struct {
+	// Key is used for state integrity check.
+	Key []byte `json:"key"`
+
+	// Metadata is the set of metadata to prepend to the state file.
+	Metadata map[string]string `json:"metadata"`
+
+	// MemoryFileSaveOpts controls how application memory is saved.
+	MemoryFileSaveOpts MemoryFileSaveOpts
+
+	// HavePagesFile indicates whether the pages file and its corresponding
+	// metadata file are provided.
+	HavePagesFile bool `json:"have_pages_file"`
+
+	// FilePayload contains the state file, followed by the pages metadata
+	// file and the pages file if HavePagesFile is true.
+	urpc.FilePayload
+
+	// Resume indicates if the sandbox process should continue running after
+	// checkpointing.
+	Resume bool
+
+	// SaveRestoreExecArgv is the argv of the save/restore binary split by
+	// spaces. The first element is the path to the binary.
+	SaveRestoreExecArgv string
+
+	// SaveRestoreExecTimeout is the timeout for waiting for the save/restore
+	// binary.
+	SaveRestoreExecTimeout time.Duration
+
+	// SaveRestoreExecContainerID is the ID of the container that the
+	// save/restore binary executes in.
+	SaveRestoreExecContainerID string
+}
+
+// MemoryFileSaveOpts controls how application memory is saved by
+// ContMgrCheckpoint.
+type MemoryFileSaveOpts struct {
+	// ExcludeCommittedZeroPages makes the sandbox scan all committed pages for
+	// zero pages, which are not saved explicitly.
+	ExcludeCommittedZeroPages bool
+
+	// PageWriters is the maximum number of goroutines writing pages
+	// concurrently, or 0 for GOMAXPROCS.
+	PageWriters int
+
+	// PageChecksums makes the sandbox save a checksum of each page to the
+	// pages file, which is verified on restore.
+	PageChecksums bool
+}
+
+// Process is a process listed by the ContMgrProcesses RPC.
+type Process struct {
+	UID uint32 `json:"uid"`
+	PID int32  `json:"pid"`
+	// Parent PID
+	PPID    int32   `json:"ppid"`
+	Threads []int32 `json:"threads"`
+	// Processor utilization
+	C int32 `json:"c"`
+	// TTY name of the process. Will be of the form "pts/N" if there is a
+	// TTY, or "?" if there is not.
+	TTY string `json:"tty"`
+	// Start time
+	STime string `json:"stime"`
+	// CPU time
+	Time string `json:"time"`
+	// Executable shortname (e.g. "sh" for /bin/sh)
+	Cmd string `json:"cmd"`
+}
+
+// ProfileOpts are the arguments of the Profile* RPCs.
+type ProfileOpts struct {
+	// FilePayload is the destination for the profiling output.
+	urpc.FilePayload
+
+	// Duration is the duration of the profile. It is ignored by ProfileHeap.
+	Duration time.Duration `json:"duration"`
+
+	// Delay is the time to wait before writing a heap profile. It is only
+	// used by ProfileHeap.
+	Delay time.Duration `json:"delay"`
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+// IOStats contains the I/O statistics of a mount or a container.
+type IOStats struct {
+	// ReadOps is the number of read operations.
+	ReadOps uint64 `json:"read_ops"`
+
+	// ReadBytes is the number of bytes read.
+	ReadBytes uint64 `json:"read_bytes"`
+
+	// ReadNanos is the total time spent in read operations.
+	ReadNanos uint64 `json:"read_nanos"`
+
+	// WriteOps is the number of write operations.
+	WriteOps uint64 `json:"write_ops"`
+
+	// WriteBytes is the number of bytes written.
+	WriteBytes uint64 `json:"write_bytes"`
+
+	// WriteNanos is the total time spent in write operations.
+	WriteNanos uint64 `json:"write_nanos"`
+}
+
+// CacheStats contains the cache statistics of a mount.
+type CacheStats struct {
+	// DentryHits is the number of path component lookups that were resolved
+	// from the dentry cache, including cached negative lookups.
+	DentryHits uint64 `json:"dentry_hits"`
+
+	// DentryMisses is the number of path component lookups that required
+	// querying the backing filesystem.
+	DentryMisses uint64 `json:"dentry_misses"`
+
+	// PageHitBytes is the number of bytes read from the page cache.
+	PageHitBytes uint64 `json:"page_hit_bytes"`
+
+	// PageMissBytes is the number of bytes that were not in the page cache
+	// when read, and had to be read from the backing filesystem.
+	PageMissBytes uint64 `json:"page_miss_bytes"`
+}
+
+// MountIOStats is the result of the ContMgrMountIOStats RPC for a single
+// mount.
+type MountIOStats struct {
+	// ID is the mount ID, as shown in /proc/[pid]/mountinfo.
+	ID uint64 `json:"id"`
+
+	// Path is the path of the mount point relative to the mount namespace
+	// root.
+	Path string `json:"path"`
+
+	// FSType is the filesystem type name.
+	FSType string `json:"fstype"`
+
+	IOStats
+
+	// Cache contains the cache statistics of the mounted filesystem. It is
+	// nil if the filesystem doesn't report cache statistics.
+	Cache *CacheStats `json:"cache,omitempty"`
+}
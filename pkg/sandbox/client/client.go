@@ -0,0 +1,238 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package client is a Go client for the sandbox control API.
+//
+// It allows sandboxes to be managed (events, profiling, checkpoint and exec)
+// over the sandbox control socket without shelling out to runsc. Dial
+// negotiates the control API version with the sandbox and fails if the
+// sandbox serves an incompatible major version.
+package client
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	controlclient "gvisor.dev/gvisor/pkg/control/client"
+	"gvisor.dev/gvisor/pkg/sandbox/api"
+	"gvisor.dev/gvisor/pkg/urpc"
+)
+
+// Version is the control API version implemented by this package.
+var Version = api.APIVersion{Major: api.ControlAPIMajor, Minor: api.ControlAPIMinor}
+
+// ErrIncompatibleVersion is returned by Dial when the sandbox serves a control
+// API version that this package cannot talk to.
+var ErrIncompatibleVersion = errors.New("incompatible control API version")
+
+// SocketPath returns the path of the control socket of sandbox id created by
+// runsc under rootDir.
+func SocketPath(rootDir, id string) string {
+	return filepath.Join(rootDir, fmt.Sprintf("runsc-%s.sock", id))
+}
+
+// Client is a connection to a sandbox control server.
+//
+// Calls on a Client are serialized; callers that issue long-running calls
+// (e.g. profiles or WaitPID) concurrently should Dial a Client per caller.
+type Client struct {
+	conn    *urpc.Client
+	version api.APIVersion
+}
+
+// Dial connects to the sandbox control socket at addr and negotiates the
+// control API version.
+func Dial(addr string) (*Client, error) {
+	return DialWithToken(addr, "")
+}
+
+// DialWithToken is like Dial, but presents token with each call. Sandboxes
+// started with a control policy only serve clients whose token the policy
+// allows (see runsc's --control-policy flag).
+func DialWithToken(addr, token string) (*Client, error) {
+	if len(addr) >= linux.UnixPathMax {
+		// connect(2) fails when the socket path is too long. Open the socket
+		// with O_PATH and refer to it through /proc instead.
+		fd, err := unix.Open(addr, unix.O_PATH, 0)
+		if err != nil {
+			return nil, fmt.Errorf("opening control socket %q: %w", addr, err)
+		}
+		defer unix.Close(fd)
+		addr = fmt.Sprintf("/proc/self/fd/%d", fd)
+	}
+	conn, err := controlclient.ConnectTo(addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to control socket %q: %w", addr, err)
+	}
+	conn.Token = token
+	c := &Client{conn: conn}
+	if err := c.negotiate(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) negotiate() error {
+	if err := c.conn.Call(api.ContMgrAPIVersion, &struct{}{}, &c.version); err != nil {
+		var rerr urpc.RemoteError
+		if errors.As(err, &rerr) && rerr.Message == urpc.ErrUnknownMethod.Error() {
+			// Sandboxes that predate versioning implement the RPCs as
+			// they were when version 1.0 was defined.
+			c.version = api.APIVersion{Major: 1}
+			return nil
+		}
+		return fmt.Errorf("querying control API version: %w", err)
+	}
+	if c.version.Major != Version.Major {
+		return fmt.Errorf("%w: sandbox serves %s, client implements %s", ErrIncompatibleVersion, c.version, Version)
+	}
+	return nil
+}
+
+// Close closes the connection to the sandbox.
+func (c *Client) Close() {
+	c.conn.Close()
+}
+
+// Version returns the control API version served by the sandbox.
+func (c *Client) Version() api.APIVersion {
+	return c.version
+}
+
+// Event returns resource usage statistics of container cid.
+func (c *Client) Event(cid string) (*api.EventOut, error) {
+	var e api.EventOut
+	if err := c.conn.Call(api.ContMgrEvent, &cid, &e); err != nil {
+		return nil, fmt.Errorf("retrieving event data for container %q: %w", cid, err)
+	}
+	return &e, nil
+}
+
+// Processes lists the processes running in container cid.
+func (c *Client) Processes(cid string) ([]*api.Process, error) {
+	var pl []*api.Process
+	if err := c.conn.Call(api.ContMgrProcesses, &cid, &pl); err != nil {
+		return nil, fmt.Errorf("listing processes of container %q: %w", cid, err)
+	}
+	return pl, nil
+}
+
+// MountIOStats returns the I/O statistics of each mount of container cid.
+// It requires control API version 1.1 or newer.
+func (c *Client) MountIOStats(cid string) ([]api.MountIOStats, error) {
+	if c.version.Minor < 1 {
+		return nil, fmt.Errorf("mount I/O statistics require control API 1.1, sandbox serves %s", c.version)
+	}
+	var stats []api.MountIOStats
+	if err := c.conn.Call(api.ContMgrMountIOStats, &cid, &stats); err != nil {
+		return nil, fmt.Errorf("retrieving mount I/O statistics of container %q: %w", cid, err)
+	}
+	return stats, nil
+}
+
+// ContainerIOStats returns the I/O statistics of container cid, across all of
+// its mounts. It requires control API version 1.9 or newer. The statistics are
+// only recorded if the sandbox runs with --io-stats.
+func (c *Client) ContainerIOStats(cid string) (api.IOStats, error) {
+	if c.version.Minor < 9 {
+		return api.IOStats{}, fmt.Errorf("container I/O statistics require control API 1.9, sandbox serves %s", c.version)
+	}
+	var stats api.IOStats
+	if err := c.conn.Call(api.ContMgrContainerIOStats, &cid, &stats); err != nil {
+		return api.IOStats{}, fmt.Errorf("retrieving I/O statistics of container %q: %w", cid, err)
+	}
+	return stats, nil
+}
+
+// Exec starts a new process described by args and returns its PID. The
+// process is not waited for, use WaitPID to collect its exit status.
+func (c *Client) Exec(args *api.ExecArgs) (int32, error) {
+	var pid int32
+	if err := c.conn.Call(api.ContMgrExecuteAsync, args, &pid); err != nil {
+		return 0, fmt.Errorf("executing %q in container %q: %w", args.Argv, args.ContainerID, err)
+	}
+	return pid, nil
+}
+
+// WaitPID waits for process pid in container cid to exit and returns its wait
+// status.
+func (c *Client) WaitPID(cid string, pid int32) (unix.WaitStatus, error) {
+	var ws uint32
+	args := api.WaitPIDArgs{PID: pid, CID: cid}
+	if err := c.conn.Call(api.ContMgrWaitPID, &args, &ws); err != nil {
+		return 0, fmt.Errorf("waiting for PID %d in container %q: %w", pid, cid, err)
+	}
+	return unix.WaitStatus(ws), nil
+}
+
+// Checkpoint saves the sandbox state as described by opts. opts.FilePayload
+// must contain the state file, optionally followed by the page metadata and
+// pages files.
+func (c *Client) Checkpoint(opts *api.SaveOpts) error {
+	if err := c.conn.Call(api.ContMgrCheckpoint, opts, nil); err != nil {
+		return fmt.Errorf("checkpointing sandbox: %w", err)
+	}
+	return nil
+}
+
+// CPUProfile writes a CPU profile collected over duration to f.
+func (c *Client) CPUProfile(f *os.File, duration time.Duration) error {
+	opts := api.ProfileOpts{
+		FilePayload: urpc.FilePayload{Files: []*os.File{f}},
+		Duration:    duration,
+	}
+	return c.conn.Call(api.ProfileCPU, &opts, nil)
+}
+
+// HeapProfile writes a heap profile to f after delay.
+func (c *Client) HeapProfile(f *os.File, delay time.Duration) error {
+	opts := api.ProfileOpts{
+		FilePayload: urpc.FilePayload{Files: []*os.File{f}},
+		Delay:       delay,
+	}
+	return c.conn.Call(api.ProfileHeap, &opts, nil)
+}
+
+// BlockProfile writes a block profile collected over duration to f.
+func (c *Client) BlockProfile(f *os.File, duration time.Duration) error {
+	opts := api.ProfileOpts{
+		FilePayload: urpc.FilePayload{Files: []*os.File{f}},
+		Duration:    duration,
+	}
+	return c.conn.Call(api.ProfileBlock, &opts, nil)
+}
+
+// MutexProfile writes a mutex profile collected over duration to f.
+func (c *Client) MutexProfile(f *os.File, duration time.Duration) error {
+	opts := api.ProfileOpts{
+		FilePayload: urpc.FilePayload{Files: []*os.File{f}},
+		Duration:    duration,
+	}
+	return c.conn.Call(api.ProfileMutex, &opts, nil)
+}
+
+// Trace writes an execution trace collected over duration to f.
+func (c *Client) Trace(f *os.File, duration time.Duration) error {
+	opts := api.ProfileOpts{
+		FilePayload: urpc.FilePayload{Files: []*os.File{f}},
+		Duration:    duration,
+	}
+	return c.conn.Call(api.ProfileTrace, &opts, nil)
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/control/server"
+	"gvisor.dev/gvisor/pkg/sandbox/api"
+)
+
+// containerManager mimics the RPC object registered by the sandbox. It must
+// have the same type name for urpc to route the calls to it.
+type containerManager struct {
+	// version is the version returned by APIVersion.
+	version *api.APIVersion
+}
+
+func (cm *containerManager) APIVersion(_ *struct{}, out *api.APIVersion) error {
+	*out = *cm.version
+	return nil
+}
+
+func (cm *containerManager) Event(cid *string, out *api.EventOut) error {
+	out.Event = api.Event{Type: "stats", ID: *cid}
+	return nil
+}
+
+// legacyManager is registered in place of containerManager to emulate
+// sandboxes that predate API versioning.
+type legacyManager struct{}
+
+func startServer(t *testing.T, obj any) string {
+	t.Helper()
+	addr := filepath.Join(t.TempDir(), "control.sock")
+	srv, err := server.Create(addr)
+	if err != nil {
+		t.Fatalf("server.Create(%q): %v", addr, err)
+	}
+	srv.Register(obj)
+	if err := srv.StartServing(); err != nil {
+		t.Fatalf("StartServing(): %v", err)
+	}
+	t.Cleanup(func() { srv.Stop(0) })
+	return addr
+}
+
+func TestDial(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		version api.APIVersion
+		wantErr error
+	}{
+		{
+			name:    "same",
+			version: Version,
+		},
+		{
+			name:    "newer-minor",
+			version: api.APIVersion{Major: Version.Major, Minor: Version.Minor + 1},
+		},
+		{
+			name:    "newer-major",
+			version: api.APIVersion{Major: Version.Major + 1},
+			wantErr: ErrIncompatibleVersion,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			addr := startServer(t, &containerManager{version: &tc.version})
+			c, err := Dial(addr)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Dial(%q) error: %v, want: %v", addr, err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer c.Close()
+			if got := c.Version(); got != tc.version {
+				t.Errorf("Version() = %s, want: %s", got, tc.version)
+			}
+			e, err := c.Event("foo")
+			if err != nil {
+				t.Fatalf("Event(): %v", err)
+			}
+			if e.Event.ID != "foo" {
+				t.Errorf("Event().Event.ID = %q, want: %q", e.Event.ID, "foo")
+			}
+		})
+	}
+}
+
+func TestDialUnversioned(t *testing.T) {
+	addr := startServer(t, &legacyManager{})
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial(%q): %v", addr, err)
+	}
+	defer c.Close()
+	if want := (api.APIVersion{Major: 1}); c.Version() != want {
+		t.Errorf("Version() = %s, want: %s", c.Version(), want)
+	}
+}
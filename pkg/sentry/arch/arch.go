@@ -36,6 +36,8 @@ const (
 	AMD64 Arch = iota
 	// ARM64 is the aarch64 architecture.
 	ARM64
+	// RISCV64 is the 64-bit RISC-V architecture.
+	RISCV64
 )
 
 // String implements fmt.Stringer.
@@ -45,6 +47,8 @@ func (a Arch) String() string {
 		return "amd64"
 	case ARM64:
 		return "arm64"
+	case RISCV64:
+		return "riscv64"
 	default:
 		return fmt.Sprintf("Arch(%d)", a)
 	}
@@ -187,13 +191,18 @@ type contextInterface interface {
 
 	// NewMmapLayout returns a layout for a new MM, where MinAddr for the
 	// returned layout must be no lower than min, and MaxAddr for the returned
-	// layout must be no higher than max. Repeated calls to NewMmapLayout may
-	// return different layouts.
-	NewMmapLayout(min, max hostarch.Addr, limits *limits.LimitSet) (MmapLayout, error)
+	// layout must be no higher than max. If randomize is true, repeated calls
+	// to NewMmapLayout may return different layouts.
+	NewMmapLayout(min, max hostarch.Addr, limits *limits.LimitSet, randomize bool) (MmapLayout, error)
 
 	// PIELoadAddress returns a preferred load address for a
-	// position-independent executable within l.
-	PIELoadAddress(l MmapLayout) hostarch.Addr
+	// position-independent executable within l. The address is randomized if
+	// randomize is true.
+	PIELoadAddress(l MmapLayout, randomize bool) hostarch.Addr
+
+	// RandomizeBrk returns a random start address for a heap that would
+	// otherwise start at brk.
+	RandomizeBrk(brk hostarch.Addr) hostarch.Addr
 
 	// Hack around our package dependences being too broken to support the
 	// equivalent of arch_ptrace():
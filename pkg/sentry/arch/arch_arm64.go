@@ -46,6 +46,10 @@ const (
 	// layout. It is defined by arch/arm64/mm/mmap.c:arch_mmap_rnd in Linux.
 	maxMmapRand64 = (1 << 33) * hostarch.PageSize
 
+	// maxBrkRand64 is the maximum randomization to apply to the start of the
+	// heap. It is defined by arch/arm64/kernel/process.c:arch_randomize_brk in Linux.
+	maxBrkRand64 = 1 << 30 // 1 GB
+
 	// minGap64 is the minimum gap to leave at the top of the address space
 	// for the stack. It is defined by arch/arm64/mm/mmap.c:MIN_GAP in Linux.
 	minGap64 = (128 << 20) + maxStackRand64
@@ -181,13 +185,26 @@ func (c *Context64) Width() uint {
 	return 8
 }
 
+// RandomizeBrk implements Context.RandomizeBrk consistently with Linux's
+// arch_randomize_brk().
+func (c *Context64) RandomizeBrk(brk hostarch.Addr) hostarch.Addr {
+	maxRand := hostarch.Addr(maxBrkRand64)
+	if brk > maxAddr64-maxRand {
+		maxRand = maxAddr64 - brk
+	}
+	if maxRand < hostarch.PageSize {
+		return brk
+	}
+	return brk + mmapRand(uint64(maxRand))
+}
+
 // mmapRand returns a random adjustment for randomizing an mmap layout.
 func mmapRand(max uint64) hostarch.Addr {
 	return hostarch.Addr(rand.Int63n(int64(max))).RoundDown()
 }
 
 // NewMmapLayout implements Context.NewMmapLayout consistently with Linux.
-func (c *Context64) NewMmapLayout(min, max hostarch.Addr, r *limits.LimitSet) (MmapLayout, error) {
+func (c *Context64) NewMmapLayout(min, max hostarch.Addr, r *limits.LimitSet, randomize bool) (MmapLayout, error) {
 	min, ok := min.RoundUp()
 	if !ok {
 		return MmapLayout{}, unix.EINVAL
@@ -229,7 +246,12 @@ func (c *Context64) NewMmapLayout(min, max hostarch.Addr, r *limits.LimitSet) (M
 		}
 	}
 
-	rnd := mmapRand(uint64(maxRand))
+	var rnd hostarch.Addr
+	if randomize {
+		rnd = mmapRand(uint64(maxRand))
+	} else {
+		maxRand = 0
+	}
 	l := MmapLayout{
 		MinAddr: min,
 		MaxAddr: max,
@@ -253,7 +275,7 @@ func (c *Context64) NewMmapLayout(min, max hostarch.Addr, r *limits.LimitSet) (M
 }
 
 // PIELoadAddress implements Context.PIELoadAddress.
-func (c *Context64) PIELoadAddress(l MmapLayout) hostarch.Addr {
+func (c *Context64) PIELoadAddress(l MmapLayout, randomize bool) hostarch.Addr {
 	base := preferredPIELoadAddr
 	max, ok := base.AddLength(maxMmapRand64)
 	if !ok {
@@ -268,6 +290,9 @@ func (c *Context64) PIELoadAddress(l MmapLayout) hostarch.Addr {
 		base = l.TopDownBase / 3 * 2
 	}
 
+	if !randomize {
+		return base
+	}
 	return base + mmapRand(maxMmapRand64)
 }
 
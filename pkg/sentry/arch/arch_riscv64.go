@@ -0,0 +1,314 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build riscv64
+// +build riscv64
+
+package arch
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/marshal"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/rand"
+	"gvisor.dev/gvisor/pkg/sentry/arch/fpu"
+	"gvisor.dev/gvisor/pkg/sentry/limits"
+)
+
+// Host specifies the host architecture.
+const Host = RISCV64
+
+// These constants come directly from Linux.
+const (
+	// maxAddr64 is the maximum userspace address. It is TASK_SIZE in Linux
+	// for a 64-bit process using Sv48 paging.
+	maxAddr64 hostarch.Addr = (1 << 47)
+
+	// maxStackRand64 is the maximum randomization to apply to the stack.
+	// It is defined by arch/riscv/include/asm/elf.h:(STACK_RND_MASK <<
+	// PAGE_SHIFT) in Linux.
+	maxStackRand64 = 0x3ffff << 12 // 1 GB
+
+	// maxMmapRand64 is the maximum randomization to apply to the mmap
+	// layout. It is defined by CONFIG_ARCH_MMAP_RND_BITS_MAX in Linux.
+	maxMmapRand64 = (1 << 24) * hostarch.PageSize
+
+	// maxBrkRand64 is the maximum randomization to apply to the start of the
+	// heap. It is defined by mm/util.c:arch_randomize_brk in Linux.
+	maxBrkRand64 = 1 << 30 // 1 GB
+
+	// minGap64 is the minimum gap to leave at the top of the address space
+	// for the stack. It is defined by mm/util.c:MIN_GAP in Linux.
+	minGap64 = (128 << 20) + maxStackRand64
+
+	// preferredPIELoadAddr is the standard Linux position-independent
+	// executable base load address. It is ELF_ET_DYN_BASE in Linux.
+	//
+	// The Platform {Min,Max}UserAddress() may preclude loading at this
+	// address. See other preferredFoo comments below.
+	preferredPIELoadAddr hostarch.Addr = maxAddr64 / 3 * 2
+)
+
+var (
+	// CPUIDInstruction doesn't exist on RISCV64.
+	CPUIDInstruction = []byte{}
+)
+
+// These constants are selected as heuristics to help make the Platform's
+// potentially limited address space conform as closely to Linux as possible.
+const (
+	preferredTopDownAllocMin hostarch.Addr = 0x3e8000000000
+	preferredAllocationGap                 = 128 << 30 // 128 GB
+	preferredTopDownBaseMin                = preferredTopDownAllocMin + preferredAllocationGap
+
+	// minMmapRand64 is the smallest we are willing to make the
+	// randomization to stay above preferredTopDownBaseMin.
+	minMmapRand64 = (1 << 18) * hostarch.PageSize
+)
+
+// Context64 represents a RISCV64 context.
+//
+// +stateify savable
+type Context64 struct {
+	State
+	sigFPState []fpu.State // fpstate to be restored on sigreturn.
+}
+
+// Arch implements Context.Arch.
+func (c *Context64) Arch() Arch {
+	return RISCV64
+}
+
+func (c *Context64) copySigFPState() []fpu.State {
+	var sigfps []fpu.State
+	for _, s := range c.sigFPState {
+		sigfps = append(sigfps, s.Fork())
+	}
+	return sigfps
+}
+
+// Fork returns an exact copy of this context.
+func (c *Context64) Fork() *Context64 {
+	return &Context64{
+		State:      c.State.Fork(),
+		sigFPState: c.copySigFPState(),
+	}
+}
+
+// Integer registers usage on RISCV64:
+// a0...a7: argument registers; a0 and a1 also hold return values.
+// ra: the return address.
+// sp: the stack pointer.
+// gp: the global pointer.
+// tp: the thread pointer.
+// t0...t6: temporary registers.
+// s0...s11: callee-saved registers; s0 is also the frame pointer.
+
+// Return returns the current syscall return value.
+func (c *Context64) Return() uintptr {
+	return uintptr(c.Regs.A0)
+}
+
+// SetReturn sets the syscall return value.
+func (c *Context64) SetReturn(value uintptr) {
+	c.Regs.A0 = uint64(value)
+}
+
+// IP returns the current instruction pointer.
+func (c *Context64) IP() uintptr {
+	return uintptr(c.Regs.Pc)
+}
+
+// SetIP sets the current instruction pointer.
+func (c *Context64) SetIP(value uintptr) {
+	c.Regs.Pc = uint64(value)
+}
+
+// Stack returns the current stack pointer.
+func (c *Context64) Stack() uintptr {
+	return uintptr(c.Regs.Sp)
+}
+
+// SetStack sets the current stack pointer.
+func (c *Context64) SetStack(value uintptr) {
+	c.Regs.Sp = uint64(value)
+}
+
+// TLS returns the current TLS pointer.
+func (c *Context64) TLS() uintptr {
+	return uintptr(c.Regs.Tp)
+}
+
+// SetTLS sets the current TLS pointer. Returns false if value is invalid.
+func (c *Context64) SetTLS(value uintptr) bool {
+	if value >= uintptr(maxAddr64) {
+		return false
+	}
+
+	c.Regs.Tp = uint64(value)
+	return true
+}
+
+// SetOldRSeqInterruptedIP implements Context.SetOldRSeqInterruptedIP.
+func (c *Context64) SetOldRSeqInterruptedIP(value uintptr) {
+	c.Regs.A3 = uint64(value)
+}
+
+// Native returns the native type for the given val.
+func (c *Context64) Native(val uintptr) marshal.Marshallable {
+	v := primitive.Uint64(val)
+	return &v
+}
+
+// Value returns the generic val for the given native type.
+func (c *Context64) Value(val marshal.Marshallable) uintptr {
+	return uintptr(*val.(*primitive.Uint64))
+}
+
+// Width returns the byte width of this architecture.
+func (c *Context64) Width() uint {
+	return 8
+}
+
+// RandomizeBrk implements Context.RandomizeBrk consistently with Linux's
+// arch_randomize_brk().
+func (c *Context64) RandomizeBrk(brk hostarch.Addr) hostarch.Addr {
+	maxRand := hostarch.Addr(maxBrkRand64)
+	if brk > maxAddr64-maxRand {
+		maxRand = maxAddr64 - brk
+	}
+	if maxRand < hostarch.PageSize {
+		return brk
+	}
+	return brk + mmapRand(uint64(maxRand))
+}
+
+// mmapRand returns a random adjustment for randomizing an mmap layout.
+func mmapRand(max uint64) hostarch.Addr {
+	return hostarch.Addr(rand.Int63n(int64(max))).RoundDown()
+}
+
+// NewMmapLayout implements Context.NewMmapLayout consistently with Linux.
+func (c *Context64) NewMmapLayout(min, max hostarch.Addr, r *limits.LimitSet, randomize bool) (MmapLayout, error) {
+	min, ok := min.RoundUp()
+	if !ok {
+		return MmapLayout{}, unix.EINVAL
+	}
+	if max > maxAddr64 {
+		max = maxAddr64
+	}
+	max = max.RoundDown()
+
+	if min > max {
+		return MmapLayout{}, unix.EINVAL
+	}
+
+	stackSize := r.Get(limits.Stack)
+
+	// MAX_GAP in Linux.
+	maxGap := (max / 6) * 5
+	gap := hostarch.Addr(stackSize.Cur)
+	if gap < minGap64 {
+		gap = minGap64
+	}
+	if gap > maxGap {
+		gap = maxGap
+	}
+	defaultDir := MmapTopDown
+	if stackSize.Cur == limits.Infinity {
+		defaultDir = MmapBottomUp
+	}
+
+	topDownMin := max - gap - maxMmapRand64
+	maxRand := hostarch.Addr(maxMmapRand64)
+	if topDownMin < preferredTopDownBaseMin {
+		// Try to keep TopDownBase above preferredTopDownBaseMin by
+		// shrinking maxRand.
+		maxAdjust := maxRand - minMmapRand64
+		needAdjust := preferredTopDownBaseMin - topDownMin
+		if needAdjust <= maxAdjust {
+			maxRand -= needAdjust
+		}
+	}
+
+	var rnd hostarch.Addr
+	if randomize {
+		rnd = mmapRand(uint64(maxRand))
+	} else {
+		maxRand = 0
+	}
+	l := MmapLayout{
+		MinAddr: min,
+		MaxAddr: max,
+		// TASK_UNMAPPED_BASE in Linux.
+		BottomUpBase:     (max/3 + rnd).RoundDown(),
+		TopDownBase:      (max - gap - rnd).RoundDown(),
+		DefaultDirection: defaultDir,
+		// We may have reduced the maximum randomization to keep
+		// TopDownBase above preferredTopDownBaseMin while maintaining
+		// our stack gap. Stack allocations must use that max
+		// randomization to avoiding eating into the gap.
+		MaxStackRand: uint64(maxRand),
+	}
+
+	// Final sanity check on the layout.
+	if !l.Valid() {
+		panic(fmt.Sprintf("Invalid MmapLayout: %+v", l))
+	}
+
+	return l, nil
+}
+
+// PIELoadAddress implements Context.PIELoadAddress.
+func (c *Context64) PIELoadAddress(l MmapLayout, randomize bool) hostarch.Addr {
+	base := preferredPIELoadAddr
+	max, ok := base.AddLength(maxMmapRand64)
+	if !ok {
+		panic(fmt.Sprintf("preferredPIELoadAddr %#x too large", base))
+	}
+
+	if max > l.MaxAddr {
+		// preferredPIELoadAddr won't fit; fall back to the standard
+		// Linux behavior of 2/3 of TopDownBase. TSAN won't like this.
+		//
+		// Don't bother trying to shrink the randomization for now.
+		base = l.TopDownBase / 3 * 2
+	}
+
+	if !randomize {
+		return base
+	}
+	return base + mmapRand(maxMmapRand64)
+}
+
+// PtracePeekUser implements Context.PtracePeekUser.
+func (c *Context64) PtracePeekUser(addr uintptr) (marshal.Marshallable, error) {
+	// Linux doesn't support PTRACE_PEEKUSR on riscv64.
+	return nil, linuxerr.EIO
+}
+
+// PtracePokeUser implements Context.PtracePokeUser.
+func (c *Context64) PtracePokeUser(addr, data uintptr) error {
+	// Linux doesn't support PTRACE_POKEUSR on riscv64.
+	return linuxerr.EIO
+}
+
+// FloatingPointData returns the state of the floating-point unit.
+func (c *Context64) FloatingPointData() *fpu.State {
+	return &c.State.fpState
+}
@@ -0,0 +1,252 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build riscv64
+// +build riscv64
+
+package arch
+
+import (
+	"fmt"
+	"io"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/cpuid"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/sentry/arch/fpu"
+	rpb "gvisor.dev/gvisor/pkg/sentry/arch/registers_go_proto"
+)
+
+// Registers represents the CPU registers for this architecture.
+//
+// +stateify savable
+type Registers struct {
+	linux.PtraceRegs
+}
+
+const (
+	// SyscallWidth is the width of the ecall instruction.
+	SyscallWidth = 4
+)
+
+// State contains the common architecture bits for riscv64 (the build tag of
+// this file ensures it's only built on riscv64).
+//
+// +stateify savable
+type State struct {
+	// The system registers.
+	Regs Registers
+
+	// Our floating point state.
+	fpState fpu.State `state:"wait"`
+
+	// OrigA0 stores the value of register A0 on syscall entry, like
+	// pt_regs.orig_a0 in Linux.
+	OrigA0 uint64
+}
+
+// Proto returns a protobuf representation of the system registers in State.
+func (s State) Proto() *rpb.Registers {
+	regs := &rpb.RISCV64Registers{
+		Pc:     s.Regs.Pc,
+		Ra:     s.Regs.Ra,
+		Sp:     s.Regs.Sp,
+		Gp:     s.Regs.Gp,
+		Tp:     s.Regs.Tp,
+		T0:     s.Regs.T0,
+		T1:     s.Regs.T1,
+		T2:     s.Regs.T2,
+		S0:     s.Regs.S0,
+		S1:     s.Regs.S1,
+		A0:     s.Regs.A0,
+		A1:     s.Regs.A1,
+		A2:     s.Regs.A2,
+		A3:     s.Regs.A3,
+		A4:     s.Regs.A4,
+		A5:     s.Regs.A5,
+		A6:     s.Regs.A6,
+		A7:     s.Regs.A7,
+		S2:     s.Regs.S2,
+		S3:     s.Regs.S3,
+		S4:     s.Regs.S4,
+		S5:     s.Regs.S5,
+		S6:     s.Regs.S6,
+		S7:     s.Regs.S7,
+		S8:     s.Regs.S8,
+		S9:     s.Regs.S9,
+		S10:    s.Regs.S10,
+		S11:    s.Regs.S11,
+		T3:     s.Regs.T3,
+		T4:     s.Regs.T4,
+		T5:     s.Regs.T5,
+		T6:     s.Regs.T6,
+		OrigA0: s.OrigA0,
+	}
+	return &rpb.Registers{Arch: &rpb.Registers_Riscv64{Riscv64: regs}}
+}
+
+// Fork creates and returns an identical copy of the state.
+func (s *State) Fork() State {
+	return State{
+		Regs:    s.Regs,
+		fpState: s.fpState.Fork(),
+		OrigA0:  s.OrigA0,
+	}
+}
+
+// StateData implements Context.StateData.
+func (s *State) StateData() *State {
+	return s
+}
+
+// SingleStep implements Context.SingleStep.
+func (s *State) SingleStep() bool {
+	return false
+}
+
+// SetSingleStep enables single stepping.
+func (s *State) SetSingleStep() {
+	// RISC-V has no hardware single-step for user mode; Linux doesn't
+	// support PTRACE_SINGLESTEP on riscv64 either.
+}
+
+// ClearSingleStep enables single stepping.
+func (s *State) ClearSingleStep() {
+	// See SetSingleStep.
+}
+
+// RegisterMap returns a map of all registers.
+func (s *State) RegisterMap() (map[string]uintptr, error) {
+	return map[string]uintptr{
+		"Pc":  uintptr(s.Regs.Pc),
+		"Ra":  uintptr(s.Regs.Ra),
+		"Sp":  uintptr(s.Regs.Sp),
+		"Gp":  uintptr(s.Regs.Gp),
+		"Tp":  uintptr(s.Regs.Tp),
+		"T0":  uintptr(s.Regs.T0),
+		"T1":  uintptr(s.Regs.T1),
+		"T2":  uintptr(s.Regs.T2),
+		"S0":  uintptr(s.Regs.S0),
+		"S1":  uintptr(s.Regs.S1),
+		"A0":  uintptr(s.Regs.A0),
+		"A1":  uintptr(s.Regs.A1),
+		"A2":  uintptr(s.Regs.A2),
+		"A3":  uintptr(s.Regs.A3),
+		"A4":  uintptr(s.Regs.A4),
+		"A5":  uintptr(s.Regs.A5),
+		"A6":  uintptr(s.Regs.A6),
+		"A7":  uintptr(s.Regs.A7),
+		"S2":  uintptr(s.Regs.S2),
+		"S3":  uintptr(s.Regs.S3),
+		"S4":  uintptr(s.Regs.S4),
+		"S5":  uintptr(s.Regs.S5),
+		"S6":  uintptr(s.Regs.S6),
+		"S7":  uintptr(s.Regs.S7),
+		"S8":  uintptr(s.Regs.S8),
+		"S9":  uintptr(s.Regs.S9),
+		"S10": uintptr(s.Regs.S10),
+		"S11": uintptr(s.Regs.S11),
+		"T3":  uintptr(s.Regs.T3),
+		"T4":  uintptr(s.Regs.T4),
+		"T5":  uintptr(s.Regs.T5),
+		"T6":  uintptr(s.Regs.T6),
+	}, nil
+}
+
+// PtraceGetRegs implements Context.PtraceGetRegs.
+func (s *State) PtraceGetRegs(dst io.Writer) (int, error) {
+	regs := s.ptraceGetRegs()
+	n, err := regs.WriteTo(dst)
+	return int(n), err
+}
+
+func (s *State) ptraceGetRegs() Registers {
+	return s.Regs
+}
+
+var ptraceRegistersSize = (*linux.PtraceRegs)(nil).SizeBytes()
+
+// PtraceSetRegs implements Context.PtraceSetRegs.
+func (s *State) PtraceSetRegs(src io.Reader) (int, error) {
+	var regs Registers
+	buf := make([]byte, ptraceRegistersSize)
+	if _, err := io.ReadFull(src, buf); err != nil {
+		return 0, err
+	}
+	regs.UnmarshalUnsafe(buf)
+	s.Regs = regs
+	return ptraceRegistersSize, nil
+}
+
+// PtraceGetFPRegs implements Context.PtraceGetFPRegs.
+func (s *State) PtraceGetFPRegs(dst io.Writer) (int, error) {
+	// TODO(gvisor.dev/issue/1238): floating-point is not supported.
+	return 0, nil
+}
+
+// PtraceSetFPRegs implements Context.PtraceSetFPRegs.
+func (s *State) PtraceSetFPRegs(src io.Reader) (int, error) {
+	// TODO(gvisor.dev/issue/1238): floating-point is not supported.
+	return 0, nil
+}
+
+// Register sets defined in include/uapi/linux/elf.h.
+const (
+	_NT_PRSTATUS = 1
+)
+
+// PtraceGetRegSet implements Context.PtraceGetRegSet.
+func (s *State) PtraceGetRegSet(regset uintptr, dst io.Writer, maxlen int, _ cpuid.FeatureSet) (int, error) {
+	switch regset {
+	case _NT_PRSTATUS:
+		if maxlen < ptraceRegistersSize {
+			return 0, linuxerr.EFAULT
+		}
+		return s.PtraceGetRegs(dst)
+	default:
+		return 0, linuxerr.EINVAL
+	}
+}
+
+// PtraceSetRegSet implements Context.PtraceSetRegSet.
+func (s *State) PtraceSetRegSet(regset uintptr, src io.Reader, maxlen int, _ cpuid.FeatureSet) (int, error) {
+	switch regset {
+	case _NT_PRSTATUS:
+		if maxlen < ptraceRegistersSize {
+			return 0, linuxerr.EFAULT
+		}
+		return s.PtraceSetRegs(src)
+	default:
+		return 0, linuxerr.EINVAL
+	}
+}
+
+// FullRestore indicates whether a full restore is required.
+func (s *State) FullRestore() bool {
+	return false
+}
+
+// New returns a new architecture context.
+func New(arch Arch) *Context64 {
+	switch arch {
+	case RISCV64:
+		return &Context64{
+			State{
+				fpState: fpu.NewState(),
+			},
+			[]fpu.State(nil),
+		}
+	}
+	panic(fmt.Sprintf("unknown architecture %v", arch))
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build riscv64
+// +build riscv64
+
+package fpu
+
+// fpStateSize is the size of union __riscv_fp_state
+// (arch/riscv/include/uapi/asm/ptrace.h), which is large enough for the F, D
+// and Q extensions.
+const fpStateSize = 528
+
+func newRiscv64FPStateSlice() []byte {
+	return alignedBytes(4096, 16)[:fpStateSize]
+}
+
+// NewState returns an initialized floating point state.
+//
+// Linux clears the floating point state of new processes
+// (arch/riscv/kernel/process.c:start_thread()), so the zeroed state is the
+// initial state.
+func NewState() State {
+	return State(newRiscv64FPStateSlice())
+}
+
+// Fork creates and returns an identical copy of the riscv64 floating point
+// state.
+func (s *State) Fork() State {
+	n := State(newRiscv64FPStateSlice())
+	copy(n, *s)
+	return n
+}
+
+// BytePointer returns a pointer to the first byte of the state.
+//
+//go:nosplit
+func (s *State) BytePointer() *byte {
+	return &(*s)[0]
+}
@@ -85,6 +85,36 @@ type UContext64 struct {
 	Sigset   linux.SignalSet
 }
 
+// MinSigStackSize returns the minimum size of a signal stack that can hold
+// the signal frame constructed by SignalSetup for the given feature set, as
+// reported to applications through AT_MINSIGSTKSZ. Compare to Linux's
+// arch/x86/kernel/signal.c:init_sigframe_size().
+func MinSigStackSize(featureSet cpuid.FeatureSet) uint64 {
+	const (
+		// sizeof(siginfo).
+		sigInfoSize = 128
+		// Alignment padding for the frame (FRAME_ALIGNMENT - 1) and the
+		// extended state (MAX_XSAVE_PADDING).
+		maxFramePadding = 15
+		maxXsavePadding = 63
+		// sizeof(struct fregs_state). Linux always reserves space for it
+		// when 32-bit emulation is configured, even for 64-bit tasks, so
+		// do the same to report identical values.
+		fregsStateSize = 112
+	)
+	// Size of the floating point state written by SignalSetup; see
+	// fpu.NewState.
+	fpSize, _ := featureSet.ExtendedStateSize()
+	fpSize -= featureSet.AMXExtendedStateSize()
+	fpSize += fpu.FP_XSTATE_MAGIC2_SIZE + fregsStateSize
+
+	var uc UContext64
+	// The restorer address, ucontext and siginfo; i.e. struct rt_sigframe.
+	frameSize := uint64(8 + uc.SizeBytes() + sigInfoSize)
+	size := frameSize + maxFramePadding + uint64(fpSize) + maxXsavePadding
+	return (size + 15) &^ 15
+}
+
 // SignalSetup implements Context.SignalSetup. (Compare to Linux's
 // arch/x86/kernel/signal.c:__setup_rt_frame().)
 func (c *Context64) SignalSetup(st *Stack, act *linux.SigAction, info *linux.SignalInfo, alt *linux.SignalStack, sigset linux.SignalSet, featureSet cpuid.FeatureSet) error {
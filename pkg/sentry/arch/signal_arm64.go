@@ -73,6 +73,28 @@ type UContext64 struct {
 	MContext SignalContext64
 }
 
+// MinSigStackSize returns the minimum size of a signal stack that can hold
+// the signal frame constructed by SignalSetup for the given feature set, as
+// reported to applications through AT_MINSIGSTKSZ. Compare to Linux's
+// arch/arm64/kernel/signal.c:minsigstksz_setup().
+//
+// SVE and SME are never exposed to applications, so all signal frame records
+// fit in the fixed-size sigcontext.__reserved area and the size doesn't
+// depend on featureSet.
+func MinSigStackSize(featureSet cpuid.FeatureSet) uint64 {
+	const (
+		// sizeof(struct rt_sigframe): siginfo (128 bytes), ucontext up to
+		// uc_mcontext (176 bytes), sigcontext up to __reserved (288
+		// bytes) and __reserved (4096 bytes).
+		rtSigframeSize = 128 + 176 + 288 + 4096
+		// sizeof(struct frame_record), rounded up to 16 bytes.
+		frameRecordSize = 16
+		// Maximum alignment padding.
+		maxPadding = 16
+	)
+	return (rtSigframeSize+15)&^15 + frameRecordSize + maxPadding
+}
+
 // SignalSetup implements Context.SignalSetup.
 func (c *Context64) SignalSetup(st *Stack, act *linux.SigAction, info *linux.SignalInfo, alt *linux.SignalStack, sigset linux.SignalSet, featureSet cpuid.FeatureSet) error {
 	sp := st.Bottom
@@ -0,0 +1,172 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build riscv64
+// +build riscv64
+
+package arch
+
+import (
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/cpuid"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/arch/fpu"
+)
+
+// SignalContext64 is equivalent to struct sigcontext, the type passed as the
+// second argument to signal handlers set by signal(2).
+//
+// +marshal
+type SignalContext64 struct {
+	Regs    linux.PtraceRegs
+	FPState QExtState // union __riscv_fp_state
+}
+
+// QExtState is equivalent to struct __riscv_q_ext_state, the largest member
+// of union __riscv_fp_state (arch/riscv/include/uapi/asm/ptrace.h).
+//
+// +marshal
+type QExtState struct {
+	F        [64]uint64 // actually [32]uint128
+	Fcsr     uint32
+	Reserved [3]uint32
+}
+
+// UContext64 is equivalent to ucontext on riscv64
+// (arch/riscv/include/uapi/asm/ucontext.h).
+//
+// +marshal
+type UContext64 struct {
+	Flags  uint64
+	Link   uint64
+	Stack  linux.SignalStack
+	Sigset linux.SignalSet
+	// glibc uses a 1024-bit sigset_t
+	_pad [120]byte // (1024 - 64) / 8 = 120
+	// sigcontext must be aligned to 16-byte
+	_pad2 [8]byte
+	// last for future expansion
+	MContext SignalContext64
+}
+
+// MinSigStackSize returns the minimum size of a signal stack that can hold
+// the signal frame constructed by SignalSetup for the given feature set, as
+// reported to applications through AT_MINSIGSTKSZ.
+//
+// The vector extension is never exposed to applications, so the size doesn't
+// depend on featureSet.
+func MinSigStackSize(featureSet cpuid.FeatureSet) uint64 {
+	// sizeof(struct rt_sigframe): siginfo (128 bytes) and ucontext.
+	rtSigframeSize := uint64(128 + (*UContext64)(nil).SizeBytes())
+	return (rtSigframeSize + 15) &^ 15
+}
+
+// SignalSetup implements Context.SignalSetup.
+func (c *Context64) SignalSetup(st *Stack, act *linux.SigAction, info *linux.SignalInfo, alt *linux.SignalStack, sigset linux.SignalSet, featureSet cpuid.FeatureSet) error {
+	sp := st.Bottom
+
+	// Construct the UContext64 now since we need its size.
+	uc := &UContext64{
+		Flags: 0,
+		Stack: *alt,
+		MContext: SignalContext64{
+			Regs: c.Regs.PtraceRegs,
+		},
+		Sigset: sigset,
+	}
+
+	ucSize := uc.SizeBytes()
+
+	// frameSize = sizeof(struct rt_sigframe) = sizeof(siginfo) + ucSize.
+	// sizeof(siginfo) == 128.
+	frameSize := ucSize + 128
+	frameBottom := (sp - hostarch.Addr(frameSize)) & ^hostarch.Addr(15)
+	sp = frameBottom + hostarch.Addr(frameSize)
+	st.Bottom = sp
+
+	// Prior to proceeding, figure out if the frame will exhaust the range
+	// for the signal stack. This is not allowed, and should immediately
+	// force signal delivery (reverting to the default handler).
+	if act.Flags&linux.SA_ONSTACK != 0 && alt.IsEnabled() && !alt.Contains(frameBottom) {
+		return unix.EFAULT
+	}
+
+	// Adjust the code.
+	info.FixSignalCodeForUser()
+
+	// Set up the stack frame. As in Linux, siginfo is at the bottom of the
+	// frame, followed by the ucontext.
+	if _, err := uc.CopyOut(st, StackBottomMagic); err != nil {
+		return err
+	}
+	ucAddr := st.Bottom
+	if _, err := info.CopyOut(st, StackBottomMagic); err != nil {
+		return err
+	}
+	infoAddr := st.Bottom
+
+	// Set up registers. Linux always returns from signal handlers through
+	// the vDSO's __vdso_rt_sigreturn on riscv64; the sentry's vDSO doesn't
+	// provide one, so the restorer set by the application is used, as on
+	// arm64.
+	c.Regs.Sp = uint64(st.Bottom)
+	c.Regs.Pc = act.Handler
+	c.Regs.A0 = uint64(info.Signo)
+	c.Regs.A1 = uint64(infoAddr)
+	c.Regs.A2 = uint64(ucAddr)
+	c.Regs.Ra = act.Restorer
+
+	// Save the thread's floating point state.
+	c.sigFPState = append(c.sigFPState, c.fpState)
+	// Signal handler gets a clean floating point state.
+	c.fpState = fpu.NewState()
+	return nil
+}
+
+// SignalRestore implements Context.SignalRestore.
+func (c *Context64) SignalRestore(st *Stack, rt bool, featureSet cpuid.FeatureSet) (linux.SignalSet, linux.SignalStack, error) {
+	// Copy out the stack frame.
+	var info linux.SignalInfo
+	if _, err := info.CopyIn(st, StackBottomMagic); err != nil {
+		return 0, linux.SignalStack{}, err
+	}
+	var uc UContext64
+	if _, err := uc.CopyIn(st, StackBottomMagic); err != nil {
+		return 0, linux.SignalStack{}, err
+	}
+
+	// Restore registers.
+	c.Regs.PtraceRegs = uc.MContext.Regs
+
+	// Restore floating point state.
+	l := len(c.sigFPState)
+	if l > 0 {
+		c.fpState = c.sigFPState[l-1]
+		// NOTE(cl/133042258): State save requires that any slice
+		// elements from '[len:cap]' to be zero value.
+		c.sigFPState[l-1] = nil
+		c.sigFPState = c.sigFPState[0 : l-1]
+	} else {
+		// This might happen if sigreturn(2) calls are unbalanced with
+		// respect to signal handler entries. This is not expected so
+		// don't bother to do anything fancy with the floating point
+		// state.
+		log.Warningf("sigreturn unable to restore application fpstate")
+		return 0, linux.SignalStack{}, unix.EFAULT
+	}
+
+	return uc.Sigset, uc.Stack, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build riscv64
+// +build riscv64
+
+package arch
+
+const restartSyscallNr = uintptr(128)
+
+// SyscallSaveOrig saves the value of register A0, which is clobbered by the
+// syscall return value. Linux saves it to pt_regs.orig_a0 on syscall entry
+// (arch/riscv/kernel/traps.c:do_trap_ecall_u()).
+func (c *Context64) SyscallSaveOrig() {
+	c.OrigA0 = c.Regs.A0
+}
+
+// SyscallNo returns the syscall number according to the 64-bit convention.
+func (c *Context64) SyscallNo() uintptr {
+	return uintptr(c.Regs.A7)
+}
+
+// SyscallArgs provides syscall arguments according to the 64-bit convention.
+//
+// Due to the way addresses are mapped for the sentry this binary *must* be
+// built in 64-bit mode. So we can just assume the syscall numbers that come
+// back match the expected host system call numbers.
+func (c *Context64) SyscallArgs() SyscallArguments {
+	return SyscallArguments{
+		SyscallArgument{Value: uintptr(c.OrigA0)},
+		SyscallArgument{Value: uintptr(c.Regs.A1)},
+		SyscallArgument{Value: uintptr(c.Regs.A2)},
+		SyscallArgument{Value: uintptr(c.Regs.A3)},
+		SyscallArgument{Value: uintptr(c.Regs.A4)},
+		SyscallArgument{Value: uintptr(c.Regs.A5)},
+	}
+}
+
+// RestartSyscall implements Context.RestartSyscall.
+// Prepare for system call restart, OrigA0 will be restored to A0.
+// Please see the linux code as reference:
+// arch/riscv/kernel/signal.c:arch_do_signal_or_restart()
+func (c *Context64) RestartSyscall() {
+	c.Regs.Pc -= SyscallWidth
+	c.Regs.A0 = c.OrigA0
+}
+
+// RestartSyscallWithRestartBlock implements Context.RestartSyscallWithRestartBlock.
+func (c *Context64) RestartSyscallWithRestartBlock() {
+	c.Regs.Pc -= SyscallWidth
+	c.Regs.A0 = c.OrigA0
+	c.Regs.A7 = uint64(restartSyscallNr)
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package control
+
+import (
+	"gvisor.dev/gvisor/pkg/faultinject"
+	"gvisor.dev/gvisor/pkg/log"
+)
+
+// Chaos controls fault injection ("chaos mode"), which delays or fails
+// syscalls, gofer RPCs and netstack socket operations matching configured
+// rules. See pkg/faultinject.
+type Chaos struct{}
+
+// ChaosArgs are the arguments to Chaos.SetRules.
+type ChaosArgs struct {
+	// Rules are the fault injection rules to install, replacing any
+	// previously installed ones. If Rules is empty, fault injection is
+	// disabled.
+	Rules []faultinject.Rule
+}
+
+// SetRules installs fault injection rules.
+func (*Chaos) SetRules(args *ChaosArgs, _ *struct{}) error {
+	if err := faultinject.SetRules(args.Rules); err != nil {
+		return err
+	}
+	if len(args.Rules) == 0 {
+		log.Infof("Fault injection disabled")
+	} else {
+		log.Infof("Fault injection enabled with %d rules: %+v", len(args.Rules), args.Rules)
+	}
+	return nil
+}
+
+// Rules returns the installed fault injection rules.
+func (*Chaos) Rules(_ *struct{}, rules *[]faultinject.Rule) error {
+	*rules = faultinject.Rules()
+	return nil
+}
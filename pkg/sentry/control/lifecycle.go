@@ -256,7 +256,10 @@ func (l *Lifecycle) StartContainer(args *StartContainerArgs, _ *uint32) error {
 
 	// Create a new pid namespace for the container. Each container must run
 	// in its own pid namespace.
-	initArgs.PIDNamespace = l.Kernel.RootPIDNamespace().NewChild(ctx, l.Kernel, l.Kernel.RootUserNamespace())
+	initArgs.PIDNamespace, err = l.Kernel.RootPIDNamespace().NewChild(ctx, l.Kernel, l.Kernel.RootUserNamespace())
+	if err != nil {
+		return fmt.Errorf("creating PID namespace: %w", err)
+	}
 
 	// Import file descriptors.
 	fdTable := l.Kernel.NewFDTable()
@@ -18,7 +18,10 @@ import (
 	"fmt"
 
 	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
 	"gvisor.dev/gvisor/pkg/sentry/strace"
+	"gvisor.dev/gvisor/pkg/sentry/watchdog"
+	"gvisor.dev/gvisor/pkg/sync"
 	"gvisor.dev/gvisor/pkg/tcpip/link/sniffer"
 )
 
@@ -63,10 +66,59 @@ type LoggingArgs struct {
 	// StraceEventAllowlist is the allowlist of syscalls to trace
 	// to event log.
 	StraceEventAllowlist []string
+
+	// SetStraceTasks indicates that we should update the set of tasks
+	// that are traced.
+	SetStraceTasks bool
+
+	// StraceTasks is the set of thread IDs and thread group IDs, in the
+	// root PID namespace, of tasks to trace. If it is empty, all tasks
+	// are traced.
+	StraceTasks []int32
+
+	// SetStraceSampleRate indicates that we should update the strace
+	// sample rate.
+	SetStraceSampleRate bool
+
+	// StraceSampleRate is N such that only one in every N traced
+	// syscalls is logged. 0 and 1 mean that all syscalls are logged.
+	StraceSampleRate uint32
+
+	// SetWatchdogAction indicates that we should update the action taken
+	// by the watchdog when it detects a stuck task.
+	SetWatchdogAction bool
+
+	// WatchdogAction is the watchdog action to set if SetWatchdogAction
+	// is true.
+	WatchdogAction watchdog.Action
 }
 
 // Logging provides functions related to logging.
-type Logging struct{}
+type Logging struct {
+	// mu protects watchdog.
+	mu sync.Mutex
+
+	// watchdog is the sandbox's watchdog. If it is nil, the watchdog
+	// action can't be changed.
+	//
+	// +checklocks:mu
+	watchdog *watchdog.Watchdog
+}
+
+// NewLogging returns a Logging that changes the action of dog, which may be
+// nil.
+func NewLogging(dog *watchdog.Watchdog) *Logging {
+	return &Logging{watchdog: dog}
+}
+
+// SetLoggingWatchdog replaces the watchdog whose action is changed by l, e.g.
+// when restore creates a new kernel. It isn't a method of Logging, since all
+// methods of Logging are exposed over URPC.
+func SetLoggingWatchdog(l *Logging, dog *watchdog.Watchdog) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.watchdog = dog
+}
 
 // Change will change the log level and strace arguments. Although
 // this functions signature requires an error it never actually
@@ -101,6 +153,30 @@ func (l *Logging) Change(args *LoggingArgs, code *int) error {
 		}
 	}
 
+	if args.SetStraceTasks {
+		ids := make([]kernel.ThreadID, 0, len(args.StraceTasks))
+		for _, id := range args.StraceTasks {
+			ids = append(ids, kernel.ThreadID(id))
+		}
+		strace.SetTaskFilter(ids)
+		log.Infof("Strace task filter set to: %v", args.StraceTasks)
+	}
+
+	if args.SetStraceSampleRate {
+		strace.SetSampleRate(args.StraceSampleRate)
+		log.Infof("Strace sample rate set to: %d", args.StraceSampleRate)
+	}
+
+	if args.SetWatchdogAction {
+		l.mu.Lock()
+		dog := l.watchdog
+		l.mu.Unlock()
+		if dog == nil {
+			return fmt.Errorf("watchdog is not available")
+		}
+		dog.SetTaskTimeoutAction(args.WatchdogAction)
+	}
+
 	return nil
 }
 
@@ -126,6 +126,9 @@ type ExecArgs struct {
 	// Capabilities is the list of capabilities to give to the process.
 	Capabilities *auth.TaskCapabilities
 
+	// NoNewPrivs sets the no_new_privs bit of the process.
+	NoNewPrivs bool
+
 	// StdioIsPty indicates that FDs 0, 1, and 2 are connected to a host pty FD.
 	StdioIsPty bool
 
@@ -212,6 +215,7 @@ func (proc *Proc) execAsync(args *ExecArgs) (*kernel.ThreadGroup, kernel.ThreadI
 		ContainerID:          args.ContainerID,
 		PIDNamespace:         pidns,
 		Origin:               kernel.OriginExec,
+		NoNewPrivs:           args.NoNewPrivs,
 	}
 	if initArgs.MountNamespace != nil {
 		// initArgs must hold a reference on MountNamespace, which will
@@ -500,6 +504,21 @@ func ContainerUsage(kr *kernel.Kernel) map[string]uint64 {
 	return cusage
 }
 
+// ContainerIOUsage retrieves per-container I/O usage.
+func ContainerIOUsage(kr *kernel.Kernel) map[string]*usage.IO {
+	cusage := make(map[string]*usage.IO)
+	for _, tg := range kr.TaskSet().Root.ThreadGroups() {
+		cid := tg.Leader().ContainerID()
+		io, ok := cusage[cid]
+		if !ok {
+			io = &usage.IO{}
+			cusage[cid] = io
+		}
+		io.Accumulate(tg.IOUsage())
+	}
+	return cusage
+}
+
 // unpackFiles unpacks the file descriptor map and, if applicable, the file
 // descriptor to be used for execution from the unmarshalled ExecArgs.
 func (args *ExecArgs) unpackFiles() (map[int]*fd.FD, *fd.FD, error) {
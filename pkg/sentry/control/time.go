@@ -0,0 +1,54 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package control
+
+import (
+	"time"
+
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+)
+
+// Time includes RPC stubs for controlling the sandbox's clocks in tests.
+type Time struct {
+	Kernel *kernel.Kernel
+}
+
+// TimeDilationArgs are the arguments to Time.SetDilation.
+type TimeDilationArgs struct {
+	// Rate is the rate at which the sandbox's clocks should advance relative
+	// to the host's clocks.
+	Rate float64 `json:"rate"`
+}
+
+// SetDilation makes the sandbox's monotonic and realtime clocks advance at a
+// multiple of the speed of the host's clocks.
+func (t *Time) SetDilation(args *TimeDilationArgs, _ *struct{}) error {
+	log.Infof("Setting time dilation to %v", args.Rate)
+	return t.Kernel.Timekeeper().SetTimeDilation(args.Rate)
+}
+
+// TimeAdvanceArgs are the arguments to Time.Advance.
+type TimeAdvanceArgs struct {
+	// Duration is the amount of time by which to step the sandbox's clocks
+	// forward.
+	Duration time.Duration `json:"duration"`
+}
+
+// Advance steps the sandbox's monotonic and realtime clocks forward.
+func (t *Time) Advance(args *TimeAdvanceArgs, _ *struct{}) error {
+	log.Infof("Advancing time by %v", args.Duration)
+	return t.Kernel.Timekeeper().AdvanceTime(args.Duration)
+}
@@ -23,6 +23,54 @@ import (
 
 // Filters returns seccomp-bpf filters for this package.
 func Filters() seccomp.SyscallRules {
+	s := VFIOFilters()
+	s.Merge(seccomp.MakeSyscallRules(map[uintptr]seccomp.SyscallRule{
+		unix.SYS_GETDENTS64: seccomp.MatchAll{},
+		unix.SYS_EVENTFD2: seccomp.PerArg{
+			seccomp.AnyValue{},
+			seccomp.EqualTo(linux.EFD_NONBLOCK | linux.EFD_SEMAPHORE),
+		},
+		unix.SYS_MMAP: seccomp.PerArg{
+			seccomp.AnyValue{},
+			seccomp.AnyValue{},
+			seccomp.EqualTo(linux.PROT_READ | linux.PROT_WRITE),
+			seccomp.EqualTo(linux.MAP_SHARED | linux.MAP_LOCKED),
+			seccomp.NonNegativeFD{},
+		},
+		unix.SYS_IOCTL: seccomp.Or{
+			seccomp.PerArg{
+				seccomp.NonNegativeFD{},
+				seccomp.EqualTo(gasket.GASKET_IOCTL_RESET),
+			},
+			seccomp.PerArg{
+				seccomp.NonNegativeFD{},
+				seccomp.EqualTo(gasket.GASKET_IOCTL_MAP_BUFFER),
+			},
+			seccomp.PerArg{
+				seccomp.NonNegativeFD{},
+				seccomp.EqualTo(gasket.GASKET_IOCTL_UNMAP_BUFFER),
+			},
+			seccomp.PerArg{
+				seccomp.NonNegativeFD{},
+				seccomp.EqualTo(gasket.GASKET_IOCTL_CLEAR_INTERRUPT_COUNTS),
+			},
+			seccomp.PerArg{
+				seccomp.NonNegativeFD{},
+				seccomp.EqualTo(gasket.GASKET_IOCTL_REGISTER_INTERRUPT),
+			},
+			seccomp.PerArg{
+				seccomp.NonNegativeFD{},
+				seccomp.EqualTo(gasket.GASKET_IOCTL_UNREGISTER_INTERRUPT),
+			},
+		},
+	}))
+	return s
+}
+
+// VFIOFilters returns the seccomp-bpf filters needed to proxy generic VFIO
+// devices, such as SR-IOV network virtual functions. Unlike Filters, they
+// don't allow the TPU-specific syscalls.
+func VFIOFilters() seccomp.SyscallRules {
 	return seccomp.MakeSyscallRules(map[uintptr]seccomp.SyscallRule{
 		unix.SYS_OPENAT: seccomp.PerArg{
 			// All paths that we openat() are absolute, so we pass a dirfd
@@ -34,16 +82,9 @@ func Filters() seccomp.SyscallRules {
 			seccomp.MaskedEqual(unix.O_CREAT|unix.O_NOFOLLOW, unix.O_NOFOLLOW),
 			seccomp.AnyValue{},
 		},
-		unix.SYS_GETDENTS64: seccomp.MatchAll{},
-		unix.SYS_EVENTFD2: seccomp.Or{
-			seccomp.PerArg{
-				seccomp.AnyValue{},
-				seccomp.EqualTo(linux.EFD_NONBLOCK),
-			},
-			seccomp.PerArg{
-				seccomp.AnyValue{},
-				seccomp.EqualTo(linux.EFD_NONBLOCK | linux.EFD_SEMAPHORE),
-			},
+		unix.SYS_EVENTFD2: seccomp.PerArg{
+			seccomp.AnyValue{},
+			seccomp.EqualTo(linux.EFD_NONBLOCK),
 		},
 		unix.SYS_MREMAP: seccomp.PerArg{
 			seccomp.AnyValue{},
@@ -53,13 +94,6 @@ func Filters() seccomp.SyscallRules {
 			seccomp.AnyValue{},
 			seccomp.EqualTo(0),
 		},
-		unix.SYS_MMAP: seccomp.PerArg{
-			seccomp.AnyValue{},
-			seccomp.AnyValue{},
-			seccomp.EqualTo(linux.PROT_READ | linux.PROT_WRITE),
-			seccomp.EqualTo(linux.MAP_SHARED | linux.MAP_LOCKED),
-			seccomp.NonNegativeFD{},
-		},
 		unix.SYS_MUNMAP:   seccomp.MatchAll{},
 		unix.SYS_PREAD64:  seccomp.MatchAll{},
 		unix.SYS_PWRITE64: seccomp.MatchAll{},
@@ -112,30 +146,6 @@ func Filters() seccomp.SyscallRules {
 				seccomp.NonNegativeFD{},
 				seccomp.EqualTo(linux.VFIO_DEVICE_RESET),
 			},
-			seccomp.PerArg{
-				seccomp.NonNegativeFD{},
-				seccomp.EqualTo(gasket.GASKET_IOCTL_RESET),
-			},
-			seccomp.PerArg{
-				seccomp.NonNegativeFD{},
-				seccomp.EqualTo(gasket.GASKET_IOCTL_MAP_BUFFER),
-			},
-			seccomp.PerArg{
-				seccomp.NonNegativeFD{},
-				seccomp.EqualTo(gasket.GASKET_IOCTL_UNMAP_BUFFER),
-			},
-			seccomp.PerArg{
-				seccomp.NonNegativeFD{},
-				seccomp.EqualTo(gasket.GASKET_IOCTL_CLEAR_INTERRUPT_COUNTS),
-			},
-			seccomp.PerArg{
-				seccomp.NonNegativeFD{},
-				seccomp.EqualTo(gasket.GASKET_IOCTL_REGISTER_INTERRUPT),
-			},
-			seccomp.PerArg{
-				seccomp.NonNegativeFD{},
-				seccomp.EqualTo(gasket.GASKET_IOCTL_UNREGISTER_INTERRUPT),
-			},
 		},
 	})
 }
@@ -374,6 +374,10 @@ func (c *cgroupInode) WriteControl(ctx context.Context, name string, value strin
 		return fmt.Errorf("this control may not be accessed from a background context")
 	}
 	wcbf, ok := cfi.(writableControllerFileImpl)
+	if !ok {
+		// Most control files delegate writes to their data source.
+		wcbf, ok = cbf.Source().Data().(writableControllerFileImpl)
+	}
 	if !ok {
 		return fmt.Errorf("control file not writable")
 	}
@@ -0,0 +1,565 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroupfs
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/gohacks"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/kernfs"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/usermem"
+)
+
+const (
+	// Bounds and default of blkio.weight. See Linux 4.x,
+	// block/cfq-iosched.c:CFQ_WEIGHT_LEGACY_*.
+	blkioWeightMin     = 10
+	blkioWeightMax     = 1000
+	blkioWeightDefault = 500
+
+	// Bounds and default of io.weight. See Linux,
+	// include/linux/cgroup.h:CGROUP_WEIGHT_*.
+	ioWeightMin     = 1
+	ioWeightMax     = 10000
+	ioWeightDefault = 100
+
+	// blkioShareWindow is the period over which the I/O of sibling cgroups
+	// is compared to share it according to their weights.
+	blkioShareWindow = 100 * time.Millisecond
+
+	// blkioOpCost is the number of bytes each I/O operation is charged in
+	// addition to the bytes it transfers when sharing I/O by weight, so that
+	// small I/O is accounted for too.
+	blkioOpCost = hostarch.PageSize
+)
+
+// Indices of blkioController.limits.
+const (
+	blkioReadBPS = iota
+	blkioWriteBPS
+	blkioReadIOPS
+	blkioWriteIOPS
+	blkioNumLimits
+)
+
+// blkioLimit is a throttling rule, i.e. the content of one of the
+// blkio.throttle.*_device files.
+//
+// +stateify savable
+type blkioLimit struct {
+	// major and minor are the device the rule was configured for. The sentry
+	// doesn't have block devices, so the rule applies to all throttled I/O of
+	// the cgroup regardless of the device.
+	major uint64
+	minor uint64
+
+	// rate is the maximum number of bytes or operations per second. Zero
+	// means unlimited.
+	rate uint64
+
+	// next is the monotonic time, in nanoseconds, at which all I/O charged so
+	// far would have been performed at rate. It is reset on restore since the
+	// host monotonic clock changes.
+	next int64 `state:"nosave"`
+}
+
+// charge charges n bytes or operations to l at time now, and returns how long
+// the caller must wait to stay within the limit.
+func (l *blkioLimit) charge(now int64, n uint64) time.Duration {
+	if l.rate == 0 {
+		return 0
+	}
+	if l.next < now {
+		l.next = now
+	}
+	l.next += int64(n/l.rate*uint64(time.Second) + n%l.rate*uint64(time.Second)/l.rate)
+	return time.Duration(l.next - now)
+}
+
+// blkioController implements the blkio cgroup controller. It provides both
+// the cgroup v1 blkio.* interface files and the cgroup v2 io.* ones, which
+// are two views of the same state.
+//
+// Throttling limits are enforced on I/O to files backed by the host, which is
+// charged after it completes: the task that performed it is then delayed until
+// the cgroup is back within its limits. Limits of all ancestors apply.
+//
+// Weights share I/O between sibling cgroups that perform I/O at the same time:
+// over each blkioShareWindow, a cgroup that has performed more than its
+// weighted share of the I/O of its siblings is delayed for as long as the
+// excess took at the rate observed in the window, which lets the others catch
+// up. A cgroup without competing siblings isn't delayed.
+//
+// +stateify savable
+type blkioController struct {
+	controllerCommon
+	controllerStateless
+	controllerNoResource
+
+	// weight is the weight of the cgroup, in io.weight units. blkio.weight
+	// is scaled from it.
+	weight atomicbitops.Uint64
+
+	mu blkioControllerMutex `state:"nosave"`
+
+	// limits are the throttling rules. Protected by mu.
+	limits [blkioNumLimits]blkioLimit
+
+	// serviceBytes and serviced count bytes and operations, indexed by
+	// read (0) and write (1). Protected by mu.
+	serviceBytes [2]uint64
+	serviced     [2]uint64
+
+	// The following fields share the I/O of the children of this cgroup by
+	// weight. They are protected by mu, and reset on restore since they
+	// depend on the host monotonic clock.
+	//
+	// shareStart is the start of the current window. shareUsed maps each
+	// child that performed I/O in the window to the cost it was charged.
+	// shareTotal is the sum of the values in shareUsed, and shareWeight the
+	// sum of the weights of the children in shareUsed.
+	shareStart  int64                       `state:"nosave"`
+	shareUsed   map[*blkioController]uint64 `state:"nosave"`
+	shareTotal  uint64                      `state:"nosave"`
+	shareWeight uint64                      `state:"nosave"`
+}
+
+var _ controller = (*blkioController)(nil)
+var _ kernel.CgroupIOThrottler = (*blkioController)(nil)
+
+func newBlkioController(fs *filesystem) *blkioController {
+	c := &blkioController{}
+	c.weight.Store(ioWeightDefault)
+	c.controllerCommon.init(kernel.CgroupControllerBlkio, fs)
+	return c
+}
+
+// Clone implements controller.Clone.
+func (c *blkioController) Clone() controller {
+	new := &blkioController{}
+	new.weight.Store(c.weight.Load())
+	new.controllerCommon.cloneFromParent(c)
+	return new
+}
+
+// AddControlFiles implements controller.AddControlFiles.
+func (c *blkioController) AddControlFiles(ctx context.Context, creds *auth.Credentials, _ *cgroupInode, contents map[string]kernfs.Inode) {
+	contents["blkio.weight"] = c.fs.newControllerWritableFile(ctx, creds, &blkioWeightData{c: c}, true)
+	contents["blkio.throttle.read_bps_device"] = c.fs.newControllerWritableFile(ctx, creds, &blkioThrottleData{c: c, limit: blkioReadBPS}, true)
+	contents["blkio.throttle.write_bps_device"] = c.fs.newControllerWritableFile(ctx, creds, &blkioThrottleData{c: c, limit: blkioWriteBPS}, true)
+	contents["blkio.throttle.read_iops_device"] = c.fs.newControllerWritableFile(ctx, creds, &blkioThrottleData{c: c, limit: blkioReadIOPS}, true)
+	contents["blkio.throttle.write_iops_device"] = c.fs.newControllerWritableFile(ctx, creds, &blkioThrottleData{c: c, limit: blkioWriteIOPS}, true)
+	contents["blkio.throttle.io_service_bytes"] = c.fs.newControllerFile(ctx, creds, &blkioStatData{c: c, bytes: true}, true)
+	contents["blkio.throttle.io_serviced"] = c.fs.newControllerFile(ctx, creds, &blkioStatData{c: c}, true)
+	contents["io.weight"] = c.fs.newControllerWritableFile(ctx, creds, &ioWeightData{c: c}, true)
+	contents["io.max"] = c.fs.newControllerWritableFile(ctx, creds, &ioMaxData{c: c}, true)
+	contents["io.stat"] = c.fs.newControllerFile(ctx, creds, &ioStatData{c: c}, true)
+}
+
+// ChargeIO implements kernel.CgroupIOThrottler.ChargeIO.
+func (c *blkioController) ChargeIO(write bool, bytes int64) time.Duration {
+	if bytes < 0 {
+		bytes = 0
+	}
+	rw, bps, iops := 0, blkioReadBPS, blkioReadIOPS
+	if write {
+		rw, bps, iops = 1, blkioWriteBPS, blkioWriteIOPS
+	}
+	now := gohacks.Nanotime()
+
+	c.mu.Lock()
+	c.serviceBytes[rw] += uint64(bytes)
+	c.serviced[rw]++
+	delay := max(c.limits[bps].charge(now, uint64(bytes)), c.limits[iops].charge(now, 1))
+	c.mu.Unlock()
+
+	if parent, ok := c.parent.(*blkioController); ok {
+		delay = max(delay, parent.shareDelay(c, now, uint64(bytes)+blkioOpCost), parent.ChargeIO(write, bytes))
+	}
+	return delay
+}
+
+// shareDelay charges cost to child c of p at time now, and returns how long c
+// must wait to stay within its weighted share of the I/O of p's children.
+func (p *blkioController) shareDelay(c *blkioController, now int64, cost uint64) time.Duration {
+	weight := c.weight.Load()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.shareUsed == nil || now-p.shareStart >= int64(blkioShareWindow) {
+		p.shareStart = now
+		p.shareTotal = 0
+		p.shareWeight = 0
+		if p.shareUsed == nil {
+			p.shareUsed = make(map[*blkioController]uint64)
+		} else {
+			clear(p.shareUsed)
+		}
+	}
+	used, ok := p.shareUsed[c]
+	if !ok {
+		p.shareWeight += weight
+	}
+	used += cost
+	p.shareUsed[c] = used
+	p.shareTotal += cost
+
+	if len(p.shareUsed) == 1 {
+		// No competing siblings.
+		return 0
+	}
+	fair := p.shareTotal * weight / p.shareWeight
+	if used <= fair {
+		return 0
+	}
+	elapsed := max(now-p.shareStart, int64(time.Millisecond))
+	delay := time.Duration((used - fair) * uint64(elapsed) / p.shareTotal)
+	return min(delay, blkioShareWindow)
+}
+
+// +stateify savable
+type blkioWeightData struct {
+	c *blkioController
+}
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *blkioWeightData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	fmt.Fprintf(buf, "%d\n", blkioWeightFromIOWeight(d.c.weight.Load()))
+	return nil
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (d *blkioWeightData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	return d.WriteBackground(ctx, src)
+}
+
+// WriteBackground implements writableControllerFileImpl.WriteBackground.
+func (d *blkioWeightData) WriteBackground(ctx context.Context, src usermem.IOSequence) (int64, error) {
+	val, n, err := parseInt64FromString(ctx, src)
+	if err != nil {
+		return 0, linuxerr.EINVAL
+	}
+	if val < blkioWeightMin || val > blkioWeightMax {
+		return 0, linuxerr.ERANGE
+	}
+	d.c.weight.Store(ioWeightFromBlkioWeight(uint64(val)))
+	return n, nil
+}
+
+// blkioWeightFromIOWeight converts an io.weight value to blkio.weight, so
+// that the defaults match.
+func blkioWeightFromIOWeight(w uint64) uint64 {
+	return min(max(w*blkioWeightDefault/ioWeightDefault, blkioWeightMin), blkioWeightMax)
+}
+
+// ioWeightFromBlkioWeight is the inverse of blkioWeightFromIOWeight.
+func ioWeightFromBlkioWeight(w uint64) uint64 {
+	return max(w*ioWeightDefault/blkioWeightDefault, ioWeightMin)
+}
+
+// ioWeightData implements io.weight.
+//
+// +stateify savable
+type ioWeightData struct {
+	c *blkioController
+}
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *ioWeightData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	fmt.Fprintf(buf, "default %d\n", d.c.weight.Load())
+	return nil
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (d *ioWeightData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	return d.WriteBackground(ctx, src)
+}
+
+// WriteBackground implements writableControllerFileImpl.WriteBackground.
+//
+// The format is "[default] WEIGHT". Per-device weights aren't supported,
+// since the sentry doesn't have block devices.
+func (d *ioWeightData) WriteBackground(ctx context.Context, src usermem.IOSequence) (int64, error) {
+	if src.NumBytes() > hostarch.PageSize {
+		return 0, linuxerr.EINVAL
+	}
+	buf := copyScratchBufferFromContext(ctx, hostarch.PageSize)
+	n, err := src.CopyIn(ctx, buf)
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(buf[:n]))
+	if len(fields) == 2 && fields[0] == "default" {
+		fields = fields[1:]
+	}
+	if len(fields) != 1 {
+		return 0, linuxerr.EINVAL
+	}
+	val, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, linuxerr.EINVAL
+	}
+	if val < ioWeightMin || val > ioWeightMax {
+		return 0, linuxerr.ERANGE
+	}
+	d.c.weight.Store(val)
+	return int64(n), nil
+}
+
+// +stateify savable
+type blkioThrottleData struct {
+	c     *blkioController
+	limit int
+}
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *blkioThrottleData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	d.c.mu.Lock()
+	defer d.c.mu.Unlock()
+	if l := &d.c.limits[d.limit]; l.rate != 0 {
+		fmt.Fprintf(buf, "%d:%d %d\n", l.major, l.minor, l.rate)
+	}
+	return nil
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (d *blkioThrottleData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	return d.WriteBackground(ctx, src)
+}
+
+// WriteBackground implements writableControllerFileImpl.WriteBackground.
+//
+// The format is "MAJOR:MINOR RATE". A rate of 0 removes the limit.
+func (d *blkioThrottleData) WriteBackground(ctx context.Context, src usermem.IOSequence) (int64, error) {
+	if src.NumBytes() > hostarch.PageSize {
+		return 0, linuxerr.EINVAL
+	}
+	buf := copyScratchBufferFromContext(ctx, hostarch.PageSize)
+	n, err := src.CopyIn(ctx, buf)
+	if err != nil {
+		return 0, err
+	}
+	major, minor, rate, err := parseBlkioThrottle(string(buf[:n]))
+	if err != nil {
+		return 0, err
+	}
+
+	d.c.mu.Lock()
+	defer d.c.mu.Unlock()
+	d.c.limits[d.limit] = blkioLimit{
+		major: major,
+		minor: minor,
+		rate:  rate,
+	}
+	return int64(n), nil
+}
+
+// parseBlkioThrottle parses a "MAJOR:MINOR RATE" throttling rule.
+func parseBlkioThrottle(rule string) (major, minor, rate uint64, err error) {
+	fields := strings.Fields(rule)
+	if len(fields) != 2 {
+		return 0, 0, 0, linuxerr.EINVAL
+	}
+	dev := strings.SplitN(fields[0], ":", 2)
+	if len(dev) != 2 {
+		return 0, 0, 0, linuxerr.EINVAL
+	}
+	if major, err = strconv.ParseUint(dev[0], 10, 32); err != nil {
+		return 0, 0, 0, linuxerr.EINVAL
+	}
+	if minor, err = strconv.ParseUint(dev[1], 10, 32); err != nil {
+		return 0, 0, 0, linuxerr.EINVAL
+	}
+	if rate, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+		return 0, 0, 0, linuxerr.EINVAL
+	}
+	return major, minor, rate, nil
+}
+
+// blkioStatData implements blkio.throttle.io_service_bytes and
+// blkio.throttle.io_serviced.
+//
+// +stateify savable
+type blkioStatData struct {
+	c     *blkioController
+	bytes bool
+}
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *blkioStatData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	d.c.mu.Lock()
+	defer d.c.mu.Unlock()
+	stats := d.c.serviced
+	if d.bytes {
+		stats = d.c.serviceBytes
+	}
+	// All I/O is reported against the device 0:0, see blkioLimit.
+	fmt.Fprintf(buf, "0:0 Read %d\n", stats[0])
+	fmt.Fprintf(buf, "0:0 Write %d\n", stats[1])
+	fmt.Fprintf(buf, "0:0 Sync %d\n", stats[0]+stats[1])
+	fmt.Fprintf(buf, "0:0 Async 0\n")
+	fmt.Fprintf(buf, "0:0 Discard 0\n")
+	fmt.Fprintf(buf, "0:0 Total %d\n", stats[0]+stats[1])
+	fmt.Fprintf(buf, "Total %d\n", stats[0]+stats[1])
+	return nil
+}
+
+// ioMaxKeys are the keys of io.max, indexed like blkioController.limits.
+var ioMaxKeys = [blkioNumLimits]string{
+	blkioReadBPS:   "rbps",
+	blkioWriteBPS:  "wbps",
+	blkioReadIOPS:  "riops",
+	blkioWriteIOPS: "wiops",
+}
+
+// ioMaxData implements io.max.
+//
+// +stateify savable
+type ioMaxData struct {
+	c *blkioController
+}
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *ioMaxData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	d.c.mu.Lock()
+	defer d.c.mu.Unlock()
+	var dev *blkioLimit
+	for i := range d.c.limits {
+		if d.c.limits[i].rate != 0 {
+			dev = &d.c.limits[i]
+			break
+		}
+	}
+	if dev == nil {
+		return nil
+	}
+	fmt.Fprintf(buf, "%d:%d", dev.major, dev.minor)
+	for i, key := range ioMaxKeys {
+		if rate := d.c.limits[i].rate; rate != 0 {
+			fmt.Fprintf(buf, " %s=%d", key, rate)
+		} else {
+			fmt.Fprintf(buf, " %s=max", key)
+		}
+	}
+	buf.WriteString("\n")
+	return nil
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (d *ioMaxData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	return d.WriteBackground(ctx, src)
+}
+
+// WriteBackground implements writableControllerFileImpl.WriteBackground.
+//
+// The format is "MAJOR:MINOR KEY=VALUE...", where KEY is one of rbps, wbps,
+// riops and wiops, and VALUE is a rate or "max" to remove the limit. Limits
+// that aren't listed are left unchanged.
+func (d *ioMaxData) WriteBackground(ctx context.Context, src usermem.IOSequence) (int64, error) {
+	if src.NumBytes() > hostarch.PageSize {
+		return 0, linuxerr.EINVAL
+	}
+	buf := copyScratchBufferFromContext(ctx, hostarch.PageSize)
+	n, err := src.CopyIn(ctx, buf)
+	if err != nil {
+		return 0, err
+	}
+	major, minor, rates, err := parseIOMax(string(buf[:n]))
+	if err != nil {
+		return 0, err
+	}
+
+	d.c.mu.Lock()
+	defer d.c.mu.Unlock()
+	for i, rate := range rates {
+		if rate == nil {
+			continue
+		}
+		d.c.limits[i] = blkioLimit{
+			major: major,
+			minor: minor,
+			rate:  *rate,
+		}
+	}
+	return int64(n), nil
+}
+
+// parseIOMax parses an io.max rule. rates[i] is nil if the limit with index i
+// isn't set by the rule, and points to 0 if it is removed.
+func parseIOMax(rule string) (major, minor uint64, rates [blkioNumLimits]*uint64, err error) {
+	fields := strings.Fields(rule)
+	if len(fields) < 2 {
+		return 0, 0, rates, linuxerr.EINVAL
+	}
+	dev := strings.SplitN(fields[0], ":", 2)
+	if len(dev) != 2 {
+		return 0, 0, rates, linuxerr.EINVAL
+	}
+	if major, err = strconv.ParseUint(dev[0], 10, 32); err != nil {
+		return 0, 0, rates, linuxerr.EINVAL
+	}
+	if minor, err = strconv.ParseUint(dev[1], 10, 32); err != nil {
+		return 0, 0, rates, linuxerr.EINVAL
+	}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return 0, 0, rates, linuxerr.EINVAL
+		}
+		i := 0
+		for i < blkioNumLimits && ioMaxKeys[i] != key {
+			i++
+		}
+		if i == blkioNumLimits {
+			return 0, 0, rates, linuxerr.EINVAL
+		}
+		var rate uint64
+		if value != "max" {
+			if rate, err = strconv.ParseUint(value, 10, 64); err != nil || rate == 0 {
+				return 0, 0, rates, linuxerr.EINVAL
+			}
+		}
+		rates[i] = &rate
+	}
+	return major, minor, rates, nil
+}
+
+// ioStatData implements io.stat.
+//
+// +stateify savable
+type ioStatData struct {
+	c *blkioController
+}
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *ioStatData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	d.c.mu.Lock()
+	defer d.c.mu.Unlock()
+	// All I/O is reported against the device 0:0, see blkioLimit.
+	fmt.Fprintf(buf, "0:0 rbytes=%d wbytes=%d rios=%d wios=%d dbytes=0 dios=0\n",
+		d.c.serviceBytes[0], d.c.serviceBytes[1], d.c.serviced[0], d.c.serviced[1])
+	return nil
+}
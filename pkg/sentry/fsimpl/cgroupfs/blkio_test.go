@@ -0,0 +1,106 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroupfs
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestBlkioController(weight uint64) *blkioController {
+	c := &blkioController{}
+	c.weight.Store(weight)
+	return c
+}
+
+func TestBlkioShareDelay(t *testing.T) {
+	p := newTestBlkioController(ioWeightDefault)
+	heavy := newTestBlkioController(300)
+	light := newTestBlkioController(100)
+	const cost = 1 << 20
+
+	// A child without competing siblings isn't delayed.
+	now := int64(time.Hour)
+	if d := p.shareDelay(heavy, now, cost); d != 0 {
+		t.Errorf("shareDelay() without siblings = %v, want 0", d)
+	}
+
+	// The light child is entitled to a quarter of the I/O, and has done half
+	// of it. It must wait for the excess quarter, which took 10ms/4.
+	now += int64(10 * time.Millisecond)
+	if got, want := p.shareDelay(light, now, cost), 10*time.Millisecond/4; got != want {
+		t.Errorf("shareDelay() over share = %v, want %v", got, want)
+	}
+	// The heavy child is entitled to 3/4 of the I/O, and has done 2/3.
+	if d := p.shareDelay(heavy, now, cost); d != 0 {
+		t.Errorf("shareDelay() within share = %v, want 0", d)
+	}
+
+	// Shares are computed over a new window once the current one expires.
+	now += int64(blkioShareWindow)
+	if d := p.shareDelay(light, now, cost); d != 0 {
+		t.Errorf("shareDelay() in a new window = %v, want 0", d)
+	}
+}
+
+func TestBlkioWeightConversion(t *testing.T) {
+	for _, tc := range []struct {
+		blkio uint64
+		io    uint64
+	}{
+		{blkio: blkioWeightDefault, io: ioWeightDefault},
+		{blkio: blkioWeightMin, io: 2},
+		{blkio: blkioWeightMax, io: 200},
+		{blkio: 100, io: 20},
+	} {
+		if got := ioWeightFromBlkioWeight(tc.blkio); got != tc.io {
+			t.Errorf("ioWeightFromBlkioWeight(%d) = %d, want %d", tc.blkio, got, tc.io)
+		}
+		if got := blkioWeightFromIOWeight(tc.io); got != tc.blkio {
+			t.Errorf("blkioWeightFromIOWeight(%d) = %d, want %d", tc.io, got, tc.blkio)
+		}
+	}
+	if got := blkioWeightFromIOWeight(ioWeightMin); got != blkioWeightMin {
+		t.Errorf("blkioWeightFromIOWeight(%d) = %d, want %d", ioWeightMin, got, blkioWeightMin)
+	}
+	if got := blkioWeightFromIOWeight(ioWeightMax); got != blkioWeightMax {
+		t.Errorf("blkioWeightFromIOWeight(%d) = %d, want %d", ioWeightMax, got, blkioWeightMax)
+	}
+}
+
+func TestParseIOMax(t *testing.T) {
+	major, minor, rates, err := parseIOMax("8:16 rbps=1048576 wiops=max\n")
+	if err != nil {
+		t.Fatalf("parseIOMax() failed: %v", err)
+	}
+	if major != 8 || minor != 16 {
+		t.Errorf("parseIOMax() got device %d:%d, want 8:16", major, minor)
+	}
+	if rates[blkioReadBPS] == nil || *rates[blkioReadBPS] != 1048576 {
+		t.Errorf("parseIOMax() didn't set rbps to 1048576")
+	}
+	if rates[blkioWriteIOPS] == nil || *rates[blkioWriteIOPS] != 0 {
+		t.Errorf("parseIOMax() didn't remove the wiops limit")
+	}
+	if rates[blkioWriteBPS] != nil || rates[blkioReadIOPS] != nil {
+		t.Errorf("parseIOMax() set limits that weren't in the rule")
+	}
+
+	for _, rule := range []string{"", "8:16", "8 rbps=1", "8:16 rbps", "8:16 foo=1", "8:16 rbps=0", "8:16 rbps=-1"} {
+		if _, _, _, err := parseIOMax(rule); err == nil {
+			t.Errorf("parseIOMax(%q) succeeded, want error", rule)
+		}
+	}
+}
@@ -86,6 +86,7 @@ const (
 )
 
 var allControllers = []kernel.CgroupControllerType{
+	kernel.CgroupControllerBlkio,
 	kernel.CgroupControllerCPU,
 	kernel.CgroupControllerCPUAcct,
 	kernel.CgroupControllerCPUSet,
@@ -96,7 +97,7 @@ var allControllers = []kernel.CgroupControllerType{
 }
 
 // SupportedMountOptions is the set of supported mount options for cgroupfs.
-var SupportedMountOptions = []string{"all", "cpu", "cpuacct", "cpuset", "devices", "job", "memory", "pids"}
+var SupportedMountOptions = []string{"all", "blkio", "cpu", "cpuacct", "cpuset", "devices", "job", "memory", "pids"}
 
 // FilesystemType implements vfs.FilesystemType.
 //
@@ -216,6 +217,10 @@ func (fsType FilesystemType) GetFilesystem(ctx context.Context, vfsObj *vfs.Virt
 	}
 
 	var wantControllers []kernel.CgroupControllerType
+	if _, ok := mopts["blkio"]; ok {
+		delete(mopts, "blkio")
+		wantControllers = append(wantControllers, kernel.CgroupControllerBlkio)
+	}
 	if _, ok := mopts["cpu"]; ok {
 		delete(mopts, "cpu")
 		wantControllers = append(wantControllers, kernel.CgroupControllerCPU)
@@ -340,6 +345,8 @@ func (fsType FilesystemType) GetFilesystem(ctx context.Context, vfsObj *vfs.Virt
 	for _, ty := range wantControllers {
 		var c controller
 		switch ty {
+		case kernel.CgroupControllerBlkio:
+			c = newBlkioController(fs)
 		case kernel.CgroupControllerCPU:
 			c = newCPUController(fs, defaults)
 		case kernel.CgroupControllerCPUAcct:
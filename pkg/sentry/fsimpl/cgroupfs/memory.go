@@ -84,6 +84,7 @@ func (c *memoryController) Clone() controller {
 func (c *memoryController) AddControlFiles(ctx context.Context, creds *auth.Credentials, cg *cgroupInode, contents map[string]kernfs.Inode) {
 	c.memCg = &memoryCgroup{cg}
 	contents["memory.usage_in_bytes"] = c.fs.newControllerFile(ctx, creds, &memoryUsageInBytesData{memCg: &memoryCgroup{cg}}, true)
+	contents["memory.stat"] = c.fs.newControllerFile(ctx, creds, &memoryStatData{memCg: &memoryCgroup{cg}}, true)
 	contents["memory.limit_in_bytes"] = c.fs.newStubControllerFile(ctx, creds, &c.limitBytes, true)
 	contents["memory.soft_limit_in_bytes"] = c.fs.newStubControllerFile(ctx, creds, &c.softLimitBytes, true)
 	contents["memory.move_charge_at_immigrate"] = c.fs.newStubControllerFile(ctx, creds, &c.moveChargeAtImmigrate, true)
@@ -158,3 +159,45 @@ func (d *memoryUsageInBytesData) Generate(ctx context.Context, buf *bytes.Buffer
 	fmt.Fprintf(buf, "%d\n", totalBytes)
 	return nil
 }
+
+// +stateify savable
+type memoryStatData struct {
+	memCg *memoryCgroup
+}
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+//
+// Only the fields that gVisor tracks per cgroup are reported. As in Linux,
+// "cache" includes "shmem", which is memory used by tmpfs files, including
+// POSIX and System V shared memory.
+func (d *memoryStatData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	k := kernel.KernelFromContext(ctx)
+
+	memCgIDs := make(map[uint32]struct{})
+	d.memCg.collectMemCgIDs(memCgIDs)
+	k.MemoryFile().UpdateUsage(memCgIDs)
+	var self, total usage.MemoryStats
+	for id := range memCgIDs {
+		stats, _ := usage.MemoryAccounting.CopyPerCg(id)
+		if id == d.memCg.ID() {
+			self = stats
+		}
+		total.Anonymous += stats.Anonymous
+		total.PageCache += stats.PageCache
+		total.Tmpfs += stats.Tmpfs
+		total.Mapped += stats.Mapped
+	}
+	for _, s := range []struct {
+		prefix string
+		stats  *usage.MemoryStats
+	}{
+		{"", &self},
+		{"total_", &total},
+	} {
+		fmt.Fprintf(buf, "%scache %d\n", s.prefix, s.stats.PageCache+s.stats.Tmpfs)
+		fmt.Fprintf(buf, "%srss %d\n", s.prefix, s.stats.Anonymous)
+		fmt.Fprintf(buf, "%sshmem %d\n", s.prefix, s.stats.Tmpfs)
+		fmt.Fprintf(buf, "%smapped_file %d\n", s.prefix, s.stats.Mapped)
+	}
+	return nil
+}
@@ -190,6 +190,7 @@ func (fs *filesystem) statFS() linux.Statfs {
 		BlockSize:    blockSize,
 		FragmentSize: blockSize,
 		Blocks:       uint64(fs.image.Blocks()),
+		FSID:         vfs.FSIDFromDevice(linux.UNNAMED_MAJOR, fs.devMinor),
 	}
 }
 
@@ -267,12 +267,26 @@ func (efd *EventFileDescription) Signal(val uint64) error {
 	efd.val += val
 	efd.mu.Unlock()
 
-	// Always trigger a notification.
-	efd.queue.Notify(waiter.ReadableEvents)
+	// Always trigger a notification. Each blocked reader consumes the entire
+	// value, or 1 in semaphore mode, so there is no need to wake more
+	// exclusive waiters than can succeed.
+	efd.queue.NotifyExclusive(waiter.ReadableEvents, efd.readersFor(val))
 
 	return nil
 }
 
+// readersFor returns the maximum number of readers that may succeed after the
+// event counter is incremented by val.
+func (efd *EventFileDescription) readersFor(val uint64) int {
+	if !efd.semMode {
+		return 1
+	}
+	if val > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(val)
+}
+
 // Readiness implements waiter.Waitable.Readiness.
 func (efd *EventFileDescription) Readiness(mask waiter.EventMask) waiter.EventMask {
 	efd.mu.Lock()
@@ -314,11 +328,22 @@ func (efd *EventFileDescription) EventUnregister(entry *waiter.Entry) {
 	efd.queue.EventUnregister(entry)
 
 	efd.mu.Lock()
-	defer efd.mu.Unlock()
 	if efd.hostfd >= 0 {
+		defer efd.mu.Unlock()
 		if err := fdnotifier.UpdateFD(int32(efd.hostfd)); err != nil {
 			panic(fmt.Sprint("UpdateFD:", err))
 		}
+		return
+	}
+	readable := efd.val > 0
+	efd.mu.Unlock()
+
+	// If an exclusive waiter is leaving while the event is still readable,
+	// it may have been woken for a value it did not consume (e.g. because it
+	// was interrupted, or because it only consumed part of a semaphore).
+	// Pass the wakeup on to the next exclusive waiter.
+	if entry.Exclusive() && readable {
+		efd.queue.NotifyExclusive(waiter.ReadableEvents, 1)
 	}
 }
 
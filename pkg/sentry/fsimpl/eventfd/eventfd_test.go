@@ -97,3 +97,64 @@ func TestEventFDStat(t *testing.T) {
 		t.Errorf("eventfd size should be 0")
 	}
 }
+
+func TestEventFDSemaphoreWakesExclusiveWaiters(t *testing.T) {
+	ctx := contexttest.Context(t)
+	vfsObj := &vfs.VirtualFilesystem{}
+	if err := vfsObj.Init(ctx); err != nil {
+		t.Fatalf("VFS init: %v", err)
+	}
+
+	eventfd, err := New(ctx, vfsObj, 0, true /* semMode */, linux.O_RDWR)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer eventfd.DecRef(ctx)
+
+	// Register several exclusive waiters, as blocked readers do.
+	var entries [3]waiter.Entry
+	var chs [3]chan struct{}
+	for i := range entries {
+		entries[i], chs[i] = waiter.NewChannelEntry(waiter.ReadableEvents)
+		entries[i].SetExclusive()
+		if err := eventfd.EventRegister(&entries[i]); err != nil {
+			t.Fatalf("EventRegister(): %v", err)
+		}
+	}
+	notified := func() (n int) {
+		for _, ch := range chs {
+			select {
+			case <-ch:
+				n++
+			default:
+			}
+		}
+		return n
+	}
+
+	// Incrementing the semaphore by 1 should wake exactly one waiter.
+	efd := eventfd.Impl().(*EventFileDescription)
+	if err := efd.Signal(1); err != nil {
+		t.Fatalf("Signal(1): %v", err)
+	}
+	if n := notified(); n != 1 {
+		t.Errorf("Signal(1) woke %d waiters, want 1", n)
+	}
+
+	// If the woken waiter leaves without reading, the wakeup should be
+	// passed on to another waiter.
+	eventfd.EventUnregister(&entries[0])
+	if n := notified(); n != 1 {
+		t.Errorf("EventUnregister of a woken waiter woke %d waiters, want 1", n)
+	}
+
+	// Incrementing the semaphore by 2 should wake both remaining waiters.
+	if err := efd.Signal(2); err != nil {
+		t.Fatalf("Signal(2): %v", err)
+	}
+	if n := notified(); n != 2 {
+		t.Errorf("Signal(2) woke %d waiters, want 2", n)
+	}
+	eventfd.EventUnregister(&entries[1])
+	eventfd.EventUnregister(&entries[2])
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gofer
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/lisafs"
+	"gvisor.dev/gvisor/pkg/sentry/contexttest"
+	"gvisor.dev/gvisor/pkg/sentry/ktime"
+	"gvisor.dev/gvisor/pkg/sentry/pgalloc"
+)
+
+func TestParseOperationAllowlist(t *testing.T) {
+	mopts := map[string]string{
+		moptNoSymlink:  "",
+		moptAppendOnly: "",
+		moptDirectfs:   "",
+	}
+	var opts filesystemOptions
+	opts.parseOperationAllowlist(mopts)
+	if !opts.noSymlink || opts.noUnlink || !opts.appendOnly {
+		t.Errorf("got noSymlink=%t noUnlink=%t appendOnly=%t, want true, false, true", opts.noSymlink, opts.noUnlink, opts.appendOnly)
+	}
+	if len(mopts) != 1 {
+		t.Errorf("unconsumed options %v, want only %q", mopts, moptDirectfs)
+	}
+}
+
+func TestCheckAppendOnlyOpen(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		appendOnly bool
+		flags      uint32
+		want       error
+	}{
+		{name: "read", appendOnly: true, flags: linux.O_RDONLY},
+		{name: "append", appendOnly: true, flags: linux.O_WRONLY | linux.O_APPEND},
+		{name: "read write append", appendOnly: true, flags: linux.O_RDWR | linux.O_APPEND},
+		{name: "write", appendOnly: true, flags: linux.O_WRONLY, want: linuxerr.EPERM},
+		{name: "append truncate", appendOnly: true, flags: linux.O_WRONLY | linux.O_APPEND | linux.O_TRUNC, want: linuxerr.EPERM},
+		{name: "write without option", flags: linux.O_WRONLY | linux.O_TRUNC},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := filesystem{opts: filesystemOptions{appendOnly: tc.appendOnly}}
+			if got := fs.checkAppendOnlyOpen(tc.flags); got != tc.want {
+				t.Errorf("checkAppendOnlyOpen(%#x) = %v, want %v", tc.flags, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAppendOnlyStat(t *testing.T) {
+	ctx := contexttest.Context(t)
+	fs := filesystem{
+		mf:          pgalloc.MemoryFileFromContext(ctx),
+		inoByKey:    make(map[inoKey]uint64),
+		clock:       ktime.RealtimeClockFromContext(ctx),
+		dentryCache: &dentryCache{maxCachedDentries: 0},
+		client:      &lisafs.Client{},
+		opts:        filesystemOptions{appendOnly: true},
+	}
+	for _, tc := range []struct {
+		mode uint32
+		want bool
+	}{
+		{mode: linux.S_IFREG | 0666, want: true},
+		{mode: linux.S_IFDIR | 0777, want: false},
+	} {
+		d, err := fs.newLisafsDentry(ctx, &lisafs.Inode{
+			ControlFD: 1,
+			Stat: linux.Statx{
+				Mask: linux.STATX_TYPE | linux.STATX_MODE,
+				Mode: uint16(tc.mode),
+			},
+		})
+		if err != nil {
+			t.Fatalf("fs.newLisafsDentry(): %v", err)
+		}
+		var stat linux.Statx
+		d.statTo(&stat)
+		if got := stat.Attributes&linux.STATX_ATTR_APPEND != 0; got != tc.want {
+			t.Errorf("mode %#o: STATX_ATTR_APPEND set = %t, want %t", tc.mode, got, tc.want)
+		}
+	}
+}
@@ -234,8 +234,8 @@ func (fs *filesystem) stepLocked(ctx context.Context, rp resolvingPath, d *dentr
 //   - parent and the dentry at name have been revalidated.
 //
 // +checklocks:parent.opMu
-func (fs *filesystem) getChildLocked(ctx context.Context, parent *dentry, name string, ds **[]*dentry) (*dentry, error) {
-	if child, err := parent.getCachedChildLocked(name); child != nil || err != nil {
+func (fs *filesystem) getChildLocked(ctx context.Context, mnt *vfs.Mount, parent *dentry, name string, ds **[]*dentry) (*dentry, error) {
+	if child, err := parent.getCachedChildLocked(mnt, name); child != nil || err != nil {
 		return child, err
 	}
 	// We don't need to check for race here because parent.opMu is held for
@@ -296,7 +296,7 @@ func (fs *filesystem) getRemoteChildLocked(ctx context.Context, parent *dentry,
 //
 // +checklocksread:parent.opMu
 func (fs *filesystem) getChildAndWalkPathLocked(ctx context.Context, parent *dentry, rp resolvingPath, ds **[]*dentry) (*dentry, error) {
-	if child, err := parent.getCachedChildLocked(rp.Component()); child != nil || err != nil {
+	if child, err := parent.getCachedChildLocked(rp.Mount(), rp.Component()); child != nil || err != nil {
 		return child, err
 	}
 	// dentry.getRemoteChildAndWalkPathLocked already handles dentry caching.
@@ -304,7 +304,8 @@ func (fs *filesystem) getChildAndWalkPathLocked(ctx context.Context, parent *den
 }
 
 // getCachedChildLocked returns a child dentry if it was cached earlier. If no
-// cached child dentry exists, (nil, nil) is returned.
+// cached child dentry exists, (nil, nil) is returned. The lookup is accounted
+// to mnt.
 //
 // Preconditions:
 //   - fs.renameMu must be locked.
@@ -314,13 +315,14 @@ func (fs *filesystem) getChildAndWalkPathLocked(ctx context.Context, parent *den
 //   - d and the dentry at name have been revalidated.
 //
 // +checklocksread:d.opMu
-func (d *dentry) getCachedChildLocked(name string) (*dentry, error) {
+func (d *dentry) getCachedChildLocked(mnt *vfs.Mount, name string) (*dentry, error) {
 	if len(name) > MaxFilenameLen {
 		return nil, linuxerr.ENAMETOOLONG
 	}
 	d.childrenMu.Lock()
 	defer d.childrenMu.Unlock()
 	if child, ok := d.children[name]; ok || d.isSynthetic() {
+		accountDentryCacheLookup(mnt, true /* hit */)
 		if child == nil {
 			return nil, linuxerr.ENOENT
 		}
@@ -330,12 +332,41 @@ func (d *dentry) getCachedChildLocked(name string) (*dentry, error) {
 	if d.childrenSet != nil {
 		// Is the child even there? Don't make RPC if not.
 		if _, ok := d.childrenSet[name]; !ok {
+			accountDentryCacheLookup(mnt, true /* hit */)
 			return nil, linuxerr.ENOENT
 		}
 	}
+	accountDentryCacheLookup(mnt, false /* hit */)
 	return nil, nil
 }
 
+// accountDentryCacheLookup accounts a dentry cache lookup to mnt and to the
+// sandbox-wide metrics.
+func accountDentryCacheLookup(mnt *vfs.Mount, hit bool) {
+	if hit {
+		fsmetric.GoferDentryCacheHits.Increment()
+	} else {
+		fsmetric.GoferDentryCacheMisses.Increment()
+	}
+	mnt.AccountDentryCacheLookup(hit)
+}
+
+// accountPageCacheRead accounts a read of done bytes through the page cache,
+// of which missed bytes were not cached, to the sandbox-wide metrics and to
+// mnt if it is not nil.
+func accountPageCacheRead(mnt *vfs.Mount, done, missed uint64) {
+	missed = min(missed, done)
+	if hit := done - missed; hit > 0 {
+		fsmetric.GoferPageCacheHitBytes.IncrementBy(hit)
+	}
+	if missed > 0 {
+		fsmetric.GoferPageCacheMissBytes.IncrementBy(missed)
+	}
+	if mnt != nil {
+		mnt.AccountPageCacheRead(done, missed)
+	}
+}
+
 // walkParentDirLocked resolves all but the last path component of rp to an
 // existing directory, starting from the given directory (which is usually
 // rp.Start().Impl().(*dentry)). It does not check that the returned directory
@@ -456,7 +487,7 @@ func (fs *filesystem) doCreateAt(ctx context.Context, rp *vfs.ResolvingPath, dir
 	}
 	parent.childrenMu.Unlock()
 	checkExistence := func() error {
-		if child, err := fs.getChildLocked(ctx, parent, name, &ds); err != nil && !linuxerr.Equals(linuxerr.ENOENT, err) {
+		if child, err := fs.getChildLocked(ctx, rp.Mount(), parent, name, &ds); err != nil && !linuxerr.Equals(linuxerr.ENOENT, err) {
 			return err
 		} else if child != nil {
 			return linuxerr.EEXIST
@@ -560,6 +591,9 @@ func (fs *filesystem) unlinkAt(ctx context.Context, rp *vfs.ResolvingPath, dir b
 	if err := parent.checkPermissions(rp.Credentials(), vfs.MayWrite|vfs.MayExec); err != nil {
 		return err
 	}
+	if fs.opts.noUnlink {
+		return linuxerr.EPERM
+	}
 	if err := rp.Mount().CheckBeginWrite(); err != nil {
 		return err
 	}
@@ -931,6 +965,19 @@ func (fs *filesystem) MknodAt(ctx context.Context, rp *vfs.ResolvingPath, opts v
 	}, nil)
 }
 
+// checkAppendOnlyOpen returns EPERM if fs is append-only and flags would
+// open a file for writing without O_APPEND, or truncate it. Compare Linux's
+// fs/namei.c:may_open() => IS_APPEND().
+func (fs *filesystem) checkAppendOnlyOpen(flags uint32) error {
+	if !fs.opts.appendOnly || !vfs.MayWriteFileWithOpenFlags(flags) {
+		return nil
+	}
+	if flags&linux.O_APPEND == 0 || flags&linux.O_TRUNC != 0 {
+		return linuxerr.EPERM
+	}
+	return nil
+}
+
 // OpenAt implements vfs.FilesystemImpl.OpenAt.
 func (fs *filesystem) OpenAt(ctx context.Context, rp *vfs.ResolvingPath, opts vfs.OpenOptions) (*vfs.FileDescription, error) {
 	// Reject O_TMPFILE, which is not supported; supporting it correctly in the
@@ -940,6 +987,9 @@ func (fs *filesystem) OpenAt(ctx context.Context, rp *vfs.ResolvingPath, opts vf
 	if opts.Flags&linux.O_TMPFILE != 0 {
 		return nil, linuxerr.EOPNOTSUPP
 	}
+	if err := fs.checkAppendOnlyOpen(opts.Flags); err != nil {
+		return nil, err
+	}
 	mayCreate := opts.Flags&linux.O_CREAT != 0
 	mustCreate := opts.Flags&(linux.O_CREAT|linux.O_EXCL) == (linux.O_CREAT | linux.O_EXCL)
 
@@ -1090,6 +1140,7 @@ func (d *dentry) open(ctx context.Context, rp *vfs.ResolvingPath, opts *vfs.Open
 		// open(2) will change the file size on server.
 		d.metadataMu.Lock()
 		defer d.metadataMu.Unlock()
+		d.directWrites.Wait()
 	}
 
 	var vfd *vfs.FileDescription
@@ -1374,6 +1425,11 @@ func (fs *filesystem) RenameAt(ctx context.Context, rp *vfs.ResolvingPath, oldPa
 	if opts.Flags&^linux.RENAME_NOREPLACE != 0 {
 		return linuxerr.EINVAL
 	}
+	if fs.opts.noUnlink {
+		// Renaming removes oldName from oldParent, and may replace an existing
+		// file at the destination.
+		return linuxerr.EPERM
+	}
 	if fs.opts.interop == InteropModeShared && opts.Flags&linux.RENAME_NOREPLACE != 0 {
 		// Requires 9P support to synchronize with other remote filesystem
 		// users.
@@ -1422,7 +1478,7 @@ func (fs *filesystem) RenameAt(ctx context.Context, rp *vfs.ResolvingPath, oldPa
 	// directory, we need to check for write permission on it.
 	oldParent.opMu.Lock()
 	defer oldParent.opMu.Unlock()
-	renamed, err := fs.getChildLocked(ctx, oldParent, oldName, &ds)
+	renamed, err := fs.getChildLocked(ctx, mnt, oldParent, oldName, &ds)
 	if err != nil {
 		return err
 	}
@@ -1454,7 +1510,7 @@ func (fs *filesystem) RenameAt(ctx context.Context, rp *vfs.ResolvingPath, oldPa
 	if newParent.isDeleted() {
 		return linuxerr.ENOENT
 	}
-	replaced, err := fs.getChildLocked(ctx, newParent, newName, &ds) // +checklocksforce: newParent.opMu taken if newParent != oldParent.
+	replaced, err := fs.getChildLocked(ctx, mnt, newParent, newName, &ds) // +checklocksforce: newParent.opMu taken if newParent != oldParent.
 	if err != nil && !linuxerr.Equals(linuxerr.ENOENT, err) {
 		return err
 	}
@@ -1641,11 +1697,17 @@ func (fs *filesystem) StatFSAt(ctx context.Context, rp *vfs.ResolvingPath) (linu
 	// tests. Testing is important, so instead of defining
 	// something completely random, use a standard value.
 	statfs.Type = linux.V9FS_MAGIC
+	// Don't expose the host filesystem's ID, which is neither unique among
+	// sandbox filesystems nor stable across checkpoint/restore.
+	statfs.FSID = vfs.FSIDFromDevice(linux.UNNAMED_MAJOR, fs.devMinor)
 	return statfs, nil
 }
 
 // SymlinkAt implements vfs.FilesystemImpl.SymlinkAt.
 func (fs *filesystem) SymlinkAt(ctx context.Context, rp *vfs.ResolvingPath, target string) error {
+	if fs.opts.noSymlink {
+		return linuxerr.EPERM
+	}
 	return fs.doCreateAt(ctx, rp, false /* dir */, func(parent *dentry, name string, ds **[]*dentry) (*dentry, error) {
 		child, err := parent.symlink(ctx, name, target, rp.Credentials())
 		if err != nil {
@@ -32,6 +32,7 @@
 //	              dentry.dataMu
 //	          filesystem.ancestryMu
 //	          filesystem.inoMu
+//	    dentry.lockMu
 //	specialFileFD.mu
 //	  specialFileFD.bufMu
 //
@@ -88,6 +89,16 @@ const (
 	moptOverlayfsStaleRead       = "overlayfs_stale_read"
 	moptDisableFileHandleSharing = "disable_file_handle_sharing"
 	moptDisableFifoOpen          = "disable_fifo_open"
+	moptReadahead                = "readahead"
+	moptMaxReadahead             = "readahead_max"
+	moptHostLocks                = "host_locks"
+
+	// Operation allowlist options. These restrict the set of mutations that
+	// the application may perform on the mount, and are enforced before any
+	// RPC is issued to the gofer.
+	moptNoSymlink  = "no_symlink"
+	moptNoUnlink   = "no_unlink"
+	moptAppendOnly = "append_only"
 
 	// Directfs options.
 	moptDirectfs = "directfs"
@@ -101,11 +112,22 @@ const (
 )
 
 // SupportedMountOptions is the set of mount options that can be set externally.
-var SupportedMountOptions = []string{moptOverlayfsStaleRead, moptDisableFileHandleSharing, moptDcache}
+var SupportedMountOptions = []string{moptOverlayfsStaleRead, moptDisableFileHandleSharing, moptDcache, moptNoSymlink, moptNoUnlink, moptAppendOnly, moptReadahead, moptMaxReadahead, moptHostLocks}
+
+// HostLocksMountOption is the mount option that mirrors POSIX-style locks held
+// by applications as host locks. See host_locks.go.
+const HostLocksMountOption = moptHostLocks
 
 const (
 	defaultMaxCachedDentries  = 1000
 	maxCachedNegativeChildren = 1000
+
+	// defaultReadahead is the default readahead window, chosen arbitrarily.
+	defaultReadahead = 64 << 10
+
+	// defaultMaxReadahead is the default maximum readahead window of
+	// sequential reads.
+	defaultMaxReadahead = 2 << 20
 )
 
 // stringFixedCache is a fixed sized cache, once initialized,
@@ -278,6 +300,14 @@ type filesystemOptions struct {
 	// sentry-handled page faults on files for which a host FD is available.
 	limitHostFDTranslation bool
 
+	// readahead is the maximum number of bytes that a read that misses the
+	// page cache reads into it. It can be adjusted per file with fadvise(2).
+	readahead uint64
+
+	// maxReadahead is the readahead window that sequential reads through an
+	// FD may ramp up to; see readaheadState.
+	maxReadahead uint64
+
 	// If overlayfsStaleRead is true, O_RDONLY host FDs provided by the remote
 	// filesystem may not be coherent with writable host FDs opened later, so
 	// all uses of the former must be replaced by uses of the latter. This is
@@ -300,6 +330,25 @@ type filesystemOptions struct {
 	// are disallowed.
 	disableFifoOpen bool
 
+	// If noSymlink is true, application attempts to create symbolic links on
+	// this filesystem fail with EPERM.
+	noSymlink bool
+
+	// If noUnlink is true, application attempts to unlink, rmdir or rename
+	// away existing files on this filesystem fail with EPERM.
+	noUnlink bool
+
+	// If appendOnly is true, files on this filesystem behave as though they
+	// had the append-only inode attribute set (see chattr(1)): they may only
+	// be opened for writing with O_APPEND, and may not be truncated.
+	appendOnly bool
+
+	// If hostLocks is true, POSIX-style locks held by applications on regular
+	// files are mirrored as host locks, so that they also exclude conflicting
+	// locks held outside of the sandbox, e.g. by other sandboxes sharing the
+	// same volume.
+	hostLocks bool
+
 	// directfs holds options for directfs mode.
 	directfs directfsOpts
 }
@@ -471,6 +520,28 @@ func (fstype FilesystemType) GetFilesystem(ctx context.Context, vfsObj *vfs.Virt
 		}
 	}
 
+	// Parse the readahead windows.
+	fsopts.readahead = defaultReadahead
+	if readaheadStr, ok := mopts[moptReadahead]; ok {
+		delete(mopts, moptReadahead)
+		readahead, err := strconv.ParseUint(readaheadStr, 10, 64)
+		if err != nil {
+			ctx.Warningf("gofer.FilesystemType.GetFilesystem: invalid readahead: %s=%s", moptReadahead, readaheadStr)
+			return nil, nil, linuxerr.EINVAL
+		}
+		fsopts.readahead = readahead
+	}
+	fsopts.maxReadahead = defaultMaxReadahead
+	if maxReadaheadStr, ok := mopts[moptMaxReadahead]; ok {
+		delete(mopts, moptMaxReadahead)
+		maxReadahead, err := strconv.ParseUint(maxReadaheadStr, 10, 64)
+		if err != nil {
+			ctx.Warningf("gofer.FilesystemType.GetFilesystem: invalid readahead_max: %s=%s", moptMaxReadahead, maxReadaheadStr)
+			return nil, nil, linuxerr.EINVAL
+		}
+		fsopts.maxReadahead = maxReadahead
+	}
+
 	// Parse the default UID and GID.
 	fsopts.dfltuid = _V9FS_DEFUID
 	if dfltuidstr, ok := mopts[moptDfltUID]; ok {
@@ -521,6 +592,11 @@ func (fstype FilesystemType) GetFilesystem(ctx context.Context, vfsObj *vfs.Virt
 		delete(mopts, moptDirectfs)
 		fsopts.directfs.enabled = true
 	}
+	fsopts.parseOperationAllowlist(mopts)
+	if _, ok := mopts[moptHostLocks]; ok {
+		delete(mopts, moptHostLocks)
+		fsopts.hostLocks = true
+	}
 	// fsopts.regularFilesUseSpecialFileFD can only be enabled by specifying
 	// "cache=none".
 
@@ -592,6 +668,23 @@ func (fstype FilesystemType) GetFilesystem(ctx context.Context, vfsObj *vfs.Virt
 	return &fs.vfsfs, &fs.root.vfsd, nil
 }
 
+// parseOperationAllowlist consumes the operation allowlist options in mopts.
+func (o *filesystemOptions) parseOperationAllowlist(mopts map[string]string) {
+	for _, opt := range []struct {
+		name string
+		val  *bool
+	}{
+		{moptNoSymlink, &o.noSymlink},
+		{moptNoUnlink, &o.noUnlink},
+		{moptAppendOnly, &o.appendOnly},
+	} {
+		if _, ok := mopts[opt.name]; ok {
+			delete(mopts, opt.name)
+			*opt.val = true
+		}
+	}
+}
+
 // initClientAndGetRoot initializes fs.client and returns the root inode for
 // this mount point. It handles the attach point (fs.opts.aname) resolution.
 func (fs *filesystem) initClientAndGetRoot(ctx context.Context) (lisafs.Inode, int, error) {
@@ -636,6 +729,10 @@ func (fs *filesystem) initClientAndGetRoot(ctx context.Context) (lisafs.Inode, i
 			_ = unix.Close(rootHostFD)
 			rootHostFD = -1
 		}
+		if fs.opts.hostLocks && !fs.client.IsSupported(lisafs.FRangeLock) {
+			log.Warningf("gofer server does not support host locks, required by the %s mount option", moptHostLocks)
+			return lisafs.Inode{}, -1, unix.EINVAL
+		}
 		// Use flipcall channels with lisafs because it makes a lot of RPCs.
 		if err := fs.client.StartChannels(); err != nil {
 			return lisafs.Inode{}, -1, err
@@ -925,6 +1022,12 @@ type dentry struct {
 	//	- size is protected by both metadataMu and dataMu (i.e. both must be
 	//		locked to mutate it; locking either is sufficient to access it).
 	size atomicbitops.Uint64
+	// directWrites tracks O_DIRECT writes that overwrite existing file
+	// contents without holding metadataMu (see regularFileFD.pwrite). Adding
+	// to directWrites requires holding metadataMu; operations that shrink the
+	// file must wait for it with metadataMu locked before truncating the
+	// remote file.
+	directWrites sync.WaitGroup `state:"nosave"`
 	// If this dentry does not represent a synthetic file, deleted is 0, and
 	// atimeDirty/mtimeDirty are non-zero, atime/mtime may have diverged from the
 	// remote file's timestamps, which should be updated when this dentry is
@@ -1010,6 +1113,18 @@ type dentry struct {
 
 	locks vfs.FileLocks
 
+	// lockMu serializes updates of POSIX-style locks in locks that are
+	// mirrored as host locks.
+	lockMu sync.Mutex `state:"nosave"`
+
+	// If this dentry represents a regular file on a filesystem with
+	// filesystemOptions.hostLocks, lockHandle (if not nil) is the handle on
+	// which POSIX-style locks in locks are mirrored as host locks. See
+	// host_locks.go.
+	//
+	// +checklocks:lockMu
+	lockHandle *handle `state:"nosave"`
+
 	// Inotify watches for this dentry.
 	//
 	// Note that inotify may behave unexpectedly in the presence of hard links,
@@ -1222,6 +1337,12 @@ func (d *dentry) statTo(stat *linux.Statx) {
 	stat.Mtime = linux.NsecToStatxTimestamp(d.mtime.Load())
 	stat.DevMajor = linux.UNNAMED_MAJOR
 	stat.DevMinor = d.fs.devMinor
+	if d.fs.opts.appendOnly && d.isRegularFile() {
+		// This makes vfs.FileDescription.SetStatusFlags() refuse to clear
+		// O_APPEND, as for Linux's S_APPEND inodes.
+		stat.AttributesMask |= linux.STATX_ATTR_APPEND
+		stat.Attributes |= linux.STATX_ATTR_APPEND
+	}
 }
 
 // Precondition: fs.renameMu is locked.
@@ -1247,7 +1368,10 @@ func (d *dentry) setStat(ctx context.Context, creds *auth.Credentials, opts *vfs
 		// filesystem implementations may return the wrong errno.
 		switch mode.FileType() {
 		case linux.S_IFREG:
-			// ok
+			// Compare Linux's fs/open.c:vfs_truncate() => IS_APPEND().
+			if d.fs.opts.appendOnly {
+				return linuxerr.EPERM
+			}
 		case linux.S_IFDIR:
 			return linuxerr.EISDIR
 		default:
@@ -1278,6 +1402,10 @@ func (d *dentry) setStat(ctx context.Context, creds *auth.Credentials, opts *vfs
 	d.metadataMu.Lock()
 	defer d.metadataMu.Unlock()
 
+	if stat.Mask&linux.STATX_SIZE != 0 {
+		d.directWrites.Wait()
+	}
+
 	isOwnerChanging := false
 	if stat.Mask&linux.STATX_UID != 0 {
 		if stat.UID == d.uid.RacyLoad() {
@@ -1745,6 +1873,15 @@ func (fs *filesystem) evictAllCachedDentriesLocked(ctx context.Context) {
 	}
 }
 
+var _ vfs.FilesystemImplCacheExtension = (*filesystem)(nil)
+
+// DropDentryCache implements vfs.FilesystemImplCacheExtension.DropDentryCache.
+func (fs *filesystem) DropDentryCache(ctx context.Context) {
+	fs.renameMu.Lock()
+	defer fs.renameMu.Unlock()
+	fs.evictAllCachedDentriesLocked(ctx)
+}
+
 // Preconditions:
 //   - fs.renameMu must be locked for writing; it may be temporarily unlocked.
 //
@@ -1860,6 +1997,13 @@ func (d *dentry) destroyDisconnected(ctx context.Context) {
 	d.mmapFD = atomicbitops.FromInt32(-1)
 	d.handleMu.Unlock()
 
+	d.lockMu.Lock()
+	if d.lockHandle != nil {
+		d.lockHandle.close(ctx)
+		d.lockHandle = nil
+	}
+	d.lockMu.Unlock()
+
 	if !d.isSynthetic() {
 		// Note that it's possible that d.atimeDirty or d.mtimeDirty are true,
 		// i.e. client and server timestamps may differ (because e.g. a client
@@ -2133,11 +2277,17 @@ func (d *dentry) syncRemoteFileLocked(ctx context.Context) error {
 	// Prefer syncing write handles over read handles, since some remote
 	// filesystem implementations may not sync changes made through write
 	// handles otherwise.
+	//
+	// Errors from syncing the write handle are returned, since they indicate
+	// that written data may not have reached the remote file (e.g. for
+	// fsync(2) and msync(MS_SYNC)). Errors from syncing the read handle are
+	// ignored, since some remote filesystem implementations don't support
+	// syncing files opened read-only.
 	wh := d.writeHandle()
-	wh.sync(ctx)
+	err := wh.sync(ctx)
 	rh := d.readHandle()
 	rh.sync(ctx)
-	return nil
+	return err
 }
 
 func (d *dentry) syncCachedFile(ctx context.Context, forFilesystemSync bool) error {
@@ -2265,6 +2415,9 @@ func (fd *fileDescription) LockBSD(ctx context.Context, uid fslock.UniqueID, own
 
 // LockPOSIX implements vfs.FileDescriptionImpl.LockPOSIX.
 func (fd *fileDescription) LockPOSIX(ctx context.Context, uid fslock.UniqueID, ownerPID int32, t fslock.LockType, r fslock.LockRange, block bool) error {
+	if d := fd.dentry(); d.usesHostLocks() {
+		return d.lockPOSIXHost(ctx, uid, ownerPID, t, r, block)
+	}
 	fd.lockLogging.Do(func() {
 		log.Infof("Range lock using gofer file handled internally.")
 	})
@@ -2273,9 +2426,20 @@ func (fd *fileDescription) LockPOSIX(ctx context.Context, uid fslock.UniqueID, o
 
 // UnlockPOSIX implements vfs.FileDescriptionImpl.UnlockPOSIX.
 func (fd *fileDescription) UnlockPOSIX(ctx context.Context, uid fslock.UniqueID, r fslock.LockRange) error {
+	if d := fd.dentry(); d.usesHostLocks() {
+		return d.unlockPOSIXHost(ctx, uid, r)
+	}
 	return fd.Locks().UnlockPOSIX(ctx, uid, r)
 }
 
+// TestPOSIX implements vfs.FileDescriptionImpl.TestPOSIX.
+func (fd *fileDescription) TestPOSIX(ctx context.Context, uid fslock.UniqueID, t fslock.LockType, r fslock.LockRange) (linux.Flock, error) {
+	if d := fd.dentry(); d.usesHostLocks() {
+		return d.testPOSIXHost(ctx, uid, t, r)
+	}
+	return fd.Locks().TestPOSIX(ctx, uid, t, r)
+}
+
 // resolvingPath is just a wrapper around *vfs.ResolvingPath. It additionally
 // holds some information around the intent behind resolving the path.
 type resolvingPath struct {
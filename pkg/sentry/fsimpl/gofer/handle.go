@@ -16,7 +16,9 @@ package gofer
 
 import (
 	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/lisafs"
 	"gvisor.dev/gvisor/pkg/safemem"
 	"gvisor.dev/gvisor/pkg/sentry/hostfd"
@@ -62,6 +64,20 @@ func (h *handle) readToBlocksAt(ctx context.Context, dsts safemem.BlockSeq, offs
 	return safemem.FromIOReader{rw}.ReadToBlocks(dsts)
 }
 
+// readToBlocksAtNoWait is equivalent to readToBlocksAt, but fails with
+// EAGAIN rather than waiting for data that isn't in the host page cache.
+// Reads that must go through the gofer always block, so they fail
+// immediately.
+func (h *handle) readToBlocksAtNoWait(ctx context.Context, dsts safemem.BlockSeq, offset uint64) (uint64, error) {
+	if dsts.IsEmpty() {
+		return 0, nil
+	}
+	if h.fd < 0 {
+		return 0, linuxerr.ErrWouldBlock
+	}
+	return hostfd.Preadv2(h.fd, dsts, int64(offset), linux.RWF_NOWAIT)
+}
+
 func (h *handle) writeFromBlocksAt(ctx context.Context, srcs safemem.BlockSeq, offset uint64) (uint64, error) {
 	if srcs.IsEmpty() {
 		return 0, nil
@@ -102,6 +118,20 @@ func (h *handle) sync(ctx context.Context) error {
 	return nil
 }
 
+// rangeLock sets (cmd = F_OFD_SETLK) or tests (cmd = F_OFD_GETLK) a host open
+// file description lock on h without blocking.
+func (h *handle) rangeLock(ctx context.Context, cmd int, lock *unix.Flock_t) error {
+	// Without directfs, syscall filters don't allow locking host FDs, even if
+	// the gofer donated one.
+	if h.fdLisa.Ok() {
+		return h.fdLisa.RangeLock(ctx, cmd, lock)
+	}
+	if h.fd >= 0 {
+		return unix.FcntlFlock(uintptr(h.fd), cmd, lock)
+	}
+	return unix.ENOLCK
+}
+
 type handleReadWriter struct {
 	ctx context.Context
 	h   handle
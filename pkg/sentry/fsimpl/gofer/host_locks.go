@@ -0,0 +1,308 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gofer
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/log"
+	fslock "gvisor.dev/gvisor/pkg/sentry/fsimpl/lock"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// On filesystems mounted with the host_locks option, POSIX-style locks held by
+// applications on regular files are mirrored as host locks, so that
+// applications coordinate with lock holders outside of the sandbox (e.g.
+// SQLite databases shared by multiple sandboxes).
+//
+// Locks are still tracked in dentry.locks, which arbitrates between lock
+// holders in the sandbox. In addition, the host holds open file description
+// (OFD) locks on dentry.lockHandle such that, on each byte of the file, it
+// holds a write lock if an application holds a write lock, a read lock if
+// applications only hold read locks, and no lock otherwise. Host locks are
+// acquired before the corresponding application locks, so an application never
+// holds a lock that conflicts with a host lock held outside of the sandbox.
+//
+// Blocking lock requests wait for conflicting locks held in the sandbox like on
+// other files, through dentry.locks. F_OFD_SETLKW can't be used to wait for
+// conflicting host locks without blocking the gofer or the sentry
+// indefinitely, so blocking lock requests poll for them instead.
+//
+// Host locks are not saved; they are re-acquired when the filesystem is
+// restored.
+
+const (
+	// hostLockMinPoll and hostLockMaxPoll bound the interval between attempts
+	// of blocking lock requests.
+	hostLockMinPoll = time.Millisecond
+	hostLockMaxPoll = 100 * time.Millisecond
+)
+
+// usesHostLocks returns true if POSIX-style locks on d are mirrored as host
+// locks.
+func (d *dentry) usesHostLocks() bool {
+	return d.fs.opts.hostLocks && d.isRegularFile() && !d.isSynthetic()
+}
+
+// lockPOSIXHost implements vfs.FileDescriptionImpl.LockPOSIX for dentries for
+// which d.usesHostLocks() is true.
+func (d *dentry) lockPOSIXHost(ctx context.Context, uid fslock.UniqueID, ownerPID int32, t fslock.LockType, r fslock.LockRange, block bool) error {
+	delay := hostLockMinPoll
+	for {
+		hostConflict, err := d.tryLockPOSIXHost(ctx, uid, ownerPID, t, r)
+		if err != linuxerr.ErrWouldBlock || !block {
+			return err
+		}
+		if !hostConflict {
+			// Wait for the conflicting locks to be released, as for files
+			// without host locks.
+			if err := d.locks.WaitPOSIX(ctx, uid, t, r); err != nil {
+				return err
+			}
+			delay = hostLockMinPoll
+			continue
+		}
+		var q waiter.NeverReady
+		if left, ok := ctx.BlockWithTimeoutOn(&q, waiter.EventIn, delay); !ok && left != 0 {
+			return linuxerr.ERESTARTSYS
+		}
+		delay = min(2*delay, hostLockMaxPoll)
+	}
+}
+
+// tryLockPOSIXHost tries to acquire a lock without blocking. If it returns
+// linuxerr.ErrWouldBlock, hostConflict is true if the conflicting lock is only
+// held outside of the sandbox.
+func (d *dentry) tryLockPOSIXHost(ctx context.Context, uid fslock.UniqueID, ownerPID int32, t fslock.LockType, r fslock.LockRange) (hostConflict bool, err error) {
+	// d.fs.renameMu is required to open d.lockHandle.
+	d.fs.renameMu.RLock()
+	defer d.fs.renameMu.RUnlock()
+	d.lockMu.Lock()
+	defer d.lockMu.Unlock()
+
+	// Check for conflicts in the sandbox first, so that they are waited for
+	// through d.locks. d.locks can't change while d.lockMu is locked.
+	flock, err := d.locks.TestPOSIX(ctx, uid, t, r)
+	if err != nil {
+		return false, err
+	}
+	if flock.Type != linux.F_UNLCK {
+		return false, linuxerr.ErrWouldBlock
+	}
+
+	h, err := d.lockHandleLocked(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	switch t {
+	case fslock.WriteLock:
+		err = setHostLock(ctx, h, unix.F_WRLCK, r)
+	case fslock.ReadLock:
+		// Only lock parts of r on which no lock is held, since write locks
+		// held by applications must not be downgraded.
+		for _, region := range d.hostLockRegionsLocked(r) {
+			if region.typ != unix.F_UNLCK {
+				continue
+			}
+			if err = setHostLock(ctx, h, unix.F_RDLCK, region.r); err != nil {
+				break
+			}
+		}
+	}
+	hostConflict = err == linuxerr.ErrWouldBlock
+	if err == nil {
+		err = d.locks.LockPOSIX(ctx, uid, ownerPID, t, r, false /* block */)
+	}
+	// Release host locks that are no longer held by applications (e.g. if
+	// they downgraded a write lock), or that were acquired above for a lock
+	// that applications couldn't acquire.
+	d.syncHostLocksLocked(ctx, h, r)
+	return hostConflict, err
+}
+
+// unlockPOSIXHost implements vfs.FileDescriptionImpl.UnlockPOSIX for dentries
+// for which d.usesHostLocks() is true.
+func (d *dentry) unlockPOSIXHost(ctx context.Context, uid fslock.UniqueID, r fslock.LockRange) error {
+	d.lockMu.Lock()
+	defer d.lockMu.Unlock()
+	if err := d.locks.UnlockPOSIX(ctx, uid, r); err != nil {
+		return err
+	}
+	if d.lockHandle != nil {
+		d.syncHostLocksLocked(ctx, d.lockHandle, r)
+	}
+	return nil
+}
+
+// testPOSIXHost implements vfs.FileDescriptionImpl.TestPOSIX for dentries for
+// which d.usesHostLocks() is true.
+func (d *dentry) testPOSIXHost(ctx context.Context, uid fslock.UniqueID, t fslock.LockType, r fslock.LockRange) (linux.Flock, error) {
+	flock, err := d.locks.TestPOSIX(ctx, uid, t, r)
+	if err != nil || flock.Type != linux.F_UNLCK {
+		return flock, err
+	}
+
+	d.fs.renameMu.RLock()
+	defer d.fs.renameMu.RUnlock()
+	d.lockMu.Lock()
+	defer d.lockMu.Unlock()
+	h, err := d.lockHandleLocked(ctx)
+	if err != nil {
+		return flock, err
+	}
+	typ := int16(unix.F_RDLCK)
+	if t == fslock.WriteLock {
+		typ = unix.F_WRLCK
+	}
+	hostLock := hostFlock(typ, r)
+	// Locks held on h itself never conflict.
+	if err := h.rangeLock(ctx, unix.F_OFD_GETLK, &hostLock); err != nil {
+		return flock, err
+	}
+	if hostLock.Type == unix.F_UNLCK {
+		return flock, nil
+	}
+	// The conflicting lock is held outside of the sandbox, so it has no
+	// meaningful owner.
+	return linux.Flock{
+		Type:   hostLock.Type,
+		Whence: linux.SEEK_SET,
+		Start:  hostLock.Start,
+		Len:    hostLock.Len,
+		PID:    -1,
+	}, nil
+}
+
+// lockHandleLocked returns d.lockHandle, opening it if necessary.
+//
+// Preconditions: d.fs.renameMu must be locked.
+//
+// +checklocks:d.lockMu
+func (d *dentry) lockHandleLocked(ctx context.Context) (*handle, error) {
+	if d.lockHandle != nil {
+		return d.lockHandle, nil
+	}
+	// Write locks require a writable handle. If the file can't be opened for
+	// writing, applications can't open it for writing either, so they can
+	// only take read locks.
+	h, err := d.openHandle(ctx, true /* read */, true /* write */, false /* trunc */)
+	if err != nil {
+		h, err = d.openHandle(ctx, true /* read */, false /* write */, false /* trunc */)
+		if err != nil {
+			return nil, err
+		}
+	}
+	d.lockHandle = &h
+	return d.lockHandle, nil
+}
+
+// restoreHostLocks re-acquires the host locks corresponding to the locks held
+// by applications on d when the sandbox was saved.
+//
+// Preconditions: d.usesHostLocks().
+func (d *dentry) restoreHostLocks(ctx context.Context) error {
+	d.fs.renameMu.RLock()
+	defer d.fs.renameMu.RUnlock()
+	d.lockMu.Lock()
+	defer d.lockMu.Unlock()
+	for _, region := range d.hostLockRegionsLocked(fslock.LockRange{0, fslock.LockEOF}) {
+		if region.typ == unix.F_UNLCK {
+			continue
+		}
+		h, err := d.lockHandleLocked(ctx)
+		if err != nil {
+			return err
+		}
+		if err := setHostLock(ctx, h, region.typ, region.r); err != nil {
+			return fmt.Errorf("failed to re-acquire host lock of type %d on %v: %w", region.typ, region.r, err)
+		}
+	}
+	return nil
+}
+
+// hostLockRegion is a byte range of a file, with the type of the host lock
+// that should be held on it.
+type hostLockRegion struct {
+	r   fslock.LockRange
+	typ int16
+}
+
+// hostLockRegionsLocked partitions r into hostLockRegions, according to the
+// locks held by applications.
+//
+// +checklocks:d.lockMu
+func (d *dentry) hostLockRegionsLocked(r fslock.LockRange) []hostLockRegion {
+	var regions []hostLockRegion
+	pos := r.Start
+	d.locks.HeldRegionsPOSIX(r, func(held fslock.LockRange, t fslock.LockType) {
+		if pos < held.Start {
+			regions = append(regions, hostLockRegion{fslock.LockRange{pos, held.Start}, unix.F_UNLCK})
+		}
+		typ := int16(unix.F_RDLCK)
+		if t == fslock.WriteLock {
+			typ = unix.F_WRLCK
+		}
+		regions = append(regions, hostLockRegion{held, typ})
+		pos = held.End
+	})
+	if pos < r.End {
+		regions = append(regions, hostLockRegion{fslock.LockRange{pos, r.End}, unix.F_UNLCK})
+	}
+	return regions
+}
+
+// syncHostLocksLocked updates the host locks held on h over r to match the
+// locks held by applications. Failures are logged rather than returned, since
+// they can't be reported to the application that caused the update.
+//
+// +checklocks:d.lockMu
+func (d *dentry) syncHostLocksLocked(ctx context.Context, h *handle, r fslock.LockRange) {
+	for _, region := range d.hostLockRegionsLocked(r) {
+		if err := setHostLock(ctx, h, region.typ, region.r); err != nil {
+			log.Warningf("gofer.dentry.syncHostLocksLocked: failed to set host lock of type %d on %v: %v", region.typ, region.r, err)
+		}
+	}
+}
+
+// setHostLock sets a host lock of the given type on r without blocking. It
+// returns linuxerr.ErrWouldBlock if a conflicting lock is held.
+func setHostLock(ctx context.Context, h *handle, typ int16, r fslock.LockRange) error {
+	lock := hostFlock(typ, r)
+	err := h.rangeLock(ctx, unix.F_OFD_SETLK, &lock)
+	if err == unix.EAGAIN || err == unix.EACCES {
+		return linuxerr.ErrWouldBlock
+	}
+	return err
+}
+
+// hostFlock returns the unix.Flock_t describing a lock of the given type on r.
+func hostFlock(typ int16, r fslock.LockRange) unix.Flock_t {
+	lock := unix.Flock_t{
+		Type:   typ,
+		Whence: unix.SEEK_SET,
+		Start:  int64(r.Start),
+	}
+	// A zero length extends the lock to the end of the file.
+	if r.End != fslock.LockEOF {
+		lock.Len = int64(r.Length())
+	}
+	return lock
+}
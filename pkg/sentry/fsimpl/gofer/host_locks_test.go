@@ -0,0 +1,227 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gofer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/lisafs"
+	"gvisor.dev/gvisor/pkg/sentry/contexttest"
+	fslock "gvisor.dev/gvisor/pkg/sentry/fsimpl/lock"
+	"gvisor.dev/gvisor/pkg/sentry/ktime"
+	"gvisor.dev/gvisor/pkg/sentry/pgalloc"
+)
+
+// newHostLocksTestDentry returns a directfs dentry for a regular file on a
+// filesystem with host locks, and the host path of the file.
+func newHostLocksTestDentry(t *testing.T, ctx context.Context) (*dentry, string) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+	if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+
+	fs := &filesystem{
+		mf:       pgalloc.MemoryFileFromContext(ctx),
+		inoByKey: make(map[inoKey]uint64),
+		clock:    ktime.RealtimeClockFromContext(ctx),
+		// Keep dentries cached until the test completes.
+		dentryCache: &dentryCache{maxCachedDentries: 10},
+		client:      &lisafs.Client{},
+		opts: filesystemOptions{
+			hostLocks: true,
+			directfs:  directfsOpts{enabled: true},
+		},
+	}
+	dirFD, err := unix.Open(dir, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("error opening %q: %v", dir, err)
+	}
+	parent, err := fs.newDirectfsDentry(dirFD)
+	if err != nil {
+		t.Fatalf("fs.newDirectfsDentry(): %v", err)
+	}
+	fileFD, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("error opening %q: %v", path, err)
+	}
+	d, err := fs.newDirectfsDentry(fileFD)
+	if err != nil {
+		t.Fatalf("fs.newDirectfsDentry(): %v", err)
+	}
+	parent.opMu.Lock()
+	parent.childrenMu.Lock()
+	parent.cacheNewChildLocked(d, "file")
+	parent.childrenMu.Unlock()
+	parent.opMu.Unlock()
+	t.Cleanup(func() {
+		d.lockMu.Lock()
+		if d.lockHandle != nil {
+			d.lockHandle.close(ctx)
+		}
+		d.lockMu.Unlock()
+		unix.Close(fileFD)
+		unix.Close(dirFD)
+	})
+
+	if !d.usesHostLocks() {
+		t.Fatalf("dentry for a regular file doesn't use host locks")
+	}
+	return d, path
+}
+
+// outsideLock tries to take a lock of the given type on [start, end) through a
+// host open file description other than the sandbox's, i.e. like another
+// sandbox sharing the file. It returns unix.EAGAIN if a conflicting lock is
+// held.
+func outsideLock(t *testing.T, path string, typ int16, start, end int64) error {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("error opening %q: %v", path, err)
+	}
+	t.Cleanup(func() { f.Close() })
+	lock := unix.Flock_t{
+		Type:   typ,
+		Whence: unix.SEEK_SET,
+		Start:  start,
+		Len:    end - start,
+	}
+	return unix.FcntlFlock(f.Fd(), unix.F_OFD_SETLK, &lock)
+}
+
+func TestHostLocksExcludeOutsideLocks(t *testing.T) {
+	ctx := contexttest.Context(t)
+	d, path := newHostLocksTestDentry(t, ctx)
+
+	if err := d.lockPOSIXHost(ctx, 1, 1, fslock.WriteLock, fslock.LockRange{0, 10}, false /* block */); err != nil {
+		t.Fatalf("lockPOSIXHost(): %v", err)
+	}
+	if err := outsideLock(t, path, unix.F_RDLCK, 5, 15); err != unix.EAGAIN {
+		t.Errorf("outside read lock on a write locked range got %v, want EAGAIN", err)
+	}
+	if err := outsideLock(t, path, unix.F_WRLCK, 10, 20); err != nil {
+		t.Errorf("outside write lock on an unlocked range got %v, want nil", err)
+	}
+
+	// Downgrading the write lock downgrades the host lock.
+	if err := d.lockPOSIXHost(ctx, 1, 1, fslock.ReadLock, fslock.LockRange{0, 10}, false /* block */); err != nil {
+		t.Fatalf("lockPOSIXHost(): %v", err)
+	}
+	if err := outsideLock(t, path, unix.F_RDLCK, 0, 10); err != nil {
+		t.Errorf("outside read lock on a read locked range got %v, want nil", err)
+	}
+
+	if err := d.unlockPOSIXHost(ctx, 1, fslock.LockRange{0, 10}); err != nil {
+		t.Fatalf("unlockPOSIXHost(): %v", err)
+	}
+	if err := outsideLock(t, path, unix.F_WRLCK, 0, 10); err != nil {
+		t.Errorf("outside write lock after unlock got %v, want nil", err)
+	}
+}
+
+func TestHostLocksOutsideConflict(t *testing.T) {
+	ctx := contexttest.Context(t)
+	d, path := newHostLocksTestDentry(t, ctx)
+
+	if err := outsideLock(t, path, unix.F_WRLCK, 0, 10); err != nil {
+		t.Fatalf("outside write lock: %v", err)
+	}
+	if err := d.lockPOSIXHost(ctx, 1, 1, fslock.ReadLock, fslock.LockRange{5, 15}, false /* block */); err != linuxerr.ErrWouldBlock {
+		t.Errorf("lockPOSIXHost() on an outside write locked range got %v, want ErrWouldBlock", err)
+	}
+	// The failed request must not leave host locks behind.
+	if err := outsideLock(t, path, unix.F_WRLCK, 10, 15); err != nil {
+		t.Errorf("outside write lock after a failed request got %v, want nil", err)
+	}
+
+	flock, err := d.testPOSIXHost(ctx, 1, fslock.WriteLock, fslock.LockRange{0, 20})
+	if err != nil {
+		t.Fatalf("testPOSIXHost(): %v", err)
+	}
+	if flock.Type != linux.F_WRLCK || flock.PID != -1 {
+		t.Errorf("testPOSIXHost() got %+v, want a write lock with PID -1", flock)
+	}
+}
+
+// TestHostLocksBlockInSandbox tests that blocking lock requests that conflict
+// with locks held in the sandbox are woken up when the locks are released.
+func TestHostLocksBlockInSandbox(t *testing.T) {
+	ctx := contexttest.Context(t)
+	d, _ := newHostLocksTestDentry(t, ctx)
+
+	if err := d.lockPOSIXHost(ctx, 1, 1, fslock.WriteLock, fslock.LockRange{0, 10}, false /* block */); err != nil {
+		t.Fatalf("lockPOSIXHost(): %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		ctx := contexttest.Context(t)
+		done <- d.lockPOSIXHost(ctx, 2, 2, fslock.WriteLock, fslock.LockRange{0, 10}, true /* block */)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("blocking lockPOSIXHost() returned %v while the lock is held", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := d.unlockPOSIXHost(ctx, 1, fslock.LockRange{0, 10}); err != nil {
+		t.Fatalf("unlockPOSIXHost(): %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("blocking lockPOSIXHost(): %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("blocking lockPOSIXHost() didn't return after the lock was released")
+	}
+}
+
+func TestHostLocksRestore(t *testing.T) {
+	ctx := contexttest.Context(t)
+	d, path := newHostLocksTestDentry(t, ctx)
+
+	// After restore, locks are held in the sandbox without host locks.
+	if err := d.locks.LockPOSIX(ctx, 1, 1, fslock.WriteLock, fslock.LockRange{0, 10}, false /* block */); err != nil {
+		t.Fatalf("LockPOSIX(): %v", err)
+	}
+	if err := d.fs.restoreHostLocks(ctx); err != nil {
+		t.Fatalf("restoreHostLocks(): %v", err)
+	}
+	if err := outsideLock(t, path, unix.F_RDLCK, 0, 10); err != unix.EAGAIN {
+		t.Errorf("outside read lock on a restored write lock got %v, want EAGAIN", err)
+	}
+}
+
+func TestHostLocksRestoreConflict(t *testing.T) {
+	ctx := contexttest.Context(t)
+	d, path := newHostLocksTestDentry(t, ctx)
+
+	if err := d.locks.LockPOSIX(ctx, 1, 1, fslock.WriteLock, fslock.LockRange{0, 10}, false /* block */); err != nil {
+		t.Fatalf("LockPOSIX(): %v", err)
+	}
+	if err := outsideLock(t, path, unix.F_RDLCK, 5, 6); err != nil {
+		t.Fatalf("outside read lock: %v", err)
+	}
+	if err := d.fs.restoreHostLocks(ctx); err == nil {
+		t.Errorf("restoreHostLocks() succeeded with a conflicting outside lock, want error")
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gofer
+
+import "gvisor.dev/gvisor/pkg/state"
+
+// Migrations allow checkpoints saved by earlier releases to be restored; see
+// pkg/state/README.md.
+func init() {
+	// The following migrations were added in state version 3.
+	state.RegisterMigrations((*filesystemOptions)(nil), state.Migration{
+		Added: []string{"readahead", "maxReadahead", "hostLocks"},
+		Migrate: func(obj any, src state.MigrationSource) {
+			opts := obj.(*filesystemOptions)
+			opts.readahead = defaultReadahead
+			opts.maxReadahead = defaultMaxReadahead
+		},
+	})
+	// Cache statistics moved to vfs.Mount.
+	state.RegisterMigrations((*filesystem)(nil), state.Migration{
+		Removed: []string{"dentryCacheHits", "dentryCacheMisses", "pageCacheHitBytes", "pageCacheMissBytes"},
+	})
+	state.RegisterMigrations((*regularFileFD)(nil), state.Migration{
+		Added: []string{"advice"},
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gofer
+
+import (
+	"gvisor.dev/gvisor/pkg/sync"
+)
+
+// readaheadState tracks the access pattern of reads through a regular file FD
+// to size its readahead window, analogously to Linux's struct file_ra_state.
+//
+// While reads are sequential, each read that misses the page cache reads
+// ahead twice as much as the previous one, up to a per-mount maximum. Any
+// other read resets the window to its initial size. If a sequential read
+// misses pages that should have been read ahead by the previous read, they
+// were evicted before they could be used, so the window is halved and kept
+// there until the sequential stream ends.
+type readaheadState struct {
+	mu sync.Mutex
+
+	// prevEnd is the end offset of the previous read.
+	prevEnd uint64
+
+	// window is the readahead window of the current read, in bytes, or 0 if
+	// no read is in progress since the last reset.
+	window uint64
+
+	// initial is the window that the current sequential stream started with.
+	initial uint64
+
+	// limit, if non-zero, is the maximum window for the current sequential
+	// stream, set when thrashing is detected.
+	limit uint64
+
+	// raEnd is the end offset of the range that the previous read may have
+	// read ahead.
+	raEnd uint64
+}
+
+// start returns the readahead window for a read at offset off. initial is the
+// window that a new sequential stream starts with, and maxWindow is the
+// largest window that it may ramp up to.
+func (ra *readaheadState) start(off, initial, maxWindow uint64) uint64 {
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	if off != ra.prevEnd || initial != ra.initial || initial == 0 {
+		ra.window = 0
+		ra.limit = 0
+		ra.raEnd = 0
+	}
+	ra.initial = initial
+	if ra.window == 0 {
+		ra.window = initial
+		return ra.window
+	}
+	maxWindow = max(maxWindow, initial)
+	if ra.limit != 0 {
+		maxWindow = min(maxWindow, ra.limit)
+	}
+	ra.window = min(2*ra.window, maxWindow)
+	return ra.window
+}
+
+// finish records the completion of a read started with start at offset off,
+// which read n bytes of which missed missed the page cache.
+func (ra *readaheadState) finish(off, n, missed uint64) {
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	end := off + n
+	// Bytes after raEnd are expected to miss.
+	expectedMissed := end - min(max(off, ra.raEnd), end)
+	if missed > expectedMissed && ra.window > ra.initial {
+		ra.limit = max(ra.window/2, ra.initial)
+		ra.window = ra.limit
+	}
+	ra.prevEnd = end
+	ra.raEnd = max(end, off+ra.window)
+}
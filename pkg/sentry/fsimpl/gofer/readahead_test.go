@@ -0,0 +1,80 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gofer
+
+import (
+	"testing"
+)
+
+func TestReadaheadRampUp(t *testing.T) {
+	const (
+		initial   = 64 << 10
+		maxWindow = 1 << 20
+		readSize  = 4 << 10
+	)
+	var ra readaheadState
+	var off uint64
+	for _, want := range []uint64{initial, 2 * initial, 4 * initial, 8 * initial, maxWindow, maxWindow} {
+		if got := ra.start(off, initial, maxWindow); got != want {
+			t.Fatalf("start(%d) = %d, want %d", off, got, want)
+		}
+		ra.finish(off, readSize, 0)
+		off += readSize
+	}
+
+	// A non-sequential read resets the window.
+	off += readSize
+	if got := ra.start(off, initial, maxWindow); got != initial {
+		t.Errorf("start(%d) after seek = %d, want %d", off, got, initial)
+	}
+}
+
+func TestReadaheadRandom(t *testing.T) {
+	var ra readaheadState
+	for i := 0; i < 3; i++ {
+		if got := ra.start(0, 0, 1<<20); got != 0 {
+			t.Errorf("start() with no initial window = %d, want 0", got)
+		}
+		ra.finish(0, 4096, 4096)
+	}
+}
+
+func TestReadaheadThrashing(t *testing.T) {
+	const (
+		initial   = 64 << 10
+		maxWindow = 1 << 20
+		readSize  = 4 << 10
+	)
+	var ra readaheadState
+	var off uint64
+	for i := 0; i < 3; i++ {
+		ra.start(off, initial, maxWindow)
+		ra.finish(off, readSize, 0)
+		off += readSize
+	}
+
+	// The next read should have been read ahead; missing it halves the
+	// window, and it stays there.
+	window := ra.start(off, initial, maxWindow)
+	ra.finish(off, readSize, readSize)
+	off += readSize
+	for i := 0; i < 3; i++ {
+		if got, want := ra.start(off, initial, maxWindow), window/2; got != want {
+			t.Errorf("start(%d) after thrashing = %d, want %d", off, got, want)
+		}
+		ra.finish(off, readSize, 0)
+		off += readSize
+	}
+}
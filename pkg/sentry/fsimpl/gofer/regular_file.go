@@ -20,6 +20,7 @@ import (
 	"math"
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/atomicbitops"
 	"gvisor.dev/gvisor/pkg/context"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/hostarch"
@@ -28,6 +29,7 @@ import (
 	"gvisor.dev/gvisor/pkg/safemem"
 	"gvisor.dev/gvisor/pkg/sentry/fsmetric"
 	"gvisor.dev/gvisor/pkg/sentry/fsutil"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
 	"gvisor.dev/gvisor/pkg/sentry/memmap"
 	"gvisor.dev/gvisor/pkg/sentry/pgalloc"
 	"gvisor.dev/gvisor/pkg/sentry/usage"
@@ -47,6 +49,14 @@ type regularFileFD struct {
 	// off is the file offset. off is protected by mu.
 	mu  sync.Mutex `state:"nosave"`
 	off int64
+
+	// advice is the last access pattern advice given by fadvise(2), one of
+	// linux.POSIX_FADV_{NORMAL,RANDOM,SEQUENTIAL}. It determines how far
+	// reads through this FD read ahead into the page cache.
+	advice atomicbitops.Int32
+
+	// ra tracks reads through this FD to adapt their readahead window.
+	ra readaheadState `state:"nosave"`
 }
 
 func newRegularFileFD(mnt *vfs.Mount, d *dentry, flags uint32) (*regularFileFD, error) {
@@ -54,6 +64,7 @@ func newRegularFileFD(mnt *vfs.Mount, d *dentry, flags uint32) (*regularFileFD,
 	fd.LockFD.Init(&d.locks)
 	if err := fd.vfsfd.Init(fd, flags, mnt, &d.vfsd, &vfs.FileDescriptionOptions{
 		AllowDirectIO: true,
+		IOPressure:    true,
 	}); err != nil {
 		return nil, err
 	}
@@ -105,8 +116,122 @@ func (fd *regularFileFD) Allocate(ctx context.Context, mode, offset, length uint
 	})
 }
 
+// minWillNeedBytes is the minimum number of bytes that POSIX_FADV_WILLNEED
+// may read into the page cache, regardless of the readahead window. Compare
+// Linux's mm/readahead.c:force_page_cache_ra(), which is limited by the
+// larger of the readahead window and the device's maximum I/O size.
+const minWillNeedBytes = 2 << 20
+
+// Advise implements vfs.FileDescriptionImpl.Advise.
+func (fd *regularFileFD) Advise(ctx context.Context, offset, length int64, advice int32) error {
+	switch advice {
+	case linux.POSIX_FADV_NORMAL, linux.POSIX_FADV_RANDOM, linux.POSIX_FADV_SEQUENTIAL:
+		fd.advice.Store(advice)
+		return nil
+	case linux.POSIX_FADV_WILLNEED, linux.POSIX_FADV_DONTNEED:
+	default:
+		return nil
+	}
+	if offset < 0 {
+		// Linux treats the offset as unsigned, so the range is beyond any
+		// file.
+		return nil
+	}
+	start := uint64(offset)
+	end := start + uint64(length)
+	if length == 0 || end < start || end > math.MaxInt64 {
+		end = math.MaxInt64
+	}
+	d := fd.dentry()
+	if advice == linux.POSIX_FADV_WILLNEED {
+		d.prefetch(ctx, start, end, max(fd.readahead(), minWillNeedBytes))
+		return nil
+	}
+	// Only drop whole pages, since partial pages are likely to be accessed
+	// again. Compare Linux's mm/fadvise.c:generic_fadvise().
+	dropStart, ok := hostarch.PageRoundUp(start)
+	if !ok {
+		return nil
+	}
+	if dropEnd := hostarch.PageRoundDown(end); dropStart < dropEnd {
+		d.Evict(ctx, pgalloc.EvictableRange{dropStart, dropEnd})
+	}
+	return nil
+}
+
+// readahead returns the maximum number of bytes that reads through fd may
+// read ahead into the page cache, which is the mount's readahead window
+// adjusted by fadvise(2) advice as in Linux's mm/fadvise.c.
+func (fd *regularFileFD) readahead() uint64 {
+	window := fd.filesystem().opts.readahead
+	switch fd.advice.Load() {
+	case linux.POSIX_FADV_RANDOM:
+		return 0
+	case linux.POSIX_FADV_SEQUENTIAL:
+		return 2 * window
+	default:
+		return window
+	}
+}
+
+// maxReadahead returns the largest readahead window that sequential reads
+// through fd may ramp up to.
+func (fd *regularFileFD) maxReadahead() uint64 {
+	window := fd.filesystem().opts.maxReadahead
+	if fd.advice.Load() == linux.POSIX_FADV_SEQUENTIAL {
+		return 2 * window
+	}
+	return window
+}
+
+// prefetch reads up to maxBytes of the file in [start, end) into the page
+// cache, for POSIX_FADV_WILLNEED. Errors are ignored, as in Linux.
+func (d *dentry) prefetch(ctx context.Context, start, end, maxBytes uint64) {
+	d.handleMu.RLock()
+	defer d.handleMu.RUnlock()
+	// Reads don't go through the page cache in these cases; see
+	// dentryReadWriter.ReadToBlocks.
+	if (d.mmapFD.RacyLoad() >= 0 && !d.fs.opts.forcePageCache) || d.fs.opts.interop == InteropModeShared {
+		return
+	}
+	mf := d.fs.mf
+	if !mf.ShouldCacheEvictable() {
+		return
+	}
+	h := d.readHandle()
+	d.dataMu.Lock()
+	defer d.dataMu.Unlock()
+	size := d.size.Load()
+	if start >= size {
+		return
+	}
+	end = min(end, size)
+	if end-start > maxBytes {
+		end = start + maxBytes
+	}
+	if start >= end {
+		return
+	}
+	pgEnd, _ := hostarch.PageRoundUp(end)
+	mr := memmap.MappableRange{hostarch.PageRoundDown(start), pgEnd}
+	if _, err := d.cache.Fill(ctx, mr, mr, size, mf, pgalloc.AllocOpts{
+		Kind:    usage.PageCache,
+		MemCgID: pgalloc.MemoryCgroupIDFromContext(ctx),
+		Mode:    pgalloc.AllocateAndWritePopulate,
+	}, h.readToBlocksAt); err != nil {
+		log.Debugf("gofer.dentry.prefetch: failed to fill %v: %v", mr, err)
+	}
+	mf.MarkEvictable(d, pgalloc.EvictableRange{mr.Start, mr.End})
+}
+
 // PRead implements vfs.FileDescriptionImpl.PRead.
 func (fd *regularFileFD) PRead(ctx context.Context, dst usermem.IOSequence, offset int64, opts vfs.ReadOptions) (int64, error) {
+	n, err := fd.pread(ctx, dst, offset, opts)
+	throttleIO(ctx, false, n)
+	return n, err
+}
+
+func (fd *regularFileFD) pread(ctx context.Context, dst usermem.IOSequence, offset int64, opts vfs.ReadOptions) (int64, error) {
 	start := fsmetric.StartReadWait()
 	d := fd.dentry()
 	defer func() {
@@ -123,12 +248,12 @@ func (fd *regularFileFD) PRead(ctx context.Context, dst usermem.IOSequence, offs
 		return 0, linuxerr.EINVAL
 	}
 
-	// Check that flags are supported.
-	//
-	// TODO(gvisor.dev/issue/2601): Support select preadv2 flags.
-	if opts.Flags&^linux.RWF_HIPRI != 0 {
+	// Check that flags are supported. RWF_DSYNC/RWF_SYNC have no effect on
+	// reads.
+	if opts.Flags&^(linux.RWF_HIPRI|linux.RWF_DSYNC|linux.RWF_SYNC|linux.RWF_NOWAIT) != 0 {
 		return 0, linuxerr.EOPNOTSUPP
 	}
+	nowait := opts.Flags&linux.RWF_NOWAIT != 0
 
 	// Check for reading at EOF before calling into MM (but not under
 	// InteropModeShared, which makes d.size unreliable).
@@ -149,6 +274,7 @@ func (fd *regularFileFD) PRead(ctx context.Context, dst usermem.IOSequence, offs
 		rw := getDentryReadWriter(ctx, d, offset)
 		// Require the read to go to the remote file.
 		rw.direct = true
+		rw.nowait = nowait
 		n, readErr = dst.CopyOutFrom(ctx, rw)
 		putDentryReadWriter(rw)
 		if d.fs.opts.interop != InteropModeShared {
@@ -157,7 +283,15 @@ func (fd *regularFileFD) PRead(ctx context.Context, dst usermem.IOSequence, offs
 		}
 	} else {
 		rw := getDentryReadWriter(ctx, d, offset)
+		rw.mnt = fd.vfsfd.Mount()
+		rw.nowait = nowait
+		if !nowait {
+			rw.readahead = fd.ra.start(uint64(offset), fd.readahead(), fd.maxReadahead())
+		}
 		n, readErr = dst.CopyOutFrom(ctx, rw)
+		if !nowait {
+			fd.ra.finish(uint64(offset), uint64(n), rw.missed)
+		}
 		putDentryReadWriter(rw)
 		if d.fs.opts.interop != InteropModeShared {
 			// Compare Linux's mm/filemap.c:do_generic_file_read() => file_accessed().
@@ -170,15 +304,17 @@ func (fd *regularFileFD) PRead(ctx context.Context, dst usermem.IOSequence, offs
 // Read implements vfs.FileDescriptionImpl.Read.
 func (fd *regularFileFD) Read(ctx context.Context, dst usermem.IOSequence, opts vfs.ReadOptions) (int64, error) {
 	fd.mu.Lock()
-	n, err := fd.PRead(ctx, dst, fd.off, opts)
+	n, err := fd.pread(ctx, dst, fd.off, opts)
 	fd.off += n
 	fd.mu.Unlock()
+	throttleIO(ctx, false, n)
 	return n, err
 }
 
 // PWrite implements vfs.FileDescriptionImpl.PWrite.
 func (fd *regularFileFD) PWrite(ctx context.Context, src usermem.IOSequence, offset int64, opts vfs.WriteOptions) (int64, error) {
 	n, _, err := fd.pwrite(ctx, src, offset, opts)
+	throttleIO(ctx, true, n)
 	return n, err
 }
 
@@ -189,10 +325,10 @@ func (fd *regularFileFD) pwrite(ctx context.Context, src usermem.IOSequence, off
 		return 0, offset, linuxerr.EINVAL
 	}
 
-	// Check that flags are supported.
-	//
-	// TODO(gvisor.dev/issue/2601): Support select pwritev2 flags.
-	if opts.Flags&^linux.RWF_HIPRI != 0 {
+	// Check that flags are supported. Writes may always need to wait for the
+	// remote filesystem, so RWF_NOWAIT is unsupported, as for Linux
+	// filesystems that don't set FMODE_NOWAIT.
+	if opts.Flags&^(linux.RWF_HIPRI|linux.RWF_DSYNC|linux.RWF_SYNC) != 0 {
 		return 0, offset, linuxerr.EOPNOTSUPP
 	}
 
@@ -231,6 +367,7 @@ func (fd *regularFileFD) pwrite(ctx context.Context, src usermem.IOSequence, off
 	rw := getDentryReadWriter(ctx, d, offset)
 	defer putDentryReadWriter(rw)
 
+	var n int64
 	if fd.vfsfd.StatusFlags()&linux.O_DIRECT != 0 {
 		if err := fd.writeCache(ctx, d, offset, src); err != nil {
 			return 0, offset, err
@@ -238,13 +375,28 @@ func (fd *regularFileFD) pwrite(ctx context.Context, src usermem.IOSequence, off
 
 		// Require the write to go to the remote file.
 		rw.direct = true
-	}
 
-	n, err := src.CopyInTo(ctx, rw)
+		// Writes that bypass the cache and don't change the file size needn't
+		// be serialized with each other, so drop d.metadataMu while writing
+		// so that concurrent O_DIRECT writers (e.g. AIO) can proceed in
+		// parallel. Compare Linux's fs/ext4/file.c:ext4_dio_write_checks(),
+		// which takes the inode lock shared for overwrites.
+		if end := uint64(offset) + uint64(src.NumBytes()); d.fs.opts.interop != InteropModeShared && end <= d.size.RacyLoad() {
+			d.directWrites.Add(1)
+			d.metadataMu.Unlock()
+			n, err = src.CopyInTo(ctx, rw)
+			d.directWrites.Done()
+			d.metadataMu.Lock()
+		} else {
+			n, err = src.CopyInTo(ctx, rw)
+		}
+	} else {
+		n, err = src.CopyInTo(ctx, rw)
+	}
 	if err != nil {
 		return n, offset + n, err
 	}
-	if n > 0 && fd.vfsfd.StatusFlags()&(linux.O_DSYNC|linux.O_SYNC) != 0 {
+	if n > 0 && (fd.vfsfd.StatusFlags()&(linux.O_DSYNC|linux.O_SYNC) != 0 || opts.Flags&(linux.RWF_DSYNC|linux.RWF_SYNC) != 0) {
 		// Note that if any of the following fail, then we can't guarantee that
 		// any data was actually written with the semantics of O_DSYNC or
 		// O_SYNC, so we return zero bytes written. Compare Linux's
@@ -319,14 +471,43 @@ func (fd *regularFileFD) Write(ctx context.Context, src usermem.IOSequence, opts
 	n, off, err := fd.pwrite(ctx, src, fd.off, opts)
 	fd.off = off
 	fd.mu.Unlock()
+	throttleIO(ctx, true, n)
 	return n, err
 }
 
+// throttleIO charges n bytes of I/O to the blkio cgroups of the task
+// performing it, if any, and delays the task if they are over their limits.
+// It must be called without holding any locks.
+func throttleIO(ctx context.Context, write bool, n int64) {
+	if n <= 0 {
+		return
+	}
+	if t := kernel.TaskFromContext(ctx); t != nil {
+		t.ThrottleIO(write, n)
+	}
+}
+
 type dentryReadWriter struct {
 	ctx    context.Context
 	d      *dentry
 	off    uint64
 	direct bool
+
+	// mnt is the Mount that page cache reads are accounted to. If nil, reads
+	// are only accounted to the sandbox-wide metrics.
+	mnt *vfs.Mount
+
+	// readahead is the maximum number of bytes to read into the page cache
+	// when a read misses it.
+	readahead uint64
+
+	// missed is the number of bytes read that missed the page cache.
+	missed uint64
+
+	// If nowait is true, reads that miss the page cache stop early (or fail
+	// with EAGAIN if nothing was read) instead of fetching from the remote
+	// file.
+	nowait bool
 }
 
 var dentryReadWriterPool = sync.Pool{
@@ -341,12 +522,17 @@ func getDentryReadWriter(ctx context.Context, d *dentry, offset int64) *dentryRe
 	rw.d = d
 	rw.off = uint64(offset)
 	rw.direct = false
+	rw.mnt = nil
+	rw.readahead = d.fs.opts.readahead
+	rw.missed = 0
+	rw.nowait = false
 	return rw
 }
 
 func putDentryReadWriter(rw *dentryReadWriter) {
 	rw.ctx = nil
 	rw.d = nil
+	rw.mnt = nil
 	dentryReadWriterPool.Put(rw)
 }
 
@@ -365,7 +551,11 @@ func (rw *dentryReadWriter) ReadToBlocks(dsts safemem.BlockSeq) (uint64, error)
 	defer rw.d.handleMu.RUnlock()
 	h := rw.d.readHandle()
 	if (rw.d.mmapFD.RacyLoad() >= 0 && !rw.d.fs.opts.forcePageCache) || rw.d.fs.opts.interop == InteropModeShared || rw.direct {
-		n, err := h.readToBlocksAt(rw.ctx, dsts, rw.off)
+		readToBlocksAt := h.readToBlocksAt
+		if rw.nowait {
+			readToBlocksAt = h.readToBlocksAtNoWait
+		}
+		n, err := readToBlocksAt(rw.ctx, dsts, rw.off)
 		rw.off += n
 		return n, err
 	}
@@ -393,7 +583,11 @@ func (rw *dentryReadWriter) ReadToBlocks(dsts safemem.BlockSeq) (uint64, error)
 		end = rend
 	}
 
-	var done uint64
+	var done, missed uint64
+	defer func() {
+		accountPageCacheRead(rw.mnt, done, missed)
+		rw.missed += missed
+	}()
 	seg, gap := rw.d.cache.Find(rw.off)
 	for rw.off < end {
 		mr := memmap.MappableRange{rw.off, end}
@@ -418,7 +612,14 @@ func (rw *dentryReadWriter) ReadToBlocks(dsts safemem.BlockSeq) (uint64, error)
 			seg, gap = seg.NextNonEmpty()
 
 		case gap.Ok():
+			if rw.nowait {
+				if done == 0 {
+					return 0, linuxerr.ErrWouldBlock
+				}
+				return done, nil
+			}
 			gapMR := gap.Range().Intersect(mr)
+			missed += gapMR.Length()
 			if fillCache {
 				// Read into the cache, then re-enter the loop to read from the
 				// cache.
@@ -428,7 +629,7 @@ func (rw *dentryReadWriter) ReadToBlocks(dsts safemem.BlockSeq) (uint64, error)
 					End:   gapEnd,
 				}
 				optMR := gap.Range()
-				_, err := rw.d.cache.Fill(rw.ctx, reqMR, maxFillRange(reqMR, optMR), rw.d.size.Load(), mf, pgalloc.AllocOpts{
+				_, err := rw.d.cache.Fill(rw.ctx, reqMR, maxFillRange(reqMR, optMR, rw.readahead), rw.d.size.Load(), mf, pgalloc.AllocOpts{
 					Kind:    usage.PageCache,
 					MemCgID: memCgID,
 					Mode:    pgalloc.AllocateAndWritePopulate,
@@ -751,7 +952,7 @@ func (d *dentry) Translate(ctx context.Context, required, optional memmap.Mappab
 	if d.mmapFD.RacyLoad() >= 0 && !d.fs.opts.forcePageCache {
 		mr := optional
 		if d.fs.opts.limitHostFDTranslation {
-			mr = maxFillRange(required, optional)
+			mr = maxFillRange(required, optional, d.fs.opts.readahead)
 		}
 		return []memmap.Translation{
 			{
@@ -784,7 +985,7 @@ func (d *dentry) Translate(ctx context.Context, required, optional memmap.Mappab
 
 	mf := d.fs.mf
 	h := d.readHandle()
-	_, cerr := d.cache.Fill(ctx, required, maxFillRange(required, optional), d.size.Load(), mf, pgalloc.AllocOpts{
+	_, cerr := d.cache.Fill(ctx, required, maxFillRange(required, optional, d.fs.opts.readahead), d.size.Load(), mf, pgalloc.AllocOpts{
 		Kind:    usage.PageCache,
 		MemCgID: memCgID,
 		Mode:    pgalloc.AllocateAndWritePopulate,
@@ -823,8 +1024,9 @@ func (d *dentry) Translate(ctx context.Context, required, optional memmap.Mappab
 	return ts, nil
 }
 
-func maxFillRange(required, optional memmap.MappableRange) memmap.MappableRange {
-	const maxReadahead = 64 << 10 // 64 KB, chosen arbitrarily
+// maxFillRange returns the range to fill for a read of required, which may be
+// extended into optional by at most maxReadahead bytes in total.
+func maxFillRange(required, optional memmap.MappableRange, maxReadahead uint64) memmap.MappableRange {
 	if required.Length() >= maxReadahead {
 		return required
 	}
@@ -839,6 +1041,65 @@ func maxFillRange(required, optional memmap.MappableRange) memmap.MappableRange
 	return optional
 }
 
+// ForEachResidentRange implements memmap.ResidencyMappable.ForEachResidentRange.
+func (d *dentry) ForEachResidentRange(ctx context.Context, mr memmap.MappableRange, fn func(memmap.MappableRange)) {
+	d.handleMu.RLock()
+	defer d.handleMu.RUnlock()
+	if d.mmapFD.RacyLoad() >= 0 && !d.fs.opts.forcePageCache {
+		// Residency is determined by the host page cache, which we can't cheaply
+		// query; report all pages as resident.
+		fn(mr)
+		return
+	}
+	d.dataMu.RLock()
+	defer d.dataMu.RUnlock()
+	for seg := d.cache.LowerBoundSegment(mr.Start); seg.Ok() && seg.Start() < mr.End; seg = seg.NextSegment() {
+		fn(seg.Range().Intersect(mr))
+	}
+}
+
+// InvalidateCache implements memmap.CacheInvalidatingMappable.InvalidateCache.
+func (d *dentry) InvalidateCache(ctx context.Context, mr memmap.MappableRange) error {
+	mf := d.fs.mf
+	d.mapsMu.Lock()
+	defer d.mapsMu.Unlock()
+	if d.mmapFD.RacyLoad() >= 0 && !d.fs.opts.forcePageCache {
+		// Mappings of the host FD are coherent with the host file.
+		return nil
+	}
+
+	// Invalidate translations of the cached pages so that subsequent accesses
+	// through shared mappings fault and re-read the remote file. Private
+	// copies of the cached pages are unaffected, consistent with Linux. This
+	// requires that d.handleMu and d.dataMu are unlocked, since invalidating
+	// translations locks MM.activeMu, which MM.Translate holds while calling
+	// d.Translate.
+	d.mappings.Invalidate(mr, memmap.InvalidateOpts{})
+
+	d.handleMu.RLock()
+	defer d.handleMu.RUnlock()
+	if d.mmapFD.RacyLoad() >= 0 && !d.fs.opts.forcePageCache {
+		// A host FD was opened since the check above.
+		return nil
+	}
+	h := d.writeHandle()
+	d.dataMu.Lock()
+	defer d.dataMu.Unlock()
+	// Write back dirty data first so that it isn't lost when the cache is
+	// dropped below. Translations that raced with this call since the
+	// invalidation above hold references on the pages that they map, so they
+	// remain valid (if stale) after the cache is dropped, as if the racing
+	// access happened before msync.
+	if err := fsutil.SyncDirty(ctx, mr, &d.cache, &d.dirty, d.size.Load(), mf, h.writeFromBlocksAt); err != nil {
+		return err
+	}
+	d.cache.Drop(mr, mf)
+	// Offsets that are mapped writably remain marked dirty (KeepDirty) so that
+	// they are written back after they are re-cached.
+	d.dirty.MarkClean(mr)
+	return nil
+}
+
 // InvalidateUnsavable implements memmap.Mappable.InvalidateUnsavable.
 func (d *dentry) InvalidateUnsavable(ctx context.Context) error {
 	// Whether we have a host fd (and consequently what memmap.File is
@@ -351,6 +351,14 @@ func (fs *filesystem) CompleteRestore(ctx context.Context, opts vfs.CompleteRest
 		}
 	}
 
+	// Re-acquire host locks for locks held by applications, which are not
+	// saved.
+	if fs.opts.hostLocks {
+		if err := fs.restoreHostLocks(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Discard state only required during restore.
 	fs.savedDeletedOpenDentries = nil
 	fs.savedDentryRW = nil
@@ -358,6 +366,26 @@ func (fs *filesystem) CompleteRestore(ctx context.Context, opts vfs.CompleteRest
 	return nil
 }
 
+// restoreHostLocks re-acquires the host locks corresponding to the locks held
+// by applications on fs's files. Deleted files are skipped, since they can't
+// be shared outside of the sandbox.
+func (fs *filesystem) restoreHostLocks(ctx context.Context) error {
+	var ds []*dentry
+	fs.syncMu.Lock()
+	for sd := fs.syncableDentries.Front(); sd != nil; sd = sd.Next() {
+		if sd.d.usesHostLocks() && !sd.d.vfsd.IsDead() {
+			ds = append(ds, sd.d)
+		}
+	}
+	fs.syncMu.Unlock()
+	for _, d := range ds {
+		if err := d.restoreHostLocks(ctx); err != nil {
+			return fmt.Errorf("failed to restore host locks for %q: %w", genericDebugPathname(fs, d), err)
+		}
+	}
+	return nil
+}
+
 // Preconditions: d is not synthetic.
 func (d *dentry) restoreDescendantsRecursive(ctx context.Context, opts *vfs.CompleteRestoreOptions) error {
 	d.childrenMu.Lock()
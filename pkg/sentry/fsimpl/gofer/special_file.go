@@ -111,6 +111,7 @@ func newSpecialFileFD(h handle, mnt *vfs.Mount, d *dentry, flags uint32) (*speci
 		AllowDirectIO: true,
 		DenyPRead:     !seekable,
 		DenyPWrite:    !seekable,
+		IOPressure:    ftype == linux.S_IFREG || ftype == linux.S_IFBLK,
 	}); err != nil {
 		if haveQueue {
 			fdnotifier.RemoveFD(h.fd)
@@ -469,7 +470,7 @@ func (fd *specialFileFD) CopyMapping(ctx context.Context, ms memmap.MappingSpace
 func (fd *specialFileFD) Translate(ctx context.Context, required, optional memmap.MappableRange, at hostarch.AccessType) ([]memmap.Translation, error) {
 	mr := optional
 	if fd.filesystem().opts.limitHostFDTranslation {
-		mr = maxFillRange(required, optional)
+		mr = maxFillRange(required, optional, fd.filesystem().opts.readahead)
 	}
 	return []memmap.Translation{
 		{
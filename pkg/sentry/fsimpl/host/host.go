@@ -709,7 +709,9 @@ func (i *inode) open(ctx context.Context, d *kernfs.Dentry, mnt *vfs.Mount, file
 		fd := &fileDescription{inode: i}
 		fd.LockFD.Init(&i.locks)
 		vfsfd := &fd.vfsfd
-		if err := vfsfd.Init(fd, flags, mnt, d.VFSDentry(), &vfs.FileDescriptionOptions{}); err != nil {
+		if err := vfsfd.Init(fd, flags, mnt, d.VFSDentry(), &vfs.FileDescriptionOptions{
+			IOPressure: fileType == unix.S_IFREG,
+		}); err != nil {
 			return nil, err
 		}
 		return vfsfd, nil
@@ -801,10 +803,9 @@ func (f *fileDescription) Allocate(ctx context.Context, mode, offset, length uin
 
 // PRead implements vfs.FileDescriptionImpl.PRead.
 func (f *fileDescription) PRead(ctx context.Context, dst usermem.IOSequence, offset int64, opts vfs.ReadOptions) (int64, error) {
-	// Check that flags are supported.
-	//
-	// TODO(gvisor.dev/issue/2601): Support select preadv2 flags.
-	if opts.Flags&^linux.RWF_HIPRI != 0 {
+	// Check that flags are supported. RWF_NOWAIT is passed through to the
+	// host, which reports EAGAIN if the read would block.
+	if opts.Flags&^(linux.RWF_HIPRI|linux.RWF_NOWAIT) != 0 {
 		return 0, linuxerr.EOPNOTSUPP
 	}
 
@@ -818,10 +819,9 @@ func (f *fileDescription) PRead(ctx context.Context, dst usermem.IOSequence, off
 
 // Read implements vfs.FileDescriptionImpl.Read.
 func (f *fileDescription) Read(ctx context.Context, dst usermem.IOSequence, opts vfs.ReadOptions) (int64, error) {
-	// Check that flags are supported.
-	//
-	// TODO(gvisor.dev/issue/2601): Support select preadv2 flags.
-	if opts.Flags&^linux.RWF_HIPRI != 0 {
+	// Check that flags are supported. RWF_NOWAIT is passed through to the
+	// host, which reports EAGAIN if the read would block.
+	if opts.Flags&^(linux.RWF_HIPRI|linux.RWF_NOWAIT) != 0 {
 		return 0, linuxerr.EOPNOTSUPP
 	}
 
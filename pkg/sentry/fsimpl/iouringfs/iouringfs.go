@@ -13,9 +13,18 @@
 // limitations under the License.
 
 // Package iouringfs provides a filesystem implementation for IO_URING basing
-// it on anonfs. Currently, we don't support neither IOPOLL nor SQPOLL modes.
-// Thus, user needs to set up IO_URING first with io_uring_setup(2) syscall and
-// then issue submission request using io_uring_enter(2).
+// it on anonfs. User needs to set up IO_URING first with io_uring_setup(2)
+// syscall and then issue submission request using io_uring_enter(2).
+//
+// Requests are executed synchronously by the task calling io_uring_enter(2),
+// in submission order. Requests that would wait in Linux (e.g. a RECV on an
+// empty blocking socket) block the entering task instead. Since there is no
+// kernel-side worker to execute requests, IOPOLL and SQPOLL modes are not
+// supported.
+//
+// IORING_OP_TIMEOUT requests don't arm a timer. They are completed when the
+// ring is entered after they expire, which includes a waiting
+// IORING_ENTER_GETEVENTS caller.
 //
 // Another important note, as of now, we don't support deferred CQE. In other
 // words, the size of the backlogged set of CQE is zero. Whenever, completion
@@ -37,7 +46,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/pgalloc"
 	"gvisor.dev/gvisor/pkg/sentry/usage"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
-	"gvisor.dev/gvisor/pkg/usermem"
+	"gvisor.dev/gvisor/pkg/waiter"
 )
 
 // FileDescription implements vfs.FileDescriptionImpl for file-based IO_URING.
@@ -71,6 +80,22 @@ type FileDescription struct {
 	// remap indicates whether the shared buffers need to be remapped
 	// due to a S/R. Protected by ProcessSubmissions critical section.
 	remap bool
+
+	// bufs are the buffers registered with IORING_REGISTER_BUFFERS, indexed
+	// by IOUringSqe.BufIndexOrGroup. Protected by ProcessSubmissions critical
+	// section.
+	bufs []registeredBuffer
+
+	// timeouts are the pending IORING_OP_TIMEOUT requests in submission
+	// order. Protected by ProcessSubmissions critical section.
+	timeouts []pendingTimeout
+
+	// completions is the number of CQEs posted for requests other than
+	// timeouts. Protected by ProcessSubmissions critical section.
+	completions uint64
+
+	// cqWaitQ is notified whenever a CQE is posted.
+	cqWaitQ waiter.Queue
 }
 
 var _ vfs.FileDescriptionImpl = (*FileDescription)(nil)
@@ -145,7 +170,7 @@ func New(ctx context.Context, vfsObj *vfs.VirtualFilesystem, entries uint32, par
 		sqemf: sqEntriesFile{
 			fr: sqefr,
 		},
-		// See ProcessSubmissions for why the capacity is 1.
+		// See FileDescription.lock for why the capacity is 1.
 		runC: make(chan struct{}, 1),
 	}
 
@@ -265,19 +290,18 @@ func (fd *FileDescription) ConfigureMMap(ctx context.Context, opts *memmap.MMapO
 	return vfs.GenericConfigureMMap(&fd.vfsfd, mf, opts)
 }
 
-// ProcessSubmissions processes the submission queue. Concurrent calls to
-// ProcessSubmissions serialize, yielding task goroutines with Task.Block since
-// processing can take a long time.
-func (fd *FileDescription) ProcessSubmissions(t *kernel.Task, toSubmit uint32, minComplete uint32, flags uint32) (int, error) {
+// lock enters the critical section protecting the rings and the remaining
+// mutable state of fd. Concurrent callers serialize, yielding task goroutines
+// with Task.Block since processing can take a long time.
+func (fd *FileDescription) lock(t *kernel.Task) {
 	// We use a combination of fd.running and fd.runC to serialize concurrent
-	// callers to ProcessSubmissions. runC has a capacity of 1. The protocol
-	// works as follows:
+	// callers. runC has a capacity of 1. The protocol works as follows:
 	//
 	// * Becoming the active task
 	//
-	// On entry to ProcessSubmissions, we try to transition running from 0 to
-	// 1. If there is already an active task, this will fail and we'll go to
-	// sleep with Task.Block(). If we succeed, we're the active task.
+	// On entry to lock, we try to transition running from 0 to 1. If there is
+	// already an active task, this will fail and we'll go to sleep with
+	// Task.Block(). If we succeed, we're the active task.
 	//
 	// * Sleep, Wakeup
 	//
@@ -285,8 +309,8 @@ func (fd *FileDescription) ProcessSubmissions(t *kernel.Task, toSubmit uint32, m
 	// we could still be racing with other tasks. Note that if multiple tasks
 	// are sleeping, only one will wake up since only one will successfully
 	// receive from runC. However we could still race with a new caller of
-	// ProcessSubmissions that hasn't gone to sleep yet. Only one waiting task
-	// will succeed and become the active task, the rest will go to sleep.
+	// lock that hasn't gone to sleep yet. Only one waiting task will succeed
+	// and become the active task, the rest will go to sleep.
 	//
 	// runC needs to be buffered to avoid a race between checking running and
 	// going back to sleep. With an unbuffered channel, we could miss a wakeup
@@ -319,39 +343,75 @@ func (fd *FileDescription) ProcessSubmissions(t *kernel.Task, toSubmit uint32, m
 		t.Block(fd.runC)
 	}
 	// We successfully set fd.running, so we're the active task now.
-	defer func() {
-		// Unblock any potentially waiting tasks.
-		if !fd.running.CompareAndSwap(1, 0) {
-			panic(fmt.Sprintf("iouringfs.FileDescription.ProcessSubmissions: active task encountered invalid fd.running state %v", fd.running.Load()))
-		}
-		select {
-		case fd.runC <- struct{}{}:
-		default:
-		}
-	}()
-
-	// The rest of this function is a critical section with respect to
-	// concurrent callers.
 
 	if fd.remap {
 		fd.mapSharedBuffers()
 		fd.remap = false
 	}
+}
+
+// unlocked calls fn outside of the critical section. It is used for requests
+// that may block for arbitrarily long, which must neither stall other callers
+// nor hold the critical section across save/restore.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) unlocked(t *kernel.Task, fn func()) {
+	fd.unlock()
+	defer fd.lock(t)
+	fn()
+}
+
+// unlock leaves the critical section entered by lock.
+func (fd *FileDescription) unlock() {
+	// Unblock any potentially waiting tasks.
+	if !fd.running.CompareAndSwap(1, 0) {
+		panic(fmt.Sprintf("iouringfs.FileDescription.unlock: active task encountered invalid fd.running state %v", fd.running.Load()))
+	}
+	select {
+	case fd.runC <- struct{}{}:
+	default:
+	}
+}
+
+// ProcessSubmissions processes the submission queue and, if
+// IORING_ENTER_GETEVENTS is set in flags, waits for minComplete completions.
+// Concurrent calls to ProcessSubmissions serialize, except while a request
+// that may block is in progress. Thus completions of concurrent calls may be
+// posted out of submission order, as in Linux.
+func (fd *FileDescription) ProcessSubmissions(t *kernel.Task, toSubmit uint32, minComplete uint32, flags uint32) (int, error) {
+	fd.lock(t)
+	submitted, err := fd.submitLocked(t, toSubmit, flags)
+	if err == nil {
+		err = fd.flushTimeoutsLocked(t)
+	}
+	fd.unlock()
+	if err != nil {
+		return -1, err
+	}
+
+	if flags&linux.IORING_ENTER_GETEVENTS != 0 {
+		if err := fd.waitCompletions(t, minComplete); err != nil && submitted == 0 {
+			return -1, err
+		}
+	}
+	return submitted, nil
+}
 
+// submitLocked consumes up to toSubmit entries from the submission queue.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) submitLocked(t *kernel.Task, toSubmit uint32, flags uint32) (int, error) {
 	var err error
 	var sqe linux.IOUringSqe
 
 	sqOff := linux.PreComputedIOSqRingOffsets()
-	cqOff := linux.PreComputedIOCqRingOffsets()
 	sqArraySize := sqe.SizeBytes() * int(fd.ioRings.SqRingEntries)
-	cqArraySize := (*linux.IOUringCqe)(nil).SizeBytes() * int(fd.ioRings.CqRingEntries)
 
 	// Fetch all buffers initially.
 	fetchRB := true
 	fetchSQA := true
-	fetchCQA := true
 
-	var view, sqaView, cqaView []byte
+	var view, sqaView []byte
 	submitted := uint32(0)
 
 	for toSubmit > submitted {
@@ -373,9 +433,6 @@ func (fd *FileDescription) ProcessSubmissions(t *kernel.Task, toSubmit uint32, m
 
 		sqHeadPtr := atomicUint32AtOffset(view, int(sqOff.Head))
 		sqTailPtr := atomicUint32AtOffset(view, int(sqOff.Tail))
-		cqHeadPtr := atomicUint32AtOffset(view, int(cqOff.Head))
-		cqTailPtr := atomicUint32AtOffset(view, int(cqOff.Tail))
-		overflowPtr := atomicUint32AtOffset(view, int(cqOff.Overflow))
 
 		// Load the pointers once, so we work with a stable value. Particularly,
 		// userspace can update the SQ tail at any time.
@@ -384,6 +441,7 @@ func (fd *FileDescription) ProcessSubmissions(t *kernel.Task, toSubmit uint32, m
 
 		// Is the submission queue is empty?
 		if sqHead == sqTail {
+			fd.ioRingsBuf.drop()
 			return int(submitted), nil
 		}
 
@@ -399,53 +457,144 @@ func (fd *FileDescription) ProcessSubmissions(t *kernel.Task, toSubmit uint32, m
 		sqe.UnmarshalUnsafe(sqaView[sqaOff : sqaOff+sqe.SizeBytes()])
 		fetchSQA = fd.sqesBuf.drop()
 
-		// Dispatch request from unmarshalled entry.
-		cqe := fd.ProcessSubmission(t, &sqe, flags)
-
 		// Advance sq head.
 		sqHeadPtr.Add(1)
+		fetchRB, err = fd.ioRingsBuf.writeback(fd.ioRings.SizeBytes())
+		if err != nil {
+			return -1, err
+		}
+
+		// Dispatch request from unmarshalled entry. A nil CQE means the
+		// request completes later, see flushTimeoutsLocked.
+		cqe := fd.ProcessSubmission(t, &sqe, flags)
 
-		// Load once so we have stable values. Particularly, userspace can
-		// update the CQ head at any time.
-		cqHead := cqHeadPtr.Load()
-		cqTail := cqTailPtr.Load()
-
-		// Marshal response to completion queue.
-		if (cqTail - cqHead) >= fd.ioRings.CqRingEntries {
-			// CQ ring full.
-			fd.ioRings.CqOverflow++
-			overflowPtr.Store(fd.ioRings.CqOverflow)
-		} else {
-			// Have room in CQ, marshal CQE.
-			if fetchCQA {
-				cqaView, err = fd.cqesBuf.view(cqArraySize)
-				if err != nil {
-					return -1, err
-				}
+		// The request may have left the critical section, in which case
+		// the shared buffers may have been remapped.
+		fetchRB = true
+		fetchSQA = true
+
+		if cqe != nil {
+			if err := fd.postCqeLocked(cqe); err != nil {
+				return -1, err
 			}
-			cqaOff := int(cqTail&fd.ioRings.CqRingMask) * cqe.SizeBytes()
-			cqe.MarshalUnsafe(cqaView[cqaOff : cqaOff+cqe.SizeBytes()])
-			fetchCQA, err = fd.cqesBuf.writebackWindow(cqaOff, cqe.SizeBytes())
-			if err != nil {
+			fd.completions++
+			if err := fd.flushTimeoutsLocked(t); err != nil {
 				return -1, err
 			}
-
-			// Advance cq tail.
-			cqTailPtr.Add(1)
 		}
 
-		fetchRB, err = fd.ioRingsBuf.writeback(fd.ioRings.SizeBytes())
+		submitted++
+	}
+
+	return int(submitted), nil
+}
+
+// postCqeLocked adds cqe to the completion queue and wakes up tasks waiting
+// for completions. If the completion queue is full, cqe is dropped and
+// accounted for in the overflow counter.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) postCqeLocked(cqe *linux.IOUringCqe) error {
+	cqOff := linux.PreComputedIOCqRingOffsets()
+	view, err := fd.ioRingsBuf.view(fd.ioRings.SizeBytes())
+	if err != nil {
+		return err
+	}
+
+	cqHeadPtr := atomicUint32AtOffset(view, int(cqOff.Head))
+	cqTailPtr := atomicUint32AtOffset(view, int(cqOff.Tail))
+	overflowPtr := atomicUint32AtOffset(view, int(cqOff.Overflow))
+
+	// Load once so we have stable values. Particularly, userspace can
+	// update the CQ head at any time.
+	cqHead := cqHeadPtr.Load()
+	cqTail := cqTailPtr.Load()
+
+	// Marshal response to completion queue.
+	if (cqTail - cqHead) >= fd.ioRings.CqRingEntries {
+		// CQ ring full.
+		fd.ioRings.CqOverflow++
+		overflowPtr.Store(fd.ioRings.CqOverflow)
+	} else {
+		// Have room in CQ, marshal CQE.
+		cqArraySize := cqe.SizeBytes() * int(fd.ioRings.CqRingEntries)
+		cqaView, err := fd.cqesBuf.view(cqArraySize)
 		if err != nil {
-			return -1, err
+			fd.ioRingsBuf.drop()
+			return err
+		}
+		cqaOff := int(cqTail&fd.ioRings.CqRingMask) * cqe.SizeBytes()
+		cqe.MarshalUnsafe(cqaView[cqaOff : cqaOff+cqe.SizeBytes()])
+		if _, err := fd.cqesBuf.writebackWindow(cqaOff, cqe.SizeBytes()); err != nil {
+			fd.ioRingsBuf.drop()
+			return err
 		}
 
-		submitted++
+		// Advance cq tail.
+		cqTailPtr.Add(1)
 	}
 
-	return int(submitted), nil
+	if _, err := fd.ioRingsBuf.writeback(fd.ioRings.SizeBytes()); err != nil {
+		return err
+	}
+	fd.cqWaitQ.Notify(waiter.EventIn)
+	return nil
+}
+
+// cqReadyLocked returns the number of CQEs available to userspace.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) cqReadyLocked() (uint32, error) {
+	cqOff := linux.PreComputedIOCqRingOffsets()
+	view, err := fd.ioRingsBuf.view(fd.ioRings.SizeBytes())
+	if err != nil {
+		return 0, err
+	}
+	ready := atomicUint32AtOffset(view, int(cqOff.Tail)).Load() - atomicUint32AtOffset(view, int(cqOff.Head)).Load()
+	fd.ioRingsBuf.drop()
+	return ready, nil
+}
+
+// waitCompletions blocks until at least minComplete CQEs are available to
+// userspace. Pending timeouts are completed as they expire while waiting.
+func (fd *FileDescription) waitCompletions(t *kernel.Task, minComplete uint32) error {
+	if minComplete == 0 {
+		return nil
+	}
+	if minComplete > fd.ioRings.CqRingEntries {
+		minComplete = fd.ioRings.CqRingEntries
+	}
+
+	e, ch := waiter.NewChannelEntry(waiter.EventIn)
+	fd.cqWaitQ.EventRegister(&e)
+	defer fd.cqWaitQ.EventUnregister(&e)
+
+	for {
+		fd.lock(t)
+		err := fd.flushTimeoutsLocked(t)
+		var ready uint32
+		if err == nil {
+			ready, err = fd.cqReadyLocked()
+		}
+		deadline, haveDeadline := fd.nextTimeoutLocked()
+		fd.unlock()
+		if err != nil {
+			return err
+		}
+		if ready >= minComplete {
+			return nil
+		}
+
+		if err := t.BlockWithDeadline(ch, haveDeadline, deadline); err != nil && !linuxerr.Equals(linuxerr.ETIMEDOUT, err) {
+			return err
+		}
+	}
 }
 
-// ProcessSubmission processes a single submission request.
+// ProcessSubmission processes a single submission request. It returns nil if
+// the request doesn't complete immediately.
+//
+// Preconditions: fd.lock has been called.
 func (fd *FileDescription) ProcessSubmission(t *kernel.Task, sqe *linux.IOUringSqe, flags uint32) *linux.IOUringCqe {
 	var (
 		cqeErr   error
@@ -456,13 +605,29 @@ func (fd *FileDescription) ProcessSubmission(t *kernel.Task, sqe *linux.IOUringS
 	switch op := sqe.Opcode; op {
 	case linux.IORING_OP_NOP:
 		// For the NOP operation, we don't do anything special.
-	case linux.IORING_OP_READV:
-		retValue, cqeErr = fd.handleReadv(t, sqe, flags)
+	case linux.IORING_OP_READV, linux.IORING_OP_READ_FIXED:
+		retValue, cqeErr = fd.handleRead(t, sqe)
 		if cqeErr == io.EOF {
 			// Don't raise EOF as errno, error translation will fail. Short
 			// reads aren't failures.
 			cqeErr = nil
 		}
+	case linux.IORING_OP_WRITEV, linux.IORING_OP_WRITE_FIXED:
+		retValue, cqeErr = fd.handleWrite(t, sqe)
+	case linux.IORING_OP_FSYNC:
+		fd.unlocked(t, func() { cqeErr = handleFsync(t, sqe) })
+	case linux.IORING_OP_TIMEOUT:
+		if cqeErr = fd.handleTimeout(t, sqe); cqeErr == nil {
+			return nil
+		}
+	case linux.IORING_OP_ACCEPT:
+		fd.unlocked(t, func() { retValue, cqeErr = handleAccept(t, sqe) })
+	case linux.IORING_OP_CONNECT:
+		fd.unlocked(t, func() { cqeErr = handleConnect(t, sqe) })
+	case linux.IORING_OP_SEND:
+		fd.unlocked(t, func() { retValue, cqeErr = handleSend(t, sqe) })
+	case linux.IORING_OP_RECV:
+		fd.unlocked(t, func() { retValue, cqeErr = handleRecv(t, sqe) })
 	default: // Unsupported operation
 		retValue = -int32(linuxerr.EINVAL.Errno())
 	}
@@ -478,46 +643,6 @@ func (fd *FileDescription) ProcessSubmission(t *kernel.Task, sqe *linux.IOUringS
 	}
 }
 
-// handleReadv handles IORING_OP_READV.
-func (fd *FileDescription) handleReadv(t *kernel.Task, sqe *linux.IOUringSqe, flags uint32) (int32, error) {
-	// Check that a file descriptor is valid.
-	if sqe.Fd < 0 {
-		return 0, linuxerr.EBADF
-	}
-	// Currently we don't support any flags for the SQEs.
-	if sqe.Flags != 0 {
-		return 0, linuxerr.EINVAL
-	}
-	// If the file is not seekable then offset must be zero. And currently, we don't support them.
-	if sqe.OffOrAddrOrCmdOp != 0 {
-		return 0, linuxerr.EINVAL
-	}
-	// ioprio should not be set for the READV operation.
-	if sqe.IoPrio != 0 {
-		return 0, linuxerr.EINVAL
-	}
-
-	// AddressSpaceActive is set to true as we are doing this from the task goroutine.And this is a
-	// case as we currently don't support neither IOPOLL nor SQPOLL modes.
-	dst, err := t.IovecsIOSequence(hostarch.Addr(sqe.AddrOrSpliceOff), int(sqe.Len), usermem.IOOpts{
-		AddressSpaceActive: true,
-	})
-	if err != nil {
-		return 0, err
-	}
-	file := t.GetFile(sqe.Fd)
-	if file == nil {
-		return 0, linuxerr.EBADF
-	}
-	defer file.DecRef(t)
-	n, err := file.PRead(t, dst, 0, vfs.ReadOptions{})
-	if err != nil {
-		return 0, err
-	}
-
-	return int32(n), nil
-}
-
 // updateCq updates a completion queue by adding a given completion queue entry.
 func (fd *FileDescription) updateCq(cqes *safemem.BlockSeq, cqe *linux.IOUringCqe, cqTail uint32) error {
 	cqeSize := uint32((*linux.IOUringCqe)(nil).SizeBytes())
@@ -0,0 +1,504 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iouringfs
+
+import (
+	"io"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/ktime"
+	"gvisor.dev/gvisor/pkg/sentry/socket"
+	"gvisor.dev/gvisor/pkg/sentry/socket/control"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/usermem"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	eventMaskRead  = waiter.EventRdNorm | waiter.EventIn | waiter.EventHUp | waiter.EventErr | waiter.EventRdHUp
+	eventMaskWrite = waiter.EventWrNorm | waiter.EventOut | waiter.EventHUp | waiter.EventErr
+
+	// maxAddrLen is the maximum socket address length we're willing to
+	// accept. It matches the limit used by connect(2).
+	maxAddrLen = 200
+)
+
+// pendingTimeout is an IORING_OP_TIMEOUT request that hasn't completed yet.
+//
+// +stateify savable
+type pendingTimeout struct {
+	// userData is the user data of the request.
+	userData uint64
+
+	// deadline is the time, measured by the application monotonic clock, at
+	// which the request completes with ETIME.
+	deadline ktime.Time
+
+	// target is the value of FileDescription.completions at which the request
+	// completes successfully. If target is 0, the request only completes on
+	// expiry.
+	target uint64
+}
+
+// opSupported returns whether opcode op is supported.
+func opSupported(op uint8) bool {
+	switch op {
+	case linux.IORING_OP_NOP,
+		linux.IORING_OP_READV,
+		linux.IORING_OP_WRITEV,
+		linux.IORING_OP_FSYNC,
+		linux.IORING_OP_READ_FIXED,
+		linux.IORING_OP_WRITE_FIXED,
+		linux.IORING_OP_TIMEOUT,
+		linux.IORING_OP_ACCEPT,
+		linux.IORING_OP_CONNECT,
+		linux.IORING_OP_SEND,
+		linux.IORING_OP_RECV:
+		return true
+	default:
+		return false
+	}
+}
+
+// prepareRW validates a read or write request, and returns the file it
+// targets, the memory it transfers to or from and the file offset, which is
+// -1 for the current file position.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) prepareRW(t *kernel.Task, sqe *linux.IOUringSqe) (*vfs.FileDescription, usermem.IOSequence, int64, error) {
+	// Check that a file descriptor is valid.
+	if sqe.Fd < 0 {
+		return nil, usermem.IOSequence{}, 0, linuxerr.EBADF
+	}
+	// Currently we don't support any flags for the SQEs.
+	if sqe.Flags != 0 {
+		return nil, usermem.IOSequence{}, 0, linuxerr.EINVAL
+	}
+	// ioprio should not be set for read and write operations.
+	if sqe.IoPrio != 0 {
+		return nil, usermem.IOSequence{}, 0, linuxerr.EINVAL
+	}
+	if sqe.OpFlags&^linux.RWF_VALID != 0 {
+		return nil, usermem.IOSequence{}, 0, linuxerr.EOPNOTSUPP
+	}
+	offset := int64(sqe.OffOrAddrOrCmdOp)
+	if offset < -1 {
+		return nil, usermem.IOSequence{}, 0, linuxerr.EINVAL
+	}
+
+	// AddressSpaceActive is set to true as requests are always processed on
+	// the task goroutine.
+	opts := usermem.IOOpts{
+		AddressSpaceActive: true,
+	}
+	var (
+		seq usermem.IOSequence
+		err error
+	)
+	switch sqe.Opcode {
+	case linux.IORING_OP_READ_FIXED, linux.IORING_OP_WRITE_FIXED:
+		seq, err = fd.fixedIOSequenceLocked(t, sqe, opts)
+	default:
+		seq, err = t.IovecsIOSequence(hostarch.Addr(sqe.AddrOrSpliceOff), int(sqe.Len), opts)
+	}
+	if err != nil {
+		return nil, usermem.IOSequence{}, 0, err
+	}
+
+	file := t.GetFile(sqe.Fd)
+	if file == nil {
+		return nil, usermem.IOSequence{}, 0, linuxerr.EBADF
+	}
+	return file, seq, offset, nil
+}
+
+// fixedIOSequenceLocked returns the IOSequence for a request using a
+// registered buffer. Like Linux, the request's range must lie within the
+// registered buffer.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) fixedIOSequenceLocked(t *kernel.Task, sqe *linux.IOUringSqe, opts usermem.IOOpts) (usermem.IOSequence, error) {
+	idx := int(sqe.BufIndexOrGroup)
+	if idx >= len(fd.bufs) {
+		return usermem.IOSequence{}, linuxerr.EFAULT
+	}
+	buf := fd.bufs[idx]
+	start := hostarch.Addr(sqe.AddrOrSpliceOff)
+	end, ok := start.AddLength(uint64(sqe.Len))
+	if !ok || start < buf.start || end > buf.end {
+		return usermem.IOSequence{}, linuxerr.EFAULT
+	}
+	return t.SingleIOSequence(start, int(sqe.Len), opts)
+}
+
+// doRW calls rw, which transfers seq at offset, until it completes with
+// anything other than "would block". If the file is in non-blocking mode or
+// nowait is set, doRW doesn't wait for the file to become ready.
+func doRW(t *kernel.Task, file *vfs.FileDescription, mask waiter.EventMask, seq usermem.IOSequence, offset int64, nowait bool, rw func(usermem.IOSequence, int64) (int64, error)) (int64, error) {
+	n, err := rw(seq, offset)
+	if !linuxerr.Equals(linuxerr.ErrWouldBlock, err) || nowait || file.StatusFlags()&linux.O_NONBLOCK != 0 {
+		return n, err
+	}
+
+	w, ch := waiter.NewChannelEntry(mask)
+	if err := file.EventRegister(&w); err != nil {
+		return n, err
+	}
+	defer file.EventUnregister(&w)
+
+	total := n
+	for {
+		seq = seq.DropFirst(int(n))
+		if offset >= 0 {
+			offset += n
+		}
+		n, err = rw(seq, offset)
+		total += n
+		if !linuxerr.Equals(linuxerr.ErrWouldBlock, err) {
+			break
+		}
+		if err = t.Block(ch); err != nil {
+			break
+		}
+	}
+	return total, err
+}
+
+// handleRead handles IORING_OP_READV and IORING_OP_READ_FIXED.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) handleRead(t *kernel.Task, sqe *linux.IOUringSqe) (int32, error) {
+	file, dst, offset, err := fd.prepareRW(t, sqe)
+	if err != nil {
+		return 0, err
+	}
+	defer file.DecRef(t)
+
+	opts := vfs.ReadOptions{Flags: sqe.OpFlags}
+	var n int64
+	fd.unlocked(t, func() {
+		n, err = doRW(t, file, eventMaskRead, dst, offset, sqe.OpFlags&linux.RWF_NOWAIT != 0, func(dst usermem.IOSequence, offset int64) (int64, error) {
+			if offset >= 0 {
+				n, err := file.PRead(t, dst, offset, opts)
+				if !linuxerr.Equals(linuxerr.ESPIPE, err) {
+					return n, err
+				}
+				// Like Linux, the offset is ignored for non-seekable files.
+			}
+			return file.Read(t, dst, opts)
+		})
+	})
+	if n != 0 && (err == io.EOF || linuxerr.Equals(linuxerr.ErrWouldBlock, err) || linuxerr.Equals(linuxerr.ErrInterrupted, err)) {
+		// Short reads aren't failures.
+		err = nil
+	}
+	return int32(n), err
+}
+
+// handleWrite handles IORING_OP_WRITEV and IORING_OP_WRITE_FIXED.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) handleWrite(t *kernel.Task, sqe *linux.IOUringSqe) (int32, error) {
+	file, src, offset, err := fd.prepareRW(t, sqe)
+	if err != nil {
+		return 0, err
+	}
+	defer file.DecRef(t)
+
+	opts := vfs.WriteOptions{Flags: sqe.OpFlags}
+	var n int64
+	fd.unlocked(t, func() {
+		n, err = doRW(t, file, eventMaskWrite, src, offset, sqe.OpFlags&linux.RWF_NOWAIT != 0, func(src usermem.IOSequence, offset int64) (int64, error) {
+			if offset >= 0 {
+				n, err := file.PWrite(t, src, offset, opts)
+				if !linuxerr.Equals(linuxerr.ESPIPE, err) {
+					return n, err
+				}
+				// Like Linux, the offset is ignored for non-seekable files.
+			}
+			return file.Write(t, src, opts)
+		})
+	})
+	if n != 0 && (linuxerr.Equals(linuxerr.ErrWouldBlock, err) || linuxerr.Equals(linuxerr.ErrInterrupted, err)) {
+		// Short writes aren't failures.
+		err = nil
+	}
+	return int32(n), err
+}
+
+// handleFsync handles IORING_OP_FSYNC.
+func handleFsync(t *kernel.Task, sqe *linux.IOUringSqe) error {
+	if sqe.Fd < 0 {
+		return linuxerr.EBADF
+	}
+	if sqe.Flags != 0 || sqe.AddrOrSpliceOff != 0 || sqe.BufIndexOrGroup != 0 {
+		return linuxerr.EINVAL
+	}
+	if sqe.OpFlags&^linux.IORING_FSYNC_DATASYNC != 0 {
+		return linuxerr.EINVAL
+	}
+	file := t.GetFile(sqe.Fd)
+	if file == nil {
+		return linuxerr.EBADF
+	}
+	defer file.DecRef(t)
+
+	// As in fdatasync(2) and sync_file_range(2), a full sync satisfies both
+	// IORING_FSYNC_DATASYNC and the requested range.
+	return file.Sync(t)
+}
+
+// handleTimeout handles IORING_OP_TIMEOUT. On success, the request is queued
+// in fd.timeouts and completed later by flushTimeoutsLocked.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) handleTimeout(t *kernel.Task, sqe *linux.IOUringSqe) error {
+	if sqe.Flags != 0 || sqe.BufIndexOrGroup != 0 || sqe.Len != 1 {
+		return linuxerr.EINVAL
+	}
+	if sqe.OpFlags&^linux.IORING_TIMEOUT_ABS != 0 {
+		return linuxerr.EINVAL
+	}
+	var ts linux.Timespec
+	if _, err := ts.CopyIn(t, hostarch.Addr(sqe.AddrOrSpliceOff)); err != nil {
+		return err
+	}
+	if ts.Sec < 0 || ts.Nsec < 0 {
+		return linuxerr.EINVAL
+	}
+
+	pt := pendingTimeout{userData: sqe.UserData}
+	if sqe.OpFlags&linux.IORING_TIMEOUT_ABS != 0 {
+		pt.deadline = ktime.FromTimespec(ts)
+	} else {
+		pt.deadline = t.Kernel().MonotonicClock().Now().Add(ts.ToDuration())
+	}
+	if count := uint32(sqe.OffOrAddrOrCmdOp); count != 0 {
+		pt.target = fd.completions + uint64(count)
+	}
+	fd.timeouts = append(fd.timeouts, pt)
+	return nil
+}
+
+// flushTimeoutsLocked posts CQEs for the pending timeouts that have either
+// expired or seen their target number of completions.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) flushTimeoutsLocked(t *kernel.Task) error {
+	if len(fd.timeouts) == 0 {
+		return nil
+	}
+	now := t.Kernel().MonotonicClock().Now()
+	pending := fd.timeouts[:0]
+	var err error
+	for _, pt := range fd.timeouts {
+		var res int32
+		switch {
+		case err != nil:
+			pending = append(pending, pt)
+			continue
+		case pt.target != 0 && fd.completions >= pt.target:
+			res = 0
+		case !now.Before(pt.deadline):
+			res = -int32(linuxerr.ETIME.Errno())
+		default:
+			pending = append(pending, pt)
+			continue
+		}
+		err = fd.postCqeLocked(&linux.IOUringCqe{
+			UserData: pt.userData,
+			Res:      res,
+		})
+	}
+	fd.timeouts = pending
+	return err
+}
+
+// nextTimeoutLocked returns the earliest deadline of the pending timeouts, if
+// any.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) nextTimeoutLocked() (ktime.Time, bool) {
+	if len(fd.timeouts) == 0 {
+		return ktime.Time{}, false
+	}
+	deadline := fd.timeouts[0].deadline
+	for _, pt := range fd.timeouts[1:] {
+		if pt.deadline.Before(deadline) {
+			deadline = pt.deadline
+		}
+	}
+	return deadline, true
+}
+
+// getSocket returns the file referred to by fd, which must be a socket.
+func getSocket(t *kernel.Task, fd int32) (*vfs.FileDescription, socket.Socket, error) {
+	file := t.GetFile(fd)
+	if file == nil {
+		return nil, nil, linuxerr.EBADF
+	}
+	s, ok := file.Impl().(socket.Socket)
+	if !ok {
+		file.DecRef(t)
+		return nil, nil, linuxerr.ENOTSOCK
+	}
+	return file, s, nil
+}
+
+// handleAccept handles IORING_OP_ACCEPT.
+func handleAccept(t *kernel.Task, sqe *linux.IOUringSqe) (int32, error) {
+	if sqe.Flags != 0 || sqe.Len != 0 || sqe.BufIndexOrGroup != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	flags := int(sqe.OpFlags)
+	if flags&^(linux.SOCK_NONBLOCK|linux.SOCK_CLOEXEC) != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	file, s, err := getSocket(t, sqe.Fd)
+	if err != nil {
+		return 0, err
+	}
+	defer file.DecRef(t)
+
+	addrPtr := hostarch.Addr(sqe.AddrOrSpliceOff)
+	addrLenPtr := hostarch.Addr(sqe.OffOrAddrOrCmdOp)
+	blocking := file.StatusFlags()&linux.SOCK_NONBLOCK == 0
+	peerRequested := addrLenPtr != 0
+	nfd, peer, peerLen, e := s.Accept(t, peerRequested, flags, blocking)
+	if e != nil {
+		return 0, e.ToError()
+	}
+	if peerRequested {
+		// NOTE: Like accept(2), errors writing the address out are ignored
+		// unless the buffer length is invalid.
+		if err := copyOutAddress(t, peer, peerLen, addrPtr, addrLenPtr); linuxerr.Equals(linuxerr.EINVAL, err) {
+			return 0, err
+		}
+	}
+	return nfd, nil
+}
+
+// copyOutAddress writes a socket address and its length to userspace,
+// truncating the address to the size of the buffer.
+func copyOutAddress(t *kernel.Task, addr linux.SockAddr, addrLen uint32, addrPtr, addrLenPtr hostarch.Addr) error {
+	var bufLen uint32
+	if _, err := primitive.CopyUint32In(t, addrLenPtr, &bufLen); err != nil {
+		return err
+	}
+	if int32(bufLen) < 0 {
+		return linuxerr.EINVAL
+	}
+	if _, err := primitive.CopyUint32Out(t, addrLenPtr, addrLen); err != nil {
+		return err
+	}
+	if addr == nil {
+		return nil
+	}
+	encodedAddr := t.CopyScratchBuffer(addr.SizeBytes())
+	addr.MarshalUnsafe(encodedAddr)
+	if bufLen > addrLen {
+		bufLen = addrLen
+	}
+	if bufLen > uint32(len(encodedAddr)) {
+		bufLen = uint32(len(encodedAddr))
+	}
+	_, err := t.CopyOutBytes(addrPtr, encodedAddr[:bufLen])
+	return err
+}
+
+// handleConnect handles IORING_OP_CONNECT.
+func handleConnect(t *kernel.Task, sqe *linux.IOUringSqe) error {
+	if sqe.Flags != 0 || sqe.Len != 0 || sqe.BufIndexOrGroup != 0 || sqe.OpFlags != 0 {
+		return linuxerr.EINVAL
+	}
+	addrLen := sqe.OffOrAddrOrCmdOp
+	if addrLen > maxAddrLen {
+		return linuxerr.EINVAL
+	}
+	addr := make([]byte, addrLen)
+	if _, err := t.CopyInBytes(hostarch.Addr(sqe.AddrOrSpliceOff), addr); err != nil {
+		return err
+	}
+	file, s, err := getSocket(t, sqe.Fd)
+	if err != nil {
+		return err
+	}
+	defer file.DecRef(t)
+
+	blocking := file.StatusFlags()&linux.SOCK_NONBLOCK == 0
+	return s.Connect(t, addr, blocking).ToError()
+}
+
+// handleSend handles IORING_OP_SEND.
+func handleSend(t *kernel.Task, sqe *linux.IOUringSqe) (int32, error) {
+	if sqe.Flags != 0 || sqe.OffOrAddrOrCmdOp != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	file, s, err := getSocket(t, sqe.Fd)
+	if err != nil {
+		return 0, err
+	}
+	defer file.DecRef(t)
+
+	// Like Linux, io_uring never raises SIGPIPE.
+	flags := int(sqe.OpFlags) | linux.MSG_NOSIGNAL
+	if file.StatusFlags()&linux.SOCK_NONBLOCK != 0 {
+		flags |= linux.MSG_DONTWAIT
+	}
+	src, err := t.SingleIOSequence(hostarch.Addr(sqe.AddrOrSpliceOff), int(sqe.Len), usermem.IOOpts{
+		AddressSpaceActive: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	n, e := s.SendMsg(t, src, nil, flags, false, ktime.Time{}, socket.ControlMessages{Unix: control.New(t, s)})
+	if n != 0 {
+		return int32(n), nil
+	}
+	return 0, e.ToError()
+}
+
+// handleRecv handles IORING_OP_RECV.
+func handleRecv(t *kernel.Task, sqe *linux.IOUringSqe) (int32, error) {
+	if sqe.Flags != 0 || sqe.OffOrAddrOrCmdOp != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	file, s, err := getSocket(t, sqe.Fd)
+	if err != nil {
+		return 0, err
+	}
+	defer file.DecRef(t)
+
+	flags := int(sqe.OpFlags) | linux.MSG_NOSIGNAL
+	if file.StatusFlags()&linux.SOCK_NONBLOCK != 0 {
+		flags |= linux.MSG_DONTWAIT
+	}
+	dst, err := t.SingleIOSequence(hostarch.Addr(sqe.AddrOrSpliceOff), int(sqe.Len), usermem.IOOpts{
+		AddressSpaceActive: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	n, _, _, _, cm, e := s.RecvMsg(t, dst, flags, false, ktime.Time{}, false, 0)
+	cm.Release(t)
+	if e != nil {
+		return 0, e.ToError()
+	}
+	return int32(n), nil
+}
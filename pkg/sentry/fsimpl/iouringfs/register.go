@@ -0,0 +1,134 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iouringfs
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+)
+
+// maxRegisteredBufferLen is the maximum length of a registered buffer. See
+// io_uring/rsrc.c:io_buffer_validate().
+const maxRegisteredBufferLen = 1 << 30
+
+// registeredBuffer is a buffer registered with IORING_REGISTER_BUFFERS.
+//
+// Unlike Linux, the backing pages aren't pinned: requests using the buffer
+// access the task's address space like other requests.
+//
+// +stateify savable
+type registeredBuffer struct {
+	start hostarch.Addr
+	end   hostarch.Addr
+}
+
+// Register implements io_uring_register(2) for the given opcode.
+func (fd *FileDescription) Register(t *kernel.Task, opcode uint32, arg hostarch.Addr, nrArgs uint32) error {
+	fd.lock(t)
+	defer fd.unlock()
+
+	switch opcode {
+	case linux.IORING_REGISTER_BUFFERS:
+		return fd.registerBuffersLocked(t, arg, nrArgs)
+	case linux.IORING_UNREGISTER_BUFFERS:
+		if arg != 0 || nrArgs != 0 {
+			return linuxerr.EINVAL
+		}
+		if len(fd.bufs) == 0 {
+			return linuxerr.ENXIO
+		}
+		fd.bufs = nil
+		return nil
+	case linux.IORING_REGISTER_PROBE:
+		return registerProbe(t, arg, nrArgs)
+	default:
+		return linuxerr.EINVAL
+	}
+}
+
+// registerBuffersLocked handles IORING_REGISTER_BUFFERS.
+//
+// Preconditions: fd.lock has been called.
+func (fd *FileDescription) registerBuffersLocked(t *kernel.Task, arg hostarch.Addr, nrArgs uint32) error {
+	if len(fd.bufs) != 0 {
+		return linuxerr.EBUSY
+	}
+	if nrArgs == 0 || nrArgs > linux.IORING_MAX_REG_BUFFERS {
+		return linuxerr.EINVAL
+	}
+
+	// struct iovec is 16 bytes on all supported architectures.
+	const iovecSize = 16
+	b := make([]byte, iovecSize*int(nrArgs))
+	if _, err := t.CopyInBytes(arg, b); err != nil {
+		return err
+	}
+	bufs := make([]registeredBuffer, 0, nrArgs)
+	for i := 0; i < len(b); i += iovecSize {
+		base := hostarch.Addr(hostarch.ByteOrder.Uint64(b[i:]))
+		length := hostarch.ByteOrder.Uint64(b[i+8:])
+		if base == 0 && length != 0 {
+			return linuxerr.EFAULT
+		}
+		if length > maxRegisteredBufferLen {
+			return linuxerr.EFAULT
+		}
+		ar, ok := t.MemoryManager().CheckIORange(base, int64(length))
+		if !ok {
+			return linuxerr.EFAULT
+		}
+		bufs = append(bufs, registeredBuffer{start: ar.Start, end: ar.End})
+	}
+	fd.bufs = bufs
+	return nil
+}
+
+// registerProbe handles IORING_REGISTER_PROBE.
+func registerProbe(t *kernel.Task, arg hostarch.Addr, nrArgs uint32) error {
+	// The highest opcode known to this implementation.
+	const lastOp = linux.IORING_OP_RECV
+	if nrArgs > lastOp+1 {
+		nrArgs = lastOp + 1
+	}
+
+	// Like Linux, require the probe to be zeroed.
+	var hdr linux.IOUringProbe
+	size := hdr.SizeBytes() + int(nrArgs)*(*linux.IOUringProbeOp)(nil).SizeBytes()
+	b := make([]byte, size)
+	if _, err := t.CopyInBytes(arg, b); err != nil {
+		return err
+	}
+	for _, c := range b {
+		if c != 0 {
+			return linuxerr.EINVAL
+		}
+	}
+
+	hdr.LastOp = lastOp
+	hdr.OpsLen = uint8(nrArgs)
+	hdr.MarshalUnsafe(b)
+	for i := uint32(0); i < nrArgs; i++ {
+		op := linux.IOUringProbeOp{Op: uint8(i)}
+		if opSupported(uint8(i)) {
+			op.Flags = linux.IO_URING_OP_SUPPORTED
+		}
+		off := hdr.SizeBytes() + int(i)*op.SizeBytes()
+		op.MarshalUnsafe(b[off:])
+	}
+	_, err := t.CopyOutBytes(arg, b)
+	return err
+}
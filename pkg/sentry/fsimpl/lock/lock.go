@@ -163,6 +163,24 @@ func (l *Locks) LockRegion(ctx context.Context, uid UniqueID, ownerPID int32, t
 	}
 }
 
+// WaitRegion blocks until uid could take a typed lock on a region of a file,
+// without taking it. Since the lock is not taken, it may be taken by another
+// uid before the caller does. It returns linuxerr.ErrInterrupted if it is
+// interrupted.
+func (l *Locks) WaitRegion(ctx context.Context, uid UniqueID, t LockType, r LockRange) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for !l.locks.canLock(uid, t, r) {
+		// Note: we release the lock in EventRegister, as in LockRegion.
+		ok := ctx.BlockOn(l, waiter.EventIn)
+		l.mu.Lock() // +checklocksforce: see above.
+		if !ok {
+			return linuxerr.ErrInterrupted
+		}
+	}
+	return nil
+}
+
 // Readiness always returns zero.
 func (l *Locks) Readiness(waiter.EventMask) waiter.EventMask {
 	return 0
@@ -539,6 +557,39 @@ func (l *Locks) TestRegion(ctx context.Context, uid UniqueID, t LockType, r Lock
 	return f
 }
 
+// HeldRegions calls fn, in increasing order, for each maximal subrange of r on
+// which locks are held, along with the strongest type of the locks held on it.
+// Regions with distinct types may be adjacent.
+//
+// fn is called with l's mutex locked, so it must not call methods on l.
+func (l *Locks) HeldRegions(r LockRange, fn func(r LockRange, t LockType)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var (
+		cur     LockRange
+		curType LockType
+	)
+	for seg := l.locks.LowerBoundSegment(r.Start); seg.Ok() && seg.Start() < r.End; seg = seg.NextSegment() {
+		t := ReadLock
+		if seg.Value().Writer != nil {
+			t = WriteLock
+		}
+		segRange := seg.Range().Intersect(r)
+		if cur.Length() != 0 && cur.End == segRange.Start && curType == t {
+			cur.End = segRange.End
+			continue
+		}
+		if cur.Length() != 0 {
+			fn(cur, curType)
+		}
+		cur, curType = segRange, t
+	}
+	if cur.Length() != 0 {
+		fn(cur, curType)
+	}
+}
+
 func (l *Locks) testRegion(r LockRange, check func(lock Lock, start, length uint64) bool) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
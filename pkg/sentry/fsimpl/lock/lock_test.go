@@ -1047,3 +1047,71 @@ func TestUnlock(t *testing.T) {
 		})
 	}
 }
+
+func TestHeldRegions(t *testing.T) {
+	type region struct {
+		LockRange
+		Type LockType
+	}
+	before := []entry{
+		{
+			Lock:      Lock{Readers: map[UniqueID]OwnerInfo{1: {}}},
+			LockRange: LockRange{0, 10},
+		},
+		{
+			Lock:      Lock{Readers: map[UniqueID]OwnerInfo{1: {}, 2: {}}},
+			LockRange: LockRange{10, 20},
+		},
+		{
+			Lock:      Lock{Writer: 3},
+			LockRange: LockRange{20, 30},
+		},
+		{
+			Lock:      Lock{Readers: map[UniqueID]OwnerInfo{1: {}}},
+			LockRange: LockRange{40, 50},
+		},
+	}
+	for _, test := range []struct {
+		name string
+		r    LockRange
+		want []region
+	}{
+		{
+			name: "whole file",
+			r:    LockRange{0, LockEOF},
+			want: []region{
+				{LockRange{0, 20}, ReadLock},
+				{LockRange{20, 30}, WriteLock},
+				{LockRange{40, 50}, ReadLock},
+			},
+		},
+		{
+			name: "partial segments",
+			r:    LockRange{5, 45},
+			want: []region{
+				{LockRange{5, 20}, ReadLock},
+				{LockRange{20, 30}, WriteLock},
+				{LockRange{40, 45}, ReadLock},
+			},
+		},
+		{
+			name: "gap",
+			r:    LockRange{30, 40},
+		},
+		{
+			name: "past last lock",
+			r:    LockRange{50, LockEOF},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			l := Locks{locks: fill(before)}
+			var got []region
+			l.HeldRegions(test.r, func(r LockRange, t LockType) {
+				got = append(got, region{r, t})
+			})
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("HeldRegions(%v) got %+v, want %+v", test.r, got, test.want)
+			}
+		})
+	}
+}
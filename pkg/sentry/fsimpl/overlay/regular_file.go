@@ -163,6 +163,16 @@ func (fd *regularFileFD) Allocate(ctx context.Context, mode, offset, length uint
 	return wrappedFD.Allocate(ctx, mode, offset, length)
 }
 
+// Advise implements vfs.FileDescriptionImpl.Advise.
+func (fd *regularFileFD) Advise(ctx context.Context, offset, length int64, advice int32) error {
+	wrappedFD, err := fd.getCurrentFD(ctx)
+	if err != nil {
+		return err
+	}
+	defer wrappedFD.DecRef(ctx)
+	return wrappedFD.Advise(ctx, offset, length, advice)
+}
+
 // SetStat implements vfs.FileDescriptionImpl.SetStat.
 func (fd *regularFileFD) SetStat(ctx context.Context, opts vfs.SetStatOptions) error {
 	d := fd.dentry()
@@ -472,6 +482,30 @@ func (d *dentry) Translate(ctx context.Context, required, optional memmap.Mappab
 	return d.wrappedMappable.Translate(ctx, required, optional, at)
 }
 
+// ForEachResidentRange implements memmap.ResidencyMappable.ForEachResidentRange.
+func (d *dentry) ForEachResidentRange(ctx context.Context, mr memmap.MappableRange, fn func(memmap.MappableRange)) {
+	d.dataMu.RLock()
+	defer d.dataMu.RUnlock()
+	if rm, ok := d.wrappedMappable.(memmap.ResidencyMappable); ok {
+		rm.ForEachResidentRange(ctx, mr, fn)
+		return
+	}
+	fn(mr)
+}
+
+// InvalidateCache implements memmap.CacheInvalidatingMappable.InvalidateCache.
+func (d *dentry) InvalidateCache(ctx context.Context, mr memmap.MappableRange) error {
+	// Lock d.mapsMu rather than d.dataMu since the wrapped Mappable may
+	// invalidate translations, which locks MM.activeMu, which MM.Translate
+	// holds while calling d.Translate (which locks d.dataMu).
+	d.mapsMu.Lock()
+	defer d.mapsMu.Unlock()
+	if cim, ok := d.wrappedMappable.(memmap.CacheInvalidatingMappable); ok {
+		return cim.InvalidateCache(ctx, mr)
+	}
+	return nil
+}
+
 // InvalidateUnsavable implements memmap.Mappable.InvalidateUnsavable.
 func (d *dentry) InvalidateUnsavable(ctx context.Context) error {
 	d.mapsMu.Lock()
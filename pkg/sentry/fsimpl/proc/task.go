@@ -59,22 +59,24 @@ func (fs *filesystem) newTaskInode(ctx context.Context, task *kernel.Task, pidns
 	}
 
 	contents := map[string]kernfs.Inode{
-		"auxv":      fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &auxvData{task: task}),
-		"cmdline":   fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &metadataData{task: task, metaType: Cmdline}),
-		"comm":      fs.newComm(ctx, task, fs.NextIno(), 0644),
-		"cwd":       fs.newCwdSymlink(ctx, task, fs.NextIno()),
-		"environ":   fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &metadataData{task: task, metaType: Environ}),
-		"exe":       fs.newExeSymlink(ctx, task, fs.NextIno()),
-		"fd":        fs.newFDDirInode(ctx, task),
-		"fdinfo":    fs.newFDInfoDirInode(ctx, task),
-		"gid_map":   fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0644, &idMapData{task: task, gids: true}),
-		"io":        fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0400, newIO(task, isThreadGroup)),
-		"limits":    fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &limitsData{task: task}),
-		"maps":      fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &mapsData{task: task}),
-		"mem":       fs.newMemInode(ctx, task, fs.NextIno(), 0600),
-		"mountinfo": fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &mountInfoData{fs: fs, task: task}),
-		"mounts":    fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &mountsData{fs: fs, task: task}),
-		"net":       fs.newTaskNetDir(ctx, task),
+		"auxv":       fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &auxvData{task: task}),
+		"build_id":   fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &buildIDData{task: task}),
+		"cmdline":    fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &metadataData{task: task, metaType: Cmdline}),
+		"comm":       fs.newComm(ctx, task, fs.NextIno(), 0644),
+		"cwd":        fs.newCwdSymlink(ctx, task, fs.NextIno()),
+		"environ":    fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &metadataData{task: task, metaType: Environ}),
+		"exe":        fs.newExeSymlink(ctx, task, fs.NextIno()),
+		"fd":         fs.newFDDirInode(ctx, task),
+		"fdinfo":     fs.newFDInfoDirInode(ctx, task),
+		"gid_map":    fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0644, &idMapData{task: task, gids: true}),
+		"io":         fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0400, newIO(task, isThreadGroup)),
+		"limits":     fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &limitsData{task: task}),
+		"maps":       fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &mapsData{task: task}),
+		"mem":        fs.newMemInode(ctx, task, fs.NextIno(), 0600),
+		"mountinfo":  fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &mountInfoData{fs: fs, task: task}),
+		"mounts":     fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &mountsData{fs: fs, task: task}),
+		"mountstats": fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0400, &mountStatsData{fs: fs, task: task}),
+		"net":        fs.newTaskNetDir(ctx, task),
 		"ns": fs.newTaskOwnedDir(ctx, task, fs.NextIno(), 0511, map[string]kernfs.Inode{
 			"net":  fs.newNamespaceSymlink(ctx, task, fs.NextIno(), linux.CLONE_NEWNET),
 			"mnt":  fs.newNamespaceSymlink(ctx, task, fs.NextIno(), linux.CLONE_NEWNS),
@@ -82,15 +84,19 @@ func (fs *filesystem) newTaskInode(ctx context.Context, task *kernel.Task, pidns
 			"user": fs.newFakeNamespaceSymlink(ctx, task, fs.NextIno(), "user"),
 			"ipc":  fs.newNamespaceSymlink(ctx, task, fs.NextIno(), linux.CLONE_NEWIPC),
 			"uts":  fs.newNamespaceSymlink(ctx, task, fs.NextIno(), linux.CLONE_NEWUTS),
+			"time": fs.newNamespaceSymlink(ctx, task, fs.NextIno(), linux.CLONE_NEWTIME),
+
+			"time_for_children": fs.newChildNamespaceSymlink(ctx, task, fs.NextIno(), linux.CLONE_NEWTIME),
 		}),
-		"oom_score":     fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, newStaticFile("0\n")),
-		"oom_score_adj": fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0644, &oomScoreAdj{task: task}),
-		"root":          fs.newRootSymlink(ctx, task, fs.NextIno()),
-		"smaps":         fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &smapsData{task: task}),
-		"stat":          fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &taskStatData{task: task, pidns: pidns, tgstats: isThreadGroup}),
-		"statm":         fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &statmData{task: task}),
-		"status":        fs.newStatusInode(ctx, task, pidns, fs.NextIno(), 0444),
-		"uid_map":       fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0644, &idMapData{task: task, gids: false}),
+		"oom_score":      fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, newStaticFile("0\n")),
+		"oom_score_adj":  fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0644, &oomScoreAdj{task: task}),
+		"root":           fs.newRootSymlink(ctx, task, fs.NextIno()),
+		"smaps":          fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &smapsData{task: task}),
+		"stat":           fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &taskStatData{task: task, pidns: pidns, tgstats: isThreadGroup}),
+		"statm":          fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0444, &statmData{task: task}),
+		"status":         fs.newStatusInode(ctx, task, pidns, fs.NextIno(), 0444),
+		"timens_offsets": fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0644, &timensOffsetsData{task: task}),
+		"uid_map":        fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0644, &idMapData{task: task, gids: false}),
 	}
 	if isThreadGroup {
 		contents["task"] = fs.newSubtasks(ctx, task, pidns, fakeCgroupControllers)
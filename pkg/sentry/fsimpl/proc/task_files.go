@@ -18,9 +18,11 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/context"
@@ -136,6 +138,37 @@ func (d *auxvData) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	return nil
 }
 
+// buildIDData implements vfs.DynamicBytesSource for /proc/[pid]/build_id,
+// which contains the hex-encoded NT_GNU_BUILD_ID note of the task's
+// executable. It is empty if the executable has no build ID.
+//
+// +stateify savable
+type buildIDData struct {
+	kernfs.DynamicBytesFile
+
+	task *kernel.Task
+}
+
+var _ dynamicInode = (*buildIDData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *buildIDData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	if d.task.ExitState() == kernel.TaskExitDead {
+		return linuxerr.ESRCH
+	}
+	m, err := getMMIncRef(d.task)
+	if err != nil {
+		// Return empty file.
+		return nil
+	}
+	defer m.DecUsers(ctx)
+
+	if id := m.BuildID(); len(id) != 0 {
+		fmt.Fprintf(buf, "%x\n", id)
+	}
+	return nil
+}
+
 // MetadataType enumerates the types of metadata that is exposed through proc.
 type MetadataType int
 
@@ -642,6 +675,7 @@ type mapsData struct {
 }
 
 var _ dynamicInode = (*mapsData)(nil)
+var _ vfs.IncrementalDynamicBytesSource = (*mapsData)(nil)
 
 // Generate implements vfs.DynamicBytesSource.Generate.
 func (d *mapsData) Generate(ctx context.Context, buf *bytes.Buffer) error {
@@ -651,6 +685,17 @@ func (d *mapsData) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	return nil
 }
 
+// GenerateFrom implements vfs.IncrementalDynamicBytesSource.GenerateFrom.
+// Record positions are virtual addresses.
+func (d *mapsData) GenerateFrom(ctx context.Context, buf *bytes.Buffer, pos uint64) (uint64, bool, error) {
+	mm := getMM(d.task)
+	if mm == nil {
+		return 0, true, nil
+	}
+	next, done := mm.ReadMapsChunkInto(ctx, buf, hostarch.Addr(pos))
+	return uint64(next), done, nil
+}
+
 // smapsData implements vfs.DynamicBytesSource for /proc/[pid]/smaps.
 //
 // +stateify savable
@@ -661,6 +706,7 @@ type smapsData struct {
 }
 
 var _ dynamicInode = (*smapsData)(nil)
+var _ vfs.IncrementalDynamicBytesSource = (*smapsData)(nil)
 
 // Generate implements vfs.DynamicBytesSource.Generate.
 func (d *smapsData) Generate(ctx context.Context, buf *bytes.Buffer) error {
@@ -670,6 +716,17 @@ func (d *smapsData) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	return nil
 }
 
+// GenerateFrom implements vfs.IncrementalDynamicBytesSource.GenerateFrom.
+// Record positions are virtual addresses.
+func (d *smapsData) GenerateFrom(ctx context.Context, buf *bytes.Buffer, pos uint64) (uint64, bool, error) {
+	mm := getMM(d.task)
+	if mm == nil {
+		return 0, true, nil
+	}
+	next, done := mm.ReadSmapsChunkInto(ctx, buf, hostarch.Addr(pos))
+	return uint64(next), done, nil
+}
+
 // +stateify savable
 type taskStatData struct {
 	kernfs.DynamicBytesFile
@@ -701,16 +758,16 @@ func (s *taskStatData) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	fmt.Fprintf(buf, "%d ", s.pidns.IDOfSession(s.task.ThreadGroup().Session()))
 	fmt.Fprintf(buf, "0 0 " /* tty_nr tpgid */)
 	fmt.Fprintf(buf, "0 " /* flags */)
-	fmt.Fprintf(buf, "0 0 0 0 " /* minflt cminflt majflt cmajflt */)
 	var cputime usage.CPUStats
 	if s.tgstats {
 		cputime = s.task.ThreadGroup().CPUStats()
 	} else {
 		cputime = s.task.CPUStats()
 	}
+	ccputime := s.task.ThreadGroup().JoinedChildCPUStats()
+	fmt.Fprintf(buf, "%d %d %d %d ", cputime.MinorFaults, ccputime.MinorFaults, cputime.MajorFaults, ccputime.MajorFaults)
 	fmt.Fprintf(buf, "%d %d ", linux.ClockTFromDuration(cputime.UserTime), linux.ClockTFromDuration(cputime.SysTime))
-	cputime = s.task.ThreadGroup().JoinedChildCPUStats()
-	fmt.Fprintf(buf, "%d %d ", linux.ClockTFromDuration(cputime.UserTime), linux.ClockTFromDuration(cputime.SysTime))
+	fmt.Fprintf(buf, "%d %d ", linux.ClockTFromDuration(ccputime.UserTime), linux.ClockTFromDuration(ccputime.SysTime))
 	fmt.Fprintf(buf, "%d %d ", s.task.Priority(), s.task.Niceness())
 	fmt.Fprintf(buf, "%d ", s.task.ThreadGroup().Count())
 
@@ -718,8 +775,14 @@ func (s *taskStatData) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	// maintained, and is hard coded as 0.
 	fmt.Fprintf(buf, "0 ")
 
-	// Start time is relative to boot time, expressed in clock ticks.
-	fmt.Fprintf(buf, "%d ", linux.ClockTFromDuration(s.task.StartTime().Sub(s.task.Kernel().Timekeeper().BootTime())))
+	// Start time is relative to boot time, expressed in clock ticks. As in
+	// Linux, it is on CLOCK_BOOTTIME in the reader's time namespace.
+	startTime := s.task.StartTime().Sub(s.task.Kernel().Timekeeper().BootTime())
+	if t := kernel.TaskFromContext(ctx); t != nil {
+		_, boottime := t.TimeNamespace().Offsets()
+		startTime += boottime
+	}
+	fmt.Fprintf(buf, "%d ", linux.ClockTFromDuration(startTime))
 
 	var vss, rss uint64
 	if mm := getMM(s.task); mm != nil {
@@ -887,6 +950,7 @@ func (s *statusFD) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	sgid := creds.SavedKGID.In(s.userns).OrOverflow()
 	var fds int
 	var vss, rss, data uint64
+	thpEnabled := 1
 	s.task.WithMuLocked(func(t *kernel.Task) {
 		if fdTable := t.FDTable(); fdTable != nil {
 			fds = fdTable.CurrentMaxFDs()
@@ -896,6 +960,9 @@ func (s *statusFD) Generate(ctx context.Context, buf *bytes.Buffer) error {
 		vss = mm.VirtualMemorySize()
 		rss = mm.ResidentSetSize()
 		data = mm.VirtualDataSize()
+		if mm.THPDisabled() {
+			thpEnabled = 0
+		}
 	}
 	// Filesystem user/group IDs aren't implemented; effective UID/GID are used
 	// instead.
@@ -915,17 +982,26 @@ func (s *statusFD) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	fmt.Fprintf(buf, "VmSize:\t%d kB\n", vss>>10)
 	fmt.Fprintf(buf, "VmRSS:\t%d kB\n", rss>>10)
 	fmt.Fprintf(buf, "VmData:\t%d kB\n", data>>10)
+	fmt.Fprintf(buf, "THP_enabled:\t%d\n", thpEnabled)
 
 	fmt.Fprintf(buf, "Threads:\t%d\n", s.task.ThreadGroup().Count())
 	fmt.Fprintf(buf, "CapInh:\t%016x\n", creds.InheritableCaps)
 	fmt.Fprintf(buf, "CapPrm:\t%016x\n", creds.PermittedCaps)
 	fmt.Fprintf(buf, "CapEff:\t%016x\n", creds.EffectiveCaps)
 	fmt.Fprintf(buf, "CapBnd:\t%016x\n", creds.BoundingCaps)
+	noNewPrivs := 0
+	if s.task.NoNewPrivs() {
+		noNewPrivs = 1
+	}
+	fmt.Fprintf(buf, "NoNewPrivs:\t%d\n", noNewPrivs)
 	fmt.Fprintf(buf, "Seccomp:\t%d\n", s.task.SeccompMode())
 	// We unconditionally report a single NUMA node. See
 	// pkg/sentry/syscalls/linux/sys_mempolicy.go.
 	fmt.Fprintf(buf, "Mems_allowed:\t1\n")
 	fmt.Fprintf(buf, "Mems_allowed_list:\t0\n")
+	cputime := s.task.CPUStats()
+	fmt.Fprintf(buf, "voluntary_ctxt_switches:\t%d\n", cputime.VoluntarySwitches)
+	fmt.Fprintf(buf, "nonvoluntary_ctxt_switches:\t%d\n", cputime.InvoluntarySwitches)
 	return nil
 }
 
@@ -1009,6 +1085,127 @@ func (o *oomScoreAdj) Write(ctx context.Context, _ *vfs.FileDescription, src use
 	return src.NumBytes(), nil
 }
 
+// timensOffsetsData implements vfs.WritableDynamicBytesSource for
+// /proc/[pid]/timens_offsets, which shows and sets the clock offsets of the
+// time namespace that the task's future children will be members of.
+//
+// +stateify savable
+type timensOffsetsData struct {
+	kernfs.DynamicBytesFile
+
+	task *kernel.Task
+}
+
+var _ vfs.WritableDynamicBytesSource = (*timensOffsetsData)(nil)
+
+// ktimeSecMax is Linux's KTIME_SEC_MAX, the largest number of seconds that
+// can be represented in nanoseconds by an int64.
+const ktimeSecMax = math.MaxInt64 / int64(time.Second)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *timensOffsetsData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	timens := d.task.GetChildTimeNamespace()
+	if timens == nil {
+		return linuxerr.ESRCH
+	}
+	defer timens.DecRef(ctx)
+	monotonic, boottime := timens.Offsets()
+	for _, o := range []struct {
+		name   string
+		offset time.Duration
+	}{
+		{"monotonic", monotonic},
+		{"boottime", boottime},
+	} {
+		// As in Linux's timespec64, nsec is always non-negative.
+		sec := int64(o.offset / time.Second)
+		nsec := int64(o.offset % time.Second)
+		if nsec < 0 {
+			sec--
+			nsec += int64(time.Second)
+		}
+		fmt.Fprintf(buf, "%-10s %10d %9d\n", o.name, sec, nsec)
+	}
+	return nil
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (d *timensOffsetsData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	if src.NumBytes() == 0 {
+		return 0, nil
+	}
+
+	// Limit input size so as not to impact performance if input size is large.
+	src = src.TakeFirst(hostarch.PageSize - 1)
+
+	str, err := usermem.CopyStringIn(ctx, src.IO, src.Addrs.Head().Start, int(src.Addrs.Head().Length()), src.Opts)
+	if err != nil && err != linuxerr.ENAMETOOLONG {
+		return 0, err
+	}
+
+	// Each line is "<clock> <sec> <nsec>", where <clock> is "monotonic",
+	// "boottime" or a clock ID. See Linux's fs/proc/base.c:timens_offsets_write().
+	now := kernel.KernelFromContext(ctx).MonotonicClock().Now()
+	var offsets []kernel.TimeOffset
+	for _, line := range strings.Split(strings.TrimSuffix(str, "\n"), "\n") {
+		if len(offsets) == 2 {
+			return 0, linuxerr.EINVAL
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return 0, linuxerr.EINVAL
+		}
+		var clockID int32
+		switch fields[0] {
+		case "monotonic":
+			clockID = linux.CLOCK_MONOTONIC
+		case "boottime":
+			clockID = linux.CLOCK_BOOTTIME
+		default:
+			id, err := strconv.ParseInt(fields[0], 10, 32)
+			if err != nil {
+				return 0, linuxerr.EINVAL
+			}
+			clockID = int32(id)
+		}
+		sec, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, linuxerr.EINVAL
+		}
+		nsec, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil || nsec >= uint64(time.Second) {
+			return 0, linuxerr.EINVAL
+		}
+		if clockID != linux.CLOCK_MONOTONIC && clockID != linux.CLOCK_BOOTTIME {
+			return 0, linuxerr.EINVAL
+		}
+
+		// The clock must remain in [0, KTIME_SEC_MAX/2] seconds in the new
+		// namespace, so that timers can't overflow.
+		if sec > ktimeSecMax/2 || sec < -ktimeSecMax/2 {
+			return 0, linuxerr.ERANGE
+		}
+		off := time.Duration(sec)*time.Second + time.Duration(nsec)
+		if res := now.Add(off); res.Nanoseconds() < 0 || res.Nanoseconds()/int64(time.Second) > ktimeSecMax/2 {
+			return 0, linuxerr.ERANGE
+		}
+		offsets = append(offsets, kernel.TimeOffset{ClockID: clockID, Offset: off})
+	}
+
+	timens := d.task.GetChildTimeNamespace()
+	if timens == nil {
+		return 0, linuxerr.ESRCH
+	}
+	defer timens.DecRef(ctx)
+	if !auth.CredentialsFromContext(ctx).HasCapabilityIn(linux.CAP_SYS_TIME, timens.UserNamespace()) {
+		return 0, linuxerr.EPERM
+	}
+	if err := timens.SetOffsets(offsets); err != nil {
+		return 0, err
+	}
+	return src.NumBytes(), nil
+}
+
 // exeSymlink is an symlink for the /proc/[pid]/exe file.
 //
 // +stateify savable
@@ -1240,6 +1437,37 @@ func (i *mountInfoData) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	return i.task.Kernel().VFS().GenerateProcMountInfo(ctx, rootDir, buf)
 }
 
+// mountStatsData is used to implement /proc/[pid]/mountstats.
+//
+// +stateify savable
+type mountStatsData struct {
+	kernfs.DynamicBytesFile
+
+	fs   *filesystem
+	task *kernel.Task
+}
+
+var _ dynamicInode = (*mountStatsData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (i *mountStatsData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	var fsctx *kernel.FSContext
+	i.task.WithMuLocked(func(t *kernel.Task) {
+		fsctx = t.FSContext()
+	})
+	if fsctx == nil {
+		// The task has been destroyed. Nothing to show here.
+		return nil
+	}
+	rootDir := fsctx.RootDirectory()
+	if !rootDir.Ok() {
+		// Root has been destroyed. Don't try to read mounts.
+		return nil
+	}
+	defer i.fs.SafeDecRef(ctx, rootDir)
+	return i.task.Kernel().VFS().GenerateProcMountStats(ctx, rootDir, buf)
+}
+
 // mountsData is used to implement /proc/[pid]/mounts.
 //
 // +stateify savable
@@ -1277,6 +1505,10 @@ type namespaceSymlink struct {
 
 	task   *kernel.Task
 	nsType int
+
+	// forChildren is true for pid_for_children-style symlinks, which refer
+	// to the namespace that the task's future children will be members of.
+	forChildren bool
 }
 
 func (fs *filesystem) newNamespaceSymlink(ctx context.Context, task *kernel.Task, ino uint64, nsType int) kernfs.Inode {
@@ -1289,6 +1521,16 @@ func (fs *filesystem) newNamespaceSymlink(ctx context.Context, task *kernel.Task
 	return taskInode
 }
 
+func (fs *filesystem) newChildNamespaceSymlink(ctx context.Context, task *kernel.Task, ino uint64, nsType int) kernfs.Inode {
+	inode := &namespaceSymlink{task: task, nsType: nsType, forChildren: true}
+
+	// Note: credentials are overridden by taskOwnedInode.
+	inode.Init(ctx, task.Credentials(), linux.UNNAMED_MAJOR, fs.devMinor, ino, "")
+
+	taskInode := &taskOwnedInode{Inode: inode, owner: task}
+	return taskInode
+}
+
 func (fs *filesystem) newFakeNamespaceSymlink(ctx context.Context, task *kernel.Task, ino uint64, ns string) kernfs.Inode {
 	// Namespace symlinks should contain the namespace name and the inode number
 	// for the namespace instance, so for example user:[123456]. We currently fake
@@ -1333,6 +1575,17 @@ func (s *namespaceSymlink) getInode(t *kernel.Task) *nsfs.Inode {
 			return pidns.GetInode()
 		}
 		return nil
+	case linux.CLONE_NEWTIME:
+		var timens *kernel.TimeNamespace
+		if s.forChildren {
+			timens = t.GetChildTimeNamespace()
+		} else {
+			timens = t.GetTimeNamespace()
+		}
+		if timens == nil {
+			return nil
+		}
+		return timens.GetInode()
 	default:
 		panic("unknown namespace")
 	}
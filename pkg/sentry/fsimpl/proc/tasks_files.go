@@ -326,8 +326,15 @@ func (*uptimeData) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	k := kernel.KernelFromContext(ctx)
 	now := ktime.NowFromContext(ctx)
 
+	uptime := now.Sub(k.Timekeeper().BootTime())
+	if t := kernel.TaskFromContext(ctx); t != nil {
+		// Uptime is CLOCK_BOOTTIME, which is offset in time namespaces.
+		_, boottime := t.TimeNamespace().Offsets()
+		uptime += boottime
+	}
+
 	// Pretend that we've spent zero time sleeping (second number).
-	fmt.Fprintf(buf, "%.2f 0.00\n", now.Sub(k.Timekeeper().BootTime()).Seconds())
+	fmt.Fprintf(buf, "%.2f 0.00\n", uptime.Seconds())
 	return nil
 }
 
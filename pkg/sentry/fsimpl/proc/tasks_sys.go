@@ -30,6 +30,8 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/inet"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/pipe"
+	"gvisor.dev/gvisor/pkg/sentry/loader"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
 	"gvisor.dev/gvisor/pkg/sync"
 	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
@@ -44,6 +46,15 @@ const (
 	tcpWMem
 )
 
+// ipv6AddrConfField identifies a field of inet.IPv6AddrConf.
+type ipv6AddrConfField int
+
+const (
+	ipv6UseTempAddr ipv6AddrConfField = iota
+	ipv6TempValidLifetime
+	ipv6TempPreferredLifetime
+)
+
 // newSysDir returns the dentry corresponding to /proc/sys directory.
 func (fs *filesystem) newSysDir(ctx context.Context, root *auth.Credentials, k *kernel.Kernel) kernfs.Inode {
 	return fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
@@ -55,22 +66,36 @@ func (fs *filesystem) newSysDir(ctx context.Context, root *auth.Credentials, k *
 			"pid_max":      fs.newInode(ctx, root, 0644, newStaticFile(fmt.Sprintf("%d\n", kernel.TasksLimit))),
 			"random": fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
 				"boot_id": fs.newInode(ctx, root, 0444, newStaticFile(randUUID())),
+				// Since Linux 5.18, the entropy pool is always fully seeded
+				// and reported as 256 bits.
+				"entropy_avail":  fs.newInode(ctx, root, 0444, newStaticFile("256\n")),
+				"entropy_source": fs.newInode(ctx, root, 0444, &entropySourceData{}),
+				"poolsize":       fs.newInode(ctx, root, 0444, newStaticFile("256\n")),
 			}),
-			"sem":    fs.newInode(ctx, root, 0444, newStaticFile(fmt.Sprintf("%d\t%d\t%d\t%d\n", linux.SEMMSL, linux.SEMMNS, linux.SEMOPM, linux.SEMMNI))),
-			"shmall": fs.newInode(ctx, root, 0444, ipcData(linux.SHMALL)),
-			"shmmax": fs.newInode(ctx, root, 0444, ipcData(linux.SHMMAX)),
-			"shmmni": fs.newInode(ctx, root, 0444, ipcData(linux.SHMMNI)),
-			"msgmni": fs.newInode(ctx, root, 0444, ipcData(linux.MSGMNI)),
-			"msgmax": fs.newInode(ctx, root, 0444, ipcData(linux.MSGMAX)),
-			"msgmnb": fs.newInode(ctx, root, 0444, ipcData(linux.MSGMNB)),
+			"randomize_va_space": fs.newInode(ctx, root, 0644, &atomicInt32File{val: &k.RandomizeVASpace, min: loader.RandomizeNone, max: loader.RandomizeFull, privileged: true}),
+			"sem":                fs.newInode(ctx, root, 0444, newStaticFile(fmt.Sprintf("%d\t%d\t%d\t%d\n", linux.SEMMSL, linux.SEMMNS, linux.SEMOPM, linux.SEMMNI))),
+			"shmall":             fs.newInode(ctx, root, 0444, ipcData(linux.SHMALL)),
+			"shmmax":             fs.newInode(ctx, root, 0444, ipcData(linux.SHMMAX)),
+			"shmmni":             fs.newInode(ctx, root, 0444, ipcData(linux.SHMMNI)),
+			"msgmni":             fs.newInode(ctx, root, 0444, ipcData(linux.MSGMNI)),
+			"msgmax":             fs.newInode(ctx, root, 0444, ipcData(linux.MSGMAX)),
+			"msgmnb":             fs.newInode(ctx, root, 0444, ipcData(linux.MSGMNB)),
+			"ns_last_pid":        fs.newInode(ctx, root, 0666, &nsLastPIDData{}),
+			"osrelease":          fs.newInode(ctx, root, 0444, &osReleaseData{}),
+			"ostype":             fs.newInode(ctx, root, 0444, &osTypeData{}),
+			"version":            fs.newInode(ctx, root, 0444, &osVersionData{}),
 			"yama": fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
 				"ptrace_scope": fs.newYAMAPtraceScopeFile(ctx, k, root),
 			}),
 		}),
 		"fs": fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
-			"nr_open": fs.newInode(ctx, root, 0644, &atomicInt32File{val: &k.MaxFDLimit, min: 8, max: kernel.MaxFdLimit}),
+			"nr_open":              fs.newInode(ctx, root, 0644, &atomicInt32File{val: &k.MaxFDLimit, min: 8, max: kernel.MaxFdLimit}),
+			"pipe-max-size":        fs.newInode(ctx, root, 0644, &pipeMaxSizeData{k: k}),
+			"pipe-user-pages-hard": fs.newInode(ctx, root, 0644, &atomicInt32File{val: &k.PipeUserPagesHard, min: 0, max: math.MaxInt32, privileged: true}),
+			"pipe-user-pages-soft": fs.newInode(ctx, root, 0644, &atomicInt32File{val: &k.PipeUserPagesSoft, min: 0, max: math.MaxInt32, privileged: true}),
 		}),
 		"vm": fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
+			"drop_caches":       fs.newInode(ctx, root, 0200, &dropCachesData{k: k}),
 			"max_map_count":     fs.newInode(ctx, root, 0444, newStaticFile("2147483647\n")),
 			"mmap_min_addr":     fs.newInode(ctx, root, 0444, &mmapMinAddrData{k: k}),
 			"overcommit_memory": fs.newInode(ctx, root, 0444, newStaticFile("0\n")),
@@ -135,6 +160,14 @@ func (fs *filesystem) newSysNetDir(ctx context.Context, root *auth.Credentials,
 				"tcp_syn_retries":           fs.newInode(ctx, root, 0444, newStaticFile("3")),
 				"tcp_timestamps":            fs.newInode(ctx, root, 0444, newStaticFile("1")),
 			}),
+			"ipv6": fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
+				"conf": fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
+					// Settings apply to all interfaces, so "all" and "default"
+					// are equivalent.
+					"all":     fs.newIPv6ConfDir(ctx, root, stack),
+					"default": fs.newIPv6ConfDir(ctx, root, stack),
+				}),
+			}),
 			"core": fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
 				"default_qdisc": fs.newInode(ctx, root, 0444, newStaticFile("pfifo_fast")),
 				"message_burst": fs.newInode(ctx, root, 0444, newStaticFile("10")),
@@ -152,6 +185,16 @@ func (fs *filesystem) newSysNetDir(ctx context.Context, root *auth.Credentials,
 	return fs.newStaticDir(ctx, root, contents)
 }
 
+// newIPv6ConfDir returns the inode for a /proc/sys/net/ipv6/conf/<dev>
+// directory.
+func (fs *filesystem) newIPv6ConfDir(ctx context.Context, root *auth.Credentials, stack inet.Stack) kernfs.Inode {
+	return fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
+		"temp_prefered_lft": fs.newInode(ctx, root, 0644, &ipv6AddrConfData{stack: stack, field: ipv6TempPreferredLifetime}),
+		"temp_valid_lft":    fs.newInode(ctx, root, 0644, &ipv6AddrConfData{stack: stack, field: ipv6TempValidLifetime}),
+		"use_tempaddr":      fs.newInode(ctx, root, 0644, &ipv6AddrConfData{stack: stack, field: ipv6UseTempAddr}),
+	})
+}
+
 // mmapMinAddrData implements vfs.DynamicBytesSource for
 // /proc/sys/vm/mmap_min_addr.
 //
@@ -170,6 +213,116 @@ func (d *mmapMinAddrData) Generate(ctx context.Context, buf *bytes.Buffer) error
 	return nil
 }
 
+// pipeMaxSizeData implements vfs.WritableDynamicBytesSource for
+// /proc/sys/fs/pipe-max-size.
+//
+// +stateify savable
+type pipeMaxSizeData struct {
+	kernfs.DynamicBytesFile
+
+	k *kernel.Kernel
+}
+
+var _ vfs.WritableDynamicBytesSource = (*pipeMaxSizeData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *pipeMaxSizeData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	fmt.Fprintf(buf, "%d\n", d.k.PipeMaxSize.Load())
+	return nil
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (d *pipeMaxSizeData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	if offset != 0 {
+		// Ignore partial writes.
+		return 0, linuxerr.EINVAL
+	}
+	buf := make([]int32, 1)
+	n, err := ParseInt32Vec(ctx, src, buf)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	if buf[0] < 0 {
+		return 0, linuxerr.EINVAL
+	}
+
+	// As in Linux, the stored value is rounded up to a valid pipe size.
+	d.k.PipeMaxSize.Store(uint32(pipe.RoundPipeSize(uint64(buf[0]))))
+	return n, nil
+}
+
+// Bits of /proc/sys/vm/drop_caches. See Linux's fs/drop_caches.c.
+const (
+	dropPageCache = 1 << iota
+	dropSlab
+	dropCachesQuiet
+)
+
+// dropCachesData implements vfs.WritableDynamicBytesSource for
+// /proc/sys/vm/drop_caches.
+//
+// +stateify savable
+type dropCachesData struct {
+	kernfs.DynamicBytesFile
+
+	k *kernel.Kernel
+}
+
+var _ vfs.WritableDynamicBytesSource = (*dropCachesData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *dropCachesData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	buf.WriteString("0\n")
+	return nil
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+//
+// Writing 1 evicts clean page cache pages that are not mapped, 2 evicts unused
+// cached dentries, and 3 does both.
+func (d *dropCachesData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	if offset != 0 {
+		// Ignore partial writes.
+		return 0, linuxerr.EINVAL
+	}
+	buf := make([]int32, 1)
+	n, err := ParseInt32Vec(ctx, src, buf)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	if buf[0] < dropPageCache || buf[0] > dropCachesQuiet {
+		return 0, linuxerr.EINVAL
+	}
+
+	if buf[0]&dropPageCache != 0 {
+		mf := d.k.MemoryFile()
+		mf.StartEvictions()
+		mf.WaitForEvictions()
+	}
+	if buf[0]&dropSlab != 0 {
+		d.k.VFS().DropDentryCaches(ctx)
+	}
+	return n, nil
+}
+
+// entropySourceData implements vfs.DynamicBytesSource for
+// /proc/sys/kernel/random/entropy_source, which doesn't exist in Linux. It
+// names the source of the sandbox's randomness selected by --entropy-source.
+//
+// +stateify savable
+type entropySourceData struct {
+	kernfs.DynamicBytesFile
+}
+
+var _ dynamicInode = (*entropySourceData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (*entropySourceData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	buf.WriteString(rand.Source())
+	buf.WriteString("\n")
+	return nil
+}
+
 // hostnameData implements vfs.DynamicBytesSource for /proc/sys/kernel/hostname.
 //
 // +stateify savable
@@ -188,6 +341,101 @@ func (*hostnameData) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	return nil
 }
 
+// osReleaseData implements vfs.DynamicBytesSource for
+// /proc/sys/kernel/osrelease.
+//
+// +stateify savable
+type osReleaseData struct {
+	kernfs.DynamicBytesFile
+}
+
+var _ dynamicInode = (*osReleaseData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (*osReleaseData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	fmt.Fprintf(buf, "%s\n", kernelVersion(ctx).Release)
+	return nil
+}
+
+// osTypeData implements vfs.DynamicBytesSource for /proc/sys/kernel/ostype.
+//
+// +stateify savable
+type osTypeData struct {
+	kernfs.DynamicBytesFile
+}
+
+var _ dynamicInode = (*osTypeData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (*osTypeData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	fmt.Fprintf(buf, "%s\n", kernelVersion(ctx).Sysname)
+	return nil
+}
+
+// osVersionData implements vfs.DynamicBytesSource for /proc/sys/kernel/version.
+//
+// +stateify savable
+type osVersionData struct {
+	kernfs.DynamicBytesFile
+}
+
+var _ dynamicInode = (*osVersionData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (*osVersionData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	fmt.Fprintf(buf, "%s\n", kernelVersion(ctx).Version)
+	return nil
+}
+
+// nsLastPIDData implements vfs.WritableDynamicBytesSource for
+// /proc/sys/kernel/ns_last_pid. It reads and writes the last PID allocated in
+// the PID namespace of the calling task.
+//
+// +stateify savable
+type nsLastPIDData struct {
+	kernfs.DynamicBytesFile
+}
+
+var _ vfs.WritableDynamicBytesSource = (*nsLastPIDData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (*nsLastPIDData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	pidns := kernel.PIDNamespaceFromContext(ctx)
+	if pidns == nil {
+		return linuxerr.EINVAL
+	}
+	fmt.Fprintf(buf, "%d\n", pidns.LastTID())
+	return nil
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (*nsLastPIDData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	if offset != 0 {
+		// Ignore partial writes.
+		return 0, linuxerr.EINVAL
+	}
+	pidns := kernel.PIDNamespaceFromContext(ctx)
+	if pidns == nil {
+		return 0, linuxerr.EINVAL
+	}
+	// "Only a process that has the CAP_SYS_ADMIN or (since Linux 5.9)
+	// CAP_CHECKPOINT_RESTORE capability inside the user namespace that owns
+	// the PID namespace can write to this file." - pid_namespaces(7)
+	creds := auth.CredentialsFromContext(ctx)
+	if !creds.HasCapabilityIn(linux.CAP_SYS_ADMIN, pidns.UserNamespace()) && !creds.HasCapabilityIn(linux.CAP_CHECKPOINT_RESTORE, pidns.UserNamespace()) {
+		return 0, linuxerr.EPERM
+	}
+	buf := make([]int32, 1)
+	n, err := ParseInt32Vec(ctx, src, buf)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	if err := pidns.SetLastTID(kernel.ThreadID(buf[0])); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
 // tcpSackData implements vfs.WritableDynamicBytesSource for
 // /proc/sys/net/tcp_sack.
 //
@@ -280,6 +528,75 @@ func (d *tcpRecoveryData) Write(ctx context.Context, _ *vfs.FileDescription, src
 	return n, nil
 }
 
+// ipv6AddrConfData implements vfs.WritableDynamicBytesSource for
+// /proc/sys/net/ipv6/conf/<dev>/{use_tempaddr,temp_valid_lft,temp_prefered_lft}.
+//
+// +stateify savable
+type ipv6AddrConfData struct {
+	kernfs.DynamicBytesFile
+
+	field ipv6AddrConfField
+	stack inet.Stack `state:"wait"`
+
+	// mu protects against concurrent reads/writes to FDs based on the dentry
+	// backing this byte source.
+	mu sync.Mutex `state:"nosave"`
+}
+
+var _ vfs.WritableDynamicBytesSource = (*ipv6AddrConfData)(nil)
+
+// value returns a pointer to the field of conf represented by d.
+func (d *ipv6AddrConfData) value(conf *inet.IPv6AddrConf) *int32 {
+	switch d.field {
+	case ipv6UseTempAddr:
+		return &conf.UseTempAddr
+	case ipv6TempValidLifetime:
+		return &conf.TempValidLifetime
+	case ipv6TempPreferredLifetime:
+		return &conf.TempPreferredLifetime
+	default:
+		panic(fmt.Sprintf("unknown ipv6AddrConfField: %d", d.field))
+	}
+}
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *ipv6AddrConfData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	conf, err := d.stack.IPv6AddrConf()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(buf, "%d\n", *d.value(&conf))
+	return err
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (d *ipv6AddrConfData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	if offset != 0 {
+		// No need to handle partial writes thus far.
+		return 0, linuxerr.EINVAL
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	conf, err := d.stack.IPv6AddrConf()
+	if err != nil {
+		return 0, err
+	}
+	buf := make([]int32, 1)
+	n, err := ParseInt32Vec(ctx, src, buf)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	*d.value(&conf) = buf[0]
+	if err := d.stack.SetIPv6AddrConf(conf); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
 // tcpMemData implements vfs.WritableDynamicBytesSource for
 // /proc/sys/net/ipv4/tcp_rmem and /proc/sys/net/ipv4/tcp_wmem.
 //
@@ -474,6 +791,10 @@ type atomicInt32File struct {
 
 	val      *atomicbitops.Int32
 	min, max int32
+
+	// If privileged is true, writes require CAP_SYS_ADMIN in the root user
+	// namespace, since the sysctl affects every container in the sandbox.
+	privileged bool
 }
 
 var _ vfs.WritableDynamicBytesSource = (*atomicInt32File)(nil)
@@ -490,6 +811,12 @@ func (f *atomicInt32File) Write(ctx context.Context, _ *vfs.FileDescription, src
 		// Ignore partial writes.
 		return 0, linuxerr.EINVAL
 	}
+	if f.privileged {
+		creds := auth.CredentialsFromContext(ctx)
+		if !creds.HasCapabilityIn(linux.CAP_SYS_ADMIN, creds.UserNamespace.Root()) {
+			return 0, linuxerr.EPERM
+		}
+	}
 	buf := make([]int32, 1)
 	n, err := ParseInt32Vec(ctx, src, buf)
 	if err != nil || n == 0 {
@@ -22,6 +22,7 @@ import (
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/rand"
 	"gvisor.dev/gvisor/pkg/sentry/contexttest"
 	"gvisor.dev/gvisor/pkg/sentry/inet"
 	"gvisor.dev/gvisor/pkg/usermem"
@@ -149,6 +150,63 @@ func TestConfigureIPForwarding(t *testing.T) {
 	}
 }
 
+func TestConfigureIPv6AddrConf(t *testing.T) {
+	ctx := context.Background()
+	s := inet.NewTestStack()
+	initial := inet.IPv6AddrConf{
+		UseTempAddr:           0,
+		TempValidLifetime:     604800,
+		TempPreferredLifetime: 86400,
+	}
+
+	for _, c := range []struct {
+		name  string
+		field ipv6AddrConfField
+		str   string
+		want  inet.IPv6AddrConf
+	}{
+		{
+			name:  "use_tempaddr",
+			field: ipv6UseTempAddr,
+			str:   "2",
+			want:  inet.IPv6AddrConf{UseTempAddr: 2, TempValidLifetime: 604800, TempPreferredLifetime: 86400},
+		},
+		{
+			name:  "temp_valid_lft",
+			field: ipv6TempValidLifetime,
+			str:   "172800",
+			want:  inet.IPv6AddrConf{UseTempAddr: 0, TempValidLifetime: 172800, TempPreferredLifetime: 86400},
+		},
+		{
+			name:  "temp_prefered_lft",
+			field: ipv6TempPreferredLifetime,
+			str:   "3600",
+			want:  inet.IPv6AddrConf{UseTempAddr: 0, TempValidLifetime: 604800, TempPreferredLifetime: 3600},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			s.AddrConf = initial
+			file := &ipv6AddrConfData{stack: s, field: c.field}
+
+			src := usermem.BytesIOSequence([]byte(c.str))
+			if n, err := file.Write(ctx, nil, src, 0); n != int64(len(c.str)) || err != nil {
+				t.Errorf("file.Write(ctx, nil, %q, 0) = (%d, %v); want (%d, nil)", c.str, n, err, len(c.str))
+			}
+			if s.AddrConf != c.want {
+				t.Errorf("got s.AddrConf = %+v, want = %+v", s.AddrConf, c.want)
+			}
+
+			var buf bytes.Buffer
+			if err := file.Generate(ctx, &buf); err != nil {
+				t.Fatalf("file.Generate(ctx, _): %v", err)
+			}
+			if got, want := buf.String(), c.str+"\n"; got != want {
+				t.Errorf("file.Generate(ctx, _) = %q, want = %q", got, want)
+			}
+		})
+	}
+}
+
 func TestParseInt32Vec(t *testing.T) {
 	ctx := context.Background()
 	tests := []struct {
@@ -204,3 +262,24 @@ func TestParseInt32Vec(t *testing.T) {
 		})
 	}
 }
+
+func TestEntropySource(t *testing.T) {
+	ctx := contexttest.Context(t)
+	t.Cleanup(func() {
+		if err := rand.UseSource(rand.SourceGetrandom, -1); err != nil {
+			t.Errorf("rand.UseSource(%q) failed: %v", rand.SourceGetrandom, err)
+		}
+	})
+	for _, source := range []string{rand.SourceGetrandom, rand.SourceDRBG} {
+		if err := rand.UseSource(source, -1); err != nil {
+			t.Fatalf("rand.UseSource(%q) failed: %v", source, err)
+		}
+		var buf bytes.Buffer
+		if err := (&entropySourceData{}).Generate(ctx, &buf); err != nil {
+			t.Fatalf("Generate() failed: %v", err)
+		}
+		if got, want := buf.String(), source+"\n"; got != want {
+			t.Errorf("Generate() with source %q = %q, want %q", source, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package seccompnotify provides the file description of seccomp user
+// notification listeners.
+package seccompnotify
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/arch"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/usermem"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// ListenerFileDescription implements vfs.FileDescriptionImpl for seccomp user
+// notification listeners.
+//
+// +stateify savable
+type ListenerFileDescription struct {
+	vfsfd vfs.FileDescription
+	vfs.FileDescriptionDefaultImpl
+	vfs.DentryMetadataFileDescriptionImpl
+	vfs.NoLockFD
+	vfs.NoAsyncEventFD
+
+	// notifier is the listener's notifier. notifier is immutable.
+	notifier *kernel.SeccompNotifier
+}
+
+var _ vfs.FileDescriptionImpl = (*ListenerFileDescription)(nil)
+
+// New creates a new listener for notifier.
+func New(ctx context.Context, vfsObj *vfs.VirtualFilesystem, notifier *kernel.SeccompNotifier) (*vfs.FileDescription, error) {
+	vd := vfsObj.NewAnonVirtualDentry("seccomp notify")
+	defer vd.DecRef(ctx)
+	lfd := &ListenerFileDescription{
+		notifier: notifier,
+	}
+	if err := lfd.vfsfd.Init(lfd, linux.O_RDWR, vd.Mount(), vd.Dentry(), &vfs.FileDescriptionOptions{
+		UseDentryMetadata: true,
+		DenyPRead:         true,
+		DenyPWrite:        true,
+	}); err != nil {
+		return nil, err
+	}
+	return &lfd.vfsfd, nil
+}
+
+// Ioctl implements vfs.FileDescriptionImpl.Ioctl.
+func (lfd *ListenerFileDescription) Ioctl(ctx context.Context, uio usermem.IO, sysno uintptr, args arch.SyscallArguments) (uintptr, error) {
+	t := kernel.TaskFromContext(ctx)
+	if t == nil {
+		return 0, linuxerr.ENOTTY
+	}
+	addr := args[2].Pointer()
+	switch args[1].Uint() {
+	case linux.SECCOMP_IOCTL_NOTIF_RECV:
+		var notif linux.SeccompNotif
+		if _, err := notif.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		// The structure must be zeroed, leaving room for future extension.
+		if notif != (linux.SeccompNotif{}) {
+			return 0, linuxerr.EINVAL
+		}
+		notif, err := lfd.notifier.Recv(t)
+		if err != nil {
+			return 0, err
+		}
+		_, err = notif.CopyOut(t, addr)
+		return 0, err
+
+	case linux.SECCOMP_IOCTL_NOTIF_SEND:
+		var resp linux.SeccompNotifResp
+		if _, err := resp.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		return 0, lfd.notifier.Send(&resp)
+
+	case linux.SECCOMP_IOCTL_NOTIF_ID_VALID:
+		var id primitive.Uint64
+		if _, err := id.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		return 0, lfd.notifier.IDValid(uint64(id))
+
+	case linux.SECCOMP_IOCTL_NOTIF_ADDFD:
+		var req linux.SeccompNotifAddFD
+		if _, err := req.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		fd, err := lfd.notifier.AddFD(t, &req)
+		return uintptr(fd), err
+
+	default:
+		return 0, linuxerr.EINVAL
+	}
+}
+
+// Readiness implements waiter.Waitable.Readiness.
+func (lfd *ListenerFileDescription) Readiness(mask waiter.EventMask) waiter.EventMask {
+	return lfd.notifier.Readiness(mask)
+}
+
+// EventRegister implements waiter.Waitable.EventRegister.
+func (lfd *ListenerFileDescription) EventRegister(e *waiter.Entry) error {
+	return lfd.notifier.EventRegister(e)
+}
+
+// EventUnregister implements waiter.Waitable.EventUnregister.
+func (lfd *ListenerFileDescription) EventUnregister(e *waiter.Entry) {
+	lfd.notifier.EventUnregister(e)
+}
+
+// Epollable implements FileDescriptionImpl.Epollable.
+func (lfd *ListenerFileDescription) Epollable() bool {
+	return true
+}
+
+// Release implements vfs.FileDescriptionImpl.Release.
+func (lfd *ListenerFileDescription) Release(context.Context) {
+	lfd.notifier.Release()
+}
+
+// RegisterFileAsyncHandler implements vfs.FileDescriptionImpl.RegisterFileAsyncHandler.
+func (lfd *ListenerFileDescription) RegisterFileAsyncHandler(fd *vfs.FileDescription) error {
+	return lfd.NoAsyncEventFD.RegisterFileAsyncHandler(fd)
+}
+
+// UnregisterFileAsyncHandler implements vfs.FileDescriptionImpl.UnregisterFileAsyncHandler.
+func (lfd *ListenerFileDescription) UnregisterFileAsyncHandler(fd *vfs.FileDescription) {
+	lfd.NoAsyncEventFD.UnregisterFileAsyncHandler(fd)
+}
@@ -32,6 +32,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/fsimpl/kernfs"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/usage"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
 )
 
@@ -125,7 +126,8 @@ func (fsType FilesystemType) GetFilesystem(ctx context.Context, vfsObj *vfs.Virt
 	}
 	devicesSub := map[string]kernfs.Inode{
 		"system": fs.newDir(ctx, creds, defaultSysDirMode, map[string]kernfs.Inode{
-			"cpu": cpuDir(ctx, fs, creds),
+			"cpu":  cpuDir(ctx, fs, creds),
+			"node": nodeDir(ctx, fs, creds),
 		}),
 	}
 
@@ -222,7 +224,9 @@ func cpuDir(ctx context.Context, fs *filesystem, creds *auth.Credentials) kernfs
 	fullMask := fullCPUMask(maxCPUCores) + "\n"
 	for i := uint(0); i < maxCPUCores; i++ {
 		oneMask := oneCPUMask(i, maxCPUCores) + "\n"
+		node := fmt.Sprintf("node%d", k.CPUNode(int32(i)))
 		children[fmt.Sprintf("cpu%d", i)] = fs.newDir(ctx, creds, defaultSysDirMode, map[string]kernfs.Inode{
+			node: kernfs.NewStaticSymlink(ctx, creds, linux.UNNAMED_MAJOR, fs.devMinor, fs.NextIno(), "../../node/"+node),
 			"topology": fs.newDir(ctx, creds, defaultSysDirMode, map[string]kernfs.Inode{
 				"core_cpus":       fs.newStaticFile(ctx, creds, defaultSysMode, oneMask),
 				"core_siblings":   fs.newStaticFile(ctx, creds, defaultSysMode, fullMask),
@@ -234,6 +238,59 @@ func cpuDir(ctx context.Context, fs *filesystem, creds *auth.Credentials) kernfs
 	return fs.newDir(ctx, creds, defaultSysDirMode, children)
 }
 
+// nodeDir returns /sys/devices/system/node, which describes the NUMA topology
+// emulated by the kernel. See Documentation/ABI/stable/sysfs-devices-node.
+func nodeDir(ctx context.Context, fs *filesystem, creds *auth.Credentials) kernfs.Inode {
+	k := kernel.KernelFromContext(ctx)
+	maxCPUCores := k.ApplicationCores()
+	nodes := k.NUMANodes()
+	// All nodes have CPUs and memory.
+	nodeList := rangeList(0, nodes) + "\n"
+	children := map[string]kernfs.Inode{
+		"has_cpu":           fs.newStaticFile(ctx, creds, defaultSysMode, nodeList),
+		"has_memory":        fs.newStaticFile(ctx, creds, defaultSysMode, nodeList),
+		"has_normal_memory": fs.newStaticFile(ctx, creds, defaultSysMode, nodeList),
+		"online":            fs.newStaticFile(ctx, creds, defaultSysMode, nodeList),
+		"possible":          fs.newStaticFile(ctx, creds, defaultSysMode, nodeList),
+	}
+	for n := uint(0); n < nodes; n++ {
+		// Local accesses have a distance of 10, remote ones 20, as for a
+		// typical two socket machine.
+		distances := make([]string, nodes)
+		for i := range distances {
+			distances[i] = "20"
+		}
+		distances[n] = "10"
+		start, end := k.NUMANodeCPUs(n)
+		nodeChildren := map[string]kernfs.Inode{
+			"cpulist":  fs.newStaticFile(ctx, creds, defaultSysMode, rangeList(start, end)+"\n"),
+			"cpumap":   fs.newStaticFile(ctx, creds, defaultSysMode, cpuRangeMask(start, end, maxCPUCores)+"\n"),
+			"distance": fs.newStaticFile(ctx, creds, defaultSysMode, strings.Join(distances, " ")+"\n"),
+			"meminfo":  fs.newNodeMeminfoFile(ctx, creds, n, nodes),
+		}
+		for cpu := start; cpu < end; cpu++ {
+			name := fmt.Sprintf("cpu%d", cpu)
+			nodeChildren[name] = kernfs.NewStaticSymlink(ctx, creds, linux.UNNAMED_MAJOR, fs.devMinor, fs.NextIno(), "../../cpu/"+name)
+		}
+		children[fmt.Sprintf("node%d", n)] = fs.newDir(ctx, creds, defaultSysDirMode, nodeChildren)
+	}
+	return fs.newDir(ctx, creds, defaultSysDirMode, children)
+}
+
+// rangeList returns a "list format ASCII string", consistent with Linux's
+// lib/bitmap.c:bitmap_print_to_pagebuf(list=true), representing the range
+// [start, end).
+func rangeList(start, end uint) string {
+	switch {
+	case start >= end:
+		return ""
+	case end-start == 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d-%d", start, end-1)
+	}
+}
+
 // fullCPUMask returns a "hex format ASCII string", consistent with Linux's
 // include/linux/cpumask.h:cpumap_print_to_pagebuf(list=false) =>
 // lib/bitmap.c:bitmap_print_to_pagebuf(list=false), representing a CPU bitmask
@@ -263,13 +320,23 @@ func fullCPUMask(cores uint) string {
 //
 // Preconditions: i < cores.
 func oneCPUMask(i, cores uint) string {
+	return cpuRangeMask(i, i+1, cores)
+}
+
+// cpuRangeMask returns a "hex format ASCII string", as for oneCPUMask,
+// representing a CPU bitmask for `cores` CPUs in which CPUs [start, end) are
+// set.
+//
+// Preconditions: end <= cores.
+func cpuRangeMask(start, end, cores uint) string {
 	var (
 		b   strings.Builder
 		sep string
 	)
+	// word returns the bits of CPUs [cores, cores+32).
 	word := func() (w uint32) {
-		if cores <= i {
-			w = uint32(1) << (i - cores)
+		for i := max(start, cores); i < min(end, cores+32); i++ {
+			w |= uint32(1) << (i - cores)
 		}
 		return
 	}
@@ -446,6 +513,39 @@ func (fs *filesystem) newCPUFile(ctx context.Context, creds *auth.Credentials, m
 	return c
 }
 
+// nodeMeminfoFile implements kernfs.Inode for
+// /sys/devices/system/node/nodeN/meminfo.
+//
+// +stateify savable
+type nodeMeminfoFile struct {
+	implStatFS
+	kernfs.DynamicBytesFile
+
+	node  uint
+	nodes uint
+}
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (m *nodeMeminfoFile) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	mf := kernel.KernelFromContext(ctx).MemoryFile()
+	_ = mf.UpdateUsage(nil) // Best effort
+	_, totalUsage := usage.MemoryAccounting.Copy()
+	totalSize := usage.TotalMemory(mf.TotalSize(), totalUsage)
+	// Memory isn't actually placed on nodes, so split it evenly among them.
+	nodeSize := totalSize / uint64(m.nodes)
+	nodeUsage := min(totalUsage/uint64(m.nodes), nodeSize)
+	fmt.Fprintf(buf, "Node %d MemTotal:       %8d kB\n", m.node, nodeSize/1024)
+	fmt.Fprintf(buf, "Node %d MemFree:        %8d kB\n", m.node, (nodeSize-nodeUsage)/1024)
+	fmt.Fprintf(buf, "Node %d MemUsed:        %8d kB\n", m.node, nodeUsage/1024)
+	return nil
+}
+
+func (fs *filesystem) newNodeMeminfoFile(ctx context.Context, creds *auth.Credentials, node, nodes uint) kernfs.Inode {
+	m := &nodeMeminfoFile{node: node, nodes: nodes}
+	m.DynamicBytesFile.Init(ctx, creds, linux.UNNAMED_MAJOR, fs.devMinor, fs.NextIno(), m, defaultSysMode)
+	return m
+}
+
 // +stateify savable
 type implStatFS struct{}
 
@@ -85,6 +85,41 @@ func TestReadCPUFile(t *testing.T) {
 	}
 }
 
+func TestReadNodeFiles(t *testing.T) {
+	s := newTestSystem(t, "" /*pciTestDir*/)
+	defer s.Destroy()
+	k := kernel.KernelFromContext(s.Ctx)
+	maxCPUCores := k.ApplicationCores()
+
+	// The test kernel has a single NUMA node containing all CPUs.
+	cpuList := "0\n"
+	if maxCPUCores > 1 {
+		cpuList = fmt.Sprintf("0-%d\n", maxCPUCores-1)
+	}
+	for fname, expected := range map[string]string{
+		"online":         "0\n",
+		"possible":       "0\n",
+		"has_cpu":        "0\n",
+		"has_memory":     "0\n",
+		"node0/cpulist":  cpuList,
+		"node0/distance": "10\n",
+	} {
+		pop := s.PathOpAtRoot(fmt.Sprintf("devices/system/node/%s", fname))
+		fd, err := s.VFS.OpenAt(s.Ctx, s.Creds, pop, &vfs.OpenOptions{})
+		if err != nil {
+			t.Fatalf("OpenAt(pop:%+v) = %+v failed: %v", pop, fd, err)
+		}
+		defer fd.DecRef(s.Ctx)
+		content, err := s.ReadToEnd(fd)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if diff := cmp.Diff(expected, content); diff != "" {
+			t.Errorf("%s: Read returned unexpected data:\n--- want\n+++ got\n%v", fname, diff)
+		}
+	}
+}
+
 func TestSysRootContainsExpectedEntries(t *testing.T) {
 	s := newTestSystem(t, "" /*pciTestDir*/)
 	defer s.Destroy()
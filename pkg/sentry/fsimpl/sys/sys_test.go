@@ -74,3 +74,43 @@ func TestOneCPUMask(t *testing.T) {
 		}
 	}
 }
+
+func TestCPURangeMask(t *testing.T) {
+	for _, test := range []struct {
+		start uint
+		end   uint
+		cores uint
+		want  string
+	}{
+		{0, 1, 1, "1"},
+		{0, 4, 4, "f"},
+		{1, 3, 4, "6"},
+		{2, 5, 5, "1c"},
+		{0, 32, 32, "ffffffff"},
+		{16, 48, 64, "0000ffff,ffff0000"},
+		{32, 33, 33, "1,00000000"},
+		{30, 34, 65, "0,00000003,c0000000"},
+	} {
+		if got := cpuRangeMask(test.start, test.end, test.cores); got != test.want {
+			t.Errorf("cpuRangeMask(%d, %d, %d): got %s, want %s", test.start, test.end, test.cores, got, test.want)
+		}
+	}
+}
+
+func TestRangeList(t *testing.T) {
+	for _, test := range []struct {
+		start uint
+		end   uint
+		want  string
+	}{
+		{0, 0, ""},
+		{0, 1, "0"},
+		{3, 4, "3"},
+		{0, 4, "0-3"},
+		{2, 8, "2-7"},
+	} {
+		if got := rangeList(test.start, test.end); got != test.want {
+			t.Errorf("rangeList(%d, %d): got %q, want %q", test.start, test.end, got, test.want)
+		}
+	}
+}
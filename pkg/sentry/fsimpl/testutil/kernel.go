@@ -151,12 +151,14 @@ func CreateTask(ctx context.Context, name string, tc *kernel.ThreadGroup, mntns
 		AllowedCPUMask:   sched.NewFullCPUSet(k.ApplicationCores()),
 		UTSNamespace:     kernel.UTSNamespaceFromContext(ctx),
 		IPCNamespace:     kernel.IPCNamespaceFromContext(ctx),
+		TimeNamespace:    k.RootTimeNamespace(),
 		MountNamespace:   mntns,
 		FSContext:        kernel.NewFSContext(root, cwd, 0022),
 		FDTable:          k.NewFDTable(),
 		UserCounters:     k.GetUserCounters(creds.RealKUID),
 	}
 	config.NetworkNamespace.IncRef()
+	config.TimeNamespace.IncRef()
 	t, err := k.TaskSet().NewTask(ctx, config)
 	if err != nil {
 		config.ThreadGroup.Release(ctx)
@@ -376,6 +376,20 @@ func (*regularFile) InvalidateUnsavable(context.Context) error {
 	return nil
 }
 
+// ForEachResidentRange implements memmap.ResidencyMappable.ForEachResidentRange.
+func (rf *regularFile) ForEachResidentRange(ctx context.Context, mr memmap.MappableRange, fn func(memmap.MappableRange)) {
+	rf.dataMu.RLock()
+	defer rf.dataMu.RUnlock()
+	for seg := rf.data.LowerBoundSegment(mr.Start); seg.Ok() && seg.Start() < mr.End; seg = seg.NextSegment() {
+		fn(seg.Range().Intersect(mr))
+	}
+}
+
+// IsShmem implements memmap.ShmemMappable.IsShmem.
+func (rf *regularFile) IsShmem() bool {
+	return true
+}
+
 // +stateify savable
 type regularFileFD struct {
 	fileDescription
@@ -494,10 +508,9 @@ func (fd *regularFileFD) PRead(ctx context.Context, dst usermem.IOSequence, offs
 	}
 
 	// Check that flags are supported. RWF_DSYNC/RWF_SYNC can be ignored since
-	// all state is in-memory.
-	//
-	// TODO(gvisor.dev/issue/2601): Support select preadv2 flags.
-	if opts.Flags&^(linux.RWF_HIPRI|linux.RWF_DSYNC|linux.RWF_SYNC) != 0 {
+	// all state is in-memory, and RWF_NOWAIT is trivially satisfied since
+	// tmpfs I/O never blocks.
+	if opts.Flags&^(linux.RWF_HIPRI|linux.RWF_DSYNC|linux.RWF_SYNC|linux.RWF_NOWAIT) != 0 {
 		return 0, linuxerr.EOPNOTSUPP
 	}
 
@@ -537,10 +550,9 @@ func (fd *regularFileFD) pwrite(ctx context.Context, src usermem.IOSequence, off
 	}
 
 	// Check that flags are supported. RWF_DSYNC/RWF_SYNC can be ignored since
-	// all state is in-memory.
-	//
-	// TODO(gvisor.dev/issue/2601): Support select preadv2 flags.
-	if opts.Flags&^(linux.RWF_HIPRI|linux.RWF_DSYNC|linux.RWF_SYNC) != 0 {
+	// all state is in-memory, and RWF_NOWAIT is trivially satisfied since
+	// tmpfs I/O never blocks.
+	if opts.Flags&^(linux.RWF_HIPRI|linux.RWF_DSYNC|linux.RWF_SYNC|linux.RWF_NOWAIT) != 0 {
 		return 0, offset, linuxerr.EOPNOTSUPP
 	}
 
@@ -621,6 +633,22 @@ func (fd *regularFileFD) ConfigureMMap(ctx context.Context, opts *memmap.MMapOpt
 	if file.initiallyUnlinked {
 		opts.NameMut = memmap.NameMutAnonShmem
 	}
+	if !opts.Private {
+		// F_SEAL_FUTURE_WRITE forbids new shared writable mappings,
+		// including ones that become writable later through mprotect(2).
+		// Unlike F_SEAL_WRITE, mappings that existed before the seal was
+		// added remain writable. See mm/shmem.c:shmem_mmap() =>
+		// seal_check_future_write().
+		file.dataMu.RLock()
+		sealed := file.seals&linux.F_SEAL_FUTURE_WRITE != 0
+		file.dataMu.RUnlock()
+		if sealed {
+			if opts.Perms.Write {
+				return linuxerr.EPERM
+			}
+			opts.MaxPerms.Write = false
+		}
+	}
 	return vfs.GenericConfigureMMap(&fd.vfsfd, file, opts)
 }
 
@@ -741,7 +769,7 @@ func (rw *regularFileReadWriter) WriteFromBlocks(srcs safemem.BlockSeq) (uint64,
 
 	// Check if seals prevent either file growth or all writes.
 	switch {
-	case rw.file.seals&linux.F_SEAL_WRITE != 0: // Write sealed
+	case rw.file.seals&(linux.F_SEAL_WRITE|linux.F_SEAL_FUTURE_WRITE) != 0: // Write sealed
 		return 0, linuxerr.EPERM
 	case end > rw.file.size.RacyLoad() && rw.file.seals&linux.F_SEAL_GROW != 0: // Grow sealed
 		// When growth is sealed, Linux effectively allows writes which would
@@ -903,6 +931,10 @@ func AddSeals(fd *vfs.FileDescription, val uint32) error {
 	rf.dataMu.Lock()
 	defer rf.dataMu.Unlock()
 
+	if val&^(linux.F_SEAL_SEAL|linux.F_SEAL_SHRINK|linux.F_SEAL_GROW|linux.F_SEAL_WRITE|linux.F_SEAL_FUTURE_WRITE) != 0 {
+		return linuxerr.EINVAL
+	}
+
 	if rf.seals&linux.F_SEAL_SEAL != 0 {
 		// Seal applied which prevents addition of any new seals.
 		return linuxerr.EPERM
@@ -234,3 +234,45 @@ func TestSetStat(t *testing.T) {
 		})
 	}
 }
+
+// TestStatFSFlags tests that fstatfs(2) reports the same flags as statfs(2).
+func TestStatFSFlags(t *testing.T) {
+	ctx := contexttest.Context(t)
+	creds := auth.CredentialsFromContext(ctx)
+	vfsObj, root, cleanup, err := newTmpfsRoot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	pop := &vfs.PathOperation{Root: root, Start: root}
+	fd, err := vfsObj.OpenAt(ctx, creds, pop, &vfs.OpenOptions{Flags: linux.O_RDONLY | linux.O_DIRECTORY})
+	if err != nil {
+		t.Fatalf("OpenAt failed: %v", err)
+	}
+	defer fd.DecRef(ctx)
+
+	for _, ro := range []bool{false, true} {
+		if err := vfsObj.SetMountReadOnly(root.Mount(), ro); err != nil {
+			t.Fatalf("SetMountReadOnly(%t) failed: %v", ro, err)
+		}
+		want := uint64(linux.ST_VALID)
+		if ro {
+			want |= linux.ST_RDONLY
+		}
+		statfs, err := vfsObj.StatFSAt(ctx, creds, pop)
+		if err != nil {
+			t.Fatalf("StatFSAt failed: %v", err)
+		}
+		if statfs.Flags != want {
+			t.Errorf("StatFSAt with read-only=%t got flags %#x, want %#x", ro, statfs.Flags, want)
+		}
+		fstatfs, err := fd.StatFS(ctx)
+		if err != nil {
+			t.Fatalf("StatFS failed: %v", err)
+		}
+		if fstatfs.Flags != want {
+			t.Errorf("StatFS with read-only=%t got flags %#x, want %#x", ro, fstatfs.Flags, want)
+		}
+	}
+}
@@ -265,9 +265,9 @@ func (fstype FilesystemType) GetFilesystem(ctx context.Context, vfsObj *vfs.Virt
 	maxSizeStr, ok := mopts["size"]
 	if ok {
 		delete(mopts, "size")
-		maxSizeInBytes, err := parseSize(maxSizeStr)
+		maxSizeInBytes, err := ParseSize(maxSizeStr)
 		if err != nil {
-			ctx.Debugf("tmpfs.FilesystemType.GetFilesystem: parseSize() failed: %v", err)
+			ctx.Debugf("tmpfs.FilesystemType.GetFilesystem: ParseSize() failed: %v", err)
 			return nil, nil, linuxerr.EINVAL
 		}
 		// Convert size in bytes to nearest Page Size bytes
@@ -374,6 +374,7 @@ func (fs *filesystem) statFS() linux.Statfs {
 		BlockSize:    hostarch.PageSize,
 		FragmentSize: hostarch.PageSize,
 		NameLength:   linux.NAME_MAX,
+		FSID:         vfs.FSIDFromDevice(linux.UNNAMED_MAJOR, fs.devMinor),
 	}
 
 	// If size is set for tmpfs return set values.
@@ -957,9 +958,9 @@ func (*fileDescription) Sync(context.Context) error {
 	return nil
 }
 
-// parseSize converts size in string to an integer bytes.
+// ParseSize converts size in string to an integer bytes.
 // Supported suffixes in string are:K, M, G, T, P, E.
-func parseSize(s string) (uint64, error) {
+func ParseSize(s string) (uint64, error) {
 	if len(s) == 0 {
 		return 0, fmt.Errorf("size parameter empty")
 	}
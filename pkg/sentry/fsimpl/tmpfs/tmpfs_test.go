@@ -176,7 +176,7 @@ func TestParseSize(t *testing.T) {
 	for _, tt := range tests {
 		testname := fmt.Sprintf("%s", tt.s)
 		t.Run(testname, func(t *testing.T) {
-			size, err := parseSize(tt.s)
+			size, err := ParseSize(tt.s)
 			if tt.wantError && err == nil {
 				t.Errorf("Invalid input: %v parsed", tt.s)
 			}
@@ -19,13 +19,13 @@ import (
 	"path"
 	"strings"
 
-	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/context"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/fspath"
 	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/loader"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
 )
 
@@ -89,11 +89,7 @@ func resolve(ctx context.Context, creds *auth.Credentials, mns *vfs.MountNamespa
 			Path:               fspath.Parse(binPath),
 			FollowFinalSymlink: true,
 		}
-		opts := &vfs.OpenOptions{
-			FileExec: true,
-			Flags:    linux.O_RDONLY,
-		}
-		dentry, err := root.Mount().Filesystem().VirtualFilesystem().OpenAt(ctx, creds, pop, opts)
+		fd, _, err := loader.OpenExecutable(ctx, root.Mount().Filesystem().VirtualFilesystem(), creds, pop)
 		if linuxerr.Equals(linuxerr.ENOENT, err) || linuxerr.Equals(linuxerr.EACCES, err) {
 			// Didn't find it here.
 			continue
@@ -101,7 +97,7 @@ func resolve(ctx context.Context, creds *auth.Credentials, mns *vfs.MountNamespa
 		if err != nil {
 			return "", err
 		}
-		dentry.DecRef(ctx)
+		fd.DecRef(ctx)
 
 		return binPath, nil
 	}
@@ -0,0 +1,267 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package userfaultfd provides the file description of userfaultfds.
+package userfaultfd
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/sentry/arch"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/mm"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/usermem"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// copyChunkSize is the maximum number of bytes buffered at a time by
+// UFFDIO_COPY.
+const copyChunkSize = 1 << 20
+
+// UserfaultFileDescription implements vfs.FileDescriptionImpl for
+// userfaultfds.
+//
+// +stateify savable
+type UserfaultFileDescription struct {
+	vfsfd vfs.FileDescription
+	vfs.FileDescriptionDefaultImpl
+	vfs.DentryMetadataFileDescriptionImpl
+	vfs.NoLockFD
+
+	// uffd handles faults for the file description. uffd is immutable.
+	uffd *mm.Userfaultfd
+}
+
+var _ vfs.FileDescriptionImpl = (*UserfaultFileDescription)(nil)
+
+// New creates a new userfaultfd that handles faults for uffd.
+func New(ctx context.Context, vfsObj *vfs.VirtualFilesystem, uffd *mm.Userfaultfd, flags uint32) (*vfs.FileDescription, error) {
+	vd := vfsObj.NewAnonVirtualDentry("[userfaultfd]")
+	defer vd.DecRef(ctx)
+	ufd := &UserfaultFileDescription{
+		uffd: uffd,
+	}
+	if err := ufd.vfsfd.Init(ufd, flags, vd.Mount(), vd.Dentry(), &vfs.FileDescriptionOptions{
+		UseDentryMetadata: true,
+		DenyPRead:         true,
+		DenyPWrite:        true,
+	}); err != nil {
+		return nil, err
+	}
+	return &ufd.vfsfd, nil
+}
+
+// Read implements vfs.FileDescriptionImpl.Read. It reads as many messages as
+// are available and fit in dst.
+func (ufd *UserfaultFileDescription) Read(ctx context.Context, dst usermem.IOSequence, _ vfs.ReadOptions) (int64, error) {
+	var msg linux.UffdMsg
+	msgSize := int64(msg.SizeBytes())
+	if dst.NumBytes() < msgSize {
+		return 0, linuxerr.EINVAL
+	}
+	buf := make([]byte, msgSize)
+	var total int64
+	for dst.NumBytes() >= msgSize {
+		msg, err := ufd.uffd.ReadMessage()
+		if err != nil {
+			if total != 0 {
+				return total, nil
+			}
+			return 0, err
+		}
+		msg.MarshalBytes(buf)
+		n, err := dst.CopyOut(ctx, buf)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+		dst = dst.DropFirst(n)
+	}
+	return total, nil
+}
+
+// Ioctl implements vfs.FileDescriptionImpl.Ioctl.
+func (ufd *UserfaultFileDescription) Ioctl(ctx context.Context, uio usermem.IO, sysno uintptr, args arch.SyscallArguments) (uintptr, error) {
+	t := kernel.TaskFromContext(ctx)
+	if t == nil {
+		return 0, linuxerr.ENOTTY
+	}
+	addr := args[2].Pointer()
+	switch args[1].Uint() {
+	case linux.UFFDIO_API:
+		var api linux.UffdioAPI
+		if _, err := api.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		features, ioctls, err := ufd.uffd.API(api.API, api.Features)
+		if err != nil {
+			// As in Linux, report no features or ioctls on failure.
+			api = linux.UffdioAPI{}
+			if _, cerr := api.CopyOut(t, addr); cerr != nil {
+				return 0, cerr
+			}
+			return 0, err
+		}
+		api.Features = features
+		api.Ioctls = ioctls
+		_, err = api.CopyOut(t, addr)
+		return 0, err
+
+	case linux.UFFDIO_REGISTER:
+		var reg linux.UffdioRegister
+		if _, err := reg.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		ioctls, err := ufd.uffd.Register(t, reg.Range.Start, reg.Range.Len, reg.Mode)
+		if err != nil {
+			return 0, err
+		}
+		reg.Ioctls = ioctls
+		_, err = reg.CopyOut(t, addr)
+		return 0, err
+
+	case linux.UFFDIO_UNREGISTER:
+		var r linux.UffdioRange
+		if _, err := r.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		return 0, ufd.uffd.Unregister(t, r.Start, r.Len)
+
+	case linux.UFFDIO_WAKE:
+		var r linux.UffdioRange
+		if _, err := r.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		return 0, ufd.uffd.Wake(r.Start, r.Len)
+
+	case linux.UFFDIO_COPY:
+		var c linux.UffdioCopy
+		if _, err := c.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		n, err := ufd.copy(t, &c)
+		c.Copy = result(n, err)
+		if _, cerr := c.CopyOut(t, addr); cerr != nil {
+			return 0, cerr
+		}
+		return 0, partialError(n, c.Len, err)
+
+	case linux.UFFDIO_ZEROPAGE:
+		var z linux.UffdioZeropage
+		if _, err := z.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		n, err := ufd.uffd.ZeroPage(t, z.Range.Start, z.Range.Len, z.Mode)
+		z.Zeropage = result(n, err)
+		if _, cerr := z.CopyOut(t, addr); cerr != nil {
+			return 0, cerr
+		}
+		return 0, partialError(n, z.Range.Len, err)
+
+	case linux.UFFDIO_WRITEPROTECT:
+		var wp linux.UffdioWriteprotect
+		if _, err := wp.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		return 0, ufd.uffd.WriteProtect(t, wp.Range.Start, wp.Range.Len, wp.Mode)
+
+	case linux.UFFDIO_CONTINUE:
+		var c linux.UffdioContinue
+		if _, err := c.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		n, err := ufd.uffd.Continue(t, c.Range.Start, c.Range.Len, c.Mode)
+		c.Mapped = result(n, err)
+		if _, cerr := c.CopyOut(t, addr); cerr != nil {
+			return 0, cerr
+		}
+		return 0, partialError(n, c.Range.Len, err)
+
+	default:
+		return 0, linuxerr.EINVAL
+	}
+}
+
+// copy implements UFFDIO_COPY, buffering the source in chunks since it may be
+// in the address space whose faults are being resolved.
+func (ufd *UserfaultFileDescription) copy(t *kernel.Task, c *linux.UffdioCopy) (int64, error) {
+	if c.Len == 0 || c.Src%hostarch.PageSize != 0 || c.Src+c.Len <= c.Src {
+		return 0, linuxerr.EINVAL
+	}
+	buf := make([]byte, min(c.Len, copyChunkSize))
+	var done int64
+	for uint64(done) < c.Len {
+		chunk := buf[:min(uint64(len(buf)), c.Len-uint64(done))]
+		if _, err := t.CopyInBytes(hostarch.Addr(c.Src+uint64(done)), chunk); err != nil {
+			return done, err
+		}
+		n, err := ufd.uffd.Copy(t, c.Dst+uint64(done), chunk, c.Mode)
+		done += n
+		if err != nil || n < int64(len(chunk)) {
+			return done, err
+		}
+	}
+	return done, nil
+}
+
+// result returns the value reported to userspace in the result field of
+// ioctls that resolve faults: the number of bytes mapped, or a negative errno
+// if none were.
+func result(n int64, err error) int64 {
+	if n == 0 && err != nil {
+		return -int64(kernel.ExtractErrno(err, -1))
+	}
+	return n
+}
+
+// partialError returns the error returned by ioctls that resolve faults after
+// mapping n of length bytes.
+func partialError(n int64, length uint64, err error) error {
+	switch {
+	case n == 0:
+		return err
+	case uint64(n) < length:
+		return linuxerr.EAGAIN
+	default:
+		return nil
+	}
+}
+
+// Readiness implements waiter.Waitable.Readiness.
+func (ufd *UserfaultFileDescription) Readiness(mask waiter.EventMask) waiter.EventMask {
+	return ufd.uffd.Readiness(mask)
+}
+
+// EventRegister implements waiter.Waitable.EventRegister.
+func (ufd *UserfaultFileDescription) EventRegister(e *waiter.Entry) error {
+	return ufd.uffd.EventRegister(e)
+}
+
+// EventUnregister implements waiter.Waitable.EventUnregister.
+func (ufd *UserfaultFileDescription) EventUnregister(e *waiter.Entry) {
+	ufd.uffd.EventUnregister(e)
+}
+
+// Epollable implements FileDescriptionImpl.Epollable.
+func (ufd *UserfaultFileDescription) Epollable() bool {
+	return true
+}
+
+// Release implements vfs.FileDescriptionImpl.Release.
+func (ufd *UserfaultFileDescription) Release(ctx context.Context) {
+	ufd.uffd.Release(ctx)
+}
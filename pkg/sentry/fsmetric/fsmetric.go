@@ -88,6 +88,26 @@ var (
 			Description: "Time waiting on host file reads from a gofer, in nanoseconds.",
 			Unit:        metricpb.MetricMetadata_UNITS_NANOSECONDS,
 		})
+	GoferDentryCacheHits = metric.MustCreateNewUint64Metric("/gofer/dentry_cache_hits",
+		metric.Uint64Metadata{
+			Cumulative:  true,
+			Description: "Number of gofer path component lookups resolved from the dentry cache.",
+		})
+	GoferDentryCacheMisses = metric.MustCreateNewUint64Metric("/gofer/dentry_cache_misses",
+		metric.Uint64Metadata{
+			Cumulative:  true,
+			Description: "Number of gofer path component lookups that required a round trip to the gofer.",
+		})
+	GoferPageCacheHitBytes = metric.MustCreateNewUint64Metric("/gofer/page_cache_hit_bytes",
+		metric.Uint64Metadata{
+			Cumulative:  true,
+			Description: "Number of bytes of gofer file reads served from the sentry page cache.",
+		})
+	GoferPageCacheMissBytes = metric.MustCreateNewUint64Metric("/gofer/page_cache_miss_bytes",
+		metric.Uint64Metadata{
+			Cumulative:  true,
+			Description: "Number of bytes of gofer file reads through the sentry page cache that were not cached.",
+		})
 )
 
 // Metrics that only apply to fs/tmpfs and fsimpl/tmpfs.
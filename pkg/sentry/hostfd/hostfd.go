@@ -19,6 +19,7 @@ import (
 	"io"
 
 	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/safemem"
 	"gvisor.dev/gvisor/pkg/sync"
 )
@@ -97,7 +98,9 @@ func Preadv2(fd int32, dsts safemem.BlockSeq, offset int64, flags uint32) (uint6
 		n uintptr
 		e unix.Errno
 	)
-	if flags == 0 && dsts.NumBlocks() == 1 {
+	if r := uringFor(offset, flags, dsts.NumBlocks()); r != nil {
+		n, e = r.readWrite(linux.IORING_OP_READV, fd, safemem.IovecsFromBlockSeq(dsts), offset)
+	} else if flags == 0 && dsts.NumBlocks() == 1 {
 		// Use read() or pread() to avoid iovec allocation and copying.
 		dst := dsts.Head()
 		if offset == -1 {
@@ -128,7 +131,9 @@ func Pwritev2(fd int32, srcs safemem.BlockSeq, offset int64, flags uint32) (uint
 		n uintptr
 		e unix.Errno
 	)
-	if flags == 0 && srcs.NumBlocks() == 1 {
+	if r := uringFor(offset, flags, srcs.NumBlocks()); r != nil {
+		n, e = r.readWrite(linux.IORING_OP_WRITEV, fd, safemem.IovecsFromBlockSeq(srcs), offset)
+	} else if flags == 0 && srcs.NumBlocks() == 1 {
 		// Use write() or pwrite() to avoid iovec allocation and copying.
 		src := srcs.Head()
 		if offset == -1 {
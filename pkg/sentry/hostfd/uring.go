@@ -0,0 +1,278 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostfd
+
+import (
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sync"
+)
+
+// uring issues host file I/O through an io_uring instance owned by the
+// sentry. Concurrent requests are batched into a single io_uring_enter(2)
+// call where possible, and completions are reaped by a dedicated goroutine.
+//
+// The io_uring is restricted to IORING_OP_READV and IORING_OP_WRITEV requests
+// on registered files. Each element of uring.reqs has a corresponding entry
+// in the registered file table, which holds the host file targeted by the
+// request while it is in flight.
+type uring struct {
+	fd int32
+
+	// Mappings of the io_uring's submission queue ring, completion queue ring,
+	// and submission queue entry array. If the host supports
+	// IORING_FEAT_SINGLE_MMAP, sqRing and cqRing are the same mapping.
+	sqRing []byte
+	cqRing []byte
+	sqes   []byte
+
+	// Views of the above mappings.
+	sqTail  *uint32
+	sqMask  uint32
+	sqArray []uint32
+	sqeArr  []linux.IOUringSqe
+	cqHead  *uint32
+	cqTail  *uint32
+	cqMask  uint32
+	cqes    []linux.IOUringCqe
+
+	// slots contains the indices of unused elements of reqs. Since there are
+	// as many requests as submission queue entries, and the completion queue
+	// is at least as large as the submission queue, receiving from slots
+	// before queueing a request ensures that neither queue overflows.
+	slots chan uint32
+	reqs  []uringRequest
+
+	// kick is used to wake the completion goroutine when it is idle.
+	kick chan struct{}
+
+	mu sync.Mutex
+
+	// unsubmitted is the number of queued submission queue entries that have
+	// not yet been passed to io_uring_enter.
+	//
+	// +checklocks:mu
+	unsubmitted uint32
+
+	// inflight is the number of queued submission queue entries whose
+	// completions have not yet been reaped.
+	//
+	// +checklocks:mu
+	inflight uint32
+
+	// waiting is true if the completion goroutine is blocked in
+	// io_uring_enter. If so, submitters must call io_uring_enter themselves.
+	//
+	// +checklocks:mu
+	waiting bool
+}
+
+// uringRequest tracks an in-flight request.
+type uringRequest struct {
+	// iovs is the iovec array passed to the host. It is referenced here so
+	// that it can't be garbage collected before the request completes.
+	iovs []unix.Iovec
+
+	// fds and update are the arguments to IORING_REGISTER_FILES_UPDATE used
+	// to set the request's registered file table entry.
+	fds    [1]int32
+	update linux.IOUringFilesUpdate
+
+	// res is the request's result.
+	res int32
+
+	// done is signaled when the request completes.
+	done chan struct{}
+}
+
+const (
+	// uringMinBackoff and uringMaxBackoff bound the delay between failed
+	// calls to io_uring_enter(2) by uring.run.
+	uringMinBackoff = time.Millisecond
+	uringMaxBackoff = time.Second
+)
+
+// hostUring is the io_uring used by Preadv2 and Pwritev2, or nil if
+// EnableUring has not been called.
+var hostUring atomic.Pointer[uring]
+
+// EnableUring causes positional reads and writes on host file descriptors to
+// be issued through an io_uring with the given number of submission queue
+// entries, instead of through preadv2(2) and pwritev2(2). The io_uring is set
+// up immediately, so EnableUring must be called before syscall filters that
+// prohibit io_uring_setup(2) are installed; subsequently, only
+// io_uring_enter(2) is required.
+func EnableUring(entries uint32) error {
+	r, err := newUring(entries)
+	if err != nil {
+		return err
+	}
+	if !hostUring.CompareAndSwap(nil, r) {
+		r.destroy()
+		return nil
+	}
+	go r.run() // S/R-SAFE: host I/O is not checkpointed.
+	return nil
+}
+
+// uringFor returns the io_uring that should be used for a positional read or
+// write with the given parameters, or nil if none should be used.
+func uringFor(offset int64, flags uint32, iovs int) *uring {
+	// Reads and writes using the file offset are avoided since they may be on
+	// non-regular files (e.g. pipes and sockets) on which the I/O may block
+	// indefinitely. The io_uring also doesn't support preadv2/pwritev2 flags.
+	if offset < 0 || flags != 0 || iovs > MaxReadWriteIov {
+		return nil
+	}
+	return hostUring.Load()
+}
+
+// readWrite performs the given IORING_OP_READV or IORING_OP_WRITEV request
+// and returns its result.
+func (r *uring) readWrite(op uint8, fd int32, iovs []unix.Iovec, offset int64) (uintptr, unix.Errno) {
+	idx := <-r.slots
+	if e := r.setFile(idx, fd); e != 0 {
+		r.slots <- idx
+		return 0, e
+	}
+	req := &r.reqs[idx]
+	req.iovs = iovs
+
+	r.mu.Lock()
+	r.queueLocked(op, iovs, offset, idx)
+	r.unsubmitted++
+	r.inflight++
+	var submit uint32
+	if r.waiting {
+		submit = r.unsubmitted
+		r.unsubmitted = 0
+	}
+	r.mu.Unlock()
+	if submit != 0 {
+		r.submit(submit)
+	} else {
+		r.wake()
+	}
+
+	<-req.done
+	res := req.res
+	req.iovs = nil
+	// Release the io_uring's reference on the host file, so that the file
+	// isn't kept open after fd is closed. If this fails, the file is released
+	// when the entry is next set.
+	r.setFile(idx, -1)
+	r.slots <- idx
+	if res < 0 {
+		return 0, unix.Errno(-res)
+	}
+	return uintptr(res), 0
+}
+
+// submit submits n queued submission queue entries.
+func (r *uring) submit(n uint32) {
+	for n != 0 {
+		m, e := r.enter(n, 0 /* minComplete */, 0 /* flags */)
+		if e == unix.EINTR {
+			continue
+		}
+		if e != 0 || m == 0 {
+			// Leave the remaining entries for the completion goroutine to
+			// retry.
+			r.mu.Lock()
+			r.unsubmitted += n
+			r.mu.Unlock()
+			r.wake()
+			return
+		}
+		n -= m
+	}
+}
+
+// wake wakes the completion goroutine if it is idle.
+func (r *uring) wake() {
+	select {
+	case r.kick <- struct{}{}:
+	default:
+	}
+}
+
+// run submits queued requests and reaps completions until the sentry exits.
+func (r *uring) run() {
+	backoff := uringMinBackoff
+	for {
+		r.mu.Lock()
+		if r.inflight == 0 {
+			r.mu.Unlock()
+			<-r.kick
+			continue
+		}
+		n := r.unsubmitted
+		r.unsubmitted = 0
+		r.waiting = true
+		r.mu.Unlock()
+
+		m, e := r.enter(n, 1 /* minComplete */, linux.IORING_ENTER_GETEVENTS)
+		if e != 0 {
+			m = 0
+		}
+
+		r.mu.Lock()
+		r.waiting = false
+		r.unsubmitted += n - m
+		r.mu.Unlock()
+
+		r.reap()
+
+		switch e {
+		case 0, unix.EINTR:
+			backoff = uringMinBackoff
+		default:
+			// Other errors, e.g. EAGAIN or EBUSY if the host is short of
+			// memory or the completion queue has overflowed, can't be
+			// resolved by retrying immediately. Requests remain queued and
+			// are retried after a delay.
+			if backoff == uringMinBackoff {
+				log.Warningf("io_uring_enter failed, retrying: %v", e)
+			}
+			time.Sleep(backoff)
+			backoff = min(2*backoff, uringMaxBackoff)
+		}
+	}
+}
+
+// reap delivers the results of completed requests.
+func (r *uring) reap() {
+	// Only this goroutine advances the completion queue head.
+	head := atomic.LoadUint32(r.cqHead)
+	tail := atomic.LoadUint32(r.cqTail)
+	if head == tail {
+		return
+	}
+	n := tail - head
+	for ; head != tail; head++ {
+		cqe := &r.cqes[head&r.cqMask]
+		req := &r.reqs[cqe.UserData]
+		req.res = cqe.Res
+		req.done <- struct{}{}
+	}
+	atomic.StoreUint32(r.cqHead, head)
+	r.mu.Lock()
+	r.inflight -= n
+	r.mu.Unlock()
+}
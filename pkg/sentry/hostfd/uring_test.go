@@ -0,0 +1,142 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostfd
+
+import (
+	"bytes"
+	"os"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/safemem"
+)
+
+func newTestUring(t *testing.T) *uring {
+	t.Helper()
+	r, err := newUring(8)
+	if err != nil {
+		t.Skipf("restricted io_uring unavailable: %v", err)
+	}
+	return r
+}
+
+func openTestFile(t *testing.T) int32 {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "uring")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	fd, err := unix.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		t.Fatalf("Dup failed: %v", err)
+	}
+	t.Cleanup(func() { unix.Close(fd) })
+	return int32(fd)
+}
+
+// submitRaw submits sqe without going through uring.readWrite, and returns the
+// result of its completion.
+func submitRaw(t *testing.T, r *uring, sqe linux.IOUringSqe) int32 {
+	t.Helper()
+	tail := atomic.LoadUint32(r.sqTail)
+	i := tail & r.sqMask
+	r.sqeArr[i] = sqe
+	r.sqArray[i] = i
+	atomic.StoreUint32(r.sqTail, tail+1)
+	if _, e := r.enter(1, 1 /* minComplete */, linux.IORING_ENTER_GETEVENTS); e != 0 {
+		t.Fatalf("io_uring_enter failed: %v", e)
+	}
+	head := atomic.LoadUint32(r.cqHead)
+	if head == atomic.LoadUint32(r.cqTail) {
+		t.Fatalf("no completion after io_uring_enter")
+	}
+	res := r.cqes[head&r.cqMask].Res
+	atomic.StoreUint32(r.cqHead, head+1)
+	return res
+}
+
+func TestUringReadWrite(t *testing.T) {
+	r := newTestUring(t)
+	go r.run()
+	fd := openTestFile(t)
+
+	want := []byte("hello, io_uring")
+	srcs := safemem.BlockSeqOf(safemem.BlockFromSafeSlice(want))
+	if n, e := r.readWrite(linux.IORING_OP_WRITEV, fd, safemem.IovecsFromBlockSeq(srcs), 10); e != 0 || n != uintptr(len(want)) {
+		t.Fatalf("IORING_OP_WRITEV: got (%d, %v), want (%d, nil)", n, e, len(want))
+	}
+	got := make([]byte, len(want))
+	dsts := safemem.BlockSeqOf(safemem.BlockFromSafeSlice(got))
+	if n, e := r.readWrite(linux.IORING_OP_READV, fd, safemem.IovecsFromBlockSeq(dsts), 10); e != 0 || n != uintptr(len(want)) {
+		t.Fatalf("IORING_OP_READV: got (%d, %v), want (%d, nil)", n, e, len(want))
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("IORING_OP_READV: got data %q, want %q", got, want)
+	}
+
+	// Requests on bad FDs fail without being queued.
+	if _, e := r.readWrite(linux.IORING_OP_READV, -1, safemem.IovecsFromBlockSeq(dsts), 0); e != unix.EBADF {
+		t.Errorf("IORING_OP_READV on FD -1: got error %v, want %v", e, unix.EBADF)
+	}
+}
+
+func TestUringRejectsDisallowedRequests(t *testing.T) {
+	r := newTestUring(t)
+	fd := openTestFile(t)
+	if e := r.setFile(0, fd); e != 0 {
+		t.Fatalf("setFile failed: %v", e)
+	}
+	buf := make([]byte, 8)
+	iov := []unix.Iovec{{Base: &buf[0], Len: uint64(len(buf))}}
+
+	for _, test := range []struct {
+		name string
+		sqe  linux.IOUringSqe
+	}{
+		{
+			name: "disallowed opcode",
+			sqe: linux.IOUringSqe{
+				Opcode: linux.IORING_OP_FSYNC,
+				Flags:  linux.IOSQE_FIXED_FILE,
+				Fd:     0,
+			},
+		},
+		{
+			name: "unregistered file",
+			sqe: linux.IOUringSqe{
+				Opcode:          linux.IORING_OP_READV,
+				Fd:              fd,
+				AddrOrSpliceOff: uint64(uintptr(unsafe.Pointer(&iov[0]))),
+				Len:             uint32(len(iov)),
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if res := submitRaw(t, r, test.sqe); res != -int32(unix.EACCES) {
+				t.Errorf("got result %d, want %d", res, -int32(unix.EACCES))
+			}
+		})
+	}
+
+	t.Run("disallowed register opcode", func(t *testing.T) {
+		if e := r.register(linux.IORING_REGISTER_BUFFERS, unsafe.Pointer(&iov[0]), uint32(len(iov))); e != unix.EACCES {
+			t.Errorf("IORING_REGISTER_BUFFERS: got error %v, want %v", e, unix.EACCES)
+		}
+	})
+}
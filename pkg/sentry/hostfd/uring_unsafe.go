@@ -0,0 +1,176 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostfd
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+)
+
+// newUring returns a new uring with the given number of submission queue
+// entries.
+func newUring(entries uint32) (*uring, error) {
+	params := linux.IOUringParams{
+		// The io_uring is created disabled so that no request can be submitted
+		// before restrictions are registered.
+		Flags: linux.IORING_SETUP_R_DISABLED,
+	}
+	fd, _, e := unix.RawSyscall(unix.SYS_IO_URING_SETUP, uintptr(entries), uintptr(unsafe.Pointer(&params)), 0)
+	if e != 0 {
+		return nil, e
+	}
+	r := &uring{fd: int32(fd)}
+
+	sqRingSize := int(params.SqOff.Array) + int(params.SqEntries)*int(unsafe.Sizeof(uint32(0)))
+	cqRingSize := int(params.CqOff.Cqes) + int(params.CqEntries)*int(unsafe.Sizeof(linux.IOUringCqe{}))
+	singleMmap := params.Features&linux.IORING_FEAT_SINGLE_MMAP != 0
+	if singleMmap && cqRingSize > sqRingSize {
+		sqRingSize = cqRingSize
+	}
+	var err error
+	if r.sqRing, err = unix.Mmap(int(fd), linux.IORING_OFF_SQ_RING, sqRingSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED|unix.MAP_POPULATE); err != nil {
+		r.destroy()
+		return nil, err
+	}
+	if singleMmap {
+		r.cqRing = r.sqRing
+	} else if r.cqRing, err = unix.Mmap(int(fd), linux.IORING_OFF_CQ_RING, cqRingSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED|unix.MAP_POPULATE); err != nil {
+		r.destroy()
+		return nil, err
+	}
+	sqesSize := int(params.SqEntries) * int(unsafe.Sizeof(linux.IOUringSqe{}))
+	if r.sqes, err = unix.Mmap(int(fd), linux.IORING_OFF_SQES, sqesSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED|unix.MAP_POPULATE); err != nil {
+		r.destroy()
+		return nil, err
+	}
+	if err := r.restrict(params.SqEntries); err != nil {
+		r.destroy()
+		return nil, err
+	}
+
+	r.sqTail = (*uint32)(unsafe.Pointer(&r.sqRing[params.SqOff.Tail]))
+	r.sqMask = *(*uint32)(unsafe.Pointer(&r.sqRing[params.SqOff.RingMask]))
+	r.sqArray = unsafe.Slice((*uint32)(unsafe.Pointer(&r.sqRing[params.SqOff.Array])), params.SqEntries)
+	r.sqeArr = unsafe.Slice((*linux.IOUringSqe)(unsafe.Pointer(&r.sqes[0])), params.SqEntries)
+	r.cqHead = (*uint32)(unsafe.Pointer(&r.cqRing[params.CqOff.Head]))
+	r.cqTail = (*uint32)(unsafe.Pointer(&r.cqRing[params.CqOff.Tail]))
+	r.cqMask = *(*uint32)(unsafe.Pointer(&r.cqRing[params.CqOff.RingMask]))
+	r.cqes = unsafe.Slice((*linux.IOUringCqe)(unsafe.Pointer(&r.cqRing[params.CqOff.Cqes])), params.CqEntries)
+
+	r.slots = make(chan uint32, params.SqEntries)
+	r.reqs = make([]uringRequest, params.SqEntries)
+	for i := range r.reqs {
+		r.reqs[i].done = make(chan struct{}, 1)
+		r.slots <- uint32(i)
+	}
+	r.kick = make(chan struct{}, 1)
+	return r, nil
+}
+
+// restrict registers a file table with one entry per submission queue entry,
+// restricts the io_uring to IORING_OP_READV and IORING_OP_WRITEV requests on
+// registered files and to updates of the file table, and then enables the
+// io_uring. Requests on io_uring are not subject to seccomp filters, so this
+// prevents a compromised sentry from using the io_uring to perform operations
+// that its filters prohibit.
+func (r *uring) restrict(entries uint32) error {
+	fds := make([]int32, entries)
+	for i := range fds {
+		fds[i] = -1
+	}
+	if e := r.register(linux.IORING_REGISTER_FILES, unsafe.Pointer(&fds[0]), entries); e != 0 {
+		return fmt.Errorf("failed to register files: %w", e)
+	}
+	restrictions := []linux.IOUringRestriction{
+		{Opcode: linux.IORING_RESTRICTION_REGISTER_OP, Arg: linux.IORING_REGISTER_FILES_UPDATE},
+		{Opcode: linux.IORING_RESTRICTION_SQE_OP, Arg: linux.IORING_OP_READV},
+		{Opcode: linux.IORING_RESTRICTION_SQE_OP, Arg: linux.IORING_OP_WRITEV},
+		{Opcode: linux.IORING_RESTRICTION_SQE_FLAGS_REQUIRED, Arg: linux.IOSQE_FIXED_FILE},
+	}
+	if e := r.register(linux.IORING_REGISTER_RESTRICTIONS, unsafe.Pointer(&restrictions[0]), uint32(len(restrictions))); e != 0 {
+		return fmt.Errorf("failed to register restrictions: %w", e)
+	}
+	if e := r.register(linux.IORING_REGISTER_ENABLE_RINGS, nil, 0); e != 0 {
+		return fmt.Errorf("failed to enable io_uring: %w", e)
+	}
+	return nil
+}
+
+// register invokes io_uring_register(2).
+func (r *uring) register(op uint32, arg unsafe.Pointer, n uint32) unix.Errno {
+	_, _, e := unix.RawSyscall6(unix.SYS_IO_URING_REGISTER, uintptr(r.fd), uintptr(op), uintptr(arg), uintptr(n), 0, 0)
+	return e
+}
+
+// setFile sets the registered file table entry at index idx to the file
+// represented by fd, or clears it if fd is -1.
+func (r *uring) setFile(idx uint32, fd int32) unix.Errno {
+	// req.fds and req.update are used since r.reqs is heap-allocated, and
+	// therefore doesn't move while the kernel reads it.
+	req := &r.reqs[idx]
+	req.fds[0] = fd
+	req.update = linux.IOUringFilesUpdate{
+		Offset: idx,
+		Fds:    uint64(uintptr(unsafe.Pointer(&req.fds[0]))),
+	}
+	_, _, e := unix.Syscall6(unix.SYS_IO_URING_REGISTER, uintptr(r.fd), linux.IORING_REGISTER_FILES_UPDATE, uintptr(unsafe.Pointer(&req.update)), 1, 0, 0)
+	return e
+}
+
+// destroy releases resources held by a uring that was never used.
+func (r *uring) destroy() {
+	if r.sqes != nil {
+		unix.Munmap(r.sqes)
+	}
+	if r.cqRing != nil && unsafe.SliceData(r.cqRing) != unsafe.SliceData(r.sqRing) {
+		unix.Munmap(r.cqRing)
+	}
+	if r.sqRing != nil {
+		unix.Munmap(r.sqRing)
+	}
+	unix.Close(int(r.fd))
+}
+
+// queueLocked fills the next submission queue entry with the given request.
+//
+// Preconditions:
+//   - r.mu must be locked.
+//   - The registered file table entry at index idx must be set.
+func (r *uring) queueLocked(op uint8, iovs []unix.Iovec, offset int64, idx uint32) {
+	// Only submitters, serialized by r.mu, advance the submission queue tail.
+	tail := atomic.LoadUint32(r.sqTail)
+	i := tail & r.sqMask
+	r.sqeArr[i] = linux.IOUringSqe{
+		Opcode:           op,
+		Flags:            linux.IOSQE_FIXED_FILE,
+		Fd:               int32(idx), // Index into the registered file table.
+		OffOrAddrOrCmdOp: uint64(offset),
+		AddrOrSpliceOff:  uint64(uintptr(unsafe.Pointer(&iovs[0]))),
+		Len:              uint32(len(iovs)),
+		UserData:         uint64(idx),
+	}
+	r.sqArray[i] = i
+	atomic.StoreUint32(r.sqTail, tail+1)
+}
+
+// enter invokes io_uring_enter(2).
+func (r *uring) enter(toSubmit, minComplete, flags uint32) (uint32, unix.Errno) {
+	n, _, e := unix.Syscall6(unix.SYS_IO_URING_ENTER, uintptr(r.fd), uintptr(toSubmit), uintptr(minComplete), uintptr(flags), 0 /* sig */, 0 /* sigsz */)
+	return uint32(n), e
+}
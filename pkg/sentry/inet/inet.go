@@ -91,7 +91,9 @@ type Stack interface {
 	// NewRoute adds the given route to the network stack's route table.
 	NewRoute(ctx context.Context, msg *nlmsg.Message) *syserr.Error
 
-	// Pause pauses the network stack before save.
+	// Pause pauses the network stack before save. Calls to Pause may nest,
+	// in which case the stack is only resumed by the last matching call to
+	// Resume.
 	Pause()
 
 	// Resume resumes the network stack after save.
@@ -132,6 +134,13 @@ type Stack interface {
 	// (inclusive).
 	SetPortRange(start uint16, end uint16) error
 
+	// IPv6AddrConf returns the IPv6 address autoconfiguration settings.
+	IPv6AddrConf() (IPv6AddrConf, error)
+
+	// SetIPv6AddrConf changes the IPv6 address autoconfiguration settings of
+	// all interfaces.
+	SetIPv6AddrConf(conf IPv6AddrConf) error
+
 	// EnableSaveRestore enables netstack s/r.
 	EnableSaveRestore() error
 
@@ -194,6 +203,26 @@ type TCPBufferSize struct {
 	Max int
 }
 
+// IPv6AddrConf contains settings controlling IPv6 temporary addresses, as per
+// RFC 4941. See the use_tempaddr, temp_valid_lft and temp_prefered_lft
+// entries in Linux's Documentation/networking/ip-sysctl.rst.
+//
+// +stateify savable
+type IPv6AddrConf struct {
+	// UseTempAddr is 0 if temporary addresses are disabled, 1 if they are
+	// enabled but public addresses are preferred as source addresses, and 2 if
+	// they are enabled and preferred.
+	UseTempAddr int32
+
+	// TempValidLifetime is the maximum valid lifetime of temporary addresses,
+	// in seconds.
+	TempValidLifetime int32
+
+	// TempPreferredLifetime is the maximum preferred lifetime of temporary
+	// addresses, in seconds.
+	TempPreferredLifetime int32
+}
+
 // StatDev describes one line of /proc/net/dev, i.e., stats for one network
 // interface.
 type StatDev [16]uint64
@@ -39,6 +39,7 @@ type TestStack struct {
 	TCPSACKFlag       bool
 	Recovery          TCPLossRecovery
 	IPForwarding      bool
+	AddrConf          IPv6AddrConf
 }
 
 // NewTestStack returns a TestStack with no network interfaces. The value of
@@ -212,6 +213,17 @@ func (*TestStack) SetPortRange(start uint16, end uint16) error {
 	return nil
 }
 
+// IPv6AddrConf implements Stack.
+func (s *TestStack) IPv6AddrConf() (IPv6AddrConf, error) {
+	return s.AddrConf, nil
+}
+
+// SetIPv6AddrConf implements Stack.
+func (s *TestStack) SetIPv6AddrConf(conf IPv6AddrConf) error {
+	s.AddrConf = conf
+	return nil
+}
+
 // GROTimeout implements Stack.
 func (*TestStack) GROTimeout(NICID int32) (time.Duration, error) {
 	// No-op.
@@ -27,6 +27,9 @@ func (t *Task) QueueAIO(cb AIOCallback) {
 	wg := &t.TaskSet().aioGoroutines
 	wg.Add(1)
 	go func() {
+		if t.k.OnPanic != nil {
+			defer t.k.ReportPanic(nil)
+		}
 		cb(ctx)
 		wg.Done()
 	}()
@@ -56,21 +56,34 @@ func (cs *CapabilitySet) Clear(cp linux.Capability) {
 // VfsCapDataOf returns a VfsCapData containing the file capabilities for the given slice of bytes.
 // For each field of the cap data, which are in the structure of either vfs_cap_data or vfs_ns_cap_data,
 // the bytes are ordered in little endian.
+//
+// Version 1 file capabilities are accepted and returned with zeroed upper
+// capability masks, as in security/commoncap.c:get_vfs_caps_from_disk().
 func VfsCapDataOf(data []byte) (linux.VfsNsCapData, error) {
 	size := len(data)
-	if size != linux.XATTR_CAPS_SZ_2 && size != linux.XATTR_CAPS_SZ_3 {
+	if size != linux.XATTR_CAPS_SZ_1 && size != linux.XATTR_CAPS_SZ_2 && size != linux.XATTR_CAPS_SZ_3 {
 		log.Warningf("the size of security.capability is invalid: size=%d", size)
 		return linux.VfsNsCapData{}, linuxerr.EINVAL
 	}
 	var capData linux.VfsNsCapData
-	if size == linux.XATTR_CAPS_SZ_3 {
+	switch size {
+	case linux.XATTR_CAPS_SZ_3:
 		capData.UnmarshalUnsafe(data)
-	} else {
+	case linux.XATTR_CAPS_SZ_2:
 		capData.VfsCapData.UnmarshalUnsafe(data)
 		// rootid = 0 is correct for version 2 file capabilities.
+	case linux.XATTR_CAPS_SZ_1:
+		// struct vfs_cap_data for version 1 holds only the lower 32 bits of
+		// each capability mask, which line up with the first three fields of
+		// VfsCapData.
+		var buf [linux.XATTR_CAPS_SZ_2]byte
+		copy(buf[:], data)
+		capData.VfsCapData.UnmarshalUnsafe(buf[:])
 	}
-	// See security/commoncap.c:validheader().
-	if sansflags := capData.MagicEtc & ^uint32(linux.VFS_CAP_FLAGS_EFFECTIVE); (size == linux.XATTR_CAPS_SZ_2 && sansflags != linux.VFS_CAP_REVISION_2) ||
+	// See security/commoncap.c:validheader() and get_vfs_caps_from_disk().
+	sansflags := capData.MagicEtc & ^uint32(linux.VFS_CAP_FLAGS_EFFECTIVE)
+	if (size == linux.XATTR_CAPS_SZ_1 && sansflags != linux.VFS_CAP_REVISION_1) ||
+		(size == linux.XATTR_CAPS_SZ_2 && sansflags != linux.VFS_CAP_REVISION_2) ||
 		(size == linux.XATTR_CAPS_SZ_3 && sansflags != linux.VFS_CAP_REVISION_3) {
 		log.Warningf("the magic header of security.capability is invalid: magic=%#x, size=%d", capData.MagicEtc, size)
 		return linux.VfsNsCapData{}, linuxerr.EINVAL
@@ -89,14 +102,18 @@ func HandleVfsCaps(vfsCaps linux.VfsNsCapData, creds *Credentials) (bool, bool,
 		// Linux skips vfs caps in this situation.
 		return false, false, nil
 	}
+	// Capabilities beyond CAP_LAST_CAP are ignored, as in
+	// security/commoncap.c:get_vfs_caps_from_disk().
+	filePermitted := CapabilitySet(vfsCaps.Permitted()) & AllCapabilities
+	fileInheritable := CapabilitySet(vfsCaps.Inheritable()) & AllCapabilities
 	// Note that ambient capabilities are not yet supported in gVisor.
 	// P'(permitted) = (P(inheritable) & F(inheritable)) | (F(permitted) & P(bounding)) | P'(ambient)
-	creds.PermittedCaps = (CapabilitySet(vfsCaps.Permitted()) & creds.BoundingCaps) |
-		(CapabilitySet(vfsCaps.Inheritable()) & creds.InheritableCaps)
+	creds.PermittedCaps = (filePermitted & creds.BoundingCaps) |
+		(fileInheritable & creds.InheritableCaps)
 	effective := (vfsCaps.MagicEtc & linux.VFS_CAP_FLAGS_EFFECTIVE) > 0
 	// Insufficient to execute correctly. Linux only returns EPERM when effective
 	// flag is set.
-	if effective && (CapabilitySet(vfsCaps.Permitted()) & ^creds.PermittedCaps) != 0 {
+	if effective && (filePermitted & ^creds.PermittedCaps) != 0 {
 		return effective, true, linuxerr.EPERM
 	}
 	return effective, true, nil
@@ -109,6 +126,10 @@ func FixupVfsCapDataOnSet(creds *Credentials, value string, kuid KUID, kgid KGID
 	if err != nil {
 		return "", err
 	}
+	// Linux only allows version 1 file capabilities to be read, not written.
+	if vfsCaps.IsRevision1() {
+		return "", linuxerr.EINVAL
+	}
 	if !creds.HasCapabilityOnFile(linux.CAP_SETFCAP, kuid, kgid) {
 		return "", linuxerr.EPERM
 	}
@@ -142,6 +163,10 @@ func FixupVfsCapDataOnGet(creds *Credentials, value string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if vfsCaps.IsRevision1() {
+		// Version 1 file capabilities have no root ID to translate.
+		return value, nil
+	}
 	// Linux does the steps mentioned in FixupVfsCapDataOnSet in reverse. But
 	// since gVisor does not support ID-mapped mounts and all filesystems are
 	// owned by the initial user namespace, we only need to reverse step 1 here.
@@ -194,6 +219,13 @@ func HandlePrivilegedRoot(creds *Credentials, hasVFSCaps bool, filename string)
 
 // UpdateCredsForNewTask updates creds for a new task as per capabilities(7).
 func UpdateCredsForNewTask(creds *Credentials, fileCaps string, filename string) error {
+	_, err := UpdateCredsForExec(creds, fileCaps, filename)
+	return err
+}
+
+// UpdateCredsForExec is equivalent to UpdateCredsForNewTask, but also returns
+// true if the file effective bit was considered set.
+func UpdateCredsForExec(creds *Credentials, fileCaps string, filename string) (bool, error) {
 	// Clear the permitted capability set. It is initialized below via
 	// HandleVfsCaps() and HandlePrivilegedRoot().
 	creds.PermittedCaps = 0
@@ -202,11 +234,11 @@ func UpdateCredsForNewTask(creds *Credentials, fileCaps string, filename string)
 	if len(fileCaps) != 0 {
 		vfsCaps, err := VfsCapDataOf([]byte(fileCaps))
 		if err != nil {
-			return err
+			return false, err
 		}
 		setEffective, hasVFSCaps, err = HandleVfsCaps(vfsCaps, creds)
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 	setEffective = HandlePrivilegedRoot(creds, hasVFSCaps, filename) || setEffective
@@ -215,7 +247,7 @@ func UpdateCredsForNewTask(creds *Credentials, fileCaps string, filename string)
 	if setEffective {
 		creds.EffectiveCaps = creds.PermittedCaps
 	}
-	return nil
+	return setEffective, nil
 }
 
 // TaskCapabilities represents all the capability sets for a task. Each of these
@@ -41,6 +41,15 @@ func vfsNsCapDataFrom(effective bool, rootid uint32, permitted, inheritable Capa
 	return capData
 }
 
+func vfsCapDataV1From(effective bool, permitted, inheritable CapabilitySet) linux.VfsNsCapData {
+	capData := vfsCapDataFrom(effective, permitted, inheritable)
+	capData.MagicEtc = linux.VFS_CAP_REVISION_1
+	if effective {
+		capData.MagicEtc |= linux.VFS_CAP_FLAGS_EFFECTIVE
+	}
+	return capData
+}
+
 func vfsCapDataFrom(effective bool, permitted, inheritable CapabilitySet) linux.VfsNsCapData {
 	var capData linux.VfsNsCapData
 	capData.MagicEtc = linux.VFS_CAP_REVISION_2
@@ -133,6 +142,16 @@ func TestVfsCapData(t *testing.T) {
 		{
 			name:    "VfsCapRevision1",
 			data:    []byte{0, 0, 0, 1, 0, 16, 0, 0, 0, 0, 0, 0},
+			capData: vfsCapDataV1From(false, CapabilitySetOf(linux.CAP_NET_ADMIN), 0),
+		},
+		{
+			name:    "VfsCapRevision1WithEffective",
+			data:    []byte{1, 0, 0, 1, 0, 32, 0, 0, 0, 4, 0, 0},
+			capData: vfsCapDataV1From(true, CapabilitySetOf(linux.CAP_NET_RAW), CapabilitySetOf(linux.CAP_NET_BIND_SERVICE)),
+		},
+		{
+			name:    "VfsCapRevision1WrongSize",
+			data:    []byte{0, 0, 0, 1, 0, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
 			wantErr: linuxerr.EINVAL,
 		},
 		{
@@ -175,3 +194,19 @@ func TestXattrCapsSizeBytes(t *testing.T) {
 		t.Errorf("XATTR_CAPS_SZ_3 = %v, got %v", linux.XATTR_CAPS_SZ_3, got)
 	}
 }
+
+func TestFixupVfsCapDataOnSetRejectsRevision1(t *testing.T) {
+	creds := NewRootCredentials(NewRootUserNamespace())
+	capData := vfsCapDataV1From(true, CapabilitySetOf(linux.CAP_NET_RAW), 0)
+	value := capData.VfsCapData.ToString()[:linux.XATTR_CAPS_SZ_1]
+	if _, err := FixupVfsCapDataOnSet(creds, value, creds.EffectiveKUID, creds.EffectiveKGID); !linuxerr.Equals(linuxerr.EINVAL, err) {
+		t.Errorf("FixupVfsCapDataOnSet(v1) returned error %v, want %v", err, linuxerr.EINVAL)
+	}
+	got, err := FixupVfsCapDataOnGet(creds, value)
+	if err != nil {
+		t.Fatalf("FixupVfsCapDataOnGet(v1) failed: %v", err)
+	}
+	if got != value {
+		t.Errorf("FixupVfsCapDataOnGet(v1) = %v, want %v", []byte(got), []byte(value))
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"time"
 
 	"gvisor.dev/gvisor/pkg/atomicbitops"
 	"gvisor.dev/gvisor/pkg/context"
@@ -46,10 +47,14 @@ const (
 	CgroupControllerJob     = CgroupControllerType("job")
 	CgroupControllerMemory  = CgroupControllerType("memory")
 	CgroupControllerPIDs    = CgroupControllerType("pids")
+	CgroupControllerBlkio   = CgroupControllerType("blkio")
 )
 
 // CgroupCtrls is the list of cgroup controllers.
-var CgroupCtrls = []CgroupControllerType{"cpu", "cpuacct", "cpuset", "devices", "job", "memory", "pids"}
+//
+// New controllers are appended so that existing controllers keep their
+// hierarchy IDs.
+var CgroupCtrls = []CgroupControllerType{"cpu", "cpuacct", "cpuset", "devices", "job", "memory", "pids", "blkio"}
 
 // ParseCgroupController parses a string as a CgroupControllerType.
 func ParseCgroupController(val string) (CgroupControllerType, error) {
@@ -68,6 +73,8 @@ func ParseCgroupController(val string) (CgroupControllerType, error) {
 		return CgroupControllerMemory, nil
 	case "pids":
 		return CgroupControllerPIDs, nil
+	case "blkio":
+		return CgroupControllerBlkio, nil
 	default:
 		return "", fmt.Errorf("no such cgroup controller")
 	}
@@ -226,6 +233,15 @@ type CgroupImpl interface {
 	ID() uint32
 }
 
+// CgroupIOThrottler is implemented by cgroup controllers that throttle file
+// I/O.
+type CgroupIOThrottler interface {
+	// ChargeIO accounts for an I/O of the given number of bytes that has been
+	// performed by a task in this cgroup, and returns how long the task should
+	// wait before performing more I/O to stay within the cgroup's limits.
+	ChargeIO(write bool, bytes int64) time.Duration
+}
+
 // hierarchy represents a cgroupfs filesystem instance, with a unique set of
 // controllers attached to it. Multiple cgroupfs mounts may reference the same
 // hierarchy.
@@ -38,6 +38,10 @@ const (
 
 	// CtxUTSNamespace is a Context.Value key for a UTSNamespace.
 	CtxUTSNamespace
+
+	// CtxNetworkHandoff is a Context.Value key for a NetworkHandoff that
+	// Kernel.LoadFrom uses to replace the saved root network stack.
+	CtxNetworkHandoff
 )
 
 // ContextCanTrace returns true if ctx is permitted to trace t, in the same sense
@@ -24,19 +24,18 @@ import (
 	"gvisor.dev/gvisor/pkg/waiter"
 )
 
-// Table to convert waiter event masks into si_band siginfo codes.
-// Taken from fs/fcntl.c:band_table.
-var bandTable = map[waiter.EventMask]int64{
-	// POLL_IN
-	waiter.EventIn: linux.EPOLLIN | linux.EPOLLRDNORM,
-	// POLL_OUT
-	waiter.EventOut: linux.EPOLLOUT | linux.EPOLLWRNORM | linux.EPOLLWRBAND,
-	// POLL_ERR
-	waiter.EventErr: linux.EPOLLERR,
-	// POLL_PRI
-	waiter.EventPri: linux.EPOLLPRI | linux.EPOLLRDBAND,
-	// POLL_HUP
-	waiter.EventHUp: linux.EPOLLHUP | linux.EPOLLERR,
+// Table to convert waiter event masks into si_code and si_band siginfo
+// values. Taken from fs/fcntl.c:band_table.
+var bandTable = []struct {
+	mask waiter.EventMask
+	code int32
+	band int64
+}{
+	{waiter.EventIn, linux.POLL_IN, linux.EPOLLIN | linux.EPOLLRDNORM},
+	{waiter.EventOut, linux.POLL_OUT, linux.EPOLLOUT | linux.EPOLLWRNORM | linux.EPOLLWRBAND},
+	{waiter.EventErr, linux.POLL_ERR, linux.EPOLLERR},
+	{waiter.EventPri, linux.POLL_PRI, linux.EPOLLPRI | linux.EPOLLRDBAND},
+	{waiter.EventHUp, linux.POLL_HUP, linux.EPOLLHUP | linux.EPOLLERR},
 }
 
 // New returns a function that creates a new vfs.FileAsync with the given
@@ -125,12 +124,21 @@ func (a *FileAsync) NotifyEvent(mask waiter.EventMask) {
 		Code:  linux.SI_KERNEL,
 	}
 	if sig != 0 {
+		// Linux's fs/fcntl.c:send_sigio_to_task() sets si_code to the
+		// POLL_* reason for the notification. Since a single notification
+		// may carry several events, report the first matching reason in
+		// band_table order, and the union of all of their bands.
 		signalInfo.Signo = int32(sig)
 		signalInfo.SetFD(uint32(a.fd))
 		var band int64
-		for m, bandCode := range bandTable {
-			if m&mask != 0 {
-				band |= bandCode
+		found := false
+		for _, b := range bandTable {
+			if b.mask&mask != 0 {
+				if !found {
+					signalInfo.Code = b.code
+					found = true
+				}
+				band |= b.band
 			}
 		}
 		signalInfo.SetBand(band)
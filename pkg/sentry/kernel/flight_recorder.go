@@ -0,0 +1,244 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+)
+
+// flightRecorderEvents is the number of events retained by each task's
+// flight recorder.
+const flightRecorderEvents = 256
+
+// FlightEventKind identifies the type of a flight recorder event.
+type FlightEventKind uint32
+
+// Flight recorder event kinds.
+const (
+	// FlightSyscallEnter is recorded when a task enters a syscall. Arg is the
+	// syscall number.
+	FlightSyscallEnter FlightEventKind = iota + 1
+
+	// FlightSyscallExit is recorded when a task returns from a syscall. Arg
+	// is the syscall number; the return value is not recorded.
+	FlightSyscallExit
+
+	// FlightStateChange is recorded when a task goroutine changes state, e.g.
+	// when it blocks or resumes executing application code. Arg is the new
+	// TaskGoroutineState.
+	FlightStateChange
+
+	// FlightFault is recorded when a task takes an application page fault
+	// that the sentry handles. Arg is the faulting address.
+	FlightFault
+)
+
+// String implements fmt.Stringer.
+func (k FlightEventKind) String() string {
+	switch k {
+	case FlightSyscallEnter:
+		return "syscall-enter"
+	case FlightSyscallExit:
+		return "syscall-exit"
+	case FlightStateChange:
+		return "state"
+	case FlightFault:
+		return "fault"
+	default:
+		return fmt.Sprintf("FlightEventKind(%d)", uint32(k))
+	}
+}
+
+// flightEntry is a single slot in a flightRecorder.
+type flightEntry struct {
+	// seq is one more than the index of the event stored in the slot, or 0
+	// while the slot is being written.
+	seq  atomicbitops.Uint64
+	time atomicbitops.Int64
+	kind atomicbitops.Uint32
+	arg  atomicbitops.Uint64
+}
+
+// flightRecorder is a fixed-size ring of a task's most recent scheduling,
+// syscall and fault events. It is written only by the task goroutine and may
+// be read concurrently, without locking, by FlightRecords.
+type flightRecorder struct {
+	// next is the total number of events recorded. The slot for event i is
+	// events[i % flightRecorderEvents].
+	next atomicbitops.Uint64
+
+	events [flightRecorderEvents]flightEntry
+}
+
+// recordFlight records an event in t's flight recorder, if one exists.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) recordFlight(kind FlightEventKind, arg uint64) {
+	if fr := t.flightRecorder; fr != nil {
+		fr.record(kind, arg)
+	}
+}
+
+// record appends an event to fr.
+//
+// Preconditions: The caller must be the only writer of fr.
+func (fr *flightRecorder) record(kind FlightEventKind, arg uint64) {
+	i := fr.next.Load()
+	e := &fr.events[i%flightRecorderEvents]
+	e.seq.Store(0)
+	e.time.Store(time.Now().UnixNano())
+	e.kind.Store(uint32(kind))
+	e.arg.Store(arg)
+	e.seq.Store(i + 1)
+	fr.next.Store(i + 1)
+}
+
+// FlightEvent is an event returned by Kernel.FlightRecords.
+type FlightEvent struct {
+	// Time is the wall time at which the event was recorded.
+	Time time.Time
+
+	// Kind is the event type.
+	Kind FlightEventKind
+
+	// Arg is the event argument, interpreted according to Kind.
+	Arg uint64
+}
+
+// TaskFlightRecord is the content of one task's flight recorder.
+type TaskFlightRecord struct {
+	// TID is the task's thread ID in the root PID namespace.
+	TID ThreadID
+
+	// Name is the task's name.
+	Name string
+
+	// Dropped is the number of events that were overwritten before they
+	// could be read.
+	Dropped uint64
+
+	// Events are the retained events, oldest first.
+	Events []FlightEvent
+
+	// st is the task's syscall table, used to name syscalls.
+	st *SyscallTable
+}
+
+// snapshot returns the events currently retained by fr, oldest first, and
+// the number of events that were dropped.
+func (fr *flightRecorder) snapshot() ([]FlightEvent, uint64) {
+	end := fr.next.Load()
+	start := uint64(0)
+	if end > flightRecorderEvents {
+		start = end - flightRecorderEvents
+	}
+	events := make([]FlightEvent, 0, end-start)
+	dropped := start
+	for i := start; i < end; i++ {
+		// The task goroutine may overwrite the slot while it is being copied,
+		// in which case the event is lost.
+		e := &fr.events[i%flightRecorderEvents]
+		if e.seq.Load() != i+1 {
+			dropped++
+			continue
+		}
+		ev := FlightEvent{
+			Time: time.Unix(0, e.time.Load()),
+			Kind: FlightEventKind(e.kind.Load()),
+			Arg:  e.arg.Load(),
+		}
+		if e.seq.Load() != i+1 {
+			dropped++
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events, dropped
+}
+
+// FlightRecords returns the contents of the flight recorders of all tasks,
+// ordered by thread ID. It returns nil if the flight recorder is disabled.
+func (k *Kernel) FlightRecords() []TaskFlightRecord {
+	if !k.FlightRecorder {
+		return nil
+	}
+	k.tasks.mu.RLock()
+	tids := make(map[*Task]ThreadID, len(k.tasks.Root.tids))
+	for t, tid := range k.tasks.Root.tids {
+		tids[t] = tid
+	}
+	k.tasks.mu.RUnlock()
+
+	records := make([]TaskFlightRecord, 0, len(tids))
+	for t, tid := range tids {
+		fr := t.flightRecorder
+		if fr == nil {
+			continue
+		}
+		t.mu.Lock()
+		name := t.image.Name
+		st := t.image.st
+		t.mu.Unlock()
+		events, dropped := fr.snapshot()
+		records = append(records, TaskFlightRecord{
+			TID:     tid,
+			Name:    name,
+			Dropped: dropped,
+			Events:  events,
+			st:      st,
+		})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].TID < records[j].TID })
+	return records
+}
+
+// eventString returns a human-readable description of e, which must be one
+// of r.Events.
+func (r *TaskFlightRecord) eventString(e *FlightEvent) string {
+	switch e.Kind {
+	case FlightSyscallEnter, FlightSyscallExit:
+		name := fmt.Sprintf("sys_%d", e.Arg)
+		if r.st != nil {
+			name = r.st.LookupName(uintptr(e.Arg))
+		}
+		return fmt.Sprintf("%s %s", e.Kind, name)
+	case FlightStateChange:
+		return fmt.Sprintf("%s %s", e.Kind, TaskGoroutineState(e.Arg))
+	default:
+		return fmt.Sprintf("%s %#x", e.Kind, e.Arg)
+	}
+}
+
+// WriteFlightRecords writes the contents of all tasks' flight recorders to w
+// in a human-readable format.
+func (k *Kernel) WriteFlightRecords(w io.Writer) error {
+	for _, r := range k.FlightRecords() {
+		if _, err := fmt.Fprintf(w, "task %d (%s): %d events, %d dropped\n", r.TID, r.Name, len(r.Events), r.Dropped); err != nil {
+			return err
+		}
+		for i := range r.Events {
+			e := &r.Events[i]
+			if _, err := fmt.Fprintf(w, "  %s %s\n", e.Time.Format("15:04:05.000000000"), r.eventString(e)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
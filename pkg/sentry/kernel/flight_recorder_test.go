@@ -0,0 +1,41 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"testing"
+)
+
+func TestFlightRecorderSnapshot(t *testing.T) {
+	for _, n := range []int{0, 1, flightRecorderEvents - 1, flightRecorderEvents, 3*flightRecorderEvents + 7} {
+		var fr flightRecorder
+		for i := 0; i < n; i++ {
+			fr.record(FlightSyscallEnter, uint64(i))
+		}
+		events, dropped := fr.snapshot()
+		wantLen := min(n, flightRecorderEvents)
+		if len(events) != wantLen {
+			t.Errorf("n=%d: got %d events, want %d", n, len(events), wantLen)
+		}
+		if want := uint64(n - wantLen); dropped != want {
+			t.Errorf("n=%d: got %d dropped, want %d", n, dropped, want)
+		}
+		for i, e := range events {
+			if want := dropped + uint64(i); e.Arg != want || e.Kind != FlightSyscallEnter {
+				t.Errorf("n=%d: event %d is %v %d, want %v %d", n, i, e.Kind, e.Arg, FlightSyscallEnter, want)
+			}
+		}
+	}
+}
@@ -605,7 +605,15 @@ func (m *Manager) WaitPrepare(w *Waiter, t Target, addr hostarch.Addr, private b
 // WaitComplete must be called when a Waiter previously added by WaitPrepare is
 // no longer eligible to be woken.
 func (m *Manager) WaitComplete(w *Waiter, t Target) {
-	// Remove w from the bucket it's in.
+	w.dequeue()
+
+	// Release references held by the waiter.
+	w.key.release(t)
+}
+
+// dequeue removes w from the bucket it's in, if any. It returns false if w was
+// no longer in any bucket, i.e. it has been woken.
+func (w *Waiter) dequeue() bool {
 	for {
 		b := w.bucket.Load()
 
@@ -613,7 +621,7 @@ func (m *Manager) WaitComplete(w *Waiter, t Target) {
 		// racy because the waiter can't be concurrently re-queued in another
 		// bucket.
 		if b == nil {
-			break
+			return false
 		}
 
 		// Take the bucket lock. Note that without holding the bucket lock, the
@@ -632,11 +640,115 @@ func (m *Manager) WaitComplete(w *Waiter, t Target) {
 		b.waiters.Remove(w)
 		w.bucket.Store(nil)
 		b.mu.Unlock()
-		break
+		return true
 	}
+}
 
-	// Release references held by the waiter.
-	w.key.release(t)
+// WaitEntry describes a futex waited on by WaitMultiplePrepare.
+type WaitEntry struct {
+	// Addr is the address of the futex.
+	Addr hostarch.Addr
+
+	// Private is true if the futex is private to the address space.
+	Private bool
+
+	// Val is the value that Addr is expected to contain.
+	Val uint32
+}
+
+// MultiWaiter waits on several futexes at once, as for futex_waitv(2).
+type MultiWaiter struct {
+	// C is sent to when any of the futexes is woken.
+	C chan struct{}
+
+	// waiters are the Waiters enqueued for each futex. All of them send to C.
+	waiters []Waiter
+
+	// queued is the number of waiters enqueued by WaitMultiplePrepare.
+	queued int
+}
+
+// NewMultiWaiter returns a new unqueued MultiWaiter that can wait on up to n
+// futexes.
+func NewMultiWaiter(n int) *MultiWaiter {
+	// C is large enough for every waiter to be woken without blocking the
+	// waker.
+	mw := &MultiWaiter{
+		C:       make(chan struct{}, n),
+		waiters: make([]Waiter, n),
+	}
+	for i := range mw.waiters {
+		mw.waiters[i].C = mw.C
+	}
+	return mw
+}
+
+// WaitMultiplePrepare checks that each futex in entries contains the expected
+// value, then enqueues mw to be woken by a send to mw.C when any of them is
+// woken. The check and enqueueing are atomic with respect to each futex, but
+// not across futexes.
+//
+// If WaitMultiplePrepare returns (-1, nil), mw must be subsequently removed by
+// calling WaitMultipleComplete, whether or not a wakeup is received on mw.C.
+// Otherwise, mw is no longer enqueued: if a futex doesn't contain the expected
+// value, WaitMultiplePrepare returns the index of a futex that was woken in
+// the meantime or EAGAIN if there is none.
+//
+// Preconditions: len(entries) <= n, as passed to NewMultiWaiter.
+func (m *Manager) WaitMultiplePrepare(mw *MultiWaiter, t Target, entries []WaitEntry) (int, error) {
+	// Prepare the MultiWaiter before taking any bucket lock.
+	for len(mw.C) != 0 {
+		<-mw.C
+	}
+	mw.queued = 0
+
+	for i := range entries {
+		e := &entries[i]
+		k, err := getKey(t, e.Addr, e.Private)
+		if err != nil {
+			return m.abortWaitMultiple(mw, t, err)
+		}
+		// Ownership of k is transferred to w below.
+		w := &mw.waiters[i]
+		w.key = k
+		w.bitmask = linux.FUTEX_BITSET_MATCH_ANY
+
+		b := m.lockBucket(&k)
+		if err := check(t, e.Addr, e.Val); err != nil {
+			b.mu.Unlock()
+			w.key.release(t)
+			return m.abortWaitMultiple(mw, t, err)
+		}
+		b.waiters.PushBack(w)
+		w.bucket.Store(b)
+		b.mu.Unlock()
+		mw.queued++
+	}
+	return -1, nil
+}
+
+// abortWaitMultiple dequeues mw after WaitMultiplePrepare failed with err.
+func (m *Manager) abortWaitMultiple(mw *MultiWaiter, t Target, err error) (int, error) {
+	if woken := m.WaitMultipleComplete(mw, t); woken >= 0 {
+		return woken, nil
+	}
+	return -1, err
+}
+
+// WaitMultipleComplete must be called when a MultiWaiter previously enqueued by
+// WaitMultiplePrepare is no longer eligible to be woken. It returns the index
+// of the last futex that was woken, or -1 if none was.
+func (m *Manager) WaitMultipleComplete(mw *MultiWaiter, t Target) int {
+	woken := -1
+	for i := 0; i < mw.queued; i++ {
+		w := &mw.waiters[i]
+		if !w.dequeue() {
+			woken = i
+		}
+		w.key.release(t)
+	}
+	mw.queued = 0
+	return woken
 }
 
 // LockPI attempts to lock the futex following the Priority-inheritance futex
@@ -452,6 +452,110 @@ func TestWakeOpSameAddressFailingOp(t *testing.T) {
 	}
 }
 
+func TestWaitMultipleWake(t *testing.T) {
+	for _, private := range []bool{false, true} {
+		t.Run(futexKind(private), func(t *testing.T) {
+			m := NewManager()
+			d := newTestData(3 * sizeofInt32)
+
+			// Wait on all three addresses.
+			entries := []WaitEntry{
+				{Addr: 0 * sizeofInt32, Private: private},
+				{Addr: 1 * sizeofInt32, Private: private},
+				{Addr: 2 * sizeofInt32, Private: private},
+			}
+			mw := NewMultiWaiter(len(entries))
+			if i, err := m.WaitMultiplePrepare(mw, d, entries); err != nil || i != -1 {
+				t.Fatalf("WaitMultiplePrepare: got (%d, %v), wanted (-1, nil)", i, err)
+			}
+
+			// Wake the second address.
+			if n, err := m.Wake(d, 1*sizeofInt32, private, ^uint32(0), 1); err != nil || n != 1 {
+				t.Errorf("Wake: got (%d, %v), wanted (1, nil)", n, err)
+			}
+			select {
+			case <-mw.C:
+			default:
+				t.Error("MultiWaiter not woken")
+			}
+
+			// Expect only the second address to be reported as woken, and
+			// no waiter to remain enqueued.
+			if i := m.WaitMultipleComplete(mw, d); i != 1 {
+				t.Errorf("WaitMultipleComplete: got %d, wanted 1", i)
+			}
+			for _, e := range entries {
+				if n, err := m.Wake(d, e.Addr, private, ^uint32(0), 1); err != nil || n != 0 {
+					t.Errorf("Wake(%#x) after WaitMultipleComplete: got (%d, %v), wanted (0, nil)", e.Addr, n, err)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitMultipleNotWoken(t *testing.T) {
+	m := NewManager()
+	d := newTestData(2 * sizeofInt32)
+
+	entries := []WaitEntry{
+		{Addr: 0 * sizeofInt32, Private: true},
+		{Addr: 1 * sizeofInt32, Private: true},
+	}
+	mw := NewMultiWaiter(len(entries))
+	if i, err := m.WaitMultiplePrepare(mw, d, entries); err != nil || i != -1 {
+		t.Fatalf("WaitMultiplePrepare: got (%d, %v), wanted (-1, nil)", i, err)
+	}
+
+	// Wake an unrelated, shared futex at the same address.
+	if n, err := m.Wake(d, 0, false, ^uint32(0), 1); err != nil || n != 0 {
+		t.Errorf("Wake: got (%d, %v), wanted (0, nil)", n, err)
+	}
+	if i := m.WaitMultipleComplete(mw, d); i != -1 {
+		t.Errorf("WaitMultipleComplete: got %d, wanted -1", i)
+	}
+}
+
+func TestWaitMultipleMismatch(t *testing.T) {
+	for _, private := range []bool{false, true} {
+		t.Run(futexKind(private), func(t *testing.T) {
+			m := NewManager()
+			d := newTestData(2 * sizeofInt32)
+
+			// The second address doesn't contain the expected value.
+			entries := []WaitEntry{
+				{Addr: 0 * sizeofInt32, Private: private},
+				{Addr: 1 * sizeofInt32, Private: private, Val: 1},
+			}
+			mw := NewMultiWaiter(len(entries))
+			if i, err := m.WaitMultiplePrepare(mw, d, entries); !linuxerr.Equals(linuxerr.EAGAIN, err) || i != -1 {
+				t.Errorf("WaitMultiplePrepare: got (%d, %v), wanted (-1, EAGAIN)", i, err)
+			}
+
+			// Expect the waiter on the first address to have been dequeued.
+			if n, err := m.Wake(d, 0, private, ^uint32(0), 1); err != nil || n != 0 {
+				t.Errorf("Wake: got (%d, %v), wanted (0, nil)", n, err)
+			}
+		})
+	}
+}
+
+func TestWaitMultipleUnaligned(t *testing.T) {
+	m := NewManager()
+	d := newTestData(2 * sizeofInt32)
+
+	entries := []WaitEntry{
+		{Addr: 0, Private: true},
+		{Addr: 1, Private: true},
+	}
+	mw := NewMultiWaiter(len(entries))
+	if i, err := m.WaitMultiplePrepare(mw, d, entries); !linuxerr.Equals(linuxerr.EINVAL, err) || i != -1 {
+		t.Errorf("WaitMultiplePrepare: got (%d, %v), wanted (-1, EINVAL)", i, err)
+	}
+	if n, err := m.Wake(d, 0, true, ^uint32(0), 1); err != nil || n != 0 {
+		t.Errorf("Wake: got (%d, %v), wanted (0, nil)", n, err)
+	}
+}
+
 const (
 	testMutexSize            = sizeofInt32
 	testMutexLocked   uint32 = 1
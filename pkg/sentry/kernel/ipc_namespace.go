@@ -136,6 +136,45 @@ func (i *IPCNamespace) DecRef(ctx context.Context) {
 	i.inode.DecRef(ctx)
 }
 
+// SemUndoList returns the task's System V semaphore undo list, creating it if
+// necessary.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) SemUndoList() *semaphore.UndoList {
+	if t.semUndoList == nil {
+		l := semaphore.NewUndoList()
+		t.mu.Lock()
+		t.semUndoList = l
+		t.mu.Unlock()
+	}
+	return t.semUndoList
+}
+
+// SemUndoListIfExists returns the task's System V semaphore undo list, or nil
+// if it doesn't have one.
+//
+// Preconditions: The caller must be running on the task goroutine, or t.mu
+// must be locked.
+func (t *Task) SemUndoListIfExists() *semaphore.UndoList {
+	return t.semUndoList
+}
+
+// exitSemUndoList detaches the task from its System V semaphore undo list,
+// applying its adjustments if the task was its last user. Compare to Linux's
+// ipc/sem.c:exit_sem().
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) exitSemUndoList() {
+	l := t.semUndoList
+	if l == nil {
+		return
+	}
+	t.mu.Lock()
+	t.semUndoList = nil
+	t.mu.Unlock()
+	l.DecRef(t, int32(t.k.tasks.Root.IDOfThreadGroup(t.tg)))
+}
+
 // IPCNamespace returns the task's IPC namespace.
 func (t *Task) IPCNamespace() *IPCNamespace {
 	t.mu.Lock()
@@ -64,6 +64,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/futex"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/ipc"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/pipe"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/sched"
 	"gvisor.dev/gvisor/pkg/sentry/ktime"
 	"gvisor.dev/gvisor/pkg/sentry/limits"
@@ -93,6 +94,10 @@ type UserCounters struct {
 	uid auth.KUID
 
 	rlimitNProc atomicbitops.Uint64
+
+	// sigPending is the number of pending signals charged to the user, and
+	// is limited by RLIMIT_SIGPENDING.
+	sigPending atomicbitops.Uint64
 }
 
 // incRLimitNProc increments the rlimitNProc counter.
@@ -114,6 +119,22 @@ func (uc *UserCounters) decRLimitNProc() {
 	uc.rlimitNProc.Add(^uint64(0))
 }
 
+// incSigPending increments the sigPending counter if doing so would not cause
+// it to exceed limit, or unconditionally if override is true. It returns true
+// if the counter was incremented.
+func (uc *UserCounters) incSigPending(limit uint64, override bool) bool {
+	if n := uc.sigPending.Add(1); n > limit && !override {
+		uc.sigPending.Add(^uint64(0))
+		return false
+	}
+	return true
+}
+
+// decSigPending decrements the sigPending counter.
+func (uc *UserCounters) decSigPending() {
+	uc.sigPending.Add(^uint64(0))
+}
+
 // CgroupMount contains the cgroup mount. These mounts are created for the root
 // container by default and are stored in the kernel.
 //
@@ -177,11 +198,13 @@ type Kernel struct {
 	rootUserNamespace    *auth.UserNamespace
 	rootNetworkNamespace *inet.Namespace
 	applicationCores     uint
+	numaNodes            uint
 	useHostCores         bool
 	extraAuxv            []arch.AuxEntry
 	vdso                 *loader.VDSO
 	vdsoParams           *VDSOParamPage
 	rootUTSNamespace     *UTSNamespace
+	rootTimeNamespace    *TimeNamespace
 	rootIPCNamespace     *IPCNamespace
 
 	// futexes is the "root" futex.Manager, from which all others are forked.
@@ -243,6 +266,23 @@ type Kernel struct {
 	// need to support timers.
 	cpuClock atomicbitops.Int64
 
+	// Task CPU time (see Task.accountCPUTime) is measured by the "task CPU
+	// clock", which advances at the rate of wall time scaled down by
+	// applicationCores/cpuShareRunning if there are more running tasks than
+	// applicationCores, so that running tasks are collectively charged at
+	// most applicationCores CPUs' worth of time. cpuShareBase was the value
+	// of the task CPU clock when gohacks.Nanotime() was cpuShareStamp, and
+	// cpuShareRunning is the number of running tasks sampled at that time.
+	// The zero value of all three is a task CPU clock equal to
+	// gohacks.Nanotime().
+	//
+	// These fields are only mutated by the CPU clock ticker, within a
+	// cpuShareSeq writer critical section.
+	cpuShareSeq     sync.SeqCount      `state:"nosave"`
+	cpuShareBase    atomicbitops.Int64 `state:"nosave"`
+	cpuShareStamp   atomicbitops.Int64 `state:"nosave"`
+	cpuShareRunning atomicbitops.Int64 `state:"nosave"`
+
 	// uniqueID is used to generate unique identifiers.
 	//
 	// uniqueID is mutable, and is accessed using atomic memory operations.
@@ -336,6 +376,10 @@ type Kernel struct {
 	// YAMAPtraceScope is the current level of YAMA ptrace restrictions.
 	YAMAPtraceScope atomicbitops.Int32
 
+	// RandomizeVASpace is the level of address space layout randomization
+	// applied to new task images; see loader.LoadArgs.RandomizeVASpace.
+	RandomizeVASpace atomicbitops.Int32
+
 	// cgroupRegistry contains the set of active cgroup controllers on the
 	// system. It is controller by cgroupfs. Nil if cgroupfs is unavailable on
 	// the system.
@@ -356,6 +400,18 @@ type Kernel struct {
 	// used by processes.
 	MaxFDLimit atomicbitops.Int32
 
+	// PipeMaxSize is the maximum size in bytes to which a process without
+	// CAP_SYS_RESOURCE may grow a pipe using F_SETPIPE_SZ. It corresponds to
+	// /proc/sys/fs/pipe-max-size.
+	PipeMaxSize atomicbitops.Uint32
+
+	// PipeUserPagesSoft and PipeUserPagesHard limit the number of pages by
+	// which processes without CAP_SYS_RESOURCE or CAP_SYS_ADMIN may grow
+	// pipes beyond their default size. Zero means no limit. They correspond
+	// to /proc/sys/fs/pipe-user-pages-soft and pipe-user-pages-hard.
+	PipeUserPagesSoft atomicbitops.Int32
+	PipeUserPagesHard atomicbitops.Int32
+
 	// devGofers maps containers (using its name) to its device gofer client.
 	devGofers   map[string]*devutil.GoferClient `state:"nosave"`
 	devGofersMu sync.Mutex                      `state:"nosave"`
@@ -367,6 +423,23 @@ type Kernel struct {
 	// It's protected by extMu.
 	containerNames map[string]string
 
+	// containerVDSOs maps container IDs to the VDSO mapped into processes
+	// executed in that container, overriding vdso. A nil VDSO means that no
+	// VDSO is mapped. Containers without an entry use vdso.
+	//
+	// It's protected by extMu.
+	containerVDSOs map[string]*loader.VDSO
+
+	// deadlineBandwidthMu protects deadlineBandwidth.
+	deadlineBandwidthMu sync.Mutex `state:"nosave"`
+
+	// deadlineBandwidth is the CPU bandwidth reserved by SCHED_DEADLINE
+	// tasks. See SchedAttr.
+	deadlineBandwidth uint64
+
+	// lastCoreSchedCookie is the last cookie returned by NewCoreSchedCookie.
+	lastCoreSchedCookie atomicbitops.Uint64
+
 	// checkpointMu is used to protect the checkpointing related fields below.
 	checkpointMu sync.Mutex `state:"nosave"`
 
@@ -389,6 +462,25 @@ type Kernel struct {
 	// UnixSocketOpts stores configuration options for management of unix sockets.
 	UnixSocketOpts transport.UnixSocketOpts
 
+	// TextSegmentOpts controls how executable segments are mapped by execve.
+	TextSegmentOpts loader.TextSegmentOpts
+
+	// HostThreadNames is true if host threads executing application code
+	// should be named after the task they run. See Task.hostThreadName.
+	HostThreadNames bool
+
+	// HostCoreSchedCookies is true if host threads executing application code
+	// should be core tagged after the core scheduling cookie of the task they
+	// run, so that the host enforces core scheduling cookies. See
+	// Task.hostCoreSchedCookie. It is not saved, since it is configured by
+	// the sandbox being restored into.
+	HostCoreSchedCookies bool `state:"nosave"`
+
+	// FlightRecorder is true if each task records its recent scheduling,
+	// syscall and fault events in memory. See FlightRecords. It is not saved,
+	// since it is configured by the sandbox being restored into.
+	FlightRecorder bool `state:"nosave"`
+
 	// SaveRestoreExecConfig stores configuration options for the save/restore
 	// exec binary.
 	SaveRestoreExecConfig *SaveRestoreExecConfig
@@ -396,6 +488,12 @@ type Kernel struct {
 	// NvidiaDriverVersion is the NVIDIA driver version configured for this
 	// sandbox.
 	NvidiaDriverVersion nvconf.DriverVersion
+
+	// OnPanic, if not nil, is called on a panicking sentry goroutine that
+	// defers ReportPanic, with the Kernel, the panicking task (or nil if the
+	// goroutine is not a task goroutine) and the value passed to panic, before
+	// the panic resumes.
+	OnPanic func(k *Kernel, t *Task, reason any) `state:"nosave"`
 }
 
 // InitKernelArgs holds arguments to Init.
@@ -419,6 +517,11 @@ type InitKernelArgs struct {
 	// most significant bit in cpu_possible_mask + 1.
 	ApplicationCores uint
 
+	// NUMANodes is the number of NUMA nodes visible to sandboxed
+	// applications. CPUs are split evenly among nodes. If NUMANodes is 0, a
+	// single node is visible.
+	NUMANodes uint
+
 	// If UseHostCores is true, Task.CPU() returns the task goroutine's CPU
 	// instead of a virtualized CPU number, and Task.CopyToCPUMask() is a
 	// no-op. If ApplicationCores is less than hostcpu.MaxPossibleCPU(), it
@@ -451,6 +554,21 @@ type InitKernelArgs struct {
 
 	// UnixSocketOpts contains configuration options for unix sockets.
 	UnixSocketOpts transport.UnixSocketOpts
+
+	// TextSegmentOpts controls how executable segments are mapped by execve.
+	TextSegmentOpts loader.TextSegmentOpts
+
+	// HostThreadNames is true if host threads executing application code
+	// should be named after the task they run.
+	HostThreadNames bool
+
+	// HostCoreSchedCookies is true if host threads executing application code
+	// should be core tagged after the core scheduling cookie of the task they
+	// run. The Sentry must be core tagged.
+	HostCoreSchedCookies bool
+
+	// FlightRecorder enables per-task in-memory event recording.
+	FlightRecorder bool
 }
 
 // Init initialize the Kernel with no tasks.
@@ -497,6 +615,14 @@ func (k *Kernel) Init(args InitKernelArgs) error {
 			k.applicationCores = minAppCores
 		}
 	}
+	k.numaNodes = max(args.NUMANodes, 1)
+	if k.numaNodes > MaxNUMANodes {
+		return fmt.Errorf("args.NUMANodes is %d, must be at most %d", args.NUMANodes, MaxNUMANodes)
+	}
+	if k.numaNodes > k.applicationCores {
+		log.Warningf("Reducing NUMA nodes from %d to the number of CPUs, %d", k.numaNodes, k.applicationCores)
+		k.numaNodes = k.applicationCores
+	}
 	k.extraAuxv = args.ExtraAuxv
 	k.vdso = args.Vdso
 	k.vdsoParams = args.VdsoParams
@@ -504,11 +630,14 @@ func (k *Kernel) Init(args InitKernelArgs) error {
 	k.netlinkPorts = port.New()
 	k.ptraceExceptions = make(map[*Task]*Task)
 	k.YAMAPtraceScope = atomicbitops.FromInt32(linux.YAMA_SCOPE_RELATIONAL)
+	k.RandomizeVASpace = atomicbitops.FromInt32(loader.RandomizeFull)
 	k.userCountersMap = make(map[auth.KUID]*UserCounters)
 	if args.MaxFDLimit == 0 {
 		args.MaxFDLimit = MaxFdLimit
 	}
 	k.MaxFDLimit.Store(args.MaxFDLimit)
+	k.PipeMaxSize.Store(pipe.MaximumPipeSize)
+	k.PipeUserPagesSoft.Store(pipe.DefaultUserPagesSoft)
 	k.containerNames = make(map[string]string)
 	k.CheckpointWait.k = k
 
@@ -539,6 +668,8 @@ func (k *Kernel) Init(args InitKernelArgs) error {
 	k.rootNetworkNamespace.SetInode(nsfs.NewInode(ctx, k.nsfsMount, k.rootNetworkNamespace))
 	k.rootIPCNamespace.SetInode(nsfs.NewInode(ctx, k.nsfsMount, k.rootIPCNamespace))
 	k.rootUTSNamespace.SetInode(nsfs.NewInode(ctx, k.nsfsMount, k.rootUTSNamespace))
+	k.rootTimeNamespace = newRootTimeNamespace(k, args.RootUserNamespace)
+	k.rootTimeNamespace.SetInode(nsfs.NewInode(ctx, k.nsfsMount, k.rootTimeNamespace))
 
 	args.RootPIDNamespace.InitInode(ctx, k)
 
@@ -576,6 +707,10 @@ func (k *Kernel) Init(args InitKernelArgs) error {
 
 	k.cgroupRegistry = newCgroupRegistry()
 	k.UnixSocketOpts = args.UnixSocketOpts
+	k.TextSegmentOpts = args.TextSegmentOpts
+	k.HostThreadNames = args.HostThreadNames
+	k.HostCoreSchedCookies = args.HostCoreSchedCookies
+	k.FlightRecorder = args.FlightRecorder
 	return nil
 }
 
@@ -757,6 +892,11 @@ func (k *Kernel) invalidateUnsavableMappings(ctx context.Context) error {
 	return nil
 }
 
+// NetworkHandoff replaces the sockets of the saved root network stack saved
+// with sockets of another network stack, e.g. when the network mode changes
+// across save/restore.
+type NetworkHandoff func(ctx context.Context, saved inet.Stack) error
+
 // LoadFrom returns a new Kernel loaded from args.
 func (k *Kernel) LoadFrom(ctx context.Context, r io.Reader, loadMFs bool, timeReady chan struct{}, net inet.Stack, clocks sentrytime.Clocks, vfsOpts *vfs.CompleteRestoreOptions, saveRestoreNet bool) error {
 	loadStart := time.Now()
@@ -822,10 +962,21 @@ func (k *Kernel) LoadFrom(ctx context.Context, r io.Reader, loadMFs bool, timeRe
 		if s == nil {
 			panic("inet.Stack cannot be nil when netstack s/r is enabled")
 		}
-		if net != nil {
-			s.ReplaceConfig(net)
+		if handoff, ok := ctx.Value(CtxNetworkHandoff).(NetworkHandoff); ok {
+			// Move the sockets of the saved stack, which is never resumed,
+			// to net.
+			if err := handoff(ctx, s); err != nil {
+				return fmt.Errorf("handing off sockets of the saved network stack: %w", err)
+			}
+			k.rootNetworkNamespace.ResetStack()
+			k.rootNetworkNamespace.RestoreRootStack(net)
+			net.Restore()
+		} else {
+			if net != nil {
+				s.ReplaceConfig(net)
+			}
+			s.Restore()
 		}
-		s.Restore()
 	} else if net != nil {
 		net.Restore()
 	}
@@ -926,6 +1077,9 @@ type CreateProcessArgs struct {
 
 	// TTY is the optional controlling TTY to associate with this process.
 	TTY *TTY
+
+	// NoNewPrivs is the initial value of the process' no_new_privs bit.
+	NoNewPrivs bool
 }
 
 // NewContext returns a context.Context that represents the task that will be
@@ -1108,9 +1262,10 @@ func (k *Kernel) CreateProcess(args CreateProcessArgs) (*ThreadGroup, ThreadID,
 		Argv:                args.Argv,
 		Envv:                args.Envv,
 		Features:            k.featureSet,
+		TextSegments:        k.TextSegmentOpts,
 	}
 
-	image, se := k.LoadTaskImage(ctx, loadArgs)
+	image, se := k.LoadTaskImage(ctx, args.ContainerID, loadArgs)
 	if se != nil {
 		return nil, 0, errors.New(se.String())
 	}
@@ -1131,16 +1286,19 @@ func (k *Kernel) CreateProcess(args CreateProcessArgs) (*ThreadGroup, ThreadID,
 		AllowedCPUMask:   sched.NewFullCPUSet(k.applicationCores),
 		UTSNamespace:     args.UTSNamespace,
 		IPCNamespace:     args.IPCNamespace,
+		TimeNamespace:    k.rootTimeNamespace,
 		MountNamespace:   mntns,
 		ContainerID:      args.ContainerID,
 		InitialCgroups:   args.InitialCgroups,
 		UserCounters:     k.GetUserCounters(args.Credentials.RealKUID),
 		Origin:           args.Origin,
+		NoNewPrivs:       args.NoNewPrivs,
 		// A task with no parent starts out with no session keyring.
 		SessionKeyring: nil,
 	}
 	config.UTSNamespace.IncRef()
 	config.IPCNamespace.IncRef()
+	config.TimeNamespace.IncRef()
 	config.NetworkNamespace.IncRef()
 	t, err := k.tasks.NewTask(ctx, config)
 	if err != nil {
@@ -1513,6 +1671,11 @@ func (k *Kernel) RootUserNamespace() *auth.UserNamespace {
 	return k.rootUserNamespace
 }
 
+// RootTimeNamespace returns the root TimeNamespace.
+func (k *Kernel) RootTimeNamespace() *TimeNamespace {
+	return k.rootTimeNamespace
+}
+
 // RootUTSNamespace returns the root UTSNamespace.
 func (k *Kernel) RootUTSNamespace() *UTSNamespace {
 	return k.rootUTSNamespace
@@ -1907,10 +2070,12 @@ func (k *Kernel) Release() {
 	k.socketMount.DecRef(ctx)
 	k.vfs.Release(ctx)
 	k.timekeeper.Destroy()
+	k.releaseContainerVDSOs(ctx)
 	k.vdso.Release(ctx)
 	k.RootNetworkNamespace().DecRef(ctx)
 	k.rootIPCNamespace.DecRef(ctx)
 	k.rootUTSNamespace.DecRef(ctx)
+	k.rootTimeNamespace.DecRef(ctx)
 	k.cleaupDevGofers()
 	k.mf.Destroy()
 	k.RootPIDNamespace().DecRef(ctx)
@@ -2078,10 +2243,25 @@ func (k *Kernel) RestoreContainerMapping(containerIDs map[string]string) {
 	defer k.extMu.Unlock()
 
 	// Delete mapping from old session and replace with new values.
+	oldNames := k.containerNames
 	k.containerNames = make(map[string]string)
 	for name, cid := range containerIDs {
 		k.containerNames[cid] = name
 	}
+
+	// Container VDSOs are keyed by container ID, so they must move along.
+	if len(k.containerVDSOs) == 0 {
+		return
+	}
+	oldVDSOs := k.containerVDSOs
+	k.containerVDSOs = make(map[string]*loader.VDSO)
+	for oldCID, v := range oldVDSOs {
+		if cid, ok := containerIDs[oldNames[oldCID]]; ok {
+			k.containerVDSOs[cid] = v
+		} else if v != nil {
+			v.Release(k.SupervisorContext())
+		}
+	}
 }
 
 // ContainerName returns the container name for a given container ID.
@@ -2090,3 +2270,67 @@ func (k *Kernel) ContainerName(cid string) string {
 	defer k.extMu.Unlock()
 	return k.containerNames[cid]
 }
+
+// SetContainerVDSO makes processes subsequently executed in the container
+// with ID cid map binary, an alternate prebuilt VDSO image, instead of the
+// kernel's VDSO. If binary is nil, no VDSO is mapped at all.
+//
+// binary must read timekeeping data from the same parameter page layout as
+// the kernel's VDSO.
+func (k *Kernel) SetContainerVDSO(cid string, binary []byte) error {
+	var v *loader.VDSO
+	if binary != nil {
+		var err error
+		if v, err = loader.NewVDSO(k.mf, binary, k.vdso); err != nil {
+			return fmt.Errorf("invalid VDSO for container %q: %w", cid, err)
+		}
+	}
+	k.extMu.Lock()
+	defer k.extMu.Unlock()
+	if k.containerVDSOs == nil {
+		k.containerVDSOs = make(map[string]*loader.VDSO)
+	}
+	if old := k.containerVDSOs[cid]; old != nil {
+		old.Release(k.SupervisorContext())
+	}
+	k.containerVDSOs[cid] = v
+	return nil
+}
+
+// ResetContainerVDSO undoes SetContainerVDSO for the container with ID cid.
+func (k *Kernel) ResetContainerVDSO(cid string) {
+	k.extMu.Lock()
+	defer k.extMu.Unlock()
+	if v := k.containerVDSOs[cid]; v != nil {
+		v.Release(k.SupervisorContext())
+	}
+	delete(k.containerVDSOs, cid)
+}
+
+// containerVDSO returns the VDSO to map into processes executed in the
+// container with ID cid, which may be nil. If it is not nil, the caller must
+// call VDSO.Release when done with it, since SetContainerVDSO and
+// ResetContainerVDSO may concurrently release the container's reference.
+func (k *Kernel) containerVDSO(cid string) *loader.VDSO {
+	k.extMu.Lock()
+	defer k.extMu.Unlock()
+	v, ok := k.containerVDSOs[cid]
+	if !ok {
+		v = k.vdso
+	}
+	if v != nil {
+		v.IncRef()
+	}
+	return v
+}
+
+func (k *Kernel) releaseContainerVDSOs(ctx context.Context) {
+	k.extMu.Lock()
+	defer k.extMu.Unlock()
+	for _, v := range k.containerVDSOs {
+		if v != nil {
+			v.Release(ctx)
+		}
+	}
+	k.containerVDSOs = nil
+}
@@ -271,17 +271,17 @@ type AsyncMFLoader struct {
 // If timeline is provided, it will be used to track async page loading.
 // It takes ownership of the timeline, and will end it when done loading all
 // pages.
-func NewAsyncMFLoader(pagesMetadata, pagesFile *fd.FD, mainMF *pgalloc.MemoryFile, timeline *timing.Timeline) *AsyncMFLoader {
+func NewAsyncMFLoader(pagesMetadata, pagesFile *fd.FD, mainMF *pgalloc.MemoryFile, pageChecksums bool, timeline *timing.Timeline) *AsyncMFLoader {
 	mfl := &AsyncMFLoader{
 		privateMFsChan: make(chan map[string]*pgalloc.MemoryFile, 1),
 	}
 	mfl.metadataWg.Add(1)
 	mfl.loadWg.Add(1)
-	go mfl.backgroundGoroutine(pagesMetadata, pagesFile, mainMF, timeline)
+	go mfl.backgroundGoroutine(pagesMetadata, pagesFile, mainMF, pageChecksums, timeline)
 	return mfl
 }
 
-func (mfl *AsyncMFLoader) backgroundGoroutine(pagesMetadataFD, pagesFileFD *fd.FD, mainMF *pgalloc.MemoryFile, timeline *timing.Timeline) {
+func (mfl *AsyncMFLoader) backgroundGoroutine(pagesMetadataFD, pagesFileFD *fd.FD, mainMF *pgalloc.MemoryFile, pageChecksums bool, timeline *timing.Timeline) {
 	defer timeline.End()
 	defer pagesMetadataFD.Close()
 	defer pagesFileFD.Close()
@@ -298,7 +298,8 @@ func (mfl *AsyncMFLoader) backgroundGoroutine(pagesMetadataFD, pagesFileFD *fd.F
 	pagesMetadata := bufio.NewReader(pagesMetadataFD)
 
 	opts := pgalloc.LoadOpts{
-		PagesFile: pagesFileFD,
+		PagesFile:     pagesFileFD,
+		PageChecksums: pageChecksums,
 		OnAsyncPageLoadStart: func(mf *pgalloc.MemoryFile) {
 			mfl.loadWg.Add(1)
 			log.Infof("Starting async page load for %p", mf)
@@ -0,0 +1,75 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"runtime"
+
+	"gvisor.dev/gvisor/pkg/sync"
+)
+
+// MembarrierSyncCore implements MEMBARRIER_CMD_PRIVATE_EXPEDITED_SYNC_CORE. It
+// interrupts every other task that shares t's MemoryManager and is executing
+// application code, and returns once all of them have left application code.
+// This is the equivalent of the IPIs that Linux sends to CPUs running the mm:
+// when an interrupted task switches back to application code, it observes any
+// code modifications made by t before the call.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) MembarrierSyncCore() {
+	type runningTask struct {
+		t     *Task
+		epoch sync.SeqCountEpoch
+	}
+	var running []runningTask
+	mm := t.MemoryManager()
+	t.k.tasks.mu.RLock()
+	t.k.tasks.forEachTaskLocked(func(ot *Task) {
+		if ot == t {
+			return
+		}
+		ot.mu.Lock()
+		sameMM := ot.MemoryManager() == mm
+		ot.mu.Unlock()
+		if !sameMM {
+			return
+		}
+		for {
+			epoch := ot.gostateSeq.BeginRead()
+			state := ot.TaskGoroutineState()
+			if !ot.gostateSeq.ReadOk(epoch) {
+				continue
+			}
+			if state == TaskGoroutineRunningApp {
+				// Unlike ot.interrupt(), this doesn't interrupt
+				// blocking operations: Switch returns
+				// ErrContextInterrupt, after which ot simply re-enters
+				// application code.
+				ot.p.Interrupt()
+				running = append(running, runningTask{ot, epoch})
+			}
+			break
+		}
+	})
+	t.k.tasks.mu.RUnlock()
+
+	// Wait for every interrupted task to leave application code, which
+	// updates its gostateSeq.
+	for _, rt := range running {
+		for rt.t.gostateSeq.ReadOk(rt.epoch) {
+			runtime.Gosched()
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/nsfs"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/pipe"
+	"gvisor.dev/gvisor/pkg/sentry/loader"
+	"gvisor.dev/gvisor/pkg/sentry/usage"
+	"gvisor.dev/gvisor/pkg/state"
+)
+
+// Migrations allow checkpoints saved by earlier releases to be restored; see
+// pkg/state/README.md. Added fields whose zero value matches the behavior of
+// the earlier release need not be initialized.
+func init() {
+	// The following migrations were added in state version 3.
+	state.RegisterMigrations((*Kernel)(nil), state.Migration{
+		Added: []string{"numaNodes", "rootTimeNamespace", "RandomizeVASpace", "PipeMaxSize", "PipeUserPagesSoft", "PipeUserPagesHard", "containerVDSOs", "deadlineBandwidth", "lastCoreSchedCookie", "TextSegmentOpts", "HostThreadNames"},
+		Migrate: func(obj any, src state.MigrationSource) {
+			k := obj.(*Kernel)
+			k.numaNodes = 1
+			k.RandomizeVASpace.Store(loader.RandomizeFull)
+			k.PipeMaxSize.Store(pipe.MaximumPipeSize)
+			k.PipeUserPagesSoft.Store(pipe.DefaultUserPagesSoft)
+			src.AfterLoad(func() { k.migrateRootTimeNamespace() })
+		},
+	})
+	state.RegisterMigrations((*UserCounters)(nil), state.Migration{
+		Added: []string{"sigPending"},
+	})
+	state.RegisterMigrations((*pendingSignal)(nil), state.Migration{
+		Added: []string{"uc"},
+	})
+	state.RegisterMigrations((*savedPendingSignal)(nil), state.Migration{
+		Added: []string{"uc"},
+	})
+	state.RegisterMigrations((*taskSeccomp)(nil), state.Migration{
+		Added: []string{"notifiers"},
+	})
+	state.RegisterMigrations((*Task)(nil), state.Migration{
+		Added: []string{"appCPUTime", "sysCPUTime", "interruptCount", "minorFaults", "majorFaults", "delayUsage", "blkIOStart", "timens", "childTimens", "semUndoList", "personality", "noNewPrivs", "syscallUserDispatch", "schedAttr", "coreSchedCookie", "ioFlusher", "rseqLen"},
+		Migrate: func(obj any, src state.MigrationSource) {
+			t := obj.(*Task)
+			t.delayUsage = &usage.Delay{}
+			// Only rseq structures of the original size could be registered.
+			if t.rseqAddr != 0 {
+				t.rseqLen = linux.SizeOfRSeq
+			}
+			// Exited tasks have released their namespaces.
+			if t.mountNamespace != nil {
+				src.AfterLoad(func() {
+					ns := t.k.migrateRootTimeNamespace()
+					ns.IncRef()
+					t.timens = ns
+				})
+			}
+		},
+	})
+	state.RegisterMigrations((*TaskImage)(nil), state.Migration{
+		Added: []string{"unreadable", "setID", "auxvStart"},
+	})
+	state.RegisterMigrations((*ThreadGroup)(nil), state.Migration{
+		Added: []string{"exitedAppCPUTime", "exitedSysCPUTime", "interruptCount", "minorFaults", "majorFaults", "delayUsage"},
+		Migrate: func(obj any, src state.MigrationSource) {
+			obj.(*ThreadGroup).delayUsage = &usage.Delay{}
+		},
+	})
+	state.RegisterMigrations((*PIDNamespace)(nil), state.Migration{
+		Added: []string{"level", "exitingInit"},
+		Migrate: func(obj any, src state.MigrationSource) {
+			ns := obj.(*PIDNamespace)
+			// Ancestors may not be loaded yet, so their levels can't be used.
+			src.AfterLoad(func() {
+				for p := ns.parent; p != nil; p = p.parent {
+					ns.level++
+				}
+			})
+		},
+	})
+	state.RegisterMigrations((*threadGroupNode)(nil), state.Migration{
+		Added: []string{"exitQueue"},
+	})
+	state.RegisterMigrations((*Timekeeper)(nil), state.Migration{
+		Added: []string{"saveDilationRate"},
+	})
+	state.RegisterMigrations((*VDSOParamPage)(nil), state.Migration{
+		Added: []string{"timeNamespaces"},
+	})
+}
+
+// migrateRootTimeNamespace returns the root time namespace, creating it if k
+// was saved before time namespaces were supported.
+func (k *Kernel) migrateRootTimeNamespace() *TimeNamespace {
+	if k.rootTimeNamespace == nil {
+		k.rootTimeNamespace = newRootTimeNamespace(k, k.rootUserNamespace)
+		k.rootTimeNamespace.SetInode(nsfs.NewInode(k.SupervisorContext(), k.nsfsMount, k.rootTimeNamespace))
+	}
+	return k.rootTimeNamespace
+}
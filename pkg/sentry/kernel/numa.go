@@ -0,0 +1,61 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+// MaxNUMANodes is the maximum number of NUMA nodes visible to sandboxed
+// applications, such that a node mask fits in a single uint64.
+const MaxNUMANodes = 64
+
+// The NUMA topology is emulated: application memory isn't placed on any
+// particular host node, and CPU i belongs to node i*NUMANodes/ApplicationCores,
+// such that each node has a contiguous range of CPUs.
+
+// NUMANodes returns the number of NUMA nodes visible to sandboxed
+// applications. The set of node IDs is [0, NUMANodes).
+func (k *Kernel) NUMANodes() uint {
+	// Kernels saved before NUMA nodes were configurable have a single node.
+	return max(k.numaNodes, 1)
+}
+
+// NUMANodeMask returns the node mask containing all NUMA nodes.
+func (k *Kernel) NUMANodeMask() uint64 {
+	nodes := k.NUMANodes()
+	if nodes == MaxNUMANodes {
+		return ^uint64(0)
+	}
+	return (uint64(1) << nodes) - 1
+}
+
+// CPUNode returns the NUMA node of the given CPU.
+func (k *Kernel) CPUNode(cpu int32) int32 {
+	if cpu < 0 || uint(cpu) >= k.applicationCores {
+		// With UseHostCores, CPU numbers come from the host.
+		return 0
+	}
+	return int32(uint(cpu) * k.NUMANodes() / k.applicationCores)
+}
+
+// NUMANodeCPUs returns the range of CPUs [start, end) of the given NUMA node.
+//
+// Preconditions: node < k.NUMANodes().
+func (k *Kernel) NUMANodeCPUs(node uint) (start, end uint) {
+	nodes := k.NUMANodes()
+	// CPU c belongs to node floor(c*nodes/cores), so the first CPU of node n
+	// is ceil(n*cores/nodes).
+	first := func(n uint) uint {
+		return (n*k.applicationCores + nodes - 1) / nodes
+	}
+	return first(node), first(node + 1)
+}
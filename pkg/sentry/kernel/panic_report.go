@@ -0,0 +1,193 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/log"
+)
+
+// PanicReport describes the state of the sandbox when a sentry goroutine
+// panics. It is intended to be serialized as JSON and attached to bug
+// reports.
+type PanicReport struct {
+	// Time is the host time at which the report was created.
+	Time time.Time `json:"time"`
+
+	// Reason is the value passed to panic.
+	Reason string `json:"reason"`
+
+	// Stack is the stack of the panicking goroutine.
+	Stack string `json:"stack"`
+
+	// Task describes the panicking task, if the panicking goroutine is a task
+	// goroutine.
+	Task *PanicTask `json:"task,omitempty"`
+
+	// Syscall describes the system call that the panicking task was
+	// executing, if any.
+	Syscall *PanicSyscall `json:"syscall,omitempty"`
+
+	// Tasks describes all tasks in the sandbox. It is empty if the task set
+	// could not be locked.
+	Tasks []PanicTask `json:"tasks,omitempty"`
+}
+
+// PanicTask describes a task in a PanicReport.
+type PanicTask struct {
+	// TID is the task's thread ID in the root PID namespace.
+	TID ThreadID `json:"tid"`
+
+	// TGID is the task's thread group ID in the root PID namespace.
+	TGID ThreadID `json:"tgid"`
+
+	// Name is the task's name.
+	Name string `json:"name"`
+
+	// ContainerID is the ID of the container the task belongs to.
+	ContainerID string `json:"container_id,omitempty"`
+
+	// State is the state of the task goroutine.
+	State string `json:"state"`
+}
+
+// PanicSyscall describes a system call in a PanicReport.
+type PanicSyscall struct {
+	// Number is the system call number.
+	Number uintptr `json:"number"`
+
+	// Name is the system call name.
+	Name string `json:"name"`
+
+	// Args are the system call arguments.
+	Args [6]uint64 `json:"args"`
+}
+
+// String implements fmt.Stringer.String.
+func (s TaskGoroutineState) String() string {
+	switch s {
+	case TaskGoroutineNonexistent:
+		return "nonexistent"
+	case TaskGoroutineRunningSys:
+		return "running-sys"
+	case TaskGoroutineRunningApp:
+		return "running-app"
+	case TaskGoroutineBlockedInterruptible:
+		return "blocked-interruptible"
+	case TaskGoroutineBlockedUninterruptible:
+		return "blocked-uninterruptible"
+	case TaskGoroutineStopped:
+		return "stopped"
+	default:
+		return fmt.Sprintf("TaskGoroutineState(%d)", uint32(s))
+	}
+}
+
+// NewPanicReport returns a PanicReport for a panic with the given reason. t
+// is the panicking task, or nil if the panicking goroutine is not a task
+// goroutine.
+//
+// Since the panicking goroutine may hold arbitrary locks, NewPanicReport never
+// blocks on a lock, at the cost of reading some task state racily and omitting
+// the task list if the task set is locked for writing.
+//
+// Preconditions: If t is not nil, the caller must be running on its task
+// goroutine.
+//
+// +checklocksignore
+func (k *Kernel) NewPanicReport(t *Task, reason any) *PanicReport {
+	r := &PanicReport{
+		Time:   time.Now(),
+		Reason: fmt.Sprint(reason),
+		Stack:  string(debug.Stack()),
+	}
+
+	ts := k.tasks
+	locked := ts.mu.TryRLockBypass()
+	if locked {
+		defer ts.mu.RUnlockBypass()
+	}
+
+	if t != nil {
+		pt := t.panicTask(locked)
+		r.Task = &pt
+		if t.TaskGoroutineState() == TaskGoroutineRunningSys {
+			if sysno := t.Arch().SyscallNo(); sysno <= t.SyscallTable().MaxSysno() {
+				s := &PanicSyscall{
+					Number: sysno,
+					Name:   t.SyscallTable().LookupName(sysno),
+				}
+				for i, arg := range t.Arch().SyscallArgs() {
+					s.Args[i] = arg.Uint64()
+				}
+				r.Syscall = s
+			}
+		}
+	}
+
+	if locked {
+		for other := range ts.Root.tids {
+			r.Tasks = append(r.Tasks, other.panicTask(true))
+		}
+	}
+	return r
+}
+
+// ReportPanic passes the value of a panic in progress to k.OnPanic, if set,
+// and then resumes the panic. t is the panicking task, or nil if the calling
+// goroutine is not a task goroutine.
+//
+// ReportPanic must be deferred directly, i.e. "defer k.ReportPanic(t)", so
+// that it can recover the panic.
+func (k *Kernel) ReportPanic(t *Task) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if k.OnPanic != nil {
+		func() {
+			// A panic while reporting must not replace the original one.
+			defer func() {
+				if r2 := recover(); r2 != nil {
+					log.Warningf("Panic while reporting panic %v: %v", r, r2)
+				}
+			}()
+			k.OnPanic(k, t, r)
+		}()
+	}
+	panic(r)
+}
+
+// panicTask returns a PanicTask describing t. Thread IDs are omitted unless
+// tasksLocked is true.
+//
+// +checklocksignore
+func (t *Task) panicTask(tasksLocked bool) PanicTask {
+	pt := PanicTask{
+		// t.mu is not locked since t may be the panicking task.
+		Name:        t.image.Name,
+		ContainerID: t.ContainerID(),
+		State:       t.TaskGoroutineState().String(),
+	}
+	if tasksLocked {
+		root := t.k.tasks.Root
+		pt.TID = root.tids[t]
+		pt.TGID = root.tgids[t.tg]
+	}
+	return pt
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+)
+
+// newPanicTestKernel returns a Kernel with a single task, which is not running.
+func newPanicTestKernel() (*Kernel, *Task) {
+	k := &Kernel{}
+	k.tasks = newTaskSet(NewRootPIDNamespace(auth.NewRootUserNamespace()))
+	tg := &ThreadGroup{}
+	t := &Task{
+		k:           k,
+		tg:          tg,
+		containerID: "container",
+		image:       TaskImage{Name: "task"},
+	}
+	root := k.tasks.Root
+	root.tasks[1] = t
+	root.tids[t] = 1
+	root.tgids[tg] = 1
+	return k, t
+}
+
+func TestNewPanicReport(t *testing.T) {
+	k, task := newPanicTestKernel()
+	want := PanicTask{
+		TID:         1,
+		TGID:        1,
+		Name:        "task",
+		ContainerID: "container",
+		State:       "nonexistent",
+	}
+
+	r := k.NewPanicReport(task, "boom")
+	if r.Reason != "boom" {
+		t.Errorf("got reason %q, want %q", r.Reason, "boom")
+	}
+	if r.Stack == "" {
+		t.Errorf("got empty stack")
+	}
+	if r.Task == nil || *r.Task != want {
+		t.Errorf("got task %+v, want %+v", r.Task, want)
+	}
+	if r.Syscall != nil {
+		t.Errorf("got syscall %+v for a task not in a syscall", r.Syscall)
+	}
+	if len(r.Tasks) != 1 || r.Tasks[0] != want {
+		t.Errorf("got tasks %+v, want [%+v]", r.Tasks, want)
+	}
+}
+
+func TestNewPanicReportNoTask(t *testing.T) {
+	k, _ := newPanicTestKernel()
+	r := k.NewPanicReport(nil, "boom")
+	if r.Task != nil {
+		t.Errorf("got task %+v, want nil", r.Task)
+	}
+	if len(r.Tasks) != 1 {
+		t.Errorf("got %d tasks, want 1", len(r.Tasks))
+	}
+}
+
+// TestNewPanicReportTasksLocked tests that NewPanicReport doesn't block if the
+// task set is locked, as the panicking goroutine may hold the lock.
+func TestNewPanicReportTasksLocked(t *testing.T) {
+	k, task := newPanicTestKernel()
+	k.tasks.mu.Lock()
+	defer k.tasks.mu.Unlock()
+
+	r := k.NewPanicReport(task, "boom")
+	if r.Task == nil || r.Task.Name != "task" || r.Task.TID != 0 {
+		t.Errorf("got task %+v, want name %q without TID", r.Task, "task")
+	}
+	if len(r.Tasks) != 0 {
+		t.Errorf("got tasks %+v, want none", r.Tasks)
+	}
+}
+
+func TestReportPanic(t *testing.T) {
+	k, task := newPanicTestKernel()
+	var (
+		gotTask   *Task
+		gotReason any
+	)
+	k.OnPanic = func(_ *Kernel, t *Task, reason any) {
+		gotTask = t
+		gotReason = reason
+	}
+
+	r := recoverFrom(func() {
+		defer k.ReportPanic(task)
+		panic("boom")
+	})
+	if r != "boom" {
+		t.Errorf("got panic %v, want %q", r, "boom")
+	}
+	if gotTask != task || gotReason != "boom" {
+		t.Errorf("OnPanic got (%p, %v), want (%p, %q)", gotTask, gotReason, task, "boom")
+	}
+
+	// ReportPanic does nothing if there is no panic.
+	gotReason = nil
+	if r := recoverFrom(func() {
+		defer k.ReportPanic(task)
+	}); r != nil {
+		t.Errorf("got panic %v, want none", r)
+	}
+	if gotReason != nil {
+		t.Errorf("OnPanic called without a panic")
+	}
+}
+
+// TestReportPanicInReporter tests that a panic while reporting a panic doesn't
+// replace the original one.
+func TestReportPanicInReporter(t *testing.T) {
+	k, _ := newPanicTestKernel()
+	k.OnPanic = func(*Kernel, *Task, any) {
+		panic("reporter")
+	}
+
+	r := recoverFrom(func() {
+		defer k.ReportPanic(nil)
+		panic("boom")
+	})
+	if r != "boom" {
+		t.Errorf("got panic %v, want %q", r, "boom")
+	}
+}
+
+// recoverFrom runs f and returns the value it panicked with, if any.
+func recoverFrom(f func()) (r any) {
+	defer func() {
+		r = recover()
+	}()
+	f()
+	return nil
+}
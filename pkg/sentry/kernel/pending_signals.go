@@ -28,10 +28,8 @@ const (
 	stdSignalCap = 1
 
 	// rtSignalCap is the maximum number of instances of a given realtime
-	// signal that may be pending.
-	//
-	// TODO(igudger): In Linux, the minimum signal queue size is
-	// RLIMIT_SIGPENDING, which is by default max_threads/2.
+	// signal that may be pending, if the signal is not charged to any user's
+	// RLIMIT_SIGPENDING.
 	rtSignalCap = 32
 )
 
@@ -76,26 +74,63 @@ type pendingSignal struct {
 
 	// If timer is not nil, it is the IntervalTimer which sent this signal.
 	timer *IntervalTimer
+
+	// If uc is not nil, it is the UserCounters whose pending signal count
+	// was incremented for this signal.
+	uc *UserCounters
 }
 
 // enqueue enqueues the given signal. enqueue returns true on success and false
-// on failure (if the given signal's queue is full).
+// on failure (if the given signal's queue is full, or if queueing it would
+// exceed limit).
+//
+// If uc is not nil, the signal is charged to uc's pending signal count, which
+// is limited to limit (RLIMIT_SIGPENDING). If uc is nil, at most rtSignalCap
+// instances of each realtime signal may be queued.
 //
 // Preconditions: info represents a valid signal.
-func (p *pendingSignals) enqueue(info *linux.SignalInfo, timer *IntervalTimer) bool {
+func (p *pendingSignals) enqueue(info *linux.SignalInfo, timer *IntervalTimer, uc *UserCounters, limit uint64) bool {
 	sig := linux.Signal(info.Signo)
 	q := &p.signals[sig.Index()]
 	if sig.IsStandard() {
 		if q.length >= stdSignalCap {
 			return false
 		}
-	} else if q.length >= rtSignalCap {
+	} else if uc == nil && q.length >= rtSignalCap {
+		return false
+	}
+	if uc == nil {
+		p.push(info, timer, nil)
+		return true
+	}
+
+	// Compare Linux's kernel/signal.c:__send_signal_locked(). Standard
+	// signals sent by the kernel or kill(2) may exceed the limit, as may
+	// timer signals, for which Linux preallocates queue entries.
+	override := (sig.IsStandard() && info.Code >= 0) || timer != nil
+	if uc.incSigPending(limit, override) {
+		p.push(info, timer, uc)
+		return true
+	}
+	if sig.IsRealtime() && info.Code != linux.SI_USER {
 		return false
 	}
-	q.pendingSignalList.PushBack(&pendingSignal{SignalInfo: info, timer: timer})
+	// Linux marks the signal pending without queueing its siginfo, such that
+	// kernel/signal.c:collect_signal() reports it as SI_USER.
+	p.push(&linux.SignalInfo{
+		Signo: info.Signo,
+		Code:  linux.SI_USER,
+	}, nil, nil)
+	return true
+}
+
+// push queues the given signal without checking or charging any limits.
+func (p *pendingSignals) push(info *linux.SignalInfo, timer *IntervalTimer, uc *UserCounters) {
+	sig := linux.Signal(info.Signo)
+	q := &p.signals[sig.Index()]
+	q.pendingSignalList.PushBack(&pendingSignal{SignalInfo: info, timer: timer, uc: uc})
 	q.length++
 	p.pendingSet.Store(p.pendingSet.RacyLoad() | uint64(linux.SignalSetOf(sig)))
-	return true
 }
 
 // dequeue dequeues and returns any pending signal not masked by mask. If no
@@ -126,6 +161,9 @@ func (p *pendingSignals) dequeueSpecific(sig linux.Signal) *linux.SignalInfo {
 	}
 	q.pendingSignalList.Remove(ps)
 	q.length--
+	if ps.uc != nil {
+		ps.uc.decSigPending()
+	}
 	if q.length == 0 {
 		p.pendingSet.Store(p.pendingSet.RacyLoad() &^ uint64(linux.SignalSetOf(sig)))
 	}
@@ -142,8 +180,18 @@ func (p *pendingSignals) discardSpecific(sig linux.Signal) {
 		if ps.timer != nil {
 			ps.timer.signalRejectedLocked()
 		}
+		if ps.uc != nil {
+			ps.uc.decSigPending()
+		}
 	}
 	q.pendingSignalList.Reset()
 	q.length = 0
 	p.pendingSet.Store(p.pendingSet.RacyLoad() &^ uint64(linux.SignalSetOf(sig)))
 }
+
+// discardAll causes all pending signals to be discarded.
+func (p *pendingSignals) discardAll() {
+	for set := p.pendingSet.RacyLoad(); set != 0; set &= set - 1 {
+		p.discardSpecific(linux.Signal(bits.TrailingZeros64(set) + 1))
+	}
+}
@@ -24,6 +24,7 @@ import (
 type savedPendingSignal struct {
 	si    *linux.SignalInfo
 	timer *IntervalTimer
+	uc    *UserCounters
 }
 
 // saveSignals is invoked by stateify.
@@ -34,6 +35,7 @@ func (p *pendingSignals) saveSignals() []savedPendingSignal {
 			pending = append(pending, savedPendingSignal{
 				si:    ps.SignalInfo,
 				timer: ps.timer,
+				uc:    ps.uc,
 			})
 		}
 	}
@@ -42,7 +44,9 @@ func (p *pendingSignals) saveSignals() []savedPendingSignal {
 
 // loadSignals is invoked by stateify.
 func (p *pendingSignals) loadSignals(_ context.Context, pending []savedPendingSignal) {
+	// The pending signal counts in UserCounters are saved separately, so
+	// don't charge them again.
 	for _, sps := range pending {
-		p.enqueue(sps.si, sps.timer)
+		p.push(sps.si, sps.timer, sps.uc)
 	}
 }
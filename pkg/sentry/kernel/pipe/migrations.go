@@ -0,0 +1,26 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipe
+
+import "gvisor.dev/gvisor/pkg/state"
+
+// Migrations allow checkpoints saved by earlier releases to be restored; see
+// pkg/state/README.md.
+func init() {
+	// The following migrations were added in state version 3.
+	state.RegisterMigrations((*Pipe)(nil), state.Migration{
+		Added: []string{"enlarged"},
+	})
+}
@@ -18,6 +18,7 @@ package pipe
 import (
 	"fmt"
 	"io"
+	"math/bits"
 
 	"golang.org/x/sys/unix"
 	"gvisor.dev/gvisor/pkg/atomicbitops"
@@ -32,20 +33,46 @@ const (
 	// It corresponds to fs/pipe.c:pipe_min_size.
 	MinimumPipeSize = hostarch.PageSize
 
-	// MaximumPipeSize is a hard limit on the maximum size of a pipe.
-	// It corresponds to fs/pipe.c:pipe_max_size.
+	// MaximumPipeSize is the default limit on the size of a pipe that may be
+	// set by a process without CAP_SYS_RESOURCE. It corresponds to the
+	// default value of fs/pipe.c:pipe_max_size, which may be changed via
+	// /proc/sys/fs/pipe-max-size.
 	MaximumPipeSize = 1048576
 
+	// maxPipeSizeHard is a hard limit on the maximum size of a pipe,
+	// regardless of privilege. It corresponds to the limit in
+	// fs/pipe.c:round_pipe_size().
+	maxPipeSizeHard = 1 << 31
+
 	// DefaultPipeSize is the system-wide default size of a pipe in bytes.
 	// It corresponds to pipe_fs_i.h:PIPE_DEF_BUFFERS.
 	DefaultPipeSize = 16 * hostarch.PageSize
 
+	// DefaultUserPagesSoft is the default limit on the number of pages by
+	// which processes without CAP_SYS_RESOURCE or CAP_SYS_ADMIN may grow
+	// pipes beyond DefaultPipeSize. It corresponds to the default value of
+	// fs/pipe.c:pipe_user_pages_soft, which may be changed via
+	// /proc/sys/fs/pipe-user-pages-soft.
+	DefaultUserPagesSoft = 16 * 1024 // PIPE_DEF_BUFFERS * INR_OPEN_CUR
+
+	// maxEnlargedBytes is a hard limit on the total number of bytes by which
+	// pipes may be grown beyond DefaultPipeSize, regardless of privilege.
+	// Unlike in Linux, pipe buffers are allocated from the sentry's heap
+	// rather than from application memory, so they must be bounded
+	// independently of the application's memory limits.
+	maxEnlargedBytes = 256 << 20
+
 	// atomicIOBytes is the maximum number of bytes that the pipe will
 	// guarantee atomic reads or writes atomically.
 	// It corresponds to limits.h:PIPE_BUF.
 	atomicIOBytes = 4096
 )
 
+// enlargedBytes is the total number of bytes by which the sizes of all pipes
+// exceed DefaultPipeSize, i.e. the sum of Pipe.enlarged. It is bounded by
+// maxEnlargedBytes.
+var enlargedBytes atomicbitops.Int64
+
 // waitReaders is a wrapper around Pipe.
 //
 // This is used for ctx.Block operations that require the synchronization of
@@ -150,6 +177,12 @@ type Pipe struct {
 	// This is protected by mu.
 	max int64
 
+	// enlarged is the number of bytes by which max exceeds DefaultPipeSize
+	// due to SetFifoSize, and which is accounted in enlargedBytes.
+	//
+	// This is protected by mu.
+	enlarged int64
+
 	// hadWriter indicates if this pipe ever had a writer. Note that this
 	// does not necessarily indicate there is *currently* a writer, just
 	// that there has been a writer at some point since the pipe was
@@ -284,17 +317,7 @@ func (p *Pipe) writeLocked(count int64, f func(safemem.BlockSeq) (uint64, error)
 		if newCap > p.max {
 			newCap = p.max
 		}
-		newBuf := make([]byte, newCap)
-		// Copy the old buffer's contents to the beginning of the new one.
-		safemem.CopySeq(
-			safemem.BlockSeqOf(safemem.BlockFromSafeSlice(newBuf)),
-			p.bufBlockSeq.DropFirst64(uint64(p.off)).TakeFirst64(uint64(p.size)))
-		// Switch to the new buffer.
-		p.buf = newBuf
-		p.bufBlocks[0] = safemem.BlockFromSafeSlice(newBuf)
-		p.bufBlocks[1] = p.bufBlocks[0]
-		p.bufBlockSeq = safemem.BlockSeqFromSlice(p.bufBlocks[:])
-		p.off = 0
+		p.reallocBufLocked(newCap)
 	}
 
 	// Prepare the view of the space to be written.
@@ -320,6 +343,34 @@ func (p *Pipe) writeLocked(count int64, f func(safemem.BlockSeq) (uint64, error)
 	return done, nil
 }
 
+// reallocBufLocked replaces p.buf with a new buffer of length newCap,
+// preserving its contents.
+//
+// Preconditions:
+//   - p.mu must be locked.
+//   - newCap >= p.size.
+func (p *Pipe) reallocBufLocked(newCap int64) {
+	newBuf := make([]byte, newCap)
+	// Copy the old buffer's contents to the beginning of the new one.
+	safemem.CopySeq(
+		safemem.BlockSeqOf(safemem.BlockFromSafeSlice(newBuf)),
+		p.bufBlockSeq.DropFirst64(uint64(p.off)).TakeFirst64(uint64(p.size)))
+	// Switch to the new buffer.
+	p.setBufLocked(newBuf)
+	p.off = 0
+}
+
+// setBufLocked sets p.buf to buf and updates the safemem views of it. It does
+// not change p.off or p.size.
+//
+// Preconditions: p.mu must be locked.
+func (p *Pipe) setBufLocked(buf []byte) {
+	p.buf = buf
+	p.bufBlocks[0] = safemem.BlockFromSafeSlice(buf)
+	p.bufBlocks[1] = p.bufBlocks[0]
+	p.bufBlockSeq = safemem.BlockSeqFromSlice(p.bufBlocks[:])
+}
+
 // rOpen signals a new reader of the pipe.
 func (p *Pipe) rOpen() {
 	p.readers.Add(1)
@@ -443,22 +494,83 @@ func (p *Pipe) queuedLocked() int64 {
 	return p.size
 }
 
-// SetFifoSize implements fs.FifoSizer.SetFifoSize.
-func (p *Pipe) SetFifoSize(size int64) (int64, error) {
-	if size < 0 {
-		return 0, linuxerr.EINVAL
+// RoundPipeSize returns size rounded up to a valid pipe size, or 0 if size
+// exceeds the maximum size of any pipe. It corresponds to
+// fs/pipe.c:round_pipe_size().
+func RoundPipeSize(size uint64) uint64 {
+	if size > maxPipeSizeHard {
+		return 0
 	}
 	if size < MinimumPipeSize {
-		size = MinimumPipeSize // Per spec.
+		return MinimumPipeSize
 	}
-	if size > MaximumPipeSize {
-		return 0, linuxerr.EPERM
+	return 1 << bits.Len64(size-1)
+}
+
+// SizeLimits are the limits applied by Pipe.SetFifoSize.
+type SizeLimits struct {
+	// MaxSize is the largest size to which the pipe may be grown.
+	MaxSize int64
+
+	// MaxEnlargedBytes, if non-zero, is the largest total number of bytes by
+	// which the sizes of all pipes may exceed DefaultPipeSize. It corresponds
+	// to Linux's per-user pipe_user_pages_soft and pipe_user_pages_hard,
+	// though it is applied to all pipes in the sandbox.
+	MaxEnlargedBytes int64
+}
+
+// SetFifoSize sets the maximum size of the pipe to size, rounded up by
+// RoundPipeSize, and returns the new size. If this would increase the size of
+// the pipe beyond limits, SetFifoSize fails with EPERM.
+func (p *Pipe) SetFifoSize(size uint64, limits SizeLimits) (int64, error) {
+	size = RoundPipeSize(size)
+	if size == 0 {
+		return 0, linuxerr.EINVAL
 	}
+	newMax := int64(size)
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	if size < p.size {
+	if newMax > p.max && newMax > limits.MaxSize {
+		return 0, linuxerr.EPERM
+	}
+	if newMax < p.size {
 		return 0, linuxerr.EBUSY
 	}
-	p.max = size
-	return size, nil
+	newEnlarged := max(newMax-DefaultPipeSize, 0)
+	if delta := newEnlarged - p.enlarged; delta > 0 {
+		total := enlargedBytes.Add(delta)
+		if total > maxEnlargedBytes || (limits.MaxEnlargedBytes != 0 && total > limits.MaxEnlargedBytes) {
+			enlargedBytes.Add(-delta)
+			return 0, linuxerr.EPERM
+		}
+	} else {
+		enlargedBytes.Add(delta)
+	}
+	p.enlarged = newEnlarged
+	p.max = newMax
+	// Don't keep a buffer larger than the pipe can use.
+	if int64(len(p.buf)) > newMax {
+		p.reallocBufLocked(newMax)
+	}
+	return newMax, nil
+}
+
+// releaseIfUnused frees the pipe's buffer and discards its contents if it has
+// no readers or writers, as Linux frees a pipe once its last file is released
+// (fs/pipe.c:put_pipe_info()). The pipe's size is reset to DefaultPipeSize if
+// it was enlarged.
+func (p *Pipe) releaseIfUnused() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.HasReaders() || p.HasWriters() {
+		return
+	}
+	p.setBufLocked(nil)
+	p.off = 0
+	p.size = 0
+	if p.enlarged != 0 {
+		enlargedBytes.Add(-p.enlarged)
+		p.enlarged = 0
+		p.max = DefaultPipeSize
+	}
 }
@@ -147,3 +147,99 @@ func TestPipeWriteUntilEnd(t *testing.T) {
 		}
 	})
 }
+
+func TestRoundPipeSize(t *testing.T) {
+	for _, test := range []struct {
+		size uint64
+		want uint64
+	}{
+		{0, MinimumPipeSize},
+		{1, MinimumPipeSize},
+		{MinimumPipeSize, MinimumPipeSize},
+		{MinimumPipeSize + 1, 2 * MinimumPipeSize},
+		{3*MinimumPipeSize + 1, 4 * MinimumPipeSize},
+		{maxPipeSizeHard, maxPipeSizeHard},
+		{maxPipeSizeHard + 1, 0},
+	} {
+		if got := RoundPipeSize(test.size); got != test.want {
+			t.Errorf("RoundPipeSize(%d): got %d, wanted %d", test.size, got, test.want)
+		}
+	}
+}
+
+func TestSetFifoSize(t *testing.T) {
+	p := NewPipe(false /* isNamed */, DefaultPipeSize)
+	defer p.releaseIfUnused()
+	if _, err := p.SetFifoSize(2*MaximumPipeSize, SizeLimits{MaxSize: MaximumPipeSize}); !linuxerr.Equals(linuxerr.EPERM, err) {
+		t.Errorf("SetFifoSize above limit: got %v, wanted EPERM", err)
+	}
+	if n, err := p.SetFifoSize(2*MaximumPipeSize, SizeLimits{MaxSize: 2 * MaximumPipeSize}); n != 2*MaximumPipeSize || err != nil {
+		t.Errorf("SetFifoSize within limit: got (%d, %v), wanted (%d, nil)", n, err, 2*MaximumPipeSize)
+	}
+	// Shrinking is always permitted, even if the size remains above limit.
+	if n, err := p.SetFifoSize(MaximumPipeSize+1, SizeLimits{}); n != 2*MaximumPipeSize || err != nil {
+		t.Errorf("SetFifoSize to current size: got (%d, %v), wanted (%d, nil)", n, err, 2*MaximumPipeSize)
+	}
+	if _, err := p.SetFifoSize(maxPipeSizeHard+1, SizeLimits{MaxSize: maxPipeSizeHard}); !linuxerr.Equals(linuxerr.EINVAL, err) {
+		t.Errorf("SetFifoSize above hard limit: got %v, wanted EINVAL", err)
+	}
+	// Pipes can't be grown beyond maxEnlargedBytes, regardless of privilege.
+	if _, err := p.SetFifoSize(maxPipeSizeHard, SizeLimits{MaxSize: maxPipeSizeHard}); !linuxerr.Equals(linuxerr.EPERM, err) {
+		t.Errorf("SetFifoSize above maxEnlargedBytes: got %v, wanted EPERM", err)
+	}
+}
+
+func TestSetFifoSizeAccounting(t *testing.T) {
+	p := NewPipe(false /* isNamed */, DefaultPipeSize)
+	before := enlargedBytes.Load()
+	limits := SizeLimits{MaxSize: MaximumPipeSize, MaxEnlargedBytes: before + 2*DefaultPipeSize}
+	if _, err := p.SetFifoSize(4*DefaultPipeSize, limits); !linuxerr.Equals(linuxerr.EPERM, err) {
+		t.Errorf("SetFifoSize above MaxEnlargedBytes: got %v, wanted EPERM", err)
+	}
+	if got := enlargedBytes.Load(); got != before {
+		t.Errorf("enlargedBytes after failed SetFifoSize: got %d, wanted %d", got, before)
+	}
+	if n, err := p.SetFifoSize(2*DefaultPipeSize, limits); n != 2*DefaultPipeSize || err != nil {
+		t.Fatalf("SetFifoSize within MaxEnlargedBytes: got (%d, %v), wanted (%d, nil)", n, err, 2*DefaultPipeSize)
+	}
+	if got, want := enlargedBytes.Load(), before+DefaultPipeSize; got != want {
+		t.Errorf("enlargedBytes after SetFifoSize: got %d, wanted %d", got, want)
+	}
+
+	// Releasing the pipe releases its enlargement.
+	p.releaseIfUnused()
+	if got := enlargedBytes.Load(); got != before {
+		t.Errorf("enlargedBytes after release: got %d, wanted %d", got, before)
+	}
+	if p.max != DefaultPipeSize {
+		t.Errorf("pipe size after release: got %d, wanted %d", p.max, DefaultPipeSize)
+	}
+}
+
+func TestSpliceMovesBuffer(t *testing.T) {
+	runTest(t, 65536, func(ctx context.Context, r1, w1 *vfs.FileDescription) {
+		runTest(t, 65536, func(ctx context.Context, r2, w2 *vfs.FileDescription) {
+			msg := []byte("here's some bytes")
+			if _, err := w1.Write(ctx, usermem.BytesIOSequence(msg), vfs.WriteOptions{}); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			src := r1.Impl().(*VFSPipeFD)
+			dst := w2.Impl().(*VFSPipeFD)
+			srcBuf := src.pipe.buf
+			if n, err := Splice(ctx, dst, src, 1024); n != int64(len(msg)) || err != nil {
+				t.Fatalf("Splice: got (%d, %v), wanted (%d, nil)", n, err, len(msg))
+			}
+			if &dst.pipe.buf[0] != &srcBuf[0] {
+				t.Errorf("Splice into an empty pipe copied data instead of moving the buffer")
+			}
+			buf := make([]byte, len(msg))
+			n, err := r2.Read(ctx, usermem.BytesIOSequence(buf), vfs.ReadOptions{})
+			if n != int64(len(msg)) || err != nil || !bytes.Equal(buf, msg) {
+				t.Fatalf("Read: got (%d, %v) %q, wanted (%d, nil) %q", n, err, buf, len(msg), msg)
+			}
+			if src.pipe.queued() != 0 {
+				t.Errorf("Source pipe not empty after Splice: %d bytes queued", src.pipe.queued())
+			}
+		})
+	})
+}
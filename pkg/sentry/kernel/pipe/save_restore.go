@@ -25,4 +25,5 @@ func (p *Pipe) afterLoad(context.Context) {
 	p.bufBlocks[0] = safemem.BlockFromSafeSlice(p.buf)
 	p.bufBlocks[1] = p.bufBlocks[0]
 	p.bufBlockSeq = safemem.BlockSeqFromSlice(p.bufBlocks[:])
+	enlargedBytes.Add(p.enlarged)
 }
@@ -190,6 +190,7 @@ func (fd *VFSPipeFD) Release(context.Context) {
 		panic("invalid pipe flags: must be readable, writable, or both")
 	}
 
+	fd.pipe.releaseIfUnused()
 	fd.pipe.queue.Notify(event)
 }
 
@@ -254,9 +255,10 @@ func (fd *VFSPipeFD) PipeSize() int64 {
 	return fd.pipe.max
 }
 
-// SetPipeSize implements fcntl(F_SETPIPE_SZ).
-func (fd *VFSPipeFD) SetPipeSize(size int64) (int64, error) {
-	return fd.pipe.SetFifoSize(size)
+// SetPipeSize implements fcntl(F_SETPIPE_SZ). limits are the limits on the
+// size that the caller may grow the pipe to.
+func (fd *VFSPipeFD) SetPipeSize(size uint64, limits SizeLimits) (int64, error) {
+	return fd.pipe.SetFifoSize(size, limits)
 }
 
 // SpliceToNonPipe performs a splice operation from fd to a non-pipe file.
@@ -467,15 +469,21 @@ func spliceOrTee(ctx context.Context, dst, src *VFSPipeFD, count int64, removeFr
 	}
 
 	firstLocked, secondLocked := lockTwoPipes(dst.pipe, src.pipe)
-	n, err := dst.pipe.writeLocked(count, func(dsts safemem.BlockSeq) (uint64, error) {
-		n, err := src.pipe.peekLocked(0, int64(dsts.NumBytes()), func(srcs safemem.BlockSeq) (uint64, error) {
-			return safemem.CopySeq(dsts, srcs)
+	var n int64
+	var err error
+	if removeFromSrc && canMoveBufLocked(dst.pipe, src.pipe, count) {
+		n = moveBufLocked(dst.pipe, src.pipe)
+	} else {
+		n, err = dst.pipe.writeLocked(count, func(dsts safemem.BlockSeq) (uint64, error) {
+			n, err := src.pipe.peekLocked(0, int64(dsts.NumBytes()), func(srcs safemem.BlockSeq) (uint64, error) {
+				return safemem.CopySeq(dsts, srcs)
+			})
+			if n > 0 && removeFromSrc {
+				src.pipe.consumeLocked(n)
+			}
+			return uint64(n), err
 		})
-		if n > 0 && removeFromSrc {
-			src.pipe.consumeLocked(n)
-		}
-		return uint64(n), err
-	})
+	}
 	secondLocked.mu.NestedUnlock(pipeLockPipe)
 	firstLocked.mu.Unlock()
 
@@ -487,3 +495,32 @@ func spliceOrTee(ctx context.Context, dst, src *VFSPipeFD, count int64, removeFr
 	}
 	return n, err
 }
+
+// canMoveBufLocked returns true if splicing count bytes from src to dst would
+// move all of the data in src into an empty dst, in which case the data can
+// be moved by exchanging buffers rather than by copying. Buffers are only
+// exchanged if each fits within the size of the pipe receiving it, so that no
+// pipe holds a buffer larger than its size.
+//
+// Preconditions: dst.mu and src.mu must be locked.
+func canMoveBufLocked(dst, src *Pipe, count int64) bool {
+	return dst.HasReaders() && dst.size == 0 && src.size > 0 && src.size <= count && int64(len(src.buf)) <= dst.max && int64(len(dst.buf)) <= src.max
+}
+
+// moveBufLocked moves all data in src to dst by exchanging their buffers, and
+// returns the number of bytes moved.
+//
+// Preconditions:
+//   - dst.mu and src.mu must be locked.
+//   - canMoveBufLocked(dst, src, count) for some count.
+func moveBufLocked(dst, src *Pipe) int64 {
+	dstBuf, srcBuf := dst.buf, src.buf
+	n := src.size
+	dst.setBufLocked(srcBuf)
+	dst.off = src.off
+	dst.size = n
+	src.setBufLocked(dstBuf)
+	src.off = 0
+	src.size = 0
+	return n
+}
@@ -822,7 +822,7 @@ func (t *Task) ptraceClone(kind ptraceCloneKind, child *Task, args *linux.CloneA
 			} else {
 				child.pendingSignals.enqueue(&linux.SignalInfo{
 					Signo: int32(linux.SIGSTOP),
-				}, nil)
+				}, nil, nil, 0)
 			}
 			// The child will self-interrupt() when its task goroutine starts
 			// running, so we don't have to.
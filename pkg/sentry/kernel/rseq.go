@@ -58,27 +58,30 @@ func (t *Task) RSeqAvailable() bool {
 // Preconditions: The caller must be running on the task goroutine.
 func (t *Task) SetRSeq(addr hostarch.Addr, length, signature uint32) error {
 	if t.rseqAddr != 0 {
-		if t.rseqAddr != addr {
+		if t.rseqAddr != addr || t.rseqLen != length {
 			return linuxerr.EINVAL
 		}
 		if t.rseqSignature != signature {
-			return linuxerr.EINVAL
+			return linuxerr.EPERM
 		}
 		return linuxerr.EBUSY
 	}
 
-	// rseq must be aligned and correctly sized.
+	// rseq must be aligned and at least the original size. Larger
+	// structures are accepted for the extensible rseq ABI; fields beyond
+	// linux.RSeqFeatureSize are left to userspace.
 	if addr&(linux.AlignOfRSeq-1) != 0 {
 		return linuxerr.EINVAL
 	}
-	if length != linux.SizeOfRSeq {
+	if length < linux.SizeOfRSeq {
 		return linuxerr.EINVAL
 	}
-	if _, ok := t.MemoryManager().CheckIORange(addr, linux.SizeOfRSeq); !ok {
+	if _, ok := t.MemoryManager().CheckIORange(addr, int64(length)); !ok {
 		return linuxerr.EFAULT
 	}
 
 	t.rseqAddr = addr
+	t.rseqLen = length
 	t.rseqSignature = signature
 
 	// Initialize the CPUID.
@@ -87,6 +90,7 @@ func (t *Task) SetRSeq(addr hostarch.Addr, length, signature uint32) error {
 	// would cause SIGSEGV.
 	if err := t.rseqUpdateCPU(); err != nil {
 		t.rseqAddr = 0
+		t.rseqLen = 0
 		t.rseqSignature = 0
 
 		t.Debugf("Failed to copy CPU to %#x for rseq: %v", t.rseqAddr, err)
@@ -108,7 +112,7 @@ func (t *Task) ClearRSeq(addr hostarch.Addr, length, signature uint32) error {
 	if t.rseqAddr != addr {
 		return linuxerr.EINVAL
 	}
-	if length != linux.SizeOfRSeq {
+	if length != t.rseqLen {
 		return linuxerr.EINVAL
 	}
 	if t.rseqSignature != signature {
@@ -120,6 +124,7 @@ func (t *Task) ClearRSeq(addr hostarch.Addr, length, signature uint32) error {
 	}
 
 	t.rseqAddr = 0
+	t.rseqLen = 0
 	t.rseqSignature = 0
 
 	if t.oldRSeqCPUAddr == 0 {
@@ -242,7 +247,15 @@ func (t *Task) rseqCopyOutCPU() error {
 	// N.B. This write is not atomic, but since this occurs on the task
 	// goroutine then as long as userspace uses a single-instruction read
 	// it can't see an invalid value.
-	_, err := t.CopyOutBytes(t.rseqAddr, buf)
+	if _, err := t.CopyOutBytes(t.rseqAddr, buf); err != nil {
+		return err
+	}
+
+	// CPU numbers are unique among the threads running at any given time, so
+	// they double as concurrency IDs.
+	hostarch.ByteOrder.PutUint32(buf, uint32(t.k.CPUNode(t.rseqCPU))) // NodeID
+	hostarch.ByteOrder.PutUint32(buf[4:], uint32(t.rseqCPU))          // MMCID
+	_, err := t.CopyOutBytes(t.rseqAddr+linux.OffsetOfRSeqNodeID, buf)
 	return err
 }
 
@@ -257,7 +270,13 @@ func (t *Task) rseqClearCPU() error {
 	// N.B. This write is not atomic, but since this occurs on the task
 	// goroutine then as long as userspace uses a single-instruction read
 	// it can't see an invalid value.
-	_, err := t.CopyOutBytes(t.rseqAddr, buf)
+	if _, err := t.CopyOutBytes(t.rseqAddr, buf); err != nil {
+		return err
+	}
+
+	hostarch.ByteOrder.PutUint32(buf, 0)     // NodeID
+	hostarch.ByteOrder.PutUint32(buf[4:], 0) // MMCID
+	_, err := t.CopyOutBytes(t.rseqAddr+linux.OffsetOfRSeqNodeID, buf)
 	return err
 }
 
@@ -363,26 +382,58 @@ func (t *Task) rseqAddrInterrupt() {
 		return
 	}
 
-	// Clear the critical section address.
-	//
-	// NOTE(b/143949567): We don't support any rseq flags, so we always
-	// restart if we are in the critical section, and thus *always* clear
-	// critAddrAddr.
-	if _, err := t.MemoryManager().ZeroOut(t, critAddrAddr, int64(t.Arch().Width()), usermem.IOOpts{
-		AddressSpaceActive: true,
-	}); err != nil {
-		t.Debugf("Failed to clear critical section address from %#x for rseq: %v", critAddrAddr, err)
+	// Finally we can actually decide whether or not to restart. Outside of
+	// the critical section, only the critical section address is cleared.
+	if !critRange.Contains(hostarch.Addr(t.Arch().IP())) {
+		t.rseqClearCriticalSection(critAddrAddr)
+		return
+	}
+
+	// The no-restart flags are deprecated, and as in Linux 6.0+ any flag in
+	// the critical section or in rseq itself is rejected, since we always
+	// restart.
+	if cs.Flags != 0 {
+		t.Debugf("Unsupported flags in %+v", cs)
 		t.forceSignal(linux.SIGSEGV, false /* unconditional */)
 		t.SendSignal(SignalInfoPriv(linux.SIGSEGV))
 		return
 	}
-
-	// Finally we can actually decide whether or not to restart.
-	if !critRange.Contains(hostarch.Addr(t.Arch().IP())) {
+	flagsAddr := t.rseqAddr + linux.OffsetOfRSeqFlags
+	buf = t.CopyScratchBuffer(4)
+	if _, err := t.CopyInBytes(flagsAddr, buf); err != nil {
+		t.Debugf("Failed to copy flags from %#x for rseq: %v", flagsAddr, err)
+		t.forceSignal(linux.SIGSEGV, false /* unconditional */)
+		t.SendSignal(SignalInfoPriv(linux.SIGSEGV))
+		return
+	}
+	if flags := hostarch.ByteOrder.Uint32(buf); flags != 0 {
+		t.Debugf("Unsupported rseq flags %#x", flags)
+		t.forceSignal(linux.SIGSEGV, false /* unconditional */)
+		t.SendSignal(SignalInfoPriv(linux.SIGSEGV))
 		return
 	}
 
-	t.Arch().SetIP(uintptr(cs.Abort))
+	if t.rseqClearCriticalSection(critAddrAddr) {
+		t.Arch().SetIP(uintptr(cs.Abort))
+	}
+}
+
+// rseqClearCriticalSection clears the critical section address in rseq at
+// critAddrAddr. It returns false and sends SIGSEGV to t on failure.
+//
+// Preconditions:
+//   - The caller must be running on the task goroutine.
+//   - t's AddressSpace must be active.
+func (t *Task) rseqClearCriticalSection(critAddrAddr hostarch.Addr) bool {
+	if _, err := t.MemoryManager().ZeroOut(t, critAddrAddr, int64(t.Arch().Width()), usermem.IOOpts{
+		AddressSpaceActive: true,
+	}); err != nil {
+		t.Debugf("Failed to clear critical section address from %#x for rseq: %v", critAddrAddr, err)
+		t.forceSignal(linux.SIGSEGV, false /* unconditional */)
+		t.SendSignal(SignalInfoPriv(linux.SIGSEGV))
+		return false
+	}
+	return true
 }
 
 // Preconditions: The caller must be running on the task goroutine.
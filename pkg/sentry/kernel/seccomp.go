@@ -34,6 +34,10 @@ const (
 	// It is used as a sentinel value in `taskSeccompFilters.cache` to indicate
 	// that a specific syscall number is uncachable.
 	uncacheableBPFAction = linux.SECCOMP_RET_ACTION_FULL
+
+	// maxSeccompErrno is the largest errno that SECCOMP_RET_ERRNO may return;
+	// larger values are clamped. This is MAX_ERRNO in Linux.
+	maxSeccompErrno = 4095
 )
 
 // taskSeccomp holds seccomp-related data for a `Task`.
@@ -44,6 +48,10 @@ type taskSeccomp struct {
 	// in the order in which they were installed.
 	filters []bpf.Program
 
+	// notifiers holds, for each filter in filters, the user notification
+	// listener created with it, or nil if there is none.
+	notifiers []*SeccompNotifier
+
 	// cache maps syscall numbers to the action to take for that syscall number.
 	// It is only populated for syscalls where determining this action does not
 	// involve any input data other than the architecture and the syscall
@@ -61,11 +69,46 @@ type taskSeccomp struct {
 func (ts *taskSeccomp) copy() *taskSeccomp {
 	return &taskSeccomp{
 		filters:          append(([]bpf.Program)(nil), ts.filters...),
+		notifiers:        append(([]*SeccompNotifier)(nil), ts.notifiers...),
 		cacheAuditNumber: ts.cacheAuditNumber,
 		cache:            ts.cache,
 	}
 }
 
+// incNotifierUsers adds a user to each of ts' notifiers, for a task whose
+// filters have become ts.
+func (ts *taskSeccomp) incNotifierUsers() {
+	for _, n := range ts.notifiers {
+		if n != nil {
+			n.incUsers()
+		}
+	}
+}
+
+// decNotifierUsers removes a user from each of ts' notifiers, for a task
+// whose filters are no longer ts.
+func (ts *taskSeccomp) decNotifierUsers() {
+	for _, n := range ts.notifiers {
+		if n != nil {
+			n.decUsers()
+		}
+	}
+}
+
+// releaseSeccomp drops t's references on its seccomp filters' notifiers. It
+// is called when t exits.
+func (t *Task) releaseSeccomp() {
+	t.tg.signalHandlers.mu.Lock()
+	defer t.tg.signalHandlers.mu.Unlock()
+	if ts := t.seccomp.Load(); ts != nil {
+		ts.decNotifierUsers()
+		// Keep the filters themselves for /proc/[pid]/status.
+		ts = ts.copy()
+		ts.notifiers = nil
+		t.seccomp.Store(ts)
+	}
+}
+
 // dataAsBPFInput returns a serialized BPF program, only valid on the current task
 // goroutine.
 //
@@ -88,20 +131,39 @@ func seccompSiginfo(t *Task, errno, sysno int32, ip hostarch.Addr) *linux.Signal
 	return si
 }
 
+// seccompActionOnly returns the action of a seccomp filter result as a signed
+// value, such that more restrictive actions compare lower. This is
+// ACTION_ONLY() in Linux's kernel/seccomp.c.
+func seccompActionOnly(ret uint32) int32 {
+	return int32(ret & uint32(linux.SECCOMP_RET_ACTION_FULL))
+}
+
 // checkSeccompSyscall applies the task's seccomp filters before the execution
 // of syscall sysno at instruction pointer ip. (These parameters must be passed
 // in because vsyscalls do not use the values in t.Arch().)
 //
+// If recheckAfterTrace is true, the filters are being rechecked after a
+// PTRACE_EVENT_SECCOMP stop, and SECCOMP_RET_TRACE allows the syscall.
+//
+// checkSeccompSyscall returns one of SECCOMP_RET_ALLOW, SECCOMP_RET_ERRNO,
+// SECCOMP_RET_TRAP, SECCOMP_RET_TRACE, SECCOMP_RET_KILL_THREAD, or
+// SECCOMP_RET_KILL_PROCESS.
+//
 // Preconditions: The caller must be running on the task goroutine.
-func (t *Task) checkSeccompSyscall(sysno int32, args arch.SyscallArguments, ip hostarch.Addr) linux.BPFAction {
-	result := linux.BPFAction(t.evaluateSyscallFilters(sysno, args, ip))
-	action := result & linux.SECCOMP_RET_ACTION
+func (t *Task) checkSeccompSyscall(sysno int32, args arch.SyscallArguments, ip hostarch.Addr, recheckAfterTrace bool) linux.BPFAction {
+	ret, notifier := t.evaluateSyscallFilters(sysno, args, ip)
+	result := linux.BPFAction(ret)
+	action := result & linux.SECCOMP_RET_ACTION_FULL
 	switch action {
 	case linux.SECCOMP_RET_TRAP:
 		// "Results in the kernel sending a SIGSYS signal to the triggering
 		// task without executing the system call. ... The SECCOMP_RET_DATA
 		// portion of the return value will be passed as si_errno." -
 		// Documentation/prctl/seccomp_filter.txt
+		//
+		// Like Linux, the signal is forced: if SIGSYS is blocked or ignored,
+		// it kills the task.
+		t.forceSignal(linux.SIGSYS, false /* unconditional */)
 		t.SendSignal(seccompSiginfo(t, int32(result.Data()), sysno, ip))
 		// "The return value register will contain an arch-dependent value." In
 		// practice, it's ~always the syscall number.
@@ -110,9 +172,20 @@ func (t *Task) checkSeccompSyscall(sysno int32, args arch.SyscallArguments, ip h
 	case linux.SECCOMP_RET_ERRNO:
 		// "Results in the lower 16-bits of the return value being passed to
 		// userland as the errno without executing the system call."
-		t.Arch().SetReturn(-uintptr(result.Data()))
+		// Linux clamps the errno to MAX_ERRNO.
+		errno := uintptr(result.Data())
+		if errno > maxSeccompErrno {
+			errno = maxSeccompErrno
+		}
+		t.Arch().SetReturn(-errno)
 
 	case linux.SECCOMP_RET_TRACE:
+		if recheckAfterTrace {
+			// "Recheck the syscall, since it may have changed." Having
+			// already stopped for the tracer, the syscall is allowed to
+			// proceed. - kernel/seccomp.c:__seccomp_filter()
+			return linux.SECCOMP_RET_ALLOW
+		}
 		// "When returned, this value will cause the kernel to attempt to
 		// notify a ptrace()-based tracer prior to executing the system call.
 		// If there is no tracer present, -ENOSYS is returned to userland and
@@ -124,6 +197,17 @@ func (t *Task) checkSeccompSyscall(sysno int32, args arch.SyscallArguments, ip h
 			return linux.SECCOMP_RET_ERRNO
 		}
 
+	case linux.SECCOMP_RET_USER_NOTIF:
+		// "Forward the system call to an attached user-space supervisor
+		// process to allow that process to decide what to do with the system
+		// call." - seccomp(2)
+		return t.seccompUserNotif(notifier, seccompData(t, sysno, args, ip))
+
+	case linux.SECCOMP_RET_LOG:
+		// "Results in the system call being executed after it is logged."
+		t.Debugf("Syscall %d: allowed and logged by seccomp", sysno)
+		return linux.SECCOMP_RET_ALLOW
+
 	case linux.SECCOMP_RET_ALLOW:
 		// "Results in the system call being executed."
 
@@ -132,29 +216,26 @@ func (t *Task) checkSeccompSyscall(sysno int32, args arch.SyscallArguments, ip h
 		// system call. The exit status of the task will be SIGSYS, not
 		// SIGKILL."
 
+	case linux.SECCOMP_RET_KILL_PROCESS:
+		// "This value results in immediate termination of the process, with a
+		// core dump. The system call is not executed." - seccomp(2)
+
 	default:
-		// consistent with Linux
-		return linux.SECCOMP_RET_KILL_THREAD
+		// "If an action value other than one of the above is specified, then
+		// the filter action is treated as either SECCOMP_RET_KILL_PROCESS
+		// (since Linux 4.14) or SECCOMP_RET_KILL_THREAD (in Linux 4.13 and
+		// earlier)." - seccomp(2)
+		return linux.SECCOMP_RET_KILL_PROCESS
 	}
 	return action
 }
 
-func (t *Task) evaluateSyscallFilters(sysno int32, args arch.SyscallArguments, ip hostarch.Addr) uint32 {
-	ret := uint32(linux.SECCOMP_RET_ALLOW)
-	ts := t.seccomp.Load()
-	if ts == nil {
-		return ret
-	}
-	arch := t.image.st.AuditNumber
-	if arch == ts.cacheAuditNumber && sysno >= 0 && sysno <= sentry.MaxSyscallNum {
-		if cached := ts.cache[sysno]; cached != uncacheableBPFAction {
-			return uint32(cached)
-		}
-	}
-
+// seccompData returns the struct seccomp_data describing syscall sysno at
+// instruction pointer ip.
+func seccompData(t *Task, sysno int32, args arch.SyscallArguments, ip hostarch.Addr) linux.SeccompData {
 	data := linux.SeccompData{
 		Nr:                 sysno,
-		Arch:               arch,
+		Arch:               t.image.st.AuditNumber,
 		InstructionPointer: uint64(ip),
 	}
 	// data.args is []uint64 and args is []arch.SyscallArgument (uintptr), so
@@ -165,6 +246,27 @@ func (t *Task) evaluateSyscallFilters(sysno int32, args arch.SyscallArguments, i
 		}
 		data.Args[i] = arg.Uint64()
 	}
+	return data
+}
+
+// evaluateSyscallFilters returns the result of the task's seccomp filters for
+// syscall sysno and, if the result is SECCOMP_RET_USER_NOTIF, the user
+// notification listener of the filter that returned it (which may be nil).
+func (t *Task) evaluateSyscallFilters(sysno int32, args arch.SyscallArguments, ip hostarch.Addr) (uint32, *SeccompNotifier) {
+	ret := uint32(linux.SECCOMP_RET_ALLOW)
+	match := -1
+	ts := t.seccomp.Load()
+	if ts == nil {
+		return ret, nil
+	}
+	arch := t.image.st.AuditNumber
+	if arch == ts.cacheAuditNumber && sysno >= 0 && sysno <= sentry.MaxSyscallNum {
+		if cached := ts.cache[sysno]; cached != uncacheableBPFAction {
+			return uint32(cached), nil
+		}
+	}
+
+	data := seccompData(t, sysno, args, ip)
 	input := dataAsBPFInput(t, &data)
 
 	// "Every filter successfully installed will be evaluated (in reverse
@@ -186,12 +288,16 @@ func (t *Task) evaluateSyscallFilters(sysno int32, args arch.SyscallArguments, i
 		// "The ordering ensures that a min_t() over composed return values
 		// always selects the least permissive choice." -
 		// include/uapi/linux/seccomp.h
-		if (thisRet & linux.SECCOMP_RET_ACTION) < (ret & linux.SECCOMP_RET_ACTION) {
+		if seccompActionOnly(thisRet) < seccompActionOnly(ret) {
 			ret = thisRet
+			match = i
 		}
 	}
 
-	return ret
+	if match < 0 || match >= len(ts.notifiers) || linux.BPFAction(ret)&linux.SECCOMP_RET_ACTION_FULL != linux.SECCOMP_RET_USER_NOTIF {
+		return ret, nil
+	}
+	return ret, ts.notifiers[match]
 }
 
 // checkFilterCacheability executes `program` on the given `input`, and
@@ -252,10 +358,14 @@ func (ts *taskSeccomp) populateCache(t *Task) {
 				sysnoIsCacheable = false
 				break
 			}
-			if (linux.BPFAction(result) & linux.SECCOMP_RET_ACTION) < (ret & linux.SECCOMP_RET_ACTION) {
+			if seccompActionOnly(result) < seccompActionOnly(uint32(ret)) {
 				ret = linux.BPFAction(result)
 			}
 		}
+		// SECCOMP_RET_USER_NOTIF depends on which filter returned it.
+		if ret&linux.SECCOMP_RET_ACTION_FULL == linux.SECCOMP_RET_USER_NOTIF {
+			sysnoIsCacheable = false
+		}
 		if sysnoIsCacheable {
 			ts.cache[sysno] = ret
 		} else {
@@ -264,10 +374,12 @@ func (ts *taskSeccomp) populateCache(t *Task) {
 	}
 }
 
-// AppendSyscallFilter adds BPF program p as a system call filter.
+// AppendSyscallFilter adds BPF program p as a system call filter. If notifier
+// is not nil, system calls for which p returns SECCOMP_RET_USER_NOTIF are
+// sent to it.
 //
 // Preconditions: The caller must be running on the task goroutine.
-func (t *Task) AppendSyscallFilter(p bpf.Program, syncAll bool) error {
+func (t *Task) AppendSyscallFilter(p bpf.Program, notifier *SeccompNotifier, syncAll bool) error {
 	// While syscallFilters are an atomic.Value we must take the mutex to prevent
 	// our read-copy-update from happening while another task is syncing syscall
 	// filters to us, this keeps the filters in a consistent state.
@@ -280,11 +392,26 @@ func (t *Task) AppendSyscallFilter(p bpf.Program, syncAll bool) error {
 	totalLength := p.Length()
 	newSeccomp := &taskSeccomp{}
 
-	if ts := t.seccomp.Load(); ts != nil {
-		for _, f := range ts.filters {
+	oldSeccomp := t.seccomp.Load()
+	if oldSeccomp != nil {
+		// "Only one listener may be installed in a task's filter chain." -
+		// kernel/seccomp.c:has_duplicate_listener()
+		if notifier != nil {
+			for _, n := range oldSeccomp.notifiers {
+				if n != nil {
+					return linuxerr.EBUSY
+				}
+			}
+		}
+		for _, f := range oldSeccomp.filters {
 			totalLength += f.Length() + 4
 		}
-		newSeccomp.filters = append(newSeccomp.filters, ts.filters...)
+		newSeccomp.filters = append(newSeccomp.filters, oldSeccomp.filters...)
+		newSeccomp.notifiers = append(newSeccomp.notifiers, oldSeccomp.notifiers...)
+		// Filters from before notifiers were tracked have none.
+		for len(newSeccomp.notifiers) < len(newSeccomp.filters) {
+			newSeccomp.notifiers = append(newSeccomp.notifiers, nil)
+		}
 	}
 
 	if totalLength > maxSyscallFilterInstructions {
@@ -292,16 +419,27 @@ func (t *Task) AppendSyscallFilter(p bpf.Program, syncAll bool) error {
 	}
 
 	newSeccomp.filters = append(newSeccomp.filters, p)
+	newSeccomp.notifiers = append(newSeccomp.notifiers, notifier)
 	newSeccomp.populateCache(t)
+	newSeccomp.incNotifierUsers()
 	t.seccomp.Store(newSeccomp)
+	if oldSeccomp != nil {
+		oldSeccomp.decNotifierUsers()
+	}
 
 	if syncAll {
 		// Note: No new privs is always assumed to be set.
 		for ot := t.tg.tasks.Front(); ot != nil; ot = ot.Next() {
-			if ot != t {
-				seccompCopy := newSeccomp.copy()
-				seccompCopy.populateCache(ot)
-				ot.seccomp.Store(seccompCopy)
+			// Exiting tasks have released their filters; like Linux, leave
+			// them alone.
+			if ot == t || ot.ExitState() >= TaskExitInitiated {
+				continue
+			}
+			seccompCopy := newSeccomp.copy()
+			seccompCopy.populateCache(ot)
+			seccompCopy.incNotifierUsers()
+			if ots := ot.seccomp.Swap(seccompCopy); ots != nil {
+				ots.decNotifierUsers()
 			}
 		}
 	}
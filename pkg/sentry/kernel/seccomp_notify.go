@@ -0,0 +1,533 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// seccompNotifState is the state of a seccompNotif.
+type seccompNotifState int
+
+const (
+	// seccompNotifInit indicates that the notification has not been received
+	// by the supervisor.
+	seccompNotifInit seccompNotifState = iota
+
+	// seccompNotifSent indicates that the notification has been received by
+	// the supervisor, which has not yet replied to it.
+	seccompNotifSent
+
+	// seccompNotifReplied indicates that the supervisor has replied to the
+	// notification.
+	seccompNotifReplied
+)
+
+// seccompNotif is a system call awaiting a response from a seccomp
+// supervisor. It is equivalent to struct seccomp_knotif in Linux.
+type seccompNotif struct {
+	// task is the task that made the system call. task is immutable.
+	task *Task
+
+	// id is the notification's cookie. id is immutable.
+	id uint64
+
+	// data describes the system call. data is immutable.
+	data linux.SeccompData
+
+	// The following fields are protected by SeccompNotifier.mu.
+
+	// state is the state of the notification.
+	state seccompNotifState
+
+	// val, errno and flags are the supervisor's reply. errno is a negated
+	// errno, as in struct seccomp_notif_resp. They are only meaningful if
+	// state is seccompNotifReplied.
+	val   int64
+	errno int32
+	flags uint32
+
+	// addfds is the queue of SECCOMP_IOCTL_NOTIF_ADDFD requests that task
+	// has not yet handled.
+	addfds []*seccompAddFD
+
+	// ready is notified when state becomes seccompNotifReplied or a request
+	// is appended to addfds.
+	ready chan struct{}
+}
+
+// wake wakes notif.task.
+//
+// Preconditions: SeccompNotifier.mu must be locked.
+func (notif *seccompNotif) wake() {
+	select {
+	case notif.ready <- struct{}{}:
+	default:
+	}
+}
+
+// seccompAddFD is a SECCOMP_IOCTL_NOTIF_ADDFD request. It is equivalent to
+// struct seccomp_kaddfd in Linux.
+type seccompAddFD struct {
+	// file is the file to install in the target's file descriptor table.
+	file *vfs.FileDescription
+
+	// fd is the file descriptor to install file at if setFD is true.
+	fd    int32
+	setFD bool
+
+	// send is true if the target's system call should return the installed
+	// file descriptor.
+	send bool
+
+	// flags are the flags of the installed file descriptor.
+	flags FDFlags
+
+	// The following fields are protected by SeccompNotifier.mu.
+
+	// completed is true if the request has been handled, in which case ret
+	// and err are its result.
+	completed bool
+	ret       int32
+	err       error
+
+	// done is closed when completed becomes true.
+	done chan struct{}
+}
+
+// complete records the result of a.
+//
+// Preconditions: SeccompNotifier.mu must be locked.
+func (a *seccompAddFD) complete(ret int32, err error) {
+	a.completed = true
+	a.ret = ret
+	a.err = err
+	close(a.done)
+}
+
+// SeccompNotifier is a seccomp user notification listener, created by
+// seccomp(SECCOMP_FILTER_FLAG_NEW_LISTENER) alongside the filter that it
+// serves. System calls for which that filter returns SECCOMP_RET_USER_NOTIF
+// are queued on the SeccompNotifier until a supervisor replies to them. It
+// is equivalent to struct notification in Linux.
+//
+// +stateify savable
+type SeccompNotifier struct {
+	// queue is notified when notifications are queued or received, and when
+	// the filter loses its last user.
+	queue waiter.Queue
+
+	// mu protects the following fields.
+	mu sync.Mutex `state:"nosave"`
+
+	// nextID is the cookie of the next notification.
+	nextID uint64
+
+	// notifs is the list of pending notifications. Tasks waiting for a reply
+	// are interrupted before save, removing their notifications.
+	notifs []*seccompNotif `state:"nosave"`
+
+	// users is the number of tasks whose seccomp filters include the
+	// notifier's filter.
+	users int64
+
+	// released is true if the listener has been closed. Once released,
+	// system calls that would be sent to the notifier fail with ENOSYS.
+	released bool
+}
+
+// NewSeccompNotifier returns a new SeccompNotifier. The notifier starts with
+// no users; it gains them when its filter is installed by
+// Task.AppendSyscallFilter.
+func NewSeccompNotifier() *SeccompNotifier {
+	return &SeccompNotifier{}
+}
+
+// incUsers increments n's user count.
+func (n *SeccompNotifier) incUsers() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.users++
+}
+
+// decUsers decrements n's user count, notifying the listener if no users
+// remain.
+func (n *SeccompNotifier) decUsers() {
+	n.mu.Lock()
+	n.users--
+	users := n.users
+	n.mu.Unlock()
+	if users == 0 {
+		n.queue.Notify(waiter.EventHUp)
+	}
+}
+
+// findLocked returns the pending notification with the given id, or nil if
+// none exists.
+//
+// Preconditions: n.mu must be locked.
+func (n *SeccompNotifier) findLocked(id uint64) *seccompNotif {
+	for _, notif := range n.notifs {
+		if notif.id == id {
+			return notif
+		}
+	}
+	return nil
+}
+
+// removeLocked removes notif from n's pending notifications.
+//
+// Preconditions: n.mu must be locked.
+func (n *SeccompNotifier) removeLocked(notif *seccompNotif) {
+	for i, other := range n.notifs {
+		if other == notif {
+			n.notifs = append(n.notifs[:i], n.notifs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Readiness implements waiter.Waitable.Readiness.
+func (n *SeccompNotifier) Readiness(mask waiter.EventMask) waiter.EventMask {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var ready waiter.EventMask
+	for _, notif := range n.notifs {
+		switch notif.state {
+		case seccompNotifInit:
+			ready |= waiter.ReadableEvents
+		case seccompNotifSent:
+			ready |= waiter.WritableEvents
+		}
+	}
+	if n.users == 0 {
+		ready |= waiter.EventHUp
+	}
+	return mask & ready
+}
+
+// EventRegister implements waiter.Waitable.EventRegister.
+func (n *SeccompNotifier) EventRegister(e *waiter.Entry) error {
+	n.queue.EventRegister(e)
+	return nil
+}
+
+// EventUnregister implements waiter.Waitable.EventUnregister.
+func (n *SeccompNotifier) EventUnregister(e *waiter.Entry) {
+	n.queue.EventUnregister(e)
+}
+
+// Release is called when the listener is closed. Pending and future
+// notifications fail with ENOSYS.
+func (n *SeccompNotifier) Release() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.released = true
+	for _, notif := range n.notifs {
+		if notif.state != seccompNotifReplied {
+			notif.state = seccompNotifReplied
+			notif.val = 0
+			notif.errno = -int32(unix.ENOSYS)
+			notif.flags = 0
+			notif.wake()
+		}
+	}
+}
+
+// Recv implements SECCOMP_IOCTL_NOTIF_RECV. It blocks until a notification
+// that the supervisor has not yet received is available, and returns it.
+// Process IDs are reported in t's PID namespace.
+func (n *SeccompNotifier) Recv(t *Task) (linux.SeccompNotif, error) {
+	e, ch := waiter.NewChannelEntry(waiter.ReadableEvents | waiter.EventHUp)
+	n.EventRegister(&e)
+	defer n.EventUnregister(&e)
+	for {
+		notif, err := n.tryRecv(t)
+		if err != linuxerr.ErrWouldBlock {
+			return notif, err
+		}
+		if err := t.Block(ch); err != nil {
+			return linux.SeccompNotif{}, linuxerr.ConvertIntr(err, linuxerr.EINTR)
+		}
+	}
+}
+
+func (n *SeccompNotifier) tryRecv(t *Task) (linux.SeccompNotif, error) {
+	n.mu.Lock()
+	for _, notif := range n.notifs {
+		if notif.state != seccompNotifInit {
+			continue
+		}
+		notif.state = seccompNotifSent
+		n.mu.Unlock()
+		n.queue.Notify(waiter.WritableEvents)
+		return linux.SeccompNotif{
+			ID:   notif.id,
+			Pid:  int32(t.PIDNamespace().IDOfTask(notif.task)),
+			Data: notif.data,
+		}, nil
+	}
+	users := n.users
+	n.mu.Unlock()
+	if users == 0 {
+		// No task can generate further notifications.
+		return linux.SeccompNotif{}, linuxerr.ENOENT
+	}
+	return linux.SeccompNotif{}, linuxerr.ErrWouldBlock
+}
+
+// Send implements SECCOMP_IOCTL_NOTIF_SEND.
+func (n *SeccompNotifier) Send(resp *linux.SeccompNotifResp) error {
+	if resp.Flags&^linux.SECCOMP_USER_NOTIF_FLAG_CONTINUE != 0 {
+		return linuxerr.EINVAL
+	}
+	if resp.Flags&linux.SECCOMP_USER_NOTIF_FLAG_CONTINUE != 0 && (resp.Error != 0 || resp.Val != 0) {
+		return linuxerr.EINVAL
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	notif := n.findLocked(resp.ID)
+	if notif == nil {
+		return linuxerr.ENOENT
+	}
+	// "Allow exactly one reply." - kernel/seccomp.c:seccomp_notify_send()
+	if notif.state != seccompNotifSent {
+		return linuxerr.EINPROGRESS
+	}
+	notif.state = seccompNotifReplied
+	notif.val = resp.Val
+	notif.errno = resp.Error
+	notif.flags = resp.Flags
+	notif.wake()
+	return nil
+}
+
+// IDValid implements SECCOMP_IOCTL_NOTIF_ID_VALID.
+func (n *SeccompNotifier) IDValid(id uint64) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if notif := n.findLocked(id); notif != nil && notif.state == seccompNotifSent {
+		return nil
+	}
+	return linuxerr.ENOENT
+}
+
+// AddFD implements SECCOMP_IOCTL_NOTIF_ADDFD. It installs t's file
+// descriptor req.Srcfd in the file descriptor table of the task that
+// generated notification req.ID, and returns the file descriptor number in
+// that table.
+func (n *SeccompNotifier) AddFD(t *Task, req *linux.SeccompNotifAddFD) (int32, error) {
+	if req.Flags&^(linux.SECCOMP_ADDFD_FLAG_SETFD|linux.SECCOMP_ADDFD_FLAG_SEND) != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	if req.NewfdFlags&^linux.O_CLOEXEC != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	if req.Newfd != 0 && req.Flags&linux.SECCOMP_ADDFD_FLAG_SETFD == 0 {
+		return 0, linuxerr.EINVAL
+	}
+	file := t.GetFile(int32(req.Srcfd))
+	if file == nil {
+		return 0, linuxerr.EBADF
+	}
+	defer file.DecRef(t)
+	a := &seccompAddFD{
+		file:  file,
+		fd:    int32(req.Newfd),
+		setFD: req.Flags&linux.SECCOMP_ADDFD_FLAG_SETFD != 0,
+		send:  req.Flags&linux.SECCOMP_ADDFD_FLAG_SEND != 0,
+		flags: FDFlags{CloseOnExec: req.NewfdFlags&linux.O_CLOEXEC != 0},
+		done:  make(chan struct{}),
+	}
+
+	n.mu.Lock()
+	notif := n.findLocked(req.ID)
+	if notif == nil {
+		n.mu.Unlock()
+		return 0, linuxerr.ENOENT
+	}
+	if notif.state != seccompNotifSent {
+		n.mu.Unlock()
+		return 0, linuxerr.EINPROGRESS
+	}
+	if a.send {
+		if len(notif.addfds) != 0 {
+			n.mu.Unlock()
+			return 0, linuxerr.EBUSY
+		}
+		// The installed file descriptor is the reply; allow no other.
+		notif.state = seccompNotifReplied
+	}
+	notif.addfds = append(notif.addfds, a)
+	notif.wake()
+	n.mu.Unlock()
+
+	if err := t.Block(a.done); err != nil {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		// The request may have completed while we were being interrupted.
+		if !a.completed {
+			for i, other := range notif.addfds {
+				if other == a {
+					notif.addfds = append(notif.addfds[:i], notif.addfds[i+1:]...)
+					break
+				}
+			}
+			return 0, linuxerr.ERESTARTSYS
+		}
+		return a.ret, a.err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return a.ret, a.err
+}
+
+// enqueue queues a notification for a system call made by t, and returns
+// it. It returns nil if the listener has been closed.
+func (n *SeccompNotifier) enqueue(t *Task, data linux.SeccompData) *seccompNotif {
+	n.mu.Lock()
+	if n.released {
+		n.mu.Unlock()
+		return nil
+	}
+	notif := &seccompNotif{
+		task:  t,
+		id:    n.nextID,
+		data:  data,
+		state: seccompNotifInit,
+		ready: make(chan struct{}, 1),
+	}
+	n.nextID++
+	n.notifs = append(n.notifs, notif)
+	n.mu.Unlock()
+	n.queue.Notify(waiter.ReadableEvents)
+	return notif
+}
+
+// seccompUserNotif implements SECCOMP_RET_USER_NOTIF for the system call
+// described by data, sending it to n and waiting for the supervisor's reply.
+// It returns SECCOMP_RET_ALLOW if the supervisor lets the system call
+// continue, and SECCOMP_RET_ERRNO with the system call's return value set
+// otherwise.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) seccompUserNotif(n *SeccompNotifier, data linux.SeccompData) linux.BPFAction {
+	var notif *seccompNotif
+	if n != nil {
+		notif = n.enqueue(t, data)
+	}
+	if notif == nil {
+		// "If there is no attached supervisor (either because the filter was
+		// not installed with the SECCOMP_FILTER_FLAG_NEW_LISTENER flag or
+		// because the file descriptor was closed), the filter returns ENOSYS"
+		// - seccomp(2)
+		tmp := uintptr(unix.ENOSYS)
+		t.Arch().SetReturn(-tmp)
+		return linux.SECCOMP_RET_ERRNO
+	}
+
+	var (
+		replaced    []*vfs.FileDescription
+		interrupted bool
+	)
+	n.mu.Lock()
+	for notif.state != seccompNotifReplied || len(notif.addfds) != 0 {
+		if len(notif.addfds) == 0 {
+			n.mu.Unlock()
+			err := t.Block(notif.ready)
+			n.mu.Lock()
+			if err != nil {
+				interrupted = true
+				break
+			}
+			continue
+		}
+		a := notif.addfds[0]
+		notif.addfds = notif.addfds[1:]
+		fd, df, err := t.seccompInstallFD(a)
+		if df != nil {
+			replaced = append(replaced, df)
+		}
+		if a.send {
+			notif.val = 0
+			notif.errno = 0
+			notif.flags = 0
+			if err != nil {
+				notif.errno = -int32(ExtractErrno(err, -1))
+			} else {
+				notif.val = int64(fd)
+			}
+		}
+		a.complete(fd, err)
+	}
+	// "If there were any pending addfd calls, clear them out. The process
+	// went away before we got a chance to handle it." -
+	// kernel/seccomp.c:seccomp_do_user_notification()
+	for _, a := range notif.addfds {
+		a.complete(0, linuxerr.ESRCH)
+	}
+	notif.addfds = nil
+	n.removeLocked(notif)
+	n.mu.Unlock()
+
+	// Dropping references on files replaced by SECCOMP_ADDFD_FLAG_SETFD may
+	// release the listener itself, so it must be done without n.mu.
+	for _, df := range replaced {
+		df.DecRef(t)
+	}
+
+	if interrupted {
+		// Like Linux, the system call is restarted, and the filter rechecked,
+		// if the signal handler permits it.
+		t.Arch().SetReturn(uintptr(-ExtractErrno(linuxerr.ERESTARTSYS, -1)))
+		t.haveSyscallReturn = true
+		return linux.SECCOMP_RET_ERRNO
+	}
+	if notif.flags&linux.SECCOMP_USER_NOTIF_FLAG_CONTINUE != 0 {
+		return linux.SECCOMP_RET_ALLOW
+	}
+	if notif.errno != 0 {
+		t.Arch().SetReturn(uintptr(int64(notif.errno)))
+	} else {
+		t.Arch().SetReturn(uintptr(notif.val))
+	}
+	return linux.SECCOMP_RET_ERRNO
+}
+
+// seccompInstallFD installs the file of SECCOMP_IOCTL_NOTIF_ADDFD request a
+// in t's file descriptor table. It returns the file descriptor number and,
+// if a replaced an existing file descriptor, the replaced file, on which
+// the caller must drop a reference.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) seccompInstallFD(a *seccompAddFD) (int32, *vfs.FileDescription, error) {
+	if a.setFD {
+		df, err := t.NewFDAt(a.fd, a.file, a.flags)
+		if err != nil {
+			return 0, nil, err
+		}
+		return a.fd, df, nil
+	}
+	fd, err := t.NewFDFrom(0, a.file, a.flags)
+	if err != nil {
+		return 0, nil, err
+	}
+	return fd, nil, nil
+}
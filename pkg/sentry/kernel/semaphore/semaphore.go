@@ -79,6 +79,9 @@ type Set struct {
 	// dead is set to true when the set is removed and can't be reached anymore.
 	// All waiters must wake up and fail when set is dead.
 	dead bool
+
+	// undos holds the adjustments of all UndoLists for this set.
+	undos []*undo
 }
 
 // sem represents a single semaphore from a set.
@@ -403,7 +406,11 @@ func (s *Set) SetVal(ctx context.Context, num int32, val int16, creds *auth.Cred
 		return linuxerr.ERANGE
 	}
 
-	// TODO(gvisor.dev/issue/137): Clear undo entries in all processes.
+	// "When a semaphore value is changed via SETVAL, the corresponding semadj
+	// value is cleared in all processes." - semctl(2)
+	for _, u := range s.undos {
+		u.adj[num] = 0
+	}
 	sem.value = val
 	sem.pid = pid
 	s.changeTime = ktime.NowFromContext(ctx)
@@ -434,10 +441,11 @@ func (s *Set) SetValAll(ctx context.Context, vals []uint16, creds *auth.Credenti
 		return linuxerr.EACCES
 	}
 
+	for _, u := range s.undos {
+		clear(u.adj)
+	}
 	for i, val := range vals {
 		sem := &s.sems[i]
-
-		// TODO(gvisor.dev/issue/137): Clear undo entries in all processes.
 		sem.value = int16(val)
 		sem.pid = pid
 		sem.wakeWaiters()
@@ -538,7 +546,9 @@ func (s *Set) CountNegativeWaiters(num int32, creds *auth.Credentials) (uint16,
 //
 // On failure, it may return an error (retries are hopeless) or it may return
 // a channel that can be waited on before attempting again.
-func (s *Set) ExecuteOps(ctx context.Context, ops []linux.Sembuf, creds *auth.Credentials, pid int32) (chan struct{}, int32, error) {
+//
+// undoList must be non-nil if any operation specifies SEM_UNDO.
+func (s *Set) ExecuteOps(ctx context.Context, ops []linux.Sembuf, creds *auth.Credentials, pid int32, undoList *UndoList) (chan struct{}, int32, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -549,6 +559,7 @@ func (s *Set) ExecuteOps(ctx context.Context, ops []linux.Sembuf, creds *auth.Cr
 
 	// Validate the operations.
 	readOnly := true
+	needUndo := false
 	for _, op := range ops {
 		if s.findSem(int32(op.SemNum)) == nil {
 			return nil, 0, linuxerr.EFBIG
@@ -556,6 +567,9 @@ func (s *Set) ExecuteOps(ctx context.Context, ops []linux.Sembuf, creds *auth.Cr
 		if op.SemOp != 0 {
 			readOnly = false
 		}
+		if op.SemFlg&linux.SEM_UNDO != 0 {
+			needUndo = true
+		}
 	}
 
 	ats := vfs.MayRead
@@ -566,25 +580,52 @@ func (s *Set) ExecuteOps(ctx context.Context, ops []linux.Sembuf, creds *auth.Cr
 		return nil, 0, linuxerr.EACCES
 	}
 
-	ch, num, err := s.executeOps(ctx, ops, pid)
+	var u *undo
+	if needUndo {
+		u = s.findOrCreateUndoLocked(undoList)
+	}
+	ch, num, err := s.executeOps(ctx, ops, pid, u)
 	if err != nil {
 		return nil, 0, err
 	}
 	return ch, num, nil
 }
 
-func (s *Set) executeOps(ctx context.Context, ops []linux.Sembuf, pid int32) (chan struct{}, int32, error) {
-	// Changes to semaphores go to this slice temporarily until they all succeed.
-	tmpVals := make([]int16, len(s.sems))
-	for i := range s.sems {
-		tmpVals[i] = s.sems[i].value
+// pendingSem holds the tentative state of a semaphore operated on by
+// executeOps.
+type pendingSem struct {
+	num   uint16
+	value int16
+	adj   int32
+}
+
+// Preconditions: u must be non-nil if any operation in ops specifies
+// SEM_UNDO.
+func (s *Set) executeOps(ctx context.Context, ops []linux.Sembuf, pid int32, u *undo) (chan struct{}, int32, error) {
+	// Changes to semaphores go to this slice temporarily until they all
+	// succeed. Only semaphores that are operated on are tracked, so that the
+	// cost of an operation doesn't depend on the size of the set.
+	pending := make([]pendingSem, 0, len(ops))
+	find := func(num uint16) *pendingSem {
+		for i := range pending {
+			if pending[i].num == num {
+				return &pending[i]
+			}
+		}
+		ps := pendingSem{num: num, value: s.sems[num].value}
+		if u != nil {
+			ps.adj = int32(u.adj[num])
+		}
+		pending = append(pending, ps)
+		return &pending[len(pending)-1]
 	}
 
 	for _, op := range ops {
 		sem := &s.sems[op.SemNum]
+		ps := find(op.SemNum)
 		if op.SemOp == 0 {
 			// Handle 'wait for zero' operation.
-			if tmpVals[op.SemNum] != 0 {
+			if ps.value != 0 {
 				// Semaphore isn't 0, must wait.
 				if op.SemFlg&linux.IPC_NOWAIT != 0 {
 					return nil, 0, linuxerr.ErrWouldBlock
@@ -600,7 +641,7 @@ func (s *Set) executeOps(ctx context.Context, ops []linux.Sembuf, pid int32) (ch
 				if -op.SemOp > valueMax {
 					return nil, 0, linuxerr.ERANGE
 				}
-				if -op.SemOp > tmpVals[op.SemNum] {
+				if -op.SemOp > ps.value {
 					// Not enough resources, must wait.
 					if op.SemFlg&linux.IPC_NOWAIT != 0 {
 						return nil, 0, linuxerr.ErrWouldBlock
@@ -612,21 +653,37 @@ func (s *Set) executeOps(ctx context.Context, ops []linux.Sembuf, pid int32) (ch
 				}
 			} else {
 				// op.SemOp > 0: Handle 'signal' operation.
-				if tmpVals[op.SemNum] > valueMax-op.SemOp {
+				if ps.value > valueMax-op.SemOp {
 					return nil, 0, linuxerr.ERANGE
 				}
 			}
 
-			tmpVals[op.SemNum] += op.SemOp
+			if op.SemFlg&linux.SEM_UNDO != 0 {
+				// Exceeding the range of adjustments is an error. Compare
+				// to Linux's ipc/sem.c:perform_atomic_semop().
+				adj := ps.adj - int32(op.SemOp)
+				if adj < -valueMax-1 || adj > valueMax {
+					return nil, 0, linuxerr.ERANGE
+				}
+				ps.adj = adj
+			}
+			ps.value += op.SemOp
 		}
 	}
 
 	// All operations succeeded, apply them.
-	// TODO(gvisor.dev/issue/137): handle undo operations.
-	for i, v := range tmpVals {
-		s.sems[i].value = v
-		s.sems[i].wakeWaiters()
-		s.sems[i].pid = pid
+	for _, ps := range pending {
+		sem := &s.sems[ps.num]
+		// Waiters on a semaphore can only be unblocked by a change to its
+		// value, so avoid scanning the waiters of unchanged semaphores.
+		if sem.value != ps.value {
+			sem.value = ps.value
+			sem.wakeWaiters()
+		}
+		sem.pid = pid
+		if u != nil {
+			u.adj[ps.num] = int16(ps.adj)
+		}
 	}
 	s.opTime = ktime.NowFromContext(ctx)
 	return nil, 0, nil
@@ -655,6 +712,14 @@ func (s *Set) Destroy() {
 	// Notify all waiters. They will fail on the next attempt to execute
 	// operations and return error.
 	s.dead = true
+	for _, u := range s.undos {
+		u.list.mu.Lock()
+		if u.list.undos[s.obj.ID] == u {
+			delete(u.list.undos, s.obj.ID)
+		}
+		u.list.mu.Unlock()
+	}
+	s.undos = nil
 	for _, s := range s.sems {
 		for w := s.waiters.Front(); w != nil; w = w.Next() {
 			w.ch <- struct{}{}
@@ -686,6 +751,137 @@ func (s *sem) wakeWaiters() {
 	}
 }
 
+// UndoList holds the semaphore adjustments ("semadj" values) of the tasks that
+// share System V semaphore undo state, i.e. tasks created with CLONE_SYSVSEM.
+// Adjustments are applied when the last such task exits or detaches from the
+// list. They are preserved across execve(2).
+//
+// +stateify savable
+type UndoList struct {
+	// mu protects the fields below. mu is ordered after Set.mu.
+	mu sync.Mutex `state:"nosave"`
+
+	// refs is the number of tasks using the list.
+	refs int64
+
+	// undos maps set IDs to the adjustments for those sets.
+	undos map[ipc.ID]*undo
+}
+
+// undo holds the adjustments of an UndoList for the semaphores in a Set.
+//
+// +stateify savable
+type undo struct {
+	// list and set are immutable.
+	list *UndoList
+	set  *Set
+
+	// adj holds the adjustment for each semaphore in set. adj is protected
+	// by set.mu.
+	adj []int16
+}
+
+// NewUndoList returns a new UndoList with a single reference.
+func NewUndoList() *UndoList {
+	return &UndoList{
+		refs:  1,
+		undos: make(map[ipc.ID]*undo),
+	}
+}
+
+// IncRef adds a user of the list.
+func (l *UndoList) IncRef() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refs++
+}
+
+// DecRef removes a user of the list. When the last user is removed, all
+// adjustments are applied to their sets, and pid is recorded as the PID of the
+// last process to operate on each adjusted semaphore. Compare to Linux's
+// ipc/sem.c:exit_sem().
+func (l *UndoList) DecRef(ctx context.Context, pid int32) {
+	l.mu.Lock()
+	l.refs--
+	if l.refs > 0 {
+		l.mu.Unlock()
+		return
+	}
+	if l.refs < 0 {
+		panic("semaphore.UndoList.DecRef() called without holding a reference")
+	}
+	sets := make([]*Set, 0, len(l.undos))
+	for _, u := range l.undos {
+		sets = append(sets, u.set)
+	}
+	l.mu.Unlock()
+
+	for _, s := range sets {
+		s.mu.Lock()
+		l.mu.Lock()
+		u, ok := l.undos[s.obj.ID]
+		if ok && u.set == s {
+			delete(l.undos, s.obj.ID)
+		}
+		l.mu.Unlock()
+		if ok && u.set == s && !s.dead {
+			s.removeUndoLocked(u)
+			for i, adj := range u.adj {
+				if adj == 0 {
+					continue
+				}
+				sem := &s.sems[i]
+				// Like Linux, clamp the adjusted value to [0, SEMVMX].
+				val := int32(sem.value) + int32(adj)
+				if val < 0 {
+					val = 0
+				} else if val > valueMax {
+					val = valueMax
+				}
+				sem.value = int16(val)
+				sem.pid = pid
+				sem.wakeWaiters()
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// findOrCreateUndoLocked returns l's adjustments for s, creating them if
+// necessary.
+//
+// Preconditions: s.mu must be locked.
+func (s *Set) findOrCreateUndoLocked(l *UndoList) *undo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if u, ok := l.undos[s.obj.ID]; ok && u.set == s {
+		return u
+	}
+	u := &undo{
+		list: l,
+		set:  s,
+		adj:  make([]int16, len(s.sems)),
+	}
+	l.undos[s.obj.ID] = u
+	s.undos = append(s.undos, u)
+	return u
+}
+
+// removeUndoLocked removes u from s.undos.
+//
+// Preconditions: s.mu must be locked.
+func (s *Set) removeUndoLocked(u *undo) {
+	for i, other := range s.undos {
+		if other == u {
+			last := len(s.undos) - 1
+			s.undos[i] = s.undos[last]
+			s.undos[last] = nil
+			s.undos = s.undos[:last]
+			return
+		}
+	}
+}
+
 func newWaiter(val int16) *waiter {
 	return &waiter{
 		value: val,
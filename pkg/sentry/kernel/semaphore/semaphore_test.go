@@ -26,7 +26,7 @@ import (
 )
 
 func executeOps(ctx context.Context, t *testing.T, set *Set, ops []linux.Sembuf, block bool) chan struct{} {
-	ch, _, err := set.executeOps(ctx, ops, 123)
+	ch, _, err := set.executeOps(ctx, ops, 123, nil)
 	if err != nil {
 		t.Fatalf("ExecuteOps(ops) failed, err: %v, ops: %+v", err, ops)
 	}
@@ -124,13 +124,13 @@ func TestNoWait(t *testing.T) {
 
 	ops[0].SemOp = -2
 	ops[0].SemFlg = linux.IPC_NOWAIT
-	if _, _, err := set.executeOps(ctx, ops, 123); err != linuxerr.ErrWouldBlock {
+	if _, _, err := set.executeOps(ctx, ops, 123, nil); err != linuxerr.ErrWouldBlock {
 		t.Fatalf("ExecuteOps(ops) wrong result, got: %v, expected: %v", err, linuxerr.ErrWouldBlock)
 	}
 
 	ops[0].SemOp = 0
 	ops[0].SemFlg = linux.IPC_NOWAIT
-	if _, _, err := set.executeOps(ctx, ops, 123); err != linuxerr.ErrWouldBlock {
+	if _, _, err := set.executeOps(ctx, ops, 123, nil); err != linuxerr.ErrWouldBlock {
 		t.Fatalf("ExecuteOps(ops) wrong result, got: %v, expected: %v", err, linuxerr.ErrWouldBlock)
 	}
 }
@@ -172,3 +172,62 @@ func TestUnregister(t *testing.T) {
 		}
 	}
 }
+
+func TestUndo(t *testing.T) {
+	ctx := contexttest.Context(t)
+	set := &Set{obj: &ipc.Object{ID: 123}, sems: make([]sem, 2)}
+	list := NewUndoList()
+	u := set.findOrCreateUndoLocked(list)
+
+	ops := []linux.Sembuf{
+		{SemNum: 0, SemOp: 3, SemFlg: linux.SEM_UNDO},
+		{SemNum: 1, SemOp: 2},
+		{SemNum: 0, SemOp: -1, SemFlg: linux.SEM_UNDO},
+	}
+	if _, _, err := set.executeOps(ctx, ops, 123, u); err != nil {
+		t.Fatalf("executeOps(ops) failed, err: %v, ops: %+v", err, ops)
+	}
+	if got, want := u.adj[0], int16(-2); got != want {
+		t.Errorf("semadj[0] got: %d, expected: %d", got, want)
+	}
+	if got, want := u.adj[1], int16(0); got != want {
+		t.Errorf("semadj[1] got: %d, expected: %d", got, want)
+	}
+
+	// Adjustments are applied when the last reference is dropped.
+	list.IncRef()
+	list.DecRef(ctx, 456)
+	if got, want := set.sems[0].value, int16(2); got != want {
+		t.Errorf("sems[0].value got: %d, expected: %d", got, want)
+	}
+	list.DecRef(ctx, 456)
+	if got, want := set.sems[0].value, int16(0); got != want {
+		t.Errorf("sems[0].value got: %d, expected: %d", got, want)
+	}
+	if got, want := set.sems[0].pid, int32(456); got != want {
+		t.Errorf("sems[0].pid got: %d, expected: %d", got, want)
+	}
+	if got, want := set.sems[1].value, int16(2); got != want {
+		t.Errorf("sems[1].value got: %d, expected: %d", got, want)
+	}
+	if len(set.undos) != 0 || len(list.undos) != 0 {
+		t.Errorf("undo entries not removed, set: %+v, list: %+v", set.undos, list.undos)
+	}
+}
+
+func TestUndoRange(t *testing.T) {
+	ctx := contexttest.Context(t)
+	set := &Set{obj: &ipc.Object{ID: 123}, sems: make([]sem, 1)}
+	u := set.findOrCreateUndoLocked(NewUndoList())
+	u.adj[0] = -linux.SEMVMX
+
+	ops := []linux.Sembuf{
+		{SemOp: 2, SemFlg: linux.SEM_UNDO},
+	}
+	if _, _, err := set.executeOps(ctx, ops, 123, u); err != linuxerr.ERANGE {
+		t.Fatalf("executeOps(ops) wrong result, got: %v, expected: %v", err, linuxerr.ERANGE)
+	}
+	if set.sems[0].value != 0 || u.adj[0] != -linux.SEMVMX {
+		t.Fatalf("failed executeOps(ops) modified state, value: %d, semadj: %d", set.sems[0].value, u.adj[0])
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/hostarch"
+)
+
+// syscallUserDispatch holds the syscall user dispatch configuration of a
+// task, as set by prctl(PR_SET_SYSCALL_USER_DISPATCH).
+//
+// +stateify savable
+type syscallUserDispatch struct {
+	// Syscalls made from an instruction pointer in [offset, offset+length)
+	// are always executed.
+	offset uint64
+	length uint64
+
+	// selector is the address of a byte in the task's address space that
+	// switches dispatch on (SYSCALL_DISPATCH_FILTER_BLOCK) and off
+	// (SYSCALL_DISPATCH_FILTER_ALLOW). If selector is 0, syscalls outside of
+	// the allowed region are always dispatched.
+	selector hostarch.Addr
+}
+
+// SetSyscallUserDispatch implements prctl(PR_SET_SYSCALL_USER_DISPATCH).
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) SetSyscallUserDispatch(mode int32, offset, length uint64, selector hostarch.Addr) error {
+	switch mode {
+	case linux.PR_SYS_DISPATCH_OFF:
+		if offset != 0 || length != 0 || selector != 0 {
+			return linuxerr.EINVAL
+		}
+		t.syscallUserDispatch = nil
+		return nil
+
+	case linux.PR_SYS_DISPATCH_ON:
+		// "Validate the direct dispatcher region just for basic sanity
+		// against overflow and a 0-sized dispatcher region." -
+		// kernel/entry/syscall_user_dispatch.c
+		if offset != 0 && offset+length <= offset {
+			return linuxerr.EINVAL
+		}
+		if selector >= t.k.Platform.MaxUserAddress() {
+			return linuxerr.EFAULT
+		}
+		t.syscallUserDispatch = &syscallUserDispatch{
+			offset:   offset,
+			length:   length,
+			selector: selector,
+		}
+		return nil
+
+	default:
+		return linuxerr.EINVAL
+	}
+}
+
+// checkSyscallUserDispatch determines whether the syscall sysno, made from
+// the current instruction pointer, must be dispatched back to the application
+// rather than executed. If so, it returns the task's next run state and true.
+//
+// Preconditions:
+//   - The caller must be running on the task goroutine.
+//   - t.syscallUserDispatch != nil.
+func (t *Task) checkSyscallUserDispatch(sysno uintptr) (taskRunState, bool) {
+	sud := t.syscallUserDispatch
+	ip := uint64(t.Arch().IP())
+	if ip-sud.offset < sud.length {
+		return nil, false
+	}
+	if sud.selector != 0 {
+		var state [1]byte
+		if _, err := t.CopyInBytes(sud.selector, state[:]); err != nil {
+			t.Debugf("Syscall %d: failed to read syscall user dispatch selector: %v", sysno, err)
+			t.forceSignal(linux.SIGSEGV, true /* unconditional */)
+			t.SendSignal(SignalInfoPriv(linux.SIGSEGV))
+			return (*runApp)(nil), true
+		}
+		switch state[0] {
+		case linux.SYSCALL_DISPATCH_FILTER_ALLOW:
+			return nil, false
+		case linux.SYSCALL_DISPATCH_FILTER_BLOCK:
+			// Dispatch below.
+		default:
+			t.Debugf("Syscall %d: invalid syscall user dispatch selector %d", sysno, state[0])
+			t.forceSignal(linux.SIGSYS, true /* unconditional */)
+			t.SendSignal(SignalInfoPriv(linux.SIGSYS))
+			return (*runApp)(nil), true
+		}
+	}
+
+	t.Debugf("Syscall %d: dispatched to user", sysno)
+	// Roll back the syscall so that the signal handler sees the original
+	// syscall number in the return value register.
+	t.Arch().SetReturn(sysno)
+	si := &linux.SignalInfo{
+		Signo: int32(linux.SIGSYS),
+		Code:  linux.SYS_USER_DISPATCH,
+	}
+	si.SetCallAddr(ip)
+	si.SetSyscall(int32(sysno))
+	si.SetArch(t.SyscallTable().AuditNumber)
+	t.forceSignal(linux.SIGSYS, false /* unconditional */)
+	t.SendSignal(si)
+	// Like Linux, skip syscall-exit reporting to ptracers for a dispatched
+	// syscall, since it was never entered.
+	return (*runApp)(nil), true
+}
@@ -438,6 +438,19 @@ func (s *SyscallTable) Lookup(sysno uintptr) SyscallFn {
 	return nil
 }
 
+// Replace replaces the implementation of syscall sysno, which must already be
+// in s.
+//
+// Preconditions: s is not in use by any Task.
+func (s *SyscallTable) Replace(sysno uintptr, sc Syscall) {
+	if _, ok := s.Table[sysno]; !ok {
+		panic(fmt.Sprintf("syscall %d (%s) is not in the table", sysno, sc.Name))
+	}
+	s.Table[sysno] = sc
+	s.lookup[sysno] = sc.Fn
+	s.pointCallbacks[sysno] = sc.PointCallback
+}
+
 // LookupName looks up a syscall name.
 func (s *SyscallTable) LookupName(sysno uintptr) string {
 	if sc, ok := s.Table[sysno]; ok {
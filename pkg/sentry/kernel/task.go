@@ -28,6 +28,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/futex"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/sched"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/semaphore"
 	"gvisor.dev/gvisor/pkg/sentry/ktime"
 	"gvisor.dev/gvisor/pkg/sentry/platform"
 	"gvisor.dev/gvisor/pkg/sentry/usage"
@@ -131,14 +132,44 @@ type Task struct {
 	// spent in TaskGoroutineRunningApp or TaskGoroutineRunningSys.
 	appSysCPUClock ktime.SyntheticClock
 
-	// yieldCount is the number of times the task goroutine has called
-	// Task.InterruptibleSleepStart, Task.UninterruptibleSleepStart, or
-	// Task.Yield(), voluntarily ceasing execution.
+	// appCPUClock and appSysCPUClock are only brought up to date once per
+	// clock tick by the CPU clock ticker, which is sufficient to drive CPU
+	// timers but too coarse for reading CPU time. appCPUTime and sysCPUTime
+	// instead measure the time the task goroutine has spent in
+	// TaskGoroutineRunningApp and TaskGoroutineRunningSys respectively, in
+	// nanoseconds, up to the last change to gostate; cpuTimeStamp was the
+	// value of gohacks.Nanotime() at that change.
+	//
+	// These fields are owned by the task goroutine, and may be read by other
+	// goroutines within a gostateSeq reader critical section.
+	appCPUTime   atomicbitops.Int64
+	sysCPUTime   atomicbitops.Int64
+	cpuTimeStamp atomicbitops.Int64 `state:"nosave"`
+
+	// yieldCount is the number of times the task goroutine has blocked,
+	// stopped, or called Task.Yield(), voluntarily ceasing execution.
 	//
 	// yieldCount is accessed using atomic memory operations. yieldCount is
 	// owned by the task goroutine.
 	yieldCount atomicbitops.Uint64
 
+	// interruptCount is the number of times execution of application code
+	// has been interrupted by platform.Context.Interrupt(), involuntarily
+	// ceasing execution.
+	//
+	// interruptCount is accessed using atomic memory operations.
+	// interruptCount is owned by the task goroutine.
+	interruptCount atomicbitops.Uint64
+
+	// minorFaults and majorFaults are the number of application page faults
+	// handled by the task goroutine without and with performing I/O
+	// respectively.
+	//
+	// minorFaults and majorFaults are accessed using atomic memory
+	// operations, and are owned by the task goroutine.
+	minorFaults atomicbitops.Uint64
+	majorFaults atomicbitops.Uint64
+
 	// pendingSignals is the set of pending signals that may be handled only by
 	// this task.
 	//
@@ -441,6 +472,16 @@ type Task struct {
 	// immutable.
 	ioUsage *usage.IO
 
+	// delayUsage holds the task's delay accounting statistics. The
+	// delayUsage pointer is immutable.
+	delayUsage *usage.Delay
+
+	// blkIOStart is the time at which the task goroutine entered its current
+	// uninterruptible sleep, as measured by the kernel's monotonic clock.
+	//
+	// blkIOStart is exclusive to the task goroutine.
+	blkIOStart ktime.Time
+
 	// logPrefix is a string containing the task's thread ID in the root PID
 	// namespace, and is prepended to log messages emitted by Task.Infof etc.
 	logPrefix atomic.Pointer[string] `state:"nosave"`
@@ -452,6 +493,10 @@ type Task struct {
 	traceContext gocontext.Context `state:"nosave"`
 	traceTask    *trace.Task       `state:"nosave"`
 
+	// straceSamples counts the syscalls made by the task that were
+	// considered for strace sampling. It is exclusive to the task goroutine.
+	straceSamples uint32 `state:"nosave"`
+
 	// creds is the task's credentials.
 	//
 	// creds is owned by the task goroutine. All auth.Credentials objects that
@@ -469,16 +514,50 @@ type Task struct {
 	// ipcns is protected by mu. ipcns is owned by the task goroutine.
 	ipcns *IPCNamespace
 
+	// timens is the task's time namespace.
+	//
+	// timens is protected by mu. timens is owned by the task goroutine.
+	timens *TimeNamespace
+
+	// childTimens, if not nil, is the time namespace that children created
+	// by this task will be members of; otherwise they are members of timens.
+	// It is set by unshare(CLONE_NEWTIME). This is Linux's
+	// nsproxy::time_ns_for_children.
+	//
+	// childTimens is protected by mu. childTimens is owned by the task
+	// goroutine.
+	childTimens *TimeNamespace
+
+	// semUndoList holds the task's System V semaphore adjustments. It is nil
+	// if the task has not used SEM_UNDO and doesn't share an undo list with
+	// other tasks.
+	//
+	// semUndoList is protected by mu. It is owned by the task goroutine.
+	semUndoList *semaphore.UndoList
+
 	// mountNamespace is the task's mount namespace.
 	//
 	// It is protected by mu. It is owned by the task goroutine.
 	mountNamespace *vfs.MountNamespace
 
+	// personality is the task's execution domain and flags, as set by
+	// personality(2). Only ADDR_NO_RANDOMIZE affects the task.
+	//
+	// personality is owned by the task goroutine.
+	personality uint32
+
 	// parentDeathSignal is sent to this task's thread group when its parent exits.
 	//
 	// parentDeathSignal is protected by mu.
 	parentDeathSignal linux.Signal
 
+	// noNewPrivs is the task's no_new_privs bit, set by
+	// prctl(PR_SET_NO_NEW_PRIVS). Once set, it is never cleared, and it is
+	// inherited by children and preserved across execve.
+	//
+	// noNewPrivs is only set by the task goroutine.
+	noNewPrivs atomicbitops.Bool
+
 	// seccomp contains all seccomp-bpf syscall filters applicable to the task.
 	// The type of the atomic is *taskSeccomp.
 	// Writing needs to be protected by the signal mutex.
@@ -486,6 +565,20 @@ type Task struct {
 	// seccomp is owned by the task goroutine.
 	seccomp atomic.Pointer[taskSeccomp] `state:".(*taskSeccomp)"`
 
+	// syscallUserDispatch is the task's syscall user dispatch configuration,
+	// or nil if syscall user dispatch is off. It is not inherited by
+	// children.
+	//
+	// syscallUserDispatch is exclusive to the task goroutine.
+	syscallUserDispatch *syscallUserDispatch
+
+	// flightRecorder records t's recent events if Kernel.FlightRecorder is
+	// enabled, and is nil otherwise. It is not saved; tasks start with an
+	// empty flight recorder after restore.
+	//
+	// flightRecorder is immutable after task creation or restore.
+	flightRecorder *flightRecorder `state:"nosave"`
+
 	// If cleartid is non-zero, treat it as a pointer to a ThreadID in the
 	// task's virtual address space; when the task exits, set the pointed-to
 	// ThreadID to 0, and wake any futex waiters.
@@ -516,6 +609,26 @@ type Task struct {
 	// niceness is protected by mu.
 	niceness int
 
+	// schedAttr is the scheduling policy of the task, which is not enforced
+	// either. The niceness of the task is tracked separately by niceness,
+	// whatever its policy.
+	//
+	// schedAttr is protected by mu.
+	schedAttr SchedAttr
+
+	// coreSchedCookie identifies the tasks that the task may share a core
+	// with, as set by prctl(PR_SCHED_CORE). 0 means the task has no cookie.
+	//
+	// coreSchedCookie is protected by mu.
+	coreSchedCookie uint64
+
+	// ioFlusher is true if the task was marked as an IO flusher by
+	// prctl(PR_SET_IO_FLUSHER). It has no effect, since the sentry doesn't
+	// reclaim memory by writing it back through tasks.
+	//
+	// ioFlusher is protected by mu.
+	ioFlusher bool
+
 	// This is used to track the numa policy for the current thread. This can be
 	// modified through a set_mempolicy(2) syscall. Since we always report a
 	// single numa node, all policies are no-ops. We only track this information
@@ -566,6 +679,12 @@ type Task struct {
 	// rseqAddr is exclusive to the task goroutine.
 	rseqAddr hostarch.Addr
 
+	// rseqLen is the length of the linux.RSeq structure at rseqAddr, as
+	// registered by the application.
+	//
+	// rseqLen is exclusive to the task goroutine.
+	rseqLen uint32
+
 	// rseqSignature is the signature that the rseq abort IP must be signed
 	// with.
 	//
@@ -620,8 +739,8 @@ type Task struct {
 
 	// userCounters is a pointer to a set of user counters.
 	//
-	// The userCounters pointer is exclusive to the task goroutine, but the
-	// userCounters instance must be atomically accessed.
+	// The userCounters pointer is immutable, but the userCounters instance
+	// must be atomically accessed.
 	userCounters *UserCounters
 
 	// sessionKeyring is a pointer to the task's session keyring, if set.
@@ -672,6 +791,9 @@ func (t *Task) afterLoad(gocontext.Context) {
 	t.rseqPreempted = true
 	t.futexWaiter = futex.NewWaiter()
 	t.p = t.k.Platform.NewContext(t.AsyncContext())
+	if t.k.FlightRecorder {
+		t.flightRecorder = &flightRecorder{}
+	}
 }
 
 // copyScratchBufferLen is the length of Task.copyScratchBuffer.
@@ -836,6 +958,14 @@ func (t *Task) ContainerID() string {
 	return t.containerID
 }
 
+// NextStraceSample increments and returns the number of syscalls made by t that
+// were considered for strace sampling. It must only be called from the task
+// goroutine.
+func (t *Task) NextStraceSample() uint32 {
+	t.straceSamples++
+	return t.straceSamples
+}
+
 // RestoreContainerID sets t's container ID in case the restored container ID
 // is different from when it was saved.
 func (t *Task) RestoreContainerID(cid string) {
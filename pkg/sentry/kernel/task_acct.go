@@ -17,6 +17,7 @@ package kernel
 // Accounting, limits, timers.
 
 import (
+	"fmt"
 	"math"
 	"sync/atomic"
 	"time"
@@ -180,6 +181,26 @@ func (tg *ThreadGroup) IOUsage() *usage.IO {
 	return &io
 }
 
+// DelayUsage returns the delay accounting usage of the thread.
+func (t *Task) DelayUsage() *usage.Delay {
+	return t.delayUsage
+}
+
+// DelayUsage returns the total delay accounting usage of all dead and live
+// threads in the group.
+func (tg *ThreadGroup) DelayUsage() *usage.Delay {
+	tg.pidns.owner.mu.RLock()
+	defer tg.pidns.owner.mu.RUnlock()
+
+	var d usage.Delay
+	tg.delayUsage.Clone(&d)
+	// Account for active tasks.
+	for t := tg.tasks.Front(); t != nil; t = t.Next() {
+		d.Accumulate(t.DelayUsage())
+	}
+	return &d
+}
+
 // Name returns t's name.
 func (t *Task) Name() string {
 	t.mu.Lock()
@@ -190,9 +211,37 @@ func (t *Task) Name() string {
 // SetName changes t's name.
 func (t *Task) SetName(name string) {
 	t.mu.Lock()
-	defer t.mu.Unlock()
 	t.image.Name = name
+	mm := t.image.MemoryManager
+	t.mu.Unlock()
 	t.Debugf("Set thread name to %q", name)
+
+	// Host threads are shared by all tasks in the thread group, so they are
+	// named after the thread group leader.
+	if mm != nil && t.k.HostThreadNames && t.tg.Leader() == t {
+		mm.SetHostThreadName(t.hostThreadName())
+	}
+}
+
+// hostThreadName returns the name that should be given to host threads
+// executing t's application code, or "" if they should not be named.
+//
+// The name is "<tgid>:<comm>", where tgid is t's thread group ID in the root
+// PID namespace and comm is the thread group leader's name, truncated to fit
+// in TASK_COMM_LEN.
+func (t *Task) hostThreadName() string {
+	if !t.k.HostThreadNames {
+		return ""
+	}
+	leader := t.tg.Leader()
+	if leader == nil {
+		return ""
+	}
+	name := fmt.Sprintf("%d:%s", t.k.tasks.Root.IDOfThreadGroup(t.tg), leader.Name())
+	if len(name) > linux.TASK_COMM_LEN-1 {
+		name = name[:linux.TASK_COMM_LEN-1]
+	}
+	return name
 }
 
 // Limits implements context.Context.Limits.
@@ -245,10 +245,14 @@ func (t *Task) UninterruptibleSleepStart(deactivate bool) {
 		t.Deactivate()
 	}
 	t.accountTaskGoroutineEnter(TaskGoroutineBlockedUninterruptible)
+	t.blkIOStart = t.k.MonotonicClock().Now()
 }
 
 // UninterruptibleSleepFinish implements context.Context.UninterruptibleSleepFinish.
 func (t *Task) UninterruptibleSleepFinish(activate bool) {
+	// Uninterruptible sleeps are used for I/O to the gofer and host, which
+	// is what Linux's delay accounting reports as block I/O delay.
+	t.delayUsage.AccountBlkIO(t.k.MonotonicClock().Now().Sub(t.blkIOStart))
 	t.accountTaskGoroutineLeave(TaskGoroutineBlockedUninterruptible)
 	if activate {
 		t.Activate()
@@ -19,9 +19,13 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/fspath"
 	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/kernfs"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
 )
 
 // EnterInitialCgroups moves t into an initial set of cgroups.
@@ -272,6 +276,83 @@ func (t *Task) GenerateProcTaskCgroup(buf *bytes.Buffer) {
 	}
 }
 
+// cloneIntoCgroups returns the set of cgroups for a child of t created with
+// CLONE_INTO_CGROUP: the cgroup represented by the cgroupfs directory open as
+// fd replaces t's cgroup in the same hierarchy, and t's other cgroups are
+// inherited. Each returned cgroup holds an extra reference that the caller
+// must drop outside of Task.mu critical sections.
+//
+// This is analogous to Linux's kernel/cgroup/cgroup.c:cgroup_css_set_fork().
+// Note that Linux only accepts cgroup2 directories; since cgroupfs only
+// provides cgroup v1 hierarchies, any cgroupfs directory is accepted here.
+func (t *Task) cloneIntoCgroups(fd int32) (map[Cgroup]struct{}, error) {
+	file := t.GetFile(fd)
+	if file == nil {
+		return nil, linuxerr.EBADF
+	}
+	defer file.DecRef(t)
+
+	d, ok := file.Dentry().Impl().(*kernfs.Dentry)
+	if !ok {
+		return nil, linuxerr.EBADF
+	}
+	impl, ok := d.Inode().(CgroupImpl)
+	if !ok {
+		return nil, linuxerr.EBADF
+	}
+	dst := Cgroup{
+		Dentry:     d,
+		CgroupImpl: impl,
+	}
+	if d.VFSDentry().IsDead() {
+		return nil, linuxerr.ENODEV
+	}
+
+	// The caller must be allowed to write to the destination's cgroup.procs,
+	// as if it were migrating the child there. See cgroup_may_write().
+	procs, err := d.WalkDentryTree(t, t.k.VFS(), fspath.Parse("cgroup.procs"))
+	if err != nil {
+		return nil, err
+	}
+	err = procs.Inode().CheckPermissions(t, t.Credentials(), vfs.MayWrite)
+	procs.DecRef(t)
+	if err != nil {
+		return nil, err
+	}
+
+	cgs := make(map[Cgroup]struct{})
+	t.mu.Lock()
+	for c := range t.cgroups {
+		if c.HierarchyID() != dst.HierarchyID() {
+			c.IncRef()
+			cgs[c] = struct{}{}
+		}
+	}
+	t.mu.Unlock()
+	dst.IncRef()
+	cgs[dst] = struct{}{}
+	return cgs, nil
+}
+
+// chargeCgroupsFor charges the cgroup in cgs with a controller of type ctl on
+// behalf of target. Returns the cgroup that's charged if any. Returned cgroup
+// has an extra ref that's transferred to the caller.
+func chargeCgroupsFor(target *Task, cgs map[Cgroup]struct{}, ctl CgroupControllerType, res CgroupResourceType, value int64) (bool, Cgroup, error) {
+	for c := range cgs {
+		for _, cc := range c.Controllers() {
+			if cc.Type() != ctl {
+				continue
+			}
+			if err := c.Charge(target, c.Dentry, ctl, res, value); err != nil {
+				return false, c, err
+			}
+			c.IncRef()
+			return true, c, nil
+		}
+	}
+	return false, Cgroup{}, nil
+}
+
 // +checklocks:t.mu
 func (t *Task) chargeLocked(target *Task, ctl CgroupControllerType, res CgroupResourceType, value int64) (bool, Cgroup, error) {
 	// Due to the uniqueness of controllers on hierarchies, at most one cgroup
@@ -294,3 +375,25 @@ func (t *Task) ChargeFor(other *Task, ctl CgroupControllerType, res CgroupResour
 	defer t.mu.Unlock()
 	return t.chargeLocked(other, ctl, res, value)
 }
+
+// ThrottleIO charges a file I/O of the given number of bytes that t has just
+// performed to t's cgroups, and blocks t for as long as any of them is over
+// its I/O limits. The wait ends early if t is interrupted; the I/O has already
+// been performed, so the interruption isn't reported.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) ThrottleIO(write bool, bytes int64) {
+	var delay time.Duration
+	t.mu.Lock()
+	for c := range t.cgroups {
+		for _, ctl := range c.Controllers() {
+			if th, ok := ctl.(CgroupIOThrottler); ok {
+				delay = max(delay, th.ChargeIO(write, bytes))
+			}
+		}
+	}
+	t.mu.Unlock()
+	if delay > 0 {
+		t.BlockWithTimeout(nil, true, delay)
+	}
+}
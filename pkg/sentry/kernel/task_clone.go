@@ -15,6 +15,8 @@
 package kernel
 
 import (
+	"math"
+
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/atomicbitops"
 	"gvisor.dev/gvisor/pkg/cleanup"
@@ -23,6 +25,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/fsimpl/kernfs"
 	"gvisor.dev/gvisor/pkg/sentry/fsimpl/nsfs"
 	"gvisor.dev/gvisor/pkg/sentry/inet"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/semaphore"
 	"gvisor.dev/gvisor/pkg/sentry/seccheck"
 	pb "gvisor.dev/gvisor/pkg/sentry/seccheck/points/points_go_proto"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
@@ -30,14 +33,13 @@ import (
 )
 
 // SupportedCloneFlags is the bitwise OR of all the supported flags for clone.
-// TODO(b/290826530): Implement CLONE_INTO_CGROUP when cgroups v2 is
-// implemented.
 const SupportedCloneFlags = linux.CLONE_VM | linux.CLONE_FS | linux.CLONE_FILES | linux.CLONE_SYSVSEM |
 	linux.CLONE_THREAD | linux.CLONE_SIGHAND | linux.CLONE_CHILD_SETTID | linux.CLONE_NEWPID |
 	linux.CLONE_CHILD_CLEARTID | linux.CLONE_CHILD_SETTID | linux.CLONE_PARENT |
 	linux.CLONE_PARENT_SETTID | linux.CLONE_SETTLS | linux.CLONE_NEWUSER | linux.CLONE_NEWUTS |
 	linux.CLONE_NEWIPC | linux.CLONE_NEWNET | linux.CLONE_PTRACE | linux.CLONE_UNTRACED |
-	linux.CLONE_IO | linux.CLONE_VFORK | linux.CLONE_DETACHED | linux.CLONE_NEWNS
+	linux.CLONE_IO | linux.CLONE_VFORK | linux.CLONE_DETACHED | linux.CLONE_NEWNS | linux.CLONE_NEWTIME |
+	linux.CLONE_INTO_CGROUP
 
 // Clone implements the clone(2) syscall and returns the thread ID of the new
 // task in t's PID namespace. Clone may return both a non-zero thread ID and a
@@ -83,6 +85,41 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 	if args.Flags&(linux.CLONE_FS|linux.CLONE_NEWNS) == linux.CLONE_FS|linux.CLONE_NEWNS {
 		return 0, nil, linuxerr.EINVAL
 	}
+	// System V semaphore undo lists cannot span IPC namespaces.
+	if args.Flags&(linux.CLONE_SYSVSEM|linux.CLONE_NEWIPC) == linux.CLONE_SYSVSEM|linux.CLONE_NEWIPC {
+		return 0, nil, linuxerr.EINVAL
+	}
+	// "If the new process will be in a different time namespace do not allow
+	// it to share VM or a thread group with the forking task." -
+	// kernel/fork.c:copy_process()
+	if args.Flags&(linux.CLONE_THREAD|linux.CLONE_VM) != 0 && t.childTimeNamespaceLocked() != t.timens {
+		return 0, nil, linuxerr.EINVAL
+	}
+
+	schedAttr, niceness, err := t.childSchedAttr()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// "CLONE_INTO_CGROUP (since Linux 5.7): By default, a child process is
+	// placed in the same version 2 cgroup as its parent. The
+	// CLONE_INTO_CGROUP flag allows the child process to be created in a
+	// different version 2 cgroup." - clone(2)
+	var initialCgroups map[Cgroup]struct{}
+	if args.Flags&linux.CLONE_INTO_CGROUP != 0 {
+		if args.Cgroup > math.MaxInt32 {
+			return 0, nil, linuxerr.EINVAL
+		}
+		var err error
+		if initialCgroups, err = t.cloneIntoCgroups(int32(args.Cgroup)); err != nil {
+			return 0, nil, err
+		}
+		defer func() {
+			for c := range initialCgroups {
+				c.decRef()
+			}
+		}()
+	}
 
 	// Pull task registers and FPU state, a cloned task will inherit the
 	// state of the current task.
@@ -115,7 +152,7 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 			return 0, nil, err
 		}
 	}
-	if args.Flags&(linux.CLONE_NEWPID|linux.CLONE_NEWNET|linux.CLONE_NEWUTS|linux.CLONE_NEWIPC) != 0 && !creds.HasCapabilityIn(linux.CAP_SYS_ADMIN, userns) {
+	if args.Flags&(linux.CLONE_NEWPID|linux.CLONE_NEWNET|linux.CLONE_NEWUTS|linux.CLONE_NEWIPC|linux.CLONE_NEWTIME) != 0 && !creds.HasCapabilityIn(linux.CAP_SYS_ADMIN, userns) {
 		return 0, nil, linuxerr.EPERM
 	}
 
@@ -147,6 +184,19 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 		ipcns.DecRef(t)
 	})
 
+	// "If CLONE_SYSVSEM is set, then the child and the calling process share a
+	// single list of System V semaphore adjustment (semadj) values. ... If
+	// this flag is not set, then the child has a separate semadj list that
+	// is initially empty." - clone(2)
+	var semUndoList *semaphore.UndoList
+	if args.Flags&linux.CLONE_SYSVSEM != 0 {
+		semUndoList = t.SemUndoList()
+		semUndoList.IncRef()
+		cu.Add(func() {
+			semUndoList.DecRef(t, 0 /* pid */)
+		})
+	}
+
 	netns := t.netns
 	if args.Flags&linux.CLONE_NEWNET != 0 {
 		netns = inet.NewNamespace(netns, userns)
@@ -179,6 +229,38 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 		sessionKeyring = nil
 	}
 
+	// The child is a member of the time namespace for children, and switches
+	// its VDSO parameter page accordingly, unless it shares its parent's
+	// address space; in the latter case, it stays in its parent's time
+	// namespace. See Linux's kernel/nsproxy.c:copy_namespaces().
+	childTimens := t.childTimeNamespaceLocked()
+	if args.Flags&linux.CLONE_NEWTIME != 0 {
+		childTimens = childTimens.clone(t.k, userns)
+	} else {
+		childTimens.IncRef()
+	}
+	cu.Add(func() {
+		childTimens.DecRef(t)
+	})
+	timens := childTimens
+	if args.Flags&linux.CLONE_VM != 0 {
+		timens = t.timens
+	} else if timens != t.timens {
+		vvar, err := timens.enter(t.k)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := switchVVAR(t, image.MemoryManager, t.timens.VVAR(), vvar); err != nil {
+			return 0, nil, err
+		}
+	}
+	if timens != childTimens {
+		timens.IncRef()
+		cu.Add(func() {
+			timens.DecRef(t)
+		})
+	}
+
 	// clone() returns 0 in the child.
 	image.Arch.SetReturn(0)
 	if args.Stack != 0 {
@@ -190,6 +272,17 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 		}
 	}
 
+	pidns := t.tg.pidns
+	if t.childPIDNamespace != nil {
+		pidns = t.childPIDNamespace
+	} else if args.Flags&linux.CLONE_NEWPID != 0 {
+		var err error
+		if pidns, err = pidns.NewChild(t, t.k, userns); err != nil {
+			return 0, nil, err
+		}
+		defer pidns.DecRef(t)
+	}
+
 	var fsContext *FSContext
 	if args.Flags&linux.CLONE_FS == 0 || args.Flags&linux.CLONE_NEWNS != 0 {
 		fsContext = t.fsContext.Fork()
@@ -220,17 +313,7 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 		fdTable.IncRef()
 	}
 
-	pidns := t.tg.pidns
-	if t.childPIDNamespace != nil {
-		pidns = t.childPIDNamespace
-	} else if args.Flags&linux.CLONE_NEWPID != 0 {
-		pidns = pidns.NewChild(t, t.k, userns)
-		defer pidns.DecRef(t)
-	}
-
 	tg := t.tg
-	rseqAddr := hostarch.Addr(0)
-	rseqSignature := uint32(0)
 	if args.Flags&linux.CLONE_THREAD == 0 {
 		sh := t.tg.signalHandlers
 		if args.Flags&linux.CLONE_SIGHAND == 0 {
@@ -238,7 +321,17 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 		}
 		tg = t.k.NewThreadGroup(pidns, sh, linux.Signal(args.ExitSignal), tg.limits.GetCopy())
 		tg.oomScoreAdj = atomicbitops.FromInt32(t.tg.oomScoreAdj.Load())
+	}
+
+	// As in Linux's rseq_fork(), the rseq registration is inherited only by
+	// children with their own copy of the address space; a child sharing it
+	// (e.g. vfork) would otherwise overwrite the parent's rseq area.
+	rseqAddr := hostarch.Addr(0)
+	rseqLen := uint32(0)
+	rseqSignature := uint32(0)
+	if args.Flags&linux.CLONE_VM == 0 {
 		rseqAddr = t.rseqAddr
+		rseqLen = t.rseqLen
 		rseqSignature = t.rseqSignature
 	}
 
@@ -255,18 +348,30 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 		FSContext:        fsContext,
 		FDTable:          fdTable,
 		Credentials:      creds,
-		Niceness:         t.Niceness(),
+		Niceness:         niceness,
+		SchedAttr:        schedAttr,
+		CoreSchedCookie:  t.CoreSchedCookie(),
+		Personality:      t.personality,
+		IOFlusher:        t.IOFlusher(),
 		NetworkNamespace: netns,
 		AllowedCPUMask:   t.CPUMask(),
 		UTSNamespace:     utsns,
 		IPCNamespace:     ipcns,
+		TimeNamespace:    timens,
+		SemUndoList:      semUndoList,
 		MountNamespace:   mntns,
 		RSeqAddr:         rseqAddr,
+		RSeqLen:          rseqLen,
 		RSeqSignature:    rseqSignature,
 		ContainerID:      t.ContainerID(),
 		UserCounters:     uc,
 		SessionKeyring:   sessionKeyring,
 		Origin:           t.Origin,
+		NoNewPrivs:       t.NoNewPrivs(),
+		InitialCgroups:   initialCgroups,
+	}
+	if childTimens != timens {
+		cfg.ChildTimeNamespace = childTimens
 	}
 	if args.Flags&linux.CLONE_THREAD == 0 {
 		cfg.Parent = t
@@ -328,13 +433,20 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 	// "If fork/clone and execve are allowed by @prog, any child processes will
 	// be constrained to the same filters and system call ABI as the parent." -
 	// Documentation/prctl/seccomp_filter.txt
+	//
+	// nt is already visible to TSYNC from other tasks in its thread group,
+	// which must not race with installing its filters.
+	nt.tg.signalHandlers.mu.Lock()
+	var seccompCopy *taskSeccomp
 	if ts := t.seccomp.Load(); ts != nil {
-		seccompCopy := ts.copy()
+		seccompCopy = ts.copy()
 		seccompCopy.populateCache(nt)
-		nt.seccomp.Store(seccompCopy)
-	} else {
-		nt.seccomp.Store(nil)
+		seccompCopy.incNotifierUsers()
+	}
+	if old := nt.seccomp.Swap(seccompCopy); old != nil {
+		old.decNotifierUsers()
 	}
+	nt.tg.signalHandlers.mu.Unlock()
 	if args.Flags&linux.CLONE_VFORK != 0 {
 		nt.vforkParent.Store(t)
 	}
@@ -504,6 +616,7 @@ func (t *Task) Setns(fd *vfs.FileDescription, flags int32) error {
 		t.ipcns = ns
 		t.mu.Unlock()
 		oldNS.DecRef(t)
+		t.exitSemUndoList()
 		return nil
 	case *vfs.MountNamespace:
 		if flags != 0 && flags != linux.CLONE_NEWNS {
@@ -551,6 +664,41 @@ func (t *Task) Setns(fd *vfs.FileDescription, flags int32) error {
 		t.mu.Unlock()
 		oldNS.DecRef(t)
 		return nil
+	case *TimeNamespace:
+		if flags != 0 && flags != linux.CLONE_NEWTIME {
+			return linuxerr.EINVAL
+		}
+		if !t.HasCapabilityIn(linux.CAP_SYS_ADMIN, ns.UserNamespace()) ||
+			!t.Credentials().HasCapability(linux.CAP_SYS_ADMIN) {
+			return linuxerr.EPERM
+		}
+		// Since the VDSO parameter page of the task's address space is
+		// replaced, the task must not share it with other threads.
+		t.tg.signalHandlers.mu.Lock()
+		tasksCount := t.tg.tasksCount
+		t.tg.signalHandlers.mu.Unlock()
+		if tasksCount != 1 {
+			return linuxerr.EUSERS
+		}
+		vvar, err := ns.enter(t.k)
+		if err != nil {
+			return err
+		}
+		if err := switchVVAR(t, t.MemoryManager(), t.timens.VVAR(), vvar); err != nil {
+			return err
+		}
+		ns.IncRef()
+		t.mu.Lock()
+		oldNS := t.timens
+		oldChildNS := t.childTimens
+		t.timens = ns
+		t.childTimens = nil
+		t.mu.Unlock()
+		oldNS.DecRef(t)
+		if oldChildNS != nil {
+			oldChildNS.DecRef(t)
+		}
+		return nil
 	case *PIDNamespace:
 		if flags != 0 && flags != linux.CLONE_NEWPID {
 			return linuxerr.EINVAL
@@ -645,7 +793,11 @@ func (t *Task) Unshare(flags int32) error {
 		if !haveCapSysAdmin {
 			return linuxerr.EPERM
 		}
-		t.childPIDNamespace = t.tg.pidns.NewChild(t, t.k, t.UserNamespace())
+		childPIDNS, err := t.tg.pidns.NewChild(t, t.k, t.UserNamespace())
+		if err != nil {
+			return err
+		}
+		t.childPIDNamespace = childPIDNS
 	}
 	if flags&linux.CLONE_NEWNET != 0 {
 		if !haveCapSysAdmin {
@@ -679,6 +831,19 @@ func (t *Task) Unshare(flags int32) error {
 		t.utsns.SetInode(nsfs.NewInode(t, t.k.nsfsMount, t.utsns))
 		cu.Add(func() { oldUTSNS.DecRef(t) })
 	}
+	if flags&linux.CLONE_NEWTIME != 0 {
+		if !haveCapSysAdmin {
+			return linuxerr.EPERM
+		}
+		// "The calling process is not moved into the new namespace. The first
+		// child created by the calling process will be placed in the new
+		// namespace." - time_namespaces(7)
+		oldChildTimens := t.childTimens
+		t.childTimens = t.childTimeNamespaceLocked().clone(t.k, creds.UserNamespace)
+		if oldChildTimens != nil {
+			cu.Add(func() { oldChildTimens.DecRef(t) })
+		}
+	}
 	if flags&linux.CLONE_NEWIPC != 0 {
 		if !haveCapSysAdmin {
 			return linuxerr.EPERM
@@ -691,6 +856,12 @@ func (t *Task) Unshare(flags int32) error {
 		t.ipcns.SetInode(nsfs.NewInode(t, t.k.nsfsMount, t.ipcns))
 		cu.Add(func() { oldIPCNS.DecRef(t) })
 	}
+	if flags&(linux.CLONE_NEWIPC|linux.CLONE_SYSVSEM) != 0 {
+		// Detach from the semaphore undo list, since it may be shared with
+		// other tasks or refer to semaphores in the old IPC namespace. See
+		// Linux's kernel/fork.c:ksys_unshare().
+		cu.Add(t.exitSemUndoList)
+	}
 	if flags&linux.CLONE_FILES != 0 {
 		oldFDTable := t.fdTable
 		t.fdTable = oldFDTable.Fork(t, MaxFdLimit)
@@ -104,6 +104,8 @@ func (t *Task) contextValue(key any, isTaskGoroutine bool) any {
 		}
 		t.mountNamespace.IncRef()
 		return t.mountNamespace
+	case vfs.CtxContainerID:
+		return t.ContainerID()
 	case devutil.CtxDevGoferClient:
 		return t.k.GetDevGoferClient(t.k.ContainerName(t.containerID))
 	case inet.CtxStack:
@@ -124,6 +126,13 @@ func (t *Task) contextValue(key any, isTaskGoroutine bool) any {
 		return t.k.mf
 	case platform.CtxPlatform:
 		return t.k
+	case platform.CtxHostThreadName:
+		return t.hostThreadName()
+	case platform.CtxHostCoreSchedCookie:
+		if !t.k.HostCoreSchedCookies {
+			return nil
+		}
+		return t.hostCoreSchedCookie()
 	case shm.CtxDeviceID:
 		return t.k.sysVShmDevID
 	case uniqueid.CtxGlobalUniqueID:
@@ -72,6 +72,7 @@ import (
 	"gvisor.dev/gvisor/pkg/cleanup"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/loader"
 	"gvisor.dev/gvisor/pkg/sentry/mm"
 	"gvisor.dev/gvisor/pkg/sentry/seccheck"
 	pb "gvisor.dev/gvisor/pkg/sentry/seccheck/points/points_go_proto"
@@ -104,7 +105,7 @@ func (t *Task) Execve(newImage *TaskImage, argv, env []string, executable *vfs.F
 	defer cu.Clean()
 	// We can't clearly hold kernel package locks while stat'ing executable.
 	if seccheck.Global.Enabled(seccheck.PointExecve) {
-		mask, info := getExecveSeccheckInfo(t, argv, env, executable, pathname)
+		mask, info := getExecveSeccheckInfo(t, argv, env, executable, pathname, newImage.MemoryManager.BuildID())
 		if err := seccheck.Global.SentToSinks(func(c seccheck.Sink) error {
 			return c.Execve(t, mask, info)
 		}); err != nil {
@@ -123,6 +124,13 @@ func (t *Task) Execve(newImage *TaskImage, argv, env []string, executable *vfs.F
 		return nil, linuxerr.EINTR
 	}
 
+	// Compute the new credentials before the point of no return, so that
+	// unusable file capabilities fail the execve. They are computed again
+	// when they are committed, since they depend on whether t is traced.
+	if _, _, err := t.credsForExecLocked(newImage.Name, newImage.setID, newImage.fileCaps); err != nil {
+		return nil, err
+	}
+
 	// Cancel any racing group stops.
 	t.tg.endGroupStopLocked(false)
 
@@ -142,7 +150,7 @@ func (t *Task) Execve(newImage *TaskImage, argv, env []string, executable *vfs.F
 	}
 
 	cu.Release()
-	return &SyscallControl{next: &runSyscallAfterExecStop{newImage}, ignoreReturn: true}, nil
+	return &SyscallControl{next: &runSyscallAfterExecStop{image: newImage}, ignoreReturn: true}, nil
 }
 
 // The runSyscallAfterExecStop state continues execve(2) after all siblings of
@@ -220,34 +228,55 @@ func (r *runSyscallAfterExecStop) execute(t *Task) taskRunState {
 	t.rseqPreempted = false
 	t.rseqCPU = -1
 	t.rseqAddr = 0
+	t.rseqLen = 0
 	t.rseqSignature = 0
 	t.oldRSeqCPUAddr = 0
 	t.tg.oldRSeqCritical.Store(&OldRSeqCriticalRegion{})
+	// Update credentials to reflect the execve. They are computed and
+	// committed while holding the TaskSet mutex, which Task.ptraceAttach
+	// also holds, so that they are limited by any tracer that attached since
+	// Execve. Once they are committed, ptrace access checks apply to them.
+	// Compare Linux's signal_struct::cred_guard_mutex.
+	t.tg.signalHandlers.mu.Lock()
+	creds, secure, credsErr := t.credsForExecLocked(r.image.Name, r.image.setID, r.image.fileCaps)
+	t.tg.signalHandlers.mu.Unlock()
+	privileged := false
+	if credsErr == nil {
+		privileged = t.commitCredsForExec(creds)
+	}
 	t.tg.pidns.owner.mu.Unlock()
 
-	oldFDTable := t.fdTable
-	t.fdTable = t.fdTable.Fork(t, int32(t.fdTable.CurrentMaxFDs()))
-	oldFDTable.DecRef(t)
-
-	// Remove FDs with the CloseOnExec flag set.
-	t.fdTable.RemoveIf(t, func(_ *vfs.FileDescription, flags FDFlags) bool {
-		return flags.CloseOnExec
-	})
+	// The new mm is not dumpable if the execve granted privileges or the
+	// executable is unreadable. See fs/exec.c:begin_new_exec and
+	// fs/exec.c:would_dump.
+	// As in Linux, an execve that grants privileges also clears personality
+	// flags that weaken the new image; see fs/exec.c:begin_new_exec.
+	if privileged {
+		t.personality &^= linux.PER_CLEAR_ON_SETID
+	}
+	if privileged || r.image.unreadable {
+		r.image.MemoryManager.SetDumpability(mm.NotDumpable)
+	} else {
+		r.image.MemoryManager.SetDumpability(mm.UserDumpable)
+	}
+	// AT_SECURE and the IDs in the auxiliary vector must reflect the
+	// committed credentials. If they can't, the new image must not run;
+	// Linux similarly kills tasks that fail execve() past the point of no
+	// return.
+	if credsErr == nil {
+		credsErr = loader.SetAuxvCreds(t, r.image.MemoryManager, r.image.Arch, r.image.auxvStart, creds, secure)
+	}
+	if credsErr != nil {
+		t.Warningf("Failed to commit credentials for execve: %v", credsErr)
+		t.forceSignal(linux.SIGKILL, true /* unconditional */)
+	}
 
-	// Handle the robust futex list.
+	// Handle the robust futex list. This must precede switching MMs, since the
+	// list is in the old MM.
 	t.exitRobustList()
 
-	// NOTE(b/30815691): We currently do not implement privileged
-	// executables (set-user/group-ID bits and file capabilities). This
-	// allows us to unconditionally enable user dumpability on the new mm.
-	// See fs/exec.c:setup_new_exec.
-	r.image.MemoryManager.SetDumpability(mm.UserDumpable)
-
 	// Switch to the new process.
 	t.MemoryManager().Deactivate()
-	// Update credentials to reflect the execve. This should precede switching
-	// MMs to ensure that dumpability has been reset first, if needed.
-	t.updateCredsForExec()
 	t.mu.Lock()
 	oldImage := t.image
 	t.image = *r.image
@@ -258,7 +287,28 @@ func (r *runSyscallAfterExecStop) execute(t *Task) taskRunState {
 	// violation.
 	oldImage.release(t)
 
+	// Unshare the FD table, if it is shared. In the common case of a vforked
+	// or forked child, the FD table isn't shared, so copying it would be
+	// wasted work (compare Linux's kernel/fork.c:unshare_fd()). This must
+	// precede resuming a vfork parent that shares the FD table
+	// (CLONE_VFORK|CLONE_FILES), which could otherwise change it before it is
+	// copied.
+	if t.fdTable.ReadRefs() > 1 {
+		oldFDTable := t.fdTable
+		t.fdTable = t.fdTable.Fork(t, int32(t.fdTable.CurrentMaxFDs()))
+		oldFDTable.DecRef(t)
+	}
+
+	// The old MM is no longer in use, so a vfork parent can resume now, rather
+	// than after the remainder of execve (compare Linux's fs/exec.c:exec_mmap()
+	// => exec_mm_release()).
 	t.unstopVforkParent()
+
+	// Remove FDs with the CloseOnExec flag set from the now unshared FD table.
+	t.fdTable.RemoveIf(t, func(_ *vfs.FileDescription, flags FDFlags) bool {
+		return flags.CloseOnExec
+	})
+
 	t.p.FullStateChanged()
 	// NOTE(b/30316266): All locks must be dropped prior to calling Activate.
 	t.MemoryManager().Activate(t)
@@ -314,7 +364,7 @@ func (t *Task) promoteLocked() {
 	oldLeader.exitNotifyLocked(false)
 }
 
-func getExecveSeccheckInfo(t *Task, argv, env []string, executable *vfs.FileDescription, pathname string) (seccheck.FieldSet, *pb.ExecveInfo) {
+func getExecveSeccheckInfo(t *Task, argv, env []string, executable *vfs.FileDescription, pathname string, buildID []byte) (seccheck.FieldSet, *pb.ExecveInfo) {
 	fields := seccheck.Global.GetFieldSet(seccheck.PointExecve)
 	info := &pb.ExecveInfo{
 		Argv: argv,
@@ -337,6 +387,7 @@ func getExecveSeccheckInfo(t *Task, argv, env []string, executable *vfs.FileDesc
 					info.BinaryGid = stat.GID
 				}
 			}
+			info.BinaryBuildId = buildID
 		}
 
 		if fields.Local.Contains(seccheck.FieldSentryExecveBinarySha256) {
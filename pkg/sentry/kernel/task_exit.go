@@ -96,7 +96,7 @@ func (t *Task) killLocked() {
 		// enqueueing an actual siginfo, such that
 		// kernel/signal.c:collect_signal() initializes si_code to SI_USER.
 		Code: linux.SI_USER,
-	}, nil)
+	}, nil, nil, 0)
 	t.interrupt()
 }
 
@@ -258,6 +258,8 @@ func (*runExitMain) execute(t *Task) taskRunState {
 	lastExiter := t.exitThreadGroup()
 
 	t.ResetKcov()
+	t.releaseSeccomp()
+	t.exitSchedAttr()
 
 	// If the task has a cleartid, and the thread group wasn't killed by a
 	// signal, handle that before releasing the MM.
@@ -299,6 +301,7 @@ func (*runExitMain) execute(t *Task) taskRunState {
 
 	t.fsContext.DecRef(t)
 	t.fdTable.DecRef(t)
+	t.exitSemUndoList()
 
 	// Detach task from all cgroups. This must happen before potentially the
 	// last ref to the cgroupfs mount is dropped below.
@@ -311,6 +314,10 @@ func (*runExitMain) execute(t *Task) taskRunState {
 	t.utsns = nil
 	ipcns := t.ipcns
 	t.ipcns = nil
+	timens := t.timens
+	t.timens = nil
+	childTimens := t.childTimens
+	t.childTimens = nil
 	netns := t.netns
 	t.netns = nil
 	childPIDNS := t.childPIDNamespace
@@ -319,6 +326,10 @@ func (*runExitMain) execute(t *Task) taskRunState {
 	mntns.DecRef(t)
 	utsns.DecRef(t)
 	ipcns.DecRef(t)
+	timens.DecRef(t)
+	if childTimens != nil {
+		childTimens.DecRef(t)
+	}
 	netns.DecRef(t)
 	if childPIDNS != nil {
 		childPIDNS.DecRef(t)
@@ -342,6 +353,33 @@ func (*runExitMain) execute(t *Task) taskRunState {
 	return (*runExitNotify)(nil)
 }
 
+// pidNamespaceExitStop is a TaskStop placed on an exiting PID namespace init
+// process while it waits for the other tasks in the namespace to be reaped.
+//
+// +stateify savable
+type pidNamespaceExitStop struct{}
+
+// Killable implements TaskStop.Killable. The stopped task is already exiting,
+// and must not complete its exit before the namespace is empty.
+func (*pidNamespaceExitStop) Killable() bool { return false }
+
+// maybeEndInitExitStopLocked ends the pidNamespaceExitStop of ns' init
+// process if no other thread groups remain in ns.
+//
+// Preconditions: The TaskSet mutex must be locked for writing.
+func (ns *PIDNamespace) maybeEndInitExitStopLocked() {
+	init := ns.exitingInit
+	if init == nil || len(ns.tgids) > 1 {
+		return
+	}
+	ns.exitingInit = nil
+	init.tg.signalHandlers.mu.Lock()
+	if _, ok := init.stop.(*pidNamespaceExitStop); ok {
+		init.endInternalStopLocked()
+	}
+	init.tg.signalHandlers.mu.Unlock()
+}
+
 // exitThreadGroup transitions t to TaskExitInitiated, indicating to t's thread
 // group that it is no longer eligible to participate in group activities. It
 // returns true if t is the last task in its thread group to call
@@ -395,11 +433,19 @@ func (t *Task) exitChildrenLocked() {
 			}, true /* group */)
 			other.signalHandlers.mu.Unlock()
 		}
-		// TODO(b/37722272): The init process waits for all processes in the
-		// namespace to exit before completing its own exit
+		// The init process waits for all processes in the namespace to exit
+		// before completing its own exit
 		// (kernel/pid_namespace.c:zap_pid_ns_processes()). Stop until all
 		// other tasks in the namespace are dead, except possibly for this
 		// thread group's leader (which can't be reaped until this task exits).
+		// Since killed tasks in nested namespaces are visible in this one,
+		// this also waits for nested namespaces to die.
+		if t.tg.isInitInLocked(t.tg.pidns) && len(t.tg.pidns.tgids) > 1 {
+			t.tg.pidns.exitingInit = t
+			t.tg.signalHandlers.mu.Lock()
+			t.beginInternalStopLocked((*pidNamespaceExitStop)(nil))
+			t.tg.signalHandlers.mu.Unlock()
+		}
 	}
 	// This is correct even if newParent is nil (it ensures that children don't
 	// wait for a parent to reap them.)
@@ -646,6 +692,10 @@ func (t *Task) exitNotifyLocked(fromPtraceDetach bool) {
 	if t.exitStateLocked() != TaskExitZombie {
 		return
 	}
+	if t == t.tg.leader && t.tg.tasksCount == 1 {
+		// Compare Linux's kernel/signal.c:do_notify_pidfd().
+		t.tg.exitQueue.Notify(waiter.ReadableEvents)
+	}
 	if !t.exitTracerNotified {
 		t.exitTracerNotified = true
 		tracer := t.Tracer()
@@ -750,14 +800,25 @@ func (t *Task) exitNotifyLocked(fromPtraceDetach bool) {
 		t.advanceExitStateLocked(TaskExitZombie, TaskExitDead)
 		for ns := t.tg.pidns; ns != nil; ns = ns.parent {
 			ns.deleteTask(t)
+			ns.maybeEndInitExitStopLocked()
 		}
 		t.userCounters.decRLimitNProc()
 		t.tg.signalHandlers.mu.Lock()
+		// Release pending signals' charges against RLIMIT_SIGPENDING; compare
+		// Linux's kernel/exit.c:__exit_signal() => flush_sigqueue().
+		t.pendingSignals.discardAll()
 		t.tg.tasks.Remove(t)
 		t.tg.tasksCount--
 		tc := t.tg.tasksCount
+		if tc == 0 {
+			t.tg.pendingSignals.discardAll()
+		}
 		t.tg.signalHandlers.mu.Unlock()
 		t.tg.ioUsage.Accumulate(t.ioUsage)
+		t.tg.delayUsage.Accumulate(t.delayUsage)
+		app, sys := t.cpuTimes()
+		t.tg.exitedAppCPUTime += app.Nanoseconds()
+		t.tg.exitedSysCPUTime += sys.Nanoseconds()
 		if tc == 1 && t != t.tg.leader {
 			// Our fromPtraceDetach doesn't matter here (in Linux terms, this
 			// is via a call to release_task()).
@@ -765,6 +826,7 @@ func (t *Task) exitNotifyLocked(fromPtraceDetach bool) {
 		} else if tc == 0 {
 			t.tg.pidWithinNS.Store(0)
 			t.tg.processGroup.decRefWithParent(t.tg.parentPG())
+			t.tg.exitQueue.Notify(waiter.ReadableEvents | waiter.EventHUp)
 		}
 		if t.parent != nil {
 			delete(t.parent.children, t)
@@ -818,6 +880,9 @@ func (t *Task) execOnDestroyActions() {
 	// Run in another goroutine to avoid extra lock dependencies.
 	go func() {
 		defer t.k.tasks.aioGoroutines.Done()
+		if t.k.OnPanic != nil {
+			defer t.k.ReportPanic(nil)
+		}
 		for act := range actions {
 			act.TaskDestroyAction(ctx)
 		}
@@ -889,6 +954,33 @@ func (tg *ThreadGroup) TerminationSignal() linux.Signal {
 	return tg.terminationSignal
 }
 
+// ExitReadiness returns the pidfd readiness of the thread group: it is readable
+// once the thread group has exited (its leader is a zombie and all other tasks
+// have been reaped), and additionally hung up once the leader has been reaped.
+func (tg *ThreadGroup) ExitReadiness(mask waiter.EventMask) waiter.EventMask {
+	tg.pidns.owner.mu.RLock()
+	defer tg.pidns.owner.mu.RUnlock()
+	var ready waiter.EventMask
+	switch {
+	case tg.tasksCount == 0:
+		ready = waiter.ReadableEvents | waiter.EventHUp
+	case tg.tasksCount == 1 && tg.leader.exitStateLocked() >= TaskExitZombie:
+		ready = waiter.ReadableEvents
+	}
+	return mask & ready
+}
+
+// ExitEventRegister registers e to be notified when the thread group's
+// readiness as returned by ExitReadiness may have changed.
+func (tg *ThreadGroup) ExitEventRegister(e *waiter.Entry) {
+	tg.exitQueue.EventRegister(e)
+}
+
+// ExitEventUnregister unregisters e from exit notifications.
+func (tg *ThreadGroup) ExitEventUnregister(e *waiter.Entry) {
+	tg.exitQueue.EventUnregister(e)
+}
+
 // Task events that can be waited for.
 const (
 	// EventExit represents an exit notification generated for a child thread
@@ -1190,7 +1282,7 @@ func (t *Task) waitCollectZombieLocked(target *Task, opts *WaitOptions, asPtrace
 	if target.parent != nil && target.parent.tg == t.tg && target.exitParentNotified {
 		target.exitParentAcked = true
 		if target == target.tg.leader {
-			t.tg.childCPUStats.Accumulate(target.tg.CPUStats())
+			t.tg.childCPUStats.Accumulate(target.tg.cpuStatsLocked())
 			t.tg.childCPUStats.Accumulate(target.tg.childCPUStats)
 			// Update t's child max resident set size. The size will be the maximum
 			// of this thread's size and all its childrens' sizes.
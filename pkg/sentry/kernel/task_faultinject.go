@@ -0,0 +1,44 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/faultinject"
+)
+
+// InjectFault injects the fault, if any, configured for the operation called
+// name in layer, by blocking t for the fault's delay. It returns the error
+// that the operation must fail with, or nil.
+//
+// If t is interrupted while delayed, InjectFault returns
+// linuxerr.ErrInterrupted.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) InjectFault(layer faultinject.Layer, name string) error {
+	f, ok := faultinject.Check(layer, name)
+	if !ok {
+		return nil
+	}
+	if f.Delay > 0 {
+		if _, err := t.BlockWithTimeout(nil, true, f.Delay); err != nil && !linuxerr.Equals(linuxerr.ETIMEDOUT, err) {
+			return linuxerr.ErrInterrupted
+		}
+	}
+	if f.Errno != 0 {
+		return f.Errno
+	}
+	return nil
+}
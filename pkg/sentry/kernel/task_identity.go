@@ -18,6 +18,7 @@ import (
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/loader"
 	"gvisor.dev/gvisor/pkg/sentry/mm"
 )
 
@@ -465,27 +466,33 @@ func (t *Task) SetKeepCaps(k bool) {
 	t.creds.Store(creds)
 }
 
-// updateCredsForExec updates t.creds to reflect an execve().
-//
-// NOTE(b/30815691): We currently do not implement privileged executables
-// (set-user/group-ID bits and file capabilities). This allows us to make a lot
-// of simplifying assumptions:
-//
-//   - We assume the no_new_privs bit (set by prctl(SET_NO_NEW_PRIVS)), which
-//     disables the features we don't support anyway, is always set. This
-//     drastically simplifies this function.
+// NoNewPrivs returns true if t's no_new_privs bit is set.
+func (t *Task) NoNewPrivs() bool {
+	return t.noNewPrivs.Load()
+}
+
+// SetNoNewPrivs sets t's no_new_privs bit, as for
+// prctl(PR_SET_NO_NEW_PRIVS, 1).
 //
-//   - We don't set AT_SECURE = 1, because no_new_privs always being set means
-//     that the conditions that require AT_SECURE = 1 never arise. (Compare Linux's
-//     security/commoncap.c:cap_bprm_set_creds() and cap_bprm_secureexec().)
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) SetNoNewPrivs() {
+	t.noNewPrivs.Store(true)
+}
+
+// credsForExecLocked returns the credentials that t will have after an
+// execve() of the file filename, whose set-user-ID and set-group-ID mode bits
+// grant setID and whose security.capability extended attribute is fileCaps.
+// It also returns true if the execve() is a secure-execution one, i.e.
+// AT_SECURE must be set. It is analogous to Linux's
+// security/commoncap.c:cap_bprm_creds_from_file().
 //
-//   - We don't check for CAP_SYS_ADMIN in prctl(PR_SET_SECCOMP), since
-//     seccomp-bpf is also allowed if the task has no_new_privs set.
+// The result depends on t's tracer, so execve() computes the credentials
+// again when it commits them; see runSyscallAfterExecStop.
 //
-//   - Task.ptraceAttach does not serialize with execve as it does in Linux,
-//     since no_new_privs being set has the same effect as the presence of an
-//     unprivileged tracer.
-func (t *Task) updateCredsForExec() {
+// Preconditions:
+//   - The caller must be running on the task goroutine.
+//   - t.tg.pidns.owner.mu and t.tg.signalHandlers.mu must be locked.
+func (t *Task) credsForExecLocked(filename string, setID loader.SetID, fileCaps string) (*auth.Credentials, bool, error) {
 	// """
 	// During an execve(2), the kernel calculates the new capabilities of
 	// the process using the following algorithm:
@@ -533,19 +540,26 @@ func (t *Task) updateCredsForExec() {
 	// As the last paragraph implies, the case of "a set-user-ID root program
 	// is being executed" also includes the case where (namespace) root is
 	// executing a non-set-user-ID program; the actual check is just based on
-	// the effective user ID.
-	var newPermitted auth.CapabilitySet // since F(inheritable) == F(permitted) == 0
-	fileEffective := false
-	creds := t.Credentials()
-	root := creds.UserNamespace.MapToKUID(auth.RootUID)
-	if creds.EffectiveKUID == root || creds.RealKUID == root {
-		newPermitted = creds.InheritableCaps | creds.BoundingCaps
-		if creds.EffectiveKUID == root {
-			fileEffective = true
+	// the effective user ID. auth.UpdateCredsForExec implements all of the
+	// above, including the decoding of F from the executable's
+	// security.capability extended attribute.
+	oldCreds := t.Credentials()
+	creds := oldCreds.Fork() // The credentials object is immutable. See doc for creds.
+	// Set-user-ID and set-group-ID are ignored if no_new_privs is set. See
+	// fs/exec.c:bprm_fill_uid().
+	if !t.NoNewPrivs() {
+		if setID.UID.Ok() {
+			creds.EffectiveKUID = setID.UID
+		}
+		if setID.GID.Ok() {
+			creds.EffectiveKGID = setID.GID
 		}
 	}
-
-	creds = creds.Fork() // The credentials object is immutable. See doc for creds.
+	effective, err := auth.UpdateCredsForExec(creds, fileCaps, filename)
+	if err != nil {
+		return nil, false, err
+	}
+	idChanged := creds.EffectiveKUID != oldCreds.EffectiveKUID || !oldCreds.InGroup(creds.EffectiveKGID)
 
 	// Now we enter poorly-documented, somewhat confusing territory. (The
 	// accompanying comment in Linux's security/commoncap.c:cap_bprm_set_creds
@@ -583,26 +597,32 @@ func (t *Task) updateCredsForExec() {
 	// C2. If either the task does not have CAP_SETUID in its user namespace, or
 	// the task has no_new_privs set, force the new effective UID and GID to
 	// the task's real UID and GID.
-	//
-	// But since no_new_privs is always set (A3 is always true), this becomes
-	// much simpler. If B1 and B2 are false, C2 is a no-op. If B3 is false, C1
-	// is a no-op. So we can just do C1 and C2 unconditionally.
-	if creds.EffectiveKUID != creds.RealKUID || creds.EffectiveKGID != creds.RealKGID {
-		creds.EffectiveKUID = creds.RealKUID
-		creds.EffectiveKGID = creds.RealKGID
-		t.parentDeathSignal = 0
+	isSetID := creds.EffectiveKUID != creds.RealKUID || creds.EffectiveKGID != creds.RealKGID
+	capGained := creds.PermittedCaps&^oldCreds.PermittedCaps != 0
+	if (isSetID || capGained) && t.unsafeExecLocked(creds) {
+		if t.NoNewPrivs() || !oldCreds.HasCapability(linux.CAP_SETUID) {
+			creds.EffectiveKUID = creds.RealKUID
+			creds.EffectiveKGID = creds.RealKGID
+		}
+		creds.PermittedCaps &= oldCreds.PermittedCaps
+		creds.EffectiveCaps &= creds.PermittedCaps
 	}
 	// (Saved set-user-ID is always set to the new effective user ID, and saved
 	// set-group-ID is always set to the new effective group ID, regardless of
 	// the above.)
-	creds.SavedKUID = creds.RealKUID
-	creds.SavedKGID = creds.RealKGID
-	creds.PermittedCaps &= newPermitted
-	if fileEffective {
-		creds.EffectiveCaps = creds.PermittedCaps
-	} else {
-		creds.EffectiveCaps = 0
-	}
+	creds.SavedKUID = creds.EffectiveKUID
+	creds.SavedKGID = creds.EffectiveKGID
+
+	// The execve() is a secure-execution one if it changed, or would have
+	// changed absent the limits above, the task's effective IDs; if the new
+	// effective IDs differ from the real ones; or if a task other than real
+	// root gains capabilities. (Ambient capabilities are not supported, so
+	// any permitted capability counts as gained.)
+	root := creds.UserNamespace.MapToKUID(auth.RootUID)
+	secure := idChanged ||
+		creds.EffectiveKUID != oldCreds.RealKUID ||
+		creds.EffectiveKGID != oldCreds.RealKGID ||
+		(creds.RealKUID != root && (effective || creds.PermittedCaps != 0))
 
 	// prctl(2): The "keep capabilities" value will be reset to 0 on subsequent
 	// calls to execve(2).
@@ -610,5 +630,63 @@ func (t *Task) updateCredsForExec() {
 
 	// "The bounding set is inherited at fork(2) from the thread's parent, and
 	// is preserved across an execve(2)". So we're done.
+	return creds, secure, nil
+}
+
+// unsafeExecLocked returns true if any of the conditions A1-A3 described in
+// Task.credsForExecLocked hold. It is analogous to Linux's
+// fs/exec.c:check_unsafe_exec() and security/commoncap.c:ptracer_capable().
+//
+// Preconditions:
+//   - The caller must be running on the task goroutine.
+//   - t.tg.pidns.owner.mu and t.tg.signalHandlers.mu must be locked.
+func (t *Task) unsafeExecLocked(creds *auth.Credentials) bool {
+	if t.NoNewPrivs() {
+		return true
+	}
+	// Linux checks the tracer's credentials at the time of PTRACE_ATTACH; we
+	// use the tracer's current credentials instead.
+	if tracer := t.Tracer(); tracer != nil && !tracer.Credentials().HasCapabilityIn(linux.CAP_SYS_PTRACE, creds.UserNamespace) {
+		return true
+	}
+	// FSContext references are only held by tasks, so the FS context is
+	// shared with another thread group iff it has more references than there
+	// are tasks in t's thread group using it.
+	fsContext := t.fsContext
+	users := int64(1)
+	for sibling := t.tg.tasks.Front(); sibling != nil; sibling = sibling.Next() {
+		if sibling == t {
+			continue
+		}
+		sibling.mu.Lock()
+		if sibling.fsContext == fsContext {
+			users++
+		}
+		sibling.mu.Unlock()
+	}
+	return fsContext.ReadRefs() > users
+}
+
+// commitCredsForExec installs creds, as returned by Task.credsForExecLocked,
+// as t's credentials. It returns true if the execve() changed t's effective
+// IDs or raised its permitted capabilities, in which case the new address
+// space must not be dumpable. Compare Linux's kernel/cred.c:commit_creds() and
+// fs/exec.c:begin_new_exec().
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) commitCredsForExec(creds *auth.Credentials) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	oldCreds := t.Credentials()
+	privileged := false
+	if creds.EffectiveKUID != oldCreds.EffectiveKUID || creds.EffectiveKGID != oldCreds.EffectiveKGID ||
+		creds.PermittedCaps&^oldCreds.PermittedCaps != 0 {
+		t.parentDeathSignal = 0
+		privileged = true
+	}
+	if creds.EffectiveKUID != creds.RealKUID || creds.EffectiveKGID != creds.RealKGID {
+		privileged = true
+	}
 	t.creds.Store(creds)
+	return privileged
 }
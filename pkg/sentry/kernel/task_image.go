@@ -17,6 +17,7 @@ package kernel
 import (
 	"fmt"
 
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/abi/linux/errno"
 	"gvisor.dev/gvisor/pkg/context"
 	"gvisor.dev/gvisor/pkg/hostarch"
@@ -53,6 +54,17 @@ type TaskImage struct {
 
 	// fileCaps is the image's extended attribute named security.capability.
 	fileCaps string
+
+	// unreadable is true if the image was loaded from an executable that the
+	// task may not read.
+	unreadable bool
+
+	// setID holds the identities granted by the image's set-user-ID and
+	// set-group-ID mode bits.
+	setID loader.SetID
+
+	// auxvStart is the address of the image's auxiliary vector on its stack.
+	auxvStart hostarch.Addr
 }
 
 // FileCaps return the task image's security.capability extended attribute.
@@ -141,16 +153,32 @@ func (t *Task) Stack() *arch.Stack {
 	}
 }
 
-// LoadTaskImage loads a specified file into a new TaskImage.
+// LoadTaskImage loads a specified file into a new TaskImage, for a task in
+// the container with ID cid.
 //
 // args.MemoryManager does not need to be set by the caller.
-func (k *Kernel) LoadTaskImage(ctx context.Context, args loader.LoadArgs) (*TaskImage, *syserr.Error) {
+func (k *Kernel) LoadTaskImage(ctx context.Context, cid string, args loader.LoadArgs) (*TaskImage, *syserr.Error) {
 	// Prepare a new user address space to load into.
 	m := mm.NewMemoryManager(k, k.mf, k.SleepForAddressSpaceActivation)
 	defer m.DecUsers(ctx)
 	args.MemoryManager = m
+	args.RandomizeVASpace = k.RandomizeVASpace.Load()
+	if args.THPDisabled {
+		m.SetTHPDisabled(true)
+		args.TextSegments.Huge = false
+	}
+	if t := TaskFromContext(ctx); t != nil {
+		if args.AsyncContext == nil {
+			args.AsyncContext = t.AsyncContext
+		}
+		args.AddrNoRandomize = t.personality&linux.ADDR_NO_RANDOMIZE != 0
+	}
 
-	info, err := loader.Load(ctx, args, k.extraAuxv, k.vdso)
+	vdso := k.containerVDSO(cid)
+	if vdso != nil {
+		defer vdso.Release(ctx)
+	}
+	info, err := loader.Load(ctx, args, k.extraAuxv, vdso)
 	if err != nil {
 		return nil, err
 	}
@@ -173,5 +201,22 @@ func (k *Kernel) LoadTaskImage(ctx context.Context, args loader.LoadArgs) (*Task
 		fu:            k.futexes.Fork(),
 		st:            st,
 		fileCaps:      info.FileCaps,
+		unreadable:    info.Unreadable,
+		setID:         info.SetID,
+		auxvStart:     info.AuxvStart,
 	}, nil
 }
+
+// Personality returns t's personality.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) Personality() uint32 {
+	return t.personality
+}
+
+// SetPersonality sets t's personality. It takes effect on t's next execve.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) SetPersonality(personality uint32) {
+	t.personality = personality
+}
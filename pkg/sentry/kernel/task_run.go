@@ -69,6 +69,10 @@ func (t *Task) run(threadID uintptr) {
 	t.blockingTimer = ktime.NewSampledTimer(t.k.MonotonicClock(), t.blockingTimerListener)
 	defer t.blockingTimer.Destroy()
 
+	if t.k.OnPanic != nil {
+		defer t.k.ReportPanic(t)
+	}
+
 	// Activate our address space.
 	t.Activate()
 	// The corresponding t.Deactivate occurs in the exit path
@@ -271,6 +275,8 @@ func (app *runApp) execute(t *Task) taskRunState {
 	case platform.ErrContextInterrupt:
 		// Interrupted by platform.Context.Interrupt(). Re-enter the run
 		// loop to figure out why.
+		t.interruptCount.Add(1)
+		t.tg.interruptCount.Add(1)
 		return (*runApp)(nil)
 
 	case platform.ErrContextSignal:
@@ -287,10 +293,20 @@ func (app *runApp) execute(t *Task) taskRunState {
 
 			region := trace.StartRegion(t.traceContext, faultRegion)
 			addr := hostarch.Addr(info.Addr())
+			t.recordFlight(FlightFault, uint64(addr))
+			blkIOCount := t.delayUsage.BlkIOCount.Load()
 			err := t.MemoryManager().HandleUserFault(t, addr, at, hostarch.Addr(t.Arch().Stack()))
 			region.End()
 			if err == nil {
-				// The fault was handled appropriately.
+				// The fault was handled appropriately. It was a major fault
+				// if handling it required waiting for I/O.
+				if t.delayUsage.BlkIOCount.Load() != blkIOCount {
+					t.majorFaults.Add(1)
+					t.tg.majorFaults.Add(1)
+				} else {
+					t.minorFaults.Add(1)
+					t.tg.minorFaults.Add(1)
+				}
 				// We can resume running the application.
 				return (*runApp)(nil)
 			}
@@ -18,14 +18,15 @@ package kernel
 
 import (
 	"fmt"
-	"math/rand/v2"
 	"time"
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/gohacks"
 	"gvisor.dev/gvisor/pkg/sentry/hostcpu"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/sched"
 	"gvisor.dev/gvisor/pkg/sentry/ktime"
+	"gvisor.dev/gvisor/pkg/sentry/mm"
 	"gvisor.dev/gvisor/pkg/sentry/usage"
 )
 
@@ -89,12 +90,16 @@ func (t *Task) accountTaskGoroutineEnter(state TaskGoroutineState) {
 		panic(fmt.Sprintf("Task goroutine switching from state %v (expected %v) to %v", oldState, TaskGoroutineRunningSys, state))
 	}
 	t.gostateSeq.BeginWrite()
+	t.accountCPUTime(TaskGoroutineRunningSys)
 	t.gostate.Store(uint32(state))
 	t.touchGostateTime()
 	t.gostateSeq.EndWrite()
+	t.recordFlight(FlightStateChange, uint64(state))
 	if state != TaskGoroutineRunningApp {
 		// Task is blocking/stopping.
 		t.k.decRunningTasks()
+		t.yieldCount.Add(1)
+		t.tg.yieldCount.Add(1)
 	}
 }
 
@@ -111,9 +116,106 @@ func (t *Task) accountTaskGoroutineLeave(state TaskGoroutineState) {
 		panic(fmt.Sprintf("Task goroutine switching from state %v (expected %v) to %v", oldState, state, TaskGoroutineRunningSys))
 	}
 	t.gostateSeq.BeginWrite()
+	t.accountCPUTime(state)
 	t.gostate.Store(uint32(TaskGoroutineRunningSys))
 	t.touchGostateTime()
 	t.gostateSeq.EndWrite()
+	t.recordFlight(FlightStateChange, uint64(TaskGoroutineRunningSys))
+}
+
+// accountCPUTime charges the time elapsed since the last change to t.gostate
+// to t's precise CPU time for oldState, the state being left.
+//
+// Preconditions:
+//   - The caller must be running on the task goroutine.
+//   - The caller must be in a t.gostateSeq writer critical section.
+func (t *Task) accountCPUTime(oldState TaskGoroutineState) {
+	now := t.k.taskCPUClockNow()
+	switch oldState {
+	case TaskGoroutineRunningApp:
+		t.appCPUTime.Add(now - t.cpuTimeStamp.Load())
+	case TaskGoroutineRunningSys:
+		t.sysCPUTime.Add(now - t.cpuTimeStamp.Load())
+	}
+	t.cpuTimeStamp.Store(now)
+}
+
+// cpuTimes returns the time t's task goroutine has spent executing application
+// and sentry code respectively, including the current execution interval if
+// any.
+func (t *Task) cpuTimes() (app, sys time.Duration) {
+	var (
+		state TaskGoroutineState
+		stamp int64
+	)
+	for {
+		epoch := t.gostateSeq.BeginRead()
+		state = t.TaskGoroutineState()
+		app = time.Duration(t.appCPUTime.Load())
+		sys = time.Duration(t.sysCPUTime.Load())
+		stamp = t.cpuTimeStamp.Load()
+		if t.gostateSeq.ReadOk(epoch) {
+			break
+		}
+	}
+	switch state {
+	case TaskGoroutineRunningApp:
+		app += time.Duration(t.k.taskCPUClockNow() - stamp)
+	case TaskGoroutineRunningSys:
+		sys += time.Duration(t.k.taskCPUClockNow() - stamp)
+	}
+	return app, sys
+}
+
+// taskCPUClockNow returns the current value of the task CPU clock, in
+// nanoseconds. See Kernel.cpuShareBase.
+func (k *Kernel) taskCPUClockNow() int64 {
+	for {
+		epoch := k.cpuShareSeq.BeginRead()
+		// Read the time within the reader critical section so that the clock
+		// can't go backwards across updateCPUShare.
+		now := k.taskCPUClockAt(gohacks.Nanotime())
+		if k.cpuShareSeq.ReadOk(epoch) {
+			return now
+		}
+	}
+}
+
+// taskCPUClockAt returns the value of the task CPU clock at
+// gohacks.Nanotime() == now.
+//
+// Preconditions: The caller must be in a k.cpuShareSeq critical section.
+func (k *Kernel) taskCPUClockAt(now int64) int64 {
+	elapsed := now - k.cpuShareStamp.Load()
+	if cores, running := int64(k.applicationCores), k.cpuShareRunning.Load(); running > cores {
+		elapsed = elapsed * cores / running
+	}
+	return k.cpuShareBase.Load() + elapsed
+}
+
+// updateCPUShare advances the task CPU clock to the current time, and samples
+// the number of running tasks that subsequently share applicationCores CPUs.
+//
+// Preconditions: The caller must be the CPU clock ticker goroutine.
+func (k *Kernel) updateCPUShare() {
+	k.cpuShareSeq.BeginWrite()
+	now := gohacks.Nanotime()
+	k.cpuShareBase.Store(k.taskCPUClockAt(now))
+	k.cpuShareStamp.Store(now)
+	k.cpuShareRunning.Store(k.runningTasks.Load())
+	k.cpuShareSeq.EndWrite()
+}
+
+// Preconditions: The TaskSet mutex must be locked.
+func (tg *ThreadGroup) cpuTimesLocked() (app, sys time.Duration) {
+	app = time.Duration(tg.exitedAppCPUTime)
+	sys = time.Duration(tg.exitedSysCPUTime)
+	for t := tg.tasks.Front(); t != nil; t = t.Next() {
+		tapp, tsys := t.cpuTimes()
+		app += tapp
+		sys += tsys
+	}
+	return app, sys
 }
 
 // Preconditions: The caller must be running on the task goroutine.
@@ -127,56 +229,96 @@ func (k *Kernel) CPUClockNow() ktime.Time {
 	return ktime.FromNanoseconds(k.cpuClock.Load())
 }
 
+// cpuClock is a ktime.Clock that reads the precise CPU time of a task or
+// thread group, but whose timers are driven by the corresponding
+// SyntheticClock, which lags it by at most one clock tick.
+//
+// +stateify savable
+type cpuClock struct {
+	// Exactly one of t and tg is non-nil. t, tg, user and timerClock are
+	// immutable.
+	t          *Task
+	tg         *ThreadGroup
+	user       bool
+	timerClock *ktime.SyntheticClock
+}
+
+// Now implements ktime.Clock.Now.
+func (c *cpuClock) Now() ktime.Time {
+	var app, sys time.Duration
+	if c.t != nil {
+		app, sys = c.t.cpuTimes()
+	} else {
+		c.tg.pidns.owner.mu.RLock()
+		app, sys = c.tg.cpuTimesLocked()
+		c.tg.pidns.owner.mu.RUnlock()
+	}
+	if c.user {
+		return ktime.FromNanoseconds(app.Nanoseconds())
+	}
+	return ktime.FromNanoseconds((app + sys).Nanoseconds())
+}
+
+// NewTimer implements ktime.Clock.NewTimer.
+func (c *cpuClock) NewTimer(l ktime.Listener) ktime.Timer {
+	return c.timerClock.NewTimer(l)
+}
+
 // UserCPUClock returns a clock measuring the CPU time the task has spent
 // executing application code.
 func (t *Task) UserCPUClock() ktime.Clock {
-	return &t.appCPUClock
+	return &cpuClock{t: t, user: true, timerClock: &t.appCPUClock}
 }
 
 // CPUClock returns a clock measuring the CPU time the task has spent executing
 // application and "kernel" code.
 func (t *Task) CPUClock() ktime.Clock {
-	return &t.appSysCPUClock
+	return &cpuClock{t: t, timerClock: &t.appSysCPUClock}
 }
 
 // UserCPUClock returns a ktime.Clock that measures the time that a thread
 // group has spent executing.
 func (tg *ThreadGroup) UserCPUClock() ktime.Clock {
-	return &tg.appCPUClock
+	return &cpuClock{tg: tg, user: true, timerClock: &tg.appCPUClock}
 }
 
 // CPUClock returns a ktime.Clock that measures the time that a thread group
 // has spent executing, including sentry time.
 func (tg *ThreadGroup) CPUClock() ktime.Clock {
-	return &tg.appSysCPUClock
+	return &cpuClock{tg: tg, timerClock: &tg.appSysCPUClock}
 }
 
 // CPUStats returns the CPU usage statistics of t.
 func (t *Task) CPUStats() usage.CPUStats {
-	// The CPU clock ticker advances t.appCPUClock before t.appSysCPUClock, so
-	// it's possible for the former to transiently exceed the latter.
-	appNS := t.appCPUClock.Now().Nanoseconds()
-	appSysNS := t.appSysCPUClock.Now().Nanoseconds()
-	sysNS := max(appSysNS-appNS, 0)
+	app, sys := t.cpuTimes()
 	return usage.CPUStats{
-		UserTime:          time.Duration(appNS),
-		SysTime:           time.Duration(sysNS),
-		VoluntarySwitches: t.yieldCount.Load(),
+		UserTime:            app,
+		SysTime:             sys,
+		VoluntarySwitches:   t.yieldCount.Load(),
+		InvoluntarySwitches: t.interruptCount.Load(),
+		MinorFaults:         t.minorFaults.Load(),
+		MajorFaults:         t.majorFaults.Load(),
 	}
 }
 
 // CPUStats returns the combined CPU usage statistics of all past and present
 // threads in tg.
 func (tg *ThreadGroup) CPUStats() usage.CPUStats {
-	// The CPU clock ticker advances tg.appCPUClock before tg.appSysCPUClock,
-	// so it's possible for the former to transiently exceed the latter.
-	appNS := tg.appCPUClock.Now().Nanoseconds()
-	appSysNS := tg.appSysCPUClock.Now().Nanoseconds()
-	sysNS := max(appSysNS-appNS, 0)
+	tg.pidns.owner.mu.RLock()
+	defer tg.pidns.owner.mu.RUnlock()
+	return tg.cpuStatsLocked()
+}
+
+// Preconditions: The TaskSet mutex must be locked.
+func (tg *ThreadGroup) cpuStatsLocked() usage.CPUStats {
+	app, sys := tg.cpuTimesLocked()
 	return usage.CPUStats{
-		UserTime:          time.Duration(appNS),
-		SysTime:           time.Duration(sysNS),
-		VoluntarySwitches: tg.yieldCount.Load(),
+		UserTime:            app,
+		SysTime:             sys,
+		VoluntarySwitches:   tg.yieldCount.Load(),
+		InvoluntarySwitches: tg.interruptCount.Load(),
+		MinorFaults:         tg.minorFaults.Load(),
+		MajorFaults:         tg.majorFaults.Load(),
 	}
 }
 
@@ -192,11 +334,12 @@ func (tg *ThreadGroup) JoinedChildCPUStats() usage.CPUStats {
 }
 
 func (k *Kernel) runCPUClockTicker() {
-	// Storage reused between iterations of the main loop:
-	var (
-		allTasks []*Task
-		incTasks = make([]*Task, k.applicationCores)
-	)
+	if k.OnPanic != nil {
+		defer k.ReportPanic(nil)
+	}
+
+	// Storage reused between iterations of the main loop.
+	var allTasks []*Task
 
 	for {
 		// Stop CPU clocks while nothing is running.
@@ -225,65 +368,38 @@ func (k *Kernel) runCPUClockTicker() {
 		// Advance the "kernel CPU clock".
 		k.cpuClock.Add(linux.ClockTick.Nanoseconds())
 
+		// Cap the CPU time charged to running tasks for the next tick.
+		k.updateCPUShare()
+
 		// Advance CPU clocks. gVisor generally has no knowledge of when sentry
 		// or application code is actually running on a CPU (due to Go and/or
 		// host kernel scheduling, with significant variation between
-		// platforms), so CPU clocks are approximated. We do so by choosing up
-		// to applicationCores running tasks (randomly, using reservoir
-		// sampling) and accounting a full CPU clock tick to each of those
-		// tasks. The alternative would be to distribute CPU time evenly to all
-		// running tasks, but:
-		//
-		// - If the CPU time per task is between 0 and 1 (nanoseconds), then
-		// neither rounded value is desirable: 0 would cause all CPU clocks to
-		// cease advancing, while 1 would cause the total CPU time accrued by
-		// all tasks to exceed the number of claimed CPUs.
-		//
-		// - This would require us to mutate CPU clocks and check timers for
-		// all running tasks and their thread groups, rather than only up to
-		// applicationCores running tasks (and their thread groups).
+		// platforms), so CPU time is approximated by the time each task
+		// goroutine has spent in a running state, as measured at every task
+		// goroutine state change (see Task.accountCPUTime), with running
+		// tasks sharing applicationCores CPUs equally if there are more of
+		// them (see Kernel.cpuShareBase). Reads of CPU
+		// clocks use this measurement directly; here we only need to bring
+		// the SyntheticClocks that drive CPU timers up to date, so that timers
+		// expire at most one clock tick late.
 		allTasks = k.tasks.Root.TasksAppend(allTasks)
-		runningTasks := 0
 		for _, t := range allTasks {
-			state := t.TaskGoroutineState()
-			if state != TaskGoroutineRunningApp && state != TaskGoroutineRunningSys {
-				continue
-			}
-			if runningTasks < len(incTasks) {
-				incTasks[runningTasks] = t
-				runningTasks++
-				continue
-			}
-			runningTasks++
-			if i := rand.IntN(runningTasks); i < len(incTasks) {
-				incTasks[i] = t
-			}
-		}
-		numIncTasks := min(runningTasks, len(incTasks))
-		// Shuffle incTasks to ensure that if multiple tasks are in the same
-		// thread group, then all are equally likely to be
-		// ThreadGroup.app[Sys]CPUClockLast when a ThreadGroup CPU timer fires.
-		rand.Shuffle(numIncTasks, func(i, j int) {
-			incTasks[i], incTasks[j] = incTasks[j], incTasks[i]
-		})
-		for _, t := range incTasks[:numIncTasks] {
-			switch t.TaskGoroutineState() {
-			case TaskGoroutineRunningApp:
-				t.appCPUClock.Add(linux.ClockTick)
+			app, sys := t.cpuTimes()
+			if d := app - time.Duration(t.appCPUClock.Now().Nanoseconds()); d > 0 {
+				t.appCPUClock.Add(d)
 				t.tg.appCPUClockLast.Store(t)
-				t.tg.appCPUClock.Add(linux.ClockTick)
-				fallthrough
-			case TaskGoroutineRunningSys:
-				t.appSysCPUClock.Add(linux.ClockTick)
+				t.tg.appCPUClock.Add(d)
+			}
+			if d := app + sys - time.Duration(t.appSysCPUClock.Now().Nanoseconds()); d > 0 {
+				t.appSysCPUClock.Add(d)
 				t.tg.appSysCPUClockLast.Store(t)
-				t.tg.appSysCPUClock.Add(linux.ClockTick)
+				t.tg.appSysCPUClock.Add(d)
 			}
 		}
 
 		// Reset storage for the next iteration.
 		clear(allTasks)
 		allTasks = allTasks[:0]
-		clear(incTasks[:numIncTasks])
 	}
 }
 
@@ -368,6 +484,10 @@ func (t *Task) SetCPUMask(mask sched.CPUSet) error {
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	// SCHED_DEADLINE tasks are admitted on the bandwidth of all CPUs.
+	if t.schedAttr.Policy == linux.SCHED_DEADLINE && mask.NumCPUs() != t.k.applicationCores {
+		return linuxerr.EBUSY
+	}
 	t.allowedCPUMask = mask
 	t.cpu.Store(assignCPU(mask, rootTID))
 	return nil
@@ -419,6 +539,241 @@ func (t *Task) SetNiceness(n int) {
 	t.niceness = n
 }
 
+// SchedAttr is the scheduling policy of a task and its parameters, as set by
+// sched_setscheduler(2) and sched_setattr(2). The sentry doesn't schedule tasks
+// according to it; it is only tracked so that it can be reported consistently,
+// and so that the CPU bandwidth reserved by SCHED_DEADLINE tasks is subject to
+// the same admission control as on Linux.
+//
+// +stateify savable
+type SchedAttr struct {
+	// Policy is the scheduling policy, one of linux.SCHED_*.
+	Policy uint32
+
+	// ResetOnFork is true if children of the task revert to SCHED_NORMAL.
+	ResetOnFork bool
+
+	// Priority is the static priority of SCHED_FIFO and SCHED_RR tasks, and 0
+	// for other policies.
+	Priority uint32
+
+	// Runtime, Deadline and Period are the parameters of SCHED_DEADLINE tasks
+	// in nanoseconds, and 0 for other policies.
+	Runtime  uint64
+	Deadline uint64
+	Period   uint64
+
+	// DeadlineFlags are the linux.SCHED_FLAG_RECLAIM and
+	// linux.SCHED_FLAG_DL_OVERRUN flags of SCHED_DEADLINE tasks.
+	DeadlineFlags uint64
+}
+
+const (
+	// deadlineBWShift is the fixed-point precision of CPU bandwidths, as
+	// BW_SHIFT in Linux.
+	deadlineBWShift = 20
+
+	// deadlineBWLimit is the bandwidth of each CPU that can be reserved by
+	// SCHED_DEADLINE tasks, 95% as per Linux's default sched_rt_runtime_us and
+	// sched_rt_period_us.
+	deadlineBWLimit = (950000 << deadlineBWShift) / 1000000
+)
+
+// bandwidth returns the CPU bandwidth reserved by a task with attributes a.
+func (a *SchedAttr) bandwidth() uint64 {
+	if a.Policy != linux.SCHED_DEADLINE || a.Period == 0 {
+		return 0
+	}
+	return (a.Runtime << deadlineBWShift) / a.Period
+}
+
+// updateDeadlineBandwidth replaces the CPU bandwidth oldBW reserved by a task
+// with newBW. It returns EBUSY if SCHED_DEADLINE tasks would reserve more
+// bandwidth than available.
+func (k *Kernel) updateDeadlineBandwidth(oldBW, newBW uint64) error {
+	if oldBW == newBW {
+		return nil
+	}
+	k.deadlineBandwidthMu.Lock()
+	defer k.deadlineBandwidthMu.Unlock()
+	total := k.deadlineBandwidth - oldBW + newBW
+	if newBW > oldBW && total > deadlineBWLimit*uint64(k.applicationCores) {
+		return linuxerr.EBUSY
+	}
+	k.deadlineBandwidth = total
+	return nil
+}
+
+// SchedAttr returns t's scheduling policy and parameters.
+func (t *Task) SchedAttr() SchedAttr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.schedAttr
+}
+
+// SetSchedAttr sets t's scheduling policy and parameters. Unlike Linux, the
+// niceness of t is left unchanged; see SetNiceness.
+//
+// It returns EPERM if attr.Policy is SCHED_DEADLINE but t is not allowed to
+// run on all CPUs, and EBUSY if the CPU bandwidth reserved by attr can't be
+// admitted.
+func (t *Task) SetSchedAttr(attr SchedAttr) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ExitState() != TaskExitNone {
+		return linuxerr.ESRCH
+	}
+	if attr.Policy == linux.SCHED_DEADLINE && t.allowedCPUMask.NumCPUs() != t.k.applicationCores {
+		return linuxerr.EPERM
+	}
+	if err := t.k.updateDeadlineBandwidth(t.schedAttr.bandwidth(), attr.bandwidth()); err != nil {
+		return err
+	}
+	t.schedAttr = attr
+	return nil
+}
+
+// childSchedAttr returns the scheduling policy, parameters and niceness
+// inherited by a child of t, following Linux's sched_fork().
+func (t *Task) childSchedAttr() (SchedAttr, int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	attr, niceness := t.schedAttr, t.niceness
+	if attr.ResetOnFork {
+		if attr.Policy == linux.SCHED_FIFO || attr.Policy == linux.SCHED_RR || attr.Policy == linux.SCHED_DEADLINE {
+			attr = SchedAttr{Policy: linux.SCHED_NORMAL}
+		} else {
+			attr.ResetOnFork = false
+		}
+		niceness = max(niceness, 0)
+	}
+	// SCHED_DEADLINE tasks can't fork, since the child's bandwidth would
+	// have to be admitted.
+	if attr.Policy == linux.SCHED_DEADLINE {
+		return SchedAttr{}, 0, linuxerr.EAGAIN
+	}
+	return attr, niceness, nil
+}
+
+// exitSchedAttr releases the CPU bandwidth reserved by t. It is called when t
+// exits.
+func (t *Task) exitSchedAttr() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.k.updateDeadlineBandwidth(t.schedAttr.bandwidth(), 0)
+}
+
+// NewCoreSchedCookie returns a new core scheduling cookie. Tasks with
+// different cookies don't trust each other enough to run on SMT siblings of
+// the same core at the same time. If k.HostCoreSchedCookies is true, the host
+// enforces this for platforms whose AddressSpaces implement
+// platform.HostCoreScheduler; otherwise, cookies only group tasks as far as
+// applications can observe.
+func (k *Kernel) NewCoreSchedCookie() uint64 {
+	return k.lastCoreSchedCookie.Add(1)
+}
+
+// CoreSchedCookie returns t's core scheduling cookie, or 0 if t has none.
+func (t *Task) CoreSchedCookie() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.coreSchedCookie
+}
+
+// hostCoreSchedCookie returns the core scheduling cookie whose host core tag
+// should be given to host threads executing t's application code.
+//
+// Host threads are shared by all tasks in the thread group, so they are core
+// tagged after the thread group leader.
+func (t *Task) hostCoreSchedCookie() uint64 {
+	if leader := t.tg.Leader(); leader != nil {
+		return leader.CoreSchedCookie()
+	}
+	return t.CoreSchedCookie()
+}
+
+// SetCoreSchedCookie sets the core scheduling cookie of target to cookie. If
+// scope is linux.PR_SCHED_CORE_SCOPE_THREAD_GROUP or
+// linux.PR_SCHED_CORE_SCOPE_PROCESS_GROUP, it sets the cookie of all tasks in
+// target's thread group or process group respectively. It returns EPERM,
+// without changing any cookie, if t may not trace one of the tasks.
+func (t *Task) SetCoreSchedCookie(target *Task, scope int32, cookie uint64) error {
+	mms, err := t.setCoreSchedCookie(target, scope, cookie)
+	if err != nil {
+		return err
+	}
+	// Core tagging host threads may wait for the platform, so it is done
+	// without kernel locks.
+	for _, m := range mms {
+		m.SetHostCoreSchedCookie(cookie)
+	}
+	return nil
+}
+
+// setCoreSchedCookie implements SetCoreSchedCookie. If k.HostCoreSchedCookies
+// is true, it returns the MemoryManagers of thread group leaders whose cookie
+// it set, whose host threads must be core tagged.
+func (t *Task) setCoreSchedCookie(target *Task, scope int32, cookie uint64) ([]*mm.MemoryManager, error) {
+	ts := t.tg.pidns.owner
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	var tasks []*Task
+	addThreadGroup := func(tg *ThreadGroup) {
+		for ot := tg.tasks.Front(); ot != nil; ot = ot.Next() {
+			tasks = append(tasks, ot)
+		}
+	}
+	switch scope {
+	case linux.PR_SCHED_CORE_SCOPE_THREAD:
+		tasks = append(tasks, target)
+	case linux.PR_SCHED_CORE_SCOPE_THREAD_GROUP:
+		addThreadGroup(target.tg)
+	case linux.PR_SCHED_CORE_SCOPE_PROCESS_GROUP:
+		pg := target.tg.processGroup
+		if pg == nil {
+			return nil, linuxerr.ESRCH
+		}
+		for tg := range ts.Root.tgids {
+			if tg.processGroup == pg {
+				addThreadGroup(tg)
+			}
+		}
+	default:
+		return nil, linuxerr.EINVAL
+	}
+
+	for _, ot := range tasks {
+		if !t.canTraceLocked(ot, false /* attach */) {
+			return nil, linuxerr.EPERM
+		}
+	}
+	var mms []*mm.MemoryManager
+	for _, ot := range tasks {
+		ot.mu.Lock()
+		ot.coreSchedCookie = cookie
+		if t.k.HostCoreSchedCookies && ot.tg.leader == ot && ot.image.MemoryManager != nil {
+			mms = append(mms, ot.image.MemoryManager)
+		}
+		ot.mu.Unlock()
+	}
+	return mms, nil
+}
+
+// IOFlusher returns true if t is an IO flusher.
+func (t *Task) IOFlusher() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ioFlusher
+}
+
+// SetIOFlusher marks t as an IO flusher or not.
+func (t *Task) SetIOFlusher(ioFlusher bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ioFlusher = ioFlusher
+}
+
 // NumaPolicy returns t's current numa policy.
 func (t *Task) NumaPolicy() (policy linux.NumaPolicy, nodeMask uint64) {
 	t.mu.Lock()
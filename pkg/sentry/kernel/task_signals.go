@@ -27,6 +27,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/arch"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
 	ucspb "gvisor.dev/gvisor/pkg/sentry/kernel/uncaught_signal_go_proto"
+	"gvisor.dev/gvisor/pkg/sentry/limits"
 	"gvisor.dev/gvisor/pkg/waiter"
 )
 
@@ -234,7 +235,12 @@ func (t *Task) deliverSignal(info *linux.SignalInfo, act linux.SigAction) taskRu
 // user-configured handler for the given signal.
 func (t *Task) deliverSignalToHandler(info *linux.SignalInfo, act linux.SigAction) error {
 	// Signal delivery to an application handler interrupts restartable
-	// sequences.
+	// sequences. As in Linux's rseq_signal_deliver(), the handler also sees
+	// up-to-date CPU IDs, since the task may have migrated since it last
+	// returned to userspace.
+	if err := t.rseqUpdateCPU(); err != nil {
+		return err
+	}
 	t.rseqInterrupt()
 
 	// Are executing on the main stack,
@@ -445,7 +451,7 @@ func (t *Task) sendSignalTimerLocked(info *linux.SignalInfo, group bool, timer *
 	if group {
 		q = &t.tg.pendingSignals
 	}
-	if !q.enqueue(info, timer) {
+	if !q.enqueue(info, timer, t.userCounters, t.tg.limits.Get(limits.SignalsPending).Cur) {
 		if sig.IsRealtime() {
 			return linuxerr.EAGAIN
 		}
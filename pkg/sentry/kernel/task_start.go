@@ -26,6 +26,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/futex"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/sched"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/semaphore"
 	"gvisor.dev/gvisor/pkg/sentry/usage"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
 )
@@ -69,6 +70,18 @@ type TaskConfig struct {
 	// Niceness is the niceness of the new task.
 	Niceness int
 
+	// SchedAttr is the scheduling policy of the new task.
+	SchedAttr SchedAttr
+
+	// CoreSchedCookie is the core scheduling cookie of the new task.
+	CoreSchedCookie uint64
+
+	// Personality is the personality of the new task.
+	Personality uint32
+
+	// IOFlusher is true if the new task is an IO flusher.
+	IOFlusher bool
+
 	// NetworkNamespace is the network namespace to be used for the new task.
 	NetworkNamespace *inet.Namespace
 
@@ -81,12 +94,26 @@ type TaskConfig struct {
 	// IPCNamespace is the IPCNamespace of the new task.
 	IPCNamespace *IPCNamespace
 
+	// TimeNamespace is the TimeNamespace of the new task.
+	TimeNamespace *TimeNamespace
+
+	// ChildTimeNamespace, if not nil, is the TimeNamespace that children of
+	// the new task will be members of. If nil, it is TimeNamespace.
+	ChildTimeNamespace *TimeNamespace
+
+	// SemUndoList is the System V semaphore undo list of the new task. It may
+	// be nil.
+	SemUndoList *semaphore.UndoList
+
 	// MountNamespace is the MountNamespace of the new task.
 	MountNamespace *vfs.MountNamespace
 
 	// RSeqAddr is a pointer to the userspace linux.RSeq structure.
 	RSeqAddr hostarch.Addr
 
+	// RSeqLen is the length of the userspace linux.RSeq structure.
+	RSeqLen uint32
+
 	// RSeqSignature is the signature that the rseq abort IP must be signed
 	// with.
 	RSeqSignature uint32
@@ -104,6 +131,9 @@ type TaskConfig struct {
 	// It may be nil.
 	SessionKeyring *auth.Key
 
+	// NoNewPrivs is the initial value of the new task's no_new_privs bit.
+	NoNewPrivs bool
+
 	Origin TaskOrigin
 }
 
@@ -121,6 +151,13 @@ func (ts *TaskSet) NewTask(ctx context.Context, cfg *TaskConfig) (*Task, error)
 		cfg.FDTable.DecRef(ctx)
 		cfg.UTSNamespace.DecRef(ctx)
 		cfg.IPCNamespace.DecRef(ctx)
+		cfg.TimeNamespace.DecRef(ctx)
+		if cfg.ChildTimeNamespace != nil {
+			cfg.ChildTimeNamespace.DecRef(ctx)
+		}
+		if cfg.SemUndoList != nil {
+			cfg.SemUndoList.DecRef(ctx, 0 /* pid */)
+		}
 		cfg.NetworkNamespace.DecRef(ctx)
 		if cfg.MountNamespace != nil {
 			cfg.MountNamespace.DecRef(ctx)
@@ -162,12 +199,21 @@ func (ts *TaskSet) newTask(ctx context.Context, cfg *TaskConfig) (*Task, error)
 		ptraceTracees:   make(map[*Task]struct{}),
 		allowedCPUMask:  cfg.AllowedCPUMask.Copy(),
 		ioUsage:         &usage.IO{},
+		delayUsage:      &usage.Delay{},
 		niceness:        cfg.Niceness,
+		schedAttr:       cfg.SchedAttr,
+		coreSchedCookie: cfg.CoreSchedCookie,
+		personality:     cfg.Personality,
+		ioFlusher:       cfg.IOFlusher,
 		utsns:           cfg.UTSNamespace,
 		ipcns:           cfg.IPCNamespace,
+		timens:          cfg.TimeNamespace,
+		childTimens:     cfg.ChildTimeNamespace,
+		semUndoList:     cfg.SemUndoList,
 		mountNamespace:  cfg.MountNamespace,
 		rseqCPU:         -1,
 		rseqAddr:        cfg.RSeqAddr,
+		rseqLen:         cfg.RSeqLen,
 		rseqSignature:   cfg.RSeqSignature,
 		futexWaiter:     futex.NewWaiter(),
 		containerID:     cfg.ContainerID,
@@ -176,9 +222,13 @@ func (ts *TaskSet) newTask(ctx context.Context, cfg *TaskConfig) (*Task, error)
 		sessionKeyring:  cfg.SessionKeyring,
 		Origin:          cfg.Origin,
 		onDestroyAction: make(map[TaskDestroyAction]struct{}),
+		noNewPrivs:      atomicbitops.FromBool(cfg.NoNewPrivs),
 	}
 	t.netns = cfg.NetworkNamespace
 	t.creds.Store(cfg.Credentials)
+	if cfg.Kernel.FlightRecorder {
+		t.flightRecorder = &flightRecorder{}
+	}
 	t.endStopCond.L = &t.tg.signalHandlers.mu
 	// We don't construct t.blockingTimer until Task.run(); see that function
 	// for justification.
@@ -189,7 +239,9 @@ func (ts *TaskSet) newTask(ctx context.Context, cfg *TaskConfig) (*Task, error)
 	)
 
 	// Reserve cgroup PIDs controller charge. This is either committed when the
-	// new task enters the cgroup below, or rolled back on failure.
+	// new task enters the cgroup below, or rolled back on failure. The charge
+	// goes to the cgroups the new task will enter, which are srcT's unless
+	// cfg.InitialCgroups is set.
 	//
 	// We may also get here from a non-task context (for example, when
 	// creating the init task, or from the exec control command). In these cases
@@ -197,7 +249,12 @@ func (ts *TaskSet) newTask(ctx context.Context, cfg *TaskConfig) (*Task, error)
 	// bypasses pid limits.
 	if srcT != nil {
 		var err error
-		if charged, cg, err = srcT.ChargeFor(t, CgroupControllerPIDs, CgroupResourcePID, 1); err != nil {
+		if cfg.InitialCgroups != nil {
+			charged, cg, err = chargeCgroupsFor(t, cfg.InitialCgroups, CgroupControllerPIDs, CgroupResourcePID, 1)
+		} else {
+			charged, cg, err = srcT.ChargeFor(t, CgroupControllerPIDs, CgroupResourcePID, 1)
+		}
+		if err != nil {
 			return nil, err
 		}
 		if charged {
@@ -24,6 +24,7 @@ import (
 	"gvisor.dev/gvisor/pkg/bits"
 	"gvisor.dev/gvisor/pkg/errors"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/faultinject"
 	"gvisor.dev/gvisor/pkg/hostarch"
 	"gvisor.dev/gvisor/pkg/marshal"
 	"gvisor.dev/gvisor/pkg/metric"
@@ -86,11 +87,18 @@ func (t *Task) executeSyscall(sysno uintptr, args arch.SyscallArguments) (rval u
 
 	fe := s.FeatureEnable.Word(sysno)
 
+	t.recordFlight(FlightSyscallEnter, uint64(sysno))
+
 	var straceContext any
 	if bits.IsAnyOn32(fe, StraceEnableBits) {
 		straceContext = s.Stracer.SyscallEnter(t, sysno, args, fe)
 	}
 
+	// A sink registered for a syscall enter point may veto the syscall by
+	// returning an error, in which case the syscall is not executed and the
+	// error is returned to the application. All sinks still see the enter
+	// and exit points of vetoed syscalls, and the first veto wins.
+	var vetoErr error
 	if bits.IsAnyOn32(fe, SecCheckRawEnter) {
 		info := pb.Syscall{
 			Sysno: uint64(sysno),
@@ -106,7 +114,7 @@ func (t *Task) executeSyscall(sysno uintptr, args arch.SyscallArguments) (rval u
 			info.ContextData = &pb.ContextData{}
 			LoadSeccheckData(t, fields.Context, info.ContextData)
 		}
-		seccheck.Global.SentToSinks(func(c seccheck.Sink) error {
+		vetoErr = seccheck.Global.SentToSinks(func(c seccheck.Sink) error {
 			return c.RawSyscall(t, fields, &info)
 		})
 	}
@@ -123,12 +131,30 @@ func (t *Task) executeSyscall(sysno uintptr, args arch.SyscallArguments) (rval u
 		}
 		cb := s.LookupSyscallToProto(sysno)
 		msg, msgType := cb(t, fields, ctxData, info)
-		seccheck.Global.SentToSinks(func(c seccheck.Sink) error {
+		if err := seccheck.Global.SentToSinks(func(c seccheck.Sink) error {
 			return c.Syscall(t, fields, ctxData, msgType, msg)
-		})
+		}); err != nil && vetoErr == nil {
+			vetoErr = err
+		}
 	}
 
-	if bits.IsOn32(fe, ExternalBeforeEnable) && (s.ExternalFilterBefore == nil || s.ExternalFilterBefore(t, sysno, args)) {
+	// Faults injected in chaos mode also prevent the syscall from being
+	// executed. See pkg/faultinject.
+	var faultErr error
+	if vetoErr == nil && faultinject.Enabled() {
+		faultErr = t.InjectFault(faultinject.LayerSyscall, s.LookupName(sysno))
+		if faultErr == linuxerr.ErrInterrupted {
+			// Restart the syscall, and possibly delay it again, after
+			// the interrupt has been handled.
+			faultErr = linuxerr.ERESTARTSYS
+		}
+	}
+
+	if vetoErr != nil {
+		err = sinkVetoError(vetoErr)
+	} else if faultErr != nil {
+		err = faultErr
+	} else if bits.IsOn32(fe, ExternalBeforeEnable) && (s.ExternalFilterBefore == nil || s.ExternalFilterBefore(t, sysno, args)) {
 		t.invokeExternal()
 		// Ensure we check for stops, then invoke the syscall again.
 		ctrl = ctrlStopAndReinvokeSyscall
@@ -155,6 +181,8 @@ func (t *Task) executeSyscall(sysno uintptr, args arch.SyscallArguments) (rval u
 		// Don't reinvoke the unix.
 	}
 
+	t.recordFlight(FlightSyscallExit, uint64(sysno))
+
 	if bits.IsAnyOn32(fe, StraceEnableBits) {
 		s.Stracer.SyscallExit(straceContext, t, sysno, rval, err)
 	}
@@ -233,24 +261,18 @@ func (t *Task) doSyscall() taskRunState {
 	tmp := uintptr(unix.ENOSYS)
 	t.Arch().SetReturn(-tmp)
 
+	// Syscall user dispatch takes precedence over seccomp, as in Linux.
+	if t.syscallUserDispatch != nil {
+		if next, ok := t.checkSyscallUserDispatch(sysno); ok {
+			return next
+		}
+	}
+
 	// Check seccomp filters. The nil check is for performance (as seccomp use
 	// is rare), not needed for correctness.
 	if t.seccomp.Load() != nil {
-		switch r := t.checkSeccompSyscall(int32(sysno), args, hostarch.Addr(t.Arch().IP())); r {
-		case linux.SECCOMP_RET_ERRNO, linux.SECCOMP_RET_TRAP:
-			t.Debugf("Syscall %d: denied by seccomp", sysno)
-			return (*runSyscallExit)(nil)
-		case linux.SECCOMP_RET_ALLOW:
-			// ok
-		case linux.SECCOMP_RET_KILL_THREAD:
-			t.Debugf("Syscall %d: killed by seccomp", sysno)
-			t.PrepareExit(linux.WaitStatusTerminationSignal(linux.SIGSYS))
-			return (*runExit)(nil)
-		case linux.SECCOMP_RET_TRACE:
-			t.Debugf("Syscall %d: stopping for PTRACE_EVENT_SECCOMP", sysno)
-			return (*runSyscallAfterPtraceEventSeccomp)(nil)
-		default:
-			panic(fmt.Sprintf("Unknown seccomp result %d", r))
+		if next, ok := t.doSyscallSeccomp(sysno, args, false /* recheckAfterTrace */); ok {
+			return next
 		}
 	}
 
@@ -258,6 +280,32 @@ func (t *Task) doSyscall() taskRunState {
 	return t.doSyscallEnter(sysno, args)
 }
 
+// doSyscallSeccomp applies t's seccomp filters to syscall sysno. If the
+// syscall must not be invoked, doSyscallSeccomp returns the task's next run
+// state and true.
+func (t *Task) doSyscallSeccomp(sysno uintptr, args arch.SyscallArguments, recheckAfterTrace bool) (taskRunState, bool) {
+	switch r := t.checkSeccompSyscall(int32(sysno), args, hostarch.Addr(t.Arch().IP()), recheckAfterTrace); r {
+	case linux.SECCOMP_RET_ERRNO, linux.SECCOMP_RET_TRAP:
+		t.Debugf("Syscall %d: denied by seccomp", sysno)
+		return (*runSyscallExit)(nil), true
+	case linux.SECCOMP_RET_ALLOW:
+		return nil, false
+	case linux.SECCOMP_RET_KILL_THREAD:
+		t.Debugf("Syscall %d: killed by seccomp", sysno)
+		t.PrepareExit(linux.WaitStatusTerminationSignal(linux.SIGSYS))
+		return (*runExit)(nil), true
+	case linux.SECCOMP_RET_KILL_PROCESS:
+		t.Debugf("Syscall %d: process killed by seccomp", sysno)
+		t.PrepareGroupExit(linux.WaitStatusTerminationSignal(linux.SIGSYS))
+		return (*runExit)(nil), true
+	case linux.SECCOMP_RET_TRACE:
+		t.Debugf("Syscall %d: stopping for PTRACE_EVENT_SECCOMP", sysno)
+		return (*runSyscallAfterPtraceEventSeccomp)(nil), true
+	default:
+		panic(fmt.Sprintf("Unknown seccomp result %d", r))
+	}
+}
+
 type runSyscallAfterPtraceEventSeccomp struct{}
 
 func (*runSyscallAfterPtraceEventSeccomp) execute(t *Task) taskRunState {
@@ -273,6 +321,11 @@ func (*runSyscallAfterPtraceEventSeccomp) execute(t *Task) taskRunState {
 		return (*runSyscallExit)(nil).execute(t)
 	}
 	args := t.Arch().SyscallArgs()
+	// "The tracer may have changed the syscall; recheck." -
+	// kernel/seccomp.c:__seccomp_filter()
+	if next, ok := t.doSyscallSeccomp(sysno, args, true /* recheckAfterTrace */); ok {
+		return next
+	}
 	return t.doSyscallEnter(sysno, args)
 }
 
@@ -384,7 +437,7 @@ func (t *Task) doVsyscall(addr hostarch.Addr, sysno uintptr) taskRunState {
 	// arguments and none of the vsyscalls uses more than two arguments.
 	args := t.Arch().SyscallArgs()
 	if t.seccomp.Load() != nil {
-		switch r := t.checkSeccompSyscall(int32(sysno), args, addr); r {
+		switch r := t.checkSeccompSyscall(int32(sysno), args, addr, false /* recheckAfterTrace */); r {
 		case linux.SECCOMP_RET_ERRNO, linux.SECCOMP_RET_TRAP:
 			t.Debugf("vsyscall %d, caller %x: denied by seccomp", sysno, t.Arch().Value(caller))
 			return (*runApp)(nil)
@@ -397,6 +450,10 @@ func (t *Task) doVsyscall(addr hostarch.Addr, sysno uintptr) taskRunState {
 			t.Debugf("vsyscall %d: killed by seccomp", sysno)
 			t.PrepareExit(linux.WaitStatusTerminationSignal(linux.SIGSYS))
 			return (*runExit)(nil)
+		case linux.SECCOMP_RET_KILL_PROCESS:
+			t.Debugf("vsyscall %d: process killed by seccomp", sysno)
+			t.PrepareGroupExit(linux.WaitStatusTerminationSignal(linux.SIGSYS))
+			return (*runExit)(nil)
 		default:
 			panic(fmt.Sprintf("Unknown seccomp result %d", r))
 		}
@@ -456,6 +513,21 @@ func (t *Task) doVsyscallInvoke(sysno uintptr, args arch.SyscallArguments, calle
 	return (*runApp)(nil)
 }
 
+// sinkVetoError converts an error returned by a seccheck.Sink at a syscall
+// enter point into the error returned to the application. Errors that do not
+// carry an errno are reported as EPERM.
+func sinkVetoError(err error) error {
+	switch err.(type) {
+	case unix.Errno, *errors.Error:
+		return err
+	default:
+		if errno, ok := linuxerr.TranslateError(err); ok {
+			return errno
+		}
+		return linuxerr.EPERM
+	}
+}
+
 // ExtractErrno extracts an integer error number from the error.
 // The syscall number is purely for context in the error case. Use -1 if
 // syscall number is unknown.
@@ -15,8 +15,11 @@
 package kernel
 
 import (
+	"math/bits"
 	"testing"
 
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/sched"
 )
 
@@ -73,3 +76,155 @@ func TestTaskCPU(t *testing.T) {
 	}
 
 }
+
+func TestDeadlineBandwidth(t *testing.T) {
+	k := &Kernel{applicationCores: 2}
+	// 50% of a CPU.
+	half := (&SchedAttr{Policy: linux.SCHED_DEADLINE, Runtime: 5000000, Deadline: 10000000, Period: 10000000}).bandwidth()
+	for i := 0; i < 3; i++ {
+		if err := k.updateDeadlineBandwidth(0, half); err != nil {
+			t.Fatalf("admitting task %d: %v", i, err)
+		}
+	}
+	// 4 halves exceed the 95% of 2 CPUs available.
+	if err := k.updateDeadlineBandwidth(0, half); !linuxerr.Equals(linuxerr.EBUSY, err) {
+		t.Errorf("admitting task 3: got error %v, want EBUSY", err)
+	}
+	// Shrinking a reservation always succeeds.
+	if err := k.updateDeadlineBandwidth(half, half/2); err != nil {
+		t.Errorf("shrinking task 2: %v", err)
+	}
+	for _, bw := range []uint64{half, half, half / 2} {
+		if err := k.updateDeadlineBandwidth(bw, 0); err != nil {
+			t.Errorf("releasing %d: %v", bw, err)
+		}
+	}
+	if k.deadlineBandwidth != 0 {
+		t.Errorf("got bandwidth %d after releasing all tasks, want 0", k.deadlineBandwidth)
+	}
+}
+
+func TestChildSchedAttr(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		attr         SchedAttr
+		niceness     int
+		wantAttr     SchedAttr
+		wantNiceness int
+		wantEAGAIN   bool
+	}{
+		{
+			name:         "normal",
+			attr:         SchedAttr{Policy: linux.SCHED_BATCH},
+			niceness:     -5,
+			wantAttr:     SchedAttr{Policy: linux.SCHED_BATCH},
+			wantNiceness: -5,
+		},
+		{
+			name:         "fifo",
+			attr:         SchedAttr{Policy: linux.SCHED_FIFO, Priority: 10},
+			wantAttr:     SchedAttr{Policy: linux.SCHED_FIFO, Priority: 10},
+			wantNiceness: 0,
+		},
+		{
+			name:         "fifo reset on fork",
+			attr:         SchedAttr{Policy: linux.SCHED_FIFO, Priority: 10, ResetOnFork: true},
+			niceness:     -5,
+			wantAttr:     SchedAttr{Policy: linux.SCHED_NORMAL},
+			wantNiceness: 0,
+		},
+		{
+			name:         "idle reset on fork",
+			attr:         SchedAttr{Policy: linux.SCHED_IDLE, ResetOnFork: true},
+			niceness:     5,
+			wantAttr:     SchedAttr{Policy: linux.SCHED_IDLE},
+			wantNiceness: 5,
+		},
+		{
+			name:       "deadline",
+			attr:       SchedAttr{Policy: linux.SCHED_DEADLINE, Runtime: 1000000, Deadline: 2000000, Period: 2000000},
+			wantEAGAIN: true,
+		},
+		{
+			name:         "deadline reset on fork",
+			attr:         SchedAttr{Policy: linux.SCHED_DEADLINE, Runtime: 1000000, Deadline: 2000000, Period: 2000000, ResetOnFork: true},
+			wantAttr:     SchedAttr{Policy: linux.SCHED_NORMAL},
+			wantNiceness: 0,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			task := &Task{schedAttr: test.attr, niceness: test.niceness}
+			attr, niceness, err := task.childSchedAttr()
+			if test.wantEAGAIN {
+				if !linuxerr.Equals(linuxerr.EAGAIN, err) {
+					t.Errorf("got error %v, want EAGAIN", err)
+				}
+				return
+			}
+			if err != nil || attr != test.wantAttr || niceness != test.wantNiceness {
+				t.Errorf("got (%+v, %d, %v), want (%+v, %d, nil)", attr, niceness, err, test.wantAttr, test.wantNiceness)
+			}
+		})
+	}
+}
+
+func TestNUMANodeCPUs(t *testing.T) {
+	for _, test := range []struct {
+		cores uint
+		nodes uint
+	}{
+		{cores: 1, nodes: 0},
+		{cores: 4, nodes: 1},
+		{cores: 6, nodes: 4},
+		{cores: 8, nodes: 3},
+		{cores: 64, nodes: 64},
+	} {
+		k := &Kernel{applicationCores: test.cores, numaNodes: test.nodes}
+		nodes := k.NUMANodes()
+		if nodes == 0 {
+			t.Fatalf("%d cores, %d nodes: NUMANodes() = 0", test.cores, test.nodes)
+		}
+		if got, want := uint(bits.OnesCount64(k.NUMANodeMask())), nodes; got != want {
+			t.Errorf("%d cores, %d nodes: got %d nodes in mask, want %d", test.cores, test.nodes, got, want)
+		}
+		// Node CPU ranges must be contiguous, non-empty, cover all CPUs and
+		// agree with CPUNode.
+		var next uint
+		for n := uint(0); n < nodes; n++ {
+			start, end := k.NUMANodeCPUs(n)
+			if start != next || end <= start {
+				t.Errorf("%d cores, %d nodes: node %d has CPUs [%d, %d), want a non-empty range starting at %d", test.cores, test.nodes, n, start, end, next)
+			}
+			for cpu := start; cpu < end; cpu++ {
+				if got := k.CPUNode(int32(cpu)); got != int32(n) {
+					t.Errorf("%d cores, %d nodes: CPUNode(%d) = %d, want %d", test.cores, test.nodes, cpu, got, n)
+				}
+			}
+			next = end
+		}
+		if next != test.cores {
+			t.Errorf("%d cores, %d nodes: nodes cover %d CPUs, want %d", test.cores, test.nodes, next, test.cores)
+		}
+	}
+}
+
+func TestTaskCPUClockCappedByApplicationCores(t *testing.T) {
+	for _, test := range []struct {
+		cores   uint
+		running int64
+		want    int64
+	}{
+		{cores: 4, running: 0, want: 1000},
+		{cores: 4, running: 4, want: 1000},
+		{cores: 4, running: 8, want: 500},
+		{cores: 2, running: 5, want: 400},
+	} {
+		k := &Kernel{applicationCores: test.cores}
+		k.cpuShareBase.Store(100)
+		k.cpuShareStamp.Store(2000)
+		k.cpuShareRunning.Store(test.running)
+		if got := k.taskCPUClockAt(3000) - 100; got != test.want {
+			t.Errorf("%d cores, %d running tasks: task CPU clock advanced by %d over 1000ns, want %d", test.cores, test.running, got, test.want)
+		}
+	}
+}
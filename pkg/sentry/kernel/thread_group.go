@@ -206,10 +206,26 @@ type ThreadGroup struct {
 	// present tasks in the thread group.
 	appSysCPUClock ktime.SyntheticClock
 
+	// exitedAppCPUTime and exitedSysCPUTime are the sums of Task.appCPUTime
+	// and Task.sysCPUTime respectively for all reaped tasks in the thread
+	// group.
+	//
+	// exitedAppCPUTime and exitedSysCPUTime are protected by the TaskSet
+	// mutex.
+	exitedAppCPUTime int64
+	exitedSysCPUTime int64
+
 	// yieldCount is the sum of Task.yieldCount for all past and present tasks
 	// in the thread group.
 	yieldCount atomicbitops.Uint64
 
+	// interruptCount, minorFaults and majorFaults are the sums of the
+	// corresponding Task fields for all past and present tasks in the thread
+	// group.
+	interruptCount atomicbitops.Uint64
+	minorFaults    atomicbitops.Uint64
+	majorFaults    atomicbitops.Uint64
+
 	// childCPUStats is the CPU usage of all joined descendants of this thread
 	// group. childCPUStats is protected by the TaskSet mutex.
 	childCPUStats usage.CPUStats
@@ -218,6 +234,10 @@ type ThreadGroup struct {
 	// The ioUsage pointer is immutable.
 	ioUsage *usage.IO
 
+	// delayUsage is the delay accounting usage for all exited tasks in the
+	// thread group. The delayUsage pointer is immutable.
+	delayUsage *usage.Delay
+
 	// maxRSS is the historical maximum resident set size of the thread group, updated when:
 	//
 	//	- A task in the thread group exits, since after all tasks have
@@ -294,6 +314,7 @@ func (k *Kernel) NewThreadGroup(pidns *PIDNamespace, sh *SignalHandlers, termina
 		terminationSignal: terminationSignal,
 		timers:            make(map[linux.TimerID]*IntervalTimer),
 		ioUsage:           &usage.IO{},
+		delayUsage:        &usage.Delay{},
 		limits:            limits,
 	}
 	tg.itimerRealTimer = ktime.NewSampledTimer(k.timekeeper.monotonicClock, &tg.itimerRealListener)
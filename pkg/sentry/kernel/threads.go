@@ -19,6 +19,7 @@ import (
 
 	"gvisor.dev/gvisor/pkg/atomicbitops"
 	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/sentry/fsimpl/nsfs"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
 	"gvisor.dev/gvisor/pkg/sync"
@@ -167,6 +168,10 @@ type PIDNamespace struct {
 	// id is a unique ID assigned to the PID namespace. id is immutable.
 	id uint64
 
+	// level is the nesting depth of the PID namespace; the root PID namespace
+	// has level 0. level is immutable.
+	level uint32
+
 	// The following fields are protected by owner.mu.
 
 	// last is the last ThreadID to be allocated in this namespace.
@@ -207,6 +212,11 @@ type PIDNamespace struct {
 	// exited.
 	exiting bool
 
+	// exitingInit is the init process task that killed the namespace while
+	// exiting, if it is stopped waiting for the namespace's other tasks to be
+	// reaped.
+	exitingInit *Task
+
 	// pidNamespaceData contains additional per-PID-namespace data.
 	extra pidNamespaceData
 
@@ -214,11 +224,16 @@ type PIDNamespace struct {
 }
 
 func newPIDNamespace(ts *TaskSet, parent *PIDNamespace, userns *auth.UserNamespace) *PIDNamespace {
+	var level uint32
+	if parent != nil {
+		level = parent.level + 1
+	}
 	return &PIDNamespace{
 		owner:         ts,
 		parent:        parent,
 		userns:        userns,
 		id:            lastPIDNSID.Add(1),
+		level:         level,
 		tasks:         make(map[ThreadID]*Task),
 		tids:          make(map[*Task]ThreadID),
 		tgids:         make(map[*ThreadGroup]ThreadID),
@@ -271,12 +286,46 @@ func (ns *PIDNamespace) Type() string {
 	return "pid"
 }
 
+// MaxPIDNamespaceLevel is the maximum nesting depth of PID namespaces below
+// the root PID namespace. This is MAX_PID_NS_LEVEL in Linux.
+const MaxPIDNamespaceLevel = 32
+
 // NewChild returns a new, empty PID namespace that is a child of ns. Authority
 // over the new PID namespace is controlled by userns.
-func (ns *PIDNamespace) NewChild(ctx context.Context, k *Kernel, userns *auth.UserNamespace) *PIDNamespace {
+func (ns *PIDNamespace) NewChild(ctx context.Context, k *Kernel, userns *auth.UserNamespace) (*PIDNamespace, error) {
+	// "Since Linux 3.7, the kernel limits the maximum nesting depth for PID
+	// namespaces to 32." - pid_namespaces(7)
+	if ns.level >= MaxPIDNamespaceLevel {
+		return nil, linuxerr.ENOSPC
+	}
 	pidns := newPIDNamespace(ns.owner, ns, userns)
 	pidns.InitInode(ctx, k)
-	return pidns
+	return pidns, nil
+}
+
+// Level returns the nesting depth of ns; the root PID namespace has level 0.
+func (ns *PIDNamespace) Level() uint32 {
+	return ns.level
+}
+
+// LastTID returns the last ThreadID allocated in ns. This is the value of
+// /proc/sys/kernel/ns_last_pid.
+func (ns *PIDNamespace) LastTID() ThreadID {
+	ns.owner.mu.RLock()
+	defer ns.owner.mu.RUnlock()
+	return ns.last
+}
+
+// SetLastTID sets the last ThreadID allocated in ns, such that the next
+// ThreadID allocated is the first unused one after tid.
+func (ns *PIDNamespace) SetLastTID(tid ThreadID) error {
+	if tid < 0 || tid >= TasksLimit {
+		return linuxerr.EINVAL
+	}
+	ns.owner.mu.Lock()
+	defer ns.owner.mu.Unlock()
+	ns.last = tid
+	return nil
 }
 
 // TaskWithID returns the task with thread ID tid in PID namespace ns. If no
@@ -417,6 +466,11 @@ type threadGroupNode struct {
 	// thread group. Events are defined in task_exit.go.
 	eventQueue waiter.Queue
 
+	// exitQueue is notified when the thread group's exit becomes observable
+	// through a pidfd, i.e. when its leader is a zombie and all other tasks in
+	// the thread group have been reaped, and again when the leader is reaped.
+	exitQueue waiter.Queue
+
 	// leader is the thread group's leader, which is the oldest task in the
 	// thread group; usually the last task in the thread group to call
 	// execve(), or if no such task exists then the first task in the thread
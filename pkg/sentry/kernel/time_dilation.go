@@ -0,0 +1,157 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/sentry/ktime"
+	sentrytime "gvisor.dev/gvisor/pkg/sentry/time"
+)
+
+// MaxTimeDilation is the maximum rate accepted by Timekeeper.SetTimeDilation.
+const MaxTimeDilation = 1000
+
+// timeDilation describes how a Timekeeper's clocks diverge from its
+// underlying clocks, for testing.
+//
+// Once installed in Timekeeper.dilation, a timeDilation is immutable.
+type timeDilation struct {
+	// rate is the rate at which the Timekeeper's clocks advance relative to
+	// the underlying monotonic clock.
+	rate float64
+
+	// base is the (offset) underlying monotonic time at which the
+	// timeDilation took effect.
+	base int64
+
+	// monotonic and realtime are the values of the Timekeeper's clocks at
+	// base.
+	monotonic int64
+	realtime  int64
+}
+
+// now returns the value of clock c given the (offset) underlying monotonic
+// time.
+func (d *timeDilation) now(c sentrytime.ClockID, underlying int64) int64 {
+	elapsed := int64(float64(underlying-d.base) * d.rate)
+	if c == sentrytime.Monotonic {
+		return d.monotonic + elapsed
+	}
+	return d.realtime + elapsed
+}
+
+// SetTimeDilation causes t's clocks to advance at rate times the speed of the
+// host's clocks, from their current values. It is intended for testing: it
+// lets test suites with long timers run quickly, or slows time down to widen
+// races.
+//
+// Once time dilation is in effect, from the first call to SetTimeDilation or
+// AdvanceTime, it persists across checkpoint and restore, and the vDSO falls
+// back to clock_gettime(2) syscalls.
+func (t *Timekeeper) SetTimeDilation(rate float64) error {
+	if !(rate > 0) || rate > MaxTimeDilation || math.IsInf(rate, 0) {
+		return fmt.Errorf("invalid time dilation rate %v: must be in (0, %d]", rate, MaxTimeDilation)
+	}
+	return t.updateDilation(func(d *timeDilation) {
+		d.rate = rate
+	})
+}
+
+// AdvanceTime steps t's monotonic and real time clocks forward by d, expiring
+// any timers that become due. Like SetTimeDilation, it is intended for
+// testing.
+func (t *Timekeeper) AdvanceTime(d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("invalid time step %v: clocks cannot be stepped backwards", d)
+	}
+	return t.updateDilation(func(dil *timeDilation) {
+		dil.monotonic += int64(d)
+		dil.realtime += int64(d)
+	})
+}
+
+// TimeDilation returns the rate at which t's clocks advance relative to the
+// host's clocks.
+func (t *Timekeeper) TimeDilation() float64 {
+	if d := t.dilation.Load(); d != nil {
+		return d.rate
+	}
+	return 1
+}
+
+// updateDilation installs a new timeDilation, which starts at the current
+// time and is then modified by f.
+func (t *Timekeeper) updateDilation(f func(d *timeDilation)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.clocks == nil {
+		return fmt.Errorf("clocks are not yet available")
+	}
+
+	// Stop the updater and disable the vDSO fast path before changing the
+	// time, so that the application can't observe undilated time from the
+	// vDSO after observing dilated time from the syscall.
+	running := t.stop != nil
+	t.stopUpdater()
+	if t.params != nil {
+		if err := t.params.Write(func() vdsoParams {
+			return vdsoParams{}
+		}); err != nil {
+			return fmt.Errorf("unable to reset VDSO params: %w", err)
+		}
+	}
+
+	underlying, err := t.underlyingTime()
+	if err != nil {
+		return err
+	}
+	d := timeDilation{
+		rate: t.TimeDilation(),
+		base: underlying,
+	}
+	if d.monotonic, err = t.GetTime(sentrytime.Monotonic); err != nil {
+		return err
+	}
+	if d.realtime, err = t.GetTime(sentrytime.Realtime); err != nil {
+		return err
+	}
+	f(&d)
+	t.dilation.Store(&d)
+
+	if running {
+		t.startUpdater(t.params)
+	}
+
+	// Existing timers may have computed their expirations using the old
+	// dilation.
+	for _, c := range []*timekeeperClock{t.monotonicClock, t.realtimeClock} {
+		if c != nil {
+			c.Notify(ktime.ClockEventSet)
+		}
+	}
+	return nil
+}
+
+// underlyingTime returns the (offset) monotonic time of t's underlying clocks.
+func (t *Timekeeper) underlyingTime() (int64, error) {
+	now, err := t.clocks.GetTime(sentrytime.Monotonic)
+	if err != nil {
+		return 0, err
+	}
+	return now + t.monotonicOffset, nil
+}
@@ -0,0 +1,345 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"time"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/nsfs"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/ktime"
+	"gvisor.dev/gvisor/pkg/sentry/mm"
+	"gvisor.dev/gvisor/pkg/sentry/pgalloc"
+	"gvisor.dev/gvisor/pkg/sentry/usage"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// TimeNamespace represents a time namespace. See time_namespaces(7).
+//
+// A time namespace offsets CLOCK_MONOTONIC and CLOCK_BOOTTIME (and their
+// variants) as observed by its members, both through syscalls and through
+// the VDSO. Offsets are relative to the root time namespace, in which
+// CLOCK_BOOTTIME is the same as CLOCK_MONOTONIC.
+//
+// +stateify savable
+type TimeNamespace struct {
+	inode *nsfs.Inode
+
+	// userns is the user namespace which owns this time namespace.
+	//
+	// userns is immutable.
+	userns *auth.UserNamespace
+
+	// monotonicOffset and boottimeOffset are the offsets in nanoseconds
+	// applied to CLOCK_MONOTONIC and CLOCK_BOOTTIME respectively.
+	//
+	// They may only be changed while holding mu, before frozen is set.
+	monotonicOffset atomicbitops.Int64
+	boottimeOffset  atomicbitops.Int64
+
+	// monotonicClock and boottimeClock are the clocks observed by members of
+	// the namespace.
+	//
+	// They are immutable.
+	monotonicClock ktime.SampledClock
+	boottimeClock  ktime.SampledClock
+
+	// mu protects the fields below.
+	mu sync.Mutex `state:"nosave"`
+
+	// frozen is set when a task first enters the namespace. After that, the
+	// namespace's offsets can no longer be changed. The root time namespace
+	// is always frozen.
+	frozen bool
+
+	// vvar is the VDSO parameter page mapped by members of the namespace. It
+	// is nil until the namespace is frozen. The root time namespace shares
+	// the kernel's parameter page.
+	vvar *mm.SpecialMappable
+
+	// params manages the contents of vvar, or is nil for the root time
+	// namespace, whose page is updated directly by the Timekeeper.
+	params *VDSOParamPage
+}
+
+// newRootTimeNamespace returns the root time namespace of k.
+func newRootTimeNamespace(k *Kernel, userns *auth.UserNamespace) *TimeNamespace {
+	ns := &TimeNamespace{
+		userns:         userns,
+		monotonicClock: k.MonotonicClock(),
+		boottimeClock:  k.MonotonicClock(),
+		frozen:         true,
+	}
+	if k.vdso != nil {
+		ns.vvar = k.vdso.ParamPage
+	}
+	return ns
+}
+
+// clone returns a new time namespace owned by userns, with the same offsets
+// as ns.
+func (ns *TimeNamespace) clone(k *Kernel, userns *auth.UserNamespace) *TimeNamespace {
+	c := &TimeNamespace{
+		userns:          userns,
+		monotonicOffset: atomicbitops.FromInt64(ns.monotonicOffset.Load()),
+		boottimeOffset:  atomicbitops.FromInt64(ns.boottimeOffset.Load()),
+	}
+	c.monotonicClock = &timeNamespaceClock{base: k.MonotonicClock(), ns: c}
+	c.boottimeClock = &timeNamespaceClock{base: k.MonotonicClock(), ns: c, boottime: true}
+	c.SetInode(nsfs.NewInode(k.SupervisorContext(), k.nsfsMount, c))
+	return c
+}
+
+// MonotonicClock returns CLOCK_MONOTONIC in ns.
+func (ns *TimeNamespace) MonotonicClock() ktime.SampledClock {
+	return ns.monotonicClock
+}
+
+// BoottimeClock returns CLOCK_BOOTTIME in ns.
+func (ns *TimeNamespace) BoottimeClock() ktime.SampledClock {
+	return ns.boottimeClock
+}
+
+// Offsets returns the offsets applied to CLOCK_MONOTONIC and CLOCK_BOOTTIME
+// in ns.
+func (ns *TimeNamespace) Offsets() (monotonic, boottime time.Duration) {
+	return time.Duration(ns.monotonicOffset.Load()), time.Duration(ns.boottimeOffset.Load())
+}
+
+// TimeOffset is a clock offset set by SetOffsets.
+type TimeOffset struct {
+	// ClockID is CLOCK_MONOTONIC or CLOCK_BOOTTIME.
+	ClockID int32
+
+	// Offset is the new offset of the clock.
+	Offset time.Duration
+}
+
+// SetOffsets sets the clock offsets of ns. Either all offsets are applied, or
+// none are. It fails with EACCES if a task has already entered ns.
+func (ns *TimeNamespace) SetOffsets(offsets []TimeOffset) error {
+	for _, o := range offsets {
+		if o.ClockID != linux.CLOCK_MONOTONIC && o.ClockID != linux.CLOCK_BOOTTIME {
+			return linuxerr.EINVAL
+		}
+	}
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if ns.frozen {
+		return linuxerr.EACCES
+	}
+	for _, o := range offsets {
+		switch o.ClockID {
+		case linux.CLOCK_MONOTONIC:
+			ns.monotonicOffset.Store(o.Offset.Nanoseconds())
+		case linux.CLOCK_BOOTTIME:
+			ns.boottimeOffset.Store(o.Offset.Nanoseconds())
+		}
+	}
+	return nil
+}
+
+// UserNamespace returns the user namespace which owns ns.
+func (ns *TimeNamespace) UserNamespace() *auth.UserNamespace {
+	return ns.userns
+}
+
+// enter freezes ns's offsets and returns the VDSO parameter page that should
+// be mapped by tasks in ns, allocating it if this is the first time a task
+// enters ns.
+func (ns *TimeNamespace) enter(k *Kernel) (*mm.SpecialMappable, error) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if ns.frozen {
+		return ns.vvar, nil
+	}
+	if k.vdso != nil {
+		mf := k.MemoryFile()
+		fr, err := mf.Allocate(hostarch.PageSize, pgalloc.AllocOpts{Kind: usage.System})
+		if err != nil {
+			return nil, err
+		}
+		ns.vvar = mm.NewSpecialMappable("[vvar]", mf, fr)
+		ns.params = NewVDSOParamPage(mf, fr)
+		k.vdsoParams.addTimeNamespace(ns)
+	}
+	ns.frozen = true
+	return ns.vvar, nil
+}
+
+// VVAR returns the VDSO parameter page mapped by tasks in ns, or nil if no
+// task has entered ns yet.
+func (ns *TimeNamespace) VVAR() *mm.SpecialMappable {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	return ns.vvar
+}
+
+// vdsoParams returns p, the parameters of the root time namespace, adjusted
+// for ns.
+func (ns *TimeNamespace) vdsoParams(p vdsoParams) vdsoParams {
+	monotonic := ns.monotonicOffset.Load()
+	p.monotonicBaseRef += monotonic
+	p.boottimeOffset = ns.boottimeOffset.Load() - monotonic
+	return p
+}
+
+// Type implements nsfs.Namespace.Type.
+func (ns *TimeNamespace) Type() string {
+	return "time"
+}
+
+// Destroy implements nsfs.Namespace.Destroy.
+func (ns *TimeNamespace) Destroy(ctx context.Context) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if ns.params == nil {
+		// The root time namespace doesn't own its parameter page.
+		return
+	}
+	KernelFromContext(ctx).vdsoParams.removeTimeNamespace(ns)
+	ns.vvar.DecRef(ctx)
+	ns.vvar = nil
+	ns.params = nil
+}
+
+// SetInode sets the nsfs inode of ns.
+func (ns *TimeNamespace) SetInode(inode *nsfs.Inode) {
+	ns.inode = inode
+}
+
+// GetInode returns the nsfs inode of ns.
+func (ns *TimeNamespace) GetInode() *nsfs.Inode {
+	return ns.inode
+}
+
+// IncRef increments the namespace's refcount.
+func (ns *TimeNamespace) IncRef() {
+	ns.inode.IncRef()
+}
+
+// DecRef decrements the namespace's refcount.
+func (ns *TimeNamespace) DecRef(ctx context.Context) {
+	ns.inode.DecRef(ctx)
+}
+
+// timeNamespaceClock is a clock in a non-root time namespace.
+//
+// +stateify savable
+type timeNamespaceClock struct {
+	// base is the clock in the root time namespace.
+	base ktime.SampledClock
+
+	// ns is the time namespace containing the clock.
+	ns *TimeNamespace
+
+	// boottime is true if the clock is CLOCK_BOOTTIME, and false if it is
+	// CLOCK_MONOTONIC.
+	boottime bool
+}
+
+// Now implements ktime.Clock.Now.
+func (c *timeNamespaceClock) Now() ktime.Time {
+	offset := &c.ns.monotonicOffset
+	if c.boottime {
+		offset = &c.ns.boottimeOffset
+	}
+	return c.base.Now().Add(time.Duration(offset.Load()))
+}
+
+// NewTimer implements ktime.Clock.NewTimer.
+func (c *timeNamespaceClock) NewTimer(l ktime.Listener) ktime.Timer {
+	return ktime.NewSampledTimer(c, l)
+}
+
+// WallTimeUntil implements ktime.SampledClock.WallTimeUntil.
+func (c *timeNamespaceClock) WallTimeUntil(t, now ktime.Time) time.Duration {
+	// The offset cancels out.
+	return c.base.WallTimeUntil(t, now)
+}
+
+// Readiness implements waiter.Waitable.Readiness.
+func (c *timeNamespaceClock) Readiness(mask waiter.EventMask) waiter.EventMask {
+	return c.base.Readiness(mask)
+}
+
+// EventRegister implements waiter.Waitable.EventRegister.
+func (c *timeNamespaceClock) EventRegister(e *waiter.Entry) error {
+	return c.base.EventRegister(e)
+}
+
+// EventUnregister implements waiter.Waitable.EventUnregister.
+func (c *timeNamespaceClock) EventUnregister(e *waiter.Entry) {
+	c.base.EventUnregister(e)
+}
+
+// TimeNamespace returns t's time namespace.
+//
+// Preconditions: The caller must be running on the task goroutine, or t.mu
+// must be locked.
+func (t *Task) TimeNamespace() *TimeNamespace {
+	return t.timens
+}
+
+// GetTimeNamespace takes a reference on t's time namespace and returns it. It
+// returns nil if t isn't alive.
+func (t *Task) GetTimeNamespace() *TimeNamespace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timens != nil {
+		t.timens.IncRef()
+	}
+	return t.timens
+}
+
+// GetChildTimeNamespace takes a reference on the time namespace that t's
+// future children will be created in and returns it. It returns nil if t
+// isn't alive.
+func (t *Task) GetChildTimeNamespace() *TimeNamespace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ns := t.childTimeNamespaceLocked()
+	if ns != nil {
+		ns.IncRef()
+	}
+	return ns
+}
+
+// childTimeNamespaceLocked returns the time namespace that t's future children
+// will be created in.
+//
+// Preconditions: The caller must be running on the task goroutine, or t.mu
+// must be locked.
+func (t *Task) childTimeNamespaceLocked() *TimeNamespace {
+	if t.childTimens != nil {
+		return t.childTimens
+	}
+	return t.timens
+}
+
+// switchVVAR replaces the mappings of the VDSO parameter page from in m with
+// mappings of to.
+func switchVVAR(ctx context.Context, m *mm.MemoryManager, from, to *mm.SpecialMappable) error {
+	if m == nil || from == to || from == nil || to == nil {
+		return nil
+	}
+	return m.ReplaceSpecialMappable(ctx, from, to)
+}
@@ -16,6 +16,7 @@ package kernel
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"gvisor.dev/gvisor/pkg/atomicbitops"
@@ -86,6 +87,20 @@ type Timekeeper struct {
 
 	// wg is used to indicate that the update goroutine has exited.
 	wg sync.WaitGroup `state:"nosave"`
+
+	// params is the VDSO parameter page kept up to date by the update
+	// goroutine. Protected by mu.
+	params *VDSOParamPage `state:"nosave"`
+
+	// dilation, if not nil, is the time dilation applied to the clocks. See
+	// SetTimeDilation.
+	dilation atomic.Pointer[timeDilation] `state:"nosave"`
+
+	// saveDilationRate is the rate of dilation at the time of save, or 0 if
+	// time was not dilated.
+	//
+	// It is only used in SetClocks after restore to reinstall dilation.
+	saveDilationRate float64
 }
 
 // NewTimekeeper returns a Timekeeper that is automatically kept up-to-date.
@@ -159,6 +174,20 @@ func (t *Timekeeper) SetClocks(c sentrytime.Clocks, params *VDSOParamPage) {
 	if t.restored == nil {
 		// Hold on to the initial "boot" time.
 		t.bootTime = ktime.FromNanoseconds(nowRealtime)
+	} else if t.saveDilationRate != 0 {
+		// Keep dilating time from the saved clock values. Since dilated
+		// real time usually runs ahead of the host's, it is only replaced
+		// by the host's if that is later.
+		d := timeDilation{
+			rate:      t.saveDilationRate,
+			base:      wantMonotonic,
+			monotonic: wantMonotonic,
+			realtime:  t.saveRealtime,
+		}
+		if nowRealtime > d.realtime {
+			d.realtime = nowRealtime
+		}
+		t.dilation.Store(&d)
 	}
 
 	t.mu.Lock()
@@ -179,6 +208,7 @@ func (t *Timekeeper) startUpdater(params *VDSOParamPage) {
 		return
 	}
 	t.stop = make(chan struct{})
+	t.params = params
 
 	// Keep the clocks up to date.
 	//
@@ -201,6 +231,11 @@ func (t *Timekeeper) startUpdater(params *VDSOParamPage) {
 				monotonicParams, monotonicOk, realtimeParams, realtimeOk := t.clocks.Update()
 
 				var p vdsoParams
+				if t.dilation.Load() != nil {
+					// The VDSO can't dilate time, so leave the params
+					// "not ready" to force it to use the syscall.
+					return p
+				}
 				if monotonicOk {
 					p.monotonicReady = 1
 					p.monotonicBaseCycles = int64(monotonicParams.BaseCycles)
@@ -272,9 +307,20 @@ func (t *Timekeeper) GetTime(c sentrytime.ClockID) (int64, error) {
 		}
 		<-t.restored
 	}
-	now, err := t.clocks.GetTime(c)
+	var now int64
+	var err error
+	if d := t.dilation.Load(); d != nil && (c == sentrytime.Monotonic || c == sentrytime.Realtime) {
+		var underlying int64
+		if underlying, err = t.underlyingTime(); err == nil {
+			now = d.now(c, underlying)
+		}
+	} else {
+		now, err = t.clocks.GetTime(c)
+		if err == nil && c == sentrytime.Monotonic {
+			now += t.monotonicOffset
+		}
+	}
 	if err == nil && c == sentrytime.Monotonic {
-		now += t.monotonicOffset
 		for {
 			// It's possible that the clock is shaky. This may be due to
 			// platform issues, e.g. the KVM platform relies on the guest
@@ -307,12 +353,15 @@ type timekeeperClock struct {
 	tk *Timekeeper
 	c  sentrytime.ClockID
 
-	// Implements ktime.SampledClock.WallTimeUntil.
-	ktime.WallRateClock `state:"nosave"`
-
 	// Implements waiter.Waitable. (We have no ability to detect
-	// discontinuities from external changes to CLOCK_REALTIME).
-	ktime.NoClockEvents `state:"nosave"`
+	// discontinuities from external changes to CLOCK_REALTIME, but do
+	// generate events for changes to time dilation.)
+	ktime.ClockEventsQueue `state:"nosave"`
+}
+
+// WallTimeUntil implements ktime.SampledClock.WallTimeUntil.
+func (tc *timekeeperClock) WallTimeUntil(t, now ktime.Time) time.Duration {
+	return time.Duration(float64(t.Sub(now)) / tc.tk.TimeDilation())
 }
 
 // Now implements ktime.Clock.Now.
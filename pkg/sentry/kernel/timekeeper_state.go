@@ -40,6 +40,11 @@ func (t *Timekeeper) beforeSave() {
 	if t.saveRealtime, err = t.GetTime(time.Realtime); err != nil {
 		panic("unable to get current realtime: " + err.Error())
 	}
+
+	t.saveDilationRate = 0
+	if d := t.dilation.Load(); d != nil {
+		t.saveDilationRate = d.rate
+	}
 }
 
 // afterLoad is invoked by stateify.
@@ -153,3 +153,85 @@ func TestTimekeeperMonotonicJumpBackwards(t *testing.T) {
 		t.Errorf("GetTime got %d want 100000", now)
 	}
 }
+
+// TestTimekeeperDilation tests that dilated clocks advance at the configured
+// rate from their values when dilation was set, and can be stepped forward.
+func TestTimekeeperDilation(t *testing.T) {
+	c := &mockClocks{
+		monotonic: 100000,
+		realtime:  500000,
+	}
+
+	tk, params := stateTestClocklessTimekeeper(t)
+	tk.SetClocks(c, params)
+	defer tk.Destroy()
+
+	c.monotonic += 1000
+	c.realtime += 1000
+	if err := tk.SetTimeDilation(10); err != nil {
+		t.Fatalf("SetTimeDilation failed: %v", err)
+	}
+
+	c.monotonic += 1000
+	c.realtime += 1000
+	for _, test := range []struct {
+		clock sentrytime.ClockID
+		want  int64
+	}{
+		{sentrytime.Monotonic, 1000 + 10*1000},
+		{sentrytime.Realtime, 501000 + 10*1000},
+	} {
+		if now, err := tk.GetTime(test.clock); err != nil || now != test.want {
+			t.Errorf("GetTime(%v) got (%d, %v) want (%d, nil)", test.clock, now, err, test.want)
+		}
+	}
+
+	if err := tk.AdvanceTime(5000); err != nil {
+		t.Fatalf("AdvanceTime failed: %v", err)
+	}
+	if now, err := tk.GetTime(sentrytime.Monotonic); err != nil || now != 16000 {
+		t.Errorf("GetTime(Monotonic) got (%d, %v) want (16000, nil)", now, err)
+	}
+
+	if err := tk.SetTimeDilation(0); err == nil {
+		t.Errorf("SetTimeDilation(0) succeeded, want error")
+	}
+	if err := tk.AdvanceTime(-1); err == nil {
+		t.Errorf("AdvanceTime(-1) succeeded, want error")
+	}
+}
+
+// TestTimekeeperDilationRestore tests that time dilation persists across
+// restore, continuing from the saved clock values.
+func TestTimekeeperDilationRestore(t *testing.T) {
+	c := &mockClocks{
+		monotonic: 900000,
+		realtime:  400000,
+	}
+
+	tk, params := stateTestClocklessTimekeeper(t)
+	tk.restored = make(chan struct{})
+	tk.saveMonotonic = 100000
+	tk.saveRealtime = 700000
+	tk.saveDilationRate = 10
+	tk.SetClocks(c, params)
+	defer tk.Destroy()
+
+	if rate := tk.TimeDilation(); rate != 10 {
+		t.Errorf("TimeDilation got %v want 10", rate)
+	}
+
+	c.monotonic += 1000
+	c.realtime += 1000
+	for _, test := range []struct {
+		clock sentrytime.ClockID
+		want  int64
+	}{
+		{sentrytime.Monotonic, 100000 + 10*1000},
+		{sentrytime.Realtime, 700000 + 10*1000},
+	} {
+		if now, err := tk.GetTime(test.clock); err != nil || now != test.want {
+			t.Errorf("GetTime(%v) got (%d, %v) want (%d, nil)", test.clock, now, err, test.want)
+		}
+	}
+}
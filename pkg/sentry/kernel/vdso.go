@@ -22,6 +22,7 @@ import (
 	"gvisor.dev/gvisor/pkg/safemem"
 	"gvisor.dev/gvisor/pkg/sentry/memmap"
 	"gvisor.dev/gvisor/pkg/sentry/pgalloc"
+	"gvisor.dev/gvisor/pkg/sync"
 )
 
 // vdsoParams are the parameters exposed to the VDSO.
@@ -40,6 +41,10 @@ type vdsoParams struct {
 	realtimeBaseCycles int64
 	realtimeBaseRef    int64
 	realtimeFrequency  uint64
+
+	// boottimeOffset is the difference between CLOCK_BOOTTIME and
+	// CLOCK_MONOTONIC in the time namespace using the page.
+	boottimeOffset int64
 }
 
 // VDSOParamPage manages a VDSO parameter page.
@@ -77,6 +82,14 @@ type VDSOParamPage struct {
 	// the sentry, so reusing this buffer is a good tradeoff between memory
 	// usage and the cost of allocation.
 	copyScratchBuffer []byte
+
+	// timeNamespacesMu protects timeNamespaces.
+	timeNamespacesMu sync.Mutex `state:"nosave"`
+
+	// timeNamespaces are the entered non-root time namespaces. Their
+	// parameter pages are written along with this one, with their offsets
+	// applied.
+	timeNamespaces map[*TimeNamespace]struct{}
 }
 
 // afterLoad is invoked by stateify.
@@ -131,8 +144,48 @@ func (v *VDSOParamPage) incrementSeq(paramPage safemem.Block) error {
 // Write updates the VDSO parameters.
 //
 // Write starts a write block, calls f to get the new parameters, writes
-// out the new parameters, then ends the write block.
+// out the new parameters, then ends the write block. It then updates the
+// parameter pages of all time namespaces registered with v.
 func (v *VDSOParamPage) Write(f func() vdsoParams) error {
+	var p vdsoParams
+	if err := v.write(func() vdsoParams {
+		p = f()
+		return p
+	}); err != nil {
+		return err
+	}
+
+	v.timeNamespacesMu.Lock()
+	defer v.timeNamespacesMu.Unlock()
+	for ns := range v.timeNamespaces {
+		if err := ns.params.write(func() vdsoParams {
+			return ns.vdsoParams(p)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTimeNamespace registers the parameter page of ns to be updated by Write.
+func (v *VDSOParamPage) addTimeNamespace(ns *TimeNamespace) {
+	v.timeNamespacesMu.Lock()
+	defer v.timeNamespacesMu.Unlock()
+	if v.timeNamespaces == nil {
+		v.timeNamespaces = make(map[*TimeNamespace]struct{})
+	}
+	v.timeNamespaces[ns] = struct{}{}
+}
+
+// removeTimeNamespace undoes a previous call to addTimeNamespace.
+func (v *VDSOParamPage) removeTimeNamespace(ns *TimeNamespace) {
+	v.timeNamespacesMu.Lock()
+	defer v.timeNamespacesMu.Unlock()
+	delete(v.timeNamespaces, ns)
+}
+
+// write implements Write for a single page.
+func (v *VDSOParamPage) write(f func() vdsoParams) error {
 	paramPage, err := v.access()
 	if err != nil {
 		return err
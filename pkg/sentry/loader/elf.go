@@ -17,9 +17,13 @@ package loader
 import (
 	"bytes"
 	"debug/elf"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"sort"
+	"time"
 
+	"golang.org/x/sys/unix"
 	"gvisor.dev/gvisor/pkg/abi"
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/context"
@@ -27,11 +31,13 @@ import (
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/hostarch"
 	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/metric"
 	"gvisor.dev/gvisor/pkg/sentry/arch"
 	"gvisor.dev/gvisor/pkg/sentry/limits"
 	"gvisor.dev/gvisor/pkg/sentry/memmap"
 	"gvisor.dev/gvisor/pkg/sentry/mm"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/sync"
 	"gvisor.dev/gvisor/pkg/usermem"
 )
 
@@ -42,6 +48,11 @@ const (
 	// maxTotalPhdrSize is the maximum combined size of all program
 	// headers.  Linux limits this to one page.
 	maxTotalPhdrSize = hostarch.PageSize
+
+	// minParallelSegmentBytes is the minimum combined size of an ELF's
+	// PT_LOAD segments for mapSegments to read them concurrently. Below this,
+	// starting goroutines costs more than reading serially.
+	minParallelSegmentBytes = 4 << 20
 )
 
 var (
@@ -95,6 +106,19 @@ type fullReader interface {
 	ReadFull(ctx context.Context, dst usermem.IOSequence, offset int64) (int64, error)
 }
 
+// unsupported32BitBinaries counts attempts to execute 32-bit ELF binaries.
+var unsupported32BitBinaries = metric.MustCreateNewUint64Metric("/exec/unsupported_32bit_binaries",
+	metric.Uint64Metadata{
+		Cumulative:  true,
+		Description: "Number of attempts to execute 32-bit ELF binaries, which are not supported.",
+	})
+
+// compatLogger logs attempts to execute 32-bit ELF binaries.
+var compatLogger = log.BasicRateLimitedLogger(time.Minute)
+
+// elf32MachineOff is the offset of e_machine in the ELF32 header.
+const elf32MachineOff = 18
+
 // parseHeader parse the ELF header, verifying that this is a supported ELF
 // file and returning the ELF program headers.
 //
@@ -122,7 +146,11 @@ func parseHeader(ctx context.Context, f fullReader) (elfInfo, error) {
 
 	// We only support 64-bit, little endian binaries
 	if class := elf.Class(ident[elf.EI_CLASS]); class != elf.ELFCLASS64 {
-		log.Infof("Unsupported ELF class: %v", class)
+		if class == elf.ELFCLASS32 {
+			report32BitBinary(ctx, f, elf.Data(ident[elf.EI_DATA]))
+		} else {
+			log.Infof("Unsupported ELF class: %v", class)
+		}
 		return elfInfo{}, linuxerr.ENOEXEC
 	}
 	if endian := elf.Data(ident[elf.EI_DATA]); endian != elf.ELFDATA2LSB {
@@ -150,17 +178,25 @@ func parseHeader(ctx context.Context, f fullReader) (elfInfo, error) {
 	}
 	hdr.UnmarshalUnsafe(hdrBuf)
 
-	// We support amd64 and arm64.
+	// We support amd64, arm64 and riscv64.
 	var a arch.Arch
 	switch machine := elf.Machine(hdr.Machine); machine {
 	case elf.EM_X86_64:
 		a = arch.AMD64
 	case elf.EM_AARCH64:
 		a = arch.ARM64
+	case elf.EM_RISCV:
+		a = arch.RISCV64
 	default:
 		log.Infof("Unsupported ELF machine %d", machine)
 		return elfInfo{}, linuxerr.ENOEXEC
 	}
+	// Like Linux's elf_check_arch(), only accept binaries for the host
+	// architecture, since guest code runs natively.
+	if a != arch.Host {
+		log.Infof("ELF architecture %v doesn't match the host architecture %v", a, arch.Host)
+		return elfInfo{}, linuxerr.ENOEXEC
+	}
 
 	var sharedObject bool
 	elfType := elf.Type(hdr.Type)
@@ -230,9 +266,69 @@ func parseHeader(ctx context.Context, f fullReader) (elfInfo, error) {
 	}, nil
 }
 
+// report32BitBinary reports an attempt to execute the 32-bit ELF binary f,
+// whose data encoding is data.
+//
+// Running 32-bit binaries (e.g. i386 or arm) requires a compat syscall table
+// and entry path, 32-bit signal frames and a 32-bit auxv layout, none of which
+// are implemented. They fail with ENOEXEC, as on Linux built without
+// CONFIG_IA32_EMULATION or CONFIG_COMPAT, which is otherwise difficult to
+// diagnose.
+func report32BitBinary(ctx context.Context, f fullReader, data elf.Data) {
+	unsupported32BitBinaries.Increment()
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if data == elf.ELFDATA2MSB {
+		byteOrder = binary.BigEndian
+	}
+	var machine [2]byte
+	if _, err := f.ReadFull(ctx, usermem.BytesIOSequence(machine[:]), elf32MachineOff); err != nil {
+		compatLogger.Warningf("Unsupported 32-bit ELF binary: 32-bit execution is not supported")
+		return
+	}
+	compatLogger.Warningf("Unsupported 32-bit %v ELF binary: 32-bit execution is not supported", elf.Machine(byteOrder.Uint16(machine[:])))
+}
+
 // mapSegment maps a phdr into the Task. offset is the offset to apply to
 // phdr.Vaddr.
-func mapSegment(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescription, phdr *elf.ProgHeader, offset hostarch.Addr) error {
+func mapSegment(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescription, phdr *elf.ProgHeader, offset hostarch.Addr, textOpts TextSegmentOpts) error {
+	s, err := mapSegmentFile(ctx, m, fd, phdr, offset, textOpts)
+	if err != nil {
+		return err
+	}
+	return s.finish(ctx, m)
+}
+
+// segmentMapping is a PT_LOAD segment whose file-backed pages have been mapped
+// by mapSegmentFile.
+type segmentMapping struct {
+	phdr *elf.ProgHeader
+
+	// addr is the page-aligned address of the segment.
+	addr hostarch.Addr
+
+	// adjust is the page offset of phdr.Vaddr.
+	adjust uint64
+
+	// fileSize is the number of bytes mapped from the file, starting at addr.
+	// mapSize is fileSize rounded up to the page size.
+	fileSize uint64
+	mapSize  uint64
+
+	// If mapSize is not zero, mappable is the memmap.Mappable mapped at addr,
+	// starting at offset fileOffset. mappable is kept alive by the mapping.
+	mappable   memmap.Mappable
+	fileOffset uint64
+
+	// If prefault is true, the file-backed pages are prefaulted. If hugeText
+	// is also true, they are copied into hugepage-backed memory.
+	prefault bool
+	hugeText bool
+}
+
+// mapSegmentFile maps the file-backed pages of phdr into m. offset is the
+// offset to apply to phdr.Vaddr. The caller must call finish on the returned
+// segmentMapping to complete the mapping of the segment.
+func mapSegmentFile(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescription, phdr *elf.ProgHeader, offset hostarch.Addr, textOpts TextSegmentOpts) (segmentMapping, error) {
 	// We must make a page-aligned mapping.
 	adjust := hostarch.Addr(phdr.Vaddr).PageOffset()
 
@@ -240,53 +336,116 @@ func mapSegment(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescriptio
 	if !ok {
 		// If offset != 0 we should have ensured this would fit.
 		ctx.Warningf("Computed segment load address overflows: %#x + %#x", phdr.Vaddr, offset)
-		return linuxerr.ENOEXEC
+		return segmentMapping{}, linuxerr.ENOEXEC
 	}
 	addr -= hostarch.Addr(adjust)
 
 	fileSize := phdr.Filesz + adjust
 	if fileSize < phdr.Filesz {
 		ctx.Infof("Computed segment file size overflows: %#x + %#x", phdr.Filesz, adjust)
-		return linuxerr.ENOEXEC
+		return segmentMapping{}, linuxerr.ENOEXEC
 	}
 	ms, ok := hostarch.Addr(fileSize).RoundUp()
 	if !ok {
 		ctx.Infof("fileSize %#x too large", fileSize)
-		return linuxerr.ENOEXEC
+		return segmentMapping{}, linuxerr.ENOEXEC
+	}
+	s := segmentMapping{
+		phdr:     phdr,
+		addr:     addr,
+		adjust:   adjust,
+		fileSize: fileSize,
+		mapSize:  uint64(ms),
+	}
+	if s.mapSize == 0 {
+		return s, nil
 	}
-	mapSize := uint64(ms)
 
-	if mapSize > 0 {
-		// This must result in a page-aligned offset. i.e., the original
-		// phdr.Off must have the same alignment as phdr.Vaddr. If that is not
-		// true, MMap will reject the mapping.
-		fileOffset := phdr.Off - adjust
-
-		prot := progFlagsAsPerms(phdr.Flags)
-		mopts := memmap.MMapOpts{
-			Length: mapSize,
-			Offset: fileOffset,
-			Addr:   addr,
-			Fixed:  true,
-			// Linux will happily allow conflicting segments to map over
-			// one another.
-			Unmap:    true,
-			Private:  true,
-			Perms:    prot,
-			MaxPerms: hostarch.AnyAccess,
+	// This must result in a page-aligned offset. i.e., the original
+	// phdr.Off must have the same alignment as phdr.Vaddr. If that is not
+	// true, MMap will reject the mapping.
+	fileOffset := phdr.Off - adjust
+
+	prot := progFlagsAsPerms(phdr.Flags)
+	prefault, hugeText := textOpts.prefault(prot, s.mapSize)
+	mopts := memmap.MMapOpts{
+		Length: s.mapSize,
+		Offset: fileOffset,
+		Addr:   addr,
+		Fixed:  true,
+		// Linux will happily allow conflicting segments to map over
+		// one another.
+		Unmap:    true,
+		Private:  true,
+		Perms:    prot,
+		MaxPerms: hostarch.AnyAccess,
+		HugeCopy: hugeText,
+	}
+	defer func() {
+		if mopts.MappingIdentity != nil {
+			mopts.MappingIdentity.DecRef(ctx)
 		}
-		defer func() {
-			if mopts.MappingIdentity != nil {
-				mopts.MappingIdentity.DecRef(ctx)
+	}()
+	if err := fd.ConfigureMMap(ctx, &mopts); err != nil {
+		ctx.Infof("File is not memory-mappable: %v", err)
+		return segmentMapping{}, err
+	}
+	if _, err := m.MMap(ctx, mopts); err != nil {
+		ctx.Infof("Error mapping PT_LOAD segment %+v at %#x: %v", phdr, addr, err)
+		return segmentMapping{}, err
+	}
+	s.mappable = mopts.Mappable
+	s.fileOffset = fileOffset
+	s.prefault = prefault
+	s.hugeText = hugeText
+	return s, nil
+}
+
+// fill reads the file-backed pages of s into memory if they are prefaulted, so
+// that prefaulting them in finish doesn't wait for I/O while holding the
+// MemoryManager's locks. Unlike the rest of the mapping of a segment, this
+// doesn't mutate the MemoryManager, so it may be done concurrently for
+// several segments. fill is best-effort; errors are reported by finish.
+func (s *segmentMapping) fill(ctx context.Context) {
+	if !s.prefault || s.mappable == nil {
+		return
+	}
+	mr := memmap.MappableRange{s.fileOffset, s.fileOffset + s.mapSize}
+	// Translate may fill a page cache. The mapping of the segment satisfies
+	// its precondition, and the translations are discarded immediately, so
+	// they can't be invalidated while in use.
+	ts, _ := s.mappable.Translate(ctx, mr, mr, hostarch.Read)
+	for _, t := range ts {
+		ims, err := t.File.MapInternal(t.FileRange(), hostarch.Read)
+		if err != nil {
+			return
+		}
+		for ; !ims.IsEmpty(); ims = ims.Tail() {
+			b := ims.Head()
+			// Fault in pages of files backed by host files, reading them if
+			// necessary. This may fail if the host doesn't support
+			// MADV_POPULATE_READ (Linux < 5.14), leaving the pages to be faulted
+			// in by finish.
+			if _, _, errno := unix.Syscall(unix.SYS_MADVISE, b.Addr(), uintptr(b.Len()), unix.MADV_POPULATE_READ); errno != 0 {
+				return
 			}
-		}()
-		if err := fd.ConfigureMMap(ctx, &mopts); err != nil {
-			ctx.Infof("File is not memory-mappable: %v", err)
-			return err
 		}
-		if _, err := m.MMap(ctx, mopts); err != nil {
-			ctx.Infof("Error mapping PT_LOAD segment %+v at %#x: %v", phdr, addr, err)
-			return err
+	}
+}
+
+// finish completes the mapping of s into m by prefaulting its file-backed
+// pages if required, and mapping its anonymous pages.
+func (s *segmentMapping) finish(ctx context.Context, m *mm.MemoryManager) error {
+	phdr, addr, fileSize, mapSize := s.phdr, s.addr, s.fileSize, s.mapSize
+	if mapSize > 0 {
+		if s.prefault {
+			// This must be done before zeroing the end of the segment below,
+			// which would otherwise break copy-on-write for only the last
+			// page of the segment.
+			if err := prefaultTextSegment(ctx, m, hostarch.AddrRange{addr, addr + hostarch.Addr(mapSize)}, s.hugeText); err != nil {
+				ctx.Infof("Error prefaulting PT_LOAD segment %+v at %#x: %v", phdr, addr, err)
+				return err
+			}
 		}
 
 		// When phdr.Memsz > phdr.Filesz, we need to clear the end of the last page that
@@ -314,9 +473,9 @@ func mapSegment(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescriptio
 		}
 	}
 
-	memSize := phdr.Memsz + adjust
+	memSize := phdr.Memsz + s.adjust
 	if memSize < phdr.Memsz {
-		ctx.Infof("Computed segment mem size overflows: %#x + %#x", phdr.Memsz, adjust)
+		ctx.Infof("Computed segment mem size overflows: %#x + %#x", phdr.Memsz, s.adjust)
 		return linuxerr.ENOEXEC
 	}
 
@@ -358,6 +517,164 @@ func mapSegment(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescriptio
 	return nil
 }
 
+// prefault returns true if the file-backed pages of a segment with
+// permissions prot, mapSize bytes of which are mapped from the file, are
+// prefaulted. huge is true if they are also copied into hugepage-backed
+// memory.
+func (o TextSegmentOpts) prefault(prot hostarch.AccessType, mapSize uint64) (prefault, huge bool) {
+	huge = o.Huge && prot.Execute && mapSize >= hostarch.HugePageSize
+	return prot.Execute && (huge || o.Prefault), huge
+}
+
+// prefaultedSegments returns the number of segments in phdrs whose file-backed
+// pages are prefaulted given opts.
+func prefaultedSegments(phdrs []*elf.ProgHeader, opts TextSegmentOpts) int {
+	n := 0
+	for _, phdr := range phdrs {
+		adjust := hostarch.Addr(phdr.Vaddr).PageOffset()
+		mapSize, ok := hostarch.Addr(phdr.Filesz + adjust).RoundUp()
+		if !ok || mapSize == 0 {
+			continue
+		}
+		if prefault, _ := opts.prefault(progFlagsAsPerms(phdr.Flags), uint64(mapSize)); prefault {
+			n++
+		}
+	}
+	return n
+}
+
+// segmentOpts controls how loadParsedELF maps PT_LOAD segments.
+type segmentOpts struct {
+	// text controls how executable segments are mapped.
+	text TextSegmentOpts
+
+	// If asyncContext is not nil, mapSegments may read prefaulted segments
+	// concurrently in goroutines using contexts returned by asyncContext.
+	asyncContext func() context.Context
+}
+
+// segmentRange returns the page-aligned range of addresses occupied by the
+// file-backed and anonymous mappings of phdr, once offset is applied to
+// phdr.Vaddr. ok is false if the range overflows.
+func segmentRange(phdr *elf.ProgHeader, offset hostarch.Addr) (ar hostarch.AddrRange, ok bool) {
+	start, ok := offset.AddLength(phdr.Vaddr)
+	if !ok {
+		return hostarch.AddrRange{}, false
+	}
+	end, ok := start.AddLength(phdr.Memsz)
+	if !ok {
+		return hostarch.AddrRange{}, false
+	}
+	end, ok = end.RoundUp()
+	if !ok {
+		return hostarch.AddrRange{}, false
+	}
+	return hostarch.AddrRange{start.RoundDown(), end}, true
+}
+
+// parallelizableSegments returns true if the segments in phdrs can be read
+// concurrently, which is the case if they are large enough to be worth it and
+// no two of them share a page. Linux allows later segments to map over
+// earlier ones, so segments that share a page must be mapped strictly in
+// order.
+func parallelizableSegments(phdrs []*elf.ProgHeader, offset hostarch.Addr) bool {
+	if len(phdrs) < 2 {
+		return false
+	}
+	ars := make([]hostarch.AddrRange, 0, len(phdrs))
+	var total uint64
+	for _, phdr := range phdrs {
+		ar, ok := segmentRange(phdr, offset)
+		if !ok {
+			// Let mapSegment report the error.
+			return false
+		}
+		ars = append(ars, ar)
+		total += uint64(ar.Length())
+	}
+	if total < minParallelSegmentBytes {
+		return false
+	}
+	sort.Slice(ars, func(i, j int) bool { return ars[i].Start < ars[j].Start })
+	for i := 1; i < len(ars); i++ {
+		if ars[i].Overlaps(ars[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// mapSegments maps the non-empty PT_LOAD segments in phdrs into m. offset is
+// the offset to apply to each phdr.Vaddr.
+//
+// Mapping a segment includes configuring the file mapping, mutating m and,
+// depending on opts, prefaulting the segment, which dominates exec time for
+// very large binaries since it reads the whole segment. Mutations of m are
+// serialized by m's locks, so segments are always mapped in program header
+// order. If opts.asyncContext is not nil, the segments are disjoint and
+// several of them are prefaulted, the pages of prefaulted segments are read
+// concurrently beforehand, outside of m's locks. Otherwise, which includes the
+// default configuration where no segment is prefaulted, segments are mapped
+// one after the other.
+func mapSegments(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescription, phdrs []elf.ProgHeader, offset hostarch.Addr, opts segmentOpts) error {
+	var loads []*elf.ProgHeader
+	for i := range phdrs {
+		// No need to load segments with size 0, but they exist in some
+		// binaries.
+		if phdrs[i].Type == elf.PT_LOAD && phdrs[i].Memsz != 0 {
+			loads = append(loads, &phdrs[i])
+		}
+	}
+
+	if opts.asyncContext == nil || prefaultedSegments(loads, opts.text) < 2 || !parallelizableSegments(loads, offset) {
+		for _, phdr := range loads {
+			if err := mapSegment(ctx, m, fd, phdr, offset, opts.text); err != nil {
+				ctx.Infof("Failed to map PT_LOAD segment: %+v", *phdr)
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Map the file-backed pages of all segments before completing the
+	// mapping of any of them, so that all of their pages can be read
+	// concurrently. This reorders the mapping of each segment's anonymous
+	// pages after the file-backed pages of later segments, which is only
+	// safe since the segments are disjoint.
+	segs := make([]segmentMapping, 0, len(loads))
+	for _, phdr := range loads {
+		s, err := mapSegmentFile(ctx, m, fd, phdr, offset, opts.text)
+		if err != nil {
+			ctx.Infof("Failed to map PT_LOAD segment: %+v", *phdr)
+			return err
+		}
+		segs = append(segs, s)
+	}
+
+	// Fill the last segment on this goroutine and the rest concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < len(segs)-1; i++ {
+		if !segs[i].prefault {
+			continue
+		}
+		wg.Add(1)
+		go func(s *segmentMapping, ctx context.Context) {
+			defer wg.Done()
+			s.fill(ctx)
+		}(&segs[i], opts.asyncContext())
+	}
+	segs[len(segs)-1].fill(ctx)
+	wg.Wait()
+
+	for i := range segs {
+		if err := segs[i].finish(ctx, m); err != nil {
+			ctx.Infof("Failed to map PT_LOAD segment: %+v", *segs[i].phdr)
+			return err
+		}
+	}
+	return nil
+}
+
 // loadedELF describes an ELF that has been successfully loaded.
 type loadedELF struct {
 	// os is the target OS of the ELF.
@@ -394,21 +711,62 @@ type loadedELF struct {
 	//	* AT_BASE
 	//	* AT_ENTRY
 	auxv arch.Auxv
+
+	// unreadable is true if the ELF or its interpreter can not be read by
+	// the caller.
+	unreadable bool
+
+	// buildID is the contents of the ELF's NT_GNU_BUILD_ID note, if any.
+	buildID []byte
+
+	// features is elfLayout.features for the ELF.
+	features uint32
+
+	// randomizeVASpace is the level of address space layout randomization
+	// applied to the ELF's address space.
+	randomizeVASpace int32
 }
 
-// loadParsedELF loads f into mm.
-//
-// info is the parsed elfInfo from the header.
-//
-// It does not load the ELF interpreter, or return any auxv entries.
-//
-// Preconditions: f is an ELF file.
-func loadParsedELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescription, info elfInfo, sharedLoadOffset hostarch.Addr) (loadedELF, error) {
+// elfLayout describes properties of an ELF derived from its program headers.
+type elfLayout struct {
+	// start is the address of the first PT_LOAD segment, before relocation.
+	start hostarch.Addr
+
+	// end is the end of the last PT_LOAD segment, before relocation.
+	end hostarch.Addr
+
+	// interpreter is the path to the ELF interpreter.
+	interpreter string
+
+	// buildID is the contents of the NT_GNU_BUILD_ID note, or nil if the ELF
+	// has none. It must not be modified.
+	buildID []byte
+
+	// features is the value of the ELF's GNU_PROPERTY_X86_FEATURE_1_AND or
+	// GNU_PROPERTY_AARCH64_FEATURE_1_AND property, depending on its
+	// architecture, or 0 if it has none.
+	features uint32
+}
+
+// parseLayout validates the program headers in info and returns the resulting
+// elfLayout.
+func parseLayout(ctx context.Context, fd *vfs.FileDescription, info elfInfo) (elfLayout, error) {
 	first := true
 	var start, end hostarch.Addr
 	var interpreter string
-	for _, phdr := range info.phdrs {
+	var buildID []byte
+	var propertyPhdr *elf.ProgHeader
+	for i, phdr := range info.phdrs {
 		switch phdr.Type {
+		case elf.PT_NOTE:
+			if buildID == nil {
+				buildID = readBuildID(ctx, fd, &phdr)
+			}
+
+		case elf.PT_GNU_PROPERTY:
+			// As in Linux, only the last PT_GNU_PROPERTY segment is used.
+			propertyPhdr = &info.phdrs[i]
+
 		case elf.PT_LOAD:
 			vaddr := hostarch.Addr(phdr.Vaddr)
 			if first {
@@ -419,27 +777,27 @@ func loadParsedELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescrip
 				// NOTE(b/37474556): Linux allows out-of-order
 				// segments, in violation of the spec.
 				ctx.Infof("PT_LOAD headers out-of-order. %#x < %#x", vaddr, end)
-				return loadedELF{}, linuxerr.ENOEXEC
+				return elfLayout{}, linuxerr.ENOEXEC
 			}
 			var ok bool
 			end, ok = vaddr.AddLength(phdr.Memsz)
 			if !ok {
 				ctx.Infof("PT_LOAD header size overflows. %#x + %#x", vaddr, phdr.Memsz)
-				return loadedELF{}, linuxerr.ENOEXEC
+				return elfLayout{}, linuxerr.ENOEXEC
 			}
 
 		case elf.PT_INTERP:
 			if phdr.Filesz < 2 {
 				ctx.Infof("PT_INTERP path too small: %v", phdr.Filesz)
-				return loadedELF{}, linuxerr.ENOEXEC
+				return elfLayout{}, linuxerr.ENOEXEC
 			}
 			if phdr.Filesz > linux.PATH_MAX {
 				ctx.Infof("PT_INTERP path too big: %v", phdr.Filesz)
-				return loadedELF{}, linuxerr.ENOEXEC
+				return elfLayout{}, linuxerr.ENOEXEC
 			}
 			if int64(phdr.Off) < 0 || int64(phdr.Off+phdr.Filesz) < 0 {
 				ctx.Infof("Unsupported PT_INTERP offset %d", phdr.Off)
-				return loadedELF{}, linuxerr.ENOEXEC
+				return elfLayout{}, linuxerr.ENOEXEC
 			}
 
 			path := make([]byte, phdr.Filesz)
@@ -447,12 +805,12 @@ func loadParsedELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescrip
 			if err != nil {
 				// If an interpreter was specified, it should exist.
 				ctx.Infof("Error reading PT_INTERP path: %v", err)
-				return loadedELF{}, linuxerr.ENOEXEC
+				return elfLayout{}, linuxerr.ENOEXEC
 			}
 
 			if path[len(path)-1] != 0 {
 				ctx.Infof("PT_INTERP path not NUL-terminated: %v", path)
-				return loadedELF{}, linuxerr.ENOEXEC
+				return elfLayout{}, linuxerr.ENOEXEC
 			}
 
 			// Strip NUL-terminator and everything beyond from
@@ -473,10 +831,213 @@ func loadParsedELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescrip
 				// the open path would return a different
 				// error.
 				ctx.Infof("PT_INTERP path is empty: %v", path)
-				return loadedELF{}, linuxerr.EACCES
+				return elfLayout{}, linuxerr.EACCES
+			}
+		}
+	}
+
+	var features uint32
+	if propertyPhdr != nil {
+		var err error
+		features, err = readGNUProperties(ctx, fd, propertyPhdr, info.arch)
+		if err != nil {
+			// Linux only parses GNU properties on architectures that select
+			// CONFIG_ARCH_USE_GNU_PROPERTY, of which we support only arm64.
+			if info.arch == arch.ARM64 {
+				return elfLayout{}, err
+			}
+			ctx.Debugf("Ignoring invalid PT_GNU_PROPERTY segment: %v", err)
+			features = 0
+		}
+	}
+
+	return elfLayout{
+		start:       start,
+		end:         end,
+		interpreter: interpreter,
+		buildID:     buildID,
+		features:    features,
+	}, nil
+}
+
+const (
+	// maxGNUPropertySegmentSize is the size of the largest accepted
+	// PT_GNU_PROPERTY segment. This is NOTE_DATA_SZ in Linux.
+	maxGNUPropertySegmentSize = 1 << 10
+
+	// gnuPropertyAlign is the alignment of GNU properties in 64-bit ELFs.
+	// This is ELF_GNU_PROPERTY_ALIGN in Linux.
+	gnuPropertyAlign = 8
+)
+
+// readGNUProperties returns the value of the feature property for arch in the
+// NT_GNU_PROPERTY_TYPE_0 note in the PT_GNU_PROPERTY segment described by
+// phdr, or 0 if the note does not have one. Compare Linux's
+// fs/binfmt_elf.c:parse_elf_properties().
+func readGNUProperties(ctx context.Context, fd *vfs.FileDescription, phdr *elf.ProgHeader, a arch.Arch) (uint32, error) {
+	const gnuName = "GNU\x00"
+	if phdr.Filesz > maxGNUPropertySegmentSize {
+		ctx.Infof("PT_GNU_PROPERTY segment too big: %d", phdr.Filesz)
+		return 0, linuxerr.ENOEXEC
+	}
+	if int64(phdr.Off) < 0 || int64(phdr.Off+phdr.Filesz) < 0 {
+		ctx.Infof("Unsupported PT_GNU_PROPERTY offset %d", phdr.Off)
+		return 0, linuxerr.ENOEXEC
+	}
+	buf := make([]byte, phdr.Filesz)
+	n, err := fd.ReadFull(ctx, usermem.BytesIOSequence(buf), int64(phdr.Off))
+	if n < linux.ElfNoteHeaderSize+int64(len(gnuName)) {
+		ctx.Infof("Error reading PT_GNU_PROPERTY segment: %v", err)
+		return 0, linuxerr.EIO
+	}
+	buf = buf[:n]
+
+	nameSize := binary.LittleEndian.Uint32(buf[0:4])
+	descSize := uint64(binary.LittleEndian.Uint32(buf[4:8]))
+	noteType := binary.LittleEndian.Uint32(buf[8:12])
+	if noteType != linux.NT_GNU_PROPERTY_TYPE_0 || nameSize != uint32(len(gnuName)) || string(buf[linux.ElfNoteHeaderSize:linux.ElfNoteHeaderSize+len(gnuName)]) != gnuName {
+		ctx.Infof("PT_GNU_PROPERTY segment does not contain an NT_GNU_PROPERTY_TYPE_0 note")
+		return 0, linuxerr.ENOEXEC
+	}
+	off := uint64(linux.ElfNoteHeaderSize+len(gnuName)+gnuPropertyAlign-1) &^ (gnuPropertyAlign - 1)
+	if off > uint64(len(buf)) || descSize > uint64(len(buf))-off {
+		ctx.Infof("NT_GNU_PROPERTY_TYPE_0 note descriptor size %d too big", descSize)
+		return 0, linuxerr.ENOEXEC
+	}
+	desc := buf[off : off+descSize]
+
+	featureType := uint32(linux.GNU_PROPERTY_X86_FEATURE_1_AND)
+	if a == arch.ARM64 {
+		featureType = linux.GNU_PROPERTY_AARCH64_FEATURE_1_AND
+	}
+	var features uint32
+	havePrevType := false
+	var prevType uint32
+	for len(desc) != 0 {
+		if len(desc) < linux.ElfGNUPropertyHeaderSize {
+			ctx.Infof("Truncated GNU property header")
+			return 0, linuxerr.ENOEXEC
+		}
+		prType := binary.LittleEndian.Uint32(desc[0:4])
+		prDataSize := uint64(binary.LittleEndian.Uint32(desc[4:8]))
+		desc = desc[linux.ElfGNUPropertyHeaderSize:]
+		step := (prDataSize + gnuPropertyAlign - 1) &^ (gnuPropertyAlign - 1)
+		if step > uint64(len(desc)) {
+			ctx.Infof("GNU property %#x data size %d too big", prType, prDataSize)
+			return 0, linuxerr.ENOEXEC
+		}
+		// Properties are supposed to be unique and sorted by type.
+		if havePrevType && prType <= prevType {
+			ctx.Infof("GNU property %#x out of order after %#x", prType, prevType)
+			return 0, linuxerr.ENOEXEC
+		}
+		havePrevType = true
+		prevType = prType
+		if prType == featureType {
+			if prDataSize != 4 {
+				ctx.Infof("GNU property %#x has data size %d, want 4", prType, prDataSize)
+				return 0, linuxerr.ENOEXEC
+			}
+			features = binary.LittleEndian.Uint32(desc[0:4])
+		}
+		desc = desc[step:]
+	}
+	return features, nil
+}
+
+const (
+	// maxNoteSegmentSize is the size of the largest PT_NOTE segment that is
+	// searched for a build ID.
+	maxNoteSegmentSize = 1 << 12
+
+	// maxBuildIDSize is the size of the largest accepted build ID. This is
+	// BUILD_ID_SIZE_MAX in Linux.
+	maxBuildIDSize = 20
+)
+
+// readBuildID returns the contents of the NT_GNU_BUILD_ID note in the PT_NOTE
+// segment described by phdr, or nil if there is none. Malformed notes are
+// ignored, since the build ID does not affect loading. Compare Linux's
+// lib/buildid.c:parse_build_id().
+func readBuildID(ctx context.Context, fd *vfs.FileDescription, phdr *elf.ProgHeader) []byte {
+	if phdr.Filesz < linux.ElfNoteHeaderSize || phdr.Filesz > maxNoteSegmentSize {
+		return nil
+	}
+	if int64(phdr.Off) < 0 || int64(phdr.Off+phdr.Filesz) < 0 {
+		return nil
+	}
+	buf := make([]byte, phdr.Filesz)
+	if _, err := fd.ReadFull(ctx, usermem.BytesIOSequence(buf), int64(phdr.Off)); err != nil {
+		ctx.Debugf("Error reading PT_NOTE segment: %v", err)
+		return nil
+	}
+
+	const gnuName = "GNU\x00"
+	for len(buf) >= linux.ElfNoteHeaderSize {
+		nameSize := uint64(binary.LittleEndian.Uint32(buf[0:4]))
+		descSize := uint64(binary.LittleEndian.Uint32(buf[4:8]))
+		noteType := binary.LittleEndian.Uint32(buf[8:12])
+		// Names and descriptors are padded to 4 bytes.
+		descOff := linux.ElfNoteHeaderSize + (nameSize+3)&^3
+		descEnd := descOff + descSize
+		if descEnd > uint64(len(buf)) {
+			return nil
+		}
+		if noteType == linux.NT_GNU_BUILD_ID && nameSize == uint64(len(gnuName)) && string(buf[linux.ElfNoteHeaderSize:linux.ElfNoteHeaderSize+nameSize]) == gnuName {
+			if descSize == 0 || descSize > maxBuildIDSize {
+				return nil
 			}
+			return append([]byte(nil), buf[descOff:descEnd]...)
+		}
+		next := (descEnd + 3) &^ 3
+		if next >= uint64(len(buf)) {
+			break
 		}
+		buf = buf[next:]
 	}
+	return nil
+}
+
+// prefaultTextSegment faults in the executable segment mapped at ar. If huge is
+// true, it also copies the segment into private memory, such that the
+// hugepage-aligned part of the segment is eligible to be hugepage-backed.
+func prefaultTextSegment(ctx context.Context, m *mm.MemoryManager, ar hostarch.AddrRange, huge bool) error {
+	if !huge {
+		return m.Prefault(ctx, ar, false /* breakCOW */)
+	}
+	// Copies are only hugepage-backed if they are hugepage-aligned, so copy
+	// the hugepage-aligned part of ar separately from the unaligned parts on
+	// either side of it.
+	hugeStart, ok := ar.Start.HugeRoundUp()
+	hugeAR := hostarch.AddrRange{hugeStart, ar.End.HugeRoundDown()}
+	if !ok || hugeAR.Start >= hugeAR.End {
+		return m.Prefault(ctx, ar, true /* breakCOW */)
+	}
+	for _, pr := range []hostarch.AddrRange{
+		{ar.Start, hugeAR.Start},
+		hugeAR,
+		{hugeAR.End, ar.End},
+	} {
+		if pr.Length() == 0 {
+			continue
+		}
+		if err := m.Prefault(ctx, pr, true /* breakCOW */); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadParsedELF loads f into mm.
+//
+// info is the parsed elfInfo from the header, and layout is the result of
+// parseLayout(info).
+//
+// It does not load the ELF interpreter, or return any auxv entries.
+//
+// Preconditions: f is an ELF file.
+func loadParsedELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescription, info elfInfo, layout elfLayout, sharedLoadOffset hostarch.Addr, opts segmentOpts) (loadedELF, error) {
+	start, end := layout.start, layout.end
 
 	// Shared objects don't have fixed load addresses. We need to pick a
 	// base address big enough to fit all segments, so we first create a
@@ -497,6 +1058,14 @@ func loadParsedELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescrip
 			return loadedELF{}, linuxerr.ENOEXEC
 		}
 
+		// Executable segments can only be hugepage-backed if they are
+		// hugepage-aligned, so align the load address to match.
+		if opts.text.Huge && uint64(totalSize) >= hostarch.HugePageSize {
+			if aligned, ok := sharedLoadOffset.HugeRoundUp(); ok {
+				sharedLoadOffset = aligned
+			}
+		}
+
 		var err error
 		offset, err = m.MMap(ctx, memmap.MMapOpts{
 			Length:  uint64(totalSize),
@@ -531,20 +1100,8 @@ func loadParsedELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescrip
 	}
 
 	// Map PT_LOAD segments.
-	for _, phdr := range info.phdrs {
-		switch phdr.Type {
-		case elf.PT_LOAD:
-			if phdr.Memsz == 0 {
-				// No need to load segments with size 0, but
-				// they exist in some binaries.
-				continue
-			}
-
-			if err := mapSegment(ctx, m, fd, &phdr, offset); err != nil {
-				ctx.Infof("Failed to map PT_LOAD segment: %+v", phdr)
-				return loadedELF{}, err
-			}
-		}
+	if err := mapSegments(ctx, m, fd, info.phdrs, offset, opts); err != nil {
+		return loadedELF{}, err
 	}
 
 	// This assumes that the first segment contains the ELF headers. This
@@ -562,10 +1119,12 @@ func loadParsedELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescrip
 		entry:       info.entry,
 		start:       start,
 		end:         end,
-		interpreter: interpreter,
+		interpreter: layout.interpreter,
 		phdrAddr:    phdrAddr,
 		phdrSize:    info.phdrSize,
 		phdrNum:     len(info.phdrs),
+		buildID:     layout.buildID,
+		features:    layout.features,
 	}, nil
 }
 
@@ -578,24 +1137,30 @@ func loadParsedELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescrip
 // Preconditions:
 //   - f is an ELF file.
 //   - f is the first ELF loaded into m.
-func loadInitialELF(ctx context.Context, m *mm.MemoryManager, fs cpuid.FeatureSet, fd *vfs.FileDescription) (loadedELF, *arch.Context64, error) {
-	info, err := parseHeader(ctx, fd)
-	if err != nil {
-		ctx.Infof("Failed to parse initial ELF: %v", err)
-		return loadedELF{}, nil, err
-	}
-
-	// Check Image Compatibility.
-	if arch.Host != info.arch {
-		ctx.Warningf("Found mismatch for platform %s with ELF type %s", arch.Host.String(), info.arch.String())
-		return loadedELF{}, nil, linuxerr.ENOEXEC
+func loadInitialELF(ctx context.Context, m *mm.MemoryManager, fs cpuid.FeatureSet, fd *vfs.FileDescription, randomizeVASpace int32, opts segmentOpts) (loadedELF, *arch.Context64, error) {
+	info, layout, key, keyOK, ok := lookupELF(ctx, fd)
+	if !ok {
+		var err error
+		info, err = parseHeader(ctx, fd)
+		if err != nil {
+			ctx.Infof("Failed to parse initial ELF: %v", err)
+			return loadedELF{}, nil, err
+		}
+		layout, err = parseLayout(ctx, fd, info)
+		if err != nil {
+			return loadedELF{}, nil, err
+		}
+		if keyOK {
+			storeELF(key, info, layout)
+		}
 	}
 
 	// Create the arch.Context64 now so we can prepare the mmap layout before
 	// mapping anything.
 	ac := arch.New(info.arch)
 
-	l, err := m.SetMmapLayout(ac, limits.FromContext(ctx))
+	randomize := randomizeVASpace != RandomizeNone
+	l, err := m.SetMmapLayout(ac, limits.FromContext(ctx), randomize)
 	if err != nil {
 		ctx.Warningf("Failed to set mmap layout: %v", err)
 		return loadedELF{}, nil, err
@@ -604,7 +1169,7 @@ func loadInitialELF(ctx context.Context, m *mm.MemoryManager, fs cpuid.FeatureSe
 	// PIELoadAddress tries to move the ELF out of the way of the default
 	// mmap base to ensure that the initial brk has sufficient space to
 	// grow.
-	le, err := loadParsedELF(ctx, m, fd, info, ac.PIELoadAddress(l))
+	le, err := loadParsedELF(ctx, m, fd, info, layout, ac.PIELoadAddress(l, randomize), opts)
 	return le, ac, err
 }
 
@@ -615,14 +1180,25 @@ func loadInitialELF(ctx context.Context, m *mm.MemoryManager, fs cpuid.FeatureSe
 // It does not return any auxv entries.
 //
 // Preconditions: f is an ELF file.
-func loadInterpreterELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescription, initial loadedELF) (loadedELF, error) {
-	info, err := parseHeader(ctx, fd)
-	if err != nil {
-		if linuxerr.Equals(linuxerr.ENOEXEC, err) {
-			// Bad interpreter.
-			err = linuxerr.ELIBBAD
+func loadInterpreterELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDescription, initial loadedELF, opts segmentOpts) (loadedELF, error) {
+	info, layout, key, keyOK, ok := lookupELF(ctx, fd)
+	if !ok {
+		var err error
+		info, err = parseHeader(ctx, fd)
+		if err != nil {
+			if linuxerr.Equals(linuxerr.ENOEXEC, err) {
+				// Bad interpreter.
+				err = linuxerr.ELIBBAD
+			}
+			return loadedELF{}, err
+		}
+		layout, err = parseLayout(ctx, fd, info)
+		if err != nil {
+			return loadedELF{}, err
+		}
+		if keyOK {
+			storeELF(key, info, layout)
 		}
-		return loadedELF{}, err
 	}
 
 	if info.os != initial.os {
@@ -636,7 +1212,50 @@ func loadInterpreterELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDe
 
 	// The interpreter is not given a load offset, as its location does not
 	// affect brk.
-	return loadParsedELF(ctx, m, fd, info, 0)
+	return loadParsedELF(ctx, m, fd, info, layout, 0, opts)
+}
+
+// checkControlFlowFeatures handles the control-flow protection features
+// requested by an ELF's GNU properties.
+//
+// None of these features are enforced by the sentry. On arm64, Linux maps
+// executable segments of ELFs that request BTI with PROT_BTI
+// (arch/arm64/kernel/process.c:arch_elf_adjust_prot()), which application
+// mappings don't support, and PAC keys are not managed per task. On x86,
+// userspace enables IBT and shadow stacks through arch_prctl(ARCH_SHSTK_ENABLE)
+// rather than the loader, which is not supported, as in Linux built without
+// CONFIG_X86_USER_SHADOW_STACK.
+//
+// If require is true, checkControlFlowFeatures returns ENOEXEC if any feature
+// is requested. Otherwise the binary runs without the requested protection,
+// which is noted in the log.
+func checkControlFlowFeatures(ctx context.Context, a arch.Arch, features uint32, require bool) error {
+	var unenforced []string
+	switch a {
+	case arch.AMD64:
+		if features&linux.GNU_PROPERTY_X86_FEATURE_1_IBT != 0 {
+			unenforced = append(unenforced, "IBT")
+		}
+		if features&linux.GNU_PROPERTY_X86_FEATURE_1_SHSTK != 0 {
+			unenforced = append(unenforced, "SHSTK")
+		}
+	case arch.ARM64:
+		if features&linux.GNU_PROPERTY_AARCH64_FEATURE_1_BTI != 0 {
+			unenforced = append(unenforced, "BTI")
+		}
+		if features&linux.GNU_PROPERTY_AARCH64_FEATURE_1_PAC != 0 {
+			unenforced = append(unenforced, "PAC")
+		}
+	}
+	if len(unenforced) == 0 {
+		return nil
+	}
+	if require {
+		ctx.Infof("ELF requests control-flow protection that cannot be enforced: %v", unenforced)
+		return linuxerr.ENOEXEC
+	}
+	ctx.Debugf("ELF requests control-flow protection that is not enforced: %v", unenforced)
+	return nil
 }
 
 // loadELF loads args.File into the Task address space.
@@ -646,7 +1265,11 @@ func loadInterpreterELF(ctx context.Context, m *mm.MemoryManager, fd *vfs.FileDe
 //
 // Preconditions: args.File is an ELF file.
 func loadELF(ctx context.Context, args LoadArgs) (loadedELF, *arch.Context64, error) {
-	bin, ac, err := loadInitialELF(ctx, args.MemoryManager, args.Features, args.File)
+	opts := segmentOpts{
+		text:         args.TextSegments,
+		asyncContext: args.AsyncContext,
+	}
+	bin, ac, err := loadInitialELF(ctx, args.MemoryManager, args.Features, args.File, args.RandomizeVASpace, opts)
 	if err != nil {
 		ctx.Infof("Error loading binary: %v", err)
 		return loadedELF{}, nil, err
@@ -661,14 +1284,14 @@ func loadELF(ctx context.Context, args LoadArgs) (loadedELF, *arch.Context64, er
 		// Refresh the traversal limit.
 		*args.RemainingTraversals = linux.MaxSymlinkTraversals
 		args.Filename = bin.interpreter
-		intFile, err := openPath(ctx, args)
+		intFile, intUnreadable, err := openPath(ctx, args)
 		if err != nil {
 			ctx.Infof("Error opening interpreter %s: %v", bin.interpreter, err)
 			return loadedELF{}, nil, err
 		}
 		defer intFile.DecRef(ctx)
 
-		interp, err = loadInterpreterELF(ctx, args.MemoryManager, intFile, bin)
+		interp, err = loadInterpreterELF(ctx, args.MemoryManager, intFile, bin, opts)
 		if err != nil {
 			ctx.Infof("Error loading interpreter: %v", err)
 			return loadedELF{}, nil, err
@@ -679,6 +1302,18 @@ func loadELF(ctx context.Context, args LoadArgs) (loadedELF, *arch.Context64, er
 			ctx.Infof("Interpreter requires an interpreter")
 			return loadedELF{}, nil, linuxerr.ENOEXEC
 		}
+		bin.unreadable = intUnreadable
+	}
+
+	// As in Linux, the properties of the interpreter, if any, take precedence
+	// over those of the executable, since the interpreter is responsible for
+	// applying protections to the executable's segments.
+	features := bin.features
+	if bin.interpreter != "" {
+		features = interp.features
+	}
+	if err := checkControlFlowFeatures(ctx, bin.arch, features, opts.text.RequireControlFlowProtection); err != nil {
+		return loadedELF{}, nil, err
 	}
 
 	// ELF-specific auxv entries.
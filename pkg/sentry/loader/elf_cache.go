@@ -0,0 +1,139 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loader
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/sync"
+)
+
+// elfCacheSize is the maximum number of entries in elfCache.
+const elfCacheSize = 64
+
+// elfCacheKey identifies a version of an executable file.
+//
+// The file's modification and status change times are included so that an
+// executable that is replaced in place (e.g. by a compiler or package
+// manager) is parsed again rather than served from a stale entry.
+type elfCacheKey struct {
+	devMajor uint32
+	devMinor uint32
+	ino      uint64
+	size     uint64
+	mtime    linux.StatxTimestamp
+	ctime    linux.StatxTimestamp
+}
+
+// elfCacheEntry is the result of parsing an ELF file's headers.
+//
+// Entries are immutable once inserted; in particular, info.phdrs must not be
+// modified by users.
+type elfCacheEntry struct {
+	info   elfInfo
+	layout elfLayout
+}
+
+// elfCache caches parsed ELF headers and interpreter paths, so that repeated
+// execs of the same binary (e.g. a shell script running the same command in a
+// loop, or a build system running the compiler thousands of times) do not
+// need to re-read and re-validate its headers.
+var elfCache struct {
+	mu sync.Mutex
+
+	// entries maps keys to parsed headers. Protected by mu.
+	entries map[elfCacheKey]*elfCacheEntry
+
+	// order holds the keys in entries from least to most recently used, for
+	// LRU eviction. This keeps frequently executed binaries cached while
+	// one-off execs (e.g. of the many small tools run by a build) cycle
+	// through the rest of the cache. Protected by mu.
+	order []elfCacheKey
+}
+
+// elfCacheKeyFor returns the elfCacheKey for fd. ok is false if fd cannot be
+// cached.
+func elfCacheKeyFor(ctx context.Context, fd *vfs.FileDescription) (elfCacheKey, bool) {
+	const mask = linux.STATX_INO | linux.STATX_SIZE | linux.STATX_MTIME | linux.STATX_CTIME
+	stat, err := fd.Stat(ctx, vfs.StatOptions{Mask: mask})
+	if err != nil || stat.Mask&mask != mask {
+		return elfCacheKey{}, false
+	}
+	return elfCacheKey{
+		devMajor: stat.DevMajor,
+		devMinor: stat.DevMinor,
+		ino:      stat.Ino,
+		size:     stat.Size,
+		mtime:    stat.Mtime,
+		ctime:    stat.Ctime,
+	}, true
+}
+
+// lookupELF returns the cached headers for fd, if any.
+//
+// If ok is false, the caller should parse fd and call storeELF with key and
+// the result if keyOK is true.
+func lookupELF(ctx context.Context, fd *vfs.FileDescription) (info elfInfo, layout elfLayout, key elfCacheKey, keyOK, ok bool) {
+	key, keyOK = elfCacheKeyFor(ctx, fd)
+	if !keyOK {
+		return elfInfo{}, elfLayout{}, key, false, false
+	}
+	e := getELF(key)
+	if e == nil {
+		return elfInfo{}, elfLayout{}, key, true, false
+	}
+	return e.info, e.layout, key, true, true
+}
+
+// getELF returns the cache entry for key, or nil if there is none, and marks
+// it as most recently used.
+func getELF(key elfCacheKey) *elfCacheEntry {
+	elfCache.mu.Lock()
+	defer elfCache.mu.Unlock()
+	e := elfCache.entries[key]
+	if e == nil {
+		return nil
+	}
+	for i, k := range elfCache.order {
+		if k == key {
+			copy(elfCache.order[i:], elfCache.order[i+1:])
+			elfCache.order[len(elfCache.order)-1] = key
+			break
+		}
+	}
+	return e
+}
+
+// storeELF inserts successfully parsed headers into the cache.
+func storeELF(key elfCacheKey, info elfInfo, layout elfLayout) {
+	elfCache.mu.Lock()
+	defer elfCache.mu.Unlock()
+	if _, ok := elfCache.entries[key]; ok {
+		return
+	}
+	if elfCache.entries == nil {
+		elfCache.entries = make(map[elfCacheKey]*elfCacheEntry)
+	}
+	if len(elfCache.order) >= elfCacheSize {
+		delete(elfCache.entries, elfCache.order[0])
+		elfCache.order = append(elfCache.order[:0], elfCache.order[1:]...)
+	}
+	elfCache.entries[key] = &elfCacheEntry{
+		info:   info,
+		layout: layout,
+	}
+	elfCache.order = append(elfCache.order, key)
+}
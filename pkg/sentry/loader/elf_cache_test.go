@@ -0,0 +1,95 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loader
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/sentry/contexttest"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/usermem"
+)
+
+func TestELFCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	elfCache.mu.Lock()
+	elfCache.entries = nil
+	elfCache.order = nil
+	elfCache.mu.Unlock()
+
+	key := func(ino int) elfCacheKey {
+		return elfCacheKey{ino: uint64(ino)}
+	}
+	for i := 0; i < elfCacheSize; i++ {
+		storeELF(key(i), elfInfo{}, elfLayout{interpreter: "interp"})
+	}
+
+	// Using the oldest entry should protect it from eviction.
+	if e := getELF(key(0)); e == nil || e.layout.interpreter != "interp" {
+		t.Fatalf("getELF(%v) = %+v, want cached entry", key(0), e)
+	}
+	storeELF(key(elfCacheSize), elfInfo{}, elfLayout{})
+
+	if getELF(key(0)) == nil {
+		t.Errorf("recently used entry %v was evicted", key(0))
+	}
+	if getELF(key(1)) != nil {
+		t.Errorf("least recently used entry %v was not evicted", key(1))
+	}
+	if getELF(key(elfCacheSize)) == nil {
+		t.Errorf("new entry %v is missing", key(elfCacheSize))
+	}
+}
+
+func TestELFCacheLookup(t *testing.T) {
+	elfCache.mu.Lock()
+	elfCache.entries = nil
+	elfCache.order = nil
+	elfCache.mu.Unlock()
+
+	ctx := contexttest.Context(t)
+	fd, cleanup := newSegmentFile(t, ctx, 0)
+	defer cleanup()
+	contents := usermem.BytesIOSequence([]byte("\x7fELF"))
+	if _, err := fd.Write(ctx, contents, vfs.WriteOptions{}); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	_, _, key, keyOK, ok := lookupELF(ctx, fd)
+	if !keyOK {
+		t.Fatalf("lookupELF got keyOK false, want true")
+	}
+	if ok {
+		t.Fatalf("lookupELF hit in an empty cache")
+	}
+	wantInfo := elfInfo{entry: 0x401000}
+	wantLayout := elfLayout{start: 0x400000, end: 0x402000, interpreter: "/lib/ld.so"}
+	storeELF(key, wantInfo, wantLayout)
+
+	info, layout, _, _, ok := lookupELF(ctx, fd)
+	if !ok {
+		t.Fatalf("lookupELF missed after storeELF")
+	}
+	if info.entry != wantInfo.entry || layout.start != wantLayout.start || layout.end != wantLayout.end || layout.interpreter != wantLayout.interpreter {
+		t.Errorf("lookupELF got entry %#x, layout %+v, want entry %#x, layout %+v", info.entry, layout, wantInfo.entry, wantLayout)
+	}
+
+	// Modifying the file must invalidate the cached headers.
+	if _, err := fd.Write(ctx, contents, vfs.WriteOptions{}); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, _, _, _, ok := lookupELF(ctx, fd); ok {
+		t.Errorf("lookupELF hit after the file was modified")
+	}
+}
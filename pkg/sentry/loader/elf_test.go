@@ -0,0 +1,442 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loader
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/fspath"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/sentry/arch"
+	"gvisor.dev/gvisor/pkg/sentry/contexttest"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/tmpfs"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/limits"
+	"gvisor.dev/gvisor/pkg/sentry/mm"
+	"gvisor.dev/gvisor/pkg/sentry/pgalloc"
+	"gvisor.dev/gvisor/pkg/sentry/platform"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/usermem"
+)
+
+func loadSegment(vaddr, memsz uint64) *elf.ProgHeader {
+	return &elf.ProgHeader{
+		Type:   elf.PT_LOAD,
+		Vaddr:  vaddr,
+		Filesz: memsz,
+		Memsz:  memsz,
+	}
+}
+
+func TestParallelizableSegments(t *testing.T) {
+	const size = minParallelSegmentBytes
+	for _, test := range []struct {
+		name  string
+		phdrs []*elf.ProgHeader
+		want  bool
+	}{
+		{
+			name:  "single",
+			phdrs: []*elf.ProgHeader{loadSegment(0, 2*size)},
+			want:  false,
+		},
+		{
+			name: "small",
+			phdrs: []*elf.ProgHeader{
+				loadSegment(0, hostarch.PageSize),
+				loadSegment(hostarch.PageSize, hostarch.PageSize),
+			},
+			want: false,
+		},
+		{
+			name: "disjoint",
+			phdrs: []*elf.ProgHeader{
+				loadSegment(0, size),
+				loadSegment(size, size),
+			},
+			want: true,
+		},
+		{
+			name: "disjoint unordered",
+			phdrs: []*elf.ProgHeader{
+				loadSegment(2*size, size),
+				loadSegment(0, size),
+			},
+			want: true,
+		},
+		{
+			name: "shared page",
+			phdrs: []*elf.ProgHeader{
+				loadSegment(0, size+1),
+				loadSegment(size+hostarch.PageSize/2, size),
+			},
+			want: false,
+		},
+		{
+			name: "overflow",
+			phdrs: []*elf.ProgHeader{
+				loadSegment(0, size),
+				loadSegment(^uint64(0)-hostarch.PageSize, size),
+			},
+			want: false,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parallelizableSegments(test.phdrs, 0x400000); got != test.want {
+				t.Errorf("parallelizableSegments got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPrefaultedSegments(t *testing.T) {
+	text := loadSegment(0, hostarch.HugePageSize)
+	text.Flags = elf.PF_R | elf.PF_X
+	smallText := loadSegment(hostarch.HugePageSize, hostarch.PageSize)
+	smallText.Flags = elf.PF_R | elf.PF_X
+	data := loadSegment(2*hostarch.HugePageSize, hostarch.HugePageSize)
+	data.Flags = elf.PF_R | elf.PF_W
+	bss := loadSegment(3*hostarch.HugePageSize, hostarch.PageSize)
+	bss.Flags = elf.PF_R | elf.PF_X
+	bss.Filesz = 0
+	phdrs := []*elf.ProgHeader{text, smallText, data, bss}
+	for _, test := range []struct {
+		name string
+		opts TextSegmentOpts
+		want int
+	}{
+		{
+			name: "default",
+			want: 0,
+		},
+		{
+			name: "prefault",
+			opts: TextSegmentOpts{Prefault: true},
+			want: 2,
+		},
+		{
+			name: "huge",
+			opts: TextSegmentOpts{Huge: true},
+			want: 1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := prefaultedSegments(phdrs, test.opts); got != test.want {
+				t.Errorf("prefaultedSegments got %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+// elfWithMachine returns an ELF executable for machine with a single PT_LOAD
+// segment.
+func elfWithMachine(machine elf.Machine) []byte {
+	hdr := linux.ElfHeader64{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(machine),
+		Version:   uint32(elf.EV_CURRENT),
+		Phoff:     uint64(header64Size),
+		Ehsize:    uint16(header64Size),
+		Phentsize: uint16(prog64Size),
+		Phnum:     1,
+	}
+	copy(hdr.Ident[:], elfMagic)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	prog := linux.ElfProg64{
+		Type:   uint32(elf.PT_LOAD),
+		Vaddr:  0x400000,
+		Filesz: hostarch.PageSize,
+		Memsz:  hostarch.PageSize,
+		Align:  hostarch.PageSize,
+	}
+	buf := make([]byte, header64Size+prog64Size)
+	hdr.MarshalUnsafe(buf)
+	prog.MarshalUnsafe(buf[header64Size:])
+	return buf
+}
+
+func TestParseHeaderMachine(t *testing.T) {
+	hostMachine := map[arch.Arch]elf.Machine{
+		arch.AMD64:   elf.EM_X86_64,
+		arch.ARM64:   elf.EM_AARCH64,
+		arch.RISCV64: elf.EM_RISCV,
+	}[arch.Host]
+	ctx := contexttest.Context(t)
+	for _, machine := range []elf.Machine{elf.EM_X86_64, elf.EM_AARCH64, elf.EM_RISCV, elf.EM_PPC64} {
+		t.Run(machine.String(), func(t *testing.T) {
+			info, err := parseHeader(ctx, &byteFullReader{elfWithMachine(machine)})
+			if machine != hostMachine {
+				if !linuxerr.Equals(linuxerr.ENOEXEC, err) {
+					t.Errorf("parseHeader got err %v, want %v", err, linuxerr.ENOEXEC)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHeader failed: %v", err)
+			}
+			if info.arch != arch.Host {
+				t.Errorf("parseHeader got arch %v, want %v", info.arch, arch.Host)
+			}
+		})
+	}
+}
+
+func TestParseHeader32Bit(t *testing.T) {
+	ctx := contexttest.Context(t)
+	for _, test := range []struct {
+		machine elf.Machine
+		data    elf.Data
+	}{
+		{machine: elf.EM_386, data: elf.ELFDATA2LSB},
+		{machine: elf.EM_ARM, data: elf.ELFDATA2LSB},
+		{machine: elf.EM_MIPS, data: elf.ELFDATA2MSB},
+	} {
+		t.Run(test.machine.String(), func(t *testing.T) {
+			var hdr [elf32MachineOff + 2]byte
+			copy(hdr[:], elfMagic)
+			hdr[elf.EI_CLASS] = byte(elf.ELFCLASS32)
+			hdr[elf.EI_DATA] = byte(test.data)
+			hdr[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+			if test.data == elf.ELFDATA2MSB {
+				binary.BigEndian.PutUint16(hdr[elf32MachineOff:], uint16(test.machine))
+			} else {
+				binary.LittleEndian.PutUint16(hdr[elf32MachineOff:], uint16(test.machine))
+			}
+
+			before := unsupported32BitBinaries.Value()
+			if _, err := parseHeader(ctx, &byteFullReader{hdr[:]}); !linuxerr.Equals(linuxerr.ENOEXEC, err) {
+				t.Errorf("parseHeader got err %v, want %v", err, linuxerr.ENOEXEC)
+			}
+			if got := unsupported32BitBinaries.Value() - before; got != 1 {
+				t.Errorf("parseHeader counted %d 32-bit binaries, want 1", got)
+			}
+		})
+	}
+}
+
+func TestCheckControlFlowFeatures(t *testing.T) {
+	ctx := contexttest.Context(t)
+	for _, test := range []struct {
+		name     string
+		arch     arch.Arch
+		features uint32
+		require  bool
+		wantErr  bool
+	}{
+		{
+			name:     "amd64 none required",
+			arch:     arch.AMD64,
+			features: 0,
+			require:  true,
+		},
+		{
+			name:     "amd64 IBT",
+			arch:     arch.AMD64,
+			features: linux.GNU_PROPERTY_X86_FEATURE_1_IBT,
+		},
+		{
+			name:     "amd64 IBT required",
+			arch:     arch.AMD64,
+			features: linux.GNU_PROPERTY_X86_FEATURE_1_IBT,
+			require:  true,
+			wantErr:  true,
+		},
+		{
+			name:     "amd64 SHSTK required",
+			arch:     arch.AMD64,
+			features: linux.GNU_PROPERTY_X86_FEATURE_1_SHSTK,
+			require:  true,
+			wantErr:  true,
+		},
+		{
+			name:     "arm64 BTI required",
+			arch:     arch.ARM64,
+			features: linux.GNU_PROPERTY_AARCH64_FEATURE_1_BTI,
+			require:  true,
+			wantErr:  true,
+		},
+		{
+			name:     "arm64 PAC required",
+			arch:     arch.ARM64,
+			features: linux.GNU_PROPERTY_AARCH64_FEATURE_1_PAC,
+			require:  true,
+			wantErr:  true,
+		},
+		{
+			name:     "arm64 BTI and PAC",
+			arch:     arch.ARM64,
+			features: linux.GNU_PROPERTY_AARCH64_FEATURE_1_BTI | linux.GNU_PROPERTY_AARCH64_FEATURE_1_PAC,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkControlFlowFeatures(ctx, test.arch, test.features, test.require)
+			if test.wantErr {
+				if !linuxerr.Equals(linuxerr.ENOEXEC, err) {
+					t.Errorf("checkControlFlowFeatures got err %v, want %v", err, linuxerr.ENOEXEC)
+				}
+			} else if err != nil {
+				t.Errorf("checkControlFlowFeatures failed: %v", err)
+			}
+		})
+	}
+}
+
+// newSegmentFile returns a tmpfs file of the given size, holding byte(off) at
+// each offset off.
+func newSegmentFile(b testing.TB, ctx context.Context, size int) (*vfs.FileDescription, func()) {
+	creds := auth.CredentialsFromContext(ctx)
+	vfsObj := &vfs.VirtualFilesystem{}
+	if err := vfsObj.Init(ctx); err != nil {
+		b.Fatalf("VFS init: %v", err)
+	}
+	vfsObj.MustRegisterFilesystemType("tmpfs", tmpfs.FilesystemType{}, &vfs.RegisterFilesystemTypeOptions{
+		AllowUserMount: true,
+	})
+	mntns, err := vfsObj.NewMountNamespace(ctx, creds, "", "tmpfs", &vfs.MountOptions{}, nil)
+	if err != nil {
+		b.Fatalf("failed to create tmpfs root mount: %v", err)
+	}
+	root := mntns.Root(ctx)
+	defer root.DecRef(ctx)
+	fd, err := vfsObj.OpenAt(ctx, creds, &vfs.PathOperation{
+		Root:  root,
+		Start: root,
+		Path:  fspath.Parse("binary"),
+	}, &vfs.OpenOptions{
+		Flags: linux.O_RDWR | linux.O_CREAT | linux.O_EXCL,
+		Mode:  linux.ModeRegular | 0755,
+	})
+	if err != nil {
+		mntns.DecRef(ctx)
+		b.Fatalf("failed to create file: %v", err)
+	}
+	buf := make([]byte, hostarch.HugePageSize)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+	for written := 0; written < size; written += len(buf) {
+		if _, err := fd.Write(ctx, usermem.BytesIOSequence(buf), vfs.WriteOptions{}); err != nil {
+			b.Fatalf("failed to write file: %v", err)
+		}
+	}
+	return fd, func() {
+		fd.DecRef(ctx)
+		mntns.DecRef(ctx)
+	}
+}
+
+func TestMapSegmentsParallel(t *testing.T) {
+	const (
+		numSegments = 3
+		segmentSize = minParallelSegmentBytes / 2
+		loadAddr    = 0x400000
+	)
+	ctx := contexttest.Context(t)
+	fd, cleanup := newSegmentFile(t, ctx, numSegments*segmentSize)
+	defer cleanup()
+
+	// Each segment has a partial last page and anonymous pages, which are
+	// mapped after the file-backed pages of later segments.
+	phdrs := make([]elf.ProgHeader, numSegments)
+	for i := range phdrs {
+		phdrs[i] = elf.ProgHeader{
+			Type:   elf.PT_LOAD,
+			Flags:  elf.PF_R | elf.PF_X,
+			Off:    uint64(i * segmentSize),
+			Vaddr:  uint64(2 * i * segmentSize),
+			Filesz: segmentSize - 100,
+			Memsz:  segmentSize + 2*hostarch.PageSize,
+		}
+	}
+	m := mm.NewMemoryManager(platform.FromContext(ctx), pgalloc.MemoryFileFromContext(ctx), false)
+	defer m.DecUsers(ctx)
+	if _, err := m.SetMmapLayout(arch.New(arch.Host), limits.FromContext(ctx), true /* randomize */); err != nil {
+		t.Fatalf("SetMmapLayout failed: %v", err)
+	}
+	opts := segmentOpts{
+		text:         TextSegmentOpts{Prefault: true},
+		asyncContext: func() context.Context { return ctx },
+	}
+	if err := mapSegments(ctx, m, fd, phdrs, loadAddr, opts); err != nil {
+		t.Fatalf("mapSegments failed: %v", err)
+	}
+
+	for i, phdr := range phdrs {
+		got := make([]byte, phdr.Memsz)
+		if _, err := m.CopyIn(ctx, hostarch.Addr(loadAddr+phdr.Vaddr), got, usermem.IOOpts{IgnorePermissions: true}); err != nil {
+			t.Fatalf("segment %d: CopyIn failed: %v", i, err)
+		}
+		for j, b := range got {
+			want := byte(0)
+			if uint64(j) < phdr.Filesz {
+				want = byte(phdr.Off + uint64(j))
+			}
+			if b != want {
+				t.Fatalf("segment %d: byte %#x is %#x, want %#x", i, j, b, want)
+			}
+		}
+	}
+}
+
+func BenchmarkMapSegments(b *testing.B) {
+	const (
+		numSegments = 8
+		segmentSize = 8 << 20
+		loadAddr    = 0x400000
+	)
+	ctx := contexttest.Context(b)
+	fd, cleanup := newSegmentFile(b, ctx, numSegments*segmentSize)
+	defer cleanup()
+
+	phdrs := make([]elf.ProgHeader, numSegments)
+	for i := range phdrs {
+		phdrs[i] = *loadSegment(uint64(i*segmentSize), segmentSize)
+		phdrs[i].Off = uint64(i * segmentSize)
+		phdrs[i].Flags = elf.PF_R | elf.PF_X
+	}
+
+	// prefault=false is the default configuration, in which segments are
+	// always mapped serially.
+	for _, prefault := range []bool{false, true} {
+		for _, parallel := range []bool{false, true} {
+			b.Run(fmt.Sprintf("prefault=%t/parallel=%t", prefault, parallel), func(b *testing.B) {
+				opts := segmentOpts{
+					text: TextSegmentOpts{Prefault: prefault},
+				}
+				if parallel {
+					opts.asyncContext = func() context.Context { return ctx }
+				}
+				for i := 0; i < b.N; i++ {
+					m := mm.NewMemoryManager(platform.FromContext(ctx), pgalloc.MemoryFileFromContext(ctx), false)
+					if _, err := m.SetMmapLayout(arch.New(arch.Host), limits.FromContext(ctx), true /* randomize */); err != nil {
+						b.Fatalf("SetMmapLayout failed: %v", err)
+					}
+					if err := mapSegments(ctx, m, fd, phdrs, loadAddr, opts); err != nil {
+						b.Fatalf("mapSegments failed: %v", err)
+					}
+					m.DecUsers(ctx)
+				}
+			})
+		}
+	}
+}
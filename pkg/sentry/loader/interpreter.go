@@ -28,18 +28,58 @@ const (
 	// interpreterScriptMagic identifies an interpreter script.
 	interpreterScriptMagic = "#!"
 
-	// interpMaxLineLength is the maximum length for the first line of an
-	// interpreter script.
-	//
-	// From execve(2): "A maximum line length of 127 characters is allowed
-	// for the first line in a #! executable shell script."
-	interpMaxLineLength = 127
+	// interpBufSize is the number of bytes of an interpreter script that are
+	// examined to find the interpreter and its argument. It is Linux's
+	// BINPRM_BUF_SIZE.
+	interpBufSize = 256
 )
 
+// isSpaceTab returns true if c delimits the interpreter and its argument.
+func isSpaceTab(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// nextNonSpaceTab returns the index of the first byte in buf[first:last+1]
+// that is not a space or tab, or -1 if there is none.
+func nextNonSpaceTab(buf []byte, first, last int) int {
+	for ; first <= last; first++ {
+		if !isSpaceTab(buf[first]) {
+			return first
+		}
+	}
+	return -1
+}
+
+// nextTerminator returns the index of the first space, tab or NUL in
+// buf[first:last+1], or -1 if there is none.
+func nextTerminator(buf []byte, first, last int) int {
+	for ; first <= last; first++ {
+		if isSpaceTab(buf[first]) || buf[first] == 0 {
+			return first
+		}
+	}
+	return -1
+}
+
+// cString returns the bytes of b preceding the first NUL.
+func cString(b []byte) []byte {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return b[:i]
+	}
+	return b
+}
+
 // parseInterpreterScript returns the interpreter path and argv.
+//
+// It follows Linux's fs/binfmt_script.c:load_script() as of Linux 5.1: only
+// the first interpBufSize bytes of the script are considered. If they contain
+// no newline, the interpreter path must still be terminated by a space, tab
+// or NUL, since Linux refuses to execute a possibly truncated interpreter
+// path; the argument, however, is silently truncated.
 func parseInterpreterScript(ctx context.Context, filename string, fd *vfs.FileDescription, argv []string) (newpath string, newargv []string, err error) {
-	line := make([]byte, interpMaxLineLength)
-	n, err := fd.ReadFull(ctx, usermem.BytesIOSequence(line), 0)
+	// As in Linux, bytes beyond the end of a short file read as NUL.
+	buf := make([]byte, interpBufSize)
+	_, err = fd.ReadFull(ctx, usermem.BytesIOSequence(buf), 0)
 	// Short read is OK.
 	if err != nil && err != io.ErrUnexpectedEOF {
 		if err == io.EOF {
@@ -47,24 +87,39 @@ func parseInterpreterScript(ctx context.Context, filename string, fd *vfs.FileDe
 		}
 		return "", []string{}, err
 	}
-	line = line[:n]
 
-	if !bytes.Equal(line[:2], []byte(interpreterScriptMagic)) {
+	if !bytes.Equal(buf[:2], []byte(interpreterScriptMagic)) {
 		return "", []string{}, linuxerr.ENOEXEC
 	}
-	// Ignore #!.
-	line = line[2:]
-
-	// Ignore everything after newline.
-	// Linux silently truncates the remainder of the line if it exceeds
-	// interpMaxLineLength.
-	i := bytes.IndexByte(line, '\n')
-	if i >= 0 {
-		line = line[:i]
+
+	// Find the end of the line. The byte at end is excluded.
+	bufEnd := len(buf) - 1
+	end := bytes.IndexByte(buf, '\n')
+	if end < 0 {
+		end = nextNonSpaceTab(buf, 2, bufEnd)
+		if end < 0 {
+			ctx.Infof("Interpreter script contains only whitespace")
+			return "", []string{}, linuxerr.ENOEXEC
+		}
+		// If there is no later space, tab or NUL, the interpreter path
+		// may be truncated.
+		if nextTerminator(buf, end, bufEnd) < 0 {
+			ctx.Infof("Interpreter script's interpreter path is too long")
+			return "", []string{}, linuxerr.ENOEXEC
+		}
+		end = bufEnd
+	}
+	// Trim trailing spaces and tabs.
+	for isSpaceTab(buf[end-1]) {
+		end--
 	}
 
-	// Skip any whitespace before the interpreter.
-	line = bytes.TrimLeft(line, " \t")
+	// Skip leading spaces and tabs.
+	name := nextNonSpaceTab(buf, 2, end)
+	if name < 0 || name == end {
+		ctx.Infof("Interpreter script contains no interpreter: %q", buf[:end])
+		return "", []string{}, linuxerr.ENOEXEC
+	}
 
 	// Linux only looks for spaces or tabs delimiting the interpreter and
 	// arg.
@@ -72,17 +127,15 @@ func parseInterpreterScript(ctx context.Context, filename string, fd *vfs.FileDe
 	// execve(2): "On Linux, the entire string following the interpreter
 	// name is passed as a single argument to the interpreter, and this
 	// string can include white space."
-	interp := line
+	interp := cString(buf[name:end])
 	var arg []byte
-	i = bytes.IndexAny(line, " \t")
-	if i >= 0 {
-		interp = line[:i]
-		arg = bytes.TrimLeft(line[i:], " \t")
-	}
-
-	if string(interp) == "" {
-		ctx.Infof("Interpreter script contains no interpreter: %v", line)
-		return "", []string{}, linuxerr.ENOEXEC
+	hasArg := false
+	if sep := nextTerminator(buf, name, end); sep >= 0 && buf[sep] != 0 {
+		interp = buf[name:sep]
+		if i := nextNonSpaceTab(buf, sep, end); i >= 0 {
+			arg = cString(buf[i:end])
+			hasArg = true
+		}
 	}
 
 	// Build the new argument list:
@@ -91,7 +144,7 @@ func parseInterpreterScript(ctx context.Context, filename string, fd *vfs.FileDe
 	newargv = append(newargv, string(interp))
 
 	// 2. The optional interpreter argument.
-	if len(arg) > 0 {
+	if hasArg {
 		newargv = append(newargv, string(arg))
 	}
 
@@ -61,6 +61,15 @@ type LoadArgs struct {
 	// The caller is responsible for checking that the user can execute this file.
 	File *vfs.FileDescription
 
+	// NameFromFile indicates that the task name should be taken from the
+	// name of File rather than from Filename. This is the case for
+	// execveat(fd, "", AT_EMPTY_PATH), where Filename is "/dev/fd/<fd>".
+	NameFromFile bool
+
+	// FileUnreadable indicates that File was opened by OpenExecutable for a
+	// caller who may execute, but not read, it.
+	FileUnreadable bool
+
 	// Root is the current filesystem root.
 	Root vfs.VirtualDentry
 
@@ -86,29 +95,95 @@ type LoadArgs struct {
 
 	// Features specifies the CPU feature set for the executable.
 	Features cpuid.FeatureSet
+
+	// TextSegments controls how executable PT_LOAD segments are mapped.
+	TextSegments TextSegmentOpts
+
+	// THPDisabled is true if transparent hugepages are disabled for the new
+	// address space, as prctl(PR_SET_THP_DISABLE) is preserved across execve.
+	// TextSegments.Huge is then ignored.
+	THPDisabled bool
+
+	// RandomizeVASpace controls address space layout randomization, as the
+	// kernel.randomize_va_space sysctl does in Linux. See RandomizeNone,
+	// RandomizeConservative and RandomizeFull.
+	RandomizeVASpace int32
+
+	// AddrNoRandomize is true if the loading task's personality has
+	// ADDR_NO_RANDOMIZE set, which disables address space layout
+	// randomization for executables that don't grant privileges.
+	AddrNoRandomize bool
+
+	// If AsyncContext is not nil, it returns a context that may be used by
+	// goroutines other than the caller's on its behalf. Load then reads the
+	// prefaulted PT_LOAD segments of large binaries concurrently where
+	// possible.
+	AsyncContext func() context.Context
+
+	// VVAR, if not nil, is mapped as the VDSO parameter page instead of the
+	// VDSO's own ParamPage. It is used for tasks in non-root time
+	// namespaces.
+	VVAR *mm.SpecialMappable
+}
+
+// Values of LoadArgs.RandomizeVASpace.
+const (
+	// RandomizeNone disables address space layout randomization.
+	RandomizeNone = 0
+
+	// RandomizeConservative randomizes the locations of the mmap base, the
+	// stack, the VDSO and position-independent executables.
+	RandomizeConservative = 1
+
+	// RandomizeFull additionally randomizes the start of the heap.
+	RandomizeFull = 2
+)
+
+// SetID holds the identities that an executable's set-user-ID and
+// set-group-ID mode bits grant. UID and GID are auth.NoID if the
+// corresponding bit is not set or is ignored.
+//
+// +stateify savable
+type SetID struct {
+	UID auth.KUID
+	GID auth.KGID
+}
+
+// TextSegmentOpts controls how executable PT_LOAD segments are mapped.
+//
+// +stateify savable
+type TextSegmentOpts struct {
+	// If Huge is true, executable PT_LOAD segments spanning at least one huge
+	// page are loaded at hugepage-aligned addresses where possible, and copied
+	// at exec time into private memory that is hugepage-backed if the
+	// MemoryFile supports it. This reduces iTLB misses and page faults for
+	// large binaries, at the cost of no longer sharing their text with other
+	// processes.
+	Huge bool
+
+	// If Prefault is true, executable PT_LOAD segments are faulted in at exec
+	// time rather than on first access.
+	Prefault bool
+
+	// If RequireControlFlowProtection is true, ELFs whose GNU properties
+	// request control-flow protection that the sentry cannot enforce fail to
+	// load with ENOEXEC rather than running without it.
+	RequireControlFlowProtection bool
 }
 
 // openPath opens args.Filename and checks that it is valid for loading.
 //
-// openPath returns an *fs.Dirent and *fs.File for args.Filename, which is not
-// installed in the Task FDTable. The caller takes ownership of both.
+// openPath returns a *vfs.FileDescription for args.Filename, which is not
+// installed in the Task FDTable. The caller takes ownership of it. unreadable
+// is true if the caller may not read the file; see OpenExecutable.
 //
-// args.Filename must be a readable, executable, regular file.
-func openPath(ctx context.Context, args LoadArgs) (*vfs.FileDescription, error) {
+// args.Filename must be an executable, regular file.
+func openPath(ctx context.Context, args LoadArgs) (fd *vfs.FileDescription, unreadable bool, err error) {
 	if args.Filename == "" {
 		ctx.Infof("cannot open empty name")
-		return nil, linuxerr.ENOENT
+		return nil, false, linuxerr.ENOENT
 	}
 
-	// TODO(gvisor.dev/issue/160): Linux requires only execute permission,
-	// not read. However, our backing filesystems may prevent us from reading
-	// the file without read permission. Additionally, a task with a
-	// non-readable executable has additional constraints on access via
-	// ptrace and procfs.
-	opts := vfs.OpenOptions{
-		Flags:    linux.O_RDONLY,
-		FileExec: true,
-	}
 	vfsObj := args.Root.Mount().Filesystem().VirtualFilesystem()
 	creds := auth.CredentialsFromContext(ctx)
 	path := fspath.Parse(args.Filename)
@@ -121,14 +196,114 @@ func openPath(ctx context.Context, args LoadArgs) (*vfs.FileDescription, error)
 	if path.Absolute {
 		pop.Start = args.Root
 	}
-	fd, err := vfsObj.OpenAt(ctx, creds, pop, &opts)
+	fd, unreadable, err = OpenExecutable(ctx, vfsObj, creds, pop)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if args.AfterOpen != nil {
 		args.AfterOpen(fd)
 	}
-	return fd, nil
+	return fd, unreadable, nil
+}
+
+// OpenExecutable opens the file at pop so that creds can execute it.
+//
+// Linux requires only execute permission to execute a file, not read
+// permission (fs/exec.c:do_open_execat()). If creds may execute but not read
+// the file, OpenExecutable opens it for reading with CAP_DAC_OVERRIDE on
+// behalf of creds and returns unreadable == true. The contents of such files
+// must not be exposed to the application, e.g. via ptrace or procfs.
+func OpenExecutable(ctx context.Context, vfsObj *vfs.VirtualFilesystem, creds *auth.Credentials, pop *vfs.PathOperation) (fd *vfs.FileDescription, unreadable bool, err error) {
+	opts := vfs.OpenOptions{
+		Flags:    linux.O_RDONLY,
+		FileExec: true,
+	}
+	fd, err = vfsObj.OpenAt(ctx, creds, pop, &opts)
+	if !linuxerr.Equals(linuxerr.EACCES, err) {
+		return fd, false, err
+	}
+
+	// Pin the file with an O_PATH FD, so that the permission check and the
+	// privileged open below refer to the same file.
+	pathFD, err := vfsObj.OpenAt(ctx, creds, pop, &vfs.OpenOptions{Flags: linux.O_PATH})
+	if err != nil {
+		return nil, false, err
+	}
+	defer pathFD.DecRef(ctx)
+	vd := pathFD.VirtualDentry()
+	filePop := &vfs.PathOperation{
+		Root:  vd,
+		Start: vd,
+	}
+	if err := vfsObj.AccessAt(ctx, creds, vfs.MayExec, filePop); err != nil {
+		return nil, false, err
+	}
+	privCreds := creds.Fork()
+	privCreds.EffectiveCaps |= auth.CapabilitySetOf(linux.CAP_DAC_OVERRIDE)
+	fd, err = vfsObj.OpenAt(ctx, privCreds, filePop, &opts)
+	if err != nil {
+		return nil, false, err
+	}
+	return fd, true, nil
+}
+
+// fileSetID returns the identities that fd's set-user-ID and set-group-ID
+// mode bits grant to the caller. It is analogous to Linux's
+// fs/exec.c:bprm_fill_uid(), except that the caller is responsible for
+// ignoring the result if the task has no_new_privs set.
+func fileSetID(ctx context.Context, fd *vfs.FileDescription) (SetID, error) {
+	setID := SetID{UID: auth.NoID, GID: auth.NoID}
+	if fd.Mount().Options().Flags.NoSUID {
+		return setID, nil
+	}
+	stat, err := fd.Stat(ctx, vfs.StatOptions{Mask: linux.STATX_MODE | linux.STATX_UID | linux.STATX_GID})
+	if err != nil {
+		return setID, err
+	}
+	mode := linux.FileMode(stat.Mode)
+	if mode&(linux.ModeSetUID|linux.ModeSetGID) == 0 {
+		return setID, nil
+	}
+	// "Be careful if suid/sgid is set": ignore both bits unless the file's
+	// owner and group are mapped in the caller's user namespace.
+	creds := auth.CredentialsFromContext(ctx)
+	uid, gid := auth.KUID(stat.UID), auth.KGID(stat.GID)
+	if !creds.UserNamespace.MapFromKUID(uid).Ok() || !creds.UserNamespace.MapFromKGID(gid).Ok() {
+		return setID, nil
+	}
+	if mode&linux.ModeSetUID != 0 {
+		setID.UID = uid
+	}
+	// A set-group-ID file without group execute permission is a candidate for
+	// mandatory locking, not a set-group-ID executable.
+	if mode&(linux.ModeSetGID|linux.ModeGroupExec) == linux.ModeSetGID|linux.ModeGroupExec {
+		setID.GID = gid
+	}
+	return setID, nil
+}
+
+// mayGrantPrivileges returns true if executing fd may grant privileges
+// through its set-user-ID or set-group-ID mode bits or its file capabilities.
+func mayGrantPrivileges(ctx context.Context, fd *vfs.FileDescription) (bool, error) {
+	setID, err := fileSetID(ctx, fd)
+	if err != nil {
+		return false, err
+	}
+	if setID.UID != auth.NoID || setID.GID != auth.NoID {
+		return true, nil
+	}
+	if fd.Mount().Options().Flags.NoSUID {
+		return false, nil
+	}
+	_, err = fd.GetXattr(ctx, &vfs.GetXattrOptions{Name: linux.XATTR_SECURITY_CAPABILITY, Size: linux.XATTR_CAPS_SZ_3})
+	switch {
+	case err == nil:
+		return true, nil
+	case linuxerr.Equals(linuxerr.ENODATA, err), linuxerr.Equals(linuxerr.EOPNOTSUPP, err):
+		return false, nil
+	default:
+		return false, err
+	}
 }
 
 // checkIsRegularFile prevents us from trying to execute a directory, pipe, etc.
@@ -173,10 +348,17 @@ const (
 //   - fs.Dirent of the binary file
 //   - Possibly updated args.Argv
 func loadExecutable(ctx context.Context, args LoadArgs) (loadedELF, *arch.Context64, *vfs.FileDescription, []string, error) {
+	// unreadable is true if any file opened so far, including interpreter
+	// scripts, is unreadable by the caller.
+	unreadable := args.FileUnreadable
 	for i := 0; i < maxLoaderAttempts; i++ {
 		if args.File == nil {
-			var err error
-			args.File, err = openPath(ctx, args)
+			var (
+				err            error
+				fileUnreadable bool
+			)
+			args.File, fileUnreadable, err = openPath(ctx, args)
+			unreadable = unreadable || fileUnreadable
 			if err != nil {
 				ctx.Infof("Error opening %s: %v", args.Filename, err)
 				return loadedELF{}, nil, nil, nil, err
@@ -204,11 +386,25 @@ func loadExecutable(ctx context.Context, args LoadArgs) (loadedELF, *arch.Contex
 
 		switch {
 		case bytes.Equal(hdr[:], []byte(elfMagic)):
+			if args.AddrNoRandomize {
+				// execve() clears ADDR_NO_RANDOMIZE if it grants
+				// privileges, so such executables are still
+				// randomized. Compare Linux's bprm->per_clear.
+				privileged, err := mayGrantPrivileges(ctx, args.File)
+				if err != nil {
+					return loadedELF{}, nil, nil, nil, err
+				}
+				if !privileged {
+					args.RandomizeVASpace = RandomizeNone
+				}
+			}
 			loaded, ac, err := loadELF(ctx, args)
 			if err != nil {
 				ctx.Infof("Error loading ELF: %v", err)
 				return loadedELF{}, nil, nil, nil, err
 			}
+			loaded.unreadable = loaded.unreadable || unreadable
+			loaded.randomizeVASpace = args.RandomizeVASpace
 			// An ELF is always terminal. Hold on to file.
 			args.File.IncRef()
 			return loaded, ac, args.File, args.Argv, err
@@ -246,6 +442,16 @@ type ImageInfo struct {
 	Name string
 	// The binary's file capability.
 	FileCaps string
+	// Unreadable is true if the binary, its ELF interpreter, or an
+	// interpreter script used to execute it can not be read by the caller.
+	Unreadable bool
+	// The binary's NT_GNU_BUILD_ID note, or nil if it has none.
+	BuildID []byte
+	// The identities granted by the binary's set-user-ID and set-group-ID
+	// mode bits.
+	SetID SetID
+	// The address of the auxiliary vector on the stack.
+	AuxvStart hostarch.Addr
 }
 
 // Load loads args.File into a MemoryManager. If args.File is nil, the path
@@ -254,6 +460,8 @@ type ImageInfo struct {
 // If Load returns ErrSwitchFile it should be called again with the returned
 // path and argv.
 //
+// If vdso is nil, no VDSO is mapped into the new image.
+//
 // Preconditions:
 //   - The Task MemoryManager is empty.
 //   - Load is called on the Task goroutine.
@@ -266,6 +474,10 @@ func Load(ctx context.Context, args LoadArgs, extraAuxv []arch.AuxEntry, vdso *V
 	defer file.DecRef(ctx)
 	fileCaps, err := file.GetXattr(ctx, &vfs.GetXattrOptions{Name: linux.XATTR_SECURITY_CAPABILITY, Size: linux.XATTR_CAPS_SZ_3})
 	switch {
+	case file.Mount().Options().Flags.NoSUID:
+		// File capabilities are ignored on nosuid mounts. See
+		// security/commoncap.c:get_file_caps().
+		fileCaps = ""
 	case linuxerr.Equals(linuxerr.ENODATA, err), linuxerr.Equals(linuxerr.EOPNOTSUPP, err):
 		// Linux converts EOPNOTSUPP to ENODATA in
 		// security/commoncap.c:get_vfs_caps_from_disk(). We communicate the lack
@@ -274,20 +486,34 @@ func Load(ctx context.Context, args LoadArgs, extraAuxv []arch.AuxEntry, vdso *V
 	case err != nil:
 		return ImageInfo{}, syserr.NewDynamic(fmt.Sprintf("failed to read file capabilities of %s: %v", args.Filename, err), syserr.FromError(err).ToLinux())
 	}
-
-	// Load the VDSO.
-	vdsoAddr, err := loadVDSO(ctx, args.MemoryManager, vdso, loaded)
+	// As in Linux, these are taken from the binary that is finally loaded:
+	// the set-ID bits and capabilities of interpreter scripts are ignored,
+	// while those of the script interpreter are honored. Those of the ELF
+	// interpreter (dynamic linker) are always ignored.
+	setID, err := fileSetID(ctx, file)
 	if err != nil {
-		return ImageInfo{}, syserr.NewDynamic(fmt.Sprintf("error loading VDSO: %v", err), syserr.FromError(err).ToLinux())
+		return ImageInfo{}, syserr.NewDynamic(fmt.Sprintf("failed to stat %s: %v", args.Filename, err), syserr.FromError(err).ToLinux())
+	}
+	// Load the VDSO, if any.
+	var vdsoAddr hostarch.Addr
+	if vdso != nil {
+		vdsoAddr, err = loadVDSO(ctx, args.MemoryManager, vdso, args.VVAR, loaded)
+		if err != nil {
+			return ImageInfo{}, syserr.NewDynamic(fmt.Sprintf("error loading VDSO: %v", err), syserr.FromError(err).ToLinux())
+		}
 	}
 
 	// Setup the heap. brk starts at the next page after the end of the
 	// executable. Userspace can assume that the remainder of the page after
-	// loaded.end is available for its use.
+	// loaded.end is available for its use. As in Linux, full randomization
+	// moves it further up by a random number of pages.
 	e, ok := loaded.end.RoundUp()
 	if !ok {
 		return ImageInfo{}, syserr.NewDynamic(fmt.Sprintf("brk overflows: %#x", loaded.end), errno.ENOEXEC)
 	}
+	if loaded.randomizeVASpace >= RandomizeFull {
+		e = ac.RandomizeBrk(e)
+	}
 	args.MemoryManager.BrkSetup(ctx, e)
 
 	// Allocate our stack.
@@ -320,17 +546,24 @@ func Load(ctx context.Context, args LoadArgs, extraAuxv []arch.AuxEntry, vdso *V
 		arch.AuxEntry{linux.AT_EUID, hostarch.Addr(c.EffectiveKUID.In(c.UserNamespace).OrOverflow())},
 		arch.AuxEntry{linux.AT_GID, hostarch.Addr(c.RealKGID.In(c.UserNamespace).OrOverflow())},
 		arch.AuxEntry{linux.AT_EGID, hostarch.Addr(c.EffectiveKGID.In(c.UserNamespace).OrOverflow())},
-		// The conditions that require AT_SECURE = 1 never arise. See
-		// kernel.Task.updateCredsForExec.
+		// The entries above describe the credentials of the caller. For
+		// execve(), SetAuxvCreds updates them once the credentials that the
+		// image runs with are committed.
 		arch.AuxEntry{linux.AT_SECURE, 0},
 		arch.AuxEntry{linux.AT_CLKTCK, linux.CLOCKS_PER_SEC},
 		arch.AuxEntry{linux.AT_EXECFN, execfn},
 		arch.AuxEntry{linux.AT_RANDOM, random},
 		arch.AuxEntry{linux.AT_PAGESZ, hostarch.PageSize},
-		arch.AuxEntry{linux.AT_SYSINFO_EHDR, vdsoAddr},
 		arch.AuxEntry{linux.AT_HWCAP, hostarch.Addr(args.Features.AllowedHWCap1())},
 		arch.AuxEntry{linux.AT_HWCAP2, hostarch.Addr(args.Features.AllowedHWCap2())},
+		arch.AuxEntry{linux.AT_MINSIGSTKSZ, hostarch.Addr(arch.MinSigStackSize(args.Features))},
+		arch.AuxEntry{linux.AT_RSEQ_FEATURE_SIZE, linux.RSeqFeatureSize},
+		arch.AuxEntry{linux.AT_RSEQ_ALIGN, linux.AlignOfRSeq},
 	}...)
+	if vdso != nil {
+		// As in Linux, AT_SYSINFO_EHDR is omitted if there is no VDSO.
+		auxv = append(auxv, arch.AuxEntry{linux.AT_SYSINFO_EHDR, vdsoAddr})
+	}
 
 	sl, err := stack.Load(newArgv, args.Envv, auxv)
 	if err != nil {
@@ -344,20 +577,71 @@ func Load(ctx context.Context, args LoadArgs, extraAuxv []arch.AuxEntry, vdso *V
 	m.SetEnvvEnd(sl.EnvvEnd)
 	m.SetAuxv(auxv)
 	m.SetExecutable(ctx, file)
-	m.SetVDSOSigReturn(uint64(vdsoAddr) + vdsoSigreturnOffset - vdsoPrelink)
+	m.SetBuildID(loaded.buildID)
+	if vdso != nil {
+		m.SetVDSOSigReturn(uint64(vdsoAddr) + vdso.sigreturnOffset)
+	}
 
 	ac.SetIP(uintptr(loaded.entry))
 	ac.SetStack(uintptr(stack.Bottom))
 
 	name := path.Base(args.Filename)
+	if args.NameFromFile && args.File != nil {
+		name = path.Base(args.File.MappedName(ctx))
+	}
 	if len(name) > linux.TASK_COMM_LEN-1 {
 		name = name[:linux.TASK_COMM_LEN-1]
 	}
 
 	return ImageInfo{
-		OS:       loaded.os,
-		Arch:     ac,
-		Name:     name,
-		FileCaps: fileCaps,
+		OS:         loaded.os,
+		Arch:       ac,
+		Name:       name,
+		FileCaps:   fileCaps,
+		Unreadable: loaded.unreadable,
+		BuildID:    loaded.buildID,
+		SetID:      setID,
+		AuxvStart:  sl.AuxvStart,
 	}, nil
 }
+
+// SetAuxvCreds updates the credential-dependent entries of the auxiliary
+// vector of an image that Load loaded into m, whose auxiliary vector starts at
+// auxvStart on its stack. creds are the credentials that the image runs with,
+// and secure is true if it runs in secure-execution mode, in which case glibc's
+// dynamic linker and libc ignore LD_PRELOAD, LD_LIBRARY_PATH and other unsafe
+// environment variables. As in Linux, the environment itself is passed
+// through unmodified.
+//
+// Preconditions: No application code has run in m.
+func SetAuxvCreds(ctx context.Context, m *mm.MemoryManager, ac *arch.Context64, auxvStart hostarch.Addr, creds *auth.Credentials, secure bool) error {
+	atSecure := hostarch.Addr(0)
+	if secure {
+		atSecure = 1
+	}
+	values := map[uint64]hostarch.Addr{
+		linux.AT_UID:    hostarch.Addr(creds.RealKUID.In(creds.UserNamespace).OrOverflow()),
+		linux.AT_EUID:   hostarch.Addr(creds.EffectiveKUID.In(creds.UserNamespace).OrOverflow()),
+		linux.AT_GID:    hostarch.Addr(creds.RealKGID.In(creds.UserNamespace).OrOverflow()),
+		linux.AT_EGID:   hostarch.Addr(creds.EffectiveKGID.In(creds.UserNamespace).OrOverflow()),
+		linux.AT_SECURE: atSecure,
+	}
+	auxv := m.Auxv()
+	for i := range auxv {
+		v, ok := values[auxv[i].Key]
+		if !ok || auxv[i].Value == v {
+			continue
+		}
+		auxv[i].Value = v
+		// Each entry is a key followed by a value.
+		word := ac.Native(uintptr(v))
+		buf := make([]byte, word.SizeBytes())
+		word.MarshalBytes(buf)
+		addr := auxvStart + hostarch.Addr((2*i+1)*len(buf))
+		if _, err := m.CopyOut(ctx, addr, buf, usermem.IOOpts{}); err != nil {
+			return err
+		}
+	}
+	m.SetAuxv(auxv)
+	return nil
+}
@@ -36,7 +36,8 @@ import (
 	"gvisor.dev/gvisor/pkg/usermem"
 )
 
-const vdsoPrelink = 0xffffffffff700000
+// maxVDSOSize is the maximum size of a VDSO image.
+const maxVDSOSize = 1 << 20
 
 type fileContext struct {
 	context.Context
@@ -175,24 +176,66 @@ type VDSO struct {
 
 	// phdrs are the VDSO ELF phdrs.
 	phdrs []elf.ProgHeader `state:".([]elfProgHeader)"`
+
+	// sigreturnOffset is the offset of the VDSO's signal return trampoline
+	// from the start of the VDSO mapping.
+	sigreturnOffset uint64
 }
 
 // PrepareVDSO validates the system VDSO and returns a VDSO, containing the
 // param page for updating by the kernel.
 func PrepareVDSO(mf *pgalloc.MemoryFile) (*VDSO, error) {
-	vdsoFile := &byteFullReader{data: vdsodata.Binary}
+	// Allocate a param page for this VDSO.
+	paramPage, err := mf.Allocate(hostarch.PageSize, pgalloc.AllocOpts{Kind: usage.System})
+	if err != nil {
+		return nil, fmt.Errorf("unable to allocate VDSO param page: %v", err)
+	}
+	pp := mm.NewSpecialMappable("[vvar]", mf, paramPage)
+	v, err := newVDSO(mf, vdsodata.Binary, pp)
+	if err != nil {
+		pp.DecRef(context.Background())
+		return nil, err
+	}
+	return v, nil
+}
+
+// NewVDSO validates binary, an alternate prebuilt VDSO image, and returns a
+// VDSO for it. The VDSO reads its timekeeping data from the parameter page
+// of base, so binary must use the same parameter page layout as the system
+// VDSO.
+func NewVDSO(mf *pgalloc.MemoryFile, binary []byte, base *VDSO) (*VDSO, error) {
+	if len(binary) > maxVDSOSize {
+		return nil, fmt.Errorf("VDSO is too large: %d > %d bytes", len(binary), maxVDSOSize)
+	}
+	base.ParamPage.IncRef()
+	v, err := newVDSO(mf, binary, base.ParamPage)
+	if err != nil {
+		base.ParamPage.DecRef(context.Background())
+		return nil, err
+	}
+	return v, nil
+}
+
+// newVDSO returns a VDSO for binary, using paramPage as its parameter page.
+// On success, it takes ownership of the caller's reference on paramPage.
+func newVDSO(mf *pgalloc.MemoryFile, binary []byte, paramPage *mm.SpecialMappable) (*VDSO, error) {
+	vdsoFile := &byteFullReader{data: binary}
 
 	// First make sure the VDSO is valid. vdsoFile does not use ctx, so a
 	// nil context can be passed.
-	info, err := validateVDSO(nil, vdsoFile, uint64(len(vdsodata.Binary)))
+	info, err := validateVDSO(nil, vdsoFile, uint64(len(binary)))
+	if err != nil {
+		return nil, err
+	}
+	sigreturnOffset, err := vdsoSigreturnOffset(binary, info.phdrs)
 	if err != nil {
 		return nil, err
 	}
 
 	// Then copy it into a VDSO mapping.
-	size, ok := hostarch.Addr(len(vdsodata.Binary)).RoundUp()
+	size, ok := hostarch.Addr(len(binary)).RoundUp()
 	if !ok {
-		return nil, fmt.Errorf("VDSO size overflows? %#x", len(vdsodata.Binary))
+		return nil, fmt.Errorf("VDSO size overflows? %#x", len(binary))
 	}
 
 	vdso, err := mf.Allocate(uint64(size), pgalloc.AllocOpts{Kind: usage.System})
@@ -206,28 +249,22 @@ func PrepareVDSO(mf *pgalloc.MemoryFile) (*VDSO, error) {
 		return nil, fmt.Errorf("unable to map VDSO memory: %v", err)
 	}
 
-	_, err = safemem.CopySeq(ims, safemem.BlockSeqOf(safemem.BlockFromSafeSlice(vdsodata.Binary)))
+	_, err = safemem.CopySeq(ims, safemem.BlockSeqOf(safemem.BlockFromSafeSlice(binary)))
 	if err != nil {
 		mf.DecRef(vdso)
 		return nil, fmt.Errorf("unable to copy VDSO into memory: %v", err)
 	}
 
-	// Finally, allocate a param page for this VDSO.
-	paramPage, err := mf.Allocate(hostarch.PageSize, pgalloc.AllocOpts{Kind: usage.System})
-	if err != nil {
-		mf.DecRef(vdso)
-		return nil, fmt.Errorf("unable to allocate VDSO param page: %v", err)
-	}
-
 	return &VDSO{
-		ParamPage: mm.NewSpecialMappable("[vvar]", mf, paramPage),
+		ParamPage: paramPage,
 		// TODO(gvisor.dev/issue/157): Don't advertise the VDSO, as
 		// some applications may not be able to handle multiple [vdso]
 		// hints.
-		vdso:  mm.NewSpecialMappable("", mf, vdso),
-		os:    info.os,
-		arch:  info.arch,
-		phdrs: info.phdrs,
+		vdso:            mm.NewSpecialMappable("", mf, vdso),
+		os:              info.os,
+		arch:            info.arch,
+		phdrs:           info.phdrs,
+		sigreturnOffset: sigreturnOffset,
 	}, nil
 }
 
@@ -245,7 +282,7 @@ func PrepareVDSO(mf *pgalloc.MemoryFile) (*VDSO, error) {
 // compatibility with such binaries, we load the VDSO much like Linux.
 //
 // loadVDSO takes a reference on the VDSO and parameter page FrameRegions.
-func loadVDSO(ctx context.Context, m *mm.MemoryManager, v *VDSO, bin loadedELF) (hostarch.Addr, error) {
+func loadVDSO(ctx context.Context, m *mm.MemoryManager, v *VDSO, vvar *mm.SpecialMappable, bin loadedELF) (hostarch.Addr, error) {
 	if v.os != bin.os {
 		ctx.Warningf("Binary ELF OS %v and VDSO ELF OS %v differ", bin.os, v.os)
 		return 0, linuxerr.ENOEXEC
@@ -255,9 +292,14 @@ func loadVDSO(ctx context.Context, m *mm.MemoryManager, v *VDSO, bin loadedELF)
 		return 0, linuxerr.ENOEXEC
 	}
 
+	paramPage := v.ParamPage
+	if vvar != nil {
+		paramPage = vvar
+	}
+
 	// Reserve address space for the VDSO and its parameter page, which is
 	// mapped just before the VDSO.
-	mapSize := v.vdso.Length() + v.ParamPage.Length()
+	mapSize := v.vdso.Length() + paramPage.Length()
 	addr, err := m.MMap(ctx, memmap.MMapOpts{
 		Length:  mapSize,
 		Private: true,
@@ -269,9 +311,9 @@ func loadVDSO(ctx context.Context, m *mm.MemoryManager, v *VDSO, bin loadedELF)
 
 	// Now map the param page.
 	_, err = m.MMap(ctx, memmap.MMapOpts{
-		Length:          v.ParamPage.Length(),
-		MappingIdentity: v.ParamPage,
-		Mappable:        v.ParamPage,
+		Length:          paramPage.Length(),
+		MappingIdentity: paramPage,
+		Mappable:        paramPage,
 		Addr:            addr,
 		Fixed:           true,
 		Unmap:           true,
@@ -285,9 +327,9 @@ func loadVDSO(ctx context.Context, m *mm.MemoryManager, v *VDSO, bin loadedELF)
 	}
 
 	// Now map the VDSO itself.
-	vdsoAddr, ok := addr.AddLength(v.ParamPage.Length())
+	vdsoAddr, ok := addr.AddLength(paramPage.Length())
 	if !ok {
-		panic(fmt.Sprintf("Part of mapped range overflows? %#x + %#x", addr, v.ParamPage.Length()))
+		panic(fmt.Sprintf("Part of mapped range overflows? %#x + %#x", addr, paramPage.Length()))
 	}
 	_, err = m.MMap(ctx, memmap.MMapOpts{
 		Length:          v.vdso.Length(),
@@ -361,26 +403,46 @@ func loadVDSO(ctx context.Context, m *mm.MemoryManager, v *VDSO, bin loadedELF)
 	return vdsoAddr, nil
 }
 
+// IncRef takes additional references on mappings held by v, which must be
+// dropped by a corresponding call to Release.
+func (v *VDSO) IncRef() {
+	v.ParamPage.IncRef()
+	v.vdso.IncRef()
+}
+
 // Release drops references on mappings held by v.
 func (v *VDSO) Release(ctx context.Context) {
 	v.ParamPage.DecRef(ctx)
 	v.vdso.DecRef(ctx)
 }
 
-var vdsoSigreturnOffset = func() uint64 {
-	f, err := elf.NewFile(bytes.NewReader(vdsodata.Binary))
+// vdsoSigreturnOffset returns the offset of the signal return trampoline in
+// the VDSO binary, whose program headers are phdrs, from the start of its
+// first PT_LOAD segment.
+func vdsoSigreturnOffset(binary []byte, phdrs []elf.ProgHeader) (uint64, error) {
+	f, err := elf.NewFile(bytes.NewReader(binary))
 	if err != nil {
-		panic(fmt.Sprintf("failed to parse vdso.so as ELF file: %v", err))
+		return 0, fmt.Errorf("failed to parse VDSO as ELF file: %v", err)
 	}
 	syms, err := f.Symbols()
 	if err != nil {
-		panic(fmt.Sprintf("failed to read symbols from vdso.so: %v", err))
+		return 0, fmt.Errorf("failed to read symbols from VDSO: %v", err)
+	}
+	var base uint64
+	for _, phdr := range phdrs {
+		if phdr.Type == elf.PT_LOAD {
+			base = phdr.Vaddr
+			break
+		}
 	}
 	const sigreturnSymbol = "__kernel_rt_sigreturn"
 	for _, sym := range syms {
 		if elf.ST_BIND(sym.Info) != elf.STB_LOCAL && sym.Section != elf.SHN_UNDEF && sym.Name == sigreturnSymbol {
-			return sym.Value
+			if sym.Value < base {
+				return 0, fmt.Errorf("symbol %q at %#x precedes the VDSO at %#x", sigreturnSymbol, sym.Value, base)
+			}
+			return sym.Value - base, nil
 		}
 	}
-	panic(fmt.Sprintf("no symbol %q in vdso.so", sigreturnSymbol))
-}()
+	return 0, fmt.Errorf("no symbol %q in VDSO", sigreturnSymbol)
+}
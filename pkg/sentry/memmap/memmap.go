@@ -102,6 +102,49 @@ type Mappable interface {
 	InvalidateUnsavable(ctx context.Context) error
 }
 
+// ResidencyMappable is an optional extension of Mappable for Mappables that
+// can report which of their offsets are currently backed by memory (e.g. a
+// page cache). It is used to implement mincore(2).
+type ResidencyMappable interface {
+	Mappable
+
+	// ForEachResidentRange calls fn for each page-aligned subrange of mr that
+	// is currently backed by memory. Calls to fn are made in increasing order
+	// of offset.
+	//
+	// Preconditions: The caller must have established a mapping for all of
+	// the queried offsets via a previous call to AddMapping.
+	ForEachResidentRange(ctx context.Context, mr MappableRange, fn func(MappableRange))
+}
+
+// ShmemMappable is an optional extension of ResidencyMappable for Mappables
+// whose contents exist only in memory (shmem, in Linux terms), such that
+// resident pages can be mapped without I/O. It is used to implement
+// userfaultfd(2) minor faults.
+type ShmemMappable interface {
+	ResidencyMappable
+
+	// IsShmem is a marker method; it returns true.
+	IsShmem() bool
+}
+
+// CacheInvalidatingMappable is an optional extension of Mappable for
+// Mappables that cache the contents of a backing object that may be changed
+// without the Mappable's involvement (e.g. a remote file). It is used to
+// implement msync(MS_INVALIDATE).
+type CacheInvalidatingMappable interface {
+	Mappable
+
+	// InvalidateCache writes back dirty cached data in mr, then discards
+	// cached data in mr that is not privately copied, such that subsequent
+	// accesses through shared mappings observe the current contents of the
+	// backing object.
+	//
+	// Preconditions: The caller must have established a mapping for all of
+	// the queried offsets via a previous call to AddMapping.
+	InvalidateCache(ctx context.Context, mr MappableRange) error
+}
+
 // Translations are returned by Mappable.Translate.
 type Translation struct {
 	// Source is the translated range in the Mappable.
@@ -362,6 +405,12 @@ type MMapOpts struct {
 	// Linux.
 	Stack bool
 
+	// If HugeCopy is true and Private is true, copy-on-write breaks in the
+	// mapping copy hugepage-aligned ranges, allowing the copies to be
+	// hugepage-backed, even if the mapping is executable. HugeCopy has no
+	// effect on mappings that are not Private.
+	HugeCopy bool
+
 	// PlatformEffect controls the synchronous effect of this call on the
 	// underlying platform.AddressSpace.
 	PlatformEffect MMapPlatformEffect
@@ -55,6 +55,10 @@ func (mm *MemoryManager) Activate(ctx context.Context) error {
 		}
 	}
 
+	// The name of host threads is computed before locking activeMu, since it
+	// requires kernel locks.
+	hostThreadName := platform.HostThreadNameFromContext(ctx)
+	hostCookie, setHostCookie := platform.HostCoreSchedCookieFromContext(ctx)
 	for {
 		// Slow path: may need to synchronize with other goroutines changing
 		// mm.active to or from zero.
@@ -123,10 +127,77 @@ func (mm *MemoryManager) Activate(ctx context.Context) error {
 		mm.active.Store(1)
 
 		mm.activeMu.Unlock()
+
+		// Naming and core tagging host threads may wait for the platform, so
+		// they are done without activeMu. as can't be released until the
+		// caller calls Deactivate.
+		if hostThreadName != "" {
+			setHostThreadName(as, hostThreadName)
+		}
+		if setHostCookie {
+			// as may have been used by another MemoryManager before, so it
+			// is core tagged even if hostCookie is 0.
+			setHostCoreSchedCookie(as, hostCookie)
+		}
 		return nil
 	}
 }
 
+// SetHostThreadName renames host threads executing application code in mm's
+// active AddressSpace, if any and if the platform supports it. Later
+// AddressSpaces are named by Activate using
+// platform.HostThreadNameFromContext.
+func (mm *MemoryManager) SetHostThreadName(name string) {
+	if !mm.activateIfActive() {
+		return
+	}
+	setHostThreadName(mm.AddressSpace(), name)
+	mm.Deactivate()
+}
+
+// SetHostCoreSchedCookie gives host threads executing application code in
+// mm's active AddressSpace, if any and if the platform supports it, the host
+// core tag corresponding to the core scheduling cookie cookie. Later
+// AddressSpaces are core tagged by Activate using
+// platform.HostCoreSchedCookieFromContext.
+func (mm *MemoryManager) SetHostCoreSchedCookie(cookie uint64) {
+	if !mm.activateIfActive() {
+		return
+	}
+	setHostCoreSchedCookie(mm.AddressSpace(), cookie)
+	mm.Deactivate()
+}
+
+// activateIfActive takes a reference on mm's AddressSpace, as in the fast
+// path of Activate, if it is already active. If it returns true, the caller
+// must call Deactivate to release the reference.
+//
+// Unlike Activate, activateIfActive doesn't lock activeMu, so the caller may
+// use the AddressSpace in ways that wait for the platform.
+func (mm *MemoryManager) activateIfActive() bool {
+	for {
+		active := mm.active.Load()
+		if active == 0 {
+			return false
+		}
+		if mm.active.CompareAndSwap(active, active+1) {
+			return true
+		}
+	}
+}
+
+func setHostThreadName(as platform.AddressSpace, name string) {
+	if n, ok := as.(platform.HostThreadNamer); ok {
+		n.SetHostThreadName(name)
+	}
+}
+
+func setHostCoreSchedCookie(as platform.AddressSpace, cookie uint64) {
+	if s, ok := as.(platform.HostCoreScheduler); ok {
+		s.SetHostCoreSchedCookie(cookie)
+	}
+}
+
 // Deactivate releases a reference to the MemoryManager.
 func (mm *MemoryManager) Deactivate() {
 	// Fast path: this is not the last goroutine to deactivate the
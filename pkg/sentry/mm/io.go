@@ -482,6 +482,12 @@ func (mm *MemoryManager) handleASIOFault(ctx context.Context, addr hostarch.Addr
 	end, _ := ioar.End.RoundUp()
 	ar := hostarch.AddrRange{addr.RoundDown(), end}
 
+	// If the fault must be handled by a userfaultfd, wait for it to be
+	// handled before the caller retries the I/O.
+	if retry, err := mm.handleSentryUserfault(ctx, addr, at, false /* ignorePermissions */); retry || err != nil {
+		return err
+	}
+
 	// Don't bother trying existingPMAsLocked; in most cases, if we did have
 	// existing pmas, we wouldn't have faulted.
 
@@ -541,6 +547,12 @@ func (mm *MemoryManager) withInternalMappings(ctx context.Context, ar hostarch.A
 	}
 	mm.activeMu.RUnlock()
 
+	if retry, err := mm.handleSentryUserfault(ctx, ar.Start, at, ignorePermissions); err != nil {
+		return 0, err
+	} else if retry {
+		return mm.withInternalMappings(ctx, ar, at, ignorePermissions, f)
+	}
+
 	// Ensure that we have usable vmas.
 	mm.mappingMu.RLock()
 	vseg, vend, verr := mm.getVMAsLocked(ctx, ar, at, ignorePermissions)
@@ -618,6 +630,12 @@ func (mm *MemoryManager) withVecInternalMappings(ctx context.Context, ars hostar
 	}
 	mm.activeMu.RUnlock()
 
+	if retry, err := mm.handleSentryUserfault(ctx, ars.Head().Start, at, ignorePermissions); err != nil {
+		return 0, err
+	} else if retry {
+		return mm.withVecInternalMappings(ctx, ars, at, ignorePermissions, f)
+	}
+
 	// Ensure that we have usable vmas.
 	mm.mappingMu.RLock()
 	vars, verr := mm.getVecVMAsLocked(ctx, ars, at, ignorePermissions)
@@ -41,11 +41,12 @@ func NewMemoryManager(p platform.Platform, mf *pgalloc.MemoryFile, sleepForActiv
 	}
 }
 
-// SetMmapLayout initializes mm's layout from the given arch.Context64.
+// SetMmapLayout initializes mm's layout from the given arch.Context64. If
+// randomize is true, the mmap base and stack location are randomized.
 //
 // Preconditions: mm contains no mappings and is not used concurrently.
-func (mm *MemoryManager) SetMmapLayout(ac *arch.Context64, r *limits.LimitSet) (arch.MmapLayout, error) {
-	layout, err := ac.NewMmapLayout(mm.p.MinUserAddress(), mm.p.MaxUserAddress(), r)
+func (mm *MemoryManager) SetMmapLayout(ac *arch.Context64, r *limits.LimitSet, randomize bool) (arch.MmapLayout, error) {
+	layout, err := ac.NewMmapLayout(mm.p.MinUserAddress(), mm.p.MaxUserAddress(), r, randomize)
 	if err != nil {
 		return arch.MmapLayout{}, err
 	}
@@ -53,6 +54,16 @@ func (mm *MemoryManager) SetMmapLayout(ac *arch.Context64, r *limits.LimitSet) (
 	return layout, nil
 }
 
+// forkUnmapRangesMax is the maximum number of discontiguous ranges that Fork
+// unmaps individually from the parent's AddressSpace when making private pmas
+// copy-on-write. If more ranges need to be unmapped, Fork instead unmaps the
+// smallest range containing all of them: this may cause the parent to take
+// extra page faults to remap pmas that weren't made copy-on-write (which don't
+// need to lock activeMu for writing), but avoids making a large number of
+// calls to platform.AddressSpace.Unmap, which dominate the latency of forking
+// large address spaces.
+const forkUnmapRangesMax = 16
+
 // Fork creates a copy of mm with 1 user, as for Linux syscalls fork() or
 // clone() (without CLONE_VM).
 func (mm *MemoryManager) Fork(ctx context.Context) (*MemoryManager, error) {
@@ -90,7 +101,9 @@ func (mm *MemoryManager) Fork(ctx context.Context) (*MemoryManager, error) {
 		auxv:                 append(arch.Auxv(nil), mm.auxv...),
 		// IncRef'd below, once we know that there isn't an error.
 		executable:         mm.executable,
+		buildID:            mm.buildID,
 		dumpability:        atomicbitops.FromInt32(mm.dumpability.Load()),
+		thpDisabled:        atomicbitops.FromBool(mm.thpDisabled.Load()),
 		aioManager:         aioManager{contexts: make(map[uint64]*AIOContext)},
 		sleepForActivation: mm.sleepForActivation,
 		vdsoSigReturnAddr:  mm.vdsoSigReturnAddr,
@@ -124,7 +137,13 @@ func (mm *MemoryManager) Fork(ctx context.Context) (*MemoryManager, error) {
 			vma.id.IncRef()
 		}
 		vma.mlockMode = memmap.MLockNone
-		dstvgap = mm2.vmas.Insert(dstvgap, vmaAR, vma).NextGap()
+		// UFFD_FEATURE_EVENT_FORK is unsupported, so mm2's vmas are not
+		// registered with userfaultfds. Write-protected pmas are copied
+		// without write permission below, since they become copy-on-write.
+		vma.clearUserfault()
+		// Since vmas are copied in order, and vmas that were not merged in
+		// mm rarely become mergeable, don't bother trying to merge them.
+		dstvgap = mm2.vmas.InsertWithoutMerging(dstvgap, vmaAR, vma).NextGap()
 		// We don't need to update mm2.usageAS since we copied it from mm
 		// above.
 	}
@@ -146,7 +165,10 @@ func (mm *MemoryManager) Fork(ctx context.Context) (*MemoryManager, error) {
 	}
 	srcvseg := mm.vmas.FirstSegment()
 	dstpgap := mm2.pmas.FirstGap()
-	var unmapAR hostarch.AddrRange
+	var (
+		unmapARs []hostarch.AddrRange
+		frs      []memmap.FileRange
+	)
 	memCgID := pgalloc.MemoryCgroupIDFromContext(ctx)
 	for srcpseg := mm.pmas.FirstSegment(); srcpseg.Ok(); srcpseg = srcpseg.NextSegment() {
 		pma := srcpseg.ValuePtr()
@@ -183,27 +205,30 @@ func (mm *MemoryManager) Fork(ctx context.Context) (*MemoryManager, error) {
 				// unmapping pmas unnecessarily will result in extra page
 				// faults. But we do want to merge consecutive AddrRanges
 				// across pma boundaries.
-				if unmapAR.End == srcpseg.Start() {
-					unmapAR.End = srcpseg.End()
+				if n := len(unmapARs); n != 0 && unmapARs[n-1].End == srcpseg.Start() {
+					unmapARs[n-1].End = srcpseg.End()
 				} else {
-					if unmapAR.Length() != 0 {
-						mm.unmapASLocked(unmapAR)
-					}
-					unmapAR = srcpseg.Range()
+					unmapARs = append(unmapARs, srcpseg.Range())
 				}
 				pma.effectivePerms.Write = false
 			}
 			pma.maxPerms.Write = false
 		}
-		fr := srcpseg.fileRange()
-		// srcpseg.ValuePtr().file == mm.mf since pma.private == true.
-		mm.mf.IncRef(fr, memCgID)
+		// srcpseg.ValuePtr().file == mm.mf since pma.private == true. The
+		// references are taken below, in a single batch.
+		frs = append(frs, srcpseg.fileRange())
 		addrRange := srcpseg.Range()
 		mm2.addRSSLocked(addrRange)
-		dstpgap = mm2.pmas.Insert(dstpgap, addrRange, *pma).NextGap()
+		// As for vmas, don't bother trying to merge pmas.
+		dstpgap = mm2.pmas.InsertWithoutMerging(dstpgap, addrRange, *pma).NextGap()
 	}
-	if unmapAR.Length() != 0 {
-		mm.unmapASLocked(unmapAR)
+	mm.mf.IncRefs(frs, memCgID)
+	if len(unmapARs) > forkUnmapRangesMax {
+		mm.unmapASLocked(hostarch.AddrRange{unmapARs[0].Start, unmapARs[len(unmapARs)-1].End})
+	} else {
+		for _, ar := range unmapARs {
+			mm.unmapASLocked(ar)
+		}
 	}
 
 	// Between when we call memmap.Mappable.AddMapping while copying vmas and
@@ -47,6 +47,17 @@ func (mm *MemoryManager) SetDumpability(d Dumpability) {
 	mm.dumpability.Store(int32(d))
 }
 
+// THPDisabled returns true if transparent hugepages are disabled for mm.
+func (mm *MemoryManager) THPDisabled() bool {
+	return mm.thpDisabled.Load()
+}
+
+// SetTHPDisabled disables or enables transparent hugepages for mm. It only
+// affects memory allocated after the call, as in Linux.
+func (mm *MemoryManager) SetTHPDisabled(disabled bool) {
+	mm.thpDisabled.Store(disabled)
+}
+
 // ArgvStart returns the start of the application argument vector.
 //
 // There is no guarantee that this value is sensible w.r.t. ArgvEnd.
@@ -165,6 +176,22 @@ func (mm *MemoryManager) SetExecutable(ctx context.Context, fd *vfs.FileDescript
 	}
 }
 
+// BuildID returns the build ID of the executable, or nil if it has none. The
+// returned slice must not be modified.
+func (mm *MemoryManager) BuildID() []byte {
+	mm.metadataMu.Lock()
+	defer mm.metadataMu.Unlock()
+	return mm.buildID
+}
+
+// SetBuildID sets the build ID of the executable. id must not be modified
+// after the call.
+func (mm *MemoryManager) SetBuildID(id []byte) {
+	mm.metadataMu.Lock()
+	defer mm.metadataMu.Unlock()
+	mm.buildID = id
+}
+
 // VDSOSigReturn returns the address of vdso_sigreturn.
 func (mm *MemoryManager) VDSOSigReturn() uint64 {
 	mm.metadataMu.Lock()
@@ -197,6 +197,12 @@ type MemoryManager struct {
 	// by metadataMu.
 	dumpability atomicbitops.Int32
 
+	// thpDisabled is true if transparent hugepages were disabled by
+	// prctl(PR_SET_THP_DISABLE), in which case memory allocated for this
+	// MemoryManager is never hugepage-backed. Like dumpability, it is
+	// accessed atomically.
+	thpDisabled atomicbitops.Bool
+
 	metadataMu metadataMutex `state:"nosave"`
 
 	// argv is the application argv. This is set up by the loader and may be
@@ -224,6 +230,12 @@ type MemoryManager struct {
 	// executable is protected by metadataMu.
 	executable *vfs.FileDescription
 
+	// buildID is the NT_GNU_BUILD_ID note of the executable, or nil if it has
+	// none. The slice is never modified.
+	//
+	// buildID is protected by metadataMu.
+	buildID []byte
+
 	// aioManager keeps track of AIOContexts used for async IOs. AIOManager
 	// must be cloned when CLONE_VM is used.
 	aioManager aioManager
@@ -245,6 +257,15 @@ type MemoryManager struct {
 	// membarrierRSeqEnabled is non-zero if EnableMembarrierRSeq has previously
 	// been called.
 	membarrierRSeqEnabled atomicbitops.Uint32
+
+	// membarrierSyncCoreEnabled is non-zero if EnableMembarrierSyncCore has
+	// previously been called.
+	membarrierSyncCoreEnabled atomicbitops.Uint32
+
+	// userfaultfdRegistered is true if any vma in mm has ever been registered
+	// with a Userfaultfd. It allows sentry accesses to skip checking for
+	// userfaultfd events in the common case.
+	userfaultfdRegistered atomicbitops.Bool
 }
 
 // vma represents a virtual memory area.
@@ -272,7 +293,8 @@ type vma struct {
 	// effectivePerms are the memory permissions on this vma which are
 	// actually used to control access.
 	//
-	// Invariant: effectivePerms == realPerms.Effective().
+	// Invariant: effectivePerms == realPerms.Effective(), except that
+	// effectivePerms.Write is false if uffdWP is true.
 	effectivePerms hostarch.AccessType `state:"manual"`
 
 	// maxPerms limits the set of permissions that may ever apply to this
@@ -297,6 +319,9 @@ type vma struct {
 	// isStack is true if this is a MAP_STACK mapping.
 	isStack bool `state:"manual"`
 
+	// hugeCopy is MMapOpts.HugeCopy for this vma.
+	hugeCopy bool `state:"manual"`
+
 	// dontfork is the MADV_DONTFORK setting for this vma configured by madvise().
 	dontfork bool
 
@@ -318,6 +343,16 @@ type vma struct {
 
 	nameMut memmap.NameMut
 
+	// If uffd is not nil, the vma is registered with it for the userfaultfd
+	// events selected by uffdMode, a mask of linux.UFFDIO_REGISTER_MODE_*.
+	uffd     *Userfaultfd
+	uffdMode uint64
+
+	// uffdWP is true if writes to the vma raise userfaultfd write-protect
+	// faults. If uffdWP is true, uffdMode includes
+	// linux.UFFDIO_REGISTER_MODE_WP.
+	uffdWP bool
+
 	// lastFault records the last address that was paged faulted. It hints at
 	// which direction addresses in this vma are being accessed.
 	//
@@ -336,6 +371,7 @@ func (v *vma) copy() vma {
 		private:        v.private,
 		growsDown:      v.growsDown,
 		isStack:        v.isStack,
+		hugeCopy:       v.hugeCopy,
 		dontfork:       v.dontfork,
 		mlockMode:      v.mlockMode,
 		numaPolicy:     v.numaPolicy,
@@ -343,6 +379,9 @@ func (v *vma) copy() vma {
 		id:             v.id,
 		name:           v.name,
 		nameMut:        v.nameMut,
+		uffd:           v.uffd,
+		uffdMode:       v.uffdMode,
+		uffdWP:         v.uffdWP,
 		lastFault:      atomic.LoadUintptr(&v.lastFault),
 	}
 }
@@ -340,3 +340,110 @@ func TestGetAllocationDirection(t *testing.T) {
 		})
 	}
 }
+
+func TestAnonFaultAround(t *testing.T) {
+	ctx := contexttest.Context(t)
+	mm := testMemoryManager(ctx)
+	defer mm.DecUsers(ctx)
+
+	addr, err := mm.MMap(ctx, memmap.MMapOpts{
+		Length:   4 * maxAnonFaultAround,
+		Private:  true,
+		Perms:    hostarch.ReadWrite,
+		MaxPerms: hostarch.AnyAccess,
+	})
+	if err != nil {
+		t.Fatalf("MMap got err %v want nil", err)
+	}
+	base, _ := addr.HugeRoundUp()
+
+	touch := func(addr hostarch.Addr) {
+		t.Helper()
+		if _, err := mm.CopyOut(ctx, addr, []byte{1}, usermem.IOOpts{}); err != nil {
+			t.Fatalf("CopyOut(%#x) got err %v want nil", addr, err)
+		}
+	}
+
+	// An isolated fault allocates a single hugepage-aligned range.
+	touch(base)
+	if got, want := mm.curRSS, uint64(hostarch.HugePageSize); got != want {
+		t.Errorf("RSS after first fault got %d want %d", got, want)
+	}
+
+	// A fault continuing the run allocates twice the size of the preceding
+	// pma.
+	touch(base + hostarch.HugePageSize)
+	if got, want := mm.curRSS, uint64(3*hostarch.HugePageSize); got != want {
+		t.Errorf("RSS after second fault got %d want %d", got, want)
+	}
+
+	// The next fault after the run allocates at least as much again.
+	touch(base + 3*hostarch.HugePageSize)
+	if got, want := mm.curRSS, uint64(7*hostarch.HugePageSize); got < want {
+		t.Errorf("RSS after third fault got %d want at least %d", got, want)
+	}
+}
+
+func TestPrefault(t *testing.T) {
+	ctx := contexttest.Context(t)
+	mm := testMemoryManager(ctx)
+	defer mm.DecUsers(ctx)
+
+	// Prefault must work on an inactive MemoryManager, and on mappings that
+	// are not writable.
+	const length = 4 * hostarch.PageSize
+	addr, err := mm.MMap(ctx, memmap.MMapOpts{
+		Length:   length,
+		Private:  true,
+		Perms:    hostarch.ReadExecute,
+		MaxPerms: hostarch.AnyAccess,
+		HugeCopy: true,
+	})
+	if err != nil {
+		t.Fatalf("MMap got err %v want nil", err)
+	}
+	ar := hostarch.AddrRange{addr, addr + length}
+	if err := mm.Prefault(ctx, ar, true /* breakCOW */); err != nil {
+		t.Fatalf("Prefault got err %v want nil", err)
+	}
+	if got, want := mm.curRSS, uint64(length); got != want {
+		t.Errorf("RSS after Prefault got %d want %d", got, want)
+	}
+	for pseg := mm.pmas.LowerBoundSegment(ar.Start); pseg.Ok() && pseg.Start() < ar.End; pseg = pseg.NextSegment() {
+		if pma := pseg.ValuePtr(); pma.needCOW {
+			t.Errorf("pma %v still needs copy-on-write after Prefault", pseg.Range())
+		}
+	}
+	if vseg := mm.vmas.FindSegment(addr); !vseg.ValuePtr().hugeCopy {
+		t.Errorf("vma %v lost hugeCopy", vseg.Range())
+	}
+
+	// Prefault fails on unmapped addresses.
+	if err := mm.MUnmap(ctx, addr, length); err != nil {
+		t.Fatalf("MUnmap got err %v want nil", err)
+	}
+	if err := mm.Prefault(ctx, ar, false /* breakCOW */); !linuxerr.Equals(linuxerr.EFAULT, err) {
+		t.Errorf("Prefault after MUnmap got err %v want EFAULT", err)
+	}
+}
+
+// TestTHPDisabledFork tests that PR_SET_THP_DISABLE is inherited by forked
+// MemoryManagers.
+func TestTHPDisabledFork(t *testing.T) {
+	ctx := contexttest.Context(t)
+	mm := testMemoryManager(ctx)
+	defer mm.DecUsers(ctx)
+
+	if mm.THPDisabled() {
+		t.Errorf("THP disabled in new MemoryManager")
+	}
+	mm.SetTHPDisabled(true)
+	mm2, err := mm.Fork(ctx)
+	if err != nil {
+		t.Fatalf("mm.Fork got err %v want nil", err)
+	}
+	defer mm2.DecUsers(ctx)
+	if !mm2.THPDisabled() {
+		t.Errorf("THP enabled in forked MemoryManager")
+	}
+}
@@ -272,6 +272,19 @@ func (mm *MemoryManager) getPMAsInternalLocked(ctx context.Context, vseg vmaIter
 						panic(fmt.Sprintf("vseg %v and pgap %v do not overlap", vseg, pgap))
 					}
 				}
+				if vma.uffd != nil {
+					// Pages whose faults are handled by a userfaultfd must
+					// not be populated here; see Userfaultfd. Don't populate
+					// pages outside ar either, since that would prevent
+					// faults on them.
+					optAR = optAR.Intersect(ar)
+					if uaddr, _, ok := vseg.firstUserfaultLocked(ctx, optAR); ok {
+						if uaddr == optAR.Start {
+							return pstart, pgap, linuxerr.EFAULT
+						}
+						optAR.End = uaddr
+					}
+				}
 				if vma.mappable == nil {
 					// Private anonymous mappings get pmas by allocating.
 					// The allocated range is limited to ar, expanded to
@@ -279,10 +292,15 @@ func (mm *MemoryManager) getPMAsInternalLocked(ctx context.Context, vseg vmaIter
 					// will not be hugepage-backed, in an attempt to reduce
 					// application page faults (that trap into the sentry) by
 					// creating AddressSpace mappings in advance.
-					allocAR := optAR.Intersect(hugeMaskAR)
+					//
+					// If the fault continues a run of private anonymous pmas,
+					// as when an application sequentially touches a fresh
+					// heap, the allocated range is extended further in the
+					// direction of the run; see anonFaultAroundRange.
+					allocAR := optAR.Intersect(anonFaultAroundRange(hugeMaskAR, vma, pgap))
 					// Don't back stacks with huge pages due to low utilization
 					// and because they're often fragmented by copy-on-write.
-					huge := mm.mf.HugepagesEnabled() && allocAR.IsHugePageAligned() && !vma.growsDown && !vma.isStack
+					huge := mm.mf.HugepagesEnabled() && !mm.thpDisabled.Load() && allocAR.IsHugePageAligned() && !vma.growsDown && !vma.isStack
 					allocOpts := pgalloc.AllocOpts{
 						Kind:    usage.Anonymous,
 						MemCgID: memCgID,
@@ -393,7 +411,7 @@ func (mm *MemoryManager) getPMAsInternalLocked(ctx context.Context, vseg vmaIter
 						}
 					}
 					var copyAR hostarch.AddrRange
-					if vma.effectivePerms.Execute {
+					if vma.effectivePerms.Execute && !vma.hugeCopy {
 						// The majority of copy-on-write breaks on executable
 						// pages come from:
 						//
@@ -405,7 +423,10 @@ func (mm *MemoryManager) getPMAsInternalLocked(ctx context.Context, vseg vmaIter
 						//
 						// Neither of these cases has enough spatial locality
 						// to benefit from copying nearby pages, so if the vma
-						// is executable, only copy the pages required.
+						// is executable, only copy the pages required, unless
+						// the vma was created with MMapOpts.HugeCopy (in which
+						// case the whole vma is usually being copied by
+						// MemoryManager.Prefault).
 						copyAR = pseg.Range().Intersect(ar)
 					} else if vma.growsDown || vma.isStack {
 						// In most cases, the new process will not use most of
@@ -433,7 +454,7 @@ func (mm *MemoryManager) getPMAsInternalLocked(ctx context.Context, vseg vmaIter
 						return pstart, pseg.PrevGap(), err
 					}
 					// Copy contents.
-					huge := mm.mf.HugepagesEnabled() && copyAR.IsHugePageAligned()
+					huge := mm.mf.HugepagesEnabled() && !mm.thpDisabled.Load() && copyAR.IsHugePageAligned()
 					reader := safemem.BlockSeqReader{Blocks: mm.internalMappingsLocked(pseg, copyAR)}
 					fr, err := mm.mf.Allocate(uint64(copyAR.Length()), pgalloc.AllocOpts{
 						Kind:       usage.Anonymous,
@@ -576,6 +597,50 @@ func (mm *MemoryManager) getPMAsInternalLocked(ctx context.Context, vseg vmaIter
 	}
 }
 
+// maxAnonFaultAround is the maximum size of the range allocated by
+// anonFaultAroundRange.
+const maxAnonFaultAround = 8 * hostarch.HugePageSize
+
+// anonFaultAroundRange returns the range in which private anonymous memory
+// should be allocated to satisfy a fault on hugeAR (which must be hugepage
+// aligned), where pgap is the pma gap containing hugeAR.Start in vma.
+//
+// If the pma preceding pgap ends at hugeAR.Start (or the pma following pgap
+// begins at hugeAR.End), the fault likely continues a sequential traversal of
+// the vma, so the returned range is extended in the same direction to twice
+// the size of that pma, up to maxAnonFaultAround. Since runs of faults
+// allocate successively larger ranges, this adaptively reduces the number of
+// faults taken by applications that touch large amounts of fresh memory,
+// while applications with sparse access patterns continue to allocate one
+// hugepage-aligned range per fault.
+func anonFaultAroundRange(hugeAR hostarch.AddrRange, vma *vma, pgap pmaGapIterator) hostarch.AddrRange {
+	// Don't extend allocations for stacks, for the same reasons that we don't
+	// back them with huge pages.
+	if vma.growsDown || vma.isStack {
+		return hugeAR
+	}
+	aroundLen := func(runLen hostarch.Addr) hostarch.Addr {
+		if runLen >= maxAnonFaultAround/2 {
+			return maxAnonFaultAround
+		}
+		return (2 * runLen).HugeRoundDown()
+	}
+	if prev := pgap.PrevSegment(); prev.Ok() && prev.End() == hugeAR.Start {
+		if n := aroundLen(prev.Range().Length()); n > hugeAR.Length() {
+			if end := hugeAR.Start + n; end > hugeAR.Start {
+				hugeAR.End = end
+			}
+		}
+	} else if next := pgap.NextSegment(); next.Ok() && next.Start() == hugeAR.End {
+		if n := aroundLen(next.Range().Length()); n > hugeAR.Length() {
+			if start := hugeAR.End - n; start < hugeAR.End {
+				hugeAR.Start = start
+			}
+		}
+	}
+	return hugeAR
+}
+
 func hugepageAligned(ar hostarch.AddrRange) hostarch.AddrRange {
 	aligned := hostarch.AddrRange{ar.Start.HugeRoundDown(), ar.End}
 	if end, ok := ar.End.HugeRoundUp(); ok {
@@ -697,6 +762,55 @@ func (mm *MemoryManager) invalidateLocked(ar hostarch.AddrRange, invalidatePriva
 	}
 }
 
+// Prefault ensures that pmas exist for all addresses in ar, as if each page
+// had been accessed by ptrace(PTRACE_PEEKDATA). If breakCOW is true, Prefault
+// additionally breaks copy-on-write for all private mappings in ar, as if each
+// page had been written by ptrace(PTRACE_POKEDATA).
+//
+// Unlike MMapOpts.PlatformEffect, Prefault does not require mm to be active,
+// so it may be used before the MemoryManager is first activated, e.g. by the
+// ELF loader. AddressSpace mappings for the new pmas are still created
+// lazily.
+//
+// Preconditions:
+//   - ar.Length() != 0.
+//   - ar must be page-aligned.
+func (mm *MemoryManager) Prefault(ctx context.Context, ar hostarch.AddrRange, breakCOW bool) error {
+	if checkInvariants {
+		if !ar.WellFormed() || ar.Length() == 0 || !ar.IsPageAligned() {
+			panic(fmt.Sprintf("invalid ar: %v", ar))
+		}
+	}
+
+	at := hostarch.Read
+	if breakCOW {
+		at = hostarch.ReadWrite
+	}
+
+	// Ensure that we have usable vmas.
+	mm.mappingMu.RLock()
+	vseg, vend, verr := mm.getVMAsLocked(ctx, ar, at, true /* ignorePermissions */)
+	if vendaddr := vend.Start(); vendaddr < ar.End {
+		if vendaddr <= ar.Start {
+			mm.mappingMu.RUnlock()
+			return verr
+		}
+		ar.End = vendaddr
+	}
+
+	// Ensure that we have usable pmas.
+	mm.activeMu.Lock()
+	_, _, perr := mm.getPMAsLocked(ctx, vseg, ar, at, false /* callerIndirectCommit */)
+	mm.mappingMu.RUnlock()
+	mm.activeMu.Unlock()
+
+	// Return the first error in order of progress through ar.
+	if perr != nil {
+		return perr
+	}
+	return verr
+}
+
 // Pin returns the memmap.File ranges currently mapped by addresses in ar in
 // mm, acquiring a reference on the returned ranges which the caller must
 // release by calling Unpin. If not all addresses are mapped, Pin returns a
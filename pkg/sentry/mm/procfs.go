@@ -16,11 +16,11 @@ package mm
 
 import (
 	"bytes"
-	"fmt"
+	"strconv"
 
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/context"
 	"gvisor.dev/gvisor/pkg/hostarch"
-	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/sentry/memmap"
 )
 
@@ -50,28 +50,92 @@ const (
 		"MMUPageSize:           4 kB\n" +
 		"Locked:                0 kB\n" +
 		"VmFlags: rd ex \n"
+
+	// mapsChunkBytes is the number of bytes after which ReadMapsChunkInto
+	// and ReadSmapsChunkInto stop appending entries, bounding the time for
+	// which mm.mappingMu is held by each call. Compare fs/seq_file.c, which
+	// emits records until a page-sized buffer is full.
+	mapsChunkBytes = hostarch.PageSize
 )
 
+// mappedNameCache caches the result of memmap.MappingIdentity.MappedName for
+// the most recently formatted vma. Consecutive vmas frequently share a
+// MappingIdentity (e.g. the segments of a mapped executable or library), and
+// computing MappedName may require walking the filesystem tree.
+type mappedNameCache struct {
+	id   memmap.MappingIdentity
+	name string
+}
+
+func (c *mappedNameCache) mappedName(ctx context.Context, id memmap.MappingIdentity) string {
+	if c == nil {
+		return id.MappedName(ctx)
+	}
+	if c.id != id {
+		c.id = id
+		c.name = id.MappedName(ctx)
+	}
+	return c.name
+}
+
+// appendPaddedHex appends the lowercase hexadecimal representation of v to b,
+// zero-padded to at least width digits.
+func appendPaddedHex(b []byte, v uint64, width int) []byte {
+	var digits [16]byte
+	d := strconv.AppendUint(digits[:0], v, 16)
+	for i := len(d); i < width; i++ {
+		b = append(b, '0')
+	}
+	return append(b, d...)
+}
+
+// appendSmapsField appends a /proc/[pid]/smaps field consisting of label, kb
+// right-aligned to width characters, and the " kB" suffix to b.
+func appendSmapsField(b []byte, label string, width int, kb uint64) []byte {
+	var digits [20]byte
+	d := strconv.AppendUint(digits[:0], kb, 10)
+	b = append(b, label...)
+	for i := len(d); i < width; i++ {
+		b = append(b, ' ')
+	}
+	b = append(b, d...)
+	return append(b, " kB\n"...)
+}
+
 // MapsCallbackFuncForBuffer creates a /proc/[pid]/maps entry including the trailing newline.
 func (mm *MemoryManager) MapsCallbackFuncForBuffer(buf *bytes.Buffer) MapsCallbackFunc {
 	return func(start, end hostarch.Addr, permissions hostarch.AccessType, private string, offset uint64, devMajor, devMinor uint32, inode uint64, path string) {
 		// Do not include the guard page: fs/proc/task_mmu.c:show_map_vma() =>
 		// stack_guard_page_start().
-		lineLen, err := fmt.Fprintf(buf, "%08x-%08x %s%s %08x %02x:%02x %d ",
-			start, end, permissions, private, offset, devMajor, devMinor, inode)
-		if err != nil {
-			log.Warningf("Failed to write to buffer with error: %v", err)
-			return
-		}
+		//
+		// This is equivalent to formatting "%08x-%08x %s%s %08x %02x:%02x %d "
+		// with fmt, which is too slow for address spaces with many vmas.
+		b := buf.AvailableBuffer()
+		b = appendPaddedHex(b, uint64(start), 8)
+		b = append(b, '-')
+		b = appendPaddedHex(b, uint64(end), 8)
+		b = append(b, ' ')
+		b = append(b, permissions.String()...)
+		b = append(b, private...)
+		b = append(b, ' ')
+		b = appendPaddedHex(b, offset, 8)
+		b = append(b, ' ')
+		b = appendPaddedHex(b, uint64(devMajor), 2)
+		b = append(b, ':')
+		b = appendPaddedHex(b, uint64(devMinor), 2)
+		b = append(b, ' ')
+		b = strconv.AppendUint(b, inode, 10)
+		b = append(b, ' ')
 
 		if path != "" {
 			// Per linux, we pad until the 74th character.
-			for pad := 73 - lineLen; pad > 0; pad-- {
-				buf.WriteByte(' ') // never returns a non-nil error
+			for pad := 73 - len(b); pad > 0; pad-- {
+				b = append(b, ' ')
 			}
-			buf.WriteString(path) // never returns a non-nil error
+			b = append(b, path...)
 		}
-		buf.WriteByte('\n') // never returns a non-nil error
+		b = append(b, '\n')
+		buf.Write(b) // never returns a non-nil error
 	}
 }
 
@@ -81,8 +145,9 @@ func (mm *MemoryManager) ReadMapsDataInto(ctx context.Context, fn MapsCallbackFu
 	mm.mappingMu.RLock()
 	defer mm.mappingMu.RUnlock()
 
+	var names mappedNameCache
 	for vseg := mm.vmas.FirstSegment(); vseg.Ok(); vseg = vseg.NextSegment() {
-		mm.appendVMAMapsEntryLocked(ctx, vseg, fn)
+		mm.appendVMAMapsEntryLocked(ctx, vseg, &names, fn)
 	}
 
 	// We always emulate vsyscall, so advertise it here. Everything about a
@@ -95,18 +160,66 @@ func (mm *MemoryManager) ReadMapsDataInto(ctx context.Context, fn MapsCallbackFu
 	fn(hostarch.Addr(0xffffffffff600000), hostarch.Addr(0xffffffffff601000), hostarch.ReadExecute, "p", 0, 0, 0, 0, "[vsyscall]")
 }
 
+// ReadMapsChunkInto is called by fsimpl/proc.mapsData.GenerateFrom to
+// implement /proc/[pid]/maps incrementally. It appends entries for vmas
+// beginning with the vma containing or following start to buf, stopping after
+// roughly mapsChunkBytes have been appended. It returns the address at which
+// the next call should start, and true if the final entry has been appended.
+func (mm *MemoryManager) ReadMapsChunkInto(ctx context.Context, buf *bytes.Buffer, start hostarch.Addr) (hostarch.Addr, bool) {
+	fn := mm.MapsCallbackFuncForBuffer(buf)
+	return mm.readChunkInto(ctx, buf, start, vsyscallMapsEntry, func(vseg vmaIterator, names *mappedNameCache) {
+		mm.appendVMAMapsEntryLocked(ctx, vseg, names, fn)
+	})
+}
+
+// ReadSmapsChunkInto is called by fsimpl/proc.smapsData.GenerateFrom to
+// implement /proc/[pid]/smaps incrementally, analogously to
+// ReadMapsChunkInto.
+func (mm *MemoryManager) ReadSmapsChunkInto(ctx context.Context, buf *bytes.Buffer, start hostarch.Addr) (hostarch.Addr, bool) {
+	return mm.readChunkInto(ctx, buf, start, vsyscallSmapsEntry, func(vseg vmaIterator, names *mappedNameCache) {
+		mm.vmaSmapsEntryIntoLocked(ctx, vseg, names, buf)
+	})
+}
+
+// readChunkInto implements ReadMapsChunkInto and ReadSmapsChunkInto.
+// appendEntry is called with mm.mappingMu locked for reading.
+func (mm *MemoryManager) readChunkInto(ctx context.Context, buf *bytes.Buffer, start hostarch.Addr, vsyscallEntry string, appendEntry func(vseg vmaIterator, names *mappedNameCache)) (hostarch.Addr, bool) {
+	if start >= vsyscallEnd {
+		return start, true
+	}
+	limit := buf.Len() + mapsChunkBytes
+
+	mm.mappingMu.RLock()
+	defer mm.mappingMu.RUnlock()
+
+	var names mappedNameCache
+	for vseg := mm.vmas.LowerBoundSegment(start); vseg.Ok(); vseg = vseg.NextSegment() {
+		if buf.Len() >= limit {
+			return vseg.Start(), false
+		}
+		appendEntry(vseg, &names)
+	}
+	// See ReadMapsDataInto for commentary on the vsyscall entry.
+	buf.WriteString(vsyscallEntry)
+	return vsyscallEnd, true
+}
+
 // vmaMapsEntryLocked returns a /proc/[pid]/maps entry for the vma iterated by
 // vseg, including the trailing newline.
 //
 // Preconditions: mm.mappingMu must be locked.
 func (mm *MemoryManager) vmaMapsEntryLocked(ctx context.Context, vseg vmaIterator) []byte {
 	var b bytes.Buffer
-	mm.appendVMAMapsEntryLocked(ctx, vseg, mm.MapsCallbackFuncForBuffer(&b))
+	mm.appendVMAMapsEntryLocked(ctx, vseg, nil, mm.MapsCallbackFuncForBuffer(&b))
 	return b.Bytes()
 }
 
+// appendVMAMapsEntryLocked calls fn with the /proc/[pid]/maps entry for the
+// vma iterated by vseg. If names is not nil, it is used to cache the vma's
+// mapped name.
+//
 // Preconditions: mm.mappingMu must be locked.
-func (mm *MemoryManager) appendVMAMapsEntryLocked(ctx context.Context, vseg vmaIterator, fn MapsCallbackFunc) {
+func (mm *MemoryManager) appendVMAMapsEntryLocked(ctx context.Context, vseg vmaIterator, names *mappedNameCache, fn MapsCallbackFunc) {
 	vma := vseg.ValuePtr()
 	private := "p"
 	if !vma.private {
@@ -126,7 +239,7 @@ func (mm *MemoryManager) appendVMAMapsEntryLocked(ctx context.Context, vseg vmaI
 	if vma.name != "" {
 		path = vma.name
 	} else if vma.id != nil {
-		path = vma.id.MappedName(ctx)
+		path = names.mappedName(ctx, vma.id)
 	}
 	fn(vseg.Start(), vseg.End(), vma.realPerms, private, vma.off, devMajor, devMinor, ino, path)
 }
@@ -137,8 +250,9 @@ func (mm *MemoryManager) ReadSmapsDataInto(ctx context.Context, buf *bytes.Buffe
 	mm.mappingMu.RLock()
 	defer mm.mappingMu.RUnlock()
 
+	var names mappedNameCache
 	for vseg := mm.vmas.FirstSegment(); vseg.Ok(); vseg = vseg.NextSegment() {
-		mm.vmaSmapsEntryIntoLocked(ctx, vseg, buf)
+		mm.vmaSmapsEntryIntoLocked(ctx, vseg, &names, buf)
 	}
 
 	// We always emulate vsyscall, so advertise it here. See
@@ -152,12 +266,13 @@ func (mm *MemoryManager) ReadSmapsDataInto(ctx context.Context, buf *bytes.Buffe
 // Preconditions: mm.mappingMu must be locked.
 func (mm *MemoryManager) vmaSmapsEntryLocked(ctx context.Context, vseg vmaIterator) []byte {
 	var b bytes.Buffer
-	mm.vmaSmapsEntryIntoLocked(ctx, vseg, &b)
+	mm.vmaSmapsEntryIntoLocked(ctx, vseg, nil, &b)
 	return b.Bytes()
 }
 
-func (mm *MemoryManager) vmaSmapsEntryIntoLocked(ctx context.Context, vseg vmaIterator, b *bytes.Buffer) {
-	mm.appendVMAMapsEntryLocked(ctx, vseg, mm.MapsCallbackFuncForBuffer(b))
+// Preconditions: mm.mappingMu must be locked.
+func (mm *MemoryManager) vmaSmapsEntryIntoLocked(ctx context.Context, vseg vmaIterator, names *mappedNameCache, buf *bytes.Buffer) {
+	mm.appendVMAMapsEntryLocked(ctx, vseg, names, mm.MapsCallbackFuncForBuffer(buf))
 	vma := vseg.ValuePtr()
 
 	// We take mm.activeMu here in each call to vmaSmapsEntryLocked, instead of
@@ -178,77 +293,88 @@ func (mm *MemoryManager) vmaSmapsEntryIntoLocked(ctx context.Context, vseg vmaIt
 	}
 	mm.activeMu.RUnlock()
 
-	fmt.Fprintf(b, "Size:           %8d kB\n", vseg.Range().Length()/1024)
-	fmt.Fprintf(b, "Rss:            %8d kB\n", rss/1024)
+	b := buf.AvailableBuffer()
+	b = appendSmapsField(b, "Size:           ", 8, uint64(vseg.Range().Length())/1024)
+	b = appendSmapsField(b, "Rss:            ", 8, rss/1024)
 	// Currently we report PSS = RSS, i.e. we pretend each page mapped by a pma
 	// is only mapped by that pma. This avoids having to query memmap.Mappables
 	// for reference count information on each page. As a corollary, all pages
 	// are accounted as "private" whether or not the vma is private; compare
 	// Linux's fs/proc/task_mmu.c:smaps_account().
-	fmt.Fprintf(b, "Pss:            %8d kB\n", rss/1024)
-	fmt.Fprintf(b, "Shared_Clean:   %8d kB\n", 0)
-	fmt.Fprintf(b, "Shared_Dirty:   %8d kB\n", 0)
+	b = appendSmapsField(b, "Pss:            ", 8, rss/1024)
+	b = appendSmapsField(b, "Shared_Clean:   ", 8, 0)
+	b = appendSmapsField(b, "Shared_Dirty:   ", 8, 0)
 	// Pretend that all pages are dirty if the vma is writable, and clean otherwise.
 	clean := rss
-	if vma.effectivePerms.Write {
+	if vma.realPerms.Write {
 		clean = 0
 	}
-	fmt.Fprintf(b, "Private_Clean:  %8d kB\n", clean/1024)
-	fmt.Fprintf(b, "Private_Dirty:  %8d kB\n", (rss-clean)/1024)
+	b = appendSmapsField(b, "Private_Clean:  ", 8, clean/1024)
+	b = appendSmapsField(b, "Private_Dirty:  ", 8, (rss-clean)/1024)
 	// Pretend that all pages are "referenced" (recently touched).
-	fmt.Fprintf(b, "Referenced:     %8d kB\n", rss/1024)
-	fmt.Fprintf(b, "Anonymous:      %8d kB\n", anon/1024)
+	b = appendSmapsField(b, "Referenced:     ", 8, rss/1024)
+	b = appendSmapsField(b, "Anonymous:      ", 8, anon/1024)
 	// Hugepages (hugetlb and THP) are not implemented.
-	fmt.Fprintf(b, "AnonHugePages:  %8d kB\n", 0)
-	fmt.Fprintf(b, "Shared_Hugetlb: %8d kB\n", 0)
-	fmt.Fprintf(b, "Private_Hugetlb: %7d kB\n", 0)
+	b = appendSmapsField(b, "AnonHugePages:  ", 8, 0)
+	b = appendSmapsField(b, "Shared_Hugetlb: ", 8, 0)
+	b = appendSmapsField(b, "Private_Hugetlb: ", 7, 0)
 	// Swap is not implemented.
-	fmt.Fprintf(b, "Swap:           %8d kB\n", 0)
-	fmt.Fprintf(b, "SwapPss:        %8d kB\n", 0)
-	fmt.Fprintf(b, "KernelPageSize: %8d kB\n", hostarch.PageSize/1024)
-	fmt.Fprintf(b, "MMUPageSize:    %8d kB\n", hostarch.PageSize/1024)
+	b = appendSmapsField(b, "Swap:           ", 8, 0)
+	b = appendSmapsField(b, "SwapPss:        ", 8, 0)
+	b = appendSmapsField(b, "KernelPageSize: ", 8, hostarch.PageSize/1024)
+	b = appendSmapsField(b, "MMUPageSize:    ", 8, hostarch.PageSize/1024)
 	locked := rss
 	if vma.mlockMode == memmap.MLockNone {
 		locked = 0
 	}
-	fmt.Fprintf(b, "Locked:         %8d kB\n", locked/1024)
+	b = appendSmapsField(b, "Locked:         ", 8, locked/1024)
 
-	b.WriteString("VmFlags: ")
+	b = append(b, "VmFlags: "...)
 	if vma.realPerms.Read {
-		b.WriteString("rd ")
+		b = append(b, "rd "...)
 	}
 	if vma.realPerms.Write {
-		b.WriteString("wr ")
+		b = append(b, "wr "...)
 	}
 	if vma.realPerms.Execute {
-		b.WriteString("ex ")
+		b = append(b, "ex "...)
 	}
 	if vma.canWriteMappableLocked() { // VM_SHARED
-		b.WriteString("sh ")
+		b = append(b, "sh "...)
 	}
 	if vma.maxPerms.Read {
-		b.WriteString("mr ")
+		b = append(b, "mr "...)
 	}
 	if vma.maxPerms.Write {
-		b.WriteString("mw ")
+		b = append(b, "mw "...)
 	}
 	if vma.maxPerms.Execute {
-		b.WriteString("me ")
+		b = append(b, "me "...)
 	}
 	if !vma.private { // VM_MAYSHARE
-		b.WriteString("ms ")
+		b = append(b, "ms "...)
 	}
 	if vma.growsDown {
-		b.WriteString("gd ")
+		b = append(b, "gd "...)
+	}
+	if vma.uffdMode&linux.UFFDIO_REGISTER_MODE_MISSING != 0 { // VM_UFFD_MISSING
+		b = append(b, "um "...)
+	}
+	if vma.uffdMode&linux.UFFDIO_REGISTER_MODE_WP != 0 { // VM_UFFD_WP
+		b = append(b, "uw "...)
 	}
 	if vma.mlockMode != memmap.MLockNone { // VM_LOCKED
-		b.WriteString("lo ")
+		b = append(b, "lo "...)
 	}
 	if vma.mlockMode == memmap.MLockLazy { // VM_LOCKONFAULT
-		b.WriteString("?? ") // no explicit encoding in fs/proc/task_mmu.c:show_smap_vma_flags()
+		b = append(b, "?? "...) // no explicit encoding in fs/proc/task_mmu.c:show_smap_vma_flags()
+	}
+	if vma.private && vma.realPerms.Write { // VM_ACCOUNT
+		b = append(b, "ac "...)
 	}
-	if vma.private && vma.effectivePerms.Write { // VM_ACCOUNT
-		b.WriteString("ac ")
+	if vma.uffdMode&linux.UFFDIO_REGISTER_MODE_MINOR != 0 { // VM_UFFD_MINOR
+		b = append(b, "ui "...)
 	}
-	b.WriteString("\n")
+	b = append(b, '\n')
+	buf.Write(b) // never returns a non-nil error
 }
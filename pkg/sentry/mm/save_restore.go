@@ -61,6 +61,7 @@ const (
 	vmaPrivate
 	vmaGrowsDown
 	vmaIsStack
+	vmaHugeCopy
 )
 
 func (v *vma) saveRealPerms() int {
@@ -101,6 +102,9 @@ func (v *vma) saveRealPerms() int {
 	if v.isStack {
 		b |= vmaIsStack
 	}
+	if v.hugeCopy {
+		b |= vmaHugeCopy
+	}
 	return b
 }
 
@@ -141,6 +145,9 @@ func (v *vma) loadRealPerms(_ goContext.Context, b int) {
 	if b&vmaIsStack > 0 {
 		v.isStack = true
 	}
+	if b&vmaHugeCopy > 0 {
+		v.hugeCopy = true
+	}
 }
 
 func (p *pma) saveFile() string {
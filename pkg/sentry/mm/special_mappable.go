@@ -125,3 +125,40 @@ func (m *SpecialMappable) FileRange() memmap.FileRange {
 func (m *SpecialMappable) Length() uint64 {
 	return m.fr.Length()
 }
+
+// ReplaceSpecialMappable replaces all mappings of from in mm with mappings of
+// to at the same addresses and with the same permissions. This is used to
+// switch the VDSO parameter page when a task changes time namespace.
+//
+// Preconditions: from.Length() == to.Length().
+func (mm *MemoryManager) ReplaceSpecialMappable(ctx context.Context, from, to *SpecialMappable) error {
+	var mappings []memmap.MMapOpts
+	mm.mappingMu.RLock()
+	for vseg := mm.vmas.FirstSegment(); vseg.Ok(); vseg = vseg.NextSegment() {
+		vma := vseg.ValuePtr()
+		if vma.mappable != from {
+			continue
+		}
+		ar := vseg.Range()
+		mappings = append(mappings, memmap.MMapOpts{
+			Length:          uint64(ar.Length()),
+			MappingIdentity: to,
+			Mappable:        to,
+			Offset:          vma.off,
+			Addr:            ar.Start,
+			Fixed:           true,
+			Unmap:           true,
+			Private:         vma.private,
+			Perms:           vma.realPerms,
+			MaxPerms:        vma.maxPerms,
+		})
+	}
+	mm.mappingMu.RUnlock()
+
+	for _, opts := range mappings {
+		if _, err := mm.MMap(ctx, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -39,8 +39,22 @@ func (mm *MemoryManager) HandleUserFault(ctx context.Context, addr hostarch.Addr
 		return linuxerr.EFAULT
 	}
 
-	// Don't bother trying existingPMAsLocked; in most cases, if we did have
-	// existing pmas, we wouldn't have faulted.
+	// If a usable pma already exists, we only need to map it into the
+	// AddressSpace, which requires only a read lock on activeMu and doesn't
+	// require mappingMu at all. This is the case when multiple threads fault
+	// on the same page concurrently, since only the first needs to create the
+	// pma, and when the AddressSpace mapping was invalidated without
+	// invalidating the pma (e.g. after the AddressSpace is reactivated, or if
+	// the platform maps less than an entire pma). Handling these faults
+	// without locking activeMu for writing avoids serializing them behind
+	// faults that need to create pmas.
+	mm.activeMu.RLock()
+	if pseg := mm.existingPMAsLocked(ar, at, false /* ignorePermissions */, false /* needInternalMappings */); pseg.Ok() {
+		err := mm.mapASLocked(ctx, pseg, ar, memmap.PlatformEffectDefault)
+		mm.activeMu.RUnlock()
+		return err
+	}
+	mm.activeMu.RUnlock()
 
 	// Ensure that we have a usable vma. Here and below, since we are only
 	// asking for a single page, there is no possibility of partial success,
@@ -48,18 +62,32 @@ func (mm *MemoryManager) HandleUserFault(ctx context.Context, addr hostarch.Addr
 	mm.mappingMu.RLock()
 	vseg, _, err := mm.getVMAsLocked(ctx, ar, at, false)
 	if err != nil {
+		// The access may have failed due to userfaultfd write-protection.
+		mm.activeMu.RLock()
+		u, f := mm.raiseUserfaultLocked(ctx, addr, at, false /* sentry */)
+		mm.activeMu.RUnlock()
 		mm.mappingMu.RUnlock()
+		if f != nil {
+			return mm.waitUserfault(ctx, u, f)
+		}
 		return err
 	}
 
 	// Ensure that we have a usable pma.
 	mm.activeMu.Lock()
 	pseg, _, err := mm.getPMAsLocked(ctx, vseg, ar, at, true /* callerIndirectCommit */)
-	mm.mappingMu.RUnlock()
 	if err != nil {
+		// getPMAsLocked fails to create pmas that must be populated by a
+		// userfaultfd handler.
+		u, f := mm.raiseUserfaultLocked(ctx, addr, at, false /* sentry */)
+		mm.mappingMu.RUnlock()
 		mm.activeMu.Unlock()
+		if f != nil {
+			return mm.waitUserfault(ctx, u, f)
+		}
 		return err
 	}
+	mm.mappingMu.RUnlock()
 
 	// Downgrade to a read-lock on activeMu since we don't need to mutate pmas
 	// anymore.
@@ -277,9 +305,12 @@ func (mm *MemoryManager) MapStack(ctx context.Context) (hostarch.AddrRange, erro
 	szaddr := hostarch.Addr(sz)
 	ctx.Debugf("Allocating stack with size of %v bytes", sz)
 
-	// Determine the stack's desired location. Unlike Linux, address
-	// randomization can't be disabled.
-	stackEnd := mm.layout.MaxAddr - hostarch.Addr(mrand.Int63n(int64(mm.layout.MaxStackRand))).RoundDown()
+	// Determine the stack's desired location. MaxStackRand is 0 if address
+	// randomization is disabled.
+	stackEnd := mm.layout.MaxAddr
+	if mm.layout.MaxStackRand != 0 {
+		stackEnd -= hostarch.Addr(mrand.Int63n(int64(mm.layout.MaxStackRand))).RoundDown()
+	}
 	if stackEnd < szaddr {
 		return hostarch.AddrRange{}, linuxerr.ENOMEM
 	}
@@ -466,6 +497,7 @@ func (mm *MemoryManager) MRemap(ctx context.Context, oldAddr hostarch.Addr, oldS
 			Private:         vma.private,
 			GrowsDown:       vma.growsDown,
 			Stack:           vma.isStack,
+			HugeCopy:        vma.hugeCopy,
 			MLockMode:       vma.mlockMode,
 			Name:            vma.name,
 			NameMut:         vma.nameMut,
@@ -568,6 +600,11 @@ func (mm *MemoryManager) MRemap(ctx context.Context, oldAddr hostarch.Addr, oldS
 		if vma.mappable != nil {
 			vma.off = vseg.mappableOffsetAt(oldAR.Start)
 		}
+		// UFFD_FEATURE_EVENT_REMAP is unsupported, so the new mapping is not
+		// registered with a userfaultfd. Since only private anonymous
+		// mappings can be write-protected, and they can't be copied, no pmas
+		// need to be updated.
+		vma.clearUserfault()
 		if vma.id != nil {
 			vma.id.IncRef()
 		}
@@ -599,6 +636,11 @@ func (mm *MemoryManager) MRemap(ctx context.Context, oldAddr hostarch.Addr, oldS
 	// overlapping oldAR.
 	vseg = mm.vmas.Isolate(vseg, oldAR)
 	vma := vseg.ValuePtr().copy()
+	// UFFD_FEATURE_EVENT_REMAP is unsupported, so the moved mapping is no
+	// longer registered with a userfaultfd; the permissions of its pmas are
+	// updated below.
+	uffdWP := vma.uffdWP
+	vma.clearUserfault()
 	mm.vmas.Remove(vseg)
 	vseg = mm.vmas.Insert(mm.vmas.FindGap(newAR.Start), newAR, vma)
 	mm.usageAS = mm.usageAS - uint64(oldAR.Length()) + uint64(newAR.Length())
@@ -614,6 +656,9 @@ func (mm *MemoryManager) MRemap(ctx context.Context, oldAddr hostarch.Addr, oldS
 	// for private pmas.
 	mm.activeMu.Lock()
 	mm.movePMAsLocked(oldAR, newAR)
+	if uffdWP {
+		mm.updateEffectivePermsLocked(vseg)
+	}
 	mm.activeMu.Unlock()
 
 	// Now that pmas have been moved to newAR, we can notify vma.mappable that
@@ -700,6 +745,9 @@ func (mm *MemoryManager) MProtect(addr hostarch.Addr, length uint64, realPerms h
 
 		vma.realPerms = realPerms
 		vma.effectivePerms = effectivePerms
+		if vma.uffdWP {
+			vma.effectivePerms.Write = false
+		}
 		if vma.isPrivateDataLocked() {
 			mm.dataAS += uint64(vmaLength)
 		}
@@ -709,13 +757,13 @@ func (mm *MemoryManager) MProtect(addr hostarch.Addr, length uint64, realPerms h
 			if pseg.Range().Overlaps(vseg.Range()) {
 				pseg = mm.pmas.Isolate(pseg, vseg.Range())
 				pma := pseg.ValuePtr()
-				if !effectivePerms.SupersetOf(pma.effectivePerms) && !didUnmapAS {
+				if !vma.effectivePerms.SupersetOf(pma.effectivePerms) && !didUnmapAS {
 					// Unmap all of ar, not just vseg.Range(), to minimize host
 					// syscalls.
 					mm.unmapASLocked(ar)
 					didUnmapAS = true
 				}
-				pma.effectivePerms = effectivePerms.Intersect(pma.translatePerms)
+				pma.effectivePerms = vma.effectivePerms.Intersect(pma.translatePerms)
 				if pma.needCOW {
 					pma.effectivePerms.Write = false
 				}
@@ -1395,14 +1443,25 @@ func (mm *MemoryManager) MSync(ctx context.Context, addr hostarch.Addr, length u
 		// It's only possible to have dirtied the Mappable through a shared
 		// mapping. Don't check if the mapping is writable, because mprotect
 		// may have changed this, and also because Linux doesn't.
-		if id := vma.id; opts.Sync && id != nil && vma.mappable != nil && !vma.private {
-			// We can't call memmap.MappingIdentity.Msync while holding
-			// mm.mappingMu since it may take fs locks that precede it in the
-			// lock order.
+		var cim memmap.CacheInvalidatingMappable
+		if opts.Invalidate && vma.mappable != nil && !vma.private {
+			cim, _ = vma.mappable.(memmap.CacheInvalidatingMappable)
+		}
+		if id := vma.id; (opts.Sync || cim != nil) && id != nil && vma.mappable != nil && !vma.private {
+			// We can't call memmap.MappingIdentity.Msync or
+			// memmap.CacheInvalidatingMappable.InvalidateCache while holding
+			// mm.mappingMu since they may take fs locks that precede it in
+			// the lock order. id holds a reference on the Mappable.
 			id.IncRef()
 			mr := vseg.mappableRangeOf(vseg.Range().Intersect(ar))
 			mm.mappingMu.RUnlock()
-			err := id.Msync(ctx, mr)
+			var err error
+			if opts.Sync {
+				err = id.Msync(ctx, mr)
+			}
+			if err == nil && cim != nil {
+				err = cim.InvalidateCache(ctx, mr)
+			}
 			id.DecRef(ctx)
 			if err != nil {
 				return err
@@ -1476,6 +1535,56 @@ func (mm *MemoryManager) VirtualMemorySizeRange(ar hostarch.AddrRange) uint64 {
 	return uint64(mm.vmas.SpanRange(ar))
 }
 
+// Mincore returns a slice containing one byte per page in ar, whose least
+// significant bit is set if the corresponding page is resident in memory.
+// Pages are resident if they are mapped by a pma in mm, or if they are
+// file-backed and present in the mapped file's cache.
+//
+// Preconditions: ar.Length() != 0. ar must be page-aligned.
+func (mm *MemoryManager) Mincore(ctx context.Context, ar hostarch.AddrRange) ([]byte, error) {
+	mm.mappingMu.RLock()
+	defer mm.mappingMu.RUnlock()
+
+	// "ENOMEM: addr to addr + length contained unmapped memory." - mincore(2)
+	if uint64(mm.vmas.SpanRange(ar)) != ar.Length() {
+		return nil, linuxerr.ENOMEM
+	}
+
+	vec := make([]byte, ar.Length()/hostarch.PageSize)
+	markResident := func(rar hostarch.AddrRange) {
+		rar = rar.Intersect(ar)
+		for i := (rar.Start - ar.Start) / hostarch.PageSize; i < (rar.End-ar.Start)/hostarch.PageSize; i++ {
+			vec[i] = 1
+		}
+	}
+
+	// Mappable locks precede mm.activeMu in the lock order, so query
+	// Mappables before locking it.
+	for vseg := mm.vmas.LowerBoundSegment(ar.Start); vseg.Ok() && vseg.Start() < ar.End; vseg = vseg.NextSegment() {
+		vma := vseg.ValuePtr()
+		if vma.mappable == nil {
+			continue
+		}
+		mr := vseg.mappableRangeOf(vseg.Range().Intersect(ar))
+		rm, ok := vma.mappable.(memmap.ResidencyMappable)
+		if !ok {
+			// Assume that the Mappable is always resident.
+			markResident(vseg.addrRangeOf(mr))
+			continue
+		}
+		rm.ForEachResidentRange(ctx, mr, func(rmr memmap.MappableRange) {
+			markResident(vseg.addrRangeOf(rmr))
+		})
+	}
+
+	mm.activeMu.RLock()
+	defer mm.activeMu.RUnlock()
+	for pseg := mm.pmas.LowerBoundSegment(ar.Start); pseg.Ok() && pseg.Start() < ar.End; pseg = pseg.NextSegment() {
+		markResident(pseg.Range())
+	}
+	return vec, nil
+}
+
 // ResidentSetSize returns the value advertised as mm's RSS in bytes.
 func (mm *MemoryManager) ResidentSetSize() uint64 {
 	mm.activeMu.RLock()
@@ -1521,6 +1630,18 @@ func (mm *MemoryManager) IsMembarrierRSeqEnabled() bool {
 	return mm.membarrierRSeqEnabled.Load() != 0
 }
 
+// EnableMembarrierSyncCore causes future calls to IsMembarrierSyncCoreEnabled
+// to return true.
+func (mm *MemoryManager) EnableMembarrierSyncCore() {
+	mm.membarrierSyncCoreEnabled.Store(1)
+}
+
+// IsMembarrierSyncCoreEnabled returns true if mm.EnableMembarrierSyncCore()
+// has previously been called.
+func (mm *MemoryManager) IsMembarrierSyncCoreEnabled() bool {
+	return mm.membarrierSyncCoreEnabled.Load() != 0
+}
+
 // FindVMAByName finds a vma with the specified name and returns its start address and offset.
 func (mm *MemoryManager) FindVMAByName(ar hostarch.AddrRange, name string) (hostarch.Addr, uint64, error) {
 	mm.mappingMu.RLock()
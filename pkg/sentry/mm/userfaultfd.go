@@ -0,0 +1,860 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mm
+
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/safemem"
+	"gvisor.dev/gvisor/pkg/sentry/memmap"
+	"gvisor.dev/gvisor/pkg/sentry/pgalloc"
+	"gvisor.dev/gvisor/pkg/sentry/usage"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	// userfaultfdFeatures is the set of userfaultfd features supported by
+	// Userfaultfd.
+	userfaultfdFeatures = linux.UFFD_FEATURE_PAGEFAULT_FLAG_WP | linux.UFFD_FEATURE_MINOR_SHMEM | linux.UFFD_FEATURE_EXACT_ADDRESS
+
+	// userfaultfdAPIIoctls is the set of ioctls supported by userfaultfds,
+	// as reported by UFFDIO_API.
+	userfaultfdAPIIoctls = 1<<linux.UFFDIO_REGISTER_NR | 1<<linux.UFFDIO_UNREGISTER_NR | 1<<linux.UFFDIO_API_NR
+
+	// userfaultfdRangeIoctls is the set of ioctls supported on registered
+	// ranges, as reported by UFFDIO_REGISTER.
+	userfaultfdRangeIoctls = 1<<linux.UFFDIO_WAKE_NR | 1<<linux.UFFDIO_COPY_NR | 1<<linux.UFFDIO_ZEROPAGE_NR | 1<<linux.UFFDIO_WRITEPROTECT_NR | 1<<linux.UFFDIO_CONTINUE_NR
+
+	// userfaultfdModes is the set of supported registration modes.
+	userfaultfdModes = linux.UFFDIO_REGISTER_MODE_MISSING | linux.UFFDIO_REGISTER_MODE_WP | linux.UFFDIO_REGISTER_MODE_MINOR
+)
+
+// Userfaultfd implements the memory management half of userfaultfd(2): faults
+// on vmas registered with a Userfaultfd are queued as messages for a handler,
+// and the faulting thread blocks until the handler resolves the fault.
+//
+// The following registration modes are supported:
+//
+//   - UFFDIO_REGISTER_MODE_MISSING, on private anonymous mappings. Accesses to
+//     pages without pmas raise faults, which are resolved by UFFDIO_COPY or
+//     UFFDIO_ZEROPAGE.
+//
+//   - UFFDIO_REGISTER_MODE_WP, on private anonymous mappings. Write-protection
+//     is tracked per vma, so UFFDIO_WRITEPROTECT splits vmas, and also applies
+//     to pages without pmas (as with Linux's UFFD_FEATURE_WP_UNPOPULATED).
+//     Writes raise faults, which are resolved by UFFDIO_WRITEPROTECT.
+//
+//   - UFFDIO_REGISTER_MODE_MINOR, on shmem mappings (see
+//     memmap.ShmemMappable). Accesses to resident pages without pmas raise
+//     faults, which are resolved by UFFDIO_CONTINUE.
+//
+// Faults raised by sentry accesses to application memory (e.g. in syscalls)
+// are handled like application faults, unless the Userfaultfd was created
+// with UFFD_USER_MODE_ONLY, in which case the access fails with EFAULT.
+// Accesses that ignore permissions (e.g. ptrace(PTRACE_PEEKDATA)) also fail
+// with EFAULT rather than raising faults.
+//
+// +stateify savable
+type Userfaultfd struct {
+	// mm is the MemoryManager whose faults are handled. mm is immutable.
+	mm *MemoryManager
+
+	// userModeOnly is true if sentry accesses fail rather than raising
+	// faults. userModeOnly is immutable.
+	userModeOnly bool
+
+	// queue is notified when messages become available to read.
+	queue waiter.Queue
+
+	// mu protects the following fields.
+	mu userfaultfdMutex `state:"nosave"`
+
+	// features is the set of linux.UFFD_FEATURE_* enabled by UFFDIO_API.
+	features uint64
+
+	// ready is true after a successful UFFDIO_API.
+	ready bool
+
+	// released is true after Release.
+	released bool
+
+	// faults are the faults that have not been woken, in the order in which
+	// they were raised. Faulting threads are interrupted for checkpointing
+	// and raise their faults again after restore, so faults is not saved.
+	faults []*userfault `state:"nosave"`
+}
+
+// userfault is a fault raised on a Userfaultfd.
+type userfault struct {
+	// addr is the address of the faulting page.
+	addr hostarch.Addr
+
+	// msg is the message describing the fault.
+	msg linux.UffdMsg
+
+	// read is true if msg has been read by the handler.
+	read bool
+
+	// done is closed when the fault is woken.
+	done chan struct{}
+}
+
+// NewUserfaultfd returns a Userfaultfd that handles faults in mm.
+func (mm *MemoryManager) NewUserfaultfd(userModeOnly bool) *Userfaultfd {
+	return &Userfaultfd{
+		mm:           mm,
+		userModeOnly: userModeOnly,
+	}
+}
+
+// API implements UFFDIO_API. It returns the supported features and ioctls.
+func (u *Userfaultfd) API(api, features uint64) (uint64, uint64, error) {
+	if api != linux.UFFD_API || features&^userfaultfdFeatures != 0 {
+		return 0, 0, linuxerr.EINVAL
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.ready {
+		return 0, 0, linuxerr.EINVAL
+	}
+	u.features = features
+	u.ready = true
+	return userfaultfdFeatures, userfaultfdAPIIoctls, nil
+}
+
+// Register implements UFFDIO_REGISTER. It returns the ioctls supported on the
+// registered range.
+func (u *Userfaultfd) Register(ctx context.Context, start, length, mode uint64) (uint64, error) {
+	if mode == 0 || mode&^userfaultfdModes != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	ar, err := u.checkRange(start, length)
+	if err != nil {
+		return 0, err
+	}
+	mm := u.mm
+	if !mm.IncUsers() {
+		return 0, linuxerr.ESRCH
+	}
+	defer mm.DecUsers(ctx)
+
+	mm.mappingMu.Lock()
+	defer mm.mappingMu.Unlock()
+	vseg := mm.vmas.LowerBoundSegment(ar.Start)
+	if !vseg.Ok() || vseg.Start() >= ar.End {
+		return 0, linuxerr.EINVAL
+	}
+	// Check all vmas before changing any, for consistency with Linux.
+	for vs := vseg; vs.Ok() && vs.Start() < ar.End; vs = vs.NextSegment() {
+		vma := vs.ValuePtr()
+		if !vma.canUserfault(mode) {
+			return 0, linuxerr.EINVAL
+		}
+		if !vma.maxPerms.Write {
+			return 0, linuxerr.EPERM
+		}
+		if vma.uffd != nil && vma.uffd != u {
+			return 0, linuxerr.EBUSY
+		}
+	}
+
+	mm.activeMu.Lock()
+	defer mm.activeMu.Unlock()
+	defer func() {
+		mm.vmas.MergeInsideRange(ar)
+		mm.vmas.MergeOutsideRange(ar)
+		mm.pmas.MergeInsideRange(ar)
+		mm.pmas.MergeOutsideRange(ar)
+	}()
+	for vseg.Ok() && vseg.Start() < ar.End {
+		vseg = mm.vmas.Isolate(vseg, ar)
+		vma := vseg.ValuePtr()
+		vma.uffd = u
+		vma.uffdMode = mode
+		if vma.uffdWP && mode&linux.UFFDIO_REGISTER_MODE_WP == 0 {
+			vma.uffdWP = false
+			mm.updateEffectivePermsLocked(vseg)
+		}
+		vseg = vseg.NextSegment()
+	}
+	mm.userfaultfdRegistered.Store(true)
+
+	ioctls := uint64(userfaultfdRangeIoctls)
+	if mode&linux.UFFDIO_REGISTER_MODE_WP == 0 {
+		ioctls &^= 1 << linux.UFFDIO_WRITEPROTECT_NR
+	}
+	if mode&linux.UFFDIO_REGISTER_MODE_MINOR == 0 {
+		ioctls &^= 1 << linux.UFFDIO_CONTINUE_NR
+	}
+	return ioctls, nil
+}
+
+// Unregister implements UFFDIO_UNREGISTER.
+func (u *Userfaultfd) Unregister(ctx context.Context, start, length uint64) error {
+	ar, err := u.checkRange(start, length)
+	if err != nil {
+		return err
+	}
+	mm := u.mm
+	if !mm.IncUsers() {
+		return linuxerr.ESRCH
+	}
+	defer mm.DecUsers(ctx)
+
+	mm.mappingMu.Lock()
+	mm.activeMu.Lock()
+	for vseg := mm.vmas.LowerBoundSegment(ar.Start); vseg.Ok() && vseg.Start() < ar.End; vseg = vseg.NextSegment() {
+		if vseg.ValuePtr().uffd != u {
+			continue
+		}
+		vseg = mm.vmas.Isolate(vseg, ar)
+		mm.clearUserfaultLocked(vseg)
+	}
+	mm.vmas.MergeInsideRange(ar)
+	mm.vmas.MergeOutsideRange(ar)
+	mm.pmas.MergeInsideRange(ar)
+	mm.pmas.MergeOutsideRange(ar)
+	mm.activeMu.Unlock()
+	mm.mappingMu.Unlock()
+
+	// Faults in ar will no longer be handled, so let them proceed.
+	u.wake(ar)
+	return nil
+}
+
+// Wake implements UFFDIO_WAKE.
+func (u *Userfaultfd) Wake(start, length uint64) error {
+	ar, err := u.checkRange(start, length)
+	if err != nil {
+		return err
+	}
+	u.wake(ar)
+	return nil
+}
+
+// Copy implements UFFDIO_COPY for a page-aligned chunk of the copied range:
+// it maps pages containing data at dst, which must be in a range registered
+// in UFFDIO_REGISTER_MODE_MISSING. It returns the number of bytes mapped.
+//
+// Preconditions: len(data) is a non-zero multiple of hostarch.PageSize.
+func (u *Userfaultfd) Copy(ctx context.Context, dst uint64, data []byte, mode uint64) (int64, error) {
+	if mode&^(linux.UFFDIO_COPY_MODE_DONTWAKE|linux.UFFDIO_COPY_MODE_WP) != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	return u.fill(ctx, dst, uint64(len(data)), data, mode&linux.UFFDIO_COPY_MODE_WP != 0, mode&linux.UFFDIO_COPY_MODE_DONTWAKE == 0)
+}
+
+// ZeroPage implements UFFDIO_ZEROPAGE. It returns the number of bytes mapped.
+func (u *Userfaultfd) ZeroPage(ctx context.Context, start, length, mode uint64) (int64, error) {
+	if mode&^linux.UFFDIO_ZEROPAGE_MODE_DONTWAKE != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	return u.fill(ctx, start, length, nil, false /* wp */, mode&linux.UFFDIO_ZEROPAGE_MODE_DONTWAKE == 0)
+}
+
+// fill maps new private anonymous pages at [start, start+length), which
+// must be in a range registered in UFFDIO_REGISTER_MODE_MISSING. The pages
+// contain data if it is not nil, and are zeroed otherwise.
+func (u *Userfaultfd) fill(ctx context.Context, start, length uint64, data []byte, wp, wake bool) (int64, error) {
+	ar, err := u.checkRange(start, length)
+	if err != nil {
+		return 0, err
+	}
+	mm := u.mm
+	if !mm.IncUsers() {
+		return 0, linuxerr.ESRCH
+	}
+	defer mm.DecUsers(ctx)
+
+	memCgID := pgalloc.MemoryCgroupIDFromContext(ctx)
+	var n int64
+	mm.mappingMu.Lock()
+	mm.activeMu.Lock()
+	for addr := ar.Start; addr < ar.End; {
+		vseg := mm.vmas.FindSegment(addr)
+		if !vseg.Ok() {
+			err = linuxerr.ENOENT
+			break
+		}
+		vma := vseg.ValuePtr()
+		if vma.uffd != u || vma.uffdMode&linux.UFFDIO_REGISTER_MODE_MISSING == 0 {
+			err = linuxerr.ENOENT
+			break
+		}
+		if wp && vma.uffdMode&linux.UFFDIO_REGISTER_MODE_WP == 0 {
+			err = linuxerr.EINVAL
+			break
+		}
+		pseg, pgap := mm.pmas.Find(addr)
+		if pseg.Ok() {
+			err = linuxerr.EEXIST
+			break
+		}
+		fillAR := ar.Intersect(vseg.Range()).Intersect(pgap.Range())
+		fillAR.Start = addr
+		if wp != vma.uffdWP {
+			vseg = mm.setUserfaultWPLocked(vseg, fillAR, wp)
+			vma = vseg.ValuePtr()
+			pgap = mm.pmas.FindGap(addr)
+		}
+		opts := pgalloc.AllocOpts{
+			Kind:    usage.Anonymous,
+			MemCgID: memCgID,
+			Mode:    pgalloc.AllocateUncommitted,
+		}
+		if data != nil {
+			off := uint64(addr - ar.Start)
+			reader := safemem.BlockSeqReader{Blocks: safemem.BlockSeqOf(safemem.BlockFromSafeSlice(data[off : off+uint64(fillAR.Length())]))}
+			opts.Mode = pgalloc.AllocateAndWritePopulate
+			opts.ReaderFunc = reader.ReadToBlocks
+		}
+		fr, aerr := mm.mf.Allocate(uint64(fillAR.Length()), opts)
+		if aerr != nil {
+			err = aerr
+			break
+		}
+		mm.addRSSLocked(fillAR)
+		mm.pmas.Insert(pgap, fillAR, pma{
+			file:           mm.mf,
+			off:            fr.Start,
+			translatePerms: hostarch.AnyAccess,
+			effectivePerms: vma.effectivePerms,
+			maxPerms:       vma.maxPerms,
+			private:        true,
+		})
+		n += int64(fillAR.Length())
+		addr = fillAR.End
+	}
+	mm.vmas.MergeInsideRange(ar)
+	mm.vmas.MergeOutsideRange(ar)
+	mm.activeMu.Unlock()
+	mm.mappingMu.Unlock()
+
+	if n != 0 && wake {
+		u.wake(hostarch.AddrRange{ar.Start, ar.Start + hostarch.Addr(n)})
+	}
+	if n != 0 {
+		return n, nil
+	}
+	return 0, err
+}
+
+// WriteProtect implements UFFDIO_WRITEPROTECT.
+func (u *Userfaultfd) WriteProtect(ctx context.Context, start, length, mode uint64) error {
+	if mode&^(linux.UFFDIO_WRITEPROTECT_MODE_WP|linux.UFFDIO_WRITEPROTECT_MODE_DONTWAKE) != 0 {
+		return linuxerr.EINVAL
+	}
+	wp := mode&linux.UFFDIO_WRITEPROTECT_MODE_WP != 0
+	dontWake := mode&linux.UFFDIO_WRITEPROTECT_MODE_DONTWAKE != 0
+	if wp && dontWake {
+		return linuxerr.EINVAL
+	}
+	ar, err := u.checkRange(start, length)
+	if err != nil {
+		return err
+	}
+	mm := u.mm
+	if !mm.IncUsers() {
+		return linuxerr.ESRCH
+	}
+	defer mm.DecUsers(ctx)
+
+	mm.mappingMu.Lock()
+	// Check that all of ar is registered before changing any of it.
+	vseg := mm.vmas.FindSegment(ar.Start)
+	for vs, pos := vseg, ar.Start; pos < ar.End; vs = vs.NextSegment() {
+		if !vs.Ok() || vs.Start() > pos {
+			mm.mappingMu.Unlock()
+			return linuxerr.ENOENT
+		}
+		if vma := vs.ValuePtr(); vma.uffd != u || vma.uffdMode&linux.UFFDIO_REGISTER_MODE_WP == 0 {
+			mm.mappingMu.Unlock()
+			return linuxerr.ENOENT
+		}
+		pos = vs.End()
+	}
+	mm.activeMu.Lock()
+	for vseg.Ok() && vseg.Start() < ar.End {
+		vseg = mm.setUserfaultWPLocked(vseg, ar, wp).NextSegment()
+	}
+	mm.vmas.MergeInsideRange(ar)
+	mm.vmas.MergeOutsideRange(ar)
+	mm.pmas.MergeInsideRange(ar)
+	mm.pmas.MergeOutsideRange(ar)
+	mm.activeMu.Unlock()
+	mm.mappingMu.Unlock()
+
+	if !wp && !dontWake {
+		u.wake(ar)
+	}
+	return nil
+}
+
+// Continue implements UFFDIO_CONTINUE: it maps resident pages of the shmem
+// mapped at [start, start+length), which must be in a range registered in
+// UFFDIO_REGISTER_MODE_MINOR. It returns the number of bytes mapped.
+func (u *Userfaultfd) Continue(ctx context.Context, start, length, mode uint64) (int64, error) {
+	if mode&^(linux.UFFDIO_CONTINUE_MODE_DONTWAKE|linux.UFFDIO_CONTINUE_MODE_WP) != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	// Write-protection is only supported on private anonymous mappings, which
+	// can't be registered in UFFDIO_REGISTER_MODE_MINOR.
+	if mode&linux.UFFDIO_CONTINUE_MODE_WP != 0 {
+		return 0, linuxerr.EINVAL
+	}
+	ar, err := u.checkRange(start, length)
+	if err != nil {
+		return 0, err
+	}
+	mm := u.mm
+	if !mm.IncUsers() {
+		return 0, linuxerr.ESRCH
+	}
+	defer mm.DecUsers(ctx)
+
+	memCgID := pgalloc.MemoryCgroupIDFromContext(ctx)
+	var n int64
+	mm.mappingMu.RLock()
+	mm.activeMu.Lock()
+	for addr := ar.Start; addr < ar.End; {
+		vseg := mm.vmas.FindSegment(addr)
+		if !vseg.Ok() {
+			err = linuxerr.ENOENT
+			break
+		}
+		vma := vseg.ValuePtr()
+		if vma.uffd != u || vma.uffdMode&linux.UFFDIO_REGISTER_MODE_MINOR == 0 {
+			err = linuxerr.ENOENT
+			break
+		}
+		pseg, pgap := mm.pmas.Find(addr)
+		if pseg.Ok() {
+			err = linuxerr.EEXIST
+			break
+		}
+		fillAR := ar.Intersect(vseg.Range()).Intersect(pgap.Range())
+		fillAR.Start = addr
+		// Only map pages that are already resident; the handler is
+		// responsible for populating the shmem through another mapping.
+		uaddr, _, ok := vseg.firstUserfaultLocked(ctx, fillAR)
+		if !ok || uaddr != fillAR.Start {
+			err = linuxerr.EFAULT
+			break
+		}
+		fillAR.End = vseg.residentEndLocked(ctx, fillAR)
+		mr := vseg.mappableRangeOf(fillAR)
+		// Don't require write permission, as for private mappings in
+		// getPMAsInternalLocked; writes will break copy-on-write or fault
+		// again as usual.
+		ts, terr := vma.mappable.Translate(ctx, mr, mr, hostarch.Read)
+		if checkInvariants {
+			if err := memmap.CheckTranslateResult(mr, mr, hostarch.Read, ts, terr); err != nil {
+				panic(fmt.Sprintf("Mappable(%T).Translate(%v, %v, %v): %v", vma.mappable, mr, mr, hostarch.Read, err))
+			}
+		}
+		for _, t := range ts {
+			newpmaAR := vseg.addrRangeOf(t.Source)
+			newpma := pma{
+				file:           t.File,
+				off:            t.Offset,
+				translatePerms: t.Perms,
+				effectivePerms: vma.effectivePerms.Intersect(t.Perms),
+				maxPerms:       vma.maxPerms.Intersect(t.Perms),
+			}
+			if vma.private {
+				newpma.effectivePerms.Write = false
+				newpma.maxPerms.Write = false
+				newpma.needCOW = true
+			}
+			mm.addRSSLocked(newpmaAR)
+			t.File.IncRef(t.FileRange(), memCgID)
+			pgap = mm.pmas.Insert(pgap, newpmaAR, newpma).NextGap()
+			n += int64(newpmaAR.Length())
+			addr = newpmaAR.End
+		}
+		if terr != nil {
+			err = terr
+			break
+		}
+	}
+	mm.activeMu.Unlock()
+	mm.mappingMu.RUnlock()
+
+	if n != 0 && mode&linux.UFFDIO_CONTINUE_MODE_DONTWAKE == 0 {
+		u.wake(hostarch.AddrRange{ar.Start, ar.Start + hostarch.Addr(n)})
+	}
+	if n != 0 {
+		return n, nil
+	}
+	return 0, err
+}
+
+// ReadMessage returns the oldest message that has not yet been read. If no
+// such message exists, it returns linuxerr.ErrWouldBlock.
+func (u *Userfaultfd) ReadMessage() (linux.UffdMsg, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.ready {
+		return linux.UffdMsg{}, linuxerr.EINVAL
+	}
+	for _, f := range u.faults {
+		if !f.read {
+			f.read = true
+			return f.msg, nil
+		}
+	}
+	return linux.UffdMsg{}, linuxerr.ErrWouldBlock
+}
+
+// Readiness implements waiter.Waitable.Readiness.
+func (u *Userfaultfd) Readiness(mask waiter.EventMask) waiter.EventMask {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.ready {
+		return mask & waiter.EventErr
+	}
+	for _, f := range u.faults {
+		if !f.read {
+			return mask & waiter.ReadableEvents
+		}
+	}
+	return 0
+}
+
+// EventRegister implements waiter.Waitable.EventRegister.
+func (u *Userfaultfd) EventRegister(e *waiter.Entry) error {
+	u.queue.EventRegister(e)
+	return nil
+}
+
+// EventUnregister implements waiter.Waitable.EventUnregister.
+func (u *Userfaultfd) EventUnregister(e *waiter.Entry) {
+	u.queue.EventUnregister(e)
+}
+
+// Release unregisters all vmas registered with u, and wakes all faults raised
+// on it.
+func (u *Userfaultfd) Release(ctx context.Context) {
+	if mm := u.mm; mm.IncUsers() {
+		mm.mappingMu.Lock()
+		mm.activeMu.Lock()
+		for vseg := mm.vmas.FirstSegment(); vseg.Ok(); vseg = vseg.NextSegment() {
+			if vseg.ValuePtr().uffd == u {
+				mm.clearUserfaultLocked(vseg)
+			}
+		}
+		mm.vmas.MergeInsideRange(mm.applicationAddrRange())
+		mm.pmas.MergeInsideRange(mm.applicationAddrRange())
+		mm.activeMu.Unlock()
+		mm.mappingMu.Unlock()
+		mm.DecUsers(ctx)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.released = true
+	for _, f := range u.faults {
+		close(f.done)
+	}
+	u.faults = nil
+}
+
+// checkRange returns the range [start, start+length), or EINVAL if it is not
+// a valid range of application addresses.
+func (u *Userfaultfd) checkRange(start, length uint64) (hostarch.AddrRange, error) {
+	u.mu.Lock()
+	ready := u.ready
+	u.mu.Unlock()
+	if !ready {
+		return hostarch.AddrRange{}, linuxerr.EINVAL
+	}
+	if length == 0 || start%hostarch.PageSize != 0 || length%hostarch.PageSize != 0 {
+		return hostarch.AddrRange{}, linuxerr.EINVAL
+	}
+	ar, ok := hostarch.Addr(start).ToRange(length)
+	if !ok || !u.mm.applicationAddrRange().IsSupersetOf(ar) {
+		return hostarch.AddrRange{}, linuxerr.EINVAL
+	}
+	return ar, nil
+}
+
+// raise queues a fault at addr with the given linux.UFFD_PAGEFAULT_FLAG_*
+// flags. It returns nil if u has been released.
+//
+// Preconditions: The caller must hold locks that prevent the fault from being
+// resolved before raise returns, i.e. mm.mappingMu or mm.activeMu.
+func (u *Userfaultfd) raise(addr hostarch.Addr, flags uint64) *userfault {
+	u.mu.Lock()
+	if u.released {
+		u.mu.Unlock()
+		return nil
+	}
+	f := &userfault{
+		addr: addr.RoundDown(),
+		msg: linux.UffdMsg{
+			Event:   linux.UFFD_EVENT_PAGEFAULT,
+			Flags:   flags,
+			Address: uint64(addr.RoundDown()),
+		},
+		done: make(chan struct{}),
+	}
+	if u.features&linux.UFFD_FEATURE_EXACT_ADDRESS != 0 {
+		f.msg.Address = uint64(addr)
+	}
+	u.faults = append(u.faults, f)
+	u.mu.Unlock()
+	u.queue.Notify(waiter.ReadableEvents)
+	return f
+}
+
+// wait blocks until f is woken.
+func (u *Userfaultfd) wait(ctx context.Context, f *userfault) error {
+	if err := ctx.Block(f.done); err != nil {
+		// Forget f; if the access is retried, it will raise a new fault.
+		u.mu.Lock()
+		for i, f2 := range u.faults {
+			if f2 == f {
+				u.faults = append(u.faults[:i], u.faults[i+1:]...)
+				break
+			}
+		}
+		u.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// wake wakes all faults in ar.
+func (u *Userfaultfd) wake(ar hostarch.AddrRange) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	faults := u.faults[:0]
+	for _, f := range u.faults {
+		if ar.Contains(f.addr) {
+			close(f.done)
+		} else {
+			faults = append(faults, f)
+		}
+	}
+	clear(u.faults[len(faults):])
+	u.faults = faults
+}
+
+// raiseUserfaultLocked raises the fault, if any, caused by an access of type
+// at to addr on the Userfaultfd with which the vma containing addr is
+// registered. If sentry is true, the access is made by the sentry rather than
+// the application.
+//
+// Preconditions:
+//   - mm.mappingMu must be locked.
+//   - mm.activeMu must be locked.
+func (mm *MemoryManager) raiseUserfaultLocked(ctx context.Context, addr hostarch.Addr, at hostarch.AccessType, sentry bool) (*Userfaultfd, *userfault) {
+	vseg := mm.vmas.FindSegment(addr)
+	if !vseg.Ok() {
+		return nil, nil
+	}
+	vma := vseg.ValuePtr()
+	u := vma.uffd
+	if u == nil || (sentry && u.userModeOnly) {
+		return nil, nil
+	}
+	// Accesses that aren't permitted by the application's permissions fail as
+	// usual.
+	if !vma.realPerms.Effective().SupersetOf(at) {
+		return nil, nil
+	}
+	var flags uint64
+	if at.Write {
+		flags |= linux.UFFD_PAGEFAULT_FLAG_WRITE
+	}
+	// As in Linux, missing and minor faults take precedence over
+	// write-protect faults.
+	pageAR := hostarch.AddrRange{addr.RoundDown(), addr.RoundDown() + hostarch.PageSize}
+	if !mm.pmas.FindSegment(pageAR.Start).Ok() {
+		if uaddr, uflags, ok := vseg.firstUserfaultLocked(ctx, pageAR); ok && uaddr == pageAR.Start {
+			return u, u.raise(addr, flags|uflags)
+		}
+	}
+	if at.Write && vma.uffdWP {
+		return u, u.raise(addr, flags|linux.UFFD_PAGEFAULT_FLAG_WP)
+	}
+	return nil, nil
+}
+
+// waitUserfault blocks until f, which was raised by an application access,
+// is woken. The access should then be retried, so waitUserfault returns nil
+// even if it is interrupted; in that case, the interruption is handled and
+// the access raises a new fault.
+func (mm *MemoryManager) waitUserfault(ctx context.Context, u *Userfaultfd, f *userfault) error {
+	u.wait(ctx, f)
+	return nil
+}
+
+// handleSentryUserfault handles the fault, if any, caused by a sentry access
+// of type at to addr on a Userfaultfd. It returns true if the access should be
+// retried.
+func (mm *MemoryManager) handleSentryUserfault(ctx context.Context, addr hostarch.Addr, at hostarch.AccessType, ignorePermissions bool) (bool, error) {
+	if ignorePermissions || !mm.userfaultfdRegistered.Load() {
+		return false, nil
+	}
+	mm.mappingMu.RLock()
+	mm.activeMu.RLock()
+	u, f := mm.raiseUserfaultLocked(ctx, addr, at, true /* sentry */)
+	mm.activeMu.RUnlock()
+	mm.mappingMu.RUnlock()
+	if f == nil {
+		return false, nil
+	}
+	if err := u.wait(ctx, f); err != nil {
+		// Restart the syscall, if any, after the interruption (e.g. for
+		// checkpointing) is handled.
+		return false, linuxerr.ERESTARTSYS
+	}
+	return true, nil
+}
+
+// canUserfault returns true if v may be registered with a Userfaultfd in the
+// given mode.
+func (v *vma) canUserfault(mode uint64) bool {
+	if mode&(linux.UFFDIO_REGISTER_MODE_MISSING|linux.UFFDIO_REGISTER_MODE_WP) != 0 && v.mappable != nil {
+		return false
+	}
+	if mode&linux.UFFDIO_REGISTER_MODE_MINOR != 0 {
+		if _, ok := v.mappable.(memmap.ShmemMappable); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// firstUserfaultLocked returns the first address in ar at which an access
+// raises a missing or minor fault on the Userfaultfd with which the vma is
+// registered, and the linux.UFFD_PAGEFAULT_FLAG_* flags of the fault.
+//
+// Preconditions:
+//   - mm.mappingMu must be locked.
+//   - vseg.Range().IsSupersetOf(ar).
+//   - No pmas exist in ar.
+func (vseg vmaIterator) firstUserfaultLocked(ctx context.Context, ar hostarch.AddrRange) (hostarch.Addr, uint64, bool) {
+	vma := vseg.ValuePtr()
+	if vma.uffd == nil {
+		return 0, 0, false
+	}
+	if vma.uffdMode&linux.UFFDIO_REGISTER_MODE_MISSING != 0 && vma.mappable == nil {
+		return ar.Start, 0, true
+	}
+	if vma.uffdMode&linux.UFFDIO_REGISTER_MODE_MINOR != 0 {
+		if sm, ok := vma.mappable.(memmap.ShmemMappable); ok {
+			var (
+				first hostarch.Addr
+				found bool
+			)
+			sm.ForEachResidentRange(ctx, vseg.mappableRangeOf(ar), func(mr memmap.MappableRange) {
+				if !found {
+					first = vseg.addrRangeOf(mr).Start
+					found = true
+				}
+			})
+			return first, linux.UFFD_PAGEFAULT_FLAG_MINOR, found
+		}
+	}
+	return 0, 0, false
+}
+
+// residentEndLocked returns the end of the resident prefix of ar in the
+// ShmemMappable mapped by the vma.
+//
+// Preconditions:
+//   - mm.mappingMu must be locked.
+//   - vseg.Range().IsSupersetOf(ar).
+//   - vseg.ValuePtr().mappable is a memmap.ShmemMappable.
+func (vseg vmaIterator) residentEndLocked(ctx context.Context, ar hostarch.AddrRange) hostarch.Addr {
+	end := ar.Start
+	vseg.ValuePtr().mappable.(memmap.ShmemMappable).ForEachResidentRange(ctx, vseg.mappableRangeOf(ar), func(mr memmap.MappableRange) {
+		if rar := vseg.addrRangeOf(mr); rar.Start == end {
+			end = rar.End
+		}
+	})
+	return end
+}
+
+// setUserfaultWPLocked sets the write-protection of the part of the vma at
+// vseg that overlaps ar, and returns an iterator to that part.
+//
+// Preconditions:
+//   - mm.mappingMu must be locked for writing.
+//   - mm.activeMu must be locked for writing.
+func (mm *MemoryManager) setUserfaultWPLocked(vseg vmaIterator, ar hostarch.AddrRange, wp bool) vmaIterator {
+	if vseg.ValuePtr().uffdWP == wp {
+		return vseg
+	}
+	vseg = mm.vmas.Isolate(vseg, ar)
+	vseg.ValuePtr().uffdWP = wp
+	mm.updateEffectivePermsLocked(vseg)
+	return vseg
+}
+
+// clearUserfaultLocked unregisters the vma at vseg from its Userfaultfd.
+//
+// Preconditions:
+//   - mm.mappingMu must be locked for writing.
+//   - mm.activeMu must be locked for writing.
+func (mm *MemoryManager) clearUserfaultLocked(vseg vmaIterator) {
+	vma := vseg.ValuePtr()
+	wp := vma.uffdWP
+	vma.clearUserfault()
+	if wp {
+		mm.updateEffectivePermsLocked(vseg)
+	}
+}
+
+// clearUserfault unregisters v from its Userfaultfd, without updating pmas.
+func (v *vma) clearUserfault() {
+	v.uffd = nil
+	v.uffdMode = 0
+	v.uffdWP = false
+	v.effectivePerms = v.realPerms.Effective()
+}
+
+// updateEffectivePermsLocked recomputes the effective permissions of the vma
+// at vseg, and of the pmas that map it, after a change to vma.uffdWP.
+//
+// Preconditions:
+//   - mm.mappingMu must be locked for writing.
+//   - mm.activeMu must be locked for writing.
+func (mm *MemoryManager) updateEffectivePermsLocked(vseg vmaIterator) {
+	vma := vseg.ValuePtr()
+	perms := vma.realPerms.Effective()
+	if vma.uffdWP {
+		perms.Write = false
+	}
+	vma.effectivePerms = perms
+	vsegAR := vseg.Range()
+	for pseg := mm.pmas.LowerBoundSegment(vsegAR.Start); pseg.Ok() && pseg.Start() < vsegAR.End; pseg = pseg.NextSegment() {
+		pseg = mm.pmas.Isolate(pseg, vsegAR)
+		pma := pseg.ValuePtr()
+		if !perms.SupersetOf(pma.effectivePerms) {
+			mm.unmapASLocked(pseg.Range())
+		}
+		pma.effectivePerms = perms.Intersect(pma.translatePerms)
+		if pma.needCOW {
+			pma.effectivePerms.Write = false
+		}
+	}
+}
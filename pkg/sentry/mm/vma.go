@@ -123,6 +123,7 @@ func (mm *MemoryManager) createVMALocked(ctx context.Context, opts memmap.MMapOp
 		private:        opts.Private,
 		growsDown:      opts.GrowsDown,
 		isStack:        opts.Stack,
+		hugeCopy:       opts.HugeCopy && opts.Private,
 		mlockMode:      opts.MLockMode,
 		numaPolicy:     linux.MPOL_DEFAULT,
 		id:             opts.MappingIdentity,
@@ -457,6 +458,7 @@ func (vmaSetFunctions) ClearValue(vma *vma) {
 	vma.mappable = nil
 	vma.id = nil
 	vma.name = ""
+	vma.uffd = nil
 	atomic.StoreUintptr(&vma.lastFault, 0)
 }
 
@@ -468,13 +470,17 @@ func (vmaSetFunctions) Merge(ar1 hostarch.AddrRange, vma1 vma, ar2 hostarch.Addr
 		vma1.private != vma2.private ||
 		vma1.growsDown != vma2.growsDown ||
 		vma1.isStack != vma2.isStack ||
+		vma1.hugeCopy != vma2.hugeCopy ||
 		vma1.mlockMode != vma2.mlockMode ||
 		vma1.numaPolicy != vma2.numaPolicy ||
 		vma1.numaNodemask != vma2.numaNodemask ||
 		vma1.dontfork != vma2.dontfork ||
 		vma1.id != vma2.id ||
 		vma1.name != vma2.name ||
-		vma1.nameMut != vma2.nameMut {
+		vma1.nameMut != vma2.nameMut ||
+		vma1.uffd != vma2.uffd ||
+		vma1.uffdMode != vma2.uffdMode ||
+		vma1.uffdWP != vma2.uffdWP {
 		return vma{}, false
 	}
 
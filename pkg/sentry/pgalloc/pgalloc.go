@@ -1174,6 +1174,22 @@ func (f *MemoryFile) IncRef(fr memmap.FileRange, memCgID uint32) {
 	f.incRefLocked(fr)
 }
 
+// IncRefs is equivalent to calling IncRef for each FileRange in frs, but
+// only locks f.mu once.
+func (f *MemoryFile) IncRefs(frs []memmap.FileRange, memCgID uint32) {
+	for _, fr := range frs {
+		if !fr.WellFormed() || fr.Length() == 0 || !hostarch.IsPageAligned(fr.Start) || !hostarch.IsPageAligned(fr.End) {
+			panic(fmt.Sprintf("invalid range: %v", fr))
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, fr := range frs {
+		f.incRefLocked(fr)
+	}
+}
+
 // Preconditions: f.mu must be locked.
 func (f *MemoryFile) incRefLocked(fr memmap.FileRange) {
 	f.forEachChunk(fr, func(chunk *chunkInfo, chunkFR memmap.FileRange) bool {
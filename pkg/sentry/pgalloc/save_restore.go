@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"math"
 	"runtime"
@@ -56,8 +57,30 @@ type SaveOpts struct {
 	// but may instead improve SaveTo() and LoadFrom() time, and checkpoint
 	// size, if the application has many committed zero pages.
 	ExcludeCommittedZeroPages bool
+
+	// PageWriters is the maximum number of goroutines that SaveTo() uses to
+	// write pages concurrently when pages are saved to a pages file (pw is a
+	// *fd.FD). If PageWriters is 0, SaveTo() uses runtime.GOMAXPROCS(0)
+	// goroutines. When pages are saved to the statefile instead, it is the
+	// number of goroutines compressing the statefile; see
+	// statefile.NewWriterWithWorkers.
+	PageWriters int
+
+	// If PageChecksums is true and pages are saved to a pages file, SaveTo()
+	// also saves a checksum of each page, which LoadFrom() verifies when the
+	// page is loaded. LoadOpts.PageChecksums must be true when loading the
+	// saved state.
+	PageChecksums bool
 }
 
+// pageSaveChunkSize is the maximum number of bytes written to a pages file by
+// a single goroutine at a time.
+const pageSaveChunkSize = 8 << 20
+
+// pageChecksumTable is used to compute page checksums (CRC-32C, which is
+// hardware-accelerated on amd64 and arm64).
+var pageChecksumTable = crc32.MakeTable(crc32.Castagnoli)
+
 // SaveTo writes f's state to the given stream.
 func (f *MemoryFile) SaveTo(ctx context.Context, w io.Writer, pw io.Writer, opts SaveOpts) error {
 	if err := f.AwaitLoadAll(); err != nil {
@@ -198,31 +221,172 @@ func (f *MemoryFile) SaveTo(ctx context.Context, w io.Writer, pw io.Writer, opts
 	ww := wire.Writer{Writer: w}
 	timePagesStart := time.Now()
 	savedBytes := uint64(0)
+	if pf, ok := pw.(*fd.FD); ok {
+		// Pages are written to a separate file, so all page data can be
+		// written before any headers, concurrently.
+		checksums, err := f.savePagesToFile(pf, opts)
+		if err != nil {
+			return err
+		}
+		if opts.PageChecksums {
+			if _, err := state.Save(ctx, w, &checksums); err != nil {
+				return err
+			}
+		}
+		for maseg := f.memAcct.FirstSegment(); maseg.Ok(); maseg = maseg.NextSegment() {
+			if !maseg.ValuePtr().knownCommitted {
+				continue
+			}
+			if err := state.WriteHeader(&ww, uint64(maseg.Range().Length()), false); err != nil {
+				return err
+			}
+			savedBytes += maseg.Range().Length()
+		}
+	} else {
+		for maseg := f.memAcct.FirstSegment(); maseg.Ok(); maseg = maseg.NextSegment() {
+			if !maseg.ValuePtr().knownCommitted {
+				continue
+			}
+			// Write a header to distinguish from objects.
+			if err := state.WriteHeader(&ww, uint64(maseg.Range().Length()), false); err != nil {
+				return err
+			}
+			// Write out data.
+			var ioErr error
+			f.forEachMappingSlice(maseg.Range(), func(s []byte) {
+				if ioErr != nil {
+					return
+				}
+				_, ioErr = pw.Write(s)
+			})
+			if ioErr != nil {
+				return ioErr
+			}
+			savedBytes += maseg.Range().Length()
+		}
+	}
+	durPages := time.Since(timePagesStart)
+	log.Infof("MemoryFile(%p): saved pages in %s (%d bytes, %.3f MiB/s)", f, durPages, savedBytes, float64(savedBytes)/durPages.Seconds()/(1024.0*1024.0))
+
+	return nil
+}
+
+// pageSaveChunk is a range of pages written to a pages file by
+// MemoryFile.savePagesToFile().
+type pageSaveChunk struct {
+	fr memmap.FileRange
+
+	// off is the offset of the pages relative to the first page written by
+	// savePagesToFile().
+	off uint64
+}
+
+// savePagesToFile writes the contents of all known-committed pages to pf,
+// starting at its current file offset, and returns their checksums if
+// opts.PageChecksums is true. If pf is seekable, pages are written by up to
+// opts.PageWriters goroutines concurrently. On success, the file offset of pf
+// is advanced past the written pages.
+//
+// Preconditions: f.mu must be locked.
+func (f *MemoryFile) savePagesToFile(pf *fd.FD, opts SaveOpts) ([]uint32, error) {
+	var (
+		chunks []pageSaveChunk
+		total  uint64
+	)
 	for maseg := f.memAcct.FirstSegment(); maseg.Ok(); maseg = maseg.NextSegment() {
 		if !maseg.ValuePtr().knownCommitted {
 			continue
 		}
-		// Write a header to distinguish from objects.
-		if err := state.WriteHeader(&ww, uint64(maseg.Range().Length()), false); err != nil {
-			return err
+		fr := maseg.Range()
+		for fr.Length() != 0 {
+			n := min(fr.Length(), pageSaveChunkSize)
+			chunks = append(chunks, pageSaveChunk{
+				fr:  memmap.FileRange{fr.Start, fr.Start + n},
+				off: total,
+			})
+			fr.Start += n
+			total += n
 		}
-		// Write out data.
+	}
+	var checksums []uint32
+	if opts.PageChecksums {
+		checksums = make([]uint32, total/hostarch.PageSize)
+	}
+
+	workers := opts.PageWriters
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(chunks))
+	base, err := unix.Seek(pf.FD(), 0, unix.SEEK_CUR)
+	if err != nil {
+		// pf is not seekable, e.g. a pipe; write pages sequentially.
+		workers = min(workers, 1)
+	}
+	writeChunk := func(c *pageSaveChunk) error {
+		off := c.off
 		var ioErr error
-		f.forEachMappingSlice(maseg.Range(), func(s []byte) {
+		f.forEachMappingSlice(c.fr, func(s []byte) {
 			if ioErr != nil {
 				return
 			}
-			_, ioErr = pw.Write(s)
+			if checksums != nil {
+				for i := 0; i < len(s); i += hostarch.PageSize {
+					checksums[(off+uint64(i))/hostarch.PageSize] = crc32.Checksum(s[i:i+hostarch.PageSize], pageChecksumTable)
+				}
+			}
+			if workers > 1 {
+				_, ioErr = pf.WriteAt(s, base+int64(off))
+			} else {
+				_, ioErr = pf.Write(s)
+			}
+			off += uint64(len(s))
 		})
-		if ioErr != nil {
-			return ioErr
+		return ioErr
+	}
+
+	if workers <= 1 {
+		for i := range chunks {
+			if err := writeChunk(&chunks[i]); err != nil {
+				return nil, err
+			}
 		}
-		savedBytes += maseg.Range().Length()
+		return checksums, nil
 	}
-	durPages := time.Since(timePagesStart)
-	log.Infof("MemoryFile(%p): saved pages in %s (%d bytes, %.3f MiB/s)", f, durPages, savedBytes, float64(savedBytes)/durPages.Seconds()/(1024.0*1024.0))
 
-	return nil
+	var (
+		next     atomicbitops.Uint64
+		failed   atomicbitops.Bool
+		errOnce  sync.Once
+		writeErr error
+		wg       sync.WaitGroup
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				i := next.Add(1) - 1
+				if i >= uint64(len(chunks)) {
+					return
+				}
+				if ioErr := writeChunk(&chunks[i]); ioErr != nil {
+					errOnce.Do(func() { writeErr = ioErr })
+					failed.Store(true)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if failed.Load() {
+		return nil, writeErr
+	}
+	if _, err := unix.Seek(pf.FD(), base+int64(total), unix.SEEK_SET); err != nil {
+		return nil, fmt.Errorf("failed to seek pages file past saved pages: %w", err)
+	}
+	log.Infof("MemoryFile(%p): wrote %d bytes of pages using %d goroutines", f, total, workers)
+	return checksums, nil
 }
 
 // MarkSavable marks f as savable.
@@ -259,6 +423,12 @@ type LoadOpts struct {
 	OnAsyncPageLoadStart func(*MemoryFile)
 	OnAsyncPageLoadDone  func(*MemoryFile, error)
 
+	// PageChecksums must be true if the state was saved with
+	// SaveOpts.PageChecksums to a pages file. If it is, pages read from
+	// PagesFile are verified against their saved checksums, and async page
+	// loading fails if they differ. PageChecksums requires PagesFile.
+	PageChecksums bool
+
 	// Optional timeline for the restore process.
 	// If async page loading is enabled, a forked timeline will be created for
 	// that async goroutine, so ownership of this timeline remains in the hands
@@ -309,6 +479,15 @@ func (f *MemoryFile) LoadFrom(ctx context.Context, r io.Reader, opts *LoadOpts)
 		return err
 	}
 	f.chunks.Store(&chunks)
+	var checksums []uint32
+	if opts.PageChecksums {
+		if opts.PagesFile == nil {
+			return fmt.Errorf("page checksums require a pages file")
+		}
+		if _, err := state.Load(ctx, r, &checksums); err != nil {
+			return err
+		}
+	}
 	mfTimeline.Reached("metadata loaded")
 	log.Infof("MemoryFile(%p): loaded metadata in %s", f, time.Since(timeMetadataStart))
 	if err := f.file.Truncate(int64(len(chunks)) * chunkSize); err != nil {
@@ -384,6 +563,8 @@ func (f *MemoryFile) LoadFrom(ctx context.Context, r io.Reader, opts *LoadOpts)
 			qavail:       aplQueueCapacity,
 			fd:           int32(opts.PagesFile.FD()),
 			opsBusy:      bitmap.New(aplQueueCapacity),
+			checksums:    checksums,
+			checksumsOff: opts.PagesFileOffset,
 			timeline:     mfTimeline.Transfer(),
 		}
 		apl = &aplg.apl
@@ -458,6 +639,9 @@ func (f *MemoryFile) LoadFrom(ctx context.Context, r io.Reader, opts *LoadOpts)
 			usage.MemoryAccounting.Inc(amount, maseg.ValuePtr().kind, maseg.ValuePtr().memCgID)
 		}
 	}
+	if opts.PageChecksums && loadedBytes/hostarch.PageSize != uint64(len(checksums)) {
+		return fmt.Errorf("mismatched page checksums: expected %d, got %d", loadedBytes/hostarch.PageSize, len(checksums))
+	}
 	durPages := time.Since(timePagesStart)
 	if apl != nil {
 		log.Infof("MemoryFile(%p): loaded page file offsets in %s; async loading %d bytes", f, durPages, loadedBytes)
@@ -678,6 +862,11 @@ type aplGoroutine struct {
 	// ops stores all aplOps.
 	ops [aplQueueCapacity]aplOp
 
+	// If checksums is not nil, checksums[i] is the checksum of the page at
+	// offset checksumsOff + i*hostarch.PageSize in the pages file.
+	checksums    []uint32 // immutable
+	checksumsOff uint64   // immutable
+
 	// Optional timeline for tracking async page loading.
 	timeline *timing.Timeline
 }
@@ -866,6 +1055,29 @@ func (g *aplGoroutine) combine(fr memmap.FileRange, off uint64, tempRef bool) ui
 	return n
 }
 
+// verifyChecksums returns an error if any page read by op does not match its
+// saved checksum.
+func (g *aplGoroutine) verifyChecksums(op *aplOp) error {
+	if g.checksums == nil {
+		return nil
+	}
+	off := uint64(op.off())
+	for _, iov := range op.iovecs() {
+		bs := sliceFromIovec(iov)
+		for i := 0; i < len(bs); i += hostarch.PageSize {
+			idx := (off - g.checksumsOff) / hostarch.PageSize
+			if idx >= uint64(len(g.checksums)) {
+				return fmt.Errorf("no checksum for pages file offset %#x", off)
+			}
+			if crc32.Checksum(bs[i:i+hostarch.PageSize], pageChecksumTable) != g.checksums[idx] {
+				return fmt.Errorf("checksum mismatch at pages file offset %#x", off)
+			}
+			off += hostarch.PageSize
+		}
+	}
+	return nil
+}
+
 func (g *aplGoroutine) main() {
 	apl := &g.apl
 	f := g.f
@@ -1105,6 +1317,12 @@ func (g *aplGoroutine) main() {
 				apl.mu.Unlock()
 				return
 			}
+			if err := g.verifyChecksums(op); err != nil {
+				log.Warningf("MemoryFile(%p): async page loading: read for pages %v: %v", f, op.frs(), err)
+				apl.err = linuxerr.EIO
+				apl.mu.Unlock()
+				return
+			}
 			haveWaiters := false
 			now := int64(0)
 			for _, fr := range op.frs() {
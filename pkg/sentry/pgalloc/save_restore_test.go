@@ -0,0 +1,149 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgalloc
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/fd"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/memutil"
+	"gvisor.dev/gvisor/pkg/safemem"
+	"gvisor.dev/gvisor/pkg/sentry/memmap"
+	"gvisor.dev/gvisor/pkg/sentry/usage"
+)
+
+// testSaveBytes is the number of bytes of pages saved by tests, which spans
+// several chunks written by different goroutines.
+const testSaveBytes = 3*pageSaveChunkSize - hostarch.PageSize
+
+func newTestMemoryFile(t *testing.T) *MemoryFile {
+	memfd, err := memutil.CreateMemFD("pgalloc_test", 0)
+	if err != nil {
+		t.Fatalf("error creating memfd: %v", err)
+	}
+	mf, err := NewMemoryFile(os.NewFile(uintptr(memfd), "pgalloc_test"), MemoryFileOpts{
+		DelayedEviction: DelayedEvictionDisabled,
+	})
+	if err != nil {
+		t.Fatalf("NewMemoryFile(): %v", err)
+	}
+	t.Cleanup(mf.Destroy)
+	return mf
+}
+
+func newTestPagesFile(t *testing.T) *fd.FD {
+	f, err := os.Create(filepath.Join(t.TempDir(), "pages"))
+	if err != nil {
+		t.Fatalf("error creating pages file: %v", err)
+	}
+	pf, err := fd.NewFromFile(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("fd.NewFromFile(): %v", err)
+	}
+	t.Cleanup(func() { pf.Close() })
+	return pf
+}
+
+// saveTestPages saves a MemoryFile holding testSaveBytes of non-zero pages to
+// pf, and returns the saved metadata, the range of the pages and their
+// contents.
+func saveTestPages(t *testing.T, pf *fd.FD, writers int) ([]byte, memmap.FileRange, []byte) {
+	mf := newTestMemoryFile(t)
+	fr, err := mf.Allocate(testSaveBytes, AllocOpts{Kind: usage.Anonymous})
+	if err != nil {
+		t.Fatalf("Allocate(): %v", err)
+	}
+	data := make([]byte, testSaveBytes)
+	for i := range data {
+		data[i] = byte(i%251 + 1)
+	}
+	ims, err := mf.MapInternal(fr, hostarch.Write)
+	if err != nil {
+		t.Fatalf("MapInternal(): %v", err)
+	}
+	if _, err := safemem.CopySeq(ims, safemem.BlockSeqOf(safemem.BlockFromSafeSlice(data))); err != nil {
+		t.Fatalf("error writing pages: %v", err)
+	}
+
+	var metadata bytes.Buffer
+	if err := mf.SaveTo(context.Background(), &metadata, pf, SaveOpts{
+		PageWriters:   writers,
+		PageChecksums: true,
+	}); err != nil {
+		t.Fatalf("SaveTo(): %v", err)
+	}
+	return metadata.Bytes(), fr, data
+}
+
+// loadTestPages loads a MemoryFile saved by saveTestPages and waits for its
+// pages to be loaded.
+func loadTestPages(t *testing.T, metadata []byte, pf *fd.FD) (*MemoryFile, error) {
+	mf := newTestMemoryFile(t)
+	if err := mf.LoadFrom(context.Background(), bytes.NewReader(metadata), &LoadOpts{
+		PagesFile:     pf,
+		PageChecksums: true,
+	}); err != nil {
+		return nil, err
+	}
+	return mf, mf.AwaitLoadAll()
+}
+
+func TestSaveLoadPagesFile(t *testing.T) {
+	for _, writers := range []int{1, 4} {
+		pf := newTestPagesFile(t)
+		metadata, fr, want := saveTestPages(t, pf, writers)
+		off, err := unix.Seek(pf.FD(), 0, unix.SEEK_CUR)
+		if err != nil {
+			t.Fatalf("error getting pages file offset: %v", err)
+		}
+		if off != testSaveBytes {
+			t.Errorf("pages file offset after saving with %d writers = %d, want %d", writers, off, testSaveBytes)
+		}
+
+		mf, err := loadTestPages(t, metadata, pf)
+		if err != nil {
+			t.Fatalf("error loading pages saved with %d writers: %v", writers, err)
+		}
+		ims, err := mf.MapInternal(fr, hostarch.Read)
+		if err != nil {
+			t.Fatalf("MapInternal(): %v", err)
+		}
+		got := make([]byte, fr.Length())
+		if _, err := safemem.CopySeq(safemem.BlockSeqOf(safemem.BlockFromSafeSlice(got)), ims); err != nil {
+			t.Fatalf("error reading pages: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("pages saved with %d writers differ after loading", writers)
+		}
+	}
+}
+
+func TestLoadPagesFileChecksumMismatch(t *testing.T) {
+	pf := newTestPagesFile(t)
+	metadata, _, _ := saveTestPages(t, pf, 4)
+	if _, err := unix.Pwrite(pf.FD(), []byte{0}, pageSaveChunkSize+7); err != nil {
+		t.Fatalf("error corrupting pages file: %v", err)
+	}
+	if _, err := loadTestPages(t, metadata, pf); err == nil {
+		t.Errorf("loading a corrupted pages file succeeded, want error")
+	}
+}
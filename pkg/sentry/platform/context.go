@@ -24,6 +24,15 @@ type contextID int
 const (
 	// CtxPlatform is a Context.Value key for a Platform.
 	CtxPlatform contextID = iota
+
+	// CtxHostThreadName is a Context.Value key for the name that should be
+	// given to host threads executing the context's application code.
+	CtxHostThreadName
+
+	// CtxHostCoreSchedCookie is a Context.Value key for the core scheduling
+	// cookie whose host core tag should be given to host threads executing
+	// the context's application code.
+	CtxHostCoreSchedCookie
 )
 
 // FromContext returns the Platform that is used to execute ctx's application
@@ -34,3 +43,23 @@ func FromContext(ctx context.Context) Platform {
 	}
 	return nil
 }
+
+// HostThreadNameFromContext returns the name that should be given to host
+// threads executing ctx's application code, or "" if they should not be
+// named.
+func HostThreadNameFromContext(ctx context.Context) string {
+	if v := ctx.Value(CtxHostThreadName); v != nil {
+		return v.(string)
+	}
+	return ""
+}
+
+// HostCoreSchedCookieFromContext returns the core scheduling cookie whose host
+// core tag should be given to host threads executing ctx's application code.
+// ok is false if host threads should not be core tagged.
+func HostCoreSchedCookieFromContext(ctx context.Context) (cookie uint64, ok bool) {
+	if v := ctx.Value(CtxHostCoreSchedCookie); v != nil {
+		return v.(uint64), true
+	}
+	return 0, false
+}
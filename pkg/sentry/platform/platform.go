@@ -343,6 +343,30 @@ type AddressSpace interface {
 	AddressSpaceIO
 }
 
+// HostThreadNamer is an optional interface implemented by AddressSpaces
+// whose application code runs on dedicated host threads. It allows those
+// threads to be named after the application, so that host-level tools (top,
+// perf, etc.) attribute their CPU time to the right sandboxed process.
+type HostThreadNamer interface {
+	// SetHostThreadName sets the name of host threads that execute
+	// application code in the AddressSpace. Names longer than
+	// linux.TASK_COMM_LEN-1 bytes are truncated.
+	SetHostThreadName(name string)
+}
+
+// HostCoreScheduler is an optional interface implemented by AddressSpaces
+// whose application code runs on dedicated host threads. It allows the host
+// to enforce the application's core scheduling cookies (see
+// prctl(PR_SCHED_CORE)), so that host threads of mutually untrusted
+// applications don't run on SMT siblings of the same core at the same time.
+type HostCoreScheduler interface {
+	// SetHostCoreSchedCookie gives host threads that execute application code
+	// in the AddressSpace the host core tag corresponding to cookie, a core
+	// scheduling cookie of the sandbox. Cookie 0 corresponds to the core tag
+	// of the Sentry.
+	SetHostCoreSchedCookie(cookie uint64)
+}
+
 // AddressSpaceIO supports IO through the memory mappings installed in an
 // AddressSpace.
 //
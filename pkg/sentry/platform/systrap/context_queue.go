@@ -17,6 +17,7 @@ package systrap
 import (
 	"sync/atomic"
 
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/sentry/platform"
 )
 
@@ -60,7 +61,14 @@ type contextQueue struct {
 
 	fastPathDisabled uint32
 	usedFastPath     uint32
-	ringbuffer       [maxContextQueueEntries]uint64
+
+	// nameGen is incremented by the Sentry each time it changes name. Stub
+	// threads rename themselves to name when they see a new nameGen, before
+	// they switch to a context.
+	nameGen uint32
+	name    [linux.TASK_COMM_LEN]byte
+
+	ringbuffer [maxContextQueueEntries]uint64
 }
 
 const (
@@ -126,6 +134,13 @@ func (q *contextQueue) add(ctx *sharedContext) *platform.ContextError {
 	return nil
 }
 
+// setName sets the name that stub threads give themselves.
+func (q *contextQueue) setName(name string) {
+	n := copy(q.name[:len(q.name)-1], name)
+	clear(q.name[n:])
+	atomic.AddUint32(&q.nameGen, 1)
+}
+
 func (q *contextQueue) disableFastPath() {
 	atomic.StoreUint32(&q.fastPathDisabled, 1)
 }
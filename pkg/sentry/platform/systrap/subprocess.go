@@ -24,6 +24,7 @@ import (
 	"golang.org/x/sys/unix"
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/coretag"
 	"gvisor.dev/gvisor/pkg/hostarch"
 	"gvisor.dev/gvisor/pkg/hostsyscall"
 	"gvisor.dev/gvisor/pkg/log"
@@ -89,6 +90,11 @@ type thread struct {
 	// These are used for the register set for system calls.
 	initRegs arch.Registers
 
+	// hostCoreSchedCookie is the value of subprocess.hostCoreSchedCookie when
+	// the thread was cloned from the syscall thread, whose core tag it
+	// inherited.
+	hostCoreSchedCookie uint64
+
 	logPrefix atomic.Pointer[string]
 }
 
@@ -162,6 +168,12 @@ type subprocess struct {
 	syscallThreadMu sync.Mutex
 	syscallThread   *syscallThread
 
+	// hostCoreSchedCookie is the core scheduling cookie whose host core tag
+	// was last given to the stub's threads. See SetHostCoreSchedCookie.
+	//
+	// +checklocks:syscallThreadMu
+	hostCoreSchedCookie uint64
+
 	// sysmsgThreadsMu protects sysmsgThreads and numSysmsgThreads
 	sysmsgThreadsMu sync.Mutex
 	// sysmsgThreads is a collection of all active sysmsg threads in the
@@ -254,6 +266,7 @@ func (s *subprocess) handlePtraceSyscallRequest(req any) {
 			handlePtraceSyscallRequestError(req, "error initializing thread: %v", err)
 			return
 		}
+		t.hostCoreSchedCookie = s.hostCoreSchedCookie
 
 		// Since the new thread was created with
 		// clone(CLONE_PTRACE), it will begin execution with
@@ -1019,6 +1032,58 @@ func (s *subprocess) MapFile(addr hostarch.Addr, f memmap.File, fr memmap.FileRa
 	return err
 }
 
+// SetHostThreadName implements platform.HostThreadNamer.SetHostThreadName.
+//
+// The name is applied to the stub's syscall thread directly. Sysmsg threads
+// can't be made to execute system calls on behalf of the Sentry, so they
+// rename themselves before they switch to the next context (see
+// sysmsg_lib.c:update_thread_name).
+func (s *subprocess) SetHostThreadName(name string) {
+	s.contextQueue.setName(name)
+
+	s.syscallThreadMu.Lock()
+	defer s.syscallThreadMu.Unlock()
+
+	t := s.syscallThread
+	msgName := t.sentryMessage.name[:]
+	n := copy(msgName[:len(msgName)-1], name)
+	clear(msgName[n:])
+	_, err := t.syscall(
+		unix.SYS_PRCTL,
+		arch.SyscallArgument{Value: unix.PR_SET_NAME},
+		arch.SyscallArgument{Value: t.nameAddr()})
+	if err != nil && err != errDeadSubprocess {
+		log.Warningf("Failed to set stub thread name: %v", err)
+	}
+}
+
+// SetHostCoreSchedCookie implements
+// platform.HostCoreScheduler.SetHostCoreSchedCookie.
+//
+// Sysmsg threads are cloned from the stub's syscall thread, so they inherit
+// its core tag, but existing sysmsg threads are core tagged individually.
+func (s *subprocess) SetHostCoreSchedCookie(cookie uint64) {
+	// Sysmsg threads are cloned with syscallThreadMu locked, so the set of
+	// threads that may have another core tag can't grow until it is
+	// unlocked, except for threads that createSysmsgThread will core tag.
+	s.syscallThreadMu.Lock()
+	defer s.syscallThreadMu.Unlock()
+	if cookie == s.hostCoreSchedCookie {
+		return
+	}
+	s.hostCoreSchedCookie = cookie
+
+	tids := []int{int(s.syscallThread.thread.tid)}
+	s.sysmsgThreadsMu.Lock()
+	for _, t := range s.sysmsgThreads {
+		tids = append(tids, int(t.thread.tid))
+	}
+	s.sysmsgThreadsMu.Unlock()
+	if err := coretag.SetCookie(cookie, tids); err != nil {
+		log.Warningf("Failed to core tag stub threads: %v", err)
+	}
+}
+
 // Unmap implements platform.AddressSpace.Unmap.
 func (s *subprocess) Unmap(addr hostarch.Addr, length uint64) {
 	_, err := s.syscall(
@@ -1199,6 +1264,17 @@ func (s *subprocess) createSysmsgThread() error {
 	s.sysmsgThreads[threadID] = sysThread
 	s.sysmsgThreadsMu.Unlock()
 
+	// SetHostCoreSchedCookie may have core tagged the stub's threads after
+	// the new thread inherited the core tag of the syscall thread, but before
+	// the new thread was added to sysmsgThreads.
+	s.syscallThreadMu.Lock()
+	if cookie := s.hostCoreSchedCookie; cookie != p.hostCoreSchedCookie {
+		if err := coretag.SetCookie(cookie, []int{int(p.tid)}); err != nil {
+			log.Warningf("Failed to core tag a new stub thread: %v", err)
+		}
+	}
+	s.syscallThreadMu.Unlock()
+
 	return nil
 }
 
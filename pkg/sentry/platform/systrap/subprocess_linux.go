@@ -83,6 +83,9 @@ func attachedThread(flags uintptr, defaultAction linux.BPFAction) (*thread, erro
 						seccomp.EqualTo(stubStart),
 						seccomp.EqualTo(stubROMapEnd - stubStart),
 					},
+					// For naming stub threads after the application
+					// (syscall thread).
+					seccomp.PerArg{seccomp.EqualTo(unix.PR_SET_NAME)},
 				},
 				unix.SYS_GETPPID: seccomp.MatchAll{},
 
@@ -15,6 +15,7 @@
 package systrap
 
 import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/hostarch"
 )
 
@@ -27,6 +28,10 @@ type syscallSentryMessage struct {
 	unused uint32
 	sysno  uint64
 	args   [6]uint64
+
+	// name holds the string argument of prctl(PR_SET_NAME), which the stub
+	// reads from this read-only mapping. See subprocess.SetHostThreadName.
+	name [linux.TASK_COMM_LEN]byte
 }
 
 // syscallStubMessage is a shared message that can be changed from a stub
@@ -34,6 +34,11 @@ func (t *syscallThread) initRequestReplyAddresses(sentryStackAddr uintptr) {
 	t.stubMessage = stubMessage
 }
 
+// nameAddr returns the address of t.sentryMessage.name in the stub process.
+func (t *syscallThread) nameAddr() uintptr {
+	return t.stubAddr + unsafe.Offsetof(syscallSentryMessage{}.name)
+}
+
 // maskAllSignals blocks all signals.
 func (t *syscallThread) maskAllSignalsAttached() {
 	p := t.thread
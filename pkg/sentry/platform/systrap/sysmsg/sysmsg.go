@@ -166,6 +166,9 @@ type Msg struct {
 	Debug uint64
 	// ThreadID is the ID of the sysmsg thread.
 	ThreadID uint32
+	// NameGen is the generation of the stub thread name that the sysmsg thread
+	// was last renamed to. See contextQueue.nameGen.
+	NameGen uint32
 }
 
 // ContextState defines the reason the context has exited back to the sentry,
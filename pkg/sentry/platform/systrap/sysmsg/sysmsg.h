@@ -67,6 +67,9 @@ struct sysmsg {
   int32_t err_line;
   uint64_t debug;
   uint32_t thread_id;
+  // name_gen is the value of context_queue.name_gen when the thread was last
+  // renamed.
+  uint32_t name_gen;
 };
 
 enum context_state {
@@ -120,6 +123,10 @@ enum stub_error {
 #define MSG_OFFSET_FROM_START (PER_THREAD_MEM_SIZE - PAGE_SIZE)
 
 #define SPINNING_QUEUE_MEM_SIZE PAGE_SIZE
+
+// TASK_COMM_LEN is the size of a thread name, including the terminating null
+// byte.
+#define TASK_COMM_LEN 16
 // LINT.ThenChange(sysmsg.go)
 
 #define FAULT_OPCODE 0x06  // "push %es" on x32 and invalid opcode on x64.
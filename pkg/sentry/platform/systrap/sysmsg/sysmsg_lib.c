@@ -15,6 +15,7 @@
 #define _GNU_SOURCE
 #include <errno.h>
 #include <linux/futex.h>
+#include <linux/prctl.h>
 #include <linux/unistd.h>
 #include <signal.h>
 #include <stdbool.h>
@@ -51,6 +52,8 @@ struct context_queue {
   uint32_t num_awake_contexts;
   uint32_t fast_path_disabled;
   uint32_t used_fast_path;
+  uint32_t name_gen;
+  char name[TASK_COMM_LEN];
   uint64_t ringbuffer[MAX_CONTEXT_QUEUE_ENTRIES];
 };
 
@@ -304,6 +307,24 @@ void init_new_thread() {
   try_to_dec_threads_to_wakeup(queue);
 }
 
+// update_thread_name renames the current thread if the Sentry has renamed stub
+// threads since the last time the current thread was renamed.
+static void update_thread_name(struct sysmsg *sysmsg,
+                               struct context_queue *queue) {
+  uint32_t name_gen = atomic_load(&queue->name_gen);
+  if (name_gen == sysmsg->name_gen) return;
+  sysmsg->name_gen = name_gen;
+
+  // The name may change concurrently, in which case name_gen changes too and
+  // the thread is renamed again.
+  char name[TASK_COMM_LEN];
+  for (int i = 0; i < TASK_COMM_LEN - 1; i++) {
+    name[i] = __atomic_load_n(&queue->name[i], __ATOMIC_RELAXED);
+  }
+  name[TASK_COMM_LEN - 1] = 0;
+  __syscall(__NR_prctl, PR_SET_NAME, (long)name, 0, 0, 0, 0);
+}
+
 // get_context retrieves a context that is ready to be restored to the user.
 // This populates sysmsg->thread_context_id.
 struct thread_context *get_context(struct sysmsg *sysmsg) {
@@ -389,7 +410,9 @@ struct thread_context *switch_context(struct sysmsg *sysmsg,
     }
   }
 
-  return get_context(sysmsg);
+  ctx = get_context(sysmsg);
+  update_thread_name(sysmsg, queue);
+  return ctx;
 }
 
 void verify_offsets() {
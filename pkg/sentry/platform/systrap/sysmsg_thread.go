@@ -152,6 +152,16 @@ func sysmsgThreadRules(stubStart uintptr) []bpf.Instruction {
 					seccomp.AnyValue{},
 					seccomp.GreaterThan(stubStart), // rip
 				},
+				// See sysmsg_lib.c:update_thread_name.
+				unix.SYS_PRCTL: seccomp.PerArg{
+					seccomp.EqualTo(linux.PR_SET_NAME),
+					seccomp.AnyValue{},
+					seccomp.EqualTo(0),
+					seccomp.EqualTo(0),
+					seccomp.EqualTo(0),
+					seccomp.EqualTo(0),
+					seccomp.GreaterThan(stubStart), // rip
+				},
 				unix.SYS_SCHED_YIELD: seccomp.PerArg{
 					seccomp.AnyValue{},
 					seccomp.AnyValue{},
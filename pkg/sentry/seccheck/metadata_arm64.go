@@ -203,7 +203,7 @@ func archInit() {
 		},
 	})
 
-	const lastSyscallInTable = 441
+	const lastSyscallInTable = 449
 	for i := 0; i <= lastSyscallInTable; i++ {
 		addRawSyscallPoint(uintptr(i))
 	}
@@ -0,0 +1,124 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy defines a stable interface for syscall policies that are
+// compiled into the sentry, e.g. by security vendors, without depending on
+// the seccheck.Sink interface which changes whenever points are added.
+//
+// A policy observes each syscall before it is executed, with access to its
+// arguments and to the credentials of the calling task, and may veto it.
+// Policies are built on seccheck: Register registers the policy as a sink,
+// and a trace session enables it for the raw syscall enter points
+// ("syscall/sysno/<number>/enter") of the syscalls it should check.
+//
+// Policies are Go code linked into the sentry. Dynamically loaded Go plugins
+// and WASM modules are not supported, since the sentry doesn't load code from
+// outside of its binary at runtime.
+package policy
+
+import (
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/fd"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/seccheck"
+	pb "gvisor.dev/gvisor/pkg/sentry/seccheck/points/points_go_proto"
+)
+
+// Syscall describes a syscall that is about to be executed.
+type Syscall struct {
+	// Sysno is the syscall number.
+	Sysno uintptr
+
+	// Args are the raw syscall arguments.
+	Args [6]uint64
+
+	// Credentials are the credentials of the calling task. They must not be
+	// modified.
+	Credentials *auth.Credentials
+}
+
+// Policy is implemented by syscall policies.
+type Policy interface {
+	// CheckSyscall is called synchronously in the goroutine of the task
+	// making the syscall, before the syscall is executed. If it returns an
+	// error, the syscall is not executed and the error is returned to the
+	// application. Errors that do not carry an errno (e.g. unix.Errno) are
+	// reported as EPERM.
+	//
+	// CheckSyscall must not block for long, since it delays the syscall.
+	CheckSyscall(ctx context.Context, s *Syscall) error
+
+	// Stop is called when the trace session that enabled the policy is
+	// deleted.
+	Stop()
+}
+
+// Desc describes a policy.
+type Desc struct {
+	// Name is a unique identifier for the policy. It's the sink name used in
+	// trace session configurations.
+	Name string
+
+	// New creates a new instance of the policy. config is the opaque json
+	// object configured for the sink in the trace session.
+	New func(config map[string]any) (Policy, error)
+}
+
+// Register makes the policy described by desc available to trace sessions. It
+// must be called from an init() function.
+func Register(desc Desc) {
+	seccheck.RegisterSink(seccheck.SinkDesc{
+		Name: desc.Name,
+		New: func(config map[string]any, _ *fd.FD) (seccheck.Sink, error) {
+			p, err := desc.New(config)
+			if err != nil {
+				return nil, err
+			}
+			return &sink{name: desc.Name, policy: p}, nil
+		},
+	})
+}
+
+// sink adapts a Policy to seccheck.Sink.
+type sink struct {
+	seccheck.SinkDefaults
+
+	name   string
+	policy Policy
+}
+
+var _ seccheck.Sink = (*sink)(nil)
+
+// Name implements seccheck.Sink.Name.
+func (s *sink) Name() string {
+	return s.name
+}
+
+// Stop implements seccheck.Sink.Stop.
+func (s *sink) Stop() {
+	s.policy.Stop()
+}
+
+// RawSyscall implements seccheck.Sink.RawSyscall.
+func (s *sink) RawSyscall(ctx context.Context, _ seccheck.FieldSet, info *pb.Syscall) error {
+	if info.Exit != nil {
+		// The syscall has already been executed.
+		return nil
+	}
+	return s.policy.CheckSyscall(ctx, &Syscall{
+		Sysno:       uintptr(info.Sysno),
+		Args:        [6]uint64{info.Arg1, info.Arg2, info.Arg3, info.Arg4, info.Arg5, info.Arg6},
+		Credentials: auth.CredentialsFromContext(ctx),
+	})
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/seccheck"
+	pb "gvisor.dev/gvisor/pkg/sentry/seccheck/points/points_go_proto"
+)
+
+// denyUID is a Policy that fails all syscalls made by a given UID.
+type denyUID struct {
+	uid     auth.KUID
+	checked []Syscall
+	stopped bool
+}
+
+// CheckSyscall implements Policy.CheckSyscall.
+func (p *denyUID) CheckSyscall(_ context.Context, s *Syscall) error {
+	p.checked = append(p.checked, *s)
+	if s.Credentials.EffectiveKUID == p.uid {
+		return unix.EACCES
+	}
+	return nil
+}
+
+// Stop implements Policy.Stop.
+func (p *denyUID) Stop() {
+	p.stopped = true
+}
+
+// observer is a seccheck.Sink that records the raw syscalls it sees.
+type observer struct {
+	seccheck.SinkDefaults
+	syscalls []*pb.Syscall
+}
+
+// Name implements seccheck.Sink.Name.
+func (*observer) Name() string {
+	return "observer"
+}
+
+// RawSyscall implements seccheck.Sink.RawSyscall.
+func (o *observer) RawSyscall(_ context.Context, _ seccheck.FieldSet, info *pb.Syscall) error {
+	o.syscalls = append(o.syscalls, info)
+	return nil
+}
+
+func TestVeto(t *testing.T) {
+	const sysno = unix.SYS_OPENAT
+	userns := auth.NewRootUserNamespace()
+	root := auth.NewRootCredentials(userns)
+	user := auth.NewUserCredentials(1000, 1000, nil, nil, userns)
+
+	policy := &denyUID{uid: user.EffectiveKUID}
+	obs := &observer{}
+	var s seccheck.State
+	reqs := []seccheck.PointReq{{Pt: seccheck.GetPointForSyscall(seccheck.SyscallRawEnter, sysno)}}
+	// The policy comes first, so that the observer must still see vetoed
+	// syscalls.
+	ps := &sink{name: "deny-uid", policy: policy}
+	s.AppendSink(ps, reqs)
+	s.AppendSink(obs, reqs)
+
+	enter := func(creds *auth.Credentials) error {
+		ctx := auth.ContextWithCredentials(context.Background(), creds)
+		info := &pb.Syscall{Sysno: sysno, Arg1: 1, Arg6: 6}
+		return s.SentToSinks(func(c seccheck.Sink) error {
+			return c.RawSyscall(ctx, seccheck.FieldSet{}, info)
+		})
+	}
+	if err := enter(root); err != nil {
+		t.Errorf("syscall by root: got %v, want nil", err)
+	}
+	if err := enter(user); err != unix.EACCES {
+		t.Errorf("syscall by UID 1000: got %v, want EACCES", err)
+	}
+	if len(obs.syscalls) != 2 {
+		t.Errorf("observer saw %d syscalls, want 2", len(obs.syscalls))
+	}
+
+	if len(policy.checked) != 2 {
+		t.Fatalf("policy checked %d syscalls, want 2", len(policy.checked))
+	}
+	got := policy.checked[1]
+	if got.Sysno != sysno || got.Args != [6]uint64{1, 0, 0, 0, 0, 6} || got.Credentials != user {
+		t.Errorf("policy got %+v, want sysno %d, args [1 0 0 0 0 6] and the caller's credentials", got, sysno)
+	}
+
+	// Exit points are not checked.
+	ctx := auth.ContextWithCredentials(context.Background(), user)
+	if err := s.SentToSinks(func(c seccheck.Sink) error {
+		return c.RawSyscall(ctx, seccheck.FieldSet{}, &pb.Syscall{Sysno: sysno, Exit: &pb.Exit{}})
+	}); err != nil {
+		t.Errorf("syscall exit: got %v, want nil", err)
+	}
+	if len(policy.checked) != 2 {
+		t.Errorf("policy checked a syscall exit")
+	}
+
+	ps.Stop()
+	if !policy.stopped {
+		t.Errorf("policy wasn't stopped with its sink")
+	}
+}
@@ -96,6 +96,13 @@ func (fm *FieldMask) Empty() bool {
 // superset of fields requested by the Sink's corresponding PointReq, but
 // may be missing requested fields in some cases (e.g. if the Sink is
 // registered concurrently with invocations of checkpoints).
+//
+// At syscall enter points (Syscall and RawSyscall called before the syscall
+// executes), a non-nil error vetoes the syscall: it is not executed and the
+// error is returned to the application. Errors that do not carry an errno
+// are reported as EPERM. All sinks see the enter and exit points of vetoed
+// syscalls. Syscall policies compiled into the sentry should use the stable
+// interface in package seccheck/policy rather than implement Sink directly.
 type Sink interface {
 	// Name return the sink name.
 	Name() string
@@ -303,14 +310,17 @@ func (s *State) appendSinkLocked(c Sink) {
 	s.registrationSeq.EndWrite()
 }
 
-// SentToSinks iterates over all sinks and calls fn for each one of them.
+// SentToSinks iterates over all sinks and calls fn for each one of them. All
+// sinks are called even if some of them fail, and the first error is
+// returned.
 func (s *State) SentToSinks(fn func(c Sink) error) error {
+	var firstErr error
 	for _, c := range s.getSinks() {
-		if err := fn(c); err != nil {
-			return err
+		if err := fn(c); err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
-	return nil
+	return firstErr
 }
 
 // GetFieldSet returns the FieldSet that has been configured for a given Point.
@@ -173,11 +173,11 @@ func TestCheckpointReturnsFirstSinkError(t *testing.T) {
 	}); err != errFirstSink {
 		t.Errorf("Clone(): got %v, wanted %v", err, errFirstSink)
 	}
-	if !sinkCalled[0] {
-		t.Errorf("Clone() did not call first Sink")
-	}
-	if sinkCalled[1] {
-		t.Errorf("Clone() called second Sink")
+	// Sinks after a failing one must still see the event.
+	for i := range sinkCalled {
+		if !sinkCalled[i] {
+			t.Errorf("Clone() did not call Sink.Clone() index %d", i)
+		}
 	}
 }
 
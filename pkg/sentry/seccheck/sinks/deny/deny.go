@@ -0,0 +1,77 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deny defines a seccheck.Sink that fails every syscall point it is
+// configured for. It serves as a minimal example of an in-sentry policy sink:
+// sinks compiled into the sentry can veto syscalls by returning an error from
+// the syscall enter points.
+package deny
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/protobuf/proto"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/fd"
+	"gvisor.dev/gvisor/pkg/sentry/seccheck"
+	pb "gvisor.dev/gvisor/pkg/sentry/seccheck/points/points_go_proto"
+)
+
+const name = "deny"
+
+func init() {
+	seccheck.RegisterSink(seccheck.SinkDesc{
+		Name: name,
+		New:  new,
+	})
+}
+
+// deny is a checker that fails syscalls with a configured errno.
+type deny struct {
+	seccheck.SinkDefaults
+
+	errno unix.Errno
+}
+
+var _ seccheck.Sink = (*deny)(nil)
+
+// new creates a deny sink. The optional "errno" configuration sets the errno
+// returned to the application, EPERM by default.
+func new(config map[string]any, _ *fd.FD) (seccheck.Sink, error) {
+	d := &deny{errno: unix.EPERM}
+	if opaque, ok := config["errno"]; ok {
+		errno, ok := opaque.(float64)
+		if !ok || errno != float64(int(errno)) || errno <= 0 || errno >= 4096 {
+			return nil, fmt.Errorf("errno %v is not a valid error number", opaque)
+		}
+		d.errno = unix.Errno(errno)
+	}
+	return d, nil
+}
+
+// Name implements seccheck.Sink.
+func (*deny) Name() string {
+	return name
+}
+
+// Syscall implements seccheck.Sink.
+func (d *deny) Syscall(context.Context, seccheck.FieldSet, *pb.ContextData, pb.MessageType, proto.Message) error {
+	return d.errno
+}
+
+// RawSyscall implements seccheck.Sink.
+func (d *deny) RawSyscall(context.Context, seccheck.FieldSet, *pb.Syscall) error {
+	return d.errno
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deny
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/sentry/seccheck"
+)
+
+func TestNew(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config map[string]any
+		want   unix.Errno
+		err    bool
+	}{
+		{name: "default", want: unix.EPERM},
+		{name: "errno", config: map[string]any{"errno": float64(unix.ENOSYS)}, want: unix.ENOSYS},
+		{name: "fraction", config: map[string]any{"errno": 1.5}, err: true},
+		{name: "negative", config: map[string]any{"errno": float64(-1)}, err: true},
+		{name: "string", config: map[string]any{"errno": "EPERM"}, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sink, err := new(tc.config, nil)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("new(%v) succeeded, want error", tc.config)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("new(%v): %v", tc.config, err)
+			}
+			if got := sink.RawSyscall(nil, seccheck.FieldSet{}, nil); got != tc.want {
+				t.Errorf("RawSyscall() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
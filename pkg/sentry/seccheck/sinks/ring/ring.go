@@ -0,0 +1,198 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ring defines a seccheck.Sink that keeps the most recent trace points
+// in memory, so that they can be included in panic reports.
+package ring
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/fd"
+	"gvisor.dev/gvisor/pkg/sentry/seccheck"
+	pb "gvisor.dev/gvisor/pkg/sentry/seccheck/points/points_go_proto"
+	"gvisor.dev/gvisor/pkg/sync"
+)
+
+const name = "ring"
+
+// defaultSize is the number of trace points kept if the "size" option is not
+// set.
+const defaultSize = 256
+
+func init() {
+	seccheck.RegisterSink(seccheck.SinkDesc{
+		Name: name,
+		New:  new,
+	})
+}
+
+// active is the most recently created ring sink.
+var active struct {
+	mu   sync.Mutex
+	ring *ring
+}
+
+// event is a trace point stored in the ring.
+type event struct {
+	msgType pb.MessageType
+	msg     proto.Message
+}
+
+// ring is a sink that keeps the most recent trace points in memory.
+type ring struct {
+	seccheck.SinkDefaults
+
+	mu sync.Mutex
+
+	// events is the ring buffer of trace points. It is protected by mu.
+	events []event
+
+	// next is the index in events of the next trace point to be stored. It is
+	// protected by mu.
+	next int
+
+	// full is true if events has wrapped around. It is protected by mu.
+	full bool
+}
+
+var _ seccheck.Sink = (*ring)(nil)
+
+func new(config map[string]any, _ *fd.FD) (seccheck.Sink, error) {
+	size := defaultSize
+	if sizeOpaque, ok := config["size"]; ok {
+		s, ok := sizeOpaque.(float64)
+		if !ok || s != float64(int(s)) || s <= 0 {
+			return nil, fmt.Errorf("size %v is not a positive int", sizeOpaque)
+		}
+		size = int(s)
+	}
+	r := &ring{events: make([]event, size)}
+
+	active.mu.Lock()
+	active.ring = r
+	active.mu.Unlock()
+	return r, nil
+}
+
+// Name implements seccheck.Sink.
+func (*ring) Name() string {
+	return name
+}
+
+// Stop implements seccheck.Sink.
+func (r *ring) Stop() {
+	active.mu.Lock()
+	if active.ring == r {
+		active.ring = nil
+	}
+	active.mu.Unlock()
+}
+
+func (r *ring) add(msg proto.Message, msgType pb.MessageType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[r.next] = event{msgType: msgType, msg: msg}
+	r.next++
+	if r.next == len(r.events) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Clone implements seccheck.Sink.
+func (r *ring) Clone(_ context.Context, _ seccheck.FieldSet, info *pb.CloneInfo) error {
+	r.add(info, pb.MessageType_MESSAGE_SENTRY_CLONE)
+	return nil
+}
+
+// Execve implements seccheck.Sink.
+func (r *ring) Execve(_ context.Context, _ seccheck.FieldSet, info *pb.ExecveInfo) error {
+	r.add(info, pb.MessageType_MESSAGE_SENTRY_EXEC)
+	return nil
+}
+
+// ExitNotifyParent implements seccheck.Sink.
+func (r *ring) ExitNotifyParent(_ context.Context, _ seccheck.FieldSet, info *pb.ExitNotifyParentInfo) error {
+	r.add(info, pb.MessageType_MESSAGE_SENTRY_EXIT_NOTIFY_PARENT)
+	return nil
+}
+
+// TaskExit implements seccheck.Sink.
+func (r *ring) TaskExit(_ context.Context, _ seccheck.FieldSet, info *pb.TaskExit) error {
+	r.add(info, pb.MessageType_MESSAGE_SENTRY_TASK_EXIT)
+	return nil
+}
+
+// ContainerStart implements seccheck.Sink.
+func (r *ring) ContainerStart(_ context.Context, _ seccheck.FieldSet, info *pb.Start) error {
+	r.add(info, pb.MessageType_MESSAGE_CONTAINER_START)
+	return nil
+}
+
+// RawSyscall implements seccheck.Sink.
+func (r *ring) RawSyscall(_ context.Context, _ seccheck.FieldSet, info *pb.Syscall) error {
+	r.add(info, pb.MessageType_MESSAGE_SYSCALL_RAW)
+	return nil
+}
+
+// Syscall implements seccheck.Sink.
+func (r *ring) Syscall(_ context.Context, _ seccheck.FieldSet, _ *pb.ContextData, msgType pb.MessageType, msg proto.Message) error {
+	r.add(msg, msgType)
+	return nil
+}
+
+// Event is a trace point returned by Recent.
+type Event struct {
+	// Type is the name of the trace point's message type.
+	Type string `json:"type"`
+
+	// Message is the JSON encoding of the trace point.
+	Message json.RawMessage `json:"message"`
+}
+
+// Recent returns the trace points kept by the most recently created ring
+// sink, oldest first. It returns nil if there is no such sink, or if it is
+// busy: Recent is meant to be called while the sentry is panicking, when
+// blocking could prevent the panic from completing.
+func Recent() []Event {
+	if !active.mu.TryLock() {
+		return nil
+	}
+	r := active.ring
+	active.mu.Unlock()
+	if r == nil || !r.mu.TryLock() {
+		return nil
+	}
+	var events []event
+	if r.full {
+		events = append(events, r.events[r.next:]...)
+	}
+	events = append(events, r.events[:r.next]...)
+	r.mu.Unlock()
+
+	out := make([]Event, 0, len(events))
+	for _, e := range events {
+		b, err := protojson.Marshal(e.msg)
+		if err != nil {
+			b, _ = json.Marshal(err.Error())
+		}
+		out = append(out, Event{Type: e.msgType.String(), Message: b})
+	}
+	return out
+}
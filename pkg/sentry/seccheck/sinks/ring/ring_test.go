@@ -0,0 +1,139 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ring
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"gvisor.dev/gvisor/pkg/sentry/seccheck"
+	pb "gvisor.dev/gvisor/pkg/sentry/seccheck/points/points_go_proto"
+)
+
+func TestNew(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config map[string]any
+		want   int
+		err    bool
+	}{
+		{name: "default", want: defaultSize},
+		{name: "size", config: map[string]any{"size": float64(3)}, want: 3},
+		{name: "zero", config: map[string]any{"size": float64(0)}, err: true},
+		{name: "fraction", config: map[string]any{"size": 1.5}, err: true},
+		{name: "string", config: map[string]any{"size": "3"}, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sink, err := new(tc.config, nil)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("new(%v) succeeded, want error", tc.config)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("new(%v): %v", tc.config, err)
+			}
+			defer sink.Stop()
+			if got := len(sink.(*ring).events); got != tc.want {
+				t.Errorf("new(%v) has size %d, want %d", tc.config, got, tc.want)
+			}
+		})
+	}
+}
+
+// recentSysnos returns the sysno of each raw syscall returned by Recent.
+func recentSysnos(t *testing.T) []uint64 {
+	t.Helper()
+	var sysnos []uint64
+	for _, e := range Recent() {
+		if e.Type != pb.MessageType_MESSAGE_SYSCALL_RAW.String() {
+			t.Fatalf("got event type %q, want %q", e.Type, pb.MessageType_MESSAGE_SYSCALL_RAW.String())
+		}
+		var msg pb.Syscall
+		if err := protojson.Unmarshal(e.Message, &msg); err != nil {
+			t.Fatalf("unmarshaling %s: %v", e.Message, err)
+		}
+		sysnos = append(sysnos, msg.Sysno)
+	}
+	return sysnos
+}
+
+func TestRecent(t *testing.T) {
+	sink, err := new(map[string]any{"size": float64(3)}, nil)
+	if err != nil {
+		t.Fatalf("new(): %v", err)
+	}
+	defer sink.Stop()
+
+	if got := Recent(); len(got) != 0 {
+		t.Errorf("Recent() = %v, want no events", got)
+	}
+	for i, want := range [][]uint64{
+		{0},
+		{0, 1},
+		{0, 1, 2},
+		// The oldest events are dropped once the ring is full.
+		{1, 2, 3},
+		{2, 3, 4},
+	} {
+		if err := sink.RawSyscall(nil, seccheck.FieldSet{}, &pb.Syscall{Sysno: uint64(i)}); err != nil {
+			t.Fatalf("RawSyscall(): %v", err)
+		}
+		got := recentSysnos(t)
+		if len(got) != len(want) {
+			t.Fatalf("after %d events got sysnos %v, want %v", i+1, got, want)
+		}
+		for j := range got {
+			if got[j] != want[j] {
+				t.Fatalf("after %d events got sysnos %v, want %v", i+1, got, want)
+			}
+		}
+	}
+}
+
+func TestRecentStopped(t *testing.T) {
+	sink, err := new(nil, nil)
+	if err != nil {
+		t.Fatalf("new(): %v", err)
+	}
+	if err := sink.RawSyscall(nil, seccheck.FieldSet{}, &pb.Syscall{}); err != nil {
+		t.Fatalf("RawSyscall(): %v", err)
+	}
+	sink.Stop()
+	if got := Recent(); got != nil {
+		t.Errorf("Recent() after Stop = %v, want nil", got)
+	}
+}
+
+// TestRecentBusy tests that Recent doesn't block if the ring is locked, since
+// it is called while the sentry is panicking.
+func TestRecentBusy(t *testing.T) {
+	sink, err := new(nil, nil)
+	if err != nil {
+		t.Fatalf("new(): %v", err)
+	}
+	defer sink.Stop()
+	if err := sink.RawSyscall(nil, seccheck.FieldSet{}, &pb.Syscall{}); err != nil {
+		t.Fatalf("RawSyscall(): %v", err)
+	}
+
+	r := sink.(*ring)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if got := Recent(); got != nil {
+		t.Errorf("Recent() with the ring locked = %v, want nil", got)
+	}
+}
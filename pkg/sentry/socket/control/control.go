@@ -128,28 +128,26 @@ func putCmsg(buf []byte, flags int, msgType uint32, align uint, data []int32) ([
 	return alignSlice(buf, align), flags
 }
 
-func putCmsgStruct(buf []byte, msgLevel, msgType uint32, align uint, data marshal.Marshallable) []byte {
-	if cap(buf)-len(buf) < linux.SizeOfControlMessageHeader {
-		return buf
+// putCmsgStruct writes a control message header and data into the unused
+// capacity of a buffer. Like Linux's put_cmsg(), if the message doesn't fit,
+// MSG_CTRUNC is set in flags and as much of the message as fits is written,
+// with the header's length set accordingly.
+func putCmsgStruct(buf []byte, flags int, msgLevel, msgType uint32, align uint, data marshal.Marshallable) ([]byte, int) {
+	space := cap(buf) - len(buf)
+	if space < linux.SizeOfControlMessageHeader {
+		return buf, flags | linux.MSG_CTRUNC
+	}
+	length := linux.SizeOfControlMessageHeader + data.SizeBytes()
+	if length > space {
+		flags |= linux.MSG_CTRUNC
+		length = space
 	}
-	ob := buf
 
-	buf = putUint64(buf, uint64(linux.SizeOfControlMessageHeader))
+	buf = putUint64(buf, uint64(length))
 	buf = putUint32(buf, msgLevel)
 	buf = putUint32(buf, msgType)
-
-	hdrBuf := buf
-	buf = append(buf, marshal.Marshal(data)...)
-
-	// If the control message data brought us over capacity, omit it.
-	if cap(buf) != cap(ob) {
-		return hdrBuf
-	}
-
-	// Update control message length to include data.
-	putUint64(ob, uint64(len(buf)-len(ob)))
-
-	return alignSlice(buf, align)
+	buf = append(buf, marshal.Marshal(data)[:length-linux.SizeOfControlMessageHeader]...)
+	return alignSlice(buf, align), flags
 }
 
 // Credentials implements SCMCredentials.Credentials.
@@ -196,10 +194,11 @@ func alignSlice(buf []byte, align uint) []byte {
 }
 
 // PackTimestamp packs a SO_TIMESTAMP socket control message.
-func PackTimestamp(t *kernel.Task, timestamp time.Time, buf []byte) []byte {
+func PackTimestamp(t *kernel.Task, timestamp time.Time, buf []byte, flags int) ([]byte, int) {
 	timestampP := linux.NsecToTimeval(timestamp.UnixNano())
 	return putCmsgStruct(
 		buf,
+		flags,
 		linux.SOL_SOCKET,
 		linux.SO_TIMESTAMP,
 		t.Arch().Width(),
@@ -207,10 +206,36 @@ func PackTimestamp(t *kernel.Task, timestamp time.Time, buf []byte) []byte {
 	)
 }
 
+// PackTimestampNS packs a SO_TIMESTAMPNS socket control message.
+func PackTimestampNS(t *kernel.Task, timestamp time.Time, buf []byte, flags int) ([]byte, int) {
+	timestampP := linux.NsecToTimespec(timestamp.UnixNano())
+	return putCmsgStruct(
+		buf,
+		flags,
+		linux.SOL_SOCKET,
+		linux.SO_TIMESTAMPNS,
+		t.Arch().Width(),
+		&timestampP,
+	)
+}
+
+// PackDropCount packs a SO_RXQ_OVFL socket control message.
+func PackDropCount(t *kernel.Task, dropCount uint32, buf []byte, flags int) ([]byte, int) {
+	return putCmsgStruct(
+		buf,
+		flags,
+		linux.SOL_SOCKET,
+		linux.SO_RXQ_OVFL,
+		t.Arch().Width(),
+		primitive.AllocateUint32(dropCount),
+	)
+}
+
 // PackInq packs a TCP_INQ socket control message.
-func PackInq(t *kernel.Task, inq int32, buf []byte) []byte {
+func PackInq(t *kernel.Task, inq int32, buf []byte, flags int) ([]byte, int) {
 	return putCmsgStruct(
 		buf,
+		flags,
 		linux.SOL_TCP,
 		linux.TCP_INQ,
 		t.Arch().Width(),
@@ -219,9 +244,10 @@ func PackInq(t *kernel.Task, inq int32, buf []byte) []byte {
 }
 
 // PackTOS packs an IP_TOS socket control message.
-func PackTOS(t *kernel.Task, tos uint8, buf []byte) []byte {
+func PackTOS(t *kernel.Task, tos uint8, buf []byte, flags int) ([]byte, int) {
 	return putCmsgStruct(
 		buf,
+		flags,
 		linux.SOL_IP,
 		linux.IP_TOS,
 		t.Arch().Width(),
@@ -230,9 +256,10 @@ func PackTOS(t *kernel.Task, tos uint8, buf []byte) []byte {
 }
 
 // PackTClass packs an IPV6_TCLASS socket control message.
-func PackTClass(t *kernel.Task, tClass uint32, buf []byte) []byte {
+func PackTClass(t *kernel.Task, tClass uint32, buf []byte, flags int) ([]byte, int) {
 	return putCmsgStruct(
 		buf,
+		flags,
 		linux.SOL_IPV6,
 		linux.IPV6_TCLASS,
 		t.Arch().Width(),
@@ -241,9 +268,10 @@ func PackTClass(t *kernel.Task, tClass uint32, buf []byte) []byte {
 }
 
 // PackTTL packs an IP_TTL socket control message.
-func PackTTL(t *kernel.Task, ttl uint32, buf []byte) []byte {
+func PackTTL(t *kernel.Task, ttl uint32, buf []byte, flags int) ([]byte, int) {
 	return putCmsgStruct(
 		buf,
+		flags,
 		linux.SOL_IP,
 		linux.IP_TTL,
 		t.Arch().Width(),
@@ -252,9 +280,10 @@ func PackTTL(t *kernel.Task, ttl uint32, buf []byte) []byte {
 }
 
 // PackHopLimit packs an IPV6_HOPLIMIT socket control message.
-func PackHopLimit(t *kernel.Task, hoplimit uint32, buf []byte) []byte {
+func PackHopLimit(t *kernel.Task, hoplimit uint32, buf []byte, flags int) ([]byte, int) {
 	return putCmsgStruct(
 		buf,
+		flags,
 		linux.SOL_IPV6,
 		linux.IPV6_HOPLIMIT,
 		t.Arch().Width(),
@@ -263,9 +292,10 @@ func PackHopLimit(t *kernel.Task, hoplimit uint32, buf []byte) []byte {
 }
 
 // PackIPPacketInfo packs an IP_PKTINFO socket control message.
-func PackIPPacketInfo(t *kernel.Task, packetInfo *linux.ControlMessageIPPacketInfo, buf []byte) []byte {
+func PackIPPacketInfo(t *kernel.Task, packetInfo *linux.ControlMessageIPPacketInfo, buf []byte, flags int) ([]byte, int) {
 	return putCmsgStruct(
 		buf,
+		flags,
 		linux.SOL_IP,
 		linux.IP_PKTINFO,
 		t.Arch().Width(),
@@ -274,9 +304,10 @@ func PackIPPacketInfo(t *kernel.Task, packetInfo *linux.ControlMessageIPPacketIn
 }
 
 // PackIPv6PacketInfo packs an IPV6_PKTINFO socket control message.
-func PackIPv6PacketInfo(t *kernel.Task, packetInfo *linux.ControlMessageIPv6PacketInfo, buf []byte) []byte {
+func PackIPv6PacketInfo(t *kernel.Task, packetInfo *linux.ControlMessageIPv6PacketInfo, buf []byte, flags int) ([]byte, int) {
 	return putCmsgStruct(
 		buf,
+		flags,
 		linux.SOL_IPV6,
 		linux.IPV6_PKTINFO,
 		t.Arch().Width(),
@@ -285,7 +316,7 @@ func PackIPv6PacketInfo(t *kernel.Task, packetInfo *linux.ControlMessageIPv6Pack
 }
 
 // PackOriginalDstAddress packs an IP_RECVORIGINALDSTADDR socket control message.
-func PackOriginalDstAddress(t *kernel.Task, originalDstAddress linux.SockAddr, buf []byte) []byte {
+func PackOriginalDstAddress(t *kernel.Task, originalDstAddress linux.SockAddr, buf []byte, flags int) ([]byte, int) {
 	var level uint32
 	var optType uint32
 	switch originalDstAddress.(type) {
@@ -299,13 +330,14 @@ func PackOriginalDstAddress(t *kernel.Task, originalDstAddress linux.SockAddr, b
 		panic("invalid address type, must be an IP address for IP_RECVORIGINALDSTADDR cmsg")
 	}
 	return putCmsgStruct(
-		buf, level, optType, t.Arch().Width(), originalDstAddress)
+		buf, flags, level, optType, t.Arch().Width(), originalDstAddress)
 }
 
 // PackSockExtendedErr packs an IP*_RECVERR socket control message.
-func PackSockExtendedErr(t *kernel.Task, sockErr linux.SockErrCMsg, buf []byte) []byte {
+func PackSockExtendedErr(t *kernel.Task, sockErr linux.SockErrCMsg, buf []byte, flags int) ([]byte, int) {
 	return putCmsgStruct(
 		buf,
+		flags,
 		sockErr.CMsgLevel(),
 		sockErr.CMsgType(),
 		t.Arch().Width(),
@@ -318,50 +350,59 @@ func PackSockExtendedErr(t *kernel.Task, sockErr linux.SockErrCMsg, buf []byte)
 // We skip control messages specific to Unix domain sockets.
 //
 // Note that some control messages may be truncated if they do not fit under
-// the capacity of buf.
-func PackControlMessages(t *kernel.Task, cmsgs socket.ControlMessages, buf []byte) []byte {
+// the capacity of buf, in which case MSG_CTRUNC is set in the returned flags.
+func PackControlMessages(t *kernel.Task, cmsgs socket.ControlMessages, buf []byte, flags int) ([]byte, int) {
 	if cmsgs.IP.HasTimestamp {
-		buf = PackTimestamp(t, cmsgs.IP.Timestamp, buf)
+		if cmsgs.IP.TimestampNS {
+			buf, flags = PackTimestampNS(t, cmsgs.IP.Timestamp, buf, flags)
+		} else {
+			buf, flags = PackTimestamp(t, cmsgs.IP.Timestamp, buf, flags)
+		}
+	}
+
+	if cmsgs.IP.HasDropCount {
+		// In Linux, SO_RXQ_OVFL is added after SO_TIMESTAMP.
+		buf, flags = PackDropCount(t, cmsgs.IP.DropCount, buf, flags)
 	}
 
 	if cmsgs.IP.HasInq {
 		// In Linux, TCP_CM_INQ is added after SO_TIMESTAMP.
-		buf = PackInq(t, cmsgs.IP.Inq, buf)
+		buf, flags = PackInq(t, cmsgs.IP.Inq, buf, flags)
 	}
 
 	if cmsgs.IP.HasTOS {
-		buf = PackTOS(t, cmsgs.IP.TOS, buf)
+		buf, flags = PackTOS(t, cmsgs.IP.TOS, buf, flags)
 	}
 
 	if cmsgs.IP.HasTTL {
-		buf = PackTTL(t, cmsgs.IP.TTL, buf)
+		buf, flags = PackTTL(t, cmsgs.IP.TTL, buf, flags)
 	}
 
 	if cmsgs.IP.HasTClass {
-		buf = PackTClass(t, cmsgs.IP.TClass, buf)
+		buf, flags = PackTClass(t, cmsgs.IP.TClass, buf, flags)
 	}
 
 	if cmsgs.IP.HasHopLimit {
-		buf = PackHopLimit(t, cmsgs.IP.HopLimit, buf)
+		buf, flags = PackHopLimit(t, cmsgs.IP.HopLimit, buf, flags)
 	}
 
 	if cmsgs.IP.HasIPPacketInfo {
-		buf = PackIPPacketInfo(t, &cmsgs.IP.PacketInfo, buf)
+		buf, flags = PackIPPacketInfo(t, &cmsgs.IP.PacketInfo, buf, flags)
 	}
 
 	if cmsgs.IP.HasIPv6PacketInfo {
-		buf = PackIPv6PacketInfo(t, &cmsgs.IP.IPv6PacketInfo, buf)
+		buf, flags = PackIPv6PacketInfo(t, &cmsgs.IP.IPv6PacketInfo, buf, flags)
 	}
 
 	if cmsgs.IP.OriginalDstAddress != nil {
-		buf = PackOriginalDstAddress(t, cmsgs.IP.OriginalDstAddress, buf)
+		buf, flags = PackOriginalDstAddress(t, cmsgs.IP.OriginalDstAddress, buf, flags)
 	}
 
 	if cmsgs.IP.SockErr != nil {
-		buf = PackSockExtendedErr(t, cmsgs.IP.SockErr, buf)
+		buf, flags = PackSockExtendedErr(t, cmsgs.IP.SockErr, buf, flags)
 	}
 
-	return buf
+	return buf, flags
 }
 
 // cmsgSpace is equivalent to CMSG_SPACE in Linux.
@@ -375,7 +416,15 @@ func CmsgsSpace(t *kernel.Task, cmsgs socket.ControlMessages) int {
 	space := 0
 
 	if cmsgs.IP.HasTimestamp {
-		space += cmsgSpace(t, linux.SizeOfTimeval)
+		if cmsgs.IP.TimestampNS {
+			space += cmsgSpace(t, linux.SizeOfTimespec)
+		} else {
+			space += cmsgSpace(t, linux.SizeOfTimeval)
+		}
+	}
+
+	if cmsgs.IP.HasDropCount {
+		space += cmsgSpace(t, linux.SizeOfControlMessageDropCount)
 	}
 
 	if cmsgs.IP.HasInq {
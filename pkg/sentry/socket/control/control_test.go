@@ -24,6 +24,7 @@ import (
 	"gvisor.dev/gvisor/pkg/binary"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
 	"gvisor.dev/gvisor/pkg/sentry/socket"
 )
 
@@ -78,3 +79,47 @@ func TestParseRightsNegativeLength(t *testing.T) {
 	}
 
 }
+
+func TestPutCmsgStructTruncation(t *testing.T) {
+	data := primitive.AllocateUint32(0x01020304)
+	full := linux.SizeOfControlMessageHeader + data.SizeBytes()
+	for _, tc := range []struct {
+		name      string
+		space     int
+		wantLen   int
+		wantFlags int
+	}{
+		{
+			name:    "fits",
+			space:   full + 4,
+			wantLen: full + 4,
+		},
+		{
+			name:      "truncated data",
+			space:     full - 2,
+			wantLen:   full - 2,
+			wantFlags: linux.MSG_CTRUNC,
+		},
+		{
+			name:      "no room for header",
+			space:     linux.SizeOfControlMessageHeader - 1,
+			wantLen:   0,
+			wantFlags: linux.MSG_CTRUNC,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf, flags := putCmsgStruct(make([]byte, 0, tc.space), 0, linux.SOL_SOCKET, linux.SO_RXQ_OVFL, 8 /* align */, data)
+			if len(buf) != tc.wantLen || flags != tc.wantFlags {
+				t.Fatalf("putCmsgStruct() = %d bytes, flags %#x, want %d bytes, flags %#x", len(buf), flags, tc.wantLen, tc.wantFlags)
+			}
+			if len(buf) == 0 {
+				return
+			}
+			var hdr linux.ControlMessageHeader
+			hdr.UnmarshalBytes(buf)
+			if want := uint64(min(full, tc.space)); hdr.Length != want {
+				t.Errorf("got cmsg_len %d, want %d", hdr.Length, want)
+			}
+		})
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/socket"
 	"gvisor.dev/gvisor/pkg/sentry/socket/control"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/sync"
 	"gvisor.dev/gvisor/pkg/syserr"
 	"gvisor.dev/gvisor/pkg/usermem"
 	"gvisor.dev/gvisor/pkg/waiter"
@@ -48,8 +49,42 @@ const (
 	// maxControlLen is the maximum size of a control message buffer used in a
 	// recvmsg or sendmsg unix.
 	maxControlLen = 1024
+
+	// maxMMsgLen is the maximum payload size of a message sent or received by
+	// SendMMsg or RecvMMsg, which is sufficient for any UDP datagram.
+	maxMMsgLen = 1 << 16
+
+	// maxMMsgBatch is the maximum number of messages transferred by a single
+	// host sendmmsg(2) or recvmmsg(2).
+	maxMMsgBatch = 64
+
+	// maxMMsgBatchLen is the maximum total payload size of the messages
+	// transferred by a single host sendmmsg(2) or recvmmsg(2).
+	maxMMsgBatchLen = 1 << 20
 )
 
+// mmsgBatch holds the buffers passed to a single host sendmmsg(2) or
+// recvmmsg(2). Batches are reused through mmsgBatchPool so that batched I/O
+// doesn't allocate on every call.
+type mmsgBatch struct {
+	buf   []byte
+	names [maxMMsgBatch * sizeofSockaddr]byte
+	iovs  [maxMMsgBatch]unix.Iovec
+	hdrs  [maxMMsgBatch]mmsghdr
+}
+
+var mmsgBatchPool = sync.Pool{
+	New: func() any {
+		return &mmsgBatch{buf: make([]byte, maxMMsgBatchLen)}
+	},
+}
+
+// reset clears the first n message headers of b.
+func (b *mmsgBatch) reset(n int) {
+	clear(b.iovs[:n])
+	clear(b.hdrs[:n])
+}
+
 // AllowedSocketType is a tuple of socket family, type, and protocol.
 type AllowedSocketType struct {
 	Family int
@@ -128,10 +163,10 @@ type Socket struct {
 
 var _ = socket.Socket(&Socket{})
 
-func newSocket(t *kernel.Task, family int, stype linux.SockType, protocol int, fd int, flags uint32) (*vfs.FileDescription, *syserr.Error) {
-	mnt := t.Kernel().SocketMount()
-	d := sockfs.NewDentry(t, mnt)
-	defer d.DecRef(t)
+func newSocket(ctx context.Context, family int, stype linux.SockType, protocol int, fd int, flags uint32) (*vfs.FileDescription, *syserr.Error) {
+	mnt := kernel.KernelFromContext(ctx).SocketMount()
+	d := sockfs.NewDentry(ctx, mnt)
+	defer d.DecRef(ctx)
 
 	s := &Socket{
 		family:   family,
@@ -596,6 +631,19 @@ func parseUnixControlMessages(unixControlMessages []unix.SocketControlMessage) s
 				ts := linux.Timeval{}
 				ts.UnmarshalUnsafe(unixCmsg.Data)
 				controlMessages.IP.Timestamp = ts.ToTime()
+
+			case linux.SO_TIMESTAMPNS:
+				controlMessages.IP.HasTimestamp = true
+				controlMessages.IP.TimestampNS = true
+				ts := linux.Timespec{}
+				ts.UnmarshalUnsafe(unixCmsg.Data)
+				controlMessages.IP.Timestamp = ts.ToTime()
+
+			case linux.SO_RXQ_OVFL:
+				controlMessages.IP.HasDropCount = true
+				var dropCount primitive.Uint32
+				dropCount.UnmarshalUnsafe(unixCmsg.Data)
+				controlMessages.IP.DropCount = uint32(dropCount)
 			}
 
 		case linux.SOL_IP:
@@ -709,7 +757,7 @@ func (s *Socket) SendMsg(t *kernel.Task, src usermem.IOSequence, to []byte, flag
 	}
 	controlBuf := make([]byte, 0, space)
 	// PackControlMessages will append up to space bytes to controlBuf.
-	controlBuf = control.PackControlMessages(t, controlMessages, controlBuf)
+	controlBuf, _ = control.PackControlMessages(t, controlMessages, controlBuf, 0)
 
 	sendmsgFromBlocks := safemem.WriterFunc(func(srcs safemem.BlockSeq) (uint64, error) {
 		// Refuse to do anything if any part of src.Addrs was unusable.
@@ -778,6 +826,203 @@ func (s *Socket) SendMsg(t *kernel.Task, src usermem.IOSequence, to []byte, flag
 	return int(n), syserr.FromError(err)
 }
 
+// SendMMsg implements socket.BatchSocket.SendMMsg.
+func (s *Socket) SendMMsg(t *kernel.Task, msgs []socket.MMsg, flags int, haveDeadline bool, deadline ktime.Time) (int, *syserr.Error) {
+	if s.family == linux.AF_PACKET {
+		// Don't allow SendMesg for AF_PACKET.
+		return 0, syserr.ErrPermissionDenied
+	}
+
+	// Only allow known and safe flags.
+	if flags&^allowedSendMsgFlags != 0 {
+		return 0, syserr.ErrInvalidArgument
+	}
+
+	// Copy in payloads up front so that they can be passed to a single host
+	// sendmmsg(2). Messages too large for this are left to SendMsg, which
+	// can propagate the host's error.
+	if msgs[0].Data.NumBytes() > maxMMsgLen {
+		n, err := s.SendMsg(t, msgs[0].Data, msgs[0].To, flags, haveDeadline, deadline, socket.ControlMessages{})
+		if err != nil {
+			return 0, err
+		}
+		msgs[0].N = n
+		return 1, nil
+	}
+	b := mmsgBatchPool.Get().(*mmsgBatch)
+	defer mmsgBatchPool.Put(b)
+	n := min(len(msgs), maxMMsgBatch)
+	b.reset(n)
+	off := 0
+	for i := 0; i < n; i++ {
+		msg := &msgs[i]
+		l := int(msg.Data.NumBytes())
+		if l > maxMMsgLen || off+l > len(b.buf) {
+			// Leave the remaining messages to the next call.
+			n = i
+			break
+		}
+		if l != 0 {
+			if _, err := msg.Data.CopyIn(t, b.buf[off:off+l]); err != nil {
+				if i == 0 {
+					return 0, syserr.FromError(err)
+				}
+				n = i
+				break
+			}
+			b.iovs[i].Base = &b.buf[off]
+			b.iovs[i].SetLen(l)
+		}
+		b.hdrs[i].hdr.Iov = &b.iovs[i]
+		b.hdrs[i].hdr.Iovlen = 1
+		if len(msg.To) != 0 {
+			b.hdrs[i].hdr.Name = &msg.To[0]
+			b.hdrs[i].hdr.Namelen = uint32(len(msg.To))
+		}
+		off += l
+	}
+	hdrs := b.hdrs[:n]
+
+	// We always do a non-blocking sendmmsg().
+	sysflags := flags | unix.MSG_DONTWAIT
+	var ch chan struct{}
+	sent := 0
+	for sent < len(hdrs) {
+		n, err := sendmmsg(s.fd, hdrs[sent:], sysflags)
+		if err == nil {
+			for i := sent; i < sent+n; i++ {
+				msgs[i].N = int(hdrs[i].len)
+			}
+			sent += n
+			continue
+		}
+		if flags&unix.MSG_DONTWAIT != 0 || !linuxerr.Equals(linuxerr.ErrWouldBlock, err) {
+			return sent, syserr.FromError(err)
+		}
+		if ch == nil {
+			var e waiter.Entry
+			e, ch = waiter.NewChannelEntry(waiter.WritableEvents | waiter.EventHUp | waiter.EventErr)
+			s.EventRegister(&e)
+			defer s.EventUnregister(&e)
+			continue
+		}
+		if err := t.BlockWithDeadline(ch, haveDeadline, deadline); err != nil {
+			if linuxerr.Equals(linuxerr.ETIMEDOUT, err) {
+				err = linuxerr.ErrWouldBlock
+			}
+			return sent, syserr.FromError(err)
+		}
+	}
+	return sent, nil
+}
+
+// RecvMMsg implements socket.BatchSocket.RecvMMsg.
+func (s *Socket) RecvMMsg(t *kernel.Task, msgs []socket.MMsg, flags int, haveDeadline bool, deadline ktime.Time) (int, *syserr.Error) {
+	// Only allow known and safe flags. Errors are only received by RecvMsg.
+	if flags&^allowedRecvMsgFlags != 0 || flags&unix.MSG_ERRQUEUE != 0 {
+		return 0, syserr.ErrInvalidArgument
+	}
+
+	b := mmsgBatchPool.Get().(*mmsgBatch)
+	defer mmsgBatchPool.Put(b)
+	received, count, err := s.recvMMsgBatch(t, b, msgs, flags, haveDeadline, deadline)
+	if err != nil {
+		return 0, err
+	}
+	// Keep receiving the messages that are already queued as long as
+	// batches are filled.
+	for received == count && received < len(msgs) {
+		var n int
+		n, count, err = s.recvMMsgBatch(t, b, msgs[received:], flags|unix.MSG_DONTWAIT, false /* haveDeadline */, ktime.Time{})
+		if err != nil {
+			break
+		}
+		received += n
+	}
+	return received, nil
+}
+
+// recvMMsgBatch receives messages into msgs with a single host recvmmsg(2)
+// using the buffers in b. It returns the number of messages received and the
+// number of messages the batch could hold.
+func (s *Socket) recvMMsgBatch(t *kernel.Task, b *mmsgBatch, msgs []socket.MMsg, flags int, haveDeadline bool, deadline ktime.Time) (int, int, *syserr.Error) {
+	count := min(len(msgs), maxMMsgBatch)
+	b.reset(count)
+	off := 0
+	for i := 0; i < count; i++ {
+		l := int(min(msgs[i].Data.NumBytes(), maxMMsgLen))
+		if off+l > len(b.buf) {
+			// Leave the remaining messages to the next call.
+			count = i
+			break
+		}
+		if l != 0 {
+			b.iovs[i].Base = &b.buf[off]
+			b.iovs[i].SetLen(l)
+			off += l
+		}
+		b.hdrs[i].hdr.Iov = &b.iovs[i]
+		b.hdrs[i].hdr.Iovlen = 1
+		if msgs[i].SenderRequested {
+			b.hdrs[i].hdr.Name = &b.names[i*sizeofSockaddr]
+			b.hdrs[i].hdr.Namelen = sizeofSockaddr
+		}
+	}
+	hdrs := b.hdrs[:count]
+
+	// We always do a non-blocking recvmmsg().
+	sysflags := flags | unix.MSG_DONTWAIT
+	var ch chan struct{}
+	n, err := recvmmsg(s.fd, hdrs, sysflags)
+	if flags&unix.MSG_DONTWAIT == 0 {
+		for linuxerr.Equals(linuxerr.ErrWouldBlock, err) {
+			// Are we closed for reading? No sense in trying to read if so.
+			if s.recvClosed.Load() {
+				break
+			}
+			if ch != nil {
+				if err = t.BlockWithDeadline(ch, haveDeadline, deadline); err != nil {
+					if linuxerr.Equals(linuxerr.ETIMEDOUT, err) {
+						err = linuxerr.ErrWouldBlock
+					}
+					break
+				}
+			} else {
+				var e waiter.Entry
+				e, ch = waiter.NewChannelEntry(waiter.ReadableEvents | waiter.EventRdHUp | waiter.EventHUp | waiter.EventErr)
+				s.EventRegister(&e)
+				defer s.EventUnregister(&e)
+			}
+			n, err = recvmmsg(s.fd, hdrs, sysflags)
+		}
+	}
+	if err != nil {
+		return 0, count, syserr.FromError(err)
+	}
+
+	// Copy out the received messages.
+	off = 0
+	for i := 0; i < n; i++ {
+		msg := &msgs[i]
+		hdr := &hdrs[i]
+		l := int(b.iovs[i].Len)
+		if _, err := msg.Data.CopyOut(t, b.buf[off:off+min(l, int(hdr.len))]); err != nil {
+			if i == 0 {
+				return 0, count, syserr.FromError(err)
+			}
+			return i, count, nil
+		}
+		off += l
+		msg.N = int(hdr.len)
+		msg.Flags = int(hdr.hdr.Flags)
+		if msg.SenderRequested && hdr.hdr.Namelen != 0 {
+			msg.From = socket.UnmarshalSockAddr(s.family, b.names[i*sizeofSockaddr:i*sizeofSockaddr+int(hdr.hdr.Namelen)])
+			msg.FromLen = hdr.hdr.Namelen
+		}
+	}
+	return n, count, nil
+}
+
 func translateIOSyscallError(err error) error {
 	if err == unix.EAGAIN || err == unix.EWOULDBLOCK {
 		return linuxerr.ErrWouldBlock
@@ -255,6 +255,31 @@ func recvmsg(fd int, msg *unix.Msghdr, flags int) (uint64, error) {
 	return uint64(n), nil
 }
 
+// mmsghdr is struct mmsghdr from include/linux/socket.h.
+type mmsghdr struct {
+	hdr unix.Msghdr
+	len uint32
+	_   [4]byte
+}
+
+// Preconditions: len(msgs) != 0.
+func recvmmsg(fd int, msgs []mmsghdr, flags int) (int, error) {
+	n, _, errno := unix.Syscall6(unix.SYS_RECVMMSG, uintptr(fd), uintptr(unsafe.Pointer(&msgs[0])), uintptr(len(msgs)), uintptr(flags), 0 /* timeout */, 0)
+	if errno != 0 {
+		return 0, translateIOSyscallError(errno)
+	}
+	return int(n), nil
+}
+
+// Preconditions: len(msgs) != 0.
+func sendmmsg(fd int, msgs []mmsghdr, flags int) (int, error) {
+	n, _, errno := unix.Syscall6(unix.SYS_SENDMMSG, uintptr(fd), uintptr(unsafe.Pointer(&msgs[0])), uintptr(len(msgs)), uintptr(flags), 0, 0)
+	if errno != 0 {
+		return 0, translateIOSyscallError(errno)
+	}
+	return int(n), nil
+}
+
 func sendmsg(fd int, msg *unix.Msghdr, flags int) (uint64, error) {
 	n, _, errno := unix.Syscall(unix.SYS_SENDMSG, uintptr(fd), uintptr(unsafe.Pointer(msg)), uintptr(flags))
 	if errno != 0 {
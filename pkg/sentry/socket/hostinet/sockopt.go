@@ -74,6 +74,7 @@ var SockOpts = []SockOpt{
 	{linux.SOL_IP, linux.SO_ORIGINAL_DST, uint64(linux.SockAddrInetSize), true, false},
 
 	{linux.SOL_IPV6, linux.IPV6_CHECKSUM, sizeofInt32, true, true},
+	{linux.SOL_IPV6, linux.IPV6_HDRINCL, sizeofInt32, true, true},
 	{linux.SOL_IPV6, linux.IPV6_MULTICAST_HOPS, sizeofInt32, true, true},
 	{linux.SOL_IPV6, linux.IPV6_RECVERR, sizeofInt32, true, true},
 	{linux.SOL_IPV6, linux.IPV6_RECVHOPLIMIT, sizeofInt32, true, true},
@@ -99,8 +100,10 @@ var SockOpts = []SockOpt{
 	{linux.SOL_SOCKET, linux.SO_RCVLOWAT, sizeofInt32, true, true},
 	{linux.SOL_SOCKET, linux.SO_REUSEADDR, sizeofInt32, true, true},
 	{linux.SOL_SOCKET, linux.SO_REUSEPORT, sizeofInt32, true, true},
+	{linux.SOL_SOCKET, linux.SO_RXQ_OVFL, sizeofInt32, true, true},
 	{linux.SOL_SOCKET, linux.SO_SNDBUF, sizeofInt32, true, true},
 	{linux.SOL_SOCKET, linux.SO_TIMESTAMP, sizeofInt32, true, true},
+	{linux.SOL_SOCKET, linux.SO_TIMESTAMPNS, sizeofInt32, true, true},
 
 	{linux.SOL_TCP, linux.TCP_CONGESTION, 0 /* string */, true, true},
 	{linux.SOL_TCP, linux.TCP_CORK, sizeofInt32, true, true},
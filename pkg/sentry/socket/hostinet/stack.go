@@ -54,6 +54,7 @@ type Stack struct {
 	tcpRecvBufSize inet.TCPBufferSize
 	tcpSendBufSize inet.TCPBufferSize
 	tcpSACKEnabled bool
+	ipv6AddrConf   inet.IPv6AddrConf
 	netDevFile     *os.File
 	netSNMPFile    *os.File
 	// allowedSocketTypes is the list of allowed socket types
@@ -98,6 +99,30 @@ func (s *Stack) Configure(allowRawSockets bool) error {
 		log.Warningf("Failed to read if TCP SACK if enabled, setting to true")
 	}
 
+	if s.supportsIPv6 {
+		for _, f := range []struct {
+			name string
+			val  *int32
+		}{
+			{"use_tempaddr", &s.ipv6AddrConf.UseTempAddr},
+			{"temp_valid_lft", &s.ipv6AddrConf.TempValidLifetime},
+			{"temp_prefered_lft", &s.ipv6AddrConf.TempPreferredLifetime},
+		} {
+			filename := "/proc/sys/net/ipv6/conf/default/" + f.name
+			contents, err := os.ReadFile(filename)
+			if err != nil {
+				log.Warningf("Failed to read %s: %v", filename, err)
+				continue
+			}
+			v, err := strconv.ParseInt(strings.TrimSpace(string(contents)), 10, 32)
+			if err != nil {
+				log.Warningf("Failed to parse %s (%q): %v", filename, contents, err)
+				continue
+			}
+			*f.val = int32(v)
+		}
+	}
+
 	if f, err := os.Open("/proc/net/dev"); err != nil {
 		log.Warningf("Failed to open /proc/net/dev: %v", err)
 	} else {
@@ -429,6 +454,16 @@ func (*Stack) SetPortRange(uint16, uint16) error {
 	return linuxerr.EACCES
 }
 
+// IPv6AddrConf implements inet.Stack.IPv6AddrConf.
+func (s *Stack) IPv6AddrConf() (inet.IPv6AddrConf, error) {
+	return s.ipv6AddrConf, nil
+}
+
+// SetIPv6AddrConf implements inet.Stack.SetIPv6AddrConf.
+func (*Stack) SetIPv6AddrConf(inet.IPv6AddrConf) error {
+	return linuxerr.EACCES
+}
+
 // EnableSaveRestore implements inet.Stack.EnableSaveRestore.
 func (*Stack) EnableSaveRestore() error {
 	return fmt.Errorf("s/r is not supported for hostinet")
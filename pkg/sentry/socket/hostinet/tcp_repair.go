@@ -0,0 +1,238 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostinet
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/syserr"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+)
+
+// RepairSockOpts are the host socket options used to take over TCP
+// connections with TCP_REPAIR. Unlike SockOpts, they aren't available to
+// applications.
+var RepairSockOpts = []SockOpt{
+	{linux.SOL_TCP, linux.TCP_REPAIR, sizeofInt32, false, true},
+	{linux.SOL_TCP, linux.TCP_REPAIR_QUEUE, sizeofInt32, false, true},
+	{linux.SOL_TCP, linux.TCP_QUEUE_SEQ, sizeofInt32, false, true},
+	{linux.SOL_TCP, linux.TCP_REPAIR_OPTIONS, 0 /* array of tcp_repair_opt */, false, true},
+	{linux.SOL_TCP, linux.TCP_REPAIR_WINDOW, linux.SizeOfTCPRepairWindow, false, true},
+	{linux.SOL_TCP, linux.TCP_TIMESTAMP, sizeofInt32, false, true},
+	{linux.SOL_SOCKET, linux.SO_SNDBUFFORCE, sizeofInt32, false, true},
+}
+
+// handoffWriteTimeout is how long writing the queues of a handed off
+// connection may wait for the host socket to become writable.
+const handoffWriteTimeout = 30 * time.Second
+
+// NewTCPSocketFromHandoff returns a host TCP socket that takes over the
+// established connection or listening endpoint described by h, e.g. a netstack
+// endpoint restored from a checkpoint taken with a different network mode.
+// Established connections are taken over with TCP_REPAIR, so the sandbox needs
+// CAP_NET_ADMIN in the host network namespace.
+func NewTCPSocketFromHandoff(ctx context.Context, h *tcp.Handoff, flags uint32) (*vfs.FileDescription, *syserr.Error) {
+	family := unix.AF_INET
+	if h.ID.LocalAddress.Len() == 16 {
+		family = unix.AF_INET6
+	}
+	fd, err := unix.Socket(family, unix.SOCK_STREAM|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, unix.IPPROTO_TCP)
+	if err != nil {
+		return nil, syserr.FromError(err)
+	}
+	if h.Listening {
+		err = listenTCP(fd, h)
+	} else {
+		err = repairTCP(fd, h)
+	}
+	if err != nil {
+		unix.Close(fd)
+		return nil, syserr.FromError(err)
+	}
+	file, serr := newSocket(ctx, family, linux.SOCK_STREAM, unix.IPPROTO_TCP, fd, flags)
+	if serr != nil {
+		return nil, serr
+	}
+	kernel.KernelFromContext(ctx).RecordSocket(file)
+	return file, nil
+}
+
+// listenTCP makes the unbound host TCP socket fd listen on the address of the
+// listening endpoint described by h.
+func listenTCP(fd int, h *tcp.Handoff) error {
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); err != nil {
+		return fmt.Errorf("setting SO_REUSEADDR: %w", err)
+	}
+	if err := unix.Bind(fd, handoffSockaddr(h.ID.LocalAddress, h.ID.LocalPort)); err != nil {
+		return fmt.Errorf("binding to %s:%d: %w", h.ID.LocalAddress, h.ID.LocalPort, err)
+	}
+	if err := unix.Listen(fd, h.Backlog); err != nil {
+		return fmt.Errorf("listening on %s:%d: %w", h.ID.LocalAddress, h.ID.LocalPort, err)
+	}
+	return nil
+}
+
+// repairTCP puts the connection described by h into the unconnected host TCP
+// socket fd, following the same steps as CRIU.
+func repairTCP(fd int, h *tcp.Handoff) error {
+	if err := unix.SetsockoptInt(fd, unix.SOL_TCP, linux.TCP_REPAIR, linux.TCP_REPAIR_ON); err != nil {
+		return fmt.Errorf("enabling TCP_REPAIR: %w", err)
+	}
+
+	// Sequence numbers can only be set before connecting. Data in the
+	// queues is accounted for once it's written below.
+	sent := h.SendQueue[:len(h.SendQueue)-h.Unsent]
+	for _, q := range []struct {
+		queue int
+		seq   uint32
+	}{
+		{linux.TCP_SEND_QUEUE, uint32(h.SndUna)},
+		{linux.TCP_RECV_QUEUE, uint32(h.RcvNxt) - uint32(len(h.RecvQueue))},
+	} {
+		if err := unix.SetsockoptInt(fd, unix.SOL_TCP, linux.TCP_REPAIR_QUEUE, q.queue); err != nil {
+			return fmt.Errorf("selecting queue %d: %w", q.queue, err)
+		}
+		if err := unix.SetsockoptInt(fd, unix.SOL_TCP, linux.TCP_QUEUE_SEQ, int(int32(q.seq))); err != nil {
+			return fmt.Errorf("setting sequence number of queue %d: %w", q.queue, err)
+		}
+	}
+
+	// In repair mode, connect doesn't send anything and moves the socket
+	// straight to the established state.
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); err != nil {
+		return fmt.Errorf("setting SO_REUSEADDR: %w", err)
+	}
+	if err := unix.Bind(fd, handoffSockaddr(h.ID.LocalAddress, h.ID.LocalPort)); err != nil {
+		return fmt.Errorf("binding to %s:%d: %w", h.ID.LocalAddress, h.ID.LocalPort, err)
+	}
+	if err := unix.Connect(fd, handoffSockaddr(h.ID.RemoteAddress, h.ID.RemotePort)); err != nil {
+		return fmt.Errorf("connecting to %s:%d: %w", h.ID.RemoteAddress, h.ID.RemotePort, err)
+	}
+
+	opts := []linux.TCPRepairOpt{{OptCode: linux.TCPOPT_MSS, OptVal: uint32(h.MSS)}}
+	if h.SndWndScale >= 0 {
+		opts = append(opts, linux.TCPRepairOpt{OptCode: linux.TCPOPT_WINDOW, OptVal: uint32(h.SndWndScale) | uint32(h.RcvWndScale)<<16})
+	}
+	if h.SACKPermitted {
+		opts = append(opts, linux.TCPRepairOpt{OptCode: linux.TCPOPT_SACK_PERM})
+	}
+	if h.Timestamps {
+		opts = append(opts, linux.TCPRepairOpt{OptCode: linux.TCPOPT_TIMESTAMP})
+	}
+	buf := make([]byte, 0, len(opts)*(*linux.TCPRepairOpt)(nil).SizeBytes())
+	for i := range opts {
+		buf = append(buf, make([]byte, opts[i].SizeBytes())...)
+		opts[i].MarshalUnsafe(buf[len(buf)-opts[i].SizeBytes():])
+	}
+	if err := unix.SetsockoptString(fd, unix.SOL_TCP, linux.TCP_REPAIR_OPTIONS, string(buf)); err != nil {
+		return fmt.Errorf("setting TCP options: %w", err)
+	}
+	if h.Timestamps {
+		if err := unix.SetsockoptInt(fd, unix.SOL_TCP, linux.TCP_TIMESTAMP, int(int32(h.TSVal))); err != nil {
+			return fmt.Errorf("setting timestamp: %w", err)
+		}
+	}
+
+	// Data written to the send queue in repair mode is considered sent but
+	// unacknowledged, while unsent data is written normally below. Nothing is
+	// acknowledged in repair mode, so the buffers must hold the queues.
+	if sndbuf := 2 * len(h.SendQueue); sndbuf > 0 {
+		if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_SNDBUFFORCE, sndbuf); err != nil {
+			return fmt.Errorf("setting SO_SNDBUFFORCE: %w", err)
+		}
+	}
+	if rcvbuf := 2 * len(h.RecvQueue); rcvbuf > 0 {
+		if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUFFORCE, rcvbuf); err != nil {
+			return fmt.Errorf("setting SO_RCVBUFFORCE: %w", err)
+		}
+	}
+	for _, q := range []struct {
+		queue int
+		data  []byte
+	}{
+		{linux.TCP_SEND_QUEUE, sent},
+		{linux.TCP_RECV_QUEUE, h.RecvQueue},
+	} {
+		if err := unix.SetsockoptInt(fd, unix.SOL_TCP, linux.TCP_REPAIR_QUEUE, q.queue); err != nil {
+			return fmt.Errorf("selecting queue %d: %w", q.queue, err)
+		}
+		if err := writeAll(fd, q.data); err != nil {
+			return fmt.Errorf("restoring queue %d: %w", q.queue, err)
+		}
+	}
+
+	window := linux.TCPRepairWindow{
+		SndWl1:    uint32(h.RcvNxt),
+		SndWnd:    uint32(h.SndWnd),
+		MaxWindow: uint32(h.SndWnd),
+		RcvWnd:    uint32(h.RcvWnd),
+		RcvWup:    uint32(h.RcvNxt),
+	}
+	windowBuf := make([]byte, window.SizeBytes())
+	window.MarshalUnsafe(windowBuf)
+	if err := unix.SetsockoptString(fd, unix.SOL_TCP, linux.TCP_REPAIR_WINDOW, string(windowBuf)); err != nil {
+		return fmt.Errorf("setting windows: %w", err)
+	}
+
+	if err := unix.SetsockoptInt(fd, unix.SOL_TCP, linux.TCP_REPAIR, linux.TCP_REPAIR_OFF); err != nil {
+		return fmt.Errorf("disabling TCP_REPAIR: %w", err)
+	}
+	if err := writeAll(fd, h.SendQueue[len(sent):]); err != nil {
+		return fmt.Errorf("sending unsent data: %w", err)
+	}
+	return nil
+}
+
+// handoffSockaddr converts a handoff address to a host socket address.
+func handoffSockaddr(addr tcpip.Address, port uint16) unix.Sockaddr {
+	if addr.Len() == 16 {
+		return &unix.SockaddrInet6{Addr: addr.As16(), Port: int(port)}
+	}
+	return &unix.SockaddrInet4{Addr: addr.As4(), Port: int(port)}
+}
+
+// writeAll writes b to the non-blocking host socket fd, waiting for up to
+// handoffWriteTimeout for fd to become writable whenever it's full.
+func writeAll(fd int, b []byte) error {
+	for len(b) > 0 {
+		n, err := unix.Write(fd, b)
+		if err == unix.EINTR {
+			continue
+		}
+		if err == unix.EAGAIN {
+			pfd := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLOUT}}
+			ready, err := unix.Poll(pfd, int(handoffWriteTimeout.Milliseconds()))
+			if err != nil && err != unix.EINTR {
+				return err
+			}
+			if ready == 0 && err == nil {
+				return fmt.Errorf("timed out after %v waiting for the socket to be writable", handoffWriteTimeout)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package genetlink provides a NETLINK_GENERIC socket protocol.
+//
+// Generic netlink multiplexes several families over a single netlink
+// protocol. Applications resolve a family's dynamically assigned ID from its
+// name using the built-in controller family ("nlctrl"). The only other
+// family implemented is TASKSTATS.
+package genetlink
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/syserr"
+)
+
+// operation describes a command supported by a family.
+type operation struct {
+	cmd   uint8
+	flags uint32
+}
+
+// family describes a generic netlink family.
+type family struct {
+	id      uint16
+	name    string
+	version uint32
+	hdrSize uint32
+	maxAttr uint32
+	ops     []operation
+}
+
+var (
+	ctrlFamily = family{
+		id:      linux.GENL_ID_CTRL,
+		name:    linux.GENL_CTRL_NAME,
+		version: linux.GENL_CTRL_VERSION,
+		maxAttr: linux.CTRL_ATTR_MCAST_GROUPS,
+		ops: []operation{
+			{cmd: linux.CTRL_CMD_GETFAMILY, flags: linux.GENL_CMD_CAP_DO | linux.GENL_CMD_CAP_DUMP},
+		},
+	}
+
+	taskstatsFamily = family{
+		id:      linux.GENL_START_ALLOC,
+		name:    linux.TASKSTATS_GENL_NAME,
+		version: linux.TASKSTATS_GENL_VERSION,
+		maxAttr: linux.TASKSTATS_CMD_ATTR_DEREGISTER_CPUMASK,
+		ops: []operation{
+			{cmd: linux.TASKSTATS_CMD_GET, flags: linux.GENL_ADMIN_PERM | linux.GENL_CMD_CAP_DO | linux.GENL_CMD_CAP_HASPOL},
+		},
+	}
+
+	families = []*family{&ctrlFamily, &taskstatsFamily}
+)
+
+// op returns the operation for cmd, or nil if f doesn't support cmd.
+func (f *family) op(cmd uint8) *operation {
+	for i := range f.ops {
+		if f.ops[i].cmd == cmd {
+			return &f.ops[i]
+		}
+	}
+	return nil
+}
+
+// Protocol implements netlink.Protocol.
+//
+// +stateify savable
+type Protocol struct{}
+
+var _ netlink.Protocol = (*Protocol)(nil)
+
+// NewProtocol creates a NETLINK_GENERIC netlink.Protocol.
+func NewProtocol(t *kernel.Task) (netlink.Protocol, *syserr.Error) {
+	return &Protocol{}, nil
+}
+
+// Protocol implements netlink.Protocol.Protocol.
+func (p *Protocol) Protocol() int {
+	return linux.NETLINK_GENERIC
+}
+
+// CanSend implements netlink.Protocol.CanSend.
+func (p *Protocol) CanSend() bool {
+	return true
+}
+
+// ProcessMessage implements netlink.Protocol.ProcessMessage.
+func (p *Protocol) ProcessMessage(ctx context.Context, s *netlink.Socket, msg *nlmsg.Message, ms *nlmsg.MessageSet) *syserr.Error {
+	hdr := msg.Header()
+
+	var f *family
+	for _, fam := range families {
+		if fam.id == hdr.Type {
+			f = fam
+			break
+		}
+	}
+	if f == nil {
+		return syserr.ErrNoFileOrDir
+	}
+
+	var genlHdr linux.GenlMsgHeader
+	attrs, ok := msg.GetData(&genlHdr)
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+
+	// See net/netlink/genetlink.c:genl_family_rcv_msg().
+	op := f.op(genlHdr.Cmd)
+	if op == nil {
+		return syserr.ErrNotSupported
+	}
+	if op.flags&linux.GENL_ADMIN_PERM != 0 {
+		creds := auth.CredentialsFromContext(ctx)
+		if !creds.HasCapability(linux.CAP_NET_ADMIN) {
+			return syserr.ErrNotPermitted
+		}
+	}
+	dump := hdr.Flags&linux.NLM_F_DUMP == linux.NLM_F_DUMP
+	if dump && op.flags&linux.GENL_CMD_CAP_DUMP == 0 {
+		return syserr.ErrNotSupported
+	}
+
+	switch f.id {
+	case linux.GENL_ID_CTRL:
+		if dump {
+			return dumpFamilies(ms)
+		}
+		return getFamily(attrs, ms)
+	case taskstatsFamily.id:
+		return getTaskstats(ctx, attrs, ms)
+	default:
+		return syserr.ErrNotSupported
+	}
+}
+
+// getFamily handles CTRL_CMD_GETFAMILY requests for a single family.
+func getFamily(attrs nlmsg.AttrsView, ms *nlmsg.MessageSet) *syserr.Error {
+	params, ok := attrs.Parse()
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+
+	// See net/netlink/genetlink.c:ctrl_getfamily().
+	err := syserr.ErrInvalidArgument
+	var f *family
+	if v, ok := params[linux.CTRL_ATTR_FAMILY_ID]; ok {
+		err = syserr.ErrNoFileOrDir
+		id, ok := v.Uint16()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		for _, fam := range families {
+			if fam.id == id {
+				f = fam
+				break
+			}
+		}
+	}
+	if v, ok := params[linux.CTRL_ATTR_FAMILY_NAME]; ok && f == nil {
+		err = syserr.ErrNoFileOrDir
+		name := v.String()
+		for _, fam := range families {
+			if fam.name == name {
+				f = fam
+				break
+			}
+		}
+	}
+	if f == nil {
+		return err
+	}
+
+	f.putFamily(ms.AddMessage(linux.NetlinkMessageHeader{
+		Type: linux.GENL_ID_CTRL,
+	}))
+	return nil
+}
+
+// dumpFamilies handles CTRL_CMD_GETFAMILY dump requests.
+func dumpFamilies(ms *nlmsg.MessageSet) *syserr.Error {
+	ms.Multi = true
+	for _, f := range families {
+		f.putFamily(ms.AddMessage(linux.NetlinkMessageHeader{
+			Type: linux.GENL_ID_CTRL,
+		}))
+	}
+	return nil
+}
+
+// putFamily fills m with a CTRL_CMD_NEWFAMILY message describing f. See
+// net/netlink/genetlink.c:ctrl_fill_info().
+func (f *family) putFamily(m *nlmsg.Message) {
+	m.Put(&linux.GenlMsgHeader{
+		Cmd:     linux.CTRL_CMD_NEWFAMILY,
+		Version: linux.GENL_CTRL_VERSION,
+	})
+	m.PutAttrString(linux.CTRL_ATTR_FAMILY_NAME, f.name)
+	m.PutAttr(linux.CTRL_ATTR_FAMILY_ID, primitive.AllocateUint16(f.id))
+	m.PutAttr(linux.CTRL_ATTR_VERSION, primitive.AllocateUint32(f.version))
+	m.PutAttr(linux.CTRL_ATTR_HDRSIZE, primitive.AllocateUint32(f.hdrSize))
+	m.PutAttr(linux.CTRL_ATTR_MAXATTR, primitive.AllocateUint32(f.maxAttr))
+
+	ops := m.NestStart(linux.CTRL_ATTR_OPS)
+	for i, op := range f.ops {
+		nest := m.NestStart(uint16(i + 1))
+		m.PutAttr(linux.CTRL_ATTR_OP_ID, primitive.AllocateUint32(uint32(op.cmd)))
+		m.PutAttr(linux.CTRL_ATTR_OP_FLAGS, primitive.AllocateUint32(op.flags))
+		m.NestEnd(nest)
+	}
+	m.NestEnd(ops)
+}
+
+// init registers the NETLINK_GENERIC provider.
+func init() {
+	netlink.RegisterProvider(linux.NETLINK_GENERIC, NewProtocol)
+}
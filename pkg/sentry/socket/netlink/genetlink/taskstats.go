@@ -0,0 +1,171 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genetlink
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/ktime"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/syserr"
+)
+
+// getTaskstats handles TASKSTATS_CMD_GET requests. See
+// kernel/taskstats.c:taskstats_user_cmd().
+func getTaskstats(ctx context.Context, attrs nlmsg.AttrsView, ms *nlmsg.MessageSet) *syserr.Error {
+	params, ok := attrs.Parse()
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+
+	t := kernel.TaskFromContext(ctx)
+	if t == nil {
+		return syserr.ErrInvalidArgument
+	}
+	pidns := t.PIDNamespace()
+	userns := t.UserNamespace()
+	now := t.Kernel().RealtimeClock().Now()
+
+	var (
+		stats  linux.Taskstats
+		aggr   uint16
+		idType uint16
+		id     uint32
+	)
+	if v, ok := params[linux.TASKSTATS_CMD_ATTR_PID]; ok {
+		if id, ok = v.Uint32(); !ok {
+			return syserr.ErrInvalidArgument
+		}
+		target := pidns.TaskWithID(kernel.ThreadID(id))
+		if target == nil {
+			return syserr.ErrNoProcess
+		}
+		fillTaskStats(&stats, target, pidns, userns, now)
+		aggr, idType = linux.TASKSTATS_TYPE_AGGR_PID, linux.TASKSTATS_TYPE_PID
+	} else if v, ok := params[linux.TASKSTATS_CMD_ATTR_TGID]; ok {
+		if id, ok = v.Uint32(); !ok {
+			return syserr.ErrInvalidArgument
+		}
+		tg := pidns.ThreadGroupWithID(kernel.ThreadID(id))
+		if tg == nil {
+			return syserr.ErrNoProcess
+		}
+		fillThreadGroupStats(&stats, tg, now)
+		aggr, idType = linux.TASKSTATS_TYPE_AGGR_TGID, linux.TASKSTATS_TYPE_TGID
+	} else if _, ok := params[linux.TASKSTATS_CMD_ATTR_REGISTER_CPUMASK]; ok {
+		// Statistics of exiting tasks are not broadcast, so there is nothing
+		// to register listeners for.
+		return syserr.ErrNotSupported
+	} else if _, ok := params[linux.TASKSTATS_CMD_ATTR_DEREGISTER_CPUMASK]; ok {
+		return syserr.ErrNotSupported
+	} else {
+		return syserr.ErrInvalidArgument
+	}
+
+	m := ms.AddMessage(linux.NetlinkMessageHeader{
+		Type: taskstatsFamily.id,
+	})
+	m.Put(&linux.GenlMsgHeader{
+		Cmd:     linux.TASKSTATS_CMD_NEW,
+		Version: linux.TASKSTATS_GENL_VERSION,
+	})
+	nest := m.NestStart(aggr)
+	m.PutAttr(idType, primitive.AllocateUint32(id))
+	m.PutAttr(linux.TASKSTATS_TYPE_STATS, &stats)
+	m.NestEnd(nest)
+	return nil
+}
+
+// fillTaskStats fills ts with the statistics of t at time now. IDs are
+// translated into pidns and userns. See kernel/taskstats.c:fill_stats().
+func fillTaskStats(ts *linux.Taskstats, t *kernel.Task, pidns *kernel.PIDNamespace, userns *auth.UserNamespace, now ktime.Time) {
+	ts.Version = linux.TASKSTATS_VERSION
+	ts.AcNice = uint8(t.Niceness())
+
+	// Delay accounting. The sentry does not observe time spent runnable but
+	// waiting for a CPU, so no CPU delay is ever reported.
+	cpu := t.CPUStats()
+	run := uint64((cpu.UserTime + cpu.SysTime).Nanoseconds())
+	delay := t.DelayUsage()
+	ts.BlkioCount = delay.BlkIOCount.Load()
+	ts.BlkioDelayTotal = delay.BlkIODelay.Load()
+	ts.CPURunRealTotal = run
+	ts.CPURunVirtualTotal = run
+
+	// Basic accounting.
+	copy(ts.AcComm[:], t.Name())
+	creds := t.Credentials()
+	ts.AcUID = uint32(creds.RealKUID.In(userns).OrOverflow())
+	ts.AcGID = uint32(creds.RealKGID.In(userns).OrOverflow())
+	ts.AcPID = uint32(pidns.IDOfTask(t))
+	if parent := t.Parent(); parent != nil {
+		ts.AcPPID = uint32(pidns.IDOfThreadGroup(parent.ThreadGroup()))
+	}
+	start := t.StartTime()
+	ts.AcBtime = uint32(start.Seconds())
+	ts.AcBtime64 = uint64(start.Seconds())
+	ts.AcEtime = uint64(now.Sub(start).Microseconds())
+	ts.AcUtime = uint64(cpu.UserTime.Microseconds())
+	ts.AcStime = uint64(cpu.SysTime.Microseconds())
+	ts.AcMinflt = cpu.MinorFaults
+	ts.AcMajflt = cpu.MajorFaults
+	ts.AcUtimescaled = ts.AcUtime
+	ts.AcStimescaled = ts.AcStime
+	ts.CPUScaledRunRealTotal = run
+	ts.Nvcsw = cpu.VoluntarySwitches
+	ts.Nivcsw = cpu.InvoluntarySwitches
+
+	// Extended accounting, in KB.
+	ts.HiwaterRSS = t.MaxRSS(linux.RUSAGE_SELF) / 1024
+
+	// I/O accounting.
+	io := t.IOUsage()
+	ts.ReadChar = io.CharsRead.Load()
+	ts.WriteChar = io.CharsWritten.Load()
+	ts.ReadSyscalls = io.ReadSyscalls.Load()
+	ts.WriteSyscalls = io.WriteSyscalls.Load()
+	ts.ReadBytes = io.BytesRead.Load()
+	ts.WriteBytes = io.BytesWritten.Load()
+	ts.CancelledWriteBytes = io.BytesWriteCancelled.Load()
+}
+
+// fillThreadGroupStats fills ts with the statistics of tg at time now. As in
+// Linux, only delay accounting, elapsed time, CPU times and context switches
+// are aggregated across the thread group. See
+// kernel/taskstats.c:fill_stats_for_tgid().
+func fillThreadGroupStats(ts *linux.Taskstats, tg *kernel.ThreadGroup, now ktime.Time) {
+	ts.Version = linux.TASKSTATS_VERSION
+
+	cpu := tg.CPUStats()
+	run := uint64((cpu.UserTime + cpu.SysTime).Nanoseconds())
+	delay := tg.DelayUsage()
+	ts.BlkioCount = delay.BlkIOCount.Load()
+	ts.BlkioDelayTotal = delay.BlkIODelay.Load()
+	ts.CPURunRealTotal = run
+	ts.CPURunVirtualTotal = run
+
+	// Elapsed time is summed over live tasks.
+	tg.ForEachTask(func(t *kernel.Task) bool {
+		ts.AcEtime += uint64(now.Sub(t.StartTime()).Microseconds())
+		return true
+	})
+	ts.AcUtime = uint64(cpu.UserTime.Microseconds())
+	ts.AcStime = uint64(cpu.SysTime.Microseconds())
+	ts.Nvcsw = cpu.VoluntarySwitches
+	ts.Nivcsw = cpu.InvoluntarySwitches
+}
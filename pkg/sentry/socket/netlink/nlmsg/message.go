@@ -173,6 +173,28 @@ func (m *Message) PutAttr(atype uint16, v marshal.Marshallable) {
 	m.putZeros(aligned - l)
 }
 
+// NestStart starts a nested netlink attribute of type atype. Attributes
+// subsequently added to the message are nested within it until NestEnd is
+// called with the returned offset. See Linux's nla_nest_start_noflag().
+func (m *Message) NestStart(atype uint16) int {
+	off := len(m.buf)
+	m.Put(&linux.NetlinkAttrHeader{
+		Type: atype,
+	})
+	return off
+}
+
+// NestEnd finalizes the length of the nested attribute started by the
+// NestStart call that returned off.
+func (m *Message) NestEnd(off int) {
+	l := len(m.buf) - off
+	if l > math.MaxUint16 {
+		panic(fmt.Sprintf("attribute too large: %d", l))
+	}
+	// Length is the first 2 bytes of the attribute header.
+	hostarch.ByteOrder.PutUint16(m.buf[off:], uint16(l))
+}
+
 // PutAttrString adds s to the message as a netlink attribute.
 func (m *Message) PutAttrString(atype uint16, s string) {
 	l := linux.NetlinkAttrHeaderSize + len(s) + 1
@@ -312,6 +334,17 @@ func (v *BytesView) String() string {
 	return string(b)
 }
 
+// Uint16 converts the raw attribute value to uint16.
+func (v *BytesView) Uint16() (uint16, bool) {
+	attr := []byte(*v)
+	val := primitive.Uint16(0)
+	if len(attr) != val.SizeBytes() {
+		return 0, false
+	}
+	val.UnmarshalBytes(attr)
+	return uint16(val), true
+}
+
 // Uint32 converts the raw attribute value to uint32.
 func (v *BytesView) Uint32() (uint32, bool) {
 	attr := []byte(*v)
@@ -373,3 +373,46 @@ func TestBytesView(t *testing.T) {
 		}
 	}
 }
+
+func TestNestedAttr(t *testing.T) {
+	msg := nlmsg.NewMessage(linux.NetlinkMessageHeader{})
+	start := len(msg.Finalize())
+	off := msg.NestStart(4)
+	msg.PutAttr(1, primitive.AllocateUint32(7))
+	msg.PutAttrString(2, "ab")
+	msg.NestEnd(off)
+
+	want := []byte{
+		0x14, 0x00, // Length
+		0x04, 0x00, // Type
+		0x08, 0x00, // Length
+		0x01, 0x00, // Type
+		0x07, 0x00, 0x00, 0x00, // Data
+		0x07, 0x00, // Length
+		0x02, 0x00, // Type
+		0x61, 0x62, 0x00, 0x00, // Data with 1 byte padding
+	}
+	if got := msg.Finalize()[start:]; !bytes.Equal(got, want) {
+		t.Errorf("Nested attribute got %v, want %v", got, want)
+	}
+
+	attrs := nlmsg.AttrsView(want)
+	hdr, value, rest, ok := attrs.ParseFirst()
+	if !ok || hdr.Type != 4 || !rest.Empty() {
+		t.Fatalf("ParseFirst got (%+v, %v, %v), want type 4 and no remaining attributes", hdr, rest, ok)
+	}
+	nested, ok := nlmsg.AttrsView(value).Parse()
+	if !ok {
+		t.Fatalf("Parse of nested attributes failed")
+	}
+	if v, ok := nested[1]; !ok {
+		t.Errorf("Nested attribute 1 missing")
+	} else if got, _ := v.Uint32(); got != 7 {
+		t.Errorf("Nested attribute 1 got %d, want 7", got)
+	}
+	if v, ok := nested[2]; !ok {
+		t.Errorf("Nested attribute 2 missing")
+	} else if got := v.String(); got != "ab" {
+		t.Errorf("Nested attribute 2 got %q, want %q", got, "ab")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netstack
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/bpf"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/socket"
+	"gvisor.dev/gvisor/pkg/syserr"
+)
+
+// sizeOfSockFprog is the size of struct sock_fprog on 64-bit architectures.
+const sizeOfSockFprog = 16
+
+// socketFilter is a classic BPF program attached to a socket with
+// SO_ATTACH_FILTER.
+//
+// +stateify savable
+type socketFilter struct {
+	prog bpf.Program
+}
+
+// Filter implements tcpip.PacketFilter.Filter.
+func (f *socketFilter) Filter(data []byte) int {
+	ret, err := bpf.Exec[bpf.BigEndian](f.prog, bpf.Input(data))
+	if err != nil {
+		// Out of bounds loads drop the packet, as in Linux.
+		return 0
+	}
+	return int(ret)
+}
+
+// supportsSocketFilter returns true if packets received by s are passed
+// through the filter attached to it.
+func supportsSocketFilter(s socket.Socket) bool {
+	family, skType, _ := s.Type()
+	return family == linux.AF_PACKET || skType == linux.SOCK_RAW
+}
+
+// attachSocketFilter implements setsockopt(SO_ATTACH_FILTER). optVal holds a
+// struct sock_fprog.
+func attachSocketFilter(t *kernel.Task, ep commonEndpoint, optVal []byte) *syserr.Error {
+	if len(optVal) < sizeOfSockFprog {
+		return syserr.ErrInvalidArgument
+	}
+	n := hostarch.ByteOrder.Uint16(optVal[0:2])
+	addr := hostarch.ByteOrder.Uint64(optVal[8:16])
+	if n == 0 || n > bpf.MaxInstructions {
+		return syserr.ErrInvalidArgument
+	}
+	insns := make([]linux.BPFInstruction, int(n))
+	if _, err := linux.CopyBPFInstructionSliceIn(t, hostarch.Addr(addr), insns); err != nil {
+		return syserr.FromError(err)
+	}
+	bpfInsns := make([]bpf.Instruction, len(insns))
+	for i, ins := range insns {
+		bpfInsns[i] = bpf.Instruction(ins)
+	}
+	prog, err := bpf.Compile(bpfInsns, true /* optimize */)
+	if err != nil {
+		t.Debugf("Invalid socket filter: %v", err)
+		return syserr.ErrInvalidArgument
+	}
+	return syserr.TranslateNetstackError(ep.SocketOptions().SetFilter(&socketFilter{prog: prog}))
+}
@@ -41,6 +41,7 @@ import (
 	"gvisor.dev/gvisor/pkg/context"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/eventchannel"
+	"gvisor.dev/gvisor/pkg/faultinject"
 	"gvisor.dev/gvisor/pkg/hostarch"
 	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/marshal"
@@ -54,6 +55,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/ktime"
 	"gvisor.dev/gvisor/pkg/sentry/memmap"
 	"gvisor.dev/gvisor/pkg/sentry/socket"
+	"gvisor.dev/gvisor/pkg/sentry/socket/control"
 	"gvisor.dev/gvisor/pkg/sentry/socket/netfilter"
 	epb "gvisor.dev/gvisor/pkg/sentry/socket/netstack/events_go_proto"
 	"gvisor.dev/gvisor/pkg/sentry/socket/netstack/packetmmap"
@@ -426,6 +428,10 @@ type sock struct {
 	// false, the same timestamp is instead stored and can be read via the
 	// SIOCGSTAMP ioctl. It is protected by readMu. See socket(7).
 	sockOptTimestamp bool
+	// sockOptTimestampNS corresponds to SO_TIMESTAMPNS. When true,
+	// sockOptTimestamp is also true and timestamps are returned with
+	// nanosecond precision. It is protected by readMu.
+	sockOptTimestampNS bool
 	// timestampValid indicates whether timestamp for SIOCGSTAMP has been
 	// set. It is protected by readMu.
 	timestampValid bool
@@ -472,6 +478,14 @@ func New(t *kernel.Task, family int, skType linux.SockType, protocol int, queue
 	return vfsfd, nil
 }
 
+// EndpointFromFile returns the endpoint of file if it's a netstack socket.
+func EndpointFromFile(file *vfs.FileDescription) (tcpip.Endpoint, bool) {
+	if s, ok := file.Impl().(*sock); ok {
+		return s.Endpoint, true
+	}
+	return nil, false
+}
+
 // Release implements vfs.FileDescriptionImpl.Release.
 func (s *sock) Release(ctx context.Context) {
 	kernel.KernelFromContext(ctx).DeleteSocket(&s.vfsfd)
@@ -490,8 +504,9 @@ func (s *sock) Release(ctx context.Context) {
 		// 1. The endpoint state is not in any of the states: FIN-WAIT1,
 		// CLOSING and LAST_ACK.
 		// 2. Timeout is reached.
-		if v.Enabled && v.Timeout != 0 {
-			t := kernel.TaskFromContext(ctx)
+		// There is no task to block if the socket is released outside of a
+		// task, e.g. when it's handed off on restore.
+		if t := kernel.TaskFromContext(ctx); v.Enabled && v.Timeout != 0 && t != nil {
 			start := t.Kernel().MonotonicClock().Now()
 			deadline := start.Add(v.Timeout)
 			_ = t.BlockWithDeadline(ch, true, deadline)
@@ -559,9 +574,22 @@ func (s *sock) Write(ctx context.Context, src usermem.IOSequence, opts vfs.Write
 	return n, nil
 }
 
+// injectFault injects the fault configured in chaos mode, if any, into the
+// socket operation called name. See pkg/faultinject.
+func injectFault(t *kernel.Task, name string) *syserr.Error {
+	if !faultinject.Enabled() {
+		return nil
+	}
+	return syserr.FromError(t.InjectFault(faultinject.LayerNetstack, name))
+}
+
 // Accept implements the linux syscall accept(2) for sockets backed by
 // tcpip.Endpoint.
 func (s *sock) Accept(t *kernel.Task, peerRequested bool, flags int, blocking bool) (int32, linux.SockAddr, uint32, *syserr.Error) {
+	if err := injectFault(t, "accept"); err != nil {
+		return 0, nil, 0, err
+	}
+
 	// Issue the accept request to get the new endpoint.
 	var peerAddr *tcpip.FullAddress
 	if peerRequested {
@@ -614,14 +642,16 @@ func (s *sock) GetSockOpt(t *kernel.Task, level, name int, outPtr hostarch.Addr,
 	// commonEndpoint. commonEndpoint should be extended to support socket
 	// options where the implementation is not shared, as unix sockets need
 	// their own support for SO_TIMESTAMP.
-	if level == linux.SOL_SOCKET && name == linux.SO_TIMESTAMP {
+	if level == linux.SOL_SOCKET && (name == linux.SO_TIMESTAMP || name == linux.SO_TIMESTAMPNS) {
 		if outLen < sizeOfInt32 {
 			return nil, syserr.ErrInvalidArgument
 		}
 		val := primitive.Int32(0)
 		s.readMu.Lock()
 		defer s.readMu.Unlock()
-		if s.sockOptTimestamp {
+		// Like Linux, SO_TIMESTAMP and SO_TIMESTAMPNS are mutually exclusive.
+		if name == linux.SO_TIMESTAMPNS && s.sockOptTimestampNS ||
+			name == linux.SO_TIMESTAMP && s.sockOptTimestamp && !s.sockOptTimestampNS {
 			val = 1
 		}
 		return &val, nil
@@ -650,13 +680,16 @@ func (s *sock) SetSockOpt(t *kernel.Task, level int, name int, optVal []byte) *s
 	// commonEndpoint. commonEndpoint should be extended to support socket
 	// options where the implementation is not shared, as unix sockets need
 	// their own support for SO_TIMESTAMP.
-	if level == linux.SOL_SOCKET && name == linux.SO_TIMESTAMP {
+	if level == linux.SOL_SOCKET && (name == linux.SO_TIMESTAMP || name == linux.SO_TIMESTAMPNS) {
 		if len(optVal) < sizeOfInt32 {
 			return syserr.ErrInvalidArgument
 		}
 		s.readMu.Lock()
 		defer s.readMu.Unlock()
+		// Enabling either option selects its format, and disabling either
+		// option disables timestamps entirely, as in Linux.
 		s.sockOptTimestamp = hostarch.ByteOrder.Uint32(optVal) != 0
+		s.sockOptTimestampNS = s.sockOptTimestamp && name == linux.SO_TIMESTAMPNS
 		return nil
 	}
 	if level == linux.SOL_TCP && name == linux.TCP_INQ {
@@ -736,6 +769,10 @@ func (s *sock) mapFamily(addr tcpip.FullAddress, family uint16) tcpip.FullAddres
 // Connect implements the linux syscall connect(2) for sockets backed by
 // tpcip.Endpoint.
 func (s *sock) Connect(t *kernel.Task, sockaddr []byte, blocking bool) *syserr.Error {
+	if err := injectFault(t, "connect"); err != nil {
+		return err
+	}
+
 	addr, family, err := socket.AddressAndFamily(sockaddr)
 	if err != nil {
 		return err
@@ -1080,6 +1117,22 @@ func getSockOptSocket(t *kernel.Task, s socket.Socket, ep commonEndpoint, family
 		v := primitive.Int32(boolToInt32(ep.SocketOptions().GetKeepAlive()))
 		return &v, nil
 
+	case linux.SO_RXQ_OVFL:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v := primitive.Int32(boolToInt32(ep.SocketOptions().GetReceiveOverflow()))
+		return &v, nil
+
+	case linux.SO_LOCK_FILTER:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v := primitive.Int32(boolToInt32(ep.SocketOptions().GetFilterLocked()))
+		return &v, nil
+
 	case linux.SO_LINGER:
 		if outLen < linux.SizeOfLinger {
 			return nil, syserr.ErrInvalidArgument
@@ -1532,6 +1585,17 @@ func getSockOptIPv6(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int
 		v := primitive.Int32(boolToInt32(ep.SocketOptions().GetIPv6ReceivePacketInfo()))
 		return &v, nil
 
+	case linux.IPV6_HDRINCL:
+		if skType != linux.SOCK_RAW {
+			return nil, syserr.ErrUnknownProtocolOption
+		}
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v := primitive.Int32(boolToInt32(ep.SocketOptions().GetHeaderIncluded()))
+		return &v, nil
+
 	case linux.IP6T_ORIGINAL_DST:
 		if outLen < linux.SockAddrInet6Size {
 			return nil, syserr.ErrInvalidArgument
@@ -2081,6 +2145,15 @@ func setSockOptSocket(t *kernel.Task, s socket.Socket, ep commonEndpoint, name i
 		ep.SocketOptions().SetKeepAlive(v != 0)
 		return nil
 
+	case linux.SO_RXQ_OVFL:
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+
+		v := hostarch.ByteOrder.Uint32(optVal)
+		ep.SocketOptions().SetReceiveOverflow(v != 0)
+		return nil
+
 	case linux.SO_SNDTIMEO:
 		if len(optVal) < linux.SizeOfTimeval {
 			return syserr.ErrInvalidArgument
@@ -2139,11 +2212,30 @@ func setSockOptSocket(t *kernel.Task, s socket.Socket, ep commonEndpoint, name i
 		})
 		return nil
 
+	case linux.SO_ATTACH_FILTER:
+		if !supportsSocketFilter(s) {
+			// Filters are ignored by other sockets.
+			incrementBadSetSocketOptionMetric(t, &socketLevelSocketFieldValue, name)
+			return nil
+		}
+		return attachSocketFilter(t, ep, optVal)
+
 	case linux.SO_DETACH_FILTER:
+		if supportsSocketFilter(s) {
+			return syserr.TranslateNetstackError(ep.SocketOptions().SetFilter(nil))
+		}
 		// optval is ignored.
 		var v tcpip.SocketDetachFilterOption
 		return syserr.TranslateNetstackError(ep.SetSockOpt(&v))
 
+	case linux.SO_LOCK_FILTER:
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+
+		v := hostarch.ByteOrder.Uint32(optVal)
+		return syserr.TranslateNetstackError(ep.SocketOptions().SetFilterLocked(v != 0))
+
 	// TODO(b/226603727): Add support for SO_RCVLOWAT option. For now, only
 	// the unsupported syscall message is removed.
 	case linux.SO_RCVLOWAT:
@@ -2162,23 +2254,19 @@ func setSockOptSocket(t *kernel.Task, s socket.Socket, ep commonEndpoint, name i
 		linux.SO_BSDCOMPAT,
 		linux.SO_PEERCRED,
 		linux.SO_SNDLOWAT,
-		linux.SO_ATTACH_FILTER,
 		linux.SO_PEERNAME,
 		linux.SO_TIMESTAMP,
 		linux.SO_ACCEPTCONN,
 		linux.SO_PEERSEC,
 		linux.SO_SNDBUFFORCE,
 		linux.SO_PASSSEC,
-		linux.SO_TIMESTAMPNS,
 		linux.SO_MARK,
 		linux.SO_TIMESTAMPING,
 		linux.SO_PROTOCOL,
 		linux.SO_DOMAIN,
-		linux.SO_RXQ_OVFL,
 		linux.SO_WIFI_STATUS,
 		linux.SO_PEEK_OFF,
 		linux.SO_NOFCS,
-		linux.SO_LOCK_FILTER,
 		linux.SO_SELECT_ERR_QUEUE,
 		linux.SO_BUSY_POLL,
 		linux.SO_MAX_PACING_RATE,
@@ -2512,6 +2600,18 @@ func setSockOptIPv6(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int
 		ep.SocketOptions().SetIPv6ReceivePacketInfo(v != 0)
 		return nil
 
+	case linux.IPV6_HDRINCL:
+		if _, skType, _ := s.Type(); skType != linux.SOCK_RAW {
+			return syserr.ErrUnknownProtocolOption
+		}
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+		v := int32(hostarch.ByteOrder.Uint32(optVal))
+
+		ep.SocketOptions().SetHeaderIncluded(v != 0)
+		return nil
+
 	case linux.IPV6_UNICAST_HOPS:
 		if len(optVal) < sizeOfInt32 {
 			return syserr.ErrInvalidArgument
@@ -2834,6 +2934,9 @@ func setSockOptIP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int,
 		return nil
 
 	case linux.IP_HDRINCL:
+		if _, skType, _ := s.Type(); skType != linux.SOCK_RAW {
+			return syserr.ErrUnknownProtocolOption
+		}
 		if len(optVal) == 0 {
 			return nil
 		}
@@ -3163,6 +3266,7 @@ func (s *sock) netstackToLinuxControlMessages(cm tcpip.ReceivableControlMessages
 	return socket.ControlMessages{
 		IP: socket.IPControlMessages{
 			HasTimestamp:       readCM.HasTimestamp && s.sockOptTimestamp,
+			TimestampNS:        s.sockOptTimestampNS,
 			Timestamp:          readCM.Timestamp,
 			HasInq:             readCM.HasInq,
 			Inq:                readCM.Inq,
@@ -3180,6 +3284,8 @@ func (s *sock) netstackToLinuxControlMessages(cm tcpip.ReceivableControlMessages
 			IPv6PacketInfo:     readCM.IPv6PacketInfo,
 			OriginalDstAddress: readCM.OriginalDstAddress,
 			SockErr:            readCM.SockErr,
+			HasDropCount:       readCM.HasDropCount,
+			DropCount:          readCM.DropCount,
 		},
 	}
 }
@@ -3264,6 +3370,9 @@ func (s *sock) recvErr(t *kernel.Task, dst usermem.IOSequence) (int, int, linux.
 // RecvMsg implements the linux syscall recvmsg(2) for sockets backed by
 // tcpip.Endpoint.
 func (s *sock) RecvMsg(t *kernel.Task, dst usermem.IOSequence, flags int, haveDeadline bool, deadline ktime.Time, senderRequested bool, _ uint64) (n int, msgFlags int, senderAddr linux.SockAddr, senderAddrLen uint32, controlMessages socket.ControlMessages, err *syserr.Error) {
+	if err := injectFault(t, "recvmsg"); err != nil {
+		return 0, 0, nil, 0, socket.ControlMessages{}, err
+	}
 	if flags&linux.MSG_ERRQUEUE != 0 {
 		return s.recvErr(t, dst)
 	}
@@ -3336,23 +3445,18 @@ func (s *sock) RecvMsg(t *kernel.Task, dst usermem.IOSequence, flags int, haveDe
 // SendMsg implements the linux syscall sendmsg(2) for sockets backed by
 // tcpip.Endpoint.
 func (s *sock) SendMsg(t *kernel.Task, src usermem.IOSequence, to []byte, flags int, haveDeadline bool, deadline ktime.Time, controlMessages socket.ControlMessages) (int, *syserr.Error) {
+	if err := injectFault(t, "sendmsg"); err != nil {
+		return 0, err
+	}
+
 	// Reject Unix control messages.
 	if !controlMessages.Unix.Empty() {
 		return 0, syserr.ErrInvalidArgument
 	}
 
-	var addr *tcpip.FullAddress
-	if len(to) > 0 {
-		addrBuf, family, err := socket.AddressAndFamily(to)
-		if err != nil {
-			return 0, err
-		}
-		if !s.checkFamily(family, false /* exact */) {
-			return 0, syserr.ErrInvalidArgument
-		}
-		addrBuf = s.mapFamily(addrBuf, family)
-
-		addr = &addrBuf
+	addr, err := s.sendAddress(to)
+	if err != nil {
+		return 0, err
 	}
 
 	opts := tcpip.WriteOptions{
@@ -3407,6 +3511,108 @@ func (s *sock) SendMsg(t *kernel.Task, src usermem.IOSequence, to []byte, flags
 	}
 }
 
+// sendAddress returns the destination address for a message sent to the
+// given sockaddr, or nil if to is empty.
+func (s *sock) sendAddress(to []byte) (*tcpip.FullAddress, *syserr.Error) {
+	if len(to) == 0 {
+		return nil, nil
+	}
+	addr, family, err := socket.AddressAndFamily(to)
+	if err != nil {
+		return nil, err
+	}
+	if !s.checkFamily(family, false /* exact */) {
+		return nil, syserr.ErrInvalidArgument
+	}
+	addr = s.mapFamily(addr, family)
+	return &addr, nil
+}
+
+// SendMMsg implements socket.BatchSocket.SendMMsg.
+func (s *sock) SendMMsg(t *kernel.Task, msgs []socket.MMsg, flags int, haveDeadline bool, deadline ktime.Time) (int, *syserr.Error) {
+	if err := injectFault(t, "sendmsg"); err != nil {
+		return 0, err
+	}
+
+	// Write the messages back to back, registering for notification at most
+	// once for the whole batch. Writes of datagrams are all or nothing.
+	var (
+		entry waiter.Entry
+		ch    <-chan struct{}
+	)
+	for i := range msgs {
+		msg := &msgs[i]
+		addr, err := s.sendAddress(msg.To)
+		if err != nil {
+			return i, err
+		}
+		opts := tcpip.WriteOptions{
+			To:          addr,
+			More:        flags&linux.MSG_MORE != 0,
+			EndOfRecord: flags&linux.MSG_EOR != 0,
+		}
+		r := msg.Data.Reader(t)
+		for {
+			n, err := s.Endpoint.Write(r, opts)
+			if _, ok := err.(*tcpip.ErrWouldBlock); !ok || flags&linux.MSG_DONTWAIT != 0 {
+				if err != nil {
+					return i, syserr.TranslateNetstackError(err)
+				}
+				msg.N = int(n)
+				break
+			}
+			if ch == nil {
+				// Don't wait immediately after registration in case the
+				// endpoint became writable in the meantime.
+				entry, ch = waiter.NewChannelEntry(waiter.WritableEvents)
+				s.EventRegister(&entry)
+				defer s.EventUnregister(&entry)
+				continue
+			}
+			if err := t.BlockWithDeadline(ch, haveDeadline, deadline); err != nil {
+				if linuxerr.Equals(linuxerr.ETIMEDOUT, err) {
+					return i, syserr.ErrTryAgain
+				}
+				return i, syserr.FromError(err)
+			}
+		}
+	}
+	return len(msgs), nil
+}
+
+// RecvMMsg implements socket.BatchSocket.RecvMMsg.
+func (s *sock) RecvMMsg(t *kernel.Task, msgs []socket.MMsg, flags int, haveDeadline bool, deadline ktime.Time) (int, *syserr.Error) {
+	if !s.isPacketBased() || flags&linux.MSG_ERRQUEUE != 0 {
+		return 0, syserr.ErrInvalidArgument
+	}
+
+	// Block for the first message, then dequeue the messages that are
+	// already queued without blocking or re-registering for events.
+	m := &msgs[0]
+	var cms socket.ControlMessages
+	var err *syserr.Error
+	m.N, m.Flags, m.From, m.FromLen, cms, err = s.RecvMsg(t, m.Data, flags, haveDeadline, deadline, m.SenderRequested, 0)
+	if err != nil {
+		return 0, err
+	}
+	if control.CmsgsSpace(t, cms) != 0 {
+		m.Flags |= linux.MSG_CTRUNC
+	}
+	trunc := flags&linux.MSG_TRUNC != 0
+	peek := flags&linux.MSG_PEEK != 0
+	for i := 1; i < len(msgs); i++ {
+		m := &msgs[i]
+		m.N, m.Flags, m.From, m.FromLen, cms, err = s.nonBlockingRead(t, m.Data, peek, trunc, m.SenderRequested)
+		if err != nil {
+			return i, nil
+		}
+		if control.CmsgsSpace(t, cms) != 0 {
+			m.Flags |= linux.MSG_CTRUNC
+		}
+	}
+	return len(msgs), nil
+}
+
 // Ioctl implements vfs.FileDescriptionImpl.
 func (s *sock) Ioctl(ctx context.Context, uio usermem.IO, sysno uintptr, args arch.SyscallArguments) (uintptr, error) {
 	t := kernel.TaskFromContext(ctx)
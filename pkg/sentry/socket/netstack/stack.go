@@ -16,6 +16,8 @@ package netstack
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/context"
@@ -24,6 +26,7 @@ import (
 	"gvisor.dev/gvisor/pkg/refs"
 	"gvisor.dev/gvisor/pkg/sentry/inet"
 	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/sync"
 	"gvisor.dev/gvisor/pkg/syserr"
 	"gvisor.dev/gvisor/pkg/tcpip"
 	"gvisor.dev/gvisor/pkg/tcpip/header"
@@ -41,6 +44,15 @@ import (
 // +stateify savable
 type Stack struct {
 	Stack *stack.Stack `state:".(*stack.Stack)"`
+
+	// pauseMu serializes Pause and Resume.
+	pauseMu sync.Mutex `state:"nosave"`
+
+	// pauses is the number of calls to Pause that have not yet been matched by
+	// a call to Resume.
+	//
+	// +checklocks:pauseMu
+	pauses int `state:"nosave"`
 }
 
 // EnableSaveRestore enables netstack s/r.
@@ -357,6 +369,39 @@ func (s *Stack) newBridge(ctx context.Context, linkAttrs map[uint16]nlmsg.BytesV
 	return nil
 }
 
+func (s *Stack) newVRF(ctx context.Context, linkAttrs map[uint16]nlmsg.BytesView, linkInfoAttrs map[uint16]nlmsg.BytesView) *syserr.Error {
+	var table uint32
+	if value, ok := linkInfoAttrs[linux.IFLA_INFO_DATA]; ok {
+		linkInfoData, ok := nlmsg.AttrsView(value).Parse()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		if v, ok := linkInfoData[linux.IFLA_VRF_TABLE]; ok {
+			if table, ok = v.Uint32(); !ok {
+				return syserr.ErrInvalidArgument
+			}
+		}
+	}
+	// As in Linux, a VRF must have a routing table.
+	if table == 0 {
+		return syserr.ErrInvalidArgument
+	}
+
+	ifname := ""
+	if v, ok := linkAttrs[linux.IFLA_IFNAME]; ok {
+		ifname = v.String()
+	}
+	ep := stack.NewVRFEndpoint(table)
+	id := s.Stack.NextNICID()
+	err := s.Stack.CreateNICWithOptions(id, ep, stack.NICOptions{
+		Name: ifname,
+	})
+	if err != nil {
+		return syserr.TranslateNetstackError(err)
+	}
+	return s.setLink(ctx, id, linkAttrs)
+}
+
 func (s *Stack) newInterface(ctx context.Context, msg *nlmsg.Message, linkAttrs map[uint16]nlmsg.BytesView) *syserr.Error {
 	var (
 		linkInfoAttrs map[uint16]nlmsg.BytesView
@@ -388,6 +433,8 @@ func (s *Stack) newInterface(ctx context.Context, msg *nlmsg.Message, linkAttrs
 		return s.newBridge(ctx, linkAttrs, linkInfoAttrs)
 	case "veth":
 		return s.newVeth(ctx, linkAttrs, linkInfoAttrs)
+	case "vrf":
+		return s.newVRF(ctx, linkAttrs, linkInfoAttrs)
 	}
 	return syserr.ErrNotSupported
 }
@@ -915,9 +962,14 @@ func (s *Stack) IPTables() (*stack.IPTables, error) {
 	return s.Stack.IPTables(), nil
 }
 
-// Pause implements inet.Stack.Pause.
+// Pause implements inet.Stack.Pause. Calls to Pause nest.
 func (s *Stack) Pause() {
-	s.Stack.Pause()
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	s.pauses++
+	if s.pauses == 1 {
+		s.Stack.Pause()
+	}
 }
 
 // Restore implements inet.Stack.Restore.
@@ -933,8 +985,17 @@ func (s *Stack) ReplaceConfig(st inet.Stack) {
 	s.Stack.ReplaceConfig(st.(*Stack).Stack)
 }
 
-// Resume implements inet.Stack.Resume.
+// Resume implements inet.Stack.Resume. The stack only resumes once every
+// call to Pause has been matched by a call to Resume.
 func (s *Stack) Resume() {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	if s.pauses > 0 {
+		s.pauses--
+		if s.pauses > 0 {
+			return
+		}
+	}
 	s.Stack.Resume()
 }
 
@@ -970,3 +1031,63 @@ func (s *Stack) PortRange() (uint16, uint16) {
 func (s *Stack) SetPortRange(start uint16, end uint16) error {
 	return syserr.TranslateNetstackError(s.Stack.SetPortRange(start, end)).ToError()
 }
+
+// ndpEndpoints returns the IPv6 NDP endpoints of all NICs, ordered by NIC ID.
+func (s *Stack) ndpEndpoints() []ipv6.NDPEndpoint {
+	var ids []tcpip.NICID
+	for id := range s.Stack.NICInfo() {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	var eps []ipv6.NDPEndpoint
+	for _, id := range ids {
+		ep, err := s.Stack.GetNetworkEndpoint(id, ipv6.ProtocolNumber)
+		if err != nil {
+			continue
+		}
+		if ndpEP, ok := ep.(ipv6.NDPEndpoint); ok {
+			eps = append(eps, ndpEP)
+		}
+	}
+	return eps
+}
+
+// IPv6AddrConf implements inet.Stack.IPv6AddrConf.
+func (s *Stack) IPv6AddrConf() (inet.IPv6AddrConf, error) {
+	// All interfaces share the same settings, see SetIPv6AddrConf.
+	c := ipv6.DefaultNDPConfigurations()
+	if eps := s.ndpEndpoints(); len(eps) > 0 {
+		c = eps[0].NDPConfigurations()
+	}
+	conf := inet.IPv6AddrConf{
+		TempValidLifetime:     int32(c.MaxTempAddrValidLifetime / time.Second),
+		TempPreferredLifetime: int32(c.MaxTempAddrPreferredLifetime / time.Second),
+	}
+	switch {
+	case !c.AutoGenTempGlobalAddresses:
+		conf.UseTempAddr = 0
+	case c.PreferPublicAddresses:
+		conf.UseTempAddr = 1
+	default:
+		conf.UseTempAddr = 2
+	}
+	return conf, nil
+}
+
+// SetIPv6AddrConf implements inet.Stack.SetIPv6AddrConf.
+func (s *Stack) SetIPv6AddrConf(conf inet.IPv6AddrConf) error {
+	if conf.TempValidLifetime < 0 || conf.TempPreferredLifetime < 0 {
+		return linuxerr.EINVAL
+	}
+	for _, ep := range s.ndpEndpoints() {
+		c := ep.NDPConfigurations()
+		c.AutoGenTempGlobalAddresses = conf.UseTempAddr > 0
+		c.PreferPublicAddresses = conf.UseTempAddr == 1
+		// Lifetimes below the minimums allowed by netstack are clamped when the
+		// configuration is validated.
+		c.MaxTempAddrValidLifetime = time.Duration(conf.TempValidLifetime) * time.Second
+		c.MaxTempAddrPreferredLifetime = time.Duration(conf.TempPreferredLifetime) * time.Second
+		ep.SetNDPConfigurations(c)
+	}
+	return nil
+}
@@ -141,6 +141,8 @@ func NewIPControlMessages(family int, cmgs tcpip.ReceivableControlMessages) IPCo
 		HasIPv6PacketInfo:  cmgs.HasIPv6PacketInfo,
 		OriginalDstAddress: orgDstAddr,
 		SockErr:            sockErrCmsgToLinux(cmgs.SockErr),
+		HasDropCount:       cmgs.HasDropCount,
+		DropCount:          cmgs.DropCount,
 	}
 
 	if cm.HasIPv6PacketInfo {
@@ -162,6 +164,10 @@ type IPControlMessages struct {
 	// was received.
 	Timestamp time.Time `state:".(int64)"`
 
+	// TimestampNS indicates whether Timestamp is returned as SCM_TIMESTAMPNS
+	// rather than SCM_TIMESTAMP.
+	TimestampNS bool
+
 	// HasInq indicates whether Inq is valid/set.
 	HasInq bool
 
@@ -210,6 +216,13 @@ type IPControlMessages struct {
 
 	// SockErr is the dequeued socket error on recvmsg(MSG_ERRQUEUE).
 	SockErr linux.SockErrCMsg
+
+	// HasDropCount indicates whether DropCount is valid/set.
+	HasDropCount bool
+
+	// DropCount is the number of packets dropped by the socket before the
+	// associated packet was queued (SO_RXQ_OVFL).
+	DropCount uint32
 }
 
 // Release releases Unix domain socket credentials and rights.
@@ -300,6 +313,56 @@ type Socket interface {
 	Type() (family int, skType linux.SockType, protocol int)
 }
 
+// MMsg is a single message sent by BatchSocket.SendMMsg or received by
+// BatchSocket.RecvMMsg.
+type MMsg struct {
+	// Data is the message payload for SendMMsg, or the buffer receiving it
+	// for RecvMMsg.
+	Data usermem.IOSequence
+
+	// To is the destination address for SendMMsg. If empty, the socket must be
+	// connected.
+	To []byte
+
+	// SenderRequested is set for RecvMMsg if the sender address should be
+	// returned in From and FromLen.
+	SenderRequested bool
+
+	// The following fields are set when the message has been transferred.
+	//
+	// N is the number of bytes sent or received; for datagrams received by
+	// RecvMMsg, N may be greater than Data.NumBytes() if MSG_TRUNC is set in
+	// the flags passed to RecvMMsg. Flags are the message flags returned by
+	// RecvMMsg. From and FromLen are the sender address and its length to be
+	// returned to the application.
+	N       int
+	Flags   int
+	From    linux.SockAddr
+	FromLen uint32
+}
+
+// BatchSocket is implemented by datagram sockets that can transfer multiple
+// messages more efficiently than one at a time, e.g. using a single host
+// sendmmsg(2) or recvmmsg(2). Messages transferred through BatchSocket never
+// carry control messages.
+type BatchSocket interface {
+	Socket
+
+	// SendMMsg sends msgs in order and returns the number of messages sent.
+	// Unless flags contains MSG_DONTWAIT, it blocks until all messages are
+	// sent or the deadline expires. It may return fewer than len(msgs)
+	// messages with a nil error, in which case the caller retries with the
+	// remaining messages; otherwise err describes why msgs[n] could not be
+	// sent.
+	SendMMsg(t *kernel.Task, msgs []MMsg, flags int, haveDeadline bool, deadline ktime.Time) (n int, err *syserr.Error)
+
+	// RecvMMsg receives up to len(msgs) messages in order and returns the
+	// number of messages received. Unless flags contains MSG_DONTWAIT, it
+	// blocks until at least one message is received or the deadline expires;
+	// it then returns the messages that can be received without blocking.
+	RecvMMsg(t *kernel.Task, msgs []MMsg, flags int, haveDeadline bool, deadline ktime.Time) (n int, err *syserr.Error)
+}
+
 // Provider is the interface implemented by providers of sockets for
 // specific address families (e.g., AF_INET).
 type Provider interface {
@@ -19,6 +19,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"strconv"
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/context"
@@ -33,6 +34,32 @@ import (
 
 var previousMetadata map[string]string
 
+// VersionKey is the key of the save metadata holding the state version.
+const VersionKey = "state_version"
+
+// PageChecksumsKey is the key of the save metadata holding the algorithm of
+// the page checksums saved with MemoryFile metadata, if any.
+const PageChecksumsKey = "page_checksums"
+
+// PageChecksumsCRC32C is the value of PageChecksumsKey for CRC-32C page
+// checksums.
+const PageChecksumsCRC32C = "crc32c"
+
+// Version is the version of the saved state format. Checkpoints saved with
+// state versions from MinVersion to Version can be restored.
+//
+// Version must be incremented by releases that change the fields of savable
+// types, along with registering the corresponding migrations with
+// state.RegisterMigrations. MinVersion must be raised to Version when a
+// change can't be migrated.
+//
+// Version 2 added page checksums to pages files. Version 3 registered
+// migrations for fields added to savable types since version 1.
+const (
+	Version    = 3
+	MinVersion = 1
+)
+
 // ErrStateFile is returned when an error is encountered writing the statefile
 // (which may occur during open or close calls in addition to write).
 type ErrStateFile struct {
@@ -94,9 +121,22 @@ func (opts SaveOpts) Save(ctx context.Context, k *kernel.Kernel, w *watchdog.Wat
 		opts.Metadata = make(map[string]string)
 	}
 	addSaveMetadata(opts.Metadata)
+	opts.Metadata[VersionKey] = strconv.Itoa(Version)
+	if opts.PagesFile != nil {
+		// Pages files are not covered by the statefile's integrity check, so
+		// always checksum their contents.
+		opts.MemoryFileSaveOpts.PageChecksums = true
+		opts.Metadata[PageChecksumsKey] = PageChecksumsCRC32C
+	}
 
-	// Open the statefile.
-	wc, err := statefile.NewWriter(opts.Destination, opts.Key, opts.Metadata)
+	// Open the statefile. Without a pages file, pages are saved to the
+	// statefile, so compress it with as many goroutines as would write
+	// pages.
+	workers := 0
+	if opts.PagesFile == nil {
+		workers = opts.MemoryFileSaveOpts.PageWriters
+	}
+	wc, err := statefile.NewWriterWithWorkers(opts.Destination, opts.Key, opts.Metadata, workers)
 	if err != nil {
 		err = ErrStateFile{err}
 	} else {
@@ -285,7 +285,7 @@ var linuxARM64 = SyscallMap{
 	272: makeSyscallInfo("kcmp", Hex, Hex, Hex, Hex, Hex),
 	273: makeSyscallInfo("finit_module", Hex, Hex, Hex),
 	274: makeSyscallInfo("sched_setattr", Hex, Hex, Hex),
-	275: makeSyscallInfo("sched_getattr", Hex, Hex, Hex),
+	275: makeSyscallInfo("sched_getattr", Hex, Hex, Hex, Hex),
 	276: makeSyscallInfo("renameat2", FD, Path, Hex, Path, Hex),
 	277: makeSyscallInfo("seccomp", Hex, Hex, Hex),
 	278: makeSyscallInfo("getrandom", Hex, Hex, Hex),
@@ -314,8 +314,10 @@ var linuxARM64 = SyscallMap{
 	434: makeSyscallInfo("pidfd_open", Hex, Hex),
 	435: makeSyscallInfo("clone3", Hex, Hex),
 	436: makeSyscallInfo("close_range", FD, FD, CloseRangeFlags),
+	438: makeSyscallInfo("pidfd_getfd", FD, FD, Hex),
 	439: makeSyscallInfo("faccessat2", FD, Path, Oct, Hex),
 	441: makeSyscallInfo("epoll_pwait2", FD, EpollEvents, Hex, Timespec, SigSet),
+	449: makeSyscallInfo("futex_waitv", Hex, Hex, Hex, Timespec, Hex),
 }
 
 func init() {
@@ -18,20 +18,26 @@ package strace
 
 import (
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"google.golang.org/protobuf/encoding/protojson"
+
 	"gvisor.dev/gvisor/pkg/abi"
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/bits"
 	"gvisor.dev/gvisor/pkg/eventchannel"
+	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/marshal/primitive"
 	"gvisor.dev/gvisor/pkg/seccomp"
 	"gvisor.dev/gvisor/pkg/sentry/arch"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
 	pb "gvisor.dev/gvisor/pkg/sentry/strace/strace_go_proto"
 	slinux "gvisor.dev/gvisor/pkg/sentry/syscalls/linux"
+	"gvisor.dev/gvisor/pkg/sync"
 
 	"gvisor.dev/gvisor/pkg/hostarch"
 )
@@ -52,6 +58,52 @@ var EventMaximumSize uint
 // logs is allowed.
 var LogAppDataAllowed = true
 
+// taskFilter, if not nil, is the set of thread IDs, in the root PID
+// namespace, of the tasks and thread groups whose system calls are traced. It
+// is set by SetTaskFilter.
+var taskFilter atomic.Pointer[map[kernel.ThreadID]struct{}]
+
+// containerFilter, if not nil, is the set of container IDs whose system calls
+// are traced. It is set by SetContainerFilter.
+var containerFilter atomic.Pointer[map[string]struct{}]
+
+// sampleRate is N such that only one in N traced system calls of each task is
+// logged. 0 and 1 both mean that all system calls are logged. It is set by
+// SetSampleRate.
+var sampleRate atomic.Uint32
+
+// logFile, if not nil, receives log sink output in place of the sentry debug
+// log. It is set by SetLogFile.
+var logFile atomic.Pointer[jsonWriter]
+
+// jsonWriter writes Strace messages to a file as JSON, one per line.
+type jsonWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// write writes event to w. It returns false if event couldn't be written, in
+// which case it has been logged to the sentry debug log instead. If w fails,
+// it is no longer used and all output goes to the sentry debug log.
+func (j *jsonWriter) write(event *pb.Strace) bool {
+	b, err := protojson.MarshalOptions{Multiline: false}.Marshal(event)
+	if err != nil {
+		log.Warningf("Failed to marshal strace event: %v", err)
+		return false
+	}
+	b = append(b, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.w.Write(b); err != nil {
+		if logFile.CompareAndSwap(j, nil) {
+			log.Warningf("Failed to write to the strace log, falling back to the debug log: %v", err)
+		}
+		return false
+	}
+	return true
+}
+
 // ItimerTypes are the possible itimer types.
 var ItimerTypes = abi.ValueSet{
 	linux.ITIMER_REAL:    "ITIMER_REAL",
@@ -554,6 +606,10 @@ func (i *SyscallInfo) post(t *kernel.Task, args arch.SyscallArguments, rval uint
 func (i *SyscallInfo) printEnter(t *kernel.Task, args arch.SyscallArguments) []string {
 	output := i.pre(t, args, LogMaximumSize)
 
+	if f := logFile.Load(); f != nil && f.write(i.enterEvent(t, output)) {
+		return output
+	}
+
 	switch len(output) {
 	case 0:
 		t.Infof("%s E %s()", t.Name(), i.name)
@@ -591,6 +647,10 @@ func (i *SyscallInfo) printExit(t *kernel.Task, elapsed time.Duration, output []
 		rval = fmt.Sprintf("%d (%#x) errno=%d (%s) (%v)", retval, retval, errno, err, elapsed)
 	}
 
+	if f := logFile.Load(); f != nil && f.write(i.exitEvent(t, elapsed, output, retval, err, errno)) {
+		return
+	}
+
 	switch len(output) {
 	case 0:
 		t.Infof("%s X %s() = %s", t.Name(), i.name,
@@ -616,32 +676,32 @@ func (i *SyscallInfo) printExit(t *kernel.Task, elapsed time.Duration, output []
 	}
 }
 
-// sendEnter sends the syscall enter to event log.
-func (i *SyscallInfo) sendEnter(t *kernel.Task, args arch.SyscallArguments) []string {
-	output := i.pre(t, args, EventMaximumSize)
-
-	event := pb.Strace{
-		Process:  t.Name(),
-		Function: i.name,
-		Info: &pb.Strace_Enter{
-			Enter: &pb.StraceEnter{},
-		},
+// newEvent returns a Strace message for a system call made by t.
+func (i *SyscallInfo) newEvent(t *kernel.Task, output []string) *pb.Strace {
+	event := &pb.Strace{
+		Process:     t.Name(),
+		Function:    i.name,
+		ContainerId: t.ContainerID(),
+		Tid:         int32(t.Kernel().TaskSet().Root.IDOfTask(t)),
+		TimeNs:      time.Now().UnixNano(),
 	}
 	for _, arg := range output {
 		event.Args = append(event.Args, arg)
 	}
-	eventchannel.Emit(&event)
-
-	return output
+	return event
 }
 
-// sendExit sends the syscall exit to event log.
-func (i *SyscallInfo) sendExit(t *kernel.Task, elapsed time.Duration, output []string, args arch.SyscallArguments, rval uintptr, err error, errno int) {
-	if err == nil {
-		// Fill in the output after successful execution.
-		i.post(t, args, rval, output, EventMaximumSize)
+// enterEvent returns the Strace message for a system call entry.
+func (i *SyscallInfo) enterEvent(t *kernel.Task, output []string) *pb.Strace {
+	event := i.newEvent(t, output)
+	event.Info = &pb.Strace_Enter{
+		Enter: &pb.StraceEnter{},
 	}
+	return event
+}
 
+// exitEvent returns the Strace message for a system call exit.
+func (i *SyscallInfo) exitEvent(t *kernel.Task, elapsed time.Duration, output []string, rval uintptr, err error, errno int) *pb.Strace {
 	exit := &pb.StraceExit{
 		Return:    fmt.Sprintf("%#x", rval),
 		ElapsedNs: elapsed.Nanoseconds(),
@@ -650,15 +710,25 @@ func (i *SyscallInfo) sendExit(t *kernel.Task, elapsed time.Duration, output []s
 		exit.Error = err.Error()
 		exit.ErrNo = int64(errno)
 	}
-	event := pb.Strace{
-		Process:  t.Name(),
-		Function: i.name,
-		Info:     &pb.Strace_Exit{Exit: exit},
-	}
-	for _, arg := range output {
-		event.Args = append(event.Args, arg)
+	event := i.newEvent(t, output)
+	event.Info = &pb.Strace_Exit{Exit: exit}
+	return event
+}
+
+// sendEnter sends the syscall enter to event log.
+func (i *SyscallInfo) sendEnter(t *kernel.Task, args arch.SyscallArguments) []string {
+	output := i.pre(t, args, EventMaximumSize)
+	eventchannel.Emit(i.enterEvent(t, output))
+	return output
+}
+
+// sendExit sends the syscall exit to event log.
+func (i *SyscallInfo) sendExit(t *kernel.Task, elapsed time.Duration, output []string, args arch.SyscallArguments, rval uintptr, err error, errno int) {
+	if err == nil {
+		// Fill in the output after successful execution.
+		i.post(t, args, rval, output, EventMaximumSize)
 	}
-	eventchannel.Emit(&event)
+	eventchannel.Emit(i.exitEvent(t, elapsed, output, rval, err, errno))
 }
 
 type syscallContext struct {
@@ -673,6 +743,9 @@ type syscallContext struct {
 // SyscallEnter implements kernel.Stracer.SyscallEnter. It logs the syscall
 // entry trace.
 func (s SyscallMap) SyscallEnter(t *kernel.Task, sysno uintptr, args arch.SyscallArguments, flags uint32) any {
+	if !taskTraced(t) || !sampled(t) {
+		flags = 0
+	}
 	info, ok := s[sysno]
 	if !ok {
 		info = SyscallInfo{
@@ -846,6 +919,92 @@ func EnableAll(sinks SinkType) {
 	}
 }
 
+// SetTaskFilter restricts tracing to the tasks whose thread ID or thread group
+// ID, in the root PID namespace, is in ids. If ids is empty, all tasks are
+// traced.
+func SetTaskFilter(ids []kernel.ThreadID) {
+	if len(ids) == 0 {
+		taskFilter.Store(nil)
+		return
+	}
+	filter := make(map[kernel.ThreadID]struct{}, len(ids))
+	for _, id := range ids {
+		filter[id] = struct{}{}
+	}
+	taskFilter.Store(&filter)
+}
+
+// SetContainerFilter restricts tracing to the tasks belonging to the
+// containers in ids. If ids is empty, tasks in all containers are traced.
+func SetContainerFilter(ids []string) {
+	if len(ids) == 0 {
+		containerFilter.Store(nil)
+		return
+	}
+	filter := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		filter[id] = struct{}{}
+	}
+	containerFilter.Store(&filter)
+}
+
+// SetSampleRate causes only one in every n traced system calls to be logged.
+// If n is 0 or 1, all traced system calls are logged.
+func SetSampleRate(n uint32) {
+	sampleRate.Store(n)
+}
+
+// SetLogFile causes the log sink to write to w as JSON-encoded Strace
+// messages, one per line, instead of to the sentry debug log. If w is nil, the
+// sentry debug log is used.
+func SetLogFile(w io.Writer) {
+	if w == nil {
+		logFile.Store(nil)
+		return
+	}
+	logFile.Store(&jsonWriter{w: w})
+}
+
+// sampled returns true if the current system call of t is selected by the
+// sample rate set by SetSampleRate. Each task is sampled independently, so
+// that busy tasks don't starve others of samples.
+func sampled(t *kernel.Task) bool {
+	n := sampleRate.Load()
+	if n <= 1 {
+		return true
+	}
+	return t.NextStraceSample()%n == 0
+}
+
+// containerTraced returns true if tasks of container cid pass the filter set
+// by SetContainerFilter.
+func containerTraced(cid string) bool {
+	filter := containerFilter.Load()
+	if filter == nil {
+		return true
+	}
+	_, ok := (*filter)[cid]
+	return ok
+}
+
+// taskTraced returns true if t passes the filters set by SetTaskFilter and
+// SetContainerFilter.
+func taskTraced(t *kernel.Task) bool {
+	if !containerTraced(t.ContainerID()) {
+		return false
+	}
+	filter := taskFilter.Load()
+	if filter == nil {
+		return true
+	}
+	root := t.Kernel().TaskSet().Root
+	if _, ok := (*filter)[root.IDOfTask(t)]; ok {
+		return true
+	}
+	_, ok := (*filter)[root.IDOfThreadGroup(t.ThreadGroup())]
+	return ok
+}
+
 func init() {
 	t, ok := Lookup(abi.Host, arch.Host)
 	if ok {
@@ -0,0 +1,93 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	pb "gvisor.dev/gvisor/pkg/sentry/strace/strace_go_proto"
+)
+
+func TestContainerFilter(t *testing.T) {
+	defer SetContainerFilter(nil)
+
+	if !containerTraced("a") {
+		t.Errorf("container traced without a filter")
+	}
+	SetContainerFilter([]string{"a", "b"})
+	for cid, want := range map[string]bool{"a": true, "b": true, "c": false, "": false} {
+		if got := containerTraced(cid); got != want {
+			t.Errorf("containerTraced(%q) = %t, want %t", cid, got, want)
+		}
+	}
+	SetContainerFilter(nil)
+	if !containerTraced("c") {
+		t.Errorf("container not traced after clearing the filter")
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	defer SetLogFile(nil)
+
+	var buf bytes.Buffer
+	SetLogFile(&buf)
+	want := &pb.Strace{Process: "test", Function: "read", ContainerId: "a", Tid: 1}
+	if !logFile.Load().write(want) {
+		t.Fatalf("write() failed")
+	}
+	line, err := buf.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("log file doesn't contain a line: %q", buf.String())
+	}
+	var got pb.Strace
+	if err := protojson.Unmarshal(line, &got); err != nil {
+		t.Fatalf("unmarshaling %q: %v", line, err)
+	}
+	if got.Process != want.Process || got.Function != want.Function || got.ContainerId != want.ContainerId || got.Tid != want.Tid {
+		t.Errorf("got event %v, want %v", &got, want)
+	}
+}
+
+// failingWriter is an io.Writer that always fails.
+type failingWriter struct {
+	writes int
+}
+
+// Write implements io.Writer.Write.
+func (w *failingWriter) Write([]byte) (int, error) {
+	w.writes++
+	return 0, errors.New("failed")
+}
+
+// TestLogFileError tests that output goes back to the debug log once writing
+// to the log file fails.
+func TestLogFileError(t *testing.T) {
+	defer SetLogFile(nil)
+
+	var w failingWriter
+	SetLogFile(&w)
+	if logFile.Load().write(&pb.Strace{}) {
+		t.Errorf("write() to a failing writer succeeded")
+	}
+	if logFile.Load() != nil {
+		t.Errorf("log file still set after a failed write")
+	}
+	if w.writes != 1 {
+		t.Errorf("got %d writes, want 1", w.writes)
+	}
+}
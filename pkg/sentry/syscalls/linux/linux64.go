@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package linux provides syscall tables for amd64 and arm64 Linux.
+// Package linux provides syscall tables for amd64, arm64 and riscv64 Linux.
 package linux
 
 import (
@@ -108,7 +108,7 @@ var AMD64 = &kernel.SyscallTable{
 		53:  syscalls.SupportedPoint("socketpair", SocketPair, PointSocketpair),
 		54:  syscalls.Supported("setsockopt", SetSockOpt),
 		55:  syscalls.Supported("getsockopt", GetSockOpt),
-		56:  syscalls.PartiallySupportedPoint("clone", Clone, PointClone, "Options CLONE_PIDFD, CLONE_NEWCGROUP, CLONE_PARENT, CLONE_NEWTIME, CLONE_CLEAR_SIGHAND, and CLONE_SYSVSEM not supported.", nil),
+		56:  syscalls.PartiallySupportedPoint("clone", Clone, PointClone, "Options CLONE_PIDFD, CLONE_NEWCGROUP, CLONE_PARENT, CLONE_NEWTIME, and CLONE_CLEAR_SIGHAND not supported.", nil),
 		57:  syscalls.SupportedPoint("fork", Fork, PointFork),
 		58:  syscalls.SupportedPoint("vfork", Vfork, PointVfork),
 		59:  syscalls.SupportedPoint("execve", Execve, PointExecve),
@@ -117,7 +117,7 @@ var AMD64 = &kernel.SyscallTable{
 		62:  syscalls.Supported("kill", Kill),
 		63:  syscalls.Supported("uname", Uname),
 		64:  syscalls.Supported("semget", Semget),
-		65:  syscalls.PartiallySupported("semop", Semop, "Option SEM_UNDO not supported.", nil),
+		65:  syscalls.Supported("semop", Semop),
 		66:  syscalls.Supported("semctl", Semctl),
 		67:  syscalls.Supported("shmdt", Shmdt),
 		68:  syscalls.Supported("msgget", Msgget),
@@ -150,7 +150,7 @@ var AMD64 = &kernel.SyscallTable{
 		95:  syscalls.Supported("umask", Umask),
 		96:  syscalls.Supported("gettimeofday", Gettimeofday),
 		97:  syscalls.Supported("getrlimit", Getrlimit),
-		98:  syscalls.PartiallySupported("getrusage", Getrusage, "Fields ru_maxrss, ru_minflt, ru_majflt, ru_inblock, ru_oublock are not supported. Fields ru_utime and ru_stime have low precision.", nil),
+		98:  syscalls.PartiallySupported("getrusage", Getrusage, "Fields ru_inblock, ru_oublock are not supported. Fields ru_utime and ru_stime have low precision. Fields ru_minflt and ru_majflt only count faults handled by the sentry.", nil),
 		99:  syscalls.PartiallySupported("sysinfo", Sysinfo, "Fields loads, sharedram, bufferram, totalswap, freeswap, totalhigh, freehigh not supported.", nil),
 		100: syscalls.Supported("times", Times),
 		101: syscalls.PartiallySupported("ptrace", Ptrace, "Options PTRACE_PEEKSIGINFO, PTRACE_SECCOMP_GET_FILTER not supported.", nil),
@@ -187,19 +187,19 @@ var AMD64 = &kernel.SyscallTable{
 		132: syscalls.Supported("utime", Utime),
 		133: syscalls.Supported("mknod", Mknod),
 		134: syscalls.Error("uselib", linuxerr.ENOSYS, "Obsolete", nil),
-		135: syscalls.ErrorWithEvent("personality", linuxerr.EINVAL, "Unable to change personality.", nil),
+		135: syscalls.PartiallySupported("personality", Personality, "Only the Linux execution domain is supported. Of the personality flags, only ADDR_NO_RANDOMIZE has an effect.", nil),
 		136: syscalls.ErrorWithEvent("ustat", linuxerr.ENOSYS, "Needs filesystem support.", nil),
 		137: syscalls.Supported("statfs", Statfs),
 		138: syscalls.Supported("fstatfs", Fstatfs),
 		139: syscalls.ErrorWithEvent("sysfs", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/165"}),
 		140: syscalls.PartiallySupported("getpriority", Getpriority, "Stub implementation.", nil),
 		141: syscalls.PartiallySupported("setpriority", Setpriority, "Stub implementation.", nil),
-		142: syscalls.CapError("sched_setparam", linux.CAP_SYS_NICE, "", nil),
-		143: syscalls.PartiallySupported("sched_getparam", SchedGetparam, "Stub implementation.", nil),
-		144: syscalls.PartiallySupported("sched_setscheduler", SchedSetscheduler, "Stub implementation.", nil),
-		145: syscalls.PartiallySupported("sched_getscheduler", SchedGetscheduler, "Stub implementation.", nil),
-		146: syscalls.PartiallySupported("sched_get_priority_max", SchedGetPriorityMax, "Stub implementation.", nil),
-		147: syscalls.PartiallySupported("sched_get_priority_min", SchedGetPriorityMin, "Stub implementation.", nil),
+		142: syscalls.PartiallySupported("sched_setparam", SchedSetparam, "Scheduling policies are not enforced.", nil),
+		143: syscalls.PartiallySupported("sched_getparam", SchedGetparam, "Scheduling policies are not enforced.", nil),
+		144: syscalls.PartiallySupported("sched_setscheduler", SchedSetscheduler, "Scheduling policies are not enforced.", nil),
+		145: syscalls.PartiallySupported("sched_getscheduler", SchedGetscheduler, "Scheduling policies are not enforced.", nil),
+		146: syscalls.Supported("sched_get_priority_max", SchedGetPriorityMax),
+		147: syscalls.Supported("sched_get_priority_min", SchedGetPriorityMin),
 		148: syscalls.ErrorWithEvent("sched_rr_get_interval", linuxerr.EPERM, "", nil),
 		149: syscalls.PartiallySupported("mlock", Mlock, "Stub implementation. The sandbox lacks appropriate permissions.", nil),
 		150: syscalls.PartiallySupported("munlock", Munlock, "Stub implementation. The sandbox lacks appropriate permissions.", nil),
@@ -273,7 +273,7 @@ var AMD64 = &kernel.SyscallTable{
 		218: syscalls.Supported("set_tid_address", SetTidAddress),
 		219: syscalls.Supported("restart_syscall", RestartSyscall),
 		220: syscalls.Supported("semtimedop", Semtimedop),
-		221: syscalls.PartiallySupported("fadvise64", Fadvise64, "The syscall is 'supported', but ignores all provided advice.", nil),
+		221: syscalls.PartiallySupported("fadvise64", Fadvise64, "Advice only affects files whose contents are cached by the sandbox.", nil),
 		222: syscalls.Supported("timer_create", TimerCreate),
 		223: syscalls.Supported("timer_settime", TimerSettime),
 		224: syscalls.Supported("timer_gettime", TimerGettime),
@@ -289,9 +289,9 @@ var AMD64 = &kernel.SyscallTable{
 		234: syscalls.Supported("tgkill", Tgkill),
 		235: syscalls.Supported("utimes", Utimes),
 		236: syscalls.Error("vserver", linuxerr.ENOSYS, "Not implemented by Linux", nil),
-		237: syscalls.PartiallySupported("mbind", Mbind, "Stub implementation. Only a single NUMA node is advertised, and mempolicy is ignored accordingly, but mbind() will succeed and has effects reflected by get_mempolicy.", []string{"gvisor.dev/issue/262"}),
-		238: syscalls.PartiallySupported("set_mempolicy", SetMempolicy, "Stub implementation.", nil),
-		239: syscalls.PartiallySupported("get_mempolicy", GetMempolicy, "Stub implementation.", nil),
+		237: syscalls.PartiallySupported("mbind", Mbind, "NUMA nodes are emulated, and memory is not actually placed on them. mbind() has effects reflected by get_mempolicy and move_pages.", []string{"gvisor.dev/issue/262"}),
+		238: syscalls.PartiallySupported("set_mempolicy", SetMempolicy, "NUMA nodes are emulated, and the policy is not enforced.", nil),
+		239: syscalls.PartiallySupported("get_mempolicy", GetMempolicy, "NUMA nodes are emulated, and pages are reported on the first node allowed by their policy.", nil),
 		240: syscalls.Supported("mq_open", MqOpen),
 		241: syscalls.Supported("mq_unlink", MqUnlink),
 		242: syscalls.ErrorWithEvent("mq_timedsend", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/136"}),    // TODO(b/29354921)
@@ -331,7 +331,7 @@ var AMD64 = &kernel.SyscallTable{
 		276: syscalls.Supported("tee", Tee),
 		277: syscalls.Supported("sync_file_range", SyncFileRange),
 		278: syscalls.ErrorWithEvent("vmsplice", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/138"}), // TODO(b/29354098)
-		279: syscalls.CapError("move_pages", linux.CAP_SYS_NICE, "", nil),                               // requires cap_sys_nice (mostly)
+		279: syscalls.PartiallySupported("move_pages", MovePages, "NUMA nodes are emulated, and pages are not actually migrated.", nil),
 		280: syscalls.Supported("utimensat", Utimensat),
 		281: syscalls.Supported("epoll_pwait", EpollPwait),
 		282: syscalls.SupportedPoint("signalfd", Signalfd, PointSignalfd),
@@ -364,10 +364,10 @@ var AMD64 = &kernel.SyscallTable{
 		309: syscalls.Supported("getcpu", Getcpu),
 		310: syscalls.Supported("process_vm_readv", ProcessVMReadv),
 		311: syscalls.Supported("process_vm_writev", ProcessVMWritev),
-		312: syscalls.CapError("kcmp", linux.CAP_SYS_PTRACE, "", nil),
+		312: syscalls.PartiallySupported("kcmp", Kcmp, "KCMP_IO always reports equal I/O contexts, as tasks don't have any.", nil),
 		313: syscalls.CapError("finit_module", linux.CAP_SYS_MODULE, "", nil),
-		314: syscalls.ErrorWithEvent("sched_setattr", linuxerr.ENOSYS, "gVisor does not implement a scheduler.", []string{"gvisor.dev/issue/264"}), // TODO(b/118902272)
-		315: syscalls.ErrorWithEvent("sched_getattr", linuxerr.ENOSYS, "gVisor does not implement a scheduler.", []string{"gvisor.dev/issue/264"}), // TODO(b/118902272)
+		314: syscalls.PartiallySupported("sched_setattr", SchedSetattr, "Scheduling policies are not enforced. SCHED_DEADLINE bandwidth is accounted but not reserved.", nil),
+		315: syscalls.PartiallySupported("sched_getattr", SchedGetattr, "Scheduling policies are not enforced. SCHED_DEADLINE bandwidth is accounted but not reserved.", nil),
 		316: syscalls.Supported("renameat2", Renameat2),
 		317: syscalls.Supported("seccomp", Seccomp),
 		318: syscalls.Supported("getrandom", GetRandom),
@@ -375,7 +375,7 @@ var AMD64 = &kernel.SyscallTable{
 		320: syscalls.CapError("kexec_file_load", linux.CAP_SYS_BOOT, "", nil),
 		321: syscalls.CapError("bpf", linux.CAP_SYS_ADMIN, "", nil),
 		322: syscalls.SupportedPoint("execveat", Execveat, PointExecveat),
-		323: syscalls.ErrorWithEvent("userfaultfd", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/266"}), // TODO(b/118906345)
+		323: syscalls.PartiallySupported("userfaultfd", Userfaultfd, "Missing and write-protect faults are only supported on anonymous memory, and minor faults on shared memory. Non-cooperative events are not supported.", nil),
 		324: syscalls.PartiallySupported("membarrier", Membarrier, "Not supported on all platforms.", nil),
 		325: syscalls.PartiallySupported("mlock2", Mlock2, "Stub implementation. The sandbox lacks appropriate permissions.", nil),
 
@@ -392,21 +392,23 @@ var AMD64 = &kernel.SyscallTable{
 		334: syscalls.PartiallySupported("rseq", RSeq, "Not supported on all platforms.", nil),
 
 		// Linux skips ahead to syscall 424 to sync numbers between arches.
-		424: syscalls.ErrorWithEvent("pidfd_send_signal", linuxerr.ENOSYS, "", nil),
+		424: syscalls.PartiallySupported("pidfd_send_signal", PidfdSendSignal, "/proc/[pid] directory file descriptors are not accepted in place of a pidfd.", nil),
 		425: syscalls.PartiallySupported("io_uring_setup", IOUringSetup, "Not all flags and functionality supported.", nil),
 		426: syscalls.PartiallySupported("io_uring_enter", IOUringEnter, "Not all flags and functionality supported.", nil),
-		427: syscalls.ErrorWithEvent("io_uring_register", linuxerr.ENOSYS, "", nil),
+		427: syscalls.PartiallySupported("io_uring_register", IOUringRegister, "Only buffer registration and opcode probing are supported.", nil),
 		428: syscalls.ErrorWithEvent("open_tree", linuxerr.ENOSYS, "", nil),
 		429: syscalls.ErrorWithEvent("move_mount", linuxerr.ENOSYS, "", nil),
 		430: syscalls.ErrorWithEvent("fsopen", linuxerr.ENOSYS, "", nil),
 		431: syscalls.ErrorWithEvent("fsconfig", linuxerr.ENOSYS, "", nil),
 		432: syscalls.ErrorWithEvent("fsmount", linuxerr.ENOSYS, "", nil),
 		433: syscalls.ErrorWithEvent("fspick", linuxerr.ENOSYS, "", nil),
-		434: syscalls.ErrorWithEvent("pidfd_open", linuxerr.ENOSYS, "", nil),
-		435: syscalls.PartiallySupported("clone3", Clone3, "Options CLONE_PIDFD, CLONE_NEWCGROUP, CLONE_INTO_CGROUP, CLONE_NEWTIME, CLONE_CLEAR_SIGHAND, CLONE_PARENT, CLONE_SYSVSEM and, SetTid are not supported.", nil),
+		434: syscalls.Supported("pidfd_open", PidfdOpen),
+		435: syscalls.PartiallySupported("clone3", Clone3, "Options CLONE_PIDFD, CLONE_NEWCGROUP, CLONE_CLEAR_SIGHAND, CLONE_PARENT and, SetTid are not supported. CLONE_INTO_CGROUP accepts any cgroupfs directory.", nil),
 		436: syscalls.Supported("close_range", CloseRange),
+		438: syscalls.Supported("pidfd_getfd", PidfdGetfd),
 		439: syscalls.Supported("faccessat2", Faccessat2),
 		441: syscalls.Supported("epoll_pwait2", EpollPwait2),
+		449: syscalls.Supported("futex_waitv", FutexWaitv),
 	},
 	Emulate: map[hostarch.Addr]uintptr{
 		0xffffffffff600000: 96,  // vsyscall gettimeofday(2)
@@ -523,7 +525,7 @@ var ARM64 = &kernel.SyscallTable{
 		89:  syscalls.CapError("acct", linux.CAP_SYS_PACCT, "", nil),
 		90:  syscalls.Supported("capget", Capget),
 		91:  syscalls.Supported("capset", Capset),
-		92:  syscalls.ErrorWithEvent("personality", linuxerr.EINVAL, "Unable to change personality.", nil),
+		92:  syscalls.PartiallySupported("personality", Personality, "Only the Linux execution domain is supported. Of the personality flags, only ADDR_NO_RANDOMIZE has an effect.", nil),
 		93:  syscalls.Supported("exit", Exit),
 		94:  syscalls.Supported("exit_group", ExitGroup),
 		95:  syscalls.Supported("waitid", Waitid),
@@ -549,15 +551,15 @@ var ARM64 = &kernel.SyscallTable{
 		115: syscalls.Supported("clock_nanosleep", ClockNanosleep),
 		116: syscalls.PartiallySupported("syslog", Syslog, "Outputs a dummy message for security reasons.", nil),
 		117: syscalls.PartiallySupported("ptrace", Ptrace, "Options PTRACE_PEEKSIGINFO, PTRACE_SECCOMP_GET_FILTER not supported.", nil),
-		118: syscalls.CapError("sched_setparam", linux.CAP_SYS_NICE, "", nil),
-		119: syscalls.PartiallySupported("sched_setscheduler", SchedSetscheduler, "Stub implementation.", nil),
-		120: syscalls.PartiallySupported("sched_getscheduler", SchedGetscheduler, "Stub implementation.", nil),
-		121: syscalls.PartiallySupported("sched_getparam", SchedGetparam, "Stub implementation.", nil),
+		118: syscalls.PartiallySupported("sched_setparam", SchedSetparam, "Scheduling policies are not enforced.", nil),
+		119: syscalls.PartiallySupported("sched_setscheduler", SchedSetscheduler, "Scheduling policies are not enforced.", nil),
+		120: syscalls.PartiallySupported("sched_getscheduler", SchedGetscheduler, "Scheduling policies are not enforced.", nil),
+		121: syscalls.PartiallySupported("sched_getparam", SchedGetparam, "Scheduling policies are not enforced.", nil),
 		122: syscalls.PartiallySupported("sched_setaffinity", SchedSetaffinity, "Stub implementation.", nil),
 		123: syscalls.PartiallySupported("sched_getaffinity", SchedGetaffinity, "Stub implementation.", nil),
 		124: syscalls.Supported("sched_yield", SchedYield),
-		125: syscalls.PartiallySupported("sched_get_priority_max", SchedGetPriorityMax, "Stub implementation.", nil),
-		126: syscalls.PartiallySupported("sched_get_priority_min", SchedGetPriorityMin, "Stub implementation.", nil),
+		125: syscalls.Supported("sched_get_priority_max", SchedGetPriorityMax),
+		126: syscalls.Supported("sched_get_priority_min", SchedGetPriorityMin),
 		127: syscalls.ErrorWithEvent("sched_rr_get_interval", linuxerr.EPERM, "", nil),
 		128: syscalls.Supported("restart_syscall", RestartSyscall),
 		129: syscalls.Supported("kill", Kill),
@@ -596,7 +598,7 @@ var ARM64 = &kernel.SyscallTable{
 		162: syscalls.Supported("setdomainname", Setdomainname),
 		163: syscalls.Supported("getrlimit", Getrlimit),
 		164: syscalls.PartiallySupported("setrlimit", Setrlimit, "Not all rlimits are enforced.", nil),
-		165: syscalls.PartiallySupported("getrusage", Getrusage, "Fields ru_maxrss, ru_minflt, ru_majflt, ru_inblock, ru_oublock are not supported. Fields ru_utime and ru_stime have low precision.", nil),
+		165: syscalls.PartiallySupported("getrusage", Getrusage, "Fields ru_inblock, ru_oublock are not supported. Fields ru_utime and ru_stime have low precision. Fields ru_minflt and ru_majflt only count faults handled by the sentry.", nil),
 		166: syscalls.Supported("umask", Umask),
 		167: syscalls.PartiallySupported("prctl", Prctl, "Not all options are supported.", nil),
 		168: syscalls.Supported("getcpu", Getcpu),
@@ -624,7 +626,7 @@ var ARM64 = &kernel.SyscallTable{
 		190: syscalls.Supported("semget", Semget),
 		191: syscalls.Supported("semctl", Semctl),
 		192: syscalls.Supported("semtimedop", Semtimedop),
-		193: syscalls.PartiallySupported("semop", Semop, "Option SEM_UNDO not supported.", nil),
+		193: syscalls.Supported("semop", Semop),
 		194: syscalls.PartiallySupported("shmget", Shmget, "Option SHM_HUGETLB is not supported.", nil),
 		195: syscalls.PartiallySupported("shmctl", Shmctl, "Options SHM_LOCK, SHM_UNLOCK are not supported.", nil),
 		196: syscalls.PartiallySupported("shmat", Shmat, "Option SHM_RND is not supported.", nil),
@@ -651,10 +653,10 @@ var ARM64 = &kernel.SyscallTable{
 		217: syscalls.Error("add_key", linuxerr.EACCES, "Not available to user.", nil),
 		218: syscalls.Error("request_key", linuxerr.EACCES, "Not available to user.", nil),
 		219: syscalls.PartiallySupported("keyctl", Keyctl, "Only supports session keyrings with zero keys in them.", nil),
-		220: syscalls.PartiallySupportedPoint("clone", Clone, PointClone, "Options CLONE_PIDFD, CLONE_NEWCGROUP, CLONE_PARENT, CLONE_NEWTIME, CLONE_CLEAR_SIGHAND, and CLONE_SYSVSEM not supported.", nil),
+		220: syscalls.PartiallySupportedPoint("clone", Clone, PointClone, "Options CLONE_PIDFD, CLONE_NEWCGROUP, CLONE_PARENT, CLONE_NEWTIME, and CLONE_CLEAR_SIGHAND not supported.", nil),
 		221: syscalls.SupportedPoint("execve", Execve, PointExecve),
 		222: syscalls.Supported("mmap", Mmap),
-		223: syscalls.PartiallySupported("fadvise64", Fadvise64, "Not all options are supported.", nil),
+		223: syscalls.PartiallySupported("fadvise64", Fadvise64, "Advice only affects files whose contents are cached by the sandbox.", nil),
 		224: syscalls.CapError("swapon", linux.CAP_SYS_ADMIN, "", nil),
 		225: syscalls.CapError("swapoff", linux.CAP_SYS_ADMIN, "", nil),
 		226: syscalls.Supported("mprotect", Mprotect),
@@ -666,11 +668,11 @@ var ARM64 = &kernel.SyscallTable{
 		232: syscalls.PartiallySupported("mincore", Mincore, "Stub implementation. The sandbox does not have access to this information. Reports all mapped pages are resident.", nil),
 		233: syscalls.PartiallySupported("madvise", Madvise, "Options MADV_DONTNEED, MADV_DONTFORK are supported. Other advice is ignored.", nil),
 		234: syscalls.ErrorWithEvent("remap_file_pages", linuxerr.ENOSYS, "Deprecated since Linux 3.16.", nil),
-		235: syscalls.PartiallySupported("mbind", Mbind, "Stub implementation. Only a single NUMA node is advertised, and mempolicy is ignored accordingly, but mbind() will succeed and has effects reflected by get_mempolicy.", []string{"gvisor.dev/issue/262"}),
-		236: syscalls.PartiallySupported("get_mempolicy", GetMempolicy, "Stub implementation.", nil),
-		237: syscalls.PartiallySupported("set_mempolicy", SetMempolicy, "Stub implementation.", nil),
+		235: syscalls.PartiallySupported("mbind", Mbind, "NUMA nodes are emulated, and memory is not actually placed on them. mbind() has effects reflected by get_mempolicy and move_pages.", []string{"gvisor.dev/issue/262"}),
+		236: syscalls.PartiallySupported("get_mempolicy", GetMempolicy, "NUMA nodes are emulated, and pages are reported on the first node allowed by their policy.", nil),
+		237: syscalls.PartiallySupported("set_mempolicy", SetMempolicy, "NUMA nodes are emulated, and the policy is not enforced.", nil),
 		238: syscalls.CapError("migrate_pages", linux.CAP_SYS_NICE, "", nil),
-		239: syscalls.CapError("move_pages", linux.CAP_SYS_NICE, "", nil), // requires cap_sys_nice (mostly)
+		239: syscalls.PartiallySupported("move_pages", MovePages, "NUMA nodes are emulated, and pages are not actually migrated.", nil),
 		240: syscalls.Supported("rt_tgsigqueueinfo", RtTgsigqueueinfo),
 		241: syscalls.ErrorWithEvent("perf_event_open", linuxerr.ENODEV, "No support for perf counters", nil),
 		242: syscalls.SupportedPoint("accept4", Accept4, PointAccept4),
@@ -687,17 +689,17 @@ var ARM64 = &kernel.SyscallTable{
 		269: syscalls.Supported("sendmmsg", SendMMsg),
 		270: syscalls.Supported("process_vm_readv", ProcessVMReadv),
 		271: syscalls.Supported("process_vm_writev", ProcessVMWritev),
-		272: syscalls.CapError("kcmp", linux.CAP_SYS_PTRACE, "", nil),
+		272: syscalls.PartiallySupported("kcmp", Kcmp, "KCMP_IO always reports equal I/O contexts, as tasks don't have any.", nil),
 		273: syscalls.CapError("finit_module", linux.CAP_SYS_MODULE, "", nil),
-		274: syscalls.ErrorWithEvent("sched_setattr", linuxerr.ENOSYS, "gVisor does not implement a scheduler.", []string{"gvisor.dev/issue/264"}), // TODO(b/118902272)
-		275: syscalls.ErrorWithEvent("sched_getattr", linuxerr.ENOSYS, "gVisor does not implement a scheduler.", []string{"gvisor.dev/issue/264"}), // TODO(b/118902272)
+		274: syscalls.PartiallySupported("sched_setattr", SchedSetattr, "Scheduling policies are not enforced. SCHED_DEADLINE bandwidth is accounted but not reserved.", nil),
+		275: syscalls.PartiallySupported("sched_getattr", SchedGetattr, "Scheduling policies are not enforced. SCHED_DEADLINE bandwidth is accounted but not reserved.", nil),
 		276: syscalls.Supported("renameat2", Renameat2),
 		277: syscalls.Supported("seccomp", Seccomp),
 		278: syscalls.Supported("getrandom", GetRandom),
 		279: syscalls.Supported("memfd_create", MemfdCreate),
 		280: syscalls.CapError("bpf", linux.CAP_SYS_ADMIN, "", nil),
 		281: syscalls.SupportedPoint("execveat", Execveat, PointExecveat),
-		282: syscalls.ErrorWithEvent("userfaultfd", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/266"}), // TODO(b/118906345)
+		282: syscalls.PartiallySupported("userfaultfd", Userfaultfd, "Missing and write-protect faults are only supported on anonymous memory, and minor faults on shared memory. Non-cooperative events are not supported.", nil),
 		283: syscalls.PartiallySupported("membarrier", Membarrier, "Not supported on all platforms.", nil),
 		284: syscalls.PartiallySupported("mlock2", Mlock2, "Stub implementation. The sandbox lacks appropriate permissions.", nil),
 
@@ -713,21 +715,346 @@ var ARM64 = &kernel.SyscallTable{
 		293: syscalls.PartiallySupported("rseq", RSeq, "Not supported on all platforms.", nil),
 
 		// Linux skips ahead to syscall 424 to sync numbers between arches.
-		424: syscalls.ErrorWithEvent("pidfd_send_signal", linuxerr.ENOSYS, "", nil),
+		424: syscalls.PartiallySupported("pidfd_send_signal", PidfdSendSignal, "/proc/[pid] directory file descriptors are not accepted in place of a pidfd.", nil),
 		425: syscalls.PartiallySupported("io_uring_setup", IOUringSetup, "Not all flags and functionality supported.", nil),
 		426: syscalls.PartiallySupported("io_uring_enter", IOUringEnter, "Not all flags and functionality supported.", nil),
-		427: syscalls.ErrorWithEvent("io_uring_register", linuxerr.ENOSYS, "", nil),
+		427: syscalls.PartiallySupported("io_uring_register", IOUringRegister, "Only buffer registration and opcode probing are supported.", nil),
 		428: syscalls.ErrorWithEvent("open_tree", linuxerr.ENOSYS, "", nil),
 		429: syscalls.ErrorWithEvent("move_mount", linuxerr.ENOSYS, "", nil),
 		430: syscalls.ErrorWithEvent("fsopen", linuxerr.ENOSYS, "", nil),
 		431: syscalls.ErrorWithEvent("fsconfig", linuxerr.ENOSYS, "", nil),
 		432: syscalls.ErrorWithEvent("fsmount", linuxerr.ENOSYS, "", nil),
 		433: syscalls.ErrorWithEvent("fspick", linuxerr.ENOSYS, "", nil),
-		434: syscalls.ErrorWithEvent("pidfd_open", linuxerr.ENOSYS, "", nil),
-		435: syscalls.PartiallySupported("clone3", Clone3, "Options CLONE_PIDFD, CLONE_NEWCGROUP, CLONE_INTO_CGROUP, CLONE_NEWTIME, CLONE_CLEAR_SIGHAND, CLONE_PARENT, CLONE_SYSVSEM and clone_args.set_tid are not supported.", nil),
+		434: syscalls.Supported("pidfd_open", PidfdOpen),
+		435: syscalls.PartiallySupported("clone3", Clone3, "Options CLONE_PIDFD, CLONE_NEWCGROUP, CLONE_CLEAR_SIGHAND, CLONE_PARENT and clone_args.set_tid are not supported. CLONE_INTO_CGROUP accepts any cgroupfs directory.", nil),
 		436: syscalls.Supported("close_range", CloseRange),
+		438: syscalls.Supported("pidfd_getfd", PidfdGetfd),
 		439: syscalls.Supported("faccessat2", Faccessat2),
 		441: syscalls.Supported("epoll_pwait2", EpollPwait2),
+		449: syscalls.Supported("futex_waitv", FutexWaitv),
+	},
+	Emulate: map[hostarch.Addr]uintptr{},
+	Missing: func(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, error) {
+		t.Kernel().EmitUnimplementedEvent(t, sysno)
+		return 0, linuxerr.ENOSYS
+	},
+}
+
+// RISCV64 is a table of Linux riscv64 syscall API with the corresponding
+// syscall numbers. riscv64 uses the generic syscall numbers, as arm64 does,
+// but doesn't have renameat, and allocates riscv_hwprobe and
+// riscv_flush_icache from the architecture-specific range
+// (arch/riscv/include/uapi/asm/unistd.h).
+var RISCV64 = &kernel.SyscallTable{
+	OS:   abi.Linux,
+	Arch: arch.RISCV64,
+	Version: kernel.Version{
+		Sysname: LinuxSysname,
+		Release: LinuxRelease,
+		Version: LinuxVersion,
+	},
+	AuditNumber: linux.AUDIT_ARCH_RISCV64,
+	Table: map[uintptr]kernel.Syscall{
+		0:   syscalls.PartiallySupported("io_setup", IoSetup, "Generally supported with exceptions. User ring optimizations are not implemented.", []string{"gvisor.dev/issue/204"}),
+		1:   syscalls.PartiallySupported("io_destroy", IoDestroy, "Generally supported with exceptions. User ring optimizations are not implemented.", []string{"gvisor.dev/issue/204"}),
+		2:   syscalls.PartiallySupported("io_submit", IoSubmit, "Generally supported with exceptions. User ring optimizations are not implemented.", []string{"gvisor.dev/issue/204"}),
+		3:   syscalls.PartiallySupported("io_cancel", IoCancel, "Generally supported with exceptions. User ring optimizations are not implemented.", []string{"gvisor.dev/issue/204"}),
+		4:   syscalls.PartiallySupported("io_getevents", IoGetevents, "Generally supported with exceptions. User ring optimizations are not implemented.", []string{"gvisor.dev/issue/204"}),
+		5:   syscalls.Supported("setxattr", SetXattr),
+		6:   syscalls.Supported("lsetxattr", Lsetxattr),
+		7:   syscalls.Supported("fsetxattr", Fsetxattr),
+		8:   syscalls.Supported("getxattr", GetXattr),
+		9:   syscalls.Supported("lgetxattr", Lgetxattr),
+		10:  syscalls.Supported("fgetxattr", Fgetxattr),
+		11:  syscalls.Supported("listxattr", ListXattr),
+		12:  syscalls.Supported("llistxattr", Llistxattr),
+		13:  syscalls.Supported("flistxattr", Flistxattr),
+		14:  syscalls.Supported("removexattr", RemoveXattr),
+		15:  syscalls.Supported("lremovexattr", Lremovexattr),
+		16:  syscalls.Supported("fremovexattr", Fremovexattr),
+		17:  syscalls.Supported("getcwd", Getcwd),
+		18:  syscalls.CapError("lookup_dcookie", linux.CAP_SYS_ADMIN, "", nil),
+		19:  syscalls.SupportedPoint("eventfd2", Eventfd2, PointEventfd2),
+		20:  syscalls.Supported("epoll_create1", EpollCreate1),
+		21:  syscalls.Supported("epoll_ctl", EpollCtl),
+		22:  syscalls.Supported("epoll_pwait", EpollPwait),
+		23:  syscalls.SupportedPoint("dup", Dup, PointDup),
+		24:  syscalls.SupportedPoint("dup3", Dup3, PointDup3),
+		25:  syscalls.SupportedPoint("fcntl", Fcntl, PointFcntl),
+		26:  syscalls.PartiallySupportedPoint("inotify_init1", InotifyInit1, PointInotifyInit1, "inotify events are only available inside the sandbox.", nil),
+		27:  syscalls.PartiallySupportedPoint("inotify_add_watch", InotifyAddWatch, PointInotifyAddWatch, "inotify events are only available inside the sandbox.", nil),
+		28:  syscalls.PartiallySupportedPoint("inotify_rm_watch", InotifyRmWatch, PointInotifyRmWatch, "inotify events are only available inside the sandbox.", nil),
+		29:  syscalls.Supported("ioctl", Ioctl),
+		30:  syscalls.CapError("ioprio_set", linux.CAP_SYS_ADMIN, "", nil), // requires cap_sys_nice or cap_sys_admin (depending)
+		31:  syscalls.CapError("ioprio_get", linux.CAP_SYS_ADMIN, "", nil), // requires cap_sys_nice or cap_sys_admin (depending)
+		32:  syscalls.Supported("flock", Flock),
+		33:  syscalls.Supported("mknodat", Mknodat),
+		34:  syscalls.Supported("mkdirat", Mkdirat),
+		35:  syscalls.Supported("unlinkat", Unlinkat),
+		36:  syscalls.Supported("symlinkat", Symlinkat),
+		37:  syscalls.Supported("linkat", Linkat),
+		39:  syscalls.Supported("umount2", Umount2),
+		40:  syscalls.Supported("mount", Mount),
+		41:  syscalls.Supported("pivot_root", PivotRoot),
+		42:  syscalls.Error("nfsservctl", linuxerr.ENOSYS, "Removed after Linux 3.1.", nil),
+		43:  syscalls.Supported("statfs", Statfs),
+		44:  syscalls.Supported("fstatfs", Fstatfs),
+		45:  syscalls.Supported("truncate", Truncate),
+		46:  syscalls.Supported("ftruncate", Ftruncate),
+		47:  syscalls.PartiallySupported("fallocate", Fallocate, "Not all options are supported.", nil),
+		48:  syscalls.Supported("faccessat", Faccessat),
+		49:  syscalls.SupportedPoint("chdir", Chdir, PointChdir),
+		50:  syscalls.SupportedPoint("fchdir", Fchdir, PointFchdir),
+		51:  syscalls.SupportedPoint("chroot", Chroot, PointChroot),
+		52:  syscalls.Supported("fchmod", Fchmod),
+		53:  syscalls.Supported("fchmodat", Fchmodat),
+		54:  syscalls.Supported("fchownat", Fchownat),
+		55:  syscalls.Supported("fchown", Fchown),
+		56:  syscalls.SupportedPoint("openat", Openat, PointOpenat),
+		57:  syscalls.SupportedPoint("close", Close, PointClose),
+		58:  syscalls.CapError("vhangup", linux.CAP_SYS_TTY_CONFIG, "", nil),
+		59:  syscalls.SupportedPoint("pipe2", Pipe2, PointPipe2),
+		60:  syscalls.CapError("quotactl", linux.CAP_SYS_ADMIN, "", nil), // requires cap_sys_admin for most operations
+		61:  syscalls.Supported("getdents64", Getdents64),
+		62:  syscalls.Supported("lseek", Lseek),
+		63:  syscalls.SupportedPoint("read", Read, PointRead),
+		64:  syscalls.SupportedPoint("write", Write, PointWrite),
+		65:  syscalls.SupportedPoint("readv", Readv, PointReadv),
+		66:  syscalls.SupportedPoint("writev", Writev, PointWritev),
+		67:  syscalls.SupportedPoint("pread64", Pread64, PointPread64),
+		68:  syscalls.SupportedPoint("pwrite64", Pwrite64, PointPwrite64),
+		69:  syscalls.SupportedPoint("preadv", Preadv, PointPreadv),
+		70:  syscalls.SupportedPoint("pwritev", Pwritev, PointPwritev),
+		71:  syscalls.Supported("sendfile", Sendfile),
+		72:  syscalls.Supported("pselect6", Pselect6),
+		73:  syscalls.Supported("ppoll", Ppoll),
+		74:  syscalls.SupportedPoint("signalfd4", Signalfd4, PointSignalfd4),
+		75:  syscalls.ErrorWithEvent("vmsplice", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/138"}), // TODO(b/29354098)
+		76:  syscalls.Supported("splice", Splice),
+		77:  syscalls.Supported("tee", Tee),
+		78:  syscalls.Supported("readlinkat", Readlinkat),
+		79:  syscalls.Supported("newfstatat", Newfstatat),
+		80:  syscalls.Supported("fstat", Fstat),
+		81:  syscalls.Supported("sync", Sync),
+		82:  syscalls.Supported("fsync", Fsync),
+		83:  syscalls.Supported("fdatasync", Fdatasync),
+		84:  syscalls.Supported("sync_file_range", SyncFileRange),
+		85:  syscalls.SupportedPoint("timerfd_create", TimerfdCreate, PointTimerfdCreate),
+		86:  syscalls.SupportedPoint("timerfd_settime", TimerfdSettime, PointTimerfdSettime),
+		87:  syscalls.SupportedPoint("timerfd_gettime", TimerfdGettime, PointTimerfdGettime),
+		88:  syscalls.Supported("utimensat", Utimensat),
+		89:  syscalls.CapError("acct", linux.CAP_SYS_PACCT, "", nil),
+		90:  syscalls.Supported("capget", Capget),
+		91:  syscalls.Supported("capset", Capset),
+		92:  syscalls.PartiallySupported("personality", Personality, "Only the Linux execution domain is supported. Of the personality flags, only ADDR_NO_RANDOMIZE has an effect.", nil),
+		93:  syscalls.Supported("exit", Exit),
+		94:  syscalls.Supported("exit_group", ExitGroup),
+		95:  syscalls.Supported("waitid", Waitid),
+		96:  syscalls.Supported("set_tid_address", SetTidAddress),
+		97:  syscalls.PartiallySupported("unshare", Unshare, "Time, cgroup namespaces not supported.", nil),
+		98:  syscalls.PartiallySupported("futex", Futex, "Robust futexes not supported.", nil),
+		99:  syscalls.Supported("set_robust_list", SetRobustList),
+		100: syscalls.Supported("get_robust_list", GetRobustList),
+		101: syscalls.Supported("nanosleep", Nanosleep),
+		102: syscalls.Supported("getitimer", Getitimer),
+		103: syscalls.Supported("setitimer", Setitimer),
+		104: syscalls.CapError("kexec_load", linux.CAP_SYS_BOOT, "", nil),
+		105: syscalls.CapError("init_module", linux.CAP_SYS_MODULE, "", nil),
+		106: syscalls.CapError("delete_module", linux.CAP_SYS_MODULE, "", nil),
+		107: syscalls.Supported("timer_create", TimerCreate),
+		108: syscalls.Supported("timer_gettime", TimerGettime),
+		109: syscalls.Supported("timer_getoverrun", TimerGetoverrun),
+		110: syscalls.Supported("timer_settime", TimerSettime),
+		111: syscalls.Supported("timer_delete", TimerDelete),
+		112: syscalls.Supported("clock_settime", ClockSettime),
+		113: syscalls.Supported("clock_gettime", ClockGettime),
+		114: syscalls.Supported("clock_getres", ClockGetres),
+		115: syscalls.Supported("clock_nanosleep", ClockNanosleep),
+		116: syscalls.PartiallySupported("syslog", Syslog, "Outputs a dummy message for security reasons.", nil),
+		117: syscalls.PartiallySupported("ptrace", Ptrace, "Options PTRACE_PEEKSIGINFO, PTRACE_SECCOMP_GET_FILTER not supported.", nil),
+		118: syscalls.PartiallySupported("sched_setparam", SchedSetparam, "Scheduling policies are not enforced.", nil),
+		119: syscalls.PartiallySupported("sched_setscheduler", SchedSetscheduler, "Scheduling policies are not enforced.", nil),
+		120: syscalls.PartiallySupported("sched_getscheduler", SchedGetscheduler, "Scheduling policies are not enforced.", nil),
+		121: syscalls.PartiallySupported("sched_getparam", SchedGetparam, "Scheduling policies are not enforced.", nil),
+		122: syscalls.PartiallySupported("sched_setaffinity", SchedSetaffinity, "Stub implementation.", nil),
+		123: syscalls.PartiallySupported("sched_getaffinity", SchedGetaffinity, "Stub implementation.", nil),
+		124: syscalls.Supported("sched_yield", SchedYield),
+		125: syscalls.Supported("sched_get_priority_max", SchedGetPriorityMax),
+		126: syscalls.Supported("sched_get_priority_min", SchedGetPriorityMin),
+		127: syscalls.ErrorWithEvent("sched_rr_get_interval", linuxerr.EPERM, "", nil),
+		128: syscalls.Supported("restart_syscall", RestartSyscall),
+		129: syscalls.Supported("kill", Kill),
+		130: syscalls.Supported("tkill", Tkill),
+		131: syscalls.Supported("tgkill", Tgkill),
+		132: syscalls.Supported("sigaltstack", Sigaltstack),
+		133: syscalls.Supported("rt_sigsuspend", RtSigsuspend),
+		134: syscalls.Supported("rt_sigaction", RtSigaction),
+		135: syscalls.Supported("rt_sigprocmask", RtSigprocmask),
+		136: syscalls.Supported("rt_sigpending", RtSigpending),
+		137: syscalls.Supported("rt_sigtimedwait", RtSigtimedwait),
+		138: syscalls.Supported("rt_sigqueueinfo", RtSigqueueinfo),
+		139: syscalls.Supported("rt_sigreturn", RtSigreturn),
+		140: syscalls.PartiallySupported("setpriority", Setpriority, "Stub implementation.", nil),
+		141: syscalls.PartiallySupported("getpriority", Getpriority, "Stub implementation.", nil),
+		142: syscalls.CapError("reboot", linux.CAP_SYS_BOOT, "", nil),
+		143: syscalls.Supported("setregid", Setregid),
+		144: syscalls.SupportedPoint("setgid", Setgid, PointSetgid),
+		145: syscalls.Supported("setreuid", Setreuid),
+		146: syscalls.SupportedPoint("setuid", Setuid, PointSetuid),
+		147: syscalls.SupportedPoint("setresuid", Setresuid, PointSetresuid),
+		148: syscalls.Supported("getresuid", Getresuid),
+		149: syscalls.SupportedPoint("setresgid", Setresgid, PointSetresgid),
+		150: syscalls.Supported("getresgid", Getresgid),
+		151: syscalls.ErrorWithEvent("setfsuid", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/260"}), // TODO(b/112851702)
+		152: syscalls.ErrorWithEvent("setfsgid", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/260"}), // TODO(b/112851702)
+		153: syscalls.Supported("times", Times),
+		154: syscalls.Supported("setpgid", Setpgid),
+		155: syscalls.Supported("getpgid", Getpgid),
+		156: syscalls.Supported("getsid", Getsid),
+		157: syscalls.SupportedPoint("setsid", Setsid, PointSetsid),
+		158: syscalls.Supported("getgroups", Getgroups),
+		159: syscalls.Supported("setgroups", Setgroups),
+		160: syscalls.Supported("uname", Uname),
+		161: syscalls.Supported("sethostname", Sethostname),
+		162: syscalls.Supported("setdomainname", Setdomainname),
+		163: syscalls.Supported("getrlimit", Getrlimit),
+		164: syscalls.PartiallySupported("setrlimit", Setrlimit, "Not all rlimits are enforced.", nil),
+		165: syscalls.PartiallySupported("getrusage", Getrusage, "Fields ru_inblock, ru_oublock are not supported. Fields ru_utime and ru_stime have low precision. Fields ru_minflt and ru_majflt only count faults handled by the sentry.", nil),
+		166: syscalls.Supported("umask", Umask),
+		167: syscalls.PartiallySupported("prctl", Prctl, "Not all options are supported.", nil),
+		168: syscalls.Supported("getcpu", Getcpu),
+		169: syscalls.Supported("gettimeofday", Gettimeofday),
+		170: syscalls.CapError("settimeofday", linux.CAP_SYS_TIME, "", nil),
+		171: syscalls.CapError("adjtimex", linux.CAP_SYS_TIME, "", nil),
+		172: syscalls.Supported("getpid", Getpid),
+		173: syscalls.Supported("getppid", Getppid),
+		174: syscalls.Supported("getuid", Getuid),
+		175: syscalls.Supported("geteuid", Geteuid),
+		176: syscalls.Supported("getgid", Getgid),
+		177: syscalls.Supported("getegid", Getegid),
+		178: syscalls.Supported("gettid", Gettid),
+		179: syscalls.PartiallySupported("sysinfo", Sysinfo, "Fields loads, sharedram, bufferram, totalswap, freeswap, totalhigh, freehigh not supported.", nil),
+		180: syscalls.Supported("mq_open", MqOpen),
+		181: syscalls.Supported("mq_unlink", MqUnlink),
+		182: syscalls.ErrorWithEvent("mq_timedsend", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/136"}),    // TODO(b/29354921)
+		183: syscalls.ErrorWithEvent("mq_timedreceive", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/136"}), // TODO(b/29354921)
+		184: syscalls.ErrorWithEvent("mq_notify", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/136"}),       // TODO(b/29354921)
+		185: syscalls.ErrorWithEvent("mq_getsetattr", linuxerr.ENOSYS, "", []string{"gvisor.dev/issue/136"}),   // TODO(b/29354921)
+		186: syscalls.Supported("msgget", Msgget),
+		187: syscalls.Supported("msgctl", Msgctl),
+		188: syscalls.Supported("msgrcv", Msgrcv),
+		189: syscalls.Supported("msgsnd", Msgsnd),
+		190: syscalls.Supported("semget", Semget),
+		191: syscalls.Supported("semctl", Semctl),
+		192: syscalls.Supported("semtimedop", Semtimedop),
+		193: syscalls.Supported("semop", Semop),
+		194: syscalls.PartiallySupported("shmget", Shmget, "Option SHM_HUGETLB is not supported.", nil),
+		195: syscalls.PartiallySupported("shmctl", Shmctl, "Options SHM_LOCK, SHM_UNLOCK are not supported.", nil),
+		196: syscalls.PartiallySupported("shmat", Shmat, "Option SHM_RND is not supported.", nil),
+		197: syscalls.Supported("shmdt", Shmdt),
+		198: syscalls.SupportedPoint("socket", Socket, PointSocket),
+		199: syscalls.SupportedPoint("socketpair", SocketPair, PointSocketpair),
+		200: syscalls.SupportedPoint("bind", Bind, PointBind),
+		201: syscalls.Supported("listen", Listen),
+		202: syscalls.SupportedPoint("accept", Accept, PointAccept),
+		203: syscalls.SupportedPoint("connect", Connect, PointConnect),
+		204: syscalls.Supported("getsockname", GetSockName),
+		205: syscalls.Supported("getpeername", GetPeerName),
+		206: syscalls.Supported("sendto", SendTo),
+		207: syscalls.Supported("recvfrom", RecvFrom),
+		208: syscalls.Supported("setsockopt", SetSockOpt),
+		209: syscalls.Supported("getsockopt", GetSockOpt),
+		210: syscalls.Supported("shutdown", Shutdown),
+		211: syscalls.Supported("sendmsg", SendMsg),
+		212: syscalls.Supported("recvmsg", RecvMsg),
+		213: syscalls.Supported("readahead", Readahead),
+		214: syscalls.Supported("brk", Brk),
+		215: syscalls.Supported("munmap", Munmap),
+		216: syscalls.Supported("mremap", Mremap),
+		217: syscalls.Error("add_key", linuxerr.EACCES, "Not available to user.", nil),
+		218: syscalls.Error("request_key", linuxerr.EACCES, "Not available to user.", nil),
+		219: syscalls.PartiallySupported("keyctl", Keyctl, "Only supports session keyrings with zero keys in them.", nil),
+		220: syscalls.PartiallySupportedPoint("clone", Clone, PointClone, "Options CLONE_PIDFD, CLONE_NEWCGROUP, CLONE_PARENT, CLONE_NEWTIME, and CLONE_CLEAR_SIGHAND not supported.", nil),
+		221: syscalls.SupportedPoint("execve", Execve, PointExecve),
+		222: syscalls.Supported("mmap", Mmap),
+		223: syscalls.PartiallySupported("fadvise64", Fadvise64, "Advice only affects files whose contents are cached by the sandbox.", nil),
+		224: syscalls.CapError("swapon", linux.CAP_SYS_ADMIN, "", nil),
+		225: syscalls.CapError("swapoff", linux.CAP_SYS_ADMIN, "", nil),
+		226: syscalls.Supported("mprotect", Mprotect),
+		227: syscalls.PartiallySupported("msync", Msync, "Full data flush is not guaranteed at this time.", nil),
+		228: syscalls.PartiallySupported("mlock", Mlock, "Stub implementation. The sandbox lacks appropriate permissions.", nil),
+		229: syscalls.PartiallySupported("munlock", Munlock, "Stub implementation. The sandbox lacks appropriate permissions.", nil),
+		230: syscalls.PartiallySupported("mlockall", Mlockall, "Stub implementation. The sandbox lacks appropriate permissions.", nil),
+		231: syscalls.PartiallySupported("munlockall", Munlockall, "Stub implementation. The sandbox lacks appropriate permissions.", nil),
+		232: syscalls.PartiallySupported("mincore", Mincore, "Stub implementation. The sandbox does not have access to this information. Reports all mapped pages are resident.", nil),
+		233: syscalls.PartiallySupported("madvise", Madvise, "Options MADV_DONTNEED, MADV_DONTFORK are supported. Other advice is ignored.", nil),
+		234: syscalls.ErrorWithEvent("remap_file_pages", linuxerr.ENOSYS, "Deprecated since Linux 3.16.", nil),
+		235: syscalls.PartiallySupported("mbind", Mbind, "NUMA nodes are emulated, and memory is not actually placed on them. mbind() has effects reflected by get_mempolicy and move_pages.", []string{"gvisor.dev/issue/262"}),
+		236: syscalls.PartiallySupported("get_mempolicy", GetMempolicy, "NUMA nodes are emulated, and pages are reported on the first node allowed by their policy.", nil),
+		237: syscalls.PartiallySupported("set_mempolicy", SetMempolicy, "NUMA nodes are emulated, and the policy is not enforced.", nil),
+		238: syscalls.CapError("migrate_pages", linux.CAP_SYS_NICE, "", nil),
+		239: syscalls.PartiallySupported("move_pages", MovePages, "NUMA nodes are emulated, and pages are not actually migrated.", nil),
+		240: syscalls.Supported("rt_tgsigqueueinfo", RtTgsigqueueinfo),
+		241: syscalls.ErrorWithEvent("perf_event_open", linuxerr.ENODEV, "No support for perf counters", nil),
+		242: syscalls.SupportedPoint("accept4", Accept4, PointAccept4),
+		243: syscalls.Supported("recvmmsg", RecvMMsg),
+		258: syscalls.ErrorWithEvent("riscv_hwprobe", linuxerr.ENOSYS, "", nil),
+		259: syscalls.ErrorWithEvent("riscv_flush_icache", linuxerr.ENOSYS, "", nil),
+		260: syscalls.Supported("wait4", Wait4),
+		261: syscalls.SupportedPoint("prlimit64", Prlimit64, PointPrlimit64),
+		262: syscalls.ErrorWithEvent("fanotify_init", linuxerr.ENOSYS, "Needs CONFIG_FANOTIFY", nil),
+		263: syscalls.ErrorWithEvent("fanotify_mark", linuxerr.ENOSYS, "Needs CONFIG_FANOTIFY", nil),
+		264: syscalls.Error("name_to_handle_at", linuxerr.EOPNOTSUPP, "Not supported by gVisor filesystems", nil),
+		265: syscalls.Error("open_by_handle_at", linuxerr.EOPNOTSUPP, "Not supported by gVisor filesystems", nil),
+		266: syscalls.CapError("clock_adjtime", linux.CAP_SYS_TIME, "", nil),
+		267: syscalls.Supported("syncfs", Syncfs),
+		268: syscalls.Supported("setns", Setns),
+		269: syscalls.Supported("sendmmsg", SendMMsg),
+		270: syscalls.Supported("process_vm_readv", ProcessVMReadv),
+		271: syscalls.Supported("process_vm_writev", ProcessVMWritev),
+		272: syscalls.PartiallySupported("kcmp", Kcmp, "KCMP_IO always reports equal I/O contexts, as tasks don't have any.", nil),
+		273: syscalls.CapError("finit_module", linux.CAP_SYS_MODULE, "", nil),
+		274: syscalls.PartiallySupported("sched_setattr", SchedSetattr, "Scheduling policies are not enforced. SCHED_DEADLINE bandwidth is accounted but not reserved.", nil),
+		275: syscalls.PartiallySupported("sched_getattr", SchedGetattr, "Scheduling policies are not enforced. SCHED_DEADLINE bandwidth is accounted but not reserved.", nil),
+		276: syscalls.Supported("renameat2", Renameat2),
+		277: syscalls.Supported("seccomp", Seccomp),
+		278: syscalls.Supported("getrandom", GetRandom),
+		279: syscalls.Supported("memfd_create", MemfdCreate),
+		280: syscalls.CapError("bpf", linux.CAP_SYS_ADMIN, "", nil),
+		281: syscalls.SupportedPoint("execveat", Execveat, PointExecveat),
+		282: syscalls.PartiallySupported("userfaultfd", Userfaultfd, "Missing and write-protect faults are only supported on anonymous memory, and minor faults on shared memory. Non-cooperative events are not supported.", nil),
+		283: syscalls.PartiallySupported("membarrier", Membarrier, "Not supported on all platforms.", nil),
+		284: syscalls.PartiallySupported("mlock2", Mlock2, "Stub implementation. The sandbox lacks appropriate permissions.", nil),
+
+		// Syscalls after 284 are "backports" from versions of Linux after 4.4.
+		285: syscalls.ErrorWithEvent("copy_file_range", linuxerr.ENOSYS, "", nil),
+		286: syscalls.SupportedPoint("preadv2", Preadv2, PointPreadv2),
+		287: syscalls.SupportedPoint("pwritev2", Pwritev2, PointPwritev2),
+		288: syscalls.ErrorWithEvent("pkey_mprotect", linuxerr.ENOSYS, "", nil),
+		289: syscalls.ErrorWithEvent("pkey_alloc", linuxerr.ENOSYS, "", nil),
+		290: syscalls.ErrorWithEvent("pkey_free", linuxerr.ENOSYS, "", nil),
+		291: syscalls.Supported("statx", Statx),
+		292: syscalls.ErrorWithEvent("io_pgetevents", linuxerr.ENOSYS, "", nil),
+		293: syscalls.PartiallySupported("rseq", RSeq, "Not supported on all platforms.", nil),
+
+		// Linux skips ahead to syscall 424 to sync numbers between arches.
+		424: syscalls.PartiallySupported("pidfd_send_signal", PidfdSendSignal, "/proc/[pid] directory file descriptors are not accepted in place of a pidfd.", nil),
+		425: syscalls.PartiallySupported("io_uring_setup", IOUringSetup, "Not all flags and functionality supported.", nil),
+		426: syscalls.PartiallySupported("io_uring_enter", IOUringEnter, "Not all flags and functionality supported.", nil),
+		427: syscalls.PartiallySupported("io_uring_register", IOUringRegister, "Only buffer registration and opcode probing are supported.", nil),
+		428: syscalls.ErrorWithEvent("open_tree", linuxerr.ENOSYS, "", nil),
+		429: syscalls.ErrorWithEvent("move_mount", linuxerr.ENOSYS, "", nil),
+		430: syscalls.ErrorWithEvent("fsopen", linuxerr.ENOSYS, "", nil),
+		431: syscalls.ErrorWithEvent("fsconfig", linuxerr.ENOSYS, "", nil),
+		432: syscalls.ErrorWithEvent("fsmount", linuxerr.ENOSYS, "", nil),
+		433: syscalls.ErrorWithEvent("fspick", linuxerr.ENOSYS, "", nil),
+		434: syscalls.Supported("pidfd_open", PidfdOpen),
+		435: syscalls.PartiallySupported("clone3", Clone3, "Options CLONE_PIDFD, CLONE_NEWCGROUP, CLONE_CLEAR_SIGHAND, CLONE_PARENT and clone_args.set_tid are not supported. CLONE_INTO_CGROUP accepts any cgroupfs directory.", nil),
+		436: syscalls.Supported("close_range", CloseRange),
+		438: syscalls.Supported("pidfd_getfd", PidfdGetfd),
+		439: syscalls.Supported("faccessat2", Faccessat2),
+		441: syscalls.Supported("epoll_pwait2", EpollPwait2),
+		449: syscalls.Supported("futex_waitv", FutexWaitv),
 	},
 	Emulate: map[hostarch.Addr]uintptr{},
 	Missing: func(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, error) {
@@ -739,4 +1066,5 @@ var ARM64 = &kernel.SyscallTable{
 func init() {
 	kernel.RegisterSyscallTable(AMD64)
 	kernel.RegisterSyscallTable(ARM64)
+	kernel.RegisterSyscallTable(RISCV64)
 }
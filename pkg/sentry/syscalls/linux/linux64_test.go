@@ -102,6 +102,37 @@ func TestSeccheckSyscalls(t *testing.T) {
 	}
 }
 
+// TestRISCV64Table checks that the riscv64 syscall table only differs from the
+// arm64 one where the riscv64 syscall numbers differ from the generic ones.
+func TestRISCV64Table(t *testing.T) {
+	riscv64Only := map[uintptr]string{
+		258: "riscv_hwprobe",
+		259: "riscv_flush_icache",
+	}
+	arm64Only := map[uintptr]string{
+		38: "renameat",
+	}
+	for sysno, sc := range RISCV64.Table {
+		if name, ok := riscv64Only[sysno]; ok {
+			if sc.Name != name {
+				t.Errorf("riscv64 syscall %d is %q, want %q", sysno, sc.Name, name)
+			}
+			continue
+		}
+		if want, ok := ARM64.Table[sysno]; !ok || want.Name != sc.Name {
+			t.Errorf("riscv64 syscall %d is %q, want arm64 syscall %q", sysno, sc.Name, want.Name)
+		}
+	}
+	for sysno, sc := range ARM64.Table {
+		if _, ok := RISCV64.Table[sysno]; ok {
+			continue
+		}
+		if name, ok := arm64Only[sysno]; !ok || name != sc.Name {
+			t.Errorf("arm64 syscall %d %q is missing on riscv64", sysno, sc.Name)
+		}
+	}
+}
+
 func TestMain(m *testing.M) {
 	seccheck.Initialize()
 	os.Exit(m.Run())
@@ -307,12 +307,15 @@ func submitCallback(t *kernel.Task, id uint64, cb *linux.IOCallback, cbAddr host
 		return err
 	}
 
-	// Check offset for reads/writes.
+	// Check offset and flags for reads/writes.
 	switch cb.OpCode {
 	case linux.IOCB_CMD_PREAD, linux.IOCB_CMD_PREADV, linux.IOCB_CMD_PWRITE, linux.IOCB_CMD_PWRITEV:
 		if cb.Offset < 0 {
 			return linuxerr.EINVAL
 		}
+		if cb.RWFlags&^linux.RWF_VALID != 0 {
+			return linuxerr.EOPNOTSUPP
+		}
 	}
 
 	// Prepare the request.
@@ -358,9 +361,9 @@ func getAIOCallback(t *kernel.Task, fd, eventFD *vfs.FileDescription, cbAddr hos
 		var err error
 		switch cb.OpCode {
 		case linux.IOCB_CMD_PREAD, linux.IOCB_CMD_PREADV:
-			ev.Result, err = fd.PRead(ctx, ioseq, cb.Offset, vfs.ReadOptions{})
+			ev.Result, err = fd.PRead(ctx, ioseq, cb.Offset, vfs.ReadOptions{Flags: cb.RWFlags})
 		case linux.IOCB_CMD_PWRITE, linux.IOCB_CMD_PWRITEV:
-			ev.Result, err = fd.PWrite(ctx, ioseq, cb.Offset, vfs.WriteOptions{})
+			ev.Result, err = fd.PWrite(ctx, ioseq, cb.Offset, vfs.WriteOptions{Flags: cb.RWFlags})
 		case linux.IOCB_CMD_FSYNC, linux.IOCB_CMD_FDSYNC:
 			err = fd.Sync(ctx)
 		}
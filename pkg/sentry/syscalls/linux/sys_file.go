@@ -15,6 +15,8 @@
 package linux
 
 import (
+	"math"
+
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/fspath"
@@ -585,6 +587,28 @@ func dup3(t *kernel.Task, oldfd, newfd int32, flags uint32) (uintptr, *kernel.Sy
 	return uintptr(newfd), nil, nil
 }
 
+// pipeSizeLimits returns the limits on the pipe sizes that t may set with
+// F_SETPIPE_SZ. See Linux's fs/pipe.c:pipe_set_size().
+func pipeSizeLimits(t *kernel.Task) pipe.SizeLimits {
+	k := t.Kernel()
+	limits := pipe.SizeLimits{MaxSize: int64(k.PipeMaxSize.Load())}
+	if t.HasCapability(linux.CAP_SYS_RESOURCE) {
+		limits.MaxSize = math.MaxInt64
+	}
+	if t.HasCapability(linux.CAP_SYS_RESOURCE) || t.HasCapability(linux.CAP_SYS_ADMIN) {
+		return limits
+	}
+	for _, pages := range []int32{k.PipeUserPagesSoft.Load(), k.PipeUserPagesHard.Load()} {
+		if pages == 0 {
+			continue
+		}
+		if bytes := int64(pages) * hostarch.PageSize; limits.MaxEnlargedBytes == 0 || bytes < limits.MaxEnlargedBytes {
+			limits.MaxEnlargedBytes = bytes
+		}
+	}
+	return limits
+}
+
 // Fcntl implements linux syscall fcntl(2).
 func Fcntl(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
 	fd := args[0].Int()
@@ -667,7 +691,7 @@ func Fcntl(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 		if !ok {
 			return 0, nil, linuxerr.EBADF
 		}
-		n, err := pipefile.SetPipeSize(int64(args[2].Int()))
+		n, err := pipefile.SetPipeSize(uint64(args[2].Uint()), pipeSizeLimits(t))
 		if err != nil {
 			return 0, nil, err
 		}
@@ -885,9 +909,9 @@ func posixLock(t *kernel.Task, args arch.SyscallArguments, file *vfs.FileDescrip
 }
 
 // Fadvise64 implements fadvise64(2).
-// This implementation currently ignores the provided advice.
 func Fadvise64(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
 	fd := args[0].Int()
+	offset := args[1].Int64()
 	length := args[2].Int64()
 	advice := args[3].Int()
 
@@ -922,8 +946,7 @@ func Fadvise64(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintp
 		return 0, nil, linuxerr.EINVAL
 	}
 
-	// Sure, whatever.
-	return 0, nil, nil
+	return 0, nil, file.Advise(t, offset, length, advice)
 }
 
 // Mkdir implements Linux syscall mkdir(2).
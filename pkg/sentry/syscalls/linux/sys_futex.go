@@ -15,6 +15,7 @@
 package linux
 
 import (
+	"math"
 	"time"
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
@@ -22,6 +23,7 @@ import (
 	"gvisor.dev/gvisor/pkg/hostarch"
 	"gvisor.dev/gvisor/pkg/sentry/arch"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/futex"
 	"gvisor.dev/gvisor/pkg/sentry/ktime"
 )
 
@@ -63,7 +65,8 @@ func futexWaitAbsolute(t *kernel.Task, clockRealtime bool, ts linux.Timespec, fo
 	} else if clockRealtime {
 		err = t.BlockWithDeadlineFrom(w.C, t.Kernel().RealtimeClock(), true, ktime.FromTimespec(ts))
 	} else {
-		err = t.BlockWithDeadline(w.C, true, ktime.FromTimespec(ts))
+		// The deadline is on CLOCK_MONOTONIC in t's time namespace.
+		err = t.BlockWithDeadlineFrom(w.C, t.TimeNamespace().MonotonicClock(), true, ktime.FromTimespec(ts))
 	}
 
 	t.Futex().WaitComplete(w, t)
@@ -273,6 +276,84 @@ func Futex(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 	}
 }
 
+// FutexWaitv implements linux syscall futex_waitv(2).
+// It waits on up to FUTEX_WAITV_MAX futexes at once, and returns the index of
+// one that was woken.
+func FutexWaitv(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	waitersAddr := args[0].Pointer()
+	nrFutexes := args[1].Uint()
+	flags := args[2].Uint()
+	timeout := args[3].Pointer()
+	clockID := args[4].Int()
+
+	if flags != 0 {
+		return 0, nil, linuxerr.EINVAL
+	}
+	if nrFutexes == 0 || nrFutexes > linux.FUTEX_WAITV_MAX || waitersAddr == 0 {
+		return 0, nil, linuxerr.EINVAL
+	}
+
+	// The timeout is absolute, on either CLOCK_MONOTONIC or CLOCK_REALTIME.
+	forever := timeout == 0
+	var timespec linux.Timespec
+	if !forever {
+		if clockID != linux.CLOCK_REALTIME && clockID != linux.CLOCK_MONOTONIC {
+			return 0, nil, linuxerr.EINVAL
+		}
+		var err error
+		timespec, err = copyTimespecIn(t, timeout)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	waiters := make([]linux.FutexWaitv, nrFutexes)
+	if _, err := linux.CopyFutexWaitvSliceIn(t, waitersAddr, waiters); err != nil {
+		return 0, nil, err
+	}
+	entries := make([]futex.WaitEntry, nrFutexes)
+	for i, w := range waiters {
+		// Only 32-bit futexes are supported, as in Linux.
+		if w.Flags&^(linux.FUTEX2_SIZE_MASK|linux.FUTEX2_PRIVATE) != 0 || w.Reserved != 0 {
+			return 0, nil, linuxerr.EINVAL
+		}
+		if w.Flags&linux.FUTEX2_SIZE_MASK != linux.FUTEX2_SIZE_U32 || w.Val > math.MaxUint32 {
+			return 0, nil, linuxerr.EINVAL
+		}
+		entries[i] = futex.WaitEntry{
+			Addr:    hostarch.Addr(w.Uaddr),
+			Private: w.Flags&linux.FUTEX2_PRIVATE != 0,
+			Val:     uint32(w.Val),
+		}
+	}
+
+	mw := futex.NewMultiWaiter(len(entries))
+	woken, err := t.Futex().WaitMultiplePrepare(mw, t, entries)
+	if err != nil {
+		return 0, nil, err
+	}
+	if woken >= 0 {
+		return uintptr(woken), nil, nil
+	}
+
+	if forever {
+		err = t.Block(mw.C)
+	} else if clockID == linux.CLOCK_REALTIME {
+		err = t.BlockWithDeadlineFrom(mw.C, t.Kernel().RealtimeClock(), true, ktime.FromTimespec(timespec))
+	} else {
+		// The deadline is on CLOCK_MONOTONIC in t's time namespace.
+		err = t.BlockWithDeadlineFrom(mw.C, t.TimeNamespace().MonotonicClock(), true, ktime.FromTimespec(timespec))
+	}
+
+	// A futex may have been woken concurrently with a timeout or interruption.
+	if woken := t.Futex().WaitMultipleComplete(mw, t); woken >= 0 {
+		return uintptr(woken), nil, nil
+	}
+	// The timeout is absolute, so the syscall can be restarted with the
+	// original arguments.
+	return 0, nil, linuxerr.ConvertIntr(err, linuxerr.ERESTARTSYS)
+}
+
 // SetRobustList implements linux syscall set_robust_list(2).
 func SetRobustList(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
 	// Despite the syscall using the name 'pid' for this variable, it is
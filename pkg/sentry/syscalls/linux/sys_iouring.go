@@ -106,11 +106,6 @@ func IOUringEnter(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (ui
 		return uintptr(ret), nil, linuxerr.EFAULT
 	}
 
-	// If a user requested to submit zero SQEs, then we don't process any and return right away.
-	if toSubmit == 0 {
-		return uintptr(ret), nil, nil
-	}
-
 	file := t.GetFile(fd)
 	if file == nil {
 		return uintptr(ret), nil, linuxerr.EBADF
@@ -127,3 +122,26 @@ func IOUringEnter(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (ui
 
 	return uintptr(ret), nil, nil
 }
+
+// IOUringRegister implements linux syscall io_uring_register(2).
+func IOUringRegister(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	if !kernel.IOUringEnabled {
+		return 0, nil, linuxerr.ENOSYS
+	}
+
+	fd := int32(args[0].Int())
+	opcode := uint32(args[1].Uint())
+	arg := args[2].Pointer()
+	nrArgs := uint32(args[3].Uint())
+
+	file := t.GetFile(fd)
+	if file == nil {
+		return 0, nil, linuxerr.EBADF
+	}
+	defer file.DecRef(t)
+	iouringfd, ok := file.Impl().(*iouringfs.FileDescription)
+	if !ok {
+		return 0, nil, linuxerr.EOPNOTSUPP
+	}
+	return 0, nil, iouringfd.Register(t, opcode, arg, nrArgs)
+}
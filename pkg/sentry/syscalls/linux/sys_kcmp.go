@@ -0,0 +1,190 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"math"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/rand"
+	"gvisor.dev/gvisor/pkg/sentry/arch"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/sync"
+)
+
+var (
+	// kcmpCookies are used to obfuscate the addresses of the objects compared
+	// by kcmp(2), so that their order doesn't leak sentry addresses. See
+	// Linux's kernel/kcmp.c:kptr_obfuscate().
+	kcmpCookies     [linux.KCMP_TYPES][2]uint64
+	kcmpCookiesOnce sync.Once
+)
+
+// kcmpObfuscate returns the obfuscated value of the address addr of an object
+// of the given kcmp type.
+func kcmpObfuscate(addr uintptr, typ int32) uint64 {
+	kcmpCookiesOnce.Do(func() {
+		var buf [linux.KCMP_TYPES * 2 * 8]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			panic("failed to read random kcmp cookies: " + err.Error())
+		}
+		for i := range kcmpCookies {
+			kcmpCookies[i][0] = hostarch.ByteOrder.Uint64(buf[i*16:])
+			// An odd multiplier makes the multiplication a bijection, and the
+			// top bit ensures that it is large.
+			kcmpCookies[i][1] = hostarch.ByteOrder.Uint64(buf[i*16+8:]) | 1<<63 | 1
+		}
+	})
+	return (uint64(addr) ^ kcmpCookies[typ][0]) * kcmpCookies[typ][1]
+}
+
+// kcmpFile returns the address of the file with descriptor fd in t's file
+// descriptor table.
+func kcmpFile(t *kernel.Task, fd uint64) (uintptr, error) {
+	if fd > math.MaxInt32 {
+		return 0, linuxerr.EBADF
+	}
+	var file *vfs.FileDescription
+	t.WithMuLocked(func(t *kernel.Task) {
+		if fdt := t.FDTable(); fdt != nil {
+			file, _ = fdt.Get(int32(fd))
+		}
+	})
+	if file == nil {
+		return 0, linuxerr.EBADF
+	}
+	// Only the address of the file is compared, so the reference can be
+	// dropped immediately.
+	addr := kcmpAddr(file)
+	file.DecRef(t)
+	return addr, nil
+}
+
+// kcmpEpollTarget returns the address of the file described by the
+// linux.KcmpEpollSlot at addr, which is registered with an epoll instance in
+// t's file descriptor table.
+func kcmpEpollTarget(caller, t *kernel.Task, addr hostarch.Addr) (uintptr, error) {
+	var slot linux.KcmpEpollSlot
+	if _, err := slot.CopyIn(caller, addr); err != nil {
+		return 0, err
+	}
+	var file *vfs.FileDescription
+	t.WithMuLocked(func(t *kernel.Task) {
+		if fdt := t.FDTable(); fdt != nil && slot.EFD <= math.MaxInt32 {
+			file, _ = fdt.Get(int32(slot.EFD))
+		}
+	})
+	if file == nil {
+		return 0, linuxerr.EBADF
+	}
+	defer file.DecRef(caller)
+	ep, ok := file.Impl().(*vfs.EpollInstance)
+	if !ok {
+		return 0, linuxerr.EINVAL
+	}
+	target := ep.InterestFile(int32(slot.TFD), slot.TOff)
+	if target == nil {
+		return 0, linuxerr.ENOENT
+	}
+	return kcmpAddr(target), nil
+}
+
+// kcmpResource returns the address of the resource of the given kcmp type
+// used by t, or 0 if t doesn't use such a resource.
+func kcmpResource(t *kernel.Task, typ int32) uintptr {
+	var addr uintptr
+	t.WithMuLocked(func(t *kernel.Task) {
+		switch typ {
+		case linux.KCMP_VM:
+			addr = kcmpAddr(t.MemoryManager())
+		case linux.KCMP_FILES:
+			addr = kcmpAddr(t.FDTable())
+		case linux.KCMP_FS:
+			addr = kcmpAddr(t.FSContext())
+		case linux.KCMP_SIGHAND:
+			addr = kcmpAddr(t.ThreadGroup().SignalHandlers())
+		case linux.KCMP_SYSVSEM:
+			addr = kcmpAddr(t.SemUndoListIfExists())
+		case linux.KCMP_IO:
+			// The sentry doesn't implement I/O contexts, so tasks never have
+			// one, like Linux tasks that haven't been given an I/O priority.
+		}
+	})
+	return addr
+}
+
+// Kcmp implements Linux syscall kcmp(2).
+func Kcmp(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	pid1 := kernel.ThreadID(args[0].Int())
+	pid2 := kernel.ThreadID(args[1].Int())
+	typ := args[2].Int()
+	idx1 := args[3].Uint64()
+	idx2 := args[4].Uint64()
+
+	task1 := t.PIDNamespace().TaskWithID(pid1)
+	task2 := t.PIDNamespace().TaskWithID(pid2)
+	if task1 == nil || task2 == nil {
+		return 0, nil, linuxerr.ESRCH
+	}
+	// "Permission to employ kcmp() is governed by ptrace access mode
+	// PTRACE_MODE_READ_REALCREDS checks against both pid1 and pid2." -
+	// kcmp(2)
+	if !t.CanTrace(task1, false /* attach */) || !t.CanTrace(task2, false /* attach */) {
+		return 0, nil, linuxerr.EPERM
+	}
+
+	var addr1, addr2 uintptr
+	switch typ {
+	case linux.KCMP_FILE:
+		var err error
+		if addr1, err = kcmpFile(task1, idx1); err != nil {
+			return 0, nil, err
+		}
+		if addr2, err = kcmpFile(task2, idx2); err != nil {
+			return 0, nil, err
+		}
+	case linux.KCMP_EPOLL_TFD:
+		var err error
+		if addr1, err = kcmpFile(task1, idx1); err != nil {
+			return 0, nil, err
+		}
+		if addr2, err = kcmpEpollTarget(t, task2, hostarch.Addr(idx2)); err != nil {
+			return 0, nil, err
+		}
+		// The target file is compared like any other file.
+		typ = linux.KCMP_FILE
+	case linux.KCMP_VM, linux.KCMP_FILES, linux.KCMP_FS, linux.KCMP_SIGHAND, linux.KCMP_IO, linux.KCMP_SYSVSEM:
+		addr1 = kcmpResource(task1, typ)
+		addr2 = kcmpResource(task2, typ)
+	default:
+		return 0, nil, linuxerr.EINVAL
+	}
+
+	// "The return value of a successful call to kcmp() is simply the result
+	// of arithmetic comparison of kernel pointers (when the kernel compares
+	// resources, it uses their memory addresses)." - kcmp(2)
+	v1, v2 := kcmpObfuscate(addr1, typ), kcmpObfuscate(addr2, typ)
+	switch {
+	case v1 == v2:
+		return 0, nil, nil
+	case v1 < v2:
+		return 1, nil, nil
+	default:
+		return 2, nil, nil
+	}
+}
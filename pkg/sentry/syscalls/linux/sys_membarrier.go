@@ -43,6 +43,8 @@ func Membarrier(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uint
 			supportedCommands |= linux.MEMBARRIER_CMD_PRIVATE_EXPEDITED_RSEQ |
 				linux.MEMBARRIER_CMD_REGISTER_PRIVATE_EXPEDITED_RSEQ
 		}
+		supportedCommands |= linux.MEMBARRIER_CMD_PRIVATE_EXPEDITED_SYNC_CORE |
+			linux.MEMBARRIER_CMD_REGISTER_PRIVATE_EXPEDITED_SYNC_CORE
 		return supportedCommands, nil, nil
 	case linux.MEMBARRIER_CMD_GLOBAL, linux.MEMBARRIER_CMD_GLOBAL_EXPEDITED, linux.MEMBARRIER_CMD_PRIVATE_EXPEDITED:
 		if flags != 0 {
@@ -73,6 +75,29 @@ func Membarrier(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uint
 		}
 		t.MemoryManager().EnableMembarrierPrivate()
 		return 0, nil, nil
+	case linux.MEMBARRIER_CMD_PRIVATE_EXPEDITED_SYNC_CORE:
+		if flags != 0 {
+			return 0, nil, linuxerr.EINVAL
+		}
+		if !t.MemoryManager().IsMembarrierSyncCoreEnabled() {
+			return 0, nil, linuxerr.EPERM
+		}
+		// Leaving application code in other tasks also implies a memory
+		// barrier, but use the platform's if there is one, as for
+		// MEMBARRIER_CMD_PRIVATE_EXPEDITED.
+		if t.Kernel().Platform.HaveGlobalMemoryBarrier() {
+			if err := t.Kernel().Platform.GlobalMemoryBarrier(); err != nil {
+				return 0, nil, err
+			}
+		}
+		t.MembarrierSyncCore()
+		return 0, nil, nil
+	case linux.MEMBARRIER_CMD_REGISTER_PRIVATE_EXPEDITED_SYNC_CORE:
+		if flags != 0 {
+			return 0, nil, linuxerr.EINVAL
+		}
+		t.MemoryManager().EnableMembarrierSyncCore()
+		return 0, nil, nil
 	case linux.MEMBARRIER_CMD_PRIVATE_EXPEDITED_RSEQ:
 		if flags&^linux.MEMBARRIER_CMD_FLAG_CPU != 0 {
 			return 0, nil, linuxerr.EINVAL
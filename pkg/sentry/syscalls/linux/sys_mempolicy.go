@@ -16,22 +16,19 @@ package linux
 
 import (
 	"fmt"
+	"math/bits"
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
 	"gvisor.dev/gvisor/pkg/sentry/arch"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
 	"gvisor.dev/gvisor/pkg/usermem"
 )
 
-// We unconditionally report a single NUMA node. This also means that our
-// "nodemask_t" is a single unsigned long (uint64).
-const (
-	maxNodes        = 1
-	allowedNodemask = (1 << maxNodes) - 1
-)
-
+// copyInNodemask copies in a nodemask. There are at most kernel.MaxNUMANodes
+// NUMA nodes, so our "nodemask_t" is a single unsigned long (uint64).
 func copyInNodemask(t *kernel.Task, addr hostarch.Addr, maxnode uint32) (uint64, error) {
 	// "nodemask points to a bit mask of node IDs that contains up to maxnode
 	// bits. The bit mask size is rounded to the next multiple of
@@ -57,7 +54,7 @@ func copyInNodemask(t *kernel.Task, addr hostarch.Addr, maxnode uint32) (uint64,
 	val := hostarch.ByteOrder.Uint64(buf)
 	// Check that only allowed bits in the first unsigned long in the nodemask
 	// are set.
-	if val&^allowedNodemask != 0 {
+	if val&^t.Kernel().NUMANodeMask() != 0 {
 		return 0, linuxerr.EINVAL
 	}
 	// Check that all remaining bits in the nodemask are 0.
@@ -118,7 +115,7 @@ func GetMempolicy(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (ui
 
 	// "EINVAL: The value specified by maxnode is less than the number of node
 	// IDs supported by the system." - get_mempolicy(2)
-	if nodemask != 0 && maxnode < maxNodes {
+	if nodemask != 0 && uint(maxnode) < t.Kernel().NUMANodes() {
 		return 0, nil, linuxerr.EINVAL
 	}
 
@@ -132,7 +129,7 @@ func GetMempolicy(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (ui
 		if nodeFlag || addrFlag {
 			return 0, nil, linuxerr.EINVAL
 		}
-		if err := copyOutNodemask(t, nodemask, maxnode, allowedNodemask); err != nil {
+		if err := copyOutNodemask(t, nodemask, maxnode, t.Kernel().NUMANodeMask()); err != nil {
 			return 0, nil, err
 		}
 		return 0, nil, nil
@@ -163,7 +160,7 @@ func GetMempolicy(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (ui
 			if err != nil {
 				return 0, nil, err
 			}
-			policy = linux.MPOL_DEFAULT // maxNodes == 1
+			policy = linux.NumaPolicy(pageNode(policy, nodemaskVal))
 		}
 		if mode != 0 {
 			if _, err := policy.CopyOut(t, mode); err != nil {
@@ -200,7 +197,7 @@ func GetMempolicy(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (ui
 		if policy&^linux.MPOL_MODE_FLAGS != linux.MPOL_INTERLEAVE {
 			return 0, nil, linuxerr.EINVAL
 		}
-		policy = linux.MPOL_DEFAULT // maxNodes == 1
+		policy = linux.NumaPolicy(pageNode(policy, nodemaskVal))
 	}
 	if mode != 0 {
 		if _, err := policy.CopyOut(t, mode); err != nil {
@@ -253,8 +250,8 @@ func Mbind(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 		return 0, nil, err
 	}
 
-	// Since we claim to have only a single node, all flags can be ignored
-	// (since all pages must already be on that single node).
+	// Since memory isn't actually placed on nodes, all flags can be ignored
+	// (pages are always on the nodes allowed by their policy, see pageNode).
 	err = t.MemoryManager().SetNumaPolicy(addr, length, mode, nodemaskVal)
 	return 0, nil, err
 }
@@ -315,3 +312,116 @@ func copyInMempolicyNodemask(t *kernel.Task, modeWithFlags linux.NumaPolicy, nod
 
 	return mode | flags, nodemaskVal, nil
 }
+
+// pageNode returns the NUMA node reported for pages allocated under the given
+// policy. Since application memory isn't actually placed on nodes, this is the
+// first node allowed by the policy, or node 0 if the policy doesn't specify
+// nodes.
+func pageNode(policy linux.NumaPolicy, nodemask uint64) int32 {
+	switch policy &^ linux.MPOL_MODE_FLAGS {
+	case linux.MPOL_PREFERRED, linux.MPOL_BIND, linux.MPOL_INTERLEAVE:
+		if nodemask != 0 {
+			return int32(bits.TrailingZeros64(nodemask))
+		}
+	}
+	return 0
+}
+
+// MovePages implements the syscall move_pages(2).
+func MovePages(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	pid := kernel.ThreadID(args[0].Int())
+	count := args[1].Uint64()
+	pages := args[2].Pointer()
+	nodes := args[3].Pointer()
+	status := args[4].Pointer()
+	flags := args[5].Int()
+
+	if flags&^(linux.MPOL_MF_MOVE|linux.MPOL_MF_MOVE_ALL) != 0 {
+		return 0, nil, linuxerr.EINVAL
+	}
+	// "MPOL_MF_MOVE_ALL ... The caller must be privileged (CAP_SYS_NICE) to
+	// use this flag." - move_pages(2)
+	if flags&linux.MPOL_MF_MOVE_ALL != 0 && !t.HasCapability(linux.CAP_SYS_NICE) {
+		return 0, nil, linuxerr.EPERM
+	}
+
+	target := t
+	if pid != 0 {
+		if target = t.PIDNamespace().TaskWithID(pid); target == nil {
+			return 0, nil, linuxerr.ESRCH
+		}
+	}
+	if !t.CanTrace(target, false /* attach */) {
+		return 0, nil, linuxerr.EPERM
+	}
+	mm := t.MemoryManager()
+	if target != t {
+		// Pin the target's memory manager by adding ourselves as a user.
+		target.WithMuLocked(func(*kernel.Task) {
+			mm = target.MemoryManager()
+		})
+		if mm == nil || !mm.IncUsers() {
+			return 0, nil, linuxerr.EINVAL
+		}
+		defer mm.DecUsers(t)
+	}
+
+	// Process the pages in chunks to bound the size of the buffers, as Linux
+	// does.
+	const chunkSize = 512
+	addrs := make([]uint64, chunkSize)
+	var wantNodes []int32
+	if nodes != 0 {
+		wantNodes = make([]int32, chunkSize)
+	}
+	stat := make([]int32, chunkSize)
+	for count > 0 {
+		n := min(count, chunkSize)
+		if _, err := primitive.CopyUint64SliceIn(t, pages, addrs[:n]); err != nil {
+			return 0, nil, err
+		}
+		if wantNodes != nil {
+			if _, err := primitive.CopyInt32SliceIn(t, nodes, wantNodes[:n]); err != nil {
+				return 0, nil, err
+			}
+		}
+		for i, a := range addrs[:n] {
+			if wantNodes != nil {
+				// "ENODEV: One of the target nodes is not online."
+				if node := wantNodes[i]; node < 0 || uint(node) >= t.Kernel().NUMANodes() {
+					return 0, nil, linuxerr.ENODEV
+				}
+			}
+			addr := hostarch.Addr(a).RoundDown()
+			policy, nodemask, err := mm.NumaPolicy(addr)
+			if err != nil {
+				// "-EFAULT: This is a zero page or the memory area is not
+				// mapped by the process." - move_pages(2)
+				stat[i] = -int32(linuxerr.EFAULT.Errno())
+				continue
+			}
+			if resident, err := mm.Mincore(t, hostarch.AddrRange{Start: addr, End: addr + hostarch.PageSize}); err != nil || resident[0] == 0 {
+				// "-ENOENT: The page is not present."
+				stat[i] = -int32(linuxerr.ENOENT.Errno())
+				continue
+			}
+			if wantNodes != nil {
+				// Pages aren't actually placed on nodes, so they can
+				// always be moved.
+				stat[i] = wantNodes[i]
+			} else {
+				stat[i] = pageNode(policy, nodemask)
+			}
+		}
+		if _, err := primitive.CopyInt32SliceOut(t, status, stat[:n]); err != nil {
+			return 0, nil, err
+		}
+		pages += hostarch.Addr(n * 8)
+		if wantNodes != nil {
+			nodes += hostarch.Addr(n * 4)
+		}
+		status += hostarch.Addr(n * 4)
+		count -= n
+	}
+	return 0, nil, nil
+}
@@ -15,8 +15,6 @@
 package linux
 
 import (
-	"bytes"
-
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/hostarch"
@@ -239,14 +237,14 @@ func Mincore(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr
 		return 0, nil, linuxerr.ENOMEM
 	}
 
-	// Pretend that all mapped pages are "resident in core".
-	mapped := t.MemoryManager().VirtualMemorySizeRange(ar)
-	// "ENOMEM: addr to addr + length contained unmapped memory."
-	if mapped != uint64(la) {
-		return 0, nil, linuxerr.ENOMEM
+	if ar.Length() == 0 {
+		return 0, nil, nil
+	}
+	resident, err := t.MemoryManager().Mincore(t, ar)
+	if err != nil {
+		return 0, nil, err
 	}
-	resident := bytes.Repeat([]byte{1}, int(mapped/hostarch.PageSize))
-	_, err := t.CopyOutBytes(vec, resident)
+	_, err = t.CopyOutBytes(vec, resident)
 	return 0, nil, err
 }
 
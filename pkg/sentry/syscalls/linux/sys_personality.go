@@ -0,0 +1,38 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/sentry/arch"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+)
+
+// Personality implements linux syscall personality(2).
+func Personality(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	personality := args[0].Uint()
+	old := t.Personality()
+	// 0xffffffff queries the personality without changing it.
+	if personality == 0xffffffff {
+		return uintptr(old), nil, nil
+	}
+	// Only the Linux execution domain is supported.
+	if personality&linux.PER_MASK != linux.PER_LINUX {
+		return 0, nil, linuxerr.EINVAL
+	}
+	t.SetPersonality(personality)
+	return uintptr(old), nil, nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/sentry/arch"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/pidfd"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+)
+
+// getPIDFD returns the file and thread group referred to by the pidfd fd. The
+// caller must call DecRef on the returned file.
+func getPIDFD(t *kernel.Task, fd int32) (*vfs.FileDescription, *kernel.ThreadGroup, error) {
+	file := t.GetFile(fd)
+	if file == nil {
+		return nil, nil, linuxerr.EBADF
+	}
+	pfd, ok := file.Impl().(*pidfd.PIDFileDescription)
+	if !ok {
+		file.DecRef(t)
+		return nil, nil, linuxerr.EBADF
+	}
+	return file, pfd.ThreadGroup(), nil
+}
+
+// PidfdOpen implements linux syscall pidfd_open(2).
+func PidfdOpen(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	pid := kernel.ThreadID(args[0].Int())
+	flags := args[1].Uint()
+
+	if flags&^linux.PIDFD_NONBLOCK != 0 || pid <= 0 {
+		return 0, nil, linuxerr.EINVAL
+	}
+	target := t.PIDNamespace().TaskWithID(pid)
+	if target == nil {
+		return 0, nil, linuxerr.ESRCH
+	}
+	// "EINVAL: pid refers to a thread that is not a thread-group leader." -
+	// pidfd_open(2)
+	tg := target.ThreadGroup()
+	if tg.Leader() != target {
+		return 0, nil, linuxerr.EINVAL
+	}
+
+	file, err := pidfd.New(t, t.Kernel().VFS(), tg, linux.O_RDWR|flags)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.DecRef(t)
+	fd, err := t.NewFDFrom(0, file, kernel.FDFlags{
+		CloseOnExec: true,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return uintptr(fd), nil, nil
+}
+
+// PidfdGetfd implements linux syscall pidfd_getfd(2).
+func PidfdGetfd(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	pidFD := args[0].Int()
+	targetFD := args[1].Int()
+	flags := args[2].Uint()
+
+	if flags != 0 {
+		return 0, nil, linuxerr.EINVAL
+	}
+	pidFile, tg, err := getPIDFD(t, pidFD)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer pidFile.DecRef(t)
+
+	target := tg.Leader()
+	if target.ExitState() == kernel.TaskExitDead {
+		return 0, nil, linuxerr.ESRCH
+	}
+	// "Permission to duplicate another process's file descriptor is governed
+	// by a ptrace access mode PTRACE_MODE_ATTACH_REALCREDS check." -
+	// pidfd_getfd(2)
+	if !t.CanTrace(target, true /* attach */) {
+		return 0, nil, linuxerr.EPERM
+	}
+
+	var file *vfs.FileDescription
+	target.WithMuLocked(func(target *kernel.Task) {
+		if fdt := target.FDTable(); fdt != nil {
+			file, _ = fdt.Get(targetFD)
+		}
+	})
+	if file == nil {
+		return 0, nil, linuxerr.EBADF
+	}
+	defer file.DecRef(t)
+	fd, err := t.NewFDFrom(0, file, kernel.FDFlags{
+		CloseOnExec: true,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return uintptr(fd), nil, nil
+}
+
+// PidfdSendSignal implements linux syscall pidfd_send_signal(2).
+func PidfdSendSignal(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	pidFD := args[0].Int()
+	sig := linux.Signal(args[1].Int())
+	infoAddr := args[2].Pointer()
+	flags := args[3].Uint()
+
+	if flags != 0 {
+		return 0, nil, linuxerr.EINVAL
+	}
+	file, tg, err := getPIDFD(t, pidFD)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.DecRef(t)
+
+	target := tg.Leader()
+	var info linux.SignalInfo
+	if infoAddr != 0 {
+		if _, err := info.CopyIn(t, infoAddr); err != nil {
+			return 0, nil, err
+		}
+		// Unlike rt_sigqueueinfo(2), the signal number in info must match
+		// sig rather than being overridden.
+		if info.Signo != int32(sig) {
+			return 0, nil, linuxerr.EINVAL
+		}
+		// If the sender is not the receiver, it can't use si_codes used by
+		// the kernel or SI_TKILL. See RtSigqueueinfo.
+		if (info.Code >= 0 || info.Code == linux.SI_TKILL) && tg != t.ThreadGroup() {
+			return 0, nil, linuxerr.EPERM
+		}
+	} else {
+		info = linux.SignalInfo{
+			Signo: int32(sig),
+			Code:  linux.SI_USER,
+		}
+		info.SetPID(int32(target.PIDNamespace().IDOfTask(t)))
+		info.SetUID(int32(t.Credentials().RealKUID.In(target.UserNamespace()).OrOverflow()))
+	}
+
+	if !mayKill(t, target, sig) {
+		return 0, nil, linuxerr.EPERM
+	}
+	return 0, nil, tg.SendSignal(&info)
+}
@@ -19,6 +19,7 @@ import (
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/hostarch"
 	"gvisor.dev/gvisor/pkg/marshal/primitive"
 	"gvisor.dev/gvisor/pkg/sentry/arch"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
@@ -165,15 +166,17 @@ func Prctl(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 		if args[1].Int() != 1 || args[2].Int() != 0 || args[3].Int() != 0 || args[4].Int() != 0 {
 			return 0, nil, linuxerr.EINVAL
 		}
-		// PR_SET_NO_NEW_PRIVS is assumed to always be set.
-		// See kernel.Task.updateCredsForExec.
+		t.SetNoNewPrivs()
 		return 0, nil, nil
 
 	case linux.PR_GET_NO_NEW_PRIVS:
 		if args[1].Int() != 0 || args[2].Int() != 0 || args[3].Int() != 0 || args[4].Int() != 0 {
 			return 0, nil, linuxerr.EINVAL
 		}
-		return 1, nil, nil
+		if t.NoNewPrivs() {
+			return 1, nil, nil
+		}
+		return 0, nil, nil
 
 	case linux.PR_SET_PTRACER:
 		pid := args[1].Int()
@@ -199,11 +202,15 @@ func Prctl(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 			return 0, nil, linuxerr.EINVAL
 		}
 
-		return 0, nil, seccomp(t, linux.SECCOMP_SET_MODE_FILTER, 0, args[2].Pointer())
+		_, err := seccomp(t, linux.SECCOMP_SET_MODE_FILTER, 0, args[2].Pointer())
+		return 0, nil, err
 
 	case linux.PR_GET_SECCOMP:
 		return uintptr(t.SeccompMode()), nil, nil
 
+	case linux.PR_SET_SYSCALL_USER_DISPATCH:
+		return 0, nil, t.SetSyscallUserDispatch(args[1].Int(), args[2].Uint64(), args[3].Uint64(), args[4].Pointer())
+
 	case linux.PR_CAPBSET_READ:
 		cp := linux.Capability(args[1].Uint64())
 		if !cp.Ok() {
@@ -251,11 +258,62 @@ func Prctl(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 			var err error
 			name, err = t.CopyInString(nameAddr, linux.ANON_VMA_NAME_MAX_LEN)
 			if err != nil {
+				// Linux's strndup_user() fails with EINVAL for names that
+				// don't fit in ANON_VMA_NAME_MAX_LEN, including the NUL.
+				if linuxerr.Equals(linuxerr.ENAMETOOLONG, err) {
+					return 0, nil, linuxerr.EINVAL
+				}
 				return 0, nil, err
 			}
 		}
 		return 0, nil, t.MemoryManager().SetVMAAnonName(args[2].Pointer(), args[3].Uint64(), name, nameIsNil)
 
+	case linux.PR_SET_THP_DISABLE:
+		if args[2].Uint64() != 0 || args[3].Uint64() != 0 || args[4].Uint64() != 0 {
+			return 0, nil, linuxerr.EINVAL
+		}
+		t.MemoryManager().SetTHPDisabled(args[1].Uint64() != 0)
+
+	case linux.PR_GET_THP_DISABLE:
+		if args[1].Uint64() != 0 || args[2].Uint64() != 0 || args[3].Uint64() != 0 || args[4].Uint64() != 0 {
+			return 0, nil, linuxerr.EINVAL
+		}
+		if t.MemoryManager().THPDisabled() {
+			return 1, nil, nil
+		}
+		return 0, nil, nil
+
+	case linux.PR_SET_IO_FLUSHER:
+		if !t.HasCapability(linux.CAP_SYS_RESOURCE) {
+			return 0, nil, linuxerr.EPERM
+		}
+		if args[2].Uint64() != 0 || args[3].Uint64() != 0 || args[4].Uint64() != 0 {
+			return 0, nil, linuxerr.EINVAL
+		}
+		switch args[1].Uint64() {
+		case 0:
+			t.SetIOFlusher(false)
+		case 1:
+			t.SetIOFlusher(true)
+		default:
+			return 0, nil, linuxerr.EINVAL
+		}
+
+	case linux.PR_GET_IO_FLUSHER:
+		if !t.HasCapability(linux.CAP_SYS_RESOURCE) {
+			return 0, nil, linuxerr.EPERM
+		}
+		if args[1].Uint64() != 0 || args[2].Uint64() != 0 || args[3].Uint64() != 0 || args[4].Uint64() != 0 {
+			return 0, nil, linuxerr.EINVAL
+		}
+		if t.IOFlusher() {
+			return 1, nil, nil
+		}
+		return 0, nil, nil
+
+	case linux.PR_SCHED_CORE:
+		return 0, nil, schedCore(t, args[1].Uint64(), args[2].Int(), args[3].Uint64(), args[4].Pointer())
+
 	case linux.PR_GET_TIMING,
 		linux.PR_SET_TIMING,
 		linux.PR_GET_TSC,
@@ -267,8 +325,6 @@ func Prctl(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 		linux.PR_MCE_KILL,
 		linux.PR_MCE_KILL_GET,
 		linux.PR_GET_TID_ADDRESS,
-		linux.PR_GET_THP_DISABLE,
-		linux.PR_SET_THP_DISABLE,
 		linux.PR_MPX_ENABLE_MANAGEMENT,
 		linux.PR_MPX_DISABLE_MANAGEMENT:
 
@@ -280,3 +336,41 @@ func Prctl(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 
 	return 0, nil, nil
 }
+
+// schedCore implements prctl(PR_SCHED_CORE), following Linux's
+// kernel/sched/core_sched.c:sched_core_share_pid().
+func schedCore(t *kernel.Task, cmd uint64, pid int32, scope uint64, addr hostarch.Addr) error {
+	if scope > linux.PR_SCHED_CORE_SCOPE_PROCESS_GROUP || cmd >= linux.PR_SCHED_CORE_MAX || pid < 0 || (cmd != linux.PR_SCHED_CORE_GET && addr != 0) {
+		return linuxerr.EINVAL
+	}
+	target := t
+	if pid != 0 {
+		target = t.PIDNamespace().TaskWithID(kernel.ThreadID(pid))
+		if target == nil {
+			return linuxerr.ESRCH
+		}
+	}
+	if !t.CanTrace(target, false /* attach */) {
+		return linuxerr.EPERM
+	}
+
+	var cookie uint64
+	switch cmd {
+	case linux.PR_SCHED_CORE_GET:
+		if scope != linux.PR_SCHED_CORE_SCOPE_THREAD || addr%8 != 0 {
+			return linuxerr.EINVAL
+		}
+		_, err := primitive.CopyUint64Out(t, addr, target.CoreSchedCookie())
+		return err
+	case linux.PR_SCHED_CORE_CREATE:
+		cookie = t.Kernel().NewCoreSchedCookie()
+	case linux.PR_SCHED_CORE_SHARE_TO:
+		cookie = t.CoreSchedCookie()
+	case linux.PR_SCHED_CORE_SHARE_FROM:
+		if scope != linux.PR_SCHED_CORE_SCOPE_THREAD {
+			return linuxerr.EINVAL
+		}
+		return t.SetCoreSchedCookie(t, linux.PR_SCHED_CORE_SCOPE_THREAD, target.CoreSchedCookie())
+	}
+	return t.SetCoreSchedCookie(target, int32(scope), cookie)
+}
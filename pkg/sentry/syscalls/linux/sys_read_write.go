@@ -100,8 +100,12 @@ func read(t *kernel.Task, file *vfs.FileDescription, dst usermem.IOSequence, opt
 		return n, err
 	}
 
-	// Register for notifications.
+	// Register for notifications. The entry is exclusive since we retry the
+	// read after every wakeup, allowing files for which a single read
+	// consumes the event (e.g. eventfds) to avoid waking every blocked
+	// reader.
 	w, ch := waiter.NewChannelEntry(eventMaskRead)
+	w.SetExclusive()
 	if err := file.EventRegister(&w); err != nil {
 		return n, err
 	}
@@ -236,10 +240,18 @@ func Preadv2(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr
 	opts := vfs.ReadOptions{
 		Flags: uint32(flags),
 	}
+	// RWF_NOWAIT reads never block, regardless of O_NONBLOCK; implementations
+	// return EAGAIN if the read can't be satisfied without blocking.
+	nowait := flags&linux.RWF_NOWAIT != 0
 	var n int64
-	if offset == -1 {
+	switch {
+	case offset == -1 && nowait:
+		n, err = file.Read(t, dst, opts)
+	case offset == -1:
 		n, err = read(t, file, dst, opts)
-	} else {
+	case nowait:
+		n, err = file.PRead(t, dst, offset, opts)
+	default:
 		n, err = pread(t, file, dst, offset, opts)
 	}
 	t.IOUsage().AccountReadSyscall(n)
@@ -489,10 +501,16 @@ func Pwritev2(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintpt
 	opts := vfs.WriteOptions{
 		Flags: uint32(flags),
 	}
+	nowait := flags&linux.RWF_NOWAIT != 0
 	var n int64
-	if offset == -1 {
+	switch {
+	case offset == -1 && nowait:
+		n, err = file.Write(t, src, opts)
+	case offset == -1:
 		n, err = write(t, file, src, opts)
-	} else {
+	case nowait:
+		n, err = file.PWrite(t, src, offset, opts)
+	default:
 		n, err = pwrite(t, file, src, offset, opts)
 	}
 	t.IOUsage().AccountWriteSyscall(n)
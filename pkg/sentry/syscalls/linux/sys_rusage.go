@@ -46,6 +46,9 @@ func getrusage(t *kernel.Task, which int32) linux.Rusage {
 		UTime:  linux.NsecToTimeval(cs.UserTime.Nanoseconds()),
 		STime:  linux.NsecToTimeval(cs.SysTime.Nanoseconds()),
 		NVCSw:  int64(cs.VoluntarySwitches),
+		NIvCSw: int64(cs.InvoluntarySwitches),
+		MinFlt: int64(cs.MinorFaults),
+		MajFlt: int64(cs.MajorFaults),
 		MaxRSS: int64(t.MaxRSS(which) / 1024),
 	}
 }
@@ -58,12 +61,12 @@ func getrusage(t *kernel.Task, which int32) linux.Rusage {
 //
 //	y    struct timeval ru_utime; /* user CPU time used */
 //	y    struct timeval ru_stime; /* system CPU time used */
-//	p    long   ru_maxrss;        /* maximum resident set size */
+//	y    long   ru_maxrss;        /* maximum resident set size */
 //	*    long   ru_ixrss;         /* integral shared memory size */
 //	*    long   ru_idrss;         /* integral unshared data size */
 //	*    long   ru_isrss;         /* integral unshared stack size */
-//	p    long   ru_minflt;        /* page reclaims (soft page faults) */
-//	p    long   ru_majflt;        /* page faults (hard page faults) */
+//	y    long   ru_minflt;        /* page reclaims (soft page faults) */
+//	y    long   ru_majflt;        /* page faults (hard page faults) */
 //	*    long   ru_nswap;         /* swaps */
 //	p    long   ru_inblock;       /* block input operations */
 //	p    long   ru_oublock;       /* block output operations */
@@ -72,6 +75,12 @@ func getrusage(t *kernel.Task, which int32) linux.Rusage {
 //	*    long   ru_nsignals;      /* signals received */
 //	y    long   ru_nvcsw;         /* voluntary context switches */
 //	y    long   ru_nivcsw;        /* involuntary context switches */
+//
+// Page faults are only counted if they are handled by the sentry; faults that
+// the platform resolves on its own, e.g. on memory that has already been
+// mapped into the application's address space, are invisible to it.
+// Involuntary context switches count interruptions of application execution
+// by the sentry, not preemption by the host or the Go runtime.
 func Getrusage(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
 	which := args[0].Int()
 	addr := args[1].Pointer()
@@ -17,13 +17,31 @@ package linux
 import (
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
 	"gvisor.dev/gvisor/pkg/sentry/arch"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/limits"
 )
 
 const (
-	onlyScheduler = linux.SCHED_NORMAL
-	onlyPriority  = 0
+	// minNice and maxNice are the bounds of niceness values.
+	minNice = -20
+	maxNice = 19
+
+	// minDeadlinePeriod and maxDeadlinePeriod are the bounds of the period of
+	// SCHED_DEADLINE tasks in nanoseconds, as per Linux's default
+	// sched_deadline_period_min_us and sched_deadline_period_max_us.
+	minDeadlinePeriod = 100 * 1000
+	maxDeadlinePeriod = (1 << 22) * 1000
+
+	// minDeadlineRuntime is the minimum runtime of SCHED_DEADLINE tasks in
+	// nanoseconds, 1 << DL_SCALE in Linux.
+	minDeadlineRuntime = 1 << 10
+
+	// deadlineFlags are the sched_setattr(2) flags that are parameters of
+	// SCHED_DEADLINE tasks.
+	deadlineFlags = linux.SCHED_FLAG_RECLAIM | linux.SCHED_FLAG_DL_OVERRUN
 )
 
 // SchedParam replicates struct sched_param in sched.h.
@@ -33,6 +51,169 @@ type SchedParam struct {
 	schedPriority int32
 }
 
+// schedTarget returns the task identified by pid in a sched_* syscall.
+func schedTarget(t *kernel.Task, pid int32) (*kernel.Task, error) {
+	if pid < 0 {
+		return nil, linuxerr.EINVAL
+	}
+	if pid == 0 {
+		return t, nil
+	}
+	target := t.PIDNamespace().TaskWithID(kernel.ThreadID(pid))
+	if target == nil {
+		return nil, linuxerr.ESRCH
+	}
+	return target, nil
+}
+
+// isRTPolicy returns true if policy is a real-time scheduling policy.
+func isRTPolicy(policy uint32) bool {
+	return policy == linux.SCHED_FIFO || policy == linux.SCHED_RR
+}
+
+// isFairPolicy returns true if the niceness of tasks with policy is relevant.
+func isFairPolicy(policy uint32) bool {
+	return policy == linux.SCHED_NORMAL || policy == linux.SCHED_BATCH
+}
+
+// checkDeadlineParams returns true if attr holds valid SCHED_DEADLINE
+// parameters, following Linux's __checkparam_dl().
+func checkDeadlineParams(attr *linux.SchedAttr) bool {
+	if attr.Deadline == 0 || attr.Runtime < minDeadlineRuntime {
+		return false
+	}
+	// Linux uses the MSB for wrap-around.
+	if attr.Deadline&(1<<63) != 0 || attr.Period&(1<<63) != 0 {
+		return false
+	}
+	period := attr.Period
+	if period == 0 {
+		period = attr.Deadline
+	}
+	// runtime <= deadline <= period.
+	if period < attr.Deadline || attr.Deadline < attr.Runtime {
+		return false
+	}
+	return period >= minDeadlinePeriod && period <= maxDeadlinePeriod
+}
+
+// canNice returns true if target may be given niceness nice by an
+// unprivileged task, as allowed by RLIMIT_NICE.
+func canNice(target *kernel.Task, nice int32) bool {
+	return target.ThreadGroup().Limits().Get(limits.Nice).Cur >= uint64(20-nice)
+}
+
+// setSchedAttr sets the scheduling policy and parameters of target to attr,
+// following Linux's __sched_setscheduler(). If keepPolicy is true, attr.Policy
+// and its SCHED_FLAG_RESET_ON_FORK flag are ignored and left unchanged.
+func setSchedAttr(t, target *kernel.Task, attr *linux.SchedAttr, keepPolicy bool) error {
+	cur := target.SchedAttr()
+	policy := attr.Policy
+	resetOnFork := attr.Flags&linux.SCHED_FLAG_RESET_ON_FORK != 0
+	if keepPolicy {
+		policy, resetOnFork = cur.Policy, cur.ResetOnFork
+	}
+	switch policy {
+	case linux.SCHED_NORMAL, linux.SCHED_BATCH, linux.SCHED_IDLE, linux.SCHED_FIFO, linux.SCHED_RR, linux.SCHED_DEADLINE:
+	default:
+		return linuxerr.EINVAL
+	}
+	if attr.Flags&^linux.SCHED_FLAG_ALL != 0 {
+		return linuxerr.EINVAL
+	}
+	// Valid priorities are 1..MAX_RT_PRIO-1 for real-time policies, and 0
+	// for others.
+	if attr.Priority > linux.MAX_RT_PRIO-1 || isRTPolicy(policy) != (attr.Priority != 0) {
+		return linuxerr.EINVAL
+	}
+	if policy == linux.SCHED_DEADLINE && !checkDeadlineParams(attr) {
+		return linuxerr.EINVAL
+	}
+	// Utilization clamping is not supported, as if Linux was built without
+	// CONFIG_UCLAMP_TASK.
+	if attr.Flags&linux.SCHED_FLAG_UTIL_CLAMP != 0 {
+		return linuxerr.EOPNOTSUPP
+	}
+
+	if !t.HasCapabilityIn(linux.CAP_SYS_NICE, target.UserNamespace()) {
+		if isFairPolicy(policy) && attr.Nice < int32(target.Niceness()) && !canNice(target, attr.Nice) {
+			return linuxerr.EPERM
+		}
+		if isRTPolicy(policy) {
+			rtprio := t.ThreadGroup().Limits().Get(limits.RealTimePriority).Cur
+			if policy != cur.Policy && rtprio == 0 {
+				return linuxerr.EPERM
+			}
+			if attr.Priority > cur.Priority && uint64(attr.Priority) > rtprio {
+				return linuxerr.EPERM
+			}
+		}
+		if policy == linux.SCHED_DEADLINE {
+			return linuxerr.EPERM
+		}
+		// SCHED_IDLE tasks are treated as having niceness 19.
+		if cur.Policy == linux.SCHED_IDLE && policy != linux.SCHED_IDLE && !canNice(target, int32(target.Niceness())) {
+			return linuxerr.EPERM
+		}
+		creds, targetCreds := t.Credentials(), target.Credentials()
+		if creds.EffectiveKUID != targetCreds.EffectiveKUID && creds.EffectiveKUID != targetCreds.RealKUID {
+			return linuxerr.EPERM
+		}
+		// Unprivileged tasks can't clear SCHED_RESET_ON_FORK.
+		if cur.ResetOnFork && !resetOnFork {
+			return linuxerr.EPERM
+		}
+	}
+
+	newAttr := kernel.SchedAttr{
+		Policy:      policy,
+		ResetOnFork: resetOnFork,
+	}
+	switch {
+	case isRTPolicy(policy):
+		newAttr.Priority = attr.Priority
+	case policy == linux.SCHED_DEADLINE:
+		newAttr.Runtime = attr.Runtime
+		newAttr.Deadline = attr.Deadline
+		newAttr.Period = attr.Period
+		if newAttr.Period == 0 {
+			newAttr.Period = attr.Deadline
+		}
+		newAttr.DeadlineFlags = attr.Flags & deadlineFlags
+	}
+	if err := target.SetSchedAttr(newAttr); err != nil {
+		return err
+	}
+	if isFairPolicy(policy) {
+		target.SetNiceness(int(attr.Nice))
+	}
+	return nil
+}
+
+// getSchedAttr returns the scheduling policy and parameters of target,
+// following Linux's get_params().
+func getSchedAttr(target *kernel.Task) linux.SchedAttr {
+	cur := target.SchedAttr()
+	attr := linux.SchedAttr{
+		Policy: cur.Policy,
+	}
+	if cur.ResetOnFork {
+		attr.Flags |= linux.SCHED_FLAG_RESET_ON_FORK
+	}
+	switch {
+	case cur.Policy == linux.SCHED_DEADLINE:
+		attr.Runtime = cur.Runtime
+		attr.Deadline = cur.Deadline
+		attr.Period = cur.Period
+		attr.Flags |= cur.DeadlineFlags
+	case isRTPolicy(cur.Policy):
+		attr.Priority = cur.Priority
+	default:
+		attr.Nice = int32(target.Niceness())
+	}
+	return attr
+}
+
 // SchedGetparam implements linux syscall sched_getparam(2).
 func SchedGetparam(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
 	pid := args[0].Int()
@@ -40,13 +221,11 @@ func SchedGetparam(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (u
 	if param == 0 {
 		return 0, nil, linuxerr.EINVAL
 	}
-	if pid < 0 {
-		return 0, nil, linuxerr.EINVAL
-	}
-	if pid != 0 && t.PIDNamespace().TaskWithID(kernel.ThreadID(pid)) == nil {
-		return 0, nil, linuxerr.ESRCH
+	target, err := schedTarget(t, pid)
+	if err != nil {
+		return 0, nil, err
 	}
-	r := SchedParam{schedPriority: onlyPriority}
+	r := SchedParam{schedPriority: int32(target.SchedAttr().Priority)}
 	if _, err := r.CopyOut(t, param); err != nil {
 		return 0, nil, err
 	}
@@ -57,13 +236,16 @@ func SchedGetparam(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (u
 // SchedGetscheduler implements linux syscall sched_getscheduler(2).
 func SchedGetscheduler(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
 	pid := args[0].Int()
-	if pid < 0 {
-		return 0, nil, linuxerr.EINVAL
+	target, err := schedTarget(t, pid)
+	if err != nil {
+		return 0, nil, err
 	}
-	if pid != 0 && t.PIDNamespace().TaskWithID(kernel.ThreadID(pid)) == nil {
-		return 0, nil, linuxerr.ESRCH
+	attr := target.SchedAttr()
+	policy := uintptr(attr.Policy)
+	if attr.ResetOnFork {
+		policy |= linux.SCHED_RESET_ON_FORK
 	}
-	return onlyScheduler, nil, nil
+	return policy, nil, nil
 }
 
 // SchedSetscheduler implements linux syscall sched_setscheduler(2).
@@ -71,31 +253,170 @@ func SchedSetscheduler(t *kernel.Task, sysno uintptr, args arch.SyscallArguments
 	pid := args[0].Int()
 	policy := args[1].Int()
 	param := args[2].Pointer()
-	if pid < 0 {
+	if param == 0 || pid < 0 || policy < 0 {
 		return 0, nil, linuxerr.EINVAL
 	}
-	if policy != onlyScheduler {
+	var r SchedParam
+	if _, err := r.CopyIn(t, param); err != nil {
+		return 0, nil, err
+	}
+	target, err := schedTarget(t, pid)
+	if err != nil {
+		return 0, nil, err
+	}
+	if r.schedPriority < 0 {
 		return 0, nil, linuxerr.EINVAL
 	}
-	if pid != 0 && t.PIDNamespace().TaskWithID(kernel.ThreadID(pid)) == nil {
-		return 0, nil, linuxerr.ESRCH
+	// SCHED_DEADLINE can only be set by sched_setattr(2), since there is no
+	// room for its parameters in struct sched_param.
+	attr := linux.SchedAttr{
+		Policy:   uint32(policy) &^ linux.SCHED_RESET_ON_FORK,
+		Priority: uint32(r.schedPriority),
+		Nice:     int32(target.Niceness()),
+	}
+	if policy&linux.SCHED_RESET_ON_FORK != 0 {
+		attr.Flags = linux.SCHED_FLAG_RESET_ON_FORK
+	}
+	return 0, nil, setSchedAttr(t, target, &attr, false /* keepPolicy */)
+}
+
+// copyInSchedAttr copies in the struct sched_attr at addr, and returns it with
+// the size given by the application. struct sched_attr is extensible: its size
+// is given by its first field, and fields unknown to the sentry must be zero.
+// See Linux's sched_copy_attr().
+func copyInSchedAttr(t *kernel.Task, addr hostarch.Addr) (linux.SchedAttr, uint32, error) {
+	var attr linux.SchedAttr
+	var size primitive.Uint32
+	if _, err := size.CopyIn(t, addr); err != nil {
+		return attr, 0, err
+	}
+	if size == 0 {
+		size = linux.SCHED_ATTR_SIZE_VER0
+	}
+	tooBig := size < linux.SCHED_ATTR_SIZE_VER0 || size > hostarch.PageSize
+	if !tooBig && uint32(size) > linux.SizeOfSchedAttr {
+		rest := make([]byte, uint32(size)-linux.SizeOfSchedAttr)
+		if _, err := t.CopyInBytes(addr+hostarch.Addr(linux.SizeOfSchedAttr), rest); err != nil {
+			return attr, 0, err
+		}
+		for _, b := range rest {
+			tooBig = tooBig || b != 0
+		}
+	}
+	if tooBig {
+		// Report the supported size.
+		size = primitive.Uint32(linux.SizeOfSchedAttr)
+		if _, err := size.CopyOut(t, addr); err != nil {
+			return attr, 0, err
+		}
+		return attr, 0, linuxerr.E2BIG
+	}
+	if _, err := attr.CopyInN(t, addr, int(min(uint32(size), linux.SizeOfSchedAttr))); err != nil {
+		return attr, 0, err
+	}
+	return attr, uint32(size), nil
+}
+
+// SchedSetparam implements linux syscall sched_setparam(2).
+func SchedSetparam(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	pid := args[0].Int()
+	param := args[1].Pointer()
+	if param == 0 || pid < 0 {
+		return 0, nil, linuxerr.EINVAL
 	}
 	var r SchedParam
 	if _, err := r.CopyIn(t, param); err != nil {
+		return 0, nil, err
+	}
+	if r.schedPriority < 0 {
 		return 0, nil, linuxerr.EINVAL
 	}
-	if r.schedPriority != onlyPriority {
+	target, err := schedTarget(t, pid)
+	if err != nil {
+		return 0, nil, err
+	}
+	attr := getSchedAttr(target)
+	attr.Priority = uint32(r.schedPriority)
+	return 0, nil, setSchedAttr(t, target, &attr, true /* keepPolicy */)
+}
+
+// SchedSetattr implements linux syscall sched_setattr(2).
+func SchedSetattr(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	pid := args[0].Int()
+	addr := args[1].Pointer()
+	flags := args[2].Uint()
+	if addr == 0 || pid < 0 || flags != 0 {
+		return 0, nil, linuxerr.EINVAL
+	}
+
+	attr, size, err := copyInSchedAttr(t, addr)
+	if err != nil {
+		return 0, nil, err
+	}
+	if attr.Flags&linux.SCHED_FLAG_UTIL_CLAMP != 0 && size < linux.SCHED_ATTR_SIZE_VER1 {
+		return 0, nil, linuxerr.EINVAL
+	}
+	attr.Nice = min(max(attr.Nice, minNice), maxNice)
+
+	target, err := schedTarget(t, pid)
+	if err != nil {
+		return 0, nil, err
+	}
+	if attr.Flags&linux.SCHED_FLAG_KEEP_PARAMS != 0 {
+		cur := getSchedAttr(target)
+		attr.Priority = cur.Priority
+		attr.Runtime = cur.Runtime
+		attr.Deadline = cur.Deadline
+		attr.Period = cur.Period
+		attr.Nice = cur.Nice
+		attr.Flags = attr.Flags&^deadlineFlags | cur.Flags&deadlineFlags
+	}
+	return 0, nil, setSchedAttr(t, target, &attr, attr.Flags&linux.SCHED_FLAG_KEEP_POLICY != 0)
+}
+
+// SchedGetattr implements linux syscall sched_getattr(2).
+func SchedGetattr(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	pid := args[0].Int()
+	addr := args[1].Pointer()
+	size := args[2].Uint()
+	flags := args[3].Uint()
+	if addr == 0 || pid < 0 || size > hostarch.PageSize || size < linux.SCHED_ATTR_SIZE_VER0 || flags != 0 {
 		return 0, nil, linuxerr.EINVAL
 	}
+	target, err := schedTarget(t, pid)
+	if err != nil {
+		return 0, nil, err
+	}
+	attr := getSchedAttr(target)
+	// Only the fields known to both the application and the sentry are
+	// copied out, and attr.Size reports how many.
+	attr.Size = min(size, linux.SizeOfSchedAttr)
+	if _, err := attr.CopyOutN(t, addr, int(attr.Size)); err != nil {
+		return 0, nil, err
+	}
 	return 0, nil, nil
 }
 
 // SchedGetPriorityMax implements linux syscall sched_get_priority_max(2).
 func SchedGetPriorityMax(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
-	return onlyPriority, nil, nil
+	switch args[0].Int() {
+	case linux.SCHED_FIFO, linux.SCHED_RR:
+		return linux.MAX_RT_PRIO - 1, nil, nil
+	case linux.SCHED_NORMAL, linux.SCHED_BATCH, linux.SCHED_IDLE, linux.SCHED_DEADLINE:
+		return 0, nil, nil
+	default:
+		return 0, nil, linuxerr.EINVAL
+	}
 }
 
 // SchedGetPriorityMin implements linux syscall sched_get_priority_min(2).
 func SchedGetPriorityMin(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
-	return onlyPriority, nil, nil
+	switch args[0].Int() {
+	case linux.SCHED_FIFO, linux.SCHED_RR:
+		return 1, nil, nil
+	case linux.SCHED_NORMAL, linux.SCHED_BATCH, linux.SCHED_IDLE, linux.SCHED_DEADLINE:
+		return 0, nil, nil
+	default:
+		return 0, nil, linuxerr.EINVAL
+	}
 }
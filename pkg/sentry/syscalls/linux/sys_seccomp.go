@@ -19,7 +19,9 @@ import (
 	"gvisor.dev/gvisor/pkg/bpf"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
 	"gvisor.dev/gvisor/pkg/sentry/arch"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/seccompnotify"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
 )
 
@@ -40,34 +42,92 @@ type userSockFprog struct {
 }
 
 // seccomp applies a seccomp policy to the current task.
-func seccomp(t *kernel.Task, mode, flags uint64, addr hostarch.Addr) error {
-	// We only support SECCOMP_SET_MODE_FILTER at the moment.
-	if mode != linux.SECCOMP_SET_MODE_FILTER {
+func seccomp(t *kernel.Task, mode, flags uint64, addr hostarch.Addr) (uintptr, error) {
+	switch mode {
+	case linux.SECCOMP_SET_MODE_FILTER:
+		return seccompSetModeFilter(t, flags, addr)
+
+	case linux.SECCOMP_GET_ACTION_AVAIL:
+		if flags != 0 {
+			return 0, linuxerr.EINVAL
+		}
+		var action primitive.Uint32
+		if _, err := action.CopyIn(t, addr); err != nil {
+			return 0, err
+		}
+		switch linux.BPFAction(action) {
+		case linux.SECCOMP_RET_KILL_PROCESS, linux.SECCOMP_RET_KILL_THREAD,
+			linux.SECCOMP_RET_TRAP, linux.SECCOMP_RET_ERRNO,
+			linux.SECCOMP_RET_USER_NOTIF, linux.SECCOMP_RET_TRACE,
+			linux.SECCOMP_RET_LOG, linux.SECCOMP_RET_ALLOW:
+			return 0, nil
+		default:
+			return 0, linuxerr.EOPNOTSUPP
+		}
+
+	case linux.SECCOMP_GET_NOTIF_SIZES:
+		if flags != 0 {
+			return 0, linuxerr.EINVAL
+		}
+		var (
+			notif linux.SeccompNotif
+			resp  linux.SeccompNotifResp
+			data  linux.SeccompData
+		)
+		sizes := linux.SeccompNotifSizes{
+			Notif:      uint16(notif.SizeBytes()),
+			Notif_resp: uint16(resp.SizeBytes()),
+			Data:       uint16(data.SizeBytes()),
+		}
+		_, err := sizes.CopyOut(t, addr)
+		return 0, err
+
+	default:
 		// Unsupported mode.
-		return linuxerr.EINVAL
+		return 0, linuxerr.EINVAL
 	}
+}
 
+// seccompSetModeFilter implements SECCOMP_SET_MODE_FILTER. If flags includes
+// SECCOMP_FILTER_FLAG_NEW_LISTENER, it returns the file descriptor of the
+// filter's user notification listener.
+func seccompSetModeFilter(t *kernel.Task, flags uint64, addr hostarch.Addr) (uintptr, error) {
 	tsync := flags&linux.SECCOMP_FILTER_FLAG_TSYNC != 0
+	newListener := flags&linux.SECCOMP_FILTER_FLAG_NEW_LISTENER != 0
 
-	// The only flag we support now is SECCOMP_FILTER_FLAG_TSYNC.
-	if flags&^linux.SECCOMP_FILTER_FLAG_TSYNC != 0 {
+	// The only flags we support now are SECCOMP_FILTER_FLAG_TSYNC and
+	// SECCOMP_FILTER_FLAG_NEW_LISTENER.
+	if flags&^(linux.SECCOMP_FILTER_FLAG_TSYNC|linux.SECCOMP_FILTER_FLAG_NEW_LISTENER) != 0 {
 		// Unsupported flag.
-		return linuxerr.EINVAL
+		return 0, linuxerr.EINVAL
+	}
+	// Without SECCOMP_FILTER_FLAG_TSYNC_ESRCH, the return value of a failed
+	// TSYNC, a thread ID, would be ambiguous with the listener's file
+	// descriptor.
+	if tsync && newListener {
+		return 0, linuxerr.EINVAL
 	}
 
 	var fprog userSockFprog
 	if _, err := fprog.CopyIn(t, addr); err != nil {
-		return err
+		return 0, err
 	}
 	if fprog.Len == 0 || fprog.Len > bpf.MaxInstructions {
 		// If the filter is already over the maximum number of instructions,
 		// do not go further and attempt to optimize the bytecode to make it
 		// smaller.
-		return linuxerr.EINVAL
+		return 0, linuxerr.EINVAL
+	}
+	// "In order to use the SECCOMP_SET_MODE_FILTER operation, either the
+	// calling thread must have the CAP_SYS_ADMIN capability in its user
+	// namespace, or the thread must already have the no_new_privs bit set."
+	// - seccomp(2)
+	if !t.NoNewPrivs() && !t.HasCapability(linux.CAP_SYS_ADMIN) {
+		return 0, linuxerr.EACCES
 	}
 	filter := make([]linux.BPFInstruction, int(fprog.Len))
 	if _, err := linux.CopyBPFInstructionSliceIn(t, hostarch.Addr(fprog.Filter), filter); err != nil {
-		return err
+		return 0, err
 	}
 	bpfFilter := make([]bpf.Instruction, len(filter))
 	for i, ins := range filter {
@@ -76,13 +136,36 @@ func seccomp(t *kernel.Task, mode, flags uint64, addr hostarch.Addr) error {
 	compiledFilter, err := bpf.Compile(bpfFilter, true /* optimize */)
 	if err != nil {
 		t.Debugf("Invalid seccomp-bpf filter: %v", err)
-		return linuxerr.EINVAL
+		return 0, linuxerr.EINVAL
 	}
 
-	return t.AppendSyscallFilter(compiledFilter, tsync)
+	if !newListener {
+		return 0, t.AppendSyscallFilter(compiledFilter, nil /* notifier */, tsync)
+	}
+
+	// Install the listener before the filter, so that the filter is never
+	// installed without a listener that can reply to its notifications.
+	notifier := kernel.NewSeccompNotifier()
+	file, err := seccompnotify.New(t, t.Kernel().VFS(), notifier)
+	if err != nil {
+		return 0, err
+	}
+	defer file.DecRef(t)
+	fd, err := t.NewFDFrom(0, file, kernel.FDFlags{CloseOnExec: true})
+	if err != nil {
+		return 0, err
+	}
+	if err := t.AppendSyscallFilter(compiledFilter, notifier, false /* syncAll */); err != nil {
+		if file := t.FDTable().Remove(t, fd); file != nil {
+			file.DecRef(t)
+		}
+		return 0, err
+	}
+	return uintptr(fd), nil
 }
 
 // Seccomp implements linux syscall seccomp(2).
 func Seccomp(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
-	return 0, nil, seccomp(t, args[0].Uint64(), args[1].Uint64(), args[2].Pointer())
+	ret, err := seccomp(t, args[0].Uint64(), args[1].Uint64(), args[2].Pointer())
+	return ret, nil, err
 }
@@ -675,9 +675,11 @@ func RecvMMsg(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintpt
 	}
 
 	// Reject flags that we don't handle yet.
-	if flags & ^(baseRecvFlags|linux.MSG_CMSG_CLOEXEC|linux.MSG_ERRQUEUE) != 0 {
+	if flags & ^(baseRecvFlags|linux.MSG_CMSG_CLOEXEC|linux.MSG_ERRQUEUE|linux.MSG_WAITFORONE) != 0 {
 		return 0, nil, linuxerr.EINVAL
 	}
+	waitForOne := flags&linux.MSG_WAITFORONE != 0
+	flags &^= linux.MSG_WAITFORONE
 
 	// Get socket from the file descriptor.
 	file := t.GetFile(fd)
@@ -719,6 +721,17 @@ func RecvMMsg(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintpt
 		}
 	}
 
+	if bs, ok := batchSocket(s); ok && flags&linux.MSG_ERRQUEUE == 0 {
+		msgs, err := captureMMsgs(t, msgPtr, vlen, false /* send */)
+		if err != nil {
+			return 0, nil, err
+		}
+		if msgs != nil {
+			n, err := recvMMsgBatch(t, bs, msgPtr, msgs, flags, waitForOne, haveDeadline, deadline)
+			return n, nil, err
+		}
+	}
+
 	var count uint32
 	var err error
 	for i := uint64(0); i < uint64(vlen); i++ {
@@ -730,6 +743,10 @@ func RecvMMsg(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintpt
 		if n, err = recvSingleMsg(t, s, mp, flags, haveDeadline, deadline); err != nil {
 			break
 		}
+		if waitForOne {
+			// Don't block for the remaining messages.
+			flags |= linux.MSG_DONTWAIT
+		}
 
 		// Copy the received length to the caller.
 		lp, ok := mp.AddLength(messageHeader64Len)
@@ -813,7 +830,7 @@ func recvSingleMsg(t *kernel.Task, s socket.Socket, msgPtr hostarch.Addr, flags
 		if err != nil {
 			return 0, linuxerr.ConvertIntr(err.ToError(), linuxerr.ERESTARTSYS)
 		}
-		if !cms.Unix.Empty() {
+		if !cms.Unix.Empty() || control.CmsgsSpace(t, cms) != 0 {
 			mflags |= linux.MSG_CTRUNC
 			cms.Release(t)
 		}
@@ -838,7 +855,7 @@ func recvSingleMsg(t *kernel.Task, s socket.Socket, msgPtr hostarch.Addr, flags
 	defer cms.Release(t)
 
 	controlData := make([]byte, 0, msg.ControlLen)
-	controlData = control.PackControlMessages(t, cms, controlData)
+	controlData, mflags = control.PackControlMessages(t, cms, controlData, mflags)
 
 	if cr, ok := s.(transport.Credentialer); ok && cr.Passcred() {
 		creds, _ := cms.Unix.Credentials.(control.SCMCredentials)
@@ -1024,6 +1041,17 @@ func SendMMsg(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintpt
 		flags |= linux.MSG_DONTWAIT
 	}
 
+	if bs, ok := batchSocket(s); ok {
+		msgs, err := captureMMsgs(t, msgPtr, vlen, true /* send */)
+		if err != nil {
+			return 0, nil, err
+		}
+		if msgs != nil {
+			n, err := sendMMsgBatch(t, bs, file, msgPtr, msgs, flags)
+			return n, nil, err
+		}
+	}
+
 	var count uint32
 	var err error
 	for i := uint64(0); i < uint64(vlen); i++ {
@@ -1053,6 +1081,162 @@ func SendMMsg(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintpt
 	return uintptr(count), nil, nil
 }
 
+// batchSocket returns s as a socket.BatchSocket if s supports batched
+// sendmmsg(2) and recvmmsg(2).
+func batchSocket(s socket.Socket) (socket.BatchSocket, bool) {
+	bs, ok := s.(socket.BatchSocket)
+	if !ok {
+		return nil, false
+	}
+	if _, skType, _ := s.Type(); skType != linux.SOCK_DGRAM {
+		return nil, false
+	}
+	return bs, true
+}
+
+// captureMMsgs copies in the mmsghdr array of a sendmmsg(2) or recvmmsg(2)
+// call at msgPtr and returns the corresponding messages for
+// socket.BatchSocket. It returns nil messages if any message has control data,
+// in which case each message must be handled individually.
+//
+// Like the per-message loops, a message that can't be captured ends the batch
+// and is only reported as an error if it is the first one.
+func captureMMsgs(t *kernel.Task, msgPtr hostarch.Addr, vlen uint32, send bool) ([]socket.MMsg, error) {
+	msgs := make([]socket.MMsg, 0, vlen)
+	for i := uint64(0); i < uint64(vlen); i++ {
+		err := func() error {
+			mp, ok := msgPtr.AddLength(i * multipleMessageHeader64Len)
+			if !ok {
+				return linuxerr.EFAULT
+			}
+			var hdr multipleMessageHeader64
+			if _, err := hdr.CopyIn(t, mp); err != nil {
+				return err
+			}
+			msg := &hdr.msgHdr
+			if msg.ControlLen != 0 {
+				msgs = nil
+				return nil
+			}
+			if msg.IovLen > linux.UIO_MAXIOV {
+				return linuxerr.EMSGSIZE
+			}
+			data, err := t.IovecsIOSequence(hostarch.Addr(msg.Iov), int(msg.IovLen), usermem.IOOpts{
+				AddressSpaceActive: true,
+			})
+			if err != nil {
+				return err
+			}
+			m := socket.MMsg{Data: data}
+			if msg.NameLen != 0 {
+				if send {
+					if m.To, err = CaptureAddress(t, hostarch.Addr(msg.Name), msg.NameLen); err != nil {
+						return err
+					}
+				} else {
+					m.SenderRequested = true
+				}
+			}
+			msgs = append(msgs, m)
+			return nil
+		}()
+		if msgs == nil {
+			return nil, nil
+		}
+		if err != nil {
+			if len(msgs) == 0 {
+				return nil, err
+			}
+			break
+		}
+	}
+	return msgs, nil
+}
+
+// recvMMsgBatch implements recvmmsg(2) for messages captured by captureMMsgs.
+func recvMMsgBatch(t *kernel.Task, s socket.BatchSocket, msgPtr hostarch.Addr, msgs []socket.MMsg, flags int32, waitForOne, haveDeadline bool, deadline ktime.Time) (uintptr, error) {
+	count := 0
+	for count < len(msgs) {
+		n, e := s.RecvMMsg(t, msgs[count:], int(flags), haveDeadline, deadline)
+		if e != nil {
+			if count == 0 {
+				return 0, linuxerr.ConvertIntr(e.ToError(), linuxerr.ERESTARTSYS)
+			}
+			break
+		}
+		for i := count; i < count+n; i++ {
+			if err := copyOutMMsg(t, msgPtr, i, &msgs[i]); err != nil {
+				if i == 0 {
+					return 0, err
+				}
+				return uintptr(i), nil
+			}
+		}
+		count += n
+		if flags&linux.MSG_DONTWAIT != 0 || waitForOne {
+			break
+		}
+	}
+	return uintptr(count), nil
+}
+
+// copyOutMMsg copies out the length, flags and sender address of the received
+// message msg to the i-th mmsghdr at msgPtr.
+func copyOutMMsg(t *kernel.Task, msgPtr hostarch.Addr, i int, msg *socket.MMsg) error {
+	mp := msgPtr + hostarch.Addr(uint64(i)*multipleMessageHeader64Len)
+	if msg.SenderRequested {
+		var name uint64
+		if _, err := primitive.CopyUint64In(t, mp, &name); err != nil {
+			return err
+		}
+		if err := writeAddress(t, msg.From, msg.FromLen, hostarch.Addr(name), mp+nameLenOffset); err != nil {
+			return err
+		}
+	}
+	if _, err := primitive.CopyInt32Out(t, mp+flagsOffset, int32(msg.Flags)); err != nil {
+		return err
+	}
+	_, err := primitive.CopyUint32Out(t, mp+hostarch.Addr(messageHeader64Len), uint32(msg.N))
+	return err
+}
+
+// sendMMsgBatch implements sendmmsg(2) for messages captured by captureMMsgs.
+func sendMMsgBatch(t *kernel.Task, s socket.BatchSocket, file *vfs.FileDescription, msgPtr hostarch.Addr, msgs []socket.MMsg, flags int32) (uintptr, error) {
+	var haveDeadline bool
+	var deadline ktime.Time
+	if dl := s.SendTimeout(); dl > 0 {
+		deadline = t.Kernel().MonotonicClock().Now().Add(time.Duration(dl) * time.Nanosecond)
+		haveDeadline = true
+	} else if dl < 0 {
+		flags |= linux.MSG_DONTWAIT
+	}
+
+	count := 0
+	for count < len(msgs) {
+		n, e := s.SendMMsg(t, msgs[count:], int(flags), haveDeadline, deadline)
+		for i := count; i < count+n; i++ {
+			mp := msgPtr + hostarch.Addr(uint64(i)*multipleMessageHeader64Len)
+			if _, err := primitive.CopyUint32Out(t, mp+hostarch.Addr(messageHeader64Len), uint32(msgs[i].N)); err != nil {
+				if i == 0 {
+					return 0, err
+				}
+				return uintptr(i), nil
+			}
+		}
+		count += n
+		if e != nil {
+			if count == 0 {
+				return 0, HandleIOError(t, false, e.ToError(), linuxerr.ERESTARTSYS, "sendmmsg", file)
+			}
+			break
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return uintptr(count), nil
+}
+
 func sendSingleMsg(t *kernel.Task, s socket.Socket, file *vfs.FileDescription, msgPtr hostarch.Addr, flags int32) (uintptr, error) {
 	// Capture the message header.
 	var msg MessageHeader64
@@ -15,6 +15,8 @@
 package linux
 
 import (
+	"fmt"
+
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
 	"gvisor.dev/gvisor/pkg/fspath"
@@ -26,7 +28,6 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/loader"
 	"gvisor.dev/gvisor/pkg/sentry/seccheck"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
-	"gvisor.dev/gvisor/pkg/usermem"
 )
 
 var (
@@ -114,6 +115,9 @@ func execveat(t *kernel.Task, dirfd int32, pathnameAddr, argvAddr, envvAddr host
 		}
 	}()
 	closeOnExec := false
+	executableUnreadable := false
+	nameFromFile := false
+	execfn := pathname
 	if path := fspath.Parse(pathname); dirfd != linux.AT_FDCWD && !path.Absolute {
 		// We must open the executable ourselves since dirfd is used as the
 		// starting point while resolving path, but the task working directory
@@ -132,21 +136,30 @@ func execveat(t *kernel.Task, dirfd int32, pathnameAddr, argvAddr, envvAddr host
 		start.IncRef()
 		dirfile.DecRef(t)
 		closeOnExec = dirfileFlags.CloseOnExec
-		file, err := t.Kernel().VFS().OpenAt(t, t.Credentials(), &vfs.PathOperation{
+		file, unreadable, err := loader.OpenExecutable(t, t.Kernel().VFS(), t.Credentials(), &vfs.PathOperation{
 			Root:               root,
 			Start:              start,
 			Path:               path,
 			FollowFinalSymlink: flags&linux.AT_SYMLINK_NOFOLLOW == 0,
-		}, &vfs.OpenOptions{
-			Flags:    linux.O_RDONLY,
-			FileExec: true,
 		})
 		start.DecRef(t)
 		if err != nil {
 			return 0, nil, err
 		}
 		executable = file
+		executableUnreadable = unreadable
 		pathname = executable.MappedName(t)
+
+		// As in Linux (fs/exec.c:alloc_bprm()), AT_EXECFN and the script
+		// path passed to interpreters name the executable relative to
+		// /dev/fd, and the task name comes from the file itself if there
+		// is no path to take it from.
+		if path.HasComponents() {
+			execfn = fmt.Sprintf("/dev/fd/%d/%s", dirfd, execfn)
+		} else {
+			execfn = fmt.Sprintf("/dev/fd/%d", dirfd)
+			nameFromFile = true
+		}
 	}
 
 	// Load the new TaskImage.
@@ -158,12 +171,17 @@ func execveat(t *kernel.Task, dirfd int32, pathnameAddr, argvAddr, envvAddr host
 		WorkingDir:          wd,
 		RemainingTraversals: &remainingTraversals,
 		ResolveFinal:        flags&linux.AT_SYMLINK_NOFOLLOW == 0,
-		Filename:            pathname,
+		Filename:            execfn,
 		File:                executable,
+		NameFromFile:        nameFromFile,
+		FileUnreadable:      executableUnreadable,
 		CloseOnExec:         closeOnExec,
 		Argv:                argv,
 		Envv:                envv,
 		Features:            t.Kernel().FeatureSet(),
+		TextSegments:        t.Kernel().TextSegmentOpts,
+		THPDisabled:         t.MemoryManager().THPDisabled(),
+		VVAR:                t.TimeNamespace().VVAR(),
 	}
 	if seccheck.Global.Enabled(seccheck.PointExecve) {
 		// Retain the first executable file that is opened (which may open
@@ -179,7 +197,7 @@ func execveat(t *kernel.Task, dirfd int32, pathnameAddr, argvAddr, envvAddr host
 		}
 	}
 
-	image, se := t.Kernel().LoadTaskImage(t, loadArgs)
+	image, se := t.Kernel().LoadTaskImage(t, t.ContainerID(), loadArgs)
 	if se != nil {
 		return 0, nil, se.ToError()
 	}
@@ -248,6 +266,11 @@ func Clone3(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 			return 0, nil, err
 		}
 	}
+	// clone_args.cgroup only exists in CLONE_ARGS_SIZE_VER2. See
+	// kernel/fork.c:copy_clone_args_from_user().
+	if cloneArgs.Flags&linux.CLONE_INTO_CGROUP != 0 && int(size) < linux.CLONE_ARGS_SIZE_VER2 {
+		return 0, nil, linuxerr.EINVAL
+	}
 
 	ntid, ctrl, err := t.Clone(&cloneArgs)
 	if err != nil {
@@ -375,12 +398,31 @@ func Waitid(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 		Events:       kernel.EventTraceeStop,
 		ConsumeEvent: options&linux.WNOWAIT == 0,
 	}
+	pidfdNonblock := false
 	switch idtype {
 	case linux.P_ALL:
 	case linux.P_PID:
 		wopts.SpecificTID = kernel.ThreadID(id)
 	case linux.P_PGID:
 		wopts.SpecificPGID = kernel.ProcessGroupID(id)
+	case linux.P_PIDFD:
+		if id < 0 {
+			return 0, nil, linuxerr.EINVAL
+		}
+		file, tg, err := getPIDFD(t, id)
+		if err != nil {
+			return 0, nil, err
+		}
+		pidfdNonblock = file.StatusFlags()&linux.O_NONBLOCK != 0
+		file.DecRef(t)
+		// Waiting on a pidfd is equivalent to waiting on the process's PID in
+		// the caller's PID namespace. If it has none, the process cannot be
+		// a waitable child.
+		tid := t.PIDNamespace().IDOfThreadGroup(tg)
+		if tid == 0 {
+			return 0, nil, linuxerr.ECHILD
+		}
+		wopts.SpecificTID = tid
 	default:
 		return 0, nil, linuxerr.EINVAL
 	}
@@ -388,6 +430,11 @@ func Waitid(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 	if err := parseCommonWaitOptions(&wopts, options); err != nil {
 		return 0, nil, err
 	}
+	if pidfdNonblock {
+		// A non-blocking pidfd makes waitid behave as if WNOHANG were set,
+		// except that the absence of an event is reported as EAGAIN.
+		wopts.BlockInterruptErr = nil
+	}
 	if options&linux.WEXITED != 0 {
 		wopts.Events |= kernel.EventExit
 	}
@@ -399,6 +446,10 @@ func Waitid(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 	if err != nil {
 		if err == kernel.ErrNoWaitableEvent {
 			err = nil
+			if options&linux.WNOHANG == 0 {
+				// Only possible for non-blocking pidfds.
+				err = linuxerr.EAGAIN
+			}
 			// "If WNOHANG was specified in options and there were no children
 			// in a waitable state, then waitid() returns 0 immediately and the
 			// state of the siginfo_t structure pointed to by infop is
@@ -407,7 +458,9 @@ func Waitid(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 			// as well.
 			if infop != 0 {
 				var si linux.SignalInfo
-				_, err = si.CopyOut(t, infop)
+				if _, cerr := si.CopyOut(t, infop); cerr != nil {
+					err = cerr
+				}
 			}
 		}
 		return 0, nil, err
@@ -569,16 +622,14 @@ func Getcpu(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 	node := args[1].Pointer()
 	// third argument to this system call is nowadays unused.
 
+	c := t.CPU()
 	if cpu != 0 {
-		if _, err := primitive.CopyInt32Out(t, cpu, t.CPU()); err != nil {
+		if _, err := primitive.CopyInt32Out(t, cpu, c); err != nil {
 			return 0, nil, err
 		}
 	}
-	// We always return node 0.
 	if node != 0 {
-		if _, err := t.MemoryManager().ZeroOut(t, node, 4, usermem.IOOpts{
-			AddressSpaceActive: true,
-		}); err != nil {
+		if _, err := primitive.CopyInt32Out(t, node, t.Kernel().CPUNode(c)); err != nil {
 			return 0, nil, err
 		}
 	}
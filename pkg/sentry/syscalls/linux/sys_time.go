@@ -56,13 +56,20 @@ func isValidCPUClock(c int32) bool {
 	return true
 }
 
-// targetTask returns the kernel.Task for the given clock id.
+// targetTask returns the kernel.Task for the given clock id, or nil if no such
+// task exists or its clock may not be accessed by t.
 func targetTask(t *kernel.Task, c int32) *kernel.Task {
 	pid := pidOfClockID(c)
 	if pid == 0 {
 		return t
 	}
-	return t.PIDNamespace().TaskWithID(pid)
+	target := t.PIDNamespace().TaskWithID(pid)
+	// Per-thread CPU clocks may only be accessed from within the same thread
+	// group. Compare Linux's kernel/time/posix-cpu-timers.c:pid_for_clock().
+	if target != nil && isCPUClockPerThread(c) && target.ThreadGroup() != t.ThreadGroup() {
+		return nil
+	}
+	return target
 }
 
 // ClockGetres implements linux syscall clock_getres(2).
@@ -77,6 +84,14 @@ func ClockGetres(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uin
 	if _, err := getClock(t, clockID); err != nil {
 		return 0, nil, linuxerr.EINVAL
 	}
+	// Unlike clock_gettime(2), clock_getres(2) only accepts process CPU clocks
+	// that name a thread group leader; glibc's clock_getcpuclockid(3) relies
+	// on this to validate its pid argument.
+	if clockID < 0 && !isCPUClockPerThread(clockID) && pidOfClockID(clockID) != 0 {
+		if target := targetTask(t, clockID); target == nil || target.ThreadGroup().Leader() != target {
+			return 0, nil, linuxerr.EINVAL
+		}
+	}
 
 	if addr == 0 {
 		// Don't need to copy out.
@@ -124,15 +139,18 @@ func getClock(t *kernel.Task, clockID int32) (ktime.Clock, error) {
 	case linux.CLOCK_REALTIME, linux.CLOCK_REALTIME_COARSE:
 		return t.Kernel().RealtimeClock(), nil
 	case linux.CLOCK_MONOTONIC, linux.CLOCK_MONOTONIC_COARSE,
-		linux.CLOCK_MONOTONIC_RAW, linux.CLOCK_BOOTTIME:
+		linux.CLOCK_MONOTONIC_RAW:
 		// CLOCK_MONOTONIC approximates CLOCK_MONOTONIC_RAW.
-		// CLOCK_BOOTTIME is internally mapped to CLOCK_MONOTONIC, as:
+		return t.TimeNamespace().MonotonicClock(), nil
+	case linux.CLOCK_BOOTTIME:
+		// CLOCK_BOOTTIME is based on CLOCK_MONOTONIC, as:
 		//	- CLOCK_BOOTTIME should behave as CLOCK_MONOTONIC while also
 		//		including suspend time.
 		//	- gVisor has no concept of suspend/resume.
 		//	- CLOCK_MONOTONIC already includes save/restore time, which is
 		//		the closest to suspend time.
-		return t.Kernel().MonotonicClock(), nil
+		// The two clocks only differ by their time namespace offsets.
+		return t.TimeNamespace().BoottimeClock(), nil
 	case linux.CLOCK_PROCESS_CPUTIME_ID:
 		return t.ThreadGroup().CPUClock(), nil
 	case linux.CLOCK_THREAD_CPUTIME_ID:
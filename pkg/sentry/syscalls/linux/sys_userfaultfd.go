@@ -0,0 +1,55 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/sentry/arch"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/userfaultfd"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+)
+
+// Userfaultfd implements linux syscall userfaultfd(2).
+func Userfaultfd(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	flags := args[0].Int()
+	if flags&^(linux.UFFD_CLOEXEC|linux.UFFD_NONBLOCK|linux.UFFD_USER_MODE_ONLY) != 0 {
+		return 0, nil, linuxerr.EINVAL
+	}
+	// As with vm.unprivileged_userfaultfd=0, handling faults taken by the
+	// sentry on behalf of the application requires CAP_SYS_PTRACE.
+	userModeOnly := flags&linux.UFFD_USER_MODE_ONLY != 0
+	if !userModeOnly && !t.HasCapability(linux.CAP_SYS_PTRACE) {
+		return 0, nil, linuxerr.EPERM
+	}
+
+	fileFlags := uint32(linux.O_RDWR)
+	if flags&linux.UFFD_NONBLOCK != 0 {
+		fileFlags |= linux.O_NONBLOCK
+	}
+	file, err := userfaultfd.New(t, t.Kernel().VFS(), t.MemoryManager().NewUserfaultfd(userModeOnly), fileFlags)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.DecRef(t)
+
+	fd, err := t.NewFDFrom(0, file, kernel.FDFlags{
+		CloseOnExec: flags&linux.UFFD_CLOEXEC != 0,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return uintptr(fd), nil, nil
+}
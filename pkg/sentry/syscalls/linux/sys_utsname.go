@@ -39,6 +39,8 @@ func Uname(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr,
 		copy(u.Machine[:], "x86_64")
 	case arch.ARM64:
 		copy(u.Machine[:], "aarch64")
+	case arch.RISCV64:
+		copy(u.Machine[:], "riscv64")
 	default:
 		copy(u.Machine[:], "unknown")
 	}
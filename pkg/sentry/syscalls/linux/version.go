@@ -0,0 +1,149 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/syscalls"
+)
+
+// release is a Linux release number, ignoring the patch level.
+type release struct {
+	major int
+	minor int
+}
+
+// String implements fmt.Stringer.
+func (r release) String() string {
+	return fmt.Sprintf("%d.%d", r.major, r.minor)
+}
+
+// before returns true if r precedes other.
+func (r release) before(other release) bool {
+	return r.major < other.major || (r.major == other.major && r.minor < other.minor)
+}
+
+// parseRelease parses the major and minor numbers of a uname release string,
+// such as "5.15.0-91-generic".
+func parseRelease(s string) (release, error) {
+	var r release
+	if n, err := fmt.Sscanf(s, "%d.%d", &r.major, &r.minor); err != nil || n != 2 {
+		return release{}, fmt.Errorf("release %q doesn't start with MAJOR.MINOR", s)
+	}
+	if r.major < 0 || r.minor < 0 {
+		return release{}, fmt.Errorf("release %q has a negative version number", s)
+	}
+	return r, nil
+}
+
+// syscallReleases maps the syscalls added to Linux after LinuxRelease to the
+// release that added them.
+var syscallReleases = map[string]release{
+	"copy_file_range":         {4, 5},
+	"preadv2":                 {4, 6},
+	"pwritev2":                {4, 6},
+	"pkey_mprotect":           {4, 9},
+	"pkey_alloc":              {4, 9},
+	"pkey_free":               {4, 9},
+	"statx":                   {4, 11},
+	"io_pgetevents":           {4, 18},
+	"rseq":                    {4, 18},
+	"pidfd_send_signal":       {5, 1},
+	"io_uring_setup":          {5, 1},
+	"io_uring_enter":          {5, 1},
+	"io_uring_register":       {5, 1},
+	"open_tree":               {5, 2},
+	"move_mount":              {5, 2},
+	"fsopen":                  {5, 2},
+	"fsconfig":                {5, 2},
+	"fsmount":                 {5, 2},
+	"fspick":                  {5, 2},
+	"pidfd_open":              {5, 3},
+	"clone3":                  {5, 3},
+	"openat2":                 {5, 6},
+	"pidfd_getfd":             {5, 6},
+	"faccessat2":              {5, 8},
+	"close_range":             {5, 9},
+	"process_madvise":         {5, 10},
+	"epoll_pwait2":            {5, 11},
+	"mount_setattr":           {5, 12},
+	"landlock_create_ruleset": {5, 13},
+	"landlock_add_rule":       {5, 13},
+	"landlock_restrict_self":  {5, 13},
+	"quotactl_fd":             {5, 14},
+	"memfd_secret":            {5, 14},
+	"process_mrelease":        {5, 15},
+	"futex_waitv":             {5, 16},
+	"set_mempolicy_home_node": {5, 17},
+	"cachestat":               {6, 5},
+	"fchmodat2":               {6, 6},
+	"map_shadow_stack":        {6, 6},
+	"futex_wake":              {6, 7},
+	"futex_wait":              {6, 7},
+	"futex_requeue":           {6, 7},
+	"statmount":               {6, 8},
+	"listmount":               {6, 8},
+	"lsm_get_self_attr":       {6, 8},
+	"lsm_set_self_attr":       {6, 8},
+	"lsm_list_modules":        {6, 8},
+	"mseal":                   {6, 10},
+}
+
+// ConfigureVersion changes the kernel version advertised by the Linux syscall
+// tables, which is reported by uname(2) and /proc. An empty rel or ver leaves
+// LinuxRelease or LinuxVersion respectively unchanged.
+//
+// If hideNewerSyscalls is true, syscalls that were added to Linux after the
+// advertised release fail with ENOSYS, as they would on that release, so that
+// applications probing for them see a consistent kernel.
+//
+// Preconditions: The syscall tables are not in use by any Task.
+func ConfigureVersion(rel, ver string, hideNewerSyscalls bool) error {
+	if len(rel) > linux.UTSLen || len(ver) > linux.UTSLen {
+		return fmt.Errorf("kernel release and version must be at most %d bytes", linux.UTSLen)
+	}
+	if rel == "" {
+		rel = LinuxRelease
+	}
+	if ver == "" {
+		ver = LinuxVersion
+	}
+	r, err := parseRelease(rel)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []*kernel.SyscallTable{AMD64, ARM64, RISCV64} {
+		table.Version.Release = rel
+		table.Version.Version = ver
+		if !hideNewerSyscalls {
+			continue
+		}
+		for sysno, sc := range table.Table {
+			added, ok := syscallReleases[sc.Name]
+			if !ok || !r.before(added) {
+				continue
+			}
+			table.Replace(sysno, syscalls.Error(sc.Name, linuxerr.ENOSYS, fmt.Sprintf("Added in Linux %v.", added), nil))
+		}
+	}
+	log.Infof("Advertising kernel release %q, version %q (newer syscalls hidden: %t)", rel, ver, hideNewerSyscalls)
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"strings"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/sentry/arch"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+)
+
+func TestParseRelease(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    release
+		wantErr bool
+	}{
+		{in: LinuxRelease, want: release{4, 4}},
+		{in: "5.15.0-91-generic", want: release{5, 15}},
+		{in: "6.1", want: release{6, 1}},
+		{in: "6", wantErr: true},
+		{in: "gvisor", wantErr: true},
+		{in: "", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := parseRelease(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("parseRelease(%q) = %v, want error", tc.in, got)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Errorf("parseRelease(%q) = (%v, %v), want (%v, nil)", tc.in, got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfigureVersion(t *testing.T) {
+	if err := ConfigureVersion("gvisor", "", false); err == nil {
+		t.Errorf("ConfigureVersion succeeded with an invalid release")
+	}
+	if err := ConfigureVersion(strings.Repeat("5", 100), "", false); err == nil {
+		t.Errorf("ConfigureVersion succeeded with a too long release")
+	}
+
+	const (
+		rel = "5.10.0-test"
+		ver = "#2 SMP PREEMPT test"
+	)
+	if err := ConfigureVersion(rel, ver, true); err != nil {
+		t.Fatalf("ConfigureVersion: %v", err)
+	}
+	if got := archToTest.Version; got.Sysname != LinuxSysname || got.Release != rel || got.Version != ver {
+		t.Errorf("got version %+v, want release %q and version %q", got, rel, ver)
+	}
+
+	// epoll_pwait2 was added in Linux 5.11, close_range in 5.9.
+	for _, tc := range []struct {
+		name   string
+		hidden bool
+	}{
+		{name: "epoll_pwait2", hidden: true},
+		{name: "futex_waitv", hidden: true},
+		{name: "close_range", hidden: false},
+		{name: "read", hidden: false},
+	} {
+		sysno, err := archToTest.LookupNo(tc.name)
+		if err != nil {
+			t.Fatalf("LookupNo(%q): %v", tc.name, err)
+		}
+		hidden := archToTest.Table[sysno].SupportLevel == kernel.SupportUnimplemented
+		if hidden != tc.hidden {
+			t.Errorf("%s: got hidden %t, want %t", tc.name, hidden, tc.hidden)
+		}
+		if !tc.hidden {
+			continue
+		}
+		if _, _, err := archToTest.Lookup(sysno)(nil, sysno, arch.SyscallArguments{}); !linuxerr.Equals(linuxerr.ENOSYS, err) {
+			t.Errorf("%s: got error %v, want ENOSYS", tc.name, err)
+		}
+	}
+}
@@ -19,7 +19,7 @@ import (
 )
 
 // CPUStats contains the subset of struct rusage fields that relate to CPU
-// scheduling.
+// scheduling and page faults.
 //
 // +stateify savable
 type CPUStats struct {
@@ -33,9 +33,19 @@ type CPUStats struct {
 	// ceded due to blocking, etc.
 	VoluntarySwitches uint64
 
-	// InvoluntarySwitches (struct rusage::ru_nivcsw) is unsupported, since
-	// "preemptive" scheduling is managed by the Go runtime, which doesn't
-	// provide this information.
+	// InvoluntarySwitches is the number of times execution of application
+	// code has been interrupted by the sentry. Preemption of task goroutines
+	// is managed by the Go runtime, which doesn't provide this information,
+	// so it isn't counted.
+	InvoluntarySwitches uint64
+
+	// MinorFaults is the number of page faults handled by the sentry without
+	// performing I/O.
+	MinorFaults uint64
+
+	// MajorFaults is the number of page faults that required the sentry to
+	// perform I/O.
+	MajorFaults uint64
 }
 
 // Accumulate adds s2 to s.
@@ -43,6 +53,9 @@ func (s *CPUStats) Accumulate(s2 CPUStats) {
 	s.UserTime += s2.UserTime
 	s.SysTime += s2.SysTime
 	s.VoluntarySwitches += s2.VoluntarySwitches
+	s.InvoluntarySwitches += s2.InvoluntarySwitches
+	s.MinorFaults += s2.MinorFaults
+	s.MajorFaults += s2.MajorFaults
 }
 
 // DifferenceSince computes s - earlierSample.
@@ -50,8 +63,11 @@ func (s *CPUStats) Accumulate(s2 CPUStats) {
 // Precondition: s >= earlierSample.
 func (s *CPUStats) DifferenceSince(earlierSample CPUStats) CPUStats {
 	return CPUStats{
-		UserTime:          s.UserTime - earlierSample.UserTime,
-		SysTime:           s.SysTime - earlierSample.SysTime,
-		VoluntarySwitches: s.VoluntarySwitches - earlierSample.VoluntarySwitches,
+		UserTime:            s.UserTime - earlierSample.UserTime,
+		SysTime:             s.SysTime - earlierSample.SysTime,
+		VoluntarySwitches:   s.VoluntarySwitches - earlierSample.VoluntarySwitches,
+		InvoluntarySwitches: s.InvoluntarySwitches - earlierSample.InvoluntarySwitches,
+		MinorFaults:         s.MinorFaults - earlierSample.MinorFaults,
+		MajorFaults:         s.MajorFaults - earlierSample.MajorFaults,
 	}
 }
@@ -0,0 +1,55 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"time"
+
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+)
+
+// Delay contains delay accounting statistics, i.e. time spent waiting for
+// resources. See Linux's Documentation/accounting/delay-accounting.rst.
+//
+// +stateify savable
+type Delay struct {
+	// BlkIOCount is the number of uninterruptible waits for I/O.
+	BlkIOCount atomicbitops.Uint64
+
+	// BlkIODelay is the total time, in nanoseconds, spent in uninterruptible
+	// waits for I/O.
+	BlkIODelay atomicbitops.Uint64
+}
+
+// Clone turns other into a clone of d.
+func (d *Delay) Clone(other *Delay) {
+	other.BlkIOCount.Store(d.BlkIOCount.Load())
+	other.BlkIODelay.Store(d.BlkIODelay.Load())
+}
+
+// AccountBlkIO does the accounting for an uninterruptible wait for I/O that
+// lasted for delay.
+func (d *Delay) AccountBlkIO(delay time.Duration) {
+	d.BlkIOCount.Add(1)
+	if delay > 0 {
+		d.BlkIODelay.Add(uint64(delay))
+	}
+}
+
+// Accumulate adds up delay usages.
+func (d *Delay) Accumulate(other *Delay) {
+	d.BlkIOCount.Add(other.BlkIOCount.Load())
+	d.BlkIODelay.Add(other.BlkIODelay.Load())
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/context"
+)
+
+// FilesystemImplCacheExtension is an optional extension to FilesystemImpl
+// implemented by filesystems that cache dentries or file data. Such
+// filesystems account cache lookups to the Mount they were made through using
+// Mount.AccountDentryCacheLookup and Mount.AccountPageCacheRead.
+type FilesystemImplCacheExtension interface {
+	// DropDentryCache evicts all cached dentries that are not in use.
+	DropDentryCache(ctx context.Context)
+}
+
+// CacheStats contains cache statistics of a Mount.
+type CacheStats struct {
+	// DentryHits is the number of path component lookups that were resolved
+	// from the dentry cache, including cached negative lookups.
+	DentryHits uint64 `json:"dentry_hits"`
+
+	// DentryMisses is the number of path component lookups that required
+	// querying the backing filesystem.
+	DentryMisses uint64 `json:"dentry_misses"`
+
+	// PageHitBytes is the number of bytes read from the page cache.
+	PageHitBytes uint64 `json:"page_hit_bytes"`
+
+	// PageMissBytes is the number of bytes that were not in the page cache
+	// when read, and had to be read from the backing filesystem.
+	PageMissBytes uint64 `json:"page_miss_bytes"`
+}
+
+// DropDentryCaches evicts unused cached dentries from all filesystems.
+func (vfs *VirtualFilesystem) DropDentryCaches(ctx context.Context) {
+	for fs := range vfs.getFilesystems() {
+		if ext, ok := fs.impl.(FilesystemImplCacheExtension); ok {
+			ext.DropDentryCache(ctx)
+		}
+		fs.DecRef(ctx)
+	}
+}
+
+// cacheStats holds the cache statistics of a Mount.
+//
+// +stateify savable
+type cacheStats struct {
+	dentryHits    atomicbitops.Uint64
+	dentryMisses  atomicbitops.Uint64
+	pageHitBytes  atomicbitops.Uint64
+	pageMissBytes atomicbitops.Uint64
+}
+
+// AccountDentryCacheLookup accounts a path component lookup through mnt that
+// was resolved from the dentry cache if hit is true, or that required
+// querying the backing filesystem otherwise.
+func (mnt *Mount) AccountDentryCacheLookup(hit bool) {
+	if hit {
+		mnt.cacheStats.dentryHits.Add(1)
+	} else {
+		mnt.cacheStats.dentryMisses.Add(1)
+	}
+}
+
+// AccountPageCacheRead accounts a read of done bytes through mnt via the page
+// cache, of which missed bytes were not cached.
+func (mnt *Mount) AccountPageCacheRead(done, missed uint64) {
+	missed = min(missed, done)
+	if hit := done - missed; hit > 0 {
+		mnt.cacheStats.pageHitBytes.Add(hit)
+	}
+	if missed > 0 {
+		mnt.cacheStats.pageMissBytes.Add(missed)
+	}
+}
+
+// CacheStats returns the cache statistics of mnt. ok is false if the mounted
+// filesystem doesn't have caches.
+func (mnt *Mount) CacheStats() (stats CacheStats, ok bool) {
+	if _, ok := mnt.fs.impl.(FilesystemImplCacheExtension); !ok {
+		return CacheStats{}, false
+	}
+	return CacheStats{
+		DentryHits:    mnt.cacheStats.dentryHits.Load(),
+		DentryMisses:  mnt.cacheStats.dentryMisses.Load(),
+		PageHitBytes:  mnt.cacheStats.pageHitBytes.Load(),
+		PageMissBytes: mnt.cacheStats.pageMissBytes.Load(),
+	}, true
+}
@@ -34,6 +34,10 @@ const (
 	// mapping filesystem unique IDs (cf. gofer.InternalFilesystemOptions.UniqueID)
 	// to host FDs.
 	CtxRestoreFilesystemFDMap
+
+	// CtxContainerID is a Context.Value key for the ID of the container that
+	// the task belongs to, used to account I/O statistics per container.
+	CtxContainerID
 )
 
 // MountNamespaceFromContext returns the MountNamespace used by ctx. If ctx is
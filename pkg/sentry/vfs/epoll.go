@@ -15,6 +15,9 @@
 package vfs
 
 import (
+	"cmp"
+	"slices"
+
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/context"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
@@ -47,6 +50,10 @@ type EpollInstance struct {
 	// EpollInstance for monitoring.
 	interest map[epollInterestKey]*epollInterest
 
+	// lastSeq is the seq of the last registered epollInterest. lastSeq is
+	// protected by interestMu.
+	lastSeq uint64
+
 	// readyMu protects ready, readySeq, epollInterest.ready, and
 	// epollInterest.epollInterestEntry. ready is analogous to Linux's struct
 	// eventpoll::lock.
@@ -91,6 +98,9 @@ type epollInterest struct {
 	// key is the file to which this epollInterest applies. key is immutable.
 	key epollInterestKey
 
+	// seq orders epollInterests by registration. seq is immutable.
+	seq uint64
+
 	// waiter is registered with key.file. entry is protected by
 	// epoll.interestMu.
 	waiter waiter.Entry
@@ -261,9 +271,11 @@ func (ep *EpollInstance) AddInterest(file *FileDescription, num int32, event lin
 
 	// Register interest in file.
 	mask := event.Events | linux.EPOLLERR | linux.EPOLLHUP
+	ep.lastSeq++
 	epi := &epollInterest{
 		epoll:    ep,
 		key:      key,
+		seq:      ep.lastSeq,
 		mask:     mask,
 		userData: event.Data,
 	}
@@ -353,6 +365,46 @@ func (ep *EpollInstance) ModifyInterest(file *FileDescription, num int32, event
 	return nil
 }
 
+// CopyEpollInterests registers file with every epoll instance that fd is
+// registered with, under the same file descriptor numbers, events and user
+// data. It is used when file replaces fd in file descriptor tables.
+//
+// Preconditions: References must be held on fd and file.
+func (fd *FileDescription) CopyEpollInterests(file *FileDescription) error {
+	fd.epollMu.Lock()
+	epis := make([]*epollInterest, 0, len(fd.epolls))
+	for epi := range fd.epolls {
+		epis = append(epis, epi)
+	}
+	fd.epollMu.Unlock()
+	for _, epi := range epis {
+		ep := epi.epoll
+		ep.interestMu.Lock()
+		_, ok := ep.interest[epi.key]
+		mask, userData := epi.mask, epi.userData
+		ep.interestMu.Unlock()
+		if !ok {
+			// epi was concurrently unregistered.
+			continue
+		}
+		key := epollInterestKey{
+			file: file,
+			num:  epi.key.num,
+		}
+		if err := ep.AddInterest(file, key.num, linux.EpollEvent{Events: mask, Data: userData}); err != nil {
+			return err
+		}
+		// AddInterest always adds EPOLLERR and EPOLLHUP, which a disabled
+		// EPOLLONESHOT registration doesn't report.
+		ep.interestMu.Lock()
+		if newEpi, ok := ep.interest[key]; ok {
+			newEpi.mask = mask
+		}
+		ep.interestMu.Unlock()
+	}
+	return nil
+}
+
 // DeleteInterest implements the semantics of EPOLL_CTL_DEL.
 //
 // Preconditions: A reference must be held on file.
@@ -382,6 +434,33 @@ func (ep *EpollInstance) DeleteInterest(file *FileDescription, num int32) error
 	return nil
 }
 
+// InterestFile returns the off'th FileDescription registered with file
+// descriptor number num, or nil if there is no such registration. If num was
+// registered with several FileDescriptions, they are ordered by registration.
+// This implements the lookup of Linux's
+// fs/eventpoll.c:get_epoll_tfile_raw_ptr(), used by kcmp(2).
+//
+// No reference is taken on the returned FileDescription, so it may only be
+// used for comparisons.
+func (ep *EpollInstance) InterestFile(num int32, off uint32) *FileDescription {
+	ep.interestMu.Lock()
+	defer ep.interestMu.Unlock()
+
+	var epis []*epollInterest
+	for key, epi := range ep.interest {
+		if key.num == num {
+			epis = append(epis, epi)
+		}
+	}
+	if uint64(off) >= uint64(len(epis)) {
+		return nil
+	}
+	slices.SortFunc(epis, func(a, b *epollInterest) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+	return epis[off].key.file
+}
+
 // NotifyEvent implements waiter.EventListener.NotifyEvent.
 func (epi *epollInterest) NotifyEvent(waiter.EventMask) {
 	newReady := false
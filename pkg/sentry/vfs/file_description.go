@@ -121,6 +121,14 @@ type FileDescriptionOptions struct {
 
 	// If DenySpliceIn is true, splice into descriptor isn't allowed.
 	DenySpliceIn bool
+
+	// If IOPressure is true, reads and writes through the file description
+	// wait for storage I/O, e.g. to a regular file backed by the gofer or the
+	// host, and count towards the I/O pressure of the calling task's
+	// container (see VirtualFilesystem.ContainerIOPressure). Reads and writes
+	// that wait for other events, e.g. through pipes, sockets, terminals and
+	// eventfds, must not set it.
+	IOPressure bool
 }
 
 // FileCreationFlags are the set of flags passed to FileDescription.Init() but
@@ -357,6 +365,12 @@ type FileDescriptionImpl interface {
 	// Preconditions: The FileDescription was opened for writing.
 	Allocate(ctx context.Context, mode, offset, length uint64) error
 
+	// Advise applies fadvise(2) advice to the given range of the file. A
+	// length of 0 means that the range extends to the end of the file.
+	// Advice is validated by the caller, and implementations are free to
+	// ignore it.
+	Advise(ctx context.Context, offset, length int64, advice int32) error
+
 	// waiter.Waitable methods may be used to poll for I/O events.
 	waiter.Waitable
 
@@ -579,9 +593,18 @@ func (fd *FileDescription) StatFS(ctx context.Context) (linux.Statfs, error) {
 		})
 		statfs, err := fd.vd.mount.fs.impl.StatFSAt(ctx, rp)
 		rp.Release(ctx)
-		return statfs, err
+		if err != nil {
+			return linux.Statfs{}, err
+		}
+		fd.vd.mount.setStatfsFlags(&statfs)
+		return statfs, nil
 	}
-	return fd.impl.StatFS(ctx)
+	statfs, err := fd.impl.StatFS(ctx)
+	if err != nil {
+		return linux.Statfs{}, err
+	}
+	fd.vd.mount.setStatfsFlags(&statfs)
+	return statfs, nil
 }
 
 // Allocate grows file represented by FileDescription to offset + length bytes.
@@ -596,6 +619,12 @@ func (fd *FileDescription) Allocate(ctx context.Context, mode, offset, length ui
 	return nil
 }
 
+// Advise applies fadvise(2) advice to the file represented by
+// FileDescription.
+func (fd *FileDescription) Advise(ctx context.Context, offset, length int64, advice int32) error {
+	return fd.impl.Advise(ctx, offset, length, advice)
+}
+
 // Readiness implements waiter.Waitable.Readiness.
 //
 // It returns fd's I/O readiness.
@@ -633,7 +662,14 @@ func (fd *FileDescription) PRead(ctx context.Context, dst usermem.IOSequence, of
 		return 0, linuxerr.EBADF
 	}
 	start := fsmetric.StartReadWait()
+	var ioStart int64
+	if RecordIOStats {
+		ioStart = fd.beginIO(ctx)
+	}
 	n, err := fd.impl.PRead(ctx, dst, offset, opts)
+	if RecordIOStats {
+		fd.accountRead(ctx, n, ioStart)
+	}
 	if n > 0 {
 		fd.Dentry().InotifyWithParent(ctx, linux.IN_ACCESS, 0, PathEvent)
 	}
@@ -648,7 +684,14 @@ func (fd *FileDescription) Read(ctx context.Context, dst usermem.IOSequence, opt
 		return 0, linuxerr.EBADF
 	}
 	start := fsmetric.StartReadWait()
+	var ioStart int64
+	if RecordIOStats {
+		ioStart = fd.beginIO(ctx)
+	}
 	n, err := fd.impl.Read(ctx, dst, opts)
+	if RecordIOStats {
+		fd.accountRead(ctx, n, ioStart)
+	}
 	if n > 0 {
 		fd.Dentry().InotifyWithParent(ctx, linux.IN_ACCESS, 0, PathEvent)
 	}
@@ -667,7 +710,14 @@ func (fd *FileDescription) PWrite(ctx context.Context, src usermem.IOSequence, o
 	if !fd.writable {
 		return 0, linuxerr.EBADF
 	}
+	var ioStart int64
+	if RecordIOStats {
+		ioStart = fd.beginIO(ctx)
+	}
 	n, err := fd.impl.PWrite(ctx, src, offset, opts)
+	if RecordIOStats {
+		fd.accountWrite(ctx, n, ioStart)
+	}
 	if n > 0 {
 		fd.Dentry().InotifyWithParent(ctx, linux.IN_MODIFY, 0, PathEvent)
 	}
@@ -679,7 +729,14 @@ func (fd *FileDescription) Write(ctx context.Context, src usermem.IOSequence, op
 	if !fd.writable {
 		return 0, linuxerr.EBADF
 	}
+	var ioStart int64
+	if RecordIOStats {
+		ioStart = fd.beginIO(ctx)
+	}
 	n, err := fd.impl.Write(ctx, src, opts)
+	if RecordIOStats {
+		fd.accountWrite(ctx, n, ioStart)
+	}
 	if n > 0 {
 		fd.Dentry().InotifyWithParent(ctx, linux.IN_MODIFY, 0, PathEvent)
 	}
@@ -70,6 +70,13 @@ func (FileDescriptionDefaultImpl) Allocate(ctx context.Context, mode, offset, le
 	return linuxerr.ENODEV
 }
 
+// Advise implements FileDescriptionImpl.Advise analogously to files without
+// page cache effects in Linux, for which mm/fadvise.c:generic_fadvise()
+// ignores the advice.
+func (FileDescriptionDefaultImpl) Advise(ctx context.Context, offset, length int64, advice int32) error {
+	return nil
+}
+
 // Readiness implements waiter.Waitable.Readiness analogously to
 // file_operations::poll == NULL in Linux.
 func (FileDescriptionDefaultImpl) Readiness(mask waiter.EventMask) waiter.EventMask {
@@ -263,6 +270,21 @@ type WritableDynamicBytesSource interface {
 	Write(ctx context.Context, fd *FileDescription, src usermem.IOSequence, offset int64) (int64, error)
 }
 
+// IncrementalDynamicBytesSource extends DynamicBytesSource to allow the
+// source's contents to be generated incrementally, so that reads need not
+// generate the whole file, consistent with Linux's fs/seq_file.c:seq_open()
+// iterators. This is beneficial for sources that are expensive to generate
+// in full, or that must hold locks while generating.
+type IncrementalDynamicBytesSource interface {
+	DynamicBytesSource
+
+	// GenerateFrom writes one or more records, beginning with the record at
+	// position pos, to buf. It returns the position of the next record to
+	// write, and done == true if there are no more records. Position 0 is the
+	// first record; positions are otherwise opaque to callers.
+	GenerateFrom(ctx context.Context, buf *bytes.Buffer, pos uint64) (next uint64, done bool, err error)
+}
+
 // DynamicBytesFileDescriptionImpl may be embedded by implementations of
 // FileDescriptionImpl that represent read-only regular files whose contents
 // are backed by a bytes.Buffer that is regenerated when necessary, consistent
@@ -271,6 +293,10 @@ type WritableDynamicBytesSource interface {
 // If data additionally implements WritableDynamicBytesSource, writes are
 // dispatched to the implementer. The source data is not automatically modified.
 //
+// If data additionally implements IncrementalDynamicBytesSource, only the
+// records required to satisfy each read are generated, and data that has been
+// read is discarded from the buffer.
+//
 // DynamicBytesFileDescriptionImpl.Init() must be called before first
 // use.
 //
@@ -282,6 +308,14 @@ type DynamicBytesFileDescriptionImpl struct {
 	buf      bytes.Buffer       `state:".([]byte)"`
 	off      int64
 	lastRead int64 // offset at which the last Read, PRead, or Seek ended
+
+	// The following fields are only used if data implements
+	// IncrementalDynamicBytesSource. bufOff is the file offset of the first
+	// byte in buf. pos is the position of the next record to generate. done
+	// is true if all records have been generated.
+	bufOff int64
+	pos    uint64
+	done   bool
 }
 
 func (fd *DynamicBytesFileDescriptionImpl) saveBuf() []byte {
@@ -298,8 +332,68 @@ func (fd *DynamicBytesFileDescriptionImpl) Init(vfsfd *FileDescription, data Dyn
 	fd.data = data
 }
 
+// resetBufLocked discards generated data.
+//
+// Preconditions: fd.mu must be locked.
+func (fd *DynamicBytesFileDescriptionImpl) resetBufLocked() {
+	fd.buf.Reset()
+	fd.bufOff = 0
+	fd.pos = 0
+	fd.done = false
+}
+
+// fillIncrementalLocked generates records from src until fd.buf begins at
+// offset and contains at least want bytes, or until all records have been
+// generated.
+//
+// Preconditions: fd.mu must be locked.
+func (fd *DynamicBytesFileDescriptionImpl) fillIncrementalLocked(ctx context.Context, src IncrementalDynamicBytesSource, offset, want int64) error {
+	if offset < fd.bufOff {
+		fd.resetBufLocked()
+	}
+	for {
+		// Discard buffered data preceding offset.
+		if skip := min(offset-fd.bufOff, int64(fd.buf.Len())); skip > 0 {
+			fd.buf.Next(int(skip))
+			fd.bufOff += skip
+		}
+		if fd.done || (fd.bufOff == offset && int64(fd.buf.Len()) >= want) {
+			return nil
+		}
+		next, done, err := src.GenerateFrom(ctx, &fd.buf, fd.pos)
+		if err != nil {
+			return err
+		}
+		fd.pos = next
+		fd.done = done
+	}
+}
+
+// Preconditions: fd.mu must be locked.
+func (fd *DynamicBytesFileDescriptionImpl) preadIncrementalLocked(ctx context.Context, src IncrementalDynamicBytesSource, dst usermem.IOSequence, offset int64) (int64, error) {
+	// Restart generation before pread() at a new offset. Compare
+	// fs/seq_file.c:seq_read_iter() => traverse().
+	if offset != fd.lastRead {
+		fd.resetBufLocked()
+	}
+	if err := fd.fillIncrementalLocked(ctx, src, offset, max(dst.NumBytes(), 1)); err != nil {
+		fd.resetBufLocked()
+		fd.lastRead = 0
+		return 0, err
+	}
+	if fd.bufOff != offset || fd.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	n, err := dst.CopyOut(ctx, fd.buf.Bytes())
+	fd.lastRead = offset + int64(n)
+	return int64(n), err
+}
+
 // Preconditions: fd.mu must be locked.
 func (fd *DynamicBytesFileDescriptionImpl) preadLocked(ctx context.Context, dst usermem.IOSequence, offset int64, opts *ReadOptions) (int64, error) {
+	if src, ok := fd.data.(IncrementalDynamicBytesSource); ok {
+		return fd.preadIncrementalLocked(ctx, src, dst, offset)
+	}
 	// Regenerate the buffer if it's empty, or before pread() at a new offset.
 	// Compare fs/seq_file.c:seq_read() => traverse().
 	switch {
@@ -356,6 +450,16 @@ func (fd *DynamicBytesFileDescriptionImpl) Seek(ctx context.Context, offset int6
 	if offset < 0 {
 		return 0, linuxerr.EINVAL
 	}
+	if _, ok := fd.data.(IncrementalDynamicBytesSource); ok {
+		if offset != fd.lastRead {
+			// Records up to offset are generated and skipped by the next
+			// read.
+			fd.resetBufLocked()
+			fd.lastRead = offset
+		}
+		fd.off = offset
+		return offset, nil
+	}
 	if offset != fd.lastRead {
 		// Regenerate the file's contents immediately. Compare
 		// fs/seq_file.c:seq_lseek() => traverse().
@@ -393,7 +497,7 @@ func (fd *DynamicBytesFileDescriptionImpl) pwriteLocked(ctx context.Context, src
 	}
 
 	// Invalidate cached data that might exist prior to this call.
-	fd.buf.Reset()
+	fd.resetBufLocked()
 	return n, nil
 }
 
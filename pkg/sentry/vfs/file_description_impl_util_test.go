@@ -72,6 +72,30 @@ func (d *storeData) Write(ctx context.Context, _ *FileDescription, src usermem.I
 	return 0, nil
 }
 
+// lineRecords is an IncrementalDynamicBytesSource whose records are the
+// integers in [0, n), one per line.
+type lineRecords struct {
+	n     uint64
+	calls int
+}
+
+var _ IncrementalDynamicBytesSource = (*lineRecords)(nil)
+
+// Generate implements DynamicBytesSource.Generate.
+func (l *lineRecords) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	for i := uint64(0); i < l.n; i++ {
+		fmt.Fprintf(buf, "%d\n", i)
+	}
+	return nil
+}
+
+// GenerateFrom implements IncrementalDynamicBytesSource.GenerateFrom.
+func (l *lineRecords) GenerateFrom(ctx context.Context, buf *bytes.Buffer, pos uint64) (uint64, bool, error) {
+	l.calls++
+	fmt.Fprintf(buf, "%d\n", pos)
+	return pos + 1, pos+1 == l.n, nil
+}
+
 // testFD is a read-only FileDescriptionImpl representing a regular file.
 type testFD struct {
 	fileDescription
@@ -211,3 +235,67 @@ func TestWritable(t *testing.T) {
 		t.Errorf("PWrite: got err (%v, %v), wanted (0, EINVAL)", n, err)
 	}
 }
+
+func TestIncremental(t *testing.T) {
+	ctx := contexttest.Context(t)
+
+	vfsObj := &VirtualFilesystem{}
+	if err := vfsObj.Init(ctx); err != nil {
+		t.Fatalf("VFS init: %v", err)
+	}
+	src := &lineRecords{n: 20}
+	var want bytes.Buffer
+	src.Generate(ctx, &want)
+	fd := newTestFD(ctx, vfsObj, linux.O_RDONLY, src)
+	defer fd.DecRef(ctx)
+
+	// The first read only generates the records it needs.
+	buf := make([]byte, 3)
+	n, err := fd.Read(ctx, usermem.BytesIOSequence(buf), ReadOptions{})
+	if n != 3 || err != nil {
+		t.Fatalf("first Read: got (%d, %v), wanted (3, nil)", n, err)
+	}
+	if got := src.calls; got != 2 {
+		t.Errorf("first Read: got %d calls to GenerateFrom, wanted 2", got)
+	}
+
+	// Subsequent reads generate the remaining records exactly once.
+	got := append([]byte(nil), buf[:n]...)
+	for {
+		n, err := fd.Read(ctx, usermem.BytesIOSequence(buf), ReadOptions{})
+		got = append(got, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+	}
+	if string(got) != want.String() {
+		t.Errorf("Read: got %q, wanted %q", got, want.String())
+	}
+	if got := src.calls; got != int(src.n) {
+		t.Errorf("got %d calls to GenerateFrom, wanted %d", got, src.n)
+	}
+
+	// PRead at a new offset restarts generation.
+	n, err = fd.PRead(ctx, usermem.BytesIOSequence(buf), 10, ReadOptions{})
+	if n != 3 || err != nil {
+		t.Fatalf("PRead: got (%d, %v), wanted (3, nil)", n, err)
+	}
+	if got, want := string(buf), want.String()[10:13]; got != want {
+		t.Errorf("PRead: got %q, wanted %q", got, want)
+	}
+
+	// So does Seek.
+	if n, err := fd.Seek(ctx, 5, linux.SEEK_SET); n != 5 || err != nil {
+		t.Fatalf("Seek: got (%d, %v), wanted (5, nil)", n, err)
+	}
+	n, err = fd.Read(ctx, usermem.BytesIOSequence(buf), ReadOptions{})
+	if n != 3 || err != nil {
+		t.Fatalf("Read after Seek: got (%d, %v), wanted (3, nil)", n, err)
+	}
+	if got, want := string(buf), want.String()[5:8]; got != want {
+		t.Errorf("Read after Seek: got %q, wanted %q", got, want)
+	}
+}
@@ -45,6 +45,16 @@ func GenericParseMountOptions(str string) map[string]string {
 	return m
 }
 
+// FSIDFromDevice returns the filesystem ID reported by statfs(2) for a
+// filesystem with the given device numbers. Since device numbers are unique
+// among mounted filesystems and preserved across checkpoint/restore, so is
+// the returned ID. Compare Linux's fs/ext2/super.c:ext2_statfs(), which
+// reports u64_to_fsid(huge_encode_dev(sb->s_dev)) when the filesystem has no
+// UUID.
+func FSIDFromDevice(major, minor uint32) [2]int32 {
+	return [2]int32{int32(linux.MakeDeviceID(uint16(major), minor)), 0}
+}
+
 // GenericStatFS returns a statfs struct filled with the common fields for a
 // general filesystem. This is analogous to Linux's fs/libfs.cs:simple_statfs().
 func GenericStatFS(fsMagic uint64) linux.Statfs {
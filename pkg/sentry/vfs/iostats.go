@@ -0,0 +1,277 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/gohacks"
+)
+
+// RecordIOStats enables the per-mount and per-container I/O statistics
+// returned by Mount.IOStats, VirtualFilesystem.ContainerIOStats and
+// /proc/[pid]/mountstats. Enabling this comes at a CPU cost due to performing
+// two clock reads per read and write call.
+var RecordIOStats = false
+
+// ioStats holds I/O statistics for a Mount or a container.
+//
+// +stateify savable
+type ioStats struct {
+	readOps    atomicbitops.Uint64
+	readBytes  atomicbitops.Uint64
+	readNanos  atomicbitops.Uint64
+	writeOps   atomicbitops.Uint64
+	writeBytes atomicbitops.Uint64
+	writeNanos atomicbitops.Uint64
+
+	// pressure is only tracked for containers.
+	pressure ioPressure
+}
+
+func (s *ioStats) accountRead(n int64, nanos uint64) {
+	s.readOps.Add(1)
+	if n > 0 {
+		s.readBytes.Add(uint64(n))
+	}
+	s.readNanos.Add(nanos)
+}
+
+func (s *ioStats) accountWrite(n int64, nanos uint64) {
+	s.writeOps.Add(1)
+	if n > 0 {
+		s.writeBytes.Add(uint64(n))
+	}
+	s.writeNanos.Add(nanos)
+}
+
+func (s *ioStats) snapshot() IOStats {
+	return IOStats{
+		ReadOps:    s.readOps.Load(),
+		ReadBytes:  s.readBytes.Load(),
+		ReadNanos:  s.readNanos.Load(),
+		WriteOps:   s.writeOps.Load(),
+		WriteBytes: s.writeBytes.Load(),
+		WriteNanos: s.writeNanos.Load(),
+	}
+}
+
+// accountRead accounts a read of n bytes through fd, that started at the
+// gohacks.Nanotime() value returned by fd.beginIO, to fd's mount and to the
+// container of the task in ctx. It must only be called if RecordIOStats is
+// true.
+func (fd *FileDescription) accountRead(ctx context.Context, n int64, start int64) {
+	now := gohacks.Nanotime()
+	nanos := uint64(now - start)
+	fd.vd.mount.ioStats.accountRead(n, nanos)
+	if s := fd.vd.mount.vfs.containerIOStatsFromContext(ctx); s != nil {
+		s.accountRead(n, nanos)
+		if fd.opts.IOPressure {
+			s.pressure.end(now)
+		}
+	}
+}
+
+// accountWrite is the equivalent of accountRead for writes.
+func (fd *FileDescription) accountWrite(ctx context.Context, n int64, start int64) {
+	now := gohacks.Nanotime()
+	nanos := uint64(now - start)
+	fd.vd.mount.ioStats.accountWrite(n, nanos)
+	if s := fd.vd.mount.vfs.containerIOStatsFromContext(ctx); s != nil {
+		s.accountWrite(n, nanos)
+		if fd.opts.IOPressure {
+			s.pressure.end(now)
+		}
+	}
+}
+
+// containerIOStatsFromContext returns the I/O statistics of the container of
+// the task in ctx, creating them if needed. It returns nil if ctx isn't
+// associated with a container.
+func (vfs *VirtualFilesystem) containerIOStatsFromContext(ctx context.Context) *ioStats {
+	cid, ok := ctx.Value(CtxContainerID).(string)
+	if !ok || cid == "" {
+		return nil
+	}
+	vfs.containerIOStatsMu.RLock()
+	s := vfs.containerIOStats[cid]
+	vfs.containerIOStatsMu.RUnlock()
+	if s != nil {
+		return s
+	}
+	vfs.containerIOStatsMu.Lock()
+	defer vfs.containerIOStatsMu.Unlock()
+	if s := vfs.containerIOStats[cid]; s != nil {
+		return s
+	}
+	if vfs.containerIOStats == nil {
+		vfs.containerIOStats = make(map[string]*ioStats)
+	}
+	s = &ioStats{}
+	vfs.containerIOStats[cid] = s
+	return s
+}
+
+// ContainerIOStats returns the I/O statistics of all file descriptions
+// read and written by tasks of container cid, across all mounts.
+func (vfs *VirtualFilesystem) ContainerIOStats(cid string) IOStats {
+	vfs.containerIOStatsMu.RLock()
+	defer vfs.containerIOStatsMu.RUnlock()
+	if s := vfs.containerIOStats[cid]; s != nil {
+		return s.snapshot()
+	}
+	return IOStats{}
+}
+
+// RemoveContainerIOStats discards the I/O statistics of container cid. It is
+// called when the container is destroyed.
+func (vfs *VirtualFilesystem) RemoveContainerIOStats(cid string) {
+	vfs.containerIOStatsMu.Lock()
+	defer vfs.containerIOStatsMu.Unlock()
+	delete(vfs.containerIOStats, cid)
+}
+
+// IOStats is a snapshot of the I/O statistics of one or more mounts or
+// containers. Only
+// reads and writes through file descriptions are accounted; memory mapped I/O
+// is not.
+type IOStats struct {
+	// ReadOps is the number of read operations.
+	ReadOps uint64 `json:"read_ops"`
+
+	// ReadBytes is the number of bytes read.
+	ReadBytes uint64 `json:"read_bytes"`
+
+	// ReadNanos is the total time spent in read operations.
+	ReadNanos uint64 `json:"read_nanos"`
+
+	// WriteOps is the number of write operations.
+	WriteOps uint64 `json:"write_ops"`
+
+	// WriteBytes is the number of bytes written.
+	WriteBytes uint64 `json:"write_bytes"`
+
+	// WriteNanos is the total time spent in write operations.
+	WriteNanos uint64 `json:"write_nanos"`
+}
+
+// Accumulate adds the statistics in other to s.
+func (s *IOStats) Accumulate(other *IOStats) {
+	s.ReadOps += other.ReadOps
+	s.ReadBytes += other.ReadBytes
+	s.ReadNanos += other.ReadNanos
+	s.WriteOps += other.WriteOps
+	s.WriteBytes += other.WriteBytes
+	s.WriteNanos += other.WriteNanos
+}
+
+// IOStats returns the I/O statistics of mnt.
+func (mnt *Mount) IOStats() IOStats {
+	return mnt.ioStats.snapshot()
+}
+
+// MountIOStats contains the I/O statistics of a single mount.
+type MountIOStats struct {
+	// ID is the mount ID, as shown in /proc/[pid]/mountinfo.
+	ID uint64 `json:"id"`
+
+	// Path is the path of the mount point relative to the mount namespace
+	// root.
+	Path string `json:"path"`
+
+	// FSType is the filesystem type name.
+	FSType string `json:"fstype"`
+
+	IOStats
+
+	// Cache contains the cache statistics of the mounted filesystem. It is
+	// nil if the filesystem doesn't report cache statistics.
+	Cache *CacheStats `json:"cache,omitempty"`
+}
+
+// MountsIOStats returns the I/O statistics of all mounts that are reachable
+// from root, sorted by mount ID.
+func (vfs *VirtualFilesystem) MountsIOStats(ctx context.Context, root VirtualDentry) ([]MountIOStats, error) {
+	vfs.lockMounts()
+	mounts := root.mount.submountsLocked()
+	// Take a reference on mounts since we need to drop vfs.mountMu before
+	// calling vfs.PathnameReachable() (=> FilesystemImpl.PrependPath()).
+	for _, mnt := range mounts {
+		mnt.IncRef()
+	}
+	vfs.unlockMounts(ctx)
+	defer func() {
+		for _, mnt := range mounts {
+			mnt.DecRef(ctx)
+		}
+	}()
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].ID < mounts[j].ID })
+
+	stats := make([]MountIOStats, 0, len(mounts))
+	for _, mnt := range mounts {
+		if ctx.Interrupted() {
+			return nil, linuxerr.ErrInterrupted
+		}
+		path, err := vfs.PathnameReachable(ctx, root, VirtualDentry{mount: mnt, dentry: mnt.root})
+		if err != nil || path == "" {
+			// Not reachable from root.
+			continue
+		}
+		s := MountIOStats{
+			ID:      mnt.ID,
+			Path:    path,
+			FSType:  mnt.fs.FilesystemType().Name(),
+			IOStats: mnt.IOStats(),
+		}
+		if cache, ok := mnt.CacheStats(); ok {
+			s.Cache = &cache
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// GenerateProcMountStats emits the contents of /proc/[pid]/mountstats for vfs
+// to buf.
+//
+// The first line for each mount follows Linux's fs/proc_namespace.c:
+// show_vfsstat(). gVisor doesn't have per-filesystem statistics like NFS, so
+// it appends its own I/O counters on an indented line instead, if
+// RecordIOStats is enabled, followed by cache counters for filesystems that
+// have caches:
+//
+//	io: <read ops> <read bytes> <read ms> <write ops> <write bytes> <write ms>
+//	cache: <dentry hits> <dentry misses> <page hit bytes> <page miss bytes>
+func (vfs *VirtualFilesystem) GenerateProcMountStats(ctx context.Context, taskRootDir VirtualDentry, buf *bytes.Buffer) error {
+	stats, err := vfs.MountsIOStats(ctx, taskRootDir)
+	if err != nil {
+		return err
+	}
+	for _, s := range stats {
+		fmt.Fprintf(buf, "device none mounted on %s with fstype %s\n", s.Path, s.FSType)
+		if RecordIOStats {
+			fmt.Fprintf(buf, "\tio: %d %d %d %d %d %d\n", s.ReadOps, s.ReadBytes, s.ReadNanos/1e6, s.WriteOps, s.WriteBytes, s.WriteNanos/1e6)
+		}
+		if s.Cache != nil {
+			fmt.Fprintf(buf, "\tcache: %d %d %d %d\n", s.Cache.DentryHits, s.Cache.DentryMisses, s.Cache.PageHitBytes, s.Cache.PageMissBytes)
+		}
+	}
+	return nil
+}
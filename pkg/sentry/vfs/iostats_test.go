@@ -0,0 +1,217 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/sentry/contexttest"
+	"gvisor.dev/gvisor/pkg/usermem"
+)
+
+// containerContext is a context.Context for a task of container cid.
+type containerContext struct {
+	context.Context
+	cid string
+}
+
+// Value implements context.Context.Value.
+func (ctx *containerContext) Value(key any) any {
+	if key == CtxContainerID {
+		return ctx.cid
+	}
+	return ctx.Context.Value(key)
+}
+
+func TestContainerIOStats(t *testing.T) {
+	ctx := contexttest.Context(t)
+	vfsObj := &VirtualFilesystem{}
+	if err := vfsObj.Init(ctx); err != nil {
+		t.Fatalf("VFS init: %v", err)
+	}
+	fd := newTestFD(ctx, vfsObj, linux.O_RDWR, &storeData{data: "init"})
+	defer fd.DecRef(ctx)
+
+	read := func(ctx context.Context) {
+		t.Helper()
+		buf := make([]byte, 10)
+		if _, err := fd.PRead(ctx, usermem.BytesIOSequence(buf), 0, ReadOptions{}); err != nil && err != io.EOF {
+			t.Fatalf("PRead: %v", err)
+		}
+	}
+
+	// Nothing is recorded unless RecordIOStats is set.
+	read(&containerContext{ctx, "a"})
+	if got := vfsObj.ContainerIOStats("a"); got != (IOStats{}) {
+		t.Errorf("ContainerIOStats(a) with RecordIOStats disabled = %+v, want zero", got)
+	}
+	if got := fd.Mount().IOStats(); got != (IOStats{}) {
+		t.Errorf("Mount.IOStats() with RecordIOStats disabled = %+v, want zero", got)
+	}
+
+	RecordIOStats = true
+	defer func() { RecordIOStats = false }()
+	read(&containerContext{ctx, "a"})
+	read(&containerContext{ctx, "a"})
+	read(&containerContext{ctx, "b"})
+	// Reads outside of any container are only accounted to the mount.
+	read(ctx)
+
+	for _, tc := range []struct {
+		cid  string
+		want uint64
+	}{
+		{cid: "a", want: 2},
+		{cid: "b", want: 1},
+		{cid: "c", want: 0},
+	} {
+		got := vfsObj.ContainerIOStats(tc.cid)
+		if got.ReadOps != tc.want || got.ReadBytes != 4*tc.want {
+			t.Errorf("ContainerIOStats(%s) = %+v, want %d reads of %d bytes", tc.cid, got, tc.want, 4*tc.want)
+		}
+	}
+	if got := fd.Mount().IOStats(); got.ReadOps != 4 || got.ReadBytes != 16 {
+		t.Errorf("Mount.IOStats() = %+v, want 4 reads of 16 bytes", got)
+	}
+
+	vfsObj.RemoveContainerIOStats("a")
+	if got := vfsObj.ContainerIOStats("a"); got != (IOStats{}) {
+		t.Errorf("ContainerIOStats(a) after RemoveContainerIOStats = %+v, want zero", got)
+	}
+}
+
+func TestIOPressure(t *testing.T) {
+	const sec = int64(time.Second)
+	var p ioPressure
+	if got := p.snapshot(sec); got != (PressureStats{}) {
+		t.Errorf("initial snapshot = %+v, want zero", got)
+	}
+
+	// Overlapping reads and writes stall the container once.
+	p.begin(2 * sec)
+	p.begin(3 * sec)
+	p.end(4 * sec)
+	p.end(5 * sec)
+	// The stall in progress is included.
+	p.begin(6 * sec)
+	got := p.snapshot(7 * sec)
+	if want := uint64(4 * sec / 1000); got.Total != want {
+		t.Errorf("Total = %d, want %d", got.Total, want)
+	}
+	// The container was stalled for 4 of the 6 seconds since the last
+	// snapshot, so each average moves towards 66.67% at a rate that depends
+	// on its window.
+	if !(got.Avg10 > got.Avg60 && got.Avg60 > got.Avg300 && got.Avg300 > 0 && got.Avg10 < 200.0/3) {
+		t.Errorf("averages = %+v, want 66.67 > Avg10 > Avg60 > Avg300 > 0", got)
+	}
+	want10 := 200.0 / 3 * (1 - math.Exp(-0.6))
+	if math.Abs(got.Avg10-want10) > 1e-9 {
+		t.Errorf("Avg10 = %f, want %f", got.Avg10, want10)
+	}
+
+	p.end(8 * sec)
+	// Ends without a matching begin, e.g. after the container's statistics
+	// were removed, are ignored.
+	p.end(9 * sec)
+	p.begin(10 * sec)
+	p.end(11 * sec)
+	if got, want := p.snapshot(12*sec).Total, uint64(6*sec/1000); got != want {
+		t.Errorf("Total = %d, want %d", got, want)
+	}
+}
+
+// waitFD is a FileDescriptionImpl whose reads wait until they are released,
+// like reads from a pipe, socket, terminal or eventfd waiting for an event.
+type waitFD struct {
+	fileDescription
+	DentryMetadataFileDescriptionImpl
+
+	// started receives a value when a read starts waiting.
+	started chan struct{}
+
+	// release releases waiting reads when it is closed.
+	release chan struct{}
+}
+
+// Release implements FileDescriptionImpl.Release.
+func (fd *waitFD) Release(context.Context) {}
+
+// Read implements FileDescriptionImpl.Read.
+func (fd *waitFD) Read(ctx context.Context, dst usermem.IOSequence, opts ReadOptions) (int64, error) {
+	fd.started <- struct{}{}
+	<-fd.release
+	return 0, nil
+}
+
+func TestIOPressureOnlyCountsStorageIO(t *testing.T) {
+	ctx := contexttest.Context(t)
+	vfsObj := &VirtualFilesystem{}
+	if err := vfsObj.Init(ctx); err != nil {
+		t.Fatalf("VFS init: %v", err)
+	}
+	RecordIOStats = true
+	defer func() { RecordIOStats = false }()
+
+	for _, tc := range []struct {
+		name       string
+		ioPressure bool
+	}{
+		// Pipes, sockets, terminals and eventfds don't set IOPressure.
+		{name: "wait", ioPressure: false},
+		{name: "storage", ioPressure: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vd := vfsObj.NewAnonVirtualDentry("waitFD")
+			defer vd.DecRef(ctx)
+			fd := &waitFD{
+				started: make(chan struct{}),
+				release: make(chan struct{}),
+			}
+			if err := fd.vfsfd.Init(fd, linux.O_RDONLY, vd.Mount(), vd.Dentry(), &FileDescriptionOptions{IOPressure: tc.ioPressure}); err != nil {
+				t.Fatalf("Init: %v", err)
+			}
+			defer fd.vfsfd.DecRef(ctx)
+
+			cctx := &containerContext{ctx, tc.name}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				fd.vfsfd.Read(cctx, usermem.BytesIOSequence(make([]byte, 1)), ReadOptions{})
+			}()
+			<-fd.started
+			time.Sleep(10 * time.Millisecond)
+			waiting := vfsObj.ContainerIOPressure(tc.name)
+			close(fd.release)
+			<-done
+
+			if got := waiting.Total != 0; got != tc.ioPressure {
+				t.Errorf("ContainerIOPressure while waiting = %+v, want stalled = %t", waiting, tc.ioPressure)
+			}
+			if got := vfsObj.ContainerIOPressure(tc.name).Total != 0; got != tc.ioPressure {
+				t.Errorf("ContainerIOPressure after waiting = %+v, want stalled = %t", vfsObj.ContainerIOPressure(tc.name), tc.ioPressure)
+			}
+			// The read is accounted to the container's I/O statistics either
+			// way.
+			if got := vfsObj.ContainerIOStats(tc.name).ReadOps; got != 1 {
+				t.Errorf("ContainerIOStats().ReadOps = %d, want 1", got)
+			}
+		})
+	}
+}
@@ -63,6 +63,15 @@ func (fl *FileLocks) LockPOSIX(ctx context.Context, uid fslock.UniqueID, ownerPI
 	return linuxerr.ERESTARTSYS
 }
 
+// WaitPOSIX blocks until a POSIX-style lock on a file region could be
+// acquired, without acquiring it.
+func (fl *FileLocks) WaitPOSIX(ctx context.Context, uid fslock.UniqueID, t fslock.LockType, r fslock.LockRange) error {
+	if err := fl.posix.WaitRegion(ctx, uid, t, r); err != nil {
+		return linuxerr.ERESTARTSYS
+	}
+	return nil
+}
+
 // UnlockPOSIX releases a POSIX-style lock on a file region.
 //
 // This operation is always successful, even if there did not exist a lock on
@@ -77,3 +86,10 @@ func (fl *FileLocks) TestPOSIX(ctx context.Context, uid fslock.UniqueID, t fsloc
 	_, ofd := uid.(*FileDescription)
 	return fl.posix.TestRegion(ctx, uid, t, r, ofd), nil
 }
+
+// HeldRegionsPOSIX calls fn, in increasing order, for each maximal subrange of
+// r on which POSIX-style locks are held, along with the strongest type of the
+// locks held on it. fn must not call methods on fl.
+func (fl *FileLocks) HeldRegionsPOSIX(r fslock.LockRange, fn func(r fslock.LockRange, t fslock.LockType)) {
+	fl.posix.HeldRegions(r, fn)
+}
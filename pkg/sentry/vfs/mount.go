@@ -122,6 +122,14 @@ type Mount struct {
 	// namespace. It is analogous to MNT_LOCKED in Linux.
 	locked bool
 
+	// ioStats holds I/O statistics for file descriptions opened through this
+	// Mount.
+	ioStats ioStats
+
+	// cacheStats holds cache statistics for lookups and reads made through
+	// this Mount.
+	cacheStats cacheStats
+
 	// The lower 63 bits of writers is the number of calls to
 	// Mount.CheckBeginWrite() that have not yet been paired with a call to
 	// Mount.EndWrite(). The MSB of writers is set if MS_RDONLY is in effect.
@@ -197,6 +205,14 @@ func (mnt *Mount) MountFlags() uint64 {
 	return flags
 }
 
+// setStatfsFlags sets statfs.Flags for statfs(2) or fstatfs(2) of a file on
+// mnt. Mount flags are always reported relative to the mount through which the
+// file was reached. See Linux's fs/statfs.c:vfs_statfs() and
+// calculate_f_flags().
+func (mnt *Mount) setStatfsFlags(statfs *linux.Statfs) {
+	statfs.Flags = linux.ST_VALID | mnt.MountFlags()
+}
+
 func (mnt *Mount) isFollower() bool {
 	return mnt.leader != nil
 }
@@ -0,0 +1,194 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"math"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/gohacks"
+	"gvisor.dev/gvisor/pkg/sync"
+)
+
+// pressureWindows are the windows over which ioPressure computes running
+// averages, as in Linux's /proc/pressure files.
+var pressureWindows = [3]time.Duration{10 * time.Second, 60 * time.Second, 300 * time.Second}
+
+// PressureStats is pressure stall information for a resource. It corresponds
+// to the "some" line of Linux's /proc/pressure files.
+type PressureStats struct {
+	// Avg10, Avg60 and Avg300 are the percentages of time during which at
+	// least one task was stalled, averaged over the last 10, 60 and 300
+	// seconds.
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+
+	// Total is the total time during which at least one task was stalled, in
+	// microseconds.
+	Total uint64 `json:"total"`
+}
+
+// ioPressure's state packs the number of reads and writes in progress, in the
+// bits from pressureCountShift up, with the time at which that number last
+// became non-zero, in microseconds modulo 1<<pressureCountShift (about 203
+// days), in the bits below.
+const (
+	pressureCountShift = 44
+	pressureCountOne   = 1 << pressureCountShift
+	pressureMicrosMask = pressureCountOne - 1
+)
+
+// pressureMicros returns the gohacks.Nanotime() value now in the format of the
+// start time in ioPressure.state.
+func pressureMicros(now int64) uint64 {
+	return uint64(now/1000) & pressureMicrosMask
+}
+
+// ioPressure tracks the time during which at least one task was waiting for a
+// read or write to complete. Compare Linux's kernel/sched/psi.c.
+//
+// +stateify savable
+type ioPressure struct {
+	// state holds the number of reads and writes in progress and the start of
+	// the current stall; see pressureCountShift. In-progress reads and writes
+	// are interrupted by save/restore, so it is not saved.
+	state atomicbitops.Uint64 `state:"nosave"`
+
+	// stallMicros is the total stall time, excluding the current stall if
+	// reads or writes are in progress.
+	stallMicros atomicbitops.Uint64
+
+	// mu serializes snapshot. begin and end don't take it.
+	mu sync.Mutex `state:"nosave"`
+
+	// avgs are the running averages of the stall percentage over
+	// pressureWindows.
+	// +checklocks:mu
+	avgs [3]float64
+
+	// lastUpdate is the gohacks.Nanotime() value at which avgs were last
+	// updated, or zero if they haven't been updated since the ioPressure was
+	// created or restored. gohacks.Nanotime() values are not comparable across
+	// save/restore, so it is not saved.
+	// +checklocks:mu
+	lastUpdate int64 `state:"nosave"`
+
+	// lastTotal is the total stall time in microseconds when avgs were last
+	// updated.
+	// +checklocks:mu
+	lastTotal uint64
+}
+
+// begin records the start of a read or write at time now.
+func (p *ioPressure) begin(now int64) {
+	for {
+		old := p.state.Load()
+		new := old + pressureCountOne
+		if old>>pressureCountShift == 0 {
+			new = pressureCountOne | pressureMicros(now)
+		}
+		if p.state.CompareAndSwap(old, new) {
+			return
+		}
+	}
+}
+
+// end records the end of a read or write at time now.
+func (p *ioPressure) end(now int64) {
+	for {
+		old := p.state.Load()
+		if old>>pressureCountShift == 0 {
+			// The matching call to begin was on statistics that have since
+			// been discarded by VirtualFilesystem.RemoveContainerIOStats.
+			return
+		}
+		new := old - pressureCountOne
+		if new>>pressureCountShift == 0 {
+			new = 0
+		}
+		if !p.state.CompareAndSwap(old, new) {
+			continue
+		}
+		if new == 0 {
+			p.stallMicros.Add((pressureMicros(now) - old) & pressureMicrosMask)
+		}
+		return
+	}
+}
+
+// snapshot updates the running averages to time now and returns the current
+// pressure stall information.
+func (p *ioPressure) snapshot(now int64) PressureStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	total := p.stallMicros.Load()
+	if state := p.state.Load(); state>>pressureCountShift != 0 {
+		total += (pressureMicros(now) - state) & pressureMicrosMask
+	}
+	// A stall that ended concurrently may be missing from both stallMicros
+	// and state, until the next snapshot. Keep the total monotonic.
+	total = max(total, p.lastTotal)
+	if p.lastUpdate != 0 && now > p.lastUpdate {
+		// The stall percentage is constant over the interval since the last
+		// update as far as we know, so decaying the previous average by the
+		// length of the interval gives an exponential moving average that
+		// doesn't depend on how often it is sampled.
+		elapsed := float64(now - p.lastUpdate)
+		pct := min(100*1000*float64(total-p.lastTotal)/elapsed, 100)
+		for i, window := range pressureWindows {
+			decay := math.Exp(-elapsed / float64(window.Nanoseconds()))
+			p.avgs[i] = p.avgs[i]*decay + pct*(1-decay)
+		}
+	}
+	p.lastUpdate = now
+	p.lastTotal = total
+	return PressureStats{
+		Avg10:  p.avgs[0],
+		Avg60:  p.avgs[1],
+		Avg300: p.avgs[2],
+		Total:  total,
+	}
+}
+
+// beginIO records the start of a read or write through fd by the task in ctx,
+// and returns the current gohacks.Nanotime() value. It must only be called if
+// RecordIOStats is true, and must be followed by a call to fd.accountRead or
+// fd.accountWrite with the returned value.
+func (fd *FileDescription) beginIO(ctx context.Context) int64 {
+	now := gohacks.Nanotime()
+	if fd.opts.IOPressure {
+		if s := fd.vd.mount.vfs.containerIOStatsFromContext(ctx); s != nil {
+			s.pressure.begin(now)
+		}
+	}
+	return now
+}
+
+// ContainerIOPressure returns the I/O pressure stall information of container
+// cid, i.e. how much of the time at least one of its tasks was waiting for a
+// read or write through a file description with FileDescriptionOptions.IOPressure
+// to complete.
+func (vfs *VirtualFilesystem) ContainerIOPressure(cid string) PressureStats {
+	vfs.containerIOStatsMu.RLock()
+	s := vfs.containerIOStats[cid]
+	vfs.containerIOStatsMu.RUnlock()
+	if s == nil {
+		return PressureStats{}
+	}
+	return s.pressure.snapshot(gohacks.Nanotime())
+}
@@ -158,6 +158,12 @@ type VirtualFilesystem struct {
 	//
 	// +checklocks:mountMu
 	toDecRef map[refs.RefCounter]int
+
+	// containerIOStats maps container IDs to the I/O statistics of their
+	// tasks. It is only populated if RecordIOStats is true.
+	containerIOStatsMu sync.RWMutex `state:"nosave"`
+	// +checklocks:containerIOStatsMu
+	containerIOStats map[string]*ioStats
 }
 
 // Init initializes a new VirtualFilesystem with no mounts or FilesystemTypes.
@@ -663,7 +669,7 @@ func (vfs *VirtualFilesystem) StatFSAt(ctx context.Context, creds *auth.Credenti
 		vfs.maybeBlockOnMountPromise(ctx, rp)
 		statfs, err := rp.mount.fs.impl.StatFSAt(ctx, rp)
 		if err == nil {
-			statfs.Flags |= rp.mount.MountFlags()
+			rp.mount.setStatfsFlags(&statfs)
 			rp.Release(ctx)
 			return statfs, nil
 		}
@@ -33,6 +33,7 @@ import (
 	"fmt"
 	"time"
 
+	"gvisor.dev/gvisor/pkg/atomicbitops"
 	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/metric"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
@@ -125,6 +126,11 @@ type Watchdog struct {
 	// Configuration options are embedded.
 	Opts
 
+	// taskTimeoutAction is the action taken when a stuck task is detected.
+	// It is initially Opts.TaskTimeoutAction, and may be changed by
+	// SetTaskTimeoutAction while the watchdog is running.
+	taskTimeoutAction atomicbitops.Int32
+
 	// period indicates how often to check all tasks. It's calculated based on
 	// opts.TaskTimeout.
 	period time.Duration
@@ -175,6 +181,7 @@ func New(k *kernel.Kernel, opts Opts) *Watchdog {
 		stop:      make(chan struct{}),
 		done:      make(chan struct{}),
 	}
+	w.taskTimeoutAction.Store(int32(opts.TaskTimeoutAction))
 
 	// Handle StartupTimeout if it exists.
 	if w.StartupTimeout > 0 {
@@ -201,11 +208,24 @@ func (w *Watchdog) Start() {
 	}
 	w.lastRun = w.k.MonotonicClock().Now()
 
-	log.Infof("Starting watchdog, period: %v, timeout: %v, action: %v", w.period, w.TaskTimeout, w.TaskTimeoutAction)
+	log.Infof("Starting watchdog, period: %v, timeout: %v, action: %v", w.period, w.TaskTimeout, w.TaskTimeoutActionNow())
 	go w.loop() // S/R-SAFE: watchdog is stopped during save and restarted after restore.
 	w.running = true
 }
 
+// TaskTimeoutActionNow returns the action currently taken when a stuck task
+// is detected.
+func (w *Watchdog) TaskTimeoutActionNow() Action {
+	return Action(w.taskTimeoutAction.Load())
+}
+
+// SetTaskTimeoutAction changes the action taken when a stuck task is
+// detected.
+func (w *Watchdog) SetTaskTimeoutAction(a Action) {
+	w.taskTimeoutAction.Store(int32(a))
+	log.Infof("Watchdog action set to: %v", a)
+}
+
 // Stop requests the watchdog to stop and wait for it.
 func (w *Watchdog) Stop() {
 	if w.TaskTimeout == 0 {
@@ -332,13 +352,13 @@ func (w *Watchdog) report(offenders map[*kernel.Task]*offender, newTaskFound boo
 	buf.WriteString("Search for 'goroutine <id>' in the stack dump to find the offending goroutine(s)")
 
 	// Force stack dump only if a new task is detected.
-	w.doAction(w.TaskTimeoutAction, newTaskFound, &buf)
+	w.doAction(w.TaskTimeoutActionNow(), newTaskFound, &buf)
 }
 
 func (w *Watchdog) reportStuckWatchdog() {
 	var buf bytes.Buffer
 	buf.WriteString("Watchdog goroutine is stuck")
-	w.doAction(w.TaskTimeoutAction, false, &buf)
+	w.doAction(w.TaskTimeoutActionNow(), false, &buf)
 }
 
 // doAction will take the given action. If the action is LogWarning, the stack
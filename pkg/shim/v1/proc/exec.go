@@ -242,7 +242,7 @@ func (e *execProcess) start(ctx context.Context) error {
 			return fmt.Errorf("failed to start console copy: %w", err)
 		}
 	} else if !e.stdio.IsNull() {
-		if err := copyPipes(ctx, e.io, e.stdio.Stdin, e.stdio.Stdout, e.stdio.Stderr, &e.wg); err != nil {
+		if err := copyPipes(ctx, e.io, e.stdio.Stdin, e.stdio.Stdout, e.stdio.Stderr, e.parent.StreamLimits, &e.wg); err != nil {
 			return fmt.Errorf("failed to start io pipe copy: %w", err)
 		}
 	}
@@ -76,6 +76,14 @@ type Init struct {
 	Sandbox  bool
 	UserLog  string
 	Monitor  ProcessMonitor
+
+	// StreamLimits bounds the memory used to copy stdout and stderr of the
+	// container and its exec'd processes.
+	StreamLimits StreamLimits
+
+	// WarmSandbox is the ID of a pre-booted blank sandbox that is adopted
+	// instead of creating a new sandbox. Only valid for sandbox containers.
+	WarmSandbox string
 }
 
 // NewRunsc returns a new runsc instance for a process.
@@ -137,7 +145,13 @@ func (p *Init) Create(ctx context.Context, r *CreateConfig) (err error) {
 		// UserLog is only useful for sandbox.
 		opts.UserLog = p.UserLog
 	}
-	if err := p.runtime.Create(ctx, r.ID, r.Bundle, opts); err != nil {
+	if p.WarmSandbox != "" {
+		// The user log is set when the blank sandbox is created.
+		adoptOpts := &runsccmd.CreateOpts{PidFile: pidFile, IO: opts.IO}
+		if err := p.runtime.Adopt(ctx, p.WarmSandbox, r.ID, r.Bundle, adoptOpts); err != nil {
+			return p.runtimeError(err, "OCI runtime adopt failed")
+		}
+	} else if err := p.runtime.Create(ctx, r.ID, r.Bundle, opts); err != nil {
 		return p.runtimeError(err, "OCI runtime create failed")
 	}
 	if r.Stdin != "" {
@@ -161,7 +175,7 @@ func (p *Init) Create(ctx context.Context, r *CreateConfig) (err error) {
 		}
 		p.console = console
 	} else if !hasNoIO(r) {
-		if err := copyPipes(ctx, p.io, r.Stdin, r.Stdout, r.Stderr, &p.wg); err != nil {
+		if err := copyPipes(ctx, p.io, r.Stdin, r.Stdout, r.Stderr, p.StreamLimits, &p.wg); err != nil {
 			return fmt.Errorf("failed to start io pipe copy: %w", err)
 		}
 	}
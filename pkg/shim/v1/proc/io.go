@@ -38,7 +38,7 @@ var bufPool = sync.Pool{
 	},
 }
 
-func copyPipes(ctx context.Context, rio runc.IO, stdin, stdout, stderr string, wg *sync.WaitGroup) error {
+func copyPipes(ctx context.Context, rio runc.IO, stdin, stdout, stderr string, limits StreamLimits, wg *sync.WaitGroup) error {
 	var sameFile *countingWriteCloser
 	for _, i := range []struct {
 		name string
@@ -49,11 +49,13 @@ func copyPipes(ctx context.Context, rio runc.IO, stdin, stdout, stderr string, w
 			dest: func(wc io.WriteCloser, rc io.Closer) {
 				wg.Add(1)
 				go func() {
-					p := bufPool.Get().(*[]byte)
-					defer bufPool.Put(p)
-					if _, err := io.CopyBuffer(wc, rio.Stdout(), *p); err != nil {
+					dropped, err := copyStream(wc, rio.Stdout(), limits)
+					if err != nil {
 						log.G(ctx).Warn("error copying stdout")
 					}
+					if dropped > 0 {
+						log.G(ctx).Warnf("dropped %d bytes of stdout, consumer is too slow", dropped)
+					}
 					wg.Done()
 					wc.Close()
 					if rc != nil {
@@ -66,11 +68,13 @@ func copyPipes(ctx context.Context, rio runc.IO, stdin, stdout, stderr string, w
 			dest: func(wc io.WriteCloser, rc io.Closer) {
 				wg.Add(1)
 				go func() {
-					p := bufPool.Get().(*[]byte)
-					defer bufPool.Put(p)
-					if _, err := io.CopyBuffer(wc, rio.Stderr(), *p); err != nil {
+					dropped, err := copyStream(wc, rio.Stderr(), limits)
+					if err != nil {
 						log.G(ctx).Warn("error copying stderr")
 					}
+					if dropped > 0 {
+						log.G(ctx).Warnf("dropped %d bytes of stderr, consumer is too slow", dropped)
+					}
 					wg.Done()
 					wc.Close()
 					if rc != nil {
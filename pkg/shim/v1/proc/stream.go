@@ -0,0 +1,147 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proc
+
+import (
+	"io"
+
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+)
+
+// defaultStreamBufferSize is the default size of each read from the
+// container's stdio pipes.
+const defaultStreamBufferSize = 32 << 10
+
+// StreamLimits configures how container stdout and stderr are copied to the
+// consumer (normally containerd's fifos).
+//
+// Memory used by a stream is bounded by MaxBuffered plus two buffers of
+// BufferSize. Once the limit is reached, the shim either stops reading from
+// the container, so that writes from the container block until the consumer
+// catches up, or drops output if DropOnOverflow is set.
+type StreamLimits struct {
+	// BufferSize is the size of each read from the container. Defaults to
+	// 32KiB if zero.
+	BufferSize int
+
+	// MaxBuffered is the maximum number of bytes read from the container that
+	// have not yet been written to the consumer. If not larger than
+	// BufferSize, output is copied synchronously with a single buffer.
+	MaxBuffered int
+
+	// DropOnOverflow discards container output that doesn't fit in
+	// MaxBuffered instead of blocking the container.
+	DropOnOverflow bool
+}
+
+func (l StreamLimits) bufferSize() int {
+	if l.BufferSize <= 0 {
+		return defaultStreamBufferSize
+	}
+	return l.BufferSize
+}
+
+// copyStream copies src to dst following limits. It returns the number of
+// bytes dropped because of overflow.
+//
+// If writing to dst fails while output is buffered, src is closed if it is an
+// io.Closer, since reads from the container can't be interrupted otherwise.
+func copyStream(dst io.Writer, src io.Reader, limits StreamLimits) (int64, error) {
+	size := limits.bufferSize()
+	if limits.MaxBuffered <= size {
+		if size == defaultStreamBufferSize {
+			p := bufPool.Get().(*[]byte)
+			defer bufPool.Put(p)
+			_, err := io.CopyBuffer(dst, src, *p)
+			return 0, err
+		}
+		_, err := io.CopyBuffer(dst, src, make([]byte, size))
+		return 0, err
+	}
+
+	var (
+		dropped atomicbitops.Int64
+		slots   = limits.MaxBuffered / size
+		chunks  = make(chan []byte, slots)
+		free    = make(chan []byte, slots+1)
+		done    = make(chan struct{})
+		readErr = make(chan error, 1)
+		exited  = make(chan struct{})
+	)
+
+	go func() {
+		defer close(exited)
+		defer close(chunks)
+		for {
+			var buf []byte
+			select {
+			case buf = <-free:
+			default:
+				buf = make([]byte, size)
+			}
+			n, err := src.Read(buf)
+			if n > 0 {
+				select {
+				case chunks <- buf[:n]:
+				case <-done:
+					return
+				default:
+					if limits.DropOnOverflow {
+						dropped.Add(int64(n))
+						select {
+						case free <- buf:
+						default:
+						}
+						break
+					}
+					select {
+					case chunks <- buf[:n]:
+					case <-done:
+						return
+					}
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					readErr <- err
+				}
+				return
+			}
+		}
+	}()
+
+	for buf := range chunks {
+		if _, err := dst.Write(buf); err != nil {
+			// Stop the reader, which may be blocked sending a chunk or
+			// reading from src.
+			close(done)
+			if c, ok := src.(io.Closer); ok {
+				c.Close()
+				<-exited
+			}
+			return dropped.Load(), err
+		}
+		select {
+		case free <- buf[:size]:
+		default:
+		}
+	}
+	select {
+	case err := <-readErr:
+		return dropped.Load(), err
+	default:
+		return dropped.Load(), nil
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// chunkReader returns count chunks of size bytes and closes eof once they have
+// all been read.
+type chunkReader struct {
+	size  int
+	count int
+	eof   chan struct{}
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if r.count == 0 {
+		close(r.eof)
+		return 0, io.EOF
+	}
+	r.count--
+	n := min(len(p), r.size)
+	for i := range p[:n] {
+		p[i] = byte(r.count)
+	}
+	return n, nil
+}
+
+// slowWriter blocks writes until wait is closed.
+type slowWriter struct {
+	wait <-chan struct{}
+	buf  bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.wait
+	return w.buf.Write(p)
+}
+
+func TestCopyStream(t *testing.T) {
+	const (
+		size  = 16
+		count = 10
+	)
+	for _, tc := range []struct {
+		name   string
+		limits StreamLimits
+		drop   bool
+	}{
+		{
+			name:   "synchronous",
+			limits: StreamLimits{BufferSize: size},
+		},
+		{
+			name:   "buffered",
+			limits: StreamLimits{BufferSize: size, MaxBuffered: 4 * size},
+		},
+		{
+			name:   "buffered-unlimited",
+			limits: StreamLimits{BufferSize: size, MaxBuffered: 2 * count * size},
+		},
+		{
+			name:   "drop",
+			limits: StreamLimits{BufferSize: size, MaxBuffered: 2 * size, DropOnOverflow: true},
+			drop:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			src := &chunkReader{size: size, count: count, eof: make(chan struct{})}
+			var dst *slowWriter
+			if tc.drop {
+				// Don't let the consumer make progress until all the
+				// input has been read to force an overflow.
+				dst = &slowWriter{wait: src.eof}
+			} else {
+				wait := make(chan struct{})
+				close(wait)
+				dst = &slowWriter{wait: wait}
+			}
+			dropped, err := copyStream(dst, src, tc.limits)
+			if err != nil {
+				t.Fatalf("copyStream(): %v", err)
+			}
+			if got := int64(dst.buf.Len()) + dropped; got != count*size {
+				t.Errorf("copied %d + dropped %d bytes, want: %d", dst.buf.Len(), dropped, count*size)
+			}
+			if tc.drop && dropped == 0 {
+				t.Errorf("no bytes dropped")
+			}
+			if !tc.drop && dropped != 0 {
+				t.Errorf("dropped %d bytes, want: 0", dropped)
+			}
+		})
+	}
+}
+
+// failingWriter fails all writes with err.
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+// TestCopyStreamWriteError checks that the reader doesn't outlive copyStream
+// if the consumer fails while the container keeps its output open.
+func TestCopyStreamWriteError(t *testing.T) {
+	const size = 16
+	for _, tc := range []struct {
+		name   string
+		limits StreamLimits
+	}{
+		{
+			name:   "buffered",
+			limits: StreamLimits{BufferSize: size, MaxBuffered: 4 * size},
+		},
+		{
+			name:   "drop",
+			limits: StreamLimits{BufferSize: size, MaxBuffered: 2 * size, DropOnOverflow: true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatalf("os.Pipe(): %v", err)
+			}
+			defer r.Close()
+			defer w.Close()
+			if _, err := w.Write(make([]byte, size)); err != nil {
+				t.Fatalf("Write(): %v", err)
+			}
+
+			wantErr := errors.New("consumer is gone")
+			if _, err := copyStream(failingWriter{err: wantErr}, r, tc.limits); err != wantErr {
+				t.Fatalf("copyStream() = %v, want: %v", err, wantErr)
+			}
+			// The reader was blocked reading from the pipe, which must now
+			// be closed.
+			if _, err := w.Write(make([]byte, size)); err == nil {
+				t.Errorf("Write() to the container's output succeeded after copyStream returned, want: EPIPE")
+			}
+		})
+	}
+}
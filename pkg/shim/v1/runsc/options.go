@@ -27,6 +27,20 @@ type options struct {
 	// IoGID is the I/O's pipes gid.
 	IoGID uint32 `toml:"io_gid" json:"ioGid"`
 
+	// IoBufferSize is the size of each read from the container's stdout and
+	// stderr. Defaults to 32KiB.
+	IoBufferSize int `toml:"io_buffer_size" json:"ioBufferSize"`
+
+	// IoMaxBuffered is the maximum number of bytes of container output that
+	// the shim buffers while the consumer is slow. Once reached, the shim stops
+	// reading from the container, which blocks writes in the container, unless
+	// IoDropOnOverflow is set. Zero disables buffering.
+	IoMaxBuffered int `toml:"io_max_buffered" json:"ioMaxBuffered"`
+
+	// IoDropOnOverflow drops container output instead of blocking the
+	// container when IoMaxBuffered is reached.
+	IoDropOnOverflow bool `toml:"io_drop_on_overflow" json:"ioDropOnOverflow"`
+
 	// BinaryName is the binary name of the runsc binary.
 	BinaryName string `toml:"binary_name" json:"binaryName"`
 
@@ -45,6 +59,15 @@ type options struct {
 	// This configuration only applies when the shim is running as a service.
 	LogPath string `toml:"log_path" json:"logPath"`
 
+	// WarmPoolDir is the directory holding pre-booted blank sandboxes. When
+	// set, new pod sandboxes adopt a blank sandbox from the pool instead of
+	// booting a new one, and the pool is replenished in the background.
+	WarmPoolDir string `toml:"warm_pool_dir" json:"warmPoolDir"`
+
+	// WarmPoolSize is the number of blank sandboxes kept in WarmPoolDir.
+	// Defaults to 1.
+	WarmPoolSize int `toml:"warm_pool_size" json:"warmPoolSize"`
+
 	// RunscConfig is a key/value map of all runsc flags.
 	RunscConfig map[string]string `toml:"runsc_config" json:"runscConfig"`
 }
@@ -360,13 +360,31 @@ func (s *runscService) Create(ctx context.Context, r *taskAPI.CreateTaskRequest)
 		Stdout:   r.Stdout,
 		Stderr:   r.Stderr,
 	}
+	var pool *warmPool
+	if s.opts.WarmPoolDir != "" {
+		pool = newWarmPool(&s.opts, ns)
+	}
 	process, err := newInit(r.Bundle, filepath.Join(r.Bundle, "work"), ns, s.platform, config, &s.opts, st.Rootfs)
 	if err != nil {
 		return nil, err
 	}
-	if err := process.Create(ctx, config); err != nil {
+	if pool != nil && process.Sandbox && !r.Terminal {
+		if spec, err := utils.ReadSpec(r.Bundle); err == nil && adoptable(spec) {
+			process.WarmSandbox = pool.claim()
+		}
+	}
+	err = process.Create(ctx, config)
+	if process.WarmSandbox != "" {
+		pool.release(ctx, process.WarmSandbox, err == nil)
+	}
+	if err != nil {
 		return nil, err
 	}
+	if pool != nil && process.Sandbox {
+		// Replace the claimed blank sandbox, or fill the pool for the first
+		// time, without delaying the pod.
+		go pool.fill(context.WithoutCancel(ctx))
+	}
 
 	// Set up OOM notification on the sandbox's cgroup. This is done on
 	// sandbox create since the sandbox process will be created here.
@@ -719,6 +737,10 @@ func (s *runscService) getV1Stats(stats *runc.Stats, r *taskAPI.StatsRequest) (*
 			Current: stats.Pids.Current,
 			Limit:   stats.Pids.Limit,
 		},
+		Blkio: &cgroupsstats.BlkIOStat{
+			IoServiceBytesRecursive: toBlkioEntries(stats.Blkio.IoServiceBytesRecursive),
+			IoServicedRecursive:     toBlkioEntries(stats.Blkio.IoServicedRecursive),
+		},
 	}
 	data, err := typeurl.MarshalAny(metrics)
 	if err != nil {
@@ -754,6 +776,7 @@ func (s *runscService) getV2Stats(stats *runc.Stats, r *taskAPI.StatsRequest) (*
 			Current: stats.Pids.Current,
 			Limit:   stats.Pids.Limit,
 		},
+		Io: toIOStat(&stats.Blkio),
 	}
 	data, err := typeurl.MarshalAny(metrics)
 	if err != nil {
@@ -766,6 +789,60 @@ func (s *runscService) getV2Stats(stats *runc.Stats, r *taskAPI.StatsRequest) (*
 	}, nil
 }
 
+func toBlkioEntries(entries []runc.BlkioEntry) []*cgroupsstats.BlkIOEntry {
+	var out []*cgroupsstats.BlkIOEntry
+	for _, e := range entries {
+		out = append(out, &cgroupsstats.BlkIOEntry{
+			Major: e.Major,
+			Minor: e.Minor,
+			Op:    e.Op,
+			Value: e.Value,
+		})
+	}
+	return out
+}
+
+// toIOStat converts cgroup v1 style blkio stats to the cgroup v2 io.stat
+// format, which has a single entry per device.
+func toIOStat(blkio *runc.Blkio) *cgroupsv2stats.IOStat {
+	type device struct{ major, minor uint64 }
+	var (
+		devices []device
+		entries = make(map[device]*cgroupsv2stats.IOEntry)
+	)
+	entry := func(e runc.BlkioEntry) *cgroupsv2stats.IOEntry {
+		d := device{e.Major, e.Minor}
+		ioe, ok := entries[d]
+		if !ok {
+			ioe = &cgroupsv2stats.IOEntry{Major: e.Major, Minor: e.Minor}
+			entries[d] = ioe
+			devices = append(devices, d)
+		}
+		return ioe
+	}
+	for _, e := range blkio.IoServiceBytesRecursive {
+		switch e.Op {
+		case "Read":
+			entry(e).Rbytes = e.Value
+		case "Write":
+			entry(e).Wbytes = e.Value
+		}
+	}
+	for _, e := range blkio.IoServicedRecursive {
+		switch e.Op {
+		case "Read":
+			entry(e).Rios = e.Value
+		case "Write":
+			entry(e).Wios = e.Value
+		}
+	}
+	stat := &cgroupsv2stats.IOStat{}
+	for _, d := range devices {
+		stat.Usage = append(stat.Usage, entries[d])
+	}
+	return stat
+}
+
 // Update updates a running container.
 func (s *runscService) Update(ctx context.Context, r *taskAPI.UpdateTaskRequest) (*types.Empty, error) {
 	return empty, errdefs.ErrNotImplemented
@@ -929,6 +1006,11 @@ func newInit(path, workDir, namespace string, platform stdio.Platform, r *proc.C
 	p.WorkDir = workDir
 	p.IoUID = int(options.IoUID)
 	p.IoGID = int(options.IoGID)
+	p.StreamLimits = proc.StreamLimits{
+		BufferSize:     options.IoBufferSize,
+		MaxBuffered:    options.IoMaxBuffered,
+		DropOnOverflow: options.IoDropOnOverflow,
+	}
 	p.Sandbox = specutils.SpecContainerType(spec) == specutils.ContainerTypeSandbox
 	p.UserLog = utils.UserLogPath(spec)
 	p.Monitor = reaper.Default
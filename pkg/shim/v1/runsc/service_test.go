@@ -17,6 +17,8 @@ package runsc
 import (
 	"testing"
 
+	cgroupsv2stats "github.com/containerd/cgroups/v2/stats"
+	runc "github.com/containerd/go-runc"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"gvisor.dev/gvisor/pkg/shim/v1/utils"
 )
@@ -119,3 +121,32 @@ func TestCgroupNoUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestToIOStat(t *testing.T) {
+	blkio := runc.Blkio{
+		IoServiceBytesRecursive: []runc.BlkioEntry{
+			{Op: "Read", Value: 100},
+			{Op: "Write", Value: 200},
+			{Op: "Total", Value: 300},
+			{Major: 8, Minor: 1, Op: "Read", Value: 10},
+		},
+		IoServicedRecursive: []runc.BlkioEntry{
+			{Op: "Read", Value: 1},
+			{Op: "Write", Value: 2},
+			{Op: "Total", Value: 3},
+		},
+	}
+	want := []cgroupsv2stats.IOEntry{
+		{Rbytes: 100, Wbytes: 200, Rios: 1, Wios: 2},
+		{Major: 8, Minor: 1, Rbytes: 10},
+	}
+	got := toIOStat(&blkio).Usage
+	if len(got) != len(want) {
+		t.Fatalf("toIOStat() returned %d entries, want: %d", len(got), len(want))
+	}
+	for i, e := range got {
+		if e.Major != want[i].Major || e.Minor != want[i].Minor || e.Rbytes != want[i].Rbytes || e.Wbytes != want[i].Wbytes || e.Rios != want[i].Rios || e.Wios != want[i].Wios {
+			t.Errorf("entry %d = %+v, want: %+v", i, e, &want[i])
+		}
+	}
+}
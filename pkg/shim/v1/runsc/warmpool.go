@@ -0,0 +1,285 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runsc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	runc "github.com/containerd/go-runc"
+	"github.com/containerd/log"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gvisor.dev/gvisor/pkg/cleanup"
+	"gvisor.dev/gvisor/pkg/shim/v1/proc"
+	"gvisor.dev/gvisor/pkg/shim/v1/runsccmd"
+	"gvisor.dev/gvisor/pkg/shim/v1/utils"
+)
+
+const (
+	// warmReadyFile is created in the bundle of a blank sandbox once it has
+	// booted. Removing it claims the sandbox.
+	warmReadyFile = "ready"
+
+	// warmIDPrefix is the prefix of blank sandbox container IDs.
+	warmIDPrefix = "warm-"
+
+	// warmStaleTimeout is how long a blank sandbox may remain booting or
+	// claimed before it's considered leaked, e.g. by a shim that crashed.
+	warmStaleTimeout = 5 * time.Minute
+)
+
+// warmPool is a directory of pre-booted blank sandboxes. Each blank sandbox is
+// a container that has been created but not started, with its bundle in a
+// subdirectory of the pool named after its container ID. Pod sandboxes adopt a
+// blank sandbox with "runsc adopt", which binds the pod's spec, rootfs and
+// stdio to it, so that sandbox boot time is not part of pod start latency.
+//
+// Claiming a blank sandbox removes its ready file, which is atomic, so that
+// each blank sandbox is adopted by at most one shim. Concurrent shims may
+// briefly overfill the pool.
+type warmPool struct {
+	dir    string
+	size   int
+	root   string
+	ns     string
+	binary string
+	config map[string]string
+
+	// status returns the status of blank sandbox id, as reported by
+	// "runsc state".
+	status func(ctx context.Context, id string) (string, error)
+
+	// destroy force deletes blank sandbox id.
+	destroy func(ctx context.Context, id string) error
+}
+
+// newWarmPool returns the warm pool configured in opts for namespace ns. It
+// must be called before the runsc config in opts is formatted for a container.
+func newWarmPool(opts *options, ns string) *warmPool {
+	size := opts.WarmPoolSize
+	if size <= 0 {
+		size = 1
+	}
+	w := &warmPool{
+		dir:    filepath.Join(opts.WarmPoolDir, ns),
+		size:   size,
+		root:   opts.Root,
+		ns:     ns,
+		binary: opts.BinaryName,
+		config: maps.Clone(opts.RunscConfig),
+	}
+	w.status = func(ctx context.Context, id string) (string, error) {
+		c, err := w.runtime(id).State(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return c.Status, nil
+	}
+	w.destroy = func(ctx context.Context, id string) error {
+		return w.runtime(id).Delete(ctx, id, &runsccmd.DeleteOpts{Force: true})
+	}
+	return w
+}
+
+// adoptable returns true if a pod sandbox with the given spec can adopt a
+// blank sandbox. Annotations that override runsc flags can't be applied to a
+// sandbox that has already booted.
+func adoptable(spec *specs.Spec) bool {
+	for annotation := range spec.Annotations {
+		if strings.HasPrefix(annotation, "dev.gvisor.flag.") || strings.HasPrefix(annotation, "dev.gvisor.bundle.") {
+			return false
+		}
+	}
+	return true
+}
+
+// runtime returns the runsc instance used to manage blank sandbox id.
+func (w *warmPool) runtime(id string) *runsccmd.Runsc {
+	config := maps.Clone(w.config)
+	runsccmd.FormatRunscPaths(id, config)
+	return proc.NewRunsc(w.root, filepath.Join(w.dir, id), w.ns, w.binary, config, nil)
+}
+
+// claim takes a ready blank sandbox from the pool and returns its ID, or ""
+// if none is ready.
+func (w *warmPool) claim() string {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.L.Warnf("Failed to read warm pool %q: %v", w.dir, err)
+		}
+		return ""
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(w.dir, e.Name(), warmReadyFile)); err == nil {
+			log.L.Debugf("Claimed blank sandbox %q", e.Name())
+			return e.Name()
+		}
+	}
+	return ""
+}
+
+// release removes the bundle of claimed blank sandbox id. The sandbox is
+// destroyed unless it was adopted.
+func (w *warmPool) release(ctx context.Context, id string, adopted bool) {
+	if !adopted {
+		if err := w.destroy(ctx, id); err != nil {
+			log.G(ctx).WithError(err).Warnf("Failed to delete blank sandbox %q", id)
+		}
+	}
+	if err := os.RemoveAll(filepath.Join(w.dir, id)); err != nil {
+		log.G(ctx).WithError(err).Warnf("Failed to remove blank sandbox bundle %q", id)
+	}
+}
+
+// fill garbage collects the pool, then creates blank sandboxes until the pool
+// has size ready ones.
+func (w *warmPool) fill(ctx context.Context) {
+	ready, err := w.gc(ctx)
+	if err != nil {
+		log.G(ctx).WithError(err).Warnf("Failed to read warm pool %q", w.dir)
+		return
+	}
+	for ; ready < w.size; ready++ {
+		if err := w.add(ctx); err != nil {
+			log.G(ctx).WithError(err).Warn("Failed to add blank sandbox to warm pool")
+			return
+		}
+	}
+}
+
+// gc destroys blank sandboxes that can no longer be adopted, and ready ones in
+// excess of the pool size. It returns the number of ready blank sandboxes left
+// in the pool.
+func (w *warmPool) gc(ctx context.Context) (int, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	ready := 0
+	for _, e := range entries {
+		id := e.Name()
+		if !e.IsDir() || !strings.HasPrefix(id, warmIDPrefix) {
+			continue
+		}
+		readyFile := filepath.Join(w.dir, id, warmReadyFile)
+		if _, err := os.Stat(readyFile); err != nil {
+			// The sandbox is booting, or was claimed by a shim that is
+			// adopting it. Removing the ready file updates the modification
+			// time of the bundle, so it's the time at which either started.
+			info, err := e.Info()
+			if err != nil || time.Since(info.ModTime()) < warmStaleTimeout {
+				continue
+			}
+			log.G(ctx).Infof("Removing stale blank sandbox %q", id)
+			w.release(ctx, id, false /* adopted */)
+			continue
+		}
+		if ready < w.size {
+			if status, err := w.status(ctx, id); err == nil && status == "created" {
+				ready++
+				continue
+			}
+		}
+		// The sandbox died, or the pool shrank. Claim it before destroying it
+		// to avoid racing with other shims.
+		if err := os.Remove(readyFile); err != nil {
+			continue
+		}
+		log.G(ctx).Infof("Removing blank sandbox %q from warm pool", id)
+		w.release(ctx, id, false /* adopted */)
+	}
+	return ready, nil
+}
+
+// add creates a blank sandbox and marks it ready.
+func (w *warmPool) add(ctx context.Context) error {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return err
+	}
+	id := warmIDPrefix + hex.EncodeToString(b[:])
+	bundle := filepath.Join(w.dir, id)
+	if err := os.MkdirAll(filepath.Join(bundle, "rootfs"), 0711); err != nil {
+		return err
+	}
+	cu := cleanup.Make(func() { _ = os.RemoveAll(bundle) })
+	defer cu.Clean()
+
+	if err := utils.WriteSpec(bundle, blankSpec()); err != nil {
+		return err
+	}
+	nullIO, err := runc.NewNullIO()
+	if err != nil {
+		return fmt.Errorf("creating new NULL IO: %w", err)
+	}
+	defer nullIO.Close()
+
+	r := w.runtime(id)
+	if err := r.Create(ctx, id, bundle, &runsccmd.CreateOpts{IO: nullIO}); err != nil {
+		return fmt.Errorf("creating blank sandbox %q: %w", id, err)
+	}
+	cu.Add(func() {
+		if err := r.Delete(ctx, id, &runsccmd.DeleteOpts{Force: true}); err != nil {
+			log.G(ctx).WithError(err).Warnf("Failed to delete blank sandbox %q", id)
+		}
+	})
+	f, err := os.Create(filepath.Join(bundle, warmReadyFile))
+	if err != nil {
+		return err
+	}
+	f.Close()
+	cu.Release()
+	log.G(ctx).Debugf("Added blank sandbox %q to warm pool", id)
+	return nil
+}
+
+// blankSpec returns the spec of a blank sandbox. Its process is never started
+// since the spec is replaced when the sandbox is adopted.
+func blankSpec() *specs.Spec {
+	return &specs.Spec{
+		Version: specs.Version,
+		Root: &specs.Root{
+			Path:     "rootfs",
+			Readonly: true,
+		},
+		Process: &specs.Process{
+			Args: []string{"/pause"},
+			Cwd:  "/",
+		},
+		Linux: &specs.Linux{
+			Namespaces: []specs.LinuxNamespace{
+				{Type: specs.PIDNamespace},
+				{Type: specs.IPCNamespace},
+				{Type: specs.UTSNamespace},
+				{Type: specs.MountNamespace},
+				{Type: specs.NetworkNamespace},
+			},
+		},
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runsc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestWarmPoolClaim(t *testing.T) {
+	dir := t.TempDir()
+	pool := newWarmPool(&options{WarmPoolDir: dir}, "k8s.io")
+	if got := pool.claim(); got != "" {
+		t.Fatalf("claim() on missing pool = %q, want \"\"", got)
+	}
+
+	for _, id := range []string{"warm-booting", "warm-ready"} {
+		if err := os.MkdirAll(filepath.Join(pool.dir, id), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(pool.dir, "warm-ready", warmReadyFile), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := pool.claim(), "warm-ready"; got != want {
+		t.Errorf("claim() = %q, want %q", got, want)
+	}
+	if got := pool.claim(); got != "" {
+		t.Errorf("claim() after pool is drained = %q, want \"\"", got)
+	}
+}
+
+func TestWarmPoolGC(t *testing.T) {
+	pool := newWarmPool(&options{WarmPoolDir: t.TempDir(), WarmPoolSize: 2}, "k8s.io")
+	statuses := map[string]string{
+		"warm-ready1":  "created",
+		"warm-ready2":  "created",
+		"warm-ready3":  "created",
+		"warm-stopped": "stopped",
+		"warm-gone":    "",
+	}
+	pool.status = func(_ context.Context, id string) (string, error) {
+		if statuses[id] == "" {
+			return "", fmt.Errorf("container %q not found", id)
+		}
+		return statuses[id], nil
+	}
+	var destroyed []string
+	pool.destroy = func(_ context.Context, id string) error {
+		destroyed = append(destroyed, id)
+		return nil
+	}
+
+	for id := range statuses {
+		if err := os.MkdirAll(filepath.Join(pool.dir, id), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(pool.dir, id, warmReadyFile), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"warm-booting", "warm-stale"} {
+		if err := os.MkdirAll(filepath.Join(pool.dir, id), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := time.Now().Add(-2 * warmStaleTimeout)
+	if err := os.Chtimes(filepath.Join(pool.dir, "warm-stale"), stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	ready, err := pool.gc(context.Background())
+	if err != nil {
+		t.Fatalf("gc() failed: %v", err)
+	}
+	if ready != pool.size {
+		t.Errorf("gc() = %d ready sandboxes, want %d", ready, pool.size)
+	}
+	slices.Sort(destroyed)
+	if want := []string{"warm-gone", "warm-ready3", "warm-stale", "warm-stopped"}; !slices.Equal(destroyed, want) {
+		t.Errorf("gc() destroyed %v, want %v", destroyed, want)
+	}
+	entries, err := os.ReadDir(pool.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	for _, e := range entries {
+		left = append(left, e.Name())
+	}
+	if want := []string{"warm-booting", "warm-ready1", "warm-ready2"}; !slices.Equal(left, want) {
+		t.Errorf("gc() left %v in the pool, want %v", left, want)
+	}
+}
+
+func TestAdoptable(t *testing.T) {
+	for _, test := range []struct {
+		annotations map[string]string
+		want        bool
+	}{
+		{want: true},
+		{annotations: map[string]string{"io.kubernetes.cri.container-type": "sandbox"}, want: true},
+		{annotations: map[string]string{"dev.gvisor.flag.debug": "true"}, want: false},
+		{annotations: map[string]string{"dev.gvisor.bundle.experimental": "true"}, want: false},
+	} {
+		if got := adoptable(&specs.Spec{Annotations: test.annotations}); got != test.want {
+			t.Errorf("adoptable(%v) = %t, want %t", test.annotations, got, test.want)
+		}
+	}
+}
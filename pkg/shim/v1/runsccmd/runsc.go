@@ -177,6 +177,24 @@ func (r *Runsc) Create(context context.Context, id, bundle string, opts *CreateO
 	return err
 }
 
+// Adopt renames the blank sandbox blankID to id and binds it to the container
+// in bundle. The blank sandbox must have been created but not started.
+func (r *Runsc) Adopt(context context.Context, blankID, id, bundle string, opts *CreateOpts) error {
+	args := []string{"adopt", "--bundle", bundle}
+	if opts != nil && opts.PidFile != "" {
+		abs, err := filepath.Abs(opts.PidFile)
+		if err != nil {
+			return err
+		}
+		args = append(args, "--pid-file", abs)
+	}
+	var cio runc.IO
+	if opts != nil {
+		cio = opts.IO
+	}
+	return r.start(context, cio, r.command(context, append(args, blankID, id)...))
+}
+
 // Pause will pause a running container.
 func (r *Runsc) Pause(context context.Context, id string) error {
 	if out, _, err := cmdOutput(r.command(context, "pause", id), true); err != nil {
@@ -363,8 +363,15 @@ func (od *objectDecoder) load(slot int, objPtr reflect.Value, wait bool, fn func
 	// to match what's expected by the decoder. The "slot" parameter here
 	// is in terms of the local type, where the fields in the encoded
 	// object are in terms of the wire object's type, which might be in a
-	// different order (but will have the same fields).
-	v := *od.encoded.Field(od.rte.FieldOrder[slot])
+	// different order (but will have the same fields, unless the type
+	// was migrated).
+	idx := od.rte.FieldOrder[slot]
+	if idx < 0 {
+		// The field was added after the object was saved, leave it as
+		// the zero value.
+		return
+	}
+	v := *od.encoded.Field(idx)
 	od.ds.decodeObject(od.ods, objPtr.Elem(), v)
 	if wait {
 		// Mark this individual object a blocker.
@@ -410,6 +417,11 @@ func (ds *decodeState) decodeStruct(ods *objectDecodeState, obj reflect.Value, e
 		// implement the saver/loader interfaces.
 		sl.StateLoad(ds.ctx, Source{internal: od})
 	}
+	for _, m := range rte.Migrations {
+		if m.Migrate != nil {
+			m.Migrate(obj.Addr().Interface(), MigrationSource{internal: od})
+		}
+	}
 }
 
 // decodeMap decodes a map value.
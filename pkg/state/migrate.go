@@ -0,0 +1,208 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"context"
+	"reflect"
+)
+
+// Migration describes a change to the fields of a savable type, and allows
+// objects saved before the change to be loaded after it.
+//
+// Fields are matched by name, so reordering fields doesn't require a
+// migration. Migrations are needed when fields are added, removed or renamed;
+// without them, loading fails with a field mismatch error.
+type Migration struct {
+	// Added are the fields added by the change. They are left as zero values
+	// when loading older objects, unless set by Migrate.
+	Added []string
+
+	// Removed are the fields removed by the change. Their saved values are
+	// discarded, but can be read by Migrate.
+	Removed []string
+
+	// Renamed maps the old names of renamed fields to their new names. The
+	// saved values of renamed fields are loaded into the new fields.
+	Renamed map[string]string
+
+	// Migrate is called with a pointer to each object saved before the change,
+	// after its StateLoad method. It must only be used to compute new fields
+	// from other fields, as other objects may not be loaded yet; use
+	// MigrationSource.AfterLoad for anything else. May be nil.
+	Migrate func(obj any, src MigrationSource)
+}
+
+// migrationDatabase maps type names to the migrations of the type, oldest
+// first.
+var migrationDatabase = map[string][]Migration{}
+
+// RegisterMigrations registers the migrations of t, which must be listed
+// oldest first. New migrations are appended to the list when the fields of t
+// change, so that objects saved by any earlier version can be loaded.
+//
+// This must be called on init and only done once per type.
+func RegisterMigrations(t Type, migrations ...Migration) {
+	name := t.StateTypeName()
+	if _, ok := migrationDatabase[name]; ok {
+		Failf("migrations of type %q registered twice", name)
+	}
+	// Check that the migrations are consistent with the current fields.
+	versions := migrationFields(t.StateFields(), migrations)
+	for v := range migrations {
+		// Duplicates in older versions are fields that were removed
+		// without being removed from the current fields.
+		assertValidType(name, versions[v])
+		m := &migrations[v]
+		for _, f := range m.Added {
+			if !containsField(versions[v+1], f) {
+				Failf("migration %d of type %q adds unknown field %q", v, name, f)
+			}
+		}
+		for _, f := range m.Renamed {
+			if !containsField(versions[v+1], f) {
+				Failf("migration %d of type %q renames to unknown field %q", v, name, f)
+			}
+		}
+	}
+	migrationDatabase[name] = migrations
+}
+
+// migrationFields returns the fields of a type at each version, given its
+// current fields and its migrations. Version v is the version before
+// migrations[v] is applied; the last version is the current one.
+func migrationFields(fields []string, migrations []Migration) [][]string {
+	versions := make([][]string, len(migrations)+1)
+	versions[len(migrations)] = fields
+	for v := len(migrations) - 1; v >= 0; v-- {
+		m := &migrations[v]
+		var prev []string
+		for _, f := range versions[v+1] {
+			if containsField(m.Added, f) {
+				continue
+			}
+			prev = append(prev, oldFieldName(m, f))
+		}
+		versions[v] = append(prev, m.Removed...)
+	}
+	return versions
+}
+
+// oldFieldName returns the name of field f before m was applied.
+func oldFieldName(m *Migration, f string) string {
+	for old, cur := range m.Renamed {
+		if cur == f {
+			return old
+		}
+	}
+	return f
+}
+
+func containsField(fields []string, f string) bool {
+	for _, other := range fields {
+		if other == f {
+			return true
+		}
+	}
+	return false
+}
+
+// sameFields returns true if a and b contain the same fields, in any order.
+// Neither contains duplicates.
+func sameFields(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, f := range a {
+		if !containsField(b, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupMigrations finds the version of type name whose fields are encoded,
+// and returns the field order mapping the current fields to the encoded ones
+// and the migrations to apply. A field order of -1 denotes a field that isn't
+// encoded. ok is false if no version matches.
+func lookupMigrations(name string, fields, encoded []string) (fieldOrder []int, migrations []Migration, ok bool) {
+	all, ok := migrationDatabase[name]
+	if !ok {
+		return nil, nil, false
+	}
+	versions := migrationFields(fields, all)
+	// Prefer the most recent version if several have the same fields, e.g.
+	// if a field was removed and then added back.
+	v := len(versions) - 1
+	for ; v >= 0; v-- {
+		if sameFields(versions[v], encoded) {
+			break
+		}
+	}
+	if v < 0 {
+		return nil, nil, false
+	}
+	migrations = all[v:]
+
+	fieldOrder = make([]int, len(fields))
+	for i, f := range fields {
+		fieldOrder[i] = -1
+		// Find the name of the field in the encoded version.
+		for j := len(migrations) - 1; j >= 0 && f != ""; j-- {
+			if containsField(migrations[j].Added, f) {
+				f = ""
+			} else {
+				f = oldFieldName(&migrations[j], f)
+			}
+		}
+		for j, other := range encoded {
+			if f != "" && f == other {
+				fieldOrder[i] = j
+				break
+			}
+		}
+	}
+	return fieldOrder, migrations, true
+}
+
+// MigrationSource is used by Migration.Migrate.
+type MigrationSource struct {
+	internal objectDecoder
+}
+
+// Load loads the saved value of field name into objPtr, and returns true. name
+// is the name of the field when the object was saved. If the object was saved
+// without the field, Load returns false.
+func (s MigrationSource) Load(name string, objPtr any) bool {
+	od := &s.internal
+	for i, f := range od.rte.EncodedFields {
+		if f == name {
+			od.ds.decodeObject(od.ods, reflect.ValueOf(objPtr).Elem(), *od.encoded.Field(i))
+			return true
+		}
+	}
+	return false
+}
+
+// AfterLoad schedules a function execution once the object is fully loaded.
+// See Source.AfterLoad.
+func (s MigrationSource) AfterLoad(fn func()) {
+	s.internal.afterLoad(fn)
+}
+
+// Context returns the context object provided at load time.
+func (s MigrationSource) Context() context.Context {
+	return s.internal.ds.ctx
+}
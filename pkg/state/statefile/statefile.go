@@ -180,6 +180,13 @@ func writeMetadataLen(w io.Writer, val uint64) error {
 //
 // Note that the returned WriteCloser must be closed.
 func NewWriter(w io.Writer, key []byte, metadata map[string]string) (io.WriteCloser, error) {
+	return NewWriterWithWorkers(w, key, metadata, 0)
+}
+
+// NewWriterWithWorkers is like NewWriter, but a compressed statefile is
+// compressed by the given number of concurrent goroutines. If workers is 0, a
+// default based on GOMAXPROCS is used.
+func NewWriterWithWorkers(w io.Writer, key []byte, metadata map[string]string, workers int) (io.WriteCloser, error) {
 	if metadata == nil {
 		metadata = make(map[string]string)
 	}
@@ -242,7 +249,7 @@ func NewWriter(w io.Writer, key []byte, metadata map[string]string) (io.WriteClo
 	// gain in restore latency reduction, while incurring much more CPU usage at
 	// save time.
 	if compression == CompressionLevelFlateBestSpeed {
-		return compressio.NewWriter(w, key, stateFileChunkSize, flate.BestSpeed)
+		return compressio.NewWriterWithWorkers(w, key, stateFileChunkSize, flate.BestSpeed, workers)
 	}
 
 	return compressio.NewSimpleWriter(w, key, stateFileChunkSize), nil
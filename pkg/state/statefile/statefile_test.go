@@ -179,6 +179,35 @@ func TestStatefile(t *testing.T) {
 
 const benchmarkDataSize = 100 * 1024 * 1024
 
+func TestWriterWithWorkers(t *testing.T) {
+	data := make([]byte, 10*stateFileChunkSize)
+	rand.Read(data)
+	for _, workers := range []int{1, 4} {
+		var buf bytes.Buffer
+		w, err := NewWriterWithWorkers(&buf, nil, nil, workers)
+		if err != nil {
+			t.Fatalf("NewWriterWithWorkers(%d): %v", workers, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("Write(): %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close(): %v", err)
+		}
+		r, _, err := NewReader(io.NopCloser(&buf), nil)
+		if err != nil {
+			t.Fatalf("NewReader(): %v", err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("ReadAll(): %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("statefile written with %d workers read back %d bytes that differ from the %d bytes written", workers, len(got), len(data))
+		}
+	}
+}
+
 func benchmark(b *testing.B, size int, write bool, compressible bool) {
 	b.StopTimer()
 	b.SetBytes(benchmarkDataSize)
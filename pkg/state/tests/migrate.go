@@ -0,0 +1,187 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/state"
+	"gvisor.dev/gvisor/pkg/state/wire"
+)
+
+// migratedStructV1 is the first version of migratedStruct.
+//
+// +stateify savable
+type migratedStructV1 struct {
+	a int64
+	b int64
+	c int64
+}
+
+// migratedStructV2 is the second version of migratedStruct, where b was
+// renamed to b2.
+//
+// +stateify savable
+type migratedStructV2 struct {
+	a  int64
+	b2 int64
+	c  int64
+}
+
+// migratedStruct is the current version, where c was replaced by d = 2*c.
+//
+// +stateify savable
+type migratedStruct struct {
+	a  int64
+	b2 int64
+	d  int64
+}
+
+// migratedStructContainer is saved by all versions, and contains the migrated
+// type.
+//
+// +stateify savable
+type migratedStructContainer struct {
+	v *migratedStruct
+	w any
+}
+
+// migratedStructContainerV1 is migratedStructContainer as saved by the first
+// version.
+//
+// +stateify savable
+type migratedStructContainerV1 struct {
+	v *migratedStructV1
+	w any
+}
+
+// unmigratedStructV1 is inner with a field added, but no registered migration.
+//
+// +stateify savable
+type unmigratedStructV1 struct {
+	v int64
+	w int64
+}
+
+func init() {
+	state.RegisterMigrations((*migratedStruct)(nil),
+		state.Migration{
+			Renamed: map[string]string{"b": "b2"},
+		},
+		state.Migration{
+			Added:   []string{"d"},
+			Removed: []string{"c"},
+			Migrate: func(obj any, src state.MigrationSource) {
+				var c int64
+				if src.Load("c", &c) {
+					obj.(*migratedStruct).d = 2 * c
+				}
+			},
+		},
+	)
+}
+
+// typeName returns the name of the type of v in statefiles.
+func typeName(v any) string {
+	typ := reflect.TypeOf(v)
+	if typ.Kind() != reflect.Ptr {
+		typ = reflect.PtrTo(typ)
+	}
+	return reflect.Zero(typ).Interface().(state.Type).StateTypeName()
+}
+
+// renameTypes rewrites a statefile so that types saved under the keys of
+// renames are named after the values instead, i.e. as if the objects were
+// saved by an older version where the types had these names.
+func renameTypes(data []byte, renames map[string]string) []byte {
+	r := wire.Reader{Reader: bytes.NewReader(data)}
+	var buf bytes.Buffer
+	w := wire.Writer{Writer: &buf}
+
+	numObjects, object, err := state.ReadHeader(&r)
+	if err != nil {
+		panic(fmt.Sprintf("reading header: %v", err))
+	}
+	if err := state.WriteHeader(&w, numObjects, object); err != nil {
+		panic(fmt.Sprintf("writing header: %v", err))
+	}
+	for i := uint64(0); i < numObjects; {
+		switch obj := wire.Load(&r).(type) {
+		case *wire.Type:
+			if name, ok := renames[obj.Name]; ok {
+				obj.Name = name
+			}
+			wire.Save(&w, obj)
+		case wire.Uint:
+			wire.Save(&w, obj)
+			wire.Save(&w, wire.Load(&r))
+			i++
+		default:
+			panic(fmt.Sprintf("wanted type or object ID, got %T", obj))
+		}
+	}
+	return buf.Bytes()
+}
+
+// migrationTestCase is a test of loading an object saved by an older version.
+type migrationTestCase struct {
+	// old is the object as saved by the older version.
+	old any
+
+	// want is the object expected to be loaded. If shouldFail is set, only
+	// its type is used.
+	want any
+
+	// shouldFail is set if loading is expected to fail.
+	shouldFail bool
+}
+
+// runMigrationTestCases saves the old object of each test case, and checks
+// that it is loaded as the wanted object. Types are renamed according to
+// renames in between, see renameTypes.
+func runMigrationTestCases(t *testing.T, prefix string, renames map[string]string, cases []migrationTestCase) {
+	t.Helper()
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%s%d", prefix, i), func(t *testing.T) {
+			saveBuffer := &bytes.Buffer{}
+			saveObjectPtr := reflect.New(reflect.TypeOf(tc.old))
+			saveObjectPtr.Elem().Set(reflect.ValueOf(tc.old))
+			if _, err := state.Save(context.Background(), saveBuffer, saveObjectPtr.Interface()); err != nil {
+				t.Fatalf("Save failed unexpectedly: %v", err)
+			}
+			data := renameTypes(saveBuffer.Bytes(), renames)
+
+			loadObjectPtr := reflect.New(reflect.TypeOf(tc.want))
+			_, err := state.Load(context.Background(), bytes.NewReader(data), loadObjectPtr.Interface())
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatalf("Load succeeded unexpectedly, loaded: %#v", loadObjectPtr.Elem().Interface())
+				}
+				t.Logf("Load failed as expected: %v", err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load failed unexpectedly: %v", err)
+			}
+			if got := loadObjectPtr.Elem().Interface(); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("Objects differ:\n\tgot:  %#v\n\twant: %#v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	renames := map[string]string{
+		typeName(migratedStructV1{}):          typeName(migratedStruct{}),
+		typeName(migratedStructV2{}):          typeName(migratedStruct{}),
+		typeName(migratedStructContainerV1{}): typeName(migratedStructContainer{}),
+	}
+	runMigrationTestCases(t, "migrations", renames, []migrationTestCase{
+		// Current version.
+		{
+			old:  migratedStruct{a: 1, b2: 2, d: 3},
+			want: migratedStruct{a: 1, b2: 2, d: 3},
+		},
+		// Older versions.
+		{
+			old:  migratedStructV1{a: 1, b: 2, c: 3},
+			want: migratedStruct{a: 1, b2: 2, d: 6},
+		},
+		{
+			old:  migratedStructV2{a: 1, b2: 2, c: 3},
+			want: migratedStruct{a: 1, b2: 2, d: 6},
+		},
+		// Migrated objects referenced by other objects.
+		{
+			old: migratedStructContainerV1{
+				v: &migratedStructV1{a: 1, b: 2, c: 3},
+				w: &migratedStructV1{a: 4, b: 5, c: 6},
+			},
+			want: migratedStructContainer{
+				v: &migratedStruct{a: 1, b2: 2, d: 6},
+				w: &migratedStruct{a: 4, b2: 5, d: 12},
+			},
+		},
+	})
+}
+
+func TestMigrationsMissing(t *testing.T) {
+	renames := map[string]string{
+		typeName(unmigratedStructV1{}): typeName(inner{}),
+	}
+	runMigrationTestCases(t, "missing", renames, []migrationTestCase{
+		{
+			old:        unmigratedStructV1{v: 1, w: 2},
+			want:       inner{},
+			shouldFail: true,
+		},
+	})
+}
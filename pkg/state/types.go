@@ -54,6 +54,12 @@ type reconciledTypeEntry struct {
 	wire.Type
 	LocalType  reflect.Type
 	FieldOrder []int
+
+	// EncodedFields and Migrations are set if the type was saved by an
+	// older version and must be migrated. EncodedFields are the fields of
+	// the saved type. FieldOrder is -1 for fields that aren't saved.
+	EncodedFields []string
+	Migrations    []Migration
 }
 
 // typeEncodeDatabase is an internal TypeInfo database for encoding.
@@ -245,8 +251,7 @@ func (tbd *typeDecodeDatabase) Lookup(id typeID, typ reflect.Type) *reconciledTy
 	// field name does not match, it will be caught in the general purpose
 	// code below.
 	if len(fields) != len(pending.Fields) {
-		Failf("type %q contains different fields: %v (decode) and %v (encode)",
-			name, fields, pending.Fields)
+		return tbd.migrate(id, rte, pending)
 	}
 	if len(fields) == 0 {
 		tbd.byID[id-1] = rte // Save.
@@ -277,9 +282,9 @@ func (tbd *typeDecodeDatabase) Lookup(id typeID, typ reflect.Type) *reconciledTy
 			}
 		}
 		if fieldOrder[i] == -1 {
-			// The type name matches but we are lacking some common fields.
-			Failf("type %q has mismatched fields: %v (decode) and %v (encode)",
-				name, fields, pending.Fields)
+			// The type name matches but we are lacking some common
+			// fields. The type may have been saved by an older version.
+			return tbd.migrate(id, rte, pending)
 		}
 	}
 	// The type has been reeconciled.
@@ -288,6 +293,21 @@ func (tbd *typeDecodeDatabase) Lookup(id typeID, typ reflect.Type) *reconciledTy
 	return rte
 }
 
+// migrate reconciles rte with the type saved by an older version, pending,
+// using the migrations registered for the type.
+func (tbd *typeDecodeDatabase) migrate(id typeID, rte *reconciledTypeEntry, pending *wire.Type) *reconciledTypeEntry {
+	fieldOrder, migrations, ok := lookupMigrations(rte.Name, rte.Fields, pending.Fields)
+	if !ok {
+		Failf("type %q has mismatched fields: %v (decode) and %v (encode), and no registered migration applies",
+			rte.Name, rte.Fields, pending.Fields)
+	}
+	rte.FieldOrder = fieldOrder
+	rte.EncodedFields = pending.Fields
+	rte.Migrations = migrations
+	tbd.byID[id-1] = rte
+	return rte
+}
+
 // interfaceType defines all interfaces.
 const interfaceType = "interface"
 
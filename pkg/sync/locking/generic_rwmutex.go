@@ -87,6 +87,13 @@ func (m *RWMutex) RLockBypass() {
 	m.mu.RLock()
 }
 
+// TryRLockBypass tries to lock m for reading without executing the validator.
+// It returns true if it succeeds and false otherwise.
+// +checklocksignore
+func (m *RWMutex) TryRLockBypass() bool {
+	return m.mu.TryRLock()
+}
+
 // RUnlockBypass undoes a single RLockBypass call.
 // +checklocksignore
 func (m *RWMutex) RUnlockBypass() {
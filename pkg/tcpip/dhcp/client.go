@@ -0,0 +1,384 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	// DefaultRetransmitTimeout is the default initial retransmission timeout,
+	// as per RFC 2131 section 4.1.
+	DefaultRetransmitTimeout = 4 * time.Second
+
+	// maxRetransmitTimeout is the maximum retransmission timeout, as per RFC
+	// 2131 section 4.1.
+	maxRetransmitTimeout = 64 * time.Second
+
+	// maxMessageSize is the largest message the clients accept.
+	maxMessageSize = 1500
+
+	// minLeaseTime is the shortest lease lifetime the clients accept.
+	// Shorter lifetimes are raised to it, so that a misconfigured server
+	// can't make the clients renew their lease in a busy loop.
+	minLeaseTime = time.Minute
+)
+
+// errNak is returned when the server refuses a request.
+var errNak = errors.New("request refused by server")
+
+// Lease is a DHCPv4 lease.
+type Lease struct {
+	// Address is the leased address, with the prefix length of its subnet.
+	Address tcpip.AddressWithPrefix
+
+	// Server is the address of the server that granted the lease.
+	Server tcpip.Address
+
+	// Routers are the default gateways, in order of preference.
+	Routers []tcpip.Address
+
+	// DNS are the DNS servers, in order of preference.
+	DNS []tcpip.Address
+
+	// Start is when the lease was requested. Lease times are relative to it.
+	Start time.Time
+
+	// LeaseTime is the duration of the lease.
+	LeaseTime time.Duration
+
+	// RenewalTime is when the client starts renewing the lease (T1).
+	RenewalTime time.Duration
+
+	// RebindingTime is when the client starts rebinding the lease (T2).
+	RebindingTime time.Duration
+}
+
+// Client is a DHCPv4 client for a single NIC.
+type Client struct {
+	stack      *stack.Stack
+	nicID      tcpip.NICID
+	linkAddr   tcpip.LinkAddress
+	retransmit time.Duration
+}
+
+// NewClient returns a DHCPv4 client for the NIC with the given ID and link
+// address. retransmit is the initial retransmission timeout.
+func NewClient(s *stack.Stack, nicID tcpip.NICID, linkAddr tcpip.LinkAddress, retransmit time.Duration) *Client {
+	return &Client{
+		stack:      s,
+		nicID:      nicID,
+		linkAddr:   linkAddr,
+		retransmit: retransmit,
+	}
+}
+
+// open returns a UDP socket bound to the client port of the NIC.
+func (c *Client) open() (*gonet.UDPConn, error) {
+	var wq waiter.Queue
+	ep, err := c.stack.NewEndpoint(udp.ProtocolNumber, ipv4.ProtocolNumber, &wq)
+	if err != nil {
+		return nil, fmt.Errorf("NewEndpoint(): %s", err)
+	}
+	ep.SocketOptions().SetBroadcast(true)
+	if err := ep.SocketOptions().SetBindToDevice(int32(c.nicID)); err != nil {
+		ep.Close()
+		return nil, fmt.Errorf("SetBindToDevice(%d): %s", c.nicID, err)
+	}
+	if err := ep.Bind(tcpip.FullAddress{NIC: c.nicID, Port: ClientPort}); err != nil {
+		ep.Close()
+		return nil, fmt.Errorf("Bind(): %s", err)
+	}
+	return gonet.NewUDPConn(&wq, ep), nil
+}
+
+// newMessage returns a client message of the given type.
+func (c *Client) newMessage(typ messageType, xid uint32) *message {
+	return &message{
+		op:     opRequest,
+		xid:    xid,
+		chaddr: c.linkAddr,
+		options: []option{
+			{code: optMessageType, body: []byte{byte(typ)}},
+			{code: optClientID, body: append([]byte{byte(header.ARPHardwareEther)}, c.linkAddr...)},
+		},
+	}
+}
+
+// exchange sends m to dst and returns the first reply for which accept
+// returns true. Requests are retransmitted with exponential backoff, as per
+// RFC 2131 section 4.1, until ctx is done.
+func (c *Client) exchange(ctx context.Context, conn *gonet.UDPConn, m *message, dst tcpip.Address, accept func(*message) bool) (*message, error) {
+	b := m.marshal()
+	to := &net.UDPAddr{IP: net.IP(dst.AsSlice()), Port: ServerPort}
+	buf := make([]byte, maxMessageSize)
+	timeout := c.retransmit
+	for {
+		if _, err := conn.WriteTo(b, to); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		conn.SetReadDeadline(deadline)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				if ne, ok := err.(net.Error); ok && ne.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			reply, err := parseMessage(buf[:n])
+			if err != nil {
+				log.Debugf("dhcp: NIC %d: ignoring malformed message: %v", c.nicID, err)
+				continue
+			}
+			if reply.op != opReply || reply.xid != m.xid || reply.chaddr != c.linkAddr {
+				continue
+			}
+			if accept(reply) {
+				return reply, nil
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		timeout = min(2*timeout, maxRetransmitTimeout)
+	}
+}
+
+// Acquire obtains a new lease, as per RFC 2131 section 3.1. It retries until
+// ctx is done.
+func (c *Client) Acquire(ctx context.Context) (Lease, error) {
+	// Until an address is leased, messages are sent from the unspecified
+	// address.
+	anyAddr := tcpip.ProtocolAddress{
+		Protocol:          ipv4.ProtocolNumber,
+		AddressWithPrefix: header.IPv4Any.WithPrefix(),
+	}
+	if err := c.stack.AddProtocolAddress(c.nicID, anyAddr, stack.AddressProperties{}); err != nil {
+		return Lease{}, fmt.Errorf("AddProtocolAddress(%d, %s): %s", c.nicID, anyAddr.AddressWithPrefix, err)
+	}
+	defer c.stack.RemoveAddress(c.nicID, anyAddr.AddressWithPrefix.Address)
+
+	conn, err := c.open()
+	if err != nil {
+		return Lease{}, err
+	}
+	defer conn.Close()
+
+	for {
+		xid := c.stack.InsecureRNG().Uint32()
+		start := time.Now()
+
+		discover := c.newMessage(msgDiscover, xid)
+		discover.broadcast = true
+		discover.options = append(discover.options, option{
+			code: optParamRequest,
+			body: []byte{byte(optSubnetMask), byte(optRouter), byte(optDNS), byte(optLeaseTime), byte(optRenewalTime), byte(optRebindingTime)},
+		})
+		offer, err := c.exchange(ctx, conn, discover, header.IPv4Broadcast, func(m *message) bool {
+			return m.messageType() == msgOffer && m.yiaddr != header.IPv4Any
+		})
+		if err != nil {
+			return Lease{}, fmt.Errorf("waiting for %s: %w", msgOffer, err)
+		}
+		serverID, ok := offer.addrOption(optServerID)
+		if !ok {
+			log.Warningf("dhcp: NIC %d: %s without server identifier", c.nicID, msgOffer)
+			continue
+		}
+
+		request := c.newMessage(msgRequest, xid)
+		request.broadcast = true
+		request.options = append(request.options,
+			option{code: optRequestedAddr, body: offer.yiaddr.AsSlice()},
+			option{code: optServerID, body: serverID.AsSlice()},
+			discover.options[len(discover.options)-1],
+		)
+		ack, err := c.exchange(ctx, conn, request, header.IPv4Broadcast, func(m *message) bool {
+			t := m.messageType()
+			return t == msgAck || t == msgNak
+		})
+		if err != nil {
+			return Lease{}, fmt.Errorf("waiting for %s: %w", msgAck, err)
+		}
+		if ack.messageType() == msgNak {
+			log.Infof("dhcp: NIC %d: %s for %s, restarting", c.nicID, msgNak, offer.yiaddr)
+			continue
+		}
+		lease, err := newLease(ack, start)
+		if err != nil {
+			log.Warningf("dhcp: NIC %d: %v", c.nicID, err)
+			continue
+		}
+		return lease, nil
+	}
+}
+
+// newLease returns the lease granted by ack.
+func newLease(ack *message, start time.Time) (Lease, error) {
+	lease := Lease{
+		Address: tcpip.AddressWithPrefix{
+			Address:   ack.yiaddr,
+			PrefixLen: header.IPv4AddressSizeBits,
+		},
+		Routers: ack.addrsOption(optRouter),
+		DNS:     ack.addrsOption(optDNS),
+		Start:   start,
+	}
+	if mask := ack.option(optSubnetMask); len(mask) == header.IPv4AddressSize {
+		lease.Address.PrefixLen = tcpip.MaskFromBytes(mask).Prefix()
+	}
+	var ok bool
+	if lease.Server, ok = ack.addrOption(optServerID); !ok {
+		return Lease{}, fmt.Errorf("%s without server identifier", msgAck)
+	}
+	if lease.LeaseTime, ok = ack.durationOption(optLeaseTime); !ok {
+		return Lease{}, fmt.Errorf("%s without lease time", msgAck)
+	}
+	lease.RenewalTime, _ = ack.durationOption(optRenewalTime)
+	lease.RebindingTime, _ = ack.durationOption(optRebindingTime)
+	clampLeaseTimes(&lease.LeaseTime, &lease.RenewalTime, &lease.RebindingTime)
+	return lease, nil
+}
+
+// clampLeaseTimes raises lifetime to at least minLeaseTime, and replaces the
+// renewal (T1) and rebinding (T2) times if they are unset or inconsistent
+// with the defaults of RFC 2131 section 4.4.5, so that
+// 0 < renewal <= rebinding <= lifetime.
+func clampLeaseTimes(lifetime, renewal, rebinding *time.Duration) {
+	*lifetime = max(*lifetime, minLeaseTime)
+	if *renewal <= 0 || *renewal > *lifetime {
+		*renewal = *lifetime / 2
+	}
+	if *rebinding < *renewal || *rebinding > *lifetime {
+		*rebinding = max(*lifetime*7/8, *renewal)
+	}
+}
+
+// extend asks for an extension of lease, as per RFC 2131 section 4.4.5. The
+// request is unicast to the server that granted the lease when renewing, or
+// broadcast when rebinding.
+func (c *Client) extend(ctx context.Context, lease Lease, rebinding bool) (Lease, error) {
+	conn, err := c.open()
+	if err != nil {
+		return Lease{}, err
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	request := c.newMessage(msgRequest, c.stack.InsecureRNG().Uint32())
+	request.ciaddr = lease.Address.Address
+	dst := lease.Server
+	if rebinding {
+		dst = header.IPv4Broadcast
+	}
+	ack, err := c.exchange(ctx, conn, request, dst, func(m *message) bool {
+		t := m.messageType()
+		return t == msgAck || t == msgNak
+	})
+	if err != nil {
+		return Lease{}, err
+	}
+	if ack.messageType() == msgNak {
+		return Lease{}, errNak
+	}
+	if ack.yiaddr != lease.Address.Address {
+		return Lease{}, fmt.Errorf("%s for %s, want %s", msgAck, ack.yiaddr, lease.Address.Address)
+	}
+	return newLease(ack, start)
+}
+
+// Release gives up lease, as per RFC 2131 section 4.4.6.
+func (c *Client) Release(lease Lease) error {
+	conn, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	release := c.newMessage(msgRelease, c.stack.InsecureRNG().Uint32())
+	release.ciaddr = lease.Address.Address
+	release.options = append(release.options, option{code: optServerID, body: lease.Server.AsSlice()})
+	_, err = conn.WriteTo(release.marshal(), &net.UDPAddr{IP: net.IP(lease.Server.AsSlice()), Port: ServerPort})
+	return err
+}
+
+// Run maintains lease until ctx is done, renewing it before it expires and
+// acquiring a new one if it can't be renewed. onLease is called whenever the
+// lease changes; old is empty when a new lease is acquired and new is empty
+// when the lease expired.
+func (c *Client) Run(ctx context.Context, lease Lease, onLease func(old, new Lease)) {
+	for {
+		if !sleepUntil(ctx, lease.Start.Add(lease.RenewalTime)) {
+			return
+		}
+		next, err := c.extendUntil(ctx, lease, false /* rebinding */, lease.Start.Add(lease.RebindingTime))
+		if err != nil && !errors.Is(err, errNak) {
+			next, err = c.extendUntil(ctx, lease, true /* rebinding */, lease.Start.Add(lease.LeaseTime))
+		}
+		if err == nil {
+			onLease(lease, next)
+			lease = next
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Infof("dhcp: NIC %d: lease for %s lost: %v", c.nicID, lease.Address, err)
+		onLease(lease, Lease{})
+		if lease, err = c.Acquire(ctx); err != nil {
+			return
+		}
+		onLease(Lease{}, lease)
+	}
+}
+
+// extendUntil calls extend, giving up at deadline.
+func (c *Client) extendUntil(ctx context.Context, lease Lease, rebinding bool, deadline time.Time) (Lease, error) {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	return c.extend(ctx, lease, rebinding)
+}
+
+// sleepUntil waits until t and returns true, or returns false if ctx is done
+// first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
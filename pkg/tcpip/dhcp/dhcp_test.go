@@ -0,0 +1,319 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
+	"gvisor.dev/gvisor/pkg/tcpip/link/pipe"
+	"gvisor.dev/gvisor/pkg/tcpip/network/arp"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	nicID          = 1
+	clientLinkAddr = tcpip.LinkAddress("\x02\x00\x00\x00\x00\x01")
+	serverLinkAddr = tcpip.LinkAddress("\x02\x00\x00\x00\x00\x02")
+	leaseTime      = time.Hour
+)
+
+var (
+	serverAddr = tcpip.AddrFrom4([4]byte{192, 168, 0, 1})
+	leasedAddr = tcpip.AddrFrom4([4]byte{192, 168, 0, 10})
+	dnsAddr    = tcpip.AddrFrom4([4]byte{192, 168, 0, 53})
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	m := &message{
+		op:        opReply,
+		xid:       0x12345678,
+		secs:      3,
+		broadcast: true,
+		ciaddr:    header.IPv4Any,
+		yiaddr:    leasedAddr,
+		siaddr:    serverAddr,
+		giaddr:    header.IPv4Any,
+		chaddr:    clientLinkAddr,
+		options: []option{
+			{code: optMessageType, body: []byte{byte(msgOffer)}},
+			{code: optServerID, body: serverAddr.AsSlice()},
+			{code: optLeaseTime, body: durationBody(leaseTime)},
+		},
+	}
+	got, err := parseMessage(m.marshal())
+	if err != nil {
+		t.Fatalf("parseMessage(_): %v", err)
+	}
+	if diff := cmp.Diff(m, got, cmp.AllowUnexported(message{}, option{})); diff != "" {
+		t.Errorf("parseMessage(m.marshal()) mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := got.messageType(), msgOffer; got != want {
+		t.Errorf("got messageType() = %s, want = %s", got, want)
+	}
+	if got, ok := got.durationOption(optLeaseTime); !ok || got != leaseTime {
+		t.Errorf("got durationOption(optLeaseTime) = (%s, %t), want = (%s, true)", got, ok, leaseTime)
+	}
+}
+
+func TestParseMessageErrors(t *testing.T) {
+	valid := (&message{op: opReply, chaddr: clientLinkAddr}).marshal()
+
+	badCookie := bytes.Clone(valid)
+	badCookie[headerLen] = 0
+
+	truncatedOption := append(bytes.Clone(valid[:len(valid)-1]), byte(optRouter), 8, 1, 2, 3, 4)
+
+	for _, test := range []struct {
+		name string
+		b    []byte
+	}{
+		{name: "short", b: valid[:headerLen]},
+		{name: "bad cookie", b: badCookie},
+		{name: "truncated option", b: truncatedOption},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseMessage(test.b); err == nil {
+				t.Errorf("parseMessage(_) succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestMessageV6RoundTrip(t *testing.T) {
+	m := &messageV6{
+		typ: msgReply,
+		xid: [3]byte{1, 2, 3},
+		options: []optionV6{
+			{code: optServerIDV6, body: []byte{0, 3, 0, 1, 2, 0, 0, 0, 0, 2}},
+			{code: optIANA, body: ianaBody(nicID, time.Minute, 2*time.Minute, []optionV6{
+				{code: optIAAddr, body: iaAddrBody(tcpip.AddrFrom16([16]byte{0x20, 0x01, 15: 1}), 3*time.Minute, 4*time.Minute)},
+			})},
+		},
+	}
+	got, err := parseMessageV6(m.marshal())
+	if err != nil {
+		t.Fatalf("parseMessageV6(_): %v", err)
+	}
+	if diff := cmp.Diff(m, got, cmp.AllowUnexported(messageV6{}, optionV6{})); diff != "" {
+		t.Errorf("parseMessageV6(m.marshal()) mismatch (-want +got):\n%s", diff)
+	}
+
+	c := &ClientV6{nicID: nicID}
+	lease, err := c.leaseFromReply(got, time.Time{})
+	if err != nil {
+		t.Fatalf("leaseFromReply(_, _): %v", err)
+	}
+	want := LeaseV6{
+		Address:           tcpip.AddrFrom16([16]byte{0x20, 0x01, 15: 1}),
+		ServerID:          []byte{0, 3, 0, 1, 2, 0, 0, 0, 0, 2},
+		RenewalTime:       time.Minute,
+		RebindingTime:     2 * time.Minute,
+		PreferredLifetime: 3 * time.Minute,
+		ValidLifetime:     4 * time.Minute,
+	}
+	if diff := cmp.Diff(want, lease); diff != "" {
+		t.Errorf("leaseFromReply(_, _) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClampLeaseTimes(t *testing.T) {
+	for _, test := range []struct {
+		name                         string
+		lifetime, renewal, rebind    time.Duration
+		wantLifetime, wantT1, wantT2 time.Duration
+	}{
+		{
+			name:     "valid",
+			lifetime: time.Hour, renewal: 10 * time.Minute, rebind: 20 * time.Minute,
+			wantLifetime: time.Hour, wantT1: 10 * time.Minute, wantT2: 20 * time.Minute,
+		},
+		{
+			name:         "defaults",
+			lifetime:     time.Hour,
+			wantLifetime: time.Hour, wantT1: 30 * time.Minute, wantT2: 52*time.Minute + 30*time.Second,
+		},
+		{
+			name:         "zero lifetime",
+			wantLifetime: minLeaseTime, wantT1: minLeaseTime / 2, wantT2: minLeaseTime * 7 / 8,
+		},
+		{
+			name:     "times beyond lifetime",
+			lifetime: time.Hour, renewal: 2 * time.Hour, rebind: 3 * time.Hour,
+			wantLifetime: time.Hour, wantT1: 30 * time.Minute, wantT2: 52*time.Minute + 30*time.Second,
+		},
+		{
+			name:     "rebinding before renewal",
+			lifetime: time.Hour, renewal: 55 * time.Minute, rebind: 10 * time.Minute,
+			wantLifetime: time.Hour, wantT1: 55 * time.Minute, wantT2: 55 * time.Minute,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			lifetime, renewal, rebind := test.lifetime, test.renewal, test.rebind
+			clampLeaseTimes(&lifetime, &renewal, &rebind)
+			if lifetime != test.wantLifetime || renewal != test.wantT1 || rebind != test.wantT2 {
+				t.Errorf("got (%s, %s, %s), want = (%s, %s, %s)", lifetime, renewal, rebind, test.wantLifetime, test.wantT1, test.wantT2)
+			}
+		})
+	}
+}
+
+// newStack returns a stack with a single NIC connected to ep.
+func newStack(t *testing.T, ep *pipe.Endpoint) *stack.Stack {
+	t.Helper()
+	s := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol, arp.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{udp.NewProtocol},
+	})
+	t.Cleanup(s.Destroy)
+	if err := s.CreateNIC(nicID, ethernet.New(ep)); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", nicID, err)
+	}
+	return s
+}
+
+// serve runs a minimal DHCPv4 server on s that leases leasedAddr.
+func serve(t *testing.T, s *stack.Stack) {
+	t.Helper()
+	protocolAddr := tcpip.ProtocolAddress{
+		Protocol:          ipv4.ProtocolNumber,
+		AddressWithPrefix: tcpip.AddressWithPrefix{Address: serverAddr, PrefixLen: 24},
+	}
+	if err := s.AddProtocolAddress(nicID, protocolAddr, stack.AddressProperties{}); err != nil {
+		t.Fatalf("AddProtocolAddress(%d, %+v, {}): %s", nicID, protocolAddr, err)
+	}
+	s.SetRouteTable([]tcpip.Route{{Destination: protocolAddr.AddressWithPrefix.Subnet(), NIC: nicID}})
+
+	var wq waiter.Queue
+	ep, err := s.NewEndpoint(udp.ProtocolNumber, ipv4.ProtocolNumber, &wq)
+	if err != nil {
+		t.Fatalf("NewEndpoint(): %s", err)
+	}
+	ep.SocketOptions().SetBroadcast(true)
+	if err := ep.Bind(tcpip.FullAddress{NIC: nicID, Port: ServerPort}); err != nil {
+		t.Fatalf("Bind(): %s", err)
+	}
+	conn := gonet.NewUDPConn(&wq, ep)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, maxMessageSize)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			m, err := parseMessage(buf[:n])
+			if err != nil || m.op != opRequest {
+				continue
+			}
+			reply := &message{
+				op:     opReply,
+				xid:    m.xid,
+				yiaddr: leasedAddr,
+				chaddr: m.chaddr,
+				options: []option{
+					{code: optServerID, body: serverAddr.AsSlice()},
+					{code: optSubnetMask, body: []byte{255, 255, 255, 0}},
+					{code: optRouter, body: serverAddr.AsSlice()},
+					{code: optDNS, body: dnsAddr.AsSlice()},
+					{code: optLeaseTime, body: durationBody(leaseTime)},
+				},
+			}
+			switch m.messageType() {
+			case msgDiscover:
+				reply.options = append(reply.options, option{code: optMessageType, body: []byte{byte(msgOffer)}})
+			case msgRequest:
+				typ := msgAck
+				if requested, ok := m.addrOption(optRequestedAddr); ok && requested != leasedAddr {
+					typ = msgNak
+				}
+				reply.options = append(reply.options, option{code: optMessageType, body: []byte{byte(typ)}})
+			default:
+				continue
+			}
+			dst := header.IPv4Broadcast
+			if m.ciaddr != header.IPv4Any {
+				dst = m.ciaddr
+			}
+			conn.WriteTo(reply.marshal(), &net.UDPAddr{IP: net.IP(dst.AsSlice()), Port: ClientPort})
+		}
+	}()
+}
+
+func TestClient(t *testing.T) {
+	clientEP, serverEP := pipe.New(clientLinkAddr, serverLinkAddr, 1500)
+	clientStack := newStack(t, clientEP)
+	serve(t, newStack(t, serverEP))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := NewClient(clientStack, nicID, clientLinkAddr, 100*time.Millisecond)
+	lease, err := c.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire(_): %v", err)
+	}
+	want := Lease{
+		Address:       tcpip.AddressWithPrefix{Address: leasedAddr, PrefixLen: 24},
+		Server:        serverAddr,
+		Routers:       []tcpip.Address{serverAddr},
+		DNS:           []tcpip.Address{dnsAddr},
+		LeaseTime:     leaseTime,
+		RenewalTime:   leaseTime / 2,
+		RebindingTime: leaseTime * 7 / 8,
+	}
+	if diff := cmp.Diff(want, lease, cmpopts.IgnoreFields(Lease{}, "Start")); diff != "" {
+		t.Errorf("Acquire(_) mismatch (-want +got):\n%s", diff)
+	}
+
+	// The unspecified address is only used while acquiring a lease.
+	if addrs := clientStack.NICInfo()[nicID].ProtocolAddresses; len(addrs) != 0 {
+		t.Errorf("got NIC addresses = %v, want none", addrs)
+	}
+
+	protocolAddr := tcpip.ProtocolAddress{
+		Protocol:          ipv4.ProtocolNumber,
+		AddressWithPrefix: lease.Address,
+	}
+	if err := clientStack.AddProtocolAddress(nicID, protocolAddr, stack.AddressProperties{}); err != nil {
+		t.Fatalf("AddProtocolAddress(%d, %+v, {}): %s", nicID, protocolAddr, err)
+	}
+	clientStack.SetRouteTable([]tcpip.Route{{Destination: lease.Address.Subnet(), NIC: nicID}})
+
+	for _, rebinding := range []bool{false, true} {
+		renewed, err := c.extend(ctx, lease, rebinding)
+		if err != nil {
+			t.Fatalf("extend(_, _, %t): %v", rebinding, err)
+		}
+		if !renewed.Start.After(lease.Start) {
+			t.Errorf("got extend(_, _, %t).Start = %s, want after %s", rebinding, renewed.Start, lease.Start)
+		}
+		if renewed.Address != lease.Address {
+			t.Errorf("got extend(_, _, %t).Address = %s, want = %s", rebinding, renewed.Address, lease.Address)
+		}
+	}
+}
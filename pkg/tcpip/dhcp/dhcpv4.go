@@ -0,0 +1,264 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dhcp implements DHCPv4 (RFC 2131) and DHCPv6 (RFC 8415) clients
+// that acquire addresses for netstack interfaces.
+package dhcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+const (
+	// ServerPort is the DHCPv4 server port.
+	ServerPort = 67
+
+	// ClientPort is the DHCPv4 client port.
+	ClientPort = 68
+)
+
+// opCode is the BOOTP message op code.
+type opCode byte
+
+const (
+	opRequest opCode = 1
+	opReply   opCode = 2
+)
+
+// messageType is the DHCPv4 message type, option 53.
+type messageType byte
+
+const (
+	msgDiscover messageType = 1
+	msgOffer    messageType = 2
+	msgRequest  messageType = 3
+	msgDecline  messageType = 4
+	msgAck      messageType = 5
+	msgNak      messageType = 6
+	msgRelease  messageType = 7
+)
+
+// String implements fmt.Stringer.String.
+func (t messageType) String() string {
+	switch t {
+	case msgDiscover:
+		return "DHCPDISCOVER"
+	case msgOffer:
+		return "DHCPOFFER"
+	case msgRequest:
+		return "DHCPREQUEST"
+	case msgDecline:
+		return "DHCPDECLINE"
+	case msgAck:
+		return "DHCPACK"
+	case msgNak:
+		return "DHCPNAK"
+	case msgRelease:
+		return "DHCPRELEASE"
+	default:
+		return fmt.Sprintf("DHCP(%d)", byte(t))
+	}
+}
+
+// optionCode is a DHCPv4 option code, as per RFC 2132.
+type optionCode byte
+
+const (
+	optPad           optionCode = 0
+	optSubnetMask    optionCode = 1
+	optRouter        optionCode = 3
+	optDNS           optionCode = 6
+	optRequestedAddr optionCode = 50
+	optLeaseTime     optionCode = 51
+	optMessageType   optionCode = 53
+	optServerID      optionCode = 54
+	optParamRequest  optionCode = 55
+	optRenewalTime   optionCode = 58
+	optRebindingTime optionCode = 59
+	optClientID      optionCode = 61
+	optEnd           optionCode = 255
+)
+
+const (
+	// headerLen is the length of the fixed part of a BOOTP message, up to and
+	// excluding the magic cookie.
+	headerLen = 236
+
+	// broadcastFlag is the BOOTP flag that asks servers to broadcast replies.
+	broadcastFlag = 1 << 15
+)
+
+var magicCookie = [4]byte{99, 130, 83, 99}
+
+// option is a DHCPv4 option.
+type option struct {
+	code optionCode
+	body []byte
+}
+
+// message is a DHCPv4 message.
+type message struct {
+	op        opCode
+	xid       uint32
+	secs      uint16
+	broadcast bool
+	ciaddr    tcpip.Address
+	yiaddr    tcpip.Address
+	siaddr    tcpip.Address
+	giaddr    tcpip.Address
+	chaddr    tcpip.LinkAddress
+	options   []option
+}
+
+// putAddr writes a to b, or zeroes if a is not an IPv4 address.
+func putAddr(b []byte, a tcpip.Address) {
+	if a.BitLen() != header.IPv4AddressSizeBits {
+		clear(b[:header.IPv4AddressSize])
+		return
+	}
+	copy(b, a.AsSlice())
+}
+
+// marshal returns the wire representation of m.
+func (m *message) marshal() []byte {
+	n := headerLen + len(magicCookie) + 1
+	for _, o := range m.options {
+		n += 2 + len(o.body)
+	}
+	b := make([]byte, n)
+	b[0] = byte(m.op)
+	b[1] = byte(header.ARPHardwareEther)
+	b[2] = byte(len(m.chaddr))
+	binary.BigEndian.PutUint32(b[4:], m.xid)
+	binary.BigEndian.PutUint16(b[8:], m.secs)
+	if m.broadcast {
+		binary.BigEndian.PutUint16(b[10:], broadcastFlag)
+	}
+	putAddr(b[12:], m.ciaddr)
+	putAddr(b[16:], m.yiaddr)
+	putAddr(b[20:], m.siaddr)
+	putAddr(b[24:], m.giaddr)
+	copy(b[28:44], m.chaddr)
+	copy(b[headerLen:], magicCookie[:])
+	off := headerLen + len(magicCookie)
+	for _, o := range m.options {
+		b[off] = byte(o.code)
+		b[off+1] = byte(len(o.body))
+		copy(b[off+2:], o.body)
+		off += 2 + len(o.body)
+	}
+	b[off] = byte(optEnd)
+	return b
+}
+
+// parseMessage parses a DHCPv4 message.
+func parseMessage(b []byte) (*message, error) {
+	if len(b) < headerLen+len(magicCookie) {
+		return nil, fmt.Errorf("message too short: %d bytes", len(b))
+	}
+	if [4]byte(b[headerLen:]) != magicCookie {
+		return nil, fmt.Errorf("bad magic cookie %v", b[headerLen:headerLen+len(magicCookie)])
+	}
+	hlen := int(b[2])
+	if hlen > 16 {
+		return nil, fmt.Errorf("bad hardware address length %d", hlen)
+	}
+	m := &message{
+		op:        opCode(b[0]),
+		xid:       binary.BigEndian.Uint32(b[4:]),
+		secs:      binary.BigEndian.Uint16(b[8:]),
+		broadcast: binary.BigEndian.Uint16(b[10:])&broadcastFlag != 0,
+		ciaddr:    tcpip.AddrFrom4Slice(b[12:16]),
+		yiaddr:    tcpip.AddrFrom4Slice(b[16:20]),
+		siaddr:    tcpip.AddrFrom4Slice(b[20:24]),
+		giaddr:    tcpip.AddrFrom4Slice(b[24:28]),
+		chaddr:    tcpip.LinkAddress(b[28 : 28+hlen]),
+	}
+	opts := b[headerLen+len(magicCookie):]
+	for len(opts) > 0 {
+		code := optionCode(opts[0])
+		if code == optEnd {
+			break
+		}
+		if code == optPad {
+			opts = opts[1:]
+			continue
+		}
+		if len(opts) < 2 || len(opts) < 2+int(opts[1]) {
+			return nil, fmt.Errorf("truncated option %d", code)
+		}
+		m.options = append(m.options, option{code: code, body: opts[2 : 2+opts[1]]})
+		opts = opts[2+opts[1]:]
+	}
+	return m, nil
+}
+
+// option returns the body of the first option with the given code, or nil.
+func (m *message) option(code optionCode) []byte {
+	for _, o := range m.options {
+		if o.code == code {
+			return o.body
+		}
+	}
+	return nil
+}
+
+// messageType returns the type of m, or 0 if m has no type.
+func (m *message) messageType() messageType {
+	if b := m.option(optMessageType); len(b) == 1 {
+		return messageType(b[0])
+	}
+	return 0
+}
+
+// addrOption returns the IPv4 address in the given option.
+func (m *message) addrOption(code optionCode) (tcpip.Address, bool) {
+	b := m.option(code)
+	if len(b) != header.IPv4AddressSize {
+		return tcpip.Address{}, false
+	}
+	return tcpip.AddrFrom4Slice(b), true
+}
+
+// addrsOption returns the list of IPv4 addresses in the given option.
+func (m *message) addrsOption(code optionCode) []tcpip.Address {
+	b := m.option(code)
+	var addrs []tcpip.Address
+	for len(b) >= header.IPv4AddressSize {
+		addrs = append(addrs, tcpip.AddrFrom4Slice(b[:header.IPv4AddressSize]))
+		b = b[header.IPv4AddressSize:]
+	}
+	return addrs
+}
+
+// durationOption returns the duration in seconds in the given option.
+func (m *message) durationOption(code optionCode) (time.Duration, bool) {
+	b := m.option(code)
+	if len(b) != 4 {
+		return 0, false
+	}
+	return time.Duration(binary.BigEndian.Uint32(b)) * time.Second, true
+}
+
+// durationBody returns the option body for a duration in seconds.
+func durationBody(d time.Duration) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(d/time.Second))
+	return b[:]
+}
@@ -0,0 +1,528 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv6"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	// ServerPortV6 is the DHCPv6 server and relay agent port.
+	ServerPortV6 = 547
+
+	// ClientPortV6 is the DHCPv6 client port.
+	ClientPortV6 = 546
+
+	// DefaultRetransmitTimeoutV6 is the default initial retransmission
+	// timeout for DHCPv6, SOL_TIMEOUT as per RFC 8415 section 7.6.
+	DefaultRetransmitTimeoutV6 = time.Second
+)
+
+// allServersAndRelays is the All_DHCP_Relay_Agents_and_Servers multicast
+// address, as per RFC 8415 section 7.1.
+var allServersAndRelays = tcpip.AddrFrom16([16]byte{0: 0xff, 1: 0x02, 13: 0x01, 15: 0x02})
+
+// messageTypeV6 is a DHCPv6 message type, as per RFC 8415 section 7.3.
+type messageTypeV6 byte
+
+const (
+	msgSolicit   messageTypeV6 = 1
+	msgAdvertise messageTypeV6 = 2
+	msgRequestV6 messageTypeV6 = 3
+	msgRenew     messageTypeV6 = 5
+	msgRebind    messageTypeV6 = 6
+	msgReply     messageTypeV6 = 7
+	msgReleaseV6 messageTypeV6 = 8
+)
+
+// optionCodeV6 is a DHCPv6 option code, as per RFC 8415 section 21.
+type optionCodeV6 uint16
+
+const (
+	optClientIDV6   optionCodeV6 = 1
+	optServerIDV6   optionCodeV6 = 2
+	optIANA         optionCodeV6 = 3
+	optIAAddr       optionCodeV6 = 5
+	optOptionReq    optionCodeV6 = 6
+	optElapsedTime  optionCodeV6 = 8
+	optStatusCode   optionCodeV6 = 13
+	optRapidCommit  optionCodeV6 = 14
+	optDNSServersV6 optionCodeV6 = 23
+)
+
+const (
+	// duidLL is the DUID-LL type, as per RFC 8415 section 11.4.
+	duidLL = 3
+
+	// statusSuccess is the Success status code.
+	statusSuccess = 0
+
+	// ianaLen and iaAddrLen are the lengths of the fixed parts of the IA_NA
+	// and IAADDR options.
+	ianaLen   = 12
+	iaAddrLen = 24
+)
+
+// optionV6 is a DHCPv6 option.
+type optionV6 struct {
+	code optionCodeV6
+	body []byte
+}
+
+// messageV6 is a DHCPv6 client/server message.
+type messageV6 struct {
+	typ     messageTypeV6
+	xid     [3]byte
+	options []optionV6
+}
+
+// marshalOptionsV6 returns the wire representation of opts.
+func marshalOptionsV6(opts []optionV6) []byte {
+	var b []byte
+	for _, o := range opts {
+		b = binary.BigEndian.AppendUint16(b, uint16(o.code))
+		b = binary.BigEndian.AppendUint16(b, uint16(len(o.body)))
+		b = append(b, o.body...)
+	}
+	return b
+}
+
+// parseOptionsV6 parses a sequence of DHCPv6 options.
+func parseOptionsV6(b []byte) ([]optionV6, error) {
+	var opts []optionV6
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, fmt.Errorf("truncated option header")
+		}
+		code := optionCodeV6(binary.BigEndian.Uint16(b))
+		n := int(binary.BigEndian.Uint16(b[2:]))
+		if len(b) < 4+n {
+			return nil, fmt.Errorf("truncated option %d", code)
+		}
+		opts = append(opts, optionV6{code: code, body: b[4 : 4+n]})
+		b = b[4+n:]
+	}
+	return opts, nil
+}
+
+// findOptionV6 returns the body of the first option with the given code, or
+// nil.
+func findOptionV6(opts []optionV6, code optionCodeV6) []byte {
+	for _, o := range opts {
+		if o.code == code {
+			return o.body
+		}
+	}
+	return nil
+}
+
+// marshal returns the wire representation of m.
+func (m *messageV6) marshal() []byte {
+	return append([]byte{byte(m.typ), m.xid[0], m.xid[1], m.xid[2]}, marshalOptionsV6(m.options)...)
+}
+
+// parseMessageV6 parses a DHCPv6 message.
+func parseMessageV6(b []byte) (*messageV6, error) {
+	if len(b) < 4 {
+		return nil, fmt.Errorf("message too short: %d bytes", len(b))
+	}
+	opts, err := parseOptionsV6(b[4:])
+	if err != nil {
+		return nil, err
+	}
+	return &messageV6{
+		typ:     messageTypeV6(b[0]),
+		xid:     [3]byte(b[1:4]),
+		options: opts,
+	}, nil
+}
+
+// option returns the body of the first option with the given code, or nil.
+func (m *messageV6) option(code optionCodeV6) []byte {
+	return findOptionV6(m.options, code)
+}
+
+// statusOK returns true if opts doesn't hold a Status Code option with an
+// error status.
+func statusOK(opts []optionV6) bool {
+	b := findOptionV6(opts, optStatusCode)
+	return len(b) < 2 || binary.BigEndian.Uint16(b) == statusSuccess
+}
+
+// ianaBody returns the body of an IA_NA option.
+func ianaBody(iaid uint32, t1, t2 time.Duration, opts []optionV6) []byte {
+	var b []byte
+	b = binary.BigEndian.AppendUint32(b, iaid)
+	b = binary.BigEndian.AppendUint32(b, uint32(t1/time.Second))
+	b = binary.BigEndian.AppendUint32(b, uint32(t2/time.Second))
+	return append(b, marshalOptionsV6(opts)...)
+}
+
+// iaAddrBody returns the body of an IAADDR option.
+func iaAddrBody(addr tcpip.Address, preferred, valid time.Duration) []byte {
+	b := append([]byte(nil), addr.AsSlice()...)
+	b = binary.BigEndian.AppendUint32(b, uint32(preferred/time.Second))
+	return binary.BigEndian.AppendUint32(b, uint32(valid/time.Second))
+}
+
+// LeaseV6 is a DHCPv6 lease of a non-temporary address.
+type LeaseV6 struct {
+	// Address is the leased address.
+	Address tcpip.Address
+
+	// ServerID is the DUID of the server that granted the lease.
+	ServerID []byte
+
+	// DNS are the DNS servers, in order of preference.
+	DNS []tcpip.Address
+
+	// Start is when the lease was requested. Lease times are relative to it.
+	Start time.Time
+
+	// RenewalTime is when the client starts renewing the lease (T1).
+	RenewalTime time.Duration
+
+	// RebindingTime is when the client starts rebinding the lease (T2).
+	RebindingTime time.Duration
+
+	// PreferredLifetime is the preferred lifetime of the address.
+	PreferredLifetime time.Duration
+
+	// ValidLifetime is the valid lifetime of the address.
+	ValidLifetime time.Duration
+}
+
+// ClientV6 is a stateful DHCPv6 client for a single NIC. It requests a single
+// non-temporary address (IA_NA). The NIC must have a link-local address.
+type ClientV6 struct {
+	stack      *stack.Stack
+	nicID      tcpip.NICID
+	duid       []byte
+	retransmit time.Duration
+}
+
+// NewClientV6 returns a DHCPv6 client for the NIC with the given ID and link
+// address. retransmit is the initial retransmission timeout.
+func NewClientV6(s *stack.Stack, nicID tcpip.NICID, linkAddr tcpip.LinkAddress, retransmit time.Duration) *ClientV6 {
+	duid := binary.BigEndian.AppendUint16(nil, duidLL)
+	duid = binary.BigEndian.AppendUint16(duid, uint16(header.ARPHardwareEther))
+	return &ClientV6{
+		stack:      s,
+		nicID:      nicID,
+		duid:       append(duid, linkAddr...),
+		retransmit: retransmit,
+	}
+}
+
+// open returns a UDP socket bound to the client port of the NIC.
+func (c *ClientV6) open() (*gonet.UDPConn, error) {
+	var wq waiter.Queue
+	ep, err := c.stack.NewEndpoint(udp.ProtocolNumber, ipv6.ProtocolNumber, &wq)
+	if err != nil {
+		return nil, fmt.Errorf("NewEndpoint(): %s", err)
+	}
+	if err := ep.SocketOptions().SetBindToDevice(int32(c.nicID)); err != nil {
+		ep.Close()
+		return nil, fmt.Errorf("SetBindToDevice(%d): %s", c.nicID, err)
+	}
+	if err := ep.Bind(tcpip.FullAddress{NIC: c.nicID, Port: ClientPortV6}); err != nil {
+		ep.Close()
+		return nil, fmt.Errorf("Bind(): %s", err)
+	}
+	return gonet.NewUDPConn(&wq, ep), nil
+}
+
+// newMessage returns a client message of the given type with a new
+// transaction ID.
+func (c *ClientV6) newMessage(typ messageTypeV6, opts ...optionV6) *messageV6 {
+	m := &messageV6{typ: typ}
+	binary.BigEndian.PutUint16(m.xid[:], uint16(c.stack.InsecureRNG().Uint32()))
+	m.xid[2] = byte(c.stack.InsecureRNG().Uint32())
+	m.options = append([]optionV6{
+		{code: optClientIDV6, body: c.duid},
+		{code: optElapsedTime, body: []byte{0, 0}},
+		{code: optOptionReq, body: binary.BigEndian.AppendUint16(nil, uint16(optDNSServersV6))},
+	}, opts...)
+	return m
+}
+
+// exchange sends m to dst and returns the first reply for which accept
+// returns true. Requests are retransmitted with exponential backoff, as per
+// RFC 8415 section 15, until ctx is done.
+func (c *ClientV6) exchange(ctx context.Context, conn *gonet.UDPConn, m *messageV6, dst tcpip.Address, accept func(*messageV6) bool) (*messageV6, error) {
+	b := m.marshal()
+	to := &net.UDPAddr{IP: net.IP(dst.AsSlice()), Port: ServerPortV6}
+	buf := make([]byte, maxMessageSize)
+	timeout := c.retransmit
+	for {
+		if _, err := conn.WriteTo(b, to); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		conn.SetReadDeadline(deadline)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				if ne, ok := err.(net.Error); ok && ne.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			reply, err := parseMessageV6(buf[:n])
+			if err != nil {
+				log.Debugf("dhcpv6: NIC %d: ignoring malformed message: %v", c.nicID, err)
+				continue
+			}
+			if reply.xid != m.xid || !bytes.Equal(reply.option(optClientIDV6), c.duid) {
+				continue
+			}
+			if accept(reply) {
+				return reply, nil
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		timeout = min(2*timeout, maxRetransmitTimeout)
+	}
+}
+
+// iaid returns the identity association ID of the client.
+func (c *ClientV6) iaid() uint32 {
+	return uint32(c.nicID)
+}
+
+// leaseFromReply returns the lease in an Advertise or Reply message.
+func (c *ClientV6) leaseFromReply(m *messageV6, start time.Time) (LeaseV6, error) {
+	if !statusOK(m.options) {
+		return LeaseV6{}, errNak
+	}
+	serverID := m.option(optServerIDV6)
+	if len(serverID) == 0 {
+		return LeaseV6{}, fmt.Errorf("message without server identifier")
+	}
+	iana := m.option(optIANA)
+	if len(iana) < ianaLen || binary.BigEndian.Uint32(iana) != c.iaid() {
+		return LeaseV6{}, fmt.Errorf("message without IA_NA")
+	}
+	iaOpts, err := parseOptionsV6(iana[ianaLen:])
+	if err != nil {
+		return LeaseV6{}, err
+	}
+	if !statusOK(iaOpts) {
+		return LeaseV6{}, errNak
+	}
+	iaAddr := findOptionV6(iaOpts, optIAAddr)
+	if len(iaAddr) < iaAddrLen {
+		return LeaseV6{}, fmt.Errorf("IA_NA without address")
+	}
+	lease := LeaseV6{
+		Address:           tcpip.AddrFrom16Slice(iaAddr[:header.IPv6AddressSize]),
+		ServerID:          append([]byte(nil), serverID...),
+		Start:             start,
+		RenewalTime:       time.Duration(binary.BigEndian.Uint32(iana[4:])) * time.Second,
+		RebindingTime:     time.Duration(binary.BigEndian.Uint32(iana[8:])) * time.Second,
+		PreferredLifetime: time.Duration(binary.BigEndian.Uint32(iaAddr[16:])) * time.Second,
+		ValidLifetime:     time.Duration(binary.BigEndian.Uint32(iaAddr[20:])) * time.Second,
+	}
+	if lease.ValidLifetime == 0 {
+		return LeaseV6{}, fmt.Errorf("address %s with zero valid lifetime", lease.Address)
+	}
+	// Pick T1 and T2 when the server leaves them to the client, as per RFC
+	// 8415 section 21.4.
+	if lease.RenewalTime == 0 {
+		lease.RenewalTime = lease.PreferredLifetime / 2
+	}
+	if lease.RebindingTime == 0 {
+		lease.RebindingTime = lease.PreferredLifetime * 4 / 5
+	}
+	clampLeaseTimes(&lease.ValidLifetime, &lease.RenewalTime, &lease.RebindingTime)
+	dns := m.option(optDNSServersV6)
+	for len(dns) >= header.IPv6AddressSize {
+		lease.DNS = append(lease.DNS, tcpip.AddrFrom16Slice(dns[:header.IPv6AddressSize]))
+		dns = dns[header.IPv6AddressSize:]
+	}
+	return lease, nil
+}
+
+// Acquire obtains a new lease, as per RFC 8415 section 18.2.1. It retries
+// until ctx is done.
+func (c *ClientV6) Acquire(ctx context.Context) (LeaseV6, error) {
+	conn, err := c.open()
+	if err != nil {
+		return LeaseV6{}, err
+	}
+	defer conn.Close()
+
+	for {
+		start := time.Now()
+		solicit := c.newMessage(msgSolicit,
+			optionV6{code: optIANA, body: ianaBody(c.iaid(), 0, 0, nil)},
+			optionV6{code: optRapidCommit},
+		)
+		var lease LeaseV6
+		advertise, err := c.exchange(ctx, conn, solicit, allServersAndRelays, func(m *messageV6) bool {
+			if m.typ != msgAdvertise && (m.typ != msgReply || m.option(optRapidCommit) == nil) {
+				return false
+			}
+			var err error
+			lease, err = c.leaseFromReply(m, start)
+			return err == nil
+		})
+		if err != nil {
+			return LeaseV6{}, fmt.Errorf("waiting for advertise: %w", err)
+		}
+		if advertise.typ == msgReply {
+			// The server committed the address right away.
+			return lease, nil
+		}
+
+		start = time.Now()
+		request := c.newMessage(msgRequestV6,
+			optionV6{code: optServerIDV6, body: lease.ServerID},
+			optionV6{code: optIANA, body: ianaBody(c.iaid(), 0, 0, []optionV6{
+				{code: optIAAddr, body: iaAddrBody(lease.Address, 0, 0)},
+			})},
+		)
+		var replyErr error
+		if _, err := c.exchange(ctx, conn, request, allServersAndRelays, func(m *messageV6) bool {
+			if m.typ != msgReply {
+				return false
+			}
+			lease, replyErr = c.leaseFromReply(m, start)
+			return true
+		}); err != nil {
+			return LeaseV6{}, fmt.Errorf("waiting for reply: %w", err)
+		}
+		if replyErr != nil {
+			log.Infof("dhcpv6: NIC %d: request for %s failed: %v, restarting", c.nicID, lease.Address, replyErr)
+			continue
+		}
+		return lease, nil
+	}
+}
+
+// extend asks for an extension of lease with a Renew message or, when
+// rebinding, a Rebind message, as per RFC 8415 section 18.2.4 and 18.2.5.
+func (c *ClientV6) extend(ctx context.Context, lease LeaseV6, rebinding bool) (LeaseV6, error) {
+	conn, err := c.open()
+	if err != nil {
+		return LeaseV6{}, err
+	}
+	defer conn.Close()
+
+	iana := optionV6{code: optIANA, body: ianaBody(c.iaid(), 0, 0, []optionV6{
+		{code: optIAAddr, body: iaAddrBody(lease.Address, 0, 0)},
+	})}
+	var m *messageV6
+	if rebinding {
+		m = c.newMessage(msgRebind, iana)
+	} else {
+		m = c.newMessage(msgRenew, optionV6{code: optServerIDV6, body: lease.ServerID}, iana)
+	}
+	start := time.Now()
+	var next LeaseV6
+	var replyErr error
+	if _, err := c.exchange(ctx, conn, m, allServersAndRelays, func(m *messageV6) bool {
+		if m.typ != msgReply {
+			return false
+		}
+		next, replyErr = c.leaseFromReply(m, start)
+		return true
+	}); err != nil {
+		return LeaseV6{}, err
+	}
+	if replyErr != nil {
+		return LeaseV6{}, replyErr
+	}
+	if next.Address != lease.Address {
+		return LeaseV6{}, fmt.Errorf("reply for %s, want %s", next.Address, lease.Address)
+	}
+	return next, nil
+}
+
+// Release gives up lease, as per RFC 8415 section 18.2.7.
+func (c *ClientV6) Release(lease LeaseV6) error {
+	conn, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	release := c.newMessage(msgReleaseV6,
+		optionV6{code: optServerIDV6, body: lease.ServerID},
+		optionV6{code: optIANA, body: ianaBody(c.iaid(), 0, 0, []optionV6{
+			{code: optIAAddr, body: iaAddrBody(lease.Address, 0, 0)},
+		})},
+	)
+	_, err = conn.WriteTo(release.marshal(), &net.UDPAddr{IP: net.IP(allServersAndRelays.AsSlice()), Port: ServerPortV6})
+	return err
+}
+
+// Run maintains lease until ctx is done, renewing it before it expires and
+// acquiring a new one if it can't be renewed. onLease is called whenever the
+// lease changes; old is empty when a new lease is acquired and new is empty
+// when the lease expired.
+func (c *ClientV6) Run(ctx context.Context, lease LeaseV6, onLease func(old, new LeaseV6)) {
+	for {
+		if !sleepUntil(ctx, lease.Start.Add(lease.RenewalTime)) {
+			return
+		}
+		next, err := c.extendUntil(ctx, lease, false /* rebinding */, lease.Start.Add(lease.RebindingTime))
+		if err != nil && !errors.Is(err, errNak) {
+			next, err = c.extendUntil(ctx, lease, true /* rebinding */, lease.Start.Add(lease.ValidLifetime))
+		}
+		if err == nil {
+			onLease(lease, next)
+			lease = next
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Infof("dhcpv6: NIC %d: lease for %s lost: %v", c.nicID, lease.Address, err)
+		onLease(lease, LeaseV6{})
+		if lease, err = c.Acquire(ctx); err != nil {
+			return
+		}
+		onLease(LeaseV6{}, lease)
+	}
+}
+
+// extendUntil calls extend, giving up at deadline.
+func (c *ClientV6) extendUntil(ctx context.Context, lease LeaseV6, rebinding bool, deadline time.Time) (LeaseV6, error) {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	return c.extend(ctx, lease, rebinding)
+}
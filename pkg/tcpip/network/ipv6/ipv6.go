@@ -2203,8 +2203,12 @@ func (e *endpoint) acquireOutgoingPrimaryAddressRLocked(remoteAddr, srcHint tcpi
 			}
 		}
 
-		// Prefer temporary addresses as per RFC 6724 section 5 rule 7.
+		// Prefer temporary addresses as per RFC 6724 section 5 rule 7, unless
+		// configured to prefer public addresses.
 		if saTemp, sbTemp := sa.addressEndpoint.Temporary(), sb.addressEndpoint.Temporary(); saTemp != sbTemp {
+			if e.mu.ndp.configs.PreferPublicAddresses {
+				return sbTemp
+			}
 			return saTemp
 		}
 
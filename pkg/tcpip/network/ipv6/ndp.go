@@ -410,6 +410,13 @@ type NDPConfigurations struct {
 	// Ignored if AutoGenGlobalAddresses is false.
 	AutoGenTempGlobalAddresses bool
 
+	// PreferPublicAddresses determines whether public addresses are preferred
+	// over temporary addresses during source address selection, as per RFC 6724
+	// section 5 rule 7.
+	//
+	// This is the equivalent of setting Linux's use_tempaddr sysctl to 1.
+	PreferPublicAddresses bool
+
 	// MaxTempAddrValidLifetime is the maximum valid lifetime for temporary
 	// SLAAC addresses.
 	MaxTempAddrValidLifetime time.Duration
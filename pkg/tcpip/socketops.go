@@ -222,6 +222,10 @@ type SocketOptions struct {
 	// the incoming packet should be returned as an ancillary message.
 	receiveOriginalDstAddress atomicbitops.Uint32
 
+	// receiveOverflowEnabled is used to specify if the number of packets
+	// dropped by the socket is passed with incoming packets.
+	receiveOverflowEnabled atomicbitops.Uint32
+
 	// ipv4RecvErrEnabled determines whether extended reliable error message
 	// passing is enabled for IPv4.
 	ipv4RecvErrEnabled atomicbitops.Uint32
@@ -260,6 +264,13 @@ type SocketOptions struct {
 	// close. We currently implement this option for TCP socket only.
 	linger LingerOption
 
+	// filter is the packet filter attached with SO_ATTACH_FILTER, or nil. It
+	// is only applied by raw and packet endpoints.
+	filter PacketFilter
+
+	// filterLocked is set by SO_LOCK_FILTER to prevent filter from changing.
+	filterLocked bool
+
 	// rcvlowat specifies the minimum number of bytes which should be
 	// received to indicate the socket as readable.
 	rcvlowat atomicbitops.Int32
@@ -269,6 +280,13 @@ type SocketOptions struct {
 	experimentOptionValue atomicbitops.Uint32
 }
 
+// PacketFilter filters the packets received by a socket.
+type PacketFilter interface {
+	// Filter returns the number of bytes of data that should be delivered to
+	// the socket. The packet is dropped if it returns 0.
+	Filter(data []byte) int
+}
+
 // InitHandler initializes the handler. This must be called before using the
 // socket options utility.
 func (so *SocketOptions) InitHandler(handler SocketOptionsHandler, stack StackHandler, getSendBufferLimits GetSendBufferLimits, getReceiveBufferLimits GetReceiveBufferLimits) {
@@ -424,6 +442,16 @@ func (so *SocketOptions) SetIPv6ReceivePacketInfo(v bool) {
 	storeAtomicBool(&so.receiveIPv6PacketInfoEnabled, v)
 }
 
+// GetReceiveOverflow gets value for SO_RXQ_OVFL option.
+func (so *SocketOptions) GetReceiveOverflow() bool {
+	return so.receiveOverflowEnabled.Load() != 0
+}
+
+// SetReceiveOverflow sets value for SO_RXQ_OVFL option.
+func (so *SocketOptions) SetReceiveOverflow(v bool) {
+	storeAtomicBool(&so.receiveOverflowEnabled, v)
+}
+
 // GetHeaderIncluded gets value for IP_HDRINCL option.
 func (so *SocketOptions) GetHeaderIncluded() bool {
 	return so.hdrIncludedEnabled.Load() != 0
@@ -771,3 +799,55 @@ func (so *SocketOptions) SetRcvlowat(rcvlowat int32) Error {
 func (so *SocketOptions) GetAcceptConn() bool {
 	return so.handler.GetAcceptConn()
 }
+
+// GetFilter gets value for SO_ATTACH_FILTER option.
+func (so *SocketOptions) GetFilter() PacketFilter {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	return so.filter
+}
+
+// SetFilter sets value for SO_ATTACH_FILTER option, or removes the filter if
+// f is nil (SO_DETACH_FILTER).
+func (so *SocketOptions) SetFilter(f PacketFilter) Error {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	if so.filterLocked {
+		return &ErrNotPermitted{}
+	}
+	if f == nil && so.filter == nil {
+		return &ErrNoSuchFile{}
+	}
+	so.filter = f
+	return nil
+}
+
+// GetFilterLocked gets value for SO_LOCK_FILTER option.
+func (so *SocketOptions) GetFilterLocked() bool {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	return so.filterLocked
+}
+
+// SetFilterLocked sets value for SO_LOCK_FILTER option. Once locked, the
+// filter can't be unlocked.
+func (so *SocketOptions) SetFilterLocked(v bool) Error {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	if so.filterLocked && !v {
+		return &ErrNotPermitted{}
+	}
+	so.filterLocked = v
+	return nil
+}
+
+// FilterPacket runs the filter attached to the socket, if any, on data and
+// returns the number of bytes of data that should be delivered.
+func (so *SocketOptions) FilterPacket(data *buffer.Buffer) int {
+	f := so.GetFilter()
+	size := int(data.Size())
+	if f == nil {
+		return size
+	}
+	return min(f.Filter(data.Flatten()), size)
+}
@@ -2751,6 +2751,74 @@ func TestNoAutoGenTempAddrWithoutStableAddr(t *testing.T) {
 	tempAddrDisp.disable()
 }
 
+// TestAutoGenTempAddrSourceSelection tests that temporary SLAAC addresses are
+// preferred as source addresses unless public addresses are configured to be
+// preferred.
+func TestAutoGenTempAddrSourceSelection(t *testing.T) {
+	const nicID = 1
+
+	prefix, _, addr := prefixSubnetAddr(0, linkAddr1)
+	_, _, remoteAddr := prefixSubnetAddr(0, linkAddr2)
+
+	seed := []byte{1}
+	var tempIIDHistory [header.IIDSize]byte
+	header.InitialTempIID(tempIIDHistory[:], seed, nicID)
+	tempAddr := header.GenerateTempIPv6SLAACAddr(tempIIDHistory[:], addr.Address)
+
+	e := channel.New(0, 1280, linkAddr1)
+	clock := faketime.NewManualClock()
+	s := stack.New(stack.Options{
+		NetworkProtocols: []stack.NetworkProtocolFactory{ipv6.NewProtocolWithOptions(ipv6.Options{
+			NDPConfigs: ipv6.NDPConfigurations{
+				HandleRAs:                  ipv6.HandlingRAsEnabledWhenForwardingDisabled,
+				AutoGenGlobalAddresses:     true,
+				AutoGenTempGlobalAddresses: true,
+			},
+			TempIIDSeed: seed,
+		})},
+		Clock: clock,
+	})
+	if err := s.CreateNIC(nicID, e); err != nil {
+		t.Fatalf("CreateNIC(%d, _) = %s", nicID, err)
+	}
+	s.SetRouteTable([]tcpip.Route{{
+		Destination: header.IPv6EmptySubnet,
+		NIC:         nicID,
+	}})
+
+	lifetime := 2 * minVLSeconds
+	e.InjectInbound(header.IPv6ProtocolNumber, raBufWithPI(llAddr2, 0, prefix, true, true, lifetime, lifetime))
+	clock.RunImmediatelyScheduledJobs()
+	if mismatch := addressCheck(s.NICInfo()[nicID].ProtocolAddresses, []tcpip.AddressWithPrefix{addr, tempAddr}, nil); mismatch != "" {
+		t.Fatal(mismatch)
+	}
+
+	checkSource := func(want tcpip.Address) {
+		t.Helper()
+
+		r, err := s.FindRoute(nicID, tcpip.Address{}, remoteAddr.Address, header.IPv6ProtocolNumber, false)
+		if err != nil {
+			t.Fatalf("FindRoute(%d, '', %s, %d, false): %s", nicID, remoteAddr.Address, header.IPv6ProtocolNumber, err)
+		}
+		defer r.Release()
+		if got := r.LocalAddress(); got != want {
+			t.Errorf("got r.LocalAddress() = %s, want = %s", got, want)
+		}
+	}
+
+	checkSource(tempAddr.Address)
+
+	ep, err := s.GetNetworkEndpoint(nicID, header.IPv6ProtocolNumber)
+	if err != nil {
+		t.Fatalf("GetNetworkEndpoint(%d, %d): %s", nicID, header.IPv6ProtocolNumber, err)
+	}
+	ndpEP := ep.(ipv6.NDPEndpoint)
+	configs := ndpEP.NDPConfigurations()
+	configs.PreferPublicAddresses = true
+	ndpEP.SetNDPConfigurations(configs)
+	checkSource(addr.Address)
+}
+
 type tempAddrState struct {
 	addrWithPrefix tcpip.AddressWithPrefix
 	generated      tcpip.MonotonicTime
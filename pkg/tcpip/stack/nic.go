@@ -92,6 +92,9 @@ type nic struct {
 	// Primary is the main controlling interface in a bonded setup.
 	Primary *nic
 
+	// vrfID is the ID of the VRF device the NIC is enslaved to, or 0.
+	vrfID atomicbitops.Int32
+
 	// experimentIPOptionEnabled indicates whether the NIC supports the
 	// experiment IP option.
 	experimentIPOptionEnabled bool
@@ -876,7 +879,7 @@ func (n *nic) DeliverTransportPacket(protocol tcpip.TransportProtocolNumber, pkt
 		RemotePort:    srcPort,
 		RemoteAddress: src,
 	}
-	if n.stack.demux.deliverPacket(protocol, pkt, id) {
+	if n.stack.demux.deliverPacket(protocol, pkt, id, n.vrf()) {
 		return TransportPacketHandled
 	}
 
@@ -947,6 +950,11 @@ func (n *nic) ID() tcpip.NICID {
 	return n.id
 }
 
+// vrf returns the ID of the VRF device the NIC is enslaved to, or 0.
+func (n *nic) vrf() tcpip.NICID {
+	return tcpip.NICID(n.vrfID.Load())
+}
+
 // Name implements NetworkInterface.
 func (n *nic) Name() string {
 	return n.name
@@ -1076,9 +1076,39 @@ func (s *Stack) SetNICCoordinator(id tcpip.NICID, mid tcpip.NICID) tcpip.Error {
 		return err
 	}
 	nic.Primary = m
+	if _, ok := b.(*VRFEndpoint); ok {
+		nic.vrfID.Store(int32(mid))
+	}
 	return nil
 }
 
+// isVRFRLocked returns true if id is the ID of a VRF device.
+//
+// +checklocksread:s.mu
+func (s *Stack) isVRFRLocked(id tcpip.NICID) bool {
+	nic, ok := s.nics[id]
+	if !ok {
+		return false
+	}
+	_, ok = nic.NetworkLinkEndpoint.(*VRFEndpoint)
+	return ok
+}
+
+// routeNICMatches returns true if a route through nic may be used by an
+// endpoint bound to the interface id. Routes through NICs enslaved to a VRF
+// device are only used by endpoints bound to the VRF device or to the NIC
+// itself.
+func routeNICMatches(id tcpip.NICID, isVRF bool, nic *nic) bool {
+	switch {
+	case isVRF:
+		return nic.vrf() == id
+	case id == 0:
+		return nic.vrf() == 0
+	default:
+		return nic.id == id
+	}
+}
+
 // SetNICAddress sets the hardware address which is identified by the nic ID.
 func (s *Stack) SetNICAddress(id tcpip.NICID, addr tcpip.LinkAddress) tcpip.Error {
 	s.mu.Lock()
@@ -1396,8 +1426,11 @@ func (s *Stack) findLocalRouteRLocked(localAddressNICID tcpip.NICID, localAddr,
 		localAddr = remoteAddr
 	}
 
-	if localAddressNICID == 0 {
+	if isVRF := s.isVRFRLocked(localAddressNICID); localAddressNICID == 0 || isVRF {
 		for _, localAddressNIC := range s.nics {
+			if isVRF && localAddressNIC.vrf() != localAddressNICID {
+				continue
+			}
 			if r := s.findLocalRouteFromNICRLocked(localAddressNIC, localAddr, remoteAddr, netProto); r != nil {
 				return r
 			}
@@ -1486,9 +1519,12 @@ func (s *Stack) FindRoute(id tcpip.NICID, localAddr, remoteAddr tcpip.Address, n
 		}
 	}
 
+	// Routes through NICs enslaved to a VRF device are looked up in the VRF.
+	isVRF := s.isVRFRLocked(id)
+
 	// If the interface is specified and we do not need a route, return a route
 	// through the interface if the interface is valid and enabled.
-	if id != 0 && !needRoute {
+	if id != 0 && !isVRF && !needRoute {
 		if nic, ok := s.nics[id]; ok && nic.Enabled() {
 			if addressEndpoint := s.getAddressEP(nic, localAddr, remoteAddr, tcpip.Address{} /* srcHint */, netProto); addressEndpoint != nil {
 				return makeRoute(
@@ -1530,7 +1566,13 @@ func (s *Stack) FindRoute(id tcpip.NICID, localAddr, remoteAddr tcpip.Address, n
 				continue
 			}
 
-			if id == 0 || id == route.NIC {
+			matches := routeNICMatches(id, isVRF, nic)
+			if !matches && nic.vrf() != 0 {
+				// Routes of a VRF are not used outside of it.
+				continue
+			}
+
+			if matches {
 				if addressEndpoint := s.getAddressEP(nic, localAddr, remoteAddr, route.SourceHint, netProto); addressEndpoint != nil {
 					var gateway tcpip.Address
 					if needRoute {
@@ -157,12 +157,18 @@ func (epsByNIC *endpointsByNIC) transportEndpoints() []TransportEndpoint {
 }
 
 // handlePacket is called by the stack when new packets arrive to this transport
-// endpoint. It returns false if the packet could not be matched to any
-// transport endpoint, true otherwise.
-func (epsByNIC *endpointsByNIC) handlePacket(id TransportEndpointID, pkt *PacketBuffer) bool {
+// endpoint. vrfID is the VRF device the receiving NIC is enslaved to, or 0. It
+// returns false if the packet could not be matched to any transport endpoint,
+// true otherwise.
+func (epsByNIC *endpointsByNIC) handlePacket(id TransportEndpointID, pkt *PacketBuffer, vrfID tcpip.NICID) bool {
 	epsByNIC.mu.RLock()
 
 	mpep, ok := epsByNIC.endpoints[pkt.NICID]
+	if !ok && vrfID != 0 {
+		// Endpoints bound to a VRF device receive packets from all of its
+		// enslaved NICs.
+		mpep, ok = epsByNIC.endpoints[vrfID]
+	}
 	if !ok {
 		if mpep, ok = epsByNIC.endpoints[0]; !ok {
 			epsByNIC.mu.RUnlock() // Don't use defer for performance reasons.
@@ -195,6 +201,9 @@ func (epsByNIC *endpointsByNIC) handleError(n *nic, id TransportEndpointID, tran
 	epsByNIC.mu.RLock()
 
 	mpep, ok := epsByNIC.endpoints[n.ID()]
+	if vrfID := n.vrf(); !ok && vrfID != 0 {
+		mpep, ok = epsByNIC.endpoints[vrfID]
+	}
 	if !ok {
 		mpep, ok = epsByNIC.endpoints[0]
 	}
@@ -552,7 +561,7 @@ func (d *transportDemuxer) unregisterEndpoint(netProtos []tcpip.NetworkProtocolN
 // deliverPacket attempts to find one or more matching transport endpoints, and
 // then, if matches are found, delivers the packet to them. Returns true if
 // the packet no longer needs to be handled.
-func (d *transportDemuxer) deliverPacket(protocol tcpip.TransportProtocolNumber, pkt *PacketBuffer, id TransportEndpointID) bool {
+func (d *transportDemuxer) deliverPacket(protocol tcpip.TransportProtocolNumber, pkt *PacketBuffer, id TransportEndpointID, vrfID tcpip.NICID) bool {
 	eps, ok := d.protocol[protocolIDs{pkt.NetworkProtocolNumber, protocol}]
 	if !ok {
 		return false
@@ -573,10 +582,10 @@ func (d *transportDemuxer) deliverPacket(protocol tcpip.TransportProtocolNumber,
 		// copy except for the final one.
 		for _, ep := range destEPs[:len(destEPs)-1] {
 			clone := pkt.Clone()
-			ep.handlePacket(id, clone)
+			ep.handlePacket(id, clone, vrfID)
 			clone.DecRef()
 		}
-		destEPs[len(destEPs)-1].handlePacket(id, pkt)
+		destEPs[len(destEPs)-1].handlePacket(id, pkt, vrfID)
 		return true
 	}
 
@@ -600,7 +609,7 @@ func (d *transportDemuxer) deliverPacket(protocol tcpip.TransportProtocolNumber,
 		}
 		return false
 	}
-	return ep.handlePacket(id, pkt)
+	return ep.handlePacket(id, pkt, vrfID)
 }
 
 // deliverRawPacket attempts to deliver the given packet and returns whether it
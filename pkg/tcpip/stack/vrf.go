@@ -0,0 +1,167 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+var _ NetworkLinkEndpoint = (*VRFEndpoint)(nil)
+
+// NewVRFEndpoint creates a new VRF (l3mdev) endpoint that uses the given
+// routing table.
+func NewVRFEndpoint(table uint32) *VRFEndpoint {
+	return &VRFEndpoint{
+		table:   table,
+		addr:    tcpip.GetRandMacAddr(),
+		members: make(map[tcpip.NICID]*nic),
+	}
+}
+
+// VRFEndpoint is a virtual routing and forwarding device. NICs enslaved to it
+// form an L3 domain:
+//
+//   - Routes through enslaved NICs are only used by endpoints bound to the VRF
+//     device (SO_BINDTODEVICE) or to one of its enslaved NICs.
+//   - Endpoints bound to the VRF device receive packets from all enslaved
+//     NICs.
+//
+// Unlike a bridge, a VRF doesn't take over packet delivery of enslaved NICs.
+//
+// +stateify savable
+type VRFEndpoint struct {
+	// table is the routing table ID. It is immutable.
+	table uint32
+
+	mu vrfRWMutex `state:"nosave"`
+	// +checklocks:mu
+	members map[tcpip.NICID]*nic
+	// +checklocks:mu
+	dispatcher NetworkDispatcher
+	// +checklocks:mu
+	addr tcpip.LinkAddress
+	// +checklocks:mu
+	mtu uint32
+}
+
+// Table returns the routing table ID of the VRF.
+func (v *VRFEndpoint) Table() uint32 {
+	return v.table
+}
+
+// AddNIC implements CoordinatorNIC.AddNIC.
+func (v *VRFEndpoint) AddNIC(n *nic) tcpip.Error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.members[n.id] = n
+	return nil
+}
+
+// DelNIC implements CoordinatorNIC.DelNIC.
+func (v *VRFEndpoint) DelNIC(n *nic) tcpip.Error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.members, n.id)
+	n.vrfID.Store(0)
+	return nil
+}
+
+// WritePackets implements LinkEndpoint.WritePackets.
+//
+// Packets are routed through enslaved NICs, so the VRF device itself never
+// transmits.
+func (v *VRFEndpoint) WritePackets(pkts PacketBufferList) (int, tcpip.Error) {
+	return 0, &tcpip.ErrNotSupported{}
+}
+
+// MTU implements LinkEndpoint.MTU.
+func (v *VRFEndpoint) MTU() uint32 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.mtu
+}
+
+// SetMTU implements LinkEndpoint.SetMTU.
+func (v *VRFEndpoint) SetMTU(mtu uint32) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mtu = mtu
+}
+
+// MaxHeaderLength implements LinkEndpoint.MaxHeaderLength.
+func (v *VRFEndpoint) MaxHeaderLength() uint16 {
+	return 0
+}
+
+// LinkAddress implements LinkEndpoint.LinkAddress.
+func (v *VRFEndpoint) LinkAddress() tcpip.LinkAddress {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.addr
+}
+
+// SetLinkAddress implements LinkEndpoint.SetLinkAddress.
+func (v *VRFEndpoint) SetLinkAddress(addr tcpip.LinkAddress) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.addr = addr
+}
+
+// Capabilities implements LinkEndpoint.Capabilities.
+func (v *VRFEndpoint) Capabilities() LinkEndpointCapabilities {
+	return CapabilitySaveRestore
+}
+
+// Attach implements LinkEndpoint.Attach.
+func (v *VRFEndpoint) Attach(dispatcher NetworkDispatcher) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, n := range v.members {
+		n.Primary = nil
+		n.vrfID.Store(0)
+	}
+	v.dispatcher = dispatcher
+	v.members = make(map[tcpip.NICID]*nic)
+}
+
+// IsAttached implements LinkEndpoint.IsAttached.
+func (v *VRFEndpoint) IsAttached() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.dispatcher != nil
+}
+
+// Wait implements LinkEndpoint.Wait.
+func (v *VRFEndpoint) Wait() {}
+
+// ARPHardwareType implements LinkEndpoint.ARPHardwareType.
+func (v *VRFEndpoint) ARPHardwareType() header.ARPHardwareType {
+	return header.ARPHardwareEther
+}
+
+// AddHeader implements LinkEndpoint.AddHeader.
+func (v *VRFEndpoint) AddHeader(*PacketBuffer) {}
+
+// ParseHeader implements LinkEndpoint.ParseHeader.
+func (v *VRFEndpoint) ParseHeader(*PacketBuffer) bool {
+	return true
+}
+
+// Close implements LinkEndpoint.Close.
+func (v *VRFEndpoint) Close() {}
+
+// SetOnCloseAction implements LinkEndpoint.SetOnCloseAction.
+func (v *VRFEndpoint) SetOnCloseAction(func()) {}
@@ -0,0 +1,112 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vrf_test
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+)
+
+func TestVRFRouting(t *testing.T) {
+	const (
+		nicID1 = 1
+		nicID2 = 2
+		vrfID  = 3
+	)
+	var (
+		addr1      = testutil.MustParse4("10.0.1.1")
+		addr2      = testutil.MustParse4("10.0.2.1")
+		remoteAddr = testutil.MustParse4("192.168.0.1")
+	)
+
+	s := stack.New(stack.Options{
+		NetworkProtocols: []stack.NetworkProtocolFactory{ipv4.NewProtocol},
+	})
+	for _, nic := range []struct {
+		id   tcpip.NICID
+		addr tcpip.Address
+	}{
+		{nicID1, addr1},
+		{nicID2, addr2},
+	} {
+		if err := s.CreateNIC(nic.id, channel.New(1, header.IPv4MinimumMTU, "")); err != nil {
+			t.Fatalf("s.CreateNIC(%d, _): %s", nic.id, err)
+		}
+		protocolAddr := tcpip.ProtocolAddress{
+			Protocol:          ipv4.ProtocolNumber,
+			AddressWithPrefix: nic.addr.WithPrefix(),
+		}
+		if err := s.AddProtocolAddress(nic.id, protocolAddr, stack.AddressProperties{}); err != nil {
+			t.Fatalf("s.AddProtocolAddress(%d, %+v, {}): %s", nic.id, protocolAddr, err)
+		}
+	}
+	s.SetRouteTable([]tcpip.Route{
+		// NIC2 before NIC1 to let NIC2 have preference.
+		{Destination: header.IPv4EmptySubnet, NIC: nicID2},
+		{Destination: header.IPv4EmptySubnet, NIC: nicID1},
+	})
+
+	if err := s.CreateNIC(vrfID, stack.NewVRFEndpoint(10 /* table */)); err != nil {
+		t.Fatalf("s.CreateNIC(%d, _): %s", vrfID, err)
+	}
+	if err := s.SetNICCoordinator(nicID2, vrfID); err != nil {
+		t.Fatalf("s.SetNICCoordinator(%d, %d): %s", nicID2, vrfID, err)
+	}
+
+	for _, test := range []struct {
+		name      string
+		nicID     tcpip.NICID
+		wantNIC   tcpip.NICID
+		wantLocal tcpip.Address
+	}{
+		{"Unbound", 0, nicID1, addr1},
+		{"BoundToVRF", vrfID, nicID2, addr2},
+		{"BoundToEnslavedNIC", nicID2, nicID2, addr2},
+		{"BoundToOtherNIC", nicID1, nicID1, addr1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := s.FindRoute(test.nicID, tcpip.Address{}, remoteAddr, ipv4.ProtocolNumber, false /* multicastLoop */)
+			if err != nil {
+				t.Fatalf("s.FindRoute(%d, _, %s, _, false): %s", test.nicID, remoteAddr, err)
+			}
+			defer r.Release()
+			if got := r.NICID(); got != test.wantNIC {
+				t.Errorf("got r.NICID() = %d, want = %d", got, test.wantNIC)
+			}
+			if got := r.LocalAddress(); got != test.wantLocal {
+				t.Errorf("got r.LocalAddress() = %s, want = %s", got, test.wantLocal)
+			}
+		})
+	}
+
+	// Routes through NIC2 are visible again once the VRF is removed.
+	if err := s.RemoveNIC(vrfID); err != nil {
+		t.Fatalf("s.RemoveNIC(%d): %s", vrfID, err)
+	}
+	r, err := s.FindRoute(0, tcpip.Address{}, remoteAddr, ipv4.ProtocolNumber, false /* multicastLoop */)
+	if err != nil {
+		t.Fatalf("s.FindRoute(0, _, %s, _, false): %s", remoteAddr, err)
+	}
+	defer r.Release()
+	if got := r.NICID(); got != nicID2 {
+		t.Errorf("got r.NICID() = %d after removing the VRF, want = %d", got, nicID2)
+	}
+}
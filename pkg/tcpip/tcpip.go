@@ -689,6 +689,13 @@ type ReceivableControlMessages struct {
 
 	// SockErr is the dequeued socket error on recvmsg(MSG_ERRQUEUE).
 	SockErr *SockError
+
+	// HasDropCount indicates whether DropCount is valid/set.
+	HasDropCount bool
+
+	// DropCount is the number of packets dropped by the endpoint before the
+	// associated packet was queued.
+	DropCount uint32
 }
 
 // PacketOwner is used to get UID and GID of the packet.
@@ -669,6 +669,14 @@ func (e *Endpoint) ConnectAndThen(addr tcpip.FullAddress, f func(netProto tcpip.
 		return &tcpip.ErrInvalidEndpointState{}
 	}
 
+	// If the endpoint is bound to a device, the route must go through it.
+	if bindToDevice := tcpip.NICID(e.ops.GetBindToDevice()); bindToDevice != 0 && nicID != bindToDevice {
+		if nicID != 0 {
+			return &tcpip.ErrInvalidEndpointState{}
+		}
+		nicID = bindToDevice
+	}
+
 	addr, netProto, err := e.checkV4Mapped(addr, false /* bind */)
 	if err != nil {
 		return err
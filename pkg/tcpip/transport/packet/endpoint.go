@@ -490,6 +490,18 @@ func (ep *endpoint) GetSockOptInt(opt tcpip.SockOptInt) (int, tcpip.Error) {
 
 // handlePacket implements stack.PacketEndpoint.HandlePacket
 func (ep *endpoint) HandlePacket(nicID tcpip.NICID, netProto tcpip.NetworkProtocolNumber, pkt *stack.PacketBuffer) {
+	// Run the socket filter before the packet is queued or written to the
+	// packet ring, so that filtered packets cost as little as possible.
+	snapLen := -1
+	if ep.ops.GetFilter() != nil {
+		pktBuf := ep.packetData(pkt)
+		snapLen = ep.ops.FilterPacket(&pktBuf)
+		pktBuf.Release()
+		if snapLen == 0 {
+			return
+		}
+	}
+
 	ep.packetMmapMu.RLock()
 	if ep.packetMMapEp != nil {
 		if handled := ep.packetMMapEp.HandlePacket(nicID, netProto, pkt); handled {
@@ -499,7 +511,7 @@ func (ep *endpoint) HandlePacket(nicID tcpip.NICID, netProto tcpip.NetworkProtoc
 	}
 	ep.packetMmapMu.RUnlock()
 
-	wasEmpty := ep.handlePacketInner(nicID, netProto, pkt)
+	wasEmpty := ep.handlePacketInner(nicID, netProto, pkt, snapLen)
 
 	ep.stats.PacketsReceived.Increment()
 	// Notify waiters that there's data to be read.
@@ -509,10 +521,24 @@ func (ep *endpoint) HandlePacket(nicID tcpip.NICID, netProto tcpip.NetworkProtoc
 }
 
 func (ep *endpoint) HandlePacketMMapCopy(nicID tcpip.NICID, netProto tcpip.NetworkProtocolNumber, pkt *stack.PacketBuffer) {
-	_ = ep.handlePacketInner(nicID, netProto, pkt)
+	_ = ep.handlePacketInner(nicID, netProto, pkt, -1 /* snapLen */)
 }
 
-func (ep *endpoint) handlePacketInner(nicID tcpip.NICID, netProto tcpip.NetworkProtocolNumber, pkt *stack.PacketBuffer) bool {
+// packetData returns the data of pkt returned to the application.
+func (ep *endpoint) packetData(pkt *stack.PacketBuffer) buffer.Buffer {
+	// Raw packet endpoints include link-headers in received packets.
+	pktBuf := pkt.ToBuffer()
+	if ep.cooked {
+		// Cooked packet endpoints don't include the link-headers in received
+		// packets.
+		pktBuf.TrimFront(int64(len(pkt.LinkHeader().Slice()) + len(pkt.VirtioNetHeader().Slice())))
+	}
+	return pktBuf
+}
+
+// handlePacketInner queues pkt. If snapLen is not negative, the packet is
+// truncated to snapLen bytes.
+func (ep *endpoint) handlePacketInner(nicID tcpip.NICID, netProto tcpip.NetworkProtocolNumber, pkt *stack.PacketBuffer, snapLen int) bool {
 	ep.rcvMu.Lock()
 
 	// Drop the packet if our buffer is currently full.
@@ -549,12 +575,9 @@ func (ep *endpoint) handlePacketInner(nicID tcpip.NICID, netProto tcpip.NetworkP
 		rcvdPkt.senderAddr.LinkAddr = hdr.SourceAddress()
 	}
 
-	// Raw packet endpoints include link-headers in received packets.
-	pktBuf := pkt.ToBuffer()
-	if ep.cooked {
-		// Cooked packet endpoints don't include the link-headers in received
-		// packets.
-		pktBuf.TrimFront(int64(len(pkt.LinkHeader().Slice()) + len(pkt.VirtioNetHeader().Slice())))
+	pktBuf := ep.packetData(pkt)
+	if snapLen >= 0 {
+		pktBuf.Truncate(int64(snapLen))
 	}
 	rcvdPkt.data = stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: pktBuf})
 
@@ -724,6 +724,14 @@ func (e *endpoint) HandlePacket(pkt *stack.PacketBuffer) {
 			panic(fmt.Sprintf("unrecognized protocol number = %d", info.NetProto))
 		}
 
+		// Run the socket filter on the data that is returned to the
+		// application.
+		n := e.ops.FilterPacket(&combinedBuf)
+		if n == 0 {
+			return false
+		}
+		combinedBuf.Truncate(int64(n))
+
 		packet.data = stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: combinedBuf.Clone()})
 		packet.receivedAt = e.stack.Clock().Now()
 
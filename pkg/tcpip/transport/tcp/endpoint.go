@@ -2438,6 +2438,14 @@ func (e *Endpoint) connect(addr tcpip.FullAddress, handshake bool) tcpip.Error {
 		return &tcpip.ErrInvalidEndpointState{}
 	}
 
+	// If the endpoint is bound to a device, the route must go through it.
+	if bindToDevice := tcpip.NICID(e.ops.GetBindToDevice()); bindToDevice != 0 && nicID != bindToDevice {
+		if nicID != 0 {
+			return &tcpip.ErrHostUnreachable{}
+		}
+		nicID = bindToDevice
+	}
+
 	// Find a route to the desired destination.
 	r, err := e.stack.FindRoute(nicID, e.TransportEndpointInfo.ID.LocalAddress, addr.Addr, netProto, false /* multicastLoop */)
 	if err != nil {
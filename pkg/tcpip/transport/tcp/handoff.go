@@ -0,0 +1,154 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp
+
+import (
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/seqnum"
+)
+
+// Handoff holds the state of an established connection or listening endpoint
+// that another TCP implementation needs to take it over, e.g. the host's via
+// TCP_REPAIR.
+//
+// +stateify savable
+type Handoff struct {
+	// ID identifies the connection. For listening endpoints, only the local
+	// address and port are set.
+	ID TCPEndpointID
+
+	// Listening indicates that the endpoint is listening rather than
+	// connected. Only ID and Backlog are set for listening endpoints.
+	Listening bool
+
+	// Backlog is the listen backlog of a listening endpoint.
+	Backlog int
+
+	// SndUna is the first unacknowledged sequence number, i.e. the
+	// sequence number of the first byte of SendQueue.
+	SndUna seqnum.Value
+
+	// SndNxt is the sequence number of the next byte to be sent.
+	SndNxt seqnum.Value
+
+	// SndWnd is the peer's advertised receive window, in bytes.
+	SndWnd seqnum.Size
+
+	// SndWndScale is the window scale advertised by the peer, or -1 if
+	// window scaling wasn't negotiated.
+	SndWndScale int
+
+	// RcvNxt is the sequence number of the next byte expected from the peer.
+	RcvNxt seqnum.Value
+
+	// RcvWnd is the receive window last advertised to the peer, in bytes.
+	RcvWnd seqnum.Size
+
+	// RcvWndScale is the window scale advertised to the peer.
+	RcvWndScale uint8
+
+	// MSS is the maximum segment size in use.
+	MSS uint16
+
+	// SACKPermitted indicates that SACK was negotiated.
+	SACKPermitted bool
+
+	// Timestamps indicates that the timestamp option was negotiated.
+	Timestamps bool
+
+	// TSVal is the current value of the local timestamp clock.
+	TSVal uint32
+
+	// SendQueue holds the data written by the application that the peer
+	// hasn't acknowledged yet, starting at SndUna. Its last Unsent bytes
+	// haven't been sent at all.
+	SendQueue []byte
+
+	// Unsent is the number of bytes at the end of SendQueue that haven't
+	// been sent.
+	Unsent int
+
+	// RecvQueue holds the data received from the peer that the application
+	// hasn't read yet.
+	RecvQueue []byte
+}
+
+// Handoff returns the state of e needed for another TCP implementation to take
+// over the connection. e must be listening, or established and not shut down,
+// and is left unchanged; the caller must make sure it doesn't send or receive
+// anything once the connection has been handed off, e.g. by handing off a
+// restored endpoint whose stack never resumes. Connections that are pending
+// on a listening endpoint aren't handed off.
+func (e *Endpoint) Handoff() (Handoff, tcpip.Error) {
+	e.LockUser()
+	defer e.UnlockUser()
+	return e.handoffLocked()
+}
+
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+// +checklocksalias:e.rcv.ep.mu=e.mu
+func (e *Endpoint) handoffLocked() (Handoff, tcpip.Error) {
+	e.sndQueueInfo.sndQueueMu.Lock()
+	sndClosed := e.sndQueueInfo.SndClosed
+	e.sndQueueInfo.sndQueueMu.Unlock()
+	state := e.EndpointState()
+	if state == StateInitial && e.origEndpointState != 0 {
+		// e was restored, but its stack hasn't been restored yet.
+		state = EndpointState(e.origEndpointState)
+	}
+	if state == StateListen {
+		e.acceptMu.Lock()
+		defer e.acceptMu.Unlock()
+		return Handoff{
+			ID: TCPEndpointID{
+				LocalAddress: e.TransportEndpointInfo.ID.LocalAddress,
+				LocalPort:    e.TransportEndpointInfo.ID.LocalPort,
+			},
+			Listening: true,
+			Backlog:   e.acceptQueue.capacity,
+		}, nil
+	}
+	if state != StateEstablished || sndClosed {
+		return Handoff{}, &tcpip.ErrInvalidEndpointState{}
+	}
+	h := Handoff{
+		ID:            TCPEndpointID(e.TransportEndpointInfo.ID),
+		SndUna:        e.snd.SndUna,
+		SndNxt:        e.snd.SndNxt,
+		SndWnd:        e.snd.SndWnd,
+		SndWndScale:   -1,
+		RcvNxt:        e.rcv.RcvNxt,
+		RcvWnd:        e.rcv.RcvNxt.Size(e.rcv.RcvAcc),
+		RcvWndScale:   e.rcv.RcvWndScale,
+		MSS:           uint16(e.snd.MaxPayloadSize),
+		SACKPermitted: e.SACKPermitted,
+		Timestamps:    e.SendTSOk,
+	}
+	if e.snd.SndWndScale > 0 || e.rcv.RcvWndScale > 0 {
+		h.SndWndScale = int(e.snd.SndWndScale)
+	}
+	if h.Timestamps {
+		h.TSVal = e.tsValNow()
+	}
+	for s := e.snd.writeList.Front(); s != nil; s = s.Next() {
+		h.SendQueue = append(h.SendQueue, s.pkt.Data().AsRange().ToSlice()...)
+	}
+	h.Unsent = len(h.SendQueue) - int(h.SndUna.Size(h.SndNxt))
+	for s := e.rcvQueue.Front(); s != nil; s = s.Next() {
+		h.RecvQueue = append(h.RecvQueue, s.pkt.Data().AsRange().ToSlice()...)
+	}
+	return h, nil
+}
@@ -1804,13 +1804,16 @@ func TestTrafficClassV6(t *testing.T) {
 
 func TestConnectBindToDevice(t *testing.T) {
 	for _, test := range []struct {
-		name   string
-		device tcpip.NICID
-		want   tcp.EndpointState
+		name       string
+		device     tcpip.NICID
+		connectErr tcpip.Error
+		want       tcp.EndpointState
 	}{
-		{"RightDevice", 1, tcp.StateEstablished},
-		{"WrongDevice", 2, tcp.StateSynSent},
-		{"AnyDevice", 0, tcp.StateEstablished},
+		{"RightDevice", 1, &tcpip.ErrConnectStarted{}, tcp.StateEstablished},
+		// The route must go through the device, which has no route to the
+		// destination.
+		{"WrongDevice", 2, &tcpip.ErrHostUnreachable{}, tcp.StateInitial},
+		{"AnyDevice", 0, &tcpip.ErrConnectStarted{}, tcp.StateEstablished},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			c := context.New(t, e2e.DefaultMTU)
@@ -1826,9 +1829,15 @@ func TestConnectBindToDevice(t *testing.T) {
 			defer c.WQ.EventUnregister(&waitEntry)
 
 			err := c.EP.Connect(tcpip.FullAddress{Addr: context.TestAddr, Port: context.TestPort})
-			if d := cmp.Diff(&tcpip.ErrConnectStarted{}, err); d != "" {
+			if d := cmp.Diff(test.connectErr, err); d != "" {
 				t.Fatalf("c.EP.Connect(...) mismatch (-want +got):\n%s", d)
 			}
+			if _, ok := err.(*tcpip.ErrConnectStarted); !ok {
+				if got := tcp.EndpointState(c.EP.State()); got != test.want {
+					t.Fatalf("unexpected endpoint state: want %s, got %s", test.want, got)
+				}
+				return
+			}
 
 			// Receive SYN packet.
 			v := c.GetPacket()
@@ -9586,3 +9595,100 @@ func TestMain(m *testing.M) {
 	refs.DoLeakCheck()
 	os.Exit(code)
 }
+
+func TestHandoff(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+
+	c.CreateConnected(context.TestInitialSequenceNumber, 30000, -1 /* epRcvBuf */)
+
+	we, ch := waiter.NewChannelEntry(waiter.ReadableEvents)
+	c.WQ.EventRegister(&we)
+	defer c.WQ.EventUnregister(&we)
+
+	// Receive data that the application doesn't read.
+	received := []byte{1, 2, 3}
+	iss := seqnum.Value(context.TestInitialSequenceNumber).Add(1)
+	c.SendPacket(received, &context.Headers{
+		SrcPort: context.TestPort,
+		DstPort: c.Port,
+		Flags:   header.TCPFlagAck,
+		SeqNum:  iss,
+		AckNum:  c.IRS.Add(1),
+		RcvWnd:  30000,
+	})
+	select {
+	case <-ch:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Timed out waiting for data to arrive")
+	}
+	v := c.GetPacket()
+	v.Release()
+
+	// Send data that the peer doesn't acknowledge.
+	sent := []byte{4, 5, 6, 7}
+	var r bytes.Reader
+	r.Reset(sent)
+	if _, err := c.EP.Write(&r, tcpip.WriteOptions{}); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+	v = c.GetPacket()
+	v.Release()
+
+	h, err := c.EP.(*tcp.Endpoint).Handoff()
+	if err != nil {
+		t.Fatalf("Handoff() failed: %s", err)
+	}
+	if got, want := h.ID.RemotePort, uint16(context.TestPort); got != want {
+		t.Errorf("got h.ID.RemotePort = %d, want = %d", got, want)
+	}
+	if got, want := h.SndUna, c.IRS.Add(1); got != want {
+		t.Errorf("got h.SndUna = %d, want = %d", got, want)
+	}
+	if got, want := h.SndNxt, c.IRS.Add(1+seqnum.Size(len(sent))); got != want {
+		t.Errorf("got h.SndNxt = %d, want = %d", got, want)
+	}
+	if got, want := h.RcvNxt, iss.Add(seqnum.Size(len(received))); got != want {
+		t.Errorf("got h.RcvNxt = %d, want = %d", got, want)
+	}
+	if !bytes.Equal(h.SendQueue, sent) || h.Unsent != 0 {
+		t.Errorf("got h.SendQueue, h.Unsent = %v, %d, want = %v, 0", h.SendQueue, h.Unsent, sent)
+	}
+	if !bytes.Equal(h.RecvQueue, received) {
+		t.Errorf("got h.RecvQueue = %v, want = %v", h.RecvQueue, received)
+	}
+
+	// Connections that aren't established can't be handed off.
+	c.EP.Shutdown(tcpip.ShutdownWrite)
+	if _, err := c.EP.(*tcp.Endpoint).Handoff(); err == nil {
+		t.Errorf("Handoff() after Shutdown(ShutdownWrite) succeeded, want error")
+	}
+}
+
+func TestHandoffListener(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+
+	c.Create(-1)
+	if err := c.EP.Bind(tcpip.FullAddress{Port: context.StackPort}); err != nil {
+		t.Fatalf("Bind failed: %s", err)
+	}
+	const backlog = 10
+	if err := c.EP.Listen(backlog); err != nil {
+		t.Fatalf("Listen failed: %s", err)
+	}
+
+	h, err := c.EP.(*tcp.Endpoint).Handoff()
+	if err != nil {
+		t.Fatalf("Handoff() failed: %s", err)
+	}
+	if !h.Listening {
+		t.Errorf("got h.Listening = false, want = true")
+	}
+	if got, want := h.ID.LocalPort, uint16(context.StackPort); got != want {
+		t.Errorf("got h.ID.LocalPort = %d, want = %d", got, want)
+	}
+	if got, want := h.Backlog, backlog; got != want {
+		t.Errorf("got h.Backlog = %d, want = %d", got, want)
+	}
+}
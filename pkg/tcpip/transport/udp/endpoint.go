@@ -46,6 +46,9 @@ type udpPacket struct {
 	tosOrTClass uint8
 	// ttlOrHopLimit stores either the TTL for IPv4 or the HopLimit for IPv6
 	ttlOrHopLimit uint8
+	// dropCount is the number of packets dropped by the endpoint when this
+	// packet was queued, if SO_RXQ_OVFL was enabled at the time.
+	dropCount uint32
 }
 
 // endpoint represents a UDP endpoint. This struct serves as the interface
@@ -74,6 +77,9 @@ type endpoint struct {
 	rcvList    udpPacketList
 	rcvBufSize int
 	rcvClosed  bool
+	// rcvDrops is the number of packets dropped because the receive buffer
+	// was full.
+	rcvDrops uint32
 
 	lastErrorMu sync.Mutex `state:"nosave"`
 	lastError   tcpip.Error
@@ -260,6 +266,18 @@ func (e *endpoint) Read(dst io.Writer, opts tcpip.ReadOptions) (tcpip.ReadResult
 			cm.HasIPPacketInfo = true
 			cm.PacketInfo = p.packetInfo
 		}
+		// IPv6 sockets receive the packet info of IPv4 packets as IPv4-mapped
+		// addresses.
+		if e.ops.GetIPv6ReceivePacketInfo() {
+			var addr [header.IPv6AddressSize]byte
+			copy(addr[:], header.IPv4MappedIPv6Subnet.ID().AsSlice())
+			copy(addr[header.IPv6AddressSize-header.IPv4AddressSize:], p.packetInfo.DestinationAddr.AsSlice())
+			cm.HasIPv6PacketInfo = true
+			cm.IPv6PacketInfo = tcpip.IPv6PacketInfo{
+				NIC:  p.packetInfo.NIC,
+				Addr: tcpip.AddrFrom16(addr),
+			}
+		}
 	case header.IPv6ProtocolNumber:
 		if e.ops.GetReceiveTClass() {
 			cm.HasTClass = true
@@ -286,6 +304,11 @@ func (e *endpoint) Read(dst io.Writer, opts tcpip.ReadOptions) (tcpip.ReadResult
 		cm.OriginalDstAddress = p.destinationAddress
 	}
 
+	if e.ops.GetReceiveOverflow() && p.dropCount != 0 {
+		cm.HasDropCount = true
+		cm.DropCount = p.dropCount
+	}
+
 	// Read Result
 	res := tcpip.ReadResult{
 		Total:           p.pkt.Data().Size(),
@@ -935,6 +958,9 @@ func (e *endpoint) HandlePacket(id stack.TransportEndpointID, pkt *stack.PacketB
 	rcvBufSize := e.ops.GetReceiveBufferSize()
 	// Drop the packet if our buffer is currently full.
 	if e.frozen || e.rcvBufSize >= int(rcvBufSize) {
+		if !e.frozen {
+			e.rcvDrops++
+		}
 		e.rcvMu.Unlock()
 		e.stack.Stats().UDP.ReceiveBufferErrors.Increment()
 		e.stats.ReceiveErrors.ReceiveBufferOverflow.Increment()
@@ -980,6 +1006,9 @@ func (e *endpoint) HandlePacket(id stack.TransportEndpointID, pkt *stack.PacketB
 	packet.packetInfo.DestinationAddr = localAddr
 	packet.packetInfo.NIC = pkt.NICID
 	packet.receivedAt = e.stack.Clock().Now()
+	if e.ops.GetReceiveOverflow() {
+		packet.dropCount = e.rcvDrops
+	}
 
 	e.rcvMu.Unlock()
 
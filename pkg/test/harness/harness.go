@@ -0,0 +1,435 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package harness provides an API for integration tests that run workloads in
+// a gVisor sandbox and check the resulting guest state.
+//
+// A typical test starts a sandbox, snapshots the guest state it is interested
+// in, runs a workload, and diffs a second snapshot against the first:
+//
+//	s := harness.Start(t, harness.Options{})
+//	opts := harness.SnapshotOpts{
+//		Dirs:  []string{"/tmp"},
+//		Files: []string{"/proc/self/mounts"},
+//	}
+//	before, err := s.Snapshot(opts)
+//	...
+//	if _, err := s.Output("/bin/sh", "-c", "mkdir /tmp/foo"); err != nil {
+//		...
+//	}
+//	after, err := s.Snapshot(opts)
+//	...
+//	for _, c := range harness.Diff(before, after) {
+//		t.Logf("%v", c)
+//	}
+//
+// The sandbox runs in the test process: the sentry and its gofers are started
+// in-process, with seccomp filters disabled, and are controlled through the
+// same RPCs that runsc uses. Only one sandbox can run in a process at a time.
+package harness
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/control/client"
+	"gvisor.dev/gvisor/pkg/control/server"
+	"gvisor.dev/gvisor/pkg/cpuid"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/metric"
+	"gvisor.dev/gvisor/pkg/sentry/control"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/seccheck"
+	"gvisor.dev/gvisor/pkg/test/testutil"
+	"gvisor.dev/gvisor/pkg/unet"
+	"gvisor.dev/gvisor/pkg/urpc"
+	"gvisor.dev/gvisor/runsc/boot"
+	"gvisor.dev/gvisor/runsc/config"
+	"gvisor.dev/gvisor/runsc/fsgofer"
+	"gvisor.dev/gvisor/runsc/specutils"
+)
+
+var (
+	// initOnce initializes the process-wide state that runsc boot initializes
+	// before creating a sandbox.
+	initOnce sync.Once
+	initErr  error
+
+	// metricsOnce initializes metrics, which can only be done once per
+	// process, after the first kernel is created.
+	metricsOnce sync.Once
+
+	// running is set while a sandbox is running.
+	running atomic.Bool
+)
+
+func initProcess() error {
+	initOnce.Do(func() {
+		cpuid.Initialize()
+		seccheck.Initialize()
+		initErr = fsgofer.OpenProcSelfFD("/proc/self/fd")
+	})
+	return initErr
+}
+
+// Options configures a sandbox started by Start.
+type Options struct {
+	// Spec is the OCI spec of the sandbox's root container. If nil, the root
+	// container runs "sleep infinity" in the host root filesystem, mounted
+	// read-only; see testutil.NewSpecWithArgs.
+	Spec *specs.Spec
+
+	// Config is the runsc configuration. If nil, testutil.TestConfig is used.
+	// Seccomp filters are always disabled, since they would apply to the test
+	// process.
+	Config *config.Config
+}
+
+// Sandbox is a running sandbox.
+type Sandbox struct {
+	id     string
+	conf   *config.Config
+	spec   *specs.Spec
+	loader *boot.Loader
+	conn   *urpc.Client
+}
+
+// Start starts a sandbox, which is destroyed when t completes.
+func Start(t *testing.T, opts Options) *Sandbox {
+	t.Helper()
+	if err := initProcess(); err != nil {
+		t.Fatalf("error initializing sandbox process: %v", err)
+	}
+	if !running.CompareAndSwap(false, true) {
+		t.Fatalf("a sandbox is already running in this process")
+	}
+	t.Cleanup(func() { running.Store(false) })
+
+	spec := opts.Spec
+	if spec == nil {
+		spec = testutil.NewSpecWithArgs("sleep", "infinity")
+	}
+	conf := opts.Config
+	if conf == nil {
+		conf = testutil.TestConfig(t)
+	}
+	conf.DisableSeccomp = true
+	id := testutil.RandomContainerID()
+
+	addr := fmt.Sprintf("\x00harness.%010d", rand.Int())
+	controllerFD, err := server.CreateSocket(addr)
+	if err != nil {
+		t.Fatalf("error creating controller socket: %v", err)
+	}
+
+	// Serve the root and every bind mount, in spec order, as runsc does.
+	rootFD, err := startGofer(t, conf, spec.Root.Path, spec.Root.Readonly)
+	if err != nil {
+		t.Fatalf("error starting root gofer: %v", err)
+	}
+	goferFDs := []int{rootFD}
+	mountConfs := []boot.GoferMountConf{{Lower: boot.Lisafs, Upper: boot.NoOverlay}}
+	for _, m := range spec.Mounts {
+		if !specutils.IsGoferMount(m) {
+			continue
+		}
+		fd, err := startGofer(t, conf, m.Source, specutils.IsReadonlyMount(m.Options))
+		if err != nil {
+			t.Fatalf("error starting gofer for mount %q: %v", m.Destination, err)
+		}
+		goferFDs = append(goferFDs, fd)
+		mountConfs = append(mountConfs, boot.GoferMountConf{Lower: boot.Lisafs, Upper: boot.NoOverlay})
+	}
+
+	stdio, err := stdioFDs()
+	if err != nil {
+		t.Fatalf("error setting up stdio: %v", err)
+	}
+
+	// The loader takes ownership of all FDs passed to it.
+	l, err := boot.New(boot.Args{
+		ID:              id,
+		Spec:            spec,
+		Conf:            conf,
+		ControllerFD:    controllerFD,
+		GoferFDs:        goferFDs,
+		GoferMountConfs: mountConfs,
+		DevGoferFD:      -1,
+		StdioFDs:        stdio,
+		ExecFD:          -1,
+		PanicReportFD:   -1,
+		PodInitConfigFD: -1,
+		ControlPolicyFD: -1,
+		HWRNGFD:         -1,
+		VDSOFD:          -1,
+	})
+	if err != nil {
+		t.Fatalf("error creating sandbox: %v", err)
+	}
+	metricsOnce.Do(func() {
+		if err := metric.Initialize(); err != nil {
+			log.Warningf("Error initializing metrics: %v", err)
+		}
+	})
+
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		l.WaitForStartSignal()
+		// Errors are also returned to the StartRoot call below.
+		_ = l.Run()
+	}()
+
+	conn, err := client.ConnectTo(addr)
+	if err != nil {
+		l.Destroy()
+		t.Fatalf("error connecting to sandbox: %v", err)
+	}
+	s := &Sandbox{
+		id:     id,
+		conf:   conf,
+		spec:   spec,
+		loader: l,
+		conn:   conn,
+	}
+	started := false
+	t.Cleanup(func() {
+		if started {
+			if err := conn.Call(boot.ContMgrSignal, &boot.SignalArgs{
+				CID:   id,
+				Signo: int32(unix.SIGKILL),
+				Mode:  boot.DeliverToAllProcesses,
+			}, nil); err != nil {
+				t.Errorf("error killing sandbox processes: %v", err)
+			}
+			l.WaitExit()
+			<-runDone
+		}
+		conn.Close()
+		l.Destroy()
+	})
+
+	if err := conn.Call(boot.ContMgrStartRoot, &id, nil); err != nil {
+		<-runDone
+		t.Fatalf("error starting sandbox: %v", err)
+	}
+	started = true
+	return s
+}
+
+// startGofer starts an in-process gofer serving root, which is stopped when t
+// completes. It returns the sandbox end of the gofer connection.
+func startGofer(t *testing.T, conf *config.Config, root string, readonly bool) (int, error) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return -1, err
+	}
+	sandboxEnd, goferEnd := fds[0], fds[1]
+
+	socket, err := unet.NewSocket(goferEnd)
+	if err != nil {
+		unix.Close(sandboxEnd)
+		unix.Close(goferEnd)
+		return -1, fmt.Errorf("creating gofer socket: %w", err)
+	}
+	srv := fsgofer.NewLisafsServer(fsgofer.Config{
+		HostUDS:            conf.GetHostUDS(),
+		HostFifo:           conf.HostFifo,
+		DonateMountPointFD: conf.DirectFS,
+	})
+	c, err := srv.CreateConnection(socket, root, readonly)
+	if err != nil {
+		unix.Close(sandboxEnd)
+		socket.Close()
+		return -1, fmt.Errorf("creating gofer connection: %w", err)
+	}
+	srv.StartConnection(c)
+	t.Cleanup(func() {
+		// Closing the socket stops the connection.
+		socket.Close()
+		srv.Wait()
+		srv.Destroy()
+	})
+	return sandboxEnd, nil
+}
+
+// stdioFDs returns the root container's stdio FDs: stdin is /dev/null, and
+// stdout and stderr are the test's stderr.
+func stdioFDs() ([]int, error) {
+	devNull, err := unix.Open("/dev/null", unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	stdio := []int{devNull}
+	for i := 0; i < 2; i++ {
+		fd, err := unix.Dup(int(os.Stderr.Fd()))
+		if err != nil {
+			for _, fd := range stdio {
+				unix.Close(fd)
+			}
+			return nil, err
+		}
+		stdio = append(stdio, fd)
+	}
+	return stdio, nil
+}
+
+// Kernel returns the sandbox's kernel, for tests that need to inspect sentry
+// state not provided by Sandbox.
+func (s *Sandbox) Kernel() *kernel.Kernel {
+	return s.loader.Kernel()
+}
+
+// Result is the result of a command run in a sandbox.
+type Result struct {
+	// Output is the combined stdout and stderr of the command.
+	Output []byte
+
+	// Status is the wait status of the command.
+	Status unix.WaitStatus
+}
+
+// Run runs argv in the sandbox's root container, with the environment,
+// working directory, credentials and capabilities of its root process, and
+// waits for it to exit. argv[0] is resolved using the root process's PATH. It
+// returns an error only if the command could not be run.
+func (s *Sandbox) Run(argv ...string) (Result, error) {
+	if len(argv) == 0 {
+		return Result{}, fmt.Errorf("no command given")
+	}
+	args := &control.ExecArgs{
+		Argv:        argv,
+		ContainerID: s.id,
+	}
+	if p := s.spec.Process; p != nil {
+		caps, err := specutils.Capabilities(s.conf.EnableRaw, p.Capabilities)
+		if err != nil {
+			return Result{}, fmt.Errorf("creating capabilities: %w", err)
+		}
+		args.Envv = p.Env
+		args.WorkingDirectory = p.Cwd
+		args.KUID = auth.KUID(p.User.UID)
+		args.KGID = auth.KGID(p.User.GID)
+		args.Capabilities = caps
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return Result{}, err
+	}
+	defer r.Close()
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		w.Close()
+		return Result{}, err
+	}
+	defer devNull.Close()
+	args.FilePayload = control.NewFilePayload(map[int]*os.File{
+		0: devNull, 1: w, 2: w,
+	}, nil)
+
+	var pid int32
+	err = s.conn.Call(boot.ContMgrExecuteAsync, args, &pid)
+	w.Close()
+	if err != nil {
+		return Result{}, fmt.Errorf("executing %v: %w", argv, err)
+	}
+
+	// Read output concurrently so that commands that produce more output
+	// than fits in the pipe can complete.
+	type readResult struct {
+		out []byte
+		err error
+	}
+	outCh := make(chan readResult, 1)
+	go func() {
+		out, err := io.ReadAll(r)
+		outCh <- readResult{out, err}
+	}()
+	var ws uint32
+	if err := s.conn.Call(boot.ContMgrWaitPID, &boot.WaitPIDArgs{PID: pid, CID: s.id}, &ws); err != nil {
+		return Result{}, fmt.Errorf("waiting for %v: %w", argv, err)
+	}
+	out := <-outCh
+	if out.err != nil {
+		return Result{}, fmt.Errorf("reading output of %v: %w", argv, out.err)
+	}
+	return Result{Output: out.out, Status: unix.WaitStatus(ws)}, nil
+}
+
+// Output runs argv like Run, and returns its output. It returns an error if
+// the command does not exit with status 0.
+func (s *Sandbox) Output(argv ...string) ([]byte, error) {
+	res, err := s.Run(argv...)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Status.Exited() || res.Status.ExitStatus() != 0 {
+		return res.Output, fmt.Errorf("%v failed, status: %v, output: %s", argv, res.Status, res.Output)
+	}
+	return res.Output, nil
+}
+
+// Metrics returns the current values of the sentry's numeric metrics whose
+// names match the regular expression filter, or all of them if filter is
+// empty. The returned map is keyed by metric name; metrics with fields are
+// keyed by name{field="value",...}, like in the Prometheus text format.
+func (s *Sandbox) Metrics(filter string) (map[string]float64, error) {
+	opts := control.MetricsExportOpts{OnlyMetrics: filter}
+	var data control.MetricsExportData
+	if err := s.conn.Call(boot.MetricsExport, &opts, &data); err != nil {
+		return nil, fmt.Errorf("exporting metrics: %w", err)
+	}
+	if err := opts.Verify(&data); err != nil {
+		return nil, fmt.Errorf("exporting metrics: %w", err)
+	}
+	metrics := make(map[string]float64)
+	for _, d := range data.Snapshot.Data {
+		if d.Number == nil {
+			continue
+		}
+		val := d.Number.Float
+		if d.Number.Int != 0 {
+			val = float64(d.Number.Int)
+		}
+		metrics[metricKey(d.Metric.Name, d.Labels, d.ExternalLabels)] = val
+	}
+	return metrics, nil
+}
+
+// metricKey returns the key of a metric with the given name and labels in the
+// map returned by Sandbox.Metrics.
+func metricKey(name string, labelSets ...map[string]string) string {
+	var labels []string
+	for _, set := range labelSets {
+		for k, v := range set {
+			labels = append(labels, fmt.Sprintf("%s=%q", k, v))
+		}
+	}
+	if len(labels) == 0 {
+		return name
+	}
+	sort.Strings(labels)
+	return name + "{" + strings.Join(labels, ",") + "}"
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	s := Start(t, Options{})
+
+	out, err := s.Output("echo", "hello")
+	if err != nil {
+		t.Fatalf("echo failed: %v", err)
+	}
+	if got, want := string(out), "hello\n"; got != want {
+		t.Errorf("echo got output %q, want %q", got, want)
+	}
+
+	res, err := s.Run("sh", "-c", "echo failing >&2; exit 3")
+	if err != nil {
+		t.Fatalf("error running sh: %v", err)
+	}
+	if !res.Status.Exited() || res.Status.ExitStatus() != 3 {
+		t.Errorf("sh got status %v, want exit status 3", res.Status)
+	}
+	if got, want := string(res.Output), "failing\n"; got != want {
+		t.Errorf("sh got output %q, want %q", got, want)
+	}
+	if _, err := s.Output("sh", "-c", "exit 3"); err == nil {
+		t.Errorf("Output succeeded for a failing command, want error")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	s := Start(t, Options{})
+
+	// /etc is a tmpfs in the default spec, so changes to it stay in the
+	// sandbox.
+	opts := SnapshotOpts{
+		Dirs:  []string{"/etc"},
+		Files: []string{"/proc/self/mounts"},
+	}
+	before, err := s.Snapshot(opts)
+	if err != nil {
+		t.Fatalf("error taking snapshot: %v", err)
+	}
+	if fi, ok := before.Files["/etc"]; !ok || fi.Type != "d" {
+		t.Errorf("got /etc %+v, want a directory", fi)
+	}
+	if before.Contents["/proc/self/mounts"] == "" {
+		t.Errorf("got empty /proc/self/mounts")
+	}
+
+	if _, err := s.Output("sh", "-c", "mkdir -m 0750 /etc/dir && echo data > /etc/dir/file && ln -s dir /etc/link"); err != nil {
+		t.Fatalf("error changing /etc: %v", err)
+	}
+	after, err := s.Snapshot(opts)
+	if err != nil {
+		t.Fatalf("error taking snapshot: %v", err)
+	}
+	want := []Change{
+		{Path: "/etc/dir", Kind: Added, After: "type=d mode=0750 size=0"},
+		{Path: "/etc/dir/file", Kind: Added, After: "type=f mode=0644 size=5"},
+		{Path: "/etc/link", Kind: Added, After: "type=l mode=0777 size=3 target=dir"},
+	}
+	var got []Change
+	for _, c := range Diff(before, after) {
+		// The tmpfs directory's own metadata may change as well.
+		if c.Path != "/etc" {
+			got = append(got, c)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff got %v, want %v", got, want)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s := Start(t, Options{})
+
+	all, err := s.Metrics("")
+	if err != nil {
+		t.Fatalf("error exporting metrics: %v", err)
+	}
+	if len(all) == 0 {
+		t.Errorf("got no metrics")
+	}
+	none, err := s.Metrics("^no_such_metric$")
+	if err != nil {
+		t.Fatalf("error exporting metrics: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("got metrics %v for a filter matching none", none)
+	}
+}
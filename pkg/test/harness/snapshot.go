@@ -0,0 +1,326 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/fspath"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+)
+
+// SnapshotOpts selects the guest state recorded by Sandbox.Snapshot.
+type SnapshotOpts struct {
+	// Dirs are guest directories whose trees are recorded. Only metadata is
+	// recorded, not file contents. Trees are not followed across mount
+	// points, but mount points themselves are recorded.
+	Dirs []string
+
+	// Files are guest files whose contents are recorded, typically procfs or
+	// sysfs files such as /proc/self/mounts.
+	Files []string
+}
+
+// FileInfo is the recorded metadata of a file.
+type FileInfo struct {
+	// Type is the file type, as printed by find -printf %y: "f" for regular
+	// files, "d" for directories, "l" for symbolic links, etc.
+	Type string
+
+	// Mode is the file's permission bits, including the setuid, setgid and
+	// sticky bits.
+	Mode uint32
+
+	// Size is the file's size in bytes. It is not recorded for directories,
+	// whose size is filesystem-specific.
+	Size int64
+
+	// Target is the target of a symbolic link.
+	Target string
+}
+
+// String implements fmt.Stringer.String.
+func (fi FileInfo) String() string {
+	s := fmt.Sprintf("type=%s mode=%#o size=%d", fi.Type, fi.Mode, fi.Size)
+	if fi.Target != "" {
+		s += " target=" + fi.Target
+	}
+	return s
+}
+
+// Snapshot is recorded guest state.
+type Snapshot struct {
+	// Files maps the paths of files in SnapshotOpts.Dirs to their metadata.
+	Files map[string]FileInfo
+
+	// Contents maps the paths in SnapshotOpts.Files to their contents.
+	Contents map[string]string
+}
+
+// Snapshot records the guest state selected by opts. Directory trees are read
+// through the sentry's VFS, as seen by the root container's init process;
+// files are read by running cat in the root container, so that per-process
+// files such as /proc/self/mounts describe a process in the container.
+func (s *Sandbox) Snapshot(opts SnapshotOpts) (*Snapshot, error) {
+	snap := &Snapshot{
+		Files:    make(map[string]FileInfo),
+		Contents: make(map[string]string),
+	}
+	if len(opts.Dirs) > 0 {
+		k := s.Kernel()
+		var leader *kernel.Task
+		if tg := k.GlobalInit(); tg != nil {
+			leader = tg.Leader()
+		}
+		if leader == nil {
+			return nil, fmt.Errorf("root container is not running")
+		}
+		mntns := leader.GetMountNamespace()
+		if mntns == nil {
+			return nil, fmt.Errorf("root container has exited")
+		}
+		ctx := k.SupervisorContext()
+		defer mntns.DecRef(ctx)
+		root := mntns.Root(ctx)
+		defer root.DecRef(ctx)
+		w := walker{
+			ctx:   ctx,
+			vfs:   k.VFS(),
+			creds: leader.Credentials(),
+			root:  root,
+			files: snap.Files,
+		}
+		for _, dir := range opts.Dirs {
+			if err := w.walkTree(dir); err != nil {
+				return nil, fmt.Errorf("listing %q: %w", dir, err)
+			}
+		}
+	}
+	for _, file := range opts.Files {
+		out, err := s.Output("cat", file)
+		if err != nil {
+			return nil, fmt.Errorf("reading %q: %w", file, err)
+		}
+		snap.Contents[file] = string(out)
+	}
+	return snap, nil
+}
+
+// walker records directory trees into files.
+type walker struct {
+	ctx   context.Context
+	vfs   *vfs.VirtualFilesystem
+	creds *auth.Credentials
+	root  vfs.VirtualDentry
+	files map[string]FileInfo
+}
+
+// pop returns a PathOperation for p, which doesn't follow a final symlink.
+func (w *walker) pop(p string) *vfs.PathOperation {
+	return &vfs.PathOperation{
+		Root:  w.root,
+		Start: w.root,
+		Path:  fspath.Parse(p),
+	}
+}
+
+// mount returns the mount that contains p.
+func (w *walker) mount(p string) (*vfs.Mount, error) {
+	vd, err := w.vfs.GetDentryAt(w.ctx, w.creds, w.pop(p), &vfs.GetDentryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer vd.DecRef(w.ctx)
+	return vd.Mount(), nil
+}
+
+// walkTree records dir and, like find -xdev, the files below it that are on
+// the same mount.
+func (w *walker) walkTree(dir string) error {
+	dir = path.Clean(dir)
+	mnt, err := w.mount(dir)
+	if err != nil {
+		return err
+	}
+	return w.walk(dir, mnt)
+}
+
+func (w *walker) walk(p string, mnt *vfs.Mount) error {
+	stat, err := w.vfs.StatAt(w.ctx, w.creds, w.pop(p), &vfs.StatOptions{
+		Mask: linux.STATX_TYPE | linux.STATX_MODE | linux.STATX_SIZE,
+	})
+	if err != nil {
+		return fmt.Errorf("stat %q: %w", p, err)
+	}
+	mode := linux.FileMode(stat.Mode)
+	fi := FileInfo{
+		Type: fileType(mode),
+		Mode: uint32(mode &^ linux.FileTypeMask),
+	}
+	if fi.Type != "d" {
+		fi.Size = int64(stat.Size)
+	}
+	if fi.Type == "l" {
+		if fi.Target, err = w.vfs.ReadlinkAt(w.ctx, w.creds, w.pop(p)); err != nil {
+			return fmt.Errorf("readlink %q: %w", p, err)
+		}
+	}
+	w.files[p] = fi
+	if fi.Type != "d" {
+		return nil
+	}
+
+	// Don't descend into other mounts.
+	m, err := w.mount(p)
+	if err != nil {
+		return fmt.Errorf("resolving %q: %w", p, err)
+	}
+	if m != mnt {
+		return nil
+	}
+	fd, err := w.vfs.OpenAt(w.ctx, w.creds, w.pop(p), &vfs.OpenOptions{
+		Flags: linux.O_RDONLY | linux.O_DIRECTORY,
+	})
+	if err != nil {
+		return fmt.Errorf("open %q: %w", p, err)
+	}
+	var names []string
+	err = fd.IterDirents(w.ctx, vfs.IterDirentsCallbackFunc(func(dirent vfs.Dirent) error {
+		if dirent.Name != "." && dirent.Name != ".." {
+			names = append(names, dirent.Name)
+		}
+		return nil
+	}))
+	fd.DecRef(w.ctx)
+	if err != nil {
+		return fmt.Errorf("reading directory %q: %w", p, err)
+	}
+	for _, name := range names {
+		if err := w.walk(path.Join(p, name), mnt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileType returns the type of a file with the given mode, as printed by find
+// -printf %y.
+func fileType(mode linux.FileMode) string {
+	switch mode.FileType() {
+	case linux.ModeRegular:
+		return "f"
+	case linux.ModeDirectory:
+		return "d"
+	case linux.ModeSymlink:
+		return "l"
+	case linux.ModeNamedPipe:
+		return "p"
+	case linux.ModeSocket:
+		return "s"
+	case linux.ModeCharacterDevice:
+		return "c"
+	case linux.ModeBlockDevice:
+		return "b"
+	default:
+		return "U"
+	}
+}
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+// Kinds of changes.
+const (
+	Added ChangeKind = iota
+	Removed
+	Modified
+)
+
+// String implements fmt.Stringer.String.
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change is a difference between two snapshots.
+type Change struct {
+	// Path is the path of the file that changed.
+	Path string
+
+	// Kind is the kind of change.
+	Kind ChangeKind
+
+	// Before and After describe the file's metadata or, for files in
+	// SnapshotOpts.Files, its contents, in the two snapshots. Before is empty
+	// for added files and After is empty for removed files.
+	Before string
+	After  string
+}
+
+// String implements fmt.Stringer.String.
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("%s: added (%s)", c.Path, c.After)
+	case Removed:
+		return fmt.Sprintf("%s: removed (was %s)", c.Path, c.Before)
+	default:
+		return fmt.Sprintf("%s: modified (%s => %s)", c.Path, c.Before, c.After)
+	}
+}
+
+// Diff returns the differences between two snapshots, sorted by path.
+func Diff(before, after *Snapshot) []Change {
+	var changes []Change
+	changes = diffMaps(changes, before.Files, after.Files)
+	changes = diffMaps(changes, before.Contents, after.Contents)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// diffMaps appends the differences between before and after to changes.
+func diffMaps[V comparable](changes []Change, before, after map[string]V) []Change {
+	for path, b := range before {
+		a, ok := after[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: path, Kind: Removed, Before: fmt.Sprint(b)})
+		case a != b:
+			changes = append(changes, Change{Path: path, Kind: Modified, Before: fmt.Sprint(b), After: fmt.Sprint(a)})
+		}
+	}
+	for path, a := range after {
+		if _, ok := before[path]; !ok {
+			changes = append(changes, Change{Path: path, Kind: Added, After: fmt.Sprint(a)})
+		}
+	}
+	return changes
+}
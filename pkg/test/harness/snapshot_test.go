@@ -0,0 +1,79 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness
+
+import (
+	"reflect"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+)
+
+func TestDiff(t *testing.T) {
+	before := &Snapshot{
+		Files: map[string]FileInfo{
+			"/tmp":         {Type: "d", Mode: 01777},
+			"/tmp/removed": {Type: "f", Mode: 0644},
+			"/tmp/same":    {Type: "f", Mode: 0644, Size: 1},
+			"/tmp/chmod":   {Type: "f", Mode: 0644},
+		},
+		Contents: map[string]string{
+			"/proc/self/mounts": "a\n",
+		},
+	}
+	after := &Snapshot{
+		Files: map[string]FileInfo{
+			"/tmp":       {Type: "d", Mode: 01777},
+			"/tmp/added": {Type: "d", Mode: 0755},
+			"/tmp/same":  {Type: "f", Mode: 0644, Size: 1},
+			"/tmp/chmod": {Type: "f", Mode: 0600},
+		},
+		Contents: map[string]string{
+			"/proc/self/mounts": "a\nb\n",
+		},
+	}
+	want := []Change{
+		{Path: "/proc/self/mounts", Kind: Modified, Before: "a\n", After: "a\nb\n"},
+		{Path: "/tmp/added", Kind: Added, After: "type=d mode=0755 size=0"},
+		{Path: "/tmp/chmod", Kind: Modified, Before: "type=f mode=0644 size=0", After: "type=f mode=0600 size=0"},
+		{Path: "/tmp/removed", Kind: Removed, Before: "type=f mode=0644 size=0"},
+	}
+	if got := Diff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff got %v, want %v", got, want)
+	}
+	if got := Diff(after, after); len(got) != 0 {
+		t.Errorf("Diff of identical snapshots got %v, want none", got)
+	}
+}
+
+func TestFileType(t *testing.T) {
+	for _, tc := range []struct {
+		mode linux.FileMode
+		want string
+	}{
+		{mode: linux.ModeRegular | 0644, want: "f"},
+		{mode: linux.ModeDirectory | 01777, want: "d"},
+		{mode: linux.ModeSymlink | 0777, want: "l"},
+		{mode: linux.ModeNamedPipe | 0600, want: "p"},
+		{mode: linux.ModeSocket | 0755, want: "s"},
+		{mode: linux.ModeCharacterDevice | 0666, want: "c"},
+		{mode: linux.ModeBlockDevice | 0660, want: "b"},
+		{mode: 0644, want: "U"},
+	} {
+		if got := fileType(tc.mode); got != tc.want {
+			t.Errorf("fileType(%#o) = %q, want %q", tc.mode, got, tc.want)
+		}
+	}
+}
@@ -45,6 +45,9 @@ var ErrTooManyFiles = errors.New("too many files")
 // ErrUnknownMethod is returned when a method is not known.
 var ErrUnknownMethod = errors.New("unknown method")
 
+// ErrPermissionDenied is returned when the server's Authorizer rejects a call.
+var ErrPermissionDenied = errors.New("permission denied")
+
 // errStopped is an internal error indicating the server has been stopped.
 var errStopped = errors.New("stopped")
 
@@ -109,12 +112,14 @@ type filePayloader interface {
 type clientCall struct {
 	Method string `json:"method"`
 	Arg    any    `json:"arg"`
+	Token  string `json:"token,omitempty"`
 }
 
 // serverCall is the client=>server method call on the server side.
 type serverCall struct {
 	Method string          `json:"method"`
 	Arg    json.RawMessage `json:"arg"`
+	Token  string          `json:"token,omitempty"`
 }
 
 // callResult is the server=>client method call result.
@@ -182,8 +187,16 @@ type Server struct {
 
 	// afterRPCCallback is called after each RPC is successfully completed.
 	afterRPCCallback func()
+
+	// authorizer, if set, is consulted before each call is dispatched.
+	authorizer Authorizer
 }
 
+// Authorizer decides whether a client that presented token (see Client.Token)
+// may invoke method. It returns nil if the call is allowed, or an error
+// describing why it was rejected.
+type Authorizer func(method, token string) error
+
 // NewServer returns a new server.
 func NewServer() *Server {
 	return NewServerWithCallback(nil)
@@ -199,6 +212,28 @@ func NewServerWithCallback(afterRPCCallback func()) *Server {
 	}
 }
 
+// SetAuthorizer installs an Authorizer that is consulted before each call is
+// dispatched. Calls rejected by the authorizer fail with ErrPermissionDenied.
+func (s *Server) SetAuthorizer(a Authorizer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authorizer = a
+}
+
+// authorize checks whether a client that presented token may invoke method.
+func (s *Server) authorize(method, token string) error {
+	s.mu.Lock()
+	a := s.authorizer
+	s.mu.Unlock()
+	if a == nil {
+		return nil
+	}
+	if err := a(method, token); err != nil {
+		return fmt.Errorf("%w: %v", ErrPermissionDenied, err)
+	}
+	return nil
+}
+
 // Stopper is an optional interface, that when implemented, allows an object
 // to have a callback executed when the server is shutting down.
 type Stopper interface {
@@ -326,6 +361,13 @@ func (s *Server) handleOne(client *unet.Socket) error {
 		return marshal(client, &result, nil)
 	}
 
+	// Check that the client is allowed to make this call.
+	if err := s.authorize(c.Method, c.Token); err != nil {
+		log.Warningf("urpc: rejected call to method %s: %v", c.Method, err)
+		result.Err = err.Error()
+		return marshal(client, &result, nil)
+	}
+
 	// Unmarshal the arguments now that we know the type.
 	na := reflect.New(rm.argType.Elem())
 	if err := json.Unmarshal(c.Arg, na.Interface()); err != nil {
@@ -536,6 +578,10 @@ type Client struct {
 	// This _must_ be provided and must be closed manually by calling
 	// Close.
 	Socket *unet.Socket
+
+	// Token, if set, is presented to the server with each call, for the
+	// server's Authorizer to authenticate the client.
+	Token string
 }
 
 // NewClient returns a new client.
@@ -657,7 +703,7 @@ func (c *Client) Call(method string, arg any, result any) error {
 	}
 
 	// Marshal the data.
-	if err := marshal(c.Socket, &clientCall{Method: method, Arg: arg}, fs); err != nil {
+	if err := marshal(c.Socket, &clientCall{Method: method, Arg: arg, Token: c.Token}, fs); err != nil {
 		return err
 	}
 
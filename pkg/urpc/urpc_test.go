@@ -16,7 +16,9 @@ package urpc
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"gvisor.dev/gvisor/pkg/unet"
@@ -208,3 +210,40 @@ func TestTooManyFiles(t *testing.T) {
 		t.Errorf("expected too many files, got %v", err.Error())
 	}
 }
+
+func TestAuthorizer(t *testing.T) {
+	serverSock, clientSock, err := unet.SocketPair(false)
+	if err != nil {
+		t.Fatalf("error creating socket pair: %v", err)
+	}
+	s := NewServer()
+	s.Register(test{})
+	s.SetAuthorizer(func(method, token string) error {
+		if token != "secret" {
+			return fmt.Errorf("unexpected token %q", token)
+		}
+		if method != "test.Func" {
+			return fmt.Errorf("method %s not allowed", method)
+		}
+		return nil
+	})
+	s.StartHandling(serverSock)
+	c := NewClient(clientSock)
+	defer c.Close()
+
+	var r testResult
+	if err := c.Call("test.Func", &testArg{StringArg: "hello"}, &r); err == nil {
+		t.Errorf("call without a token succeeded, want permission denied")
+	} else if !strings.Contains(err.Error(), ErrPermissionDenied.Error()) {
+		t.Errorf("expected permission denied error, got %v", err)
+	}
+	c.Token = "secret"
+	if err := c.Call("test.Func", &testArg{StringArg: "hello"}, &r); err != nil {
+		t.Errorf("allowed call failed: %v", err)
+	}
+	if err := c.Call("test.Err", &testArg{}, &r); err == nil {
+		t.Errorf("expected non-nil err, got nil")
+	} else if !strings.Contains(err.Error(), ErrPermissionDenied.Error()) {
+		t.Errorf("expected permission denied error, got %v", err)
+	}
+}
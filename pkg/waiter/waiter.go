@@ -144,6 +144,12 @@ type Entry struct {
 
 	// mask should be immutable once queued.
 	mask EventMask
+
+	// exclusive indicates that the entry belongs to a waiter that will
+	// consume the event it is woken for, so that Queue.NotifyExclusive may
+	// avoid waking other exclusive waiters. exclusive should be immutable
+	// once queued.
+	exclusive bool
 }
 
 // Init initializes the Entry.
@@ -159,6 +165,24 @@ func (e *Entry) Mask() EventMask {
 	return e.mask
 }
 
+// SetExclusive marks the entry as exclusive. Exclusive entries are woken by
+// Queue.Notify like any other entry, but Queue.NotifyExclusive wakes only a
+// limited number of them. This is analogous to Linux's WQ_FLAG_EXCLUSIVE.
+//
+// Waiters using exclusive entries must retry the operation they are waiting
+// for after each wakeup, since a wakeup that is not acted upon is not passed
+// on to other exclusive waiters by the Queue.
+//
+// This must only be called when unregistered.
+func (e *Entry) SetExclusive() {
+	e.exclusive = true
+}
+
+// Exclusive returns true if the entry is exclusive.
+func (e *Entry) Exclusive() bool {
+	return e.exclusive
+}
+
 // NotifyEvent notifies the event listener.
 //
 // Mask should be the full set of active events.
@@ -208,21 +232,35 @@ func NewFunctionEntry(mask EventMask, fn func(EventMask)) (e Entry) {
 //
 // +stateify savable
 type Queue struct {
+	// list contains non-exclusive entries.
 	list waiterList
-	mu   sync.RWMutex `state:"nosave"`
+
+	// exclusiveList contains exclusive entries, in the order in which they
+	// should next be woken by NotifyExclusive.
+	exclusiveList waiterList
+
+	mu sync.RWMutex `state:"nosave"`
 }
 
 // EventRegister adds a waiter to the wait queue.
 func (q *Queue) EventRegister(e *Entry) {
 	q.mu.Lock()
-	q.list.PushBack(e)
+	if e.exclusive {
+		q.exclusiveList.PushBack(e)
+	} else {
+		q.list.PushBack(e)
+	}
 	q.mu.Unlock()
 }
 
 // EventUnregister removes the given waiter entry from the wait queue.
 func (q *Queue) EventUnregister(e *Entry) {
 	q.mu.Lock()
-	q.list.Remove(e)
+	if e.exclusive {
+		q.exclusiveList.Remove(e)
+	} else {
+		q.list.Remove(e)
+	}
 	q.mu.Unlock()
 }
 
@@ -230,14 +268,46 @@ func (q *Queue) EventUnregister(e *Entry) {
 // in common with the notification mask.
 func (q *Queue) Notify(mask EventMask) {
 	q.mu.RLock()
-	for e := q.list.Front(); e != nil; e = e.Next() {
+	notifyList(&q.list, mask)
+	notifyList(&q.exclusiveList, mask)
+	q.mu.RUnlock()
+}
+
+// NotifyExclusive notifies all non-exclusive waiters in the queue whose masks
+// have at least one bit in common with the notification mask, and at most n
+// such exclusive waiters. Exclusive waiters are woken in FIFO order: each
+// woken exclusive waiter is moved to the end of the queue, so that repeated
+// calls to NotifyExclusive do not starve any waiter.
+func (q *Queue) NotifyExclusive(mask EventMask, n int) {
+	q.mu.Lock()
+	notifyList(&q.list, mask)
+	if n > 0 {
+		var woken waiterList
+		for e := q.exclusiveList.Front(); e != nil && n > 0; {
+			next := e.Next()
+			if m := mask & e.mask; m != 0 {
+				e.eventListener.NotifyEvent(m) // Skip intermediate call.
+				q.exclusiveList.Remove(e)
+				woken.PushBack(e)
+				n--
+			}
+			e = next
+		}
+		q.exclusiveList.PushBackList(&woken)
+	}
+	q.mu.Unlock()
+}
+
+// notifyList notifies all waiters in l whose masks have at least one bit in
+// common with mask.
+func notifyList(l *waiterList, mask EventMask) {
+	for e := l.Front(); e != nil; e = e.Next() {
 		m := mask & e.mask
 		if m == 0 {
 			continue
 		}
 		e.eventListener.NotifyEvent(m) // Skip intermediate call.
 	}
-	q.mu.RUnlock()
 }
 
 // Events returns the set of events being waited on. It is the union of the
@@ -249,6 +319,9 @@ func (q *Queue) Events() EventMask {
 	for e := q.list.Front(); e != nil; e = e.Next() {
 		ret |= e.mask
 	}
+	for e := q.exclusiveList.Front(); e != nil; e = e.Next() {
+		ret |= e.mask
+	}
 	return ret
 }
 
@@ -256,7 +329,7 @@ func (q *Queue) Events() EventMask {
 func (q *Queue) IsEmpty() bool {
 	q.mu.RLock()
 	defer q.mu.RUnlock()
-	return q.list.Front() == nil
+	return q.list.Front() == nil && q.exclusiveList.Front() == nil
 }
 
 // NeverReady implements the Waitable interface but is never ready. Otherwise,
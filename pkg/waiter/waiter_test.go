@@ -180,3 +180,63 @@ func TestConcurrentNotification(t *testing.T) {
 		t.Errorf("cnt = %d, want %d", cnt.Load(), concurrency*waiterCount)
 	}
 }
+
+func TestNotifyExclusive(t *testing.T) {
+	var q Queue
+
+	// Register a non-exclusive waiter and three exclusive waiters.
+	var nonExclusive int
+	e := NewFunctionEntry(EventIn, func(EventMask) { nonExclusive++ })
+	q.EventRegister(&e)
+	defer q.EventUnregister(&e)
+
+	var woken []int
+	var entries [3]Entry
+	for i := range entries {
+		i := i
+		entries[i] = NewFunctionEntry(EventIn, func(EventMask) { woken = append(woken, i) })
+		entries[i].SetExclusive()
+		q.EventRegister(&entries[i])
+		defer q.EventUnregister(&entries[i])
+	}
+
+	// Exclusive waiters should be woken in FIFO order, and the non-exclusive
+	// waiter should be woken every time.
+	q.NotifyExclusive(EventIn, 2)
+	q.NotifyExclusive(EventIn, 2)
+	if want := []int{0, 1, 2, 0}; !intsEqual(woken, want) {
+		t.Errorf("Exclusive waiters woken: got %v, want %v", woken, want)
+	}
+	if nonExclusive != 2 {
+		t.Errorf("Non-exclusive waiter woken %d times, want 2", nonExclusive)
+	}
+
+	// Notify should wake all waiters.
+	woken = nil
+	q.Notify(EventIn)
+	if len(woken) != 3 {
+		t.Errorf("Notify woke %d exclusive waiters, want 3", len(woken))
+	}
+	if nonExclusive != 3 {
+		t.Errorf("Non-exclusive waiter woken %d times, want 3", nonExclusive)
+	}
+
+	// Exclusive waiters with disjoint masks should be skipped.
+	woken = nil
+	q.NotifyExclusive(EventOut, 3)
+	if len(woken) != 0 {
+		t.Errorf("NotifyExclusive with disjoint mask woke %v", woken)
+	}
+}
+
+func intsEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
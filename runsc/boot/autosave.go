@@ -26,7 +26,6 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/strace"
 	"gvisor.dev/gvisor/pkg/sync"
 	"gvisor.dev/gvisor/runsc/specutils"
-	"gvisor.dev/gvisor/runsc/version"
 )
 
 func getTargetForSaveResume(l *Loader) func(k *kernel.Kernel) {
@@ -39,9 +38,11 @@ func getTargetForSaveResume(l *Loader) func(k *kernel.Kernel) {
 			Autosave:    true,
 			Resume:      true,
 			Destination: &buf,
-			Metadata:    map[string]string{VersionKey: version.Version()},
+			Metadata:    make(map[string]string),
 		}
-		specsStr, err := specutils.ConvertSpecsToString(l.GetContainerSpecs())
+		containerSpecs := l.GetContainerSpecs()
+		saveCompatMetadata(saveOpts.Metadata, l.root.conf, containerSpecs)
+		specsStr, err := specutils.ConvertSpecsToString(containerSpecs)
 		if err != nil {
 			panic(fmt.Sprintf("error to get container specs from metadata, %v", err))
 		}
@@ -62,13 +63,15 @@ func getTargetForSaveRestore(l *Loader, files []*fd.FD) func(k *kernel.Kernel) {
 				Autosave:    true,
 				Resume:      false,
 				Destination: files[0],
-				Metadata:    map[string]string{VersionKey: version.Version()},
+				Metadata:    make(map[string]string),
 			}
 			if len(files) == 3 {
 				saveOpts.PagesMetadata = files[1]
 				saveOpts.PagesFile = files[2]
 			}
-			specsStr, err := specutils.ConvertSpecsToString(l.GetContainerSpecs())
+			containerSpecs := l.GetContainerSpecs()
+			saveCompatMetadata(saveOpts.Metadata, l.root.conf, containerSpecs)
+			specsStr, err := specutils.ConvertSpecsToString(containerSpecs)
 			if err != nil {
 				panic(fmt.Sprintf("error to get container specs from metadata, %v", err))
 			}
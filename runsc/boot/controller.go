@@ -30,9 +30,12 @@ import (
 	"gvisor.dev/gvisor/pkg/fd"
 	"gvisor.dev/gvisor/pkg/fspath"
 	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sandbox/api"
 	"gvisor.dev/gvisor/pkg/sentry/control"
 	"gvisor.dev/gvisor/pkg/sentry/fsimpl/erofs"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/pgalloc"
 	"gvisor.dev/gvisor/pkg/sentry/seccheck"
 	"gvisor.dev/gvisor/pkg/sentry/socket/netstack"
 	"gvisor.dev/gvisor/pkg/sentry/socket/plugin"
@@ -44,12 +47,15 @@ import (
 	"gvisor.dev/gvisor/runsc/config"
 	"gvisor.dev/gvisor/runsc/specutils"
 	"gvisor.dev/gvisor/runsc/starttime"
-	"gvisor.dev/gvisor/runsc/version"
 )
 
 const (
+	// ContMgrAdoptRoot binds the root container of a created, but not yet
+	// started, sandbox to a new container.
+	ContMgrAdoptRoot = "containerManager.AdoptRoot"
+
 	// ContMgrCheckpoint checkpoints a container.
-	ContMgrCheckpoint = "containerManager.Checkpoint"
+	ContMgrCheckpoint = api.ContMgrCheckpoint
 
 	// ContMgrCreateSubcontainer creates a sub-container.
 	ContMgrCreateSubcontainer = "containerManager.CreateSubcontainer"
@@ -59,16 +65,16 @@ const (
 	ContMgrDestroySubcontainer = "containerManager.DestroySubcontainer"
 
 	// ContMgrEvent gets stats about the container used by "runsc events".
-	ContMgrEvent = "containerManager.Event"
+	ContMgrEvent = api.ContMgrEvent
 
 	// ContMgrExecuteAsync executes a command in a container.
-	ContMgrExecuteAsync = "containerManager.ExecuteAsync"
+	ContMgrExecuteAsync = api.ContMgrExecuteAsync
 
 	// ContMgrPortForward starts port forwarding with the sandbox.
 	ContMgrPortForward = "containerManager.PortForward"
 
 	// ContMgrProcesses lists processes running in a container.
-	ContMgrProcesses = "containerManager.Processes"
+	ContMgrProcesses = api.ContMgrProcesses
 
 	// ContMgrRestore restores a container from a statefile.
 	ContMgrRestore = "containerManager.Restore"
@@ -88,13 +94,20 @@ const (
 	// ContMgrStartSubcontainer starts a sub-container inside a running sandbox.
 	ContMgrStartSubcontainer = "containerManager.StartSubcontainer"
 
+	// ContMgrUpgrade saves the sandbox and hands its host FDs over to a new
+	// sandbox process.
+	ContMgrUpgrade = "containerManager.Upgrade"
+
+	// ContMgrUpgradeAbort resumes a sandbox saved by ContMgrUpgrade.
+	ContMgrUpgradeAbort = "containerManager.UpgradeAbort"
+
 	// ContMgrWait waits on the init process of the container and returns its
 	// ExitStatus.
 	ContMgrWait = "containerManager.Wait"
 
 	// ContMgrWaitPID waits on a process with a certain PID in the sandbox and
 	// return its ExitStatus.
-	ContMgrWaitPID = "containerManager.WaitPID"
+	ContMgrWaitPID = api.ContMgrWaitPID
 
 	// ContMgrWaitCheckpoint waits for the next Kernel checkpoint to complete.
 	ContMgrWaitCheckpoint = "containerManager.WaitCheckpoint"
@@ -122,8 +135,27 @@ const (
 
 	// ContMgrContainerRuntimeState returns the runtime state of a container.
 	ContMgrContainerRuntimeState = "containerManager.ContainerRuntimeState"
+
+	// ContMgrMountIOStats returns per-mount I/O statistics of a container.
+	ContMgrMountIOStats = api.ContMgrMountIOStats
+
+	// ContMgrContainerIOStats returns the I/O statistics of a container.
+	ContMgrContainerIOStats = api.ContMgrContainerIOStats
+
+	// ContMgrAPIVersion returns the version of the control API implemented by
+	// the sandbox.
+	ContMgrAPIVersion = api.ContMgrAPIVersion
+)
+
+// Control API version. See api.ControlAPIMajor.
+const (
+	ControlAPIMajor = api.ControlAPIMajor
+	ControlAPIMinor = api.ControlAPIMinor
 )
 
+// APIVersion is the result of the ContMgrAPIVersion RPC.
+type APIVersion = api.APIVersion
+
 const (
 	// NetworkCreateLinksAndRoutes creates links and routes in a network stack.
 	NetworkCreateLinksAndRoutes = "Network.CreateLinksAndRoutes"
@@ -131,17 +163,28 @@ const (
 	// NetworkInitPluginStack initializes third-party network stack.
 	NetworkInitPluginStack = "Network.InitPluginStack"
 
+	// NetworkCreateSharedMemLink creates a link to another sandbox over
+	// shared memory.
+	NetworkCreateSharedMemLink = "Network.CreateSharedMemLink"
+
 	// DebugStacks collects sandbox stacks for debugging.
 	DebugStacks = "debug.Stacks"
+
+	// DebugFlightRecord collects the contents of the tasks' flight recorders.
+	DebugFlightRecord = "debug.FlightRecord"
+
+	// DebugFDs returns the inventory of host FDs held by the sentry at
+	// startup.
+	DebugFDs = "debug.FDs"
 )
 
 // Profiling related commands (see pprof.go for more details).
 const (
-	ProfileCPU   = "Profile.CPU"
-	ProfileHeap  = "Profile.Heap"
-	ProfileBlock = "Profile.Block"
-	ProfileMutex = "Profile.Mutex"
-	ProfileTrace = "Profile.Trace"
+	ProfileCPU   = api.ProfileCPU
+	ProfileHeap  = api.ProfileHeap
+	ProfileBlock = api.ProfileBlock
+	ProfileMutex = api.ProfileMutex
+	ProfileTrace = api.ProfileTrace
 )
 
 // Logging related commands (see logging.go for more details).
@@ -149,6 +192,18 @@ const (
 	LoggingChange = "Logging.Change"
 )
 
+// Fault injection related commands (see chaos.go for more details).
+const (
+	ChaosSetRules = "Chaos.SetRules"
+	ChaosRules    = "Chaos.Rules"
+)
+
+// Time related commands (see time.go for more details).
+const (
+	TimeSetDilation = "Time.SetDilation"
+	TimeAdvance     = "Time.Advance"
+)
+
 // Usage related commands (see usage.go for more details).
 const (
 	UsageCollect = "Usage.Collect"
@@ -175,6 +230,9 @@ type controller struct {
 
 	// manager holds the containerManager methods.
 	manager *containerManager
+
+	// logging holds the Logging methods.
+	logging *control.Logging
 }
 
 // newController creates a new controller. The caller must call
@@ -202,22 +260,29 @@ func (c *controller) registerHandlers() {
 	c.srv.Register(c.manager)
 	c.srv.Register(&control.Cgroups{Kernel: l.k})
 	c.srv.Register(&control.Lifecycle{Kernel: l.k})
-	c.srv.Register(&control.Logging{})
+	c.logging = control.NewLogging(l.watchdog)
+	c.srv.Register(c.logging)
 	c.srv.Register(&control.Proc{Kernel: l.k})
 	c.srv.Register(&control.State{Kernel: l.k})
 	c.srv.Register(&control.Usage{Kernel: l.k})
 	c.srv.Register(&control.Metrics{})
-	c.srv.Register(&debug{})
+	c.srv.Register(&control.Chaos{})
+	c.srv.Register(&debug{k: l.k, hostFDs: l.hostFDs})
+	if l.root.conf.TestOnlyTimeDilation != "" {
+		c.srv.Register(&control.Time{Kernel: l.k})
+	}
 
 	if eps, ok := l.k.RootNetworkNamespace().Stack().(*netstack.Stack); ok {
 		c.srv.Register(&Network{
-			Stack:  eps.Stack,
-			Kernel: l.k,
+			Stack:    eps.Stack,
+			Kernel:   l.k,
+			handover: l.handover,
+			dhcp:     l.dhcp,
 		})
 	}
 
 	if pluginStack, ok := l.k.RootNetworkNamespace().Stack().(plugin.PluginStack); ok {
-		c.srv.Register(&Network{PluginStack: pluginStack})
+		c.srv.Register(&Network{PluginStack: pluginStack, handover: l.handover})
 	}
 
 	if l.root.conf.ProfileEnable {
@@ -266,6 +331,36 @@ func (cm *containerManager) StartRoot(cid *string, _ *struct{}) error {
 	return cm.onStart()
 }
 
+// APIVersion returns the version of the control API served by the sandbox.
+func (cm *containerManager) APIVersion(_ *struct{}, out *APIVersion) error {
+	*out = APIVersion{Major: ControlAPIMajor, Minor: ControlAPIMinor}
+	return nil
+}
+
+// MountIOStats returns the I/O statistics of the mounts in the mount namespace
+// of a container.
+func (cm *containerManager) MountIOStats(cid *string, out *[]api.MountIOStats) error {
+	log.Debugf("containerManager.MountIOStats, cid: %s", *cid)
+	stats, err := cm.l.mountIOStats(*cid)
+	if err != nil {
+		return err
+	}
+	*out = stats
+	return nil
+}
+
+// ContainerIOStats returns the I/O statistics of all reads and writes done by
+// the tasks of a container.
+func (cm *containerManager) ContainerIOStats(cid *string, out *api.IOStats) error {
+	log.Debugf("containerManager.ContainerIOStats, cid: %s", *cid)
+	stats, err := cm.l.containerIOStats(*cid)
+	if err != nil {
+		return err
+	}
+	*out = stats
+	return nil
+}
+
 // onStart notifies that sandbox is ready to start and wait for the result.
 func (cm *containerManager) onStart() error {
 	cm.startChan <- struct{}{}
@@ -276,9 +371,37 @@ func (cm *containerManager) onStart() error {
 }
 
 // Processes retrieves information about processes running in the sandbox.
-func (cm *containerManager) Processes(cid *string, out *[]*control.Process) error {
+func (cm *containerManager) Processes(cid *string, out *[]*api.Process) error {
 	log.Debugf("containerManager.Processes, cid: %s", *cid)
-	return control.Processes(cm.l.k, *cid, out)
+	var pl []*control.Process
+	if err := control.Processes(cm.l.k, *cid, &pl); err != nil {
+		return err
+	}
+	*out = make([]*api.Process, 0, len(pl))
+	for _, p := range pl {
+		*out = append(*out, processToAPI(p))
+	}
+	return nil
+}
+
+// processToAPI converts a process listed by the sentry to its control API
+// representation.
+func processToAPI(p *control.Process) *api.Process {
+	threads := make([]int32, 0, len(p.Threads))
+	for _, tid := range p.Threads {
+		threads = append(threads, int32(tid))
+	}
+	return &api.Process{
+		UID:     uint32(p.UID),
+		PID:     int32(p.PID),
+		PPID:    int32(p.PPID),
+		Threads: threads,
+		C:       p.C,
+		TTY:     p.TTY,
+		STime:   p.STime,
+		Time:    p.Time,
+		Cmd:     p.Cmd,
+	}
 }
 
 // CreateArgs contains arguments to the Create method.
@@ -325,6 +448,10 @@ type StartArgs struct {
 	// IsDevIoFilePresent indicates whether the dev gofer FD is present.
 	IsDevIoFilePresent bool
 
+	// IsVDSOFilePresent indicates whether the FD of the alternate VDSO
+	// selected by VDSOAnnotation is present.
+	IsVDSOFilePresent bool
+
 	// GoferMountConfs contains information about how the gofer mounts have been
 	// configured. The first entry is for rootfs and the following entries are
 	// for bind mounts in Spec.Mounts (in the same order).
@@ -334,103 +461,168 @@ type StartArgs struct {
 	//   * stdin, stdout, and stderr (optional: if terminal is disabled).
 	//   * file descriptors to gofer-backing host files (optional).
 	//   * file descriptor for /dev gofer connection (optional)
+	//   * file descriptor for the alternate VDSO (optional)
 	//   * file descriptors to connect to gofer to serve the root filesystem.
 	urpc.FilePayload
 }
 
-// StartSubcontainer runs a created container within a sandbox.
-func (cm *containerManager) StartSubcontainer(args *StartArgs, _ *struct{}) error {
-	// Validate arguments.
-	if args == nil {
-		return errors.New("start missing arguments")
-	}
-	log.Debugf("containerManager.StartSubcontainer, cid: %s, args: %+v", args.CID, args)
+// startFDs are the FDs donated with StartArgs.
+type startFDs struct {
+	stdios            []*fd.FD
+	goferFilestoreFDs []*fd.FD
+	devGoferFD        *fd.FD
+	vdsoFD            *fd.FD
+	goferFDs          []*fd.FD
+}
+
+// newStartFDs validates args and dups the FDs donated with it. The caller must
+// call startFDs.close() when done.
+func newStartFDs(args *StartArgs) (*startFDs, error) {
 	if args.Spec == nil {
-		return errors.New("start arguments missing spec")
+		return nil, errors.New("start arguments missing spec")
 	}
 	if args.Conf == nil {
-		return errors.New("start arguments missing config")
+		return nil, errors.New("start arguments missing config")
 	}
 	if args.CID == "" {
-		return errors.New("start argument missing container ID")
+		return nil, errors.New("start argument missing container ID")
 	}
 	expectedFDs := 1 // At least one FD for the root filesystem.
 	expectedFDs += args.NumGoferFilestoreFDs
 	if args.IsDevIoFilePresent {
 		expectedFDs++
 	}
+	if args.IsVDSOFilePresent {
+		expectedFDs++
+	}
 	if !args.Spec.Process.Terminal {
 		expectedFDs += 3
 	}
 	if len(args.Files) < expectedFDs {
-		return fmt.Errorf("start arguments must contain at least %d FDs, but only got %d", expectedFDs, len(args.Files))
+		return nil, fmt.Errorf("start arguments must contain at least %d FDs, but only got %d", expectedFDs, len(args.Files))
 	}
 
-	// All validation passed, logs the spec for debugging.
-	specutils.LogSpecDebug(args.Spec, args.Conf.OCISeccomp)
+	fds := &startFDs{}
+	cu := cleanup.Make(fds.close)
+	defer cu.Clean()
 
 	goferFiles := args.Files
-	var stdios []*fd.FD
 	if !args.Spec.Process.Terminal {
 		// When not using a terminal, stdios come as the first 3 files in the
 		// payload.
 		var err error
-		stdios, err = fd.NewFromFiles(goferFiles[:3])
+		fds.stdios, err = fd.NewFromFiles(goferFiles[:3])
 		if err != nil {
-			return fmt.Errorf("error dup'ing stdio files: %w", err)
+			return nil, fmt.Errorf("error dup'ing stdio files: %w", err)
 		}
 		goferFiles = goferFiles[3:]
 	}
-	defer func() {
-		for _, fd := range stdios {
-			_ = fd.Close()
-		}
-	}()
 
-	var goferFilestoreFDs []*fd.FD
 	for i := 0; i < args.NumGoferFilestoreFDs; i++ {
 		goferFilestoreFD, err := fd.NewFromFile(goferFiles[i])
 		if err != nil {
-			return fmt.Errorf("error dup'ing gofer filestore file: %w", err)
+			return nil, fmt.Errorf("error dup'ing gofer filestore file: %w", err)
 		}
-		goferFilestoreFDs = append(goferFilestoreFDs, goferFilestoreFD)
+		fds.goferFilestoreFDs = append(fds.goferFilestoreFDs, goferFilestoreFD)
 	}
 	goferFiles = goferFiles[args.NumGoferFilestoreFDs:]
-	defer func() {
-		for _, fd := range goferFilestoreFDs {
-			_ = fd.Close()
-		}
-	}()
 
-	var devGoferFD *fd.FD
 	if args.IsDevIoFilePresent {
 		var err error
-		devGoferFD, err = fd.NewFromFile(goferFiles[0])
+		fds.devGoferFD, err = fd.NewFromFile(goferFiles[0])
 		if err != nil {
-			return fmt.Errorf("error dup'ing dev gofer file: %w", err)
+			return nil, fmt.Errorf("error dup'ing dev gofer file: %w", err)
 		}
 		goferFiles = goferFiles[1:]
-		defer devGoferFD.Close()
 	}
 
-	goferFDs, err := fd.NewFromFiles(goferFiles)
+	if args.IsVDSOFilePresent {
+		var err error
+		fds.vdsoFD, err = fd.NewFromFile(goferFiles[0])
+		if err != nil {
+			return nil, fmt.Errorf("error dup'ing VDSO file: %w", err)
+		}
+		goferFiles = goferFiles[1:]
+	}
+
+	var err error
+	fds.goferFDs, err = fd.NewFromFiles(goferFiles)
 	if err != nil {
-		return fmt.Errorf("error dup'ing gofer files: %w", err)
+		return nil, fmt.Errorf("error dup'ing gofer files: %w", err)
 	}
-	defer func() {
-		for _, fd := range goferFDs {
+	cu.Release()
+	return fds, nil
+}
+
+// close closes all FDs that are still owned by f.
+func (f *startFDs) close() {
+	for _, fds := range [][]*fd.FD{f.stdios, f.goferFilestoreFDs, f.goferFDs} {
+		for _, fd := range fds {
 			_ = fd.Close()
 		}
-	}()
+	}
+	if f.devGoferFD != nil {
+		_ = f.devGoferFD.Close()
+	}
+	if f.vdsoFD != nil {
+		_ = f.vdsoFD.Close()
+	}
+}
+
+// StartSubcontainer runs a created container within a sandbox.
+func (cm *containerManager) StartSubcontainer(args *StartArgs, _ *struct{}) error {
+	// Validate arguments.
+	if args == nil {
+		return errors.New("start missing arguments")
+	}
+	log.Debugf("containerManager.StartSubcontainer, cid: %s, args: %+v", args.CID, args)
+	fds, err := newStartFDs(args)
+	if err != nil {
+		return err
+	}
+	defer fds.close()
 
-	if err := cm.l.startSubcontainer(args.Spec, args.Conf, args.CID, stdios, goferFDs, goferFilestoreFDs, devGoferFD, args.GoferMountConfs); err != nil {
+	// All validation passed, logs the spec for debugging.
+	specutils.LogSpecDebug(args.Spec, args.Conf.OCISeccomp)
+
+	if err := cm.l.handover.addContainer(args.CID, fds); err != nil {
+		return err
+	}
+	if err := cm.l.startSubcontainer(args.Spec, args.Conf, args.CID, fds.stdios, fds.goferFDs, fds.goferFilestoreFDs, fds.devGoferFD, fds.vdsoFD, args.GoferMountConfs); err != nil {
 		log.Debugf("containerManager.StartSubcontainer failed, cid: %s, args: %+v, err: %v", args.CID, args, err)
+		cm.l.handover.removeContainer(args.CID)
 		return err
 	}
 	log.Debugf("Container started, cid: %s", args.CID)
 	return nil
 }
 
+// AdoptRoot binds the root container of a created, but not yet started,
+// sandbox to the container described by args. The root container is renamed
+// to args.CID, and its spec, root filesystem and stdio are replaced. This
+// allows blank sandboxes to be booted ahead of time and bound to containers
+// when they are needed.
+func (cm *containerManager) AdoptRoot(args *StartArgs, _ *struct{}) error {
+	if args == nil {
+		return errors.New("adopt missing arguments")
+	}
+	log.Debugf("containerManager.AdoptRoot, cid: %s, args: %+v", args.CID, args)
+	fds, err := newStartFDs(args)
+	if err != nil {
+		return err
+	}
+	defer fds.close()
+
+	specutils.LogSpecDebug(args.Spec, args.Conf.OCISeccomp)
+
+	if err := cm.l.adoptRoot(args.Spec, args.CID, fds, args.GoferMountConfs); err != nil {
+		log.Debugf("containerManager.AdoptRoot failed, cid: %s, args: %+v, err: %v", args.CID, args, err)
+		return err
+	}
+	log.Debugf("Root container adopted, cid: %s", args.CID)
+	return nil
+}
+
 // DestroySubcontainer stops a container if it is still running and cleans up
 // its filesystem.
 func (cm *containerManager) DestroySubcontainer(cid *string, _ *struct{}) error {
@@ -440,9 +632,9 @@ func (cm *containerManager) DestroySubcontainer(cid *string, _ *struct{}) error
 
 // ExecuteAsync starts running a command on a created or running sandbox. It
 // returns the PID of the new process.
-func (cm *containerManager) ExecuteAsync(args *control.ExecArgs, pid *int32) error {
+func (cm *containerManager) ExecuteAsync(args *api.ExecArgs, pid *int32) error {
 	log.Debugf("containerManager.ExecuteAsync, cid: %s, args: %+v", args.ContainerID, args)
-	tgid, err := cm.l.executeAsync(args)
+	tgid, err := cm.l.executeAsync(execArgsFromAPI(args))
 	if err != nil {
 		log.Debugf("containerManager.ExecuteAsync failed, cid: %s, args: %+v, err: %v", args.ContainerID, args, err)
 		return err
@@ -451,10 +643,63 @@ func (cm *containerManager) ExecuteAsync(args *control.ExecArgs, pid *int32) err
 	return nil
 }
 
+// execArgsFromAPI converts the arguments of ContMgrExecuteAsync to the
+// sentry's.
+func execArgsFromAPI(args *api.ExecArgs) *control.ExecArgs {
+	eargs := &control.ExecArgs{
+		Filename:         args.Filename,
+		Argv:             args.Argv,
+		Envv:             args.Envv,
+		WorkingDirectory: args.WorkingDirectory,
+		KUID:             auth.KUID(args.KUID),
+		KGID:             auth.KGID(args.KGID),
+		NoNewPrivs:       args.NoNewPrivs,
+		StdioIsPty:       args.StdioIsPty,
+		FilePayload: control.FilePayload{
+			FilePayload: args.FilePayload,
+			GuestFDs:    args.GuestFDs,
+		},
+		ContainerID: args.ContainerID,
+	}
+	for _, kgid := range args.ExtraKGIDs {
+		eargs.ExtraKGIDs = append(eargs.ExtraKGIDs, auth.KGID(kgid))
+	}
+	if caps := args.Capabilities; caps != nil {
+		eargs.Capabilities = &auth.TaskCapabilities{
+			PermittedCaps:   auth.CapabilitySet(caps.PermittedCaps),
+			InheritableCaps: auth.CapabilitySet(caps.InheritableCaps),
+			EffectiveCaps:   auth.CapabilitySet(caps.EffectiveCaps),
+			BoundingCaps:    auth.CapabilitySet(caps.BoundingCaps),
+			AmbientCaps:     auth.CapabilitySet(caps.AmbientCaps),
+		}
+	}
+	return eargs
+}
+
 // Checkpoint pauses a sandbox and saves its state.
-func (cm *containerManager) Checkpoint(o *control.SaveOpts, _ *struct{}) error {
+func (cm *containerManager) Checkpoint(o *api.SaveOpts, _ *struct{}) error {
 	log.Debugf("containerManager.Checkpoint")
-	return cm.l.save(o)
+	return cm.l.save(saveOptsFromAPI(o))
+}
+
+// saveOptsFromAPI converts the arguments of ContMgrCheckpoint to the
+// sentry's.
+func saveOptsFromAPI(o *api.SaveOpts) *control.SaveOpts {
+	return &control.SaveOpts{
+		Key:      o.Key,
+		Metadata: o.Metadata,
+		MemoryFileSaveOpts: pgalloc.SaveOpts{
+			ExcludeCommittedZeroPages: o.MemoryFileSaveOpts.ExcludeCommittedZeroPages,
+			PageWriters:               o.MemoryFileSaveOpts.PageWriters,
+			PageChecksums:             o.MemoryFileSaveOpts.PageChecksums,
+		},
+		HavePagesFile:              o.HavePagesFile,
+		FilePayload:                o.FilePayload,
+		Resume:                     o.Resume,
+		SaveRestoreExecArgv:        o.SaveRestoreExecArgv,
+		SaveRestoreExecTimeout:     o.SaveRestoreExecTimeout,
+		SaveRestoreExecContainerID: o.SaveRestoreExecContainerID,
+	}
 }
 
 // PortForwardOpts contains options for port forwarding to a port in a
@@ -468,11 +713,29 @@ type PortForwardOpts struct {
 	ContainerID string
 	// Port is the port to to forward.
 	Port uint16
+	// Protocol is the protocol to forward, one of PortForwardTCP (the
+	// default when empty), PortForwardUDP or PortForwardUnix.
+	Protocol string
+	// Path is the absolute path of the unix domain socket inside the
+	// container to forward to. Only used with PortForwardUnix.
+	Path string
 }
 
+// Protocols supported by PortForwardOpts.Protocol.
+const (
+	// PortForwardTCP forwards a stream to a TCP port in the container.
+	PortForwardTCP = "tcp"
+	// PortForwardUDP forwards datagrams to a UDP port in the container. The
+	// donated FD must preserve message boundaries (e.g. SOCK_SEQPACKET).
+	PortForwardUDP = "udp"
+	// PortForwardUnix forwards a stream to a SOCK_STREAM unix domain socket
+	// bound inside the container.
+	PortForwardUnix = "unix"
+)
+
 // PortForward initiates a port forward to the container.
 func (cm *containerManager) PortForward(opts *PortForwardOpts, _ *struct{}) error {
-	log.Debugf("containerManager.PortForward, cid: %s, port: %d", opts.ContainerID, opts.Port)
+	log.Debugf("containerManager.PortForward, cid: %s, protocol: %q, port: %d, path: %q", opts.ContainerID, opts.Protocol, opts.Port, opts.Path)
 	if err := cm.l.portForward(opts); err != nil {
 		log.Debugf("containerManager.PortForward failed, opts: %+v, err: %v", opts, err)
 		return err
@@ -491,6 +754,15 @@ type RestoreOpts struct {
 	HavePagesFile  bool
 	HaveDeviceFile bool
 	Background     bool
+
+	// AllowIncompatible allows restoring checkpoints whose metadata doesn't
+	// match the sandbox, for differences that are known to be safe in some
+	// cases. See checkRestoreCompat.
+	AllowIncompatible bool
+
+	// ExitedProcesses are processes started by exec that exited before the
+	// sandbox was saved by Upgrade, so that they can still be waited for.
+	ExitedProcesses []UpgradeProcess
 }
 
 // Restore loads a container from a statefile.
@@ -513,6 +785,7 @@ func (cm *containerManager) Restore(o *RestoreOpts, _ *struct{}) error {
 	if len(o.Files) == 0 {
 		return fmt.Errorf("at least one file must be passed to Restore")
 	}
+	cm.l.restoreExitedProcessesLocked(o.ExitedProcesses)
 
 	stateFile, err := o.ReleaseFD(0)
 	if err != nil {
@@ -566,8 +839,19 @@ func (cm *containerManager) Restore(o *RestoreOpts, _ *struct{}) error {
 		}
 		fileIdx++
 
+		var pageChecksums bool
+		switch alg := metadata[state.PageChecksumsKey]; alg {
+		case "":
+		case state.PageChecksumsCRC32C:
+			pageChecksums = true
+		default:
+			pagesMetadata.Close()
+			pagesFile.Close()
+			return fmt.Errorf("unsupported page checksums %q", alg)
+		}
+
 		// This immediately starts loading the main MemoryFile asynchronously.
-		cm.restorer.asyncMFLoader = kernel.NewAsyncMFLoader(pagesMetadata, pagesFile, cm.restorer.mainMF, timer.Fork("PagesFileLoader"))
+		cm.restorer.asyncMFLoader = kernel.NewAsyncMFLoader(pagesMetadata, pagesFile, cm.restorer.mainMF, pageChecksums, timer.Fork("PagesFileLoader"))
 	}
 
 	if o.HaveDeviceFile {
@@ -617,11 +901,10 @@ func (cm *containerManager) Restore(o *RestoreOpts, _ *struct{}) error {
 		return err
 	}
 	cm.restorer.checkpointedSpecs = specs
+	cm.restorer.mountsDigest = metadata[MountsDigestKey]
 
-	checkpointVersion := metadata[VersionKey]
-	currentVersion := version.Version()
-	if checkpointVersion != currentVersion {
-		return fmt.Errorf("runsc version does not match across checkpoint restore, checkpoint: %v current: %v", checkpointVersion, currentVersion)
+	if err := checkRestoreCompat(metadata, cm.l.root.conf, o.AllowIncompatible); err != nil {
+		return err
 	}
 	return cm.restorer.restoreContainerInfo(cm.l, &cm.l.root, timer.Fork("cont:root"))
 }
@@ -707,6 +990,14 @@ func (cm *containerManager) RestoreSubcontainer(args *StartArgs, _ *struct{}) er
 		return fmt.Errorf("error dup'ing gofer files: %w", err)
 	}
 
+	if err := cm.l.handover.addContainer(args.CID, &startFDs{
+		stdios:            stdios,
+		goferFilestoreFDs: goferFilestoreFDs,
+		devGoferFD:        devGoferFD,
+		goferFDs:          goferFDs,
+	}); err != nil {
+		return err
+	}
 	err = cm.restorer.restoreSubcontainer(args.Spec, args.Conf, cm.l, args.CID, stdios, goferFDs, goferFilestoreFDs, devGoferFD, args.GoferMountConfs, timeline.Transfer())
 	if err != nil {
 		log.Debugf("containerManager.RestoreSubcontainer failed, cid: %s, args: %+v, err: %v", args.CID, args, err)
@@ -737,13 +1028,7 @@ func (cm *containerManager) Wait(cid *string, waitStatus *uint32) error {
 }
 
 // WaitPIDArgs are arguments to the WaitPID method.
-type WaitPIDArgs struct {
-	// PID is the PID in the container's PID namespace.
-	PID int32
-
-	// CID is the container ID.
-	CID string
-}
+type WaitPIDArgs = api.WaitPIDArgs
 
 // WaitPID waits for the process with PID 'pid' in the sandbox.
 func (cm *containerManager) WaitPID(args *WaitPIDArgs, waitStatus *uint32) error {
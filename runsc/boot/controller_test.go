@@ -0,0 +1,147 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/sandbox/api"
+	"gvisor.dev/gvisor/pkg/sentry/control"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/pgalloc"
+)
+
+// recode encodes from as urpc does, and decodes it into to.
+func recode(t *testing.T, from, to any) {
+	t.Helper()
+	b, err := json.Marshal(from)
+	if err != nil {
+		t.Fatalf("json.Marshal(%+v): %v", from, err)
+	}
+	if err := json.Unmarshal(b, to); err != nil {
+		t.Fatalf("json.Unmarshal(%s): %v", b, err)
+	}
+}
+
+// TestExecArgsFromAPI checks that the ExecArgs sent by runsc, which are the
+// sentry's, survive the conversion to and from the control API.
+func TestExecArgsFromAPI(t *testing.T) {
+	want := &control.ExecArgs{
+		Filename:         "/bin/true",
+		Argv:             []string{"true", "arg"},
+		Envv:             []string{"PATH=/bin"},
+		WorkingDirectory: "/tmp",
+		KUID:             1000,
+		KGID:             1001,
+		ExtraKGIDs:       []auth.KGID{1002, 1003},
+		Capabilities: &auth.TaskCapabilities{
+			PermittedCaps:   1 << 1,
+			InheritableCaps: 1 << 2,
+			EffectiveCaps:   1 << 3,
+			BoundingCaps:    1 << 4,
+			AmbientCaps:     1 << 5,
+		},
+		NoNewPrivs: true,
+		StdioIsPty: true,
+		FilePayload: control.FilePayload{
+			GuestFDs: []int{0, 1, 2},
+		},
+		ContainerID: "cid",
+	}
+	var args api.ExecArgs
+	recode(t, want, &args)
+	if diff := cmp.Diff(want, execArgsFromAPI(&args)); diff != "" {
+		t.Errorf("execArgsFromAPI() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestSaveOptsFromAPI is the equivalent of TestExecArgsFromAPI for SaveOpts.
+func TestSaveOptsFromAPI(t *testing.T) {
+	want := &control.SaveOpts{
+		Key:      []byte("key"),
+		Metadata: map[string]string{"foo": "bar"},
+		MemoryFileSaveOpts: pgalloc.SaveOpts{
+			ExcludeCommittedZeroPages: true,
+			PageWriters:               4,
+			PageChecksums:             true,
+		},
+		HavePagesFile:              true,
+		Resume:                     true,
+		SaveRestoreExecArgv:        "/bin/true arg",
+		SaveRestoreExecTimeout:     time.Minute,
+		SaveRestoreExecContainerID: "cid",
+	}
+	var o api.SaveOpts
+	recode(t, want, &o)
+	if diff := cmp.Diff(want, saveOptsFromAPI(&o)); diff != "" {
+		t.Errorf("saveOptsFromAPI() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestProcessToAPI checks that processes converted to the control API are
+// understood by runsc, which decodes them as the sentry's.
+func TestProcessToAPI(t *testing.T) {
+	want := &control.Process{
+		UID:     1000,
+		PID:     2,
+		PPID:    1,
+		Threads: []kernel.ThreadID{2, 3},
+		C:       4,
+		TTY:     "pts/0",
+		STime:   "12:00",
+		Time:    "10ms",
+		Cmd:     "sh",
+	}
+	var got control.Process
+	recode(t, processToAPI(want), &got)
+	if diff := cmp.Diff(want, &got); diff != "" {
+		t.Errorf("processToAPI() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestProfileOpts checks that the profile RPCs, which decode their arguments
+// as the sentry's, understand api.ProfileOpts.
+func TestProfileOpts(t *testing.T) {
+	opts := api.ProfileOpts{Duration: time.Second, Delay: time.Minute}
+	var cpu control.CPUProfileOpts
+	recode(t, &opts, &cpu)
+	if cpu.Duration != opts.Duration {
+		t.Errorf("CPUProfileOpts.Duration = %v, want %v", cpu.Duration, opts.Duration)
+	}
+	var heap control.HeapProfileOpts
+	recode(t, &opts, &heap)
+	if heap.Delay != opts.Delay {
+		t.Errorf("HeapProfileOpts.Delay = %v, want %v", heap.Delay, opts.Delay)
+	}
+	var block control.BlockProfileOpts
+	recode(t, &opts, &block)
+	if block.Duration != opts.Duration {
+		t.Errorf("BlockProfileOpts.Duration = %v, want %v", block.Duration, opts.Duration)
+	}
+	var mutex control.MutexProfileOpts
+	recode(t, &opts, &mutex)
+	if mutex.Duration != opts.Duration {
+		t.Errorf("MutexProfileOpts.Duration = %v, want %v", mutex.Duration, opts.Duration)
+	}
+	var trace control.TraceProfileOpts
+	recode(t, &opts, &trace)
+	if trace.Duration != opts.Duration {
+		t.Errorf("TraceProfileOpts.Duration = %v, want %v", trace.Duration, opts.Duration)
+	}
+}
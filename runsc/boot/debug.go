@@ -15,10 +15,18 @@
 package boot
 
 import (
+	"fmt"
+	"strings"
+
 	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
 )
 
 type debug struct {
+	k *kernel.Kernel
+
+	// hostFDs is the inventory of host FDs found by AuditFDs at startup.
+	hostFDs []HostFD
 }
 
 // Stacks collects all sandbox stacks and copies them to 'stacks'.
@@ -27,3 +35,22 @@ func (*debug) Stacks(_ *struct{}, stacks *string) error {
 	*stacks = string(buf)
 	return nil
 }
+
+// FlightRecord copies the contents of all tasks' flight recorders to 'record'.
+func (d *debug) FlightRecord(_ *struct{}, record *string) error {
+	if !d.k.FlightRecorder {
+		return fmt.Errorf("flight recorder is disabled, start the sandbox with --flight-recorder")
+	}
+	var b strings.Builder
+	if err := d.k.WriteFlightRecords(&b); err != nil {
+		return err
+	}
+	*record = b.String()
+	return nil
+}
+
+// FDs copies the inventory of host FDs found at startup to 'fds'.
+func (d *debug) FDs(_ *struct{}, fds *[]HostFD) error {
+	*fds = d.hostFDs
+	return nil
+}
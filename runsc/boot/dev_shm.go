@@ -0,0 +1,161 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"path/filepath"
+	"strings"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/fspath"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/tmpfs"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+)
+
+const (
+	// DevShmSizeAnnotation sets the size limit of the container's /dev/shm,
+	// using the same syntax as the tmpfs size= mount option. If the spec
+	// doesn't mount anything at /dev/shm, a tmpfs of that size is mounted
+	// there. Otherwise, it overrides the size of the tmpfs mounted there.
+	DevShmSizeAnnotation = "dev.gvisor.spec.dev-shm-size"
+
+	// DevShmCleanupAnnotation selects what happens to the POSIX shared
+	// memory objects in the container's /dev/shm when the container is
+	// destroyed. See devShmCleanupPolicy for values.
+	DevShmCleanupAnnotation = "dev.gvisor.spec.dev-shm-cleanup"
+
+	devShmPath = "/dev/shm"
+)
+
+// devShmCleanupPolicy is the value of DevShmCleanupAnnotation.
+type devShmCleanupPolicy string
+
+const (
+	// devShmCleanupKeep leaves /dev/shm alone, as Linux does. Objects in a
+	// /dev/shm shared with other containers outlive the container.
+	devShmCleanupKeep devShmCleanupPolicy = "keep"
+
+	// devShmCleanupUnlink unlinks all objects in /dev/shm when the container
+	// is destroyed. Processes that still have an object open or mapped
+	// keep using it, and its memory is released once they're done.
+	devShmCleanupUnlink devShmCleanupPolicy = "unlink"
+)
+
+// devShmCleanup returns the /dev/shm cleanup policy requested by spec.
+func devShmCleanup(spec *specs.Spec) devShmCleanupPolicy {
+	switch val := devShmCleanupPolicy(spec.Annotations[DevShmCleanupAnnotation]); val {
+	case "", devShmCleanupKeep:
+		return devShmCleanupKeep
+	case devShmCleanupUnlink:
+		return devShmCleanupUnlink
+	default:
+		log.Warningf("Ignoring invalid annotation %s=%q", DevShmCleanupAnnotation, val)
+		return devShmCleanupKeep
+	}
+}
+
+// applyDevShmSize applies DevShmSizeAnnotation to mounts, which are the
+// mounts compiled from spec.
+func applyDevShmSize(spec *specs.Spec, mounts []specs.Mount) []specs.Mount {
+	size, ok := spec.Annotations[DevShmSizeAnnotation]
+	if !ok {
+		return mounts
+	}
+	if _, err := tmpfs.ParseSize(size); err != nil {
+		log.Warningf("Ignoring invalid annotation %s=%q: %v", DevShmSizeAnnotation, size, err)
+		return mounts
+	}
+	sizeOpt := "size=" + size
+	for i := len(mounts) - 1; i >= 0; i-- {
+		m := &mounts[i]
+		if filepath.Clean(m.Destination) != devShmPath {
+			continue
+		}
+		if m.Type != tmpfs.Name {
+			log.Warningf("Ignoring annotation %s: %s is a %q mount", DevShmSizeAnnotation, devShmPath, m.Type)
+			return mounts
+		}
+		opts := make([]string, 0, len(m.Options)+1)
+		for _, opt := range m.Options {
+			if !strings.HasPrefix(opt, "size=") {
+				opts = append(opts, opt)
+			}
+		}
+		m.Options = append(opts, sizeOpt)
+		return mounts
+	}
+	return append(mounts, specs.Mount{
+		Type:        tmpfs.Name,
+		Destination: devShmPath,
+		Source:      "shm",
+		Options:     []string{"nosuid", "noexec", "nodev", "mode=1777", sizeOpt},
+	})
+}
+
+// cleanupDevShm unlinks all entries in /dev/shm in mntns.
+func cleanupDevShm(ctx context.Context, vfsObj *vfs.VirtualFilesystem, creds *auth.Credentials, mntns *vfs.MountNamespace) {
+	root := mntns.Root(ctx)
+	defer root.DecRef(ctx)
+	dir := &vfs.PathOperation{
+		Root:  root,
+		Start: root,
+		Path:  fspath.Parse(devShmPath),
+	}
+	fd, err := vfsObj.OpenAt(ctx, creds, dir, &vfs.OpenOptions{Flags: linux.O_RDONLY | linux.O_DIRECTORY})
+	if err != nil {
+		log.Warningf("Failed to open %s for cleanup: %v", devShmPath, err)
+		return
+	}
+	var names []string
+	err = fd.IterDirents(ctx, vfs.IterDirentsCallbackFunc(func(dirent vfs.Dirent) error {
+		if dirent.Name != "." && dirent.Name != ".." && dirent.Type != linux.DT_DIR {
+			names = append(names, dirent.Name)
+		}
+		return nil
+	}))
+	fd.DecRef(ctx)
+	if err != nil {
+		log.Warningf("Failed to list %s for cleanup: %v", devShmPath, err)
+		return
+	}
+	for _, name := range names {
+		obj := &vfs.PathOperation{
+			Root:  root,
+			Start: root,
+			Path:  fspath.Parse(devShmPath + "/" + name),
+		}
+		if err := vfsObj.UnlinkAt(ctx, creds, obj); err != nil {
+			log.Warningf("Failed to unlink %s/%s: %v", devShmPath, name, err)
+		}
+	}
+	log.Infof("Unlinked %d objects from %s", len(names), devShmPath)
+}
+
+// cleanupRootDevShm unlinks all entries in the root container's /dev/shm if
+// its spec requests devShmCleanupUnlink. It is called once the root container
+// has exited, when the sandbox is destroyed.
+func (l *Loader) cleanupRootDevShm() {
+	if l.rootShmMntns == nil {
+		return
+	}
+	ctx := l.k.SupervisorContext()
+	cleanupDevShm(ctx, l.k.VFS(), auth.NewRootCredentials(l.k.RootUserNamespace()), l.rootShmMntns)
+	l.rootShmMntns.DecRef(ctx)
+	l.rootShmMntns = nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/fspath"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+)
+
+func TestApplyDevShmSize(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		mounts      []specs.Mount
+		want        []specs.Mount
+	}{
+		{
+			name: "no annotation",
+			mounts: []specs.Mount{
+				{Destination: "/dev", Type: "dev"},
+			},
+			want: []specs.Mount{
+				{Destination: "/dev", Type: "dev"},
+			},
+		},
+		{
+			name:        "added",
+			annotations: map[string]string{DevShmSizeAnnotation: "1g"},
+			mounts: []specs.Mount{
+				{Destination: "/dev", Type: "dev"},
+			},
+			want: []specs.Mount{
+				{Destination: "/dev", Type: "dev"},
+				{Destination: "/dev/shm", Type: "tmpfs", Source: "shm", Options: []string{"nosuid", "noexec", "nodev", "mode=1777", "size=1g"}},
+			},
+		},
+		{
+			name:        "overridden",
+			annotations: map[string]string{DevShmSizeAnnotation: "1g"},
+			mounts: []specs.Mount{
+				{Destination: "/dev/shm/", Type: "tmpfs", Options: []string{"nosuid", "size=65536k", "mode=1777"}},
+			},
+			want: []specs.Mount{
+				{Destination: "/dev/shm/", Type: "tmpfs", Options: []string{"nosuid", "mode=1777", "size=1g"}},
+			},
+		},
+		{
+			name:        "bind mount",
+			annotations: map[string]string{DevShmSizeAnnotation: "1g"},
+			mounts: []specs.Mount{
+				{Destination: "/dev/shm", Type: "bind", Source: "/dev/shm"},
+			},
+			want: []specs.Mount{
+				{Destination: "/dev/shm", Type: "bind", Source: "/dev/shm"},
+			},
+		},
+		{
+			name:        "invalid",
+			annotations: map[string]string{DevShmSizeAnnotation: "1x"},
+			mounts: []specs.Mount{
+				{Destination: "/dev", Type: "dev"},
+			},
+			want: []specs.Mount{
+				{Destination: "/dev", Type: "dev"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &specs.Spec{Annotations: tc.annotations}
+			got := applyDevShmSize(spec, tc.mounts)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("applyDevShmSize() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDevShmCleanup(t *testing.T) {
+	for val, want := range map[string]devShmCleanupPolicy{
+		"":       devShmCleanupKeep,
+		"keep":   devShmCleanupKeep,
+		"unlink": devShmCleanupUnlink,
+		"bogus":  devShmCleanupKeep,
+	} {
+		spec := &specs.Spec{Annotations: map[string]string{DevShmCleanupAnnotation: val}}
+		if got := devShmCleanup(spec); got != want {
+			t.Errorf("devShmCleanup(%q) = %q, want %q", val, got, want)
+		}
+	}
+}
+
+// TestRootDevShmCleanup checks that objects in the root container's /dev/shm
+// are unlinked once it has exited if its spec requests it.
+func TestRootDevShmCleanup(t *testing.T) {
+	spec := testSpec()
+	spec.Process.Args = []string{"/bin/sh", "-c", "echo > /dev/shm/obj"}
+	spec.Annotations = map[string]string{
+		DevShmSizeAnnotation:    "1M",
+		DevShmCleanupAnnotation: string(devShmCleanupUnlink),
+	}
+	l, cleanup, err := createLoader(testConfig(), spec)
+	if err != nil {
+		t.Fatalf("error creating loader: %v", err)
+	}
+	defer l.Destroy()
+	defer cleanup()
+
+	// Read the start chan result, otherwise Run will block forever.
+	go func() { <-l.ctrl.manager.startResultChan }()
+	if err := l.Run(); err != nil {
+		t.Fatalf("error running container: %v", err)
+	}
+	if status := l.WaitExit(); !status.Exited() || status.ExitStatus() != 0 {
+		t.Fatalf("application exited with %s, want exit status 0", status)
+	}
+
+	mntns := l.rootShmMntns
+	if mntns == nil {
+		t.Fatalf("root container's mount namespace not held for /dev/shm cleanup")
+	}
+	ctx := l.k.SupervisorContext()
+	mntns.IncRef()
+	defer mntns.DecRef(ctx)
+	root := mntns.Root(ctx)
+	defer root.DecRef(ctx)
+	creds := auth.NewRootCredentials(l.k.RootUserNamespace())
+	stat := func() error {
+		_, err := l.k.VFS().StatAt(ctx, creds, &vfs.PathOperation{
+			Root:  root,
+			Start: root,
+			Path:  fspath.Parse(devShmPath + "/obj"),
+		}, &vfs.StatOptions{})
+		return err
+	}
+	if err := stat(); err != nil {
+		t.Fatalf("error getting /dev/shm/obj: %v", err)
+	}
+
+	l.cleanupRootDevShm()
+	if err := stat(); !linuxerr.Equals(linuxerr.ENOENT, err) {
+		t.Errorf("getting /dev/shm/obj after cleanup: got error %v, want ENOENT", err)
+	}
+	if l.rootShmMntns != nil {
+		t.Errorf("root container's mount namespace still held after cleanup")
+	}
+}
@@ -19,84 +19,46 @@ import (
 	"strconv"
 
 	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sandbox/api"
 	"gvisor.dev/gvisor/pkg/sentry/control"
 	"gvisor.dev/gvisor/pkg/sentry/usage"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
 )
 
-// NetworkInterface is the network statistics of the particular network interface
-type NetworkInterface struct {
-	// Name is the name of the network interface.
-	Name      string
-	RxBytes   uint64
-	RxPackets uint64
-	RxErrors  uint64
-	RxDropped uint64
-	TxBytes   uint64
-	TxPackets uint64
-	TxErrors  uint64
-	TxDropped uint64
-}
-
-// EventOut is the return type of the Event command.
-type EventOut struct {
-	Event Event `json:"event"`
-
-	// ContainerUsage maps each container ID to its total CPU usage.
-	ContainerUsage map[string]uint64 `json:"containerUsage"`
-}
-
-// Event struct for encoding the event data to JSON. Corresponds to runc's
-// main.event struct.
-type Event struct {
-	Type string `json:"type"`
-	ID   string `json:"id"`
-	Data Stats  `json:"data"`
-}
-
-// Stats is the runc specific stats structure for stability when encoding and
-// decoding stats.
-type Stats struct {
-	CPU               CPU                 `json:"cpu"`
-	Memory            Memory              `json:"memory"`
-	Pids              Pids                `json:"pids"`
-	NetworkInterfaces []*NetworkInterface `json:"network_interfaces"`
-}
-
-// Pids contains stats on processes.
-type Pids struct {
-	Current uint64 `json:"current,omitempty"`
-	Limit   uint64 `json:"limit,omitempty"`
-}
-
-// MemoryEntry contains stats on a kind of memory.
-type MemoryEntry struct {
-	Limit   uint64 `json:"limit"`
-	Usage   uint64 `json:"usage,omitempty"`
-	Max     uint64 `json:"max,omitempty"`
-	Failcnt uint64 `json:"failcnt"`
-}
-
-// Memory contains stats on memory.
-type Memory struct {
-	Cache     uint64            `json:"cache,omitempty"`
-	Usage     MemoryEntry       `json:"usage,omitempty"`
-	Swap      MemoryEntry       `json:"swap,omitempty"`
-	Kernel    MemoryEntry       `json:"kernel,omitempty"`
-	KernelTCP MemoryEntry       `json:"kernelTCP,omitempty"`
-	Raw       map[string]uint64 `json:"raw,omitempty"`
-}
-
-// CPU contains stats on the CPU.
-type CPU struct {
-	Usage CPUUsage `json:"usage"`
-}
+// Event types are defined in the api package so that clients of the control
+// API don't need to depend on runsc/boot.
+type (
+	NetworkInterface = api.NetworkInterface
+	EventOut         = api.EventOut
+	Event            = api.Event
+	Stats            = api.Stats
+	Pids             = api.Pids
+	BlkioEntry       = api.BlkioEntry
+	Blkio            = api.Blkio
+	PSIData          = api.PSIData
+	PSIStats         = api.PSIStats
+	MemoryEntry      = api.MemoryEntry
+	Memory           = api.Memory
+	CPU              = api.CPU
+	CPUUsage         = api.CPUUsage
+)
 
-// CPUUsage contains stats on CPU usage.
-type CPUUsage struct {
-	Kernel uint64   `json:"kernel,omitempty"`
-	User   uint64   `json:"user,omitempty"`
-	Total  uint64   `json:"total,omitempty"`
-	PerCPU []uint64 `json:"percpu,omitempty"`
+// blkioFromUsage converts I/O usage to Blkio using the runc operation names.
+func blkioFromUsage(io *usage.IO) Blkio {
+	rbytes, wbytes := io.CharsRead.Load(), io.CharsWritten.Load()
+	rios, wios := io.ReadSyscalls.Load(), io.WriteSyscalls.Load()
+	return Blkio{
+		IoServiceBytesRecursive: []BlkioEntry{
+			{Op: "Read", Value: rbytes},
+			{Op: "Write", Value: wbytes},
+			{Op: "Total", Value: rbytes + wbytes},
+		},
+		IoServicedRecursive: []BlkioEntry{
+			{Op: "Read", Value: rios},
+			{Op: "Write", Value: wios},
+			{Op: "Total", Value: rios + wios},
+		},
+	}
 }
 
 func (cm *containerManager) getUsageFromCgroups(file control.CgroupControlFile) (uint64, error) {
@@ -148,6 +110,17 @@ func (cm *containerManager) Event(cid *string, out *EventOut) error {
 	}
 	out.Event.Data.NetworkInterfaces = networkStats
 
+	if io, ok := control.ContainerIOUsage(cm.l.k)[*cid]; ok {
+		out.Event.Data.Blkio = blkioFromUsage(io)
+	}
+	if vfs.RecordIOStats {
+		// Only "some" pressure is tracked: the sentry doesn't know whether
+		// tasks that aren't doing I/O are runnable.
+		out.Event.Data.Blkio.PSI = &PSIStats{
+			Some: PSIData(cm.l.k.VFS().ContainerIOPressure(*cid)),
+		}
+	}
+
 	numContainers := cm.l.containerCount()
 	if numContainers == 0 {
 		return fmt.Errorf("no container was found")
@@ -0,0 +1,137 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/log"
+)
+
+// FD classes assigned by AuditFDs to FDs that are not in the expected set.
+const (
+	// FDClassStdio is the class of the sentry's own stdin, stdout and stderr.
+	FDClassStdio = "stdio"
+
+	// FDClassRuntime is the class of FDs opened by the Go runtime, e.g. for
+	// the network poller.
+	FDClassRuntime = "runtime"
+
+	// FDClassUnexpected is the class of FDs that the sentry has no use for,
+	// e.g. FDs leaked by the process that started it. They are closed.
+	FDClassUnexpected = "unexpected"
+)
+
+// HostFD describes a host file descriptor found by the startup FD audit.
+type HostFD struct {
+	// FD is the host file descriptor number.
+	FD int `json:"fd"`
+
+	// Class describes why the sentry holds the FD. For donated FDs, it is
+	// the name of the flag that the FD was donated with.
+	Class string `json:"class"`
+
+	// Target is the target of the FD's /proc/self/fd link, e.g. a path or
+	// "socket:[1234]".
+	Target string `json:"target"`
+
+	// Closed is true if the audit closed the FD.
+	Closed bool `json:"closed,omitempty"`
+}
+
+// String implements fmt.Stringer.
+func (h HostFD) String() string {
+	s := fmt.Sprintf("%d\t%s\t%s", h.FD, h.Class, h.Target)
+	if h.Closed {
+		s += "\t(closed)"
+	}
+	return s
+}
+
+// runtimeFDTargets are the /proc/self/fd link targets of FDs that the Go
+// runtime opens on its own.
+var runtimeFDTargets = map[string]struct{}{
+	"anon_inode:[eventpoll]": {},
+	"anon_inode:[eventfd]":   {},
+}
+
+// AuditFDs enumerates the host FDs open in the current process and classifies
+// them. expected maps the FDs donated to the sentry to their class. Any FD
+// that is neither expected, stdio, nor opened by the Go runtime is closed, so
+// that the sandbox only holds the host resources it was deliberately given.
+//
+// AuditFDs must be called before New, since FDs that the Loader opens are
+// not expected.
+func AuditFDs(expected map[int]string) ([]HostFD, error) {
+	const selfFDDir = "/proc/self/fd"
+	dir, err := os.Open(selfFDDir)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", selfFDDir, err)
+	}
+	dirFD := int(dir.Fd())
+
+	var fds []HostFD
+	for _, name := range names {
+		fd, err := strconv.Atoi(name)
+		if err != nil {
+			return nil, fmt.Errorf("strconv.Atoi(%s) failed: %v", name, err)
+		}
+		if fd == dirFD {
+			continue
+		}
+		target, err := os.Readlink(filepath.Join(selfFDDir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Closed since Readdirnames.
+				continue
+			}
+			return nil, fmt.Errorf("os.Readlink(%s) failed: %v", name, err)
+		}
+		hfd := HostFD{FD: fd, Class: classifyFD(fd, target, expected), Target: target}
+		if hfd.Class == FDClassUnexpected {
+			log.Warningf("Closing unexpected FD %d: %s", fd, target)
+			if err := unix.Close(fd); err != nil {
+				return nil, fmt.Errorf("closing unexpected FD %d: %w", fd, err)
+			}
+			hfd.Closed = true
+		}
+		fds = append(fds, hfd)
+	}
+	sort.Slice(fds, func(i, j int) bool { return fds[i].FD < fds[j].FD })
+	return fds, nil
+}
+
+func classifyFD(fd int, target string, expected map[int]string) string {
+	if class, ok := expected[fd]; ok {
+		return class
+	}
+	if fd <= 2 {
+		return FDClassStdio
+	}
+	if _, ok := runtimeFDTargets[target]; ok {
+		return FDClassRuntime
+	}
+	return FDClassUnexpected
+}
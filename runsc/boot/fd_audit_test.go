@@ -0,0 +1,43 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"testing"
+)
+
+func TestClassifyFD(t *testing.T) {
+	expected := map[int]string{
+		2:  "debug-log-fd",
+		10: "controller-fd",
+	}
+	for _, tc := range []struct {
+		fd     int
+		target string
+		want   string
+	}{
+		{fd: 0, target: "/dev/null", want: FDClassStdio},
+		{fd: 2, target: "/tmp/runsc.log.boot.txt", want: "debug-log-fd"},
+		{fd: 4, target: "anon_inode:[eventpoll]", want: FDClassRuntime},
+		{fd: 5, target: "anon_inode:[eventfd]", want: FDClassRuntime},
+		{fd: 10, target: "socket:[1234]", want: "controller-fd"},
+		{fd: 11, target: "/etc/shadow", want: FDClassUnexpected},
+		{fd: 12, target: "socket:[5678]", want: FDClassUnexpected},
+	} {
+		if got := classifyFD(tc.fd, tc.target, expected); got != tc.want {
+			t.Errorf("classifyFD(%d, %q) = %q, want %q", tc.fd, tc.target, got, tc.want)
+		}
+	}
+}
@@ -37,14 +37,19 @@ type Options struct {
 	Platform              platform.SeccompInfo
 	HostNetwork           bool
 	HostNetworkRawSockets bool
+	HostNetworkTCPRepair  bool
 	HostFilesystem        bool
 	ProfileEnable         bool
 	NVProxy               bool
 	NVProxyCaps           nvconf.DriverCaps
 	TPUProxy              bool
+	SRIOV                 bool
 	ControllerFD          uint32
 	CgoEnabled            bool
 	PluginNetwork         bool
+	HostUring             bool
+	HostLocks             bool
+	CoreTags              bool
 }
 
 // isInstrumentationEnabled returns whether there are any
@@ -65,14 +70,19 @@ func (opt Options) ConfigKey() string {
 	sb.WriteString(fmt.Sprintf("Platform=%q ", opt.Platform.ConfigKey()))
 	sb.WriteString(fmt.Sprintf("HostNetwork=%t ", opt.HostNetwork))
 	sb.WriteString(fmt.Sprintf("HostNetworkRawSockets=%t ", opt.HostNetworkRawSockets))
+	sb.WriteString(fmt.Sprintf("HostNetworkTCPRepair=%t ", opt.HostNetworkTCPRepair))
 	sb.WriteString(fmt.Sprintf("HostFilesystem=%t ", opt.HostFilesystem))
 	sb.WriteString(fmt.Sprintf("ProfileEnable=%t ", opt.ProfileEnable))
 	sb.WriteString(fmt.Sprintf("Instrumentation=%t ", isInstrumentationEnabled()))
 	sb.WriteString(fmt.Sprintf("NVProxy=%t ", opt.NVProxy))
 	sb.WriteString(fmt.Sprintf("NVProxyCaps=%v ", opt.NVProxyCaps))
 	sb.WriteString(fmt.Sprintf("TPUProxy=%t ", opt.TPUProxy))
+	sb.WriteString(fmt.Sprintf("SRIOV=%t ", opt.SRIOV))
 	sb.WriteString(fmt.Sprintf("CgoEnabled=%t ", opt.CgoEnabled))
 	sb.WriteString(fmt.Sprintf("PluginNetwork=%t ", opt.PluginNetwork))
+	sb.WriteString(fmt.Sprintf("HostUring=%t ", opt.HostUring))
+	sb.WriteString(fmt.Sprintf("HostLocks=%t ", opt.HostLocks))
+	sb.WriteString(fmt.Sprintf("CoreTags=%t ", opt.CoreTags))
 	return strings.TrimSpace(sb.String())
 }
 
@@ -102,12 +112,18 @@ func Warnings(opt Options) []string {
 	if opt.TPUProxy {
 		warnings = append(warnings, "TPU device proxy enabled: syscall filters less restrictive!")
 	}
+	if opt.SRIOV {
+		warnings = append(warnings, "SR-IOV network device proxy enabled: syscall filters less restrictive!")
+	}
 	if opt.CgoEnabled {
 		warnings = append(warnings, "CGO enabled: syscall filters less restrictive!")
 	}
 	if opt.PluginNetwork {
 		warnings = append(warnings, "plugin network stack enabled: syscall filters less restrictive!")
 	}
+	if opt.HostUring {
+		warnings = append(warnings, "host io_uring enabled: syscall filters less restrictive!")
+	}
 	return warnings
 }
 
@@ -141,13 +157,16 @@ func rules(opt Options, vars precompiledseccomp.Values) (seccomp.SyscallRules, s
 	s.Merge(instrumentationFilters())
 
 	if opt.HostNetwork {
-		s.Merge(hostInetFilters(opt.HostNetworkRawSockets))
+		s.Merge(hostInetFilters(opt.HostNetworkRawSockets, opt.HostNetworkTCPRepair))
 	}
 	if opt.ProfileEnable {
 		s.Merge(profileFilters())
 	}
 	if opt.HostFilesystem {
 		s.Merge(hostFilesystemFilters())
+		if opt.HostLocks {
+			s.Merge(hostLocksFilters())
+		}
 	}
 	if opt.NVProxy {
 		s.Merge(nvproxy.Filters(opt.NVProxyCaps))
@@ -155,12 +174,21 @@ func rules(opt Options, vars precompiledseccomp.Values) (seccomp.SyscallRules, s
 	if opt.TPUProxy {
 		s.Merge(tpuproxy.Filters())
 	}
+	if opt.SRIOV {
+		s.Merge(tpuproxy.VFIOFilters())
+	}
 	if opt.CgoEnabled {
 		s.Merge(cgoFilters())
 	}
 	if opt.PluginNetwork {
 		s.Merge(plugin.SeccompFilters())
 	}
+	if opt.HostUring {
+		s.Merge(hostUringFilters())
+	}
+	if opt.CoreTags {
+		s.Merge(coreTagsFilters())
+	}
 
 	s.Merge(opt.Platform.SyscallFilters(vars))
 	return s, seccomp.DenyNewExecMappings
@@ -344,6 +344,64 @@ func selfPIDFilters(pid uint64) seccomp.SyscallRules {
 	})
 }
 
+// hostLocksFilters contains syscalls that are needed by directfs for host locks
+// on gofer mounts with the host_locks option.
+func hostLocksFilters() seccomp.SyscallRules {
+	return seccomp.MakeSyscallRules(map[uintptr]seccomp.SyscallRule{
+		unix.SYS_FCNTL: seccomp.Or{
+			seccomp.PerArg{
+				seccomp.NonNegativeFD{},
+				seccomp.EqualTo(unix.F_OFD_SETLK),
+			},
+			seccomp.PerArg{
+				seccomp.NonNegativeFD{},
+				seccomp.EqualTo(unix.F_OFD_GETLK),
+			},
+		},
+	})
+}
+
+// coreTagsFilters contains syscalls that are needed to core tag host threads
+// executing application code after the application's core scheduling cookies.
+func coreTagsFilters() seccomp.SyscallRules {
+	return seccomp.MakeSyscallRules(map[uintptr]seccomp.SyscallRule{
+		unix.SYS_PRCTL: seccomp.Or{
+			seccomp.PerArg{
+				seccomp.EqualTo(unix.PR_SCHED_CORE),
+				seccomp.EqualTo(unix.PR_SCHED_CORE_GET),
+				seccomp.AnyValue{},
+				seccomp.EqualTo(linux.PR_SCHED_CORE_SCOPE_THREAD),
+				seccomp.AnyValue{},
+				seccomp.EqualTo(0),
+			},
+			seccomp.PerArg{
+				seccomp.EqualTo(unix.PR_SCHED_CORE),
+				seccomp.EqualTo(unix.PR_SCHED_CORE_CREATE),
+				seccomp.AnyValue{},
+				seccomp.EqualTo(linux.PR_SCHED_CORE_SCOPE_THREAD),
+				seccomp.EqualTo(0),
+				seccomp.EqualTo(0),
+			},
+			seccomp.PerArg{
+				seccomp.EqualTo(unix.PR_SCHED_CORE),
+				seccomp.EqualTo(unix.PR_SCHED_CORE_SHARE_TO),
+				seccomp.AnyValue{},
+				seccomp.EqualTo(linux.PR_SCHED_CORE_SCOPE_THREAD),
+				seccomp.EqualTo(0),
+				seccomp.EqualTo(0),
+			},
+			seccomp.PerArg{
+				seccomp.EqualTo(unix.PR_SCHED_CORE),
+				seccomp.EqualTo(unix.PR_SCHED_CORE_SHARE_FROM),
+				seccomp.AnyValue{},
+				seccomp.EqualTo(linux.PR_SCHED_CORE_SCOPE_THREAD),
+				seccomp.EqualTo(0),
+				seccomp.EqualTo(0),
+			},
+		},
+	})
+}
+
 // hostFilesystemFilters contains syscalls that are needed by directfs.
 func hostFilesystemFilters() seccomp.SyscallRules {
 	// Directfs allows FD-based filesystem syscalls. We deny these syscalls with
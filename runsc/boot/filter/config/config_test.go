@@ -48,6 +48,10 @@ func TestIoctlFirstArgumentIsNonNegativeFD(t *testing.T) {
 			Platform: (&systrap.Systrap{}).SeccompInfo(),
 			TPUProxy: true,
 		},
+		"sriov": {
+			Platform: (&systrap.Systrap{}).SeccompInfo(),
+			SRIOV:    true,
+		},
 		"host network": {
 			Platform:    (&systrap.Systrap{}).SeccompInfo(),
 			HostNetwork: true,
@@ -57,6 +61,11 @@ func TestIoctlFirstArgumentIsNonNegativeFD(t *testing.T) {
 			HostNetwork:           true,
 			HostNetworkRawSockets: true,
 		},
+		"host network with TCP repair": {
+			Platform:             (&systrap.Systrap{}).SeccompInfo(),
+			HostNetwork:          true,
+			HostNetworkTCPRepair: true,
+		},
 		"profiling": {
 			Platform:      (&systrap.Systrap{}).SeccompInfo(),
 			ProfileEnable: true,
@@ -65,6 +74,19 @@ func TestIoctlFirstArgumentIsNonNegativeFD(t *testing.T) {
 			Platform:       (&systrap.Systrap{}).SeccompInfo(),
 			HostFilesystem: true,
 		},
+		"host io_uring": {
+			Platform:  (&systrap.Systrap{}).SeccompInfo(),
+			HostUring: true,
+		},
+		"host filesystem with host locks": {
+			Platform:       (&systrap.Systrap{}).SeccompInfo(),
+			HostFilesystem: true,
+			HostLocks:      true,
+		},
+		"core tags": {
+			Platform: (&systrap.Systrap{}).SeccompInfo(),
+			CoreTags: true,
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			rules, _ := Rules(options)
@@ -106,13 +128,18 @@ func TestOptionsConfigKey(t *testing.T) {
 		},
 		"HostNetwork":           func(opt *Options) { opt.HostNetwork = !opt.HostNetwork },
 		"HostNetworkRawSockets": func(opt *Options) { opt.HostNetworkRawSockets = !opt.HostNetworkRawSockets },
+		"HostNetworkTCPRepair":  func(opt *Options) { opt.HostNetworkTCPRepair = !opt.HostNetworkTCPRepair },
 		"HostFilesystem":        func(opt *Options) { opt.HostFilesystem = !opt.HostFilesystem },
 		"ProfileEnable":         func(opt *Options) { opt.ProfileEnable = !opt.ProfileEnable },
 		"NVProxy":               func(opt *Options) { opt.NVProxy = !opt.NVProxy },
 		"NVProxyCaps":           func(opt *Options) { opt.NVProxyCaps = ^opt.NVProxyCaps },
 		"TPUProxy":              func(opt *Options) { opt.TPUProxy = !opt.TPUProxy },
+		"SRIOV":                 func(opt *Options) { opt.SRIOV = !opt.SRIOV },
 		"CgoEnabled":            func(opt *Options) { opt.CgoEnabled = !opt.CgoEnabled },
 		"PluginNetwork":         func(opt *Options) { opt.PluginNetwork = !opt.PluginNetwork },
+		"HostUring":             func(opt *Options) { opt.HostUring = !opt.HostUring },
+		"HostLocks":             func(opt *Options) { opt.HostLocks = !opt.HostLocks },
+		"CoreTags":              func(opt *Options) { opt.CoreTags = !opt.CoreTags },
 	}
 
 	// Map of `Options` struct field names mapped to a function to mutate them.
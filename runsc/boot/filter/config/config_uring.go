@@ -0,0 +1,53 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/seccomp"
+)
+
+// hostUringFilters returns extra syscalls made by the sentry's host io_uring.
+// The io_uring is set up and restricted before filters are installed, so only
+// io_uring_enter(2) and updates to its registered file table are required.
+func hostUringFilters() seccomp.SyscallRules {
+	return seccomp.MakeSyscallRules(map[uintptr]seccomp.SyscallRule{
+		unix.SYS_IO_URING_REGISTER: seccomp.PerArg{
+			seccomp.NonNegativeFD{},
+			seccomp.EqualTo(linux.IORING_REGISTER_FILES_UPDATE),
+			seccomp.AnyValue{},
+			seccomp.EqualTo(1),
+		},
+		unix.SYS_IO_URING_ENTER: seccomp.Or{
+			seccomp.PerArg{
+				seccomp.NonNegativeFD{},
+				seccomp.AnyValue{},
+				seccomp.AnyValue{},
+				seccomp.EqualTo(0),
+				seccomp.EqualTo(0),
+				seccomp.EqualTo(0),
+			},
+			seccomp.PerArg{
+				seccomp.NonNegativeFD{},
+				seccomp.AnyValue{},
+				seccomp.AnyValue{},
+				seccomp.EqualTo(linux.IORING_ENTER_GETEVENTS),
+				seccomp.EqualTo(0),
+				seccomp.EqualTo(0),
+			},
+		},
+	})
+}
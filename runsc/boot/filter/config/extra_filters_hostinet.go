@@ -22,7 +22,9 @@ import (
 )
 
 // hostInetFilters contains syscalls that are needed by sentry/socket/hostinet.
-func hostInetFilters(allowRawSockets bool) seccomp.SyscallRules {
+// If allowTCPRepair is true, they also permit the socket options needed to
+// take over TCP connections on restore.
+func hostInetFilters(allowRawSockets, allowTCPRepair bool) seccomp.SyscallRules {
 	rules := seccomp.MakeSyscallRules(map[uintptr]seccomp.SyscallRule{
 		unix.SYS_ACCEPT4: seccomp.PerArg{
 			seccomp.AnyValue{},
@@ -79,7 +81,15 @@ func hostInetFilters(allowRawSockets bool) seccomp.SyscallRules {
 		unix.SYS_LISTEN:   seccomp.MatchAll{},
 		unix.SYS_READV:    seccomp.MatchAll{},
 		unix.SYS_RECVFROM: seccomp.MatchAll{},
+		unix.SYS_RECVMMSG: seccomp.PerArg{
+			seccomp.NonNegativeFD{},
+			seccomp.AnyValue{},
+			seccomp.AnyValue{},
+			seccomp.AnyValue{},
+			seccomp.EqualTo(0),
+		},
 		unix.SYS_RECVMSG:  seccomp.MatchAll{},
+		unix.SYS_SENDMMSG: seccomp.MatchAll{},
 		unix.SYS_SENDMSG:  seccomp.MatchAll{},
 		unix.SYS_SENDTO:   seccomp.MatchAll{},
 		unix.SYS_SHUTDOWN: seccomp.Or{
@@ -142,8 +152,12 @@ func hostInetFilters(allowRawSockets bool) seccomp.SyscallRules {
 	rules.Set(unix.SYS_SOCKET, socketRules)
 
 	// Generate rules for socket options based on hostinet's supported
-	// socket options.
-	for _, opt := range hostinet.SockOpts {
+	// socket options, and the options used to take over TCP connections.
+	opts := hostinet.SockOpts
+	if allowTCPRepair {
+		opts = append(append([]hostinet.SockOpt(nil), opts...), hostinet.RepairSockOpts...)
+	}
+	for _, opt := range opts {
 		if opt.AllowGet {
 			rules.Add(unix.SYS_GETSOCKOPT, seccomp.PerArg{
 				seccomp.AnyValue{},
@@ -0,0 +1,122 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"fmt"
+	"math"
+	"runtime/debug"
+	"strconv"
+
+	"gvisor.dev/gvisor/pkg/gomaxprocs"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/tmpfs"
+	"gvisor.dev/gvisor/runsc/config"
+)
+
+const (
+	// autoMemoryLimitDivisor is the fraction of the sandbox memory that the
+	// sentry's Go heap may grow to before the garbage collector works
+	// harder, with --sentry-memory-limit=auto.
+	autoMemoryLimitDivisor = 8
+
+	// autoGOGC is the garbage collection target percentage used with
+	// --sentry-gogc=auto when a memory limit is set. Collections are less
+	// frequent than with the default of 100, which reduces the pauses
+	// observed by applications, while the memory limit keeps the heap
+	// bounded. This replaces the usual "memory ballast" technique.
+	autoGOGC = 400
+)
+
+// goRuntimeSettings are the settings of the sentry's Go runtime.
+type goRuntimeSettings struct {
+	// maxProcs is the base GOMAXPROCS.
+	maxProcs int
+
+	// gcPercent is passed to debug.SetGCPercent if setGCPercent is true.
+	gcPercent    int
+	setGCPercent bool
+
+	// memoryLimit is passed to debug.SetMemoryLimit if it is non-negative.
+	memoryLimit int64
+}
+
+// computeGoRuntimeSettings returns the Go runtime settings for a sandbox with
+// numCPU CPUs and totalMem bytes of memory (0 if unknown). Settings that are
+// not set by flags are left to the Go runtime, which honors the GOGC and
+// GOMEMLIMIT environment variables.
+func computeGoRuntimeSettings(conf *config.Config, numCPU int, totalMem uint64) (goRuntimeSettings, error) {
+	s := goRuntimeSettings{
+		maxProcs:    numCPU,
+		memoryLimit: -1,
+	}
+	if conf.SentryMaxProcs > 0 {
+		s.maxProcs = conf.SentryMaxProcs
+	}
+
+	switch conf.SentryMemoryLimit {
+	case "":
+	case "auto":
+		if totalMem > 0 {
+			s.memoryLimit = int64(totalMem / autoMemoryLimitDivisor)
+		}
+	case "off":
+		s.memoryLimit = math.MaxInt64
+	default:
+		limit, err := tmpfs.ParseSize(conf.SentryMemoryLimit)
+		if err != nil || limit > math.MaxInt64 {
+			return goRuntimeSettings{}, fmt.Errorf("invalid sentry-memory-limit %q", conf.SentryMemoryLimit)
+		}
+		s.memoryLimit = int64(limit)
+	}
+
+	switch conf.SentryGOGC {
+	case "":
+	case "auto":
+		if s.memoryLimit >= 0 && s.memoryLimit != math.MaxInt64 {
+			s.gcPercent = autoGOGC
+			s.setGCPercent = true
+		}
+	case "off":
+		s.gcPercent = -1
+		s.setGCPercent = true
+	default:
+		percent, err := strconv.Atoi(conf.SentryGOGC)
+		if err != nil || percent < 0 {
+			return goRuntimeSettings{}, fmt.Errorf("invalid sentry-gogc %q", conf.SentryGOGC)
+		}
+		s.gcPercent = percent
+		s.setGCPercent = true
+	}
+	return s, nil
+}
+
+// configureGoRuntime applies the Go runtime settings of the sentry.
+func configureGoRuntime(conf *config.Config, numCPU int, totalMem uint64) error {
+	s, err := computeGoRuntimeSettings(conf, numCPU, totalMem)
+	if err != nil {
+		return err
+	}
+	gomaxprocs.SetBase(s.maxProcs)
+	if s.setGCPercent {
+		log.Infof("Setting sentry GOGC to %d", s.gcPercent)
+		debug.SetGCPercent(s.gcPercent)
+	}
+	if s.memoryLimit >= 0 {
+		log.Infof("Setting sentry memory limit to %d bytes", s.memoryLimit)
+		debug.SetMemoryLimit(s.memoryLimit)
+	}
+	return nil
+}
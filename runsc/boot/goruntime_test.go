@@ -0,0 +1,115 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"math"
+	"testing"
+
+	"gvisor.dev/gvisor/runsc/config"
+)
+
+func TestComputeGoRuntimeSettings(t *testing.T) {
+	const gib = 1 << 30
+	for _, tc := range []struct {
+		name     string
+		gogc     string
+		memLimit string
+		maxProcs int
+		totalMem uint64
+		want     goRuntimeSettings
+	}{
+		{
+			name:     "default",
+			totalMem: 64 * gib,
+			want:     goRuntimeSettings{maxProcs: 4, memoryLimit: -1},
+		},
+		{
+			name:     "auto",
+			gogc:     "auto",
+			memLimit: "auto",
+			totalMem: 64 * gib,
+			want:     goRuntimeSettings{maxProcs: 4, gcPercent: autoGOGC, setGCPercent: true, memoryLimit: 8 * gib},
+		},
+		{
+			name:     "auto with unknown memory size",
+			gogc:     "auto",
+			memLimit: "auto",
+			want:     goRuntimeSettings{maxProcs: 4, memoryLimit: -1},
+		},
+		{
+			name:     "auto GOGC without limit",
+			gogc:     "auto",
+			totalMem: 64 * gib,
+			want:     goRuntimeSettings{maxProcs: 4, memoryLimit: -1},
+		},
+		{
+			name:     "auto limit only",
+			memLimit: "auto",
+			totalMem: 64 * gib,
+			want:     goRuntimeSettings{maxProcs: 4, memoryLimit: 8 * gib},
+		},
+		{
+			name:     "flags",
+			gogc:     "200",
+			memLimit: "2g",
+			maxProcs: 2,
+			totalMem: 64 * gib,
+			want:     goRuntimeSettings{maxProcs: 2, gcPercent: 200, setGCPercent: true, memoryLimit: 2 * gib},
+		},
+		{
+			name:     "off",
+			gogc:     "off",
+			memLimit: "off",
+			totalMem: 64 * gib,
+			want:     goRuntimeSettings{maxProcs: 4, gcPercent: -1, setGCPercent: true, memoryLimit: math.MaxInt64},
+		},
+		{
+			name:     "auto GOGC with limit off",
+			gogc:     "auto",
+			memLimit: "off",
+			totalMem: 64 * gib,
+			want:     goRuntimeSettings{maxProcs: 4, memoryLimit: math.MaxInt64},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := &config.Config{
+				SentryGOGC:        tc.gogc,
+				SentryMemoryLimit: tc.memLimit,
+				SentryMaxProcs:    tc.maxProcs,
+			}
+			got, err := computeGoRuntimeSettings(conf, 4, tc.totalMem)
+			if err != nil {
+				t.Fatalf("computeGoRuntimeSettings failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("computeGoRuntimeSettings = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestComputeGoRuntimeSettingsInvalid(t *testing.T) {
+	for _, conf := range []*config.Config{
+		{SentryGOGC: "-1"},
+		{SentryGOGC: "lots"},
+		{SentryMemoryLimit: "1x"},
+		{SentryMemoryLimit: "automatic"},
+	} {
+		if _, err := computeGoRuntimeSettings(conf, 4, 0); err == nil {
+			t.Errorf("computeGoRuntimeSettings(%+v) succeeded, want error", conf)
+		}
+	}
+}
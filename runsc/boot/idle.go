@@ -0,0 +1,133 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"fmt"
+	"os"
+	gtime "time"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/control"
+	"gvisor.dev/gvisor/pkg/state/statefile"
+	"gvisor.dev/gvisor/pkg/urpc"
+)
+
+// idleCheckpointMaxPollInterval is the maximum interval at which the sandbox
+// is checked for idleness.
+const idleCheckpointMaxPollInterval = 10 * gtime.Second
+
+// startIdleMonitor starts a goroutine that checkpoints the sandbox to
+// l.saveFDs and stops it once it has been idle for longer than
+// --idle-checkpoint-timeout. It is a no-op if idle checkpointing is not
+// enabled.
+func (l *Loader) startIdleMonitor() {
+	timeout := l.root.conf.IdleCheckpointTimeout
+	if timeout <= 0 || len(l.saveFDs) == 0 || len(l.root.conf.TestOnlyAutosaveImagePath) != 0 {
+		return
+	}
+	log.Infof("Idle checkpointing enabled, timeout: %v", timeout)
+	go l.monitorIdle(timeout)
+}
+
+// monitorIdle polls the sandbox and checkpoints it once it has been idle for
+// longer than timeout. The sandbox is idle if no task has used CPU time and no
+// packet has been sent or received on any network interface.
+func (l *Loader) monitorIdle(timeout gtime.Duration) {
+	interval := min(timeout/4, idleCheckpointMaxPollInterval)
+	ticker := gtime.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastCPU := l.k.CPUClockNow()
+	lastPackets := l.networkPackets()
+	idleSince := gtime.Now()
+	for range ticker.C {
+		cpu := l.k.CPUClockNow()
+		packets := l.networkPackets()
+		if cpu != lastCPU || packets != lastPackets || !l.idleCheckpointable() {
+			lastCPU, lastPackets = cpu, packets
+			idleSince = gtime.Now()
+			continue
+		}
+		if idle := gtime.Since(idleSince); idle >= timeout {
+			log.Infof("Sandbox has been idle for %v, checkpointing", idle)
+			if err := l.saveIdle(); err != nil {
+				log.Warningf("Idle checkpoint failed: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// idleCheckpointable returns true if the sandbox may be checkpointed for being
+// idle. Only started single-container sandboxes without outstanding exec
+// processes are checkpointed, since only those can be restored on demand.
+func (l *Loader) idleCheckpointable() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.state != started && l.state != restored {
+		return false
+	}
+	return len(l.processes) == 1
+}
+
+// networkPackets returns the total number of packets sent and received on all
+// network interfaces.
+func (l *Loader) networkPackets() uint64 {
+	stats, err := l.networkStats()
+	if err != nil {
+		log.Warningf("Failed to get network stats: %v", err)
+		return 0
+	}
+	var packets uint64
+	for _, s := range stats {
+		packets += s.RxPackets + s.TxPackets
+	}
+	return packets
+}
+
+// saveIdle checkpoints the sandbox to l.saveFDs and stops it. If the
+// checkpoint fails, the state file is truncated so that no restore from the
+// partial image is attempted.
+func (l *Loader) saveIdle() error {
+	files := make([]*os.File, 0, len(l.saveFDs))
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for _, fd := range l.saveFDs {
+		f, err := fd.File()
+		if err != nil {
+			return fmt.Errorf("duplicating checkpoint file: %w", err)
+		}
+		files = append(files, f)
+	}
+	o := control.SaveOpts{
+		Metadata: statefile.Options{Compression: statefile.CompressionLevelFlateBestSpeed}.WriteToMetadata(map[string]string{}),
+		FilePayload: urpc.FilePayload{
+			Files: files,
+		},
+		HavePagesFile: len(files) > 1,
+	}
+	if err := l.save(&o); err != nil {
+		if terr := unix.Ftruncate(l.saveFDs[0].FD(), 0); terr != nil {
+			log.Warningf("Failed to truncate partial checkpoint: %v", terr)
+		}
+		return err
+	}
+	return nil
+}
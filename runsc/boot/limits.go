@@ -79,12 +79,14 @@ func (d *defs) initDefaults() error {
 	ls.SetUnchecked(limits.Rss, limits.Limit{Cur: limits.Infinity, Max: limits.Infinity})
 	ls.SetUnchecked(limits.RealTimePriority, limits.Limit{Cur: 0, Max: 0})
 	ls.SetUnchecked(limits.Rttime, limits.Limit{Cur: limits.Infinity, Max: limits.Infinity})
+	// SignalsPending depends on the host's memory size; it is replaced by the
+	// host's limit below.
 	ls.SetUnchecked(limits.SignalsPending, limits.Limit{Cur: 0, Max: 0})
 	ls.SetUnchecked(limits.Stack, limits.Limit{Cur: 8388608, Max: limits.Infinity})
 
-	// Read host limits that directly affect the sandbox and adjust the defaults
-	// based on them.
-	for _, res := range []int{unix.RLIMIT_FSIZE, unix.RLIMIT_NOFILE} {
+	// Read host limits that directly affect the sandbox, or that containers
+	// otherwise inherit from the host, and adjust the defaults based on them.
+	for _, res := range []int{unix.RLIMIT_FSIZE, unix.RLIMIT_NOFILE, unix.RLIMIT_SIGPENDING} {
 		var hl unix.Rlimit
 		if err := unix.Getrlimit(res, &hl); err != nil {
 			return err
@@ -113,14 +115,15 @@ func (d *defs) initDefaults() error {
 	return nil
 }
 
-func createLimitSet(spec *specs.Spec, enableTPUProxy bool) (*limits.LimitSet, error) {
+func createLimitSet(spec *specs.Spec, enableVFIOProxy bool) (*limits.LimitSet, error) {
 	ls, err := defaults.get()
 	if err != nil {
 		return nil, err
 	}
-	// Set RLIMIT_MEMLOCK's default value to unlimited when TPUProxy is enabled.
-	// The value will be overwritten if the exact rlimit is provided.
-	if enableTPUProxy {
+	// Set RLIMIT_MEMLOCK's default value to unlimited when VFIO devices are
+	// proxied, since DMA mappings pin memory. The value will be overwritten if
+	// the exact rlimit is provided.
+	if enableVFIOProxy {
 		ls.SetUnchecked(limits.MemoryLocked, limits.Limit{Cur: limits.Infinity, Max: limits.Infinity})
 	}
 	// Then apply overwrites on top of defaults.
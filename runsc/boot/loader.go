@@ -18,9 +18,11 @@ package boot
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
 	gtime "time"
 
 	"github.com/moby/sys/capability"
@@ -30,15 +32,16 @@ import (
 	"gvisor.dev/gvisor/pkg/bpf"
 	"gvisor.dev/gvisor/pkg/cleanup"
 	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/control/server"
 	"gvisor.dev/gvisor/pkg/coverage"
 	"gvisor.dev/gvisor/pkg/cpuid"
 	"gvisor.dev/gvisor/pkg/fd"
-	"gvisor.dev/gvisor/pkg/gomaxprocs"
 	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/memutil"
 	"gvisor.dev/gvisor/pkg/metric"
 	"gvisor.dev/gvisor/pkg/rand"
 	"gvisor.dev/gvisor/pkg/refs"
+	"gvisor.dev/gvisor/pkg/sandbox/api"
 	"gvisor.dev/gvisor/pkg/sentry/control"
 	"gvisor.dev/gvisor/pkg/sentry/devices/nvproxy"
 	"gvisor.dev/gvisor/pkg/sentry/devices/nvproxy/nvconf"
@@ -46,6 +49,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/fsimpl/host"
 	"gvisor.dev/gvisor/pkg/sentry/fsimpl/tmpfs"
 	"gvisor.dev/gvisor/pkg/sentry/fsimpl/user"
+	"gvisor.dev/gvisor/pkg/sentry/hostfd"
 	"gvisor.dev/gvisor/pkg/sentry/inet"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
@@ -58,6 +62,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/socket/netfilter"
 	"gvisor.dev/gvisor/pkg/sentry/socket/plugin"
 	"gvisor.dev/gvisor/pkg/sentry/socket/unix/transport"
+	slinux "gvisor.dev/gvisor/pkg/sentry/syscalls/linux"
 	"gvisor.dev/gvisor/pkg/sentry/time"
 	"gvisor.dev/gvisor/pkg/sentry/usage"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
@@ -91,6 +96,7 @@ import (
 
 	// Include other supported socket providers.
 	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink"
+	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink/genetlink"
 	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink/netfilter"
 	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink/route"
 	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink/uevent"
@@ -209,6 +215,12 @@ type Loader struct {
 	// should be called when a sandbox is destroyed.
 	stopProfiling func()
 
+	// rootShmMntns is a reference on the mount namespace of the root
+	// container, held to clean up its /dev/shm when the sandbox is destroyed.
+	// It is nil unless the root container's spec requests
+	// devShmCleanupUnlink.
+	rootShmMntns *vfs.MountNamespace
+
 	// PreSeccompCallback is called right before installing seccomp filters.
 	PreSeccompCallback func()
 
@@ -273,6 +285,17 @@ type Loader struct {
 	// saveRestoreNet indicates if the saved network stack should be used
 	// during restore.
 	saveRestoreNet bool
+
+	// hostFDs is the inventory of host FDs found by AuditFDs at startup.
+	// Immutable.
+	hostFDs []HostFD
+
+	// handover retains the host FDs donated to the sandbox for Upgrade. It is
+	// nil unless --hot-upgrade is set. Immutable.
+	handover *handover
+
+	// dhcp owns the DHCP clients of the sandbox's network links. Immutable.
+	dhcp *dhcpClients
 }
 
 // execID uniquely identifies a sentry process that is executed in a container.
@@ -281,6 +304,12 @@ type execID struct {
 	pid kernel.ThreadID
 }
 
+// PIDNamespaceContainerAnnotation is the annotation used to make a
+// subcontainer join the PID namespace of another container in the sandbox,
+// named by its ID, instead of the PID namespace given in its spec. Debug
+// containers use it to see the processes of the container they debug.
+const PIDNamespaceContainerAnnotation = "dev.gvisor.spec.pidns-container"
+
 // execProcess contains the thread group and host TTY of a sentry process.
 type execProcess struct {
 	// tg will be nil for containers that haven't started yet.
@@ -296,6 +325,10 @@ type execProcess struct {
 	// TTY file is passed during container create and must be saved until
 	// container start.
 	hostTTY *fd.FD
+
+	// exitStatus is the wait status of a process that was started by exec
+	// and exited before the sandbox was upgraded. tg is nil if it is set.
+	exitStatus *uint32
 }
 
 // fdMapping maps guest to host file descriptors. Guest file descriptors are
@@ -357,12 +390,30 @@ type Args struct {
 	TotalHostMem uint64
 	// UserLogFD is the file descriptor to write user logs to.
 	UserLogFD int
+	// StraceLogFD is the file descriptor to write strace output to. 0 means
+	// the debug log.
+	StraceLogFD int
+	// PanicReportFD is the file descriptor to write panic reports to, or -1.
+	PanicReportFD int
 	// ProductName is the value to show in
 	// /sys/devices/virtual/dmi/id/product_name.
 	ProductName string
 	// PodInitConfigFD is the file descriptor to a file passed in the
 	//	--pod-init-config flag
 	PodInitConfigFD int
+	// ControlPolicyFD is the file descriptor to a file passed in the
+	// --control-policy flag, or -1 if none.
+	ControlPolicyFD int
+	// ControlTokenFD is the file descriptor to the file holding the sandbox
+	// owner's control token, or -1 if none. It is required with
+	// ControlPolicyFD.
+	ControlTokenFD int
+	// HWRNGFD is the file descriptor to the device passed in the
+	// --hwrng-device flag, or -1 if none.
+	HWRNGFD int
+	// VDSOFD is the file descriptor to the root container's alternate VDSO,
+	// selected by VDSOAnnotation, or -1 if none.
+	VDSOFD int
 	// SinkFDs is an ordered array of file descriptors to be used by seccheck
 	// sinks configured from the --pod-init-config file.
 	SinkFDs []int
@@ -376,6 +427,9 @@ type Args struct {
 	HostTHP HostTHP
 
 	SaveFDs []*fd.FD
+
+	// HostFDs is the inventory of host FDs found by AuditFDs at startup.
+	HostFDs []HostFD
 }
 
 // HostTHP holds host transparent hugepage settings.
@@ -398,6 +452,10 @@ const (
 	// containerSpecsKey is the key used to add and pop the container specs to the
 	// kernel during save/restore.
 	containerSpecsKey = "container_specs"
+
+	// hostUringEntries is the number of submission queue entries in the host
+	// io_uring used when config.Config.HostUring is set.
+	hostUringEntries = 256
 )
 
 func getRootCredentials(spec *specs.Spec, conf *config.Config, userNs *auth.UserNamespace) *auth.Credentials {
@@ -444,6 +502,10 @@ func New(args Args) (*Loader, error) {
 	if err := rand.Init(); err != nil {
 		return nil, fmt.Errorf("setting up rand: %w", err)
 	}
+	if err := rand.UseSource(args.Conf.EntropySource, args.HWRNGFD); err != nil {
+		return nil, fmt.Errorf("setting up entropy source: %w", err)
+	}
+	log.Infof("Entropy source: %s", rand.Source())
 
 	if err := usage.Init(); err != nil {
 		return nil, fmt.Errorf("setting up memory usage: %w", err)
@@ -454,6 +516,13 @@ func New(args Args) (*Loader, error) {
 	}
 
 	kernel.IOUringEnabled = args.Conf.IOUring
+	vfs.RecordIOStats = args.Conf.IOStats
+
+	if args.Conf.KernelRelease != "" || args.Conf.KernelVersion != "" || args.Conf.KernelReleaseSyscalls {
+		if err := slinux.ConfigureVersion(args.Conf.KernelRelease, args.Conf.KernelVersion, args.Conf.KernelReleaseSyscalls); err != nil {
+			return nil, fmt.Errorf("configuring kernel version: %w", err)
+		}
+	}
 
 	eid := execID{cid: args.ID}
 	l := &Loader{
@@ -466,6 +535,8 @@ func New(args Args) (*Loader, error) {
 		containerIDs:   make(map[string]string),
 		containerSpecs: make(map[string]*specs.Spec),
 		saveFDs:        args.SaveFDs,
+		hostFDs:        args.HostFDs,
+		dhcp:           newDHCPClients(),
 	}
 
 	containerName := l.registerContainer(args.Spec, args.ID)
@@ -519,6 +590,18 @@ func New(args Args) (*Loader, error) {
 		})
 	}
 
+	if args.Conf.HotUpgrade {
+		l.handover = newHandover()
+		if err := l.handover.addContainer(args.ID, &startFDs{
+			stdios:            l.root.stdioFDs,
+			goferFilestoreFDs: l.root.goferFilestoreFDs,
+			devGoferFD:        l.root.devGoferFD,
+			goferFDs:          l.root.goferFDs,
+		}); err != nil {
+			return nil, fmt.Errorf("retaining FDs for upgrade: %w", err)
+		}
+	}
+
 	// Create kernel and platform.
 	p, err := createPlatform(args.Conf, args.Device)
 	if err != nil {
@@ -553,8 +636,20 @@ func New(args Args) (*Loader, error) {
 	tk := kernel.NewTimekeeper()
 	params := kernel.NewVDSOParamPage(l.k.MemoryFile(), vdso.ParamPage.FileRange())
 	tk.SetClocks(time.NewCalibratedClocks(), params)
+	if args.Conf.TestOnlyTimeDilation != "" {
+		rate, err := strconv.ParseFloat(args.Conf.TestOnlyTimeDilation, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing time dilation %q: %w", args.Conf.TestOnlyTimeDilation, err)
+		}
+		// A rate of 1 only enables the control RPCs.
+		if rate != 1 {
+			if err := tk.SetTimeDilation(rate); err != nil {
+				return nil, fmt.Errorf("setting time dilation: %w", err)
+			}
+		}
+	}
 
-	if err := enableStrace(args.Conf); err != nil {
+	if err := enableStrace(args.Conf, args.StraceLogFD); err != nil {
 		return nil, fmt.Errorf("enabling strace: %w", err)
 	}
 
@@ -581,7 +676,9 @@ func New(args Args) (*Loader, error) {
 		args.NumCPU = runtime.NumCPU()
 	}
 	log.Infof("CPUs: %d", args.NumCPU)
-	gomaxprocs.SetBase(args.NumCPU)
+	if err := configureGoRuntime(args.Conf, args.NumCPU, args.TotalMem); err != nil {
+		return nil, err
+	}
 
 	if args.TotalHostMem > 0 {
 		// As per tmpfs(5), the default size limit is 50% of total physical RAM.
@@ -621,6 +718,7 @@ func New(args Args) (*Loader, error) {
 		RootUserNamespace:    creds.UserNamespace,
 		RootNetworkNamespace: netns,
 		ApplicationCores:     uint(args.NumCPU),
+		NUMANodes:            uint(args.Conf.NUMANodes),
 		Vdso:                 vdso,
 		VdsoParams:           params,
 		RootUTSNamespace:     kernel.NewUTSNamespace(args.Spec.Hostname, args.Spec.Hostname, creds.UserNamespace),
@@ -628,10 +726,22 @@ func New(args Args) (*Loader, error) {
 		RootPIDNamespace:     kernel.NewRootPIDNamespace(creds.UserNamespace),
 		MaxFDLimit:           maxFDLimit,
 		UnixSocketOpts:       unixSocketOpts,
+		TextSegmentOpts: loader.TextSegmentOpts{
+			Huge:                         args.Conf.AppHugeText,
+			Prefault:                     args.Conf.AppPrefaultText,
+			RequireControlFlowProtection: args.Conf.AppRequireControlFlowProtection,
+		},
+		HostThreadNames:      args.Conf.HostThreadNames,
+		HostCoreSchedCookies: args.Conf.EnableCoreTags,
+		FlightRecorder:       args.Conf.FlightRecorder,
 	}); err != nil {
 		return nil, fmt.Errorf("initializing kernel: %w", err)
 	}
 
+	if args.PanicReportFD > 0 {
+		l.k.OnPanic = newPanicReporter(os.NewFile(uintptr(args.PanicReportFD), "panic report"), args.Conf.Platform)
+	}
+
 	if err := registerFilesystems(l.k, &l.root); err != nil {
 		return nil, fmt.Errorf("registering filesystems: %w", err)
 	}
@@ -681,6 +791,15 @@ func New(args Args) (*Loader, error) {
 
 	l.k.RegisterContainerName(args.ID, l.root.containerName)
 
+	var vdsoFD *fd.FD
+	if args.VDSOFD >= 0 {
+		vdsoFD = fd.New(args.VDSOFD)
+		defer vdsoFD.Close()
+	}
+	if err := setupContainerVDSO(l.k, args.Conf, args.Spec, args.ID, vdsoFD); err != nil {
+		return nil, err
+	}
+
 	// We don't care about child signals; some platforms can generate a
 	// tremendous number of useless ones (I'm looking at you, ptrace).
 	if err := sighandling.IgnoreChildStop(); err != nil {
@@ -702,6 +821,27 @@ func New(args Args) (*Loader, error) {
 		return nil, fmt.Errorf("creating control server: %w", err)
 	}
 	l.ctrl = ctrl
+	if err := l.handover.setController(ctrl.srv.FD()); err != nil {
+		return nil, err
+	}
+	if args.ControlPolicyFD >= 0 {
+		if args.ControlTokenFD < 0 {
+			return nil, fmt.Errorf("control policy requires a control token")
+		}
+		tokenFile := os.NewFile(uintptr(args.ControlTokenFD), "control token")
+		token, err := io.ReadAll(tokenFile)
+		tokenFile.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading control token: %w", err)
+		}
+		policyFile := os.NewFile(uintptr(args.ControlPolicyFD), "control policy")
+		policy, err := server.LoadPolicy(policyFile, strings.TrimSpace(string(token)))
+		policyFile.Close()
+		if err != nil {
+			return nil, err
+		}
+		ctrl.srv.SetPolicy(policy)
+	}
 
 	// Only start serving after Loader is set to controller and controller is set
 	// to Loader, because they are both used in the urpc methods.
@@ -715,7 +855,7 @@ func New(args Args) (*Loader, error) {
 // createProcessArgs creates args that can be used with kernel.CreateProcess.
 func createProcessArgs(id string, spec *specs.Spec, conf *config.Config, creds *auth.Credentials, k *kernel.Kernel, pidns *kernel.PIDNamespace) (kernel.CreateProcessArgs, error) {
 	// Create initial limits.
-	ls, err := createLimitSet(spec, specutils.TPUProxyIsEnabled(spec, conf))
+	ls, err := createLimitSet(spec, specutils.VFIOProxyIsEnabled(spec, conf))
 	if err != nil {
 		return kernel.CreateProcessArgs{}, fmt.Errorf("creating limits: %w", err)
 	}
@@ -747,6 +887,7 @@ func createProcessArgs(id string, spec *specs.Spec, conf *config.Config, creds *
 		IPCNamespace:         k.RootIPCNamespace(),
 		ContainerID:          id,
 		PIDNamespace:         pidns,
+		NoNewPrivs:           spec.Process.NoNewPrivileges,
 	}
 
 	return procArgs, nil
@@ -763,6 +904,14 @@ func (l *Loader) Destroy() {
 	}
 	l.watchdog.Stop()
 
+	// Stop DHCP clients while the network stack is still usable, so that they
+	// can release their leases.
+	l.dhcp.stop()
+
+	// Clean up the root container's /dev/shm while its mounts are still
+	// usable.
+	l.cleanupRootDevShm()
+
 	ctx := l.k.SupervisorContext()
 	l.mu.Lock()
 	for _, m := range l.sharedMounts {
@@ -887,6 +1036,16 @@ func (l *Loader) installSeccompFilters() error {
 	if l.PreSeccompCallback != nil {
 		l.PreSeccompCallback()
 	}
+	// The host io_uring must be set up before filters are installed, since
+	// they don't permit io_uring_setup(2).
+	hostUring := false
+	if l.root.conf.HostUring {
+		if err := hostfd.EnableUring(hostUringEntries); err != nil {
+			log.Warningf("Failed to set up host io_uring, falling back to synchronous host I/O: %v", err)
+		} else {
+			hostUring = true
+		}
+	}
 	if l.root.conf.DisableSeccomp {
 		log.Warningf("*** SECCOMP WARNING: syscall filter is DISABLED. Running in less secure mode.")
 	} else {
@@ -909,14 +1068,19 @@ func (l *Loader) installSeccompFilters() error {
 			Platform:              l.k.Platform.SeccompInfo(),
 			HostNetwork:           hostnet,
 			HostNetworkRawSockets: hostnet && l.root.conf.EnableRaw,
+			HostNetworkTCPRepair:  hostnet && l.root.conf.NetworkHandoff,
 			HostFilesystem:        l.root.conf.DirectFS,
 			ProfileEnable:         l.root.conf.ProfileEnable,
 			NVProxy:               nvproxyEnabled,
 			NVProxyCaps:           nvproxyCaps,
 			TPUProxy:              specutils.TPUProxyIsEnabled(l.root.spec, l.root.conf),
+			SRIOV:                 l.root.conf.Network == config.NetworkSRIOV,
 			ControllerFD:          uint32(l.ctrl.srv.FD()),
 			CgoEnabled:            config.CgoEnabled,
 			PluginNetwork:         l.root.conf.Network == config.NetworkPlugin,
+			HostUring:             hostUring,
+			HostLocks:             l.root.conf.HostLocks,
+			CoreTags:              l.root.conf.EnableCoreTags,
 		}
 		if err := filter.Install(opts); err != nil {
 			return fmt.Errorf("installing seccomp filters: %w", err)
@@ -1005,6 +1169,12 @@ func (l *Loader) run() error {
 	}
 
 	ep.tg = l.k.GlobalInit()
+	if ep.tg != nil && devShmCleanup(l.root.spec) == devShmCleanupUnlink {
+		// task.MountNamespace() does not take a ref, so we must do so ourselves.
+		if mntns := ep.tg.Leader().MountNamespace(); mntns != nil && mntns.TryIncRef() {
+			l.rootShmMntns = mntns
+		}
+	}
 	if ns, ok := specutils.GetNS(specs.PIDNamespace, l.root.spec); ok {
 		ep.pidnsPath = ns.Path
 	}
@@ -1041,6 +1211,98 @@ func (l *Loader) run() error {
 	} else {
 		l.state = started
 	}
+	l.startIdleMonitor()
+	return nil
+}
+
+// adoptRoot replaces the root container of a created, but not yet started,
+// sandbox with container cid described by spec. Used FDs are released from
+// fds; it's safe for the caller to close any remaining FDs upon return.
+func (l *Loader) adoptRoot(spec *specs.Spec, cid string, fds *startFDs, goferMountConfs []GoferMountConf) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.state != created {
+		return fmt.Errorf("cannot adopt container %q: sandbox is %s", cid, l.state)
+	}
+	if len(l.processes) != 1 {
+		return fmt.Errorf("cannot adopt container %q: sandbox has other containers or processes", cid)
+	}
+	if _, ok := l.processes[execID{cid: l.sandboxID}]; !ok {
+		return fmt.Errorf("cannot adopt container %q: root container %q was deleted", cid, l.sandboxID)
+	}
+	if spec.Process.Terminal || l.root.spec.Process.Terminal {
+		return fmt.Errorf("cannot adopt container %q: terminals are not supported", cid)
+	}
+	conf := l.root.conf
+	if specutils.NVProxyEnabled(spec, conf) != specutils.NVProxyEnabled(l.root.spec, conf) ||
+		specutils.VFIOProxyIsEnabled(spec, conf) != specutils.VFIOProxyIsEnabled(l.root.spec, conf) {
+		return fmt.Errorf("cannot adopt container %q: device proxy configuration differs from the sandbox", cid)
+	}
+
+	creds := getRootCredentials(spec, conf, l.k.RootUserNamespace())
+	if creds == nil {
+		return fmt.Errorf("getting root credentials")
+	}
+	procArgs, err := createProcessArgs(cid, spec, conf, creds, l.k, l.k.RootPIDNamespace())
+	if err != nil {
+		return fmt.Errorf("creating init process for container %q: %w", cid, err)
+	}
+	mountHints, err := NewPodMountHints(spec)
+	if err != nil {
+		return fmt.Errorf("creating pod mount hints: %w", err)
+	}
+	if err := setupContainerVDSO(l.k, conf, spec, cid, fds.vdsoFD); err != nil {
+		return err
+	}
+
+	// Replace stdios in place, since host FDs must map to the same number when
+	// the sandbox is restored.
+	for i, stdio := range fds.stdios {
+		if err := unix.Dup3(stdio.FD(), l.root.stdioFDs[i].FD(), unix.O_CLOEXEC); err != nil {
+			return fmt.Errorf("dup3 of stdios failed: %w", err)
+		}
+	}
+	if err := l.handover.addContainer(cid, fds); err != nil {
+		return err
+	}
+
+	// Rename the root container. No task has been created for it yet, so only
+	// the loader's bookkeeping needs to be updated.
+	oldCID := l.sandboxID
+	ep := l.processes[execID{cid: oldCID}]
+	delete(l.processes, execID{cid: oldCID})
+	l.processes[execID{cid: cid}] = ep
+	delete(l.containerIDs, l.root.containerName)
+	delete(l.containerSpecs, l.root.containerName)
+	containerName := l.registerContainerLocked(spec, cid)
+	l.k.RegisterContainerName(cid, containerName)
+	l.k.RootUTSNamespace().SetHostName(spec.Hostname)
+	l.k.RootUTSNamespace().SetDomainName(spec.Hostname)
+
+	for _, f := range l.root.goferFDs {
+		_ = f.Close()
+	}
+	for _, f := range l.root.goferFilestoreFDs {
+		_ = f.Close()
+	}
+	if l.root.devGoferFD != nil {
+		_ = l.root.devGoferFD.Close()
+	}
+	l.sandboxID = cid
+	l.root.cid = cid
+	l.root.containerName = containerName
+	l.root.spec = spec
+	l.root.procArgs = procArgs
+	l.root.goferMountConfs = goferMountConfs
+	l.root.goferFDs, fds.goferFDs = fds.goferFDs, nil
+	l.root.goferFilestoreFDs, fds.goferFilestoreFDs = fds.goferFilestoreFDs, nil
+	l.root.devGoferFD, fds.devGoferFD = fds.devGoferFD, nil
+	l.mountHints = mountHints
+	if oldCID != cid {
+		l.handover.removeContainer(oldCID)
+	}
+	log.Infof("Root container %q adopted as %q", oldCID, cid)
 	return nil
 }
 
@@ -1060,7 +1322,7 @@ func (l *Loader) createSubcontainer(cid string, tty *fd.FD) error {
 // startSubcontainer starts a child container. It returns the thread group ID of
 // the newly created process. Used FDs are either closed or released. It's safe
 // for the caller to close any remaining files upon return.
-func (l *Loader) startSubcontainer(spec *specs.Spec, conf *config.Config, cid string, stdioFDs, goferFDs, goferFilestoreFDs []*fd.FD, devGoferFD *fd.FD, goferMountConfs []GoferMountConf) error {
+func (l *Loader) startSubcontainer(spec *specs.Spec, conf *config.Config, cid string, stdioFDs, goferFDs, goferFilestoreFDs []*fd.FD, devGoferFD, vdsoFD *fd.FD, goferMountConfs []GoferMountConf) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -1078,7 +1340,15 @@ func (l *Loader) startSubcontainer(spec *specs.Spec, conf *config.Config, cid st
 		return fmt.Errorf("getting root credentials")
 	}
 	var pidns *kernel.PIDNamespace
-	if ns, ok := specutils.GetNS(specs.PIDNamespace, spec); ok {
+	if target, ok := spec.Annotations[PIDNamespaceContainerAnnotation]; ok {
+		tp := l.processes[execID{cid: target}]
+		if tp == nil || tp.tg == nil {
+			return fmt.Errorf("container %q whose PID namespace to join is not running", target)
+		}
+		log.Debugf("Joining PID namespace of container %q", target)
+		pidns = tp.tg.PIDNamespace()
+		ep.pidnsPath = tp.pidnsPath
+	} else if ns, ok := specutils.GetNS(specs.PIDNamespace, spec); ok {
 		if ns.Path != "" {
 			for _, p := range l.processes {
 				if ns.Path == p.pidnsPath {
@@ -1090,7 +1360,11 @@ func (l *Loader) startSubcontainer(spec *specs.Spec, conf *config.Config, cid st
 		}
 		if pidns == nil {
 			log.Warningf("PID namespace %q not found, running in new PID namespace", ns.Path)
-			pidns = l.k.RootPIDNamespace().NewChild(l.k.SupervisorContext(), l.k, l.k.RootUserNamespace())
+			var err error
+			pidns, err = l.k.RootPIDNamespace().NewChild(l.k.SupervisorContext(), l.k, l.k.RootUserNamespace())
+			if err != nil {
+				return fmt.Errorf("creating PID namespace: %w", err)
+			}
 		}
 		ep.pidnsPath = ns.Path
 	} else {
@@ -1099,6 +1373,9 @@ func (l *Loader) startSubcontainer(spec *specs.Spec, conf *config.Config, cid st
 
 	containerName := l.registerContainerLocked(spec, cid)
 	l.k.RegisterContainerName(cid, containerName)
+	if err := setupContainerVDSO(l.k, conf, spec, cid, vdsoFD); err != nil {
+		return err
+	}
 	info := &containerInfo{
 		cid:               cid,
 		containerName:     containerName,
@@ -1262,7 +1539,7 @@ func (l *Loader) createContainerProcess(info *containerInfo) (*kernel.ThreadGrou
 
 			task := tg.Leader()
 			// NOTE: It seems Flags are ignored by runc so we ignore them too.
-			if err := task.AppendSyscallFilter(program, true); err != nil {
+			if err := task.AppendSyscallFilter(program, nil /* notifier */, true /* syncAll */); err != nil {
 				return nil, nil, fmt.Errorf("appending seccomp filters: %w", err)
 			}
 		}
@@ -1340,7 +1617,19 @@ func (l *Loader) destroySubcontainer(cid string) error {
 
 	// The container exists, but has it been started?
 	if tg != nil {
+		// Hold on to the mount namespace to clean up /dev/shm once all
+		// container processes are gone.
+		// task.MountNamespace() does not take a ref, so we must do so ourselves.
+		var shmMntns *vfs.MountNamespace
+		if spec := l.containerSpecs[l.k.ContainerName(cid)]; spec != nil && devShmCleanup(spec) == devShmCleanupUnlink {
+			if mntns := tg.Leader().MountNamespace(); mntns != nil && mntns.TryIncRef() {
+				shmMntns = mntns
+			}
+		}
 		if err := l.signalAllProcesses(cid, int32(linux.SIGKILL)); err != nil {
+			if shmMntns != nil {
+				shmMntns.DecRef(l.k.SupervisorContext())
+			}
 			return fmt.Errorf("sending SIGKILL to all container processes: %w", err)
 		}
 		// Wait for all processes that belong to the container to exit (including
@@ -1350,6 +1639,11 @@ func (l *Loader) destroySubcontainer(cid string) error {
 				t.ThreadGroup().WaitExited()
 			}
 		}
+		if shmMntns != nil {
+			ctx := l.k.SupervisorContext()
+			cleanupDevShm(ctx, l.k.VFS(), auth.NewRootCredentials(l.k.RootUserNamespace()), shmMntns)
+			shmMntns.DecRef(ctx)
+		}
 	}
 
 	// No more failure from this point on.
@@ -1360,8 +1654,14 @@ func (l *Loader) destroySubcontainer(cid string) error {
 			delete(l.processes, key)
 		}
 	}
+	// Remove the container's cgroups, which would otherwise prevent the
+	// container from being started again, e.g. by "runsc restart".
+	l.removeCgroupSubmounts(cid)
 	// Cleanup the device gofer.
 	l.k.RemoveDevGofer(l.k.ContainerName(cid))
+	l.k.ResetContainerVDSO(cid)
+	l.handover.removeContainer(cid)
+	l.k.VFS().RemoveContainerIOStats(cid)
 
 	log.Debugf("Container destroyed, cid: %s", cid)
 	return nil
@@ -1406,7 +1706,7 @@ func (l *Loader) executeAsync(args *control.ExecArgs) (kernel.ThreadID, error) {
 	}
 	args.PIDNamespace = tg.PIDNamespace()
 
-	args.Limits, err = createLimitSet(l.root.spec, specutils.TPUProxyIsEnabled(l.root.spec, l.root.conf))
+	args.Limits, err = createLimitSet(l.root.spec, specutils.VFIOProxyIsEnabled(l.root.spec, l.root.conf))
 	if err != nil {
 		return 0, fmt.Errorf("creating limits: %w", err)
 	}
@@ -1490,6 +1790,15 @@ func (l *Loader) waitPID(tgid kernel.ThreadID, cid string, waitStatus *uint32) e
 
 	// Try to find a process that was exec'd
 	eid := execID{cid: cid, pid: tgid}
+	l.mu.Lock()
+	if ep, ok := l.processes[eid]; ok && ep.exitStatus != nil {
+		// The process exited before the sandbox was upgraded.
+		*waitStatus = *ep.exitStatus
+		delete(l.processes, eid)
+		l.mu.Unlock()
+		return nil
+	}
+	l.mu.Unlock()
 	execTG, err := l.threadGroupFromID(eid)
 	if err == nil {
 		ws := l.wait(execTG)
@@ -1556,7 +1865,7 @@ func newRootNetworkNamespace(conf *config.Config, clock tcpip.Clock, userns *aut
 		// No network namespacing support for hostinet yet, hence creator is nil.
 		return inet.NewRootNamespace(hostinet.NewStack(), nil, userns), nil
 
-	case config.NetworkNone, config.NetworkSandbox:
+	case config.NetworkNone, config.NetworkSandbox, config.NetworkSRIOV:
 		s, err := newEmptySandboxNetworkStack(clock, conf.AllowPacketEndpointWrite)
 		if err != nil {
 			return nil, err
@@ -1894,22 +2203,57 @@ func (l *Loader) portForward(opts *PortForwardOpts) error {
 	// Create a proxy to forward data between the fdConn and the sandboxed application.
 	pair := pf.ProxyPair{To: fdConn}
 
-	switch l.root.conf.Network {
-	case config.NetworkSandbox:
-		stack := l.k.RootNetworkNamespace().Stack().(*netstack.Stack).Stack
-		nsConn, err := pf.NewNetstackConn(stack, opts.Port)
-		if err != nil {
-			return fmt.Errorf("creating netstack port forward connection: %w", err)
+	switch opts.Protocol {
+	case "", PortForwardTCP, PortForwardUDP:
+		udp := opts.Protocol == PortForwardUDP
+		switch l.root.conf.Network {
+		case config.NetworkSandbox:
+			stack := l.k.RootNetworkNamespace().Stack().(*netstack.Stack).Stack
+			newConn := pf.NewNetstackConn
+			if udp {
+				newConn = pf.NewNetstackUDPConn
+			}
+			nsConn, err := newConn(stack, opts.Port)
+			if err != nil {
+				return fmt.Errorf("creating netstack port forward connection: %w", err)
+			}
+			pair.From = nsConn
+		case config.NetworkHost:
+			newConn := pf.NewHostInetConn
+			if udp {
+				newConn = pf.NewHostInetUDPConn
+			}
+			hConn, err := newConn(opts.Port)
+			if err != nil {
+				return fmt.Errorf("creating hostinet port forward connection: %w", err)
+			}
+			pair.From = hConn
+		default:
+			return fmt.Errorf("unsupported network type %q for container %q", l.root.conf.Network, cid)
 		}
-		pair.From = nsConn
-	case config.NetworkHost:
-		hConn, err := pf.NewHostInetConn(opts.Port)
+	case PortForwardUnix:
+		// task.MountNamespace() does not take a ref, so we must do so ourselves.
+		mntns := tg.Leader().MountNamespace()
+		if mntns == nil || !mntns.TryIncRef() {
+			return fmt.Errorf("container %q has stopped", cid)
+		}
+		defer mntns.DecRef(ctx)
+		root := mntns.Root(ctx)
+		defer root.DecRef(ctx)
+		uConn, err := pf.NewUnixSocketConn(ctx, &pf.UnixSocketOpts{
+			VFS:         l.k.VFS(),
+			Creds:       tg.Leader().Credentials(),
+			Root:        root,
+			Path:        opts.Path,
+			SocketMount: l.k.SocketMount(),
+			UniqueID:    l.k,
+		})
 		if err != nil {
-			return fmt.Errorf("creating hostinet port forward connection: %w", err)
+			return fmt.Errorf("creating unix socket port forward connection: %w", err)
 		}
-		pair.From = hConn
+		pair.From = uConn
 	default:
-		return fmt.Errorf("unsupported network type %q for container %q", l.root.conf.Network, cid)
+		return fmt.Errorf("unsupported port forward protocol %q", opts.Protocol)
 	}
 	cu.Release()
 	proxy := pf.NewProxy(pair, opts.ContainerID)
@@ -1980,6 +2324,61 @@ func (l *Loader) pidsCount(cid string) (int, error) {
 	return l.k.TaskSet().Root.NumTasksPerContainer(cid), nil
 }
 
+// mountIOStats returns the I/O statistics of the mounts in the mount namespace
+// of container cid.
+func (l *Loader) mountIOStats(cid string) ([]api.MountIOStats, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tg, err := l.tryThreadGroupFromIDLocked(execID{cid: cid})
+	if err != nil {
+		return nil, err
+	}
+	if tg == nil {
+		return nil, fmt.Errorf("container %q not started", cid)
+	}
+	ctx := l.k.SupervisorContext()
+	// task.MountNamespace() does not take a ref, so we must do so ourselves.
+	mntns := tg.Leader().MountNamespace()
+	if mntns == nil || !mntns.TryIncRef() {
+		return nil, fmt.Errorf("container %q has stopped", cid)
+	}
+	defer mntns.DecRef(ctx)
+	root := mntns.Root(ctx)
+	defer root.DecRef(ctx)
+	stats, err := l.k.VFS().MountsIOStats(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]api.MountIOStats, 0, len(stats))
+	for _, s := range stats {
+		ms := api.MountIOStats{
+			ID:      s.ID,
+			Path:    s.Path,
+			FSType:  s.FSType,
+			IOStats: api.IOStats(s.IOStats),
+		}
+		if s.Cache != nil {
+			cache := api.CacheStats(*s.Cache)
+			ms.Cache = &cache
+		}
+		out = append(out, ms)
+	}
+	return out, nil
+}
+
+// containerIOStats returns the I/O statistics of all reads and writes done by
+// the tasks of container cid.
+func (l *Loader) containerIOStats(cid string) (api.IOStats, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.tryThreadGroupFromIDLocked(execID{cid: cid}); err != nil {
+		return api.IOStats{}, err
+	}
+	return api.IOStats(l.k.VFS().ContainerIOStats(cid)), nil
+}
+
 func (l *Loader) networkStats() ([]*NetworkInterface, error) {
 	var stats []*NetworkInterface
 	stack := l.k.RootNetworkNamespace().Stack()
@@ -2066,3 +2465,9 @@ func (l *Loader) GetContainerSpecs() map[string]*specs.Spec {
 	defer l.mu.Unlock()
 	return l.containerSpecs
 }
+
+// Kernel returns the sandbox's kernel. The kernel is replaced when the sandbox
+// is restored, so callers should not retain it across a restore.
+func (l *Loader) Kernel() *kernel.Kernel {
+	return l.k
+}
@@ -144,7 +144,12 @@ func createLoader(conf *config.Config, spec *specs.Spec) (*Loader, func(), error
 		StdioFDs:        stdio,
 		GoferMountConfs: []GoferMountConf{{Lower: Lisafs, Upper: NoOverlay}},
 		PodInitConfigFD: -1,
+		ControlPolicyFD: -1,
+		ControlTokenFD:  -1,
 		ExecFD:          -1,
+		PanicReportFD:   -1,
+		HWRNGFD:         -1,
+		VDSOFD:          -1,
 	}
 	l, err := New(args)
 	if err != nil {
@@ -279,6 +284,8 @@ func TestHostnetWithRawSockets(t *testing.T) {
 		Conf:            conf,
 		DevGoferFD:      -1,
 		PodInitConfigFD: -1,
+		ControlPolicyFD: -1,
+		ControlTokenFD:  -1,
 		ExecFD:          -1,
 	})
 	if err == nil {
@@ -191,6 +191,16 @@ func (m *MountHint) IsSandboxLocal() bool {
 	return m.Share == container || m.Share == pod
 }
 
+// ShouldOverlay returns true if this mount may be overlaid with a
+// sandbox-internal upper layer. This is only possible if every user of the
+// mount observes the same upper layer: either the mount is used by a single
+// container, or all containers use the same shared mount. Otherwise, e.g. for
+// bind mounts with share=pod, each container has its own gofer mount of the
+// volume, and writes must reach the volume to be visible to other containers.
+func (m *MountHint) ShouldOverlay() bool {
+	return m.Share == container || m.ShouldShareMount()
+}
+
 // checkCompatible verifies that shared mount is compatible with master.
 // Master options must be the same or less restrictive than the container mount,
 // e.g. master can be 'rw' while container mounts as 'ro'.
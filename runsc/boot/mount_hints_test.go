@@ -15,6 +15,7 @@
 package boot
 
 import (
+	"fmt"
 	"slices"
 	"strings"
 	"testing"
@@ -250,6 +251,67 @@ func TestHintsCheckCompatible(t *testing.T) {
 	}
 }
 
+func TestHintsOverlayAndAccessType(t *testing.T) {
+	for _, tc := range []struct {
+		mntType    string
+		share      ShareType
+		overlay    bool
+		shareMount bool
+		fileAccess config.FileAccessType
+	}{
+		{
+			mntType:    "tmpfs",
+			share:      container,
+			overlay:    true,
+			shareMount: true,
+			fileAccess: config.FileAccessExclusive,
+		},
+		{
+			mntType:    "tmpfs",
+			share:      pod,
+			overlay:    true,
+			shareMount: true,
+			fileAccess: config.FileAccessExclusive,
+		},
+		{
+			mntType:    "tmpfs",
+			share:      shared,
+			fileAccess: config.FileAccessShared,
+		},
+		{
+			mntType:    "bind",
+			share:      container,
+			overlay:    true,
+			fileAccess: config.FileAccessExclusive,
+		},
+		{
+			// Each container has its own gofer mount, so the volume must not be
+			// overlaid and file contents must not be cached exclusively.
+			mntType:    "bind",
+			share:      pod,
+			fileAccess: config.FileAccessShared,
+		},
+		{
+			mntType:    "bind",
+			share:      shared,
+			fileAccess: config.FileAccessShared,
+		},
+	} {
+		t.Run(fmt.Sprintf("%s-%s", tc.mntType, tc.share), func(t *testing.T) {
+			hint := MountHint{Mount: specs.Mount{Type: tc.mntType}, Share: tc.share}
+			if got := hint.ShouldOverlay(); got != tc.overlay {
+				t.Errorf("ShouldOverlay() = %t, want: %t", got, tc.overlay)
+			}
+			if got := hint.ShouldShareMount(); got != tc.shareMount {
+				t.Errorf("ShouldShareMount() = %t, want: %t", got, tc.shareMount)
+			}
+			if got := hint.fileAccessType(); got != tc.fileAccess {
+				t.Errorf("fileAccessType() = %v, want: %v", got, tc.fileAccess)
+			}
+		})
+	}
+}
+
 // TestRootfsHintHappy tests that valid rootfs annotations can be parsed correctly.
 func TestRootfsHintHappy(t *testing.T) {
 	const imagePath = "/tmp/rootfs.img"
@@ -15,13 +15,18 @@
 package boot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"golang.org/x/sys/unix"
 	"gvisor.dev/gvisor/pkg/hostos"
@@ -30,10 +35,13 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/socket/netfilter"
 	"gvisor.dev/gvisor/pkg/sentry/socket/plugin"
 	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/dhcp"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
 	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
 	"gvisor.dev/gvisor/pkg/tcpip/link/fdbased"
 	"gvisor.dev/gvisor/pkg/tcpip/link/loopback"
 	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/fifo"
+	"gvisor.dev/gvisor/pkg/tcpip/link/sharedmem"
 	"gvisor.dev/gvisor/pkg/tcpip/link/sniffer"
 	"gvisor.dev/gvisor/pkg/tcpip/link/xdp"
 	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
@@ -77,6 +85,12 @@ type Network struct {
 	// PluginStack is a third-party network stack to use in place of
 	// netstack when non-nil.
 	PluginStack plugin.PluginStack
+
+	// handover retains the links' FDs for Upgrade. It may be nil.
+	handover *handover
+
+	// dhcp owns the DHCP clients of links configured with DHCP.
+	dhcp *dhcpClients
 }
 
 // Route represents a route in the network stack.
@@ -119,6 +133,10 @@ type FDBasedLink struct {
 	// ProcessorsPerChannel controls how many goroutines are used to handle
 	// packets on each channel.
 	ProcessorsPerChannel int
+
+	// DHCP indicates that the link's addresses and routes are acquired with
+	// DHCPv4 and DHCPv6. Addresses then only holds link-local addresses.
+	DHCP bool
 }
 
 // BindOpt indicates whether the sentry or runsc process is responsible for
@@ -151,6 +169,10 @@ type XDPLink struct {
 	// NumChannels controls how many underlying FDs are to be used to
 	// create this endpoint.
 	NumChannels int
+
+	// DHCP indicates that the link's addresses and routes are acquired with
+	// DHCPv4 and DHCPv6. Addresses then only holds link-local addresses.
+	DHCP bool
 }
 
 // LoopbackLink configures a loopback link.
@@ -190,6 +212,29 @@ type CreateLinksAndRoutesArgs struct {
 	DisconnectOk bool
 }
 
+// SharedMemLink configures a link to another sandbox on the same host over a
+// pair of shared memory queues.
+type SharedMemLink struct {
+	Name        string
+	MTU         int
+	Addresses   []IPWithPrefix
+	LinkAddress net.HardwareAddr
+
+	// Server indicates that this end of the link serves the queues, i.e. it
+	// transmits on the peer's RX queue and receives on the peer's TX queue.
+	Server bool
+}
+
+// CreateSharedMemLinkArgs are arguments to CreateSharedMemLink.
+type CreateSharedMemLinkArgs struct {
+	// FilePayload contains the fds of the TX queue, the fds of the RX queue
+	// (see sharedmem.QueueConfig.FDs) and a socket connected to the peer, in
+	// that order.
+	urpc.FilePayload
+
+	Link SharedMemLink
+}
+
 // InitPluginStackArgs are arguments to InitPluginStack.
 type InitPluginStackArgs struct {
 	urpc.FilePayload
@@ -235,6 +280,8 @@ func (n *Network) InitPluginStack(args *InitPluginStackArgs, _ *struct{}) error
 		return fmt.Errorf("plugin stack is not registered")
 	}
 
+	n.handover.fail(errors.New("plugin network stacks cannot be handed over"))
+
 	fdNum := len(args.FilePayload.Files)
 	fds := make([]int, fdNum)
 	for i := 0; i < fdNum; i++ {
@@ -290,6 +337,9 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 	// Collect routes from all links.
 	var routes []tcpip.Route
 
+	// Links whose addresses are acquired with DHCP once routes are set.
+	var dhcpLinks []dhcpLink
+
 	// Loopback normally appear before other interfaces.
 	for _, link := range args.LoopbackLinks {
 		nicID := n.Stack.NextNICID()
@@ -422,6 +472,10 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 				proto, tcpipAddr := ipToAddressAndProto(neigh.IP)
 				n.Stack.AddStaticNeighbor(nicID, proto, tcpipAddr, tcpip.LinkAddress(neigh.HardwareAddr))
 			}
+
+			if link.DHCP {
+				dhcpLinks = append(dhcpLinks, dhcpLink{nicID: nicID, name: link.Name, mac: mac, addrs: link.Addresses})
+			}
 		}
 	} else if len(args.XDPLinks) > 0 {
 		if nlinks := len(args.XDPLinks); nlinks > 1 {
@@ -515,6 +569,10 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 			proto, tcpipAddr := ipToAddressAndProto(neigh.IP)
 			n.Stack.AddStaticNeighbor(nicID, proto, tcpipAddr, tcpip.LinkAddress(neigh.HardwareAddr))
 		}
+
+		if link.DHCP {
+			dhcpLinks = append(dhcpLinks, dhcpLink{nicID: nicID, name: link.Name, mac: mac, addrs: link.Addresses})
+		}
 	}
 
 	if !args.Defaultv4Gateway.Route.Empty() {
@@ -557,6 +615,271 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 		}
 	}
 
+	for _, link := range dhcpLinks {
+		if err := n.startDHCP(link); err != nil {
+			return err
+		}
+	}
+
+	n.handover.setNetwork(args)
+	return nil
+}
+
+// dhcpAcquireTimeout bounds how long sandbox startup waits for a DHCPv4 lease.
+const dhcpAcquireTimeout = 30 * time.Second
+
+// dhcpLink is a NIC whose addresses are acquired with DHCP.
+type dhcpLink struct {
+	nicID tcpip.NICID
+	name  string
+	mac   tcpip.LinkAddress
+	addrs []IPWithPrefix
+}
+
+// dhcpClients owns the goroutines of the sandbox's DHCP clients, so that they
+// are stopped before the network stack is released.
+type dhcpClients struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newDHCPClients() *dhcpClients {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &dhcpClients{ctx: ctx, cancel: cancel}
+}
+
+// run runs f in a new goroutine. ctx is done once stop is called.
+func (d *dhcpClients) run(f func(ctx context.Context)) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		f(d.ctx)
+	}()
+}
+
+// stop stops all DHCP clients, which release their leases, and waits for them
+// to return.
+func (d *dhcpClients) stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// startDHCP acquires a DHCPv4 lease for link and keeps it up to date in the
+// background. If link has an IPv6 link-local address, DHCPv6 is also started
+// in the background; failing to get a DHCPv6 lease is not an error since many
+// networks only use SLAAC.
+func (n *Network) startDHCP(link dhcpLink) error {
+	ctx, cancel := context.WithTimeout(n.dhcp.ctx, dhcpAcquireTimeout)
+	defer cancel()
+	client := dhcp.NewClient(n.Stack, link.nicID, link.mac, dhcp.DefaultRetransmitTimeout)
+	lease, err := client.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire DHCPv4 lease for interface %q: %w", link.name, err)
+	}
+	n.dhcp.run(func(ctx context.Context) {
+		current := lease
+		onLease := func(old, new dhcp.Lease) {
+			current = new
+			if err := n.updateDHCPLease(link.nicID, old, new); err != nil {
+				log.Warningf("Failed to update DHCPv4 lease on interface %q: %v", link.name, err)
+			}
+		}
+		onLease(dhcp.Lease{}, lease)
+		client.Run(ctx, lease, onLease)
+		if current.Address.Address.Len() != 0 {
+			if err := client.Release(current); err != nil {
+				log.Warningf("Failed to release DHCPv4 lease on interface %q: %v", link.name, err)
+			}
+		}
+	})
+
+	for _, addr := range link.addrs {
+		if addr.Address.To4() != nil || !addr.Address.IsLinkLocalUnicast() {
+			continue
+		}
+		n.dhcp.run(func(ctx context.Context) {
+			client := dhcp.NewClientV6(n.Stack, link.nicID, link.mac, dhcp.DefaultRetransmitTimeoutV6)
+			lease, err := client.Acquire(ctx)
+			if err != nil {
+				log.Infof("No DHCPv6 lease for interface %q: %v", link.name, err)
+				return
+			}
+			current := lease
+			onLease := func(old, new dhcp.LeaseV6) {
+				current = new
+				if err := n.updateDHCPv6Lease(link.nicID, old, new); err != nil {
+					log.Warningf("Failed to update DHCPv6 lease on interface %q: %v", link.name, err)
+				}
+			}
+			onLease(dhcp.LeaseV6{}, lease)
+			client.Run(ctx, lease, onLease)
+			if current.Address.Len() != 0 {
+				if err := client.Release(current); err != nil {
+					log.Warningf("Failed to release DHCPv6 lease on interface %q: %v", link.name, err)
+				}
+			}
+		})
+		break
+	}
+	return nil
+}
+
+// updateDHCPLease replaces the address and routes of the old DHCPv4 lease with
+// those of the new one. Either lease may be empty.
+func (n *Network) updateDHCPLease(nicID tcpip.NICID, old, new dhcp.Lease) error {
+	if old.Address == new.Address && slices.Equal(old.Routers, new.Routers) {
+		return nil
+	}
+	if old.Address.Address.Len() != 0 {
+		log.Infof("Removing DHCPv4 address %s from NIC %d", old.Address, nicID)
+		n.Stack.RemoveRoutes(func(r tcpip.Route) bool {
+			return r.NIC == nicID && (r.Destination == old.Address.Subnet() || r.Destination == header.IPv4EmptySubnet)
+		})
+		if err := n.Stack.RemoveAddress(nicID, old.Address.Address); err != nil {
+			return fmt.Errorf("RemoveAddress(%d, %s) failed: %s", nicID, old.Address.Address, err)
+		}
+	}
+	if new.Address.Address.Len() == 0 {
+		return nil
+	}
+	log.Infof("Adding DHCPv4 address %s with routers %v to NIC %d", new.Address, new.Routers, nicID)
+	protocolAddr := tcpip.ProtocolAddress{
+		Protocol:          ipv4.ProtocolNumber,
+		AddressWithPrefix: new.Address,
+	}
+	if err := n.Stack.AddProtocolAddress(nicID, protocolAddr, stack.AddressProperties{}); err != nil {
+		return fmt.Errorf("AddProtocolAddress(%d, %+v, {}) failed: %s", nicID, protocolAddr, err)
+	}
+	n.Stack.AddRoute(tcpip.Route{Destination: new.Address.Subnet(), NIC: nicID})
+	if len(new.Routers) > 0 {
+		n.Stack.AddRoute(tcpip.Route{Destination: header.IPv4EmptySubnet, Gateway: new.Routers[0], NIC: nicID})
+	}
+	return nil
+}
+
+// updateDHCPv6Lease replaces the address of the old DHCPv6 lease with that of
+// the new one. Either lease may be empty. Routes are learned from router
+// advertisements rather than DHCPv6.
+func (n *Network) updateDHCPv6Lease(nicID tcpip.NICID, old, new dhcp.LeaseV6) error {
+	if old.Address == new.Address {
+		return nil
+	}
+	if old.Address.Len() != 0 {
+		log.Infof("Removing DHCPv6 address %s from NIC %d", old.Address, nicID)
+		if err := n.Stack.RemoveAddress(nicID, old.Address); err != nil {
+			return fmt.Errorf("RemoveAddress(%d, %s) failed: %s", nicID, old.Address, err)
+		}
+	}
+	if new.Address.Len() == 0 {
+		return nil
+	}
+	log.Infof("Adding DHCPv6 address %s to NIC %d", new.Address, nicID)
+	protocolAddr := tcpip.ProtocolAddress{
+		Protocol:          ipv6.ProtocolNumber,
+		AddressWithPrefix: new.Address.WithPrefix(),
+	}
+	if err := n.Stack.AddProtocolAddress(nicID, protocolAddr, stack.AddressProperties{}); err != nil {
+		return fmt.Errorf("AddProtocolAddress(%d, %+v, {}) failed: %s", nicID, protocolAddr, err)
+	}
+	return nil
+}
+
+// CreateSharedMemLink creates a NIC backed by shared memory queues connected to
+// another sandbox on the same host, and routes to its subnets.
+func (n *Network) CreateSharedMemLink(args *CreateSharedMemLinkArgs, _ *struct{}) error {
+	if n.Stack == nil {
+		return fmt.Errorf("shared memory links require netstack")
+	}
+	n.handover.fail(errors.New("shared memory links cannot be handed over"))
+	const (
+		queueFDs = 5
+		// eventFDIndex is the index of a queue's event FD among its FDs; see
+		// sharedmem.QueueConfig.FDs.
+		eventFDIndex = 1
+	)
+	if got, want := len(args.FilePayload.Files), 2*queueFDs+1; got != want {
+		return fmt.Errorf("args.FilePayload.Files has %d FDs, want %d", got, want)
+	}
+	// fds are closed on return, except for those set to -1 because they
+	// are owned by the endpoint.
+	fds := make([]int, 0, len(args.FilePayload.Files))
+	defer func() {
+		for _, fd := range fds {
+			if fd >= 0 {
+				_ = unix.Close(fd)
+			}
+		}
+	}()
+	for _, f := range args.FilePayload.Files {
+		fd, err := unix.Dup(int(f.Fd()))
+		if err != nil {
+			return fmt.Errorf("failed to dup FD %v: %v", f.Fd(), err)
+		}
+		fds = append(fds, fd)
+	}
+	tx, err := sharedmem.QueueConfigFromFDs(fds[:queueFDs])
+	if err != nil {
+		return err
+	}
+	rx, err := sharedmem.QueueConfigFromFDs(fds[queueFDs : 2*queueFDs])
+	if err != nil {
+		return err
+	}
+	peerFD := fds[2*queueFDs]
+
+	link := args.Link
+	opts := sharedmem.Options{
+		MTU:         uint32(link.MTU),
+		BufferSize:  sharedmem.DefaultBufferSize,
+		LinkAddress: tcpip.LinkAddress(link.LinkAddress),
+		TX:          tx,
+		RX:          rx,
+		PeerFD:      peerFD,
+		OnClosed: func(err tcpip.Error) {
+			log.Warningf("Shared memory link %q closed: %v", link.Name, err)
+		},
+	}
+	newEndpoint := sharedmem.New
+	if link.Server {
+		newEndpoint = sharedmem.NewServerEndpoint
+	}
+	linkEP, err := newEndpoint(opts)
+	if err != nil {
+		return fmt.Errorf("creating shared memory endpoint: %v", err)
+	}
+
+	nicID := n.Stack.NextNICID()
+	log.Infof("Enabling shared memory interface %q with id %d on addresses %+v (%v)", link.Name, nicID, link.Addresses, opts.LinkAddress)
+	if err := n.createNICWithAddrs(nicID, linkEP, stack.NICOptions{Name: link.Name}, link.Addresses); err != nil {
+		// Removing the NIC closes the endpoint.
+		if n.Stack.RemoveNIC(nicID) != nil {
+			linkEP.Close()
+		}
+		// Wake up the goroutine waiting for the peer to exit, if any.
+		_ = unix.Shutdown(peerFD, unix.SHUT_RDWR)
+		linkEP.Wait()
+		if !link.Server {
+			// Client endpoints close the RX event FD in Wait.
+			fds[queueFDs+eventFDIndex] = -1
+		}
+		return err
+	}
+	// The queues are mapped by now. The endpoint keeps using the peer FD;
+	// client endpoints also keep the event FDs, while server endpoints use
+	// duplicates of them.
+	fds[2*queueFDs] = -1
+	if !link.Server {
+		fds[eventFDIndex] = -1
+		fds[queueFDs+eventFDIndex] = -1
+	}
+	for _, addr := range link.Addresses {
+		subnet := tcpip.AddressWithPrefix{
+			Address:   ipToAddress(addr.Address),
+			PrefixLen: addr.PrefixLen,
+		}.Subnet()
+		n.Stack.AddRoute(tcpip.Route{Destination: subnet, NIC: nicID})
+	}
 	return nil
 }
 
@@ -0,0 +1,122 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/inet"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/socket/hostinet"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netstack"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+)
+
+// handOffToHostinet implements kernel.NetworkHandoff. It replaces the netstack
+// sockets in the file descriptor tables of all tasks with host sockets that
+// take over their connections, so that a checkpoint taken with netstack can be
+// restored with hostinet. Only listening and established TCP sockets can be
+// handed off; restore fails if there are other netstack sockets. Socket
+// options other than the connection's negotiated TCP options are not handed
+// off.
+func handOffToHostinet(ctx context.Context, _ inet.Stack) error {
+	k := kernel.KernelFromContext(ctx)
+
+	// Sockets may be shared by several file descriptors, possibly in different
+	// tables, which must all refer to the same host socket.
+	replaced := make(map[*vfs.FileDescription]*vfs.FileDescription)
+	defer func() {
+		for _, file := range replaced {
+			file.DecRef(ctx)
+		}
+	}()
+	seen := make(map[*kernel.FDTable]struct{})
+	for _, t := range k.TaskSet().Root.Tasks() {
+		var fdt *kernel.FDTable
+		t.WithMuLocked(func(t *kernel.Task) {
+			fdt = t.FDTable()
+		})
+		if fdt == nil {
+			continue
+		}
+		if _, ok := seen[fdt]; ok {
+			continue
+		}
+		seen[fdt] = struct{}{}
+
+		type socketFD struct {
+			fd    int32
+			file  *vfs.FileDescription
+			flags kernel.FDFlags
+		}
+		var sockets []socketFD
+		fdt.ForEach(ctx, func(fd int32, file *vfs.FileDescription, flags kernel.FDFlags) bool {
+			if _, ok := netstack.EndpointFromFile(file); ok {
+				file.IncRef()
+				sockets = append(sockets, socketFD{fd, file, flags})
+			}
+			return true
+		})
+		for i, s := range sockets {
+			newFile, err := handOffSocket(ctx, s.file, replaced)
+			if err == nil {
+				var old *vfs.FileDescription
+				if old, err = fdt.NewFDAt(ctx, s.fd, newFile, s.flags); old != nil {
+					old.DecRef(ctx)
+				}
+			}
+			if err != nil {
+				for _, s := range sockets[i:] {
+					s.file.DecRef(ctx)
+				}
+				return fmt.Errorf("handing off socket FD %d of %v: %w", s.fd, t, err)
+			}
+			s.file.DecRef(ctx)
+		}
+	}
+	log.Infof("Handed off %d netstack sockets to the host network stack", len(replaced))
+	return nil
+}
+
+// handOffSocket returns the host socket that replaces the netstack socket
+// file, creating it if it's not in replaced yet.
+func handOffSocket(ctx context.Context, file *vfs.FileDescription, replaced map[*vfs.FileDescription]*vfs.FileDescription) (*vfs.FileDescription, error) {
+	if newFile, ok := replaced[file]; ok {
+		return newFile, nil
+	}
+	ep, _ := netstack.EndpointFromFile(file)
+	tcpEP, ok := ep.(*tcp.Endpoint)
+	if !ok {
+		return nil, fmt.Errorf("only TCP sockets can be handed off")
+	}
+	h, tcpipErr := tcpEP.Handoff()
+	if tcpipErr != nil {
+		return nil, fmt.Errorf("only listening and established TCP sockets can be handed off: %s", tcpipErr)
+	}
+	newFile, serr := hostinet.NewTCPSocketFromHandoff(ctx, &h, file.StatusFlags()&linux.O_NONBLOCK)
+	if serr != nil {
+		return nil, serr.ToError()
+	}
+	if err := file.CopyEpollInterests(newFile); err != nil {
+		newFile.DecRef(ctx)
+		return nil, fmt.Errorf("copying epoll registrations: %w", err)
+	}
+	replaced[file] = newFile
+	return newFile, nil
+}
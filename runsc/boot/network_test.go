@@ -0,0 +1,156 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/link/sharedmem"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+func newSharedMemLinkTestNetwork(t *testing.T) *Network {
+	s := stack.New(stack.Options{
+		NetworkProtocols: []stack.NetworkProtocolFactory{ipv4.NewProtocol},
+	})
+	t.Cleanup(s.Destroy)
+	return &Network{Stack: s}
+}
+
+// newSharedMemLinkArgs returns the arguments to create one end of a shared
+// memory link.
+func newSharedMemLinkArgs(t *testing.T, name, addr string, server bool) *CreateSharedMemLinkArgs {
+	qp, err := sharedmem.NewQueuePair(sharedmem.QueueOptions{SharedMemPath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("sharedmem.NewQueuePair(): %v", err)
+	}
+	txCfg, rxCfg := qp.TXQueueConfig(), qp.RXQueueConfig()
+	var fds []int
+	fds = append(fds, txCfg.FDs()...)
+	fds = append(fds, rxCfg.FDs()...)
+	peerFDs, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET, 0)
+	if err != nil {
+		t.Fatalf("unix.Socketpair(): %v", err)
+	}
+	fds = append(fds, peerFDs[0])
+
+	ip, ipNet, err := net.ParseCIDR(addr)
+	if err != nil {
+		t.Fatalf("net.ParseCIDR(%q): %v", addr, err)
+	}
+	prefixLen, _ := ipNet.Mask.Size()
+	args := &CreateSharedMemLinkArgs{
+		Link: SharedMemLink{
+			Name:        name,
+			MTU:         1500,
+			Addresses:   []IPWithPrefix{{Address: ip, PrefixLen: prefixLen}},
+			LinkAddress: net.HardwareAddr{0x02, 0, 0, 0, 0, 1},
+			Server:      server,
+		},
+	}
+	for _, fd := range fds {
+		args.FilePayload.Files = append(args.FilePayload.Files, os.NewFile(uintptr(fd), "shm-link"))
+	}
+	t.Cleanup(func() {
+		for _, f := range args.FilePayload.Files {
+			f.Close()
+		}
+		unix.Close(peerFDs[1])
+	})
+	return args
+}
+
+// openFDs returns the number of FDs open in the test process.
+func openFDs(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatalf("error reading /proc/self/fd: %v", err)
+	}
+	return len(entries)
+}
+
+func TestCreateSharedMemLink(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		server bool
+	}{
+		{name: "client"},
+		{name: "server", server: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n := newSharedMemLinkTestNetwork(t)
+			args := newSharedMemLinkArgs(t, "shm0", "10.10.0.1/24", tc.server)
+			if err := n.CreateSharedMemLink(args, nil); err != nil {
+				t.Fatalf("CreateSharedMemLink(): %v", err)
+			}
+
+			var nicID tcpip.NICID
+			for id, info := range n.Stack.NICInfo() {
+				if info.Name == "shm0" {
+					nicID = id
+				}
+			}
+			if nicID == 0 {
+				t.Fatalf("no NIC named %q in %+v", "shm0", n.Stack.NICInfo())
+			}
+			want := tcpip.AddressWithPrefix{
+				Address:   tcpip.AddrFrom4([4]byte{10, 10, 0, 1}),
+				PrefixLen: 24,
+			}.Subnet()
+			found := false
+			for _, r := range n.Stack.GetRouteTable() {
+				if r.NIC == nicID && r.Destination == want {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("no route to %v through NIC %d in %+v", want, nicID, n.Stack.GetRouteTable())
+			}
+		})
+	}
+}
+
+func TestCreateSharedMemLinkErrors(t *testing.T) {
+	n := newSharedMemLinkTestNetwork(t)
+	args := newSharedMemLinkArgs(t, "shm0", "10.10.0.1/24", false /* server */)
+
+	bad := &CreateSharedMemLinkArgs{Link: args.Link}
+	bad.FilePayload.Files = args.FilePayload.Files[1:]
+	if err := n.CreateSharedMemLink(bad, nil); err == nil {
+		t.Errorf("CreateSharedMemLink() with %d FDs succeeded, want error", len(bad.FilePayload.Files))
+	}
+
+	if err := n.CreateSharedMemLink(args, nil); err != nil {
+		t.Fatalf("CreateSharedMemLink(): %v", err)
+	}
+	// Creating another link with the same name fails once the endpoint has
+	// been created, which must not leak the FDs it was given.
+	for _, server := range []bool{false, true} {
+		dup := newSharedMemLinkArgs(t, "shm0", "10.10.1.1/24", server)
+		before := openFDs(t)
+		if err := n.CreateSharedMemLink(dup, nil); err == nil {
+			t.Errorf("CreateSharedMemLink() with a duplicate name succeeded, want error")
+		}
+		if after := openFDs(t); after != before {
+			t.Errorf("CreateSharedMemLink() failure (server: %t) left %d FDs open, want %d", server, after, before)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/seccheck/sinks/ring"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/runsc/version"
+)
+
+// panicReportWriteTimeout bounds the time spent writing a panic report, in
+// case the report is written to a socket whose reader is stuck.
+const panicReportWriteTimeout = 5 * time.Second
+
+// panicReport is the JSON report written to the --panic-report destination
+// when a sentry goroutine panics.
+type panicReport struct {
+	*kernel.PanicReport
+
+	// Version is the runsc version.
+	Version string `json:"version"`
+
+	// Platform describes the platform.
+	Platform panicReportPlatform `json:"platform"`
+
+	// RecentEvents are the most recent trace points recorded by the "ring"
+	// seccheck sink, if it is configured.
+	RecentEvents []ring.Event `json:"recent_events,omitempty"`
+}
+
+// panicReportPlatform describes the platform in a panicReport.
+type panicReportPlatform struct {
+	Name                            string `json:"name"`
+	SupportsAddressSpaceIO          bool   `json:"supports_address_space_io"`
+	CooperativelySharesAddressSpace bool   `json:"cooperatively_shares_address_space"`
+}
+
+// newPanicReporter returns a function, suitable for kernel.Kernel.OnPanic,
+// that writes a panic report to f. Only the first panic is reported.
+func newPanicReporter(f *os.File, platformName string) func(*kernel.Kernel, *kernel.Task, any) {
+	var once sync.Once
+	return func(k *kernel.Kernel, t *kernel.Task, reason any) {
+		once.Do(func() {
+			writePanicReport(f, newPanicReport(k, t, reason, platformName))
+		})
+	}
+}
+
+// newPanicReport returns the panicReport for a panic in k with the given
+// reason. t is the panicking task, or nil.
+func newPanicReport(k *kernel.Kernel, t *kernel.Task, reason any, platformName string) *panicReport {
+	p := k.Platform
+	return &panicReport{
+		PanicReport: k.NewPanicReport(t, reason),
+		Version:     version.Version(),
+		Platform: panicReportPlatform{
+			Name:                            platformName,
+			SupportsAddressSpaceIO:          p.SupportsAddressSpaceIO(),
+			CooperativelySharesAddressSpace: p.CooperativelySharesAddressSpace(),
+		},
+		RecentEvents: ring.Recent(),
+	}
+}
+
+// writePanicReport writes r to f as a single line of JSON.
+func writePanicReport(f *os.File, r *panicReport) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		log.Warningf("Failed to marshal panic report: %v", err)
+		return
+	}
+	b = append(b, '\n')
+	// Deadlines are not supported on regular files; ignore the error.
+	_ = f.SetWriteDeadline(time.Now().Add(panicReportWriteTimeout))
+	if _, err := f.Write(b); err != nil {
+		log.Warningf("Failed to write panic report: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gvisor.dev/gvisor/runsc/version"
+)
+
+// TestPanicReporter tests that the reporter writes a single JSON report, for
+// the first panic only.
+func TestPanicReporter(t *testing.T) {
+	conf := testConfig()
+	l, cleanup, err := createLoader(conf, testSpec())
+	if err != nil {
+		t.Fatalf("error creating loader: %v", err)
+	}
+	defer l.Destroy()
+	defer cleanup()
+
+	path := filepath.Join(t.TempDir(), "report")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("error creating report file: %v", err)
+	}
+	defer f.Close()
+
+	report := newPanicReporter(f, conf.Platform)
+	report(l.k, nil, "first")
+	report(l.k, nil, "second")
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading report: %v", err)
+	}
+	if n := bytes.Count(b, []byte("\n")); n != 1 {
+		t.Fatalf("got %d report lines, want 1: %s", n, b)
+	}
+	var got panicReport
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("error unmarshaling report %s: %v", b, err)
+	}
+	if got.PanicReport == nil || got.Reason != "first" {
+		t.Errorf("got report %s, want reason %q", b, "first")
+	}
+	if got.PanicReport != nil && got.Task != nil {
+		t.Errorf("got task %+v for a panic outside of a task goroutine", got.Task)
+	}
+	if got.Version != version.Version() {
+		t.Errorf("got version %q, want %q", got.Version, version.Version())
+	}
+	if got.Platform.Name != conf.Platform {
+		t.Errorf("got platform %q, want %q", got.Platform.Name, conf.Platform)
+	}
+}
@@ -71,9 +71,14 @@ func NewProxy(pair ProxyPair, cid string) *Proxy {
 	}
 }
 
+// bufSize is the size of the buffers used to copy between connections. It is
+// large enough to hold any UDP datagram, since datagram connections transfer
+// exactly one message per Read and Write.
+const bufSize = 65536
+
 // readFrom reads from the application's vfs.FileDescription and writes to the shim.
 func (pf *Proxy) readFrom(ctx context.Context) error {
-	buf := make([]byte, 16384 /* 16kb buffer size */)
+	buf := make([]byte, bufSize)
 	for ctx.Err() == nil {
 		if err := doCopy(ctx, pf.to, pf.from, buf, pf.cancelFrom); err != nil {
 			return fmt.Errorf("readFrom failed on container %q: %v", pf.cid, err)
@@ -84,7 +89,7 @@ func (pf *Proxy) readFrom(ctx context.Context) error {
 
 // writeTo writes to the application's vfs.FileDescription and reads from the shim.
 func (pf *Proxy) readTo(ctx context.Context) error {
-	buf := make([]byte, 16384 /* 16kb buffer size */)
+	buf := make([]byte, bufSize)
 	for ctx.Err() == nil {
 		if err := doCopy(ctx, pf.from, pf.to, buf, pf.cancelTo); err != nil {
 			return fmt.Errorf("readTo failed on container %q: %v", pf.cid, err)
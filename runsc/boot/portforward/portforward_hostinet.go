@@ -41,20 +41,34 @@ type hostInetConn struct {
 	fd *fileDescriptor.FD
 	// port is the port on which to connect.
 	port uint16
+	// stype is the socket type of fd.
+	stype int
 	// once makes sure we close only once.
 	once sync.Once
 }
 
 // NewHostInetConn creates a hostInetConn backed by a host socket on the localhost address.
 func NewHostInetConn(port uint16) (proxyConn, error) {
+	return newHostInetConn(unix.SOCK_STREAM, unix.IPPROTO_TCP, port)
+}
+
+// NewHostInetUDPConn creates a hostInetConn backed by a connected host UDP
+// socket on the localhost address. Each Read and Write transfers a single
+// datagram.
+func NewHostInetUDPConn(port uint16) (proxyConn, error) {
+	return newHostInetConn(unix.SOCK_DGRAM, unix.IPPROTO_UDP, port)
+}
+
+func newHostInetConn(stype, protocol int, port uint16) (proxyConn, error) {
 	// NOTE: Options must match sandbox seccomp filters. See filter/config.go
-	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_STREAM|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, unix.IPPROTO_TCP)
+	fd, err := unix.Socket(unix.AF_INET, stype|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, protocol)
 	if err != nil {
 		return nil, err
 	}
 	s := hostInetConn{
-		fd:   fileDescriptor.New(fd),
-		port: port,
+		fd:    fileDescriptor.New(fd),
+		port:  port,
+		stype: stype,
 	}
 
 	cu := cleanup.Make(func() {
@@ -101,6 +115,9 @@ func NewHostInetConn(port uint16) (proxyConn, error) {
 }
 
 func (s *hostInetConn) Name() string {
+	if s.stype == unix.SOCK_DGRAM {
+		return fmt.Sprintf("localhost:udp:port:%d", s.port)
+	}
 	return fmt.Sprintf("localhost:port:%d", s.port)
 }
 
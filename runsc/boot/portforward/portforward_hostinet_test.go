@@ -113,6 +113,66 @@ func TestLocalHostSocket(t *testing.T) {
 	}
 }
 
+func TestLocalHostUDPSocket(t *testing.T) {
+	ctx := contexttest.Context(t)
+	clientData := []byte("what is the answer?")
+	serverData := []byte("42")
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket failed: %v", err)
+	}
+	defer pc.Close()
+
+	port := pc.LocalAddr().(*net.UDPAddr).Port
+	var g errgroup.Group
+
+	g.Go(func() error {
+		data := make([]byte, 1024)
+		recLen, addr, err := pc.ReadFrom(data)
+		if err != nil {
+			return fmt.Errorf("could not read datagram: %v", err)
+		}
+		if !slices.Equal(data[:recLen], clientData) {
+			return fmt.Errorf("server mismatch data received: got: %s want: %s", data[:recLen], clientData)
+		}
+		if _, err := pc.WriteTo(serverData, addr); err != nil {
+			return fmt.Errorf("could not write datagram: %v", err)
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		sock, err := NewHostInetUDPConn(uint16(port))
+		if err != nil {
+			return fmt.Errorf("could not create local host UDP socket: %v", err)
+		}
+		defer sock.Close(ctx)
+		n, err := sock.Write(ctx, clientData, nil)
+		if err != nil {
+			return fmt.Errorf("could not write to local host UDP socket: %v", err)
+		}
+		if n != len(clientData) {
+			return fmt.Errorf("short datagram write: got: %d want: %d", n, len(clientData))
+		}
+
+		// A single read must return exactly one datagram.
+		data := make([]byte, 1024)
+		n, err = sock.Read(ctx, data, nil)
+		if err != nil {
+			return fmt.Errorf("could not read from local host UDP socket: %v", err)
+		}
+		if !slices.Equal(data[:n], serverData) {
+			return fmt.Errorf("client mismatch data received: got: %s want: %s", data[:n], serverData)
+		}
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 type netConnMockEndpoint struct {
 	conn net.Conn
 	mu   sync.Mutex
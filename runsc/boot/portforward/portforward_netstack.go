@@ -25,6 +25,7 @@ import (
 	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
 	"gvisor.dev/gvisor/pkg/tcpip/stack"
 	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
 	"gvisor.dev/gvisor/pkg/waiter"
 )
 
@@ -33,6 +34,8 @@ import (
 type netstackConn struct {
 	// ep is the tcpip.Endpoint on which to read and write.
 	ep tcpip.Endpoint
+	// proto is the transport protocol of ep.
+	proto tcpip.TransportProtocolNumber
 	// port is the port on which to connect.
 	port uint16
 	// wq is the WaitQueue for this connection to wait on notifications.
@@ -44,15 +47,26 @@ type netstackConn struct {
 // NewNetstackConn creates a new port forwarding connection to the given
 // port in netstack mode.
 func NewNetstackConn(stack *stack.Stack, port uint16) (proxyConn, error) {
+	return newNetstackConn(stack, tcp.ProtocolNumber, port)
+}
+
+// NewNetstackUDPConn creates a new port forwarding connection to the given
+// UDP port in netstack mode. Each Read and Write transfers a single datagram.
+func NewNetstackUDPConn(stack *stack.Stack, port uint16) (proxyConn, error) {
+	return newNetstackConn(stack, udp.ProtocolNumber, port)
+}
+
+func newNetstackConn(stack *stack.Stack, proto tcpip.TransportProtocolNumber, port uint16) (proxyConn, error) {
 	var wq waiter.Queue
-	ep, tcpErr := stack.NewEndpoint(tcp.ProtocolNumber, ipv4.ProtocolNumber, &wq)
+	ep, tcpErr := stack.NewEndpoint(proto, ipv4.ProtocolNumber, &wq)
 	if tcpErr != nil {
 		return nil, fmt.Errorf("creating endpoint: %v", tcpErr)
 	}
 	n := &netstackConn{
-		ep:   ep,
-		port: port,
-		wq:   &wq,
+		ep:    ep,
+		proto: proto,
+		port:  port,
+		wq:    &wq,
 	}
 	waitEntry, notifyCh := waiter.NewChannelEntry(waiter.WritableEvents)
 	n.wq.EventRegister(&waitEntry)
@@ -67,6 +81,7 @@ func NewNetstackConn(stack *stack.Stack, port uint16) (proxyConn, error) {
 		tcpErr = n.ep.LastError()
 	}
 	if tcpErr != nil {
+		ep.Close()
 		return nil, fmt.Errorf("connecting endpoint: %v", tcpErr)
 	}
 	return n, nil
@@ -74,6 +89,9 @@ func NewNetstackConn(stack *stack.Stack, port uint16) (proxyConn, error) {
 
 // Name implements proxyConn.Name.
 func (n *netstackConn) Name() string {
+	if n.proto == udp.ProtocolNumber {
+		return fmt.Sprintf("netstack:udp:port:%d", n.port)
+	}
 	return fmt.Sprintf("netstack:port:%d", n.port)
 }
 
@@ -0,0 +1,95 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package portforward
+
+import (
+	"fmt"
+	"path"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/fspath"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/sockfs"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/socket/unix"
+	"gvisor.dev/gvisor/pkg/sentry/socket/unix/transport"
+	"gvisor.dev/gvisor/pkg/sentry/uniqueid"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+)
+
+// UnixSocketOpts holds the arguments to NewUnixSocketConn.
+type UnixSocketOpts struct {
+	// VFS is the virtual filesystem used to resolve Path.
+	VFS *vfs.VirtualFilesystem
+	// Creds are the credentials used to resolve and connect to Path.
+	Creds *auth.Credentials
+	// Root is the root directory of the container's mount namespace.
+	Root vfs.VirtualDentry
+	// Path is the absolute path of the socket inside the container.
+	Path string
+	// SocketMount is the mount on which to create the client socket file.
+	SocketMount *vfs.Mount
+	// UniqueID provides the unique ID of the client endpoint.
+	UniqueID uniqueid.Provider
+}
+
+// unixSocketConn is a connection to a unix domain socket bound inside the
+// sandbox. unixSocketConn implements proxyConn.
+type unixSocketConn struct {
+	fileDescriptionConn
+	path string
+}
+
+// NewUnixSocketConn connects to the SOCK_STREAM unix domain socket bound at
+// opts.Path and returns a port forwarding connection for it.
+func NewUnixSocketConn(ctx context.Context, opts *UnixSocketOpts) (proxyConn, error) {
+	if !path.IsAbs(opts.Path) {
+		return nil, fmt.Errorf("unix socket path %q must be absolute", opts.Path)
+	}
+	pop := vfs.PathOperation{
+		Root:               opts.Root,
+		Start:              opts.Root,
+		Path:               fspath.Parse(opts.Path),
+		FollowFinalSymlink: true,
+	}
+	bep, err := opts.VFS.BoundEndpointAt(ctx, opts.Creds, &pop, &vfs.BoundEndpointOptions{Addr: opts.Path})
+	if err != nil {
+		return nil, fmt.Errorf("resolving %q: %w", opts.Path, err)
+	}
+	defer bep.Release(ctx)
+
+	ep := transport.NewConnectioned(ctx, linux.SOCK_STREAM, opts.UniqueID)
+	if err := ep.Connect(ctx, bep, transport.UnixSocketOpts{DisconnectOnSave: true}); err != nil {
+		ep.Close(ctx)
+		return nil, fmt.Errorf("connecting to %q: %w", opts.Path, err.ToError())
+	}
+
+	d := sockfs.NewDentry(ctx, opts.SocketMount)
+	defer d.DecRef(ctx)
+	fd, err := unix.NewFileDescription(ep, linux.SOCK_STREAM, linux.O_RDWR, nil /* ns */, opts.SocketMount, d, &vfs.FileLocks{})
+	if err != nil {
+		ep.Close(ctx)
+		return nil, err
+	}
+	return &unixSocketConn{
+		fileDescriptionConn: fileDescriptionConn{file: fd},
+		path:                opts.Path,
+	}, nil
+}
+
+// Name implements proxyConn.Name.
+func (u *unixSocketConn) Name() string {
+	return fmt.Sprintf("unix:%s", u.path)
+}
@@ -45,7 +45,6 @@ import (
 	"gvisor.dev/gvisor/runsc/boot/pprof"
 	"gvisor.dev/gvisor/runsc/config"
 	"gvisor.dev/gvisor/runsc/specutils"
-	"gvisor.dev/gvisor/runsc/version"
 )
 
 const (
@@ -115,6 +114,11 @@ type restorer struct {
 	// checkpointedSpecs contains the map of container specs used during
 	// checkpoint.
 	checkpointedSpecs map[string]*specs.Spec
+
+	// mountsDigest is the digest of the mounts of checkpointed containers,
+	// saved in the checkpoint metadata. It is empty if the checkpoint doesn't
+	// have one.
+	mountsDigest string
 }
 
 // restoreSubcontainer restores a subcontainer.
@@ -168,6 +172,9 @@ func (r *restorer) restoreContainerInfo(l *Loader, info *containerInfo, containe
 	// Non-container-specific restore work:
 
 	if len(r.containers) == r.totalContainers {
+		if err := checkRestoreMounts(r.mountsDigest, r.checkpointedSpecs, l.GetContainerSpecs(), l.root.conf.RestoreSpecValidation); err != nil {
+			return err
+		}
 		if err := specutils.RestoreValidateSpec(r.checkpointedSpecs, l.GetContainerSpecs(), l.root.conf); err != nil {
 			return fmt.Errorf("failed to handle restore spec validation: %w", err)
 		}
@@ -210,12 +217,17 @@ func (r *restorer) restore(l *Loader) error {
 
 	// Release the kernel and replace it with a new one that will be restored into.
 	var oldNvidiaDriverVersion nvconf.DriverVersion
+	var oldOnPanic func(*kernel.Kernel, *kernel.Task, any)
 	if l.k != nil {
 		oldNvidiaDriverVersion = l.k.NvidiaDriverVersion
+		oldOnPanic = l.k.OnPanic
 		l.k.Release()
 	}
 	l.k = &kernel.Kernel{
-		Platform: p,
+		Platform:             p,
+		OnPanic:              oldOnPanic,
+		FlightRecorder:       l.root.conf.FlightRecorder,
+		HostCoreSchedCookies: l.root.conf.EnableCoreTags,
 	}
 	l.k.SetMemoryFile(r.mainMF)
 
@@ -274,9 +286,17 @@ func (r *restorer) restore(l *Loader) error {
 		r.asyncMFLoader.KickoffPrivate(mfmap)
 	}
 
+	// A checkpoint with a saved netstack that is restored with hostinet hands
+	// its TCP connections off to the host.
+	saveRestoreNet := l.saveRestoreNet
+	if l.root.conf.NetworkHandoff {
+		saveRestoreNet = true
+		ctx = context.WithValue(ctx, kernel.CtxNetworkHandoff, kernel.NetworkHandoff(handOffToHostinet))
+	}
+
 	// Load the state.
 	r.timer.Reached("loading kernel")
-	if err := l.k.LoadFrom(ctx, r.stateFile, r.asyncMFLoader == nil, nil, oldInetStack, time.NewCalibratedClocks(), &vfs.CompleteRestoreOptions{}, l.saveRestoreNet); err != nil {
+	if err := l.k.LoadFrom(ctx, r.stateFile, r.asyncMFLoader == nil, nil, oldInetStack, time.NewCalibratedClocks(), &vfs.CompleteRestoreOptions{}, saveRestoreNet); err != nil {
 		return fmt.Errorf("failed to load kernel: %w", err)
 	}
 	r.timer.Reached("kernel loaded")
@@ -307,6 +327,7 @@ func (r *restorer) restore(l *Loader) error {
 	// Change the loader fields to reflect the changes made when restoring.
 	l.watchdog.Stop()
 	l.watchdog = dog
+	control.SetLoggingWatchdog(l.ctrl.logging, dog)
 	l.root.procArgs = kernel.CreateProcessArgs{}
 	l.sandboxID = l.root.cid
 
@@ -424,11 +445,12 @@ func (l *Loader) save(o *control.SaveOpts) (err error) {
 	}
 	o.Metadata[ContainerCountKey] = strconv.Itoa(l.containerCount())
 
-	// Save runsc version.
-	o.Metadata[VersionKey] = version.Version()
+	// Save runsc version, features and mounts to check them on restore.
+	containerSpecs := l.GetContainerSpecs()
+	saveCompatMetadata(o.Metadata, l.root.conf, containerSpecs)
 
 	// Save container specs.
-	specsStr, err := specutils.ConvertSpecsToString(l.GetContainerSpecs())
+	specsStr, err := specutils.ConvertSpecsToString(containerSpecs)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,324 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/state"
+	"gvisor.dev/gvisor/runsc/config"
+	"gvisor.dev/gvisor/runsc/version"
+)
+
+const (
+	// FeaturesKey is the key used to save the sandbox features that affect
+	// the checkpoint format in the save metadata. The value is a comma
+	// separated list of name=value pairs.
+	FeaturesKey = "sentry_features"
+
+	// MountsDigestKey is the key used to save a digest of the mounts of all
+	// containers in the save metadata.
+	MountsDigestKey = "mounts_digest"
+)
+
+// ErrIncompatibleCheckpoint is returned when a checkpoint can't be restored by
+// this sandbox.
+var ErrIncompatibleCheckpoint = errors.New("incompatible checkpoint")
+
+// checkpointFeature is a sandbox configuration that must match between
+// checkpoint and restore.
+type checkpointFeature struct {
+	name  string
+	value func(*config.Config) string
+
+	// overridable is true if restoring with a different value is known to
+	// work in some cases, and can be allowed with --allow-incompatible.
+	overridable bool
+}
+
+// checkpointFeatures is the list of features saved in FeaturesKey. Features
+// can be added, but must not be renamed, as this would make older checkpoints
+// unrestorable.
+var checkpointFeatures = []checkpointFeature{
+	{
+		// Platform state is not saved, but the platform affects the layout
+		// of the address space.
+		name:        "platform",
+		value:       func(c *config.Config) string { return c.Platform },
+		overridable: true,
+	},
+	{
+		name:  "network",
+		value: func(c *config.Config) string { return c.Network.String() },
+	},
+	{
+		name:  "overlay2",
+		value: func(c *config.Config) string { return c.GetOverlay2().String() },
+	},
+	{
+		name:  "directfs",
+		value: func(c *config.Config) string { return strconv.FormatBool(c.DirectFS) },
+	},
+	{
+		name:  "nvproxy",
+		value: func(c *config.Config) string { return strconv.FormatBool(c.NVProxy) },
+	},
+	{
+		name:  "tpuproxy",
+		value: func(c *config.Config) string { return strconv.FormatBool(c.TPUProxy) },
+	},
+}
+
+// featuresString returns the value of FeaturesKey for conf.
+func featuresString(conf *config.Config) string {
+	pairs := make([]string, 0, len(checkpointFeatures))
+	for _, f := range checkpointFeatures {
+		pairs = append(pairs, f.name+"="+f.value(conf))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// parseFeatures parses the value of FeaturesKey.
+func parseFeatures(s string) (map[string]string, error) {
+	features := make(map[string]string)
+	if s == "" {
+		return features, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid feature %q in checkpoint metadata", pair)
+		}
+		features[name] = value
+	}
+	return features, nil
+}
+
+// mountsDigest returns a digest of the mounts of all containers in specs.
+// Mount sources are excluded since they can change across checkpoint/restore.
+func mountsDigest(containerSpecs map[string]*specs.Spec) string {
+	names := make([]string, 0, len(containerSpecs))
+	for name := range containerSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "container %q\n", name)
+		for _, m := range containerSpecs[name].Mounts {
+			fmt.Fprintf(h, "%s\n", mountString(m))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func mountString(m specs.Mount) string {
+	return fmt.Sprintf("%q type=%q options=%q", m.Destination, m.Type, m.Options)
+}
+
+// saveCompatMetadata adds the metadata used by checkRestoreCompat and
+// checkRestoreMounts to metadata.
+func saveCompatMetadata(metadata map[string]string, conf *config.Config, containerSpecs map[string]*specs.Spec) {
+	metadata[VersionKey] = version.Version()
+	metadata[FeaturesKey] = featuresString(conf)
+	metadata[MountsDigestKey] = mountsDigest(containerSpecs)
+}
+
+// incompatibility is a difference between the sandbox that was checkpointed
+// and the sandbox restoring it.
+type incompatibility struct {
+	what        string
+	checkpoint  string
+	current     string
+	overridable bool
+}
+
+func (i *incompatibility) String() string {
+	return fmt.Sprintf("%s differs, checkpoint: %s, current: %s", i.what, i.checkpoint, i.current)
+}
+
+// incompatibilitiesError returns an error describing incompat. Overridable
+// incompatibilities are logged and ignored if allowIncompatible is set.
+func incompatibilitiesError(incompat []incompatibility, allowIncompatible bool) error {
+	var msgs []string
+	for i := range incompat {
+		if allowIncompatible && incompat[i].overridable {
+			log.Warningf("Restoring incompatible checkpoint: %s", &incompat[i])
+			continue
+		}
+		msg := incompat[i].String()
+		if incompat[i].overridable {
+			msg += " (can be ignored with --allow-incompatible)"
+		}
+		msgs = append(msgs, msg)
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrIncompatibleCheckpoint, strings.Join(msgs, "; "))
+}
+
+// checkRestoreCompat checks that the checkpoint described by metadata can be
+// restored by a sandbox configured with conf. Metadata that is missing, e.g.
+// because the checkpoint was taken by an older version, is not checked.
+func checkRestoreCompat(metadata map[string]string, conf *config.Config, allowIncompatible bool) error {
+	var incompat []incompatibility
+	if v := metadata[VersionKey]; v != version.Version() {
+		// Checkpoints taken by other versions can be restored if the state
+		// format is compatible, see state.Version.
+		if sv, err := strconv.Atoi(metadata[state.VersionKey]); err == nil && sv >= state.MinVersion && sv <= state.Version {
+			log.Infof("Restoring checkpoint taken by runsc version %q with state version %d", v, sv)
+		} else {
+			checkpoint := strconv.Quote(v)
+			if err == nil {
+				checkpoint += fmt.Sprintf(" (state version %d, supported: %d to %d)", sv, state.MinVersion, state.Version)
+			}
+			incompat = append(incompat, incompatibility{
+				what:        "runsc version",
+				checkpoint:  checkpoint,
+				current:     strconv.Quote(version.Version()),
+				overridable: true,
+			})
+		}
+	}
+	if s, ok := metadata[FeaturesKey]; ok {
+		features, err := parseFeatures(s)
+		if err != nil {
+			return err
+		}
+		for _, f := range checkpointFeatures {
+			old, ok := features[f.name]
+			if !ok {
+				continue
+			}
+			if cur := f.value(conf); old != cur {
+				incompat = append(incompat, incompatibility{
+					what:        fmt.Sprintf("feature %q", f.name),
+					checkpoint:  strconv.Quote(old),
+					current:     strconv.Quote(cur),
+					overridable: f.overridable,
+				})
+			}
+		}
+	}
+	return incompatibilitiesError(incompat, allowIncompatible)
+}
+
+// checkRestoreMounts checks that the mounts of the containers being restored,
+// newSpecs, match digest, the value of MountsDigestKey in the checkpoint.
+// oldSpecs are the container specs saved in the checkpoint, and are used to
+// report which mounts differ. Checkpoints taken by older versions don't have a
+// digest, which is represented by an empty string. Mismatches are handled
+// according to policy, like other differences between the specs.
+func checkRestoreMounts(digest string, oldSpecs, newSpecs map[string]*specs.Spec, policy config.RestoreSpecValidationPolicy) error {
+	if policy == config.RestoreSpecValidationIgnore {
+		return nil
+	}
+	err := diffRestoreMounts(digest, oldSpecs, newSpecs)
+	if err != nil && policy == config.RestoreSpecValidationWarning {
+		log.Warningf("Failed to validate restore mounts (ignoring error as per configuration): %v", err)
+		return nil
+	}
+	return err
+}
+
+// diffRestoreMounts implements checkRestoreMounts, returning an error that
+// reports the differences between oldSpecs and newSpecs if they don't match
+// digest.
+func diffRestoreMounts(digest string, oldSpecs, newSpecs map[string]*specs.Spec) error {
+	if digest == "" || digest == mountsDigest(newSpecs) {
+		return nil
+	}
+	var incompat []incompatibility
+	for name, oldSpec := range oldSpecs {
+		newSpec, ok := newSpecs[name]
+		if !ok {
+			incompat = append(incompat, incompatibility{
+				what:       fmt.Sprintf("container %q", name),
+				checkpoint: "present",
+				current:    "missing",
+			})
+			continue
+		}
+		incompat = append(incompat, diffMounts(name, oldSpec.Mounts, newSpec.Mounts)...)
+	}
+	for name := range newSpecs {
+		if _, ok := oldSpecs[name]; !ok {
+			incompat = append(incompat, incompatibility{
+				what:       fmt.Sprintf("container %q", name),
+				checkpoint: "missing",
+				current:    "present",
+			})
+		}
+	}
+	if len(incompat) == 0 {
+		// The specs in the metadata don't match the digest, most likely
+		// because the mounts were reordered.
+		incompat = append(incompat, incompatibility{
+			what:       "mounts digest",
+			checkpoint: digest,
+			current:    mountsDigest(newSpecs),
+		})
+	}
+	sort.Slice(incompat, func(i, j int) bool { return incompat[i].what < incompat[j].what })
+	return incompatibilitiesError(incompat, false)
+}
+
+// diffMounts returns the mounts of container name that differ between o and n.
+func diffMounts(name string, o, n []specs.Mount) []incompatibility {
+	oldMnts := make(map[string]string)
+	for _, m := range o {
+		oldMnts[m.Destination] = mountString(m)
+	}
+	newMnts := make(map[string]string)
+	for _, m := range n {
+		newMnts[m.Destination] = mountString(m)
+	}
+
+	var incompat []incompatibility
+	for dst, old := range oldMnts {
+		cur, ok := newMnts[dst]
+		if !ok {
+			cur = "none"
+		}
+		if old != cur {
+			incompat = append(incompat, incompatibility{
+				what:       fmt.Sprintf("container %q mount %q", name, dst),
+				checkpoint: old,
+				current:    cur,
+			})
+		}
+	}
+	for dst, cur := range newMnts {
+		if _, ok := oldMnts[dst]; !ok {
+			incompat = append(incompat, incompatibility{
+				what:       fmt.Sprintf("container %q mount %q", name, dst),
+				checkpoint: "none",
+				current:    cur,
+			})
+		}
+	}
+	return incompat
+}
@@ -0,0 +1,213 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gvisor.dev/gvisor/pkg/sentry/state"
+	"gvisor.dev/gvisor/runsc/config"
+)
+
+func TestCheckRestoreCompat(t *testing.T) {
+	saved := testConfig()
+	saved.Platform = "ptrace"
+	metadata := make(map[string]string)
+	saveCompatMetadata(metadata, saved, map[string]*specs.Spec{"root": testSpec()})
+
+	for _, tc := range []struct {
+		name              string
+		metadata          map[string]string
+		modify            func(*config.Config)
+		allowIncompatible bool
+		// wantErr contains the substrings of the expected error, or is empty
+		// if restore should be allowed.
+		wantErr []string
+	}{
+		{
+			name:     "same",
+			metadata: metadata,
+		},
+		{
+			name:     "platform",
+			metadata: metadata,
+			modify:   func(c *config.Config) { c.Platform = "systrap" },
+			wantErr:  []string{`feature "platform" differs, checkpoint: "ptrace", current: "systrap"`, "--allow-incompatible"},
+		},
+		{
+			name:              "platform allowed",
+			metadata:          metadata,
+			modify:            func(c *config.Config) { c.Platform = "systrap" },
+			allowIncompatible: true,
+		},
+		{
+			name:              "network",
+			metadata:          metadata,
+			modify:            func(c *config.Config) { c.Network = config.NetworkSandbox },
+			allowIncompatible: true,
+			wantErr:           []string{`feature "network" differs, checkpoint: "none", current: "sandbox"`},
+		},
+		{
+			name:     "multiple",
+			metadata: metadata,
+			modify: func(c *config.Config) {
+				c.Platform = "systrap"
+				c.DirectFS = !c.DirectFS
+			},
+			wantErr: []string{`feature "platform"`, `feature "directfs"`},
+		},
+		{
+			name:     "version",
+			metadata: map[string]string{VersionKey: "old"},
+			wantErr:  []string{`runsc version differs, checkpoint: "old"`},
+		},
+		{
+			name:     "compatible state version",
+			metadata: map[string]string{VersionKey: "old", state.VersionKey: strconv.Itoa(state.Version)},
+		},
+		{
+			name:     "incompatible state version",
+			metadata: map[string]string{VersionKey: "new", state.VersionKey: strconv.Itoa(state.Version + 1)},
+			wantErr:  []string{`runsc version differs, checkpoint: "new" (state version`},
+		},
+		{
+			name:              "version allowed",
+			metadata:          map[string]string{VersionKey: "old"},
+			allowIncompatible: true,
+		},
+		{
+			name:     "old checkpoint",
+			metadata: map[string]string{VersionKey: metadata[VersionKey]},
+			modify:   func(c *config.Config) { c.Network = config.NetworkSandbox },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := testConfig()
+			conf.Platform = "ptrace"
+			if tc.modify != nil {
+				tc.modify(conf)
+			}
+			err := checkRestoreCompat(tc.metadata, conf, tc.allowIncompatible)
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("checkRestoreCompat() failed: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrIncompatibleCheckpoint) {
+				t.Fatalf("checkRestoreCompat() = %v, want %v", err, ErrIncompatibleCheckpoint)
+			}
+			for _, want := range tc.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("checkRestoreCompat() = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckRestoreMounts(t *testing.T) {
+	oldSpec := testSpec()
+	oldSpec.Mounts = []specs.Mount{
+		{Destination: "/proc", Type: "proc"},
+		{Destination: "/data", Type: "bind", Source: "/old", Options: []string{"ro"}},
+	}
+	oldSpecs := map[string]*specs.Spec{"root": oldSpec}
+	digest := mountsDigest(oldSpecs)
+
+	for _, tc := range []struct {
+		name    string
+		mounts  []specs.Mount
+		wantErr string
+	}{
+		{
+			name: "same",
+			mounts: []specs.Mount{
+				{Destination: "/proc", Type: "proc"},
+				{Destination: "/data", Type: "bind", Source: "/old", Options: []string{"ro"}},
+			},
+		},
+		{
+			name: "source changed",
+			mounts: []specs.Mount{
+				{Destination: "/proc", Type: "proc"},
+				{Destination: "/data", Type: "bind", Source: "/new", Options: []string{"ro"}},
+			},
+		},
+		{
+			name: "options changed",
+			mounts: []specs.Mount{
+				{Destination: "/proc", Type: "proc"},
+				{Destination: "/data", Type: "bind", Source: "/old", Options: []string{"rw"}},
+			},
+			wantErr: `container "root" mount "/data" differs`,
+		},
+		{
+			name: "mount removed",
+			mounts: []specs.Mount{
+				{Destination: "/proc", Type: "proc"},
+			},
+			wantErr: `current: none`,
+		},
+		{
+			name: "mount added",
+			mounts: []specs.Mount{
+				{Destination: "/proc", Type: "proc"},
+				{Destination: "/data", Type: "bind", Source: "/old", Options: []string{"ro"}},
+				{Destination: "/tmp", Type: "tmpfs"},
+			},
+			wantErr: `container "root" mount "/tmp" differs, checkpoint: none`,
+		},
+		{
+			name: "reordered",
+			mounts: []specs.Mount{
+				{Destination: "/data", Type: "bind", Source: "/old", Options: []string{"ro"}},
+				{Destination: "/proc", Type: "proc"},
+			},
+			wantErr: "mounts digest differs",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			newSpec := testSpec()
+			newSpec.Mounts = tc.mounts
+			newSpecs := map[string]*specs.Spec{"root": newSpec}
+			err := checkRestoreMounts(digest, oldSpecs, newSpecs, config.RestoreSpecValidationEnforce)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkRestoreMounts() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("checkRestoreMounts() = %v, want error containing %q", err, tc.wantErr)
+			}
+			// Mismatches are only reported when validation is enforced.
+			for _, policy := range []config.RestoreSpecValidationPolicy{config.RestoreSpecValidationWarning, config.RestoreSpecValidationIgnore} {
+				if err := checkRestoreMounts(digest, oldSpecs, newSpecs, policy); err != nil {
+					t.Errorf("checkRestoreMounts(%v) failed: %v", policy, err)
+				}
+			}
+		})
+	}
+
+	// Checkpoints without a digest are not checked.
+	if err := checkRestoreMounts("", oldSpecs, nil, config.RestoreSpecValidationEnforce); err != nil {
+		t.Errorf("checkRestoreMounts() without digest failed: %v", err)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/seccheck"
 
 	// Register supported of sinks.
+	_ "gvisor.dev/gvisor/pkg/sentry/seccheck/sinks/deny"
 	_ "gvisor.dev/gvisor/pkg/sentry/seccheck/sinks/null"
 	_ "gvisor.dev/gvisor/pkg/sentry/seccheck/sinks/remote"
 )
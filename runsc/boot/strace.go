@@ -15,16 +15,27 @@
 package boot
 
 import (
+	"os"
 	"strings"
 
 	"gvisor.dev/gvisor/pkg/sentry/strace"
 	"gvisor.dev/gvisor/runsc/config"
 )
 
-func enableStrace(conf *config.Config) error {
+func enableStrace(conf *config.Config, logFD int) error {
 	// We must initialize even if strace is not enabled.
 	strace.Initialize()
 
+	// Output and filters are set up even if strace is not enabled, since it
+	// can be enabled at runtime.
+	if logFD > 0 {
+		strace.SetLogFile(os.NewFile(uintptr(logFD), "strace log file"))
+	}
+	strace.SetSampleRate(uint32(conf.StraceSampleRate))
+	if len(conf.StraceContainers) > 0 {
+		strace.SetContainerFilter(strings.Split(conf.StraceContainers, ","))
+	}
+
 	if !conf.Strace {
 		return nil
 	}
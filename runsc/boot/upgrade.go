@@ -0,0 +1,583 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/cleanup"
+	"gvisor.dev/gvisor/pkg/fd"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/control"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/state/statefile"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/urpc"
+)
+
+// UpgradeOpts are the arguments to the Upgrade RPC.
+type UpgradeOpts struct {
+	// FilePayload contains the files that the sandbox state, pages metadata
+	// and pages are saved to, in that order. They are typically pipes, so that
+	// the caller, rather than the sandbox, is charged for the memory that the
+	// state is kept in.
+	urpc.FilePayload
+
+	// Flags are the flags that the new sandbox process will be started with,
+	// as returned by config.Config.ToFlags.
+	Flags []string
+}
+
+// UpgradeProcess describes a process started by Execute in UpgradeResult.
+type UpgradeProcess struct {
+	// CID is the ID of the container that the process belongs to.
+	CID string
+
+	// PID is the PID of the process, as returned by Execute.
+	PID int32
+
+	// WaitStatus is the wait status of the process.
+	WaitStatus uint32
+}
+
+// UpgradeContainer describes the FDs of a container in UpgradeResult.
+type UpgradeContainer struct {
+	// CID is the container ID.
+	CID string
+
+	// NumStdioFDs is the number of stdio FDs, either 0 or 3.
+	NumStdioFDs int
+
+	// NumGoferFilestoreFDs is the number of gofer filestore FDs.
+	NumGoferFilestoreFDs int
+
+	// HaveDevGoferFD is true if there is a dev gofer FD.
+	HaveDevGoferFD bool
+
+	// NumGoferFDs is the number of gofer FDs.
+	NumGoferFDs int
+}
+
+// UpgradeResult is the result of the Upgrade RPC.
+type UpgradeResult struct {
+	// FilePayload contains, in order: the control server socket; for each
+	// container in Containers, its stdio, gofer filestore, dev gofer and gofer
+	// FDs; and the files that were passed to CreateLinksAndRoutes.
+	urpc.FilePayload
+
+	// Containers describes the FDs of each container in FilePayload. The root
+	// container comes first.
+	Containers []UpgradeContainer
+
+	// RootMounts are the resolved mounts of the root container.
+	RootMounts []specs.Mount
+
+	// Network contains the arguments that CreateLinksAndRoutes was called
+	// with, so that the network can be set up again in the new sandbox
+	// process. It is nil if CreateLinksAndRoutes was not called.
+	Network *CreateLinksAndRoutesArgs
+
+	// ExitedProcesses are the processes started by Execute that have exited,
+	// but have not been waited for with WaitPID yet. They are passed to the
+	// new sandbox process in RestoreOpts, so that they can still be waited
+	// for.
+	ExitedProcesses []UpgradeProcess
+}
+
+// handover retains duplicates of the host FDs donated to the sandbox, so that
+// they can be handed over to a new sandbox process by Upgrade. It is only
+// created if --hot-upgrade is set; all methods are no-ops on a nil handover.
+type handover struct {
+	// upgraded is set once the FDs have been handed over, until the upgrade
+	// is aborted. Containers cannot be added meanwhile.
+	upgraded atomicbitops.Bool
+
+	mu sync.Mutex
+
+	// controllerFD is the control server socket.
+	//
+	// +checklocks:mu
+	controllerFD *fd.FD
+
+	// containers maps container IDs to their FDs. vdsoFD is never set, since
+	// the VDSO is part of the saved state.
+	//
+	// +checklocks:mu
+	containers map[string]*startFDs
+
+	// network are the arguments that CreateLinksAndRoutes was called with,
+	// and networkFDs are the files that were passed with them.
+	//
+	// +checklocks:mu
+	network *CreateLinksAndRoutesArgs
+	// +checklocks:mu
+	networkFDs []*fd.FD
+
+	// err, if set, is the reason why the sandbox cannot be upgraded.
+	//
+	// +checklocks:mu
+	err error
+}
+
+func newHandover() *handover {
+	return &handover{containers: make(map[string]*startFDs)}
+}
+
+func dupFD(f *fd.FD) (*fd.FD, error) {
+	if f == nil {
+		return nil, nil
+	}
+	nfd, err := unix.Dup(f.FD())
+	if err != nil {
+		return nil, err
+	}
+	return fd.New(nfd), nil
+}
+
+func dupFDs(fds []*fd.FD) ([]*fd.FD, error) {
+	dups := make([]*fd.FD, 0, len(fds))
+	for _, f := range fds {
+		dup, err := dupFD(f)
+		if err != nil {
+			for _, d := range dups {
+				_ = d.Close()
+			}
+			return nil, err
+		}
+		dups = append(dups, dup)
+	}
+	return dups, nil
+}
+
+// isUpgraded returns true if the FDs have been handed over.
+func (h *handover) isUpgraded() bool {
+	return h != nil && h.upgraded.Load()
+}
+
+// fail records that the sandbox cannot be upgraded because of err.
+func (h *handover) fail(err error) {
+	if h == nil {
+		return
+	}
+	log.Warningf("Sandbox cannot be upgraded: %v", err)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err == nil {
+		h.err = err
+	}
+}
+
+// setController retains the control server socket.
+func (h *handover) setController(controllerFD int) error {
+	if h == nil {
+		return nil
+	}
+	dup, err := unix.Dup(controllerFD)
+	if err != nil {
+		return fmt.Errorf("duplicating control server socket: %w", err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.controllerFD = fd.New(dup)
+	return nil
+}
+
+// addContainer retains the FDs of container cid, replacing any FDs previously
+// retained for it. It must be called before the FDs are consumed.
+func (h *handover) addContainer(cid string, fds *startFDs) error {
+	if h == nil {
+		return nil
+	}
+	dup := &startFDs{}
+	cu := cleanup.Make(dup.close)
+	defer cu.Clean()
+
+	var err error
+	if dup.stdios, err = dupFDs(fds.stdios); err != nil {
+		return fmt.Errorf("duplicating stdio FDs: %w", err)
+	}
+	if dup.goferFilestoreFDs, err = dupFDs(fds.goferFilestoreFDs); err != nil {
+		return fmt.Errorf("duplicating gofer filestore FDs: %w", err)
+	}
+	if dup.devGoferFD, err = dupFD(fds.devGoferFD); err != nil {
+		return fmt.Errorf("duplicating dev gofer FD: %w", err)
+	}
+	if dup.goferFDs, err = dupFDs(fds.goferFDs); err != nil {
+		return fmt.Errorf("duplicating gofer FDs: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.upgraded.Load() {
+		return errors.New("sandbox is being upgraded")
+	}
+	if old, ok := h.containers[cid]; ok {
+		old.close()
+	}
+	h.containers[cid] = dup
+	cu.Release()
+	return nil
+}
+
+// removeContainer releases the FDs retained for container cid.
+func (h *handover) removeContainer(cid string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if fds, ok := h.containers[cid]; ok {
+		fds.close()
+		delete(h.containers, cid)
+	}
+}
+
+// setNetwork retains args, which CreateLinksAndRoutes succeeded with.
+func (h *handover) setNetwork(args *CreateLinksAndRoutesArgs) {
+	if h == nil {
+		return
+	}
+	if len(args.XDPLinks) > 0 {
+		// The sentry may have bound the XDP socket and loaded BPF programs
+		// that can't be set up again.
+		h.fail(errors.New("XDP links cannot be handed over"))
+		return
+	}
+	fds, err := fd.NewFromFiles(args.Files)
+	if err != nil {
+		h.fail(fmt.Errorf("duplicating network FDs: %w", err))
+		return
+	}
+	network := *args
+	network.FilePayload = urpc.FilePayload{}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, f := range h.networkFDs {
+		_ = f.Close()
+	}
+	h.network = &network
+	h.networkFDs = fds
+}
+
+// handOver fills out with duplicates of the retained FDs, and marks the FDs
+// as handed over. rootCID is the ID of the root container.
+func (h *handover) handOver(rootCID string, out *UpgradeResult) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err != nil {
+		return fmt.Errorf("sandbox cannot be upgraded: %w", h.err)
+	}
+	if h.controllerFD == nil {
+		return errors.New("control server socket was not retained")
+	}
+	if _, ok := h.containers[rootCID]; !ok {
+		return fmt.Errorf("FDs of root container %q were not retained", rootCID)
+	}
+	cids := []string{rootCID}
+	for cid := range h.containers {
+		if cid != rootCID {
+			cids = append(cids, cid)
+		}
+	}
+	sort.Strings(cids[1:])
+
+	var files []*os.File
+	cu := cleanup.Make(func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	})
+	defer cu.Clean()
+	add := func(fds ...*fd.FD) error {
+		for _, f := range fds {
+			file, err := f.File()
+			if err != nil {
+				return fmt.Errorf("duplicating FD %d: %w", f.FD(), err)
+			}
+			files = append(files, file)
+		}
+		return nil
+	}
+
+	if err := add(h.controllerFD); err != nil {
+		return err
+	}
+	var containers []UpgradeContainer
+	for _, cid := range cids {
+		c := h.containers[cid]
+		if cid != rootCID && len(c.stdios) == 0 {
+			// The terminal of a subcontainer is not donated with its FDs.
+			return fmt.Errorf("container %q has a terminal, which cannot be handed over", cid)
+		}
+		if err := add(c.stdios...); err != nil {
+			return err
+		}
+		if err := add(c.goferFilestoreFDs...); err != nil {
+			return err
+		}
+		if c.devGoferFD != nil {
+			if err := add(c.devGoferFD); err != nil {
+				return err
+			}
+		}
+		if err := add(c.goferFDs...); err != nil {
+			return err
+		}
+		containers = append(containers, UpgradeContainer{
+			CID:                  cid,
+			NumStdioFDs:          len(c.stdios),
+			NumGoferFilestoreFDs: len(c.goferFilestoreFDs),
+			HaveDevGoferFD:       c.devGoferFD != nil,
+			NumGoferFDs:          len(c.goferFDs),
+		})
+	}
+	if h.network != nil {
+		if err := add(h.networkFDs...); err != nil {
+			return err
+		}
+		network := *h.network
+		out.Network = &network
+	}
+
+	out.Files = files
+	out.Containers = containers
+	h.upgraded.Store(true)
+	cu.Release()
+	return nil
+}
+
+// upgradeIgnoredFlags are the flags that may differ between the sandbox and
+// the upgrade command, since they only affect logging.
+var upgradeIgnoredFlags = map[string]struct{}{
+	"alsologtostderr":  {},
+	"debug":            {},
+	"debug-log":        {},
+	"debug-log-format": {},
+	"log":              {},
+	"log-format":       {},
+	"panic-log":        {},
+}
+
+// checkUpgradeFlags returns an error if a flag has a different value in want,
+// the flags of the sandbox, and in got, the flags of the upgrade command.
+// Flags that are only known to one of the runsc versions are ignored.
+func checkUpgradeFlags(want, got []string) error {
+	parse := func(flags []string) map[string]string {
+		m := make(map[string]string, len(flags))
+		for _, f := range flags {
+			name, value, _ := strings.Cut(strings.TrimLeft(f, "-"), "=")
+			m[name] = value
+		}
+		return m
+	}
+	gotFlags := parse(got)
+	var mismatches []string
+	for name, value := range parse(want) {
+		if _, ok := upgradeIgnoredFlags[name]; ok {
+			continue
+		}
+		if gotValue, ok := gotFlags[name]; ok && gotValue != value {
+			mismatches = append(mismatches, fmt.Sprintf("--%s=%q (sandbox has %q)", name, gotValue, value))
+		}
+	}
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		return fmt.Errorf("flags don't match the sandbox's: %s", strings.Join(mismatches, ", "))
+	}
+	return nil
+}
+
+// exitedProcesses returns the processes started by Execute that have exited
+// but have not been waited for. It fails if any such process is still
+// running, since its stdio cannot be handed over.
+//
+// Preconditions: The kernel must be paused.
+func (l *Loader) exitedProcesses() ([]UpgradeProcess, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var procs []UpgradeProcess
+	for eid, ep := range l.processes {
+		if eid.pid == 0 {
+			// This is the init process of a container.
+			continue
+		}
+		if ep.exitStatus != nil {
+			// The process was handed over by a previous upgrade.
+			procs = append(procs, UpgradeProcess{CID: eid.cid, PID: int32(eid.pid), WaitStatus: *ep.exitStatus})
+			continue
+		}
+		if ep.tg == nil || ep.tg.Count() != 0 {
+			return nil, fmt.Errorf("process %d of container %q was started by exec and is still running, which cannot be handed over", eid.pid, eid.cid)
+		}
+		procs = append(procs, UpgradeProcess{CID: eid.cid, PID: int32(eid.pid), WaitStatus: uint32(ep.tg.ExitStatus())})
+	}
+	sort.Slice(procs, func(i, j int) bool {
+		if procs[i].CID != procs[j].CID {
+			return procs[i].CID < procs[j].CID
+		}
+		return procs[i].PID < procs[j].PID
+	})
+	return procs, nil
+}
+
+// restoreExitedProcessesLocked registers the processes handed over by the
+// Upgrade RPC of the previous sandbox process, so that they can be waited for.
+//
+// +checklocks:l.mu
+func (l *Loader) restoreExitedProcessesLocked(procs []UpgradeProcess) {
+	for _, p := range procs {
+		ws := p.WaitStatus
+		l.processes[execID{cid: p.CID, pid: kernel.ThreadID(p.PID)}] = &execProcess{exitStatus: &ws}
+	}
+}
+
+// Upgrade saves the sandbox state to the files in o and hands the host FDs
+// donated to the sandbox over to the caller, so that the sandbox can be
+// restored in a new sandbox process, typically running a newer runsc binary,
+// without restarting its containers.
+//
+// Once the state is saved, the sandbox stays paused and stops accepting
+// control connections, so that the new sandbox process can take over the
+// control socket. The caller then either kills the sandbox process once the
+// new one has restored the sandbox, or calls UpgradeAbort over the same
+// connection to resume the sandbox. Packets that the sandbox receives in the
+// meantime are dropped.
+//
+// The sandbox must have been started with --hot-upgrade.
+func (cm *containerManager) Upgrade(o *UpgradeOpts, out *UpgradeResult) error {
+	log.Debugf("containerManager.Upgrade")
+	h := cm.l.handover
+	if h == nil {
+		return errors.New("sandbox was not started with --hot-upgrade")
+	}
+	if n := len(o.Files); n != 3 {
+		return fmt.Errorf("got %d files, wanted 3", n)
+	}
+	if err := checkUpgradeFlags(cm.l.root.conf.ToFlags(), o.Flags); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	haveController := h.controllerFD != nil
+	h.mu.Unlock()
+	if !haveController {
+		// Connections could no longer be accepted if the upgrade failed.
+		return errors.New("control server socket was not retained")
+	}
+
+	cm.l.mu.Lock()
+	state := cm.l.state
+	rootCID := cm.l.root.cid
+	rootMounts := cm.l.root.spec.Mounts
+	cm.l.mu.Unlock()
+	if state != started && state != restored {
+		return fmt.Errorf("cannot upgrade a sandbox in state=%s", state)
+	}
+
+	// Keep the sandbox paused until the upgrade is committed or aborted, so
+	// that the saved state stays current.
+	cm.l.k.Pause()
+	cu := cleanup.Make(cm.l.k.Unpause)
+	defer cu.Clean()
+	if stack := cm.l.k.RootNetworkNamespace().Stack(); stack != nil {
+		stack.Pause()
+		cu.Add(stack.Resume)
+	}
+
+	exited, err := cm.l.exitedProcesses()
+	if err != nil {
+		return err
+	}
+	if err := cm.l.ctrl.srv.StopAccepting(); err != nil {
+		return fmt.Errorf("stopping to accept control connections: %w", err)
+	}
+	cu.Add(func() {
+		if err := cm.l.resumeAccepting(); err != nil {
+			log.Warningf("Failed to resume accepting control connections: %v", err)
+		}
+	})
+	if err := h.handOver(rootCID, out); err != nil {
+		return err
+	}
+	out.RootMounts = rootMounts
+	out.ExitedProcesses = exited
+	cu.Add(func() {
+		h.upgraded.Store(false)
+		for _, f := range out.Files {
+			_ = f.Close()
+		}
+		*out = UpgradeResult{}
+	})
+
+	// The state is only kept in memory until the new sandbox process restores
+	// it, so don't spend time compressing it.
+	saveOpts := control.SaveOpts{
+		Metadata:      statefile.Options{Compression: statefile.CompressionLevelNone}.WriteToMetadata(map[string]string{}),
+		FilePayload:   o.FilePayload,
+		HavePagesFile: true,
+		Resume:        true,
+	}
+	if err := cm.l.save(&saveOpts); err != nil {
+		return fmt.Errorf("saving sandbox: %w", err)
+	}
+	cu.Release()
+	log.Infof("Sandbox state saved and FDs handed over for upgrade")
+	return nil
+}
+
+// UpgradeAbort resumes the sandbox after a successful Upgrade, if the new
+// sandbox process failed to restore it. It must be called over the connection
+// that Upgrade was called over, since the sandbox no longer accepts
+// connections.
+func (cm *containerManager) UpgradeAbort(_, _ *struct{}) error {
+	log.Debugf("containerManager.UpgradeAbort")
+	h := cm.l.handover
+	if !h.isUpgraded() {
+		return errors.New("sandbox is not being upgraded")
+	}
+	if err := cm.l.resumeAccepting(); err != nil {
+		return fmt.Errorf("resuming to accept control connections: %w", err)
+	}
+	h.upgraded.Store(false)
+	if stack := cm.l.k.RootNetworkNamespace().Stack(); stack != nil {
+		stack.Resume()
+	}
+	cm.l.k.Unpause()
+	log.Infof("Sandbox upgrade aborted, sandbox resumed")
+	return control.PostResume(cm.l.k, nil)
+}
+
+// resumeAccepting resumes accepting connections on the control socket after
+// Upgrade stopped it.
+func (l *Loader) resumeAccepting() error {
+	h := l.handover
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.controllerFD == nil {
+		return errors.New("control server socket was not retained")
+	}
+	dup, err := dupFD(h.controllerFD)
+	if err != nil {
+		return err
+	}
+	return l.ctrl.srv.ResumeAccepting(dup.Release())
+}
@@ -0,0 +1,215 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/fd"
+)
+
+// newTestFDs returns n FDs and the inodes of the files they refer to.
+func newTestFDs(t *testing.T, n int) ([]*fd.FD, []uint64) {
+	t.Helper()
+	dir := t.TempDir()
+	var fds []*fd.FD
+	for i := 0; i < n; i++ {
+		f, err := os.CreateTemp(dir, "handover")
+		if err != nil {
+			t.Fatalf("CreateTemp: %v", err)
+		}
+		file, err := fd.NewFromFile(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("NewFromFile: %v", err)
+		}
+		fds = append(fds, file)
+	}
+	var inos []uint64
+	for _, f := range fds {
+		var st unix.Stat_t
+		if err := unix.Fstat(f.FD(), &st); err != nil {
+			t.Fatalf("Fstat: %v", err)
+		}
+		inos = append(inos, st.Ino)
+	}
+	t.Cleanup(func() {
+		for _, f := range fds {
+			_ = f.Close()
+		}
+	})
+	return fds, inos
+}
+
+func fileInos(t *testing.T, files []*os.File) []uint64 {
+	t.Helper()
+	var inos []uint64
+	for _, f := range files {
+		var st unix.Stat_t
+		if err := unix.Fstat(int(f.Fd()), &st); err != nil {
+			t.Fatalf("Fstat: %v", err)
+		}
+		inos = append(inos, st.Ino)
+	}
+	return inos
+}
+
+func TestHandover(t *testing.T) {
+	fds, inos := newTestFDs(t, 9)
+	h := newHandover()
+	if err := h.setController(fds[0].FD()); err != nil {
+		t.Fatalf("setController: %v", err)
+	}
+	root := &startFDs{stdios: fds[1:4], goferFDs: fds[4:5]}
+	if err := h.addContainer("root", root); err != nil {
+		t.Fatalf("addContainer(root): %v", err)
+	}
+	sub := &startFDs{stdios: fds[5:8], devGoferFD: fds[8]}
+	if err := h.addContainer("sub", sub); err != nil {
+		t.Fatalf("addContainer(sub): %v", err)
+	}
+	if err := h.addContainer("gone", sub); err != nil {
+		t.Fatalf("addContainer(gone): %v", err)
+	}
+	h.removeContainer("gone")
+
+	// The retained FDs must remain valid after the originals are closed.
+	for _, f := range fds {
+		_ = f.Close()
+	}
+
+	var out UpgradeResult
+	if err := h.handOver("root", &out); err != nil {
+		t.Fatalf("handOver: %v", err)
+	}
+	defer func() {
+		for _, f := range out.Files {
+			_ = f.Close()
+		}
+	}()
+	if !h.isUpgraded() {
+		t.Errorf("isUpgraded() = false after handOver")
+	}
+	want := []UpgradeContainer{
+		{CID: "root", NumStdioFDs: 3, NumGoferFDs: 1},
+		{CID: "sub", NumStdioFDs: 3, HaveDevGoferFD: true},
+	}
+	if len(out.Containers) != len(want) {
+		t.Fatalf("got containers %+v, want %+v", out.Containers, want)
+	}
+	for i := range want {
+		if out.Containers[i] != want[i] {
+			t.Errorf("container %d: got %+v, want %+v", i, out.Containers[i], want[i])
+		}
+	}
+	got := fileInos(t, out.Files)
+	if len(got) != len(inos) {
+		t.Fatalf("got %d files, want %d", len(got), len(inos))
+	}
+	for i := range inos {
+		if got[i] != inos[i] {
+			t.Errorf("file %d: got inode %d, want %d", i, got[i], inos[i])
+		}
+	}
+	if out.Network != nil {
+		t.Errorf("got network %+v, want nil", out.Network)
+	}
+
+	if err := h.addContainer("late", &startFDs{}); err == nil {
+		t.Errorf("addContainer after handOver succeeded, want error")
+	}
+}
+
+func TestHandoverFailure(t *testing.T) {
+	fds, _ := newTestFDs(t, 2)
+	h := newHandover()
+	if err := h.setController(fds[0].FD()); err != nil {
+		t.Fatalf("setController: %v", err)
+	}
+	if err := h.addContainer("root", &startFDs{goferFDs: fds[1:]}); err != nil {
+		t.Fatalf("addContainer: %v", err)
+	}
+	h.setNetwork(&CreateLinksAndRoutesArgs{XDPLinks: []XDPLink{{Name: "eth0"}}})
+
+	var out UpgradeResult
+	if err := h.handOver("root", &out); err == nil {
+		t.Fatalf("handOver succeeded with XDP links, want error")
+	}
+	if h.isUpgraded() {
+		t.Errorf("isUpgraded() = true after failed handOver")
+	}
+	if len(out.Files) != 0 {
+		t.Errorf("got %d files after failed handOver, want 0", len(out.Files))
+	}
+}
+
+func TestNilHandover(t *testing.T) {
+	var h *handover
+	if err := h.addContainer("root", &startFDs{}); err != nil {
+		t.Errorf("addContainer: %v", err)
+	}
+	h.removeContainer("root")
+	h.setNetwork(&CreateLinksAndRoutesArgs{})
+	h.fail(errors.New("test"))
+	if h.isUpgraded() {
+		t.Errorf("isUpgraded() = true for nil handover")
+	}
+}
+
+func TestCheckUpgradeFlags(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		want    []string
+		got     []string
+		wantErr bool
+	}{
+		{
+			name: "same",
+			want: []string{"--platform=systrap", "--network=sandbox"},
+			got:  []string{"--network=sandbox", "--platform=systrap"},
+		},
+		{
+			name:    "different",
+			want:    []string{"--platform=systrap", "--network=sandbox"},
+			got:     []string{"--platform=kvm", "--network=sandbox"},
+			wantErr: true,
+		},
+		{
+			name: "new flag",
+			want: []string{"--platform=systrap"},
+			got:  []string{"--platform=systrap", "--new-flag=true"},
+		},
+		{
+			name: "removed flag",
+			want: []string{"--platform=systrap", "--old-flag=true"},
+			got:  []string{"--platform=systrap"},
+		},
+		{
+			name: "logging",
+			want: []string{"--debug=false", "--debug-log=/tmp/old/"},
+			got:  []string{"--debug=true", "--debug-log=/tmp/new/"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkUpgradeFlags(tc.want, tc.got)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("checkUpgradeFlags(%v, %v) = %v, want error: %t", tc.want, tc.got, err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/fd"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/runsc/config"
+)
+
+const (
+	// VDSOAnnotation selects the VDSO mapped into the container's processes.
+	// It is either "none", which disables the VDSO, or the host path of an
+	// alternate prebuilt VDSO image, which is opened outside the sandbox and
+	// donated to it. When unset, the sentry's compiled-in VDSO is used.
+	//
+	// The annotation is ignored unless config.Config.AllowVDSOAnnotation is
+	// set.
+	VDSOAnnotation = "dev.gvisor.spec.vdso"
+
+	vdsoNone = "none"
+
+	// maxVDSOFileSize bounds the amount of data read from a VDSO file.
+	maxVDSOFileSize = 1 << 20
+)
+
+// VDSOPath returns the host path of the alternate VDSO requested by spec, or
+// "" if none.
+func VDSOPath(conf *config.Config, spec *specs.Spec) string {
+	if spec == nil || !conf.AllowVDSOAnnotation {
+		return ""
+	}
+	if val := spec.Annotations[VDSOAnnotation]; val != vdsoNone {
+		return val
+	}
+	return ""
+}
+
+// OpenVDSOFile opens the alternate VDSO file at path, which is returned by
+// VDSOPath, on the host. The path comes from the container spec, so it must
+// name a regular file that isn't a symlink; O_NONBLOCK prevents the open from
+// blocking on a FIFO before that is checked.
+func OpenVDSOFile(path string) (*os.File, error) {
+	hostFD, err := unix.Open(path, unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("opening VDSO file %q: %w", path, err)
+	}
+	var stat unix.Stat_t
+	if err := unix.Fstat(hostFD, &stat); err != nil {
+		unix.Close(hostFD)
+		return nil, fmt.Errorf("fstat(%q): %w", path, err)
+	}
+	if stat.Mode&unix.S_IFMT != unix.S_IFREG {
+		unix.Close(hostFD)
+		return nil, fmt.Errorf("VDSO file %q is not a regular file", path)
+	}
+	return os.NewFile(uintptr(hostFD), path), nil
+}
+
+// setupContainerVDSO applies VDSOAnnotation to the container with ID cid.
+// vdsoFD is the file opened from VDSOPath(conf, spec), or nil.
+func setupContainerVDSO(k *kernel.Kernel, conf *config.Config, spec *specs.Spec, cid string, vdsoFD *fd.FD) error {
+	val := spec.Annotations[VDSOAnnotation]
+	if val == "" {
+		return nil
+	}
+	if !conf.AllowVDSOAnnotation {
+		log.Warningf("Ignoring annotation %s=%q, since --allow-vdso-annotation is not set", VDSOAnnotation, val)
+		return nil
+	}
+	if val == vdsoNone {
+		return k.SetContainerVDSO(cid, nil)
+	}
+	if vdsoFD == nil {
+		return fmt.Errorf("annotation %s=%q: VDSO file was not provided", VDSOAnnotation, val)
+	}
+	binary, err := io.ReadAll(io.LimitReader(vdsoFD, maxVDSOFileSize+1))
+	if err != nil {
+		return fmt.Errorf("reading VDSO file %q: %w", val, err)
+	}
+	if len(binary) > maxVDSOFileSize {
+		return fmt.Errorf("VDSO file %q is larger than %d bytes", val, maxVDSOFileSize)
+	}
+	return k.SetContainerVDSO(cid, binary)
+}
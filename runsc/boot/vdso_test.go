@@ -0,0 +1,77 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/runsc/config"
+)
+
+func TestVDSOPath(t *testing.T) {
+	for _, tc := range []struct {
+		annotations map[string]string
+		allow       bool
+		want        string
+	}{
+		{allow: true, want: ""},
+		{annotations: map[string]string{VDSOAnnotation: "none"}, allow: true, want: ""},
+		{annotations: map[string]string{VDSOAnnotation: "/opt/vdso.so"}, allow: true, want: "/opt/vdso.so"},
+		{annotations: map[string]string{VDSOAnnotation: "/opt/vdso.so"}, allow: false, want: ""},
+	} {
+		conf := &config.Config{AllowVDSOAnnotation: tc.allow}
+		spec := &specs.Spec{Annotations: tc.annotations}
+		if got := VDSOPath(conf, spec); got != tc.want {
+			t.Errorf("VDSOPath(allow=%t, %v) = %q, want %q", tc.allow, tc.annotations, got, tc.want)
+		}
+	}
+	if got := VDSOPath(&config.Config{AllowVDSOAnnotation: true}, nil); got != "" {
+		t.Errorf("VDSOPath(nil) = %q, want \"\"", got)
+	}
+}
+
+func TestOpenVDSOFile(t *testing.T) {
+	dir := t.TempDir()
+	regular := filepath.Join(dir, "vdso.so")
+	if err := os.WriteFile(regular, []byte("vdso"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	symlink := filepath.Join(dir, "symlink")
+	if err := os.Symlink(regular, symlink); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+	fifo := filepath.Join(dir, "fifo")
+	if err := unix.Mkfifo(fifo, 0644); err != nil {
+		t.Fatalf("Mkfifo failed: %v", err)
+	}
+
+	f, err := OpenVDSOFile(regular)
+	if err != nil {
+		t.Fatalf("OpenVDSOFile(%q) failed: %v", regular, err)
+	}
+	f.Close()
+
+	// Opening a FIFO must fail rather than block.
+	for _, path := range []string{symlink, fifo, dir, filepath.Join(dir, "nonexistent")} {
+		if f, err := OpenVDSOFile(path); err == nil {
+			f.Close()
+			t.Errorf("OpenVDSOFile(%q) succeeded, want error", path)
+		}
+	}
+}
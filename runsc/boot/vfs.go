@@ -292,7 +292,7 @@ func compileMounts(spec *specs.Spec, conf *config.Config, containerID string) []
 	// there are submounts of these mandatory mounts already in the spec.
 	mounts = append(mounts[:0], append(mandatoryMounts, mounts[0:]...)...)
 
-	return mounts
+	return applyDevShmSize(spec, mounts)
 }
 
 // goferMountData creates a slice of gofer mount data.
@@ -962,6 +962,9 @@ func getMountNameAndOptions(spec *specs.Spec, conf *config.Config, m *mountInfo,
 		if err != nil {
 			return "", nil, err
 		}
+		if !conf.HostLocks && slices.Contains(data, gofer.HostLocksMountOption) {
+			return "", nil, fmt.Errorf("mount option %q requires --host-locks", gofer.HostLocksMountOption)
+		}
 		data = append(data, goferMountData(m.goferFD.Release(), getMountAccessType(conf, m.hint), conf)...)
 		internalData = gofer.InternalFilesystemOptions{
 			UniqueID: vfs.RestoreID{
@@ -1239,6 +1242,59 @@ func (c *containerMounter) mountCgroupSubmounts(ctx context.Context, spec *specs
 	return nil
 }
 
+// removeCgroupSubmounts removes the cgroup directories that
+// mountCgroupSubmounts created for container cid, along with any cgroups that
+// the container's processes created under them, so that the container can be
+// started again with the same ID.
+//
+// Preconditions: All processes of the container have exited.
+func (l *Loader) removeCgroupSubmounts(cid string) {
+	ctx := l.k.SupervisorContext()
+	creds := auth.NewRootCredentials(l.k.RootUserNamespace())
+	for _, ctrl := range kernel.CgroupCtrls {
+		cgroupMnt := l.k.GetCgroupMount(string(ctrl))
+		if cgroupMnt == nil {
+			// Cgroups are not mounted in the sandbox.
+			return
+		}
+		root := vfs.MakeVirtualDentry(cgroupMnt.Mount, cgroupMnt.Root)
+		if err := removeCgroupTree(ctx, l.k.VFS(), creds, root, cid); err != nil && !linuxerr.Equals(linuxerr.ENOENT, err) {
+			log.Warningf("Failed to remove %s cgroup of container %q: %v", ctrl, cid, err)
+		}
+	}
+}
+
+// removeCgroupTree removes the cgroup at path relative to root, and all of its
+// descendants.
+func removeCgroupTree(ctx context.Context, vfsObj *vfs.VirtualFilesystem, creds *auth.Credentials, root vfs.VirtualDentry, path string) error {
+	pop := &vfs.PathOperation{
+		Root:  root,
+		Start: root,
+		Path:  fspath.Parse(path),
+	}
+	fd, err := vfsObj.OpenAt(ctx, creds, pop, &vfs.OpenOptions{Flags: linux.O_RDONLY | linux.O_DIRECTORY})
+	if err != nil {
+		return err
+	}
+	var children []string
+	err = fd.IterDirents(ctx, vfs.IterDirentsCallbackFunc(func(dirent vfs.Dirent) error {
+		if dirent.Name != "." && dirent.Name != ".." && dirent.Type == linux.DT_DIR {
+			children = append(children, dirent.Name)
+		}
+		return nil
+	}))
+	fd.DecRef(ctx)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := removeCgroupTree(ctx, vfsObj, creds, root, path+"/"+child); err != nil {
+			return err
+		}
+	}
+	return vfsObj.RmdirAt(ctx, creds, pop)
+}
+
 // mountSharedMaster mounts the master of a volume that is shared among
 // containers in a pod.
 func (c *containerMounter) mountSharedMaster(ctx context.Context, spec *specs.Spec, conf *config.Config, mntInfo *mountInfo, creds *auth.Credentials) (*vfs.Mount, error) {
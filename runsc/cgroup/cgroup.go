@@ -358,6 +358,55 @@ func NewFromPid(pid int, useSystemd bool) (Cgroup, error) {
 	return new(strconv.Itoa(pid), "", useSystemd)
 }
 
+// MoveProcesses moves all processes in src to dst, e.g. to move a sandbox that
+// was created ahead of time to the cgroup of the container it runs. Processes
+// that are created in src while they are moved, e.g. by fork(2), are moved
+// as well.
+func MoveProcesses(src, dst Cgroup) error {
+	if IsOnlyV2() {
+		return moveProcesses(src.MakePath(""), dst.MakePath(""))
+	}
+	for key, ctrlr := range controllers {
+		if err := moveProcesses(src.MakePath(key), dst.MakePath(key)); err != nil {
+			if ctrlr.optional() && os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// moveProcesses moves all processes in the cgroup at srcPath to the cgroup at
+// dstPath.
+func moveProcesses(srcPath, dstPath string) error {
+	if srcPath == dstPath {
+		return nil
+	}
+	// Bound the number of passes in case processes are created faster than
+	// they can be moved.
+	const maxPasses = 10
+	for i := 0; i < maxPasses; i++ {
+		procs, err := getValue(srcPath, "cgroup.procs")
+		if err != nil {
+			return err
+		}
+		pids := strings.Fields(procs)
+		if len(pids) == 0 {
+			return nil
+		}
+		for _, pid := range pids {
+			// Writing a PID to a cgroup.procs file moves the process to the
+			// corresponding cgroup - cgroups(7). ESRCH means that the process
+			// has exited.
+			if err := setValue(dstPath, "cgroup.procs", pid); err != nil && !errors.Is(err, unix.ESRCH) {
+				return fmt.Errorf("moving process %s to cgroup %q: %w", pid, dstPath, err)
+			}
+		}
+	}
+	return fmt.Errorf("processes are still being created in cgroup %q", srcPath)
+}
+
 // LikelySystemdPath returns true if the path looks like a systemd path. This is
 // by no means an exhaustive check, it's just a useful proxy for logging a
 // warning.
@@ -52,6 +52,12 @@ type cgroupSystemd struct {
 	Parent string
 	// ScopePrefix is the prefix for the scope name.
 	ScopePrefix string
+	// UserManager is true if the scope is managed by the calling user's
+	// systemd instance instead of the system instance, e.g. in rootless mode.
+	UserManager bool
+	// ManagerCgroup is the cgroup of the user's systemd instance, relative to
+	// the cgroup mountpoint. It is empty for the system instance.
+	ManagerCgroup string
 
 	properties []systemdDbus.Property
 	dbusConn   *systemdDbus.Conn
@@ -75,9 +81,10 @@ func newCgroupV2Systemd(cgv2 *cgroupV2) (*cgroupSystemd, error) {
 	if err := validSlice(cg.Parent); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidGroupPath, err)
 	}
-	// Rewrite Path so that it is compatible with cgroupv2 methods.
-	cg.Path = filepath.Join(expandSlice(cg.Parent), cg.unitName())
-	conn, err := systemdDbus.NewWithContext(ctx)
+	// An unprivileged user can't create units in the system instance, so use
+	// the user's own systemd instance, which has controllers delegated to it.
+	cg.UserManager = hostUID() != 0
+	conn, err := newSystemdConn(ctx, cg.UserManager)
 	if err != nil {
 		return nil, err
 	}
@@ -88,10 +95,91 @@ func newCgroupV2Systemd(cgv2 *cgroupV2) (*cgroupSystemd, error) {
 	if version < 244 {
 		return nil, fmt.Errorf("systemd version %d not supported, please upgrade to at least 244", version)
 	}
+	if cg.UserManager {
+		if cg.ManagerCgroup, err = managerCgroup(conn); err != nil {
+			return nil, err
+		}
+		// Only the controllers delegated to the user manager are usable.
+		data, err := os.ReadFile(filepath.Join(cg.Mountpoint, cg.ManagerCgroup, controllersFile))
+		if err != nil {
+			return nil, err
+		}
+		cg.Controllers = strings.Fields(string(data))
+	}
+	// Rewrite Path so that it is compatible with cgroupv2 methods.
+	cg.Path = filepath.Join(cg.ManagerCgroup, expandSlice(cg.Parent), cg.unitName())
 	cg.dbusConn = conn
 	return cg, err
 }
 
+// newSystemdConn connects to the system instance of systemd, or to the
+// calling user's instance if user is true.
+func newSystemdConn(ctx context.Context, user bool) (*systemdDbus.Conn, error) {
+	if !user {
+		return systemdDbus.NewWithContext(ctx)
+	}
+	// systemdDbus.NewUserConnectionContext authenticates with os.Getuid(),
+	// which is 0 after runsc re-executes itself in a user namespace in
+	// rootless mode. The bus checks the credentials against the UID in the
+	// initial user namespace, so authenticate with that instead.
+	return systemdDbus.NewConnection(func() (*dbus.Conn, error) {
+		conn, err := dbus.SessionBusPrivateNoAutoStartup(dbus.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("connecting to the user session bus, check that DBUS_SESSION_BUS_ADDRESS or XDG_RUNTIME_DIR is set: %w", err)
+		}
+		if err := conn.Auth([]dbus.Auth{dbus.AuthExternal(strconv.Itoa(hostUID()))}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if err := conn.Hello(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	})
+}
+
+// managerCgroup returns the cgroup of the systemd instance that conn is
+// connected to.
+func managerCgroup(conn *systemdDbus.Conn) (string, error) {
+	v, err := conn.GetManagerProperty("ControlGroup")
+	if err != nil {
+		return "", fmt.Errorf("unable to get systemd manager cgroup: %w", err)
+	}
+	// Properties are returned in their D-Bus text form, i.e. quoted.
+	path, err := strconv.Unquote(v)
+	if err != nil {
+		return "", fmt.Errorf("can't parse systemd manager cgroup %q: %w", v, err)
+	}
+	return path, nil
+}
+
+// hostUID returns the UID in the initial user namespace that the current
+// effective UID maps to.
+func hostUID() int {
+	euid := os.Geteuid()
+	data, err := os.ReadFile("/proc/self/uid_map")
+	if err != nil {
+		return euid
+	}
+	return mapID(string(data), euid)
+}
+
+// mapID maps id through idMap, which has the format of
+// /proc/[pid]/uid_map. id is returned unchanged if it isn't mapped.
+func mapID(idMap string, id int) int {
+	for _, line := range strings.Split(idMap, "\n") {
+		var inside, outside, length int
+		if _, err := fmt.Sscanf(line, "%d %d %d", &inside, &outside, &length); err != nil {
+			continue
+		}
+		if id >= inside && id-inside < length {
+			return outside + id - inside
+		}
+	}
+	return id
+}
+
 // Install configures the properties for a scope unit but does not start the
 // unit.
 func (c *cgroupSystemd) Install(res *specs.LinuxResources) error {
@@ -127,6 +215,20 @@ func (c *cgroupSystemd) Install(res *specs.LinuxResources) error {
 			c.properties = append(c.properties, props...)
 			continue
 		}
+		if c.UserManager {
+			// The user manager only gets the controllers that the system
+			// instance delegates to it, often just memory and pids. Missing
+			// controllers are fine unless the spec asks for limits that need
+			// them, which must not be silently dropped.
+			props, err := ctrlr.generateProperties(res)
+			if err != nil {
+				return err
+			}
+			if len(props) != 0 {
+				return fmt.Errorf("cgroup controller %q is not delegated to the systemd user manager for %q, but is needed by the resource limits", controllerName, c.Path)
+			}
+			continue
+		}
 		if ctrlr.optional() {
 			if err := ctrlr.skip(res); err != nil {
 				return err
@@ -145,7 +247,7 @@ func (c *cgroupSystemd) unitName() string {
 // MakePath builds a path to the given controller.
 func (c *cgroupSystemd) MakePath(string) string {
 	fullSlicePath := expandSlice(c.Parent)
-	path := filepath.Join(c.Mountpoint, fullSlicePath, c.unitName())
+	path := filepath.Join(c.Mountpoint, c.ManagerCgroup, fullSlicePath, c.unitName())
 	return path
 }
 
@@ -159,7 +261,7 @@ func (c *cgroupSystemd) Join() (func(), error) {
 	clean := cleanup.Make(func() { _ = c.Uninstall() })
 	defer clean.Clean()
 
-	conn, err := systemdDbus.NewWithContext(ctx)
+	conn, err := newSystemdConn(ctx, c.UserManager)
 	if err != nil {
 		return nil, err
 	}
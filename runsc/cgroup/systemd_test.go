@@ -249,3 +249,60 @@ func filterProperties(got []systemdDbus.Property, want []systemdDbus.Property) [
 	}
 	return filtered
 }
+
+func TestInstallUserManager(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		res     *specs.LinuxResources
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			res:  nil,
+		},
+		{
+			name: "delegated",
+			res: &specs.LinuxResources{
+				Memory: &specs.LinuxMemory{
+					Limit: int64Ptr(1),
+				},
+			},
+		},
+		{
+			name: "not delegated",
+			res: &specs.LinuxResources{
+				CPU: &specs.LinuxCPU{
+					Quota:  int64Ptr(5),
+					Period: uint64Ptr(10),
+				},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cg := cgroupSystemd{Name: "123", Parent: "parent.slice", UserManager: true}
+			cg.Controllers = []string{"memory", "pids"}
+			if err := cg.Install(tc.res); (err != nil) != tc.wantErr {
+				t.Errorf("Install() got error: %v, want error: %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMapID(t *testing.T) {
+	for _, tc := range []struct {
+		idMap string
+		id    int
+		want  int
+	}{
+		{idMap: "         0          0 4294967295\n", id: 0, want: 0},
+		{idMap: "         0       1000          1\n", id: 0, want: 1000},
+		{idMap: "         0       1000          1\n         1     100000      65536\n", id: 2, want: 100001},
+		{idMap: "         0       1000          1\n", id: 5, want: 5},
+		{idMap: "", id: 1000, want: 1000},
+	} {
+		if got := mapID(tc.idMap, tc.id); got != tc.want {
+			t.Errorf("mapID(%q, %d) = %d, want %d", tc.idMap, tc.id, got, tc.want)
+		}
+	}
+}
@@ -95,7 +95,7 @@ func Main() {
 	}
 
 	// NFtables is only supported for netstack.
-	if (conf.Network == config.NetworkNone || conf.Network == config.NetworkSandbox) && conf.Nftables {
+	if (conf.Network == config.NetworkNone || conf.Network == config.NetworkSandbox || conf.Network == config.NetworkSRIOV) && conf.Nftables {
 		nftables.EnableNFTables()
 	}
 
@@ -254,6 +254,7 @@ func forEachCmd(cb func(cmd subcommands.Command, group string)) {
 	cb(subcommands.FlagsCommand(), "")
 
 	// Register OCI user-facing runsc commands.
+	cb(new(cmd.Adopt), "")
 	cb(new(cmd.Checkpoint), "")
 	cb(new(cmd.Create), "")
 	cb(new(cmd.Delete), "")
@@ -265,12 +266,15 @@ func forEachCmd(cb func(cmd subcommands.Command, group string)) {
 	cb(new(cmd.PS), "")
 	cb(new(cmd.Pause), "")
 	cb(new(cmd.PortForward), "")
+	cb(new(cmd.Restart), "")
 	cb(new(cmd.Restore), "")
 	cb(new(cmd.Resume), "")
 	cb(new(cmd.Run), "")
+	cb(new(cmd.SharedMemLink), "")
 	cb(new(cmd.Spec), "")
 	cb(new(cmd.Start), "")
 	cb(new(cmd.State), "")
+	cb(new(cmd.Upgrade), "")
 	cb(new(cmd.Wait), "")
 
 	// Helpers.
@@ -282,7 +286,9 @@ func forEachCmd(cb func(cmd subcommands.Command, group string)) {
 	cb(new(trace.Trace), helperGroup)
 
 	const debugGroup = "debug"
+	cb(new(cmd.Config), debugGroup)
 	cb(new(cmd.Debug), debugGroup)
+	cb(new(cmd.DebugContainer), debugGroup)
 	cb(new(cmd.Statefile), debugGroup)
 	cb(new(cmd.Symbolize), debugGroup)
 	cb(new(cmd.Usage), debugGroup)
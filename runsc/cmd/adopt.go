@@ -0,0 +1,122 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/subcommands"
+	"gvisor.dev/gvisor/runsc/cmd/util"
+	"gvisor.dev/gvisor/runsc/config"
+	"gvisor.dev/gvisor/runsc/container"
+	"gvisor.dev/gvisor/runsc/flag"
+	"gvisor.dev/gvisor/runsc/specutils"
+)
+
+// Adopt implements subcommands.Command for the "adopt" command.
+type Adopt struct {
+	// bundleDir is the path to the bundle directory of the adopted container
+	// (defaults to the current working directory).
+	bundleDir string
+
+	// pidFile is the filename that the sandbox pid will be written to.
+	pidFile string
+}
+
+// Name implements subcommands.Command.Name.
+func (*Adopt) Name() string {
+	return "adopt"
+}
+
+// Synopsis implements subcommands.Command.Synopsis.
+func (*Adopt) Synopsis() string {
+	return "bind a created, blank sandbox to a new container"
+}
+
+// Usage implements subcommands.Command.Usage.
+func (*Adopt) Usage() string {
+	return `adopt [flags] <blank container id> <container id> - bind a blank sandbox to a new container.
+
+The blank sandbox must have been created with "runsc create", but not started.
+Its root container is renamed to <container id>, and its root filesystem,
+process and stdio are replaced with the ones of the bundle. The container can
+then be started with "runsc start <container id>".
+
+The bundle's annotations must not override runsc flags, since they can't be
+applied to a running sandbox.
+`
+}
+
+// SetFlags implements subcommands.Command.SetFlags.
+func (a *Adopt) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&a.bundleDir, "bundle", "", "path to the root of the bundle directory, defaults to the current directory")
+	f.StringVar(&a.pidFile, "pid-file", "", "filename that the container pid will be written to")
+}
+
+// Execute implements subcommands.Command.Execute.
+func (a *Adopt) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	if f.NArg() != 2 {
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	blankID := f.Arg(0)
+	id := f.Arg(1)
+	conf := args[0].(*config.Config)
+
+	if conf.Rootless {
+		return util.Errorf("Rootless mode not supported with %q", a.Name())
+	}
+
+	bundleDir := a.bundleDir
+	if bundleDir == "" {
+		bundleDir = getwdOrDie()
+	}
+	// The blank sandbox is already running with conf, so flag overrides from
+	// the spec's annotations can't be applied to it.
+	specConf := *conf
+	spec, err := specutils.ReadSpec(bundleDir, &specConf)
+	if err != nil {
+		return util.Errorf("reading spec: %v", err)
+	}
+	if flags, specFlags := conf.ToFlags(), specConf.ToFlags(); !sameFlags(flags, specFlags) {
+		return util.Errorf("spec annotations change runsc flags, which can't be applied to a blank sandbox: %v", specFlags)
+	}
+	specutils.LogSpecDebug(spec, conf.OCISeccomp)
+
+	c, err := container.Load(conf.RootDir, container.FullID{ContainerID: blankID}, container.LoadOpts{})
+	if err != nil {
+		return util.Errorf("loading container: %v", err)
+	}
+	contArgs := container.Args{
+		ID:        id,
+		Spec:      spec,
+		BundleDir: bundleDir,
+		PIDFile:   a.pidFile,
+	}
+	if err := c.Adopt(conf, contArgs); err != nil {
+		return util.Errorf("adopting container: %v", err)
+	}
+	return subcommands.ExitSuccess
+}
+
+// sameFlags returns true if a and b contain the same flags, in any order.
+func sameFlags(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
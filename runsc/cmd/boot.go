@@ -72,6 +72,16 @@ var (
 		capability.CAP_NET_BIND_SERVICE: "CAP_NET_BIND_SERVICE",
 		capability.CAP_NET_RAW:          "CAP_NET_RAW",
 	}
+
+	// rootlessSandboxCaps is the reduced set of capabilities that the sandbox
+	// may hold in rootless mode. Capabilities are only meaningful in the user
+	// namespace owned by the unprivileged caller, and the sandbox never gets
+	// CAP_NET_ADMIN or CAP_NET_RAW, so networking is limited to what the
+	// caller's user could do without runsc.
+	rootlessSandboxCaps = append([]string{
+		"CAP_SYS_PTRACE",
+		"CAP_NET_BIND_SERVICE",
+	}, directfsSandboxCaps...)
 )
 
 // Boot implements subcommands.Command for the "boot" command which starts a
@@ -135,6 +145,12 @@ type Boot struct {
 	// userLogFD is the file descriptor to write user logs to.
 	userLogFD int
 
+	// straceLogFD is the file descriptor to write strace output to.
+	straceLogFD int
+
+	// panicReportFD is the file descriptor to write panic reports to.
+	panicReportFD int
+
 	// startSyncFD is the file descriptor to synchronize runsc and sandbox.
 	startSyncFD int
 
@@ -147,6 +163,21 @@ type Boot struct {
 
 	podInitConfigFD int
 
+	// controlPolicyFD is the file descriptor to the control server policy
+	// passed in the --control-policy flag.
+	controlPolicyFD int
+
+	// controlTokenFD is the file descriptor to the file holding the sandbox
+	// owner's control token, required with controlPolicyFD.
+	controlTokenFD int
+
+	// hwrngFD is the file descriptor to the hardware RNG device passed in the
+	// --hwrng-device flag.
+	hwrngFD int
+
+	// vdsoFD is the file descriptor to the root container's alternate VDSO.
+	vdsoFD int
+
 	sinkFDs intFlags
 
 	saveFDs intFlags
@@ -232,9 +263,15 @@ func (b *Boot) SetFlags(f *flag.FlagSet) {
 	f.Var(&b.goferFilestoreFDs, "gofer-filestore-fds", "FDs to the regular files that will back the overlayfs or tmpfs mount if a gofer mount is to be overlaid.")
 	f.Var(&b.goferMountConfs, "gofer-mount-confs", "information about how the gofer mounts have been configured.")
 	f.IntVar(&b.userLogFD, "user-log-fd", 0, "file descriptor to write user logs to. 0 means no logging.")
+	f.IntVar(&b.straceLogFD, "strace-log-fd", 0, "file descriptor to write strace output to. 0 means the debug log.")
+	f.IntVar(&b.panicReportFD, "panic-report-fd", -1, "file descriptor to write a JSON report to when the sentry panics.")
 	f.IntVar(&b.startSyncFD, "start-sync-fd", -1, "required FD to used to synchronize sandbox startup")
 	f.IntVar(&b.mountsFD, "mounts-fd", -1, "mountsFD is an optional file descriptor to read list of mounts after they have been resolved (direct paths, no symlinks).")
 	f.IntVar(&b.podInitConfigFD, "pod-init-config-fd", -1, "file descriptor to the pod init configuration file.")
+	f.IntVar(&b.controlPolicyFD, "control-policy-fd", -1, "file descriptor to the control server policy file.")
+	f.IntVar(&b.controlTokenFD, "control-token-fd", -1, "file descriptor to the file holding the sandbox owner's control token.")
+	f.IntVar(&b.hwrngFD, "hwrng-fd", -1, "file descriptor to the hardware RNG device used with --entropy-source=hwrng.")
+	f.IntVar(&b.vdsoFD, "vdso-fd", -1, "file descriptor to the alternate VDSO image of the root container.")
 	f.Var(&b.sinkFDs, "sink-fds", "ordered list of file descriptors to be used by the sinks defined in --pod-init-config.")
 	f.Var(&b.saveFDs, "save-fds", "ordered list of file descriptors to be used save checkpoints. Order: kernel state, page metadata, page file")
 
@@ -411,6 +448,11 @@ func (b *Boot) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcomma
 				})
 			}
 		}
+		if conf.Rootless {
+			if dropped := specutils.RestrictCapabilities(caps, rootlessSandboxCaps); len(dropped) != 0 {
+				log.Infof("Rootless mode: not granting capabilities %v to the sandbox", dropped)
+			}
+		}
 		argOverride["apply-caps"] = "false"
 
 		// Remove the args that have already been done before calling self.
@@ -502,6 +544,13 @@ func (b *Boot) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcomma
 		}
 	}
 
+	// Close any FD that wasn't deliberately donated to the sandbox, and keep
+	// the inventory of the remaining ones for `runsc debug --fds`.
+	hostFDs, err := boot.AuditFDs(b.donatedFDs(f))
+	if err != nil {
+		util.Fatalf("auditing FDs: %v", err)
+	}
+
 	// Create the loader.
 	bootArgs := boot.Args{
 		ID:                  f.Arg(0),
@@ -520,13 +569,20 @@ func (b *Boot) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcomma
 		TotalMem:            b.totalMem,
 		TotalHostMem:        b.totalHostMem,
 		UserLogFD:           b.userLogFD,
+		StraceLogFD:         b.straceLogFD,
+		PanicReportFD:       b.panicReportFD,
 		ProductName:         b.productName,
 		PodInitConfigFD:     b.podInitConfigFD,
+		ControlPolicyFD:     b.controlPolicyFD,
+		ControlTokenFD:      b.controlTokenFD,
+		HWRNGFD:             b.hwrngFD,
+		VDSOFD:              b.vdsoFD,
 		SinkFDs:             b.sinkFDs.GetArray(),
 		ProfileOpts:         b.profileFDs.ToOpts(),
 		NvidiaDriverVersion: nvidiaDriverVersion,
 		HostTHP:             b.hostTHP,
 		SaveFDs:             b.saveFDs.GetFDs(),
+		HostFDs:             hostFDs,
 	}
 	l, err := boot.New(bootArgs)
 	if err != nil {
@@ -728,6 +784,29 @@ func validateOpenFDs(passFDs []boot.FDMapping) {
 	}
 }
 
+// donatedFDs returns the FDs donated to this process, mapped to the name of
+// the flag that they were donated with.
+func (b *Boot) donatedFDs(f *flag.FlagSet) map[int]string {
+	fds := make(map[int]string)
+	visit := func(fl *flag.Flag) {
+		if !strings.HasSuffix(fl.Name, "-fd") && !strings.HasSuffix(fl.Name, "-fds") {
+			return
+		}
+		for _, v := range strings.Split(fl.Value.String(), ",") {
+			if fd, err := strconv.Atoi(v); err == nil && fd >= 0 {
+				fds[fd] = fl.Name
+			}
+		}
+	}
+	// Global flags, e.g. --debug-log-fd, and the boot command's own flags.
+	flag.CommandLine.Visit(visit)
+	f.Visit(visit)
+	for _, m := range b.passFDs {
+		fds[m.Host] = "pass-fd"
+	}
+	return fds
+}
+
 // exportFinalMetrics exports all metrics to the given file.
 // The file is closed as part of this function.
 func exportFinalMetrics(f *os.File) error {
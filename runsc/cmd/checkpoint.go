@@ -36,6 +36,7 @@ type Checkpoint struct {
 	leaveRunning              bool
 	compression               CheckpointCompression
 	excludeCommittedZeroPages bool
+	pageWriters               int
 	saveRestoreExecArgv       string
 	saveRestoreExecTimeout    time.Duration
 
@@ -68,6 +69,7 @@ func (c *Checkpoint) SetFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.leaveRunning, "leave-running", false, "restart the container after checkpointing")
 	f.Var(newCheckpointCompressionValue(statefile.CompressionLevelDefault, &c.compression), "compression", "compress checkpoint image on disk. Values: none|flate-best-speed.")
 	f.BoolVar(&c.excludeCommittedZeroPages, "exclude-committed-zero-pages", false, "exclude committed zero-filled pages from checkpoint")
+	f.IntVar(&c.pageWriters, "page-writers", 0, "number of goroutines writing the checkpoint pages file, or compressing the checkpoint image if pages are saved to it, concurrently. 0 uses one per CPU available to the sandbox.")
 	f.BoolVar(&c.direct, "direct", false, "use O_DIRECT for writing checkpoint pages file")
 	f.StringVar(&c.saveRestoreExecArgv, "save-restore-exec-argv", "", "argv (split by spaces) for a save/restore binary that's automatically executed in the sandbox before saving and after restoring. If the execution fails, the save/restore process will fail.")
 	f.DurationVar(&c.saveRestoreExecTimeout, "save-restore-exec-timeout", control.DefaultSaveRestoreExecTimeout, "timeout for the binary pointed to by save-restore-exec-argv.")
@@ -108,6 +110,7 @@ func (c *Checkpoint) Execute(_ context.Context, f *flag.FlagSet, args ...any) su
 	}
 	mfOpts := pgalloc.SaveOpts{
 		ExcludeCommittedZeroPages: c.excludeCommittedZeroPages,
+		PageWriters:               c.pageWriters,
 	}
 
 	if c.leaveRunning {
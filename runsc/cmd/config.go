@@ -0,0 +1,180 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/subcommands"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/control"
+	"gvisor.dev/gvisor/pkg/sentry/watchdog"
+	"gvisor.dev/gvisor/runsc/cmd/util"
+	"gvisor.dev/gvisor/runsc/config"
+	"gvisor.dev/gvisor/runsc/container"
+	"gvisor.dev/gvisor/runsc/flag"
+)
+
+// Config implements subcommands.Command for the "config" command.
+type Config struct{}
+
+// Name implements subcommands.Command.Name.
+func (*Config) Name() string {
+	return "config"
+}
+
+// Synopsis implements subcommands.Command.Synopsis.
+func (*Config) Synopsis() string {
+	return "change debugging options of a running sandbox"
+}
+
+// Usage implements subcommands.Command.Usage.
+func (*Config) Usage() string {
+	return `config set <container id> <option>=<value>...
+
+Changes debugging options of the sandbox running the container, without
+restarting it. Options are:
+
+  log-level=warning|info|debug       sets the log verbosity.
+  log-packets=true|false             enables or disables packet logging.
+  strace=off|all|<syscall>,...       sets the system calls to trace to the log.
+  strace-tasks=all|<id>,...          restricts tracing to tasks whose thread
+                                     ID or thread group ID, in the root PID
+                                     namespace, is listed.
+  strace-sample-rate=<n>             logs only one in every n traced system
+                                     calls.
+  watchdog-action=log|panic          sets the action taken when a stuck task
+                                     is detected.
+`
+}
+
+// SetFlags implements subcommands.Command.SetFlags.
+func (*Config) SetFlags(*flag.FlagSet) {}
+
+// Execute implements subcommands.Command.Execute.
+func (*Config) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	if f.NArg() < 3 || f.Arg(0) != "set" {
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+	id := f.Arg(1)
+	conf := args[0].(*config.Config)
+
+	var largs control.LoggingArgs
+	for _, opt := range f.Args()[2:] {
+		if err := setLoggingOption(&largs, opt); err != nil {
+			util.Fatalf("%v", err)
+		}
+	}
+
+	c, err := container.Load(conf.RootDir, container.FullID{ContainerID: id}, container.LoadOpts{SkipCheck: true})
+	if err != nil {
+		util.Fatalf("loading container %q: %v", id, err)
+	}
+	if !c.IsSandboxRunning() {
+		util.Fatalf("container sandbox is not running")
+	}
+	if err := c.Sandbox.ChangeLogging(largs); err != nil {
+		util.Fatalf("%v", err)
+	}
+	return subcommands.ExitSuccess
+}
+
+// setLoggingOption parses opt, of the form <option>=<value>, into args.
+func setLoggingOption(args *control.LoggingArgs, opt string) error {
+	name, value, ok := strings.Cut(opt, "=")
+	if !ok {
+		return fmt.Errorf("invalid option %q, must be <option>=<value>", opt)
+	}
+	switch name {
+	case "log-level":
+		level, err := parseLogLevel(value)
+		if err != nil {
+			return err
+		}
+		args.SetLevel = true
+		args.Level = level
+
+	case "log-packets":
+		lp, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for log-packets %q", value)
+		}
+		args.SetLogPackets = true
+		args.LogPackets = lp
+
+	case "strace":
+		args.SetStrace = true
+		switch strings.ToLower(value) {
+		case "off":
+		case "all":
+			args.EnableStrace = true
+		default:
+			args.EnableStrace = true
+			args.StraceAllowlist = strings.Split(value, ",")
+		}
+
+	case "strace-tasks":
+		args.SetStraceTasks = true
+		args.StraceTasks = nil
+		if strings.ToLower(value) == "all" {
+			break
+		}
+		for _, s := range strings.Split(value, ",") {
+			id, err := strconv.ParseInt(s, 10, 32)
+			if err != nil || id <= 0 {
+				return fmt.Errorf("invalid task ID %q", s)
+			}
+			args.StraceTasks = append(args.StraceTasks, int32(id))
+		}
+
+	case "strace-sample-rate":
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid sample rate %q", value)
+		}
+		args.SetStraceSampleRate = true
+		args.StraceSampleRate = uint32(n)
+
+	case "watchdog-action":
+		var action watchdog.Action
+		if err := action.Set(value); err != nil {
+			return err
+		}
+		args.SetWatchdogAction = true
+		args.WatchdogAction = action
+
+	default:
+		return fmt.Errorf("unknown option %q", name)
+	}
+	return nil
+}
+
+// parseLogLevel parses a log level given by name or number.
+func parseLogLevel(value string) (log.Level, error) {
+	switch strings.ToLower(value) {
+	case "warning", "0":
+		return log.Warning, nil
+	case "info", "1":
+		return log.Info, nil
+	case "debug", "2":
+		return log.Debug, nil
+	default:
+		return 0, fmt.Errorf("invalid log level %q", value)
+	}
+}
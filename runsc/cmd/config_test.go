@@ -0,0 +1,118 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sentry/control"
+	"gvisor.dev/gvisor/pkg/sentry/watchdog"
+)
+
+func TestSetLoggingOption(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []string
+		want control.LoggingArgs
+	}{
+		{
+			name: "log level by name",
+			opts: []string{"log-level=Debug"},
+			want: control.LoggingArgs{SetLevel: true, Level: log.Debug},
+		},
+		{
+			name: "log level by number",
+			opts: []string{"log-level=1"},
+			want: control.LoggingArgs{SetLevel: true, Level: log.Info},
+		},
+		{
+			name: "log packets",
+			opts: []string{"log-packets=true"},
+			want: control.LoggingArgs{SetLogPackets: true, LogPackets: true},
+		},
+		{
+			name: "strace off",
+			opts: []string{"strace=off"},
+			want: control.LoggingArgs{SetStrace: true},
+		},
+		{
+			name: "strace all",
+			opts: []string{"strace=all"},
+			want: control.LoggingArgs{SetStrace: true, EnableStrace: true},
+		},
+		{
+			name: "strace syscalls",
+			opts: []string{"strace=read,write"},
+			want: control.LoggingArgs{SetStrace: true, EnableStrace: true, StraceAllowlist: []string{"read", "write"}},
+		},
+		{
+			name: "strace tasks",
+			opts: []string{"strace-tasks=1,42"},
+			want: control.LoggingArgs{SetStraceTasks: true, StraceTasks: []int32{1, 42}},
+		},
+		{
+			name: "strace tasks reset",
+			opts: []string{"strace-tasks=1", "strace-tasks=all"},
+			want: control.LoggingArgs{SetStraceTasks: true},
+		},
+		{
+			name: "strace sample rate",
+			opts: []string{"strace-sample-rate=10"},
+			want: control.LoggingArgs{SetStraceSampleRate: true, StraceSampleRate: 10},
+		},
+		{
+			name: "watchdog action",
+			opts: []string{"watchdog-action=panic"},
+			want: control.LoggingArgs{SetWatchdogAction: true, WatchdogAction: watchdog.Panic},
+		},
+		{
+			name: "multiple",
+			opts: []string{"log-level=warning", "strace=all"},
+			want: control.LoggingArgs{SetLevel: true, Level: log.Warning, SetStrace: true, EnableStrace: true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got control.LoggingArgs
+			for _, opt := range tc.opts {
+				if err := setLoggingOption(&got, opt); err != nil {
+					t.Fatalf("setLoggingOption(%q): %v", opt, err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("setLoggingOption(%q) mismatch (-want +got):\n%s", tc.opts, diff)
+			}
+		})
+	}
+}
+
+func TestSetLoggingOptionErrors(t *testing.T) {
+	for _, opt := range []string{
+		"log-level",
+		"log-level=verbose",
+		"log-packets=maybe",
+		"strace-tasks=0",
+		"strace-tasks=1,abc",
+		"strace-sample-rate=-1",
+		"watchdog-action=ignore",
+		"unknown=1",
+	} {
+		var args control.LoggingArgs
+		if err := setLoggingOption(&args, opt); err == nil {
+			t.Errorf("setLoggingOption(%q) succeeded, want error", opt)
+		}
+	}
+}
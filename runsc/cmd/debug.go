@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"os/signal"
 	"strconv"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/google/subcommands"
 	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/faultinject"
 	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/sentry/control"
 	"gvisor.dev/gvisor/runsc/cmd/util"
@@ -37,6 +39,7 @@ import (
 type Debug struct {
 	pid          int
 	stacks       bool
+	flightRecord bool
 	signal       int
 	profileBlock string
 	profileCPU   string
@@ -50,6 +53,10 @@ type Debug struct {
 	duration     time.Duration
 	ps           bool
 	mount        string
+	chaos        string
+	fds          bool
+	timeDilation float64
+	advanceTime  time.Duration
 }
 
 // Name implements subcommands.Command.
@@ -71,6 +78,7 @@ func (*Debug) Usage() string {
 func (d *Debug) SetFlags(f *flag.FlagSet) {
 	f.IntVar(&d.pid, "pid", 0, "sandbox process ID. Container ID is not necessary if this is set")
 	f.BoolVar(&d.stacks, "stacks", false, "if true, dumps all sandbox stacks to the log")
+	f.BoolVar(&d.flightRecord, "flight-record", false, "if true, dumps the recent events of each task recorded by --flight-recorder to the log")
 	f.StringVar(&d.profileBlock, "profile-block", "", "writes block profile to the given file.")
 	f.StringVar(&d.profileCPU, "profile-cpu", "", "writes CPU profile to the given file.")
 	f.StringVar(&d.profileHeap, "profile-heap", "", "writes heap profile to the given file.")
@@ -84,6 +92,10 @@ func (d *Debug) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&d.logPackets, "log-packets", "", "A boolean value to enable or disable packet logging: true or false.")
 	f.BoolVar(&d.ps, "ps", false, "lists processes")
 	f.StringVar(&d.mount, "mount", "", "Mount a filesystem (-mount fstype:source:destination).")
+	f.BoolVar(&d.fds, "fds", false, "lists the host FDs held by the sandbox at startup, and the unexpected ones that were closed")
+	f.StringVar(&d.chaos, "chaos", "", `Path to a JSON file with a list of fault injection rules to install, or "off" to disable fault injection.`)
+	f.Float64Var(&d.timeDilation, "time-dilation", 0, "makes the sandbox clocks advance at the given multiple of the host clocks' speed. Requires the sandbox to run with --TESTONLY-time-dilation.")
+	f.DurationVar(&d.advanceTime, "advance-time", 0, "steps the sandbox clocks forward by the given duration. Requires the sandbox to run with --TESTONLY-time-dilation.")
 }
 
 // Execute implements subcommands.Command.Execute.
@@ -158,6 +170,57 @@ func (d *Debug) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcomm
 		}
 		util.Infof("     *** Stack dump ***\n%s", stacks)
 	}
+	if d.flightRecord {
+		util.Infof("Retrieving sandbox flight record")
+		record, err := c.Sandbox.FlightRecord()
+		if err != nil {
+			return util.Errorf("retrieving flight record: %v", err)
+		}
+		util.Infof("     *** Flight record ***\n%s", record)
+	}
+	if d.fds {
+		util.Infof("Retrieving sandbox host FDs")
+		fds, err := c.Sandbox.HostFDs()
+		if err != nil {
+			return util.Errorf("retrieving host FDs: %v", err)
+		}
+		var b strings.Builder
+		for _, fd := range fds {
+			b.WriteString(fd.String())
+			b.WriteString("\n")
+		}
+		util.Infof("     *** Host FDs ***\n%s", b.String())
+	}
+	if d.chaos != "" {
+		var rules []faultinject.Rule
+		if d.chaos == "off" {
+			util.Infof("Disabling fault injection")
+		} else {
+			data, err := os.ReadFile(d.chaos)
+			if err != nil {
+				return util.Errorf("reading fault injection rules: %v", err)
+			}
+			if err := json.Unmarshal(data, &rules); err != nil {
+				return util.Errorf("parsing fault injection rules in %q: %v", d.chaos, err)
+			}
+			util.Infof("Installing %d fault injection rules", len(rules))
+		}
+		if err := c.Sandbox.SetChaosRules(rules); err != nil {
+			return util.Errorf("%v", err)
+		}
+	}
+	if d.timeDilation != 0 {
+		util.Infof("Setting time dilation to %v", d.timeDilation)
+		if err := c.Sandbox.SetTimeDilation(d.timeDilation); err != nil {
+			return util.Errorf("%v", err)
+		}
+	}
+	if d.advanceTime != 0 {
+		util.Infof("Advancing time by %v", d.advanceTime)
+		if err := c.Sandbox.AdvanceTime(d.advanceTime); err != nil {
+			return util.Errorf("%v", err)
+		}
+	}
 	if d.strace != "" || len(d.logLevel) != 0 || len(d.logPackets) != 0 {
 		args := control.LoggingArgs{}
 		switch strings.ToLower(d.strace) {
@@ -181,17 +244,12 @@ func (d *Debug) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcomm
 		}
 
 		if len(d.logLevel) != 0 {
-			args.SetLevel = true
-			switch strings.ToLower(d.logLevel) {
-			case "warning", "0":
-				args.Level = log.Warning
-			case "info", "1":
-				args.Level = log.Info
-			case "debug", "2":
-				args.Level = log.Debug
-			default:
-				return util.Errorf("invalid log level %q", d.logLevel)
+			level, err := parseLogLevel(d.logLevel)
+			if err != nil {
+				return util.Errorf("%v", err)
 			}
+			args.SetLevel = true
+			args.Level = level
 			util.Infof("Setting log level %v", args.Level)
 		}
 
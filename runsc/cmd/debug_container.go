@@ -0,0 +1,171 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/google/subcommands"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/runsc/boot"
+	"gvisor.dev/gvisor/runsc/cmd/util"
+	"gvisor.dev/gvisor/runsc/config"
+	"gvisor.dev/gvisor/runsc/console"
+	"gvisor.dev/gvisor/runsc/container"
+	"gvisor.dev/gvisor/runsc/flag"
+	"gvisor.dev/gvisor/runsc/specutils"
+)
+
+// DebugContainer implements subcommands.Command for the "debug-container"
+// command. It starts an ephemeral container in the sandbox of an existing
+// container, like Kubernetes ephemeral debug containers.
+type DebugContainer struct {
+	target string
+	rootfs string
+	id     string
+}
+
+// Name implements subcommands.Command.Name.
+func (*DebugContainer) Name() string {
+	return "debug-container"
+}
+
+// Synopsis implements subcommands.Command.Synopsis.
+func (*DebugContainer) Synopsis() string {
+	return "run an ephemeral debug container next to an existing container"
+}
+
+// Usage implements subcommands.Command.Usage.
+func (*DebugContainer) Usage() string {
+	return `debug-container --target=<container id> --rootfs=<path> <command> [args...]
+
+Starts <command> in a new container inside the sandbox of the target
+container. The new container uses <path> as its root filesystem, which
+typically holds the debugging tools, and shares the PID and network
+namespaces of the target container so that its processes can be inspected.
+The debug container is destroyed once <command> exits.
+
+OPTIONS:
+`
+}
+
+// SetFlags implements subcommands.Command.SetFlags.
+func (d *DebugContainer) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&d.target, "target", "", "ID of the container to debug")
+	f.StringVar(&d.rootfs, "rootfs", "", "path to the root filesystem of the debug container")
+	f.StringVar(&d.id, "id", "", "ID of the debug container, defaults to a random ID derived from the target's")
+}
+
+// Execute implements subcommands.Command.Execute.
+func (d *DebugContainer) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	if f.NArg() == 0 || d.target == "" || d.rootfs == "" {
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	conf := args[0].(*config.Config)
+	waitStatus := args[1].(*unix.WaitStatus)
+
+	target, err := container.Load(conf.RootDir, container.FullID{ContainerID: d.target}, container.LoadOpts{})
+	if err != nil {
+		return util.Errorf("loading container: %v", err)
+	}
+	if target.Status != container.Running {
+		return util.Errorf("container %q is not running: %v", target.ID, target.Status)
+	}
+	rootfs, err := resolvePath(d.rootfs)
+	if err != nil {
+		return util.Errorf("resolving rootfs: %v", err)
+	}
+	id := d.id
+	if id == "" {
+		id = fmt.Sprintf("%s-debug-%06d", target.ID, rand.Int31n(1000000))
+	}
+
+	// If stdio is a terminal, it becomes the debug container's TTY.
+	spec := debugContainerSpec(target, rootfs, f.Args(), console.StdioIsPty())
+	specutils.LogSpecDebug(spec, conf.OCISeccomp)
+
+	// The gofer reads the spec from the bundle directory.
+	bundleDir, err := os.MkdirTemp("", "runsc-debug-container")
+	if err != nil {
+		return util.Errorf("creating bundle directory: %v", err)
+	}
+	defer os.RemoveAll(bundleDir)
+	out, err := json.Marshal(spec)
+	if err != nil {
+		return util.Errorf("marshaling spec: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(bundleDir, "config.json"), out, 0644); err != nil {
+		return util.Errorf("writing spec: %v", err)
+	}
+
+	cont, err := container.New(conf, container.Args{
+		ID:         id,
+		Spec:       spec,
+		BundleDir:  bundleDir,
+		StdioFiles: []*os.File{os.Stdin, os.Stdout, os.Stderr},
+	})
+	if err != nil {
+		return util.Errorf("creating debug container: %v", err)
+	}
+	defer cont.Destroy()
+
+	if err := cont.Start(conf); err != nil {
+		return util.Errorf("starting debug container: %v", err)
+	}
+	stopForwarding := cont.ForwardSignals(0 /* pid */, spec.Process.Terminal /* fgProcess */)
+	defer stopForwarding()
+
+	ws, err := cont.Wait()
+	if err != nil {
+		return util.Errorf("waiting for debug container: %v", err)
+	}
+	*waitStatus = ws
+	return subcommands.ExitSuccess
+}
+
+// debugContainerSpec returns the spec of a debug container for target, which
+// runs args in rootfs. The debug container runs as the same user, with the
+// same capabilities and environment, as target's init process. terminal must
+// be true iff the debug container's stdio is a TTY.
+func debugContainerSpec(target *container.Container, rootfs string, args []string, terminal bool) *specs.Spec {
+	return &specs.Spec{
+		Version: specs.Version,
+		Root: &specs.Root{
+			Path: rootfs,
+		},
+		Process: &specs.Process{
+			Args:         args,
+			Env:          target.Spec.Process.Env,
+			Cwd:          "/",
+			User:         target.Spec.Process.User,
+			Capabilities: target.Spec.Process.Capabilities,
+			Terminal:     terminal,
+		},
+		Hostname: target.Spec.Hostname,
+		Annotations: map[string]string{
+			specutils.ContainerdContainerTypeAnnotation: specutils.ContainerdContainerTypeContainer,
+			specutils.ContainerdSandboxIDAnnotation:     target.Sandbox.ID,
+			boot.PIDNamespaceContainerAnnotation:        target.ID,
+		},
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gvisor.dev/gvisor/runsc/boot"
+	"gvisor.dev/gvisor/runsc/container"
+	"gvisor.dev/gvisor/runsc/sandbox"
+	"gvisor.dev/gvisor/runsc/specutils"
+)
+
+func TestDebugContainerSpec(t *testing.T) {
+	target := &container.Container{
+		ID: "target",
+		Spec: &specs.Spec{
+			Process: &specs.Process{
+				Env:  []string{"PATH=/bin"},
+				User: specs.User{UID: 1000, GID: 1000, AdditionalGids: []uint32{10}},
+				Capabilities: &specs.LinuxCapabilities{
+					Effective: []string{"CAP_KILL"},
+				},
+			},
+			Hostname: "target-host",
+		},
+		Sandbox: &sandbox.Sandbox{ID: "sandbox"},
+	}
+	args := []string{"sh", "-c", "ps"}
+	for _, terminal := range []bool{false, true} {
+		spec := debugContainerSpec(target, "/debug-rootfs", args, terminal)
+		if spec.Process.Terminal != terminal {
+			t.Errorf("terminal %t: got Process.Terminal %t", terminal, spec.Process.Terminal)
+		}
+		if diff := cmp.Diff(target.Spec.Process.User, spec.Process.User); diff != "" {
+			t.Errorf("terminal %t: Process.User mismatch (-want +got):\n%s", terminal, diff)
+		}
+		if diff := cmp.Diff(target.Spec.Process.Capabilities, spec.Process.Capabilities); diff != "" {
+			t.Errorf("terminal %t: Process.Capabilities mismatch (-want +got):\n%s", terminal, diff)
+		}
+		if diff := cmp.Diff(args, spec.Process.Args); diff != "" {
+			t.Errorf("terminal %t: Process.Args mismatch (-want +got):\n%s", terminal, diff)
+		}
+		if got, want := spec.Root.Path, "/debug-rootfs"; got != want {
+			t.Errorf("terminal %t: got Root.Path %q, want %q", terminal, got, want)
+		}
+		if got, want := spec.Hostname, target.Spec.Hostname; got != want {
+			t.Errorf("terminal %t: got Hostname %q, want %q", terminal, got, want)
+		}
+		wantAnnotations := map[string]string{
+			specutils.ContainerdContainerTypeAnnotation: specutils.ContainerdContainerTypeContainer,
+			specutils.ContainerdSandboxIDAnnotation:     "sandbox",
+			boot.PIDNamespaceContainerAnnotation:        "target",
+		}
+		if diff := cmp.Diff(wantAnnotations, spec.Annotations); diff != "" {
+			t.Errorf("terminal %t: Annotations mismatch (-want +got):\n%s", terminal, diff)
+		}
+	}
+}
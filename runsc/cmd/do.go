@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"net"
 	"os"
@@ -31,6 +32,7 @@ import (
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/sys/unix"
 	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/runsc/boot"
 	"gvisor.dev/gvisor/runsc/cmd/util"
 	"gvisor.dev/gvisor/runsc/config"
 	"gvisor.dev/gvisor/runsc/console"
@@ -53,6 +55,7 @@ type Do struct {
 	uidMap  idMapSlice
 	gidMap  idMapSlice
 	volumes volumes
+	ports   portMappings
 }
 
 // Name implements subcommands.Command.Name.
@@ -74,6 +77,10 @@ with a writable tmpfs overlay on top of it. The given command is executed inside
 the sandbox. It's to be used to quickly test applications without having to
 install or run docker. It doesn't give nearly as many options and it's to be
 used for testing only.
+
+Volumes and ports can be specified with docker-like shorthands:
+
+	# runsc do -v /tmp/data:/data:ro -p 8080:80 python3 -m http.server 80
 `
 }
 
@@ -139,6 +146,94 @@ func (v *volumes) String() string {
 	return strings.Join(*v, ",")
 }
 
+// toMounts converts the volumes to bind mounts. Each volume has the form
+// SRC[:DST[:ro|rw]], where DST defaults to SRC. As before modes were
+// supported, everything after the first colon is the destination unless it
+// ends with a mode, so destinations may contain colons.
+func (v *volumes) toMounts() ([]specs.Mount, error) {
+	var mounts []specs.Mount
+	for _, vol := range *v {
+		parts := strings.SplitN(vol, ":", 2)
+		m := specs.Mount{
+			Type:        "bind",
+			Source:      parts[0],
+			Destination: parts[0],
+		}
+		if len(parts) == 2 {
+			m.Destination = parts[1]
+			for _, mode := range []string{"ro", "rw"} {
+				if dst, ok := strings.CutSuffix(parts[1], ":"+mode); ok {
+					m.Destination = dst
+					m.Options = []string{mode}
+					break
+				}
+			}
+		}
+		if m.Source == "" || !filepath.IsAbs(m.Destination) {
+			return nil, fmt.Errorf("invalid volume %q: source must be set and destination must be absolute", vol)
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts, nil
+}
+
+// portMapping forwards a port on the host to a port in the container.
+type portMapping struct {
+	hostPort      int
+	containerPort uint16
+}
+
+type portMappings []portMapping
+
+// Set implements flag.Value.Set. The value has the form [HOST:]CONTAINER.
+func (p *portMappings) Set(value string) error {
+	hostStr, containerStr, found := strings.Cut(value, ":")
+	if !found {
+		containerStr = hostStr
+	}
+	hostPort, err := strconv.Atoi(hostStr)
+	if err != nil || hostPort <= 0 || hostPort > math.MaxUint16 {
+		return fmt.Errorf("invalid host port in %q", value)
+	}
+	containerPort, err := strconv.Atoi(containerStr)
+	if err != nil || containerPort <= 0 || containerPort > math.MaxUint16 {
+		return fmt.Errorf("invalid container port in %q", value)
+	}
+	*p = append(*p, portMapping{hostPort: hostPort, containerPort: uint16(containerPort)})
+	return nil
+}
+
+// Get implements flag.Value.Get.
+func (p *portMappings) Get() any {
+	return p
+}
+
+// String implements flag.Value.String.
+func (p *portMappings) String() string {
+	ports := make([]string, 0, len(*p))
+	for _, m := range *p {
+		ports = append(ports, fmt.Sprintf("%d:%d", m.hostPort, m.containerPort))
+	}
+	return strings.Join(ports, ",")
+}
+
+// listen binds the host ports of the mappings for TCP, in order. If any of
+// them can't be bound, the listeners already created are closed.
+func (p portMappings) listen() ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(p))
+	for _, m := range p {
+		l, err := net.Listen("tcp", ":"+strconv.Itoa(m.hostPort))
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("binding host port %d: %w", m.hostPort, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
 // SetFlags implements subcommands.Command.SetFlags.
 func (c *Do) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.root, "root", "/", `path to the root directory, defaults to "/"`)
@@ -149,7 +244,10 @@ func (c *Do) SetFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.overlay, "force-overlay", true, "use an overlay. WARNING: disabling gives the command write access to the host")
 	f.Var(&c.uidMap, "uid-map", "Add a user id mapping [ContainerID, HostID, Size]")
 	f.Var(&c.gidMap, "gid-map", "Add a group id mapping [ContainerID, HostID, Size]")
-	f.Var(&c.volumes, "volume", "Add a volume path SRC[:DST]. This option can be used multiple times to add several volumes.")
+	f.Var(&c.volumes, "volume", "Add a volume path SRC[:DST[:ro|rw]]. This option can be used multiple times to add several volumes.")
+	f.Var(&c.volumes, "v", "Shorthand for -volume.")
+	f.Var(&c.ports, "publish", "Forward a host port to a container port [HOST:]CONTAINER. This option can be used multiple times to forward several ports.")
+	f.Var(&c.ports, "p", "Shorthand for -publish.")
 }
 
 // Execute implements subcommands.Command.Execute.
@@ -210,20 +308,11 @@ func (c *Do) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcommand
 			"dev.gvisor.spec.rootfs.overlay": "memory",
 		}
 	}
-	for _, v := range c.volumes {
-		parts := strings.SplitN(v, ":", 2)
-		var src, dst string
-		if len(parts) == 2 {
-			src, dst = parts[0], parts[1]
-		} else {
-			src, dst = v, v
-		}
-		spec.Mounts = append(spec.Mounts, specs.Mount{
-			Type:        "bind",
-			Source:      src,
-			Destination: dst,
-		})
+	mounts, err := c.volumes.toMounts()
+	if err != nil {
+		return util.Errorf("Error parsing volumes: %v", err)
 	}
+	spec.Mounts = append(spec.Mounts, mounts...)
 
 	cid := fmt.Sprintf("runsc-%06d", rand.Int31n(1000000))
 
@@ -234,6 +323,9 @@ func (c *Do) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcommand
 	}
 
 	if conf.Network == config.NetworkNone {
+		if len(c.ports) > 0 {
+			return util.Errorf("Port forwarding requires networking, but --network=none is set")
+		}
 		addNamespace(spec, specs.LinuxNamespace{Type: specs.NetworkNamespace})
 	} else if conf.Rootless {
 		if conf.Network == config.NetworkSandbox {
@@ -257,7 +349,7 @@ func (c *Do) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcommand
 		}
 	}
 
-	return startContainerAndWait(spec, conf, cid, waitStatus)
+	return startContainerAndWait(spec, conf, cid, c.ports, waitStatus)
 }
 
 func addNamespace(spec *specs.Spec, ns specs.LinuxNamespace) {
@@ -468,7 +560,7 @@ func calculatePeerIP(ip string) (string, error) {
 	return fmt.Sprintf("%s.%s.%s.%d", parts[0], parts[1], parts[2], n), nil
 }
 
-func startContainerAndWait(spec *specs.Spec, conf *config.Config, cid string, waitStatus *unix.WaitStatus) subcommands.ExitStatus {
+func startContainerAndWait(spec *specs.Spec, conf *config.Config, cid string, ports portMappings, waitStatus *unix.WaitStatus) subcommands.ExitStatus {
 	specutils.LogSpecDebug(spec, conf.OCISeccomp)
 
 	out, err := json.Marshal(spec)
@@ -496,6 +588,18 @@ func startContainerAndWait(spec *specs.Spec, conf *config.Config, cid string, wa
 		Attached:  true,
 	}
 
+	// Bind the forwarded host ports before starting the container, so that
+	// the command fails rather than running the container without them.
+	listeners, err := ports.listen()
+	if err != nil {
+		return util.Errorf("forwarding ports: %v", err)
+	}
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+
 	ct, err := container.New(conf, containerArgs)
 	if err != nil {
 		return util.Errorf("creating container: %v", err)
@@ -514,6 +618,20 @@ func startContainerAndWait(spec *specs.Spec, conf *config.Config, cid string, wa
 	stopForwarding := ct.ForwardSignals(0 /* pid */, spec.Process.Terminal /* fgProcess */)
 	defer stopForwarding()
 
+	// Forward the requested host ports using the same machinery as the
+	// "port-forward" command. Forwarding stops once the container exits.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i, p := range ports {
+		go func(p portMapping, l net.Listener) {
+			log.Infof("Forwarding host port %d to container port %d", p.hostPort, p.containerPort)
+			opts := boot.PortForwardOpts{ContainerID: ct.ID, Port: p.containerPort}
+			if err := forwardListener(ctx, ct, l, opts); err != nil && ctx.Err() == nil {
+				log.Warningf("port forwarding %d:%d: %v", p.hostPort, p.containerPort, err)
+			}
+		}(p, listeners[i])
+	}
+
 	ws, err := ct.Wait()
 	if err != nil {
 		return util.Errorf("waiting for container: %v", err)
@@ -0,0 +1,179 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestPortMappings(t *testing.T) {
+	for _, tc := range []struct {
+		value   string
+		want    portMapping
+		wantErr bool
+	}{
+		{value: "8080:80", want: portMapping{hostPort: 8080, containerPort: 80}},
+		{value: "53", want: portMapping{hostPort: 53, containerPort: 53}},
+		{value: "65535:1", want: portMapping{hostPort: 65535, containerPort: 1}},
+		{value: "0:80", wantErr: true},
+		{value: "8080:65536", wantErr: true},
+		{value: "a:80", wantErr: true},
+		{value: "8080:", wantErr: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			var p portMappings
+			err := p.Set(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Set(%q) succeeded, want error", tc.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set(%q): %v", tc.value, err)
+			}
+			if len(p) != 1 || p[0] != tc.want {
+				t.Errorf("Set(%q) got %+v, want %+v", tc.value, p, tc.want)
+			}
+		})
+	}
+}
+
+func TestVolumesToMounts(t *testing.T) {
+	for _, tc := range []struct {
+		value   string
+		want    specs.Mount
+		wantErr bool
+	}{
+		{
+			value: "/data",
+			want:  specs.Mount{Type: "bind", Source: "/data", Destination: "/data"},
+		},
+		{
+			value: "/tmp/data:/data",
+			want:  specs.Mount{Type: "bind", Source: "/tmp/data", Destination: "/data"},
+		},
+		{
+			value: "/tmp/data:/data:ro",
+			want:  specs.Mount{Type: "bind", Source: "/tmp/data", Destination: "/data", Options: []string{"ro"}},
+		},
+		{
+			value: "/tmp/data:/data:rw",
+			want:  specs.Mount{Type: "bind", Source: "/tmp/data", Destination: "/data", Options: []string{"rw"}},
+		},
+		{
+			value: "/tmp/data:/data:foo",
+			want:  specs.Mount{Type: "bind", Source: "/tmp/data", Destination: "/data:foo"},
+		},
+		{
+			value: "/tmp/data:/da:ta",
+			want:  specs.Mount{Type: "bind", Source: "/tmp/data", Destination: "/da:ta"},
+		},
+		{
+			value: "/tmp/data:/da:ta:ro",
+			want:  specs.Mount{Type: "bind", Source: "/tmp/data", Destination: "/da:ta", Options: []string{"ro"}},
+		},
+		{value: "/tmp/data:data", wantErr: true},
+		{value: "/tmp/data:data:ro", wantErr: true},
+		{value: ":/data", wantErr: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			v := volumes{tc.value}
+			mounts, err := v.toMounts()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("toMounts(%q) succeeded, want error", tc.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("toMounts(%q): %v", tc.value, err)
+			}
+			if diff := cmp.Diff([]specs.Mount{tc.want}, mounts); diff != "" {
+				t.Errorf("toMounts(%q) mismatch (-want +got):\n%s", tc.value, diff)
+			}
+		})
+	}
+}
+
+func TestPortMappingsListen(t *testing.T) {
+	busy, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	defer busy.Close()
+	busyPort := busy.Addr().(*net.TCPAddr).Port
+	free, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	freePort := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+
+	for _, tc := range []struct {
+		name    string
+		ports   portMappings
+		wantErr bool
+	}{
+		{
+			name:  "free",
+			ports: portMappings{{hostPort: freePort, containerPort: 80}},
+		},
+		{
+			name:    "busy",
+			ports:   portMappings{{hostPort: busyPort, containerPort: 80}},
+			wantErr: true,
+		},
+		{
+			name: "free then busy",
+			ports: portMappings{
+				{hostPort: freePort, containerPort: 80},
+				{hostPort: busyPort, containerPort: 81},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			listeners, err := tc.ports.listen()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("listen(%v) succeeded, want error", &tc.ports)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("listen(%v): %v", &tc.ports, err)
+				}
+				if len(listeners) != len(tc.ports) {
+					t.Errorf("listen(%v) got %d listeners, want %d", &tc.ports, len(listeners), len(tc.ports))
+				}
+				for _, l := range listeners {
+					l.Close()
+				}
+			}
+			// No port must remain bound after the listeners are closed,
+			// including on error.
+			l, err := net.Listen("tcp", ":"+strconv.Itoa(freePort))
+			if err != nil {
+				t.Fatalf("host port %d still bound: %v", freePort, err)
+			}
+			l.Close()
+		})
+	}
+}
@@ -125,6 +125,9 @@ func (ex *Exec) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcomm
 	if err != nil {
 		util.Fatalf("loading sandbox: %v", err)
 	}
+	if c, err = container.RestoreIdle(c); err != nil {
+		util.Fatalf("restoring idle sandbox: %v", err)
+	}
 
 	e, err := ex.parseArgs(f, c.Spec.Process, conf.EnableRaw)
 	if err != nil {
@@ -375,6 +378,7 @@ func (ex *Exec) argsFromCLI(p *specs.Process, argv []string, enableRaw bool) (*c
 		KGID:             kgid,
 		ExtraKGIDs:       extraKGIDs,
 		Capabilities:     caps,
+		NoNewPrivs:       p.NoNewPrivileges,
 		StdioIsPty:       ex.consoleSocket != "" || console.StdioIsPty(),
 	}, nil
 }
@@ -441,6 +445,7 @@ func argsFromProcess(specProc *specs.Process, p *specs.Process, enableRaw bool)
 		KGID:             auth.KGID(p.User.GID),
 		ExtraKGIDs:       extraKGIDs,
 		Capabilities:     caps,
+		NoNewPrivs:       p.NoNewPrivileges,
 		StdioIsPty:       p.Terminal,
 	}, nil
 }
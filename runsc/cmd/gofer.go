@@ -106,6 +106,7 @@ type Gofer struct {
 	specFD           int
 	mountsFD         int
 	goferToHostRPCFD int
+	readCacheFD      int
 	profileFDs       profile.FDArgs
 	syncFDs          goferSyncFDs
 	stopProfiling    func()
@@ -139,6 +140,7 @@ func (g *Gofer) SetFlags(f *flag.FlagSet) {
 	f.IntVar(&g.specFD, "spec-fd", -1, "required fd with the container spec")
 	f.IntVar(&g.mountsFD, "mounts-fd", -1, "mountsFD is the file descriptor to write list of mounts after they have been resolved (direct paths, no symlinks).")
 	f.IntVar(&g.goferToHostRPCFD, "rpc-fd", -1, "gofer-to-host RPC file descriptor.")
+	f.IntVar(&g.readCacheFD, "read-cache-fd", -1, "optional FD of the sandbox's trust domain directory in --gofer-read-cache.")
 
 	// Add synchronization FD flags.
 	g.syncFDs.setFlags(f)
@@ -274,6 +276,14 @@ func (g *Gofer) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcomm
 	egid := unix.Getegid()
 	log.Debugf("Process running as uid=%d euid=%d gid=%d egid=%d", ruid, euid, rgid, egid)
 
+	var readCache *fsgofer.ReadCache
+	if g.readCacheFD >= 0 {
+		readCache, err = fsgofer.NewReadCache(g.readCacheFD, conf.GoferReadCacheSize)
+		if err != nil {
+			util.Fatalf("Failed to set up read cache: %v", err)
+		}
+	}
+
 	// Initialize filters.
 	opts := filter.Options{
 		UDSOpenEnabled:   conf.GetHostUDS().AllowOpen(),
@@ -281,12 +291,13 @@ func (g *Gofer) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcomm
 		ProfileEnabled:   len(profileOpts) > 0,
 		DirectFS:         conf.DirectFS,
 		CgoEnabled:       config.CgoEnabled,
+		HostLocks:        conf.HostLocks,
 	}
 	if err := filter.Install(opts); err != nil {
 		util.Fatalf("installing seccomp filters: %v", err)
 	}
 
-	return g.serve(spec, conf, root, ruid, euid, rgid, egid)
+	return g.serve(spec, conf, root, readCache, ruid, euid, rgid, egid)
 }
 
 func newSocket(ioFD int) *unet.Socket {
@@ -297,7 +308,7 @@ func newSocket(ioFD int) *unet.Socket {
 	return socket
 }
 
-func (g *Gofer) serve(spec *specs.Spec, conf *config.Config, root string, ruid int, euid int, rgid int, egid int) subcommands.ExitStatus {
+func (g *Gofer) serve(spec *specs.Spec, conf *config.Config, root string, readCache *fsgofer.ReadCache, ruid int, euid int, rgid int, egid int) subcommands.ExitStatus {
 	type connectionConfig struct {
 		sock      *unet.Socket
 		mountPath string
@@ -310,10 +321,12 @@ func (g *Gofer) serve(spec *specs.Spec, conf *config.Config, root string, ruid i
 		HostUDS:            conf.GetHostUDS(),
 		HostFifo:           conf.HostFifo,
 		DonateMountPointFD: conf.DirectFS,
+		HostLocks:          conf.HostLocks,
 		RUID:               ruid,
 		EUID:               euid,
 		RGID:               rgid,
 		EGID:               egid,
+		ReadCache:          readCache,
 	})
 
 	ioFDs := g.ioFDs
@@ -654,9 +667,11 @@ func (g *Gofer) setupDev(spec *specs.Spec, conf *config.Config, root, procPath s
 	}
 	nvproxyEnabled := specutils.NVProxyEnabled(spec, conf)
 	tpuproxyEnabled := specutils.TPUProxyIsEnabled(spec, conf)
+	sriovEnabled := conf.Network == config.NetworkSRIOV
 	for _, dev := range spec.Linux.Devices {
 		shouldMount := (nvproxyEnabled && shouldExposeNvidiaDevice(dev.Path)) ||
-			(tpuproxyEnabled && shouldExposeTpuDevice(dev.Path))
+			(tpuproxyEnabled && shouldExposeTpuDevice(dev.Path)) ||
+			(sriovEnabled && shouldExposeVFIODevice(dev.Path))
 		if !shouldMount {
 			continue
 		}
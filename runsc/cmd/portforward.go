@@ -22,10 +22,12 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/google/subcommands"
 	"gvisor.dev/gvisor/pkg/log"
@@ -39,8 +41,8 @@ import (
 
 // PortForward implements subcommands.Command for the "portforward" command.
 type PortForward struct {
-	portNum int
-	stream  string
+	stream   string
+	protocol string
 }
 
 // Name implements subcommands.Command.Name.
@@ -55,12 +57,16 @@ func (*PortForward) Synopsis() string {
 
 // Usage implements subcommands.Command.Usage.
 func (*PortForward) Usage() string {
-	return `port-forward CONTAINER_ID [LOCAL_PORT:]REMOTE_PORT - port forward to gvisor container.
+	return `port-forward CONTAINER_ID [LOCAL_PORT:]REMOTE - port forward to gvisor container.
 
 Port forwarding has two modes. Local mode opens a local port and forwards
 connections to another port inside the specified container. Stream mode
 forwards a single connection on a UDS to the specified port in the container.
 
+REMOTE is a port number for the "tcp" and "udp" protocols, and the absolute
+path of a listening SOCK_STREAM unix domain socket inside the container for
+the "unix" protocol.
+
 EXAMPLES:
 
 The following will forward connections on local port 8080 to port 80 in the
@@ -73,6 +79,19 @@ The following will forward a single new connection on the unix domain socket at
 
 	# runsc port-forward --stream /tmp/pipe nginx 80
 
+The following will forward UDP datagrams on local port 5353 to port 53 in the
+container named 'dns':
+
+	# runsc port-forward --protocol=udp dns 5353:53
+
+The following will forward connections on local port 9000 to the unix domain
+socket at /run/app.sock in the container named 'app':
+
+	# runsc port-forward --protocol=unix app 9000:/run/app.sock
+
+In stream mode with the "udp" protocol, the UDS must be a SOCK_SEQPACKET
+socket; each message is forwarded as a single datagram.
+
 OPTIONS:
 `
 }
@@ -80,6 +99,7 @@ OPTIONS:
 // SetFlags implements subcommands.Command.SetFlags.
 func (p *PortForward) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&p.stream, "stream", "", "Stream mode - a Unix domain socket")
+	f.StringVar(&p.protocol, "protocol", boot.PortForwardTCP, "protocol to forward: tcp, udp or unix")
 }
 
 // Execute implements subcommands.Command.Execute.
@@ -107,36 +127,38 @@ func (p *PortForward) Execute(ctx context.Context, f *flag.FlagSet, args ...any)
 	}
 
 	// Allow forwarding to a local port.
-	ports := strings.Split(portStr, ":")
-	if len(ports) != 2 {
+	local, remote, ok := strings.Cut(portStr, ":")
+	if !ok {
 		util.Fatalf("invalid port string %q", portStr)
 	}
 
-	localPort, err := strconv.Atoi(ports[0])
+	localPort, err := strconv.Atoi(local)
 	if err != nil {
 		util.Fatalf("invalid port string %q: %v", portStr, err)
 	}
-	portNum, err := strconv.Atoi(ports[1])
+	opts, err := p.remoteOpts(remote)
 	if err != nil {
 		util.Fatalf("invalid port string %q: %v", portStr, err)
 	}
-	if portNum <= 0 || portNum > math.MaxUint16 {
-		util.Fatalf("invalid port %d: %v", portNum, err)
-	}
+	opts.ContainerID = c.ID
 
 	// Start port forwarding with the local port.
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(ctx)
 	wg.Add(1)
-	go func(localPort, portNum int) {
+	go func(localPort int) {
 		defer cancel()
 		defer wg.Done()
 		// Print message to local user.
-		fmt.Printf("Forwarding local port %d to %d...\n", localPort, portNum)
-		if err := localForward(ctx, c, localPort, uint16(portNum)); err != nil {
+		fmt.Printf("Forwarding local %s port %d to %s...\n", p.localNetwork(), localPort, remote)
+		forward := localForward
+		if p.protocol == boot.PortForwardUDP {
+			forward = localForwardUDP
+		}
+		if err := forward(ctx, c, localPort, opts); err != nil {
 			log.Warningf("port forwarding: %v", err)
 		}
-	}(localPort, portNum)
+	}(localPort)
 
 	// Exit port forwarding if the container exits.
 	go func() {
@@ -160,12 +182,49 @@ func (p *PortForward) Execute(ctx context.Context, f *flag.FlagSet, args ...any)
 	return subcommands.ExitSuccess
 }
 
-// localForward starts port forwarding from the given local port.
-func localForward(ctx context.Context, c *container.Container, localPort int, containerPort uint16) error {
+// remoteOpts returns the port forwarding options for the given remote port or
+// unix socket path.
+func (p *PortForward) remoteOpts(remote string) (boot.PortForwardOpts, error) {
+	switch p.protocol {
+	case boot.PortForwardTCP, boot.PortForwardUDP:
+		portNum, err := strconv.Atoi(remote)
+		if err != nil {
+			return boot.PortForwardOpts{}, err
+		}
+		if portNum <= 0 || portNum > math.MaxUint16 {
+			return boot.PortForwardOpts{}, fmt.Errorf("invalid port %d", portNum)
+		}
+		return boot.PortForwardOpts{Port: uint16(portNum), Protocol: p.protocol}, nil
+	case boot.PortForwardUnix:
+		if !filepath.IsAbs(remote) {
+			return boot.PortForwardOpts{}, fmt.Errorf("unix socket path %q must be absolute", remote)
+		}
+		return boot.PortForwardOpts{Path: remote, Protocol: p.protocol}, nil
+	default:
+		return boot.PortForwardOpts{}, fmt.Errorf("unsupported protocol %q", p.protocol)
+	}
+}
+
+// localNetwork returns the network of the local listener.
+func (p *PortForward) localNetwork() string {
+	if p.protocol == boot.PortForwardUDP {
+		return "udp"
+	}
+	return "tcp"
+}
+
+// localForward starts port forwarding from the given local TCP port.
+func localForward(ctx context.Context, c *container.Container, localPort int, opts boot.PortForwardOpts) error {
 	l, err := net.Listen("tcp", ":"+strconv.Itoa(localPort))
 	if err != nil {
 		return err
 	}
+	return forwardListener(ctx, c, l, opts)
+}
+
+// forwardListener forwards the connections accepted by l until ctx is done,
+// and closes l.
+func forwardListener(ctx context.Context, c *container.Container, l net.Listener, opts boot.PortForwardOpts) error {
 	defer l.Close()
 
 	var localConnChan = make(chan net.Conn, 1)
@@ -198,7 +257,7 @@ func localForward(ctx context.Context, c *container.Container, localPort int, co
 			go func() {
 				defer localConn.Close()
 				fmt.Println("Forwarding new connection...")
-				err := portCopy(ctx, c, localConn, containerPort)
+				err := portCopy(ctx, c, localConn, opts)
 				if err != nil {
 					log.Warningf("port forwarding: %v", err)
 				}
@@ -208,29 +267,157 @@ func localForward(ctx context.Context, c *container.Container, localPort int, co
 	}
 }
 
-// doStream does the stream version of the port-forward command.
-func (p *PortForward) doStream(ctx context.Context, port string, c *container.Container) error {
-	var err error
-	p.portNum, err = strconv.Atoi(port)
+const (
+	// maxUDPSessions is the maximum number of concurrent UDP forwarding
+	// sessions. Datagrams from new local peers are dropped beyond it.
+	maxUDPSessions = 1024
+
+	// udpSessionTimeout is how long a UDP forwarding session is kept
+	// without datagrams in either direction.
+	udpSessionTimeout = 2 * time.Minute
+)
+
+// localForwardUDP starts port forwarding from the given local UDP port. Each
+// local peer address gets its own forwarding session, carried over a
+// SOCK_SEQPACKET socket pair so that datagram boundaries are preserved.
+func localForwardUDP(ctx context.Context, c *container.Container, localPort int, opts boot.PortForwardOpts) error {
+	pc, err := net.ListenPacket("udp", ":"+strconv.Itoa(localPort))
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		pc.Close()
+	}()
+
+	var mu sync.Mutex
+	sessions := make(map[string]*os.File)
+	// endSession removes the session of the peer with address key if it is
+	// s, and closes s.
+	endSession := func(key string, s *os.File) {
+		mu.Lock()
+		if sessions[key] == s {
+			delete(sessions, key)
+		}
+		mu.Unlock()
+		s.Close()
+	}
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, s := range sessions {
+			s.Close()
+		}
+	}()
+
+	buf := make([]byte, math.MaxUint16)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		key := addr.String()
+		mu.Lock()
+		s, ok := sessions[key]
+		if !ok {
+			if len(sessions) >= maxUDPSessions {
+				mu.Unlock()
+				log.Warningf("port forwarding from %v: too many UDP sessions, dropping datagram", addr)
+				continue
+			}
+			s, err = udpSession(c, pc, addr, opts, func(s *os.File) {
+				endSession(key, s)
+			})
+			if err != nil {
+				mu.Unlock()
+				log.Warningf("port forwarding from %v: %v", addr, err)
+				continue
+			}
+			sessions[key] = s
+		}
+		mu.Unlock()
+		if _, err := s.Write(buf[:n]); err != nil {
+			log.Warningf("port forwarding from %v: %v", addr, err)
+			endSession(key, s)
+			continue
+		}
+		s.SetReadDeadline(time.Now().Add(udpSessionTimeout))
+	}
+}
+
+// udpSession starts forwarding datagrams between the local peer at addr and
+// the container. Replies from the container are sent back to addr through pc.
+// The session ends when the container closes it, or when no datagrams are
+// forwarded in either direction for udpSessionTimeout; done is then called with
+// the returned file, and must close it.
+func udpSession(c *container.Container, pc net.PacketConn, addr net.Addr, opts boot.PortForwardOpts, done func(*os.File)) (*os.File, error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
 	if err != nil {
-		return fmt.Errorf("invalid port string %q: %v", port, err)
+		return nil, err
 	}
+	remote := os.NewFile(uintptr(fds[1]), "udp-forward-remote")
+	defer remote.Close()
+	// The local end is non-blocking so that read deadlines can be used to
+	// expire idle sessions.
+	if err := syscall.SetNonblock(fds[0], true); err != nil {
+		syscall.Close(fds[0])
+		return nil, err
+	}
+	local := os.NewFile(uintptr(fds[0]), "udp-forward-local")
+
+	opts.FilePayload = urpc.FilePayload{Files: []*os.File{remote}}
+	if err := c.PortForward(&opts); err != nil {
+		local.Close()
+		return nil, fmt.Errorf("PortForward: %v", err)
+	}
+	if err := local.SetReadDeadline(time.Now().Add(udpSessionTimeout)); err != nil {
+		local.Close()
+		return nil, err
+	}
+
+	fmt.Printf("Forwarding new UDP session from %v...\n", addr)
+	go func() {
+		defer done(local)
+		buf := make([]byte, math.MaxUint16)
+		for {
+			n, err := local.Read(buf)
+			if err != nil || n == 0 {
+				break
+			}
+			if _, err := pc.WriteTo(buf[:n], addr); err != nil {
+				log.Warningf("port forwarding to %v: %v", addr, err)
+				break
+			}
+			local.SetReadDeadline(time.Now().Add(udpSessionTimeout))
+		}
+		fmt.Printf("Finished forwarding UDP session from %v...\n", addr)
+	}()
+	return local, nil
+}
 
-	if p.portNum < 0 || p.portNum > math.MaxUint16 {
-		return fmt.Errorf("invalid port %d: %v", p.portNum, err)
+// doStream does the stream version of the port-forward command.
+func (p *PortForward) doStream(ctx context.Context, remote string, c *container.Container) error {
+	opts, err := p.remoteOpts(remote)
+	if err != nil {
+		return fmt.Errorf("invalid remote %q: %v", remote, err)
 	}
 
-	f, err := openStream(p.stream)
+	stype := syscall.SOCK_STREAM
+	if p.protocol == boot.PortForwardUDP {
+		stype = syscall.SOCK_SEQPACKET
+	}
+	f, err := openStream(p.stream, stype)
 	if err != nil {
 		return fmt.Errorf("opening uds stream: %v", err)
 	}
 	defer f.Close()
 
-	if err := c.PortForward(&boot.PortForwardOpts{
-		Port:        uint16(p.portNum),
-		ContainerID: c.ID,
-		FilePayload: urpc.FilePayload{Files: []*os.File{f}},
-	}); err != nil {
+	opts.ContainerID = c.ID
+	opts.FilePayload = urpc.FilePayload{Files: []*os.File{f}}
+	if err := c.PortForward(&opts); err != nil {
 		return fmt.Errorf("PortForward: %v", err)
 	}
 
@@ -239,7 +426,7 @@ func (p *PortForward) doStream(ctx context.Context, port string, c *container.Co
 
 // portCopy creates a UDS and begins copying data to and from the local
 // connection.
-func portCopy(ctx context.Context, c *container.Container, localConn net.Conn, port uint16) error {
+func portCopy(ctx context.Context, c *container.Container, localConn net.Conn, opts boot.PortForwardOpts) error {
 	// Create a new path address for the UDS.
 	addr, err := tmpUDSAddr()
 	if err != nil {
@@ -254,7 +441,7 @@ func portCopy(ctx context.Context, c *container.Container, localConn net.Conn, p
 	defer l.Close()
 
 	// Open the UDS as a File so it can be donated to the sentry.
-	streamFile, err := openStream(addr)
+	streamFile, err := openStream(addr, syscall.SOCK_STREAM)
 	if err != nil {
 		return fmt.Errorf("opening uds stream: %v", err)
 	}
@@ -263,10 +450,8 @@ func portCopy(ctx context.Context, c *container.Container, localConn net.Conn, p
 	// Request port forwarding from the sentry. This request will return
 	// immediately after port forwarding is started and connection state is
 	// handled via the UDS from then on.
-	if err := c.PortForward(&boot.PortForwardOpts{
-		Port:        port,
-		FilePayload: urpc.FilePayload{Files: []*os.File{streamFile}},
-	}); err != nil {
+	opts.FilePayload = urpc.FilePayload{Files: []*os.File{streamFile}}
+	if err := c.PortForward(&opts); err != nil {
 		return fmt.Errorf("PortForward: %v", err)
 	}
 
@@ -332,11 +517,11 @@ func tmpUDSAddr() (string, error) {
 	return path, nil
 }
 
-// openStream opens a UDS as a socket and returns the file descriptor in an
-// os.File object.
-func openStream(name string) (*os.File, error) {
+// openStream opens a UDS of the given type as a socket and returns the file
+// descriptor in an os.File object.
+func openStream(name string, stype int) (*os.File, error) {
 	// The net package will abstract the fd, so we use raw syscalls.
-	fd, err := syscall.Socket(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	fd, err := syscall.Socket(syscall.AF_UNIX, stype, 0)
 	if err != nil {
 		return nil, err
 	}
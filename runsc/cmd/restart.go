@@ -0,0 +1,75 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/google/subcommands"
+	"gvisor.dev/gvisor/runsc/cmd/util"
+	"gvisor.dev/gvisor/runsc/config"
+	"gvisor.dev/gvisor/runsc/container"
+	"gvisor.dev/gvisor/runsc/flag"
+)
+
+// Restart implements subcommands.Command for the "restart" command.
+type Restart struct {
+	containerID string
+}
+
+// Name implements subcommands.Command.Name.
+func (*Restart) Name() string {
+	return "restart"
+}
+
+// Synopsis implements subcommands.Command.Synopsis.
+func (*Restart) Synopsis() string {
+	return "restart a container inside a running sandbox without affecting other containers"
+}
+
+// Usage implements subcommands.Command.Usage.
+func (*Restart) Usage() string {
+	return `restart --container=<container id> - kill all processes of a container and start it again.
+
+The container must not be the root container of its sandbox. Its processes,
+mounts and gofer are torn down and recreated from the container's spec.
+`
+}
+
+// SetFlags implements subcommands.Command.SetFlags.
+func (r *Restart) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&r.containerID, "container", "", "ID of the container to restart")
+}
+
+// Execute implements subcommands.Command.Execute.
+func (r *Restart) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	if f.NArg() != 0 || r.containerID == "" {
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	conf := args[0].(*config.Config)
+
+	cont, err := container.Load(conf.RootDir, container.FullID{ContainerID: r.containerID}, container.LoadOpts{})
+	if err != nil {
+		util.Fatalf("loading container: %v", err)
+	}
+
+	if err := cont.Restart(conf); err != nil {
+		util.Fatalf("restart failed: %v", err)
+	}
+
+	return subcommands.ExitSuccess
+}
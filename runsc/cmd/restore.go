@@ -53,6 +53,12 @@ type Restore struct {
 	// uncompressed for background to work; if the checkpoint is compressed,
 	// background has no effect.
 	background bool
+
+	// allowIncompatible allows restoring a checkpoint taken by a different
+	// runsc version or with a different configuration, when the difference is
+	// known to be safe in some cases. Differences that are never safe, e.g. in
+	// mounts or network configuration, still fail the restore.
+	allowIncompatible bool
 }
 
 // Name implements subcommands.Command.Name.
@@ -77,6 +83,7 @@ func (r *Restore) SetFlags(f *flag.FlagSet) {
 	f.BoolVar(&r.detach, "detach", false, "detach from the container's process")
 	f.BoolVar(&r.direct, "direct", false, "use O_DIRECT for reading checkpoint pages file")
 	f.BoolVar(&r.background, "background", false, "allow image loading to continue after restore exits (requires uncompressed checkpoint)")
+	f.BoolVar(&r.allowIncompatible, "allow-incompatible", false, "allow restoring a checkpoint taken by a different runsc version or platform, which may fail or misbehave")
 
 	// Unimplemented flags necessary for compatibility with docker.
 
@@ -153,7 +160,7 @@ func (r *Restore) Execute(_ context.Context, f *flag.FlagSet, args ...any) subco
 	}
 
 	log.Debugf("Restore: %v", r.imagePath)
-	err = c.Restore(conf, r.imagePath, r.direct, r.background)
+	err = c.Restore(conf, r.imagePath, r.direct, r.background, r.allowIncompatible)
 	if err != nil {
 		return util.Errorf("starting container: %v", err)
 	}
@@ -0,0 +1,91 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/google/subcommands"
+	"gvisor.dev/gvisor/runsc/cmd/util"
+	"gvisor.dev/gvisor/runsc/config"
+	"gvisor.dev/gvisor/runsc/container"
+	"gvisor.dev/gvisor/runsc/flag"
+)
+
+// Upgrade implements subcommands.Command for the "upgrade" command.
+type Upgrade struct {
+	// background allows the sandbox memory to be loaded in the background
+	// after the new sandbox process starts running the containers.
+	background bool
+}
+
+// Name implements subcommands.Command.Name.
+func (*Upgrade) Name() string {
+	return "upgrade"
+}
+
+// Synopsis implements subcommands.Command.Synopsis.
+func (*Upgrade) Synopsis() string {
+	return "replace a running sandbox process with one running this runsc binary (experimental)"
+}
+
+// Usage implements subcommands.Command.Usage.
+func (*Upgrade) Usage() string {
+	return `upgrade [flags] <container id> - replace the sandbox process of the given
+root container with one running this runsc binary, without restarting its
+containers.
+
+The sandbox is checkpointed to memory and restored by the new sandbox process,
+which takes over the host file descriptors of the old one, including the
+control socket, gofer connections, stdio and network links. The old sandbox
+process stays paused until the new one has restored the sandbox, and resumes
+if the new one fails to. Packets received while the sandbox is paused are
+dropped.
+
+The sandbox must have been created with --hot-upgrade, and the flags passed to
+this command must match the ones the sandbox was created with. The sandbox
+memory is held twice while the upgrade is in progress; the copy held by this
+command is not charged to the sandbox's cgroup.
+
+The sandbox cannot be upgraded while processes started with "runsc exec" are
+running. Containers with a terminal other than the root container, XDP, shared
+memory links and plugin networking are not supported.
+`
+}
+
+// SetFlags implements subcommands.Command.SetFlags.
+func (u *Upgrade) SetFlags(f *flag.FlagSet) {
+	f.BoolVar(&u.background, "background", false, "allow sandbox memory to be loaded in the background after upgrade exits")
+}
+
+// Execute implements subcommands.Command.Execute.
+func (u *Upgrade) Execute(_ context.Context, f *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	if f.NArg() != 1 {
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	id := f.Arg(0)
+	conf := args[0].(*config.Config)
+
+	cont, err := container.Load(conf.RootDir, container.FullID{ContainerID: id}, container.LoadOpts{RootContainer: true})
+	if err != nil {
+		util.Fatalf("loading container: %v", err)
+	}
+	if err := container.Upgrade(conf, cont, u.background); err != nil {
+		util.Fatalf("upgrade failed: %v", err)
+	}
+	return subcommands.ExitSuccess
+}
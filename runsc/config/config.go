@@ -24,8 +24,10 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/rand"
 	"gvisor.dev/gvisor/pkg/refs"
 	"gvisor.dev/gvisor/pkg/sentry/devices/nvproxy/nvconf"
 	"gvisor.dev/gvisor/pkg/sentry/watchdog"
@@ -78,6 +80,11 @@ type Config struct {
 	// PanicLog is the path to log GO's runtime messages, if not empty.
 	PanicLog string `flag:"panic-log"`
 
+	// PanicReport is the destination of JSON reports describing the sandbox
+	// state when the sentry panics, if not empty. It is a file path, or the
+	// path of a unix stream socket if prefixed with "unix:".
+	PanicReport string `flag:"panic-report"`
+
 	// CoverageReport is the path to write Go coverage information, if not empty.
 	CoverageReport string `flag:"coverage-report"`
 
@@ -98,6 +105,12 @@ type Config struct {
 	// DO NOT call it directly, use GetOverlay2() instead.
 	Overlay2 Overlay2 `flag:"overlay2"`
 
+	// SelfContained runs containers without a gofer process. The root
+	// filesystem must be an EROFS image, set with the rootfs annotations, and
+	// is overlaid with a memory-backed tmpfs. Mounts that need a gofer, such as
+	// bind mounts of host directories, are rejected.
+	SelfContained bool `flag:"self-contained"`
+
 	// FSGoferHostUDS is deprecated: use host-uds=all.
 	FSGoferHostUDS bool `flag:"fsgofer-host-uds"`
 
@@ -142,6 +155,11 @@ type Config struct {
 	// for non-loopback interfaces.
 	QDisc QueueingDiscipline `flag:"qdisc"`
 
+	// NetworkDHCP indicates that non-loopback interfaces acquire their
+	// addresses and routes with DHCPv4 and DHCPv6 rather than copying them
+	// from the host network namespace.
+	NetworkDHCP bool `flag:"network-dhcp"`
+
 	// LogPackets indicates that all network packets should be logged.
 	LogPackets bool `flag:"log-packets"`
 
@@ -156,6 +174,35 @@ type Config struct {
 	// If unset, a sane platform-specific default will be used.
 	PlatformDevicePath string `flag:"platform_device_path"`
 
+	// HostThreadNames causes host threads that execute application code to
+	// be named after the sandboxed process they run, where the platform
+	// supports it. It is disabled by default, since it exposes application
+	// names on the host.
+	HostThreadNames bool `flag:"host-thread-names"`
+
+	// FlightRecorder enables a per-task in-memory ring buffer of recent
+	// scheduling, syscall and fault events, which can be dumped with
+	// `runsc debug --flight-record`.
+	FlightRecorder bool `flag:"flight-recorder"`
+
+	// SentryGOGC sets the garbage collection target percentage of the
+	// sentry's Go runtime, as the GOGC environment variable does. "off"
+	// disables the garbage collector until SentryMemoryLimit is reached.
+	// "auto" makes collections less frequent if SentryMemoryLimit sets a
+	// limit. If empty, the Go runtime default is used.
+	SentryGOGC string `flag:"sentry-gogc"`
+
+	// SentryMemoryLimit sets the soft memory limit of the sentry's Go
+	// runtime, as the GOMEMLIMIT environment variable does, using the same
+	// syntax as the tmpfs size= mount option. "off" disables the limit.
+	// "auto" derives the limit from the sandbox memory size. If empty, the
+	// Go runtime default is used.
+	SentryMemoryLimit string `flag:"sentry-memory-limit"`
+
+	// SentryMaxProcs sets GOMAXPROCS for the sentry. If 0, it is the number
+	// of CPUs of the sandbox.
+	SentryMaxProcs int `flag:"sentry-maxprocs"`
+
 	// MetricServer, if set, indicates that metrics should be exported on this address.
 	// This may either be 1) "addr:port" to export metrics on a specific network interface address,
 	// 2) ":port" for exporting metrics on all addresses, or 3) an absolute path to a Unix Domain
@@ -201,6 +248,21 @@ type Config struct {
 	// sent to log if false.
 	StraceEvent bool `flag:"strace-event"`
 
+	// StraceLog is the path of a file to which strace output is written as
+	// JSON, one record per line, instead of to the debug log. It accepts the
+	// same patterns as DebugLog, or "unix:<path>" to stream the records to a
+	// remote collector listening on a Unix stream socket.
+	StraceLog string `flag:"strace-log"`
+
+	// StraceSampleRate causes only one in every StraceSampleRate traced
+	// syscalls to be logged. 0 and 1 mean that all syscalls are logged.
+	StraceSampleRate uint `flag:"strace-sample-rate"`
+
+	// StraceContainers is the set of container IDs whose syscalls are traced
+	// (comma-separated values). If empty, syscalls from all containers are
+	// traced.
+	StraceContainers string `flag:"strace-containers"`
+
 	// DisableSeccomp indicates whether seccomp syscall filters should be
 	// disabled. Pardon the double negation, but default to enabled is important.
 	DisableSeccomp bool
@@ -209,7 +271,9 @@ type Config struct {
 	// run in a core tagged process. This isolates the sentry from sharing
 	// physical cores with other core tagged processes. This is useful as a
 	// mitigation for hyperthreading side channel based attacks. Requires host
-	// linux kernel >= 5.14.
+	// linux kernel >= 5.14. On platforms that run application code on
+	// dedicated host threads, core scheduling cookies set by the application
+	// with prctl(PR_SCHED_CORE) are also enforced by the host.
 	EnableCoreTags bool `flag:"enable-core-tags"`
 
 	// WatchdogAction sets what action the watchdog takes when triggered.
@@ -257,7 +321,9 @@ type Config struct {
 	// Defense in depth measures are weaker in rootless mode. Specifically, the
 	// sandbox and Gofer process run as root inside a user namespace with root
 	// mapped to the caller's user. When using rootless, the container root path
-	// should not have a symlink.
+	// should not have a symlink. The sandbox never holds CAP_NET_ADMIN or
+	// CAP_NET_RAW, and resource limits are only applied with SystemdCgroup,
+	// through the caller's systemd user instance.
 	Rootless bool `flag:"rootless"`
 
 	// AlsoLogToStderr allows to send log messages to stderr.
@@ -275,6 +341,11 @@ type Config struct {
 	// Allows overriding of flags in OCI annotations.
 	AllowFlagOverride bool `flag:"allow-flag-override"`
 
+	// AllowVDSOAnnotation allows containers to disable the VDSO, or to map an
+	// alternate VDSO image read from the host, with the dev.gvisor.spec.vdso
+	// annotation.
+	AllowVDSOAnnotation bool `flag:"allow-vdso-annotation"`
+
 	// Enables seccomp inside the sandbox.
 	OCISeccomp bool `flag:"oci-seccomp"`
 
@@ -288,6 +359,19 @@ type Config struct {
 	// take during pod creation.
 	PodInitConfig string `flag:"pod-init-config"`
 
+	// ControlPolicy is the path to a JSON file restricting which control
+	// server RPCs clients other than runsc may invoke, by the token they
+	// present. See pkg/control/server.Policy.
+	ControlPolicy string `flag:"control-policy"`
+
+	// EntropySource is the source of randomness visible to the sandbox,
+	// e.g. through getrandom(2) and /dev/urandom. See pkg/rand.Source*.
+	EntropySource string `flag:"entropy-source"`
+
+	// HWRNGDevice is the host hardware RNG device read with
+	// --entropy-source=hwrng.
+	HWRNGDevice string `flag:"hwrng-device"`
+
 	// Use pools to manage buffer memory instead of heap.
 	BufferPooling bool `flag:"buffer-pooling"`
 
@@ -311,14 +395,55 @@ type Config struct {
 	// asynchronous I/O operations.
 	IOUring bool `flag:"iouring"`
 
+	// IOStats enables per-mount and per-container I/O statistics, at the cost
+	// of reading the clock twice on every read and write.
+	IOStats bool `flag:"io-stats"`
+
+	// HostUring causes the sentry to issue positional reads and writes on
+	// host file descriptors through a host io_uring instance, batching
+	// concurrent I/O to reduce syscall overhead.
+	HostUring bool `flag:"host-uring"`
+
+	// HostLocks allows gofer mounts to use the host_locks mount option, on
+	// which POSIX-style locks held by applications are also held on the host.
+	HostLocks bool `flag:"host-locks"`
+
 	// DirectFS sets up the sandbox to directly access/mutate the filesystem from
 	// the sentry. Sentry runs with escalated privileges. Gofer process still
 	// exists, but is mostly idle. Not supported in rootless mode.
 	DirectFS bool `flag:"directfs"`
 
+	// GoferReadCache is the path to a host directory in which gofers cache
+	// large files of read-only mounts, keyed by the content digest in their
+	// extended attributes. See fsgofer.ReadCache.
+	GoferReadCache string `flag:"gofer-read-cache"`
+
+	// GoferReadCacheDomain is the trust domain of the sandbox, e.g. its
+	// tenant. Files are only shared through the read cache by sandboxes in
+	// the same trust domain.
+	GoferReadCacheDomain string `flag:"gofer-read-cache-domain"`
+
+	// GoferReadCacheSize is the maximum size in bytes of files in the read
+	// cache of each trust domain. Least recently used files are evicted
+	// beyond it.
+	GoferReadCacheSize uint64 `flag:"gofer-read-cache-size"`
+
 	// AppHugePages enables support for application huge pages.
 	AppHugePages bool `flag:"app-huge-pages"`
 
+	// AppHugeText causes large executable segments to be copied into
+	// hugepage-backed memory at exec time.
+	AppHugeText bool `flag:"app-huge-text"`
+
+	// AppPrefaultText causes executable segments to be faulted in at exec
+	// time.
+	AppPrefaultText bool `flag:"app-prefault-text"`
+
+	// AppRequireControlFlowProtection causes executables that request
+	// control-flow protection (IBT, SHSTK, BTI or PAC) to fail with ENOEXEC,
+	// since the sentry cannot enforce it.
+	AppRequireControlFlowProtection bool `flag:"app-require-control-flow-protection"`
+
 	// NVProxy enables support for Nvidia GPUs.
 	NVProxy bool `flag:"nvproxy"`
 
@@ -382,6 +507,11 @@ type Config struct {
 	// TestOnlyAutosaveResume indicates save resume for syscall tests.
 	TestOnlyAutosaveResume bool `flag:"TESTONLY-autosave-resume"`
 
+	// TestOnlyTimeDilation, if not empty, is the rate at which the sandbox's
+	// monotonic and realtime clocks advance relative to the host's clocks,
+	// and enables the control RPCs that change the rate or step the clocks.
+	TestOnlyTimeDilation string `flag:"TESTONLY-time-dilation"`
+
 	// RestoreSpecValidation indicates the level of spec validation to be
 	// performed during restore.
 	RestoreSpecValidation RestoreSpecValidationPolicy `flag:"restore-spec-validation"`
@@ -389,12 +519,48 @@ type Config struct {
 	// GVisorMarkerFile enables the /proc/gvisor/kernel_is_gvisor marker file.
 	GVisorMarkerFile bool `flag:"gvisor-marker-file"`
 
+	// KernelRelease, if set, is the kernel release reported by uname(2) and
+	// /proc instead of the default one, e.g. "5.15.0".
+	KernelRelease string `flag:"kernel-release"`
+
+	// KernelVersion, if set, is the kernel version reported by uname(2) and
+	// /proc instead of the default one, e.g. "#1 SMP Fri Jan 27 02:56:13 UTC
+	// 2023".
+	KernelVersion string `flag:"kernel-version"`
+
+	// KernelReleaseSyscalls makes syscalls that were added to Linux after the
+	// reported kernel release fail with ENOSYS, as they would on that release.
+	KernelReleaseSyscalls bool `flag:"kernel-release-syscalls"`
+
+	// NUMANodes is the number of NUMA nodes advertised to the sandbox. CPUs
+	// are split evenly among nodes, but memory isn't actually placed on
+	// them.
+	NUMANodes int `flag:"numa-nodes"`
+
 	// SystrapDisableSyscallPatching disables syscall patching in Systrap.
 	SystrapDisableSyscallPatching bool `flag:"systrap-disable-syscall-patching"`
 
 	// SaveRestoreNetstack indicates whether netstack should be saved and restored.
 	SaveRestoreNetstack bool `flag:"save-restore-netstack"`
 
+	// NetworkHandoff makes a restore with hostinet take over the TCP
+	// connections of a checkpoint taken with a saved netstack.
+	NetworkHandoff bool `flag:"network-handoff"`
+
+	// IdleCheckpointTimeout, if non-zero, makes the sandbox checkpoint itself
+	// to IdleCheckpointImagePath and exit once it has been idle (no running
+	// tasks and no network activity) for this long. The sandbox is restored
+	// by the next exec into the container.
+	IdleCheckpointTimeout time.Duration `flag:"idle-checkpoint-timeout"`
+
+	// IdleCheckpointImagePath is the directory in which idle checkpoint
+	// images are stored, one subdirectory per sandbox.
+	IdleCheckpointImagePath string `flag:"idle-checkpoint-image-path"`
+
+	// HotUpgrade makes the sandbox retain the host FDs donated to it, so that
+	// "runsc upgrade" can hand the running sandbox over to a new runsc binary.
+	HotUpgrade bool `flag:"hot-upgrade"`
+
 	// Nftables enables support for nftables to be used instead of iptables.
 	Nftables bool `flag:"TESTONLY-nftables"`
 }
@@ -407,6 +573,19 @@ func (c *Config) validate() error {
 	if overlay2 := c.GetOverlay2(); c.FileAccess == FileAccessShared && overlay2.Enabled() {
 		return fmt.Errorf("overlay flag is incompatible with shared file access for rootfs")
 	}
+	if c.GoferReadCache != "" {
+		if c.DirectFS {
+			// The sentry opens files itself with directfs.
+			return fmt.Errorf("gofer-read-cache flag requires directfs to be disabled")
+		}
+		if !filepath.IsAbs(c.GoferReadCache) {
+			return fmt.Errorf("gofer-read-cache must be an absolute path, got: %q", c.GoferReadCache)
+		}
+		// The domain is the name of a subdirectory of the cache.
+		if d := c.GoferReadCacheDomain; d == "" || d == "." || d == ".." || strings.Contains(d, "/") {
+			return fmt.Errorf("gofer-read-cache requires gofer-read-cache-domain to be set to a valid directory name, got: %q", d)
+		}
+	}
 	if c.NumNetworkChannels <= 0 {
 		return fmt.Errorf("num_network_channels must be > 0, got: %d", c.NumNetworkChannels)
 	}
@@ -429,6 +608,49 @@ func (c *Config) validate() error {
 		// Deprecated flag was used together with flag that replaced it.
 		return fmt.Errorf("fsgofer-host-uds has been replaced with host-uds flag")
 	}
+	switch c.EntropySource {
+	case "", rand.SourceGetrandom, rand.SourceDRBG:
+	case rand.SourceHWRNG:
+		if c.HWRNGDevice == "" {
+			return fmt.Errorf("entropy-source=%s requires hwrng-device", c.EntropySource)
+		}
+	default:
+		return fmt.Errorf("invalid entropy-source %q", c.EntropySource)
+	}
+	if c.SentryGOGC != "" && c.SentryGOGC != "off" && c.SentryGOGC != "auto" {
+		if n, err := strconv.Atoi(c.SentryGOGC); err != nil || n < 0 {
+			return fmt.Errorf("invalid sentry-gogc %q: must be a non-negative integer, \"off\" or \"auto\"", c.SentryGOGC)
+		}
+	}
+	// The limit matches kernel.MaxNUMANodes.
+	if c.NUMANodes < 1 || c.NUMANodes > 64 {
+		return fmt.Errorf("numa-nodes must be between 1 and 64, got: %d", c.NUMANodes)
+	}
+	if c.SentryMaxProcs < 0 {
+		return fmt.Errorf("sentry-maxprocs must be >= 0, got: %d", c.SentryMaxProcs)
+	}
+	if c.TestOnlyTimeDilation != "" {
+		if rate, err := strconv.ParseFloat(c.TestOnlyTimeDilation, 64); err != nil || !(rate > 0) {
+			return fmt.Errorf("invalid TESTONLY-time-dilation %q: must be a positive number", c.TestOnlyTimeDilation)
+		}
+	}
+	if c.IdleCheckpointTimeout < 0 {
+		return fmt.Errorf("idle-checkpoint-timeout must be >= 0, got: %v", c.IdleCheckpointTimeout)
+	}
+	if c.IdleCheckpointTimeout > 0 {
+		if c.IdleCheckpointImagePath == "" {
+			return fmt.Errorf("idle-checkpoint-timeout flag requires idle-checkpoint-image-path")
+		}
+		if c.Network == NetworkHost {
+			return fmt.Errorf("idle-checkpoint-timeout flag is incompatible with hostinet")
+		}
+	}
+	if c.NetworkHandoff && (c.Network != NetworkHost || !c.SaveRestoreNetstack) {
+		return fmt.Errorf("network-handoff flag requires --network=host and --save-restore-netstack")
+	}
+	if c.HotUpgrade && c.Network == NetworkHost {
+		return fmt.Errorf("hot-upgrade flag is incompatible with hostinet")
+	}
 	if len(c.ProfilingMetrics) > 0 && len(c.ProfilingMetricsLog) == 0 {
 		return fmt.Errorf("profiling-metrics flag requires defining a profiling-metrics-log for output")
 	}
@@ -631,6 +853,11 @@ const (
 
 	// NetworkPlugin uses third-party network stack.
 	NetworkPlugin
+
+	// NetworkSRIOV sets up just loopback using netstack and passes the SR-IOV
+	// virtual functions in the container's VFIO groups through to the
+	// container, where they are driven by a userspace driver.
+	NetworkSRIOV
 )
 
 func networkTypePtr(v NetworkType) *NetworkType {
@@ -648,6 +875,8 @@ func (n *NetworkType) Set(v string) error {
 		*n = NetworkNone
 	case "plugin":
 		*n = NetworkPlugin
+	case "sriov":
+		*n = NetworkSRIOV
 	default:
 		return fmt.Errorf("invalid network type %q", v)
 	}
@@ -670,6 +899,8 @@ func (n NetworkType) String() string {
 		return "none"
 	case NetworkPlugin:
 		return "plugin"
+	case NetworkSRIOV:
+		return "sriov"
 	}
 	panic(fmt.Sprintf("Invalid network type %d", n))
 }
@@ -308,6 +308,40 @@ func TestValidationFail(t *testing.T) {
 			},
 			error: "overlay flag has been replaced with overlay2 flag",
 		},
+		{
+			name: "gofer-read-cache+directfs",
+			flags: map[string]string{
+				"gofer-read-cache":        "/var/cache/runsc",
+				"gofer-read-cache-domain": "tenant",
+			},
+			error: "gofer-read-cache flag requires directfs to be disabled",
+		},
+		{
+			name: "gofer-read-cache:relative",
+			flags: map[string]string{
+				"gofer-read-cache":        "cache",
+				"gofer-read-cache-domain": "tenant",
+				"directfs":                "false",
+			},
+			error: "gofer-read-cache must be an absolute path",
+		},
+		{
+			name: "gofer-read-cache:no-domain",
+			flags: map[string]string{
+				"gofer-read-cache": "/var/cache/runsc",
+				"directfs":         "false",
+			},
+			error: "gofer-read-cache requires gofer-read-cache-domain",
+		},
+		{
+			name: "gofer-read-cache:domain-path",
+			flags: map[string]string{
+				"gofer-read-cache":        "/var/cache/runsc",
+				"gofer-read-cache-domain": "../tenant",
+				"directfs":                "false",
+			},
+			error: "gofer-read-cache requires gofer-read-cache-domain",
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			testFlags := flag.NewFlagSet("test", flag.ContinueOnError)
@@ -26,6 +26,7 @@ import (
 	"text/template"
 
 	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/rand"
 	"gvisor.dev/gvisor/pkg/refs"
 	"gvisor.dev/gvisor/pkg/sentry/watchdog"
 	"gvisor.dev/gvisor/runsc/flag"
@@ -66,6 +67,7 @@ func RegisterFlags(flagSet *flag.FlagSet) {
 	flagSet.String("debug-log", "", "additional location for logs. If it ends with '/', log files are created inside the directory with default names. The following variables are available: %TIMESTAMP%, %COMMAND%.")
 	flagSet.String("debug-command", "", `comma-separated list of commands to be debugged if --debug-log is also set. Empty means debug all. "!" negates the expression. E.g. "create,start" or "!boot,events"`)
 	flagSet.String("panic-log", "", "file path where panic reports and other Go's runtime messages are written.")
+	flagSet.String("panic-report", "", "file path, or unix socket path prefixed with 'unix:', where a JSON report of the sandbox state is written when the sentry panics.")
 	flagSet.String("coverage-report", "", "file path where Go coverage reports are written. Reports will only be generated if runsc is built with --collect_code_coverage and --instrumentation_filter Bazel flags.")
 	flagSet.Bool("log-packets", false, "enable network packet logging.")
 	flagSet.String("pcap-log", "", "location of PCAP log file.")
@@ -76,6 +78,7 @@ func RegisterFlags(flagSet *flag.FlagSet) {
 		flagSet.Bool("alsologtostderr", false, "send log messages to stderr.")
 	}
 	flagSet.Bool(flagAllowFlagOverride, false, "allow OCI annotations (dev.gvisor.flag.<name>) to override flags for debugging.")
+	flagSet.Bool("allow-vdso-annotation", false, "allow containers to disable the VDSO, or to use an alternate VDSO image from the host, with the dev.gvisor.spec.vdso annotation.")
 	flagSet.String("traceback", "system", "golang runtime's traceback level")
 
 	// Metrics flags.
@@ -90,10 +93,18 @@ func RegisterFlags(flagSet *flag.FlagSet) {
 	flagSet.String(flagStraceSyscalls, "", "comma-separated list of syscalls to trace. If --strace is true and this list is empty, then all syscalls will be traced.")
 	flagSet.Uint(flagStraceLogSize, 1024, "default size (in bytes) to log data argument blobs.")
 	flagSet.Bool("strace-event", false, "send strace to event.")
+	flagSet.String("strace-log", "", "file to write strace output to as JSON, instead of the debug log. Accepts the same patterns as --debug-log, or unix:<path> to stream it to a Unix stream socket.")
+	flagSet.Uint("strace-sample-rate", 0, "log only one in every N traced syscalls. 0 or 1 logs all of them.")
+	flagSet.String("strace-containers", "", "comma-separated list of container IDs to trace. If empty, all containers are traced.")
 
 	// Flags that control sandbox runtime behavior.
 	flagSet.String("platform", "systrap", "specifies which platform to use: systrap (default), ptrace, kvm.")
 	flagSet.String("platform_device_path", "", "path to a platform-specific device file (e.g. /dev/kvm for KVM platform). If unset, will use a sane platform-specific default.")
+	flagSet.Bool("flight-recorder", false, "record recent scheduling, syscall and fault events of each task in memory, to be dumped with 'runsc debug --flight-record'.")
+	flagSet.String("sentry-gogc", "", "garbage collection target percentage of the sentry's Go runtime, like GOGC, or \"off\". \"auto\" makes collections less frequent when sentry-memory-limit sets a limit. If empty, the Go runtime default is used.")
+	flagSet.String("sentry-memory-limit", "", "soft memory limit of the sentry's Go runtime, like GOMEMLIMIT, with an optional k/m/g/t suffix, or \"off\". \"auto\" derives the limit from the sandbox memory size. If empty, the Go runtime default is used.")
+	flagSet.Int("sentry-maxprocs", 0, "GOMAXPROCS of the sentry. If 0, it is the number of CPUs of the sandbox.")
+	flagSet.Bool("host-thread-names", false, "name host threads that execute application code (systrap stubs) \"<pid>:<comm>\" after the sandboxed process, so host profilers and top attribute CPU time correctly. This exposes application names on the host.")
 	flagSet.Var(watchdogActionPtr(watchdog.LogWarning), "watchdog-action", "sets what action the watchdog takes when triggered: log (default), panic.")
 	flagSet.Int("panic-signal", -1, "register signal handling that panics. Usually set to SIGUSR2(12) to troubleshoot hangs. -1 disables it.")
 	flagSet.Bool("profile", false, "prepares the sandbox to use Golang profiler. Note that enabling profiler loosens the seccomp protection added to the sandbox (DO NOT USE IN PRODUCTION).")
@@ -102,18 +113,24 @@ func RegisterFlags(flagSet *flag.FlagSet) {
 	flagSet.String("profile-heap", "", "collects a heap profile to this file path for the duration of the container execution. Requires -profile=true.")
 	flagSet.String("profile-mutex", "", "collects a mutex profile to this file path for the duration of the container execution. Requires -profile=true.")
 	flagSet.String("trace", "", "collects a Go runtime execution trace to this file path for the duration of the container execution.")
-	flagSet.Bool("rootless", false, "it allows the sandbox to be started with a user that is not root. Sandbox and Gofer processes may run with same privileges as current user.")
+	flagSet.Bool("rootless", false, "it allows the sandbox to be started with a user that is not root. Sandbox and Gofer processes may run with same privileges as current user. Use with --systemd-cgroup to apply resource limits through the user's systemd instance.")
 	flagSet.Var(leakModePtr(refs.NoLeakChecking), "ref-leak-mode", "sets reference leak check mode: disabled (default), log-names, log-traces.")
 	flagSet.Bool("cpu-num-from-quota", false, "set cpu number to cpu quota (least integer greater or equal to quota value, but not less than 2)")
 	flagSet.Bool(flagOCISeccomp, false, "Enables loading OCI seccomp filters inside the sandbox.")
 	flagSet.Bool("enable-core-tags", false, "enables core tagging. Requires host linux kernel >= 5.14.")
 	flagSet.String("pod-init-config", "", "path to configuration file with additional steps to take during pod creation.")
+	flagSet.String("control-policy", "", "path to a JSON policy restricting which control server RPCs clients other than runsc may invoke, by the token they present.")
+	flagSet.String("entropy-source", rand.SourceGetrandom, "source of randomness for the sandbox: getrandom (default, host getrandom(2)), drbg (per-CPU AES-256 CTR_DRBGs per NIST SP 800-90A, reseeded from the host), hwrng (host hardware RNG device set by --hwrng-device).")
+	flagSet.String("hwrng-device", "/dev/hwrng", "host hardware RNG device used with --entropy-source=hwrng.")
 	flagSet.Var(HostSettingsCheck.Ptr(), "host-settings", "how to handle non-optimal host kernel settings: check (default, advisory-only), ignore (do not check), adjust (best-effort auto-adjustment), or enforce (auto-adjustment must succeed).")
 	flagSet.Var(RestoreSpecValidationEnforce.Ptr(), "restore-spec-validation", "how to handle spec validation during restore.")
 	flagSet.Bool("systrap-disable-syscall-patching", false, "disables syscall patching when using the Systrap platform. May be necessary to use in case the workload uses the GS register, or uses ptrace within gVisor. Has significant performance implications and is only recommended when the sandbox is known to run otherwise-incompatible workloads. Only relevant for x86.")
 
 	// Flags that control sandbox runtime behavior: MM related.
 	flagSet.Bool("app-huge-pages", true, "enable use of huge pages for application memory; requires /sys/kernel/mm/transparent_hugepage/shmem_enabled = advise")
+	flagSet.Bool("app-huge-text", false, "copy executable segments of at least 2MiB into huge pages at exec time to reduce iTLB misses; text is no longer shared between processes. Requires --app-huge-pages.")
+	flagSet.Bool("app-prefault-text", false, "fault in executable segments at exec time rather than on first access.")
+	flagSet.Bool("app-require-control-flow-protection", false, "refuse to execute binaries whose ELF properties request control-flow protection (IBT, SHSTK, BTI or PAC), which is not enforced inside the sandbox, instead of running them without it.")
 
 	// Flags that control sandbox runtime behavior: FS related.
 	flagSet.Var(fileAccessTypePtr(FileAccessExclusive), "file-access", "specifies which filesystem validation to use for the root mount: exclusive (default), shared.")
@@ -125,10 +142,15 @@ func RegisterFlags(flagSet *flag.FlagSet) {
 		"    'mount' can be 'root' or 'all'\n"+
 		"    'medium' can be 'memory', 'self' or 'dir=/abs/dir/path' in which filestore will be created\n"+
 		"    'size' optional parameter overrides default overlay upper layer size\n")
+	flagSet.Bool("self-contained", false, "run containers without a gofer process, booting from an EROFS rootfs image with a tmpfs overlay. Bind mounts and device proxies are not supported.")
 	flagSet.Bool("fsgofer-host-uds", false, "DEPRECATED: use host-uds=all")
 	flagSet.Var(hostUDSPtr(HostUDSNone), flagHostUDS, "controls permission to access host Unix-domain sockets. Values: none|open|create|all, default: none")
 	flagSet.Var(hostFifoPtr(HostFifoNone), "host-fifo", "controls permission to access host FIFOs (or named pipes). Values: none|open, default: none")
 	flagSet.Bool("gvisor-marker-file", false, "enable the presence of the /proc/gvisor/kernel_is_gvisor file that can be used by applications to detect that gVisor is in use")
+	flagSet.String("kernel-release", "", "kernel release reported to the sandbox by uname(2) and /proc, e.g. \"5.15.0\". If empty, a default release is used.")
+	flagSet.String("kernel-version", "", "kernel version reported to the sandbox by uname(2) and /proc, e.g. \"#1 SMP Fri Jan 27 02:56:13 UTC 2023\". If empty, a default version is used.")
+	flagSet.Int("numa-nodes", 1, "number of NUMA nodes advertised to the sandbox by the NUMA syscalls and /sys/devices/system/node. CPUs are split evenly among nodes, but memory isn't actually placed on them.")
+	flagSet.Bool("kernel-release-syscalls", false, "make syscalls that were added to Linux after the reported kernel release fail with ENOSYS, so that applications probing for kernel features see a consistent kernel.")
 
 	flagSet.Bool("vfs2", true, "DEPRECATED: this flag has no effect.")
 	flagSet.Bool("fuse", true, "DEPRECATED: this flag has no effect.")
@@ -138,11 +160,17 @@ func RegisterFlags(flagSet *flag.FlagSet) {
 	flagSet.Int("fdlimit", -1, "Specifies a limit on the number of host file descriptors that can be open. Applies separately to the sentry and gofer. Note: each file in the sandbox holds more than one host FD open.")
 	flagSet.Int("dcache", -1, "Set the global dentry cache size. This acts as a coarse-grained control on the number of host FDs simultaneously open by the sentry. If negative, per-mount caches are used.")
 	flagSet.Bool("iouring", false, "TEST ONLY; Enables io_uring syscalls in the sentry. Support is experimental and very limited.")
+	flagSet.Bool("io-stats", false, "record per-mount and per-container I/O statistics, reported by /proc/[pid]/mountstats and the control API. This reads the clock twice on every read and write.")
+	flagSet.Bool("host-uring", false, "issue host file I/O from the sentry through a host io_uring instance. Requires io_uring to be available on the host.")
+	flagSet.Bool("host-locks", false, "allow gofer mounts to use the host_locks mount option, on which POSIX-style locks held by applications are also held on the host, so that they exclude conflicting locks held by other sandboxes sharing the volume.")
 	flagSet.Bool("directfs", true, "directly access the container filesystems from the sentry. Sentry runs with higher privileges.")
+	flagSet.String("gofer-read-cache", "", "host directory in which gofers cache large files of read-only mounts that have a \"user.gvisor.content-digest\" extended attribute (\"sha256:<hex>\"), so that sandboxes launched from the same image read them from the cache. Digests are trusted, so they must be set by trusted image tooling. Requires --directfs=false and --gofer-read-cache-domain.")
+	flagSet.String("gofer-read-cache-domain", "", "trust domain of the sandbox, e.g. its tenant. Only sandboxes with the same trust domain share files through --gofer-read-cache.")
+	flagSet.Uint64("gofer-read-cache-size", 10<<30, "maximum size in bytes of the files cached in --gofer-read-cache for each trust domain.")
 	flagSet.Bool("TESTONLY-nftables", false, "TEST ONLY; Enables nftables support in the sentry.")
 
 	// Flags that control sandbox runtime behavior: network related.
-	flagSet.Var(networkTypePtr(NetworkSandbox), "network", "specifies which network to use: sandbox (default), host, none. Using network inside the sandbox is more secure because it's isolated from the host network.")
+	flagSet.Var(networkTypePtr(NetworkSandbox), "network", "specifies which network to use: sandbox (default), host, none, sriov. Using network inside the sandbox is more secure because it's isolated from the host network.")
 	flagSet.Bool("net-raw", false, "enable raw sockets. When false, raw sockets are disabled by removing CAP_NET_RAW from containers (`runsc exec` will still be able to utilize raw sockets). Raw sockets allow malicious containers to craft packets and potentially attack the network.")
 	flagSet.Bool("gso", true, "enable host segmentation offload if it is supported by a network device.")
 	flagSet.Bool("software-gso", true, "enable gVisor segmentation offload when host offload can't be enabled.")
@@ -150,6 +178,7 @@ func RegisterFlags(flagSet *flag.FlagSet) {
 	flagSet.Bool("tx-checksum-offload", false, "enable TX checksum offload.")
 	flagSet.Bool("rx-checksum-offload", true, "enable RX checksum offload.")
 	flagSet.Var(queueingDisciplinePtr(QDiscFIFO), "qdisc", "specifies which queueing discipline to apply by default to the non loopback nics used by the sandbox.")
+	flagSet.Bool("network-dhcp", false, "EXPERIMENTAL: configure non-loopback interfaces with DHCPv4 and DHCPv6 instead of copying addresses and routes from the host network namespace.")
 	flagSet.Int("num-network-channels", 1, "number of underlying channels(FDs) to use for network link endpoints.")
 	flagSet.Int("network-processors-per-channel", 0, "number of goroutines in each channel for processng inbound packets. If 0, the link endpoint will divide GOMAXPROCS evenly among the number of channels specified by num-network-channels.")
 	flagSet.Bool("buffer-pooling", true, "DEPRECATED: this flag has no effect. Buffer pooling is always enabled.")
@@ -159,6 +188,10 @@ func RegisterFlags(flagSet *flag.FlagSet) {
 	flagSet.Bool(flagReproduceNFTables, false, "Attempt to scrape and reproduce nftable rules inside the sandbox. Overrides reproduce-nat when true.")
 	flagSet.Bool(flagNetDisconnectOK, true, "Indicates whether open network connections and open unix domain sockets should be disconnected upon save.")
 	flagSet.Bool("save-restore-netstack", true, "Indicates whether netstack save/restore is enabled.")
+	flagSet.Bool("network-handoff", false, "EXPERIMENTAL: when restoring with --network=host a checkpoint taken with netstack, take over its listening and established TCP connections with TCP_REPAIR. Requires CAP_NET_ADMIN.")
+	flagSet.Duration("idle-checkpoint-timeout", 0, "EXPERIMENTAL: if non-zero, checkpoint and stop the sandbox after it has had no running tasks and no network activity for this long. The sandbox is restored when a packet arrives for it while its container is waited on, or by the next exec into its container. Requires --idle-checkpoint-image-path.")
+	flagSet.String("idle-checkpoint-image-path", "", "directory in which idle checkpoint images are stored, in one subdirectory per sandbox.")
+	flagSet.Bool("hot-upgrade", false, "EXPERIMENTAL: retain the host FDs donated to the sandbox so that it can be handed over to a new runsc binary with \"runsc upgrade\".")
 
 	// Flags that control sandbox runtime behavior: accelerator related.
 	flagSet.Bool("nvproxy", false, "EXPERIMENTAL: enable support for Nvidia GPUs")
@@ -174,6 +207,7 @@ func RegisterFlags(flagSet *flag.FlagSet) {
 	flagSet.Bool("TESTONLY-afs-syscall-panic", false, "TEST ONLY; do not ever use! Used for tests exercising gVisor panic reporting.")
 	flagSet.String("TESTONLY-autosave-image-path", "", "TEST ONLY; enable auto save for syscall tests and set path for state file.")
 	flagSet.Bool("TESTONLY-autosave-resume", false, "TEST ONLY; enable auto save and resume for syscall tests and set path for state file.")
+	flagSet.String("TESTONLY-time-dilation", "", "TEST ONLY; makes the sandbox clocks advance at the given multiple of the host clocks' speed, e.g. 10 to run timers 10 times faster, and enables 'runsc debug --time-dilation' and '--advance-time'. A rate other than 1 disables the vDSO fast path.")
 }
 
 // overrideAllowlist lists all flags that can be changed using OCI
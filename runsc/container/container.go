@@ -192,6 +192,12 @@ type Args struct {
 
 	// ExecFile is the host file used for program execution.
 	ExecFile *os.File
+
+	// StdioFiles, if set, are the stdio files of the container. For the init
+	// container, they are used instead of the current process' stdio or a new
+	// console. For other containers, StdioFiles[0] is used as the container's
+	// TTY if its spec enables a terminal and ConsoleSocket is empty.
+	StdioFiles []*os.File
 }
 
 // New creates the container in a new Sandbox process, unless the metadata
@@ -265,30 +271,11 @@ func New(conf *config.Config, args Args) (*Container, error) {
 	if isRoot(args.Spec) {
 		log.Debugf("Creating new sandbox for container, cid: %s", args.ID)
 
-		if args.Spec.Linux == nil {
-			args.Spec.Linux = &specs.Linux{}
-		}
-		// Don't force the use of cgroups in tests because they lack permission to do so.
-		if args.Spec.Linux.CgroupsPath == "" && !conf.TestOnlyAllowRunAsCurrentUserWithoutChroot {
-			args.Spec.Linux.CgroupsPath = "/" + args.ID
-		}
-		var subCgroup, parentCgroup, containerCgroup cgroup.Cgroup
-		if !conf.IgnoreCgroups {
-			var err error
-
-			// Create and join cgroup before processes are created to ensure they are
-			// part of the cgroup from the start (and all their children processes).
-			parentCgroup, subCgroup, err = c.setupCgroupForRoot(conf, args.Spec)
-			if err != nil {
-				return nil, fmt.Errorf("cannot set up cgroup for root: %w", err)
-			}
-			// Join the child cgroup when using cgroupfs. Joining non leaf-node
-			// cgroups is illegal in cgroupsv2 and will return EBUSY.
-			if subCgroup != nil && !conf.SystemdCgroup && cgroup.IsOnlyV2() {
-				containerCgroup = subCgroup
-			} else {
-				containerCgroup = parentCgroup
-			}
+		// Create and join cgroup before processes are created to ensure they are
+		// part of the cgroup from the start (and all their children processes).
+		containerCgroup, subCgroup, err := c.setupSandboxCgroups(conf, args.Spec, args.ID)
+		if err != nil {
+			return nil, err
 		}
 		c.CompatCgroup = cgroup.CgroupJSON{Cgroup: subCgroup}
 		mountHints, err := boot.NewPodMountHints(args.Spec)
@@ -322,6 +309,7 @@ func New(conf *config.Config, args Args) (*Container, error) {
 				MountHints:          mountHints,
 				PassFiles:           args.PassFiles,
 				ExecFile:            args.ExecFile,
+				StdioFiles:          args.StdioFiles,
 			}
 			sand, err := sandbox.New(conf, sandArgs)
 			if err != nil {
@@ -365,6 +353,9 @@ func New(conf *config.Config, args Args) (*Container, error) {
 			}
 			// tty file is transferred to the sandbox, then it can be closed here.
 			defer tty.Close()
+		} else if args.Spec.Process.Terminal && len(args.StdioFiles) > 0 {
+			// The caller's TTY is used as the container's TTY.
+			tty = args.StdioFiles[0]
 		}
 
 		if err := c.Sandbox.CreateSubcontainer(conf, c.ID, tty); err != nil {
@@ -419,15 +410,207 @@ func (c *Container) Start(conf *config.Config) error {
 
 // Restore takes a container and replaces its kernel and file system
 // to restore a container from its state file.
-func (c *Container) Restore(conf *config.Config, imagePath string, direct, background bool) error {
+func (c *Container) Restore(conf *config.Config, imagePath string, direct, background, allowIncompatible bool) error {
 	log.Debugf("Restore container, cid: %s", c.ID)
 
 	restore := func(conf *config.Config, spec *specs.Spec) error {
-		return c.Sandbox.Restore(conf, spec, c.ID, imagePath, direct, background)
+		return c.Sandbox.Restore(conf, spec, c.ID, imagePath, direct, background, allowIncompatible)
 	}
 	return c.startImpl(conf, "restore", restore, c.Sandbox.RestoreSubcontainer)
 }
 
+// Adopt binds c, the root container of a sandbox that has been created but not
+// started, to the root container described by args and renames it to args.ID.
+// This allows blank sandboxes to be booted ahead of time and bound to their
+// container when it is created, taking sandbox boot time out of container
+// start latency.
+//
+// A new gofer is started for the adopted container's root filesystem and
+// mounts. The sandbox is moved to the cgroups that would have been created
+// for the adopted container, which get its resource limits, but the number of
+// CPUs and the amount of memory that the sandbox reports were set when it
+// booted. Its network stack is copied from the adopted container's network
+// namespace when it starts. On failure, c must be destroyed.
+func (c *Container) Adopt(conf *config.Config, args Args) error {
+	log.Debugf("Adopt container, cid: %s, new cid: %s", c.ID, args.ID)
+	if err := validateID(args.ID); err != nil {
+		return err
+	}
+	if err := c.Saver.lock(BlockAcquire); err != nil {
+		return err
+	}
+	defer c.Saver.UnlockOrDie()
+
+	if err := c.requireStatus("adopt", Created); err != nil {
+		return err
+	}
+	if !c.IsSandboxRoot() || !isRoot(args.Spec) {
+		return fmt.Errorf("only root containers can be adopted")
+	}
+	if args.Spec.Process.Terminal || args.ConsoleSocket != "" {
+		return fmt.Errorf("cannot adopt container %q: terminals are not supported", args.ID)
+	}
+
+	// Reserve the new container ID.
+	saver := StateFile{
+		RootDir: conf.RootDir,
+		ID: FullID{
+			SandboxID:   args.ID,
+			ContainerID: args.ID,
+		},
+	}
+	if err := saver.LockForNew(); err != nil {
+		return fmt.Errorf("cannot lock container metadata file: %w", err)
+	}
+	cu := cleanup.Make(func() {
+		saver.UnlockOrDie()
+		_ = saver.Destroy()
+		_ = saver.close()
+	})
+	defer cu.Clean()
+
+	if err := modifySpecForDirectfs(conf, args.Spec); err != nil {
+		return fmt.Errorf("failed to modify spec for directfs: %v", err)
+	}
+	if err := nvProxyPreGoferHostSetup(args.Spec, conf); err != nil {
+		return err
+	}
+	for _, err := range c.removeSelfFilestores(c.Sandbox) {
+		log.Warningf("%v", err)
+	}
+
+	// Self-backed filestores are named after the sandbox ID, so the new ID must
+	// be in place before the gofer is created.
+	oldGoferPid := c.GoferPid
+	oldID := c.Saver.ID
+	c.ID = args.ID
+	c.Spec = args.Spec
+	c.BundleDir = args.BundleDir
+	c.Saver.ID = saver.ID
+
+	oldCgroup, oldCompatCgroup := c.Sandbox.CgroupJSON.Cgroup, c.CompatCgroup.Cgroup
+	sandboxCgroup, compatCgroup, err := c.setupSandboxCgroups(conf, c.Spec, c.ID)
+	if err != nil {
+		c.Saver.ID = oldID
+		return err
+	}
+	cgroupCU := cleanup.Make(func() {
+		if compatCgroup != nil && compatCgroup != sandboxCgroup {
+			if err := compatCgroup.Uninstall(); err != nil {
+				log.Warningf("Error uninstalling cgroup: %v", err)
+			}
+		}
+		if sandboxCgroup != nil {
+			if err := sandboxCgroup.Uninstall(); err != nil {
+				log.Warningf("Error uninstalling cgroup: %v", err)
+			}
+		}
+	})
+	defer cgroupCU.Clean()
+	if sandboxCgroup != nil && oldCgroup == nil {
+		c.Saver.ID = oldID
+		return fmt.Errorf("cannot adopt container %q: the blank sandbox was created without a cgroup", args.ID)
+	}
+
+	err = runInCgroup(sandboxCgroup, func() error {
+		goferFiles, goferFilestores, devIOFile, mountsFile, err := c.createGoferProcess(conf, c.Sandbox.MountHints, false /* attached */)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if mountsFile != nil {
+				_ = mountsFile.Close()
+			}
+			if devIOFile != nil {
+				_ = devIOFile.Close()
+			}
+			for _, f := range goferFiles {
+				_ = f.Close()
+			}
+			for _, f := range goferFilestores {
+				_ = f.Close()
+			}
+		}()
+		if mountsFile != nil {
+			cleanMounts, err := specutils.ReadMounts(mountsFile)
+			if err != nil {
+				return fmt.Errorf("reading mounts file: %v", err)
+			}
+			c.Spec.Mounts = cleanMounts
+		}
+
+		stdios := []*os.File{os.Stdin, os.Stdout, os.Stderr}
+		if err := c.Sandbox.AdoptRoot(c.Spec, conf, c.ID, stdios, goferFiles, goferFilestores, devIOFile, c.GoferMountConfs); err != nil {
+			return err
+		}
+		if sandboxCgroup == nil {
+			// The sandbox keeps the cgroup it was created in, if any.
+			return nil
+		}
+		// Move the sandbox, including processes that it created, e.g. stub
+		// processes of the platform. The blank container's gofer is moved as
+		// well, but it is killed below.
+		if err := cgroup.MoveProcesses(oldCgroup, sandboxCgroup); err != nil {
+			return fmt.Errorf("moving sandbox to cgroup: %w", err)
+		}
+		return nil
+	})
+	c.Saver.ID = oldID
+	if err != nil {
+		if c.GoferPid != oldGoferPid && c.GoferPid != 0 {
+			_ = unix.Kill(c.GoferPid, unix.SIGKILL)
+		}
+		return err
+	}
+	if sandboxCgroup != nil {
+		cgroupCU.Release()
+		c.Sandbox.CgroupJSON = cgroup.CgroupJSON{Cgroup: sandboxCgroup}
+		c.CompatCgroup = cgroup.CgroupJSON{Cgroup: compatCgroup}
+		if oldCompatCgroup != nil && oldCompatCgroup != oldCgroup {
+			if err := oldCompatCgroup.Uninstall(); err != nil {
+				log.Warningf("Error uninstalling cgroup of the blank sandbox: %v", err)
+			}
+		}
+		if err := oldCgroup.Uninstall(); err != nil {
+			log.Warningf("Error uninstalling cgroup of the blank sandbox: %v", err)
+		}
+	}
+
+	// The blank container's gofer is no longer used by the sandbox.
+	if oldGoferPid != 0 {
+		log.Debugf("Killing gofer for adopted container, PID: %d", oldGoferPid)
+		if err := unix.Kill(oldGoferPid, unix.SIGKILL); err != nil {
+			log.Warningf("Error sending signal %d to gofer %d: %v", unix.SIGKILL, oldGoferPid, err)
+		}
+	}
+
+	// Move the metadata file over to the new ID.
+	cu.Release()
+	if err := c.Saver.moveTo(&saver); err != nil {
+		return err
+	}
+	c.CreatedAt = time.Now()
+	if err := c.saveLocked(); err != nil {
+		return err
+	}
+
+	if c.Spec.Hooks != nil {
+		if err := executeHooks(c.Spec.Hooks.Prestart, c.State()); err != nil {
+			return err
+		}
+		if err := executeHooks(c.Spec.Hooks.CreateRuntime, c.State()); err != nil {
+			return err
+		}
+	}
+
+	if args.PIDFile != "" {
+		if err := os.WriteFile(args.PIDFile, []byte(strconv.Itoa(c.SandboxPid())), 0644); err != nil {
+			return fmt.Errorf("error writing PID file: %v", err)
+		}
+	}
+	return nil
+}
+
 func (c *Container) startImpl(conf *config.Config, action string, startRoot func(conf *config.Config, spec *specs.Spec) error, startSub func(spec *specs.Spec, conf *config.Config, cid string, stdios, goferFiles, goferFilestores []*os.File, devIOFile *os.File, goferConfs []boot.GoferMountConf) error) error {
 	if err := c.Saver.lock(BlockAcquire); err != nil {
 		return err
@@ -605,12 +788,52 @@ func (c *Container) SandboxPid() int {
 // and wait returns immediately.
 func (c *Container) Wait() (unix.WaitStatus, error) {
 	log.Debugf("Wait on container, cid: %s", c.ID)
-	ws, err := c.Sandbox.Wait(c.ID)
-	if err == nil {
-		// Wait succeeded, container is not running anymore.
-		c.changeStatus(Stopped)
+	for {
+		pid := c.Sandbox.Getpid()
+		ws, err := c.Sandbox.Wait(c.ID)
+		if c.reloadUpgradedSandbox(pid) {
+			continue
+		}
+		if err == nil && c.idleCheckpointed() {
+			// The sandbox exited after checkpointing itself, the container
+			// only hibernates until it's restored.
+			restored, err := c.waitIdle()
+			if err != nil {
+				return ws, fmt.Errorf("waiting on idle container: %w", err)
+			}
+			if restored {
+				continue
+			}
+		}
+		if err == nil {
+			// Wait succeeded, container is not running anymore.
+			c.changeStatus(Stopped)
+		}
+		return ws, err
+	}
+}
+
+// reloadUpgradedSandbox is called when a wait on the sandbox process with PID
+// pid has returned. Upgrade kills the sandbox process once a new one has taken
+// over, which ends waits on it without the container having exited. If that
+// happened, reloadUpgradedSandbox updates c.Sandbox and returns true, and the
+// wait should be retried.
+func (c *Container) reloadUpgradedSandbox(pid int) bool {
+	if pid == 0 || c.Sandbox.IsRunning() {
+		return false
+	}
+	// Upgrade holds the state file lock until the new sandbox process has
+	// restored the container, so this waits for it to complete.
+	nc, err := Load(c.Saver.RootDir, c.Saver.ID, LoadOpts{Exact: true, SkipCheck: true})
+	if err != nil || nc.Sandbox == nil {
+		return false
+	}
+	if newPid := nc.Sandbox.Getpid(); newPid == 0 || newPid == pid || !nc.Sandbox.IsRunning() {
+		return false
 	}
-	return ws, err
+	log.Infof("Sandbox %q was upgraded, waiting on new sandbox process %d", c.Sandbox.ID, nc.Sandbox.Getpid())
+	c.Sandbox = nc.Sandbox
+	return true
 }
 
 // WaitRootPID waits for process 'pid' in the sandbox's PID namespace and
@@ -620,7 +843,14 @@ func (c *Container) WaitRootPID(pid int32) (unix.WaitStatus, error) {
 	if !c.IsSandboxRunning() {
 		return 0, fmt.Errorf("sandbox is not running")
 	}
-	return c.Sandbox.WaitPID(c.Sandbox.ID, pid)
+	for {
+		sandboxPid := c.Sandbox.Getpid()
+		ws, err := c.Sandbox.WaitPID(c.Sandbox.ID, pid)
+		if err != nil && c.reloadUpgradedSandbox(sandboxPid) {
+			continue
+		}
+		return ws, err
+	}
 }
 
 // WaitPID waits for process 'pid' in the container's PID namespace and returns
@@ -630,7 +860,14 @@ func (c *Container) WaitPID(pid int32) (unix.WaitStatus, error) {
 	if !c.IsSandboxRunning() {
 		return 0, fmt.Errorf("sandbox is not running")
 	}
-	return c.Sandbox.WaitPID(c.ID, pid)
+	for {
+		sandboxPid := c.Sandbox.Getpid()
+		ws, err := c.Sandbox.WaitPID(c.ID, pid)
+		if err != nil && c.reloadUpgradedSandbox(sandboxPid) {
+			continue
+		}
+		return ws, err
+	}
 }
 
 // WaitCheckpoint waits for the Kernel to have been successfully checkpointed.
@@ -808,22 +1045,10 @@ func (c *Container) Destroy() error {
 	}
 
 	// Clean up self-backed filestore files created in their respective mounts.
-	c.forEachSelfMount(func(mountSrc string) {
-		if sb != nil {
-			if hint := sb.MountHints.FindMount(mountSrc); hint != nil && hint.ShouldShareMount() {
-				// Don't delete filestore file for shared mounts. The sandbox owns a
-				// shared master mount which uses this filestore and is shared with
-				// multiple mount points.
-				return
-			}
-		}
-		filestorePath := boot.SelfFilestorePath(mountSrc, c.sandboxID())
-		if err := os.Remove(filestorePath); err != nil {
-			err = fmt.Errorf("failed to delete filestore file %q: %v", filestorePath, err)
-			log.Warningf("%v", err)
-			errs = append(errs, err.Error())
-		}
-	})
+	for _, err := range c.removeSelfFilestores(sb) {
+		log.Warningf("%v", err)
+		errs = append(errs, err.Error())
+	}
 	if sb != nil && sb.IsRootContainer(c.ID) {
 		// When the root container is being destroyed, we can clean up filestores
 		// used by shared mounts.
@@ -840,6 +1065,13 @@ func (c *Container) Destroy() error {
 				errs = append(errs, err.Error())
 			}
 		}
+		if sb.IdleImagePath != "" {
+			if err := os.RemoveAll(sb.IdleImagePath); err != nil {
+				err = fmt.Errorf("failed to delete idle checkpoint image %q: %v", sb.IdleImagePath, err)
+				log.Warningf("%v", err)
+				errs = append(errs, err.Error())
+			}
+		}
 	}
 
 	c.changeStatus(Stopped)
@@ -875,6 +1107,93 @@ func (c *Container) Destroy() error {
 	return fmt.Errorf("%s", strings.Join(errs, "\n"))
 }
 
+// Restart stops all processes of a subcontainer and starts it again from its
+// spec, leaving the other containers in the sandbox running. The container's
+// gofer and mounts are torn down and recreated, like a container crashing and
+// being restarted in a pod.
+func (c *Container) Restart(conf *config.Config) error {
+	log.Debugf("Restart container, cid: %s", c.ID)
+	if err := c.Saver.lock(BlockAcquire); err != nil {
+		return err
+	}
+	unlock := cleanup.Make(c.Saver.UnlockOrDie)
+	defer unlock.Clean()
+
+	if c.IsSandboxRoot() {
+		return fmt.Errorf("cannot restart root container %q, the sandbox must be restarted instead", c.ID)
+	}
+	if err := c.requireStatus("restart", Running, Stopped); err != nil {
+		return err
+	}
+	if !c.IsSandboxRunning() {
+		return fmt.Errorf("sandbox is not running")
+	}
+
+	// Tear down the container's processes, mounts, gofer and cgroups inside
+	// the sandbox. The sandbox and the container's host cgroup are kept.
+	if err := c.Sandbox.DestroyContainer(c.ID); err != nil {
+		return fmt.Errorf("destroying container %q: %v", c.ID, err)
+	}
+	if c.GoferPid != 0 {
+		log.Debugf("Killing gofer for container, cid: %s, PID: %d", c.ID, c.GoferPid)
+		if err := unix.Kill(c.GoferPid, unix.SIGKILL); err != nil {
+			log.Warningf("Error sending signal %d to gofer %d: %v", unix.SIGKILL, c.GoferPid, err)
+		}
+	}
+	if err := c.waitForStopped(); err != nil {
+		return err
+	}
+	if errs := c.removeSelfFilestores(c.Sandbox); len(errs) != 0 {
+		return errs[0]
+	}
+	c.changeStatus(Stopped)
+	if err := c.saveLocked(); err != nil {
+		return err
+	}
+
+	var tty *os.File
+	if c.ConsoleSocket != "" {
+		var err error
+		tty, err = console.NewWithSocket(c.ConsoleSocket)
+		if err != nil {
+			return fmt.Errorf("setting up console with socket %q: %w", c.ConsoleSocket, err)
+		}
+		defer tty.Close()
+	}
+	if err := c.Sandbox.CreateSubcontainer(conf, c.ID, tty); err != nil {
+		return fmt.Errorf("cannot create subcontainer: %w", err)
+	}
+	c.changeStatus(Created)
+	if err := c.saveLocked(); err != nil {
+		return err
+	}
+
+	// Start takes the lock again.
+	unlock.Clean()
+	return c.Start(conf)
+}
+
+// removeSelfFilestores deletes the self-backed filestore files created in the
+// container's mounts. Filestores of mounts shared in sb are left in place.
+func (c *Container) removeSelfFilestores(sb *sandbox.Sandbox) []error {
+	var errs []error
+	c.forEachSelfMount(func(mountSrc string) {
+		if sb != nil {
+			if hint := sb.MountHints.FindMount(mountSrc); hint != nil && hint.ShouldShareMount() {
+				// Don't delete filestore file for shared mounts. The sandbox owns a
+				// shared master mount which uses this filestore and is shared with
+				// multiple mount points.
+				return
+			}
+		}
+		filestorePath := boot.SelfFilestorePath(mountSrc, c.sandboxID())
+		if err := os.Remove(filestorePath); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete filestore file %q: %v", filestorePath, err))
+		}
+	})
+	return errs
+}
+
 func (c *Container) sandboxID() string {
 	return c.Saver.ID.SandboxID
 }
@@ -968,14 +1287,22 @@ func (c *Container) initGoferConfs(ovlConf config.Overlay2, mountHints *boot.Pod
 		if specutils.IsReadonlyMount(c.Spec.Mounts[i].Options) {
 			overlayMedium = config.NoOverlay
 		}
-		if hint := mountHints.FindMount(c.Spec.Mounts[i].Source); hint != nil && hint.IsSandboxLocal() {
-			// Note that we want overlayMedium=self even if this is a read-only mount so that
-			// the shared mount is created correctly. Future containers may mount this writably.
-			overlayMedium = config.SelfOverlay
-			if !specutils.IsGoferMount(hint.Mount) {
-				mountType = hint.Mount.Type
+		if hint := mountHints.FindMount(c.Spec.Mounts[i].Source); hint != nil {
+			switch {
+			case hint.ShouldOverlay():
+				// Note that we want overlayMedium=self even if this is a read-only mount so that
+				// the shared mount is created correctly. Future containers may mount this writably.
+				overlayMedium = config.SelfOverlay
+				if !specutils.IsGoferMount(hint.Mount) {
+					mountType = hint.Mount.Type
+				}
+				overlaySize = ""
+			case hint.IsSandboxLocal():
+				// Each container has its own gofer mount of this volume. Overlaying
+				// them independently would hide writes made by one container from
+				// the others, so all writes must go through to the volume.
+				overlayMedium = config.NoOverlay
 			}
-			overlaySize = ""
 		}
 		goferConf, err := createGoferConf(overlayMedium, overlaySize, mountType, c.Spec.Mounts[i].Source)
 		if err != nil {
@@ -1210,6 +1537,33 @@ func shouldSpawnGofer(spec *specs.Spec, conf *config.Config, goferConfs []boot.G
 	return shouldCreateDeviceGofer(spec, conf)
 }
 
+// setSelfContainedGoferConfs checks that the container can run without a
+// gofer process and overlays its rootfs image with a memory-backed tmpfs.
+// Filestore-backed overlays are not used because filestores are created in the
+// gofer's mount namespace.
+func (c *Container) setSelfContainedGoferConfs(conf *config.Config) error {
+	if !c.GoferMountConfs[0].ShouldUseErofs() {
+		return fmt.Errorf("self-contained mode requires an EROFS rootfs image, set with the %q and %q annotations", boot.RootfsPrefix+"type", boot.RootfsPrefix+"source")
+	}
+	if !c.Spec.Root.Readonly {
+		c.GoferMountConfs[0].Upper = boot.MemoryOverlay
+	}
+	goferMntIdx := 1 // First index is for rootfs.
+	for _, m := range c.Spec.Mounts {
+		if !specutils.IsGoferMount(m) {
+			continue
+		}
+		if c.GoferMountConfs[goferMntIdx].ShouldUseLisafs() {
+			return fmt.Errorf("mount %q requires a gofer, which is not supported in self-contained mode", m.Destination)
+		}
+		goferMntIdx++
+	}
+	if shouldCreateDeviceGofer(c.Spec, conf) {
+		return fmt.Errorf("device proxies require a gofer, which is not supported in self-contained mode")
+	}
+	return nil
+}
+
 // createGoferProcess returns an IO file list and a mounts file on success.
 // The IO file list consists of image files and/or socket files to connect to
 // a gofer endpoint for the mount points using Gofers. The mounts file is the
@@ -1223,6 +1577,11 @@ func (c *Container) createGoferProcess(conf *config.Config, mountHints *boot.Pod
 	if err := c.initGoferConfs(conf.GetOverlay2(), mountHints, rootfsHint); err != nil {
 		return nil, nil, nil, nil, fmt.Errorf("error initializing gofer confs: %w", err)
 	}
+	if conf.SelfContained {
+		if err := c.setSelfContainedGoferConfs(conf); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
 	if !c.GoferMountConfs[0].ShouldUseLisafs() && specutils.GPUFunctionalityRequestedViaHook(c.Spec, conf) {
 		// nvidia-container-runtime-hook attempts to populate the container
 		// rootfs with NVIDIA libraries and devices. With EROFS, spec.Root.Path
@@ -1322,6 +1681,18 @@ func (c *Container) createGoferProcess(conf *config.Config, mountHints *boot.Pod
 	}
 	rpcClntFD, _ := rpcClnt.Release()
 	donations.DonateAndClose("rpc-fd", os.NewFile(uintptr(rpcClntFD), "gofer-rpc"))
+
+	if conf.GoferReadCache != "" {
+		// Each trust domain has its own cache, so that sandboxes can't learn
+		// or read files of other trust domains through it.
+		cacheDir := filepath.Join(conf.GoferReadCache, conf.GoferReadCacheDomain)
+		if err := os.MkdirAll(cacheDir, 0700); err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("creating gofer read cache directory: %w", err)
+		}
+		if err := donations.OpenAndDonate("read-cache-fd", cacheDir, os.O_RDONLY|unix.O_DIRECTORY); err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("opening gofer read cache directory: %w", err)
+		}
+	}
 	rpcPidCh := make(chan int, 1)
 	defer close(rpcPidCh)
 	go func() {
@@ -1472,7 +1843,8 @@ func (c *Container) changeStatus(s Status) {
 		panic(fmt.Sprintf("invalid state transition: %v => %v", c.Status, s))
 
 	case Created:
-		if c.Status != Creating {
+		// Stopped subcontainers are created again when restarted.
+		if c.Status != Creating && c.Status != Stopped {
 			panic(fmt.Sprintf("invalid state transition: %v => %v", c.Status, s))
 		}
 		if c.Sandbox == nil {
@@ -1736,6 +2108,32 @@ func (c *Container) createParentCgroup(parentPath string, conf *config.Config) (
 	return parentCgroup, nil
 }
 
+// setupSandboxCgroups sets up the cgroups of a new sandbox whose root
+// container has the given spec and ID. It returns the cgroup that the sandbox
+// and gofer processes must join, and the root container's cgroup, if any.
+func (c *Container) setupSandboxCgroups(conf *config.Config, spec *specs.Spec, id string) (cgroup.Cgroup, cgroup.Cgroup, error) {
+	if spec.Linux == nil {
+		spec.Linux = &specs.Linux{}
+	}
+	// Don't force the use of cgroups in tests because they lack permission to do so.
+	if spec.Linux.CgroupsPath == "" && !conf.TestOnlyAllowRunAsCurrentUserWithoutChroot {
+		spec.Linux.CgroupsPath = "/" + id
+	}
+	if conf.IgnoreCgroups {
+		return nil, nil, nil
+	}
+	parentCgroup, subCgroup, err := c.setupCgroupForRoot(conf, spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot set up cgroup for root: %w", err)
+	}
+	// Join the child cgroup when using cgroupfs. Joining non leaf-node
+	// cgroups is illegal in cgroupsv2 and will return EBUSY.
+	if subCgroup != nil && !conf.SystemdCgroup && cgroup.IsOnlyV2() {
+		return subCgroup, subCgroup, nil
+	}
+	return parentCgroup, subCgroup, nil
+}
+
 // setupCgroupForRoot configures and returns cgroup for the sandbox and the
 // root container. If `cgroupParentAnnotation` is set, use that path as the
 // sandbox cgroup and use Spec.Linux.CgroupsPath as the root container cgroup.
@@ -1838,12 +2236,14 @@ func (c *Container) donateGoferProfileFDs(conf *config.Config, donations *donati
 // resources. In case of success, returns the cgroups instance and nil error.
 // For rootless, it's possible that cgroups operations fail, in this case the
 // error is suppressed and a nil cgroups instance is returned to indicate that
-// no cgroups was configured.
+// no cgroups was configured. This doesn't apply with --systemd-cgroup, where
+// the cgroup is delegated by the user's systemd instance and failing to
+// configure it would leave the sandbox without resource limits.
 func cgroupInstall(conf *config.Config, cg cgroup.Cgroup, res *specs.LinuxResources) (cgroup.Cgroup, error) {
 	if err := cg.Install(res); err != nil {
 		switch {
-		case (errors.Is(err, unix.EACCES) || errors.Is(err, unix.EROFS)) && conf.Rootless:
-			log.Warningf("Skipping cgroup configuration in rootless mode: %v", err)
+		case (errors.Is(err, unix.EACCES) || errors.Is(err, unix.EROFS)) && conf.Rootless && !conf.SystemdCgroup:
+			log.Warningf("Skipping cgroup configuration in rootless mode, resource limits will not be applied (use --systemd-cgroup to apply them through the user's systemd instance): %v", err)
 			return nil, nil
 		default:
 			return nil, fmt.Errorf("configuring cgroup: %v", err)
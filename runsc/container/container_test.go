@@ -1095,7 +1095,7 @@ func testCheckpointRestore(t *testing.T, conf *config.Config, compression statef
 	}
 	defer cont2.Destroy()
 
-	if err := cont2.Restore(conf, dir, false /* direct */, false /* background */); err != nil {
+	if err := cont2.Restore(conf, dir, false /* direct */, false /* background */, false /* allowIncompatible */); err != nil {
 		t.Fatalf("error restoring container: %v", err)
 	}
 
@@ -1146,7 +1146,7 @@ func testCheckpointRestore(t *testing.T, conf *config.Config, compression statef
 	}
 	defer cont3.Destroy()
 
-	if err := cont3.Restore(conf, dir, false /* direct */, false /* background */); err != nil {
+	if err := cont3.Restore(conf, dir, false /* direct */, false /* background */, false /* allowIncompatible */); err != nil {
 		t.Fatalf("error restoring container: %v", err)
 	}
 
@@ -1280,7 +1280,7 @@ func TestCheckpointRestoreExecKilled(t *testing.T) {
 	}
 	defer cont2.Destroy()
 
-	if err := cont2.Restore(conf, dir, false /* direct */, false /* background */); err != nil {
+	if err := cont2.Restore(conf, dir, false /* direct */, false /* background */, false /* allowIncompatible */); err != nil {
 		t.Fatalf("error restoring container: %v", err)
 	}
 
@@ -1294,6 +1294,222 @@ func TestCheckpointRestoreExecKilled(t *testing.T) {
 	}
 }
 
+// TestIdleCheckpointRestore checks that an idle sandbox checkpoints itself and
+// exits, and that RestoreIdle restores it.
+func TestIdleCheckpointRestore(t *testing.T) {
+	spec, conf := sleepSpecConf(t)
+	dir, err := os.MkdirTemp(testutil.TmpDir(), "idle-checkpoint-test")
+	if err != nil {
+		t.Fatalf("os.MkdirTemp failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	conf.IdleCheckpointTimeout = time.Second
+	conf.IdleCheckpointImagePath = dir
+
+	_, bundleDir, cu, err := testutil.SetupContainer(spec, conf)
+	if err != nil {
+		t.Fatalf("error setting up container: %v", err)
+	}
+	defer cu()
+
+	args := Args{
+		ID:        testutil.RandomContainerID(),
+		Spec:      spec,
+		BundleDir: bundleDir,
+	}
+	cont, err := New(conf, args)
+	if err != nil {
+		t.Fatalf("error creating container: %v", err)
+	}
+	defer func() {
+		if cont != nil {
+			cont.Destroy()
+		}
+	}()
+	if err := cont.Start(conf); err != nil {
+		t.Fatalf("error starting container: %v", err)
+	}
+
+	// Wait on the container, which keeps waiting while it is idle and on the
+	// restored sandbox process.
+	waiter, err := Load(conf.RootDir, FullID{ContainerID: args.ID}, LoadOpts{})
+	if err != nil {
+		t.Fatalf("error loading container: %v", err)
+	}
+	waitCh := make(chan error, 1)
+	go func() {
+		ws, err := waiter.Wait()
+		if err == nil && ws.Signal() != unix.SIGKILL {
+			err = fmt.Errorf("got wait status %v, want SIGKILL", ws)
+		}
+		waitCh <- err
+	}()
+
+	// sleep doesn't use any CPU, so the sandbox checkpoints itself and exits.
+	idle := func() error {
+		var err error
+		cont, err = Load(conf.RootDir, FullID{ContainerID: args.ID}, LoadOpts{})
+		if err != nil {
+			return &backoff.PermanentError{Err: err}
+		}
+		if !cont.idleCheckpointed() {
+			return fmt.Errorf("container is not idle checkpointed")
+		}
+		return nil
+	}
+	if err := testutil.Poll(idle, 30*time.Second); err != nil {
+		t.Fatalf("error waiting for idle checkpoint: %v", err)
+	}
+	if cont.Status != Stopped {
+		t.Fatalf("got container status %v, want %v", cont.Status, Stopped)
+	}
+	select {
+	case err := <-waitCh:
+		t.Fatalf("wait returned for idle container: %v", err)
+	default:
+	}
+
+	cont, err = RestoreIdle(cont)
+	if err != nil {
+		t.Fatalf("error restoring idle container: %v", err)
+	}
+	if cont.Status != Running {
+		t.Fatalf("got container status %v, want %v", cont.Status, Running)
+	}
+	expectedPL := []*control.Process{
+		newProcessBuilder().Cmd("sleep").PID(1).Process(),
+	}
+	if err := waitForProcessList(cont, expectedPL); err != nil {
+		t.Fatalf("error waiting for restored process: %v", err)
+	}
+	execArgs := &control.ExecArgs{
+		Filename: "/bin/true",
+		Argv:     []string{"/bin/true"},
+	}
+	if ws, err := cont.executeSync(conf, execArgs); err != nil {
+		t.Fatalf("error executing in container: %v", err)
+	} else if es := ws.ExitStatus(); es != 0 {
+		t.Fatalf("exec got exit status %d, want 0", es)
+	}
+	select {
+	case err := <-waitCh:
+		t.Fatalf("wait returned after restore: %v", err)
+	default:
+	}
+
+	if err := cont.SignalContainer(unix.SIGKILL, false); err != nil {
+		t.Fatalf("error killing container: %v", err)
+	}
+	if err := <-waitCh; err != nil {
+		t.Errorf("error waiting on container: %v", err)
+	}
+}
+
+// TestUpgrade checks that a container keeps running when its sandbox process
+// is upgraded, and that waits on it survive the upgrade.
+func TestUpgrade(t *testing.T) {
+	spec, conf := sleepSpecConf(t)
+	conf.HotUpgrade = true
+	_, bundleDir, cu, err := testutil.SetupContainer(spec, conf)
+	if err != nil {
+		t.Fatalf("error setting up container: %v", err)
+	}
+	defer cu()
+
+	args := Args{
+		ID:        testutil.RandomContainerID(),
+		Spec:      spec,
+		BundleDir: bundleDir,
+	}
+	cont, err := New(conf, args)
+	if err != nil {
+		t.Fatalf("error creating container: %v", err)
+	}
+	defer cont.Destroy()
+	if err := cont.Start(conf); err != nil {
+		t.Fatalf("error starting container: %v", err)
+	}
+
+	// Start an exec'd process that exits without being waited for.
+	execArgs := &control.ExecArgs{
+		Filename: "/bin/sh",
+		Argv:     []string{"/bin/sh", "-c", "exit 3"},
+	}
+	execPID, err := cont.Execute(conf, execArgs)
+	if err != nil {
+		t.Fatalf("error executing in container: %v", err)
+	}
+	expectedPL := []*control.Process{
+		newProcessBuilder().Cmd("sleep").PID(1).Process(),
+	}
+	if err := waitForProcessList(cont, expectedPL); err != nil {
+		t.Fatalf("error waiting for exec'd process to exit: %v", err)
+	}
+
+	// Wait on the container from another process, which keeps waiting on the
+	// upgraded sandbox process.
+	waiter, err := Load(conf.RootDir, FullID{ContainerID: args.ID}, LoadOpts{})
+	if err != nil {
+		t.Fatalf("error loading container: %v", err)
+	}
+	waitCh := make(chan error, 1)
+	go func() {
+		ws, err := waiter.Wait()
+		if err == nil && ws.Signal() != unix.SIGKILL {
+			err = fmt.Errorf("got wait status %v, want SIGKILL", ws)
+		}
+		waitCh <- err
+	}()
+
+	oldPid := cont.Sandbox.Getpid()
+	if err := Upgrade(conf, cont, false /* background */); err != nil {
+		t.Fatalf("error upgrading sandbox: %v", err)
+	}
+	if newPid := cont.Sandbox.Getpid(); newPid == oldPid {
+		t.Fatalf("sandbox PID is still %d after upgrade", oldPid)
+	}
+	if err := unix.Kill(oldPid, 0); err == nil {
+		t.Errorf("old sandbox process %d is still running", oldPid)
+	}
+	if err := waitForProcessList(cont, expectedPL); err != nil {
+		t.Fatalf("error waiting for upgraded process: %v", err)
+	}
+	select {
+	case err := <-waitCh:
+		t.Fatalf("wait returned after upgrade: %v", err)
+	default:
+	}
+
+	// The exit status of the exec'd process is handed over.
+	if ws, err := cont.WaitPID(execPID); err != nil {
+		t.Fatalf("error waiting for exec'd process: %v", err)
+	} else if es := ws.ExitStatus(); es != 3 {
+		t.Errorf("exec'd process got exit status %d, want 3", es)
+	}
+
+	// Running exec'd processes cannot be handed over.
+	execArgs = &control.ExecArgs{
+		Filename: "/bin/sleep",
+		Argv:     []string{"/bin/sleep", "1000"},
+	}
+	if _, err := cont.Execute(conf, execArgs); err != nil {
+		t.Fatalf("error executing in container: %v", err)
+	}
+	upgradedPid := cont.Sandbox.Getpid()
+	if err := Upgrade(conf, cont, false /* background */); err == nil {
+		t.Fatalf("upgrade with a running exec'd process succeeded")
+	}
+	if pid := cont.Sandbox.Getpid(); pid != upgradedPid {
+		t.Fatalf("sandbox PID changed from %d to %d after failed upgrade", upgradedPid, pid)
+	}
+	if err := cont.SignalContainer(unix.SIGKILL, true /* all */); err != nil {
+		t.Fatalf("error killing container: %v", err)
+	}
+	if err := <-waitCh; err != nil {
+		t.Fatalf("error waiting for container: %v", err)
+	}
+}
+
 // TestCheckpointRestoreCreateMountPoint tests that mountpoints created during
 // container creation are re-created after checkpoint/restore.
 func TestCheckpointRestoreCreateMountPoint(t *testing.T) {
@@ -1365,7 +1581,7 @@ func TestCheckpointRestoreCreateMountPoint(t *testing.T) {
 	}
 	defer cont2.Destroy()
 
-	if err := cont2.Restore(conf, dir, false /* direct */, false /* background */); err != nil {
+	if err := cont2.Restore(conf, dir, false /* direct */, false /* background */, false /* allowIncompatible */); err != nil {
 		t.Fatalf("error restoring container: %v", err)
 	}
 
@@ -1477,7 +1693,7 @@ func TestUnixDomainSockets(t *testing.T) {
 			}
 			defer contRestore.Destroy()
 
-			if err := contRestore.Restore(conf, dir, false /* direct */, false /* background */); err != nil {
+			if err := contRestore.Restore(conf, dir, false /* direct */, false /* background */, false /* allowIncompatible */); err != nil {
 				t.Fatalf("error restoring container: %v", err)
 			}
 
@@ -2711,6 +2927,46 @@ func TestRlimitsExec(t *testing.T) {
 	}
 }
 
+// TestVDSOAnnotation checks that the VDSO annotation disables the VDSO only
+// if --allow-vdso-annotation is set.
+func TestVDSOAnnotation(t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		t.Run(fmt.Sprintf("allow=%t", allow), func(t *testing.T) {
+			spec, conf := sleepSpecConf(t)
+			conf.AllowVDSOAnnotation = allow
+			spec.Annotations = map[string]string{boot.VDSOAnnotation: "none"}
+
+			_, bundleDir, cleanup, err := testutil.SetupContainer(spec, conf)
+			if err != nil {
+				t.Fatalf("error setting up container: %v", err)
+			}
+			defer cleanup()
+
+			args := Args{
+				ID:        testutil.RandomContainerID(),
+				Spec:      spec,
+				BundleDir: bundleDir,
+			}
+			cont, err := New(conf, args)
+			if err != nil {
+				t.Fatalf("error creating container: %v", err)
+			}
+			defer cont.Destroy()
+			if err := cont.Start(conf); err != nil {
+				t.Fatalf("error starting container: %v", err)
+			}
+
+			out, err := executeCombinedOutput(conf, cont, nil, "/bin/sh", "-c", "cat /proc/self/maps")
+			if err != nil {
+				t.Fatalf("error reading /proc/self/maps: %v", err)
+			}
+			if got, want := strings.Contains(string(out), "[vdso]"), !allow; got != want {
+				t.Errorf("[vdso] mapped: got %t, want %t, /proc/self/maps:\n%s", got, want, out)
+			}
+		})
+	}
+}
+
 // TestUsage checks that usage generates the expected memory usage.
 func TestUsage(t *testing.T) {
 	spec, conf := sleepSpecConf(t)
@@ -2841,7 +3097,7 @@ func TestUsageFD(t *testing.T) {
 	}
 	defer cont2.Destroy()
 
-	if err := cont2.Restore(conf, dir, false /* direct */, false /* background */); err != nil {
+	if err := cont2.Restore(conf, dir, false /* direct */, false /* background */, false /* allowIncompatible */); err != nil {
 		t.Fatalf("error restoring container: %v", err)
 	}
 
@@ -3526,6 +3782,86 @@ func TestRootfsEROFS(t *testing.T) {
 	}
 }
 
+// TestSelfContained checks that a container with an EROFS rootfs runs without a
+// gofer process in self-contained mode, and that its rootfs is writable.
+func TestSelfContained(t *testing.T) {
+	// Skip this test if mkfs.erofs or busybox are not available.
+	skipIfNotAvailable(t, "mkfs.erofs", "busybox")
+
+	testDir, err := os.MkdirTemp(testutil.TmpDir(), "self_contained_test_")
+	if err != nil {
+		t.Fatalf("os.MkdirTemp() failed: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	rootfsDir, rootfsImage, err := createRootfsEROFS(testDir)
+	if err != nil {
+		t.Fatalf("failed to create EROFS rootfs image: %v", err)
+	}
+
+	conf := testutil.TestConfig(t)
+	conf.SelfContained = true
+
+	spec := testutil.NewSpecWithArgs("/busybox", "sh", "-c", "echo foo > /foo && grep '/ / rw - overlay' /proc/self/mountinfo")
+	spec.Root.Path = rootfsDir
+	if spec.Annotations == nil {
+		spec.Annotations = make(map[string]string)
+	}
+	spec.Annotations[boot.RootfsPrefix+"type"] = erofs.Name
+	spec.Annotations[boot.RootfsPrefix+"source"] = rootfsImage
+
+	_, bundleDir, cleanup, err := testutil.SetupContainer(spec, conf)
+	if err != nil {
+		t.Fatalf("error setting up container: %v", err)
+	}
+	defer cleanup()
+
+	args := Args{
+		ID:        testutil.RandomContainerID(),
+		Spec:      spec,
+		BundleDir: bundleDir,
+	}
+	c, err := New(conf, args)
+	if err != nil {
+		t.Fatalf("error creating container: %v", err)
+	}
+	defer c.Destroy()
+	if c.GoferPid != 0 {
+		t.Errorf("gofer started in self-contained mode, pid: %d", c.GoferPid)
+	}
+	if err := c.Start(conf); err != nil {
+		t.Fatalf("error starting container: %v", err)
+	}
+	ws, err := c.Wait()
+	if err != nil {
+		t.Fatalf("error waiting for container: %v", err)
+	}
+	if ws.ExitStatus() != 0 {
+		t.Errorf("got exit status %v want %v", ws.ExitStatus(), 0)
+	}
+
+	// Bind mounts need a gofer and are rejected.
+	spec.Mounts = append(spec.Mounts, specs.Mount{
+		Type:        "bind",
+		Destination: "/tmp",
+		Source:      "/tmp",
+	})
+	_, bundleDir, cleanup2, err := testutil.SetupContainer(spec, conf)
+	if err != nil {
+		t.Fatalf("error setting up container: %v", err)
+	}
+	defer cleanup2()
+	args = Args{
+		ID:        testutil.RandomContainerID(),
+		Spec:      spec,
+		BundleDir: bundleDir,
+	}
+	if c, err := New(conf, args); err == nil {
+		c.Destroy()
+		t.Fatalf("New() with a bind mount succeeded in self-contained mode")
+	}
+}
+
 // TestCheckpointRestoreEROFS does the checkpoint/restore test on each platform using
 // an EROFS image as the rootfs.
 func TestCheckpointRestoreEROFS(t *testing.T) {
@@ -3977,7 +4313,7 @@ func TestSpecValidation(t *testing.T) {
 			}
 			defer cont2.Destroy()
 
-			err = cont2.Restore(conf, dir, false /* direct */, false /* background */)
+			err = cont2.Restore(conf, dir, false /* direct */, false /* background */, false /* allowIncompatible */)
 			if err == nil {
 				if test.wantErr == "" {
 					return
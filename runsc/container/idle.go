@@ -0,0 +1,245 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/runsc/boot"
+	"gvisor.dev/gvisor/runsc/config"
+	"gvisor.dev/gvisor/runsc/flag"
+	"gvisor.dev/gvisor/runsc/specutils"
+)
+
+// idleWakePollInterval is the interval at which a waiter on an idle
+// checkpointed container checks whether another process restored it.
+const idleWakePollInterval = time.Second
+
+// restoringSuffix is appended to the path of an idle checkpoint image while
+// it is being restored.
+const restoringSuffix = ".restoring"
+
+// idleCheckpointed returns true if c is the root container of a sandbox that
+// checkpointed itself and exited after being idle for longer than
+// --idle-checkpoint-timeout (see boot.Loader.startIdleMonitor).
+func (c *Container) idleCheckpointed() bool {
+	if c.Sandbox == nil || c.Sandbox.IdleImagePath == "" || !c.IsSandboxRoot() || c.Sandbox.IsRunning() {
+		return false
+	}
+	stateFilePath := filepath.Join(c.Sandbox.IdleImagePath, boot.CheckpointStateFileName)
+	fi, err := os.Stat(stateFilePath)
+	return err == nil && fi.Size() != 0
+}
+
+// waitIdle waits until c, which is idle checkpointed, is needed again. This is
+// the case when a packet arrives for the sandbox's network namespace, which is
+// then restored, or when another process (e.g. "runsc exec") restored it. If c
+// was restored, c.Sandbox is updated to the restored sandbox and waitIdle
+// returns true. If c was destroyed instead, waitIdle returns false.
+func (c *Container) waitIdle() (bool, error) {
+	pid := c.Sandbox.Getpid()
+	imagePath := c.Sandbox.IdleImagePath
+	log.Infof("Container %q is idle checkpointed, waiting for it to be needed", c.ID)
+
+	var waker *packetWaker
+	if ns, ok := specutils.GetNS(specs.NetworkNamespace, c.Spec); ok && ns.Path != "" {
+		var err error
+		if waker, err = newPacketWaker(ns.Path); err != nil {
+			// The container can still be restored by exec.
+			log.Warningf("Failed to watch network namespace %q, the container is only restored by exec: %v", ns.Path, err)
+		} else {
+			defer waker.close()
+		}
+	}
+
+	for {
+		if c.reloadUpgradedSandbox(pid) {
+			return true, nil
+		}
+		if !c.idleCheckpointed() {
+			if _, err := os.Stat(imagePath + restoringSuffix); err != nil {
+				// The image was neither restored nor is being restored,
+				// so the container was destroyed.
+				return false, nil
+			}
+			// Another process is restoring the container.
+			time.Sleep(idleWakePollInterval)
+			continue
+		}
+		if waker == nil {
+			time.Sleep(idleWakePollInterval)
+			continue
+		}
+		woken, err := waker.wait(idleWakePollInterval)
+		if err != nil {
+			return false, fmt.Errorf("waiting for network activity: %w", err)
+		}
+		if !woken {
+			continue
+		}
+		log.Infof("Network activity for idle container %q, restoring it", c.ID)
+		restored, err := RestoreIdle(c)
+		if err != nil {
+			return false, err
+		}
+		c.Sandbox = restored.Sandbox
+		return true, nil
+	}
+}
+
+// RestoreIdle restores the root container c if it is idle checkpointed (see
+// Container.idleCheckpointed). The restored container is returned; c is
+// returned unchanged if there is nothing to restore.
+//
+// The container is restored with the configuration its sandbox was created
+// with, and its stdio is connected to the null device, since the original
+// stdio didn't outlive the sandbox.
+func RestoreIdle(c *Container) (*Container, error) {
+	if c.Status != Stopped || !c.idleCheckpointed() {
+		return c, nil
+	}
+	imagePath := c.Sandbox.IdleImagePath
+
+	// Move the image aside, since the new sandbox creates its own idle
+	// checkpoint files in imagePath. This also ensures that only one caller
+	// restores the container.
+	restorePath := imagePath + restoringSuffix
+	if err := os.Rename(imagePath, restorePath); err != nil {
+		if os.IsNotExist(err) {
+			log.Infof("Idle container %q is being restored by another process", c.ID)
+			return Load(c.Saver.RootDir, c.Saver.ID, LoadOpts{})
+		}
+		return nil, fmt.Errorf("moving idle checkpoint image %q: %w", imagePath, err)
+	}
+	// Pages may still be loaded in the background after Restore returns, but
+	// they are read through file descriptors that remain valid after removal.
+	defer os.RemoveAll(restorePath)
+
+	conf, err := idleConf(c.Sandbox.IdleConf)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sandbox configuration: %w", err)
+	}
+	log.Infof("Restoring idle container %q from %q", c.ID, restorePath)
+	spec, err := specutils.ReadSpec(c.BundleDir, conf)
+	if err != nil {
+		return nil, fmt.Errorf("reading spec: %w", err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer devNull.Close()
+	args := Args{
+		ID:         c.ID,
+		Spec:       spec,
+		BundleDir:  c.BundleDir,
+		StdioFiles: []*os.File{devNull, devNull, devNull},
+	}
+	if err := c.Destroy(); err != nil {
+		return nil, fmt.Errorf("destroying idle container: %w", err)
+	}
+
+	restored, err := New(conf, args)
+	if err != nil {
+		return nil, fmt.Errorf("creating container: %w", err)
+	}
+	if err := restored.Restore(conf, restorePath, false /* direct */, true /* background */, false /* allowIncompatible */); err != nil {
+		_ = restored.Destroy()
+		return nil, fmt.Errorf("restoring idle container: %w", err)
+	}
+	return restored, nil
+}
+
+// idleConf returns the configuration described by flags, as returned by
+// config.Config.ToFlags.
+func idleConf(flags []string) (*config.Config, error) {
+	flagSet := flag.NewFlagSet("idle", flag.ContinueOnError)
+	config.RegisterFlags(flagSet)
+	if err := flagSet.Parse(flags); err != nil {
+		return nil, err
+	}
+	return config.NewFromFlags(flagSet)
+}
+
+const (
+	ethPAll = 0x0300 // htons(ETH_P_ALL)
+	ethPARP = 0x0608 // htons(ETH_P_ARP)
+)
+
+// packetWaker detects packets arriving in a network namespace.
+type packetWaker struct {
+	fd int
+}
+
+// newPacketWaker returns a packetWaker for the network namespace at nsPath.
+func newPacketWaker(nsPath string) (*packetWaker, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	restoreNS, err := specutils.ApplyNS(specs.LinuxNamespace{
+		Type: specs.NetworkNamespace,
+		Path: nsPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("joining net namespace %q: %w", nsPath, err)
+	}
+	defer restoreNS()
+	// Packet sockets belong to the network namespace they are created in, and
+	// receive packets from all interfaces in it if unbound.
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, ethPAll)
+	if err != nil {
+		return nil, fmt.Errorf("creating packet socket: %w", err)
+	}
+	return &packetWaker{fd: fd}, nil
+}
+
+// wait waits for up to timeout for a packet that may be the start of a
+// connection to the sandbox, and returns true if one arrived. These are
+// unicast packets to the sandbox, and ARP packets, since the sandbox's
+// addresses must be resolved before a peer can connect to it.
+func (w *packetWaker) wait(timeout time.Duration) (bool, error) {
+	pfd := []unix.PollFd{{Fd: int32(w.fd), Events: unix.POLLIN}}
+	if _, err := unix.Poll(pfd, int(timeout.Milliseconds())); err != nil && err != unix.EINTR {
+		return false, err
+	}
+	buf := make([]byte, 1)
+	for {
+		_, from, err := unix.Recvfrom(w.fd, buf, unix.MSG_TRUNC)
+		if err == unix.EAGAIN || err == unix.EINTR {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		ll, ok := from.(*unix.SockaddrLinklayer)
+		if !ok {
+			continue
+		}
+		if ll.Pkttype == unix.PACKET_HOST || (ll.Protocol == ethPARP && ll.Pkttype != unix.PACKET_OUTGOING) {
+			return true, nil
+		}
+	}
+}
+
+// close releases the resources held by w.
+func (w *packetWaker) close() {
+	_ = unix.Close(w.fd)
+}
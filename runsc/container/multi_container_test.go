@@ -131,7 +131,7 @@ func restoreContainers(conf *config.Config, specs []*specs.Spec, ids []string, i
 		cu.Add(func() { cont.Destroy() })
 		containers = append(containers, cont)
 
-		if err := cont.Restore(conf, imagePath, false /* direct */, false /* background */); err != nil {
+		if err := cont.Restore(conf, imagePath, false /* direct */, false /* background */, false /* allowIncompatible */); err != nil {
 			return nil, nil, fmt.Errorf("error restoring container: %v", err)
 		}
 
@@ -454,6 +454,42 @@ func TestMultiPIDNSPath(t *testing.T) {
 	}
 }
 
+// TestMultiPIDNSContainerAnnotation checks that a container can join the PID
+// namespace of another container by its ID, as debug containers do.
+func TestMultiPIDNSContainerAnnotation(t *testing.T) {
+	rootDir, cleanup, err := testutil.SetupRootDir()
+	if err != nil {
+		t.Fatalf("error creating root dir: %v", err)
+	}
+	defer cleanup()
+
+	conf := testutil.TestConfig(t)
+	conf.RootDir = rootDir
+
+	// Note: use curly braces to keep 'sh' process around as PID 1 of the
+	// second container's PID namespace.
+	podSpecs, ids := createSpecs(
+		sleepCmd,
+		[]string{"sh", "-c", "{ sleep 1000; }"},
+		[]string{"sh", "-c", `test "$(cat /proc/1/comm)" = sh`})
+	podSpecs[1].Linux = &specs.Linux{
+		Namespaces: []specs.LinuxNamespace{{Type: "pid"}},
+	}
+	podSpecs[2].Annotations[boot.PIDNamespaceContainerAnnotation] = ids[1]
+
+	containers, cleanup, err := startContainers(conf, podSpecs, ids)
+	if err != nil {
+		t.Fatalf("error starting containers: %v", err)
+	}
+	defer cleanup()
+
+	if ws, err := containers[2].Wait(); err != nil {
+		t.Errorf("failed to wait for container: %v", err)
+	} else if es := ws.ExitStatus(); es != 0 {
+		t.Errorf("container did not see the PID namespace of %q, exit status: %d", ids[1], es)
+	}
+}
+
 // TestMultiPIDNSKill kills processes using PID when containers are using
 // different PID namespaces to ensure PID is taken from the root namespace.
 func TestMultiPIDNSKill(t *testing.T) {
@@ -1017,6 +1053,138 @@ func TestMultiContainerProcesses(t *testing.T) {
 	}
 }
 
+// TestMultiContainerRestart checks that a subcontainer can be restarted without
+// affecting the root container.
+func TestMultiContainerRestart(t *testing.T) {
+	rootDir, cleanup, err := testutil.SetupRootDir()
+	if err != nil {
+		t.Fatalf("error creating root dir: %v", err)
+	}
+	defer cleanup()
+
+	conf := testutil.TestConfig(t)
+	conf.RootDir = rootDir
+
+	specs, ids := createSpecs(sleepCmd, sleepCmd)
+	containers, cleanup, err := startContainers(conf, specs, ids)
+	if err != nil {
+		t.Fatalf("error starting containers: %v", err)
+	}
+	defer cleanup()
+
+	expectedPL0 := []*control.Process{
+		newProcessBuilder().PID(1).Cmd("sleep").Process(),
+	}
+	if err := waitForProcessList(containers[0], expectedPL0); err != nil {
+		t.Errorf("failed to wait for process to start: %v", err)
+	}
+	if err := waitForProcessList(containers[1], []*control.Process{
+		newProcessBuilder().PID(2).Cmd("sleep").Process(),
+	}); err != nil {
+		t.Errorf("failed to wait for process to start: %v", err)
+	}
+
+	// The root container can't be restarted on its own.
+	if err := containers[0].Restart(conf); err == nil {
+		t.Errorf("restarting root container succeeded, want error")
+	}
+
+	if err := containers[1].Restart(conf); err != nil {
+		t.Fatalf("error restarting container: %v", err)
+	}
+	if got, want := containers[1].Status, Running; got != want {
+		t.Errorf("container status after restart: got %v, want %v", got, want)
+	}
+
+	// The subcontainer runs a new init process, while the root container is
+	// unchanged.
+	if err := waitForProcessList(containers[1], []*control.Process{
+		newProcessBuilder().PID(3).Cmd("sleep").Process(),
+	}); err != nil {
+		t.Errorf("failed to wait for restarted process: %v", err)
+	}
+	if err := waitForProcessList(containers[0], expectedPL0); err != nil {
+		t.Errorf("root container changed after restart: %v", err)
+	}
+
+	// The restarted container can be restarted again.
+	if err := containers[1].Restart(conf); err != nil {
+		t.Fatalf("error restarting container again: %v", err)
+	}
+	if err := waitForProcessList(containers[1], []*control.Process{
+		newProcessBuilder().PID(4).Cmd("sleep").Process(),
+	}); err != nil {
+		t.Errorf("failed to wait for restarted process: %v", err)
+	}
+}
+
+// TestMultiContainerRestartCgroups checks that a subcontainer's cgroups,
+// including the ones its processes created, are torn down when it is
+// restarted, so that they can be created again.
+func TestMultiContainerRestartCgroups(t *testing.T) {
+	rootDir, cleanup, err := testutil.SetupRootDir()
+	if err != nil {
+		t.Fatalf("error creating root dir: %v", err)
+	}
+	defer cleanup()
+
+	conf := testutil.TestConfig(t)
+	conf.RootDir = rootDir
+
+	podSpecs, ids := createSpecs(
+		sleepCmd,
+		[]string{"sh", "-c", "mkdir /sys/fs/cgroup/memory/child && sleep 1000"})
+	mnt := specs.Mount{
+		Destination: "/sys/fs/cgroup",
+		Type:        "cgroup",
+	}
+	for _, spec := range podSpecs {
+		spec.Mounts = append(spec.Mounts, mnt)
+	}
+	createSharedMount(mnt, "cgroup-mount", podSpecs...)
+	containers, cleanup, err := startContainers(conf, podSpecs, ids)
+	if err != nil {
+		t.Fatalf("error starting containers: %v", err)
+	}
+	defer cleanup()
+
+	childCgroup := control.CgroupControlFile{
+		Controller: "memory",
+		Path:       "/" + containers[1].ID + "/child",
+		Name:       "memory.usage_in_bytes",
+	}
+	// The container only runs sleep if it created the child cgroup.
+	waitForSleep := func() error {
+		return testutil.Poll(func() error {
+			procs, err := containers[1].Processes()
+			if err != nil {
+				return &backoff.PermanentError{Err: err}
+			}
+			for _, p := range procs {
+				if p.Cmd == "sleep" {
+					return nil
+				}
+			}
+			return fmt.Errorf("sleep not running, got processes: %s", procListToString(procs))
+		}, 30*time.Second)
+	}
+	if err := waitForSleep(); err != nil {
+		t.Fatalf("container didn't start: %v", err)
+	}
+	if _, err := containers[1].Sandbox.CgroupsReadControlFile(childCgroup); err != nil {
+		t.Fatalf("child cgroup not found: %v", err)
+	}
+	// Creating the child cgroup fails after a restart if it was left behind.
+	for i := 0; i < 2; i++ {
+		if err := containers[1].Restart(conf); err != nil {
+			t.Fatalf("error restarting container: %v", err)
+		}
+		if err := waitForSleep(); err != nil {
+			t.Fatalf("container didn't start after restart: %v", err)
+		}
+	}
+}
+
 // TestMultiContainerKillAll checks that all process that belong to a container
 // are killed when SIGKILL is sent to *all* processes in that container.
 func TestMultiContainerKillAll(t *testing.T) {
@@ -1887,6 +2055,90 @@ func TestMultiContainerSharedBindMount(t *testing.T) {
 	}
 }
 
+// Test that file contents written through MAP_SHARED mappings and write(2) in
+// one container are immediately visible through read(2) and MAP_SHARED
+// mappings in another container, when both use their own gofer mount of a
+// volume shared across the pod.
+func TestMultiContainerSharedBindMountCoherence(t *testing.T) {
+	app, err := testutil.FindFile("test/cmd/test_app/test_app")
+	if err != nil {
+		t.Fatal("error finding test_app:", err)
+	}
+
+	testSharedMount(t, func(t *testing.T, conf *config.Config, sourceDir string, mntType string) {
+		if mntType != "bind" {
+			t.Skipf("This test is only for pod-shared bind mounts, skipping %q mount type", mntType)
+		}
+		podSpec, ids := createSpecs(sleepCmd, sleepCmd)
+		mnt := specs.Mount{
+			Destination: "/mydir/test",
+			Source:      sourceDir,
+			Type:        mntType,
+		}
+		for _, spec := range podSpec {
+			spec.Mounts = append(spec.Mounts, mnt)
+			spec.Annotations[boot.MountPrefix+"coherent.source"] = mnt.Source
+			spec.Annotations[boot.MountPrefix+"coherent.type"] = "bind"
+			spec.Annotations[boot.MountPrefix+"coherent.share"] = "pod"
+		}
+
+		containers, cleanup, err := startContainers(conf, podSpec, ids)
+		if err != nil {
+			t.Fatalf("error starting containers: %v", err)
+		}
+		defer cleanup()
+
+		file := path.Join(mnt.Destination, "data")
+		execs := []execDesc{
+			{
+				c:    containers[0],
+				cmd:  []string{app, "mmap-file", "--path", file, "--write", "producer"},
+				name: "write through mapping in container0",
+			},
+			{
+				c:    containers[1],
+				cmd:  []string{"/bin/sh", "-c", fmt.Sprintf("test \"$(cat %s)\" = producer", file)},
+				name: "mapped write visible to read(2) in container1",
+			},
+			{
+				c:    containers[1],
+				cmd:  []string{app, "mmap-file", "--path", file, "--want", "producer"},
+				name: "mapped write visible to mapping in container1",
+			},
+			{
+				c:    containers[1],
+				cmd:  []string{"/bin/sh", "-c", fmt.Sprintf("printf consumer > %s", file)},
+				name: "overwrite with write(2) in container1",
+			},
+			{
+				c:    containers[0],
+				cmd:  []string{app, "mmap-file", "--path", file, "--want", "consumer"},
+				name: "written data visible to mapping in container0",
+			},
+			{
+				c:    containers[1],
+				cmd:  []string{app, "mmap-file", "--path", file, "--write", "mapped-2"},
+				name: "write through mapping in container1",
+			},
+			{
+				c:    containers[0],
+				cmd:  []string{"/bin/sh", "-c", fmt.Sprintf("test \"$(cat %s)\" = mapped-2", file)},
+				name: "mapped write visible to read(2) in container0",
+			},
+		}
+		execMany(t, conf, execs)
+
+		// The volume is not overlaid, so the data must have reached the host.
+		got, err := os.ReadFile(path.Join(sourceDir, "data"))
+		if err != nil {
+			t.Fatalf("os.ReadFile() failed: %v", err)
+		}
+		if want := "mapped-2"; string(got) != want {
+			t.Errorf("host file contains %q, want: %q", got, want)
+		}
+	})
+}
+
 // Test that one container can send an FD to another container, even though
 // they have distinct MountNamespaces.
 func TestMultiContainerMultiRootCanHandleFDs(t *testing.T) {
@@ -420,6 +420,24 @@ func (s *StateFile) lockPath() string {
 	return buildPath(s.RootDir, s.ID, "lock")
 }
 
+// moveTo destroys the state created by s, which must be locked, and makes s
+// refer to dst instead. dst must have been locked with LockForNew; its lock is
+// transferred to s.
+func (s *StateFile) moveTo(dst *StateFile) error {
+	if err := s.Destroy(); err != nil {
+		return err
+	}
+	s.UnlockOrDie()
+	if err := s.close(); err != nil {
+		log.Warningf("Error closing lock file %q: %v", s.lockPath(), err)
+	}
+	s.RootDir = dst.RootDir
+	s.ID = dst.ID
+	s.flock = dst.flock
+	dst.flock = nil
+	return nil
+}
+
 // Destroy deletes all state created by the stateFile. It may be called with the
 // lock file held. In that case, the lock file must still be unlocked and
 // properly closed after destroy returns.
@@ -412,6 +412,7 @@ func TestProcfsDump(t *testing.T) {
 	}
 
 	wantCgroup := []kernel.TaskCgroupEntry{
+		{HierarchyID: 8, Controllers: "blkio", Path: "/"},
 		{HierarchyID: 7, Controllers: "pids", Path: "/"},
 		{HierarchyID: 6, Controllers: "memory", Path: "/"},
 		{HierarchyID: 5, Controllers: "job", Path: "/"},
@@ -421,7 +422,7 @@ func TestProcfsDump(t *testing.T) {
 		{HierarchyID: 1, Controllers: "cpu", Path: "/"},
 	}
 	if len(procfsDump[0].Cgroup) != len(wantCgroup) {
-		t.Errorf("expected 7 cgroup controllers, got %+v", procfsDump[0].Cgroup)
+		t.Errorf("expected 8 cgroup controllers, got %+v", procfsDump[0].Cgroup)
 	} else {
 		for i, cgroup := range procfsDump[0].Cgroup {
 			if cgroup != wantCgroup[i] {
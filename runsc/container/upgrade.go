@@ -0,0 +1,86 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/runsc/config"
+	"gvisor.dev/gvisor/runsc/sandbox"
+	"gvisor.dev/gvisor/runsc/specutils"
+)
+
+// Upgrade replaces the sandbox process of root container c with a new one
+// running the current runsc binary. The containers in the sandbox keep
+// running. See sandbox.Sandbox.Upgrade.
+//
+// The state files of all containers in the sandbox are locked during the
+// upgrade, so that other runsc commands wait for it to complete and then see
+// the new sandbox process.
+func Upgrade(conf *config.Config, c *Container, background bool) error {
+	log.Debugf("Upgrade sandbox, cid: %s", c.ID)
+	if c.Sandbox == nil || !c.IsSandboxRoot() {
+		return fmt.Errorf("container %q is not the root container of a sandbox", c.ID)
+	}
+	if err := c.requireStatus("upgrade", Running); err != nil {
+		return err
+	}
+	containers, err := LoadSandbox(conf.RootDir, c.Sandbox.ID, LoadOpts{})
+	if err != nil {
+		return fmt.Errorf("loading sandbox containers: %w", err)
+	}
+	upgradeContainers := make([]sandbox.UpgradeContainer, 0, len(containers))
+	for _, sc := range containers {
+		if err := sc.Saver.lock(BlockAcquire); err != nil {
+			return err
+		}
+		defer sc.Saver.UnlockOrDie()
+
+		// Containers that are created but not started are not saved.
+		if err := sc.requireStatus("upgrade", Running, Stopped); err != nil {
+			return err
+		}
+		upgradeContainers = append(upgradeContainers, sandbox.UpgradeContainer{
+			ID:              sc.ID,
+			Spec:            sc.Spec,
+			BundleDir:       sc.BundleDir,
+			GoferMountConfs: sc.GoferMountConfs,
+		})
+	}
+
+	oomScoreAdj, err := specutils.GetOOMScoreAdj(c.Sandbox.Getpid())
+	if err != nil {
+		return err
+	}
+	// Only the new sandbox process runs in the sandbox's cgroup. The saved
+	// state is held by this process, so that it isn't charged to the sandbox.
+	if err := c.Sandbox.Upgrade(conf, upgradeContainers, background, func(fn func() error) error {
+		return runInCgroup(c.Sandbox.CgroupJSON.Cgroup, fn)
+	}); err != nil {
+		return err
+	}
+	if err := setOOMScoreAdj(c.Sandbox.Getpid(), oomScoreAdj); err != nil {
+		log.Warningf("Failed to set oom_score_adj of the upgraded sandbox: %v", err)
+	}
+
+	for _, sc := range containers {
+		sc.Sandbox = c.Sandbox
+		if err := sc.saveLocked(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
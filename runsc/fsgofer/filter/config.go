@@ -254,3 +254,17 @@ var lisafsFilters = seccomp.MakeSyscallRules(map[uintptr]seccomp.SyscallRule{
 	unix.SYS_UNLINKAT:   seccomp.MatchAll{},
 	unix.SYS_UTIMENSAT:  seccomp.MatchAll{},
 })
+
+// hostLocksFilters are needed by the FRangeLock RPC.
+var hostLocksFilters = seccomp.MakeSyscallRules(map[uintptr]seccomp.SyscallRule{
+	unix.SYS_FCNTL: seccomp.Or{
+		seccomp.PerArg{
+			seccomp.AnyValue{},
+			seccomp.EqualTo(unix.F_OFD_SETLK),
+		},
+		seccomp.PerArg{
+			seccomp.AnyValue{},
+			seccomp.EqualTo(unix.F_OFD_GETLK),
+		},
+	},
+})
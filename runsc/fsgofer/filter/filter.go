@@ -29,6 +29,7 @@ type Options struct {
 	ProfileEnabled   bool
 	DirectFS         bool
 	CgoEnabled       bool
+	HostLocks        bool
 }
 
 // Install installs seccomp filters.
@@ -63,6 +64,9 @@ func Install(opt Options) error {
 	// When DirectFS is not enabled, filters for LisaFS are installed.
 	if !opt.DirectFS {
 		s.Merge(lisafsFilters)
+		if opt.HostLocks {
+			s.Merge(hostLocksFilters)
+		}
 	}
 
 	return seccomp.Install(s, seccomp.DenyNewExecMappings, seccomp.DefaultProgramOptions())
@@ -66,6 +66,10 @@ type Config struct {
 	// be donated to the client on Mount RPC.
 	DonateMountPointFD bool
 
+	// HostLocks indicates whether the client can set host locks with the
+	// FRangeLock RPC.
+	HostLocks bool
+
 	// Gofer process's RUID.
 	RUID int
 
@@ -77,6 +81,9 @@ type Config struct {
 
 	// Gofer process's EGID.
 	EGID int
+
+	// ReadCache, if not nil, is used to serve large files of read-only mounts.
+	ReadCache *ReadCache
 }
 
 var procSelfFD *rwfd.FD
@@ -162,7 +169,7 @@ func (s *LisafsServer) MaxMessageSize() uint32 {
 // SupportedMessages implements lisafs.ServerImpl.SupportedMessages.
 func (s *LisafsServer) SupportedMessages() []lisafs.MID {
 	// Note that Flush, FListXattr and FRemoveXattr are not supported.
-	return []lisafs.MID{
+	mids := []lisafs.MID{
 		lisafs.Mount,
 		lisafs.Channel,
 		lisafs.FStat,
@@ -193,6 +200,10 @@ func (s *LisafsServer) SupportedMessages() []lisafs.MID {
 		lisafs.Accept,
 		lisafs.ConnectWithCreds,
 	}
+	if s.config.HostLocks {
+		mids = append(mids, lisafs.FRangeLock)
+	}
+	return mids
 }
 
 // controlFDLisa implements lisafs.ControlFDImpl.
@@ -536,7 +547,14 @@ func (fd *controlFDLisa) Open(flags uint32) (*lisafs.OpenFD, int, error) {
 	switch {
 	case ftype == unix.S_IFREG:
 		// Best effort to donate file to the Sentry (for performance only).
-		hostFDToDonate, _ = unix.Dup(openHostFD)
+		if server.config.ReadCache != nil && fd.Conn().ReadOnly() {
+			// The sentry reads through the donated FD, so donate the cached
+			// copy of the file if there is one.
+			hostFDToDonate = server.config.ReadCache.Open(openHostFD)
+		}
+		if hostFDToDonate < 0 {
+			hostFDToDonate, _ = unix.Dup(openHostFD)
+		}
 
 	case ftype == unix.S_IFIFO,
 		ftype == unix.S_IFCHR,
@@ -1099,6 +1117,11 @@ func (fd *openFDLisa) Flush() error {
 	return nil
 }
 
+// RangeLock implements lisafs.OpenFDImpl.RangeLock.
+func (fd *openFDLisa) RangeLock(cmd int, lock *unix.Flock_t) error {
+	return unix.FcntlFlock(uintptr(fd.hostFD), cmd, lock)
+}
+
 // Getdent64 implements lisafs.OpenFDImpl.Getdent64.
 func (fd *openFDLisa) Getdent64(count uint32, seek0 bool, recordDirent func(lisafs.Dirent64)) error {
 	if seek0 {
@@ -0,0 +1,340 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsgofer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/cleanup"
+	"gvisor.dev/gvisor/pkg/log"
+)
+
+// ReadCacheDigestXattr is the extended attribute holding the content digest of
+// files that can be served from a ReadCache, in the OCI digest format
+// "sha256:<lowercase hex>".
+const ReadCacheDigestXattr = "user.gvisor.content-digest"
+
+const (
+	// readCacheAlgorithm is the only supported digest algorithm. Cached files
+	// are stored in a subdirectory of the cache directory named after it.
+	readCacheAlgorithm = "sha256"
+
+	// readCacheMinSize is the minimum size of cached files. Smaller files are
+	// cheap enough to read from their mount.
+	readCacheMinSize = 1 << 20
+
+	// readCacheMaxFills is the maximum number of files that are copied into
+	// the cache concurrently.
+	readCacheMaxFills = 2
+
+	// readCacheBufSize is the size of the buffer used to copy files into the
+	// cache.
+	readCacheBufSize = 1 << 20
+
+	// readCacheTmpSuffix is the suffix of files being copied into the cache.
+	readCacheTmpSuffix = ".tmp"
+
+	// readCacheTmpTimeout is the time after which files being copied into the
+	// cache are assumed to have been leaked, e.g. by a gofer that was killed.
+	readCacheTmpTimeout = time.Hour
+)
+
+// ReadCache is a content-addressed cache of large read-only files of gofer
+// mounts, stored in a host directory that can be shared by gofers of multiple
+// sandboxes. Files are keyed by the digest in their ReadCacheDigestXattr,
+// which is typically set by the tooling that unpacks container images. When
+// such a file is opened, the cached copy is donated to the sentry in place of
+// the file itself, so that repeated launches of sandboxes using the same
+// image read it from the cache directory rather than from its mount, which
+// may be slow (e.g. backed by a lazily fetched image).
+//
+// Files are verified against their digest when they are copied into the
+// cache, but digests are trusted when files are opened: a file claiming the
+// digest of another file is served the content of the other file, and
+// whether a file is cached reveals that another sandbox used it. Thus, each
+// trust domain (e.g. tenant) must use its own cache directory, and the cache
+// must only be used with images whose extended attributes are set by tooling
+// trusted by the domain.
+//
+// The total size of cached files is bounded by evicting the least recently
+// used ones, as tracked by their modification time, which is updated whenever
+// they are opened.
+type ReadCache struct {
+	// dirFD is a host FD for the cache directory. It is immutable.
+	dirFD int
+
+	// maxSize is the maximum total size of cached files. It is immutable.
+	maxSize int64
+
+	// fills limits the number of concurrent fills.
+	fills chan struct{}
+
+	// evictMu serializes evictions.
+	evictMu sync.Mutex
+
+	// mu protects the fields below.
+	mu sync.Mutex
+
+	// pending contains the digests of the files being copied into the cache.
+	pending map[string]struct{}
+}
+
+// NewReadCache returns a ReadCache storing up to maxSize bytes of files in the
+// directory dirFD, which must remain open for the lifetime of the ReadCache.
+func NewReadCache(dirFD int, maxSize uint64) (*ReadCache, error) {
+	if err := unix.Mkdirat(dirFD, readCacheAlgorithm, 0700); err != nil && err != unix.EEXIST {
+		return nil, fmt.Errorf("creating %q in the read cache directory: %w", readCacheAlgorithm, err)
+	}
+	c := &ReadCache{
+		dirFD:   dirFD,
+		maxSize: int64(min(maxSize, 1<<63-1)),
+		fills:   make(chan struct{}, readCacheMaxFills),
+		pending: make(map[string]struct{}),
+	}
+	// Clean up files leaked by previous gofers.
+	if err := c.evict(); err != nil {
+		log.Warningf("Failed to evict files from the read cache: %v", err)
+	}
+	return c, nil
+}
+
+// contentDigest returns the hex digest in the ReadCacheDigestXattr of the file
+// hostFD, or "" if it doesn't have a valid one.
+func contentDigest(hostFD int) string {
+	var buf [len(readCacheAlgorithm) + 1 + 2*sha256.Size]byte
+	n, err := unix.Fgetxattr(hostFD, ReadCacheDigestXattr, buf[:])
+	if err != nil {
+		return ""
+	}
+	algorithm, digest, ok := strings.Cut(string(buf[:n]), ":")
+	if !ok || algorithm != readCacheAlgorithm || len(digest) != 2*sha256.Size {
+		return ""
+	}
+	// The digest is used as a file name, so only accept the canonical
+	// encoding.
+	for _, c := range digest {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return ""
+		}
+	}
+	return digest
+}
+
+// Open returns a host FD for the cached copy of the regular file hostFD, or -1
+// if there is none. In the latter case, the file is copied into the cache in
+// the background if it has a content digest.
+func (c *ReadCache) Open(hostFD int) int {
+	var stat unix.Stat_t
+	if err := unix.Fstat(hostFD, &stat); err != nil || stat.Size < readCacheMinSize {
+		return -1
+	}
+	digest := contentDigest(hostFD)
+	if digest == "" {
+		return -1
+	}
+	name := path.Join(readCacheAlgorithm, digest)
+	cachedFD, err := unix.Openat(c.dirFD, name, unix.O_RDONLY|openFlags, 0)
+	if err == unix.ENOENT {
+		if stat.Size <= c.maxSize {
+			c.fill(hostFD, digest, stat.Size)
+		}
+		return -1
+	}
+	if err != nil {
+		log.Warningf("Failed to open %q in the read cache: %v", name, err)
+		return -1
+	}
+	var cachedStat unix.Stat_t
+	if err := unix.Fstat(cachedFD, &cachedStat); err != nil || cachedStat.Mode&unix.S_IFMT != unix.S_IFREG || cachedStat.Size != stat.Size {
+		// At least one of the files doesn't match the digest.
+		log.Warningf("Ignoring %q in the read cache: got size %d, want %d", name, cachedStat.Size, stat.Size)
+		_ = unix.Close(cachedFD)
+		return -1
+	}
+	// Mark the file as recently used.
+	ts := []unix.Timespec{{Nsec: unix.UTIME_OMIT}, {Nsec: unix.UTIME_NOW}}
+	if err := unix.UtimesNanoAt(c.dirFD, name, ts, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		log.Debugf("Failed to update the modification time of %q in the read cache: %v", name, err)
+	}
+	return cachedFD
+}
+
+// fill starts copying the file hostFD with the given digest and size into the
+// cache, unless it is already being copied. Files are skipped if too many are
+// being copied, and will be copied when they are opened again.
+func (c *ReadCache) fill(hostFD int, digest string, size int64) {
+	select {
+	case c.fills <- struct{}{}:
+	default:
+		return
+	}
+	c.mu.Lock()
+	if _, ok := c.pending[digest]; ok {
+		c.mu.Unlock()
+		<-c.fills
+		return
+	}
+	c.pending[digest] = struct{}{}
+	c.mu.Unlock()
+
+	srcFD, err := unix.Dup(hostFD)
+	if err != nil {
+		c.fillDone(digest)
+		return
+	}
+	go func() {
+		defer c.fillDone(digest)
+		defer unix.Close(srcFD)
+		if err := c.copyIn(srcFD, digest, size); err != nil {
+			log.Warningf("Failed to add %s:%s to the read cache: %v", readCacheAlgorithm, digest, err)
+			return
+		}
+		if err := c.evict(); err != nil {
+			log.Warningf("Failed to evict files from the read cache: %v", err)
+		}
+	}()
+}
+
+// fillDone marks the fill of the given digest as complete.
+func (c *ReadCache) fillDone(digest string) {
+	c.mu.Lock()
+	delete(c.pending, digest)
+	c.mu.Unlock()
+	<-c.fills
+}
+
+// copyIn copies the first size bytes of the file srcFD into the cache, if they
+// match the given digest.
+func (c *ReadCache) copyIn(srcFD int, digest string, size int64) error {
+	name := path.Join(readCacheAlgorithm, digest)
+	// The file is written under a name that is unique among gofers sharing
+	// the cache directory, and renamed once complete.
+	tmpName := fmt.Sprintf("%s.%016x%s", name, rand.Uint64(), readCacheTmpSuffix)
+	tmpFD, err := unix.Openat(c.dirFD, tmpName, unix.O_WRONLY|unix.O_CREAT|unix.O_EXCL|openFlags, 0444)
+	if err != nil {
+		return err
+	}
+	cu := cleanup.Make(func() {
+		_ = unix.Unlinkat(c.dirFD, tmpName, 0)
+	})
+	defer cu.Clean()
+	defer unix.Close(tmpFD)
+
+	h := sha256.New()
+	buf := make([]byte, readCacheBufSize)
+	for off := int64(0); off < size; {
+		n, err := unix.Pread(srcFD, buf[:min(int64(len(buf)), size-off)], off)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("unexpected EOF at offset %d of %d", off, size)
+		}
+		h.Write(buf[:n])
+		for written := 0; written < n; {
+			w, err := unix.Pwrite(tmpFD, buf[written:n], off+int64(written))
+			if err != nil {
+				return err
+			}
+			written += w
+		}
+		off += int64(n)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != digest {
+		return fmt.Errorf("file content has digest %s:%s", readCacheAlgorithm, got)
+	}
+	if err := unix.Fsync(tmpFD); err != nil {
+		return err
+	}
+	if err := unix.Renameat(c.dirFD, tmpName, c.dirFD, name); err != nil {
+		return err
+	}
+	cu.Release()
+	log.Debugf("Added %q to the read cache", name)
+	return nil
+}
+
+// evict removes the least recently used files from the cache until their total
+// size is at most c.maxSize, and removes files whose copy into the cache was
+// interrupted. Files may still be open, e.g. by the sentry, in which case they
+// are only freed once closed.
+func (c *ReadCache) evict() error {
+	c.evictMu.Lock()
+	defer c.evictMu.Unlock()
+
+	fd, err := unix.Openat(c.dirFD, readCacheAlgorithm, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	dir := os.NewFile(uintptr(fd), readCacheAlgorithm)
+	defer dir.Close()
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return err
+	}
+
+	type cachedFile struct {
+		name  string
+		size  int64
+		mtime time.Time
+	}
+	var (
+		files []cachedFile
+		total int64
+	)
+	for _, name := range names {
+		var stat unix.Stat_t
+		if err := unix.Fstatat(fd, name, &stat, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+			continue
+		}
+		mtime := time.Unix(stat.Mtim.Unix())
+		if strings.HasSuffix(name, readCacheTmpSuffix) {
+			if time.Since(mtime) > readCacheTmpTimeout {
+				log.Infof("Removing leaked file %q from the read cache", name)
+				_ = unix.Unlinkat(fd, name, 0)
+			}
+			continue
+		}
+		files = append(files, cachedFile{name, stat.Size, mtime})
+		total += stat.Size
+	}
+	if total <= c.maxSize {
+		return nil
+	}
+	slices.SortFunc(files, func(a, b cachedFile) int {
+		return a.mtime.Compare(b.mtime)
+	})
+	for _, f := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if err := unix.Unlinkat(fd, f.name, 0); err != nil && err != unix.ENOENT {
+			return err
+		}
+		log.Debugf("Evicted %q from the read cache", f.name)
+		total -= f.size
+	}
+	return nil
+}
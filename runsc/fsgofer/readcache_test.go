@@ -0,0 +1,270 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsgofer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// newDigestFile creates a file with the given content and digest xattr, and
+// returns a host FD for it.
+func newDigestFile(t *testing.T, content []byte, digest string) int {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(name, content, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if digest != "" {
+		if err := unix.Setxattr(name, ReadCacheDigestXattr, []byte(digest), 0); err != nil {
+			if err == unix.ENOTSUP {
+				t.Skipf("user xattrs are not supported: %v", err)
+			}
+			t.Fatalf("Setxattr: %v", err)
+		}
+	}
+	fd, err := unix.Open(name, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = unix.Close(fd) })
+	return fd
+}
+
+// newTestReadCache returns a ReadCache in a new directory, and the path of the
+// directory.
+func newTestReadCache(t *testing.T) (*ReadCache, string) {
+	t.Helper()
+	return newTestReadCacheIn(t, t.TempDir(), 1<<30)
+}
+
+// newTestReadCacheIn returns a ReadCache of the given size in dir, and the
+// path of the directory.
+func newTestReadCacheIn(t *testing.T, dir string, maxSize uint64) (*ReadCache, string) {
+	t.Helper()
+	dirFD, err := unix.Open(dir, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = unix.Close(dirFD) })
+	c, err := NewReadCache(dirFD, maxSize)
+	if err != nil {
+		t.Fatalf("NewReadCache: %v", err)
+	}
+	return c, dir
+}
+
+// waitForFills waits until c isn't copying any file into the cache.
+func waitForFills(t *testing.T, c *ReadCache) {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		c.mu.Lock()
+		n := len(c.pending)
+		c.mu.Unlock()
+		if n == 0 {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for the read cache to be filled")
+}
+
+func testContent() ([]byte, string) {
+	content := bytes.Repeat([]byte("gvisor"), readCacheMinSize/4)
+	sum := sha256.Sum256(content)
+	return content, hex.EncodeToString(sum[:])
+}
+
+func TestContentDigest(t *testing.T) {
+	_, digest := testContent()
+	for _, tc := range []struct {
+		name  string
+		xattr string
+		want  string
+	}{
+		{name: "valid", xattr: "sha256:" + digest, want: digest},
+		{name: "none"},
+		{name: "algorithm", xattr: "sha512:" + digest},
+		{name: "uppercase", xattr: "sha256:" + strings.ToUpper(digest)},
+		{name: "short", xattr: "sha256:" + digest[1:]},
+		{name: "path", xattr: "sha256:../" + digest[3:]},
+		{name: "no algorithm", xattr: digest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fd := newDigestFile(t, nil, tc.xattr)
+			if got := contentDigest(fd); got != tc.want {
+				t.Errorf("contentDigest() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// mtime returns the modification time of the file at name.
+func mtime(t *testing.T, name string) time.Time {
+	t.Helper()
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	return info.ModTime()
+}
+
+func TestReadCache(t *testing.T) {
+	content, digest := testContent()
+	fd := newDigestFile(t, content, "sha256:"+digest)
+	c, dir := newTestReadCache(t)
+
+	if cachedFD := c.Open(fd); cachedFD >= 0 {
+		unix.Close(cachedFD)
+		t.Fatalf("Open() returned a cached file before the cache was filled")
+	}
+	waitForFills(t, c)
+	got, err := os.ReadFile(filepath.Join(dir, readCacheAlgorithm, digest))
+	if err != nil {
+		t.Fatalf("reading cached file: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("cached file has %d bytes that don't match the original file", len(got))
+	}
+
+	// Opening the cached file marks it as recently used.
+	cachedName := filepath.Join(dir, readCacheAlgorithm, digest)
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(cachedName, old, old); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	cachedFD := c.Open(fd)
+	if cachedFD < 0 {
+		t.Fatalf("Open() = %d, want cached file", cachedFD)
+	}
+	defer unix.Close(cachedFD)
+	if got := mtime(t, cachedName); !got.After(old) {
+		t.Errorf("cached file modification time = %v, want after %v", got, old)
+	}
+	buf := make([]byte, len(content))
+	if n, err := unix.Pread(cachedFD, buf, 0); err != nil || n != len(content) || !bytes.Equal(buf, content) {
+		t.Errorf("Pread(cached FD) = (%d, %v), want %d matching bytes", n, err, len(content))
+	}
+}
+
+func TestReadCacheWrongDigest(t *testing.T) {
+	content, digest := testContent()
+	// The file claims the digest of different content.
+	content[0]++
+	fd := newDigestFile(t, content, "sha256:"+digest)
+	c, dir := newTestReadCache(t)
+
+	if cachedFD := c.Open(fd); cachedFD >= 0 {
+		unix.Close(cachedFD)
+		t.Fatalf("Open() returned a cached file for an empty cache")
+	}
+	waitForFills(t, c)
+	entries, err := os.ReadDir(filepath.Join(dir, readCacheAlgorithm))
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got cache entries %v, want none", entries)
+	}
+}
+
+func TestReadCacheSkipsFiles(t *testing.T) {
+	content, digest := testContent()
+	c, dir := newTestReadCache(t)
+	for _, tc := range []struct {
+		name    string
+		content []byte
+		xattr   string
+	}{
+		{name: "small", content: content[:readCacheMinSize-1], xattr: "sha256:" + digest},
+		{name: "no digest", content: content},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fd := newDigestFile(t, tc.content, tc.xattr)
+			if cachedFD := c.Open(fd); cachedFD >= 0 {
+				unix.Close(cachedFD)
+				t.Errorf("Open() returned a cached file")
+			}
+			waitForFills(t, c)
+			if _, err := os.Stat(filepath.Join(dir, readCacheAlgorithm, digest)); !os.IsNotExist(err) {
+				t.Errorf("file was added to the cache: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadCacheSizeMismatch(t *testing.T) {
+	content, digest := testContent()
+	fd := newDigestFile(t, content, "sha256:"+digest)
+	c, dir := newTestReadCache(t)
+	// The cached file is corrupted.
+	if err := os.WriteFile(filepath.Join(dir, readCacheAlgorithm, digest), content[1:], 0444); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if cachedFD := c.Open(fd); cachedFD >= 0 {
+		unix.Close(cachedFD)
+		t.Errorf("Open() returned a cached file with the wrong size")
+	}
+}
+
+func TestReadCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, readCacheAlgorithm)
+	if err := os.Mkdir(cacheDir, 0700); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	now := time.Now()
+	for _, f := range []struct {
+		name string
+		age  time.Duration
+	}{
+		{name: "oldest", age: 3 * time.Minute},
+		{name: "old", age: 2 * time.Minute},
+		{name: "recent", age: time.Minute},
+		{name: "recent.0123456789abcdef" + readCacheTmpSuffix, age: time.Minute},
+		{name: "leaked.0123456789abcdef" + readCacheTmpSuffix, age: 2 * readCacheTmpTimeout},
+	} {
+		name := filepath.Join(cacheDir, f.name)
+		if err := os.WriteFile(name, make([]byte, 100), 0444); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if err := os.Chtimes(name, now.Add(-f.age), now.Add(-f.age)); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	}
+
+	// Creating the cache evicts files that don't fit in it.
+	newTestReadCacheIn(t, dir, 250)
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	want := []string{"old", "recent", "recent.0123456789abcdef" + readCacheTmpSuffix}
+	if !slices.Equal(got, want) {
+		t.Errorf("got cache entries %v, want %v", got, want)
+	}
+}
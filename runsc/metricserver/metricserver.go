@@ -36,6 +36,7 @@ import (
 	"gvisor.dev/gvisor/pkg/atomicbitops"
 	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/prometheus"
+	"gvisor.dev/gvisor/pkg/sandbox/api"
 	"gvisor.dev/gvisor/pkg/sentry/control"
 	"gvisor.dev/gvisor/pkg/state"
 	"gvisor.dev/gvisor/pkg/sync"
@@ -86,6 +87,10 @@ type servedSandbox struct {
 	// `spec_metadata` metric.
 	specMetadataLabels map[string]string
 
+	// containerIDs are the IDs of the containers within `sandbox`. They are
+	// used to query the per-mount cache statistics of each container.
+	containerIDs []string
+
 	// verifier allows verifying the data integrity of the metrics we get from this sandbox.
 	// It is not always initialized when the sandbox is discovered, but rather upon first metrics
 	// access to the sandbox. Metric registration data is loaded from the root container's
@@ -179,6 +184,11 @@ func (s *servedSandbox) load() (*sandbox.Sandbox, *prometheus.Verifier, error) {
 		// Compute spec metadata.
 		s.specMetadataLabels = containermetrics.ComputeSpecMetadata(allContainers)
 
+		s.containerIDs = make([]string, 0, len(allContainers))
+		for _, cont := range allContainers {
+			s.containerIDs = append(s.containerIDs, cont.ID)
+		}
+
 		s.sandbox = rootContainer.Sandbox
 		s.createdAt = rootContainer.CreatedAt
 	}
@@ -244,6 +254,65 @@ func querySandboxMetrics(ctx context.Context, sand *sandbox.Sandbox, verifier *p
 	}
 }
 
+// mountCacheMetrics are the per-mount cache metrics, in the order in which
+// mountCacheData returns their data for each mount.
+var mountCacheMetrics = []*prometheus.Metric{
+	&MountDentryCacheHitsMetric,
+	&MountDentryCacheMissesMetric,
+	&MountPageCacheHitBytesMetric,
+	&MountPageCacheMissBytesMetric,
+}
+
+// queryMountIOStats queries the sandbox for the per-mount I/O statistics of
+// each of the given containers. Containers for which statistics cannot be
+// retrieved, e.g. because they have not started yet, are omitted.
+func queryMountIOStats(ctx context.Context, sand *sandbox.Sandbox, cids []string) map[string][]api.MountIOStats {
+	ch := make(chan map[string][]api.MountIOStats, 1)
+	go func() {
+		stats := make(map[string][]api.MountIOStats, len(cids))
+		for _, cid := range cids {
+			s, err := sand.MountIOStats(cid)
+			if err != nil {
+				log.Debugf("Cannot get mount I/O statistics of container %q: %v", cid, err)
+				continue
+			}
+			stats[cid] = s
+		}
+		ch <- stats
+	}()
+	select {
+	case <-ctx.Done():
+		return nil
+	case stats := <-ch:
+		return stats
+	}
+}
+
+// mountCacheData returns the data of the per-mount cache metrics of container
+// cid, given the per-mount statistics of the container. Only metrics whose
+// names match filter, if not nil, are returned.
+func mountCacheData(cid string, stats []api.MountIOStats, filter *regexp.Regexp) []*prometheus.Data {
+	var data []*prometheus.Data
+	for _, s := range stats {
+		if s.Cache == nil {
+			continue
+		}
+		labels := map[string]string{
+			MountCacheMetricContainerLabel: cid,
+			MountCacheMetricPathLabel:      s.Path,
+			MountCacheMetricFSTypeLabel:    s.FSType,
+		}
+		values := []uint64{s.Cache.DentryHits, s.Cache.DentryMisses, s.Cache.PageHitBytes, s.Cache.PageMissBytes}
+		for i, metric := range mountCacheMetrics {
+			if filter != nil && !filter.MatchString(metric.Name) {
+				continue
+			}
+			data = append(data, prometheus.LabeledIntData(metric, labels, int64(values[i])))
+		}
+	}
+	return data
+}
+
 // metricServer implements the metric server.
 type metricServer struct {
 	rootDir                string
@@ -289,6 +358,10 @@ type metricServer struct {
 	// is consistently passing in the same value for this parameter in each successive request.
 	lastValidMetricFilter string
 
+	// lastValidMetricFilterReg is the compiled regular expression corresponding to
+	// lastValidMetricFilter.
+	lastValidMetricFilterReg *regexp.Regexp
+
 	// lastValidCapabilityFilterStr stores the last value of the "runsc-capability-filter" parameter
 	// for /metrics requests.
 	// It represents the last-known compilable regular expression that was passed to /metrics.
@@ -535,6 +608,7 @@ type sandboxMetricsResult struct {
 	isCheckpointed bool
 	isRestored     bool
 	snapshot       *prometheus.Snapshot
+	mountStats     map[string][]api.MountIOStats
 	err            error
 }
 
@@ -575,10 +649,14 @@ func queryMultiSandboxMetrics(ctx context.Context, loadedSandboxes []sandboxLoad
 				isCheckpointed := false
 				isRestored := false
 				var snapshot *prometheus.Snapshot
+				var mountStats map[string][]api.MountIOStats
 				err := s.err
 				if err == nil {
 					queryCtx, queryCtxCancel := context.WithTimeout(ctx, perSandboxTime)
 					snapshot, err = querySandboxMetrics(queryCtx, s.sandbox, s.verifier, metricsFilter)
+					if err == nil {
+						mountStats = queryMountIOStats(queryCtx, s.sandbox, s.served.containerIDs)
+					}
 					queryCtxCancel()
 					isRunning = s.sandbox.IsRunning()
 					isCheckpointed = s.sandbox.Checkpointed
@@ -590,6 +668,7 @@ func queryMultiSandboxMetrics(ctx context.Context, loadedSandboxes []sandboxLoad
 					isCheckpointed:    isCheckpointed,
 					isRestored:        isRestored,
 					snapshot:          snapshot,
+					mountStats:        mountStats,
 					err:               err,
 				})
 			}
@@ -628,18 +707,25 @@ func (m *metricServer) serveMetrics(w *httpResponseWriter, req *http.Request) ht
 	defer ctxCancel()
 
 	metricsFilter := req.URL.Query().Get("runsc-sandbox-metrics-filter")
+	var metricsFilterReg *regexp.Regexp
 	var capabilityFilterReg *regexp.Regexp
 	capabilityFilterStr := req.URL.Query().Get("runsc-capability-filter")
 
 	m.mu.Lock()
 
-	if metricsFilter != "" && metricsFilter != m.lastValidMetricFilter {
-		_, err := regexp.Compile(metricsFilter)
-		if err != nil {
-			m.mu.Unlock()
-			return httpResult{http.StatusBadRequest, errors.New("provided metric filter is not a valid regular expression")}
+	if metricsFilter != "" {
+		if metricsFilter != m.lastValidMetricFilter {
+			reg, err := regexp.Compile(metricsFilter)
+			if err != nil {
+				m.mu.Unlock()
+				return httpResult{http.StatusBadRequest, errors.New("provided metric filter is not a valid regular expression")}
+			}
+			m.lastValidMetricFilter = metricsFilter
+			m.lastValidMetricFilterReg = reg
+			metricsFilterReg = reg
+		} else {
+			metricsFilterReg = m.lastValidMetricFilterReg
 		}
-		m.lastValidMetricFilter = metricsFilter
 	}
 	if capabilityFilterStr != "" {
 		if capabilityFilterStr != m.lastValidCapabilityFilterStr {
@@ -715,6 +801,11 @@ func (m *metricServer) serveMetrics(w *httpResponseWriter, req *http.Request) ht
 			selfMetrics.Add(prometheus.LabeledIntData(&SpecMetadataMetric, r.served.specMetadataLabels, 1).SetExternalLabels(r.served.extraLabels))
 			createdAt := float64(r.served.createdAt.Unix()) + (float64(r.served.createdAt.Nanosecond()) / 1e9)
 			selfMetrics.Add(prometheus.LabeledFloatData(&SandboxCreationMetric, nil, createdAt).SetExternalLabels(r.served.extraLabels))
+			for cid, stats := range r.mountStats {
+				for _, data := range mountCacheData(cid, stats, metricsFilterReg) {
+					selfMetrics.Add(data.SetExternalLabels(r.served.extraLabels))
+				}
+			}
 		} else {
 			// If the sandbox isn't running, it is normal that metrics are not exported for it, so
 			// do not report this case as an error.
@@ -61,7 +61,30 @@ var (
 		Type: prometheus.TypeGauge,
 		Help: "When the sandbox was created, as a unix timestamp in seconds.",
 	}
-	NumRunningSandboxesMetric = prometheus.Metric{
+	MountDentryCacheHitsMetric = prometheus.Metric{
+		Name: "mount_dentry_cache_hits",
+		Type: prometheus.TypeCounter,
+		Help: "Number of path component lookups through a mount resolved from the dentry cache.",
+	}
+	MountDentryCacheMissesMetric = prometheus.Metric{
+		Name: "mount_dentry_cache_misses",
+		Type: prometheus.TypeCounter,
+		Help: "Number of path component lookups through a mount that required querying the backing filesystem.",
+	}
+	MountPageCacheHitBytesMetric = prometheus.Metric{
+		Name: "mount_page_cache_hit_bytes",
+		Type: prometheus.TypeCounter,
+		Help: "Number of bytes read through a mount that were served from the page cache.",
+	}
+	MountPageCacheMissBytesMetric = prometheus.Metric{
+		Name: "mount_page_cache_miss_bytes",
+		Type: prometheus.TypeCounter,
+		Help: "Number of bytes read through a mount that were not in the page cache.",
+	}
+	MountCacheMetricContainerLabel = "container"
+	MountCacheMetricPathLabel      = "mount_path"
+	MountCacheMetricFSTypeLabel    = "fstype"
+	NumRunningSandboxesMetric      = prometheus.Metric{
 		Name: "num_sandboxes_running",
 		Type: prometheus.TypeGauge,
 		Help: "Number of sandboxes running at present.",
@@ -98,6 +121,10 @@ var Metrics = []*prometheus.Metric{
 	&SandboxCapabilitiesMetric,
 	&SpecMetadataMetric,
 	&SandboxCreationMetric,
+	&MountDentryCacheHitsMetric,
+	&MountDentryCacheMissesMetric,
+	&MountPageCacheHitBytesMetric,
+	&MountPageCacheMissBytesMetric,
 	&NumRunningSandboxesMetric,
 	&NumCannotExportSandboxesMetric,
 	&NumTotalSandboxesMetric,
@@ -17,9 +17,13 @@ package metricserver
 import (
 	"io/fs"
 	"os"
+	"regexp"
 	"syscall"
 	"testing"
 	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/sandbox/api"
 )
 
 type fakeFileInfo struct {
@@ -196,3 +200,65 @@ func TestSufficientlyEqualStats(t *testing.T) {
 		})
 	}
 }
+
+// TestMountCacheData tests mountCacheData.
+func TestMountCacheData(t *testing.T) {
+	stats := []api.MountIOStats{
+		{
+			Path:   "/",
+			FSType: "9p",
+			Cache: &api.CacheStats{
+				DentryHits:    1,
+				DentryMisses:  2,
+				PageHitBytes:  3,
+				PageMissBytes: 4,
+			},
+		},
+		{
+			// Filesystems without caches have no cache metrics.
+			Path:   "/proc",
+			FSType: "proc",
+		},
+	}
+	for _, test := range []struct {
+		name   string
+		filter *regexp.Regexp
+		want   map[string]int64
+	}{
+		{
+			name: "unfiltered",
+			want: map[string]int64{
+				MountDentryCacheHitsMetric.Name:    1,
+				MountDentryCacheMissesMetric.Name:  2,
+				MountPageCacheHitBytesMetric.Name:  3,
+				MountPageCacheMissBytesMetric.Name: 4,
+			},
+		},
+		{
+			name:   "filtered",
+			filter: regexp.MustCompile("dentry"),
+			want: map[string]int64{
+				MountDentryCacheHitsMetric.Name:   1,
+				MountDentryCacheMissesMetric.Name: 2,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := make(map[string]int64)
+			for _, data := range mountCacheData("cid", stats, test.filter) {
+				wantLabels := map[string]string{
+					MountCacheMetricContainerLabel: "cid",
+					MountCacheMetricPathLabel:      "/",
+					MountCacheMetricFSTypeLabel:    "9p",
+				}
+				if diff := cmp.Diff(wantLabels, data.Labels); diff != "" {
+					t.Errorf("metric %q has unexpected labels (-want +got):\n%s", data.Metric.Name, diff)
+				}
+				got[data.Metric.Name] = data.Number.Int
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mountCacheData returned unexpected values (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
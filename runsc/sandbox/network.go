@@ -19,9 +19,7 @@ import (
 	"net"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
-	"strconv"
 
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/vishvananda/netlink"
@@ -47,12 +45,16 @@ import (
 // device.
 //
 // If 'conf.Network' is NoNetwork, skips local configuration and creates a
-// loopback interface only.
+// loopback interface only. SRIOV does the same after checking that the VFIO
+// groups passed to the container only hold SR-IOV virtual functions, which the
+// container drives directly with a userspace driver.
+//
+// The network configuration is copied from the network namespace at nsPath.
 //
 // Run the following container to test it:
 //
 //	docker run -di --runtime=runsc -p 8080:80 -v $PWD:/usr/local/apache2/htdocs/ httpd:2.4
-func setupNetwork(conn *urpc.Client, pid int, conf *config.Config, disableIPv6 bool) error {
+func setupNetwork(conn *urpc.Client, pid int, nsPath string, spec *specs.Spec, conf *config.Config, disableIPv6 bool) error {
 	log.Infof("Setting up network")
 
 	switch conf.Network {
@@ -61,10 +63,16 @@ func setupNetwork(conn *urpc.Client, pid int, conf *config.Config, disableIPv6 b
 		if err := createDefaultLoopbackInterface(conf, conn); err != nil {
 			return fmt.Errorf("creating default loopback interface: %v", err)
 		}
+	case config.NetworkSRIOV:
+		vfs, err := sriovVFs(spec, "/sys")
+		if err != nil {
+			return fmt.Errorf("checking SR-IOV devices: %w", err)
+		}
+		log.Infof("Passing SR-IOV virtual functions %v through, create loopback interface only", vfs)
+		if err := createDefaultLoopbackInterface(conf, conn); err != nil {
+			return fmt.Errorf("creating default loopback interface: %v", err)
+		}
 	case config.NetworkSandbox:
-		// Build the path to the net namespace of the sandbox process.
-		// This is what we will copy.
-		nsPath := filepath.Join("/proc", strconv.Itoa(pid), "ns/net")
 		if err := createInterfacesAndRoutesFromNS(conn, nsPath, conf, disableIPv6); err != nil {
 			return fmt.Errorf("creating interfaces from net namespace %q: %v", nsPath, err)
 		}
@@ -202,7 +210,7 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 			}
 			ipAddrs = append(ipAddrs, ipNet)
 		}
-		if len(ipAddrs) == 0 {
+		if len(ipAddrs) == 0 && !conf.NetworkDHCP {
 			log.Warningf("No usable IP addresses found for interface %q, skipping", iface.Name)
 			continue
 		}
@@ -224,26 +232,31 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 			}
 		}
 
-		// Scrape the routes before removing the address, since that
-		// will remove the routes as well.
-		routes, defv4, defv6, err := routesForIface(iface, disableIPv6)
-		if err != nil {
-			return fmt.Errorf("getting routes for interface %q: %v", iface.Name, err)
-		}
-		if defv4 != nil {
-			if !args.Defaultv4Gateway.Route.Empty() {
-				return fmt.Errorf("more than one default route found, interface: %v, route: %v, default route: %+v", iface.Name, defv4, args.Defaultv4Gateway)
+		// With DHCP, addresses and routes are acquired by the sandbox itself.
+		var routes []boot.Route
+		if !conf.NetworkDHCP {
+			// Scrape the routes before removing the address, since that
+			// will remove the routes as well.
+			var defv4, defv6 *boot.Route
+			routes, defv4, defv6, err = routesForIface(iface, disableIPv6)
+			if err != nil {
+				return fmt.Errorf("getting routes for interface %q: %v", iface.Name, err)
+			}
+			if defv4 != nil {
+				if !args.Defaultv4Gateway.Route.Empty() {
+					return fmt.Errorf("more than one default route found, interface: %v, route: %v, default route: %+v", iface.Name, defv4, args.Defaultv4Gateway)
+				}
+				args.Defaultv4Gateway.Route = *defv4
+				args.Defaultv4Gateway.Name = iface.Name
 			}
-			args.Defaultv4Gateway.Route = *defv4
-			args.Defaultv4Gateway.Name = iface.Name
-		}
 
-		if defv6 != nil {
-			if !args.Defaultv6Gateway.Route.Empty() {
-				return fmt.Errorf("more than one default route found, interface: %v, route: %v, default route: %+v", iface.Name, defv6, args.Defaultv6Gateway)
+			if defv6 != nil {
+				if !args.Defaultv6Gateway.Route.Empty() {
+					return fmt.Errorf("more than one default route found, interface: %v, route: %v, default route: %+v", iface.Name, defv6, args.Defaultv6Gateway)
+				}
+				args.Defaultv6Gateway.Route = *defv6
+				args.Defaultv6Gateway.Name = iface.Name
 			}
-			args.Defaultv6Gateway.Route = *defv6
-			args.Defaultv6Gateway.Name = iface.Name
 		}
 
 		// Get the link for the interface.
@@ -254,11 +267,14 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 		linkAddress := ifaceLink.Attrs().HardwareAddr
 
 		// Collect the addresses for the interface, enable forwarding,
-		// and remove them from the host.
+		// and remove them from the host. With DHCP, only IPv6 link-local
+		// addresses are kept as they are needed to talk to DHCPv6 servers.
 		var addresses []boot.IPWithPrefix
 		for _, addr := range ipAddrs {
-			prefix, _ := addr.Mask.Size()
-			addresses = append(addresses, boot.IPWithPrefix{Address: addr.IP, PrefixLen: prefix})
+			if !conf.NetworkDHCP || (addr.IP.To4() == nil && addr.IP.IsLinkLocalUnicast()) {
+				prefix, _ := addr.Mask.Size()
+				addresses = append(addresses, boot.IPWithPrefix{Address: addr.IP, PrefixLen: prefix})
+			}
 
 			// Steal IP address from NIC.
 			if err := removeAddress(ifaceLink, addr.String()); err != nil {
@@ -291,6 +307,7 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 				LinkAddress:       linkAddress,
 				Addresses:         addresses,
 				GVisorGRO:         conf.GVisorGRO,
+				DHCP:              conf.NetworkDHCP,
 			})
 		} else {
 			link := boot.FDBasedLink{
@@ -305,6 +322,7 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 				Neighbors:            neighbors,
 				LinkAddress:          linkAddress,
 				Addresses:            addresses,
+				DHCP:                 conf.NetworkDHCP,
 			}
 
 			log.Debugf("Setting up network channels")
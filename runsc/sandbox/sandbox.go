@@ -17,11 +17,14 @@ package sandbox
 
 import (
 	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math"
+	"net"
 	"os"
 	"os/exec"
 	"path"
@@ -41,10 +44,13 @@ import (
 	"gvisor.dev/gvisor/pkg/control/client"
 	"gvisor.dev/gvisor/pkg/control/server"
 	"gvisor.dev/gvisor/pkg/coverage"
+	"gvisor.dev/gvisor/pkg/faultinject"
 	"gvisor.dev/gvisor/pkg/fd"
 	"gvisor.dev/gvisor/pkg/log"
 	metricpb "gvisor.dev/gvisor/pkg/metric/metric_go_proto"
 	"gvisor.dev/gvisor/pkg/prometheus"
+	"gvisor.dev/gvisor/pkg/rand"
+	"gvisor.dev/gvisor/pkg/sandbox/api"
 	"gvisor.dev/gvisor/pkg/sentry/control"
 	"gvisor.dev/gvisor/pkg/sentry/devices/nvproxy"
 	"gvisor.dev/gvisor/pkg/sentry/devices/nvproxy/nvconf"
@@ -103,6 +109,38 @@ func createControlSocket(rootDir, id string) (string, int, error) {
 	return "", -1, fmt.Errorf("unable to find location to write socket file")
 }
 
+// controlTokenPath returns the path of the file holding the control token of
+// the sandbox whose control socket is at socketPath.
+//
+// The control server authenticates runsc by this token when a control policy
+// is set (see server.Policy), because the peer credentials of a control
+// socket connection are meaningless inside the sandbox's user namespace.
+func controlTokenPath(socketPath string) string {
+	return strings.TrimSuffix(socketPath, ".sock") + ".token"
+}
+
+// createControlToken writes a new random control token to a file at path that
+// only the sandbox owner can read.
+func createControlToken(path string) error {
+	var token [32]byte
+	if _, err := cryptorand.Read(token[:]); err != nil {
+		return fmt.Errorf("generating control token: %w", err)
+	}
+	// Don't reuse a stale token file that others may be able to read.
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(hex.EncodeToString(token[:])); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // pid is an atomic type that implements JSON marshal/unmarshal interfaces.
 type pid struct {
 	val atomicbitops.Int64
@@ -226,6 +264,21 @@ type Sandbox struct {
 
 	// Restored will be true when the sandbox has been restored.
 	Restored bool `json:"restored"`
+
+	// IdleImagePath is the directory the sandbox checkpoints itself to once
+	// it has been idle for longer than --idle-checkpoint-timeout. It is empty
+	// if idle checkpointing is not enabled for the sandbox.
+	IdleImagePath string `json:"idleImagePath"`
+
+	// IdleConf is the configuration the sandbox was created with, as
+	// returned by config.Config.ToFlags. It is used to restore the sandbox
+	// from IdleImagePath.
+	IdleConf []string `json:"idleConf"`
+
+	// AdoptedNetNSPath is the path to the network namespace of the container
+	// adopted by the sandbox, if it differs from the sandbox process's. See
+	// AdoptRoot.
+	AdoptedNetNSPath string `json:"adoptedNetNSPath"`
 }
 
 // Getpid returns the process ID of the sandbox process.
@@ -294,6 +347,16 @@ type Args struct {
 
 	// ExecFile is the file from the host used for program execution.
 	ExecFile *os.File
+
+	// ControllerFile, if set, is the control server socket of a previous
+	// sandbox process being upgraded. It is donated to the sandbox instead of
+	// a new control socket.
+	ControllerFile *os.File
+
+	// StdioFiles, if set, are the root container's stdio files, e.g. handed
+	// over by a previous sandbox process being upgraded. They are donated to
+	// the sandbox instead of the current process' stdio or a new console.
+	StdioFiles []*os.File
 }
 
 // New creates the sandbox process. The caller must call Destroy() on the
@@ -441,7 +504,7 @@ func (s *Sandbox) StartRoot(conf *config.Config, spec *specs.Spec) error {
 		return err
 	}
 	// Configure the network.
-	if err := setupNetwork(conn, pid, conf, disableIPv6); err != nil {
+	if err := setupNetwork(conn, pid, s.netNSPath(), spec, conf, disableIPv6); err != nil {
 		return fmt.Errorf("setting up network: %w", err)
 	}
 
@@ -462,10 +525,67 @@ func (s *Sandbox) StartSubcontainer(spec *specs.Spec, conf *config.Config, cid s
 	}
 	s.fixPidns(spec)
 
+	args, vdsoFile, err := newStartArgs(spec, conf, cid, stdios, goferFiles, goferFilestores, devIOFile, goferConfs)
+	if err != nil {
+		return err
+	}
+	if vdsoFile != nil {
+		defer vdsoFile.Close()
+	}
+
+	// Start running the container.
+	if err := s.call(boot.ContMgrStartSubcontainer, args, nil); err != nil {
+		return fmt.Errorf("starting sub-container %v: %w", spec.Process.Args, err)
+	}
+	return nil
+}
+
+// AdoptRoot binds the root container of the sandbox, which must be created
+// but not yet started, to container cid described by spec. The files have the
+// same meaning as in StartSubcontainer. On success, the sandbox is renamed to
+// cid.
+func (s *Sandbox) AdoptRoot(spec *specs.Spec, conf *config.Config, cid string, stdios, goferFiles, goferFilestores []*os.File, devIOFile *os.File, goferConfs []boot.GoferMountConf) error {
+	log.Debugf("Adopt container %q as root of sandbox %q, PID: %d", cid, s.ID, s.Pid.load())
+
+	if err := s.configureStdios(conf, stdios); err != nil {
+		return err
+	}
+
+	args, vdsoFile, err := newStartArgs(spec, conf, cid, stdios, goferFiles, goferFilestores, devIOFile, goferConfs)
+	if err != nil {
+		return err
+	}
+	if vdsoFile != nil {
+		defer vdsoFile.Close()
+	}
+
+	if err := s.call(boot.ContMgrAdoptRoot, args, nil); err != nil {
+		return fmt.Errorf("adopting container %q: %w", cid, err)
+	}
+	s.ID = cid
+	if ns, ok := specutils.GetNS(specs.NetworkNamespace, spec); ok && ns.Path != "" {
+		s.AdoptedNetNSPath = ns.Path
+	}
+	return nil
+}
+
+// netNSPath returns the path to the network namespace whose configuration is
+// copied into the sandbox's network stack.
+func (s *Sandbox) netNSPath() string {
+	if s.AdoptedNetNSPath != "" {
+		return s.AdoptedNetNSPath
+	}
+	return filepath.Join("/proc", strconv.Itoa(s.Pid.load()), "ns/net")
+}
+
+// newStartArgs returns the arguments to start container cid in the sandbox.
+// The returned VDSO file, if any, must be closed by the caller after the call.
+func newStartArgs(spec *specs.Spec, conf *config.Config, cid string, stdios, goferFiles, goferFilestores []*os.File, devIOFile *os.File, goferConfs []boot.GoferMountConf) (*boot.StartArgs, *os.File, error) {
 	// The payload contains (in this specific order):
 	// * stdin/stdout/stderr (optional: only present when not using TTY)
-	// * The subcontainer's gofer filestore files (optional)
-	// * The subcontainer's dev gofer file (optional)
+	// * The container's gofer filestore files (optional)
+	// * The container's dev gofer file (optional)
+	// * The container's VDSO file (optional)
 	// * Gofer files.
 	payload := urpc.FilePayload{}
 	payload.Files = append(payload.Files, stdios...)
@@ -473,26 +593,31 @@ func (s *Sandbox) StartSubcontainer(spec *specs.Spec, conf *config.Config, cid s
 	if devIOFile != nil {
 		payload.Files = append(payload.Files, devIOFile)
 	}
+	var vdsoFile *os.File
+	if path := boot.VDSOPath(conf, spec); path != "" {
+		var err error
+		vdsoFile, err = boot.OpenVDSOFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		payload.Files = append(payload.Files, vdsoFile)
+	}
 	payload.Files = append(payload.Files, goferFiles...)
 
-	// Start running the container.
-	args := boot.StartArgs{
+	return &boot.StartArgs{
 		Spec:                 spec,
 		Conf:                 conf,
 		CID:                  cid,
 		NumGoferFilestoreFDs: len(goferFilestores),
 		IsDevIoFilePresent:   devIOFile != nil,
+		IsVDSOFilePresent:    vdsoFile != nil,
 		GoferMountConfs:      goferConfs,
 		FilePayload:          payload,
-	}
-	if err := s.call(boot.ContMgrStartSubcontainer, &args, nil); err != nil {
-		return fmt.Errorf("starting sub-container %v: %w", spec.Process.Args, err)
-	}
-	return nil
+	}, vdsoFile, nil
 }
 
 // Restore sends the restore call for a container in the sandbox.
-func (s *Sandbox) Restore(conf *config.Config, spec *specs.Spec, cid string, imagePath string, direct, background bool) error {
+func (s *Sandbox) Restore(conf *config.Config, spec *specs.Spec, cid string, imagePath string, direct, background, allowIncompatible bool) error {
 	if err := hostsettings.Handle(conf); err != nil {
 		return fmt.Errorf("host settings: %w (use --host-settings=ignore to bypass)", err)
 	}
@@ -510,7 +635,8 @@ func (s *Sandbox) Restore(conf *config.Config, spec *specs.Spec, cid string, ima
 		FilePayload: urpc.FilePayload{
 			Files: []*os.File{sf},
 		},
-		Background: background,
+		Background:        background,
+		AllowIncompatible: allowIncompatible,
 	}
 
 	// If the pages file exists, we must pass it in.
@@ -560,7 +686,7 @@ func (s *Sandbox) Restore(conf *config.Config, spec *specs.Spec, cid string, ima
 		return err
 	}
 	// Configure the network.
-	if err := setupNetwork(conn, s.Pid.load(), conf, disableIPv6); err != nil {
+	if err := setupNetwork(conn, s.Pid.load(), s.netNSPath(), spec, conf, disableIPv6); err != nil {
 		return fmt.Errorf("setting up network: %v", err)
 	}
 
@@ -719,6 +845,17 @@ func (s *Sandbox) Event(cid string) (*boot.EventOut, error) {
 	return &e, nil
 }
 
+// MountIOStats retrieves the I/O and cache statistics of the mounts of
+// container cid.
+func (s *Sandbox) MountIOStats(cid string) ([]api.MountIOStats, error) {
+	log.Debugf("Getting mount I/O statistics for container %q in sandbox %q", cid, s.ID)
+	var stats []api.MountIOStats
+	if err := s.call(boot.ContMgrMountIOStats, &cid, &stats); err != nil {
+		return nil, fmt.Errorf("retrieving mount I/O statistics from sandbox: %w", err)
+	}
+	return stats, nil
+}
+
 // PortForward starts port forwarding to the sandbox.
 func (s *Sandbox) PortForward(opts *boot.PortForwardOpts) error {
 	log.Debugf("Requesting port forward for container %q in sandbox %q: %+v", opts.ContainerID, s.ID, opts)
@@ -735,6 +872,22 @@ func (s *Sandbox) PortForward(opts *boot.PortForwardOpts) error {
 	return nil
 }
 
+// CreateSharedMemLink connects the sandbox to a peer sandbox on the same host
+// over the shared memory queues in args.
+func (s *Sandbox) CreateSharedMemLink(args *boot.CreateSharedMemLinkArgs) error {
+	log.Debugf("Creating shared memory link %q in sandbox %q", args.Link.Name, s.ID)
+	conn, err := s.sandboxConnect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.Call(boot.NetworkCreateSharedMemLink, args, nil); err != nil {
+		return fmt.Errorf("creating shared memory link: %w", err)
+	}
+	return nil
+}
+
 // SetRootDir sets the root directory from the current runsc invocation.
 func (s *Sandbox) SetRootDir(rootDir string) {
 	s.rootDir = rootDir
@@ -767,6 +920,10 @@ func (s *Sandbox) sandboxConnect() (*urpc.Client, error) {
 	if path == "" {
 		return nil, fmt.Errorf("no control socket found for sandbox %q", s.ID)
 	}
+	token, err := os.ReadFile(controlTokenPath(path))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading control token: %w", err)
+	}
 	if len(path) >= linux.UnixPathMax {
 		// This is not an abstract socket path. It is a filesystem path.
 		// UDS connect fails when the len(socket path) >= UNIX_PATH_MAX. Instead
@@ -782,6 +939,7 @@ func (s *Sandbox) sandboxConnect() (*urpc.Client, error) {
 	if err != nil {
 		return nil, s.connError(err)
 	}
+	conn.Token = string(token)
 	return conn, nil
 }
 
@@ -856,6 +1014,14 @@ func (s *Sandbox) createSandboxProcess(conf *config.Config, args *Args, startSyn
 	if err := donations.DonateDebugLogFile("panic-log-fd", conf.PanicLog, "panic", test, s.StartTime); err != nil {
 		return fmt.Errorf("donating panic log file: %w", err)
 	}
+	if err := donatePanicReport(&donations, conf.PanicReport); err != nil {
+		return fmt.Errorf("donating panic report destination: %w", err)
+	}
+	// Strace can be enabled at runtime, so the destination is donated even
+	// if --strace isn't set.
+	if err := donateStraceLog(&donations, conf.StraceLog, test, s.StartTime); err != nil {
+		return fmt.Errorf("donating strace log destination: %w", err)
+	}
 	covFilename := conf.CoverageReport
 	if covFilename == "" {
 		covFilename = os.Getenv("GO_COVERAGE_FILE")
@@ -933,13 +1099,19 @@ func (s *Sandbox) createSandboxProcess(conf *config.Config, args *Args, startSyn
 	cmd.Args = append(cmd.Args, "--gofer-mount-confs="+args.GoferMountConfs.String())
 
 	// Create a socket for the control server and donate it to the sandbox.
-	controlSocketPath, sockFD, err := createControlSocket(conf.RootDir, s.ID)
-	if err != nil {
-		return fmt.Errorf("failed to create control socket: %v", err)
+	if args.ControllerFile != nil {
+		// Keep the socket, and therefore s.ControlSocketPath, of the sandbox
+		// process being upgraded.
+		donations.DonateAndClose("controller-fd", args.ControllerFile)
+	} else {
+		controlSocketPath, sockFD, err := createControlSocket(conf.RootDir, s.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create control socket: %v", err)
+		}
+		s.ControlSocketPath = controlSocketPath
+		log.Infof("Control socket path: %q", s.ControlSocketPath)
+		donations.DonateAndClose("controller-fd", os.NewFile(uintptr(sockFD), "control_server_socket"))
 	}
-	s.ControlSocketPath = controlSocketPath
-	log.Infof("Control socket path: %q", s.ControlSocketPath)
-	donations.DonateAndClose("controller-fd", os.NewFile(uintptr(sockFD), "control_server_socket"))
 
 	specFile, err := specutils.OpenSpec(args.BundleDir)
 	if err != nil {
@@ -950,7 +1122,33 @@ func (s *Sandbox) createSandboxProcess(conf *config.Config, args *Args, startSyn
 	if err := donations.OpenAndDonate("pod-init-config-fd", conf.PodInitConfig, os.O_RDONLY); err != nil {
 		return err
 	}
+	if conf.ControlPolicy != "" {
+		tokenPath := controlTokenPath(s.ControlSocketPath)
+		if args.ControllerFile == nil {
+			if err := createControlToken(tokenPath); err != nil {
+				return fmt.Errorf("creating control token: %w", err)
+			}
+		}
+		if err := donations.OpenAndDonate("control-policy-fd", conf.ControlPolicy, os.O_RDONLY); err != nil {
+			return err
+		}
+		if err := donations.OpenAndDonate("control-token-fd", tokenPath, os.O_RDONLY); err != nil {
+			return err
+		}
+	}
+	if conf.EntropySource == rand.SourceHWRNG {
+		if err := donations.OpenAndDonate("hwrng-fd", conf.HWRNGDevice, os.O_RDONLY); err != nil {
+			return err
+		}
+	}
 	donations.DonateAndClose("sink-fds", args.SinkFiles...)
+	if path := boot.VDSOPath(conf, args.Spec); path != "" {
+		vdsoFile, err := boot.OpenVDSOFile(path)
+		if err != nil {
+			return err
+		}
+		donations.DonateAndClose("vdso-fd", vdsoFile)
+	}
 
 	if len(conf.TestOnlyAutosaveImagePath) != 0 {
 		files, err := createSaveFiles(conf.TestOnlyAutosaveImagePath, false, statefile.CompressionLevelFlateBestSpeed)
@@ -958,6 +1156,23 @@ func (s *Sandbox) createSandboxProcess(conf *config.Config, args *Args, startSyn
 			return fmt.Errorf("failed to create auto save files: %w", err)
 		}
 		donations.DonateAndClose("save-fds", files...)
+	} else if conf.IdleCheckpointTimeout > 0 && !args.Spec.Process.Terminal {
+		// Any image left behind belongs to a destroyed sandbox with the
+		// same ID, so it is safe to discard it.
+		imagePath := filepath.Join(conf.IdleCheckpointImagePath, s.ID)
+		if err := os.RemoveAll(imagePath); err != nil {
+			return fmt.Errorf("removing stale idle checkpoint image %q: %w", imagePath, err)
+		}
+		if err := os.MkdirAll(imagePath, 0700); err != nil {
+			return fmt.Errorf("creating idle checkpoint image directory %q: %w", imagePath, err)
+		}
+		files, err := createSaveFiles(imagePath, false, statefile.CompressionLevelFlateBestSpeed)
+		if err != nil {
+			return fmt.Errorf("failed to create idle checkpoint files: %w", err)
+		}
+		donations.DonateAndClose("save-fds", files...)
+		s.IdleImagePath = imagePath
+		s.IdleConf = conf.ToFlags()
 	}
 
 	if err := createSandboxProcessExtra(conf, args, &donations); err != nil {
@@ -1130,9 +1345,15 @@ func (s *Sandbox) createSandboxProcess(conf *config.Config, args *Args, startSyn
 	cmd.Stderr = nil
 	var stdios [3]*os.File
 
-	// If the console control socket file is provided, then create a new
-	// pty master/replica pair and set the TTY on the sandbox process.
-	if args.Spec.Process.Terminal && args.ConsoleSocket != "" {
+	if len(args.StdioFiles) > 0 {
+		// The stdios were provided by the caller.
+		if len(args.StdioFiles) != len(stdios) {
+			return fmt.Errorf("got %d stdio files, want %d", len(args.StdioFiles), len(stdios))
+		}
+		copy(stdios[:], args.StdioFiles)
+	} else if args.Spec.Process.Terminal && args.ConsoleSocket != "" {
+		// If the console control socket file is provided, then create a new
+		// pty master/replica pair and set the TTY on the sandbox process.
 		// console.NewWithSocket will send the master on the given
 		// socket, and return the replica.
 		tty, err := console.NewWithSocket(args.ConsoleSocket)
@@ -1388,6 +1609,9 @@ func (s *Sandbox) destroy() error {
 		if err := os.Remove(controlSocketPath); err != nil {
 			log.Warningf("failed to delete control socket file %q: %v", controlSocketPath, err)
 		}
+		if err := os.Remove(controlTokenPath(controlSocketPath)); err != nil && !os.IsNotExist(err) {
+			log.Warningf("failed to delete control token file: %v", err)
+		}
 	}
 	pid := s.Pid.load()
 	if pid != 0 {
@@ -1615,6 +1839,58 @@ func (s *Sandbox) Stacks() (string, error) {
 	return stacks, nil
 }
 
+// FlightRecord returns the contents of the sandbox tasks' flight recorders.
+func (s *Sandbox) FlightRecord() (string, error) {
+	log.Debugf("Flight record sandbox %q", s.ID)
+	var record string
+	if err := s.call(boot.DebugFlightRecord, nil, &record); err != nil {
+		return "", fmt.Errorf("getting sandbox %q flight record: %w", s.ID, err)
+	}
+	return record, nil
+}
+
+// HostFDs returns the inventory of host FDs held by the sandbox at startup.
+func (s *Sandbox) HostFDs() ([]boot.HostFD, error) {
+	log.Debugf("Host FDs sandbox %q", s.ID)
+	var fds []boot.HostFD
+	if err := s.call(boot.DebugFDs, nil, &fds); err != nil {
+		return nil, fmt.Errorf("getting sandbox %q host FDs: %w", s.ID, err)
+	}
+	return fds, nil
+}
+
+// SetChaosRules installs fault injection rules in the sandbox, replacing any
+// previously installed ones. If rules is empty, fault injection is disabled.
+func (s *Sandbox) SetChaosRules(rules []faultinject.Rule) error {
+	log.Debugf("Set chaos rules in sandbox %q: %+v", s.ID, rules)
+	args := control.ChaosArgs{Rules: rules}
+	if err := s.call(boot.ChaosSetRules, &args, nil); err != nil {
+		return fmt.Errorf("setting sandbox %q chaos rules: %w", s.ID, err)
+	}
+	return nil
+}
+
+// SetTimeDilation makes the sandbox's clocks advance at rate times the speed
+// of the host's clocks.
+func (s *Sandbox) SetTimeDilation(rate float64) error {
+	log.Debugf("Set time dilation in sandbox %q to %v", s.ID, rate)
+	args := control.TimeDilationArgs{Rate: rate}
+	if err := s.call(boot.TimeSetDilation, &args, nil); err != nil {
+		return fmt.Errorf("setting sandbox %q time dilation: %w", s.ID, err)
+	}
+	return nil
+}
+
+// AdvanceTime steps the sandbox's clocks forward by d.
+func (s *Sandbox) AdvanceTime(d time.Duration) error {
+	log.Debugf("Advance time in sandbox %q by %v", s.ID, d)
+	args := control.TimeAdvanceArgs{Duration: d}
+	if err := s.call(boot.TimeAdvance, &args, nil); err != nil {
+		return fmt.Errorf("advancing sandbox %q time: %w", s.ID, err)
+	}
+	return nil
+}
+
 // HeapProfile writes a heap profile to the given file.
 func (s *Sandbox) HeapProfile(f *os.File, delay time.Duration) error {
 	log.Debugf("Heap profile %q", s.ID)
@@ -1881,6 +2157,49 @@ func (s *Sandbox) fixPidns(spec *specs.Spec) {
 	panic("unreachable")
 }
 
+// donatePanicReport opens dest, the destination of panic reports, and donates
+// it to the sandbox. dest is a file path, or the path of a unix stream socket
+// if prefixed with "unix:". It's a noop if dest is empty.
+func donatePanicReport(donations *donation.Agency, dest string) error {
+	path, ok := strings.CutPrefix(dest, "unix:")
+	if !ok {
+		return donations.OpenAndDonate("panic-report-fd", dest, os.O_CREATE|os.O_WRONLY|os.O_APPEND)
+	}
+	f, err := dialUnixFile(path)
+	if err != nil {
+		return err
+	}
+	donations.DonateAndClose("panic-report-fd", f)
+	return nil
+}
+
+// donateStraceLog donates the destination of --strace-log, which is either a
+// file pattern like --debug-log, or "unix:<path>" to stream to a collector
+// listening on a Unix stream socket.
+func donateStraceLog(donations *donation.Agency, dest, test string, start time.Time) error {
+	path, ok := strings.CutPrefix(dest, "unix:")
+	if !ok {
+		return donations.DonateDebugLogFile("strace-log-fd", dest, "strace", test, start)
+	}
+	f, err := dialUnixFile(path)
+	if err != nil {
+		return err
+	}
+	donations.DonateAndClose("strace-log-fd", f)
+	return nil
+}
+
+// dialUnixFile connects to the Unix stream socket at path and returns the
+// connection as a file.
+func dialUnixFile(path string) (*os.File, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.(*net.UnixConn).File()
+}
+
 // ConfigureCmdForRootless configures cmd to donate a socket FD that can be
 // used to synchronize userns configuration.
 func ConfigureCmdForRootless(cmd *exec.Cmd, donations *donation.Agency) (*os.File, error) {
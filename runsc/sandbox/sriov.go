@@ -0,0 +1,67 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// vfioGroupPathRegex matches VFIO group device paths, e.g. /dev/vfio/42.
+var vfioGroupPathRegex = regexp.MustCompile(`^/dev/vfio/(\d+)$`)
+
+// sriovVFs returns the PCI addresses of the SR-IOV virtual functions passed to
+// the container in spec. Every VFIO group in spec must only contain virtual
+// functions bound to the vfio-pci driver, so that the container can't take
+// over a physical function or an unrelated device.
+func sriovVFs(spec *specs.Spec, sysfsRoot string) ([]string, error) {
+	if spec.Linux == nil {
+		return nil, fmt.Errorf("no VFIO group devices in spec")
+	}
+	var vfs []string
+	for _, dev := range spec.Linux.Devices {
+		m := vfioGroupPathRegex.FindStringSubmatch(dev.Path)
+		if m == nil {
+			continue
+		}
+		groupDir := filepath.Join(sysfsRoot, "kernel/iommu_groups", m[1], "devices")
+		entries, err := os.ReadDir(groupDir)
+		if err != nil {
+			return nil, fmt.Errorf("reading IOMMU group of %q: %w", dev.Path, err)
+		}
+		for _, e := range entries {
+			addr := e.Name()
+			if _, err := os.Stat(filepath.Join(groupDir, addr, "physfn")); err != nil {
+				return nil, fmt.Errorf("PCI device %s in IOMMU group of %q is not an SR-IOV virtual function", addr, dev.Path)
+			}
+			driver, err := os.Readlink(filepath.Join(groupDir, addr, "driver"))
+			if err != nil {
+				return nil, fmt.Errorf("reading driver of PCI device %s: %w", addr, err)
+			}
+			if filepath.Base(driver) != "vfio-pci" {
+				return nil, fmt.Errorf("PCI device %s is bound to %q, want vfio-pci", addr, filepath.Base(driver))
+			}
+			vfs = append(vfs, addr)
+		}
+	}
+	if len(vfs) == 0 {
+		return nil, fmt.Errorf("no VFIO group devices in spec")
+	}
+	return vfs, nil
+}
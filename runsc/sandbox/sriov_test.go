@@ -0,0 +1,103 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// addPCIDevice creates a fake sysfs entry for a PCI device in the given IOMMU
+// group.
+func addPCIDevice(t *testing.T, sysfsRoot, group, addr, driver string, vf bool) {
+	t.Helper()
+	dir := filepath.Join(sysfsRoot, "kernel/iommu_groups", group, "devices", addr)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("../../../bus/pci/drivers", driver), filepath.Join(dir, "driver")); err != nil {
+		t.Fatal(err)
+	}
+	if vf {
+		if err := os.Mkdir(filepath.Join(dir, "physfn"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSRIOVVFs(t *testing.T) {
+	sysfsRoot := t.TempDir()
+	addPCIDevice(t, sysfsRoot, "10", "0000:3b:02.0", "vfio-pci", true)
+	addPCIDevice(t, sysfsRoot, "11", "0000:3b:02.1", "vfio-pci", true)
+	addPCIDevice(t, sysfsRoot, "20", "0000:3b:00.0", "vfio-pci", false)
+	addPCIDevice(t, sysfsRoot, "21", "0000:3b:02.2", "iavf", true)
+
+	for _, tc := range []struct {
+		name    string
+		devices []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "virtual functions",
+			devices: []string{"/dev/vfio/vfio", "/dev/vfio/10", "/dev/vfio/11"},
+			want:    []string{"0000:3b:02.0", "0000:3b:02.1"},
+		},
+		{
+			name:    "no groups",
+			devices: []string{"/dev/vfio/vfio"},
+			wantErr: true,
+		},
+		{
+			name:    "physical function",
+			devices: []string{"/dev/vfio/10", "/dev/vfio/20"},
+			wantErr: true,
+		},
+		{
+			name:    "not bound to vfio-pci",
+			devices: []string{"/dev/vfio/21"},
+			wantErr: true,
+		},
+		{
+			name:    "missing group",
+			devices: []string{"/dev/vfio/30"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &specs.Spec{Linux: &specs.Linux{}}
+			for _, path := range tc.devices {
+				spec.Linux.Devices = append(spec.Linux.Devices, specs.LinuxDevice{Path: path, Type: "c"})
+			}
+			got, err := sriovVFs(spec, sysfsRoot)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("sriovVFs() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sriovVFs(): %v", err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("sriovVFs() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/cleanup"
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/urpc"
+	"gvisor.dev/gvisor/runsc/boot"
+	"gvisor.dev/gvisor/runsc/config"
+)
+
+// UpgradeContainer describes a container of a sandbox being upgraded.
+type UpgradeContainer struct {
+	// ID is the container ID.
+	ID string
+
+	// Spec is the container's spec.
+	Spec *specs.Spec
+
+	// BundleDir is the directory containing the container bundle.
+	BundleDir string
+
+	// GoferMountConfs contains information about how the container's gofer
+	// mounts have been configured.
+	GoferMountConfs boot.GoferMountConfFlags
+}
+
+// upgradeFiles are the host files of a container handed over by Upgrade.
+type upgradeFiles struct {
+	stdios          []*os.File
+	goferFilestores []*os.File
+	devIO           *os.File
+	gofers          []*os.File
+}
+
+// Upgrade replaces the sandbox process with a new one running the current
+// runsc binary, without restarting the sandbox's containers. The sandbox is
+// saved to memory, and restored by the new sandbox process using the host FDs
+// handed over by the old one. Since the control socket is handed over as
+// well, clients that connect during the upgrade are served by the new sandbox
+// process once it has started.
+//
+// The old sandbox process stays paused until the new one has restored the
+// sandbox, and is then killed. If the new sandbox process fails to restore
+// the sandbox, it is killed instead and the old one resumes. Pending Wait
+// calls to the old sandbox process fail once it is killed; see
+// container.Container.Wait.
+//
+// containers must describe every container in the sandbox. runInCgroup must
+// run its argument in the sandbox's cgroup; it is used to start the new
+// sandbox process. The sandbox must have been started with --hot-upgrade.
+func (s *Sandbox) Upgrade(conf *config.Config, containers []UpgradeContainer, background bool, runInCgroup func(func() error) error) error {
+	oldPid := s.Pid.load()
+	log.Debugf("Upgrade sandbox %q, PID: %d", s.ID, oldPid)
+
+	// Refer to the old sandbox process through a pidfd, so that it can't be
+	// confused with another process once it exits.
+	pidfd, err := unix.PidfdOpen(oldPid, 0)
+	if err != nil {
+		return fmt.Errorf("opening pidfd of sandbox process %d: %w", oldPid, err)
+	}
+	defer unix.Close(pidfd)
+
+	// The old sandbox process stops accepting connections once it is saved,
+	// so the upgrade can only be aborted over the connection that it was
+	// prepared over.
+	conn, err := s.sandboxConnect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var result boot.UpgradeResult
+	saveFiles, err := saveForUpgrade(conn, conf, &result)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, f := range saveFiles {
+			_ = f.Close()
+		}
+	}()
+	defer func() {
+		for _, f := range result.Files {
+			_ = f.Close()
+		}
+	}()
+	abort := cleanup.Make(func() {
+		if err := conn.Call(boot.ContMgrUpgradeAbort, nil, nil); err != nil {
+			log.Warningf("Failed to resume sandbox %q after failed upgrade: %v", s.ID, err)
+			return
+		}
+		log.Infof("Upgrade of sandbox %q aborted, PID: %d", s.ID, oldPid)
+	})
+	defer abort.Clean()
+
+	// Split the result payload, see boot.UpgradeResult.
+	remaining := result.Files
+	take := func(n int) []*os.File {
+		n = min(n, len(remaining))
+		files := remaining[:n]
+		remaining = remaining[n:]
+		return files
+	}
+	controllerFile := take(1)
+	if len(controllerFile) != 1 || len(result.Containers) == 0 {
+		return fmt.Errorf("invalid upgrade result: %d files, %d containers", len(result.Files), len(result.Containers))
+	}
+	files := make(map[string]*upgradeFiles)
+	for _, c := range result.Containers {
+		f := &upgradeFiles{
+			stdios:          take(c.NumStdioFDs),
+			goferFilestores: take(c.NumGoferFilestoreFDs),
+		}
+		if c.HaveDevGoferFD {
+			if devIO := take(1); len(devIO) == 1 {
+				f.devIO = devIO[0]
+			}
+		}
+		f.gofers = take(c.NumGoferFDs)
+		files[c.CID] = f
+	}
+	var networkFiles []*os.File
+	if result.Network != nil {
+		networkFiles = take(len(remaining))
+	}
+	if len(remaining) != 0 || len(result.Containers) != len(files) {
+		return fmt.Errorf("invalid upgrade result: %d files, %d containers", len(result.Files), len(result.Containers))
+	}
+	byID := make(map[string]*UpgradeContainer)
+	for i := range containers {
+		byID[containers[i].ID] = &containers[i]
+	}
+	for cid := range files {
+		if _, ok := byID[cid]; !ok {
+			return fmt.Errorf("container %q of the upgraded sandbox is unknown", cid)
+		}
+	}
+	rootID := result.Containers[0].CID
+	root := byID[rootID]
+	rootFiles := files[rootID]
+	log.Infof("Sandbox %q saved for upgrade, starting new sandbox process", s.ID)
+
+	mountsFile, err := mountsMemfd(result.RootMounts)
+	if err != nil {
+		return err
+	}
+	args := &Args{
+		ID:                  s.ID,
+		Spec:                root.Spec,
+		BundleDir:           root.BundleDir,
+		IOFiles:             rootFiles.gofers,
+		DevIOFile:           rootFiles.devIO,
+		GoferFilestoreFiles: rootFiles.goferFilestores,
+		GoferMountConfs:     root.GoferMountConfs,
+		MountHints:          s.MountHints,
+		MountsFile:          mountsFile,
+		Cgroup:              s.CgroupJSON.Cgroup,
+		ControllerFile:      controllerFile[0],
+		StdioFiles:          rootFiles.stdios,
+	}
+	if len(conf.PodInitConfig) > 0 {
+		initConf, err := boot.LoadInitConfig(conf.PodInitConfig)
+		if err != nil {
+			return fmt.Errorf("loading init config file: %w", err)
+		}
+		args.SinkFiles, err = initConf.Setup()
+		if err != nil {
+			return fmt.Errorf("cannot init config: %w", err)
+		}
+	}
+
+	clientSyncFile, sandboxSyncFile, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("creating pipe for sandbox %q: %v", s.ID, err)
+	}
+	defer clientSyncFile.Close()
+
+	// createSandboxProcess overwrites the fields describing the sandbox
+	// process. Restore them if the upgrade is aborted.
+	var (
+		oldChild         = s.child
+		oldUID           = s.UID
+		oldGID           = s.GID
+		oldIdleImagePath = s.IdleImagePath
+		oldOOMScoreAdj   = s.OriginalOOMScoreAdj
+	)
+	abort.Add(func() {
+		if s.child && s.Pid.load() != oldPid {
+			if err := unix.Kill(s.Pid.load(), unix.SIGKILL); err != nil {
+				log.Warningf("Failed to kill new sandbox process %d: %v", s.Pid.load(), err)
+			} else if err := s.waitForStopped(); err != nil {
+				log.Warningf("Failed to wait for new sandbox process: %v", err)
+			}
+		}
+		s.Pid.store(oldPid)
+		s.child = oldChild
+		s.UID = oldUID
+		s.GID = oldGID
+		s.IdleImagePath = oldIdleImagePath
+		s.OriginalOOMScoreAdj = oldOOMScoreAdj
+	})
+	err = runInCgroup(func() error {
+		return s.createSandboxProcess(conf, args, sandboxSyncFile)
+	})
+	sandboxSyncFile.Close()
+	if err != nil {
+		return fmt.Errorf("cannot create sandbox process: %w", err)
+	}
+	s.OriginalOOMScoreAdj = oldOOMScoreAdj
+	b := make([]byte, 1)
+	if l, err := clientSyncFile.Read(b); err != nil || l != 1 {
+		return fmt.Errorf("waiting for sandbox to start: %v", err)
+	}
+
+	newConn, err := s.sandboxConnect()
+	if err != nil {
+		return err
+	}
+	defer newConn.Close()
+
+	// Set up the same links again, over the handed over FDs.
+	if result.Network != nil {
+		if result.Network.NATBlob && len(networkFiles) > 0 {
+			// The old sandbox process read the NAT ruleset through the same
+			// file description.
+			if _, err := networkFiles[len(networkFiles)-1].Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("rewinding NAT ruleset: %w", err)
+			}
+		}
+		result.Network.FilePayload = urpc.FilePayload{Files: networkFiles}
+		if err := newConn.Call(boot.NetworkCreateLinksAndRoutes, result.Network, nil); err != nil {
+			return fmt.Errorf("setting up network: %w", err)
+		}
+	}
+
+	restoreOpts := boot.RestoreOpts{
+		FilePayload:     urpc.FilePayload{Files: []*os.File{saveFiles[0]}},
+		Background:      background,
+		ExitedProcesses: result.ExitedProcesses,
+	}
+	if st, err := saveFiles[1].Stat(); err != nil {
+		return err
+	} else if st.Size() > 0 {
+		restoreOpts.HavePagesFile = true
+		restoreOpts.Files = append(restoreOpts.Files, saveFiles[1:]...)
+	}
+	if deviceFile, err := deviceFileForPlatform(conf.Platform, conf.PlatformDevicePath); err != nil {
+		return err
+	} else if deviceFile != nil {
+		defer deviceFile.Close()
+		restoreOpts.HaveDeviceFile = true
+		restoreOpts.Files = append(restoreOpts.Files, deviceFile.ReleaseToFile("device file"))
+	}
+	if err := newConn.Call(boot.ContMgrRestore, &restoreOpts, nil); err != nil {
+		return fmt.Errorf("restoring container %q: %w", rootID, err)
+	}
+
+	for _, c := range result.Containers[1:] {
+		sub := byID[c.CID]
+		f := files[c.CID]
+		if err := s.RestoreSubcontainer(sub.Spec, conf, sub.ID, f.stdios, f.gofers, f.goferFilestores, f.devIO, sub.GoferMountConfs); err != nil {
+			return err
+		}
+	}
+
+	// The new sandbox process has taken over. Kill the old one rather than
+	// letting it exit, since tearing down its kernel would use the gofer
+	// connections and network links that the new one now owns.
+	abort.Release()
+	if err := killAndWait(pidfd); err != nil {
+		return fmt.Errorf("killing old sandbox process %d: %w", oldPid, err)
+	}
+	if oldChild {
+		// Collect the zombie of the old sandbox process.
+		if _, err := unix.Wait4(oldPid, nil, 0, nil); err != nil {
+			log.Warningf("Failed to wait for old sandbox process %d: %v", oldPid, err)
+		}
+	}
+	log.Infof("Sandbox %q upgraded, PID: %d", s.ID, s.Pid.load())
+	return nil
+}
+
+// saveForUpgrade calls the Upgrade RPC over conn, and returns memfds holding
+// the sandbox state, pages metadata and pages. The sandbox writes them to
+// pipes that the caller copies into the memfds, so that the memory that they
+// use is charged to the caller rather than to the sandbox's cgroup, where it
+// could push the sandbox over its memory limit.
+func saveForUpgrade(conn *urpc.Client, conf *config.Config, result *boot.UpgradeResult) ([]*os.File, error) {
+	var memfds, readers, writers []*os.File
+	closeAll := func(files []*os.File) {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}
+	defer func() {
+		closeAll(readers)
+		closeAll(writers)
+	}()
+	cu := cleanup.Make(func() { closeAll(memfds) })
+	defer cu.Clean()
+	for _, name := range []string{boot.CheckpointStateFileName, boot.CheckpointPagesMetadataFileName, boot.CheckpointPagesFileName} {
+		fd, err := unix.MemfdCreate("runsc-upgrade-"+name, unix.MFD_CLOEXEC)
+		if err != nil {
+			return nil, fmt.Errorf("creating memfd for %q: %w", name, err)
+		}
+		memfds = append(memfds, os.NewFile(uintptr(fd), name))
+
+		// Unlike os.Pipe, this leaves the pipe in blocking mode, which the
+		// sandbox expects of the files it saves to.
+		var p [2]int
+		if err := unix.Pipe2(p[:], unix.O_CLOEXEC); err != nil {
+			return nil, fmt.Errorf("creating pipe for %q: %w", name, err)
+		}
+		readers = append(readers, os.NewFile(uintptr(p[0]), name+" reader"))
+		writers = append(writers, os.NewFile(uintptr(p[1]), name+" writer"))
+	}
+
+	var wg sync.WaitGroup
+	copyErrs := make([]error, len(memfds))
+	for i := range memfds {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := io.Copy(memfds[i], readers[i]); err != nil {
+				copyErrs[i] = fmt.Errorf("copying %q: %w", memfds[i].Name(), err)
+			}
+		}(i)
+	}
+	opts := boot.UpgradeOpts{
+		FilePayload: urpc.FilePayload{Files: writers},
+		Flags:       conf.ToFlags(),
+	}
+	err := conn.Call(boot.ContMgrUpgrade, &opts, result)
+	// The copies complete once both the sandbox and this process have closed
+	// the write ends of the pipes.
+	closeAll(writers)
+	writers = nil
+	wg.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("saving sandbox for upgrade: %w", err)
+	}
+	if err := errors.Join(copyErrs...); err != nil {
+		if abortErr := conn.Call(boot.ContMgrUpgradeAbort, nil, nil); abortErr != nil {
+			log.Warningf("Failed to resume sandbox after failed upgrade: %v", abortErr)
+		}
+		for _, f := range result.Files {
+			_ = f.Close()
+		}
+		return nil, err
+	}
+	for _, f := range memfds {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("rewinding %q: %w", f.Name(), err)
+		}
+	}
+	cu.Release()
+	return memfds, nil
+}
+
+// killAndWait kills the process referred to by pidfd, and waits for it to
+// exit.
+func killAndWait(pidfd int) error {
+	if err := unix.PidfdSendSignal(pidfd, unix.SIGKILL, nil, 0); err != nil {
+		return err
+	}
+	const waitTimeout = 2 * time.Minute
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		// The pidfd becomes readable once the process exits.
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return fmt.Errorf("process did not exit within %v", waitTimeout)
+		}
+		fds := []unix.PollFd{{Fd: int32(pidfd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(timeout.Milliseconds()))
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+	}
+}
+
+// mountsMemfd returns a memfd containing mounts, in the format read by
+// specutils.ReadMounts.
+func mountsMemfd(mounts []specs.Mount) (*os.File, error) {
+	data, err := json.Marshal(mounts)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := unix.MemfdCreate("runsc-upgrade-mounts", unix.MFD_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("creating memfd for mounts: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), "mounts file")
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("writing mounts: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
@@ -23,6 +23,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -138,12 +140,6 @@ func ValidateSpec(spec *specs.Spec) error {
 		log.Warningf("AppArmor profile %q is being ignored", spec.Process.ApparmorProfile)
 	}
 
-	// PR_SET_NO_NEW_PRIVS is assumed to always be set.
-	// See kernel.Task.updateCredsForExecLocked.
-	if !spec.Process.NoNewPrivileges {
-		log.Warningf("noNewPrivileges ignored. PR_SET_NO_NEW_PRIVS is assumed to always be set.")
-	}
-
 	if spec.Linux != nil && spec.Linux.RootfsPropagation != "" {
 		if err := validateRootfsPropagation(spec.Linux.RootfsPropagation); err != nil {
 			return err
@@ -401,6 +397,20 @@ func DropCapability(caps *specs.LinuxCapabilities, drop string) {
 	caps.Ambient = remove(caps.Ambient, drop)
 }
 
+// RestrictCapabilities removes all capabilities that are not in allowed from
+// all capability sets. It returns the capabilities that were removed.
+func RestrictCapabilities(caps *specs.LinuxCapabilities, allowed []string) []string {
+	var dropped []string
+	for _, c := range mergeUnique(caps.Bounding, caps.Effective, caps.Inheritable, caps.Permitted, caps.Ambient) {
+		if !slices.Contains(allowed, c) {
+			DropCapability(caps, c)
+			dropped = append(dropped, c)
+		}
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
 func mergeUnique(strSlices ...[]string) []string {
 	common := make(map[string]struct{})
 	for _, strSlice := range strSlices {
@@ -596,6 +606,12 @@ func TPUProxyIsEnabled(spec *specs.Spec, conf *config.Config) bool {
 	return AnnotationToBool(spec, AnnotationTPU)
 }
 
+// VFIOProxyIsEnabled checks if VFIO group devices are proxied to the host,
+// either for TPUs or for SR-IOV network virtual functions.
+func VFIOProxyIsEnabled(spec *specs.Spec, conf *config.Config) bool {
+	return TPUProxyIsEnabled(spec, conf) || conf.Network == config.NetworkSRIOV
+}
+
 // VFIOFunctionalityRequested returns true if the container should have access
 // to VFIO functionality.
 func VFIOFunctionalityRequested(dev *specs.LinuxDevice) bool {
@@ -17,6 +17,7 @@ package specutils
 import (
 	"fmt"
 	"os/exec"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -395,6 +396,26 @@ func TestSeccomp(t *testing.T) {
 	}
 }
 
+func TestRestrictCapabilities(t *testing.T) {
+	caps := &specs.LinuxCapabilities{
+		Bounding:  []string{"CAP_CHOWN", "CAP_NET_ADMIN", "CAP_NET_RAW"},
+		Effective: []string{"CAP_CHOWN", "CAP_NET_RAW"},
+		Permitted: []string{"CAP_CHOWN", "CAP_NET_ADMIN"},
+	}
+	dropped := RestrictCapabilities(caps, []string{"CAP_CHOWN", "CAP_SYS_PTRACE"})
+	if want := []string{"CAP_NET_ADMIN", "CAP_NET_RAW"}; !reflect.DeepEqual(dropped, want) {
+		t.Errorf("RestrictCapabilities() dropped %v, want %v", dropped, want)
+	}
+	want := &specs.LinuxCapabilities{
+		Bounding:  []string{"CAP_CHOWN"},
+		Effective: []string{"CAP_CHOWN"},
+		Permitted: []string{"CAP_CHOWN"},
+	}
+	if !reflect.DeepEqual(caps, want) {
+		t.Errorf("RestrictCapabilities() got %+v, want %+v", caps, want)
+	}
+}
+
 func TestNvidiaDriverCapabilities(t *testing.T) {
 	testAllowedCapsFlag := "utility,compute,graphics"
 	testAllowedCaps, _, err := nvconf.DriverCapsFromString(testAllowedCapsFlag)
@@ -49,6 +49,7 @@ func main() {
 	subcommands.Register(new(forkBomb), "")
 	subcommands.Register(new(fsTreeCreator), "")
 	subcommands.Register(new(gvisorDetect), "")
+	subcommands.Register(new(mmapFile), "")
 	subcommands.Register(new(ptyRunner), "")
 	subcommands.Register(new(reaper), "")
 	subcommands.Register(new(syscall), "")
@@ -0,0 +1,98 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/google/subcommands"
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/runsc/flag"
+)
+
+// mmapFile writes to or reads from a file through a shared memory mapping.
+type mmapFile struct {
+	path  string
+	write string
+	want  string
+}
+
+// Name implements subcommands.Command.Name.
+func (*mmapFile) Name() string {
+	return "mmap-file"
+}
+
+// Synopsis implements subcommands.Command.Synopsys.
+func (*mmapFile) Synopsis() string {
+	return "writes to or reads from a file through a MAP_SHARED mapping"
+}
+
+// Usage implements subcommands.Command.Usage.
+func (*mmapFile) Usage() string {
+	return "mmap-file --path=<path> [--write=<data>] [--want=<data>]"
+}
+
+// SetFlags implements subcommands.Command.SetFlags.
+func (m *mmapFile) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&m.path, "path", "", "path to the file to map")
+	f.StringVar(&m.write, "write", "", "data to write at the beginning of the file through the mapping; the file is resized to fit")
+	f.StringVar(&m.want, "want", "", "data expected at the beginning of the file when read through the mapping")
+}
+
+// Execute implements subcommands.Command.Execute.
+func (m *mmapFile) Execute(ctx context.Context, f *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	if m.path == "" || (m.write == "") == (m.want == "") {
+		fmt.Println("--path and exactly one of --write or --want must be set")
+		return subcommands.ExitUsageError
+	}
+	file, err := os.OpenFile(m.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		fmt.Printf("open(%q) failed: %v\n", m.path, err)
+		return subcommands.ExitFailure
+	}
+	defer file.Close()
+
+	size := len(m.want)
+	prot := unix.PROT_READ
+	if m.write != "" {
+		size = len(m.write)
+		prot |= unix.PROT_WRITE
+		if err := file.Truncate(int64(size)); err != nil {
+			fmt.Printf("ftruncate(%q, %d) failed: %v\n", m.path, size, err)
+			return subcommands.ExitFailure
+		}
+	}
+	data, err := unix.Mmap(int(file.Fd()), 0, size, prot, unix.MAP_SHARED)
+	if err != nil {
+		fmt.Printf("mmap(%q) failed: %v\n", m.path, err)
+		return subcommands.ExitFailure
+	}
+	defer unix.Munmap(data)
+
+	if m.write != "" {
+		// Deliberately don't msync(2): writes through a MAP_SHARED mapping must
+		// be visible to other users of the file immediately.
+		copy(data, m.write)
+		return subcommands.ExitSuccess
+	}
+	if !bytes.Equal(data, []byte(m.want)) {
+		fmt.Printf("mapping of %q contains %q, want: %q\n", m.path, data, m.want)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
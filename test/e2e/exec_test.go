@@ -282,6 +282,33 @@ func TestNoEpermFileCap(t *testing.T) {
 	}
 }
 
+// Test that file capabilities are applied when an unprivileged process
+// execve(2)s a file with file capabilities.
+func TestFileCapOnExecve(t *testing.T) {
+	ctx := context.Background()
+	d := dockerutil.MakeContainer(ctx, t)
+	defer d.CleanUp(ctx)
+
+	if err := d.Spawn(ctx, dockerutil.RunOpts{
+		Image:  "basic/filecap",
+		CapAdd: []string{"NET_ADMIN"},
+	}, "sleep", "infinity"); err != nil {
+		t.Fatalf("docker run failed: %v", err)
+	}
+
+	// In basic/filecap image, /mnt/cat has file cap set to cap_net_admin+ep.
+	// The shell runs without any capabilities and gains CAP_NET_ADMIN only by
+	// executing /mnt/cat.
+	output, err := d.Exec(ctx, dockerutil.ExecOpts{User: "1001"}, "sh", "-c", "/mnt/cat /proc/self/status; true")
+	if err != nil {
+		t.Fatalf("failed to execute /mnt/cat: %v", err)
+	}
+	netAdminOnlyStatusCaps := fmt.Sprintf("CapInh:\t%s\nCapPrm:\t%s\nCapEff:\t%s\n", noCap, netAdminOnlyCap, netAdminOnlyCap)
+	if !strings.Contains(output, netAdminOnlyStatusCaps) {
+		t.Errorf("can't find expected output %q in output: %q", netAdminOnlyStatusCaps, output)
+	}
+}
+
 // Test that 'exec --privileged' adds all capabilities, except for CAP_NET_RAW
 // which is removed from the container when --net-raw=false.
 func TestExecPrivileged(t *testing.T) {
@@ -0,0 +1,144 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <fcntl.h>
+#include <linux/memfd.h>
+#include <signal.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/mman.h>
+#include <sys/socket.h>
+#include <sys/syscall.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+#include "gtest/gtest.h"
+#include "benchmark/benchmark.h"
+#include "test/util/logging.h"
+#include "test/util/test_util.h"
+
+#ifndef F_ADD_SEALS
+#define F_ADD_SEALS (F_LINUX_SPECIFIC_BASE + 9)
+#endif
+
+#ifndef F_SEAL_WRITE
+#define F_SEAL_WRITE 0x0008
+#endif
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+// sendFD sends fd over the unix domain socket sock.
+void sendFD(int sock, int fd) {
+  char data = 0;
+  struct iovec iov = {&data, sizeof(data)};
+  char control[CMSG_SPACE(sizeof(int))] = {};
+  struct msghdr msg = {};
+  msg.msg_iov = &iov;
+  msg.msg_iovlen = 1;
+  msg.msg_control = control;
+  msg.msg_controllen = sizeof(control);
+  struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+  cmsg->cmsg_level = SOL_SOCKET;
+  cmsg->cmsg_type = SCM_RIGHTS;
+  cmsg->cmsg_len = CMSG_LEN(sizeof(int));
+  memcpy(CMSG_DATA(cmsg), &fd, sizeof(int));
+  TEST_PCHECK(sendmsg(sock, &msg, 0) == 1);
+}
+
+// recvFD receives a file descriptor from the unix domain socket sock.
+int recvFD(int sock) {
+  char data;
+  struct iovec iov = {&data, sizeof(data)};
+  char control[CMSG_SPACE(sizeof(int))] = {};
+  struct msghdr msg = {};
+  msg.msg_iov = &iov;
+  msg.msg_iovlen = 1;
+  msg.msg_control = control;
+  msg.msg_controllen = sizeof(control);
+  TEST_PCHECK(recvmsg(sock, &msg, 0) == 1);
+  struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+  TEST_CHECK(cmsg != nullptr && cmsg->cmsg_type == SCM_RIGHTS);
+  int fd;
+  memcpy(&fd, CMSG_DATA(cmsg), sizeof(int));
+  return fd;
+}
+
+// produceBatches is the body of a dataloader worker process: each time a byte
+// is received on sock, it fills a new sealed memfd of the given size and sends
+// it back.
+void produceBatches(int sock, size_t size) {
+  char req;
+  while (read(sock, &req, 1) == 1) {
+    int fd = syscall(__NR_memfd_create, "batch", MFD_ALLOW_SEALING);
+    TEST_PCHECK(fd >= 0);
+    TEST_PCHECK(ftruncate(fd, size) == 0);
+    void* addr = mmap(nullptr, size, PROT_READ | PROT_WRITE, MAP_SHARED, fd, 0);
+    TEST_PCHECK(addr != MAP_FAILED);
+    memset(addr, req, size);
+    TEST_PCHECK(munmap(addr, size) == 0);
+    TEST_PCHECK(fcntl(fd, F_ADD_SEALS, F_SEAL_WRITE) == 0);
+    sendFD(sock, fd);
+    TEST_PCHECK(close(fd) == 0);
+  }
+  _exit(0);
+}
+
+// Models the way PyTorch dataloader workers hand batches to the main process:
+// the worker fills a memfd and passes it over a unix domain socket, and the
+// main process maps and reads it without copying.
+void BM_MemfdShareBatch(benchmark::State& state) {
+  const size_t size = state.range(0) * kPageSize;
+
+  int socks[2];
+  TEST_PCHECK(socketpair(AF_UNIX, SOCK_STREAM, 0, socks) == 0);
+  pid_t child = fork();
+  TEST_PCHECK(child >= 0);
+  if (child == 0) {
+    close(socks[0]);
+    produceBatches(socks[1], size);
+  }
+  TEST_PCHECK(close(socks[1]) == 0);
+
+  char req = 1;
+  for (auto _ : state) {
+    TEST_PCHECK(write(socks[0], &req, 1) == 1);
+    int fd = recvFD(socks[0]);
+    void* addr = mmap(nullptr, size, PROT_READ, MAP_SHARED, fd, 0);
+    TEST_PCHECK(addr != MAP_FAILED);
+    const char* c = reinterpret_cast<const char*>(addr);
+    for (size_t off = 0; off < size; off += kPageSize) {
+      TEST_CHECK(c[off] == req);
+    }
+    TEST_PCHECK(munmap(addr, size) == 0);
+    TEST_PCHECK(close(fd) == 0);
+  }
+
+  TEST_PCHECK(close(socks[0]) == 0);
+  int status;
+  TEST_PCHECK(waitpid(child, &status, 0) == child);
+  TEST_CHECK(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+
+  state.SetBytesProcessed(static_cast<int64_t>(size) *
+                          static_cast<int64_t>(state.iterations()));
+}
+
+BENCHMARK(BM_MemfdShareBatch)->Range(1, 1 << 14)->UseRealTime();
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
@@ -0,0 +1,101 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gvisor.dev/gvisor/pkg/test/testutil"
+	"gvisor.dev/gvisor/runsc/cgroup"
+	"gvisor.dev/gvisor/runsc/container"
+)
+
+// TestAdoptCgroup checks that a blank sandbox adopted by a container is moved
+// to the container's cgroup, which gets the container's resource limits.
+func TestAdoptCgroup(t *testing.T) {
+	rootDir, clean, err := testutil.SetupRootDir()
+	if err != nil {
+		t.Fatalf("error creating root dir: %v", err)
+	}
+	defer clean()
+	conf := testutil.TestConfig(t)
+	conf.RootDir = rootDir
+
+	blankSpec := testutil.NewSpecWithArgs("sleep", "1000")
+	blankSpec.Linux = &specs.Linux{CgroupsPath: "/" + testutil.RandomID("runsc-blank-")}
+	blankBundle, clean, err := testutil.SetupBundleDir(blankSpec)
+	if err != nil {
+		t.Fatalf("error setting up bundle: %v", err)
+	}
+	defer clean()
+	c, err := container.New(conf, container.Args{
+		ID:        testutil.RandomContainerID(),
+		Spec:      blankSpec,
+		BundleDir: blankBundle,
+	})
+	if err != nil {
+		t.Fatalf("error creating container: %v", err)
+	}
+	defer c.Destroy()
+
+	controller := "memory"
+	if cgroup.IsOnlyV2() {
+		controller = ""
+	}
+	blankPath := c.Sandbox.CgroupJSON.Cgroup.MakePath(controller)
+
+	limit := int64(512 << 20)
+	spec := testutil.NewSpecWithArgs("sleep", "1000")
+	spec.Linux = &specs.Linux{
+		CgroupsPath: "/" + testutil.RandomID("runsc-adopt-"),
+		Resources: &specs.LinuxResources{
+			Memory: &specs.LinuxMemory{Limit: &limit},
+		},
+	}
+	bundle, clean, err := testutil.SetupBundleDir(spec)
+	if err != nil {
+		t.Fatalf("error setting up bundle: %v", err)
+	}
+	defer clean()
+	if err := c.Adopt(conf, container.Args{
+		ID:        testutil.RandomContainerID(),
+		Spec:      spec,
+		BundleDir: bundle,
+	}); err != nil {
+		t.Fatalf("error adopting container: %v", err)
+	}
+	if err := c.Start(conf); err != nil {
+		t.Fatalf("error starting container: %v", err)
+	}
+
+	path := c.Sandbox.CgroupJSON.Cgroup.MakePath(controller)
+	if path == blankPath {
+		t.Fatalf("sandbox cgroup is still %q after adoption", path)
+	}
+	if err := verifyPid(c.Sandbox.Getpid(), filepath.Join(path, "cgroup.procs")); err != nil {
+		t.Errorf("sandbox is not in the adopted container's cgroup: %v", err)
+	}
+	if got, err := c.Sandbox.CgroupJSON.Cgroup.MemoryLimit(); err != nil {
+		t.Errorf("error reading memory limit: %v", err)
+	} else if got != uint64(limit) {
+		t.Errorf("got memory limit %d, want %d", got, limit)
+	}
+	if _, err := os.Stat(blankPath); !os.IsNotExist(err) {
+		t.Errorf("cgroup of the blank sandbox %q was not removed: %v", blankPath, err)
+	}
+}
@@ -21,6 +21,7 @@
 
 #include <algorithm>
 #include <string>
+#include <vector>
 
 #include "gtest/gtest.h"
 #include "test/syscalls/linux/file_base.h"
@@ -59,6 +60,10 @@ PosixErrorOr<size_t> VmaSizeAt(uintptr_t addr) {
   return it->end - it->start;
 }
 
+#ifndef RWF_DSYNC
+#define RWF_DSYNC 0x2
+#endif  // RWF_DSYNC
+
 constexpr char kData[] = "hello world!";
 
 int SubmitCtx(aio_context_t ctx, long nr, struct iocb** iocbpp) {
@@ -357,6 +362,72 @@ TEST_F(AIOTest, Timeout) {
   ASSERT_THAT(GetEvents(1, 1, events, &timeout), SyscallSucceedsWithValue(0));
 }
 
+TEST_F(AIOTest, ManyConcurrentWrites) {
+  constexpr int kNumRequests = 64;
+  constexpr int kChunkSize = 4096;
+  ASSERT_THAT(SetupContext(kNumRequests), SyscallSucceeds());
+
+  std::vector<std::string> bufs;
+  std::vector<struct iocb> cbs(kNumRequests);
+  std::vector<struct iocb*> cbps(kNumRequests);
+  for (int i = 0; i < kNumRequests; i++) {
+    bufs.push_back(std::string(kChunkSize, 'a' + (i % 26)));
+  }
+  for (int i = 0; i < kNumRequests; i++) {
+    cbs[i] = CreateCallback();
+    cbs[i].aio_data = i;
+    cbs[i].aio_buf = reinterpret_cast<uint64_t>(bufs[i].data());
+    cbs[i].aio_nbytes = kChunkSize;
+    cbs[i].aio_offset = i * kChunkSize;
+    cbps[i] = &cbs[i];
+  }
+
+  ASSERT_THAT(Submit(kNumRequests, cbps.data()),
+              SyscallSucceedsWithValue(kNumRequests));
+
+  std::vector<struct io_event> events(kNumRequests);
+  int done = 0;
+  while (done < kNumRequests) {
+    int n = GetEvents(1, kNumRequests - done, events.data() + done, nullptr);
+    ASSERT_THAT(n, SyscallSucceeds());
+    done += n;
+  }
+  for (const auto& ev : events) {
+    EXPECT_EQ(ev.res, kChunkSize);
+  }
+
+  std::string contents(kNumRequests * kChunkSize, 0);
+  ASSERT_THAT(pread(test_file_fd_.get(), contents.data(), contents.size(), 0),
+              SyscallSucceedsWithValue(contents.size()));
+  for (int i = 0; i < kNumRequests; i++) {
+    EXPECT_EQ(contents.substr(i * kChunkSize, kChunkSize), bufs[i]);
+  }
+}
+
+TEST_F(AIOTest, InvalidRWFlags) {
+  ASSERT_THAT(SetupContext(128), SyscallSucceeds());
+
+  struct iocb cb = CreateCallback();
+  struct iocb* cbs[1] = {&cb};
+  cb.aio_rw_flags = 0x80000000;
+
+  ASSERT_THAT(Submit(1, cbs), SyscallFailsWithErrno(EOPNOTSUPP));
+}
+
+TEST_F(AIOTest, RWFlagsDsync) {
+  ASSERT_THAT(SetupContext(128), SyscallSucceeds());
+
+  struct iocb cb = CreateCallback();
+  struct iocb* cbs[1] = {&cb};
+  cb.aio_rw_flags = RWF_DSYNC;
+
+  ASSERT_THAT(Submit(1, cbs), SyscallSucceedsWithValue(1));
+
+  struct io_event events[1];
+  ASSERT_THAT(GetEvents(1, 1, events, nullptr), SyscallSucceedsWithValue(1));
+  EXPECT_EQ(events[0].res, strlen(kData));
+}
+
 class AIOReadWriteParamTest : public AIOTest,
                               public ::testing::WithParamInterface<int> {};
 
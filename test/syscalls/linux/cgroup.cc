@@ -15,10 +15,14 @@
 // All tests in this file rely on being about to mount and unmount cgroupfs,
 // which isn't expected to work, or be safe on a general linux system.
 
+#include <fcntl.h>
 #include <limits.h>
 #include <linux/magic.h>
+#include <signal.h>
 #include <sys/mount.h>
 #include <sys/statfs.h>
+#include <sys/syscall.h>
+#include <sys/wait.h>
 #include <unistd.h>
 
 #include <cerrno>
@@ -34,6 +38,7 @@
 #include "absl/time/time.h"
 #include "test/util/cgroup_util.h"
 #include "test/util/cleanup.h"
+#include "test/util/file_descriptor.h"
 #include "test/util/linux_capability_util.h"
 #include "test/util/mount_util.h"
 #include "test/util/posix_error.h"
@@ -55,7 +60,7 @@ using ::testing::Key;
 using ::testing::Not;
 
 std::vector<std::string> known_controllers = {
-    "cpu", "cpuset", "cpuacct", "devices", "job", "memory", "pids",
+    "blkio", "cpu", "cpuset", "cpuacct", "devices", "job", "memory", "pids",
 };
 
 bool CgroupsAvailable() {
@@ -63,6 +68,40 @@ bool CgroupsAvailable() {
          TEST_CHECK_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN));
 }
 
+#ifndef SYS_clone3
+#define SYS_clone3 435
+#endif  // SYS_clone3
+
+#ifndef CLONE_INTO_CGROUP
+#define CLONE_INTO_CGROUP 0x200000000ULL
+#endif  // CLONE_INTO_CGROUP
+
+// CloneArgs is struct clone_args, from include/uapi/linux/sched.h. Old
+// versions of glibc do not expose it.
+struct CloneArgs {
+  uint64_t flags;
+  uint64_t pidfd;
+  uint64_t child_tid;
+  uint64_t parent_tid;
+  uint64_t exit_signal;
+  uint64_t stack;
+  uint64_t stack_size;
+  uint64_t tls;
+  uint64_t set_tid;
+  uint64_t set_tid_size;
+  uint64_t cgroup;
+};
+
+// CloneIntoCgroup forks a child directly into the cgroup directory open as
+// cgroup_fd, using clone3(CLONE_INTO_CGROUP).
+pid_t CloneIntoCgroup(uint64_t cgroup_fd) {
+  CloneArgs ca = {};
+  ca.flags = CLONE_INTO_CGROUP;
+  ca.exit_signal = SIGCHLD;
+  ca.cgroup = cgroup_fd;
+  return syscall(SYS_clone3, &ca, sizeof(ca));
+}
+
 // NoopThreads spawns a set of threads that do nothing until they're asked to
 // exit. Useful for testing functionality that requires a process with multiple
 // threads.
@@ -320,6 +359,44 @@ TEST(Cgroup, MigrateInvalidPID) {
               PosixErrorIs(EINVAL));
 }
 
+TEST(Cgroup, CloneIntoCgroup) {
+  SKIP_IF(!CgroupsAvailable());
+  Cgroup c = Cgroup::RootCgroup("/sys/fs/cgroup/cpuacct");
+  Cgroup child = ASSERT_NO_ERRNO_AND_VALUE(c.CreateChild("child1"));
+  const FileDescriptor dirfd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open(child.Path(), O_RDONLY | O_DIRECTORY));
+
+  pid_t pid;
+  ASSERT_THAT(pid = CloneIntoCgroup(dirfd.get()), SyscallSucceeds());
+  if (pid == 0) {
+    _exit(child.ContainsCallingProcess().ok() ? 0 : 1);
+  }
+
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status = " << status;
+
+  // The parent stays in its original cgroup.
+  EXPECT_NO_ERRNO(c.ContainsCallingProcess());
+}
+
+TEST(Cgroup, CloneIntoCgroupInvalidFD) {
+  SKIP_IF(!CgroupsAvailable());
+
+  // Not a cgroup directory.
+  const FileDescriptor dirfd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open("/", O_RDONLY | O_DIRECTORY));
+  EXPECT_THAT(CloneIntoCgroup(dirfd.get()), SyscallFailsWithErrno(EBADF));
+
+  // Not an open file descriptor.
+  EXPECT_THAT(CloneIntoCgroup(INT_MAX), SyscallFailsWithErrno(EBADF));
+
+  // Out of range.
+  EXPECT_THAT(CloneIntoCgroup(uint64_t{INT_MAX} + 1),
+              SyscallFailsWithErrno(EINVAL));
+}
+
 // Regression test for b/222278194.
 TEST(Cgroup, DuplicateUnlinkOnDirFD) {
   SKIP_IF(!CgroupsAvailable());
@@ -496,6 +573,25 @@ TEST(MemoryCgroup, MemoryUsageInBytes) {
   EXPECT_GE(usage, 0);
 }
 
+TEST(MemoryCgroup, MemoryStatReportsShmem) {
+  SKIP_IF(!CgroupsAvailable());
+
+  Cgroup c = Cgroup::RootCgroup("/sys/fs/cgroup/memory");
+  std::string stat =
+      ASSERT_NO_ERRNO_AND_VALUE(c.ReadControlFile("memory.stat"));
+
+  absl::flat_hash_set<std::string> keys;
+  for (absl::string_view line :
+       absl::StrSplit(stat, '\n', absl::SkipEmpty())) {
+    std::vector<absl::string_view> tokens = absl::StrSplit(line, ' ');
+    ASSERT_EQ(tokens.size(), 2) << line;
+    EXPECT_THAT(Atoi<int64_t>(tokens[1]), IsPosixErrorOkAndHolds(Ge(0)));
+    keys.insert(std::string(tokens[0]));
+  }
+  EXPECT_TRUE(keys.contains("shmem"));
+  EXPECT_TRUE(keys.contains("total_shmem"));
+}
+
 TEST(CPUCgroup, ControlFilesHaveDefaultValues) {
   SKIP_IF(!CgroupsAvailable());
 
@@ -961,6 +1057,26 @@ TEST(PIDsCgroup, MigrationCanExceedLimit) {
               IsPosixErrorOkAndHolds(Gt(0)));
 }
 
+TEST(PIDsCgroup, CloneIntoCgroupChargesDestination) {
+  SKIP_IF(!CgroupsAvailable());
+
+  Cgroup c = Cgroup::RootCgroup("/sys/fs/cgroup/pids");
+  Cgroup child = ASSERT_NO_ERRNO_AND_VALUE(c.CreateChild("child"));
+  ASSERT_NO_ERRNO(child.WriteIntegerControlFile("pids.max", 0));
+  const FileDescriptor dirfd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open(child.Path(), O_RDONLY | O_DIRECTORY));
+
+  // The limit of the destination applies, even though the parent's cgroup is
+  // unlimited.
+  const pid_t pid = CloneIntoCgroup(dirfd.get());
+  if (pid == 0) {
+    _exit(0);
+  }
+  EXPECT_THAT(pid, SyscallFailsWithErrno(EAGAIN));
+  EXPECT_THAT(child.ReadIntegerControlFile("pids.current"),
+              IsPosixErrorOkAndHolds(0));
+}
+
 TEST(PIDsCgroup, SetInvalidLimit) {
   SKIP_IF(!CgroupsAvailable());
 
@@ -1000,6 +1116,101 @@ TEST(PIDsCgroup, CanLowerLimitBelowCurrentCharge) {
   EXPECT_NO_ERRNO(child.WriteIntegerControlFile("pids.max", 0));
 }
 
+TEST(BlkioCgroup, ControlFilesExist) {
+  SKIP_IF(!CgroupsAvailable());
+
+  Cgroup c = Cgroup::RootCgroup("/sys/fs/cgroup/blkio");
+  EXPECT_THAT(c.ReadIntegerControlFile("blkio.weight"),
+              IsPosixErrorOkAndHolds(500));
+  EXPECT_THAT(c.ReadControlFile("blkio.throttle.read_bps_device"),
+              IsPosixErrorOkAndHolds(""));
+  EXPECT_THAT(c.ReadControlFile("blkio.throttle.write_iops_device"),
+              IsPosixErrorOkAndHolds(""));
+  EXPECT_NO_ERRNO(c.ReadControlFile("blkio.throttle.io_service_bytes"));
+  EXPECT_NO_ERRNO(c.ReadControlFile("blkio.throttle.io_serviced"));
+  EXPECT_THAT(c.ReadControlFile("io.weight"),
+              IsPosixErrorOkAndHolds("default 100\n"));
+  EXPECT_THAT(c.ReadControlFile("io.max"), IsPosixErrorOkAndHolds(""));
+  EXPECT_NO_ERRNO(c.ReadControlFile("io.stat"));
+}
+
+TEST(BlkioCgroup, SetWeight) {
+  SKIP_IF(!CgroupsAvailable());
+
+  Cgroup c = Cgroup::RootCgroup("/sys/fs/cgroup/blkio");
+  Cgroup child = ASSERT_NO_ERRNO_AND_VALUE(c.CreateChild("child"));
+
+  ASSERT_NO_ERRNO(child.WriteIntegerControlFile("blkio.weight", 100));
+  EXPECT_THAT(child.WriteIntegerControlFile("blkio.weight", 1),
+              PosixErrorIs(ERANGE, _));
+  EXPECT_THAT(child.WriteIntegerControlFile("blkio.weight", 1001),
+              PosixErrorIs(ERANGE, _));
+  EXPECT_THAT(child.ReadIntegerControlFile("blkio.weight"),
+              IsPosixErrorOkAndHolds(100));
+}
+
+TEST(BlkioCgroup, SetIOWeight) {
+  SKIP_IF(!CgroupsAvailable());
+
+  Cgroup c = Cgroup::RootCgroup("/sys/fs/cgroup/blkio");
+  Cgroup child = ASSERT_NO_ERRNO_AND_VALUE(c.CreateChild("child"));
+
+  ASSERT_NO_ERRNO(child.WriteControlFile("io.weight", "default 200"));
+  EXPECT_THAT(child.ReadControlFile("io.weight"),
+              IsPosixErrorOkAndHolds("default 200\n"));
+  // blkio.weight is a scaled view of io.weight.
+  EXPECT_THAT(child.ReadIntegerControlFile("blkio.weight"),
+              IsPosixErrorOkAndHolds(1000));
+  ASSERT_NO_ERRNO(child.WriteIntegerControlFile("io.weight", 50));
+  EXPECT_THAT(child.ReadIntegerControlFile("blkio.weight"),
+              IsPosixErrorOkAndHolds(250));
+  EXPECT_THAT(child.WriteIntegerControlFile("io.weight", 0),
+              PosixErrorIs(ERANGE, _));
+  EXPECT_THAT(child.WriteIntegerControlFile("io.weight", 10001),
+              PosixErrorIs(ERANGE, _));
+}
+
+TEST(BlkioCgroup, SetIOMax) {
+  SKIP_IF(!CgroupsAvailable());
+
+  Cgroup c = Cgroup::RootCgroup("/sys/fs/cgroup/blkio");
+  Cgroup child = ASSERT_NO_ERRNO_AND_VALUE(c.CreateChild("child"));
+
+  ASSERT_NO_ERRNO(child.WriteControlFile("io.max", "8:0 wbps=1048576"));
+  EXPECT_THAT(child.ReadControlFile("io.max"),
+              IsPosixErrorOkAndHolds(
+                  "8:0 rbps=max wbps=1048576 riops=max wiops=max\n"));
+  // io.max and blkio.throttle.* are views of the same limits.
+  EXPECT_THAT(child.ReadControlFile("blkio.throttle.write_bps_device"),
+              IsPosixErrorOkAndHolds("8:0 1048576\n"));
+  EXPECT_THAT(child.WriteControlFile("io.max", "8:0 foo=1"),
+              PosixErrorIs(EINVAL, _));
+
+  ASSERT_NO_ERRNO(child.WriteControlFile("io.max", "8:0 wbps=max"));
+  EXPECT_THAT(child.ReadControlFile("io.max"), IsPosixErrorOkAndHolds(""));
+}
+
+TEST(BlkioCgroup, SetThrottle) {
+  SKIP_IF(!CgroupsAvailable());
+
+  Cgroup c = Cgroup::RootCgroup("/sys/fs/cgroup/blkio");
+  Cgroup child = ASSERT_NO_ERRNO_AND_VALUE(c.CreateChild("child"));
+
+  ASSERT_NO_ERRNO(
+      child.WriteControlFile("blkio.throttle.write_bps_device", "8:0 1048576"));
+  EXPECT_THAT(child.ReadControlFile("blkio.throttle.write_bps_device"),
+              IsPosixErrorOkAndHolds("8:0 1048576\n"));
+  EXPECT_THAT(
+      child.WriteControlFile("blkio.throttle.write_bps_device", "8 1048576"),
+      PosixErrorIs(EINVAL, _));
+
+  // Writing a zero rate removes the limit.
+  ASSERT_NO_ERRNO(
+      child.WriteControlFile("blkio.throttle.write_bps_device", "8:0 0"));
+  EXPECT_THAT(child.ReadControlFile("blkio.throttle.write_bps_device"),
+              IsPosixErrorOkAndHolds(""));
+}
+
 TEST(DevicesCgroup, ControlFilesExist) {
   SKIP_IF(!CgroupsAvailable());
 
@@ -13,7 +13,10 @@
 // limitations under the License.
 
 #include <pthread.h>
+#include <signal.h>
 #include <sys/time.h>
+#include <sys/wait.h>
+#include <unistd.h>
 
 #include <cerrno>
 #include <cstdint>
@@ -89,14 +92,78 @@ TEST(ClockGettime, JavaThreadTime) {
   struct timespec tp;
   ASSERT_THAT(clock_getres(clockid, &tp), SyscallSucceeds());
   EXPECT_TRUE(tp.tv_sec > 0 || tp.tv_nsec > 0);
-  // A thread cputime is updated each 10msec and there is no approximation
-  // if a task is running.
+  // Wait for the thread's CPU time to become non-zero.
   do {
     ASSERT_THAT(clock_gettime(clockid, &tp), SyscallSucceeds());
   } while (tp.tv_sec == 0 && tp.tv_nsec == 0);
   EXPECT_TRUE(tp.tv_sec > 0 || tp.tv_nsec > 0);
 }
 
+// Thread CPU clocks are not sampled at the scheduler tick (10ms), so
+// consecutive reads observe much smaller increments.
+TEST(ClockGettime, ThreadCputimeIsFineGrained) {
+  const int64_t kMaxIncrement = absl::ToInt64Nanoseconds(absl::Milliseconds(1));
+  constexpr int kSamples = 100;
+
+  int fine = 0;
+  for (int i = 0; i < kSamples; i++) {
+    int64_t start = clock_gettime_nsecs(CLOCK_THREAD_CPUTIME_ID);
+    int64_t now;
+    do {
+      now = clock_gettime_nsecs(CLOCK_THREAD_CPUTIME_ID);
+    } while (now == start);
+    ASSERT_GT(now, start);
+    if (now - start < kMaxIncrement) {
+      fine++;
+    }
+  }
+  // Tolerate occasional preemption between reads.
+  EXPECT_GE(fine, kSamples / 2);
+}
+
+TEST(ClockGettime, Getcpuclockid) {
+  clockid_t clockid;
+  ASSERT_EQ(clock_getcpuclockid(getpid(), &clockid), 0);
+  spin_ns(absl::ToInt64Nanoseconds(absl::Milliseconds(10)));
+  EXPECT_GE(clock_gettime_nsecs(clockid),
+            absl::ToInt64Nanoseconds(absl::Milliseconds(10)));
+
+  // A child process's CPU clock may be read by other processes.
+  pid_t child = fork();
+  if (child == 0) {
+    pause();
+    _exit(0);
+  }
+  ASSERT_THAT(child, SyscallSucceeds());
+  EXPECT_EQ(clock_getcpuclockid(child, &clockid), 0);
+  struct timespec tp;
+  EXPECT_THAT(clock_gettime(clockid, &tp), SyscallSucceeds());
+
+  // But the per-thread CPU clocks of another process may not be.
+  clockid_t thread_clockid =
+      static_cast<clockid_t>(~static_cast<uint32_t>(child) << 3) |
+      4 /* CPUCLOCK_PERTHREAD_MASK */ | 2 /* CPUCLOCK_SCHED */;
+  EXPECT_THAT(clock_gettime(thread_clockid, &tp),
+              SyscallFailsWithErrno(EINVAL));
+
+  ASSERT_THAT(kill(child, SIGKILL), SyscallSucceeds());
+  int status;
+  ASSERT_THAT(RetryEINTR(waitpid)(child, &status, 0),
+              SyscallSucceedsWithValue(child));
+}
+
+TEST(ClockGettime, GetcpuclockidNonLeader) {
+  pid_t tid = -1;
+  ScopedThread thread([&] {
+    tid = gettid();
+    // clock_getcpuclockid(3) only accepts thread group leaders.
+    clockid_t clockid;
+    EXPECT_EQ(clock_getcpuclockid(tid, &clockid), ESRCH);
+  });
+  thread.Join();
+  EXPECT_GT(tid, 0);
+}
+
 // There is not much to test here, since CLOCK_REALTIME may be discontiguous.
 TEST(ClockGettime, RealtimeWorks) {
   struct timespec tp;
@@ -13,6 +13,7 @@
 // limitations under the License.
 
 #include <errno.h>
+#include <poll.h>
 #include <pthread.h>
 #include <stdio.h>
 #include <stdlib.h>
@@ -22,6 +23,11 @@
 #include <sys/types.h>
 #include <unistd.h>
 
+#include <algorithm>
+#include <atomic>
+#include <memory>
+#include <vector>
+
 #include "gtest/gtest.h"
 #include "test/util/epoll_util.h"
 #include "test/util/eventfd_util.h"
@@ -193,6 +199,71 @@ TEST(EventfdTest, NotifyNonZero) {
   EXPECT_EQ(val, 1);
 }
 
+TEST(EventfdTest, SemaphoreContention) {
+  constexpr int kThreads = 8;
+  constexpr int kReadsPerThread = 100;
+
+  FileDescriptor efd = ASSERT_NO_ERRNO_AND_VALUE(NewEventFD(0, EFD_SEMAPHORE));
+
+  // Each blocking read must consume exactly 1, even when many readers are
+  // woken by the same write.
+  std::atomic<int> reads(0);
+  std::vector<std::unique_ptr<ScopedThread>> readers;
+  for (int i = 0; i < kThreads; i++) {
+    readers.push_back(std::make_unique<ScopedThread>([&] {
+      for (int j = 0; j < kReadsPerThread; j++) {
+        uint64_t val = 0;
+        ASSERT_THAT(read(efd.get(), &val, sizeof(val)),
+                    SyscallSucceedsWithValue(sizeof(val)));
+        EXPECT_EQ(val, 1);
+        reads++;
+      }
+    }));
+  }
+
+  // Alternate between single and batched increments.
+  int remaining = kThreads * kReadsPerThread;
+  for (uint64_t val = 1; remaining > 0; val = val % 3 + 1) {
+    val = std::min<uint64_t>(val, remaining);
+    ASSERT_THAT(write(efd.get(), &val, sizeof(val)),
+                SyscallSucceedsWithValue(sizeof(val)));
+    remaining -= val;
+  }
+
+  for (auto& reader : readers) {
+    reader->Join();
+  }
+  EXPECT_EQ(reads.load(), kThreads * kReadsPerThread);
+
+  // All of the value should have been consumed.
+  struct pollfd pfd = {.fd = efd.get(), .events = POLLIN};
+  EXPECT_THAT(poll(&pfd, 1, 0), SyscallSucceedsWithValue(0));
+}
+
+TEST(EventfdTest, SemaphoreBatchWakesAllReaders) {
+  constexpr int kThreads = 8;
+
+  FileDescriptor efd = ASSERT_NO_ERRNO_AND_VALUE(NewEventFD(0, EFD_SEMAPHORE));
+
+  std::vector<std::unique_ptr<ScopedThread>> readers;
+  for (int i = 0; i < kThreads; i++) {
+    readers.push_back(std::make_unique<ScopedThread>([&] {
+      uint64_t val = 0;
+      ASSERT_THAT(read(efd.get(), &val, sizeof(val)),
+                  SyscallSucceedsWithValue(sizeof(val)));
+      EXPECT_EQ(val, 1);
+    }));
+  }
+
+  // A single write must allow all blocked readers to complete.
+  uint64_t val = kThreads;
+  ASSERT_THAT(write(efd.get(), &val, sizeof(val)),
+              SyscallSucceedsWithValue(sizeof(val)));
+  for (auto& reader : readers) {
+    reader->Join();
+  }
+}
+
 TEST(EventfdTest, SpliceReturnsEINVAL) {
   // Splicing into eventfd has been disabled in
   // 36e2c7421f02 ("fs: don't allow splice read/write without explicit ops").
@@ -210,7 +210,7 @@ TEST(ExecTest, InterpreterScript) {
 std::string GetShortTestTmpdir() {
 #ifdef ANDROID
   // Using GetAbsoluteTestTmpdir() can cause the tmp directory path to exceed
-  // the max length of the interpreter script path (127).
+  // the max length of the interpreter script line (255).
   //
   // However, existing systems that are built with the ANDROID configuration
   // have their temp directory in a different location, and must respect the
@@ -329,6 +329,37 @@ TEST(ExecTest, InterpreterScriptNoPath) {
   EXPECT_EQ(execve_errno, ENOEXEC);
 }
 
+// An argument that extends past the first 255 bytes is truncated.
+TEST(ExecTest, InterpreterScriptLongArgTruncated) {
+  // Symlink through /tmp to ensure the path is short enough.
+  TempPath link = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateSymlinkTo(
+      GetShortTestTmpdir(), RunfilePath(kBasicWorkload)));
+
+  std::string prefix = absl::StrCat("#!", link.path(), " ");
+  ASSERT_LT(prefix.size(), 255);
+  std::string arg(512, 'a');
+
+  TempPath script = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateFileWith(
+      GetShortTestTmpdir(), absl::StrCat(prefix, arg, "\n"), 0755));
+
+  CheckExec(script.path(), {script.path()}, {}, ArgEnvExitStatus(2, 0),
+            absl::StrCat(link.path(), "\n", arg.substr(0, 255 - prefix.size()),
+                         "\n", script.path(), "\n"));
+}
+
+// An interpreter path that extends past the first 255 bytes is rejected
+// rather than truncated.
+TEST(ExecTest, InterpreterScriptLongPath) {
+  TempPath script = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateFileWith(
+      GetShortTestTmpdir(),
+      absl::StrCat("#!/", std::string(512, 'a'), " foo\n"), 0755));
+
+  int execve_errno;
+  ASSERT_NO_ERRNO_AND_VALUE(
+      ForkAndExec(script.path(), {script.path()}, {}, nullptr, &execve_errno));
+  EXPECT_EQ(execve_errno, ENOEXEC);
+}
+
 // AT_EXECFN is the path passed to execve.
 TEST(ExecTest, ExecFn) {
   // Symlink through /tmp to ensure the path is short enough.
@@ -638,6 +669,29 @@ TEST(ExecveatTest, EmptyPathBasic) {
                 absl::StrCat(path, "\n"));
 }
 
+// AT_EXECFN names the executable relative to /dev/fd when execveat is
+// passed an fd and an empty path.
+TEST(ExecveatTest, EmptyPathExecFn) {
+  std::string path = RunfilePath(kStateWorkload);
+  const FileDescriptor fd = ASSERT_NO_ERRNO_AND_VALUE(Open(path, O_PATH));
+
+  CheckExecveat(fd.get(), "", {path, "PrintExecFn"}, {}, AT_EMPTY_PATH,
+                ArgEnvExitStatus(0, 0),
+                absl::StrCat("/dev/fd/", fd.get(), "\n"));
+}
+
+TEST(ExecveatTest, RelativePathExecFn) {
+  std::string absolute_path = RunfilePath(kStateWorkload);
+  std::string parent_dir = std::string(Dirname(absolute_path));
+  std::string base = std::string(Basename(absolute_path));
+  const FileDescriptor dirfd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open(parent_dir, O_DIRECTORY));
+
+  CheckExecveat(dirfd.get(), base, {base, "PrintExecFn"}, {}, /*flags=*/0,
+                ArgEnvExitStatus(0, 0),
+                absl::StrCat("/dev/fd/", dirfd.get(), "/", base, "\n"));
+}
+
 TEST(ExecveatTest, EmptyPathWithDirFD) {
   std::string path = RunfilePath(kBasicWorkload);
   std::string parent_dir = std::string(Dirname(path));
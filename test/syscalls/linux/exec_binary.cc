@@ -14,6 +14,7 @@
 
 #include <elf.h>
 #include <errno.h>
+#include <fcntl.h>
 #include <signal.h>
 #include <sys/ptrace.h>
 #include <sys/syscall.h>
@@ -32,6 +33,7 @@
 #include "gtest/gtest.h"
 #include "absl/strings/str_cat.h"
 #include "absl/strings/string_view.h"
+#include "test/util/capability_util.h"
 #include "test/util/cleanup.h"
 #include "test/util/file_descriptor.h"
 #include "test/util/fs_util.h"
@@ -216,6 +218,18 @@ constexpr int kSyscallSize = 4;
 #error "Unknown architecture"
 #endif
 
+// GNU property definitions, which may be missing from older <elf.h>.
+constexpr uint32_t kPtGnuProperty = 0x6474e553;
+constexpr uint32_t kNtGnuPropertyType0 = 5;
+#if defined(__x86_64__)
+// GNU_PROPERTY_X86_FEATURE_1_AND, requesting IBT and SHSTK.
+constexpr uint32_t kGnuPropertyFeature1And = 0xc0000002;
+#elif defined(__aarch64__)
+// GNU_PROPERTY_AARCH64_FEATURE_1_AND, requesting BTI and PAC.
+constexpr uint32_t kGnuPropertyFeature1And = 0xc0000000;
+#endif
+constexpr uint32_t kGnuPropertyFeatures = 0x3;
+
 // This test suite tests executable loading in the kernel (ELF and interpreter
 // scripts).
 
@@ -279,6 +293,41 @@ struct ElfBinary {
     phdrs.insert(phdrs.begin(), phdr);
   }
 
+  // AddGNUProperty adds a PT_GNU_PROPERTY segment containing a note of type
+  // note_type, which holds a single property of type pr_type and value
+  // pr_value.
+  //
+  // A later call to UpdateOffsets is required to make the new phdr valid.
+  void AddGNUProperty(uint32_t note_type, uint32_t pr_type,
+                      uint32_t pr_value) {
+    // Notes and properties are 8-byte aligned in 64-bit ELFs.
+    while (data.size() % 8 != 0) {
+      data.push_back(0);
+    }
+    const int start = data.size();
+    auto append32 = [this](uint32_t v) {
+      const char* p = reinterpret_cast<const char*>(&v);
+      data.insert(data.end(), p, p + sizeof(v));
+    };
+    append32(4);          // n_namesz
+    append32(16);         // n_descsz
+    append32(note_type);  // n_type
+    data.insert(data.end(), {'G', 'N', 'U', '\0'});
+    append32(pr_type);   // pr_type
+    append32(4);         // pr_datasz
+    append32(pr_value);  // pr_data
+    append32(0);         // Padding.
+
+    ElfPhdr phdr = {};
+    phdr.p_type = kPtGnuProperty;
+    phdr.p_flags = PF_R;
+    phdr.p_offset = start;
+    phdr.p_filesz = data.size() - start;
+    phdr.p_memsz = phdr.p_filesz;
+    phdr.p_align = 8;
+    phdrs.push_back(phdr);
+  }
+
   // Writes the header, phdrs, and data to fd.
   PosixError Write(int fd) const {
     int ret = WriteFd(fd, &header, sizeof(header));
@@ -702,6 +751,48 @@ TEST(ElfTest, OutOfOrderSegments) {
                      })));
 }
 
+// ELFs requesting control-flow protection through GNU properties can be
+// executed.
+TEST(ElfTest, GNUProperty) {
+  ElfBinary<64> elf = StandardElf();
+  elf.AddGNUProperty(kNtGnuPropertyType0, kGnuPropertyFeature1And,
+                     kGnuPropertyFeatures);
+  elf.UpdateOffsets();
+
+  TempPath file = ASSERT_NO_ERRNO_AND_VALUE(CreateElfWith(elf));
+
+  pid_t child;
+  int execve_errno;
+  auto cleanup = ASSERT_NO_ERRNO_AND_VALUE(
+      ForkAndExec(file.path(), {file.path()}, {}, &child, &execve_errno));
+  ASSERT_EQ(execve_errno, 0);
+
+  ASSERT_NO_ERRNO(WaitStopped(child));
+}
+
+// A PT_GNU_PROPERTY segment must contain an NT_GNU_PROPERTY_TYPE_0 note on
+// arm64. Linux doesn't parse GNU properties on x86, so it is ignored there.
+TEST(ElfTest, GNUPropertyWrongNoteType) {
+  ElfBinary<64> elf = StandardElf();
+  elf.AddGNUProperty(kNtGnuPropertyType0 + 1, kGnuPropertyFeature1And,
+                     kGnuPropertyFeatures);
+  elf.UpdateOffsets();
+
+  TempPath file = ASSERT_NO_ERRNO_AND_VALUE(CreateElfWith(elf));
+
+  pid_t child;
+  int execve_errno;
+  auto cleanup = ASSERT_NO_ERRNO_AND_VALUE(
+      ForkAndExec(file.path(), {file.path()}, {}, &child, &execve_errno));
+#if defined(__aarch64__)
+  EXPECT_EQ(execve_errno, ENOEXEC);
+#else
+  ASSERT_EQ(execve_errno, 0);
+
+  ASSERT_NO_ERRNO(WaitStopped(child));
+#endif
+}
+
 // header.e_phoff is bound the end of the file.
 TEST(ElfTest, OutOfBoundsPhdrs) {
   ElfBinary<64> elf = StandardElf();
@@ -1305,10 +1396,6 @@ TEST(ElfTest, NoExecute) {
 
 // Execute, but no read permissions on the binary works just fine.
 TEST(ElfTest, NoRead) {
-  // TODO(gvisor.dev/issue/160): gVisor's backing filesystem may prevent the
-  // sentry from reading the executable.
-  SKIP_IF(IsRunningOnGvisor());
-
   ElfBinary<64> elf = StandardElf();
   elf.UpdateOffsets();
 
@@ -1324,9 +1411,12 @@ TEST(ElfTest, NoRead) {
 
   ASSERT_NO_ERRNO(WaitStopped(child));
 
-  // TODO(gvisor.dev/issue/160): A task with a non-readable executable is marked
-  // non-dumpable, preventing access to proc files. gVisor does not implement
-  // this behavior.
+  // A task with a non-readable executable is marked non-dumpable, preventing
+  // access to its memory through procfs.
+  if (!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_PTRACE))) {
+    EXPECT_THAT(open(absl::StrCat("/proc/", child, "/mem").c_str(), O_RDONLY),
+                SyscallFailsWithErrno(EACCES));
+  }
 }
 
 // No execute permissions on the ELF interpreter.
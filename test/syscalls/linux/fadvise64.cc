@@ -13,9 +13,13 @@
 // limitations under the License.
 
 #include <errno.h>
+#include <fcntl.h>
+#include <sys/stat.h>
 #include <syscall.h>
 #include <unistd.h>
 
+#include <string>
+
 #include "gmock/gmock.h"
 #include "gtest/gtest.h"
 #include "test/util/file_descriptor.h"
@@ -30,7 +34,8 @@ TEST(FAdvise64Test, Basic) {
   auto file = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateFile());
   const auto fd = ASSERT_NO_ERRNO_AND_VALUE(Open(file.path(), O_RDONLY));
 
-  // fadvise64 is noop in gVisor, so just test that it succeeds.
+  // Advice has no observable effect other than performance, so just test
+  // that it succeeds.
   ASSERT_THAT(syscall(__NR_fadvise64, fd.get(), 0, 10, POSIX_FADV_NORMAL),
               SyscallSucceeds());
   ASSERT_THAT(syscall(__NR_fadvise64, fd.get(), 0, 10, POSIX_FADV_RANDOM),
@@ -45,6 +50,56 @@ TEST(FAdvise64Test, Basic) {
               SyscallSucceeds());
 }
 
+// Dropping or prefetching cached pages doesn't change file contents, including
+// data that hasn't been written back yet.
+TEST(FAdvise64Test, CacheAdvicePreservesContents) {
+  const std::string contents(3 * kPageSize + 10, 'a');
+  auto file = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateFile());
+  const auto fd = ASSERT_NO_ERRNO_AND_VALUE(Open(file.path(), O_RDWR));
+  ASSERT_THAT(pwrite(fd.get(), contents.data(), contents.size(), 0),
+              SyscallSucceedsWithValue(contents.size()));
+
+  for (int advice : {POSIX_FADV_DONTNEED, POSIX_FADV_WILLNEED}) {
+    ASSERT_THAT(syscall(__NR_fadvise64, fd.get(), 0, 0, advice),
+                SyscallSucceeds());
+    ASSERT_THAT(syscall(__NR_fadvise64, fd.get(), kPageSize / 2, kPageSize,
+                        advice),
+                SyscallSucceeds());
+    std::string buf(contents.size(), '\0');
+    ASSERT_THAT(pread(fd.get(), buf.data(), buf.size(), 0),
+                SyscallSucceedsWithValue(contents.size()));
+    EXPECT_EQ(buf, contents);
+  }
+}
+
+// Prefetching past EOF neither changes the file nor reads beyond it.
+TEST(FAdvise64Test, WillNeedPastEOF) {
+  const std::string contents(10, 'a');
+  auto file = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateFile());
+  const auto fd = ASSERT_NO_ERRNO_AND_VALUE(Open(file.path(), O_RDWR));
+  ASSERT_THAT(pwrite(fd.get(), contents.data(), contents.size(), 0),
+              SyscallSucceedsWithValue(contents.size()));
+
+  ASSERT_THAT(syscall(__NR_fadvise64, fd.get(), 4 * kPageSize, 4 * kPageSize,
+                      POSIX_FADV_WILLNEED),
+              SyscallSucceeds());
+  struct stat st;
+  ASSERT_THAT(fstat(fd.get(), &st), SyscallSucceeds());
+  EXPECT_EQ(st.st_size, contents.size());
+  char c;
+  EXPECT_THAT(pread(fd.get(), &c, 1, 4 * kPageSize),
+              SyscallSucceedsWithValue(0));
+
+  // Data written past the prefetched range later is read back correctly.
+  const std::string more(kPageSize, 'b');
+  ASSERT_THAT(pwrite(fd.get(), more.data(), more.size(), 5 * kPageSize),
+              SyscallSucceedsWithValue(more.size()));
+  std::string buf(more.size(), '\0');
+  ASSERT_THAT(pread(fd.get(), buf.data(), buf.size(), 5 * kPageSize),
+              SyscallSucceedsWithValue(more.size()));
+  EXPECT_EQ(buf, more);
+}
+
 TEST(FAdvise64Test, FAdvise64WithOpath) {
   auto file = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateFile());
   const auto fd = ASSERT_NO_ERRNO_AND_VALUE(Open(file.path(), O_PATH));
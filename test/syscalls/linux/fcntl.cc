@@ -1542,6 +1542,7 @@ TEST_F(FcntlSignalTest, SetSigCustom) {
   signals_received_.pop_front();
   EXPECT_EQ(sig.num, SIGUSR1);
   EXPECT_EQ(sig.info.si_signo, SIGUSR1);
+  EXPECT_EQ(sig.info.si_code, POLL_IN);
   EXPECT_EQ(sig.info.si_fd, pipe_read_fd_);
   EXPECT_EQ(sig.info.si_band, EPOLLIN | EPOLLRDNORM);
 }
@@ -1572,6 +1573,7 @@ TEST_F(FcntlSignalTest, SetSigWithSigioStillGetsSiginfo) {
   SignalDelivery sig = signals_received_.front();
   EXPECT_EQ(sig.num, SIGIO);
   EXPECT_EQ(sig.info.si_signo, SIGIO);
+  EXPECT_EQ(sig.info.si_code, POLL_IN);
   EXPECT_EQ(sig.info.si_fd, pipe_read_fd_);
   EXPECT_EQ(sig.info.si_band, EPOLLIN | EPOLLRDNORM);
 }
@@ -19,10 +19,12 @@
 #include <sys/time.h>
 #include <sys/types.h>
 #include <syscall.h>
+#include <time.h>
 #include <unistd.h>
 
 #include <algorithm>
 #include <atomic>
+#include <cstdint>
 #include <memory>
 #include <vector>
 
@@ -157,6 +159,52 @@ int futex_unlock_pi(bool priv, std::atomic<int>* uaddr) {
   return RetryEINTR(syscall)(SYS_futex, uaddr, op, nullptr, nullptr);
 }
 
+#ifndef SYS_futex_waitv
+#define SYS_futex_waitv 449
+#endif
+
+// Equivalent to struct futex_waitv, which older headers don't define.
+struct futex_waitv_entry {
+  uint64_t val;
+  uint64_t uaddr;
+  uint32_t flags;
+  uint32_t reserved;
+};
+
+// FUTEX_32 from <linux/futex.h>.
+constexpr uint32_t kFutex32 = 2;
+
+futex_waitv_entry futex_waitv_entry_for(bool priv, std::atomic<int>* uaddr,
+                                        int val) {
+  futex_waitv_entry entry = {};
+  entry.val = static_cast<uint32_t>(val);
+  entry.uaddr = reinterpret_cast<uint64_t>(uaddr);
+  entry.flags = kFutex32 | (priv ? FUTEX_PRIVATE_FLAG : 0);
+  return entry;
+}
+
+// futex_waitv waits on waiters until the given CLOCK_MONOTONIC deadline.
+int futex_waitv(std::vector<futex_waitv_entry>* waiters,
+                absl::Time deadline = absl::InfiniteFuture()) {
+  struct timespec deadline_ts;
+  if (deadline != absl::InfiniteFuture()) {
+    struct timespec now;
+    TEST_PCHECK(clock_gettime(CLOCK_MONOTONIC, &now) == 0);
+    deadline_ts = absl::ToTimespec(absl::DurationFromTimespec(now) +
+                                   (deadline - absl::Now()));
+  }
+  return RetryEINTR(syscall)(
+      SYS_futex_waitv, waiters->data(), waiters->size(), 0,
+      deadline == absl::InfiniteFuture() ? nullptr : &deadline_ts,
+      CLOCK_MONOTONIC);
+}
+
+// FutexWaitvSupported returns true if futex_waitv(2) is implemented.
+bool FutexWaitvSupported() {
+  return syscall(SYS_futex_waitv, nullptr, 0, 0, nullptr, 0) == -1 &&
+         errno == EINVAL;
+}
+
 // Fixture for futex tests parameterized by whether to use private or shared
 // futexes.
 class PrivateAndSharedFutexTest : public ::testing::TestWithParam<bool> {
@@ -538,6 +586,84 @@ TEST_P(PrivateAndSharedFutexTest, WakeWrongKind) {
   EXPECT_THAT(futex_wake(!IsPrivate(), &a, 1), SyscallSucceedsWithValue(0));
 }
 
+TEST_P(PrivateAndSharedFutexTest, Waitv_WrongVal) {
+  SKIP_IF(!FutexWaitvSupported());
+
+  std::atomic<int> a(1);
+  std::atomic<int> b(1);
+  std::vector<futex_waitv_entry> waiters = {
+      futex_waitv_entry_for(IsPrivate(), &a, a),
+      futex_waitv_entry_for(IsPrivate(), &b, b + 1),
+  };
+  EXPECT_THAT(futex_waitv(&waiters), SyscallFailsWithErrno(EAGAIN));
+}
+
+TEST_P(PrivateAndSharedFutexTest, Waitv_Timeout) {
+  SKIP_IF(!FutexWaitvSupported());
+
+  std::atomic<int> a(1);
+  std::atomic<int> b(1);
+  std::vector<futex_waitv_entry> waiters = {
+      futex_waitv_entry_for(IsPrivate(), &a, a),
+      futex_waitv_entry_for(IsPrivate(), &b, b),
+  };
+
+  MonotonicTimer timer;
+  timer.Start();
+  constexpr absl::Duration kTimeout = absl::Seconds(1);
+  EXPECT_THAT(futex_waitv(&waiters, absl::Now() + kTimeout),
+              SyscallFailsWithErrno(ETIMEDOUT));
+  EXPECT_GE(timer.Duration(), kTimeout);
+}
+
+TEST_P(PrivateAndSharedFutexTest, Waitv_WakeOne) {
+  SKIP_IF(!FutexWaitvSupported());
+
+  constexpr int kInitialValue = 1;
+  std::atomic<int> a(kInitialValue);
+  std::atomic<int> b(kInitialValue);
+  std::atomic<int> c(kInitialValue);
+
+  // Prevent save/restore from interrupting futex_waitv, which will cause it to
+  // return EAGAIN instead of the expected result if futex_waitv is restarted
+  // after we change the value of b below.
+  DisableSave ds;
+  ScopedThread thread([&] {
+    std::vector<futex_waitv_entry> waiters = {
+        futex_waitv_entry_for(IsPrivate(), &a, kInitialValue),
+        futex_waitv_entry_for(IsPrivate(), &b, kInitialValue),
+        futex_waitv_entry_for(IsPrivate(), &c, kInitialValue),
+    };
+    EXPECT_THAT(futex_waitv(&waiters), SyscallSucceedsWithValue(1));
+  });
+  absl::SleepFor(kWaiterStartupDelay);
+
+  // Change b so that if futex_wake happens before futex_waitv, the latter
+  // returns EAGAIN instead of hanging the test.
+  b.fetch_add(1);
+  EXPECT_THAT(futex_wake(IsPrivate(), &b, 1), SyscallSucceedsWithValue(1));
+}
+
+TEST_P(PrivateAndSharedFutexTest, Waitv_WakeWrongKind) {
+  SKIP_IF(!FutexWaitvSupported());
+
+  constexpr int kInitialValue = 1;
+  std::atomic<int> a(kInitialValue);
+
+  DisableSave ds;
+  ScopedThread thread([&] {
+    std::vector<futex_waitv_entry> waiters = {
+        futex_waitv_entry_for(IsPrivate(), &a, kInitialValue),
+    };
+    EXPECT_THAT(futex_waitv(&waiters, absl::Now() + kIneffectiveWakeTimeout),
+                SyscallFailsWithErrno(ETIMEDOUT));
+  });
+  absl::SleepFor(kWaiterStartupDelay);
+
+  a.fetch_add(1);
+  EXPECT_THAT(futex_wake(!IsPrivate(), &a, 1), SyscallSucceedsWithValue(0));
+}
+
 INSTANTIATE_TEST_SUITE_P(SharedPrivate, PrivateAndSharedFutexTest,
                          ::testing::Bool());
 
@@ -751,6 +877,67 @@ int set_robust_list(struct robust_list_head* head, size_t len) {
   return syscall(__NR_set_robust_list, head, len);
 }
 
+TEST(FutexWaitvTest, InvalidArguments) {
+  SKIP_IF(!FutexWaitvSupported());
+
+  std::atomic<int> a(1);
+  std::vector<futex_waitv_entry> waiters = {
+      futex_waitv_entry_for(true, &a, a),
+  };
+  struct timespec timeout = {};
+
+  // Non-zero flags.
+  EXPECT_THAT(syscall(SYS_futex_waitv, waiters.data(), 1, 1, nullptr, 0),
+              SyscallFailsWithErrno(EINVAL));
+  // Too few and too many futexes.
+  EXPECT_THAT(syscall(SYS_futex_waitv, waiters.data(), 0, 0, nullptr, 0),
+              SyscallFailsWithErrno(EINVAL));
+  std::vector<futex_waitv_entry> many(129, waiters[0]);
+  EXPECT_THAT(syscall(SYS_futex_waitv, many.data(), many.size(), 0, nullptr, 0),
+              SyscallFailsWithErrno(EINVAL));
+  // Null waiters.
+  EXPECT_THAT(syscall(SYS_futex_waitv, nullptr, 1, 0, nullptr, 0),
+              SyscallFailsWithErrno(EINVAL));
+  // Invalid clock.
+  EXPECT_THAT(syscall(SYS_futex_waitv, waiters.data(), 1, 0, &timeout,
+                      CLOCK_BOOTTIME),
+              SyscallFailsWithErrno(EINVAL));
+
+  // Unsupported futex size.
+  waiters[0].flags = FUTEX_PRIVATE_FLAG;
+  EXPECT_THAT(syscall(SYS_futex_waitv, waiters.data(), 1, 0, nullptr, 0),
+              SyscallFailsWithErrno(EINVAL));
+  // Non-zero reserved field.
+  waiters[0] = futex_waitv_entry_for(true, &a, a);
+  waiters[0].reserved = 1;
+  EXPECT_THAT(syscall(SYS_futex_waitv, waiters.data(), 1, 0, nullptr, 0),
+              SyscallFailsWithErrno(EINVAL));
+  // Value that doesn't fit in 32 bits.
+  waiters[0] = futex_waitv_entry_for(true, &a, a);
+  waiters[0].val |= uint64_t{1} << 32;
+  EXPECT_THAT(syscall(SYS_futex_waitv, waiters.data(), 1, 0, nullptr, 0),
+              SyscallFailsWithErrno(EINVAL));
+  // Unaligned address.
+  waiters[0] = futex_waitv_entry_for(true, &a, a);
+  waiters[0].uaddr += 1;
+  EXPECT_THAT(syscall(SYS_futex_waitv, waiters.data(), 1, 0, nullptr, 0),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(FutexWaitvTest, RealtimeTimeout) {
+  SKIP_IF(!FutexWaitvSupported());
+
+  std::atomic<int> a(1);
+  std::vector<futex_waitv_entry> waiters = {
+      futex_waitv_entry_for(true, &a, a),
+  };
+  // A deadline in the past times out immediately.
+  auto const deadline_ts = absl::ToTimespec(absl::Now() - absl::Seconds(1));
+  EXPECT_THAT(syscall(SYS_futex_waitv, waiters.data(), waiters.size(), 0,
+                      &deadline_ts, CLOCK_REALTIME),
+              SyscallFailsWithErrno(ETIMEDOUT));
+}
+
 TEST(RobustFutexTest, BasicSetGet) {
   struct robust_list_head hd = {};
   struct robust_list_head* hd_ptr = &hd;
@@ -179,6 +179,62 @@ TEST(GetrusageTest, Wait4) {
   EXPECT_GT(rusage_children.ru_maxrss, 0);
 }
 
+// Touches every page of a fresh anonymous mapping of the given size.
+void TouchNewMemory(size_t size) {
+  void* addr =
+      mmap(nullptr, size, PROT_READ | PROT_WRITE, MAP_ANONYMOUS | MAP_PRIVATE,
+           -1, 0);
+  TEST_PCHECK(addr != MAP_FAILED);
+  for (size_t i = 0; i < size; i += kPageSize) {
+    static_cast<volatile char*>(addr)[i] = 1;
+  }
+  TEST_PCHECK(munmap(addr, size) == 0);
+}
+
+TEST(GetrusageTest, MinorFaults) {
+  struct rusage before;
+  ASSERT_THAT(getrusage(RUSAGE_SELF, &before), SyscallSucceeds());
+  TouchNewMemory(64 * kPageSize);
+  struct rusage after;
+  ASSERT_THAT(getrusage(RUSAGE_SELF, &after), SyscallSucceeds());
+  EXPECT_GT(after.ru_minflt, before.ru_minflt);
+}
+
+TEST(GetrusageTest, ThreadMaxRSS) {
+  struct rusage rusage_thread;
+  ASSERT_THAT(getrusage(RUSAGE_THREAD, &rusage_thread), SyscallSucceeds());
+  EXPECT_GT(rusage_thread.ru_maxrss, 0);
+}
+
+TEST(GetrusageTest, VoluntarySwitches) {
+  struct rusage before;
+  ASSERT_THAT(getrusage(RUSAGE_THREAD, &before), SyscallSucceeds());
+  // Each sleep blocks, voluntarily giving up the CPU.
+  for (int i = 0; i < 5; i++) {
+    absl::SleepFor(absl::Milliseconds(1));
+  }
+  struct rusage after;
+  ASSERT_THAT(getrusage(RUSAGE_THREAD, &after), SyscallSucceeds());
+  EXPECT_GE(after.ru_nvcsw - before.ru_nvcsw, 5);
+}
+
+TEST(GetrusageTest, Wait4Faults) {
+  pid_t pid = fork();
+  if (pid == 0) {
+    TouchNewMemory(64 * kPageSize);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  struct rusage rusage_child;
+  int status;
+  ASSERT_THAT(RetryEINTR(wait4)(pid, &status, 0, &rusage_child),
+              SyscallSucceeds());
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+  // The child faulted in its memory, and because it has exited its faults
+  // are reported.
+  EXPECT_GT(rusage_child.ru_minflt, 0);
+}
+
 }  // namespace
 
 }  // namespace testing
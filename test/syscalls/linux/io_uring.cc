@@ -21,15 +21,25 @@
 #include <string.h>
 #include <sys/epoll.h>
 #include <sys/mman.h>
+#include <sys/socket.h>
 #include <sys/stat.h>
 #include <sys/types.h>
+#include <sys/uio.h>
+#include <sys/un.h>
+#include <time.h>
 #include <unistd.h>
 
 #include <cerrno>
 #include <cstddef>
 #include <cstdint>
+#include <string>
+#include <vector>
 
 #include "gtest/gtest.h"
+#include "absl/time/clock.h"
+#include "absl/time/time.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/fs_util.h"
 #include "test/util/io_uring_util.h"
 #include "test/util/memory_util.h"
 #include "test/util/multiprocess_util.h"
@@ -134,7 +144,7 @@ TEST(IOUringTest, UnsupportedFlags) {
 
   IOUringParams params = {};
   memset(&params, 0, sizeof(params));
-  params.flags |= IORING_SETUP_SQPOLL;
+  params.flags |= IORING_SETUP_IOPOLL;
   ASSERT_THAT(IOUringSetup(1, &params), SyscallFailsWithErrno(EINVAL));
 }
 
@@ -1213,6 +1223,276 @@ TEST(IOUringTest, READVWithInvalidSqeFieldValue) {
   io_uring->store_cq_head(cq_head + 1);
 }
 
+// Submits sqe as the next entry of io_uring, waits for a completion and
+// returns the oldest unconsumed CQE.
+PosixErrorOr<IOUringCqe> SubmitAndWait(IOUring *io_uring,
+                                       const IOUringParams &params,
+                                       const IOUringSqe &sqe) {
+  uint32_t sq_tail = io_uring->load_sq_tail();
+  uint32_t index = sq_tail & io_uring->get_sq_mask();
+  io_uring->get_sqes()[index] = sqe;
+  io_uring->get_sq_array()[index] = index;
+  io_uring->store_sq_tail(sq_tail + 1);
+
+  if (io_uring->Enter(1, 1, IORING_ENTER_GETEVENTS, nullptr) < 0) {
+    return PosixError(errno, "io_uring_enter");
+  }
+
+  uint32_t cq_head = io_uring->load_cq_head();
+  if (io_uring->load_cq_tail() == cq_head) {
+    return PosixError(EAGAIN, "no completion");
+  }
+  IOUringCqe cqe = io_uring->get_cqes()[cq_head & (params.cq_entries - 1)];
+  io_uring->store_cq_head(cq_head + 1);
+  return cqe;
+}
+
+// Tests that IORING_OP_WRITEV writes at the requested offset.
+TEST(IOUringTest, WritevAtOffset) {
+  SKIP_IF(!IOUringAvailable());
+
+  IOUringParams params = {};
+  std::unique_ptr<IOUring> io_uring =
+      ASSERT_NO_ERRNO_AND_VALUE(IOUring::InitIOUring(1, params));
+
+  const TempPath file = ASSERT_NO_ERRNO_AND_VALUE(
+      TempPath::CreateFileWith(GetAbsoluteTestTmpdir(), "DEADBEEF", 0666));
+  FileDescriptor filefd = ASSERT_NO_ERRNO_AND_VALUE(Open(file.path(), O_RDWR));
+
+  char data[] = "CAFE";
+  struct iovec iov = {data, 4};
+  IOUringSqe sqe = {};
+  sqe.opcode = IORING_OP_WRITEV;
+  sqe.fd = filefd.get();
+  sqe.addr = reinterpret_cast<uint64_t>(&iov);
+  sqe.len = 1;
+  sqe.off = 2;
+  sqe.user_data = 42;
+
+  IOUringCqe cqe =
+      ASSERT_NO_ERRNO_AND_VALUE(SubmitAndWait(io_uring.get(), params, sqe));
+  EXPECT_EQ(cqe.user_data, 42);
+  EXPECT_EQ(cqe.res, 4);
+  EXPECT_EQ(ASSERT_NO_ERRNO_AND_VALUE(GetContents(file.path())), "DECAFEEF");
+}
+
+// Tests that IORING_OP_FSYNC succeeds on a regular file and fails on a bad
+// file descriptor.
+TEST(IOUringTest, Fsync) {
+  SKIP_IF(!IOUringAvailable());
+
+  IOUringParams params = {};
+  std::unique_ptr<IOUring> io_uring =
+      ASSERT_NO_ERRNO_AND_VALUE(IOUring::InitIOUring(1, params));
+
+  const TempPath file = ASSERT_NO_ERRNO_AND_VALUE(
+      TempPath::CreateFileIn(GetAbsoluteTestTmpdir()));
+  FileDescriptor filefd = ASSERT_NO_ERRNO_AND_VALUE(Open(file.path(), O_RDWR));
+
+  IOUringSqe sqe = {};
+  sqe.opcode = IORING_OP_FSYNC;
+  sqe.fd = filefd.get();
+  IOUringCqe cqe =
+      ASSERT_NO_ERRNO_AND_VALUE(SubmitAndWait(io_uring.get(), params, sqe));
+  EXPECT_EQ(cqe.res, 0);
+
+  sqe.fd = -1;
+  cqe = ASSERT_NO_ERRNO_AND_VALUE(SubmitAndWait(io_uring.get(), params, sqe));
+  EXPECT_EQ(cqe.res, -EBADF);
+}
+
+// Tests that IORING_OP_READ_FIXED reads into a registered buffer and rejects
+// buffers which aren't registered.
+TEST(IOUringTest, ReadFixed) {
+  SKIP_IF(!IOUringAvailable());
+
+  IOUringParams params = {};
+  std::unique_ptr<IOUring> io_uring =
+      ASSERT_NO_ERRNO_AND_VALUE(IOUring::InitIOUring(1, params));
+
+  const TempPath file = ASSERT_NO_ERRNO_AND_VALUE(
+      TempPath::CreateFileWith(GetAbsoluteTestTmpdir(), "DEADBEEF", 0666));
+  FileDescriptor filefd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open(file.path(), O_RDONLY));
+
+  char buf[16] = {};
+  struct iovec iov = {buf, sizeof(buf)};
+  ASSERT_THAT(IOUringRegister(io_uring->Fd(), IORING_REGISTER_BUFFERS, &iov, 1),
+              SyscallSucceeds());
+  EXPECT_THAT(IOUringRegister(io_uring->Fd(), IORING_REGISTER_BUFFERS, &iov, 1),
+              SyscallFailsWithErrno(EBUSY));
+
+  IOUringSqe sqe = {};
+  sqe.opcode = IORING_OP_READ_FIXED;
+  sqe.fd = filefd.get();
+  sqe.addr = reinterpret_cast<uint64_t>(buf + 4);
+  sqe.len = 4;
+  sqe.off = 4;
+  sqe.buf_index = 0;
+  IOUringCqe cqe =
+      ASSERT_NO_ERRNO_AND_VALUE(SubmitAndWait(io_uring.get(), params, sqe));
+  EXPECT_EQ(cqe.res, 4);
+  EXPECT_EQ(std::string(buf + 4, 4), "BEEF");
+
+  sqe.buf_index = 1;
+  cqe = ASSERT_NO_ERRNO_AND_VALUE(SubmitAndWait(io_uring.get(), params, sqe));
+  EXPECT_EQ(cqe.res, -EFAULT);
+
+  ASSERT_THAT(
+      IOUringRegister(io_uring->Fd(), IORING_UNREGISTER_BUFFERS, nullptr, 0),
+      SyscallSucceeds());
+  EXPECT_THAT(
+      IOUringRegister(io_uring->Fd(), IORING_UNREGISTER_BUFFERS, nullptr, 0),
+      SyscallFailsWithErrno(ENXIO));
+}
+
+// Tests that an IORING_OP_TIMEOUT completes with ETIME once it expires, and
+// that io_uring_enter(2) waits for it.
+TEST(IOUringTest, TimeoutExpires) {
+  SKIP_IF(!IOUringAvailable());
+
+  IOUringParams params = {};
+  std::unique_ptr<IOUring> io_uring =
+      ASSERT_NO_ERRNO_AND_VALUE(IOUring::InitIOUring(1, params));
+
+  struct timespec ts = {0, 10 * 1000 * 1000};  // 10ms
+  IOUringSqe sqe = {};
+  sqe.opcode = IORING_OP_TIMEOUT;
+  sqe.fd = -1;
+  sqe.addr = reinterpret_cast<uint64_t>(&ts);
+  sqe.len = 1;
+  sqe.user_data = 42;
+
+  absl::Time start = absl::Now();
+  IOUringCqe cqe =
+      ASSERT_NO_ERRNO_AND_VALUE(SubmitAndWait(io_uring.get(), params, sqe));
+  EXPECT_GE(absl::Now() - start, absl::Milliseconds(10));
+  EXPECT_EQ(cqe.user_data, 42);
+  EXPECT_EQ(cqe.res, -ETIME);
+}
+
+// Tests that IORING_OP_SEND and IORING_OP_RECV transfer data over a socket
+// pair.
+TEST(IOUringTest, SendRecv) {
+  SKIP_IF(!IOUringAvailable());
+
+  IOUringParams params = {};
+  std::unique_ptr<IOUring> io_uring =
+      ASSERT_NO_ERRNO_AND_VALUE(IOUring::InitIOUring(1, params));
+
+  int fds[2];
+  ASSERT_THAT(socketpair(AF_UNIX, SOCK_STREAM, 0, fds), SyscallSucceeds());
+  FileDescriptor sender(fds[0]);
+  FileDescriptor receiver(fds[1]);
+
+  char data[] = "DEADBEEF";
+  IOUringSqe sqe = {};
+  sqe.opcode = IORING_OP_SEND;
+  sqe.fd = sender.get();
+  sqe.addr = reinterpret_cast<uint64_t>(data);
+  sqe.len = 8;
+  IOUringCqe cqe =
+      ASSERT_NO_ERRNO_AND_VALUE(SubmitAndWait(io_uring.get(), params, sqe));
+  EXPECT_EQ(cqe.res, 8);
+
+  char buf[8] = {};
+  sqe.opcode = IORING_OP_RECV;
+  sqe.fd = receiver.get();
+  sqe.addr = reinterpret_cast<uint64_t>(buf);
+  sqe.len = sizeof(buf);
+  cqe = ASSERT_NO_ERRNO_AND_VALUE(SubmitAndWait(io_uring.get(), params, sqe));
+  EXPECT_EQ(cqe.res, 8);
+  EXPECT_EQ(std::string(buf, 8), "DEADBEEF");
+}
+
+// Tests that IORING_OP_CONNECT and IORING_OP_ACCEPT establish a connection.
+TEST(IOUringTest, ConnectAccept) {
+  SKIP_IF(!IOUringAvailable());
+
+  IOUringParams params = {};
+  std::unique_ptr<IOUring> io_uring =
+      ASSERT_NO_ERRNO_AND_VALUE(IOUring::InitIOUring(1, params));
+
+  // Use an abstract socket address.
+  struct sockaddr_un addr = {};
+  addr.sun_family = AF_UNIX;
+  constexpr char kName[] = "\0io_uring_connect_accept";
+  memcpy(addr.sun_path, kName, sizeof(kName) - 1);
+  socklen_t addrlen =
+      offsetof(struct sockaddr_un, sun_path) + sizeof(kName) - 1;
+
+  int server_fd;
+  ASSERT_THAT(server_fd = socket(AF_UNIX, SOCK_STREAM, 0), SyscallSucceeds());
+  FileDescriptor server(server_fd);
+  ASSERT_THAT(
+      bind(server.get(), reinterpret_cast<struct sockaddr *>(&addr), addrlen),
+      SyscallSucceeds());
+  ASSERT_THAT(listen(server.get(), 1), SyscallSucceeds());
+  int client_fd;
+  ASSERT_THAT(client_fd = socket(AF_UNIX, SOCK_STREAM, 0), SyscallSucceeds());
+  FileDescriptor client(client_fd);
+
+  IOUringSqe sqe = {};
+  sqe.opcode = IORING_OP_CONNECT;
+  sqe.fd = client.get();
+  sqe.addr = reinterpret_cast<uint64_t>(&addr);
+  sqe.off = addrlen;
+  IOUringCqe cqe =
+      ASSERT_NO_ERRNO_AND_VALUE(SubmitAndWait(io_uring.get(), params, sqe));
+  EXPECT_EQ(cqe.res, 0);
+
+  sqe = {};
+  sqe.opcode = IORING_OP_ACCEPT;
+  sqe.fd = server.get();
+  sqe.accept_flags = SOCK_CLOEXEC;
+  cqe = ASSERT_NO_ERRNO_AND_VALUE(SubmitAndWait(io_uring.get(), params, sqe));
+  ASSERT_GE(cqe.res, 0);
+  FileDescriptor accepted(cqe.res);
+  EXPECT_THAT(fcntl(accepted.get(), F_GETFD),
+              SyscallSucceedsWithValue(FD_CLOEXEC));
+}
+
+// Tests that IORING_SETUP_SQPOLL is rejected, since gVisor has no kernel-side
+// submission queue polling thread.
+TEST(IOUringTest, SQPollUnsupported) {
+  SKIP_IF(!IOUringAvailable());
+  SKIP_IF(!IsRunningOnGvisor());
+
+  IOUringParams params = {};
+  params.flags = IORING_SETUP_SQPOLL;
+  EXPECT_THAT(IOUringSetup(1, &params), SyscallFailsWithErrno(EINVAL));
+}
+
+// Tests that IORING_REGISTER_PROBE reports supported opcodes.
+TEST(IOUringTest, Probe) {
+  SKIP_IF(!IOUringAvailable());
+
+  IOUringParams params = {};
+  FileDescriptor iouringfd = ASSERT_NO_ERRNO_AND_VALUE(NewIOUringFD(1, params));
+
+  constexpr int kNumOps = 256;
+  std::vector<char> buf(sizeof(struct io_uring_probe) +
+                        kNumOps * sizeof(struct io_uring_probe_op));
+  struct io_uring_probe *probe =
+      reinterpret_cast<struct io_uring_probe *>(buf.data());
+  ASSERT_THAT(IOUringRegister(iouringfd.get(), IORING_REGISTER_PROBE, probe,
+                              kNumOps),
+              SyscallSucceeds());
+  ASSERT_GT(probe->ops_len, IORING_OP_RECV);
+  for (int op : {IORING_OP_NOP, IORING_OP_READV, IORING_OP_WRITEV,
+                 IORING_OP_FSYNC, IORING_OP_READ_FIXED, IORING_OP_WRITE_FIXED,
+                 IORING_OP_TIMEOUT, IORING_OP_ACCEPT, IORING_OP_CONNECT,
+                 IORING_OP_SEND, IORING_OP_RECV}) {
+    EXPECT_EQ(probe->ops[op].op, op);
+    EXPECT_NE(probe->ops[op].flags & IO_URING_OP_SUPPORTED, 0) << op;
+  }
+
+  // The probe must be zeroed.
+  ASSERT_THAT(IOUringRegister(iouringfd.get(), IORING_REGISTER_PROBE, probe,
+                              kNumOps),
+              SyscallFailsWithErrno(EINVAL));
+}
+
 }  // namespace
 
 }  // namespace testing
@@ -0,0 +1,220 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <fcntl.h>
+#include <signal.h>
+#include <sys/epoll.h>
+#include <sys/syscall.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+#include <cerrno>
+#include <cstdint>
+
+#include "gtest/gtest.h"
+#include "test/util/cleanup.h"
+#include "test/util/epoll_util.h"
+#include "test/util/eventfd_util.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/posix_error.h"
+#include "test/util/test_util.h"
+#include "test/util/thread_util.h"
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+// From linux/kcmp.h, which isn't available everywhere.
+constexpr int kKcmpFile = 0;
+constexpr int kKcmpVM = 1;
+constexpr int kKcmpFiles = 2;
+constexpr int kKcmpFS = 3;
+constexpr int kKcmpSighand = 4;
+constexpr int kKcmpIO = 5;
+constexpr int kKcmpSysvsem = 6;
+constexpr int kKcmpEpollTFD = 7;
+constexpr int kKcmpTypes = 8;
+
+struct KcmpEpollSlot {
+  uint32_t efd;
+  uint32_t tfd;
+  uint32_t toff;
+};
+
+int kcmp(pid_t pid1, pid_t pid2, int type, uint64_t idx1, uint64_t idx2) {
+  return syscall(SYS_kcmp, pid1, pid2, type, idx1, idx2);
+}
+
+// Skips the test if kcmp isn't available, as on Linux without CONFIG_KCMP.
+void SkipIfKcmpUnsupported() {
+  int ret = kcmp(getpid(), getpid(), kKcmpVM, 0, 0);
+  SKIP_IF(ret < 0 && errno == ENOSYS);
+}
+
+// Returns a child process that sleeps until it is killed.
+PosixErrorOr<pid_t> ForkSleepingChild() {
+  pid_t child = fork();
+  if (child == 0) {
+    while (true) {
+      pause();
+    }
+  }
+  if (child < 0) {
+    return PosixError(errno, "fork");
+  }
+  return child;
+}
+
+void KillAndReap(pid_t child) {
+  EXPECT_THAT(kill(child, SIGKILL), SyscallSucceeds());
+  int status;
+  EXPECT_THAT(RetryEINTR(waitpid)(child, &status, 0),
+              SyscallSucceedsWithValue(child));
+}
+
+TEST(KcmpTest, SameFile) {
+  SkipIfKcmpUnsupported();
+  const FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open("/dev/null", O_RDONLY));
+  const FileDescriptor dup_fd = ASSERT_NO_ERRNO_AND_VALUE(fd.Dup());
+  EXPECT_THAT(kcmp(getpid(), getpid(), kKcmpFile, fd.get(), fd.get()),
+              SyscallSucceedsWithValue(0));
+  EXPECT_THAT(kcmp(getpid(), getpid(), kKcmpFile, fd.get(), dup_fd.get()),
+              SyscallSucceedsWithValue(0));
+}
+
+TEST(KcmpTest, DifferentFiles) {
+  SkipIfKcmpUnsupported();
+  const FileDescriptor fd1 =
+      ASSERT_NO_ERRNO_AND_VALUE(Open("/dev/null", O_RDONLY));
+  const FileDescriptor fd2 =
+      ASSERT_NO_ERRNO_AND_VALUE(Open("/dev/null", O_RDONLY));
+  int ret1 = kcmp(getpid(), getpid(), kKcmpFile, fd1.get(), fd2.get());
+  int ret2 = kcmp(getpid(), getpid(), kKcmpFile, fd2.get(), fd1.get());
+  // The files are ordered consistently.
+  ASSERT_THAT(ret1, SyscallSucceeds());
+  ASSERT_THAT(ret2, SyscallSucceeds());
+  EXPECT_TRUE((ret1 == 1 && ret2 == 2) || (ret1 == 2 && ret2 == 1))
+      << "ret1 = " << ret1 << ", ret2 = " << ret2;
+}
+
+TEST(KcmpTest, SelfResources) {
+  SkipIfKcmpUnsupported();
+  for (int type : {kKcmpVM, kKcmpFiles, kKcmpFS, kKcmpSighand, kKcmpIO,
+                   kKcmpSysvsem}) {
+    EXPECT_THAT(kcmp(getpid(), getpid(), type, 0, 0),
+                SyscallSucceedsWithValue(0))
+        << "type " << type;
+  }
+}
+
+TEST(KcmpTest, Thread) {
+  SkipIfKcmpUnsupported();
+  pid_t tid;
+  ScopedThread t([&] {
+    tid = gettid();
+    // Threads share all resources compared by kcmp.
+    for (int type : {kKcmpVM, kKcmpFiles, kKcmpFS, kKcmpSighand}) {
+      EXPECT_THAT(kcmp(getpid(), tid, type, 0, 0),
+                  SyscallSucceedsWithValue(0))
+          << "type " << type;
+    }
+  });
+  t.Join();
+}
+
+TEST(KcmpTest, ForkedChild) {
+  SkipIfKcmpUnsupported();
+  const FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open("/dev/null", O_RDONLY));
+  const pid_t child = ASSERT_NO_ERRNO_AND_VALUE(ForkSleepingChild());
+  auto cleanup = Cleanup([child] { KillAndReap(child); });
+
+  for (int type : {kKcmpVM, kKcmpFiles, kKcmpFS, kKcmpSighand}) {
+    int ret = kcmp(getpid(), child, type, 0, 0);
+    ASSERT_THAT(ret, SyscallSucceeds()) << "type " << type;
+    EXPECT_NE(ret, 0) << "type " << type;
+  }
+  // The child's copy of fd refers to the same file.
+  EXPECT_THAT(kcmp(getpid(), child, kKcmpFile, fd.get(), fd.get()),
+              SyscallSucceedsWithValue(0));
+}
+
+TEST(KcmpTest, EpollTargetFile) {
+  SkipIfKcmpUnsupported();
+  const FileDescriptor epfd = ASSERT_NO_ERRNO_AND_VALUE(NewEpollFD());
+  const FileDescriptor efd = ASSERT_NO_ERRNO_AND_VALUE(NewEventFD());
+  const FileDescriptor other = ASSERT_NO_ERRNO_AND_VALUE(NewEventFD());
+  ASSERT_NO_ERRNO(RegisterEpollFD(epfd.get(), efd.get(), EPOLLIN, 0));
+
+  KcmpEpollSlot slot = {};
+  slot.efd = epfd.get();
+  slot.tfd = efd.get();
+  int ret = kcmp(getpid(), getpid(), kKcmpEpollTFD, efd.get(),
+                 reinterpret_cast<uint64_t>(&slot));
+  // Linux requires CONFIG_EPOLL and CONFIG_CHECKPOINT_RESTORE.
+  SKIP_IF(ret < 0 && errno == EINVAL && !IsRunningOnGvisor());
+  EXPECT_THAT(ret, SyscallSucceedsWithValue(0));
+
+  ret = kcmp(getpid(), getpid(), kKcmpEpollTFD, other.get(),
+             reinterpret_cast<uint64_t>(&slot));
+  ASSERT_THAT(ret, SyscallSucceeds());
+  EXPECT_NE(ret, 0);
+
+  // The file isn't registered at this offset.
+  slot.toff = 1;
+  EXPECT_THAT(kcmp(getpid(), getpid(), kKcmpEpollTFD, efd.get(),
+                   reinterpret_cast<uint64_t>(&slot)),
+              SyscallFailsWithErrno(ENOENT));
+
+  // other isn't registered at all.
+  slot.toff = 0;
+  slot.tfd = other.get();
+  EXPECT_THAT(kcmp(getpid(), getpid(), kKcmpEpollTFD, efd.get(),
+                   reinterpret_cast<uint64_t>(&slot)),
+              SyscallFailsWithErrno(ENOENT));
+
+  // slot.efd isn't an epoll instance.
+  slot.efd = efd.get();
+  EXPECT_THAT(kcmp(getpid(), getpid(), kKcmpEpollTFD, efd.get(),
+                   reinterpret_cast<uint64_t>(&slot)),
+              SyscallFailsWithErrno(EINVAL));
+
+  EXPECT_THAT(kcmp(getpid(), getpid(), kKcmpEpollTFD, efd.get(), 0),
+              SyscallFailsWithErrno(EFAULT));
+}
+
+TEST(KcmpTest, InvalidArguments) {
+  SkipIfKcmpUnsupported();
+  const FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open("/dev/null", O_RDONLY));
+
+  EXPECT_THAT(kcmp(getpid(), getpid(), kKcmpTypes, 0, 0),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(kcmp(getpid(), getpid(), -1, 0, 0),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(kcmp(getpid(), getpid(), kKcmpFile, fd.get(), -1),
+              SyscallFailsWithErrno(EBADF));
+  EXPECT_THAT(kcmp(getpid(), getpid(), kKcmpFile, fd.get(), 1 << 30),
+              SyscallFailsWithErrno(EBADF));
+  EXPECT_THAT(kcmp(getpid(), -1, kKcmpVM, 0, 0),
+              SyscallFailsWithErrno(ESRCH));
+  EXPECT_THAT(kcmp(0, getpid(), kKcmpVM, 0, 0), SyscallFailsWithErrno(ESRCH));
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
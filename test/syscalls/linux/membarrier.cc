@@ -262,6 +262,30 @@ TEST(MembarrierTest, PrivateExpedited) {
       &state, [] { std::atomic_signal_fence(std::memory_order_seq_cst); });
 }
 
+TEST(MembarrierTest, PrivateExpeditedSyncCore) {
+  constexpr int kRequiredCommands =
+      MEMBARRIER_CMD_PRIVATE_EXPEDITED_SYNC_CORE |
+      MEMBARRIER_CMD_REGISTER_PRIVATE_EXPEDITED_SYNC_CORE;
+  SKIP_IF((ASSERT_NO_ERRNO_AND_VALUE(SupportedMembarrierCommands()) &
+           kRequiredCommands) != kRequiredCommands);
+
+  ASSERT_THAT(
+      membarrier(MEMBARRIER_CMD_REGISTER_PRIVATE_EXPEDITED_SYNC_CORE, 0),
+      SyscallSucceeds());
+
+  MembarrierTestSharedState state;
+  state.Init();
+
+  ScopedThread remote_thread([&] {
+    RunMembarrierTestRemoteSide(&state, [] {
+      TEST_PCHECK(membarrier(MEMBARRIER_CMD_PRIVATE_EXPEDITED_SYNC_CORE, 0) ==
+                  0);
+    });
+  });
+  RunMembarrierTestLocalSide(
+      &state, [] { std::atomic_signal_fence(std::memory_order_seq_cst); });
+}
+
 }  // namespace
 
 }  // namespace testing
@@ -51,6 +51,10 @@ namespace {
 #define F_SEAL_GROW 0x0004
 #define F_SEAL_WRITE 0x0008
 
+#ifndef F_SEAL_FUTURE_WRITE
+#define F_SEAL_FUTURE_WRITE 0x0010
+#endif /* F_SEAL_FUTURE_WRITE */
+
 using ::gvisor::testing::IsTmpfs;
 using ::testing::StartsWith;
 
@@ -410,6 +414,57 @@ TEST(MemfdTest, NoPartialSealApplicationWhenWriteSealRejected) {
   EXPECT_THAT(fcntl(memfd.get(), F_GET_SEALS), SyscallSucceedsWithValue(0));
 }
 
+// Unknown seals are rejected.
+TEST(MemfdTest, UnknownSeal) {
+  const FileDescriptor memfd =
+      ASSERT_NO_ERRNO_AND_VALUE(MemfdCreate(kMemfdName, MFD_ALLOW_SEALING));
+  EXPECT_THAT(fcntl(memfd.get(), F_ADD_SEALS, 0x8000),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(fcntl(memfd.get(), F_GET_SEALS), SyscallSucceedsWithValue(0));
+}
+
+// F_SEAL_FUTURE_WRITE can be added while the memfd has writable mappings,
+// which remain writable, but prevents any new writes.
+TEST(MemfdTest, SealFutureWrite) {
+  const FileDescriptor memfd =
+      ASSERT_NO_ERRNO_AND_VALUE(MemfdCreate(kMemfdName, MFD_ALLOW_SEALING));
+  ASSERT_THAT(ftruncate(memfd.get(), kPageSize), SyscallSucceeds());
+  const Mapping m = ASSERT_NO_ERRNO_AND_VALUE(Mmap(
+      nullptr, kPageSize, PROT_READ | PROT_WRITE, MAP_SHARED, memfd.get(), 0));
+
+  ASSERT_THAT(fcntl(memfd.get(), F_ADD_SEALS, F_SEAL_FUTURE_WRITE),
+              SyscallSucceeds());
+  EXPECT_THAT(fcntl(memfd.get(), F_GET_SEALS),
+              SyscallSucceedsWithValue(F_SEAL_FUTURE_WRITE));
+
+  // The existing mapping can still be written, and the write is visible
+  // through the file.
+  *reinterpret_cast<char*>(m.ptr()) = 'x';
+  char c;
+  EXPECT_THAT(pread(memfd.get(), &c, 1, 0), SyscallSucceedsWithValue(1));
+  EXPECT_EQ(c, 'x');
+
+  // But the file can't be written through write(2).
+  EXPECT_THAT(pwrite(memfd.get(), &c, 1, 0), SyscallFailsWithErrno(EPERM));
+
+  // Nor through new shared writable mappings.
+  void* ret = mmap(nullptr, kPageSize, PROT_READ | PROT_WRITE, MAP_SHARED,
+                   memfd.get(), 0);
+  EXPECT_EQ(ret, MAP_FAILED);
+  EXPECT_EQ(errno, EPERM);
+
+  // New shared read-only mappings can't be made writable.
+  const Mapping ro = ASSERT_NO_ERRNO_AND_VALUE(
+      Mmap(nullptr, kPageSize, PROT_READ, MAP_SHARED, memfd.get(), 0));
+  EXPECT_EQ(*reinterpret_cast<char*>(ro.ptr()), 'x');
+  EXPECT_THAT(mprotect(ro.ptr(), kPageSize, PROT_READ | PROT_WRITE),
+              SyscallFailsWithErrno(EACCES));
+
+  // Private mappings are ok.
+  EXPECT_NO_ERRNO(Mmap(nullptr, kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE,
+                       memfd.get(), 0));
+}
+
 // Seals are inode level properties, and apply to all file descriptors referring
 // to a memfd.
 TEST(MemfdTest, SealsAreInodeLevelProperties) {
@@ -13,13 +13,19 @@
 // limitations under the License.
 
 #include <errno.h>
+#include <sys/mman.h>
 #include <sys/syscall.h>
+#include <unistd.h>
 
 #include <memory>
+#include <string>
 
 #include "gtest/gtest.h"
 #include "absl/memory/memory.h"
+#include "absl/strings/str_cat.h"
+#include "test/util/capability_util.h"
 #include "test/util/cleanup.h"
+#include "test/util/fs_util.h"
 #include "test/util/memory_util.h"
 #include "test/util/test_util.h"
 #include "test/util/thread_util.h"
@@ -60,6 +66,11 @@ int mbind(void* addr, unsigned long len, int mode,
   return syscall(SYS_mbind, addr, len, mode, nodemask, maxnode, flags);
 }
 
+int move_pages(pid_t pid, unsigned long count, void** pages, const int* nodes,
+               int* status, int flags) {
+  return syscall(SYS_move_pages, pid, count, pages, nodes, status, flags);
+}
+
 // Creates a cleanup object that resets the calling thread's mempolicy to the
 // system default when the calling scope ends.
 Cleanup ScopedMempolicy() {
@@ -289,6 +300,98 @@ TEST(MempolicyTest, Mbind) {
   EXPECT_EQ(mode, MPOL_PREFERRED);
 }
 
+TEST(MempolicyTest, NodesMatchSysfs) {
+  uint64_t nodemask = 0;
+  ASSERT_THAT(
+      get_mempolicy(nullptr, &nodemask, sizeof(nodemask) * BITS_PER_BYTE,
+                    nullptr, MPOL_F_MEMS_ALLOWED),
+      SyscallSucceeds());
+  // Hosts may have offline nodes, which aren't in a single range.
+  SKIP_IF(!IsRunningOnGvisor());
+
+  const int nodes = __builtin_popcountll(nodemask);
+  std::string online = ASSERT_NO_ERRNO_AND_VALUE(
+      GetContents("/sys/devices/system/node/online"));
+  EXPECT_EQ(online, nodes == 1 ? "0\n" : absl::StrCat("0-", nodes - 1, "\n"));
+  for (int node = 0; node < nodes; node++) {
+    EXPECT_TRUE(ASSERT_NO_ERRNO_AND_VALUE(Exists(
+        absl::StrCat("/sys/devices/system/node/node", node, "/cpulist"))));
+  }
+  EXPECT_FALSE(ASSERT_NO_ERRNO_AND_VALUE(
+      Exists(absl::StrCat("/sys/devices/system/node/node", nodes))));
+}
+
+TEST(MempolicyTest, MovePagesQuery) {
+  const auto mapping = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE | MAP_ANONYMOUS));
+  char* const touched = reinterpret_cast<char*>(mapping.ptr());
+  *touched = 1;
+  // Use a separate mapping with different permissions, so that it isn't
+  // faulted in along with the touched page.
+  const auto untouched_mapping = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ, MAP_PRIVATE | MAP_ANONYMOUS));
+  void* const untouched = untouched_mapping.ptr();
+  Mapping unmapped = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE | MAP_ANONYMOUS));
+  void* const unmapped_addr = unmapped.ptr();
+  unmapped.reset();
+
+  void* pages[] = {touched, untouched, unmapped_addr};
+  int status[] = {-1, -1, -1};
+  ASSERT_THAT(move_pages(0, 3, pages, nullptr, status, 0), SyscallSucceeds());
+  EXPECT_GE(status[0], 0);
+  if (IsRunningOnGvisor()) {
+    EXPECT_EQ(status[0], 0);
+  }
+  EXPECT_EQ(status[1], -ENOENT);
+  EXPECT_EQ(status[2], -EFAULT);
+
+  // A page bound to a node is reported on that node.
+  uint64_t nodemask = 0x1;
+  ASSERT_THAT(mbind(touched, kPageSize, MPOL_BIND, &nodemask,
+                    sizeof(nodemask) * BITS_PER_BYTE, 0),
+              SyscallSucceeds());
+  status[0] = -1;
+  ASSERT_THAT(move_pages(getpid(), 1, pages, nullptr, status, 0),
+              SyscallSucceeds());
+  EXPECT_EQ(status[0], 0);
+}
+
+TEST(MempolicyTest, MovePages) {
+  const auto mapping = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE | MAP_ANONYMOUS));
+  *reinterpret_cast<char*>(mapping.ptr()) = 1;
+
+  void* pages[] = {mapping.ptr()};
+  int nodes[] = {0};
+  int status[] = {-1};
+  ASSERT_THAT(move_pages(0, 1, pages, nodes, status, MPOL_MF_MOVE),
+              SyscallSucceeds());
+  EXPECT_EQ(status[0], 0);
+
+  nodes[0] = -1;
+  EXPECT_THAT(move_pages(0, 1, pages, nodes, status, MPOL_MF_MOVE),
+              SyscallFailsWithErrno(ENODEV));
+}
+
+TEST(MempolicyTest, MovePagesInvalidArguments) {
+  const auto mapping = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE | MAP_ANONYMOUS));
+  void* pages[] = {mapping.ptr()};
+  int status[] = {-1};
+
+  EXPECT_THAT(move_pages(0, 1, pages, nullptr, status, MPOL_MF_STRICT),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(move_pages(-1, 1, pages, nullptr, status, 0),
+              SyscallFailsWithErrno(ESRCH));
+  EXPECT_THAT(move_pages(0, 1, nullptr, nullptr, status, 0),
+              SyscallFailsWithErrno(EFAULT));
+  if (!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_NICE))) {
+    EXPECT_THAT(move_pages(0, 1, pages, nullptr, status, MPOL_MF_MOVE_ALL),
+                SyscallFailsWithErrno(EPERM));
+  }
+}
+
 }  // namespace
 
 }  // namespace testing
@@ -50,6 +50,43 @@ TEST(MincoreTest, DirtyAnonPagesAreResident) {
   EXPECT_EQ(kTestPageCount, CountSetLSBs(vec));
 }
 
+TEST(MincoreTest, UntouchedAnonPagesAreNotResident) {
+  // Use a mapping larger than a huge page so that it isn't populated eagerly.
+  constexpr size_t kTestPageCount = 1024;
+  auto const kTestMappingBytes = kTestPageCount * kPageSize;
+  auto m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kTestMappingBytes, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+
+  std::vector<unsigned char> vec(kTestPageCount, 0);
+  ASSERT_THAT(mincore(m.ptr(), kTestMappingBytes, vec.data()),
+              SyscallSucceeds());
+  EXPECT_EQ(size_t{0}, CountSetLSBs(vec));
+
+  // Touching the first page makes it resident.
+  *static_cast<volatile char*>(m.ptr()) = 1;
+  ASSERT_THAT(mincore(m.ptr(), kTestMappingBytes, vec.data()),
+              SyscallSucceeds());
+  EXPECT_EQ(1, vec[0] & 1);
+
+  // Discarding it makes it non-resident again.
+  ASSERT_THAT(madvise(m.ptr(), kTestMappingBytes, MADV_DONTNEED),
+              SyscallSucceeds());
+  ASSERT_THAT(mincore(m.ptr(), kTestMappingBytes, vec.data()),
+              SyscallSucceeds());
+  EXPECT_EQ(size_t{0}, CountSetLSBs(vec));
+}
+
+TEST(MincoreTest, UnmappedRangeFails) {
+  auto m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(2 * kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  ASSERT_THAT(munmap(reinterpret_cast<void*>(m.addr() + kPageSize), kPageSize),
+              SyscallSucceeds());
+
+  std::vector<unsigned char> vec(2, 0);
+  EXPECT_THAT(mincore(m.ptr(), 2 * kPageSize, vec.data()),
+              SyscallFailsWithErrno(ENOMEM));
+}
+
 TEST(MincoreTest, UnalignedAddressFails) {
   // Map and touch two pages, then try to mincore the second half of the first
   // page + the first half of the second page. Both pages are mapped, but
@@ -30,6 +30,7 @@
 #include <unistd.h>
 
 #include <limits>
+#include <memory>
 #include <vector>
 
 #include "gmock/gmock.h"
@@ -43,6 +44,7 @@
 #include "test/util/multiprocess_util.h"
 #include "test/util/temp_path.h"
 #include "test/util/test_util.h"
+#include "test/util/thread_util.h"
 
 using ::testing::AnyOf;
 using ::testing::Eq;
@@ -1754,6 +1756,36 @@ TEST(MMapNoFixtureTest, MapReadOnlyAfterCreateWriteOnly) {
             static_cast<char*>(mapping.endptr()), buf.data());
 }
 
+// Many threads faulting on the same pages concurrently must all observe the
+// same pages.
+TEST(MMapNoFixtureTest, ConcurrentFaults) {
+  constexpr int kThreads = 8;
+  // Large enough that the mapping isn't populated at mmap() time.
+  constexpr size_t kPages = 1024;
+  Mapping const m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPages * kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  char* const p = static_cast<char*>(m.ptr());
+
+  std::vector<std::unique_ptr<ScopedThread>> threads;
+  for (int i = 0; i < kThreads; i++) {
+    threads.push_back(std::make_unique<ScopedThread>([p, i] {
+      for (size_t page = 0; page < kPages; page++) {
+        p[page * kPageSize + i] = static_cast<char>(i + 1);
+      }
+    }));
+  }
+  for (auto& t : threads) {
+    t->Join();
+  }
+
+  for (size_t page = 0; page < kPages; page++) {
+    for (int i = 0; i < kThreads; i++) {
+      ASSERT_EQ(p[page * kPageSize + i], static_cast<char>(i + 1))
+          << "page " << page << " byte " << i;
+    }
+  }
+}
+
 // Conditional on MAP_32BIT.
 // This flag is supported only on x86-64, for 64-bit programs.
 #ifdef __x86_64__
@@ -12,9 +12,11 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+#include <fcntl.h>
 #include <sys/mman.h>
 #include <unistd.h>
 
+#include <cstring>
 #include <functional>
 #include <string>
 #include <utility>
@@ -142,6 +144,56 @@ TEST_P(MsyncFullParamTest, InvalidateUnlockedSucceeds) {
 // The test for MS_INVALIDATE on mlocked pages is in mlock.cc since it requires
 // probing for mlock support.
 
+// Writes made through a shared file mapping must be visible through the file
+// after msync(MS_SYNC | MS_INVALIDATE), and must not be discarded from the
+// mapping by MS_INVALIDATE.
+TEST(MsyncTest, SharedFileSyncInvalidatePreservesWrites) {
+  auto const file = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateFile());
+  FileDescriptor const fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open(file.path(), O_RDWR));
+  ASSERT_THAT(ftruncate(fd.get(), kPageSize), SyscallSucceeds());
+  Mapping const m = ASSERT_NO_ERRNO_AND_VALUE(Mmap(
+      nullptr, kPageSize, PROT_READ | PROT_WRITE, MAP_SHARED, fd.get(), 0));
+
+  std::string const data = "msync";
+  memcpy(m.ptr(), data.data(), data.size());
+  ASSERT_THAT(msync(m.ptr(), m.len(), MS_SYNC | MS_INVALIDATE),
+              SyscallSucceeds());
+
+  std::vector<char> buf(data.size());
+  ASSERT_THAT(pread(fd.get(), buf.data(), buf.size(), 0),
+              SyscallSucceedsWithValue(buf.size()));
+  EXPECT_EQ(std::string(buf.begin(), buf.end()), data);
+  EXPECT_EQ(0, memcmp(m.ptr(), data.data(), data.size()));
+
+  // Subsequent writes through the mapping must still reach the file.
+  std::string const data2 = "again";
+  memcpy(m.ptr(), data2.data(), data2.size());
+  ASSERT_THAT(msync(m.ptr(), m.len(), MS_SYNC), SyscallSucceeds());
+  ASSERT_THAT(pread(fd.get(), buf.data(), buf.size(), 0),
+              SyscallSucceedsWithValue(buf.size()));
+  EXPECT_EQ(std::string(buf.begin(), buf.end()), data2);
+}
+
+// After msync(MS_INVALIDATE), a shared file mapping must observe writes made
+// to the file through write(2).
+TEST(MsyncTest, SharedFileInvalidateObservesFileWrites) {
+  auto const file = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateFile());
+  FileDescriptor const fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open(file.path(), O_RDWR));
+  ASSERT_THAT(ftruncate(fd.get(), kPageSize), SyscallSucceeds());
+  Mapping const m = ASSERT_NO_ERRNO_AND_VALUE(
+      Mmap(nullptr, kPageSize, PROT_READ, MAP_SHARED, fd.get(), 0));
+  // Fault the page in.
+  EXPECT_EQ(0, *reinterpret_cast<volatile char*>(m.ptr()));
+
+  std::string const data = "written";
+  ASSERT_THAT(pwrite(fd.get(), data.data(), data.size(), 0),
+              SyscallSucceedsWithValue(data.size()));
+  ASSERT_THAT(msync(m.ptr(), m.len(), MS_INVALIDATE), SyscallSucceeds());
+  EXPECT_EQ(0, memcmp(m.ptr(), data.data(), data.size()));
+}
+
 INSTANTIATE_TEST_SUITE_P(
     All, MsyncFullParamTest,
     ::testing::Combine(::testing::ValuesIn(kMsyncFlags),
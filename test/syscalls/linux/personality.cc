@@ -0,0 +1,70 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <errno.h>
+#include <sys/personality.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+#include "gtest/gtest.h"
+#include "test/util/multiprocess_util.h"
+#include "test/util/test_util.h"
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+constexpr unsigned long kQuery = 0xffffffff;
+
+TEST(PersonalityTest, SetAndQuery) {
+  const auto rest = [] {
+    const int old = personality(kQuery);
+    TEST_CHECK(old >= 0);
+    TEST_CHECK(personality(PER_LINUX | ADDR_NO_RANDOMIZE) == old);
+    TEST_CHECK(personality(kQuery) == (PER_LINUX | ADDR_NO_RANDOMIZE));
+    TEST_CHECK(personality(PER_LINUX) == (PER_LINUX | ADDR_NO_RANDOMIZE));
+    TEST_CHECK(personality(kQuery) == PER_LINUX);
+  };
+  EXPECT_THAT(InForkedProcess(rest), IsPosixErrorOkAndHolds(0));
+}
+
+TEST(PersonalityTest, InheritedByChildren) {
+  const auto rest = [] {
+    TEST_CHECK(personality(PER_LINUX | ADDR_NO_RANDOMIZE) >= 0);
+    pid_t child = fork();
+    if (child == 0) {
+      _exit(personality(kQuery) == (PER_LINUX | ADDR_NO_RANDOMIZE) ? 0 : 1);
+    }
+    TEST_CHECK(child > 0);
+    int status;
+    TEST_CHECK(waitpid(child, &status, 0) == child);
+    TEST_CHECK(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+  };
+  EXPECT_THAT(InForkedProcess(rest), IsPosixErrorOkAndHolds(0));
+}
+
+TEST(PersonalityTest, UnsupportedExecutionDomain) {
+  // gVisor only supports the Linux execution domain.
+  SKIP_IF(!IsRunningOnGvisor());
+  const auto rest = [] {
+    TEST_CHECK(personality(PER_SVR4) == -1 && errno == EINVAL);
+  };
+  EXPECT_THAT(InForkedProcess(rest), IsPosixErrorOkAndHolds(0));
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
@@ -0,0 +1,306 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <fcntl.h>
+#include <poll.h>
+#include <signal.h>
+#include <sys/syscall.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+#include <cerrno>
+#include <cstring>
+
+#include "gtest/gtest.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/posix_error.h"
+#include "test/util/save_util.h"
+#include "test/util/test_util.h"
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+#ifndef SYS_pidfd_send_signal
+#define SYS_pidfd_send_signal 424
+#endif  // SYS_pidfd_send_signal
+
+#ifndef SYS_pidfd_open
+#define SYS_pidfd_open 434
+#endif  // SYS_pidfd_open
+
+#ifndef SYS_pidfd_getfd
+#define SYS_pidfd_getfd 438
+#endif  // SYS_pidfd_getfd
+
+#ifndef P_PIDFD
+#define P_PIDFD 3
+#endif  // P_PIDFD
+
+#ifndef PIDFD_NONBLOCK
+#define PIDFD_NONBLOCK O_NONBLOCK
+#endif  // PIDFD_NONBLOCK
+
+int pidfd_open(pid_t pid, unsigned int flags) {
+  return syscall(SYS_pidfd_open, pid, flags);
+}
+
+// Returns a new pidfd for pid.
+PosixErrorOr<FileDescriptor> OpenPidfd(pid_t pid, unsigned int flags = 0) {
+  int fd = pidfd_open(pid, flags);
+  MaybeSave();
+  if (fd < 0) {
+    return PosixError(errno, "pidfd_open");
+  }
+  return FileDescriptor(fd);
+}
+
+// Returns a duplicate of targetfd in the process referred to by pidfd.
+PosixErrorOr<FileDescriptor> GetPidfdFD(int pidfd, int targetfd) {
+  int fd = syscall(SYS_pidfd_getfd, pidfd, targetfd, 0);
+  MaybeSave();
+  if (fd < 0) {
+    return PosixError(errno, "pidfd_getfd");
+  }
+  return FileDescriptor(fd);
+}
+
+int pidfd_getfd(int pidfd, int targetfd, unsigned int flags) {
+  return syscall(SYS_pidfd_getfd, pidfd, targetfd, flags);
+}
+
+int pidfd_send_signal(int pidfd, int sig, siginfo_t* info,
+                      unsigned int flags) {
+  return syscall(SYS_pidfd_send_signal, pidfd, sig, info, flags);
+}
+
+// A child process that blocks until its control pipe is closed or written to.
+class BlockedChild {
+ public:
+  BlockedChild() {
+    int fds[2];
+    TEST_PCHECK(pipe(fds) == 0);
+    pid_ = fork();
+    if (pid_ == 0) {
+      close(fds[1]);
+      char c;
+      TEST_PCHECK(read(fds[0], &c, 1) >= 0);
+      _exit(kExitCode);
+    }
+    TEST_PCHECK(pid_ > 0);
+    close(fds[0]);
+    release_fd_ = fds[1];
+  }
+
+  ~BlockedChild() {
+    Release();
+    if (pid_ > 0) {
+      waitpid(pid_, nullptr, 0);
+    }
+  }
+
+  // Allows the child to exit with kExitCode.
+  void Release() {
+    if (release_fd_ >= 0) {
+      close(release_fd_);
+      release_fd_ = -1;
+    }
+  }
+
+  // Indicates that the child has already been reaped.
+  void Reaped() { pid_ = -1; }
+
+  pid_t pid() const { return pid_; }
+
+  static constexpr int kExitCode = 42;
+
+ private:
+  pid_t pid_;
+  int release_fd_;
+};
+
+TEST(PidfdTest, OpenInvalid) {
+  EXPECT_THAT(pidfd_open(0, 0), SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(pidfd_open(-1, 0), SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(pidfd_open(getpid(), ~PIDFD_NONBLOCK),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(PidfdTest, OpenReapedProcess) {
+  pid_t child = fork();
+  if (child == 0) {
+    _exit(0);
+  }
+  ASSERT_THAT(child, SyscallSucceeds());
+  ASSERT_THAT(waitpid(child, nullptr, 0), SyscallSucceedsWithValue(child));
+  EXPECT_THAT(pidfd_open(child, 0), SyscallFailsWithErrno(ESRCH));
+}
+
+TEST(PidfdTest, OpenIsCloseOnExec) {
+  FileDescriptor pidfd = ASSERT_NO_ERRNO_AND_VALUE(OpenPidfd(getpid()));
+  EXPECT_THAT(fcntl(pidfd.get(), F_GETFD),
+              SyscallSucceedsWithValue(FD_CLOEXEC));
+}
+
+TEST(PidfdTest, PollReadableOnExit) {
+  BlockedChild child;
+  const pid_t pid = child.pid();
+  FileDescriptor pidfd = ASSERT_NO_ERRNO_AND_VALUE(OpenPidfd(child.pid()));
+
+  struct pollfd pfd = {.fd = pidfd.get(), .events = POLLIN};
+  EXPECT_THAT(poll(&pfd, 1, 0), SyscallSucceedsWithValue(0));
+
+  child.Release();
+  ASSERT_THAT(RetryEINTR(poll)(&pfd, 1, -1), SyscallSucceedsWithValue(1));
+  EXPECT_EQ(pfd.revents & POLLIN, POLLIN);
+
+  // Polling doesn't reap the child, so it is still waitable.
+  siginfo_t info = {};
+  ASSERT_THAT(waitid(static_cast<idtype_t>(P_PIDFD), pidfd.get(), &info,
+                     WEXITED),
+              SyscallSucceeds());
+  child.Reaped();
+  EXPECT_EQ(info.si_signo, SIGCHLD);
+  EXPECT_EQ(info.si_code, CLD_EXITED);
+  EXPECT_EQ(info.si_pid, pid);
+  EXPECT_EQ(info.si_status, BlockedChild::kExitCode);
+}
+
+TEST(PidfdTest, WaitidNonblock) {
+  BlockedChild child;
+  FileDescriptor pidfd =
+      ASSERT_NO_ERRNO_AND_VALUE(OpenPidfd(child.pid(), PIDFD_NONBLOCK));
+
+  siginfo_t info = {};
+  EXPECT_THAT(waitid(static_cast<idtype_t>(P_PIDFD), pidfd.get(), &info,
+                     WEXITED),
+              SyscallFailsWithErrno(EAGAIN));
+  // With WNOHANG the same condition is reported as success.
+  EXPECT_THAT(waitid(static_cast<idtype_t>(P_PIDFD), pidfd.get(), &info,
+                     WEXITED | WNOHANG),
+              SyscallSucceeds());
+  EXPECT_EQ(info.si_pid, 0);
+}
+
+TEST(PidfdTest, WaitidNotPidfd) {
+  siginfo_t info = {};
+  FileDescriptor fd = ASSERT_NO_ERRNO_AND_VALUE(Open("/dev/null", O_RDONLY));
+  EXPECT_THAT(waitid(static_cast<idtype_t>(P_PIDFD), fd.get(), &info,
+                     WEXITED),
+              SyscallFailsWithErrno(EBADF));
+}
+
+TEST(PidfdTest, WaitidNotChild) {
+  FileDescriptor pidfd = ASSERT_NO_ERRNO_AND_VALUE(OpenPidfd(getpid()));
+  siginfo_t info = {};
+  EXPECT_THAT(waitid(static_cast<idtype_t>(P_PIDFD), pidfd.get(), &info,
+                     WEXITED | WNOHANG),
+              SyscallFailsWithErrno(ECHILD));
+}
+
+TEST(PidfdTest, SendSignal) {
+  BlockedChild child;
+  FileDescriptor pidfd = ASSERT_NO_ERRNO_AND_VALUE(OpenPidfd(child.pid()));
+
+  // Signal 0 only checks for existence and permission.
+  ASSERT_THAT(pidfd_send_signal(pidfd.get(), 0, nullptr, 0),
+              SyscallSucceeds());
+  EXPECT_THAT(pidfd_send_signal(pidfd.get(), SIGKILL, nullptr, 1),
+              SyscallFailsWithErrno(EINVAL));
+  ASSERT_THAT(pidfd_send_signal(pidfd.get(), SIGKILL, nullptr, 0),
+              SyscallSucceeds());
+
+  siginfo_t info = {};
+  ASSERT_THAT(waitid(static_cast<idtype_t>(P_PIDFD), pidfd.get(), &info,
+                     WEXITED),
+              SyscallSucceeds());
+  child.Reaped();
+  EXPECT_EQ(info.si_code, CLD_KILLED);
+  EXPECT_EQ(info.si_status, SIGKILL);
+
+  // The process is gone; the pidfd remains readable but can no longer be
+  // signaled.
+  EXPECT_THAT(pidfd_send_signal(pidfd.get(), SIGKILL, nullptr, 0),
+              SyscallFailsWithErrno(ESRCH));
+  struct pollfd pfd = {.fd = pidfd.get(), .events = POLLIN};
+  ASSERT_THAT(poll(&pfd, 1, 0), SyscallSucceedsWithValue(1));
+  EXPECT_EQ(pfd.revents & POLLIN, POLLIN);
+}
+
+TEST(PidfdTest, SendSignalMismatchedInfo) {
+  FileDescriptor pidfd = ASSERT_NO_ERRNO_AND_VALUE(OpenPidfd(getpid()));
+  siginfo_t info = {};
+  info.si_signo = SIGUSR1;
+  info.si_code = SI_QUEUE;
+  EXPECT_THAT(pidfd_send_signal(pidfd.get(), SIGUSR2, &info, 0),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(PidfdTest, Getfd) {
+  // The child creates a pipe, fills it, and reports the read end's fd number
+  // over report.
+  int report[2];
+  ASSERT_THAT(pipe(report), SyscallSucceeds());
+  int release[2];
+  ASSERT_THAT(pipe(release), SyscallSucceeds());
+  pid_t child = fork();
+  if (child == 0) {
+    close(report[0]);
+    close(release[1]);
+    int fds[2];
+    TEST_PCHECK(pipe(fds) == 0);
+    TEST_PCHECK(write(fds[1], "x", 1) == 1);
+    TEST_PCHECK(write(report[1], &fds[0], sizeof(fds[0])) ==
+                sizeof(fds[0]));
+    char c;
+    TEST_PCHECK(read(release[0], &c, 1) >= 0);
+    _exit(0);
+  }
+  ASSERT_THAT(child, SyscallSucceeds());
+  close(report[1]);
+  close(release[0]);
+  FileDescriptor release_fd(release[1]);
+
+  int targetfd;
+  ASSERT_THAT(ReadFd(report[0], &targetfd, sizeof(targetfd)),
+              SyscallSucceedsWithValue(sizeof(targetfd)));
+  close(report[0]);
+
+  FileDescriptor pidfd = ASSERT_NO_ERRNO_AND_VALUE(OpenPidfd(child));
+  EXPECT_THAT(pidfd_getfd(pidfd.get(), targetfd, 1),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(pidfd_getfd(pidfd.get(), -1, 0), SyscallFailsWithErrno(EBADF));
+  EXPECT_THAT(pidfd_getfd(release_fd.get(), targetfd, 0),
+              SyscallFailsWithErrno(EBADF));
+
+  FileDescriptor stolen =
+      ASSERT_NO_ERRNO_AND_VALUE(GetPidfdFD(pidfd.get(), targetfd));
+  EXPECT_THAT(fcntl(stolen.get(), F_GETFD),
+              SyscallSucceedsWithValue(FD_CLOEXEC));
+  char c;
+  EXPECT_THAT(ReadFd(stolen.get(), &c, 1), SyscallSucceedsWithValue(1));
+  EXPECT_EQ(c, 'x');
+
+  release_fd.reset();
+  int status;
+  ASSERT_THAT(RetryEINTR(waitpid)(child, &status, 0),
+              SyscallSucceedsWithValue(child));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0) << status;
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
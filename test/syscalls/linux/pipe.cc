@@ -23,13 +23,17 @@
 #include <syscall.h>
 #include <unistd.h>
 
+#include <string>
 #include <vector>
 
 #include "gtest/gtest.h"
+#include "absl/strings/ascii.h"
+#include "absl/strings/numbers.h"
 #include "absl/strings/str_cat.h"
 #include "absl/synchronization/notification.h"
 #include "absl/time/clock.h"
 #include "absl/time/time.h"
+#include "test/util/capability_util.h"
 #include "test/util/file_descriptor.h"
 #include "test/util/fs_util.h"
 #include "test/util/posix_error.h"
@@ -639,6 +643,32 @@ TEST_P(PipeTest, SizeChangeFull) {
               SyscallFailsWithErrno(EBUSY));
 }
 
+TEST_P(PipeTest, SizeChangeRoundsToPowerOfTwo) {
+  SKIP_IF(!CreateBlocking());
+
+  const int page_size = getpagesize();
+  ASSERT_THAT(fcntl(wfd_.get(), F_SETPIPE_SZ, 3 * page_size + 1),
+              SyscallSucceedsWithValue(4 * page_size));
+  EXPECT_EQ(Size(), static_cast<size_t>(4 * page_size));
+}
+
+TEST_P(PipeTest, SizeChangeAboveMaxSize) {
+  SKIP_IF(!CreateBlocking());
+
+  std::string contents =
+      ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/sys/fs/pipe-max-size"));
+  int max_size;
+  ASSERT_TRUE(absl::SimpleAtoi(absl::StripAsciiWhitespace(contents),
+                               &max_size));
+
+  // Without CAP_SYS_RESOURCE, the pipe can't grow beyond pipe-max-size.
+  AutoCapability cap(CAP_SYS_RESOURCE, false);
+  EXPECT_THAT(fcntl(wfd_.get(), F_SETPIPE_SZ, 2 * max_size),
+              SyscallFailsWithErrno(EPERM));
+  EXPECT_THAT(fcntl(wfd_.get(), F_SETPIPE_SZ, max_size),
+              SyscallSucceedsWithValue(max_size));
+}
+
 TEST_P(PipeTest, Streaming) {
   SKIP_IF(!CreateBlocking());
 
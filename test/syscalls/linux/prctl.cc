@@ -12,27 +12,49 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+#include <fcntl.h>
+#include <linux/capability.h>
+#include <signal.h>
+#include <sys/auxv.h>
 #include <sys/prctl.h>
 #include <sys/ptrace.h>
+#include <sys/stat.h>
+#include <sys/statvfs.h>
+#include <sys/syscall.h>
 #include <sys/types.h>
 #include <sys/wait.h>
+#include <sys/xattr.h>
 #include <unistd.h>
 
 #include <string>
 
 #include "gtest/gtest.h"
 #include "absl/flags/flag.h"
+#include "absl/strings/str_cat.h"
 #include "test/util/capability_util.h"
 #include "test/util/cleanup.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/fs_util.h"
 #include "test/util/multiprocess_util.h"
 #include "test/util/posix_error.h"
 #include "test/util/signal_util.h"
+#include "test/util/temp_path.h"
 #include "test/util/test_util.h"
 #include "test/util/thread_util.h"
 
 ABSL_FLAG(bool, prctl_no_new_privs_test_child, false,
           "If true, exit with the return value of prctl(PR_GET_NO_NEW_PRIVS) "
           "plus an offset (see test source).");
+ABSL_FLAG(bool, prctl_file_caps_test_child, false,
+          "If true, exit with 1 if CAP_NET_RAW is effective or 0 otherwise, "
+          "plus an offset (see test source).");
+ABSL_FLAG(bool, prctl_at_secure_test_child, false,
+          "If true, exit with 1 if AT_SECURE is set, plus 2 if the effective "
+          "UID differs from the real UID, plus 4 if the IDs in the auxiliary "
+          "vector are wrong, plus an offset (see test source).");
+ABSL_FLAG(bool, prctl_thp_disable_test_child, false,
+          "If true, exit with the return value of prctl(PR_GET_THP_DISABLE) "
+          "plus an offset (see test source).");
 
 namespace gvisor {
 namespace testing {
@@ -49,6 +71,33 @@ namespace {
 #define SUID_DUMP_ROOT 2
 #endif /* SUID_DUMP_ROOT */
 
+#ifndef PR_SET_SYSCALL_USER_DISPATCH
+#define PR_SET_SYSCALL_USER_DISPATCH 59
+#define PR_SYS_DISPATCH_OFF 0
+#define PR_SYS_DISPATCH_ON 1
+#define SYSCALL_DISPATCH_FILTER_ALLOW 0
+#define SYSCALL_DISPATCH_FILTER_BLOCK 1
+#endif /* PR_SET_SYSCALL_USER_DISPATCH */
+#ifndef SYS_USER_DISPATCH
+#define SYS_USER_DISPATCH 2
+#endif /* SYS_USER_DISPATCH */
+
+#ifndef PR_SET_IO_FLUSHER
+#define PR_SET_IO_FLUSHER 57
+#define PR_GET_IO_FLUSHER 58
+#endif /* PR_SET_IO_FLUSHER */
+
+#ifndef PR_SCHED_CORE
+#define PR_SCHED_CORE 62
+#define PR_SCHED_CORE_GET 0
+#define PR_SCHED_CORE_CREATE 1
+#define PR_SCHED_CORE_SHARE_TO 2
+#define PR_SCHED_CORE_SHARE_FROM 3
+#define PR_SCHED_CORE_SCOPE_THREAD 0
+#define PR_SCHED_CORE_SCOPE_THREAD_GROUP 1
+#define PR_SCHED_CORE_SCOPE_PROCESS_GROUP 2
+#endif /* PR_SCHED_CORE */
+
 TEST(PrctlTest, NameInitialized) {
   const size_t name_length = 20;
   char name[name_length] = {};
@@ -139,6 +188,180 @@ TEST(PrctlTest, NoNewPrivsPreservedAcrossCloneForkAndExecve) {
               SyscallSucceedsWithValue(no_new_privs));
 }
 
+// Offset added to exit code from file capability test child to distinguish
+// from other abnormal exits.
+constexpr int kPrctlFileCapsTestChildExitBase = 100;
+
+// Executes a copy of this binary carrying CAP_NET_RAW as an effective file
+// capability from an unprivileged child, with or without no_new_privs set.
+// Returns whether CAP_NET_RAW was effective after the execve.
+PosixErrorOr<bool> ExecWithFileCaps(bool no_new_privs) {
+  ASSIGN_OR_RETURN_ERRNO(std::string contents, GetContents("/proc/self/exe"));
+  ASSIGN_OR_RETURN_ERRNO(
+      TempPath exe,
+      TempPath::CreateFileWith(GetAbsoluteTestTmpdir(), contents, 0755));
+  struct vfs_cap_data caps = {};
+  caps.magic_etc = VFS_CAP_REVISION_2 | VFS_CAP_FLAGS_EFFECTIVE;
+  caps.data[CAP_TO_INDEX(CAP_NET_RAW)].permitted = CAP_TO_MASK(CAP_NET_RAW);
+  RETURN_ERROR_IF_SYSCALL_FAIL(setxattr(
+      exe.path().c_str(), "security.capability", &caps, sizeof(caps), 0));
+  // Execute through an FD, since the unprivileged child may not be able to
+  // search the test's temporary directory.
+  ASSIGN_OR_RETURN_ERRNO(FileDescriptor fd, Open(exe.path(), O_RDONLY));
+
+  const std::string path = exe.path();
+  char* const argv[] = {const_cast<char*>(path.c_str()),
+                        const_cast<char*>("--prctl_file_caps_test_child"),
+                        nullptr};
+  char* const envp[] = {nullptr};
+  pid_t child_pid = fork();
+  if (child_pid == 0) {
+    if (no_new_privs) {
+      TEST_PCHECK(prctl(PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0) == 0);
+    }
+    // Switching away from root clears the permitted and effective capability
+    // sets.
+    TEST_PCHECK(syscall(SYS_setresgid, 65534, 65534, 65534) == 0);
+    TEST_PCHECK(syscall(SYS_setresuid, 65534, 65534, 65534) == 0);
+    fexecve(fd.get(), argv, envp);
+    _exit(1);
+  }
+  RETURN_ERROR_IF_SYSCALL_FAIL(child_pid);
+
+  int status;
+  RETURN_ERROR_IF_SYSCALL_FAIL(RetryEINTR(waitpid)(child_pid, &status, 0));
+  if (!WIFEXITED(status) ||
+      WEXITSTATUS(status) < kPrctlFileCapsTestChildExitBase) {
+    return PosixError(ECHILD, absl::StrCat("child status ", status));
+  }
+  return WEXITSTATUS(status) == kPrctlFileCapsTestChildExitBase + 1;
+}
+
+TEST(PrctlTest, FileCapabilitiesRaisedOnExecve) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SETFCAP)) ||
+          !ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SETUID)) ||
+          !ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SETGID)));
+
+  EXPECT_TRUE(ASSERT_NO_ERRNO_AND_VALUE(ExecWithFileCaps(false)));
+}
+
+TEST(PrctlTest, NoNewPrivsPreventsFileCapabilities) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SETFCAP)) ||
+          !ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SETUID)) ||
+          !ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SETGID)));
+
+  EXPECT_FALSE(ASSERT_NO_ERRNO_AND_VALUE(ExecWithFileCaps(true)));
+}
+
+// Offset added to exit code from AT_SECURE test child to distinguish from
+// other abnormal exits.
+constexpr int kPrctlAtSecureTestChildExitBase = 100;
+
+// Bits in the exit code of the AT_SECURE test child, above the offset.
+constexpr int kAtSecureSet = 1;
+constexpr int kEffectiveUIDChanged = 2;
+// Set if the IDs in the auxiliary vector don't match the child's credentials.
+constexpr int kAuxvIDsMismatch = 4;
+
+// Executes a copy of this binary owned by 65533:65533 with the given mode and
+// optionally carrying CAP_NET_RAW as an effective file capability, from a
+// child running as 65534:65534 with or without no_new_privs set. Returns the
+// child's report of AT_SECURE and its effective UID.
+PosixErrorOr<int> ExecAtSecureChild(mode_t mode, bool file_caps,
+                                    bool no_new_privs) {
+  ASSIGN_OR_RETURN_ERRNO(std::string contents, GetContents("/proc/self/exe"));
+  ASSIGN_OR_RETURN_ERRNO(
+      TempPath exe,
+      TempPath::CreateFileWith(GetAbsoluteTestTmpdir(), contents, 0755));
+  RETURN_ERROR_IF_SYSCALL_FAIL(chown(exe.path().c_str(), 65533, 65533));
+  // chown(2) clears the set-user-ID and set-group-ID bits, so set the mode
+  // afterward.
+  RETURN_ERROR_IF_SYSCALL_FAIL(chmod(exe.path().c_str(), mode));
+  if (file_caps) {
+    struct vfs_cap_data caps = {};
+    caps.magic_etc = VFS_CAP_REVISION_2 | VFS_CAP_FLAGS_EFFECTIVE;
+    caps.data[CAP_TO_INDEX(CAP_NET_RAW)].permitted = CAP_TO_MASK(CAP_NET_RAW);
+    RETURN_ERROR_IF_SYSCALL_FAIL(setxattr(
+        exe.path().c_str(), "security.capability", &caps, sizeof(caps), 0));
+  }
+  ASSIGN_OR_RETURN_ERRNO(FileDescriptor fd, Open(exe.path(), O_RDONLY));
+
+  const std::string path = exe.path();
+  char* const argv[] = {const_cast<char*>(path.c_str()),
+                        const_cast<char*>("--prctl_at_secure_test_child"),
+                        nullptr};
+  char* const envp[] = {nullptr};
+  pid_t child_pid = fork();
+  if (child_pid == 0) {
+    if (no_new_privs) {
+      TEST_PCHECK(prctl(PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0) == 0);
+    }
+    TEST_PCHECK(syscall(SYS_setresgid, 65534, 65534, 65534) == 0);
+    TEST_PCHECK(syscall(SYS_setresuid, 65534, 65534, 65534) == 0);
+    fexecve(fd.get(), argv, envp);
+    _exit(1);
+  }
+  RETURN_ERROR_IF_SYSCALL_FAIL(child_pid);
+
+  int status;
+  RETURN_ERROR_IF_SYSCALL_FAIL(RetryEINTR(waitpid)(child_pid, &status, 0));
+  if (!WIFEXITED(status) ||
+      WEXITSTATUS(status) < kPrctlAtSecureTestChildExitBase) {
+    return PosixError(ECHILD, absl::StrCat("child status ", status));
+  }
+  return WEXITSTATUS(status) - kPrctlAtSecureTestChildExitBase;
+}
+
+// Returns true if ExecAtSecureChild can be used.
+PosixErrorOr<bool> CanExecSetID() {
+  for (int cap : {CAP_CHOWN, CAP_FOWNER, CAP_SETUID, CAP_SETGID}) {
+    ASSIGN_OR_RETURN_ERRNO(bool have, HaveCapability(cap));
+    if (!have) {
+      return false;
+    }
+  }
+  // Set-ID bits are ignored on nosuid mounts.
+  struct statvfs st;
+  RETURN_ERROR_IF_SYSCALL_FAIL(statvfs(GetAbsoluteTestTmpdir().c_str(), &st));
+  return (st.f_flag & ST_NOSUID) == 0;
+}
+
+TEST(PrctlTest, PlainExecveDoesNotSetATSecure) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(CanExecSetID()));
+
+  EXPECT_EQ(ASSERT_NO_ERRNO_AND_VALUE(ExecAtSecureChild(0755, false, false)),
+            0);
+}
+
+TEST(PrctlTest, SetUIDExecveSetsATSecure) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(CanExecSetID()));
+
+  EXPECT_EQ(ASSERT_NO_ERRNO_AND_VALUE(ExecAtSecureChild(04755, false, false)),
+            kAtSecureSet | kEffectiveUIDChanged);
+}
+
+TEST(PrctlTest, SetGIDExecveSetsATSecure) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(CanExecSetID()));
+
+  EXPECT_EQ(ASSERT_NO_ERRNO_AND_VALUE(ExecAtSecureChild(02755, false, false)),
+            kAtSecureSet);
+}
+
+TEST(PrctlTest, NoNewPrivsIgnoresSetUID) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(CanExecSetID()));
+
+  EXPECT_EQ(ASSERT_NO_ERRNO_AND_VALUE(ExecAtSecureChild(04755, false, true)),
+            0);
+}
+
+TEST(PrctlTest, FileCapabilitiesSetATSecure) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(CanExecSetID()));
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SETFCAP)));
+
+  EXPECT_EQ(ASSERT_NO_ERRNO_AND_VALUE(ExecAtSecureChild(0755, true, false)),
+            kAtSecureSet);
+}
+
 TEST(PrctlTest, PDeathSig) {
   pid_t child_pid;
 
@@ -307,6 +530,279 @@ TEST(PrctlTest, OrphansReparentedToSubreaper) {
   EXPECT_TRUE(got_sigchild);
 }
 
+// Returns true if the kernel supports PR_SET_SYSCALL_USER_DISPATCH (Linux
+// 5.11+).
+bool SyscallUserDispatchSupported() {
+  if (prctl(PR_SET_SYSCALL_USER_DISPATCH, PR_SYS_DISPATCH_OFF, 0, 0, 0) < 0) {
+    return errno != EINVAL;
+  }
+  return true;
+}
+
+TEST(PrctlTest, SyscallUserDispatchInvalidArgs) {
+  SKIP_IF(!SyscallUserDispatchSupported());
+
+  char selector = SYSCALL_DISPATCH_FILTER_ALLOW;
+  // Disabling dispatch takes no other arguments.
+  EXPECT_THAT(prctl(PR_SET_SYSCALL_USER_DISPATCH, PR_SYS_DISPATCH_OFF, 1, 0, 0),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(prctl(PR_SET_SYSCALL_USER_DISPATCH, PR_SYS_DISPATCH_OFF, 0, 0,
+                    &selector),
+              SyscallFailsWithErrno(EINVAL));
+  // The allowed region must not wrap around.
+  EXPECT_THAT(prctl(PR_SET_SYSCALL_USER_DISPATCH, PR_SYS_DISPATCH_ON,
+                    ~0UL - 1, 4, &selector),
+              SyscallFailsWithErrno(EINVAL));
+  // Unknown modes are rejected.
+  EXPECT_THAT(prctl(PR_SET_SYSCALL_USER_DISPATCH, 2, 0, 0, 0),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+volatile char sud_selector = SYSCALL_DISPATCH_FILTER_ALLOW;
+volatile int sud_signals = 0;
+volatile int sud_code = 0;
+volatile int sud_syscall = 0;
+
+void SyscallUserDispatchHandler(int sig, siginfo_t* info, void* ucontext) {
+  // Allow syscalls again first, so that returning from the handler works.
+  sud_selector = SYSCALL_DISPATCH_FILTER_ALLOW;
+  sud_signals = sud_signals + 1;
+  sud_code = info->si_code;
+  sud_syscall = info->si_syscall;
+}
+
+TEST(PrctlTest, SyscallUserDispatchSelector) {
+  SKIP_IF(!SyscallUserDispatchSupported());
+
+  struct sigaction sa = {};
+  sa.sa_sigaction = SyscallUserDispatchHandler;
+  sa.sa_flags = SA_SIGINFO;
+  auto const sig_cleanup =
+      ASSERT_NO_ERRNO_AND_VALUE(ScopedSigaction(SIGSYS, sa));
+
+  sud_selector = SYSCALL_DISPATCH_FILTER_ALLOW;
+  sud_signals = 0;
+  ASSERT_THAT(prctl(PR_SET_SYSCALL_USER_DISPATCH, PR_SYS_DISPATCH_ON, 0, 0,
+                    &sud_selector),
+              SyscallSucceeds());
+
+  // Syscalls are executed normally while the selector allows them.
+  const pid_t ppid = getppid();
+  EXPECT_EQ(syscall(SYS_getppid), ppid);
+  EXPECT_EQ(sud_signals, 0);
+
+  // No syscalls may be made between blocking and the dispatched syscall. The
+  // syscall is not executed, so the return value register still holds the
+  // syscall number.
+  sud_selector = SYSCALL_DISPATCH_FILTER_BLOCK;
+  const long ret = syscall(SYS_getppid);
+
+  EXPECT_EQ(ret, SYS_getppid);
+  EXPECT_EQ(sud_signals, 1);
+  EXPECT_EQ(sud_code, SYS_USER_DISPATCH);
+  EXPECT_EQ(sud_syscall, SYS_getppid);
+
+  ASSERT_THAT(prctl(PR_SET_SYSCALL_USER_DISPATCH, PR_SYS_DISPATCH_OFF, 0, 0, 0),
+              SyscallSucceeds());
+}
+
+TEST(PrctlTest, SyscallUserDispatchNotInherited) {
+  SKIP_IF(!SyscallUserDispatchSupported());
+
+  static char selector = SYSCALL_DISPATCH_FILTER_ALLOW;
+  ASSERT_THAT(
+      prctl(PR_SET_SYSCALL_USER_DISPATCH, PR_SYS_DISPATCH_ON, 0, 0, &selector),
+      SyscallSucceeds());
+  auto cleanup = Cleanup([] {
+    prctl(PR_SET_SYSCALL_USER_DISPATCH, PR_SYS_DISPATCH_OFF, 0, 0, 0);
+  });
+
+  // Block syscalls in the child only. If dispatch were inherited, the child
+  // would be unable to exit normally.
+  pid_t child_pid = fork();
+  if (child_pid == 0) {
+    selector = SYSCALL_DISPATCH_FILTER_BLOCK;
+    _exit(0);
+  }
+  ASSERT_THAT(child_pid, SyscallSucceeds());
+
+  int status;
+  ASSERT_THAT(RetryEINTR(waitpid)(child_pid, &status, 0),
+              SyscallSucceedsWithValue(child_pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status = " << status;
+}
+
+// Offset added to exit code from THP disable test child to distinguish from
+// other abnormal exits.
+constexpr int kPrctlTHPDisableTestChildExitBase = 100;
+
+TEST(PrctlTest, THPDisable) {
+  const auto rest = [] {
+    TEST_CHECK(prctl(PR_GET_THP_DISABLE, 0, 0, 0, 0) == 0);
+    TEST_CHECK_ERRNO(prctl(PR_SET_THP_DISABLE, 1, 1, 0, 0), EINVAL);
+    TEST_CHECK_ERRNO(prctl(PR_GET_THP_DISABLE, 1, 0, 0, 0), EINVAL);
+
+    TEST_CHECK_SUCCESS(prctl(PR_SET_THP_DISABLE, 1, 0, 0, 0));
+    TEST_CHECK(prctl(PR_GET_THP_DISABLE, 0, 0, 0, 0) == 1);
+    std::string status = TEST_CHECK_NO_ERRNO_AND_VALUE(
+        GetContents("/proc/self/status"));
+    TEST_CHECK(status.find("THP_enabled:\t0\n") != std::string::npos);
+
+    // The flag is inherited by children and preserved across execve.
+    pid_t child_pid = -1;
+    int execve_errno = 0;
+    auto cleanup = TEST_CHECK_NO_ERRNO_AND_VALUE(
+        ForkAndExec("/proc/self/exe",
+                    {"/proc/self/exe", "--prctl_thp_disable_test_child"}, {},
+                    nullptr, &child_pid, &execve_errno));
+    TEST_CHECK(child_pid > 0 && execve_errno == 0);
+    int status_code = 0;
+    TEST_CHECK_SUCCESS(RetryEINTR(waitpid)(child_pid, &status_code, 0));
+    TEST_CHECK(WIFEXITED(status_code) &&
+               WEXITSTATUS(status_code) ==
+                   kPrctlTHPDisableTestChildExitBase + 1);
+
+    TEST_CHECK_SUCCESS(prctl(PR_SET_THP_DISABLE, 0, 0, 0, 0));
+    TEST_CHECK(prctl(PR_GET_THP_DISABLE, 0, 0, 0, 0) == 0);
+  };
+  EXPECT_THAT(InForkedProcess(rest), IsPosixErrorOkAndHolds(0));
+}
+
+TEST(PrctlTest, IOFlusher) {
+  if (!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_RESOURCE))) {
+    EXPECT_THAT(prctl(PR_SET_IO_FLUSHER, 1, 0, 0, 0),
+                SyscallFailsWithErrno(EPERM));
+    EXPECT_THAT(prctl(PR_GET_IO_FLUSHER, 0, 0, 0, 0),
+                SyscallFailsWithErrno(EPERM));
+    return;
+  }
+
+  // The flag is per-thread, so set it on a separate thread.
+  ScopedThread([] {
+    EXPECT_THAT(prctl(PR_GET_IO_FLUSHER, 0, 0, 0, 0),
+                SyscallSucceedsWithValue(0));
+    EXPECT_THAT(prctl(PR_SET_IO_FLUSHER, 2, 0, 0, 0),
+                SyscallFailsWithErrno(EINVAL));
+    ASSERT_THAT(prctl(PR_SET_IO_FLUSHER, 1, 0, 0, 0), SyscallSucceeds());
+    EXPECT_THAT(prctl(PR_GET_IO_FLUSHER, 0, 0, 0, 0),
+                SyscallSucceedsWithValue(1));
+
+    // Threads inherit the flag.
+    ScopedThread([] {
+      EXPECT_THAT(prctl(PR_GET_IO_FLUSHER, 0, 0, 0, 0),
+                  SyscallSucceedsWithValue(1));
+    });
+
+    ASSERT_THAT(prctl(PR_SET_IO_FLUSHER, 0, 0, 0, 0), SyscallSucceeds());
+    EXPECT_THAT(prctl(PR_GET_IO_FLUSHER, 0, 0, 0, 0),
+                SyscallSucceedsWithValue(0));
+  });
+}
+
+// Returns the core scheduling cookie of the thread tid.
+PosixErrorOr<uint64_t> GetCoreSchedCookie(pid_t tid) {
+  uint64_t cookie = 0;
+  RETURN_ERROR_IF_SYSCALL_FAIL(prctl(PR_SCHED_CORE, PR_SCHED_CORE_GET, tid,
+                                     PR_SCHED_CORE_SCOPE_THREAD, &cookie));
+  return cookie;
+}
+
+// Returns true if core scheduling is available. On Linux, it requires SMT.
+bool CoreSchedSupported() {
+  uint64_t cookie;
+  return prctl(PR_SCHED_CORE, PR_SCHED_CORE_GET, 0, PR_SCHED_CORE_SCOPE_THREAD,
+               &cookie) == 0;
+}
+
+TEST(PrctlTest, SchedCoreInvalidArgs) {
+  SKIP_IF(!CoreSchedSupported());
+
+  uint64_t cookie;
+  EXPECT_THAT(prctl(PR_SCHED_CORE, 4, 0, PR_SCHED_CORE_SCOPE_THREAD, 0),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(prctl(PR_SCHED_CORE, PR_SCHED_CORE_CREATE, 0, 3, 0),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(prctl(PR_SCHED_CORE, PR_SCHED_CORE_CREATE, 0,
+                    PR_SCHED_CORE_SCOPE_THREAD, &cookie),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(prctl(PR_SCHED_CORE, PR_SCHED_CORE_GET, 0,
+                    PR_SCHED_CORE_SCOPE_THREAD_GROUP, &cookie),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(prctl(PR_SCHED_CORE, PR_SCHED_CORE_GET, -1,
+                    PR_SCHED_CORE_SCOPE_THREAD, &cookie),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(PrctlTest, SchedCore) {
+  SKIP_IF(!CoreSchedSupported());
+
+  const auto rest = [] {
+    TEST_CHECK(TEST_CHECK_NO_ERRNO_AND_VALUE(GetCoreSchedCookie(0)) == 0);
+
+    // Create a cookie for the whole thread group, which is shared with new
+    // threads and children.
+    TEST_CHECK_SUCCESS(prctl(PR_SCHED_CORE, PR_SCHED_CORE_CREATE, 0,
+                             PR_SCHED_CORE_SCOPE_THREAD_GROUP, 0));
+    const uint64_t cookie =
+        TEST_CHECK_NO_ERRNO_AND_VALUE(GetCoreSchedCookie(0));
+    TEST_CHECK(cookie != 0);
+    ScopedThread([cookie] {
+      TEST_CHECK(TEST_CHECK_NO_ERRNO_AND_VALUE(GetCoreSchedCookie(0)) ==
+                 cookie);
+
+      // A new cookie for this thread only.
+      TEST_CHECK_SUCCESS(prctl(PR_SCHED_CORE, PR_SCHED_CORE_CREATE, 0,
+                               PR_SCHED_CORE_SCOPE_THREAD, 0));
+      const uint64_t thread_cookie =
+          TEST_CHECK_NO_ERRNO_AND_VALUE(GetCoreSchedCookie(0));
+      TEST_CHECK(thread_cookie != 0 && thread_cookie != cookie);
+
+      // Take the cookie of the thread group leader back.
+      TEST_CHECK_SUCCESS(prctl(PR_SCHED_CORE, PR_SCHED_CORE_SHARE_FROM,
+                               getpid(), PR_SCHED_CORE_SCOPE_THREAD, 0));
+      TEST_CHECK(TEST_CHECK_NO_ERRNO_AND_VALUE(GetCoreSchedCookie(0)) ==
+                 cookie);
+    });
+
+    pid_t child_pid = fork();
+    if (child_pid == 0) {
+      TEST_CHECK(TEST_CHECK_NO_ERRNO_AND_VALUE(GetCoreSchedCookie(0)) ==
+                 cookie);
+      _exit(0);
+    }
+    TEST_CHECK_SUCCESS(child_pid);
+    int status;
+    TEST_CHECK_SUCCESS(RetryEINTR(waitpid)(child_pid, &status, 0));
+    TEST_CHECK(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+
+    // Clear the cookie of another process by sharing a cookie-less thread's
+    // cookie to it.
+    child_pid = fork();
+    if (child_pid == 0) {
+      while (true) {
+        pause();
+      }
+    }
+    TEST_CHECK_SUCCESS(child_pid);
+    TEST_CHECK(TEST_CHECK_NO_ERRNO_AND_VALUE(GetCoreSchedCookie(child_pid)) ==
+               cookie);
+    ScopedThread([child_pid] {
+      TEST_CHECK_SUCCESS(prctl(PR_SCHED_CORE, PR_SCHED_CORE_CREATE, 0,
+                               PR_SCHED_CORE_SCOPE_THREAD, 0));
+      TEST_CHECK_SUCCESS(prctl(PR_SCHED_CORE, PR_SCHED_CORE_SHARE_TO,
+                               child_pid, PR_SCHED_CORE_SCOPE_THREAD_GROUP, 0));
+      const uint64_t thread_cookie =
+          TEST_CHECK_NO_ERRNO_AND_VALUE(GetCoreSchedCookie(0));
+      TEST_CHECK(TEST_CHECK_NO_ERRNO_AND_VALUE(GetCoreSchedCookie(
+                     child_pid)) == thread_cookie);
+    });
+    TEST_CHECK_SUCCESS(kill(child_pid, SIGKILL));
+    TEST_CHECK_SUCCESS(RetryEINTR(waitpid)(child_pid, &status, 0));
+  };
+  EXPECT_THAT(InForkedProcess(rest), IsPosixErrorOkAndHolds(0));
+}
+
 }  // namespace
 
 }  // namespace testing
@@ -320,5 +816,27 @@ int main(int argc, char** argv) {
          prctl(PR_GET_NO_NEW_PRIVS, 0, 0, 0, 0));
   }
 
+  if (absl::GetFlag(FLAGS_prctl_file_caps_test_child)) {
+    auto have = gvisor::testing::HaveCapability(CAP_NET_RAW);
+    exit(gvisor::testing::kPrctlFileCapsTestChildExitBase +
+         (have.ok() && have.ValueOrDie() ? 1 : 0));
+  }
+
+  if (absl::GetFlag(FLAGS_prctl_at_secure_test_child)) {
+    exit(gvisor::testing::kPrctlAtSecureTestChildExitBase +
+         (getauxval(AT_SECURE) ? gvisor::testing::kAtSecureSet : 0) +
+         (geteuid() != getuid() ? gvisor::testing::kEffectiveUIDChanged : 0) +
+         (getauxval(AT_UID) != getuid() || getauxval(AT_EUID) != geteuid() ||
+                  getauxval(AT_GID) != getgid() ||
+                  getauxval(AT_EGID) != getegid()
+              ? gvisor::testing::kAuxvIDsMismatch
+              : 0));
+  }
+
+  if (absl::GetFlag(FLAGS_prctl_thp_disable_test_child)) {
+    exit(gvisor::testing::kPrctlTHPDisableTestChildExitBase +
+         prctl(PR_GET_THP_DISABLE, 0, 0, 0, 0));
+  }
+
   return gvisor::testing::RunAllTests();
 }
@@ -47,6 +47,10 @@ namespace {
 #define RWF_HIPRI 0x1
 #endif  // RWF_HIPRI
 
+#ifndef RWF_NOWAIT
+#define RWF_NOWAIT 0x8
+#endif  // RWF_NOWAIT
+
 constexpr int kBufSize = 1024;
 
 std::string SetContent() {
@@ -172,6 +176,59 @@ TEST(Preadv2Test, TestCallWithRWF_HIPRI) {
 
   EXPECT_EQ(content, std::string(buf.data(), buf.size()));
 }
+// This test reads cached file data with RWF_NOWAIT.
+TEST(Preadv2Test, TestCallWithRWF_NOWAIT) {
+  SKIP_IF(preadv2(-1, nullptr, 0, 0, 0) < 0 && errno == ENOSYS);
+
+  std::string content = SetContent();
+
+  const TempPath file = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateFileWith(
+      GetAbsoluteTestTmpdir(), content, TempPath::kDefaultFileMode));
+  const FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open(file.path(), O_RDONLY));
+
+  std::vector<char> buf(kBufSize, '0');
+  struct iovec iov;
+  iov.iov_base = buf.data();
+  iov.iov_len = buf.size();
+
+  // Populate the page cache.
+  ASSERT_THAT(
+      preadv2(fd.get(), &iov, /*iovcnt=*/1, /*offset=*/0, /*flags=*/0),
+      SyscallSucceedsWithValue(kBufSize));
+  buf.assign(kBufSize, '0');
+
+  int ret =
+      preadv2(fd.get(), &iov, /*iovcnt=*/1, /*offset=*/0, /*flags=*/RWF_NOWAIT);
+  // Not all filesystems support RWF_NOWAIT.
+  SKIP_IF(ret < 0 && errno == EOPNOTSUPP);
+  ASSERT_THAT(ret, SyscallSucceedsWithValue(kBufSize));
+  EXPECT_EQ(content, std::string(buf.data(), buf.size()));
+}
+
+// This test reads from an empty pipe with RWF_NOWAIT.
+TEST(Preadv2Test, TestRWF_NOWAITEmptyPipe) {
+  SKIP_IF(preadv2(-1, nullptr, 0, 0, 0) < 0 && errno == ENOSYS);
+
+  int pipe_fds[2];
+  ASSERT_THAT(pipe(pipe_fds), SyscallSucceeds());
+  const FileDescriptor rfd(pipe_fds[0]);
+  const FileDescriptor wfd(pipe_fds[1]);
+
+  char c;
+  struct iovec iov;
+  iov.iov_base = &c;
+  iov.iov_len = 1;
+
+  int ret = preadv2(rfd.get(), &iov, /*iovcnt=*/1,
+                    /*offset=*/static_cast<off_t>(-1), /*flags=*/RWF_NOWAIT);
+  int err = errno;
+  // Older kernels don't support RWF_NOWAIT for pipes.
+  SKIP_IF(ret < 0 && err == EOPNOTSUPP);
+  EXPECT_EQ(ret, -1);
+  EXPECT_EQ(err, EAGAIN);
+}
+
 // This test calls preadv2 with an invalid flag.
 TEST(Preadv2Test, TestInvalidFlag) {
   SKIP_IF(preadv2(-1, nullptr, 0, 0, 0) < 0 && errno == ENOSYS);
@@ -28,6 +28,7 @@
 #include <sys/prctl.h>
 #include <sys/ptrace.h>
 #include <sys/stat.h>
+#include <sys/auxv.h>
 #include <sys/statfs.h>
 #include <sys/utsname.h>
 #include <syscall.h>
@@ -55,6 +56,7 @@
 #include "absl/container/node_hash_set.h"
 #include "absl/flags/flag.h"
 #include "absl/strings/ascii.h"
+#include "absl/strings/escaping.h"
 #include "absl/strings/match.h"
 #include "absl/strings/numbers.h"
 #include "absl/strings/str_cat.h"
@@ -528,6 +530,10 @@ TEST(ProcSelfAuxv, EntryPresence) {
   EXPECT_EQ(auxv_entries.count(AT_EXECFN), 1);
   EXPECT_EQ(auxv_entries.count(AT_PAGESZ), 1);
   EXPECT_EQ(auxv_entries.count(AT_SYSINFO_EHDR), 1);
+  if (IsRunningOnGvisor()) {
+    // Not provided by older Linux kernels.
+    EXPECT_EQ(auxv_entries.count(51 /* AT_MINSIGSTKSZ */), 1);
+  }
 }
 
 TEST(ProcSelfAuxv, EntryValues) {
@@ -553,6 +559,49 @@ TEST(ProcSelfAuxv, EntryValues) {
   EXPECT_EQ(i, proc_auxv.size());
 }
 
+// Returns the hex-encoded NT_GNU_BUILD_ID note of the running executable,
+// found through its program headers in memory, or an empty string if it has
+// none.
+std::string SelfBuildID() {
+  const auto* phdrs = reinterpret_cast<const Elf64_Phdr*>(getauxval(AT_PHDR));
+  const size_t phnum = getauxval(AT_PHNUM);
+  uintptr_t bias = 0;
+  for (size_t i = 0; i < phnum; i++) {
+    if (phdrs[i].p_type == PT_PHDR) {
+      bias = reinterpret_cast<uintptr_t>(phdrs) - phdrs[i].p_vaddr;
+    }
+  }
+  for (size_t i = 0; i < phnum; i++) {
+    if (phdrs[i].p_type != PT_NOTE) {
+      continue;
+    }
+    const char* note = reinterpret_cast<const char*>(bias + phdrs[i].p_vaddr);
+    const char* end = note + phdrs[i].p_filesz;
+    while (note + sizeof(Elf64_Nhdr) <= end) {
+      const auto* nhdr = reinterpret_cast<const Elf64_Nhdr*>(note);
+      const char* name = note + sizeof(Elf64_Nhdr);
+      const char* desc = name + ((nhdr->n_namesz + 3) & ~3);
+      if (nhdr->n_type == NT_GNU_BUILD_ID && nhdr->n_namesz == 4 &&
+          memcmp(name, "GNU", 4) == 0) {
+        return absl::StrCat(
+            absl::BytesToHexString(absl::string_view(desc, nhdr->n_descsz)),
+            "\n");
+      }
+      note = desc + ((nhdr->n_descsz + 3) & ~3);
+    }
+  }
+  return "";
+}
+
+TEST(ProcPidBuildID, MatchesExecutable) {
+  // /proc/[pid]/build_id is specific to gVisor.
+  SKIP_IF(!IsRunningOnGvisor());
+
+  std::string build_id =
+      ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/self/build_id"));
+  EXPECT_EQ(build_id, SelfBuildID());
+}
+
 // Just open and read a part of /proc/self/mem, check that we can read an item.
 TEST(ProcPidMem, Read) {
   auto memfd = ASSERT_NO_ERRNO_AND_VALUE(Open("/proc/self/mem", O_RDONLY));
@@ -1154,6 +1203,43 @@ TEST(ProcSelfMaps, Mprotect) {
                                                    3 * kPageSize, PROT_READ)));
 }
 
+TEST(ProcSelfMaps, ManyVMAsSmallReads) {
+  // Create kNumVMAs single-page VMAs by alternating permissions within a
+  // reservation that begins and ends with PROT_NONE pages.
+  constexpr int kNumVMAs = 2000;
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon((2 * kNumVMAs + 1) * kPageSize, PROT_NONE, MAP_PRIVATE));
+  for (int i = 0; i < kNumVMAs; i++) {
+    ASSERT_THAT(mprotect(reinterpret_cast<void*>(m.addr() +
+                                                 (2 * i + 1) * kPageSize),
+                         kPageSize, PROT_READ),
+                SyscallSucceeds());
+  }
+
+  // Read the file using reads much smaller than the file, which must still
+  // produce every entry exactly once.
+  const FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open("/proc/self/maps", O_RDONLY));
+  std::string contents;
+  char buf[100];
+  int n;
+  while ((n = read(fd.get(), buf, sizeof(buf))) > 0) {
+    contents.append(buf, n);
+  }
+  ASSERT_THAT(n, SyscallSucceeds());
+
+  std::vector<std::string> strings = absl::StrSplit(contents, '\n');
+  absl::flat_hash_set<std::string> lines;
+  for (const auto& s : strings) {
+    EXPECT_TRUE(s.empty() || lines.insert(s).second) << "duplicate: " << s;
+  }
+  for (int i = 0; i < kNumVMAs; i++) {
+    EXPECT_TRUE(lines.contains(AnonymousMapsEntry(
+        m.addr() + (2 * i + 1) * kPageSize, kPageSize, PROT_READ)))
+        << "missing VMA " << i;
+  }
+}
+
 // Expected pathname for MAP_SHARED | MAP_ANONYMOUS mappings. See proc(5),
 // "/proc/[pid]/map_files/".
 constexpr char kSharedAnonPath[] = "/dev/zero (deleted)";
@@ -1240,6 +1326,84 @@ TEST(ProcSelfMaps, AnonNameSharedAnon) {
   EXPECT_EQ(entry.filename, kSharedAnonPath);
 }
 
+TEST(ProcSelfMaps, AnonNameInvalid) {
+  const Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ, MAP_PRIVATE | MAP_ANONYMOUS));
+
+  int rv = prctl(PR_SET_VMA, PR_SET_VMA_ANON_NAME, m.addr(), m.len(), "test");
+  SKIP_IF(rv < 0 && errno == EINVAL);
+  ASSERT_THAT(rv, SyscallSucceeds());
+
+  // ANON_VMA_NAME_MAX_LEN (80) includes the terminating NUL.
+  const std::string longest(79, 'a');
+  EXPECT_THAT(prctl(PR_SET_VMA, PR_SET_VMA_ANON_NAME, m.addr(), m.len(),
+                    longest.c_str()),
+              SyscallSucceeds());
+  const std::string too_long(80, 'a');
+  EXPECT_THAT(prctl(PR_SET_VMA, PR_SET_VMA_ANON_NAME, m.addr(), m.len(),
+                    too_long.c_str()),
+              SyscallFailsWithErrno(EINVAL));
+
+  for (const char* name : {"a[b", "a]b", "a$b", "a\\b", "a`b", "a\nb"}) {
+    EXPECT_THAT(
+        prctl(PR_SET_VMA, PR_SET_VMA_ANON_NAME, m.addr(), m.len(), name),
+        SyscallFailsWithErrno(EINVAL))
+        << name;
+  }
+
+  auto proc_self_maps =
+      ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/self/maps"));
+  auto entries = ASSERT_NO_ERRNO_AND_VALUE(ParseProcMaps(proc_self_maps));
+  auto entry =
+      ASSERT_NO_ERRNO_AND_VALUE(FindUniqueMapsEntry(entries, m.addr()));
+  EXPECT_EQ(entry.filename, absl::StrCat("[anon:", longest, "]"));
+}
+
+TEST(ProcSelfMaps, AnonNamePartial) {
+  const Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(3 * kPageSize, PROT_READ, MAP_PRIVATE | MAP_ANONYMOUS));
+  const uintptr_t middle = m.addr() + kPageSize;
+
+  int rv = prctl(PR_SET_VMA, PR_SET_VMA_ANON_NAME, middle, kPageSize, "test");
+  SKIP_IF(rv < 0 && errno == EINVAL);
+  ASSERT_THAT(rv, SyscallSucceeds());
+
+  auto proc_self_maps =
+      ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/self/maps"));
+  auto entries = ASSERT_NO_ERRNO_AND_VALUE(ParseProcMaps(proc_self_maps));
+  auto entry = ASSERT_NO_ERRNO_AND_VALUE(FindUniqueMapsEntry(entries, middle));
+  EXPECT_EQ(entry.start, middle);
+  EXPECT_EQ(entry.end, middle + kPageSize);
+  EXPECT_EQ(entry.filename, "[anon:test]");
+  entry = ASSERT_NO_ERRNO_AND_VALUE(
+      FindUniqueMapsEntry(entries, middle + kPageSize));
+  EXPECT_NE(entry.filename, "[anon:test]");
+
+  // Naming the rest of the mapping identically merges it back together.
+  ASSERT_THAT(
+      prctl(PR_SET_VMA, PR_SET_VMA_ANON_NAME, m.addr(), m.len(), "test"),
+      SyscallSucceeds());
+  proc_self_maps = ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/self/maps"));
+  entries = ASSERT_NO_ERRNO_AND_VALUE(ParseProcMaps(proc_self_maps));
+  entry = ASSERT_NO_ERRNO_AND_VALUE(FindUniqueMapsEntry(entries, middle));
+  EXPECT_LE(entry.start, m.addr());
+  EXPECT_GE(entry.end, m.endaddr());
+  EXPECT_EQ(entry.filename, "[anon:test]");
+}
+
+TEST(ProcSelfMaps, AnonNameUnmapped) {
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ, MAP_PRIVATE | MAP_ANONYMOUS));
+  const uintptr_t addr = m.addr();
+  const size_t len = m.len();
+  int rv = prctl(PR_SET_VMA, PR_SET_VMA_ANON_NAME, addr, len, "test");
+  SKIP_IF(rv < 0 && errno == EINVAL);
+  ASSERT_THAT(rv, SyscallSucceeds());
+  m.reset();
+  EXPECT_THAT(prctl(PR_SET_VMA, PR_SET_VMA_ANON_NAME, addr, len, "test"),
+              SyscallFailsWithErrno(ENOMEM));
+}
+
 // Test parameterized by mmap flags.
 class ProcSelfMapsMmapFileTest : public ::testing::TestWithParam<int> {};
 
@@ -2745,6 +2909,37 @@ TEST(ProcSysVmOvercommitMemory, HasNumericValue) {
       << overcommit_memory;
 }
 
+TEST(ProcSysVmDropCaches, Write) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN)));
+
+  EXPECT_NO_ERRNO(SetContents("/proc/sys/vm/drop_caches", "2"));
+  EXPECT_THAT(SetContents("/proc/sys/vm/drop_caches", "0"),
+              PosixErrorIs(EINVAL, ::testing::_));
+  EXPECT_THAT(SetContents("/proc/sys/vm/drop_caches", "5"),
+              PosixErrorIs(EINVAL, ::testing::_));
+}
+
+TEST(ProcSysKernelRandom, EntropyAvail) {
+  const std::string entropy_avail_str = ASSERT_NO_ERRNO_AND_VALUE(
+      GetContents("/proc/sys/kernel/random/entropy_avail"));
+  int entropy_avail;
+  ASSERT_TRUE(absl::SimpleAtoi(entropy_avail_str, &entropy_avail))
+      << "/proc/sys/kernel/random/entropy_avail does not contain a numeric "
+         "value: "
+      << entropy_avail_str;
+  EXPECT_GT(entropy_avail, 0);
+}
+
+TEST(ProcSysKernelRandom, EntropySource) {
+  // entropy_source is specific to gVisor.
+  SKIP_IF(!IsRunningOnGvisor());
+
+  const std::string entropy_source = ASSERT_NO_ERRNO_AND_VALUE(
+      GetContents("/proc/sys/kernel/random/entropy_source"));
+  EXPECT_THAT(entropy_source,
+              AnyOf(Eq("getrandom\n"), Eq("drbg\n"), Eq("hwrng\n")));
+}
+
 // Check that link for proc fd entries point the target node, not the
 // symlink itself. Regression test for b/31155070.
 TEST(ProcTaskFd, FstatatFollowsSymlink) {
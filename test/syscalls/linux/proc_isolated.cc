@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+#include <fcntl.h>
+#include <linux/capability.h>
 #include <linux/msg.h>
 #include <linux/sem.h>
 #include <linux/shm.h>
@@ -19,6 +21,8 @@
 #include "gtest/gtest.h"
 #include "absl/strings/numbers.h"
 #include "absl/strings/str_split.h"
+#include "test/util/capability_util.h"
+#include "test/util/file_descriptor.h"
 #include "test/util/fs_util.h"
 #include "test/util/test_util.h"
 
@@ -95,6 +99,28 @@ TEST(ProcDefaults, PresenceOfMsgMniMaxMnb) {
   ASSERT_EQ(msgmnb, MSGMNB);
 }
 
+// Full address space layout randomization, including the heap, is enabled by
+// default.
+TEST(ProcDefaults, RandomizeVASpace) {
+  int randomize_va_space = 0;
+  std::string proc_file = ASSERT_NO_ERRNO_AND_VALUE(
+      GetContents("/proc/sys/kernel/randomize_va_space"));
+  ASSERT_TRUE(absl::SimpleAtoi(proc_file, &randomize_va_space));
+  EXPECT_EQ(randomize_va_space, 2);
+}
+
+// randomize_va_space affects every container in the sandbox, so changing it
+// requires CAP_SYS_ADMIN.
+TEST(ProcDefaults, RandomizeVASpaceRequiresCapSysAdmin) {
+  SKIP_IF(!IsRunningOnGvisor());
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN)));
+
+  AutoCapability cap(CAP_SYS_ADMIN, false);
+  FileDescriptor fd = ASSERT_NO_ERRNO_AND_VALUE(
+      Open("/proc/sys/kernel/randomize_va_space", O_WRONLY));
+  EXPECT_THAT(WriteFd(fd.get(), "0", 1), SyscallFailsWithErrno(EPERM));
+}
+
 }  // namespace
 }  // namespace testing
 }  // namespace gvisor
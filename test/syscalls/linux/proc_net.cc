@@ -29,6 +29,7 @@
 #include "absl/strings/string_view.h"
 #include "absl/time/clock.h"
 #include "test/util/capability_util.h"
+#include "test/util/cleanup.h"
 #include "test/util/file_descriptor.h"
 #include "test/util/fs_util.h"
 #include "test/util/socket_util.h"
@@ -662,6 +663,38 @@ TEST(ProcSysNetPortRange, CanReadAndWrite) {
   EXPECT_EQ(min + kSize, max);
 }
 
+constexpr char kUseTempAddr[] = "/proc/sys/net/ipv6/conf/default/use_tempaddr";
+constexpr char kTempValidLft[] =
+    "/proc/sys/net/ipv6/conf/default/temp_valid_lft";
+
+TEST(ProcSysNetIpv6Conf, TempValidLifetime) {
+  SKIP_IF(access(kTempValidLft, F_OK) != 0);
+
+  std::string contents = ASSERT_NO_ERRNO_AND_VALUE(GetContents(kTempValidLft));
+  ASSERT_EQ(contents.back(), '\n');
+  contents.pop_back();
+  int lifetime;
+  ASSERT_TRUE(absl::SimpleAtoi(contents, &lifetime));
+  EXPECT_GT(lifetime, 0);
+}
+
+TEST(ProcSysNetIpv6Conf, UseTempAddrCanReadAndWrite) {
+  SKIP_IF(access(kUseTempAddr, F_OK) != 0);
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability((CAP_NET_ADMIN))) ||
+          IsRunningWithHostinet());
+
+  const std::string initial =
+      ASSERT_NO_ERRNO_AND_VALUE(GetContents(kUseTempAddr));
+  auto cleanup = Cleanup(
+      [&] { EXPECT_NO_ERRNO(SetContents(kUseTempAddr, initial)); });
+
+  for (const char* value : {"0", "1", "2"}) {
+    ASSERT_NO_ERRNO(SetContents(kUseTempAddr, value));
+    EXPECT_EQ(ASSERT_NO_ERRNO_AND_VALUE(GetContents(kUseTempAddr)),
+              absl::StrCat(value, "\n"));
+  }
+}
+
 }  // namespace
 }  // namespace testing
 }  // namespace gvisor
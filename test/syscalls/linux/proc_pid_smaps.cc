@@ -12,8 +12,10 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+#include <errno.h>
 #include <stddef.h>
 #include <stdint.h>
+#include <sys/prctl.h>
 
 #include <algorithm>
 #include <iostream>
@@ -118,6 +120,27 @@ TEST(ProcPidSmapsTest, PrivateAnon) {
   }
 }
 
+#ifndef PR_SET_VMA
+#define PR_SET_VMA 0x53564d41
+#endif
+#ifndef PR_SET_VMA_ANON_NAME
+#define PR_SET_VMA_ANON_NAME 0
+#endif
+
+TEST(ProcPidSmapsTest, AnonName) {
+  Mapping const m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(2 * kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  int rv = prctl(PR_SET_VMA, PR_SET_VMA_ANON_NAME, m.addr(), m.len(), "test");
+  SKIP_IF(rv < 0 && errno == EINVAL);
+  ASSERT_THAT(rv, SyscallSucceeds());
+
+  auto const entries = ASSERT_NO_ERRNO_AND_VALUE(ReadProcSelfSmaps());
+  auto const entry =
+      ASSERT_NO_ERRNO_AND_VALUE(FindUniqueSmapsEntry(entries, m.addr()));
+  EXPECT_EQ(entry.maps_entry.filename, "[anon:test]");
+  EXPECT_EQ(entry.size_kb, m.len() / 1024);
+}
+
 TEST(ProcPidSmapsTest, SharedReadOnlyFile) {
   size_t const kFileSize = kPageSize;
 
@@ -12,9 +12,14 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+#include <errno.h>
+#include <fcntl.h>
+#include <sched.h>
 #include <stdint.h>
+#include <stdio.h>
 #include <sys/mman.h>
 #include <sys/syscall.h>
+#include <sys/wait.h>
 #include <unistd.h>
 
 #include <algorithm>
@@ -380,6 +385,114 @@ TEST(Processes, ExecSwapThreadGroupLeader) {
   EXPECT_EQ(result.post_exec_tid, result.pre_clone_tid);
 }
 
+TEST(Processes, NSLastPIDSetsNextPID) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN)));
+
+  pid_t const pid = fork();
+  if (pid == 0) {
+    // Use a new PID namespace so that no other process allocates PIDs.
+    TEST_PCHECK(unshare(CLONE_NEWPID) == 0);
+    pid_t const init = fork();
+    if (init == 0) {
+      int const fd = open("/proc/sys/kernel/ns_last_pid", O_RDWR);
+      TEST_PCHECK(fd >= 0);
+      TEST_PCHECK(write(fd, "1000", 4) == 4);
+      pid_t const child = fork();
+      if (child == 0) {
+        _exit(0);
+      }
+      TEST_CHECK(child == 1001);
+      int status;
+      TEST_PCHECK(waitpid(child, &status, 0) == child);
+      char buf[16] = {};
+      TEST_PCHECK(pread(fd, buf, sizeof(buf) - 1, 0) > 0);
+      TEST_CHECK(strcmp(buf, "1001\n") == 0);
+      _exit(0);
+    }
+    TEST_PCHECK(init > 0);
+    int status;
+    TEST_PCHECK(waitpid(init, &status, 0) == init);
+    TEST_CHECK(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
+TEST(Processes, PIDNamespaceNestingIsLimited) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN)));
+
+  pid_t const pid = fork();
+  if (pid == 0) {
+    // Nest PID namespaces until the limit is reached. Each process waits for
+    // its child and propagates its exit status.
+    for (int depth = 0;; depth++) {
+      TEST_CHECK(depth <= 32);
+      if (unshare(CLONE_NEWPID) != 0) {
+        TEST_CHECK(errno == ENOSPC);
+        _exit(0);
+      }
+      pid_t const child = fork();
+      TEST_PCHECK(child >= 0);
+      if (child == 0) {
+        continue;
+      }
+      int status;
+      TEST_PCHECK(waitpid(child, &status, 0) == child);
+      _exit(WIFEXITED(status) ? WEXITSTATUS(status) : 1);
+    }
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
+// When the init process of a PID namespace exits, it is not reaped until the
+// other processes in the namespace have been killed and reaped.
+TEST(Processes, PIDNamespaceInitExitWaitsForNamespace) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN)));
+
+  pid_t const pid = fork();
+  if (pid == 0) {
+    int pipe_fds[2];
+    TEST_PCHECK(pipe(pipe_fds) == 0);
+    TEST_PCHECK(unshare(CLONE_NEWPID) == 0);
+    pid_t const init = fork();
+    if (init == 0) {
+      pid_t const child = fork();
+      if (child == 0) {
+        // Hold the write end of the pipe until killed.
+        TEST_PCHECK(close(pipe_fds[0]) == 0);
+        while (true) {
+          pause();
+        }
+      }
+      TEST_PCHECK(child > 0);
+      _exit(0);
+    }
+    TEST_PCHECK(init > 0);
+    TEST_PCHECK(close(pipe_fds[1]) == 0);
+    int status;
+    TEST_PCHECK(waitpid(init, &status, 0) == init);
+    TEST_CHECK(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+    // The child is gone, so the pipe has no writers.
+    TEST_PCHECK(fcntl(pipe_fds[0], F_SETFL, O_NONBLOCK) == 0);
+    char c;
+    TEST_PCHECK(read(pipe_fds[0], &c, 1) == 0);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
 }  // namespace testing
 }  // namespace gvisor
 
@@ -13,6 +13,7 @@
 // limitations under the License.
 
 #include <arpa/inet.h>
+#include <linux/filter.h>
 #include <net/if.h>
 #include <netinet/in.h>
 #include <netinet/ip.h>
@@ -25,6 +26,7 @@
 
 #include "gmock/gmock.h"
 #include "gtest/gtest.h"
+#include "absl/base/macros.h"
 #include "test/syscalls/linux/ip_socket_test_util.h"
 #include "test/syscalls/linux/unix_domain_socket_test_util.h"
 #include "test/util/capability_util.h"
@@ -43,6 +45,10 @@ namespace {
 #define TCPHDR_RST 0x4
 #define TCPHDR_FLAGS_OFF 13
 
+#ifndef IPV6_HDRINCL
+#define IPV6_HDRINCL 36
+#endif
+
 using ::testing::AnyOf;
 
 // Fixture for tests parameterized by protocol.
@@ -856,19 +862,96 @@ void RawSocketTest::ReceiveBufFrom(int sock, char* recv_buf,
 }
 
 TEST_P(RawSocketTest, SetSocketDetachFilterNoInstalledFilter) {
-  // TODO(gvisor.dev/2746): Support SO_ATTACH_FILTER/SO_DETACH_FILTER.
-  if (IsRunningOnGvisor()) {
-    constexpr int val = 0;
-    ASSERT_THAT(setsockopt(s_, SOL_SOCKET, SO_DETACH_FILTER, &val, sizeof(val)),
-                SyscallSucceeds());
-    return;
-  }
-
   constexpr int val = 0;
   ASSERT_THAT(setsockopt(s_, SOL_SOCKET, SO_DETACH_FILTER, &val, sizeof(val)),
               SyscallFailsWithErrno(ENOENT));
 }
 
+// Attaches a filter to s that accepts ret bytes of every packet.
+void AttachAcceptFilter(int s, uint32_t ret) {
+  struct sock_filter code[] = {
+      {BPF_RET | BPF_K, 0, 0, ret},
+  };
+  struct sock_fprog bpf = {
+      .len = ABSL_ARRAYSIZE(code),
+      .filter = code,
+  };
+  ASSERT_THAT(setsockopt(s, SOL_SOCKET, SO_ATTACH_FILTER, &bpf, sizeof(bpf)),
+              SyscallSucceeds());
+}
+
+TEST_P(RawSocketTest, AttachFilterDropsPackets) {
+  ASSERT_NO_FATAL_FAILURE(AttachAcceptFilter(s_, 0));
+
+  constexpr char kBuf[] = "filtered";
+  ASSERT_NO_FATAL_FAILURE(SendBuf(kBuf, sizeof(kBuf)));
+  char buf[64];
+  ASSERT_THAT(RetryEINTR(recv)(s_, buf, sizeof(buf), MSG_DONTWAIT),
+              SyscallFailsWithErrno(EAGAIN));
+
+  // Packets are received again once the filter is detached.
+  constexpr int val = 0;
+  ASSERT_THAT(setsockopt(s_, SOL_SOCKET, SO_DETACH_FILTER, &val, sizeof(val)),
+              SyscallSucceeds());
+  ASSERT_NO_FATAL_FAILURE(SendBuf(kBuf, sizeof(kBuf)));
+  std::vector<char> recv_buf(sizeof(kBuf) + HdrLen());
+  ASSERT_NO_FATAL_FAILURE(ReceiveBuf(recv_buf.data(), recv_buf.size()));
+  EXPECT_EQ(memcmp(recv_buf.data() + HdrLen(), kBuf, sizeof(kBuf)), 0);
+}
+
+TEST_P(RawSocketTest, AttachFilterTruncatesPackets) {
+  constexpr int kSnapLen = 2;
+  ASSERT_NO_FATAL_FAILURE(AttachAcceptFilter(s_, HdrLen() + kSnapLen));
+
+  constexpr char kBuf[] = "truncated";
+  ASSERT_NO_FATAL_FAILURE(SendBuf(kBuf, sizeof(kBuf)));
+  std::vector<char> recv_buf(sizeof(kBuf) + HdrLen());
+  ASSERT_THAT(RetryEINTR(recv)(s_, recv_buf.data(), recv_buf.size(), 0),
+              SyscallSucceedsWithValue(HdrLen() + kSnapLen));
+  EXPECT_EQ(memcmp(recv_buf.data() + HdrLen(), kBuf, kSnapLen), 0);
+}
+
+TEST_P(RawSocketTest, LockFilter) {
+  ASSERT_NO_FATAL_FAILURE(AttachAcceptFilter(s_, 0xffff));
+
+  constexpr int kSockOptOn = 1;
+  ASSERT_THAT(setsockopt(s_, SOL_SOCKET, SO_LOCK_FILTER, &kSockOptOn,
+                         sizeof(kSockOptOn)),
+              SyscallSucceeds());
+  int got = 0;
+  socklen_t got_len = sizeof(got);
+  ASSERT_THAT(getsockopt(s_, SOL_SOCKET, SO_LOCK_FILTER, &got, &got_len),
+              SyscallSucceeds());
+  EXPECT_EQ(got, kSockOptOn);
+
+  // A locked filter can't be replaced, removed or unlocked.
+  constexpr int kSockOptOff = 0;
+  ASSERT_THAT(setsockopt(s_, SOL_SOCKET, SO_DETACH_FILTER, &kSockOptOff,
+                         sizeof(kSockOptOff)),
+              SyscallFailsWithErrno(EPERM));
+  ASSERT_THAT(setsockopt(s_, SOL_SOCKET, SO_LOCK_FILTER, &kSockOptOff,
+                         sizeof(kSockOptOff)),
+              SyscallFailsWithErrno(EPERM));
+}
+
+TEST_P(RawSocketTest, IPv6HdrIncl) {
+  SKIP_IF(Family() != AF_INET6);
+
+  int got = -1;
+  socklen_t got_len = sizeof(got);
+  ASSERT_THAT(getsockopt(s_, SOL_IPV6, IPV6_HDRINCL, &got, &got_len),
+              SyscallSucceeds());
+  EXPECT_EQ(got, 0);
+
+  constexpr int kSockOptOn = 1;
+  ASSERT_THAT(setsockopt(s_, SOL_IPV6, IPV6_HDRINCL, &kSockOptOn,
+                         sizeof(kSockOptOn)),
+              SyscallSucceeds());
+  ASSERT_THAT(getsockopt(s_, SOL_IPV6, IPV6_HDRINCL, &got, &got_len),
+              SyscallSucceeds());
+  EXPECT_EQ(got, kSockOptOn);
+}
+
 TEST_P(RawSocketTest, GetSocketDetachFilter) {
   int val = 0;
   socklen_t val_len = sizeof(val);
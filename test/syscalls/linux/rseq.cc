@@ -127,6 +127,20 @@ TEST(RseqTest, DoubleRegister) {
   RunChildTest(kRseqTestDoubleRegister, 0);
 }
 
+// Registering again with a different signature fails with EPERM.
+TEST(RseqTest, DoubleRegisterDifferentSignature) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(RSeqSupported()));
+
+  RunChildTest(kRseqTestDoubleRegisterDifferentSignature, 0);
+}
+
+// Structures larger than the original struct rseq are accepted.
+TEST(RseqTest, RegisterExtended) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(RSeqSupported()));
+
+  RunChildTest(kRseqTestRegisterExtended, 0);
+}
+
 // Registration can be done again after unregister.
 TEST(RseqTest, RegisterUnregister) {
   SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(RSeqSupported()));
@@ -97,6 +97,47 @@ int TestDoubleRegister() {
   return 0;
 }
 
+// Registering again with a different signature fails with EPERM.
+int TestDoubleRegisterDifferentSignature() {
+  constexpr int kSignature = 0;
+
+  struct rseq r = {};
+  int ret = sys_rseq(&r, sizeof(r), 0, kSignature);
+  if (sys_errno(ret) != 0) {
+    return 1;
+  }
+
+  ret = sys_rseq(&r, sizeof(r), 0, kSignature + 1);
+  if (sys_errno(ret) != EPERM) {
+    return 1;
+  }
+
+  return 0;
+}
+
+// Structures larger than the original struct rseq are accepted, and must be
+// unregistered with the same length.
+int TestRegisterExtended() {
+  struct rseq r[2] = {};
+
+  int ret = sys_rseq(&r[0], sizeof(r), 0, 0);
+  if (sys_errno(ret) != 0) {
+    return 1;
+  }
+
+  ret = sys_rseq(&r[0], sizeof(r[0]), kRseqFlagUnregister, 0);
+  if (sys_errno(ret) != EINVAL) {
+    return 1;
+  }
+
+  ret = sys_rseq(&r[0], sizeof(r), kRseqFlagUnregister, 0);
+  if (sys_errno(ret) != 0) {
+    return 1;
+  }
+
+  return 0;
+}
+
 // Registration can be done again after unregister.
 int TestRegisterUnregister() {
   struct rseq r = {};
@@ -411,6 +452,12 @@ extern "C" int main(int argc, char** argv, char** envp) {
   if (strcmp(argv[1], kRseqTestDoubleRegister) == 0) {
     return TestDoubleRegister();
   }
+  if (strcmp(argv[1], kRseqTestDoubleRegisterDifferentSignature) == 0) {
+    return TestDoubleRegisterDifferentSignature();
+  }
+  if (strcmp(argv[1], kRseqTestRegisterExtended) == 0) {
+    return TestRegisterExtended();
+  }
   if (strcmp(argv[1], kRseqTestRegisterUnregister) == 0) {
     return TestRegisterUnregister();
   }
@@ -23,6 +23,9 @@ namespace testing {
 constexpr char kRseqTestUnaligned[] = "unaligned";
 constexpr char kRseqTestRegister[] = "register";
 constexpr char kRseqTestDoubleRegister[] = "double-register";
+constexpr char kRseqTestDoubleRegisterDifferentSignature[] =
+    "double-register-different-signature";
+constexpr char kRseqTestRegisterExtended[] = "register-extended";
 constexpr char kRseqTestRegisterUnregister[] = "register-unregister";
 constexpr char kRseqTestUnregisterDifferentPtr[] = "unregister-different-ptr";
 constexpr char kRseqTestUnregisterDifferentSignature[] =
@@ -12,8 +12,12 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+#include <signal.h>
+#include <sys/resource.h>
 #include <sys/syscall.h>
 #include <sys/types.h>
+#include <sys/wait.h>
+#include <time.h>
 #include <unistd.h>
 
 #include <cerrno>
@@ -151,6 +155,92 @@ TEST_F(RtSignalTest, SignoMatch) {
   EXPECT_EQ(saved_info.si_signo, SIGUSR2);
 }
 
+TEST(RtSignalQueueTest, QueuedInOrderBeyondDefaultCap) {
+  // Queue more instances of a realtime signal than gVisor historically
+  // allowed, and check that they are all delivered in the order sent, before
+  // a higher-numbered realtime signal.
+  constexpr int kSignals = 64;
+  const int sig = SIGRTMIN;
+  const int sig2 = SIGRTMIN + 1;
+  sigset_t set;
+  sigemptyset(&set);
+  sigaddset(&set, sig);
+  sigaddset(&set, sig2);
+  auto mask_cleanup =
+      ASSERT_NO_ERRNO_AND_VALUE(ScopedSignalMask(SIG_BLOCK, set));
+
+  ASSERT_THAT(sigqueue(getpid(), sig2, sigval{.sival_int = -1}),
+              SyscallSucceeds());
+  for (int i = 0; i < kSignals; i++) {
+    ASSERT_THAT(sigqueue(getpid(), sig, sigval{.sival_int = i}),
+                SyscallSucceeds());
+  }
+
+  const struct timespec timeout = {};
+  siginfo_t info;
+  for (int i = 0; i < kSignals; i++) {
+    ASSERT_THAT(sigtimedwait(&set, &info, &timeout),
+                SyscallSucceedsWithValue(sig));
+    EXPECT_EQ(info.si_code, SI_QUEUE);
+    EXPECT_EQ(info.si_value.sival_int, i);
+  }
+  ASSERT_THAT(sigtimedwait(&set, &info, &timeout),
+              SyscallSucceedsWithValue(sig2));
+  EXPECT_EQ(info.si_value.sival_int, -1);
+}
+
+TEST(RtSignalQueueTest, SigpendingLimit) {
+  // Run in a child process so that the lowered limit doesn't affect other
+  // tests.
+  const pid_t child = fork();
+  if (child == 0) {
+    const int sig = SIGRTMIN;
+    sigset_t set;
+    sigemptyset(&set);
+    sigaddset(&set, sig);
+    sigaddset(&set, SIGUSR1);
+    TEST_PCHECK(sigprocmask(SIG_BLOCK, &set, nullptr) == 0);
+
+    struct rlimit rlim;
+    TEST_PCHECK(getrlimit(RLIMIT_SIGPENDING, &rlim) == 0);
+    const rlim_t old_cur = rlim.rlim_cur;
+    rlim.rlim_cur = 0;
+    TEST_PCHECK(setrlimit(RLIMIT_SIGPENDING, &rlim) == 0);
+
+    // Realtime signals with siginfo can't be queued.
+    TEST_CHECK(sigqueue(getpid(), sig, sigval{.sival_int = 1}) == -1);
+    TEST_PCHECK(errno == EAGAIN);
+
+    // Realtime signals sent by kill(2) are still delivered.
+    const struct timespec timeout = {};
+    siginfo_t info;
+    TEST_PCHECK(kill(getpid(), sig) == 0);
+    TEST_PCHECK(sigtimedwait(&set, &info, &timeout) == sig);
+    TEST_CHECK(info.si_code == SI_USER);
+
+    // Standard signals are delivered, but without their siginfo.
+    TEST_PCHECK(sigqueue(getpid(), SIGUSR1, sigval{.sival_int = 1}) == 0);
+    TEST_PCHECK(sigtimedwait(&set, &info, &timeout) == SIGUSR1);
+    TEST_CHECK(info.si_code == SI_USER);
+
+    // Restoring the limit allows realtime signals to be queued again.
+    rlim.rlim_cur = old_cur;
+    TEST_PCHECK(setrlimit(RLIMIT_SIGPENDING, &rlim) == 0);
+    TEST_PCHECK(sigqueue(getpid(), sig, sigval{.sival_int = 2}) == 0);
+    TEST_PCHECK(sigtimedwait(&set, &info, &timeout) == sig);
+    TEST_CHECK(info.si_code == SI_QUEUE);
+    TEST_CHECK(info.si_value.sival_int == 2);
+    _exit(0);
+  }
+  ASSERT_THAT(child, SyscallSucceeds());
+
+  int status;
+  ASSERT_THAT(RetryEINTR(waitpid)(child, &status, 0),
+              SyscallSucceedsWithValue(child));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status = " << status;
+}
+
 }  // namespace
 
 }  // namespace testing
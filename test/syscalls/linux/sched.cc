@@ -14,9 +14,19 @@
 
 #include <errno.h>
 #include <sched.h>
+#include <sys/resource.h>
+#include <sys/syscall.h>
+#include <unistd.h>
+
+#include <cstdint>
+#include <memory>
+#include <vector>
 
 #include "gtest/gtest.h"
+#include "absl/synchronization/notification.h"
+#include "test/util/capability_util.h"
 #include "test/util/test_util.h"
+#include "test/util/thread_util.h"
 
 namespace gvisor {
 namespace testing {
@@ -65,6 +75,243 @@ TEST(SchedGetschedulerTest, ReturnsESRCH) {
   EXPECT_THAT(sched_getscheduler(kImpossiblePID), SyscallFailsWithErrno(ESRCH));
 }
 
+// struct sched_attr isn't defined by glibc.
+struct sched_attr_v1 {
+  uint32_t size;
+  uint32_t sched_policy;
+  uint64_t sched_flags;
+  int32_t sched_nice;
+  uint32_t sched_priority;
+  uint64_t sched_runtime;
+  uint64_t sched_deadline;
+  uint64_t sched_period;
+  uint32_t sched_util_min;
+  uint32_t sched_util_max;
+};
+
+constexpr uint32_t kSchedAttrSizeVer0 = 48;
+constexpr int kSchedDeadline = 6;
+constexpr uint64_t kSchedFlagResetOnFork = 0x01;
+
+int sched_setattr(pid_t tid, struct sched_attr_v1* attr, unsigned int flags) {
+  return syscall(SYS_sched_setattr, tid, attr, flags);
+}
+
+int sched_getattr(pid_t tid, struct sched_attr_v1* attr, unsigned int size,
+                  unsigned int flags) {
+  return syscall(SYS_sched_getattr, tid, attr, size, flags);
+}
+
+struct sched_attr_v1 DeadlineAttr(uint64_t runtime, uint64_t deadline,
+                                  uint64_t period) {
+  struct sched_attr_v1 attr = {};
+  attr.size = sizeof(attr);
+  attr.sched_policy = kSchedDeadline;
+  attr.sched_runtime = runtime;
+  attr.sched_deadline = deadline;
+  attr.sched_period = period;
+  return attr;
+}
+
+TEST(SchedGetattrTest, Default) {
+  struct sched_attr_v1 attr = {};
+  ASSERT_THAT(sched_getattr(0, &attr, sizeof(attr), 0), SyscallSucceeds());
+  EXPECT_EQ(attr.size, sizeof(attr));
+  EXPECT_EQ(attr.sched_policy, SCHED_OTHER);
+  EXPECT_EQ(attr.sched_priority, 0);
+  EXPECT_EQ(attr.sched_runtime, 0);
+  EXPECT_EQ(attr.sched_nice, getpriority(PRIO_PROCESS, 0));
+}
+
+TEST(SchedGetattrTest, SmallerSize) {
+  struct sched_attr_v1 attr = {};
+  attr.sched_util_max = 0xdead;
+  ASSERT_THAT(sched_getattr(0, &attr, kSchedAttrSizeVer0, 0),
+              SyscallSucceeds());
+  EXPECT_EQ(attr.size, kSchedAttrSizeVer0);
+  EXPECT_EQ(attr.sched_util_max, 0xdead);
+}
+
+TEST(SchedGetattrTest, InvalidArguments) {
+  struct sched_attr_v1 attr = {};
+  EXPECT_THAT(sched_getattr(0, &attr, kSchedAttrSizeVer0 - 1, 0),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(sched_getattr(0, &attr, sizeof(attr), 1),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(sched_getattr(-1, &attr, sizeof(attr), 0),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(sched_getattr(0, nullptr, sizeof(attr), 0),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(sched_getattr(kImpossiblePID, &attr, sizeof(attr), 0),
+              SyscallFailsWithErrno(ESRCH));
+}
+
+TEST(SchedSetattrTest, InvalidArguments) {
+  struct sched_attr_v1 attr = {};
+  attr.size = sizeof(attr);
+  EXPECT_THAT(sched_setattr(0, &attr, 1), SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(sched_setattr(0, nullptr, 0), SyscallFailsWithErrno(EINVAL));
+
+  // Invalid policy.
+  attr.sched_policy = 4;
+  EXPECT_THAT(sched_setattr(0, &attr, 0), SyscallFailsWithErrno(EINVAL));
+
+  // Real-time policies require a priority, others forbid it.
+  attr.sched_policy = SCHED_FIFO;
+  EXPECT_THAT(sched_setattr(0, &attr, 0), SyscallFailsWithErrno(EINVAL));
+  attr.sched_policy = SCHED_OTHER;
+  attr.sched_priority = 1;
+  EXPECT_THAT(sched_setattr(0, &attr, 0), SyscallFailsWithErrno(EINVAL));
+
+  // runtime <= deadline <= period must hold.
+  attr = DeadlineAttr(20000000, 10000000, 30000000);
+  EXPECT_THAT(sched_setattr(0, &attr, 0), SyscallFailsWithErrno(EINVAL));
+  attr = DeadlineAttr(10000000, 30000000, 20000000);
+  EXPECT_THAT(sched_setattr(0, &attr, 0), SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(SchedSetattrTest, SizeTooBig) {
+  struct {
+    struct sched_attr_v1 attr;
+    uint64_t extra;
+  } big = {};
+  big.attr.size = sizeof(big);
+  big.attr.sched_policy = SCHED_OTHER;
+  big.extra = 1;
+  EXPECT_THAT(sched_setattr(0, &big.attr, 0), SyscallFailsWithErrno(E2BIG));
+  EXPECT_EQ(big.attr.size, sizeof(big.attr));
+
+  // Unknown trailing fields are accepted if they are zero.
+  big.attr.size = sizeof(big);
+  big.extra = 0;
+  big.attr.sched_nice = getpriority(PRIO_PROCESS, 0);
+  EXPECT_THAT(sched_setattr(0, &big.attr, 0), SyscallSucceeds());
+
+  struct sched_attr_v1 attr = {};
+  attr.size = 1;
+  EXPECT_THAT(sched_setattr(0, &attr, 0), SyscallFailsWithErrno(E2BIG));
+  EXPECT_EQ(attr.size, sizeof(attr));
+}
+
+TEST(SchedSetattrTest, Nice) {
+  // Niceness is per-thread, so change it on a separate thread.
+  ScopedThread([] {
+    struct sched_attr_v1 attr = {};
+    attr.size = sizeof(attr);
+    attr.sched_policy = SCHED_BATCH;
+    attr.sched_nice = 19;
+    ASSERT_THAT(sched_setattr(0, &attr, 0), SyscallSucceeds());
+    EXPECT_EQ(getpriority(PRIO_PROCESS, syscall(SYS_gettid)), 19);
+    EXPECT_THAT(sched_getscheduler(0), SyscallSucceedsWithValue(SCHED_BATCH));
+
+    attr = {};
+    ASSERT_THAT(sched_getattr(0, &attr, sizeof(attr), 0), SyscallSucceeds());
+    EXPECT_EQ(attr.sched_policy, SCHED_BATCH);
+    EXPECT_EQ(attr.sched_nice, 19);
+  });
+}
+
+TEST(SchedSetattrTest, ResetOnFork) {
+  ScopedThread([] {
+    struct sched_attr_v1 attr = {};
+    attr.size = sizeof(attr);
+    attr.sched_policy = SCHED_OTHER;
+    attr.sched_flags = kSchedFlagResetOnFork;
+    attr.sched_nice = getpriority(PRIO_PROCESS, syscall(SYS_gettid));
+    ASSERT_THAT(sched_setattr(0, &attr, 0), SyscallSucceeds());
+    EXPECT_THAT(sched_getscheduler(0),
+                SyscallSucceedsWithValue(SCHED_OTHER | SCHED_RESET_ON_FORK));
+
+    attr = {};
+    ASSERT_THAT(sched_getattr(0, &attr, sizeof(attr), 0), SyscallSucceeds());
+    EXPECT_EQ(attr.sched_flags, kSchedFlagResetOnFork);
+  });
+}
+
+TEST(SchedSetattrTest, Deadline) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_NICE)));
+
+  ScopedThread([] {
+    // 10ms every 100ms.
+    struct sched_attr_v1 attr = DeadlineAttr(10000000, 30000000, 100000000);
+    ASSERT_THAT(sched_setattr(0, &attr, 0), SyscallSucceeds());
+    EXPECT_THAT(sched_getscheduler(0),
+                SyscallSucceedsWithValue(kSchedDeadline));
+
+    attr = {};
+    ASSERT_THAT(sched_getattr(0, &attr, sizeof(attr), 0), SyscallSucceeds());
+    EXPECT_EQ(attr.sched_policy, kSchedDeadline);
+    EXPECT_EQ(attr.sched_runtime, 10000000);
+    EXPECT_EQ(attr.sched_deadline, 30000000);
+    EXPECT_EQ(attr.sched_period, 100000000);
+
+    // SCHED_DEADLINE tasks can't fork.
+    EXPECT_THAT(fork(), SyscallFailsWithErrno(EAGAIN));
+
+    // sched_setscheduler(2) can't set SCHED_DEADLINE parameters.
+    struct sched_param param = {};
+    EXPECT_THAT(sched_setscheduler(0, kSchedDeadline, &param),
+                SyscallFailsWithErrno(EINVAL));
+  });
+}
+
+TEST(SchedSetattrTest, DeadlineAdmission) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_NICE)));
+
+  // Each thread reserves a full CPU, which can't be admitted for all of them
+  // since only 95% of the CPU bandwidth is available to SCHED_DEADLINE tasks.
+  const int n = NumCPUs() + 1;
+  absl::Notification done;
+  std::vector<std::unique_ptr<ScopedThread>> threads;
+  bool busy = false;
+  for (int i = 0; i < n && !busy; i++) {
+    pid_t tid = -1;
+    absl::Notification started;
+    threads.push_back(std::make_unique<ScopedThread>([&] {
+      tid = syscall(SYS_gettid);
+      started.Notify();
+      done.WaitForNotification();
+    }));
+    started.WaitForNotification();
+
+    struct sched_attr_v1 attr = DeadlineAttr(10000000, 10000000, 10000000);
+    int ret = sched_setattr(tid, &attr, 0);
+    if (ret < 0) {
+      EXPECT_EQ(errno, EBUSY);
+      busy = true;
+    }
+  }
+  EXPECT_TRUE(busy);
+  done.Notify();
+  threads.clear();
+
+  // The bandwidth is released when the threads exit. Linux may release it
+  // asynchronously.
+  ScopedThread([] {
+    struct sched_attr_v1 attr = DeadlineAttr(10000000, 10000000, 20000000);
+    int ret;
+    for (int i = 0; i < 100; i++) {
+      ret = sched_setattr(0, &attr, 0);
+      if (ret == 0 || errno != EBUSY) {
+        break;
+      }
+      usleep(10000);
+    }
+    EXPECT_THAT(ret, SyscallSucceeds());
+  });
+}
+
+TEST(SchedGetPriorityTest, Ranges) {
+  EXPECT_THAT(sched_get_priority_max(SCHED_FIFO), SyscallSucceedsWithValue(99));
+  EXPECT_THAT(sched_get_priority_min(SCHED_FIFO), SyscallSucceedsWithValue(1));
+  EXPECT_THAT(sched_get_priority_max(SCHED_RR), SyscallSucceedsWithValue(99));
+  EXPECT_THAT(sched_get_priority_min(SCHED_RR), SyscallSucceedsWithValue(1));
+  EXPECT_THAT(sched_get_priority_max(SCHED_OTHER), SyscallSucceedsWithValue(0));
+  EXPECT_THAT(sched_get_priority_min(SCHED_OTHER), SyscallSucceedsWithValue(0));
+  EXPECT_THAT(sched_get_priority_max(-1), SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(sched_get_priority_min(-1), SyscallFailsWithErrno(EINVAL));
+}
+
 }  // namespace
 
 }  // namespace testing
@@ -20,6 +20,7 @@
 #include <sched.h>
 #include <signal.h>
 #include <string.h>
+#include <sys/ioctl.h>
 #include <sys/prctl.h>
 #include <sys/syscall.h>
 #include <time.h>
@@ -44,6 +45,10 @@
 #define SYS_SECCOMP 1
 #endif
 
+#ifndef SECCOMP_ADDFD_FLAG_SEND
+#define SECCOMP_ADDFD_FLAG_SEND (1UL << 1)
+#endif
+
 namespace gvisor {
 namespace testing {
 
@@ -58,9 +63,11 @@ constexpr uint32_t kFilteredSyscall = __NR_arch_specific_syscall + 15;
 #endif
 
 // Applies a seccomp-bpf filter that returns `filtered_result` for
-// `sysno` and allows all other syscalls. Async-signal-safe.
-void ApplySeccompFilter(uint32_t sysno, uint32_t filtered_result,
-                        uint32_t flags = 0) {
+// `sysno` and allows all other syscalls, and returns the result of seccomp(2)
+// (the listener file descriptor if `flags` includes
+// SECCOMP_FILTER_FLAG_NEW_LISTENER). Async-signal-safe.
+int ApplySeccompFilter(uint32_t sysno, uint32_t filtered_result,
+                       uint32_t flags = 0) {
   // "Prior to [PR_SET_SECCOMP], the task must call prctl(PR_SET_NO_NEW_PRIVS,
   // 1) or run with CAP_SYS_ADMIN privileges in its namespace." -
   // Documentation/prctl/seccomp_filter.txt
@@ -96,13 +103,15 @@ void ApplySeccompFilter(uint32_t sysno, uint32_t filtered_result,
   struct sock_fprog prog;
   prog.len = ABSL_ARRAYSIZE(filter);
   prog.filter = filter;
+  int ret = 0;
   if (flags) {
-    TEST_CHECK(syscall(__NR_seccomp, SECCOMP_SET_MODE_FILTER, flags, &prog) ==
-               0);
+    ret = syscall(__NR_seccomp, SECCOMP_SET_MODE_FILTER, flags, &prog);
+    TEST_PCHECK(ret >= 0);
   } else {
     TEST_PCHECK(prctl(PR_SET_SECCOMP, SECCOMP_MODE_FILTER, &prog, 0, 0) == 0);
   }
   MaybeSave();
+  return ret;
 }
 
 // ApplyUncacheableFilter adds a no-op filter which reads one of the
@@ -215,6 +224,76 @@ TEST(SeccompTest, RetKillOnlyKillsOneThread) {
       << "status " << status;
 }
 
+// Invokes kFilteredSyscall from a new thread in the calling process, and waits
+// for that thread to exit. Returns only if the calling thread survives.
+void InvokeFilteredSyscallInThread(Mapping const& stack) {
+  // See RetKillOnlyKillsOneThread.
+  clone(
+      +[](void* arg) {
+        syscall(kFilteredSyscall);
+        _exit(1);  // should be unreachable
+        return 2;  // should be very unreachable, shut up the compiler
+      },
+      stack.endptr(),
+      CLONE_FILES | CLONE_FS | CLONE_SIGHAND | CLONE_THREAD | CLONE_VM |
+          CLONE_VFORK,
+      nullptr);
+}
+
+TEST(SeccompTest, RetKillProcessKillsThreadGroup) {
+  Mapping stack = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(2 * kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+
+  pid_t const pid = fork();
+  if (pid == 0) {
+    RegisterSignalHandler(SIGSYS, +[](int, siginfo_t*, void*) { _exit(1); });
+    ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_KILL_PROCESS);
+    InvokeFilteredSyscallInThread(stack);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFSIGNALED(status) && WTERMSIG(status) == SIGSYS)
+      << "status " << status;
+}
+
+TEST(SeccompTest, UnknownActionKillsThreadGroup) {
+  Mapping stack = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(2 * kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+
+  pid_t const pid = fork();
+  if (pid == 0) {
+    RegisterSignalHandler(SIGSYS, +[](int, siginfo_t*, void*) { _exit(1); });
+    // 0x00010000 is not a valid action.
+    ApplySeccompFilter(kFilteredSyscall, 0x00010000);
+    InvokeFilteredSyscallInThread(stack);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFSIGNALED(status) && WTERMSIG(status) == SIGSYS)
+      << "status " << status;
+}
+
+TEST(SeccompTest, RetKillProcessTakesPrecedenceOverRetErrno) {
+  pid_t const pid = fork();
+  if (pid == 0) {
+    // SECCOMP_RET_KILL_PROCESS has the sign bit set, which must not be
+    // masked off when comparing actions.
+    ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_KILL_PROCESS);
+    ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_ERRNO | ENOTNAM);
+    syscall(kFilteredSyscall);
+    TEST_CHECK_MSG(false, "Survived invocation of test syscall");
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFSIGNALED(status) && WTERMSIG(status) == SIGSYS)
+      << "status " << status;
+}
+
 TEST(SeccompTest, RetTrapCausesSIGSYS) {
   pid_t const pid = fork();
   if (pid == 0) {
@@ -248,6 +327,25 @@ TEST(SeccompTest, RetTrapCausesSIGSYS) {
       << "status " << status;
 }
 
+TEST(SeccompTest, RetTrapWithBlockedSIGSYSKills) {
+  pid_t const pid = fork();
+  if (pid == 0) {
+    RegisterSignalHandler(SIGSYS, +[](int, siginfo_t*, void*) { _exit(1); });
+    sigset_t set;
+    sigemptyset(&set);
+    sigaddset(&set, SIGSYS);
+    TEST_PCHECK(sigprocmask(SIG_BLOCK, &set, nullptr) == 0);
+    ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_TRAP);
+    syscall(kFilteredSyscall);
+    TEST_CHECK_MSG(false, "Survived invocation of test syscall");
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFSIGNALED(status) && WTERMSIG(status) == SIGSYS)
+      << "status " << status;
+}
+
 #ifdef __x86_64__
 
 constexpr uint64_t kVsyscallTimeEntry = 0xffffffffff600400;
@@ -337,6 +435,210 @@ TEST(SeccompTest, RetErrnoReturnsErrno) {
       << "status " << status;
 }
 
+TEST(SeccompTest, RetErrnoClampsToMaxErrno) {
+  pid_t const pid = fork();
+  if (pid == 0) {
+    ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_ERRNO | 0xffff);
+    // MAX_ERRNO.
+    TEST_CHECK(syscall(kFilteredSyscall) == -1 && errno == 4095);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
+TEST(SeccompTest, RetUserNotifWithoutListenerReturnsENOSYS) {
+  pid_t const pid = fork();
+  if (pid == 0) {
+    ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_USER_NOTIF);
+    TEST_CHECK(syscall(kFilteredSyscall) == -1 && errno == ENOSYS);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
+TEST(SeccompTest, UserNotifClosedListenerReturnsENOSYS) {
+  pid_t const pid = fork();
+  if (pid == 0) {
+    int const listener =
+        ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_USER_NOTIF,
+                           SECCOMP_FILTER_FLAG_NEW_LISTENER);
+    TEST_PCHECK(close(listener) == 0);
+    TEST_CHECK(syscall(kFilteredSyscall) == -1 && errno == ENOSYS);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
+TEST(SeccompTest, UserNotifSecondListenerIsRejected) {
+  pid_t const pid = fork();
+  if (pid == 0) {
+    ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_USER_NOTIF,
+                       SECCOMP_FILTER_FLAG_NEW_LISTENER);
+    struct sock_filter filter[] = {
+        BPF_STMT(BPF_RET | BPF_K, SECCOMP_RET_ALLOW),
+    };
+    struct sock_fprog prog;
+    prog.len = ABSL_ARRAYSIZE(filter);
+    prog.filter = filter;
+    TEST_CHECK(syscall(__NR_seccomp, SECCOMP_SET_MODE_FILTER,
+                       SECCOMP_FILTER_FLAG_NEW_LISTENER, &prog) == -1 &&
+               errno == EBUSY);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
+// The supervisor's reply is returned by the filtered syscall, in a child of
+// the process that installed the filter.
+TEST(SeccompTest, UserNotifReplyIsReturned) {
+  pid_t const pid = fork();
+  if (pid == 0) {
+    int const listener =
+        ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_USER_NOTIF,
+                           SECCOMP_FILTER_FLAG_NEW_LISTENER);
+    pid_t const child = fork();
+    if (child == 0) {
+      TEST_CHECK(syscall(kFilteredSyscall, 1) == 42);
+      TEST_CHECK(syscall(kFilteredSyscall, 2) == -1 && errno == EPERM);
+      _exit(0);
+    }
+    TEST_PCHECK(child > 0);
+    for (int i = 0; i < 2; i++) {
+      struct seccomp_notif req = {};
+      TEST_PCHECK(ioctl(listener, SECCOMP_IOCTL_NOTIF_RECV, &req) == 0);
+      TEST_CHECK(req.pid == child);
+      TEST_CHECK(req.data.nr == kFilteredSyscall);
+      TEST_PCHECK(ioctl(listener, SECCOMP_IOCTL_NOTIF_ID_VALID, &req.id) ==
+                  0);
+      struct seccomp_notif_resp resp = {};
+      resp.id = req.id;
+      if (req.data.args[0] == 1) {
+        resp.val = 42;
+      } else {
+        resp.error = -EPERM;
+      }
+      TEST_PCHECK(ioctl(listener, SECCOMP_IOCTL_NOTIF_SEND, &resp) == 0);
+      // Only one reply is allowed.
+      TEST_CHECK(ioctl(listener, SECCOMP_IOCTL_NOTIF_SEND, &resp) == -1);
+    }
+    int status;
+    TEST_PCHECK(waitpid(child, &status, 0) == child);
+    TEST_CHECK(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
+TEST(SeccompTest, UserNotifContinueExecutesSyscall) {
+  pid_t const pid = fork();
+  if (pid == 0) {
+    int const listener =
+        ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_USER_NOTIF,
+                           SECCOMP_FILTER_FLAG_NEW_LISTENER);
+    pid_t const child = fork();
+    if (child == 0) {
+      // kFilteredSyscall is not implemented.
+      TEST_CHECK(syscall(kFilteredSyscall) == -1 && errno == ENOSYS);
+      _exit(0);
+    }
+    TEST_PCHECK(child > 0);
+    struct seccomp_notif req = {};
+    TEST_PCHECK(ioctl(listener, SECCOMP_IOCTL_NOTIF_RECV, &req) == 0);
+    struct seccomp_notif_resp resp = {};
+    resp.id = req.id;
+    resp.flags = SECCOMP_USER_NOTIF_FLAG_CONTINUE;
+    resp.val = 1;
+    // SECCOMP_USER_NOTIF_FLAG_CONTINUE may not be combined with a reply.
+    TEST_CHECK(ioctl(listener, SECCOMP_IOCTL_NOTIF_SEND, &resp) == -1 &&
+               errno == EINVAL);
+    resp.val = 0;
+    TEST_PCHECK(ioctl(listener, SECCOMP_IOCTL_NOTIF_SEND, &resp) == 0);
+    int status;
+    TEST_PCHECK(waitpid(child, &status, 0) == child);
+    TEST_CHECK(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
+TEST(SeccompTest, UserNotifAddFDSend) {
+  pid_t const pid = fork();
+  if (pid == 0) {
+    int const listener =
+        ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_USER_NOTIF,
+                           SECCOMP_FILTER_FLAG_NEW_LISTENER);
+    int pipefds[2];
+    TEST_PCHECK(pipe(pipefds) == 0);
+    pid_t const child = fork();
+    if (child == 0) {
+      TEST_PCHECK(close(pipefds[1]) == 0);
+      int const fd = syscall(kFilteredSyscall);
+      TEST_PCHECK(fd >= 0);
+      TEST_PCHECK(write(fd, "x", 1) == 1);
+      _exit(0);
+    }
+    TEST_PCHECK(child > 0);
+    struct seccomp_notif req = {};
+    TEST_PCHECK(ioctl(listener, SECCOMP_IOCTL_NOTIF_RECV, &req) == 0);
+    struct seccomp_notif_addfd addfd = {};
+    addfd.id = req.id;
+    addfd.flags = SECCOMP_ADDFD_FLAG_SEND;
+    addfd.srcfd = pipefds[1];
+    TEST_PCHECK(ioctl(listener, SECCOMP_IOCTL_NOTIF_ADDFD, &addfd) >= 0);
+    TEST_PCHECK(close(pipefds[1]) == 0);
+    char c;
+    TEST_PCHECK(read(pipefds[0], &c, 1) == 1);
+    TEST_CHECK(c == 'x');
+    int status;
+    TEST_PCHECK(waitpid(child, &status, 0) == child);
+    TEST_CHECK(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
+TEST(SeccompTest, RetLogAllowsSyscall) {
+  pid_t const pid = fork();
+  if (pid == 0) {
+    ApplySeccompFilter(kFilteredSyscall, SECCOMP_RET_LOG);
+    TEST_CHECK(syscall(kFilteredSyscall) == -1 && errno == ENOSYS);
+    _exit(0);
+  }
+  ASSERT_THAT(pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(waitpid(pid, &status, 0), SyscallSucceedsWithValue(pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status " << status;
+}
+
 TEST(SeccompTest, RetAllowAllowsSyscall) {
   pid_t const pid = fork();
   if (pid == 0) {
@@ -12,14 +12,18 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+#include <fcntl.h>
 #include <signal.h>
 #include <sys/ipc.h>
 #include <sys/sem.h>
 #include <sys/types.h>
+#include <sys/wait.h>
+#include <unistd.h>
 
 #include <atomic>
 #include <cerrno>
 #include <ctime>
+#include <functional>
 #include <memory>
 #include <set>
 
@@ -1020,6 +1024,132 @@ TEST(SemaphoreTest, SemInfo) {
   EXPECT_EQ(info.semvmx, kSemVmx);
 }
 
+// Runs fn in a child process and waits for it to exit successfully.
+void RunInChild(const std::function<void()>& fn) {
+  const pid_t child_pid = fork();
+  if (child_pid == 0) {
+    fn();
+    _exit(0);
+  }
+  ASSERT_THAT(child_pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(RetryEINTR(waitpid)(child_pid, &status, 0),
+              SyscallSucceedsWithValue(child_pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << " status " << status;
+}
+
+TEST(SemaphoreTest, SemUndoAppliedOnExit) {
+  AutoSem sem(semget(IPC_PRIVATE, 2, 0600 | IPC_CREAT));
+  ASSERT_THAT(sem.get(), SyscallSucceeds());
+  ASSERT_THAT(semctl(sem.get(), 1, SETVAL, 3), SyscallSucceeds());
+
+  RunInChild([&] {
+    struct sembuf bufs[] = {
+        {.sem_num = 0, .sem_op = 2, .sem_flg = SEM_UNDO},
+        {.sem_num = 1, .sem_op = -1, .sem_flg = SEM_UNDO},
+    };
+    TEST_PCHECK(semop(sem.get(), bufs, ABSL_ARRAYSIZE(bufs)) == 0);
+    TEST_PCHECK(semctl(sem.get(), 0, GETVAL) == 2);
+    TEST_PCHECK(semctl(sem.get(), 1, GETVAL) == 2);
+  });
+
+  EXPECT_THAT(semctl(sem.get(), 0, GETVAL), SyscallSucceedsWithValue(0));
+  EXPECT_THAT(semctl(sem.get(), 1, GETVAL), SyscallSucceedsWithValue(3));
+}
+
+TEST(SemaphoreTest, SemUndoClearedBySetVal) {
+  AutoSem sem(semget(IPC_PRIVATE, 1, 0600 | IPC_CREAT));
+  ASSERT_THAT(sem.get(), SyscallSucceeds());
+
+  RunInChild([&] {
+    struct sembuf buf = {.sem_num = 0, .sem_op = 1, .sem_flg = SEM_UNDO};
+    TEST_PCHECK(semop(sem.get(), &buf, 1) == 0);
+    TEST_PCHECK(semctl(sem.get(), 0, SETVAL, 5) == 0);
+  });
+
+  EXPECT_THAT(semctl(sem.get(), 0, GETVAL), SyscallSucceedsWithValue(5));
+}
+
+TEST(SemaphoreTest, SemUndoSharedByThreads) {
+  AutoSem sem(semget(IPC_PRIVATE, 1, 0600 | IPC_CREAT));
+  ASSERT_THAT(sem.get(), SyscallSucceeds());
+
+  RunInChild([&] {
+    // Threads are created with CLONE_SYSVSEM, so the adjustment belongs to
+    // the process and isn't applied when the thread exits.
+    ScopedThread([&] {
+      struct sembuf buf = {.sem_num = 0, .sem_op = 1, .sem_flg = SEM_UNDO};
+      TEST_PCHECK(semop(sem.get(), &buf, 1) == 0);
+    }).Join();
+    TEST_PCHECK(semctl(sem.get(), 0, GETVAL) == 1);
+  });
+
+  EXPECT_THAT(semctl(sem.get(), 0, GETVAL), SyscallSucceedsWithValue(0));
+}
+
+// SEM_UNDO adjustments are preserved across execve(2), and applied when the
+// new program exits.
+TEST(SemaphoreTest, SemUndoPreservedAcrossExec) {
+  AutoSem sem(semget(IPC_PRIVATE, 1, 0600 | IPC_CREAT));
+  ASSERT_THAT(sem.get(), SyscallSucceeds());
+
+  // exec_pipe is closed by a successful execve.
+  int exec_pipe[2];
+  ASSERT_THAT(pipe2(exec_pipe, O_CLOEXEC), SyscallSucceeds());
+  int stdin_pipe[2];
+  ASSERT_THAT(pipe(stdin_pipe), SyscallSucceeds());
+
+  const pid_t child_pid = fork();
+  if (child_pid == 0) {
+    close(exec_pipe[0]);
+    close(stdin_pipe[1]);
+    TEST_PCHECK(dup2(stdin_pipe[0], STDIN_FILENO) == STDIN_FILENO);
+    struct sembuf buf = {.sem_num = 0, .sem_op = 1, .sem_flg = SEM_UNDO};
+    TEST_PCHECK(semop(sem.get(), &buf, 1) == 0);
+    // The shell blocks until the parent closes the other end of stdin.
+    char* const argv[] = {const_cast<char*>("/bin/sh"),
+                          const_cast<char*>("-c"),
+                          const_cast<char*>("read x"), nullptr};
+    execv(argv[0], argv);
+    _exit(1);
+  }
+  ASSERT_THAT(child_pid, SyscallSucceeds());
+  close(exec_pipe[1]);
+  close(stdin_pipe[0]);
+
+  char c;
+  EXPECT_THAT(RetryEINTR(read)(exec_pipe[0], &c, 1),
+              SyscallSucceedsWithValue(0));
+  close(exec_pipe[0]);
+  EXPECT_THAT(semctl(sem.get(), 0, GETVAL), SyscallSucceedsWithValue(1));
+
+  close(stdin_pipe[1]);
+  int status;
+  ASSERT_THAT(RetryEINTR(waitpid)(child_pid, &status, 0),
+              SyscallSucceedsWithValue(child_pid));
+  EXPECT_THAT(semctl(sem.get(), 0, GETVAL), SyscallSucceedsWithValue(0));
+}
+
+TEST(SemaphoreTest, SemUndoRange) {
+  AutoSem sem(semget(IPC_PRIVATE, 1, 0600 | IPC_CREAT));
+  ASSERT_THAT(sem.get(), SyscallSucceeds());
+
+  RunInChild([&] {
+    struct sembuf buf = {.sem_num = 0, .sem_op = kSemVmx, .sem_flg = SEM_UNDO};
+    TEST_PCHECK(semop(sem.get(), &buf, 1) == 0);
+    buf = {.sem_num = 0, .sem_op = -kSemVmx, .sem_flg = 0};
+    TEST_PCHECK(semop(sem.get(), &buf, 1) == 0);
+    // The adjustment would be -(kSemAem + 2).
+    buf = {.sem_num = 0, .sem_op = 2, .sem_flg = SEM_UNDO};
+    TEST_PCHECK(semop(sem.get(), &buf, 1) == -1 && errno == ERANGE);
+    TEST_PCHECK(semctl(sem.get(), 0, GETVAL) == 0);
+  });
+
+  // The adjustment of -kSemVmx is clamped at 0.
+  EXPECT_THAT(semctl(sem.get(), 0, GETVAL), SyscallSucceedsWithValue(0));
+}
+
 TEST(SempahoreTest, RemoveNonExistentSemaphore) {
   EXPECT_THAT(semctl(-1, 0, IPC_RMID), SyscallFailsWithErrno(EINVAL));
 }
@@ -16,6 +16,7 @@
 #include <signal.h>
 #include <stdio.h>
 #include <string.h>
+#include <sys/auxv.h>
 #include <unistd.h>
 
 #include <functional>
@@ -87,6 +88,42 @@ TEST(SigaltstackTest, Success) {
   EXPECT_NE(0, ss_flags & SS_ONSTACK);
 }
 
+#ifndef AT_MINSIGSTKSZ
+#define AT_MINSIGSTKSZ 51
+#endif
+
+volatile bool got_minimal_signal = false;
+
+void minimal_handler(int sig, siginfo_t* siginfo, void* arg) {
+  got_minimal_signal = true;
+}
+
+// A signal stack of AT_MINSIGSTKSZ bytes must be large enough to hold the
+// signal frame.
+TEST(SigaltstackTest, MinSigStkSz) {
+  unsigned long const minsigstksz = getauxval(AT_MINSIGSTKSZ);
+  // AT_MINSIGSTKSZ is not provided by older Linux kernels.
+  SKIP_IF(!IsRunningOnGvisor() && minsigstksz == 0);
+  ASSERT_GT(minsigstksz, 0);
+
+  std::vector<char> stack_mem(minsigstksz);
+  stack_t stack = {};
+  stack.ss_sp = stack_mem.data();
+  stack.ss_size = stack_mem.size();
+  auto const cleanup_sigstack =
+      ASSERT_NO_ERRNO_AND_VALUE(ScopedSigaltstack(stack));
+
+  struct sigaction sa = {};
+  sa.sa_sigaction = minimal_handler;
+  sigfillset(&sa.sa_mask);
+  sa.sa_flags = SA_SIGINFO | SA_ONSTACK;
+  auto const cleanup_sa =
+      ASSERT_NO_ERRNO_AND_VALUE(ScopedSigaction(SIGUSR1, sa));
+
+  EXPECT_THAT(tgkill(getpid(), gettid(), SIGUSR1), SyscallSucceeds());
+  EXPECT_TRUE(got_minimal_signal);
+}
+
 TEST(SigaltstackTest, ResetByExecve) {
   std::vector<char> stack_mem(SIGSTKSZ);
   stack_t stack = {};
@@ -159,6 +159,78 @@ TEST_P(AllSocketPairTest, BasicRecvmmsg) {
   }
 }
 
+TEST_P(AllSocketPairTest, RecvmmsgWaitForOne) {
+  auto sockets = ASSERT_NO_ERRNO_AND_VALUE(NewSocketPair());
+  constexpr int kChunkSize = 20;
+  constexpr int kSent = 3;
+  char sent_data[kChunkSize * kSent];
+  RandomizeBuffer(sent_data, sizeof(sent_data));
+
+  for (int i = 0; i < kSent; i++) {
+    ASSERT_THAT(
+        WriteFd(sockets->first_fd(), &sent_data[i * kChunkSize], kChunkSize),
+        SyscallSucceedsWithValue(kChunkSize));
+  }
+
+  // With MSG_WAITFORONE, recvmmsg returns the available messages instead of
+  // blocking for all of them.
+  char received_data[kChunkSize * 10];
+  std::vector<struct mmsghdr> msgs(10);
+  std::vector<struct iovec> iovs(msgs.size());
+  for (size_t i = 0; i < msgs.size(); i++) {
+    iovs[i].iov_len = kChunkSize;
+    iovs[i].iov_base = &received_data[i * kChunkSize];
+    msgs[i].msg_hdr.msg_iov = &iovs[i];
+    msgs[i].msg_hdr.msg_iovlen = 1;
+  }
+  ASSERT_THAT(RetryEINTR(recvmmsg)(sockets->second_fd(), &msgs[0], msgs.size(),
+                                   MSG_WAITFORONE, nullptr),
+              SyscallSucceedsWithValue(kSent));
+
+  EXPECT_EQ(0, memcmp(sent_data, received_data, sizeof(sent_data)));
+  for (int i = 0; i < kSent; i++) {
+    EXPECT_EQ(kChunkSize, msgs[i].msg_len);
+  }
+}
+
+// Tests that messages are transferred correctly when there are more of them
+// than the sentry sends or receives in one batch.
+TEST_P(AllSocketPairTest, SendmmsgRecvmmsgManyMessages) {
+  auto sockets = ASSERT_NO_ERRNO_AND_VALUE(NewSocketPair());
+  constexpr int kChunkSize = 16;
+  constexpr int kMessages = 200;
+  std::vector<char> sent_data(kChunkSize * kMessages);
+  RandomizeBuffer(sent_data.data(), sent_data.size());
+
+  std::vector<struct mmsghdr> msgs(kMessages);
+  std::vector<struct iovec> iovs(kMessages);
+  for (int i = 0; i < kMessages; i++) {
+    iovs[i].iov_len = kChunkSize;
+    iovs[i].iov_base = &sent_data[i * kChunkSize];
+    msgs[i].msg_hdr.msg_iov = &iovs[i];
+    msgs[i].msg_hdr.msg_iovlen = 1;
+  }
+  ASSERT_THAT(
+      RetryEINTR(sendmmsg)(sockets->first_fd(), msgs.data(), kMessages, 0),
+      SyscallSucceedsWithValue(kMessages));
+
+  std::vector<char> received_data(sent_data.size());
+  msgs.assign(kMessages, {});
+  for (int i = 0; i < kMessages; i++) {
+    iovs[i].iov_base = &received_data[i * kChunkSize];
+    msgs[i].msg_hdr.msg_iov = &iovs[i];
+    msgs[i].msg_hdr.msg_iovlen = 1;
+  }
+  ASSERT_THAT(RetryEINTR(recvmmsg)(sockets->second_fd(), msgs.data(),
+                                   kMessages, MSG_WAITFORONE, nullptr),
+              SyscallSucceedsWithValue(kMessages));
+
+  EXPECT_EQ(sent_data, received_data);
+  for (const struct mmsghdr& msg : msgs) {
+    EXPECT_EQ(kChunkSize, msg.msg_len);
+  }
+}
+
 TEST_P(AllSocketPairTest, SendmsgRecvmsg10KB) {
   auto sockets = ASSERT_NO_ERRNO_AND_VALUE(NewSocketPair());
   std::vector<char> sent_data(10 * 1024);
@@ -0,0 +1,261 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <linux/genetlink.h>
+#include <linux/netlink.h>
+#include <linux/taskstats.h>
+#include <sys/socket.h>
+#include <sys/syscall.h>
+#include <sys/types.h>
+#include <unistd.h>
+
+#include <algorithm>
+#include <cstdint>
+#include <cstring>
+#include <string>
+
+#include "gtest/gtest.h"
+#include "absl/strings/str_cat.h"
+#include "test/syscalls/linux/socket_netlink_util.h"
+#include "test/util/capability_util.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/posix_error.h"
+#include "test/util/socket_util.h"
+#include "test/util/test_util.h"
+
+// Tests for NETLINK_GENERIC sockets.
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+constexpr uint32_t kSeq = 12345;
+
+// GenlRequest is a generic netlink request with room for attributes.
+struct GenlRequest {
+  struct nlmsghdr hdr;
+  struct genlmsghdr genl;
+  char attrs[128];
+};
+
+// InitGenlRequest initializes req as a request for cmd of family.
+void InitGenlRequest(GenlRequest* req, uint16_t family, uint8_t cmd,
+                     uint8_t version) {
+  memset(req, 0, sizeof(*req));
+  req->hdr.nlmsg_len = NLMSG_LENGTH(GENL_HDRLEN);
+  req->hdr.nlmsg_type = family;
+  req->hdr.nlmsg_flags = NLM_F_REQUEST;
+  req->hdr.nlmsg_seq = kSeq;
+  req->genl.cmd = cmd;
+  req->genl.version = version;
+}
+
+// AddAttr appends an attribute with the given payload to req.
+void AddAttr(GenlRequest* req, uint16_t type, const void* data, int len) {
+  struct nlattr* attr = reinterpret_cast<struct nlattr*>(
+      reinterpret_cast<char*>(req) + NLMSG_ALIGN(req->hdr.nlmsg_len));
+  InitNetlinkAttr(attr, len, type);
+  memcpy(reinterpret_cast<char*>(attr) + NLA_HDRLEN, data, len);
+  req->hdr.nlmsg_len =
+      NLMSG_ALIGN(req->hdr.nlmsg_len) + NLA_ALIGN(NLA_HDRLEN + len);
+}
+
+// FindAttr returns the attribute of the given type in the len bytes of
+// attributes starting at attrs, or nullptr if there is none.
+const struct nlattr* FindAttr(const char* attrs, int len, uint16_t type) {
+  while (len >= NLA_HDRLEN) {
+    const struct nlattr* attr = reinterpret_cast<const struct nlattr*>(attrs);
+    if (attr->nla_len < NLA_HDRLEN || attr->nla_len > len) {
+      return nullptr;
+    }
+    if ((attr->nla_type & NLA_TYPE_MASK) == type) {
+      return attr;
+    }
+    len -= NLA_ALIGN(attr->nla_len);
+    attrs += NLA_ALIGN(attr->nla_len);
+  }
+  return nullptr;
+}
+
+// FindGenlAttr returns the top-level attribute of the given type in the
+// generic netlink message hdr.
+const struct nlattr* FindGenlAttr(const struct nlmsghdr* hdr, uint16_t type) {
+  const char* attrs =
+      reinterpret_cast<const char*>(NLMSG_DATA(hdr)) + GENL_HDRLEN;
+  return FindAttr(attrs, hdr->nlmsg_len - NLMSG_LENGTH(GENL_HDRLEN), type);
+}
+
+// ResolveFamily returns the ID of the generic netlink family called name.
+PosixErrorOr<uint16_t> ResolveFamily(const FileDescriptor& fd,
+                                     const std::string& name) {
+  GenlRequest req;
+  InitGenlRequest(&req, GENL_ID_CTRL, CTRL_CMD_GETFAMILY, 1);
+  AddAttr(&req, CTRL_ATTR_FAMILY_NAME, name.c_str(), name.size() + 1);
+
+  uint16_t id = 0;
+  RETURN_IF_ERRNO(NetlinkRequestResponseSingle(
+      fd, &req, req.hdr.nlmsg_len, [&](const struct nlmsghdr* hdr) {
+        if (hdr->nlmsg_type != GENL_ID_CTRL) {
+          return;
+        }
+        const struct nlattr* attr = FindGenlAttr(hdr, CTRL_ATTR_FAMILY_ID);
+        if (attr != nullptr) {
+          memcpy(&id, reinterpret_cast<const char*>(attr) + NLA_HDRLEN,
+                 sizeof(id));
+        }
+      }));
+  if (id == 0) {
+    return PosixError(ENOENT, absl::StrCat("family not found: ", name));
+  }
+  return id;
+}
+
+TEST(NetlinkGenericTest, ResolveController) {
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+
+  EXPECT_THAT(ResolveFamily(fd, "nlctrl"),
+              IsPosixErrorOkAndHolds(GENL_ID_CTRL));
+}
+
+TEST(NetlinkGenericTest, ResolveTaskstats) {
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+
+  uint16_t id =
+      ASSERT_NO_ERRNO_AND_VALUE(ResolveFamily(fd, TASKSTATS_GENL_NAME));
+  EXPECT_GT(id, GENL_ID_CTRL);
+}
+
+TEST(NetlinkGenericTest, UnknownFamilyName) {
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+
+  GenlRequest req;
+  InitGenlRequest(&req, GENL_ID_CTRL, CTRL_CMD_GETFAMILY, 1);
+  const char name[] = "NOSUCHFAMILY";
+  AddAttr(&req, CTRL_ATTR_FAMILY_NAME, name, sizeof(name));
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(ENOENT, ::testing::_));
+}
+
+TEST(NetlinkGenericTest, UnknownFamilyID) {
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+
+  GenlRequest req;
+  InitGenlRequest(&req, 0xfff0, 1, 1);
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(ENOENT, ::testing::_));
+}
+
+TEST(NetlinkGenericTest, TaskstatsPid) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+  uint16_t id =
+      ASSERT_NO_ERRNO_AND_VALUE(ResolveFamily(fd, TASKSTATS_GENL_NAME));
+
+  // Generate some I/O to be accounted.
+  int pipefds[2];
+  ASSERT_THAT(pipe(pipefds), SyscallSucceeds());
+  FileDescriptor rfd(pipefds[0]);
+  FileDescriptor wfd(pipefds[1]);
+  char buf[16] = {};
+  ASSERT_THAT(WriteFd(wfd.get(), buf, sizeof(buf)),
+              SyscallSucceedsWithValue(sizeof(buf)));
+  ASSERT_THAT(ReadFd(rfd.get(), buf, sizeof(buf)),
+              SyscallSucceedsWithValue(sizeof(buf)));
+
+  const uint32_t tid = syscall(SYS_gettid);
+  GenlRequest req;
+  InitGenlRequest(&req, id, TASKSTATS_CMD_GET, TASKSTATS_GENL_VERSION);
+  AddAttr(&req, TASKSTATS_CMD_ATTR_PID, &tid, sizeof(tid));
+
+  bool found = false;
+  ASSERT_NO_ERRNO(NetlinkRequestResponseSingle(
+      fd, &req, req.hdr.nlmsg_len, [&](const struct nlmsghdr* hdr) {
+        ASSERT_EQ(hdr->nlmsg_type, id);
+        const struct nlattr* aggr =
+            FindGenlAttr(hdr, TASKSTATS_TYPE_AGGR_PID);
+        ASSERT_NE(aggr, nullptr);
+        const char* nested = reinterpret_cast<const char*>(aggr) + NLA_HDRLEN;
+        const int nested_len = aggr->nla_len - NLA_HDRLEN;
+
+        const struct nlattr* pid =
+            FindAttr(nested, nested_len, TASKSTATS_TYPE_PID);
+        ASSERT_NE(pid, nullptr);
+        uint32_t got_pid;
+        memcpy(&got_pid, reinterpret_cast<const char*>(pid) + NLA_HDRLEN,
+               sizeof(got_pid));
+        EXPECT_EQ(got_pid, tid);
+
+        const struct nlattr* stats =
+            FindAttr(nested, nested_len, TASKSTATS_TYPE_STATS);
+        ASSERT_NE(stats, nullptr);
+        struct taskstats ts = {};
+        memcpy(&ts, reinterpret_cast<const char*>(stats) + NLA_HDRLEN,
+               std::min<size_t>(stats->nla_len - NLA_HDRLEN, sizeof(ts)));
+        EXPECT_GE(ts.version, 10);
+        EXPECT_EQ(ts.ac_pid, tid);
+        EXPECT_EQ(ts.ac_ppid, getppid());
+        EXPECT_EQ(ts.ac_uid, getuid());
+        EXPECT_GE(ts.read_char, sizeof(buf));
+        EXPECT_GE(ts.write_char, sizeof(buf));
+        EXPECT_GE(ts.read_syscalls, 1);
+        EXPECT_GE(ts.write_syscalls, 1);
+        found = true;
+      }));
+  EXPECT_TRUE(found);
+}
+
+TEST(NetlinkGenericTest, TaskstatsNoSuchProcess) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+  uint16_t id =
+      ASSERT_NO_ERRNO_AND_VALUE(ResolveFamily(fd, TASKSTATS_GENL_NAME));
+
+  // PIDs are limited to 2^22, so this can't exist.
+  const uint32_t pid = 1 << 30;
+  GenlRequest req;
+  InitGenlRequest(&req, id, TASKSTATS_CMD_GET, TASKSTATS_GENL_VERSION);
+  AddAttr(&req, TASKSTATS_CMD_ATTR_PID, &pid, sizeof(pid));
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(ESRCH, ::testing::_));
+}
+
+TEST(NetlinkGenericTest, TaskstatsRequiresNetAdmin) {
+  SKIP_IF(ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+  uint16_t id =
+      ASSERT_NO_ERRNO_AND_VALUE(ResolveFamily(fd, TASKSTATS_GENL_NAME));
+
+  const uint32_t tid = syscall(SYS_gettid);
+  GenlRequest req;
+  InitGenlRequest(&req, id, TASKSTATS_CMD_GET, TASKSTATS_GENL_VERSION);
+  AddAttr(&req, TASKSTATS_CMD_ATTR_PID, &tid, sizeof(tid));
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(EPERM, ::testing::_));
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
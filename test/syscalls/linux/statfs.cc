@@ -16,6 +16,7 @@
 #include <linux/magic.h>
 #include <sys/mount.h>
 #include <sys/statfs.h>
+#include <sys/statvfs.h>
 #include <unistd.h>
 
 #include <cstdint>
@@ -35,6 +36,9 @@ namespace testing {
 
 namespace {
 
+// ST_VALID is not exposed by glibc's <sys/statvfs.h>.
+constexpr int64_t kStValid = 0x0020;
+
 TEST(StatfsTest, CannotStatBadPath) {
   auto temp_file = NewTempAbsPath();
 
@@ -76,6 +80,68 @@ TEST(StatFsTest, MountFlags) {
   }
 }
 
+TEST(StatFsTest, FlagsValid) {
+  auto const dir = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateDir());
+  struct statfs st;
+  ASSERT_THAT(statfs(dir.path().c_str(), &st), SyscallSucceeds());
+  EXPECT_EQ(st.f_flags & kStValid, kStValid);
+}
+
+TEST(StatFsTest, BindMountFlagsAreMountSpecific) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN)));
+
+  auto const dir = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateDir());
+  auto const mount = ASSERT_NO_ERRNO_AND_VALUE(
+      Mount("", dir.path(), "tmpfs", 0, "mode=0777", 0));
+  auto const bind_dir = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateDir());
+  auto const bind_mount = ASSERT_NO_ERRNO_AND_VALUE(
+      Mount(dir.path(), bind_dir.path(), "", MS_BIND, "", MNT_DETACH));
+  ASSERT_THAT(mount(nullptr, bind_dir.path().c_str(), nullptr,
+                    MS_REMOUNT | MS_BIND | MS_RDONLY | MS_NOEXEC, nullptr),
+              SyscallSucceeds());
+
+  struct statfs st;
+  ASSERT_THAT(statfs(dir.path().c_str(), &st), SyscallSucceeds());
+  EXPECT_EQ(st.f_flags & (ST_RDONLY | ST_NOEXEC), 0);
+  struct statfs bind_st;
+  ASSERT_THAT(statfs(bind_dir.path().c_str(), &bind_st), SyscallSucceeds());
+  EXPECT_EQ(bind_st.f_flags & (ST_RDONLY | ST_NOEXEC),
+            ST_RDONLY | ST_NOEXEC);
+
+  // Both mounts refer to the same filesystem, so they share its ID.
+  EXPECT_EQ(st.f_fsid.__val[0], bind_st.f_fsid.__val[0]);
+  EXPECT_EQ(st.f_fsid.__val[1], bind_st.f_fsid.__val[1]);
+}
+
+TEST(StatFsTest, FsidUniquePerFilesystem) {
+  // Linux only reports a tmpfs filesystem ID since 6.7.
+  SKIP_IF(!IsRunningOnGvisor());
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN)));
+
+  auto const dir1 = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateDir());
+  auto const mount1 = ASSERT_NO_ERRNO_AND_VALUE(
+      Mount("", dir1.path(), "tmpfs", 0, "mode=0777", 0));
+  auto const dir2 = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateDir());
+  auto const mount2 = ASSERT_NO_ERRNO_AND_VALUE(
+      Mount("", dir2.path(), "tmpfs", 0, "mode=0777", 0));
+
+  struct statfs st1;
+  ASSERT_THAT(statfs(dir1.path().c_str(), &st1), SyscallSucceeds());
+  struct statfs st2;
+  ASSERT_THAT(statfs(dir2.path().c_str(), &st2), SyscallSucceeds());
+  EXPECT_TRUE(st1.f_fsid.__val[0] != 0 || st1.f_fsid.__val[1] != 0);
+  EXPECT_TRUE(st1.f_fsid.__val[0] != st2.f_fsid.__val[0] ||
+              st1.f_fsid.__val[1] != st2.f_fsid.__val[1]);
+
+  // The ID is stable and doesn't depend on how the filesystem is reached.
+  const FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open(dir1.path(), O_RDONLY | O_DIRECTORY));
+  struct statfs fst;
+  ASSERT_THAT(fstatfs(fd.get(), &fst), SyscallSucceeds());
+  EXPECT_EQ(st1.f_fsid.__val[0], fst.f_fsid.__val[0]);
+  EXPECT_EQ(st1.f_fsid.__val[1], fst.f_fsid.__val[1]);
+}
+
 TEST(FstatfsTest, CannotStatBadFd) {
   struct statfs st;
   EXPECT_THAT(fstatfs(-1, &st), SyscallFailsWithErrno(EBADF));
@@ -0,0 +1,240 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <errno.h>
+#include <fcntl.h>
+#include <linux/futex.h>
+#include <sched.h>
+#include <string.h>
+#include <sys/stat.h>
+#include <sys/syscall.h>
+#include <sys/wait.h>
+#include <time.h>
+#include <unistd.h>
+
+#include <atomic>
+#include <cstdlib>
+#include <functional>
+#include <string>
+#include <vector>
+
+#include "gmock/gmock.h"
+#include "gtest/gtest.h"
+#include "absl/strings/numbers.h"
+#include "absl/strings/str_cat.h"
+#include "absl/strings/str_split.h"
+#include "test/util/capability_util.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/fs_util.h"
+#include "test/util/logging.h"
+#include "test/util/multiprocess_util.h"
+#include "test/util/posix_error.h"
+#include "test/util/test_util.h"
+
+#ifndef CLONE_NEWTIME
+#define CLONE_NEWTIME 0x80
+#endif
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+constexpr int64_t kOffsetSec = 24 * 60 * 60;
+
+int64_t ClockSec(clockid_t clock) {
+  struct timespec ts;
+  TEST_CHECK_SUCCESS(clock_gettime(clock, &ts));
+  return ts.tv_sec;
+}
+
+int64_t RawClockSec(clockid_t clock) {
+  struct timespec ts;
+  TEST_CHECK_SUCCESS(syscall(SYS_clock_gettime, clock, &ts));
+  return ts.tv_sec;
+}
+
+void SkipIfNoTimeNamespaces() {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN)));
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_TIME)));
+  SKIP_IF(access("/proc/self/ns/time", F_OK) != 0);
+}
+
+TEST(TimeNamespaceTest, DefaultOffsetsAreZero) {
+  SKIP_IF(access("/proc/self/timens_offsets", F_OK) != 0);
+
+  std::string offsets =
+      ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/self/timens_offsets"));
+  EXPECT_EQ(offsets,
+            "monotonic           0         0\n"
+            "boottime            0         0\n");
+}
+
+TEST(TimeNamespaceTest, UnshareChangesChildNamespace) {
+  SkipIfNoTimeNamespaces();
+
+  const auto rest = [] {
+    struct stat self_before, children_before, children_after, self_after;
+    TEST_CHECK_SUCCESS(stat("/proc/self/ns/time", &self_before));
+    TEST_CHECK_SUCCESS(
+        stat("/proc/self/ns/time_for_children", &children_before));
+    TEST_CHECK(self_before.st_ino == children_before.st_ino);
+
+    TEST_CHECK_SUCCESS(unshare(CLONE_NEWTIME));
+    TEST_CHECK_SUCCESS(stat("/proc/self/ns/time", &self_after));
+    TEST_CHECK_SUCCESS(
+        stat("/proc/self/ns/time_for_children", &children_after));
+    TEST_CHECK(self_before.st_ino == self_after.st_ino);
+    TEST_CHECK(self_after.st_ino != children_after.st_ino);
+  };
+  EXPECT_THAT(InForkedProcess(rest), IsPosixErrorOkAndHolds(0));
+}
+
+TEST(TimeNamespaceTest, OffsetsApplyToChildren) {
+  SkipIfNoTimeNamespaces();
+
+  const auto rest = [] {
+    TEST_CHECK_SUCCESS(unshare(CLONE_NEWTIME));
+    const std::string offsets =
+        absl::StrCat("monotonic ", kOffsetSec, " 0\nboottime ",
+                     2 * kOffsetSec, " 0\n");
+    TEST_CHECK_NO_ERRNO(SetContents("/proc/self/timens_offsets", offsets));
+
+    const int64_t mono = ClockSec(CLOCK_MONOTONIC);
+    const int64_t boot = ClockSec(CLOCK_BOOTTIME);
+
+    pid_t child = fork();
+    if (child == 0) {
+      // Check both the VDSO and the syscall implementations.
+      for (auto get : {ClockSec, RawClockSec}) {
+        int64_t delta = get(CLOCK_MONOTONIC) - mono;
+        TEST_CHECK(delta >= kOffsetSec && delta < kOffsetSec + 60);
+        delta = get(CLOCK_BOOTTIME) - boot;
+        TEST_CHECK(delta >= 2 * kOffsetSec && delta < 2 * kOffsetSec + 60);
+      }
+      // CLOCK_REALTIME is not affected.
+      TEST_CHECK(std::abs(ClockSec(CLOCK_REALTIME) - time(nullptr)) < 60);
+      _exit(0);
+    }
+    TEST_CHECK_SUCCESS(child);
+    int status;
+    TEST_CHECK_SUCCESS(waitpid(child, &status, 0));
+    TEST_CHECK(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+
+    // Once a task has entered the namespace, its offsets are frozen.
+    TEST_CHECK(SetContents("/proc/self/timens_offsets", "monotonic 1 0\n")
+                   .errno_value() == EACCES);
+  };
+  EXPECT_THAT(InForkedProcess(rest), IsPosixErrorOkAndHolds(0));
+}
+
+// Runs fn in a child process in a new time namespace whose clocks are
+// offset by kOffsetSec (CLOCK_MONOTONIC) and 2 * kOffsetSec (CLOCK_BOOTTIME).
+void InOffsetTimeNamespace(const std::function<void()>& fn) {
+  const auto rest = [&] {
+    TEST_CHECK_SUCCESS(unshare(CLONE_NEWTIME));
+    const std::string offsets =
+        absl::StrCat("monotonic ", kOffsetSec, " 0\nboottime ",
+                     2 * kOffsetSec, " 0\n");
+    TEST_CHECK_NO_ERRNO(SetContents("/proc/self/timens_offsets", offsets));
+    pid_t child = fork();
+    if (child == 0) {
+      fn();
+      _exit(0);
+    }
+    TEST_CHECK_SUCCESS(child);
+    int status;
+    TEST_CHECK_SUCCESS(waitpid(child, &status, 0));
+    TEST_CHECK(WIFEXITED(status) && WEXITSTATUS(status) == 0);
+  };
+  EXPECT_THAT(InForkedProcess(rest), IsPosixErrorOkAndHolds(0));
+}
+
+TEST(TimeNamespaceTest, FutexDeadlineUsesNamespaceClock) {
+  SkipIfNoTimeNamespaces();
+
+  InOffsetTimeNamespace([] {
+    // FUTEX_WAIT_BITSET takes an absolute CLOCK_MONOTONIC deadline, which
+    // must be interpreted in the time namespace; otherwise it would only
+    // expire after kOffsetSec.
+    std::atomic<int> futex_word(0);
+    struct timespec deadline;
+    TEST_CHECK_SUCCESS(clock_gettime(CLOCK_MONOTONIC, &deadline));
+    const int64_t start = deadline.tv_sec;
+    deadline.tv_sec += 1;
+    TEST_CHECK(syscall(SYS_futex, &futex_word, FUTEX_WAIT_BITSET_PRIVATE, 0,
+                       &deadline, nullptr, FUTEX_BITSET_MATCH_ANY) == -1 &&
+               errno == ETIMEDOUT);
+    TEST_CHECK(ClockSec(CLOCK_MONOTONIC) - start < 60);
+  });
+}
+
+TEST(TimeNamespaceTest, ProcStatStartTimeUsesNamespaceClock) {
+  SkipIfNoTimeNamespaces();
+
+  InOffsetTimeNamespace([] {
+    // Field 22 of /proc/[pid]/stat is the start time on CLOCK_BOOTTIME, in
+    // clock ticks. Skip the command, which may contain spaces.
+    const std::string stat =
+        TEST_CHECK_NO_ERRNO_AND_VALUE(GetContents("/proc/self/stat"));
+    std::vector<std::string> fields =
+        absl::StrSplit(stat.substr(stat.rfind(')') + 2), ' ');
+    TEST_CHECK(fields.size() > 19);
+    int64_t start_ticks;
+    TEST_CHECK(absl::SimpleAtoi(fields[19], &start_ticks));
+    const int64_t start_sec = start_ticks / sysconf(_SC_CLK_TCK);
+    TEST_CHECK(start_sec >= 2 * kOffsetSec);
+    TEST_CHECK(start_sec <= ClockSec(CLOCK_BOOTTIME));
+  });
+}
+
+TEST(TimeNamespaceTest, InvalidOffsets) {
+  SkipIfNoTimeNamespaces();
+
+  const auto rest = [] {
+    TEST_CHECK_SUCCESS(unshare(CLONE_NEWTIME));
+    const FileDescriptor fd = TEST_CHECK_NO_ERRNO_AND_VALUE(
+        Open("/proc/self/timens_offsets", O_WRONLY));
+    for (const char* offsets : {"realtime 1 0\n", "monotonic 1 1000000000\n",
+                                "monotonic 1 -1\n", "monotonic\n"}) {
+      TEST_CHECK(write(fd.get(), offsets, strlen(offsets)) == -1 &&
+                 errno == EINVAL);
+    }
+    const char* offsets = "monotonic -100000000000 0\n";
+    TEST_CHECK(write(fd.get(), offsets, strlen(offsets)) == -1 &&
+               errno == ERANGE);
+  };
+  EXPECT_THAT(InForkedProcess(rest), IsPosixErrorOkAndHolds(0));
+}
+
+TEST(TimeNamespaceTest, ThreadCannotEnterOtherNamespace) {
+  SkipIfNoTimeNamespaces();
+
+  const auto rest = [] {
+    TEST_CHECK_SUCCESS(unshare(CLONE_NEWTIME));
+    // A thread shares its address space, and therefore its VDSO data, with
+    // its parent, so it can't be created in a different time namespace.
+    char stack[4096];
+    TEST_CHECK(clone(
+                   [](void*) { return 0; }, stack + sizeof(stack),
+                   CLONE_VM | CLONE_THREAD | CLONE_SIGHAND, nullptr) == -1 &&
+               errno == EINVAL);
+  };
+  EXPECT_THAT(InForkedProcess(rest), IsPosixErrorOkAndHolds(0));
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
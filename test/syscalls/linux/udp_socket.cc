@@ -1681,6 +1681,157 @@ TEST_P(UdpSocketTest, SoTimestamp) {
   }
 }
 
+TEST_P(UdpSocketTest, SoTimestampNS) {
+  ASSERT_NO_ERRNO(BindLoopback());
+  ASSERT_THAT(connect(sock_.get(), bind_addr_, addrlen_), SyscallSucceeds());
+
+  int v = 1;
+  ASSERT_THAT(
+      setsockopt(bind_.get(), SOL_SOCKET, SO_TIMESTAMPNS, &v, sizeof(v)),
+      SyscallSucceeds());
+
+  // SO_TIMESTAMP and SO_TIMESTAMPNS are mutually exclusive.
+  socklen_t optlen = sizeof(v);
+  ASSERT_THAT(getsockopt(bind_.get(), SOL_SOCKET, SO_TIMESTAMP, &v, &optlen),
+              SyscallSucceeds());
+  EXPECT_EQ(v, kSockOptOff);
+  ASSERT_THAT(getsockopt(bind_.get(), SOL_SOCKET, SO_TIMESTAMPNS, &v, &optlen),
+              SyscallSucceeds());
+  EXPECT_EQ(v, kSockOptOn);
+
+  char buf[3];
+  ASSERT_THAT(RetryEINTR(write)(sock_.get(), buf, sizeof(buf)),
+              SyscallSucceedsWithValue(sizeof(buf)));
+
+  struct pollfd pfd = {bind_.get(), POLLIN, 0};
+  ASSERT_THAT(RetryEINTR(poll)(&pfd, 1, /*timeout=*/1000),
+              SyscallSucceedsWithValue(1));
+
+  char cmsgbuf[CMSG_SPACE(sizeof(struct timespec))];
+  msghdr msg = {};
+  iovec iov = {};
+  msg.msg_iov = &iov;
+  msg.msg_iovlen = 1;
+  msg.msg_control = cmsgbuf;
+  msg.msg_controllen = sizeof(cmsgbuf);
+
+  ASSERT_THAT(RetryEINTR(recvmsg)(bind_.get(), &msg, 0),
+              SyscallSucceedsWithValue(0));
+  EXPECT_EQ(msg.msg_flags & MSG_CTRUNC, 0);
+
+  struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+  ASSERT_NE(cmsg, nullptr);
+  ASSERT_EQ(cmsg->cmsg_level, SOL_SOCKET);
+  ASSERT_EQ(cmsg->cmsg_type, SCM_TIMESTAMPNS);
+  ASSERT_EQ(cmsg->cmsg_len, CMSG_LEN(sizeof(struct timespec)));
+
+  struct timespec ts = {};
+  memcpy(&ts, CMSG_DATA(cmsg), sizeof(struct timespec));
+  ASSERT_TRUE(ts.tv_sec != 0 || ts.tv_nsec != 0);
+}
+
+TEST_P(UdpSocketTest, TimestampControlMessageTruncated) {
+  ASSERT_NO_ERRNO(BindLoopback());
+  ASSERT_THAT(connect(sock_.get(), bind_addr_, addrlen_), SyscallSucceeds());
+
+  int v = 1;
+  ASSERT_THAT(setsockopt(bind_.get(), SOL_SOCKET, SO_TIMESTAMP, &v, sizeof(v)),
+              SyscallSucceeds());
+
+  char buf[3];
+  for (int i = 0; i < 2; i++) {
+    ASSERT_THAT(RetryEINTR(write)(sock_.get(), buf, sizeof(buf)),
+                SyscallSucceedsWithValue(sizeof(buf)));
+  }
+
+  struct pollfd pfd = {bind_.get(), POLLIN, 0};
+  ASSERT_THAT(RetryEINTR(poll)(&pfd, 1, /*timeout=*/1000),
+              SyscallSucceedsWithValue(1));
+
+  // A control buffer that only fits part of the timestamp receives the
+  // truncated message, like Linux's put_cmsg().
+  char cmsgbuf[CMSG_LEN(sizeof(struct timeval)) - 4];
+  msghdr msg = {};
+  iovec iov = {.iov_base = buf, .iov_len = sizeof(buf)};
+  msg.msg_iov = &iov;
+  msg.msg_iovlen = 1;
+  msg.msg_control = cmsgbuf;
+  msg.msg_controllen = sizeof(cmsgbuf);
+
+  ASSERT_THAT(RetryEINTR(recvmsg)(bind_.get(), &msg, 0),
+              SyscallSucceedsWithValue(sizeof(buf)));
+  EXPECT_EQ(msg.msg_flags & MSG_CTRUNC, MSG_CTRUNC);
+  EXPECT_EQ(msg.msg_controllen, sizeof(cmsgbuf));
+  struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+  ASSERT_NE(cmsg, nullptr);
+  EXPECT_EQ(cmsg->cmsg_level, SOL_SOCKET);
+  EXPECT_EQ(cmsg->cmsg_type, SO_TIMESTAMP);
+  EXPECT_EQ(cmsg->cmsg_len, sizeof(cmsgbuf));
+
+  // Without a control buffer, the timestamp is dropped.
+  msg.msg_control = nullptr;
+  msg.msg_controllen = 0;
+  ASSERT_THAT(RetryEINTR(recvmsg)(bind_.get(), &msg, 0),
+              SyscallSucceedsWithValue(sizeof(buf)));
+  EXPECT_EQ(msg.msg_flags & MSG_CTRUNC, MSG_CTRUNC);
+}
+
+TEST_P(UdpSocketTest, SoRxqOvfl) {
+  ASSERT_NO_ERRNO(BindLoopback());
+  ASSERT_THAT(connect(sock_.get(), bind_addr_, addrlen_), SyscallSucceeds());
+
+  int v = 1;
+  ASSERT_THAT(setsockopt(bind_.get(), SOL_SOCKET, SO_RXQ_OVFL, &v, sizeof(v)),
+              SyscallSucceeds());
+  socklen_t optlen = sizeof(v);
+  v = 0;
+  ASSERT_THAT(getsockopt(bind_.get(), SOL_SOCKET, SO_RXQ_OVFL, &v, &optlen),
+              SyscallSucceeds());
+  EXPECT_EQ(v, kSockOptOn);
+
+  // Overflow the receive buffer.
+  int rcvbuf = 0;
+  optlen = sizeof(rcvbuf);
+  ASSERT_THAT(
+      getsockopt(bind_.get(), SOL_SOCKET, SO_RCVBUF, &rcvbuf, &optlen),
+      SyscallSucceeds());
+  std::vector<char> data(1024);
+  for (int i = 0; i < 2 * rcvbuf / static_cast<int>(data.size()) + 1; i++) {
+    ASSERT_THAT(RetryEINTR(write)(sock_.get(), data.data(), data.size()),
+                SyscallSucceedsWithValue(data.size()));
+  }
+
+  // Drain the queue, then send a packet that is queued after the drops.
+  while (RetryEINTR(recv)(bind_.get(), data.data(), data.size(),
+                          MSG_DONTWAIT) > 0) {
+  }
+  ASSERT_THAT(RetryEINTR(write)(sock_.get(), data.data(), data.size()),
+              SyscallSucceedsWithValue(data.size()));
+
+  struct pollfd pfd = {bind_.get(), POLLIN, 0};
+  ASSERT_THAT(RetryEINTR(poll)(&pfd, 1, /*timeout=*/1000),
+              SyscallSucceedsWithValue(1));
+
+  char cmsgbuf[CMSG_SPACE(sizeof(uint32_t))];
+  msghdr msg = {};
+  iovec iov = {.iov_base = data.data(), .iov_len = data.size()};
+  msg.msg_iov = &iov;
+  msg.msg_iovlen = 1;
+  msg.msg_control = cmsgbuf;
+  msg.msg_controllen = sizeof(cmsgbuf);
+  ASSERT_THAT(RetryEINTR(recvmsg)(bind_.get(), &msg, 0),
+              SyscallSucceedsWithValue(data.size()));
+
+  struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+  ASSERT_NE(cmsg, nullptr);
+  EXPECT_EQ(cmsg->cmsg_level, SOL_SOCKET);
+  EXPECT_EQ(cmsg->cmsg_type, SO_RXQ_OVFL);
+  ASSERT_EQ(cmsg->cmsg_len, CMSG_LEN(sizeof(uint32_t)));
+  uint32_t drops = 0;
+  memcpy(&drops, CMSG_DATA(cmsg), sizeof(drops));
+  EXPECT_GT(drops, 0);
+}
+
 TEST_P(UdpSocketTest, WriteShutdownNotConnected) {
   EXPECT_THAT(shutdown(bind_.get(), SHUT_WR), SyscallFailsWithErrno(ENOTCONN));
 }
@@ -1937,6 +2088,14 @@ TEST_P(UdpSocketTest, SetSocketDetachFilter) {
 
 #endif  // __linux__
 
+TEST_P(UdpSocketTest, SetIPHdrInclFails) {
+  // IP_HDRINCL is only supported by raw sockets.
+  constexpr int kSockOptOn = 1;
+  ASSERT_THAT(setsockopt(sock_.get(), SOL_IP, IP_HDRINCL, &kSockOptOn,
+                         sizeof(kSockOptOn)),
+              SyscallFailsWithErrno(ENOPROTOOPT));
+}
+
 TEST_P(UdpSocketTest, SetSocketDetachFilterNoInstalledFilter) {
   // TODO(gvisor.dev/2746): Support SO_ATTACH_FILTER/SO_DETACH_FILTER.
   SKIP_IF(IsRunningOnGvisor());
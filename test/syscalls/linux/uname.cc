@@ -16,9 +16,14 @@
 #include <sys/utsname.h>
 #include <unistd.h>
 
+#include <string>
+
 #include "gtest/gtest.h"
+#include "absl/strings/match.h"
+#include "absl/strings/str_cat.h"
 #include "absl/strings/string_view.h"
 #include "test/util/capability_util.h"
+#include "test/util/fs_util.h"
 #include "test/util/test_util.h"
 #include "test/util/thread_util.h"
 
@@ -38,6 +43,30 @@ TEST(UnameTest, Sanity) {
   EXPECT_NE(strlen(buf.domainname), 0);
 }
 
+// The kernel version is reported consistently by uname and /proc.
+TEST(UnameTest, MatchesProc) {
+  struct utsname buf;
+  ASSERT_THAT(uname(&buf), SyscallSucceeds());
+
+  EXPECT_EQ(ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/sys/kernel/ostype")),
+            absl::StrCat(buf.sysname, "\n"));
+  EXPECT_EQ(
+      ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/sys/kernel/osrelease")),
+      absl::StrCat(buf.release, "\n"));
+  EXPECT_EQ(ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/sys/kernel/version")),
+            absl::StrCat(buf.version, "\n"));
+
+  // /proc/version contains build information between the release and version
+  // on Linux.
+  std::string const version =
+      ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/version"));
+  EXPECT_TRUE(absl::StartsWith(
+      version, absl::StrCat(buf.sysname, " version ", buf.release, " ")))
+      << version;
+  EXPECT_TRUE(absl::EndsWith(version, absl::StrCat(buf.version, "\n")))
+      << version;
+}
+
 TEST(UnameTest, SetNames) {
   SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN)));
 
@@ -0,0 +1,326 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <errno.h>
+#include <fcntl.h>
+#include <linux/userfaultfd.h>
+#include <poll.h>
+#include <string.h>
+#include <sys/ioctl.h>
+#include <sys/mman.h>
+#include <sys/syscall.h>
+#include <unistd.h>
+
+#include <atomic>
+#include <cstdint>
+#include <vector>
+
+#include "gtest/gtest.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/memory_util.h"
+#include "test/util/test_util.h"
+#include "test/util/thread_util.h"
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+#ifndef UFFD_USER_MODE_ONLY
+#define UFFD_USER_MODE_ONLY 1
+#endif
+
+// NewUserfaultfd returns a new userfaultfd that only handles faults taken by
+// the application.
+PosixErrorOr<FileDescriptor> NewUserfaultfd(int flags) {
+  int fd = syscall(__NR_userfaultfd, flags | UFFD_USER_MODE_ONLY);
+  MaybeSave();
+  if (fd < 0) {
+    return PosixError(errno, "userfaultfd");
+  }
+  return FileDescriptor(fd);
+}
+
+// Returns an initialized userfaultfd with the given features, or an empty
+// FileDescriptor if userfaultfd or any of the features is unavailable.
+FileDescriptor InitUserfaultfd(uint64_t features) {
+  auto fd_or = NewUserfaultfd(O_CLOEXEC);
+  if (!fd_or.ok()) {
+    // ENOSYS if userfaultfd is unimplemented, EPERM or EINVAL if the host
+    // restricts it.
+    return FileDescriptor();
+  }
+  FileDescriptor fd = std::move(fd_or).ValueOrDie();
+  struct uffdio_api api = {};
+  api.api = UFFD_API;
+  api.features = features;
+  if (ioctl(fd.get(), UFFDIO_API, &api) < 0) {
+    return FileDescriptor();
+  }
+  return fd;
+}
+
+// ReadFault blocks until a fault message is available on fd and returns it.
+struct uffd_msg ReadFault(int fd) {
+  struct uffd_msg msg = {};
+  EXPECT_THAT(read(fd, &msg, sizeof(msg)),
+              SyscallSucceedsWithValue(sizeof(msg)));
+  EXPECT_EQ(msg.event, UFFD_EVENT_PAGEFAULT);
+  return msg;
+}
+
+void RegisterRange(int fd, void* addr, size_t len, uint64_t mode) {
+  struct uffdio_register reg = {};
+  reg.range.start = reinterpret_cast<uint64_t>(addr);
+  reg.range.len = len;
+  reg.mode = mode;
+  ASSERT_THAT(ioctl(fd, UFFDIO_REGISTER, &reg), SyscallSucceeds());
+}
+
+TEST(UserfaultfdTest, InvalidFlags) {
+  SKIP_IF(!InitUserfaultfd(0).is_valid());
+  EXPECT_THAT(syscall(__NR_userfaultfd, UFFD_USER_MODE_ONLY | 0x2),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(UserfaultfdTest, API) {
+  auto fd_or = NewUserfaultfd(O_CLOEXEC);
+  SKIP_IF(!fd_or.ok());
+  FileDescriptor fd = std::move(fd_or).ValueOrDie();
+
+  // Reads fail until the API has been negotiated.
+  struct pollfd pfd = {fd.get(), POLLIN, 0};
+  EXPECT_THAT(poll(&pfd, 1, 0), SyscallSucceedsWithValue(1));
+  EXPECT_EQ(pfd.revents & POLLERR, POLLERR);
+
+  struct uffdio_api api = {};
+  api.api = 0;
+  EXPECT_THAT(ioctl(fd.get(), UFFDIO_API, &api),
+              SyscallFailsWithErrno(EINVAL));
+
+  api.api = UFFD_API;
+  api.features = 0;
+  ASSERT_THAT(ioctl(fd.get(), UFFDIO_API, &api), SyscallSucceeds());
+  EXPECT_NE(api.ioctls & (1ULL << _UFFDIO_REGISTER), 0);
+  EXPECT_NE(api.ioctls & (1ULL << _UFFDIO_UNREGISTER), 0);
+
+  // The API can only be negotiated once.
+  api.api = UFFD_API;
+  api.features = 0;
+  EXPECT_THAT(ioctl(fd.get(), UFFDIO_API, &api),
+              SyscallFailsWithErrno(EINVAL));
+
+  // With no faults pending, reads block.
+  pfd = {fd.get(), POLLIN, 0};
+  EXPECT_THAT(poll(&pfd, 1, 0), SyscallSucceedsWithValue(0));
+}
+
+TEST(UserfaultfdTest, RegisterRequiresAlignment) {
+  FileDescriptor fd = InitUserfaultfd(0);
+  SKIP_IF(!fd.is_valid());
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+
+  struct uffdio_register reg = {};
+  reg.range.start = m.addr() + 1;
+  reg.range.len = kPageSize;
+  reg.mode = UFFDIO_REGISTER_MODE_MISSING;
+  EXPECT_THAT(ioctl(fd.get(), UFFDIO_REGISTER, &reg),
+              SyscallFailsWithErrno(EINVAL));
+
+  reg.range.start = m.addr();
+  reg.mode = 0;
+  EXPECT_THAT(ioctl(fd.get(), UFFDIO_REGISTER, &reg),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(UserfaultfdTest, MissingFaultResolvedByCopy) {
+  FileDescriptor fd = InitUserfaultfd(0);
+  SKIP_IF(!fd.is_valid());
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  ASSERT_NO_FATAL_FAILURE(
+      RegisterRange(fd.get(), m.ptr(), kPageSize,
+                    UFFDIO_REGISTER_MODE_MISSING));
+
+  Mapping src = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  memset(src.ptr(), 'a', kPageSize);
+
+  ScopedThread handler([&] {
+    struct uffd_msg msg = ReadFault(fd.get());
+    EXPECT_EQ(msg.arg.pagefault.address & ~(kPageSize - 1), m.addr());
+
+    struct uffdio_copy copy = {};
+    copy.dst = m.addr();
+    copy.src = src.addr();
+    copy.len = kPageSize;
+    EXPECT_THAT(ioctl(fd.get(), UFFDIO_COPY, &copy), SyscallSucceeds());
+    EXPECT_EQ(copy.copy, static_cast<int64_t>(kPageSize));
+  });
+
+  // This blocks until the handler populates the page.
+  EXPECT_EQ(*reinterpret_cast<volatile char*>(m.ptr()), 'a');
+  handler.Join();
+
+  // The page is now populated, so copying to it again fails.
+  struct uffdio_copy copy = {};
+  copy.dst = m.addr();
+  copy.src = src.addr();
+  copy.len = kPageSize;
+  EXPECT_THAT(ioctl(fd.get(), UFFDIO_COPY, &copy),
+              SyscallFailsWithErrno(EEXIST));
+  EXPECT_EQ(copy.copy, -EEXIST);
+}
+
+TEST(UserfaultfdTest, MissingFaultResolvedByZeroPage) {
+  FileDescriptor fd = InitUserfaultfd(0);
+  SKIP_IF(!fd.is_valid());
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  ASSERT_NO_FATAL_FAILURE(
+      RegisterRange(fd.get(), m.ptr(), kPageSize,
+                    UFFDIO_REGISTER_MODE_MISSING));
+
+  ScopedThread handler([&] {
+    struct uffd_msg msg = ReadFault(fd.get());
+    EXPECT_NE(msg.arg.pagefault.flags & UFFD_PAGEFAULT_FLAG_WRITE, 0);
+
+    struct uffdio_zeropage zero = {};
+    zero.range.start = m.addr();
+    zero.range.len = kPageSize;
+    EXPECT_THAT(ioctl(fd.get(), UFFDIO_ZEROPAGE, &zero), SyscallSucceeds());
+    EXPECT_EQ(zero.zeropage, static_cast<int64_t>(kPageSize));
+  });
+
+  *reinterpret_cast<volatile char*>(m.ptr()) = 'b';
+  handler.Join();
+  EXPECT_EQ(*reinterpret_cast<volatile char*>(m.ptr()), 'b');
+}
+
+TEST(UserfaultfdTest, UnregisterResolvesFaults) {
+  FileDescriptor fd = InitUserfaultfd(0);
+  SKIP_IF(!fd.is_valid());
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  ASSERT_NO_FATAL_FAILURE(
+      RegisterRange(fd.get(), m.ptr(), kPageSize,
+                    UFFDIO_REGISTER_MODE_MISSING));
+
+  struct uffdio_range range = {};
+  range.start = m.addr();
+  range.len = kPageSize;
+  ASSERT_THAT(ioctl(fd.get(), UFFDIO_UNREGISTER, &range), SyscallSucceeds());
+
+  // The range is no longer registered, so this does not block.
+  EXPECT_EQ(*reinterpret_cast<volatile char*>(m.ptr()), 0);
+}
+
+TEST(UserfaultfdTest, WriteProtect) {
+  FileDescriptor fd = InitUserfaultfd(UFFD_FEATURE_PAGEFAULT_FLAG_WP);
+  SKIP_IF(!fd.is_valid());
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  *reinterpret_cast<volatile char*>(m.ptr()) = 'a';
+  ASSERT_NO_FATAL_FAILURE(RegisterRange(
+      fd.get(), m.ptr(), kPageSize,
+      UFFDIO_REGISTER_MODE_MISSING | UFFDIO_REGISTER_MODE_WP));
+
+  struct uffdio_writeprotect wp = {};
+  wp.range.start = m.addr();
+  wp.range.len = kPageSize;
+  wp.mode = UFFDIO_WRITEPROTECT_MODE_WP;
+  ASSERT_THAT(ioctl(fd.get(), UFFDIO_WRITEPROTECT, &wp), SyscallSucceeds());
+
+  // Reads are unaffected by write protection.
+  EXPECT_EQ(*reinterpret_cast<volatile char*>(m.ptr()), 'a');
+
+  std::atomic<bool> resolved(false);
+  ScopedThread handler([&] {
+    struct uffd_msg msg = ReadFault(fd.get());
+    EXPECT_NE(msg.arg.pagefault.flags & UFFD_PAGEFAULT_FLAG_WP, 0);
+    EXPECT_NE(msg.arg.pagefault.flags & UFFD_PAGEFAULT_FLAG_WRITE, 0);
+
+    resolved.store(true);
+    struct uffdio_writeprotect unprotect = {};
+    unprotect.range.start = m.addr();
+    unprotect.range.len = kPageSize;
+    unprotect.mode = 0;
+    EXPECT_THAT(ioctl(fd.get(), UFFDIO_WRITEPROTECT, &unprotect),
+                SyscallSucceeds());
+  });
+
+  // This blocks until the handler removes write protection.
+  *reinterpret_cast<volatile char*>(m.ptr()) = 'b';
+  EXPECT_TRUE(resolved.load());
+  handler.Join();
+  EXPECT_EQ(*reinterpret_cast<volatile char*>(m.ptr()), 'b');
+}
+
+TEST(UserfaultfdTest, MinorFaultResolvedByContinue) {
+  FileDescriptor fd = InitUserfaultfd(UFFD_FEATURE_MINOR_SHMEM);
+  SKIP_IF(!fd.is_valid());
+
+  int raw_memfd;
+  ASSERT_THAT(raw_memfd = syscall(__NR_memfd_create, "uffd", 0),
+              SyscallSucceeds());
+  FileDescriptor memfd(raw_memfd);
+  std::vector<char> buf(kPageSize, 'c');
+  ASSERT_THAT(pwrite(memfd.get(), buf.data(), buf.size(), 0),
+              SyscallSucceedsWithValue(buf.size()));
+
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(Mmap(
+      nullptr, kPageSize, PROT_READ | PROT_WRITE, MAP_SHARED, memfd.get(), 0));
+  ASSERT_NO_FATAL_FAILURE(
+      RegisterRange(fd.get(), m.ptr(), kPageSize, UFFDIO_REGISTER_MODE_MINOR));
+
+  ScopedThread handler([&] {
+    struct uffd_msg msg = ReadFault(fd.get());
+    EXPECT_NE(msg.arg.pagefault.flags & UFFD_PAGEFAULT_FLAG_MINOR, 0);
+
+    struct uffdio_continue cont = {};
+    cont.range.start = m.addr();
+    cont.range.len = kPageSize;
+    EXPECT_THAT(ioctl(fd.get(), UFFDIO_CONTINUE, &cont), SyscallSucceeds());
+    EXPECT_EQ(cont.mapped, static_cast<int64_t>(kPageSize));
+  });
+
+  // This blocks until the handler maps the existing page.
+  EXPECT_EQ(*reinterpret_cast<volatile char*>(m.ptr()), 'c');
+  handler.Join();
+}
+
+TEST(UserfaultfdTest, ReadRequiresMessageSizedBuffer) {
+  FileDescriptor fd = InitUserfaultfd(0);
+  SKIP_IF(!fd.is_valid());
+  char buf[sizeof(struct uffd_msg) - 1];
+  EXPECT_THAT(read(fd.get(), buf, sizeof(buf)), SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(UserfaultfdTest, NonblockingReadWithNoFaults) {
+  auto fd_or = NewUserfaultfd(O_NONBLOCK);
+  SKIP_IF(!fd_or.ok());
+  FileDescriptor fd = std::move(fd_or).ValueOrDie();
+  struct uffdio_api api = {};
+  api.api = UFFD_API;
+  ASSERT_THAT(ioctl(fd.get(), UFFDIO_API, &api), SyscallSucceeds());
+
+  struct uffd_msg msg;
+  EXPECT_THAT(read(fd.get(), &msg, sizeof(msg)), SyscallFailsWithErrno(EAGAIN));
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
@@ -32,16 +32,27 @@ namespace testing {
 
 #define __NR_io_uring_setup 425
 #define __NR_io_uring_enter 426
+#define __NR_io_uring_register 427
 
 // io_uring_setup(2) flags.
+#define IORING_SETUP_IOPOLL (1U << 0)
 #define IORING_SETUP_SQPOLL (1U << 1)
 #define IORING_SETUP_CQSIZE (1U << 3)
 
 // io_uring_enter(2) flags
 #define IORING_ENTER_GETEVENTS (1U << 0)
 
+// io_uring_register(2) opcodes.
+#define IORING_REGISTER_BUFFERS 0
+#define IORING_UNREGISTER_BUFFERS 1
+#define IORING_REGISTER_PROBE 8
+
 #define IORING_FEAT_SINGLE_MMAP (1U << 0)
 
+#define IORING_TIMEOUT_ABS (1U << 0)
+
+#define IO_URING_OP_SUPPORTED (1U << 0)
+
 #define IORING_OFF_SQ_RING 0ULL
 #define IORING_OFF_CQ_RING 0x8000000ULL
 #define IORING_OFF_SQES 0x10000000ULL
@@ -49,6 +60,15 @@ namespace testing {
 // IO_URING operation codes.
 #define IORING_OP_NOP 0
 #define IORING_OP_READV 1
+#define IORING_OP_WRITEV 2
+#define IORING_OP_FSYNC 3
+#define IORING_OP_READ_FIXED 4
+#define IORING_OP_WRITE_FIXED 5
+#define IORING_OP_TIMEOUT 11
+#define IORING_OP_ACCEPT 13
+#define IORING_OP_CONNECT 16
+#define IORING_OP_SEND 26
+#define IORING_OP_RECV 27
 
 #define BLOCK_SZ kPageSize
 
@@ -151,6 +171,21 @@ struct io_uring_sqe {
   };
 };
 
+struct io_uring_probe_op {
+  uint8_t op;
+  uint8_t resv;
+  uint16_t flags;
+  uint32_t resv2;
+};
+
+struct io_uring_probe {
+  uint8_t last_op;
+  uint8_t ops_len;
+  uint16_t resv;
+  uint32_t resv2[3];
+  struct io_uring_probe_op ops[0];
+};
+
 using IOSqringOffsets = struct io_sqring_offsets;
 using ICqringOffsets = struct io_cqring_offsets;
 using IOUringCqe = struct io_uring_cqe;
@@ -216,6 +251,12 @@ inline int IOUringEnter(unsigned int fd, unsigned int to_submit,
   return syscall(__NR_io_uring_enter, fd, to_submit, min_complete, flags, sig);
 }
 
+// This is a wrapper for the io_uring_register(2) system call.
+inline int IOUringRegister(unsigned int fd, unsigned int opcode, void *arg,
+                           unsigned int nr_args) {
+  return syscall(__NR_io_uring_register, fd, opcode, arg, nr_args);
+}
+
 // Returns a new iouringfd with the given number of entries.
 inline PosixErrorOr<FileDescriptor> NewIOUringFD(uint32_t entries,
                                                  IOUringParams &params) {
@@ -36,7 +36,9 @@ int __common_clock_gettime(clockid_t clock, struct timespec* ts) {
       break;
 
     case CLOCK_BOOTTIME:
-      // Fallthrough, CLOCK_BOOTTIME is an alias for CLOCK_MONOTONIC
+      ret = ClockBoottime(ts);
+      break;
+
     case CLOCK_MONOTONIC_RAW:
       // Fallthrough, CLOCK_MONOTONIC_RAW is an alias for CLOCK_MONOTONIC
     case CLOCK_MONOTONIC_COARSE:
@@ -41,6 +41,10 @@ struct params {
   int64_t realtime_base_cycles;
   int64_t realtime_base_ref;
   uint64_t realtime_frequency;
+
+  // The difference between CLOCK_BOOTTIME and CLOCK_MONOTONIC in the time
+  // namespace using this page.
+  int64_t boottime_offset;
 };
 
 // Returns a pointer to the global parameter page.
@@ -123,15 +127,17 @@ int ClockRealtime(struct timespec* ts) {
   return 0;
 }
 
-// ClockMonotonic() is the VDSO implementation of
-// clock_gettime(CLOCK_MONOTONIC).
-int ClockMonotonic(struct timespec* ts) {
+// clock_monotonic() implements ClockMonotonic() and ClockBoottime(), which
+// differ only by the time namespace's boottime_offset.
+inline int clock_monotonic(clockid_t clock, bool boottime,
+                           struct timespec* ts) {
   struct params* params = get_params();
   uint64_t seq;
   uint64_t ready;
   int64_t base_ref;
   int64_t base_cycles;
   uint64_t frequency;
+  int64_t offset;
   int64_t now_cycles;
 
   do {
@@ -140,20 +146,33 @@ int ClockMonotonic(struct timespec* ts) {
     base_ref = params->monotonic_base_ref;
     base_cycles = params->monotonic_base_cycles;
     frequency = params->monotonic_frequency;
+    offset = boottime ? params->boottime_offset : 0;
     now_cycles = cycle_clock();
   } while (read_seqcount_retry(&params->seq_count, seq));
 
   if (!ready) {
     // The sandbox kernel ensures that we won't compute a time later than this
     // once the params are ready.
-    return sys_clock_gettime(CLOCK_MONOTONIC, ts);
+    return sys_clock_gettime(clock, ts);
   }
 
   int64_t delta_cycles =
       (now_cycles < base_cycles) ? 0 : now_cycles - base_cycles;
-  int64_t now_ns = base_ref + cycles_to_ns(frequency, delta_cycles);
+  int64_t now_ns = base_ref + offset + cycles_to_ns(frequency, delta_cycles);
   *ts = ns_to_timespec(now_ns);
   return 0;
 }
 
+// ClockMonotonic() is the VDSO implementation of
+// clock_gettime(CLOCK_MONOTONIC).
+int ClockMonotonic(struct timespec* ts) {
+  return clock_monotonic(CLOCK_MONOTONIC, false, ts);
+}
+
+// ClockBoottime() is the VDSO implementation of
+// clock_gettime(CLOCK_BOOTTIME).
+int ClockBoottime(struct timespec* ts) {
+  return clock_monotonic(CLOCK_BOOTTIME, true, ts);
+}
+
 }  // namespace vdso